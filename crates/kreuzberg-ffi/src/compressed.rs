@@ -0,0 +1,429 @@
+//! Compressed extraction results for FFI.
+//!
+//! A `CExtractionResult` holds the content and every JSON field as separate C strings,
+//! which the binding then copies into its own memory: a 100MB text output briefly exists
+//! three times over. These functions instead serialize the whole result as JSON, streamed
+//! through a zstd encoder when the content is large, into a single buffer that bindings
+//! decode incrementally.
+//!
+//! The packed batch functions do the same for a batch: every result goes into one JSON
+//! array, so a batch of tiny documents costs one buffer rather than a dozen C strings per
+//! document.
+
+use std::ffi::CStr;
+use std::os::raw::c_char;
+use std::path::Path;
+use std::ptr;
+
+use kreuzberg::core::config::ExtractionConfig;
+use kreuzberg::types::ExtractionResult;
+use serde::Serialize;
+
+use crate::ffi_panic_guard;
+use crate::helpers::{clear_last_error, parse_extraction_config_from_json, set_last_error};
+use crate::types::CBytesWithMime;
+
+/// zstd level used for results; level 3 is zstd's default speed/ratio trade-off.
+const COMPRESSION_LEVEL: i32 = 3;
+
+/// A result as encoded for the bindings: the fields of the core `ExtractionResult` and a
+/// `success` flag like the one `CExtractionResult` carries, so that both forms decode alike.
+/// It is false for the documents of a batch that failed to extract, whose results hold the
+/// error in `metadata.error`.
+#[derive(Serialize)]
+struct EncodedResult<'a> {
+    #[serde(flatten)]
+    result: &'a ExtractionResult,
+    success: bool,
+}
+
+impl<'a> From<&'a ExtractionResult> for EncodedResult<'a> {
+    fn from(result: &'a ExtractionResult) -> Self {
+        Self {
+            result,
+            success: result.metadata.error.is_none(),
+        }
+    }
+}
+
+/// Serialize `result` as JSON, compressed with zstd when its content holds at least
+/// `compress_above` bytes. Compressed output starts with the zstd frame magic number and
+/// plain output with `{`, so readers tell them apart without a flag.
+fn encode_result(result: &ExtractionResult, compress_above: usize) -> Result<Vec<u8>, String> {
+    encode_json(&EncodedResult::from(result), result.content.len(), compress_above)
+}
+
+/// Serialize `results` as a JSON array, compressed with zstd when their contents hold at
+/// least `compress_above` bytes together.
+fn encode_results(results: &[ExtractionResult], compress_above: usize) -> Result<Vec<u8>, String> {
+    let content_len = results.iter().map(|result| result.content.len()).sum();
+    let encoded: Vec<EncodedResult> = results.iter().map(EncodedResult::from).collect();
+    encode_json(&encoded, content_len, compress_above)
+}
+
+fn encode_json<T: Serialize + ?Sized>(value: &T, content_len: usize, compress_above: usize) -> Result<Vec<u8>, String> {
+    if content_len < compress_above {
+        return serde_json::to_vec(value).map_err(|e| format!("Failed to serialize result: {}", e));
+    }
+    let mut encoder = zstd::stream::write::Encoder::new(Vec::new(), COMPRESSION_LEVEL)
+        .map_err(|e| format!("Failed to initialize zstd encoder: {}", e))?;
+    serde_json::to_writer(&mut encoder, value).map_err(|e| format!("Failed to serialize result: {}", e))?;
+    encoder
+        .finish()
+        .map_err(|e| format!("Failed to compress result: {}", e))
+}
+
+/// Hand `data` to the caller, storing its length in `out_len`.
+fn into_raw_buffer(data: Vec<u8>, out_len: *mut usize) -> *mut u8 {
+    let boxed = data.into_boxed_slice();
+    unsafe { *out_len = boxed.len() };
+    Box::into_raw(boxed) as *mut u8
+}
+
+fn parse_config(config_json: *const c_char) -> Result<ExtractionConfig, String> {
+    if config_json.is_null() {
+        return Ok(ExtractionConfig::default());
+    }
+    let config_str = unsafe { CStr::from_ptr(config_json) }
+        .to_str()
+        .map_err(|e| format!("Invalid UTF-8 in config JSON: {}", e))?;
+    parse_extraction_config_from_json(config_str)
+}
+
+/// Extract a byte array and return the result as JSON, zstd-compressed when its content
+/// holds at least `compress_above` bytes.
+///
+/// The JSON object has the fields of the core `ExtractionResult` and the `success` flag of
+/// `CExtractionResult`. Compressed buffers start
+/// with the zstd magic number `28 B5 2F FD`.
+///
+/// # Safety
+///
+/// - `data` must be a valid pointer to a byte array of length `data_len`
+/// - `mime_type` must be a valid null-terminated C string
+/// - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+/// - `out_len` must be a valid pointer; it receives the length of the returned buffer
+/// - The returned buffer must be freed with `kreuzberg_free_bytes`
+/// - Returns NULL on error (check `kreuzberg_last_error` for details)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_extract_bytes_compressed(
+    data: *const u8,
+    data_len: usize,
+    mime_type: *const c_char,
+    config_json: *const c_char,
+    compress_above: usize,
+    out_len: *mut usize,
+) -> *mut u8 {
+    ffi_panic_guard!("kreuzberg_extract_bytes_compressed", {
+        clear_last_error();
+
+        if data.is_null() || mime_type.is_null() || out_len.is_null() {
+            set_last_error("data, mime_type and out_len cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let bytes = unsafe { std::slice::from_raw_parts(data, data_len) };
+        let mime_str = match unsafe { CStr::from_ptr(mime_type) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in MIME type: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        let encoded = parse_config(config_json).and_then(|config| {
+            let result = kreuzberg::extract_bytes_sync(bytes, mime_str, &config).map_err(|e| e.to_string())?;
+            encode_result(&result, compress_above)
+        });
+        match encoded {
+            Ok(buffer) => into_raw_buffer(buffer, out_len),
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Extract a file and return the result as JSON, zstd-compressed when its content holds
+/// at least `compress_above` bytes. See `kreuzberg_extract_bytes_compressed`.
+///
+/// # Safety
+///
+/// - `file_path` must be a valid null-terminated C string
+/// - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+/// - `out_len` must be a valid pointer; it receives the length of the returned buffer
+/// - The returned buffer must be freed with `kreuzberg_free_bytes`
+/// - Returns NULL on error (check `kreuzberg_last_error` for details)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_extract_file_compressed(
+    file_path: *const c_char,
+    config_json: *const c_char,
+    compress_above: usize,
+    out_len: *mut usize,
+) -> *mut u8 {
+    ffi_panic_guard!("kreuzberg_extract_file_compressed", {
+        clear_last_error();
+
+        if file_path.is_null() || out_len.is_null() {
+            set_last_error("file_path and out_len cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let path_str = match unsafe { CStr::from_ptr(file_path) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in file path: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        let encoded = parse_config(config_json).and_then(|config| {
+            let result = kreuzberg::extract_file_sync(Path::new(path_str), None, &config).map_err(|e| e.to_string())?;
+            encode_result(&result, compress_above)
+        });
+        match encoded {
+            Ok(buffer) => into_raw_buffer(buffer, out_len),
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Batch extract files and return their results packed into one JSON array, in the order
+/// of `file_paths`, zstd-compressed when their contents hold at least `compress_above`
+/// bytes together.
+///
+/// # Safety
+///
+/// - `file_paths` must be a valid pointer to an array of `count` null-terminated C strings
+/// - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+/// - `out_len` must be a valid pointer; it receives the length of the returned buffer
+/// - The returned buffer must be freed with `kreuzberg_free_bytes`
+/// - Returns NULL on error (check `kreuzberg_last_error` for details)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_batch_extract_files_packed(
+    file_paths: *const *const c_char,
+    count: usize,
+    config_json: *const c_char,
+    compress_above: usize,
+    out_len: *mut usize,
+) -> *mut u8 {
+    ffi_panic_guard!("kreuzberg_batch_extract_files_packed", {
+        clear_last_error();
+
+        if file_paths.is_null() || out_len.is_null() {
+            set_last_error("file_paths and out_len cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let mut paths = Vec::with_capacity(count);
+        for i in 0..count {
+            let path_ptr = unsafe { *file_paths.add(i) };
+            if path_ptr.is_null() {
+                set_last_error(format!("File path at index {} is NULL", i));
+                return ptr::null_mut();
+            }
+            match unsafe { CStr::from_ptr(path_ptr) }.to_str() {
+                Ok(s) => paths.push(Path::new(s)),
+                Err(e) => {
+                    set_last_error(format!("Invalid UTF-8 in file path at index {}: {}", i, e));
+                    return ptr::null_mut();
+                }
+            }
+        }
+
+        let encoded = parse_config(config_json).and_then(|config| {
+            let results = kreuzberg::batch_extract_file_sync(paths, &config).map_err(|e| e.to_string())?;
+            encode_results(&results, compress_above)
+        });
+        match encoded {
+            Ok(buffer) => into_raw_buffer(buffer, out_len),
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Batch extract byte arrays and return their results packed into one JSON array. See
+/// `kreuzberg_batch_extract_files_packed`.
+///
+/// # Safety
+///
+/// - `items` must be a valid pointer to an array of `count` CBytesWithMime structures
+/// - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+/// - `out_len` must be a valid pointer; it receives the length of the returned buffer
+/// - The returned buffer must be freed with `kreuzberg_free_bytes`
+/// - Returns NULL on error (check `kreuzberg_last_error` for details)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_batch_extract_bytes_packed(
+    items: *const CBytesWithMime,
+    count: usize,
+    config_json: *const c_char,
+    compress_above: usize,
+    out_len: *mut usize,
+) -> *mut u8 {
+    ffi_panic_guard!("kreuzberg_batch_extract_bytes_packed", {
+        clear_last_error();
+
+        if items.is_null() || out_len.is_null() {
+            set_last_error("items and out_len cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let mut contents = Vec::with_capacity(count);
+        for i in 0..count {
+            let item = unsafe { &*items.add(i) };
+            if item.data.is_null() || item.mime_type.is_null() {
+                set_last_error(format!("Data or MIME type at index {} is NULL", i));
+                return ptr::null_mut();
+            }
+            let bytes = unsafe { std::slice::from_raw_parts(item.data, item.data_len) };
+            match unsafe { CStr::from_ptr(item.mime_type) }.to_str() {
+                Ok(mime) => contents.push((bytes.to_vec(), mime.to_string())),
+                Err(e) => {
+                    set_last_error(format!("Invalid UTF-8 in MIME type at index {}: {}", i, e));
+                    return ptr::null_mut();
+                }
+            }
+        }
+
+        let encoded = parse_config(config_json).and_then(|config| {
+            let results = kreuzberg::batch_extract_bytes_sync(contents, &config).map_err(|e| e.to_string())?;
+            encode_results(&results, compress_above)
+        });
+        match encoded {
+            Ok(buffer) => into_raw_buffer(buffer, out_len),
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Free a buffer returned by `kreuzberg_extract_bytes_compressed`,
+/// `kreuzberg_extract_file_compressed` or the packed batch functions.
+///
+/// # Safety
+///
+/// - `data` must have been returned by one of those functions, with `len` the length it reported
+/// - `data` must not be used after this call
+/// - Passing NULL is a no-op
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_free_bytes(data: *mut u8, len: usize) {
+    if !data.is_null() {
+        drop(unsafe { Box::from_raw(ptr::slice_from_raw_parts_mut(data, len)) });
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::ffi::CString;
+
+    fn text_result(content: &str) -> ExtractionResult {
+        let data = content.as_bytes();
+        kreuzberg::extract_bytes_sync(data, "text/plain", &ExtractionConfig::default()).unwrap()
+    }
+
+    #[test]
+    fn test_encode_result_plain_below_threshold() {
+        let encoded = encode_result(&text_result("short text"), 1024).unwrap();
+        assert_eq!(encoded[0], b'{');
+        let value: serde_json::Value = serde_json::from_slice(&encoded).unwrap();
+        assert_eq!(value["content"], "short text");
+        assert_eq!(value["success"], true);
+    }
+
+    #[test]
+    fn test_encode_results_marks_failed_documents() {
+        let mut failed = text_result("Error: unreadable");
+        failed.metadata.error = Some(kreuzberg::types::ErrorMetadata {
+            error_type: "Parsing".to_string(),
+            message: "unreadable".to_string(),
+        });
+        let encoded = encode_results(&[text_result("fine"), failed], usize::MAX).unwrap();
+        let value: serde_json::Value = serde_json::from_slice(&encoded).unwrap();
+        assert_eq!(value[0]["success"], true);
+        assert_eq!(value[1]["success"], false);
+    }
+
+    #[test]
+    fn test_encode_result_compressed_above_threshold() {
+        let content = "repeated line of text\n".repeat(10_000);
+        let encoded = encode_result(&text_result(&content), 1024).unwrap();
+        assert_eq!(&encoded[..4], &[0x28, 0xB5, 0x2F, 0xFD]);
+        assert!(encoded.len() < content.len() / 10);
+        let decoded = zstd::stream::decode_all(encoded.as_slice()).unwrap();
+        let value: serde_json::Value = serde_json::from_slice(&decoded).unwrap();
+        assert!(value["content"].as_str().unwrap().starts_with("repeated line of text"));
+    }
+
+    #[test]
+    fn test_extract_bytes_compressed_round_trip() {
+        let data = b"hello compressed world";
+        let mime = CString::new("text/plain").unwrap();
+        let mut len = 0usize;
+        let buffer = unsafe {
+            kreuzberg_extract_bytes_compressed(data.as_ptr(), data.len(), mime.as_ptr(), ptr::null(), 0, &mut len)
+        };
+        assert!(!buffer.is_null());
+        let slice = unsafe { std::slice::from_raw_parts(buffer, len) };
+        let decoded = zstd::stream::decode_all(slice).unwrap();
+        let value: serde_json::Value = serde_json::from_slice(&decoded).unwrap();
+        assert!(value["content"].as_str().unwrap().contains("hello compressed world"));
+        unsafe { kreuzberg_free_bytes(buffer, len) };
+    }
+
+    #[test]
+    fn test_batch_extract_bytes_packed() {
+        let first = b"first document";
+        let second = b"second document";
+        let mime = CString::new("text/plain").unwrap();
+        let items = [
+            CBytesWithMime {
+                data: first.as_ptr(),
+                data_len: first.len(),
+                mime_type: mime.as_ptr(),
+            },
+            CBytesWithMime {
+                data: second.as_ptr(),
+                data_len: second.len(),
+                mime_type: mime.as_ptr(),
+            },
+        ];
+        let mut len = 0usize;
+        let buffer = unsafe {
+            kreuzberg_batch_extract_bytes_packed(items.as_ptr(), items.len(), ptr::null(), usize::MAX, &mut len)
+        };
+        assert!(!buffer.is_null());
+        let slice = unsafe { std::slice::from_raw_parts(buffer, len) };
+        let value: serde_json::Value = serde_json::from_slice(slice).unwrap();
+        let results = value.as_array().unwrap();
+        assert_eq!(results.len(), 2);
+        assert!(results[1]["content"].as_str().unwrap().contains("second document"));
+        unsafe { kreuzberg_free_bytes(buffer, len) };
+    }
+
+    #[test]
+    fn test_extract_bytes_compressed_null_out_len() {
+        let data = b"text";
+        let mime = CString::new("text/plain").unwrap();
+        let buffer = unsafe {
+            kreuzberg_extract_bytes_compressed(
+                data.as_ptr(),
+                data.len(),
+                mime.as_ptr(),
+                ptr::null(),
+                0,
+                ptr::null_mut(),
+            )
+        };
+        assert!(buffer.is_null());
+    }
+}
@@ -0,0 +1,60 @@
+//! Configuration document format conversion
+//!
+//! Converts configuration documents between JSON, YAML, and TOML without interpreting
+//! their fields, so bindings can keep binding-specific settings that the core
+//! ExtractionConfig does not model.
+
+/// Convert a configuration document from one format to another.
+///
+/// # Arguments
+///
+/// * `content` - The document text
+/// * `from` - Source format: "json", "yaml" (or "yml"), or "toml"
+/// * `to` - Target format, with the same choices
+///
+/// # Returns
+///
+/// The converted document, or error message.
+pub fn convert_config_format(content: &str, from: &str, to: &str) -> Result<String, String> {
+    let value: serde_json::Value = match from {
+        "json" => serde_json::from_str(content).map_err(|e| format!("Invalid JSON config: {}", e))?,
+        "yaml" | "yml" => serde_yaml_ng::from_str(content).map_err(|e| format!("Invalid YAML config: {}", e))?,
+        "toml" => toml::from_str(content).map_err(|e| format!("Invalid TOML config: {}", e))?,
+        other => return Err(format!("Unsupported config format: {}", other)),
+    };
+
+    match to {
+        "json" => serde_json::to_string(&value).map_err(|e| format!("Failed to serialize config to JSON: {}", e)),
+        "yaml" | "yml" => {
+            serde_yaml_ng::to_string(&value).map_err(|e| format!("Failed to serialize config to YAML: {}", e))
+        }
+        "toml" => toml::to_string(&value).map_err(|e| format!("Failed to serialize config to TOML: {}", e)),
+        other => Err(format!("Unsupported config format: {}", other)),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_yaml_to_json() {
+        let json = convert_config_format("use_cache: false\nocr:\n  backend: tesseract\n", "yaml", "json").unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(value["use_cache"], false);
+        assert_eq!(value["ocr"]["backend"], "tesseract");
+    }
+
+    #[test]
+    fn test_json_to_toml_round_trip() {
+        let toml = convert_config_format(r#"{"chunking":{"max_chars":500}}"#, "json", "toml").unwrap();
+        let json = convert_config_format(&toml, "toml", "json").unwrap();
+        assert_eq!(json, r#"{"chunking":{"max_chars":500}}"#);
+    }
+
+    #[test]
+    fn test_unsupported_format() {
+        assert!(convert_config_format("{}", "ini", "json").is_err());
+        assert!(convert_config_format("{}", "json", "xml").is_err());
+    }
+}
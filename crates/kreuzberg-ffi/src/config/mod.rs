@@ -10,6 +10,7 @@
 //! - Elimination of drift/inconsistencies
 //! - Better performance (no JSON round-trips in language bindings)
 
+mod format;
 mod html;
 mod loader;
 mod merge;
@@ -17,6 +18,7 @@ mod parse;
 mod serialize;
 
 // Re-export key functions for internal use
+pub use format::convert_config_format;
 pub use loader::{
     discover_config_as_json, get_embedding_preset, list_embedding_presets, load_config_as_json, load_config_from_file,
 };
@@ -24,7 +26,7 @@ pub use merge::merge_configs;
 pub use parse::parse_extraction_config_from_json;
 pub use serialize::{config_to_json_string, get_field_as_json, json_to_c_string};
 
-use crate::ffi_panic_guard;
+use crate::{ffi_panic_guard, ffi_panic_guard_i32};
 use crate::helpers::{clear_last_error, set_last_error, string_to_c_string};
 use kreuzberg::core::config::ExtractionConfig;
 use std::ffi::{CStr, CString};
@@ -54,28 +56,30 @@ use std::ptr;
 /// - Returns NULL if parsing fails (error available via `kreuzberg_last_error`)
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_config_from_json(json_config: *const c_char) -> *mut ExtractionConfig {
-    if json_config.is_null() {
-        set_last_error("Config JSON cannot be NULL".to_string());
-        return ptr::null_mut();
-    }
-
-    clear_last_error();
-
-    let json_str = match unsafe { CStr::from_ptr(json_config) }.to_str() {
-        Ok(s) => s,
-        Err(e) => {
-            set_last_error(format!("Invalid UTF-8 in config JSON: {}", e));
+    ffi_panic_guard!("kreuzberg_config_from_json", {
+        if json_config.is_null() {
+            set_last_error("Config JSON cannot be NULL".to_string());
             return ptr::null_mut();
         }
-    };
 
-    match parse_extraction_config_from_json(json_str) {
-        Ok(config) => Box::into_raw(Box::new(config)),
-        Err(e) => {
-            set_last_error(e);
-            ptr::null_mut()
+        clear_last_error();
+
+        let json_str = match unsafe { CStr::from_ptr(json_config) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in config JSON: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        match parse_extraction_config_from_json(json_str) {
+            Ok(config) => Box::into_raw(Box::new(config)),
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
         }
-    }
+    })
 }
 
 /// Free an ExtractionConfig allocated by kreuzberg_config_from_json or similar.
@@ -87,9 +91,15 @@ pub unsafe extern "C" fn kreuzberg_config_from_json(json_config: *const c_char)
 /// - `config` must not be used after this call
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_config_free(config: *mut ExtractionConfig) {
-    if !config.is_null() {
-        let _ = unsafe { Box::from_raw(config) };
-    }
+    ffi_panic_guard!(
+        "kreuzberg_config_free",
+        {
+            if !config.is_null() {
+                let _ = unsafe { Box::from_raw(config) };
+            }
+        },
+        ()
+    )
 }
 
 /// Validate a JSON config string without parsing it.
@@ -104,28 +114,30 @@ pub unsafe extern "C" fn kreuzberg_config_free(config: *mut ExtractionConfig) {
 /// - `json_config` must be a valid null-terminated C string
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_config_is_valid(json_config: *const c_char) -> i32 {
-    if json_config.is_null() {
-        set_last_error("Config JSON cannot be NULL".to_string());
-        return 0;
-    }
-
-    clear_last_error();
-
-    let json_str = match unsafe { CStr::from_ptr(json_config) }.to_str() {
-        Ok(s) => s,
-        Err(e) => {
-            set_last_error(format!("Invalid UTF-8 in config JSON: {}", e));
+    ffi_panic_guard_i32!("kreuzberg_config_is_valid", {
+        if json_config.is_null() {
+            set_last_error("Config JSON cannot be NULL".to_string());
             return 0;
         }
-    };
 
-    match parse_extraction_config_from_json(json_str) {
-        Ok(_) => 1,
-        Err(e) => {
-            set_last_error(e);
-            0
+        clear_last_error();
+
+        let json_str = match unsafe { CStr::from_ptr(json_config) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in config JSON: {}", e));
+                return 0;
+            }
+        };
+
+        match parse_extraction_config_from_json(json_str) {
+            Ok(_) => 1,
+            Err(e) => {
+                set_last_error(e);
+                0
+            }
         }
-    }
+    })
 }
 
 /// Serialize an ExtractionConfig to JSON string.
@@ -136,17 +148,19 @@ pub unsafe extern "C" fn kreuzberg_config_is_valid(json_config: *const c_char) -
 /// - The returned pointer must be freed with `kreuzberg_free_string`
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_config_to_json(config: *const ExtractionConfig) -> *mut c_char {
-    if config.is_null() {
-        set_last_error("Config cannot be NULL".to_string());
-        return ptr::null_mut();
-    }
+    ffi_panic_guard!("kreuzberg_config_to_json", {
+        if config.is_null() {
+            set_last_error("Config cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
 
-    clear_last_error();
+        clear_last_error();
 
-    match config_to_json_string(unsafe { &*config }) {
-        Some(json) => json_to_c_string(json),
-        None => ptr::null_mut(),
-    }
+        match config_to_json_string(unsafe { &*config }) {
+            Some(json) => json_to_c_string(json),
+            None => ptr::null_mut(),
+        }
+    })
 }
 
 /// Get a specific field from config as JSON string.
@@ -160,30 +174,32 @@ pub unsafe extern "C" fn kreuzberg_config_get_field(
     config: *const ExtractionConfig,
     field_name: *const c_char,
 ) -> *mut c_char {
-    if config.is_null() {
-        set_last_error("Config cannot be NULL".to_string());
-        return ptr::null_mut();
-    }
-
-    if field_name.is_null() {
-        set_last_error("Field name cannot be NULL".to_string());
-        return ptr::null_mut();
-    }
-
-    clear_last_error();
-
-    let field_str = match unsafe { CStr::from_ptr(field_name) }.to_str() {
-        Ok(s) => s,
-        Err(e) => {
-            set_last_error(format!("Invalid UTF-8 in field name: {}", e));
+    ffi_panic_guard!("kreuzberg_config_get_field", {
+        if config.is_null() {
+            set_last_error("Config cannot be NULL".to_string());
             return ptr::null_mut();
         }
-    };
 
-    match get_field_as_json(unsafe { &*config }, field_str) {
-        Some(json) => json_to_c_string(json),
-        None => ptr::null_mut(),
-    }
+        if field_name.is_null() {
+            set_last_error("Field name cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        clear_last_error();
+
+        let field_str = match unsafe { CStr::from_ptr(field_name) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in field name: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        match get_field_as_json(unsafe { &*config }, field_str) {
+            Some(json) => json_to_c_string(json),
+            None => ptr::null_mut(),
+        }
+    })
 }
 
 /// Merge two configs (override takes precedence over base).
@@ -202,21 +218,23 @@ pub unsafe extern "C" fn kreuzberg_config_merge(
     base: *mut ExtractionConfig,
     override_config: *const ExtractionConfig,
 ) -> i32 {
-    if base.is_null() {
-        set_last_error("Base config cannot be NULL".to_string());
-        return 0;
-    }
+    ffi_panic_guard_i32!("kreuzberg_config_merge", {
+        if base.is_null() {
+            set_last_error("Base config cannot be NULL".to_string());
+            return 0;
+        }
 
-    if override_config.is_null() {
-        set_last_error("Override config cannot be NULL".to_string());
-        return 0;
-    }
+        if override_config.is_null() {
+            set_last_error("Override config cannot be NULL".to_string());
+            return 0;
+        }
 
-    clear_last_error();
+        clear_last_error();
 
-    merge_configs(unsafe { &mut *base }, unsafe { &*override_config });
+        merge_configs(unsafe { &mut *base }, unsafe { &*override_config });
 
-    1
+        1
+    })
 }
 
 /// Load an ExtractionConfig from a file (returns JSON string).
@@ -318,6 +336,53 @@ pub unsafe extern "C" fn kreuzberg_config_discover() -> *mut c_char {
     })
 }
 
+/// Convert a configuration document between JSON, YAML, and TOML.
+///
+/// The document is converted as plain data: fields are neither validated nor filled
+/// with defaults, so settings handled by the bindings survive the conversion.
+/// `from_format` and `to_format` are "json", "yaml", or "toml".
+///
+/// # Safety
+///
+/// - `content`, `from_format`, and `to_format` must be valid null-terminated C strings
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_config_convert_format(
+    content: *const c_char,
+    from_format: *const c_char,
+    to_format: *const c_char,
+) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_config_convert_format", {
+        clear_last_error();
+
+        if content.is_null() || from_format.is_null() || to_format.is_null() {
+            set_last_error("content, from_format, and to_format cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let (content, from, to) = match (
+            unsafe { CStr::from_ptr(content) }.to_str(),
+            unsafe { CStr::from_ptr(from_format) }.to_str(),
+            unsafe { CStr::from_ptr(to_format) }.to_str(),
+        ) {
+            (Ok(content), Ok(from), Ok(to)) => (content, from, to),
+            _ => {
+                set_last_error("Invalid UTF-8 in config conversion arguments".to_string());
+                return ptr::null_mut();
+            }
+        };
+
+        match convert_config_format(content, from, to).and_then(string_to_c_string) {
+            Ok(ptr) => ptr,
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
 /// List available embedding preset names.
 ///
 /// # Safety
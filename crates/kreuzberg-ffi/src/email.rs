@@ -0,0 +1,140 @@
+//! Email parsing functions for FFI.
+//!
+//! Bindings render email bodies, quoted replies, and attachments according to their own
+//! options, so they need the parsed message rather than the text the email extractor
+//! produces.
+
+use crate::ffi_panic_guard;
+use crate::helpers::{clear_last_error, set_last_error, string_to_c_string};
+use base64::Engine;
+use base64::engine::general_purpose::STANDARD;
+use kreuzberg::types::EmailExtractionResult;
+use serde::Serialize;
+use std::ffi::CStr;
+use std::os::raw::c_char;
+use std::ptr;
+
+/// An attachment with its content encoded as base64, which is far more compact in JSON
+/// than the byte array `EmailAttachment` serializes to.
+#[derive(Debug, Serialize)]
+struct ParsedAttachment {
+    name: Option<String>,
+    mime_type: Option<String>,
+    size: Option<usize>,
+    data: Option<String>,
+}
+
+#[derive(Debug, Serialize)]
+struct ParsedEmail {
+    subject: Option<String>,
+    from_email: Option<String>,
+    to_emails: Vec<String>,
+    cc_emails: Vec<String>,
+    bcc_emails: Vec<String>,
+    date: Option<String>,
+    message_id: Option<String>,
+    plain_text: Option<String>,
+    html_content: Option<String>,
+    attachments: Vec<ParsedAttachment>,
+}
+
+impl From<EmailExtractionResult> for ParsedEmail {
+    fn from(email: EmailExtractionResult) -> Self {
+        let attachments = email
+            .attachments
+            .into_iter()
+            .map(|attachment| ParsedAttachment {
+                name: attachment.filename.or(attachment.name),
+                mime_type: attachment.mime_type,
+                size: attachment.size,
+                data: attachment.data.map(|data| STANDARD.encode(data)),
+            })
+            .collect();
+        Self {
+            subject: email.subject,
+            from_email: email.from_email,
+            to_emails: email.to_emails,
+            cc_emails: email.cc_emails,
+            bcc_emails: email.bcc_emails,
+            date: email.date,
+            message_id: email.message_id,
+            plain_text: email.plain_text,
+            html_content: email.html_content,
+            attachments,
+        }
+    }
+}
+
+fn parse_email(data: &[u8], mime_type: &str) -> Result<String, String> {
+    let email = kreuzberg::extraction::extract_email_content(data, mime_type).map_err(|e| e.to_string())?;
+    serde_json::to_string(&ParsedEmail::from(email)).map_err(|e| format!("Failed to serialize email: {}", e))
+}
+
+/// Parse an email message (.eml or .msg) without rendering it.
+///
+/// Returns a JSON object with the headers, the plain text and HTML bodies, and the
+/// attachments with base64-encoded content.
+///
+/// # Safety
+///
+/// - `data` must point to a valid buffer of at least `len` bytes
+/// - `mime_type` must be a valid null-terminated C string
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_email_parse(data: *const u8, len: usize, mime_type: *const c_char) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_email_parse", {
+        clear_last_error();
+
+        if data.is_null() || mime_type.is_null() {
+            set_last_error("data and mime_type cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let mime_str = match unsafe { CStr::from_ptr(mime_type) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in MIME type: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        let slice = unsafe { std::slice::from_raw_parts(data, len) };
+
+        match parse_email(slice, mime_str).and_then(string_to_c_string) {
+            Ok(ptr) => ptr,
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::ffi::CString;
+
+    #[test]
+    fn test_email_parse_null_data() {
+        let mime = CString::new("message/rfc822").unwrap();
+        let result = unsafe { kreuzberg_email_parse(ptr::null(), 0, mime.as_ptr()) };
+        assert!(result.is_null());
+    }
+
+    #[test]
+    fn test_parse_email_eml() {
+        let eml = b"From: alice@example.com\r\nTo: bob@example.com\r\nSubject: Hello\r\n\r\nHi Bob\r\n";
+        let json = parse_email(eml, "message/rfc822").unwrap();
+        let value: serde_json::Value = serde_json::from_str(&json).unwrap();
+        assert_eq!(value["subject"], "Hello");
+        assert_eq!(value["to_emails"][0], "bob@example.com");
+        assert!(value["plain_text"].as_str().unwrap().contains("Hi Bob"));
+    }
+
+    #[test]
+    fn test_parse_email_unsupported_mime() {
+        assert!(parse_email(b"data", "application/pdf").is_err());
+    }
+}
@@ -2,7 +2,8 @@
 //!
 //! This module provides the main FFI entry points for document extraction operations.
 //! These functions are the most critical part of the FFI layer and handle both
-//! synchronous file and byte array extraction operations, including batch processing.
+//! synchronous file and byte array extraction operations, including batch processing,
+//! and the location of the temporary files extractions write.
 //!
 //! # Safety
 //!
@@ -29,6 +30,7 @@ use std::ptr;
 use kreuzberg::core::config::ExtractionConfig;
 
 use crate::ffi_panic_guard;
+use crate::ffi_panic_guard_i32;
 use crate::helpers::{clear_last_error, parse_extraction_config_from_json, set_last_error, to_c_extraction_result};
 use crate::memory::kreuzberg_free_result;
 use crate::types::{CBatchResult, CBytesWithMime, CExtractionResult};
@@ -553,3 +555,62 @@ pub unsafe extern "C" fn kreuzberg_batch_extract_bytes_sync(
         }
     })
 }
+
+/// Redirect the temporary files that extractions spool to disk, such as the documents
+/// converted by LibreOffice, to the directory `dir`. NULL restores the system temporary
+/// directory.
+///
+/// The setting is process-wide and applies to extractions started afterwards. The
+/// directory must exist; the library creates and removes its files within it.
+///
+/// # Safety
+///
+/// - `dir` must be NULL or a valid null-terminated C string
+/// - Returns 0 on success and -1 on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_set_temp_dir(dir: *const c_char) -> i32 {
+    ffi_panic_guard_i32!("kreuzberg_set_temp_dir", {
+        clear_last_error();
+
+        if dir.is_null() {
+            kreuzberg::utils::set_temp_dir(None);
+            return 0;
+        }
+
+        let dir_str = match unsafe { CStr::from_ptr(dir) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in temp dir: {}", e));
+                return -1;
+            }
+        };
+        let path = Path::new(dir_str);
+        if !path.is_dir() {
+            set_last_error(format!("Temp dir is not a directory: {}", dir_str));
+            return -1;
+        }
+
+        kreuzberg::utils::set_temp_dir(Some(path.to_path_buf()));
+        0
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::ffi::CString;
+
+    #[test]
+    fn test_set_temp_dir() {
+        let dir = tempfile::tempdir().unwrap();
+        let c_dir = CString::new(dir.path().to_str().unwrap()).unwrap();
+        assert_eq!(unsafe { kreuzberg_set_temp_dir(c_dir.as_ptr()) }, 0);
+        assert_eq!(kreuzberg::utils::temp_dir(), dir.path());
+
+        let missing = CString::new(dir.path().join("missing").to_str().unwrap()).unwrap();
+        assert_eq!(unsafe { kreuzberg_set_temp_dir(missing.as_ptr()) }, -1);
+
+        assert_eq!(unsafe { kreuzberg_set_temp_dir(ptr::null()) }, 0);
+        assert_eq!(kreuzberg::utils::temp_dir(), std::env::temp_dir());
+    }
+}
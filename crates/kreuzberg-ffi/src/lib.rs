@@ -4,8 +4,10 @@
 //! Go (cgo), C# (P/Invoke), Zig, and other languages with C FFI support.
 
 mod batch_streaming;
+mod compressed;
 mod config;
 mod config_builder;
+mod email;
 mod error;
 mod extraction;
 mod helpers;
@@ -13,7 +15,10 @@ mod html_options;
 mod memory;
 mod mime;
 mod panic_shield;
+mod pdf_pages;
 mod plugins;
+mod profiling;
+mod render;
 mod result;
 mod result_pool;
 mod result_view;
@@ -25,10 +30,15 @@ mod validation;
 pub use batch_streaming::{
     ErrorCallback, ResultCallback, kreuzberg_extract_batch_parallel, kreuzberg_extract_batch_streaming,
 };
+pub use compressed::{
+    kreuzberg_batch_extract_bytes_packed, kreuzberg_batch_extract_files_packed, kreuzberg_extract_bytes_compressed,
+    kreuzberg_extract_file_compressed, kreuzberg_free_bytes,
+};
 pub use config::{
-    kreuzberg_config_discover, kreuzberg_config_free, kreuzberg_config_from_file, kreuzberg_config_from_json,
-    kreuzberg_config_get_field, kreuzberg_config_is_valid, kreuzberg_config_merge, kreuzberg_config_to_json,
-    kreuzberg_get_embedding_preset, kreuzberg_list_embedding_presets, kreuzberg_load_extraction_config_from_file,
+    kreuzberg_config_convert_format, kreuzberg_config_discover, kreuzberg_config_free, kreuzberg_config_from_file,
+    kreuzberg_config_from_json, kreuzberg_config_get_field, kreuzberg_config_is_valid, kreuzberg_config_merge,
+    kreuzberg_config_to_json, kreuzberg_get_embedding_preset, kreuzberg_list_embedding_presets,
+    kreuzberg_load_extraction_config_from_file,
 };
 pub use config_builder::{
     kreuzberg_config_builder_build, kreuzberg_config_builder_free, kreuzberg_config_builder_new,
@@ -37,6 +47,7 @@ pub use config_builder::{
     kreuzberg_config_builder_set_pdf, kreuzberg_config_builder_set_post_processor,
     kreuzberg_config_builder_set_use_cache,
 };
+pub use email::kreuzberg_email_parse;
 pub use error::ErrorCode as KreuzbergErrorCode;
 pub use error::{
     CErrorDetails, kreuzberg_classify_error, kreuzberg_error_code_count, kreuzberg_error_code_description,
@@ -47,6 +58,7 @@ pub use error::{
 pub use extraction::{
     kreuzberg_batch_extract_bytes_sync, kreuzberg_batch_extract_files_sync, kreuzberg_extract_bytes_sync,
     kreuzberg_extract_bytes_sync_with_config, kreuzberg_extract_file_sync, kreuzberg_extract_file_sync_with_config,
+    kreuzberg_set_temp_dir,
 };
 pub use helpers::*;
 pub use html_options::{
@@ -63,9 +75,14 @@ pub use mime::{
 };
 pub use panic_shield::{
     ErrorCode, StructuredError, clear_structured_error, get_last_error_code, get_last_error_message,
-    get_last_panic_context, set_structured_error,
+    get_last_panic_backtrace, get_last_panic_context, kreuzberg_inject_fault, set_structured_error,
 };
+pub use pdf_pages::{kreuzberg_pdf_page_hashes, kreuzberg_pdf_probe, kreuzberg_pdf_select_pages};
 pub use plugins::*;
+pub use profiling::{
+    PROFILE_FORMAT_FLAMEGRAPH, PROFILE_FORMAT_FOLDED, kreuzberg_profiling_start, kreuzberg_profiling_stop,
+};
+pub use render::{kreuzberg_pdf_text_layer, kreuzberg_render_pdf_pages};
 pub use result::{
     CMetadataField, kreuzberg_result_get_chunk_count, kreuzberg_result_get_detected_language,
     kreuzberg_result_get_metadata_field, kreuzberg_result_get_page_count,
@@ -82,7 +99,10 @@ pub use string_intern::{
     kreuzberg_string_intern_stats,
 };
 pub use types::*;
-pub use util::{kreuzberg_last_error, kreuzberg_last_error_code, kreuzberg_last_panic_context, kreuzberg_version};
+pub use util::{
+    KREUZBERG_ABI_VERSION, kreuzberg_build_info, kreuzberg_last_error, kreuzberg_last_error_code,
+    kreuzberg_last_panic_context, kreuzberg_version,
+};
 pub use validation::*;
 
 #[cfg(test)]
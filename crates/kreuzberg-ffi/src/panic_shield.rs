@@ -1,5 +1,10 @@
 use kreuzberg::panic_context::PanicContext;
+use std::backtrace::Backtrace;
 use std::cell::RefCell;
+use std::ffi::{CString, c_char};
+use std::sync::Once;
+
+use crate::helpers::{LAST_ERROR_C_STRING, set_last_error};
 
 /// Structured error that includes both the error message and optional panic context.
 #[derive(Debug, Clone)]
@@ -8,6 +13,8 @@ pub struct StructuredError {
     pub message: String,
     /// Optional panic context if this error originated from a panic
     pub panic_context: Option<PanicContext>,
+    /// Backtrace of the panicking thread if this error originated from a panic
+    pub backtrace: Option<String>,
     /// Error code for programmatic error handling
     pub code: ErrorCode,
 }
@@ -40,6 +47,7 @@ impl StructuredError {
         Self {
             message: context.format(),
             panic_context: Some(context),
+            backtrace: take_panic_backtrace(),
             code: ErrorCode::Panic,
         }
     }
@@ -49,6 +57,7 @@ impl StructuredError {
         Self {
             message,
             panic_context: None,
+            backtrace: None,
             code,
         }
     }
@@ -65,6 +74,45 @@ impl StructuredError {
 
 thread_local! {
     static LAST_STRUCTURED_ERROR: RefCell<Option<StructuredError>> = const { RefCell::new(None) };
+    static PANIC_BACKTRACE: RefCell<Option<String>> = const { RefCell::new(None) };
+}
+
+/// Maximum backtrace length kept with a panic, in bytes.
+const MAX_BACKTRACE_LEN: usize = 32 * 1024;
+
+static PANIC_HOOK: Once = Once::new();
+
+/// Installs, once per process, a panic hook that records the backtrace of the panicking
+/// thread for the panic guards to attach to the structured error. The previous hook still
+/// runs afterwards.
+pub fn install_panic_hook() {
+    PANIC_HOOK.call_once(|| {
+        let previous = std::panic::take_hook();
+        std::panic::set_hook(Box::new(move |info| {
+            let mut backtrace = Backtrace::force_capture().to_string();
+            if backtrace.len() > MAX_BACKTRACE_LEN {
+                backtrace.truncate(backtrace.floor_char_boundary(MAX_BACKTRACE_LEN));
+                backtrace.push_str("... [truncated]");
+            }
+            let _ = PANIC_BACKTRACE.try_with(|last| *last.borrow_mut() = Some(backtrace));
+            previous(info);
+        }));
+    });
+}
+
+/// Takes the backtrace recorded by the panic hook for the last panic on this thread.
+fn take_panic_backtrace() -> Option<String> {
+    PANIC_BACKTRACE.with(|last| last.borrow_mut().take())
+}
+
+/// Records a caught panic as the last error, both structured and as the message returned
+/// by `kreuzberg_last_error`.
+pub fn set_panic_error(context: PanicContext) {
+    let error = StructuredError::from_panic(context);
+    if let Ok(message) = CString::new(error.message.clone()) {
+        LAST_ERROR_C_STRING.with(|last| *last.borrow_mut() = Some(message));
+    }
+    set_structured_error(error);
 }
 
 /// Sets the last structured error.
@@ -87,11 +135,75 @@ pub fn get_last_panic_context() -> Option<PanicContext> {
     LAST_STRUCTURED_ERROR.with(|last| last.borrow().as_ref().and_then(|e| e.panic_context.clone()))
 }
 
+/// Gets the backtrace of the last panic if the last error was a panic.
+pub fn get_last_panic_backtrace() -> Option<String> {
+    LAST_STRUCTURED_ERROR.with(|last| last.borrow().as_ref().and_then(|e| e.backtrace.clone()))
+}
+
 /// Clears the last structured error.
 pub fn clear_structured_error() {
     LAST_STRUCTURED_ERROR.with(|last| *last.borrow_mut() = None);
 }
 
+#[cfg(feature = "fault-injection")]
+static ARMED_FAULT: std::sync::Mutex<Option<String>> = std::sync::Mutex::new(None);
+
+/// Panics if a fault was armed for `function` with `kreuzberg_inject_fault`, disarming it.
+///
+/// Called by the panic guards inside the guarded body. Without the `fault-injection`
+/// feature this does nothing.
+#[inline]
+pub fn inject_fault(function: &str) {
+    #[cfg(feature = "fault-injection")]
+    {
+        let mut armed = ARMED_FAULT.lock().unwrap_or_else(|e| e.into_inner());
+        if armed.as_deref() == Some(function) {
+            *armed = None;
+            drop(armed);
+            panic!("Injected fault in {}", function);
+        }
+    }
+    #[cfg(not(feature = "fault-injection"))]
+    let _ = function;
+}
+
+/// Arm a fault: the next call of the FFI function named `function` panics inside its panic
+/// guard, so bindings can test that they recover. NULL disarms the pending fault.
+///
+/// For tests only. Returns 0 on success and -1 on error (check `kreuzberg_last_error`),
+/// including when the library was built without the `fault-injection` feature.
+///
+/// # Safety
+///
+/// - `function` must be NULL or a valid null-terminated C string
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_inject_fault(function: *const c_char) -> i32 {
+    #[cfg(feature = "fault-injection")]
+    {
+        let name = if function.is_null() {
+            None
+        } else {
+            match unsafe { std::ffi::CStr::from_ptr(function) }.to_str() {
+                Ok(name) => Some(name.to_string()),
+                Err(_) => {
+                    set_last_error("Invalid UTF-8 in function name".to_string());
+                    return -1;
+                }
+            }
+        };
+        *ARMED_FAULT.lock().unwrap_or_else(|e| e.into_inner()) = name;
+        0
+    }
+    #[cfg(not(feature = "fault-injection"))]
+    {
+        let _ = function;
+        set_last_error(
+            "Fault injection requires kreuzberg-ffi built with the `fault-injection` feature".to_string(),
+        );
+        -1
+    }
+}
+
 /// Macro to wrap FFI functions with panic catching.
 ///
 /// This macro catches panics at FFI boundaries and converts them to structured errors.
@@ -123,29 +235,37 @@ pub fn clear_structured_error() {
 ///
 /// The macro will:
 /// - Catch any panics that occur in the wrapped code
-/// - Create a PanicContext with file/line/function information
-/// - Store the structured error in thread-local storage
+/// - Create a PanicContext with file/line/function information and the backtrace of the panic
+/// - Store the structured error and the error message in thread-local storage
 /// - Return a null pointer (for pointer-returning functions) or false (for bool-returning functions) to indicate failure
 #[macro_export]
 macro_rules! ffi_panic_guard {
     ($function_name:expr, $body:expr) => {{
-        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| $body)) {
+        $crate::panic_shield::install_panic_hook();
+        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| {
+            $crate::panic_shield::inject_fault($function_name);
+            $body
+        })) {
             Ok(result) => result,
             Err(panic_info) => {
                 let context =
                     kreuzberg::panic_context::PanicContext::new(file!(), line!(), $function_name, panic_info.as_ref());
-                $crate::panic_shield::set_structured_error($crate::panic_shield::StructuredError::from_panic(context));
+                $crate::panic_shield::set_panic_error(context);
                 std::ptr::null_mut()
             }
         }
     }};
     ($function_name:expr, $body:expr, $default:expr) => {{
-        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| $body)) {
+        $crate::panic_shield::install_panic_hook();
+        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| {
+            $crate::panic_shield::inject_fault($function_name);
+            $body
+        })) {
             Ok(result) => result,
             Err(panic_info) => {
                 let context =
                     kreuzberg::panic_context::PanicContext::new(file!(), line!(), $function_name, panic_info.as_ref());
-                $crate::panic_shield::set_structured_error($crate::panic_shield::StructuredError::from_panic(context));
+                $crate::panic_shield::set_panic_error(context);
                 $default
             }
         }
@@ -158,12 +278,16 @@ macro_rules! ffi_panic_guard {
 #[macro_export]
 macro_rules! ffi_panic_guard_bool {
     ($function_name:expr, $body:expr) => {{
-        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| $body)) {
+        $crate::panic_shield::install_panic_hook();
+        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| {
+            $crate::panic_shield::inject_fault($function_name);
+            $body
+        })) {
             Ok(result) => result,
             Err(panic_info) => {
                 let context =
                     kreuzberg::panic_context::PanicContext::new(file!(), line!(), $function_name, panic_info.as_ref());
-                $crate::panic_shield::set_structured_error($crate::panic_shield::StructuredError::from_panic(context));
+                $crate::panic_shield::set_panic_error(context);
                 false
             }
         }
@@ -176,12 +300,16 @@ macro_rules! ffi_panic_guard_bool {
 #[macro_export]
 macro_rules! ffi_panic_guard_i32 {
     ($function_name:expr, $body:expr) => {{
-        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| $body)) {
+        $crate::panic_shield::install_panic_hook();
+        match std::panic::catch_unwind(std::panic::AssertUnwindSafe(|| {
+            $crate::panic_shield::inject_fault($function_name);
+            $body
+        })) {
             Ok(result) => result,
             Err(panic_info) => {
                 let context =
                     kreuzberg::panic_context::PanicContext::new(file!(), line!(), $function_name, panic_info.as_ref());
-                $crate::panic_shield::set_structured_error($crate::panic_shield::StructuredError::from_panic(context));
+                $crate::panic_shield::set_panic_error(context);
                 -1
             }
         }
@@ -273,4 +401,36 @@ mod tests {
         assert!(msg.contains("intentional panic"));
         assert!(msg.contains("test_panic"));
     }
+
+    #[test]
+    fn test_ffi_panic_guard_records_message_and_backtrace() {
+        crate::helpers::clear_last_error();
+
+        let result: i32 = crate::ffi_panic_guard_i32!("test_backtrace", {
+            panic!("backtrace panic");
+            #[allow(unreachable_code)]
+            0
+        });
+
+        assert_eq!(result, -1);
+        let message = LAST_ERROR_C_STRING.with(|last| last.borrow().clone()).expect("error message");
+        assert!(message.to_str().unwrap().contains("backtrace panic"));
+        assert!(get_last_panic_backtrace().is_some_and(|backtrace| !backtrace.is_empty()));
+    }
+
+    #[cfg(feature = "fault-injection")]
+    #[test]
+    fn test_inject_fault() {
+        assert_eq!(unsafe { kreuzberg_inject_fault(c"test_injected".as_ptr()) }, 0);
+        assert_eq!(crate::ffi_panic_guard_i32!("test_injected", { 1 }), -1);
+        assert!(get_last_error_message().unwrap().contains("Injected fault in test_injected"));
+        assert_eq!(crate::ffi_panic_guard_i32!("test_injected", { 1 }), 1);
+    }
+
+    #[cfg(not(feature = "fault-injection"))]
+    #[test]
+    fn test_inject_fault_unavailable() {
+        assert_eq!(unsafe { kreuzberg_inject_fault(c"test_injected".as_ptr()) }, -1);
+        assert_eq!(crate::ffi_panic_guard_i32!("test_injected", { 1 }), 1);
+    }
 }
@@ -0,0 +1,452 @@
+//! PDF page-level functions for FFI.
+//!
+//! These functions support incremental re-extraction and extraction planning in the
+//! bindings: callers fingerprint what every page draws, extract a document
+//! holding only the pages that changed, or probe a document without extracting it.
+
+use crate::ffi_panic_guard;
+use crate::helpers::{clear_last_error, set_last_error, string_to_c_string};
+use base64::Engine;
+use base64::engine::general_purpose::STANDARD;
+use lopdf::content::Content;
+use lopdf::{Dictionary, Document, Object, ObjectId};
+use serde::Serialize;
+use std::collections::HashSet;
+use std::ffi::CStr;
+use std::os::raw::c_char;
+use std::ptr;
+
+const FNV_OFFSET_BASIS: u64 = 0xcbf29ce484222325;
+const FNV_PRIME: u64 = 0x100000001b3;
+
+/// Extends an FNV-1a hash with data. Fingerprints are persisted by callers, so the hash must be stable across
+/// builds and platforms, which `std::hash` does not guarantee.
+fn fnv1a(hash: u64, data: &[u8]) -> u64 {
+    data.iter()
+        .fold(hash, |hash, &byte| (hash ^ byte as u64).wrapping_mul(FNV_PRIME))
+}
+
+fn load_document(pdf_bytes: &[u8]) -> Result<Document, String> {
+    Document::load_mem(pdf_bytes).map_err(|e| format!("Failed to load PDF: {}", e))
+}
+
+/// Page attributes inherited from the page tree when a page does not set them.
+const INHERITED_KEYS: [&[u8]; 4] = [b"Resources", b"MediaBox", b"CropBox", b"Rotate"];
+
+/// Keys pointing back up the page tree, or from an annotation to its page. Following them
+/// would make every page hash depend on every other page.
+const SKIPPED_KEYS: [&[u8]; 2] = [b"Parent", b"P"];
+
+/// Hashes a page with everything it draws: its content, its own and inherited attributes,
+/// and the objects they reference, such as fonts, images, form XObjects, and annotations
+/// with their appearance streams.
+struct PageHasher<'a> {
+    document: &'a Document,
+    hash: u64,
+    visited: HashSet<ObjectId>,
+}
+
+impl<'a> PageHasher<'a> {
+    fn new(document: &'a Document) -> Self {
+        Self {
+            document,
+            hash: FNV_OFFSET_BASIS,
+            visited: HashSet::new(),
+        }
+    }
+
+    fn write(&mut self, data: &[u8]) {
+        self.hash = fnv1a(self.hash, data);
+    }
+
+    /// Writes data prefixed with its length, so adjacent values cannot run together.
+    fn bytes(&mut self, data: &[u8]) {
+        self.write(&(data.len() as u64).to_le_bytes());
+        self.write(data);
+    }
+
+    fn page(&mut self, page_id: ObjectId, content: &[u8]) -> u64 {
+        self.bytes(content);
+        self.visited.insert(page_id);
+        let document = self.document;
+        if let Ok(page) = document.get_dictionary(page_id) {
+            self.dictionary(page);
+            for key in INHERITED_KEYS {
+                if page.has(key) {
+                    continue;
+                }
+                if let Some(value) = self.inherited(page, key) {
+                    self.bytes(key);
+                    self.object(value);
+                }
+            }
+        }
+        self.hash
+    }
+
+    /// Looks key up in the ancestors of page, nearest first.
+    fn inherited(&self, page: &'a Dictionary, key: &[u8]) -> Option<&'a Object> {
+        let mut node = page;
+        let mut seen = HashSet::new();
+        loop {
+            let parent = node.get(b"Parent").and_then(Object::as_reference).ok()?;
+            if !seen.insert(parent) {
+                return None;
+            }
+            node = self.document.get_dictionary(parent).ok()?;
+            if let Ok(value) = node.get(key) {
+                return Some(value);
+            }
+        }
+    }
+
+    fn object(&mut self, object: &Object) {
+        match object {
+            Object::Null => self.write(b"n"),
+            Object::Boolean(value) => self.write(if *value { b"t" } else { b"f" }),
+            Object::Integer(value) => {
+                self.write(b"i");
+                self.write(&value.to_le_bytes());
+            }
+            Object::Real(value) => {
+                self.write(b"r");
+                self.write(&value.to_le_bytes());
+            }
+            Object::Name(name) => {
+                self.write(b"/");
+                self.bytes(name);
+            }
+            Object::String(text, _) => {
+                self.write(b"(");
+                self.bytes(text);
+            }
+            Object::Array(items) => {
+                self.write(b"[");
+                self.write(&(items.len() as u64).to_le_bytes());
+                for item in items {
+                    self.object(item);
+                }
+            }
+            Object::Dictionary(dictionary) => self.dictionary(dictionary),
+            Object::Stream(stream) => {
+                self.write(b"s");
+                self.dictionary(&stream.dict);
+                self.bytes(&stream.content);
+            }
+            Object::Reference(id) => self.reference(*id),
+        }
+    }
+
+    /// Writes the entries of a dictionary sorted by key, so that rewriting a file without
+    /// changing it keeps its hashes.
+    fn dictionary(&mut self, dictionary: &Dictionary) {
+        let mut entries: Vec<(&Vec<u8>, &Object)> = dictionary
+            .iter()
+            .filter(|(key, _)| !SKIPPED_KEYS.contains(&key.as_slice()))
+            .collect();
+        entries.sort_by(|a, b| a.0.cmp(b.0));
+        self.write(b"<");
+        self.write(&(entries.len() as u64).to_le_bytes());
+        for (key, value) in entries {
+            self.bytes(key);
+            self.object(value);
+        }
+    }
+
+    /// Writes the object id refers to the first time it is met. Other pages, reached for
+    /// instance through link destinations, are not followed.
+    fn reference(&mut self, id: ObjectId) {
+        self.write(b"R");
+        if !self.visited.insert(id) {
+            return;
+        }
+        let document = self.document;
+        match document.get_object(id) {
+            Ok(Object::Dictionary(dictionary)) if is_page_node(dictionary) => self.write(b"p"),
+            Ok(object) => self.object(object),
+            Err(_) => self.write(b"?"),
+        }
+    }
+}
+
+fn is_page_node(dictionary: &Dictionary) -> bool {
+    matches!(
+        dictionary.get(b"Type").and_then(Object::as_name),
+        Ok(b"Page") | Ok(b"Pages")
+    )
+}
+
+fn page_hashes(pdf_bytes: &[u8]) -> Result<Vec<String>, String> {
+    let document = load_document(pdf_bytes)?;
+    document
+        .get_pages()
+        .iter()
+        .map(|(page_number, page_id)| {
+            let content = document
+                .get_page_content(*page_id)
+                .map_err(|e| format!("Failed to read content of page {}: {}", page_number, e))?;
+            let hash = PageHasher::new(&document).page(*page_id, &content);
+            Ok(format!("{:016x}", hash))
+        })
+        .collect()
+}
+
+/// Structural facts about a PDF, gathered without extracting it.
+#[derive(Debug, Serialize)]
+struct PdfProbe {
+    page_count: usize,
+    /// Pages whose content streams show text. The others need OCR to yield text.
+    text_pages: usize,
+    encrypted: bool,
+}
+
+/// Text-showing operators of PDF content streams.
+const TEXT_OPERATORS: [&str; 4] = ["Tj", "TJ", "'", "\""];
+
+fn probe(pdf_bytes: &[u8]) -> Result<PdfProbe, String> {
+    let document = load_document(pdf_bytes)?;
+    let pages = document.get_pages();
+    let text_pages = pages
+        .values()
+        .filter(|page_id| {
+            document
+                .get_page_content(**page_id)
+                .ok()
+                .and_then(|content| Content::decode(&content).ok())
+                .is_some_and(|content| {
+                    content
+                        .operations
+                        .iter()
+                        .any(|operation| TEXT_OPERATORS.contains(&operation.operator.as_str()))
+                })
+        })
+        .count();
+    Ok(PdfProbe {
+        page_count: pages.len(),
+        text_pages,
+        encrypted: document.is_encrypted(),
+    })
+}
+
+fn select_pages(pdf_bytes: &[u8], keep: &[u32]) -> Result<Vec<u8>, String> {
+    let mut document = load_document(pdf_bytes)?;
+    let pages = document.get_pages();
+    if let Some(missing) = keep.iter().find(|page| !pages.contains_key(*page)) {
+        return Err(format!("Page {} not found", missing));
+    }
+    let delete: Vec<u32> = pages.keys().copied().filter(|page| !keep.contains(page)).collect();
+    document.delete_pages(&delete);
+    document.prune_objects();
+
+    let mut output = Vec::new();
+    document
+        .save_to(&mut output)
+        .map_err(|e| format!("Failed to write PDF: {}", e))?;
+    Ok(output)
+}
+
+/// Fingerprint every page of a PDF.
+///
+/// Returns a JSON array with one hex-encoded 64-bit hash per page, in page order. A page
+/// hash covers its content streams, its attributes including inherited ones, and the
+/// objects they reference, such as fonts, images, form XObjects, and annotations.
+///
+/// # Safety
+///
+/// - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_pdf_page_hashes(pdf_bytes: *const u8, len: usize) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_pdf_page_hashes", {
+        clear_last_error();
+
+        if pdf_bytes.is_null() {
+            set_last_error("pdf_bytes cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let slice = unsafe { std::slice::from_raw_parts(pdf_bytes, len) };
+
+        let json = page_hashes(slice).and_then(|hashes| {
+            serde_json::to_string(&hashes).map_err(|e| format!("Failed to serialize hashes: {}", e))
+        });
+        match json.and_then(string_to_c_string) {
+            Ok(ptr) => ptr,
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Probe a PDF without extracting it.
+///
+/// Returns a JSON object with `page_count`, `text_pages` (pages whose content streams
+/// show text), and `encrypted`.
+///
+/// # Safety
+///
+/// - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_pdf_probe(pdf_bytes: *const u8, len: usize) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_pdf_probe", {
+        clear_last_error();
+
+        if pdf_bytes.is_null() {
+            set_last_error("pdf_bytes cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let slice = unsafe { std::slice::from_raw_parts(pdf_bytes, len) };
+
+        let json = probe(slice)
+            .and_then(|probe| serde_json::to_string(&probe).map_err(|e| format!("Failed to serialize probe: {}", e)));
+        match json.and_then(string_to_c_string) {
+            Ok(ptr) => ptr,
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Build a PDF holding only the given pages of another PDF.
+///
+/// `pages_json` is a JSON array of 1-indexed page numbers. Returns the new PDF encoded as
+/// base64.
+///
+/// # Safety
+///
+/// - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+/// - `pages_json` must be a valid null-terminated C string
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_pdf_select_pages(
+    pdf_bytes: *const u8,
+    len: usize,
+    pages_json: *const c_char,
+) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_pdf_select_pages", {
+        clear_last_error();
+
+        if pdf_bytes.is_null() || pages_json.is_null() {
+            set_last_error("pdf_bytes and pages_json cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let pages_str = match unsafe { CStr::from_ptr(pages_json) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in pages: {}", e));
+                return ptr::null_mut();
+            }
+        };
+        let keep: Vec<u32> = match serde_json::from_str(pages_str) {
+            Ok(keep) => keep,
+            Err(e) => {
+                set_last_error(format!("Failed to parse pages JSON: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        let slice = unsafe { std::slice::from_raw_parts(pdf_bytes, len) };
+
+        match select_pages(slice, &keep).and_then(|pdf| string_to_c_string(STANDARD.encode(pdf))) {
+            Ok(ptr) => ptr,
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::ffi::CString;
+
+    #[test]
+    fn test_fnv1a_is_stable() {
+        assert_eq!(fnv1a(FNV_OFFSET_BASIS, b""), FNV_OFFSET_BASIS);
+        assert_eq!(fnv1a(FNV_OFFSET_BASIS, b"a"), 0xaf63dc4c8601ec8c);
+    }
+
+    /// Builds a one-page PDF drawing an image XObject holding image_data.
+    fn image_pdf(image_data: &[u8]) -> Vec<u8> {
+        use lopdf::{Stream, dictionary};
+
+        let mut document = Document::with_version("1.5");
+        let pages_id = document.new_object_id();
+        let image_id = document.add_object(Stream::new(
+            dictionary! {
+                "Type" => "XObject",
+                "Subtype" => "Image",
+                "Width" => 1,
+                "Height" => 1,
+                "ColorSpace" => "DeviceGray",
+                "BitsPerComponent" => 8,
+            },
+            image_data.to_vec(),
+        ));
+        let content_id = document.add_object(Stream::new(dictionary! {}, b"q 10 0 0 10 0 0 cm /Im0 Do Q".to_vec()));
+        let page_id = document.add_object(dictionary! {
+            "Type" => "Page",
+            "Parent" => pages_id,
+            "Contents" => content_id,
+        });
+        document.objects.insert(
+            pages_id,
+            Object::Dictionary(dictionary! {
+                "Type" => "Pages",
+                "Kids" => vec![page_id.into()],
+                "Count" => 1,
+                "MediaBox" => vec![0.into(), 0.into(), 100.into(), 100.into()],
+                "Resources" => dictionary! { "XObject" => dictionary! { "Im0" => image_id } },
+            }),
+        );
+        let catalog_id = document.add_object(dictionary! { "Type" => "Catalog", "Pages" => pages_id });
+        document.trailer.set("Root", catalog_id);
+
+        let mut output = Vec::new();
+        document.save_to(&mut output).unwrap();
+        output
+    }
+
+    #[test]
+    fn test_page_hashes_cover_inherited_resources() {
+        let before = page_hashes(&image_pdf(&[0])).unwrap();
+        assert_eq!(before, page_hashes(&image_pdf(&[0])).unwrap());
+        assert_ne!(before, page_hashes(&image_pdf(&[255])).unwrap());
+    }
+
+    #[test]
+    fn test_pdf_page_hashes_invalid_pdf() {
+        let data = b"not a pdf";
+        let result = unsafe { kreuzberg_pdf_page_hashes(data.as_ptr(), data.len()) };
+        assert!(result.is_null());
+    }
+
+    #[test]
+    fn test_pdf_probe_null_bytes() {
+        let result = unsafe { kreuzberg_pdf_probe(ptr::null(), 0) };
+        assert!(result.is_null());
+    }
+
+    #[test]
+    fn test_pdf_select_pages_null_pages() {
+        let data = b"%PDF-1.4\n";
+        let result = unsafe { kreuzberg_pdf_select_pages(data.as_ptr(), data.len(), ptr::null()) };
+        assert!(result.is_null());
+
+        let pages = CString::new("not json").unwrap();
+        let result = unsafe { kreuzberg_pdf_select_pages(data.as_ptr(), data.len(), pages.as_ptr()) };
+        assert!(result.is_null());
+    }
+}
@@ -405,6 +405,64 @@ pub unsafe extern "C" fn kreuzberg_list_document_extractors() -> *mut c_char {
     })
 }
 
+/// Name the DocumentExtractor that would handle a MIME type.
+///
+/// Built-in extractors are registered first if no extraction has run yet.
+///
+/// # Safety
+///
+/// - `mime_type` must be a valid null-terminated C string
+/// - Returned string must be freed with `kreuzberg_free_string`.
+/// - Returns NULL on error, including when no extractor supports the MIME type (check `kreuzberg_last_error`).
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_document_extractor_for_mime(mime_type: *const c_char) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_document_extractor_for_mime", {
+        clear_last_error();
+
+        if mime_type.is_null() {
+            set_last_error("MIME type cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let mime_str = match unsafe { CStr::from_ptr(mime_type) }.to_str() {
+            Ok(s) => s,
+            Err(e) => {
+                set_last_error(format!("Invalid UTF-8 in MIME type: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        if let Err(e) = kreuzberg::extractors::ensure_initialized() {
+            set_last_error(format!("Failed to register built-in extractors: {}", e));
+            return ptr::null_mut();
+        }
+
+        let registry = kreuzberg::plugins::registry::get_document_extractor_registry();
+        let registry_guard = match registry.read() {
+            Ok(guard) => guard,
+            Err(e) => {
+                // ~keep: Lock poisoning indicates a panic in another thread holding the lock.
+                set_last_error(format!("Failed to acquire registry read lock: {}", e));
+                return ptr::null_mut();
+            }
+        };
+
+        match registry_guard.get(mime_str) {
+            Ok(extractor) => match CString::new(extractor.name()) {
+                Ok(cstr) => cstr.into_raw(),
+                Err(e) => {
+                    set_last_error(format!("Failed to create C string: {}", e));
+                    ptr::null_mut()
+                }
+            },
+            Err(e) => {
+                set_last_error(e.to_string());
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
 /// Clear all registered DocumentExtractors.
 ///
 /// # Safety
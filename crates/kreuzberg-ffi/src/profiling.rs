@@ -0,0 +1,181 @@
+//! Native CPU profiling for FFI.
+//!
+//! Bindings profile their own code with their runtime's profiler, which sees the core as a
+//! single opaque call. With the `profiling` feature, these functions sample the process
+//! with pprof-rs instead, resolving the core's Rust frames.
+//!
+//! The profiler samples on SIGPROF. The handler installed before a profile starts is
+//! restored when it stops, so a host runtime's profiler works again afterwards, but the two
+//! must not run at the same time.
+
+use std::ptr;
+
+use crate::helpers::{clear_last_error, set_last_error};
+use crate::{ffi_panic_guard, ffi_panic_guard_i32};
+
+/// Folded stacks: one `thread;outer;...;inner count` line per sampled stack.
+pub const PROFILE_FORMAT_FOLDED: i32 = 0;
+/// An SVG flame graph.
+pub const PROFILE_FORMAT_FLAMEGRAPH: i32 = 1;
+
+#[cfg(all(feature = "profiling", unix))]
+mod sampler {
+    use std::fmt::Write;
+    use std::ptr;
+    use std::sync::Mutex;
+
+    use super::{PROFILE_FORMAT_FLAMEGRAPH, PROFILE_FORMAT_FOLDED};
+
+    struct Session {
+        guard: pprof::ProfilerGuard<'static>,
+        previous: libc::sigaction,
+    }
+
+    // SAFETY: the guard only refers to pprof's global profiler, and `sigaction` is plain data.
+    unsafe impl Send for Session {}
+
+    static SESSION: Mutex<Option<Session>> = Mutex::new(None);
+
+    pub fn start(frequency: i32) -> Result<(), String> {
+        let mut session = SESSION.lock().map_err(|_| "Profiler state is poisoned".to_string())?;
+        if session.is_some() {
+            return Err("A native profile is already being captured".to_string());
+        }
+        let mut previous: libc::sigaction = unsafe { std::mem::zeroed() };
+        unsafe { libc::sigaction(libc::SIGPROF, ptr::null(), &mut previous) };
+        let guard = pprof::ProfilerGuardBuilder::default()
+            .frequency(frequency.clamp(1, 10000))
+            .blocklist(&["libc", "libpthread", "libgcc", "libm"])
+            .build()
+            .map_err(|e| format!("Failed to start profiler: {}", e))?;
+        *session = Some(Session { guard, previous });
+        Ok(())
+    }
+
+    pub fn stop(format: i32) -> Result<Vec<u8>, String> {
+        let Session { guard, previous } = SESSION
+            .lock()
+            .map_err(|_| "Profiler state is poisoned".to_string())?
+            .take()
+            .ok_or_else(|| "No native profile is being captured".to_string())?;
+        let report = guard.report().build();
+        drop(guard);
+        unsafe { libc::sigaction(libc::SIGPROF, &previous, ptr::null_mut()) };
+        let report = report.map_err(|e| format!("Failed to build profile: {}", e))?;
+
+        match format {
+            PROFILE_FORMAT_FOLDED => {
+                let mut folded = String::new();
+                for (frames, count) in &report.data {
+                    folded.push_str(&frames.thread_name);
+                    for symbol in frames.frames.iter().rev().flat_map(|frame| frame.iter().rev()) {
+                        folded.push(';');
+                        folded.push_str(&symbol.name());
+                    }
+                    let _ = writeln!(folded, " {}", count);
+                }
+                Ok(folded.into_bytes())
+            }
+            PROFILE_FORMAT_FLAMEGRAPH => {
+                let mut svg = Vec::new();
+                report.flamegraph(&mut svg).map_err(|e| format!("Failed to render flame graph: {}", e))?;
+                Ok(svg)
+            }
+            _ => Err(format!("Unknown profile format {}", format)),
+        }
+    }
+}
+
+#[cfg(not(all(feature = "profiling", unix)))]
+mod sampler {
+    const UNAVAILABLE: &str = "Native profiling requires a Unix build of kreuzberg-ffi with the `profiling` feature";
+
+    pub fn start(_frequency: i32) -> Result<(), String> {
+        Err(UNAVAILABLE.to_string())
+    }
+
+    pub fn stop(_format: i32) -> Result<Vec<u8>, String> {
+        Err(UNAVAILABLE.to_string())
+    }
+}
+
+/// Start sampling the CPU time of the process at `frequency` Hz.
+///
+/// Returns 0 on success and -1 on error (check `kreuzberg_last_error` for details), including
+/// when a profile is already being captured or the library was built without the
+/// `profiling` feature.
+#[unsafe(no_mangle)]
+pub extern "C" fn kreuzberg_profiling_start(frequency: i32) -> i32 {
+    ffi_panic_guard_i32!("kreuzberg_profiling_start", {
+        clear_last_error();
+        match sampler::start(frequency) {
+            Ok(()) => 0,
+            Err(e) => {
+                set_last_error(e);
+                -1
+            }
+        }
+    })
+}
+
+/// Stop the profile started with `kreuzberg_profiling_start` and return it encoded in
+/// `format`, `PROFILE_FORMAT_FOLDED` (0) or `PROFILE_FORMAT_FLAMEGRAPH` (1).
+///
+/// # Safety
+///
+/// - `out_len` must be a valid pointer; it receives the length of the returned buffer
+/// - The returned buffer must be freed with `kreuzberg_free_bytes`
+/// - Returns NULL on error (check `kreuzberg_last_error` for details)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_profiling_stop(format: i32, out_len: *mut usize) -> *mut u8 {
+    ffi_panic_guard!("kreuzberg_profiling_stop", {
+        clear_last_error();
+
+        if out_len.is_null() {
+            set_last_error("out_len cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        match sampler::stop(format) {
+            Ok(profile) => {
+                let boxed = profile.into_boxed_slice();
+                unsafe { *out_len = boxed.len() };
+                Box::into_raw(boxed) as *mut u8
+            }
+            Err(e) => {
+                set_last_error(e);
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[cfg(not(all(feature = "profiling", unix)))]
+    #[test]
+    fn test_profiling_unavailable() {
+        assert_eq!(kreuzberg_profiling_start(1000), -1);
+        let mut len = 0usize;
+        assert!(unsafe { kreuzberg_profiling_stop(PROFILE_FORMAT_FOLDED, &mut len) }.is_null());
+    }
+
+    #[cfg(all(feature = "profiling", unix))]
+    #[test]
+    fn test_profile_round_trip() {
+        assert_eq!(kreuzberg_profiling_start(1000), 0);
+        assert_eq!(kreuzberg_profiling_start(1000), -1);
+        let mut sum = 0u64;
+        for i in 0..20_000_000u64 {
+            sum = sum.wrapping_add(i * i);
+        }
+        assert!(sum > 0);
+        let mut len = 0usize;
+        let profile = unsafe { kreuzberg_profiling_stop(PROFILE_FORMAT_FOLDED, &mut len) };
+        assert!(!profile.is_null());
+        unsafe { crate::kreuzberg_free_bytes(profile, len) };
+        assert!(unsafe { kreuzberg_profiling_stop(PROFILE_FORMAT_FOLDED, &mut len) }.is_null());
+    }
+}
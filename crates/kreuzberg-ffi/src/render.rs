@@ -0,0 +1,478 @@
+//! Page rendering functions for FFI.
+//!
+//! This module exposes the core PDF renderer so bindings can produce page previews
+//! without shipping a second PDF library. Presentations are converted to PDF with
+//! LibreOffice first. The text layer of PDF pages can also be read on its own, without
+//! rasterizing them.
+
+use crate::ffi_panic_guard;
+use crate::helpers::{clear_last_error, set_last_error, string_to_c_string};
+use base64::Engine;
+use base64::engine::general_purpose::STANDARD;
+use image::ImageFormat;
+use kreuzberg::core::mime::{LEGACY_POWERPOINT_MIME_TYPE, POWER_POINT_MIME_TYPE};
+use kreuzberg::extraction::convert_office_doc_to_pdf;
+use kreuzberg::pdf::PdfError;
+use kreuzberg::pdf::rendering::{PageRenderOptions, PdfRenderer};
+use serde::{Deserialize, Serialize};
+use std::ffi::CStr;
+use std::io::Cursor;
+use std::os::raw::c_char;
+use std::ptr;
+
+const PDF_POINTS_PER_INCH: f64 = 72.0;
+/// Highest resolution a render request may ask for.
+const MAX_RENDER_DPI: i32 = 600;
+/// Largest width or height of a rendered page, in pixels. Pages that would exceed it at
+/// the requested resolution are rejected rather than rendered at a lower one.
+const MAX_RENDER_DIMENSION: i32 = 10_000;
+
+/// Rendering options accepted by `kreuzberg_render_pdf_pages`.
+#[derive(Debug, Deserialize)]
+#[serde(default)]
+struct RenderRequest {
+    /// First page to render (1-indexed).
+    first_page: usize,
+    /// Last page to render (1-indexed, inclusive). Renders to the end when absent.
+    last_page: Option<usize>,
+    dpi: i32,
+    /// Output encoding: "png" or "jpeg".
+    format: String,
+    password: Option<String>,
+    /// Include the positioned words of each page.
+    text_layer: bool,
+    /// MIME type of the document: PDF when absent, or a PowerPoint presentation.
+    mime_type: Option<String>,
+}
+
+impl Default for RenderRequest {
+    fn default() -> Self {
+        Self {
+            first_page: 1,
+            last_page: None,
+            dpi: 72,
+            format: "png".to_string(),
+            password: None,
+            text_layer: false,
+            mime_type: None,
+        }
+    }
+}
+
+/// A rendered page. `data` holds the encoded image as base64.
+#[derive(Debug, Serialize)]
+struct RenderedPage {
+    page_number: usize,
+    width: u32,
+    height: u32,
+    format: String,
+    data: String,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    words: Option<Vec<RenderedWord>>,
+}
+
+/// A word of the text layer, in pixels of the rendered image measured from its top-left corner.
+#[derive(Debug, Serialize)]
+struct RenderedWord {
+    text: String,
+    left: f64,
+    top: f64,
+    width: f64,
+    height: f64,
+}
+
+/// Options accepted by `kreuzberg_pdf_text_layer`.
+#[derive(Debug, Deserialize)]
+#[serde(default)]
+struct TextLayerRequest {
+    /// First page to read (1-indexed).
+    first_page: usize,
+    /// Last page to read (1-indexed, inclusive). Reads to the end when absent.
+    last_page: Option<usize>,
+    /// Resolution the word positions and page sizes are scaled to.
+    dpi: i32,
+    password: Option<String>,
+}
+
+impl Default for TextLayerRequest {
+    fn default() -> Self {
+        Self {
+            first_page: 1,
+            last_page: None,
+            dpi: 72,
+            password: None,
+        }
+    }
+}
+
+/// The text layer of a page, sized as the page would be rendered at the requested resolution.
+#[derive(Debug, Serialize)]
+struct TextLayerPage {
+    page_number: usize,
+    width: u32,
+    height: u32,
+    words: Vec<RenderedWord>,
+}
+
+fn render_pages(pdf_bytes: &[u8], request: &RenderRequest) -> Result<Vec<RenderedPage>, String> {
+    let format = match request.format.as_str() {
+        "png" => ImageFormat::Png,
+        "jpeg" | "jpg" => ImageFormat::Jpeg,
+        other => return Err(format!("Unsupported render format: {}", other)),
+    };
+    if request.first_page == 0 {
+        return Err("first_page must be at least 1".to_string());
+    }
+    if request.dpi <= 0 || request.dpi > MAX_RENDER_DPI {
+        return Err(format!(
+            "dpi must be between 1 and {}, got {}",
+            MAX_RENDER_DPI, request.dpi
+        ));
+    }
+
+    let converted;
+    let pdf_bytes = match request.mime_type.as_deref() {
+        None | Some("application/pdf") => pdf_bytes,
+        Some(POWER_POINT_MIME_TYPE) => {
+            converted = presentation_to_pdf(pdf_bytes, "pptx")?;
+            converted.as_slice()
+        }
+        Some(LEGACY_POWERPOINT_MIME_TYPE) => {
+            converted = presentation_to_pdf(pdf_bytes, "ppt")?;
+            converted.as_slice()
+        }
+        Some(other) => return Err(format!("Page rendering is not supported for {}", other)),
+    };
+
+    let renderer = PdfRenderer::new().map_err(|e| e.to_string())?;
+    let options = PageRenderOptions {
+        target_dpi: request.dpi,
+        max_image_dimension: MAX_RENDER_DIMENSION,
+        auto_adjust_dpi: false,
+        ..Default::default()
+    };
+
+    let mut pages = Vec::new();
+    let mut page_number = request.first_page;
+    while request.last_page.is_none_or(|last| page_number <= last) {
+        let image = match renderer.render_page_to_image_with_password(
+            pdf_bytes,
+            page_number - 1,
+            &options,
+            request.password.as_deref(),
+        ) {
+            Ok(image) => image,
+            Err(PdfError::PageNotFound(_)) if request.last_page.is_none() && page_number > 1 => break,
+            Err(e) => return Err(e.to_string()),
+        };
+
+        let words = if request.text_layer {
+            let scale = request.dpi as f64 / PDF_POINTS_PER_INCH;
+            let words = renderer
+                .page_words_with_password(pdf_bytes, page_number - 1, request.password.as_deref())
+                .map_err(|e| e.to_string())?;
+            Some(
+                words
+                    .into_iter()
+                    .map(|word| RenderedWord {
+                        text: word.text,
+                        left: word.left as f64 * scale,
+                        top: word.top as f64 * scale,
+                        width: word.width as f64 * scale,
+                        height: word.height as f64 * scale,
+                    })
+                    .collect(),
+            )
+        } else {
+            None
+        };
+
+        let mut encoded = Cursor::new(Vec::new());
+        image
+            .write_to(&mut encoded, format)
+            .map_err(|e| format!("Failed to encode page {}: {}", page_number, e))?;
+
+        pages.push(RenderedPage {
+            page_number,
+            width: image.width(),
+            height: image.height(),
+            format: request.format.clone(),
+            data: STANDARD.encode(encoded.into_inner()),
+            words,
+        });
+        page_number += 1;
+    }
+
+    Ok(pages)
+}
+
+fn text_layer_pages(pdf_bytes: &[u8], request: &TextLayerRequest) -> Result<Vec<TextLayerPage>, String> {
+    if request.first_page == 0 {
+        return Err("first_page must be at least 1".to_string());
+    }
+    if request.last_page.is_some_and(|last| last < request.first_page) {
+        return Err("last_page must not be before first_page".to_string());
+    }
+    if request.dpi <= 0 || request.dpi > MAX_RENDER_DPI {
+        return Err(format!(
+            "dpi must be between 1 and {}, got {}",
+            MAX_RENDER_DPI, request.dpi
+        ));
+    }
+
+    let renderer = PdfRenderer::new().map_err(|e| e.to_string())?;
+    let layers = renderer
+        .text_layer_with_password(
+            pdf_bytes,
+            request.first_page - 1,
+            request.last_page.map(|last| last - 1),
+            request.password.as_deref(),
+        )
+        .map_err(|e| e.to_string())?;
+
+    let scale = request.dpi as f64 / PDF_POINTS_PER_INCH;
+    Ok(layers
+        .into_iter()
+        .map(|layer| TextLayerPage {
+            page_number: layer.page_index + 1,
+            width: ((layer.width as f64 * scale) as u32).max(1),
+            height: ((layer.height as f64 * scale) as u32).max(1),
+            words: layer
+                .words
+                .into_iter()
+                .map(|word| RenderedWord {
+                    text: word.text,
+                    left: word.left as f64 * scale,
+                    top: word.top as f64 * scale,
+                    width: word.width as f64 * scale,
+                    height: word.height as f64 * scale,
+                })
+                .collect(),
+        })
+        .collect())
+}
+
+/// Convert a PowerPoint presentation to PDF with LibreOffice.
+fn presentation_to_pdf(bytes: &[u8], extension: &str) -> Result<Vec<u8>, String> {
+    let runtime = tokio::runtime::Runtime::new().map_err(|e| format!("Failed to create runtime: {}", e))?;
+    runtime
+        .block_on(convert_office_doc_to_pdf(bytes, extension))
+        .map_err(|e| e.to_string())
+}
+
+/// Render PDF pages to PNG or JPEG images.
+///
+/// `options_json` is a JSON object with optional fields `first_page` (1-indexed, default 1),
+/// `last_page` (inclusive, default: last page), `dpi` (1 to 600, default 72), `format` ("png"
+/// or "jpeg", default "png"), `password`, `text_layer` (default false) and `mime_type`.
+/// With `mime_type` set to a PowerPoint type (PPTX or PPT), the document is converted to
+/// PDF with LibreOffice first. Pages wider or taller than 10000 pixels at the requested
+/// resolution fail the call. The result is a JSON array of objects with `page_number`,
+/// `width`, `height`, `format`, and base64-encoded `data`. With `text_layer`, each page also
+/// has `words`: objects with `text`, `left`, `top`, `width`, and `height` in pixels of the
+/// image, measured from its top-left corner.
+///
+/// # Safety
+///
+/// - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+/// - `options_json` must be a valid null-terminated C string or NULL for defaults
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+///
+/// # Example (C)
+///
+/// ```c
+/// char* pages = kreuzberg_render_pdf_pages(data, len, "{\"last_page\": 1, \"dpi\": 96}");
+/// if (pages != NULL) {
+///     printf("Rendered: %s\n", pages);
+///     kreuzberg_free_string(pages);
+/// }
+/// ```
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_render_pdf_pages(
+    pdf_bytes: *const u8,
+    len: usize,
+    options_json: *const c_char,
+) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_render_pdf_pages", {
+        clear_last_error();
+
+        if pdf_bytes.is_null() {
+            set_last_error("pdf_bytes cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let request = if options_json.is_null() {
+            RenderRequest::default()
+        } else {
+            let options_str = match unsafe { CStr::from_ptr(options_json) }.to_str() {
+                Ok(s) => s,
+                Err(e) => {
+                    set_last_error(format!("Invalid UTF-8 in render options: {}", e));
+                    return ptr::null_mut();
+                }
+            };
+            match serde_json::from_str::<RenderRequest>(options_str) {
+                Ok(request) => request,
+                Err(e) => {
+                    set_last_error(format!("Failed to parse render options JSON: {}", e));
+                    return ptr::null_mut();
+                }
+            }
+        };
+
+        let slice = unsafe { std::slice::from_raw_parts(pdf_bytes, len) };
+
+        let pages = match render_pages(slice, &request) {
+            Ok(pages) => pages,
+            Err(e) => {
+                set_last_error(e);
+                return ptr::null_mut();
+            }
+        };
+
+        match serde_json::to_string(&pages) {
+            Ok(json) => match string_to_c_string(json) {
+                Ok(ptr) => ptr,
+                Err(e) => {
+                    set_last_error(e);
+                    ptr::null_mut()
+                }
+            },
+            Err(e) => {
+                set_last_error(format!("Failed to serialize rendered pages: {}", e));
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+/// Read the text layer of PDF pages without rendering them.
+///
+/// `options_json` is a JSON object with optional fields `first_page` (1-indexed, default 1),
+/// `last_page` (inclusive, default: last page), `dpi` (1 to 600, default 72) and `password`.
+/// The result is a JSON array of objects with `page_number`, `width`, `height`, and `words`,
+/// sized and positioned as `kreuzberg_render_pdf_pages` reports them at the same `dpi` with
+/// `text_layer`, but without the image.
+///
+/// # Safety
+///
+/// - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+/// - `options_json` must be a valid null-terminated C string or NULL for defaults
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub unsafe extern "C" fn kreuzberg_pdf_text_layer(
+    pdf_bytes: *const u8,
+    len: usize,
+    options_json: *const c_char,
+) -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_pdf_text_layer", {
+        clear_last_error();
+
+        if pdf_bytes.is_null() {
+            set_last_error("pdf_bytes cannot be NULL".to_string());
+            return ptr::null_mut();
+        }
+
+        let request = if options_json.is_null() {
+            TextLayerRequest::default()
+        } else {
+            let options_str = match unsafe { CStr::from_ptr(options_json) }.to_str() {
+                Ok(s) => s,
+                Err(e) => {
+                    set_last_error(format!("Invalid UTF-8 in text layer options: {}", e));
+                    return ptr::null_mut();
+                }
+            };
+            match serde_json::from_str::<TextLayerRequest>(options_str) {
+                Ok(request) => request,
+                Err(e) => {
+                    set_last_error(format!("Failed to parse text layer options JSON: {}", e));
+                    return ptr::null_mut();
+                }
+            }
+        };
+
+        let slice = unsafe { std::slice::from_raw_parts(pdf_bytes, len) };
+
+        let pages = match text_layer_pages(slice, &request) {
+            Ok(pages) => pages,
+            Err(e) => {
+                set_last_error(e);
+                return ptr::null_mut();
+            }
+        };
+
+        match serde_json::to_string(&pages) {
+            Ok(json) => match string_to_c_string(json) {
+                Ok(ptr) => ptr,
+                Err(e) => {
+                    set_last_error(e);
+                    ptr::null_mut()
+                }
+            },
+            Err(e) => {
+                set_last_error(format!("Failed to serialize text layer: {}", e));
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use std::ffi::CString;
+
+    #[test]
+    fn test_render_pdf_pages_null_bytes() {
+        let result = unsafe { kreuzberg_render_pdf_pages(ptr::null(), 0, ptr::null()) };
+        assert!(result.is_null());
+    }
+
+    #[test]
+    fn test_render_pdf_pages_invalid_options() {
+        let data = b"%PDF-1.4\n";
+        let options = CString::new("{\"format\": \"gif\"}").unwrap();
+        let result = unsafe { kreuzberg_render_pdf_pages(data.as_ptr(), data.len(), options.as_ptr()) };
+        assert!(result.is_null());
+    }
+
+    #[test]
+    fn test_render_pages_rejects_excessive_dpi() {
+        let request = RenderRequest {
+            dpi: MAX_RENDER_DPI + 1,
+            ..Default::default()
+        };
+        let err = render_pages(b"%PDF-1.4\n", &request).unwrap_err();
+        assert!(err.contains("dpi"), "{}", err);
+    }
+
+    #[test]
+    fn test_render_pages_rejects_unsupported_mime_type() {
+        let request = RenderRequest {
+            mime_type: Some("text/plain".to_string()),
+            ..Default::default()
+        };
+        let err = render_pages(b"plain text", &request).unwrap_err();
+        assert!(err.contains("text/plain"), "{}", err);
+    }
+
+    #[test]
+    fn test_pdf_text_layer_null_bytes() {
+        let result = unsafe { kreuzberg_pdf_text_layer(ptr::null(), 0, ptr::null()) };
+        assert!(result.is_null());
+    }
+
+    #[test]
+    fn test_text_layer_pages_rejects_inverted_range() {
+        let request = TextLayerRequest {
+            first_page: 3,
+            last_page: Some(2),
+            ..Default::default()
+        };
+        let err = text_layer_pages(b"%PDF-1.4\n", &request).unwrap_err();
+        assert!(err.contains("last_page"), "{}", err);
+    }
+}
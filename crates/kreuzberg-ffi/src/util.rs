@@ -1,16 +1,21 @@
 //! Utility functions for version and error reporting.
 //!
 //! This module provides FFI functions for:
-//! - Getting the library version
+//! - Getting the library version, ABI revision and enabled features
 //! - Retrieving error information (message, code, panic context)
 
 use crate::ffi_panic_guard;
-use crate::helpers::LAST_ERROR_C_STRING;
-use crate::panic_shield::{get_last_error_code, get_last_panic_context};
+use crate::helpers::{LAST_ERROR_C_STRING, set_last_error};
+use crate::panic_shield::{get_last_error_code, get_last_panic_backtrace, get_last_panic_context};
 use std::ffi::CString;
 use std::os::raw::c_char;
 use std::ptr;
 
+/// Revision of the C API. It is bumped whenever a function changes signature or ownership
+/// rules, or bindings start relying on a new function, so that a binding can refuse a
+/// library older than the header it was built against.
+pub const KREUZBERG_ABI_VERSION: u32 = 2;
+
 /// Get the last error message from a failed operation.
 ///
 /// # Safety
@@ -76,6 +81,7 @@ pub unsafe extern "C" fn kreuzberg_last_error_code() -> i32 {
 /// - function: Name of the function that panicked
 /// - message: Panic message
 /// - timestamp_secs: Unix timestamp when panic occurred
+/// - backtrace: Backtrace of the panicking thread, or null if unavailable
 ///
 /// # Safety
 ///
@@ -112,7 +118,8 @@ pub unsafe extern "C" fn kreuzberg_last_panic_context() -> *mut c_char {
                     "line": ctx.line,
                     "function": ctx.function,
                     "message": ctx.message,
-                    "timestamp_secs": timestamp_secs
+                    "timestamp_secs": timestamp_secs,
+                    "backtrace": get_last_panic_backtrace()
                 });
 
                 match serde_json::to_string(&json_value) {
@@ -146,10 +153,52 @@ pub unsafe extern "C" fn kreuzberg_version() -> *const c_char {
     concat!(env!("CARGO_PKG_VERSION"), "\0").as_ptr() as *const c_char
 }
 
+/// Get the version, ABI revision and enabled features of the library as a JSON object,
+/// for bindings to check at initialization that they can use it:
+///
+/// ```json
+/// {"version": "4.2.2", "abi_version": 2, "features": ["pdf", "embeddings"]}
+/// ```
+///
+/// # Safety
+///
+/// - The returned string must be freed with `kreuzberg_free_string`
+/// - Returns NULL on error (check `kreuzberg_last_error`)
+#[unsafe(no_mangle)]
+pub extern "C" fn kreuzberg_build_info() -> *mut c_char {
+    ffi_panic_guard!("kreuzberg_build_info", {
+        let features: Vec<&str> = [
+            ("embeddings", cfg!(feature = "embeddings")),
+            ("rayon", cfg!(feature = "rayon")),
+            ("pdf", cfg!(feature = "pdf")),
+            ("keywords-yake", cfg!(feature = "keywords-yake")),
+            ("keywords-rake", cfg!(feature = "keywords-rake")),
+            ("profiling", cfg!(feature = "profiling")),
+            ("fault-injection", cfg!(feature = "fault-injection")),
+        ]
+        .into_iter()
+        .filter_map(|(name, enabled)| enabled.then_some(name))
+        .collect();
+
+        let info = serde_json::json!({
+            "version": env!("CARGO_PKG_VERSION"),
+            "abi_version": KREUZBERG_ABI_VERSION,
+            "features": features,
+        });
+        match CString::new(info.to_string()) {
+            Ok(c_str) => c_str.into_raw(),
+            Err(e) => {
+                set_last_error(format!("Failed to create C string: {}", e));
+                ptr::null_mut()
+            }
+        }
+    })
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
-    use crate::helpers::{clear_last_error, set_last_error};
+    use crate::helpers::clear_last_error;
     use std::ffi::CStr;
 
     #[test]
@@ -163,6 +212,18 @@ mod tests {
         assert!(version_str.contains('.'));
     }
 
+    #[test]
+    fn test_build_info() {
+        let info = kreuzberg_build_info();
+        assert!(!info.is_null());
+        let json: serde_json::Value = serde_json::from_str(unsafe { CStr::from_ptr(info) }.to_str().unwrap()).unwrap();
+        unsafe { crate::kreuzberg_free_string(info) };
+
+        assert_eq!(json["version"], env!("CARGO_PKG_VERSION"));
+        assert_eq!(json["abi_version"], KREUZBERG_ABI_VERSION);
+        assert!(json["features"].is_array());
+    }
+
     #[test]
     fn test_last_error_null_when_no_error() {
         clear_last_error();
@@ -9,6 +9,7 @@
 //! All validator functions return:
 //! - `1` if the value is valid
 //! - `0` if the value is invalid (with error message set via `set_last_error()`)
+//! - `-1` if validation panicked (with the panic recorded as the last error)
 //!
 //! # String Functions
 //!
@@ -41,6 +42,7 @@ use kreuzberg::core::config_validation::{
 };
 
 use crate::set_last_error;
+use crate::{ffi_panic_guard, ffi_panic_guard_i32};
 
 const VALID_BINARIZATION_METHODS: &[&str] = &["otsu", "adaptive", "sauvola"];
 const VALID_TOKEN_REDUCTION_LEVELS: &[&str] = &["off", "light", "moderate", "aggressive", "maximum"];
@@ -76,26 +78,28 @@ const VALID_LANGUAGE_CODES: &[&str] = &[
 /// ```
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_validate_binarization_method(method: *const c_char) -> i32 {
-    if method.is_null() {
-        set_last_error("method cannot be NULL".to_string());
-        return 0;
-    }
-
-    let method_str = match unsafe { CStr::from_ptr(method) }.to_str() {
-        Ok(s) => s,
-        Err(_) => {
-            set_last_error("Invalid UTF-8 in method".to_string());
+    ffi_panic_guard_i32!("kreuzberg_validate_binarization_method", {
+        if method.is_null() {
+            set_last_error("method cannot be NULL".to_string());
             return 0;
         }
-    };
 
-    match validate_binarization_method(method_str) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
+        let method_str = match unsafe { CStr::from_ptr(method) }.to_str() {
+            Ok(s) => s,
+            Err(_) => {
+                set_last_error("Invalid UTF-8 in method".to_string());
+                return 0;
+            }
+        };
+
+        match validate_binarization_method(method_str) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
         }
-    }
+    })
 }
 
 /// Validates an OCR backend string.
@@ -122,26 +126,28 @@ pub unsafe extern "C" fn kreuzberg_validate_binarization_method(method: *const c
 /// ```
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_validate_ocr_backend(backend: *const c_char) -> i32 {
-    if backend.is_null() {
-        set_last_error("backend cannot be NULL".to_string());
-        return 0;
-    }
-
-    let backend_str = match unsafe { CStr::from_ptr(backend) }.to_str() {
-        Ok(s) => s,
-        Err(_) => {
-            set_last_error("Invalid UTF-8 in backend".to_string());
+    ffi_panic_guard_i32!("kreuzberg_validate_ocr_backend", {
+        if backend.is_null() {
+            set_last_error("backend cannot be NULL".to_string());
             return 0;
         }
-    };
 
-    match validate_ocr_backend(backend_str) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
+        let backend_str = match unsafe { CStr::from_ptr(backend) }.to_str() {
+            Ok(s) => s,
+            Err(_) => {
+                set_last_error("Invalid UTF-8 in backend".to_string());
+                return 0;
+            }
+        };
+
+        match validate_ocr_backend(backend_str) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
         }
-    }
+    })
 }
 
 /// Validates a language code (ISO 639-1 or 639-3 format).
@@ -170,26 +176,28 @@ pub unsafe extern "C" fn kreuzberg_validate_ocr_backend(backend: *const c_char)
 /// ```
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_validate_language_code(code: *const c_char) -> i32 {
-    if code.is_null() {
-        set_last_error("code cannot be NULL".to_string());
-        return 0;
-    }
-
-    let code_str = match unsafe { CStr::from_ptr(code) }.to_str() {
-        Ok(s) => s,
-        Err(_) => {
-            set_last_error("Invalid UTF-8 in code".to_string());
+    ffi_panic_guard_i32!("kreuzberg_validate_language_code", {
+        if code.is_null() {
+            set_last_error("code cannot be NULL".to_string());
             return 0;
         }
-    };
 
-    match validate_language_code(code_str) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
+        let code_str = match unsafe { CStr::from_ptr(code) }.to_str() {
+            Ok(s) => s,
+            Err(_) => {
+                set_last_error("Invalid UTF-8 in code".to_string());
+                return 0;
+            }
+        };
+
+        match validate_language_code(code_str) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
         }
-    }
+    })
 }
 
 /// Validates a token reduction level string.
@@ -216,26 +224,28 @@ pub unsafe extern "C" fn kreuzberg_validate_language_code(code: *const c_char) -
 /// ```
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_validate_token_reduction_level(level: *const c_char) -> i32 {
-    if level.is_null() {
-        set_last_error("level cannot be NULL".to_string());
-        return 0;
-    }
-
-    let level_str = match unsafe { CStr::from_ptr(level) }.to_str() {
-        Ok(s) => s,
-        Err(_) => {
-            set_last_error("Invalid UTF-8 in level".to_string());
+    ffi_panic_guard_i32!("kreuzberg_validate_token_reduction_level", {
+        if level.is_null() {
+            set_last_error("level cannot be NULL".to_string());
             return 0;
         }
-    };
 
-    match validate_token_reduction_level(level_str) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
+        let level_str = match unsafe { CStr::from_ptr(level) }.to_str() {
+            Ok(s) => s,
+            Err(_) => {
+                set_last_error("Invalid UTF-8 in level".to_string());
+                return 0;
+            }
+        };
+
+        match validate_token_reduction_level(level_str) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
         }
-    }
+    })
 }
 
 /// Validates a tesseract Page Segmentation Mode (PSM) value.
@@ -256,13 +266,15 @@ pub unsafe extern "C" fn kreuzberg_validate_token_reduction_level(level: *const
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_validate_tesseract_psm(psm: i32) -> i32 {
-    match validate_tesseract_psm(psm) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
-        }
-    }
+    ffi_panic_guard_i32!("kreuzberg_validate_tesseract_psm", {
+        match validate_tesseract_psm(psm) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
+        }
+    })
 }
 
 /// Validates a tesseract OCR Engine Mode (OEM) value.
@@ -283,13 +295,15 @@ pub extern "C" fn kreuzberg_validate_tesseract_psm(psm: i32) -> i32 {
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_validate_tesseract_oem(oem: i32) -> i32 {
-    match validate_tesseract_oem(oem) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
-        }
-    }
+    ffi_panic_guard_i32!("kreuzberg_validate_tesseract_oem", {
+        match validate_tesseract_oem(oem) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
+        }
+    })
 }
 
 /// Validates a tesseract output format string.
@@ -316,26 +330,28 @@ pub extern "C" fn kreuzberg_validate_tesseract_oem(oem: i32) -> i32 {
 /// ```
 #[unsafe(no_mangle)]
 pub unsafe extern "C" fn kreuzberg_validate_output_format(format: *const c_char) -> i32 {
-    if format.is_null() {
-        set_last_error("format cannot be NULL".to_string());
-        return 0;
-    }
-
-    let format_str = match unsafe { CStr::from_ptr(format) }.to_str() {
-        Ok(s) => s,
-        Err(_) => {
-            set_last_error("Invalid UTF-8 in format".to_string());
+    ffi_panic_guard_i32!("kreuzberg_validate_output_format", {
+        if format.is_null() {
+            set_last_error("format cannot be NULL".to_string());
             return 0;
         }
-    };
 
-    match validate_output_format(format_str) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
+        let format_str = match unsafe { CStr::from_ptr(format) }.to_str() {
+            Ok(s) => s,
+            Err(_) => {
+                set_last_error("Invalid UTF-8 in format".to_string());
+                return 0;
+            }
+        };
+
+        match validate_output_format(format_str) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
         }
-    }
+    })
 }
 
 /// Validates a confidence threshold value.
@@ -358,13 +374,15 @@ pub unsafe extern "C" fn kreuzberg_validate_output_format(format: *const c_char)
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_validate_confidence(confidence: f64) -> i32 {
-    match validate_confidence(confidence) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
-        }
-    }
+    ffi_panic_guard_i32!("kreuzberg_validate_confidence", {
+        match validate_confidence(confidence) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
+        }
+    })
 }
 
 /// Validates a DPI (dots per inch) value.
@@ -387,13 +405,15 @@ pub extern "C" fn kreuzberg_validate_confidence(confidence: f64) -> i32 {
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_validate_dpi(dpi: i32) -> i32 {
-    match validate_dpi(dpi) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
-        }
-    }
+    ffi_panic_guard_i32!("kreuzberg_validate_dpi", {
+        match validate_dpi(dpi) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
+        }
+    })
 }
 
 /// Validates chunking parameters.
@@ -417,13 +437,15 @@ pub extern "C" fn kreuzberg_validate_dpi(dpi: i32) -> i32 {
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_validate_chunking_params(max_chars: usize, max_overlap: usize) -> i32 {
-    match validate_chunking_params(max_chars, max_overlap) {
-        Ok(()) => 1,
-        Err(e) => {
-            set_last_error(e.to_string());
-            0
-        }
-    }
+    ffi_panic_guard_i32!("kreuzberg_validate_chunking_params", {
+        match validate_chunking_params(max_chars, max_overlap) {
+            Ok(()) => 1,
+            Err(e) => {
+                set_last_error(e.to_string());
+                0
+            }
+        }
+    })
 }
 
 /// Returns valid binarization methods as a JSON array string.
@@ -446,22 +468,24 @@ pub extern "C" fn kreuzberg_validate_chunking_params(max_chars: usize, max_overl
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_get_valid_binarization_methods() -> *mut c_char {
-    let json = format!(
-        "[{}]",
-        VALID_BINARIZATION_METHODS
-            .iter()
-            .map(|m| format!("\"{}\"", m))
-            .collect::<Vec<_>>()
-            .join(",")
-    );
-
-    match std::ffi::CString::new(json) {
-        Ok(c_str) => c_str.into_raw(),
-        Err(e) => {
-            set_last_error(format!("Failed to allocate string: {}", e));
-            std::ptr::null_mut()
-        }
-    }
+    ffi_panic_guard!("kreuzberg_get_valid_binarization_methods", {
+        let json = format!(
+            "[{}]",
+            VALID_BINARIZATION_METHODS
+                .iter()
+                .map(|m| format!("\"{}\"", m))
+                .collect::<Vec<_>>()
+                .join(",")
+        );
+
+        match std::ffi::CString::new(json) {
+            Ok(c_str) => c_str.into_raw(),
+            Err(e) => {
+                set_last_error(format!("Failed to allocate string: {}", e));
+                std::ptr::null_mut()
+            }
+        }
+    })
 }
 
 /// Returns valid language codes as a JSON array string.
@@ -480,22 +504,24 @@ pub extern "C" fn kreuzberg_get_valid_binarization_methods() -> *mut c_char {
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_get_valid_language_codes() -> *mut c_char {
-    let json = format!(
-        "[{}]",
-        VALID_LANGUAGE_CODES
-            .iter()
-            .map(|c| format!("\"{}\"", c))
-            .collect::<Vec<_>>()
-            .join(",")
-    );
-
-    match std::ffi::CString::new(json) {
-        Ok(c_str) => c_str.into_raw(),
-        Err(e) => {
-            set_last_error(format!("Failed to allocate string: {}", e));
-            std::ptr::null_mut()
-        }
-    }
+    ffi_panic_guard!("kreuzberg_get_valid_language_codes", {
+        let json = format!(
+            "[{}]",
+            VALID_LANGUAGE_CODES
+                .iter()
+                .map(|c| format!("\"{}\"", c))
+                .collect::<Vec<_>>()
+                .join(",")
+        );
+
+        match std::ffi::CString::new(json) {
+            Ok(c_str) => c_str.into_raw(),
+            Err(e) => {
+                set_last_error(format!("Failed to allocate string: {}", e));
+                std::ptr::null_mut()
+            }
+        }
+    })
 }
 
 /// Returns valid OCR backends as a JSON array string.
@@ -514,22 +540,24 @@ pub extern "C" fn kreuzberg_get_valid_language_codes() -> *mut c_char {
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_get_valid_ocr_backends() -> *mut c_char {
-    let json = format!(
-        "[{}]",
-        VALID_OCR_BACKENDS
-            .iter()
-            .map(|b| format!("\"{}\"", b))
-            .collect::<Vec<_>>()
-            .join(",")
-    );
-
-    match std::ffi::CString::new(json) {
-        Ok(c_str) => c_str.into_raw(),
-        Err(e) => {
-            set_last_error(format!("Failed to allocate string: {}", e));
-            std::ptr::null_mut()
-        }
-    }
+    ffi_panic_guard!("kreuzberg_get_valid_ocr_backends", {
+        let json = format!(
+            "[{}]",
+            VALID_OCR_BACKENDS
+                .iter()
+                .map(|b| format!("\"{}\"", b))
+                .collect::<Vec<_>>()
+                .join(",")
+        );
+
+        match std::ffi::CString::new(json) {
+            Ok(c_str) => c_str.into_raw(),
+            Err(e) => {
+                set_last_error(format!("Failed to allocate string: {}", e));
+                std::ptr::null_mut()
+            }
+        }
+    })
 }
 
 /// Returns valid token reduction levels as a JSON array string.
@@ -548,22 +576,24 @@ pub extern "C" fn kreuzberg_get_valid_ocr_backends() -> *mut c_char {
 /// ```
 #[unsafe(no_mangle)]
 pub extern "C" fn kreuzberg_get_valid_token_reduction_levels() -> *mut c_char {
-    let json = format!(
-        "[{}]",
-        VALID_TOKEN_REDUCTION_LEVELS
-            .iter()
-            .map(|l| format!("\"{}\"", l))
-            .collect::<Vec<_>>()
-            .join(",")
-    );
-
-    match std::ffi::CString::new(json) {
-        Ok(c_str) => c_str.into_raw(),
-        Err(e) => {
-            set_last_error(format!("Failed to allocate string: {}", e));
-            std::ptr::null_mut()
-        }
-    }
+    ffi_panic_guard!("kreuzberg_get_valid_token_reduction_levels", {
+        let json = format!(
+            "[{}]",
+            VALID_TOKEN_REDUCTION_LEVELS
+                .iter()
+                .map(|l| format!("\"{}\"", l))
+                .collect::<Vec<_>>()
+                .join(",")
+        );
+
+        match std::ffi::CString::new(json) {
+            Ok(c_str) => c_str.into_raw(),
+            Err(e) => {
+                set_last_error(format!("Failed to allocate string: {}", e));
+                std::ptr::null_mut()
+            }
+        }
+    })
 }
 
 #[cfg(test)]
@@ -928,6 +928,9 @@ impl TryFrom<JsExtractionConfig> for ExtractionConfig {
                 })
                 .transpose()?
                 .unwrap_or_default(),
+            passwords: None,
+            include: None,
+            dicom: None,
         })
     }
 }
@@ -124,6 +124,9 @@ impl ExtractionConfig {
                 } else {
                     kreuzberg::core::config::formats::OutputFormat::Plain
                 },
+                passwords: None,
+                include: None,
+                dicom: None,
             },
             html_options_dict,
         })
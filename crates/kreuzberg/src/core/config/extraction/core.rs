@@ -5,11 +5,11 @@
 
 use serde::{Deserialize, Serialize};
 
-use super::super::formats::OutputFormat;
+use super::super::formats::{OutputFormat, ResultField};
 use super::super::ocr::OcrConfig;
 use super::super::page::PageConfig;
 use super::super::processing::{ChunkingConfig, PostProcessorConfig};
-use super::types::{ImageExtractionConfig, LanguageDetectionConfig, TokenReductionConfig};
+use super::types::{DicomConfig, ImageExtractionConfig, LanguageDetectionConfig, TokenReductionConfig};
 
 /// Main extraction configuration.
 ///
@@ -115,6 +115,24 @@ pub struct ExtractionConfig {
     /// when format conversion is applied.
     #[serde(default)]
     pub output_format: OutputFormat,
+
+    /// Passwords to try, in order, on encrypted archive entries (ZIP, 7z).
+    ///
+    /// Entries that none of them decrypts are reported in the archive metadata instead of
+    /// failing the archive. Encrypted PDFs use `pdf_options.passwords`.
+    #[serde(default)]
+    pub passwords: Option<Vec<String>>,
+
+    /// Parts of the result to return (None = all).
+    ///
+    /// The other parts are dropped after post-processing, which still sees the full result,
+    /// so callers wanting only tables or metadata do not pay for serializing the content.
+    #[serde(default)]
+    pub include: Option<Vec<ResultField>>,
+
+    /// DICOM extraction configuration (None = use defaults)
+    #[serde(default)]
+    pub dicom: Option<DicomConfig>,
 }
 
 impl Default for ExtractionConfig {
@@ -139,6 +157,9 @@ impl Default for ExtractionConfig {
             max_concurrent_extractions: None,
             result_format: crate::types::OutputFormat::Unified,
             output_format: OutputFormat::Plain,
+            passwords: None,
+            include: None,
+            dicom: None,
         }
     }
 }
@@ -16,7 +16,7 @@ mod types;
 
 // Re-export all public types for backward compatibility
 pub use self::core::ExtractionConfig;
-pub use self::types::{ImageExtractionConfig, LanguageDetectionConfig, TokenReductionConfig};
+pub use self::types::{DicomConfig, ImageExtractionConfig, LanguageDetectionConfig, TokenReductionConfig};
 
 #[cfg(test)]
 mod tests {
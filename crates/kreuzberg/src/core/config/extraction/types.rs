@@ -4,6 +4,7 @@
 //! - Image extraction and processing
 //! - Token reduction
 //! - Language detection
+//! - DICOM extraction
 
 use serde::{Deserialize, Serialize};
 
@@ -63,6 +64,22 @@ pub struct LanguageDetectionConfig {
     pub detect_multiple: bool,
 }
 
+/// DICOM extraction configuration.
+#[derive(Debug, Clone, Default, Serialize, Deserialize)]
+pub struct DicomConfig {
+    /// Remove the patient name, ID and birth date, the accession number, the institution
+    /// and the referring physician from metadata and content, and person names from
+    /// structured reports. Occurrences of the patient identifiers in report and OCR text
+    /// are masked.
+    #[serde(default)]
+    pub anonymize: bool,
+
+    /// Run OCR over the first frame of the pixel data to recover burned-in text, using the
+    /// OCR configuration of the extraction (requires the `ocr` feature)
+    #[serde(default)]
+    pub ocr_pixel_data: bool,
+}
+
 // Default value functions
 fn default_true() -> bool {
     true
@@ -1,8 +1,9 @@
 //! Output format configuration and validation.
 //!
 //! This module defines the `OutputFormat` enum for controlling how extraction
-//! results are formatted (plain text, markdown, HTML, etc.) and provides
-//! serialization/deserialization support.
+//! results are formatted (plain text, markdown, HTML, etc.), the `ResultField` enum for
+//! selecting the parts of a result to return, and provides serialization/deserialization
+//! support.
 
 use serde::{Deserialize, Serialize};
 use std::str::FromStr;
@@ -54,6 +55,27 @@ impl FromStr for OutputFormat {
     }
 }
 
+/// A part of an extraction result, for selecting the parts to return.
+///
+/// Parts left out of `ExtractionConfig::include` are dropped at the end of the pipeline,
+/// so they are not serialized or copied across language bindings.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Serialize, Deserialize)]
+#[serde(rename_all = "lowercase")]
+pub enum ResultField {
+    /// The `content` text, with its djot and element representations
+    Content,
+    /// Extracted tables
+    Tables,
+    /// Extracted images
+    Images,
+    /// Text chunks
+    Chunks,
+    /// Per-page content
+    Pages,
+    /// Document metadata, including detected languages
+    Metadata,
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -11,8 +11,10 @@ pub mod pdf;
 pub mod processing;
 
 // Re-export main types for backward compatibility
-pub use extraction::{ExtractionConfig, ImageExtractionConfig, LanguageDetectionConfig, TokenReductionConfig};
-pub use formats::OutputFormat;
+pub use extraction::{
+    DicomConfig, ExtractionConfig, ImageExtractionConfig, LanguageDetectionConfig, TokenReductionConfig,
+};
+pub use formats::{OutputFormat, ResultField};
 pub use ocr::OcrConfig;
 pub use page::PageConfig;
 #[cfg(feature = "pdf")]
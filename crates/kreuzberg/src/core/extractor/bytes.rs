@@ -10,13 +10,11 @@ use crate::KreuzbergError;
 use crate::Result;
 use crate::core::config::ExtractionConfig;
 use crate::core::mime::{LEGACY_POWERPOINT_MIME_TYPE, LEGACY_WORD_MIME_TYPE};
-#[cfg(feature = "office")]
-use crate::extraction::libreoffice::{convert_doc_to_docx, convert_ppt_to_pptx};
 use crate::types::ExtractionResult;
 
-#[cfg(feature = "office")]
-use super::file::apply_libreoffice_metadata;
 use super::file::extract_bytes_with_extractor;
+#[cfg(feature = "office")]
+use super::file::extract_legacy_office;
 #[cfg(feature = "otel")]
 use super::file::record_error;
 
@@ -74,11 +72,7 @@ pub async fn extract_bytes(content: &[u8], mime_type: &str, config: &ExtractionC
         match validated_mime.as_str() {
             #[cfg(feature = "office")]
             LEGACY_WORD_MIME_TYPE => {
-                let conversion = convert_doc_to_docx(content).await?;
-                let mut result =
-                    extract_bytes_with_extractor(&conversion.converted_bytes, &conversion.target_mime, config).await?;
-                apply_libreoffice_metadata(&mut result, LEGACY_WORD_MIME_TYPE, &conversion);
-                return Ok(result);
+                return extract_legacy_office(content, LEGACY_WORD_MIME_TYPE, config).await;
             }
             #[cfg(not(feature = "office"))]
             LEGACY_WORD_MIME_TYPE => {
@@ -88,11 +82,7 @@ pub async fn extract_bytes(content: &[u8], mime_type: &str, config: &ExtractionC
             }
             #[cfg(feature = "office")]
             LEGACY_POWERPOINT_MIME_TYPE => {
-                let conversion = convert_ppt_to_pptx(content).await?;
-                let mut result =
-                    extract_bytes_with_extractor(&conversion.converted_bytes, &conversion.target_mime, config).await?;
-                apply_libreoffice_metadata(&mut result, LEGACY_POWERPOINT_MIME_TYPE, &conversion);
-                return Ok(result);
+                return extract_legacy_office(content, LEGACY_POWERPOINT_MIME_TYPE, config).await;
             }
             #[cfg(not(feature = "office"))]
             LEGACY_POWERPOINT_MIME_TYPE => {
@@ -6,7 +6,6 @@
 //! - File validation and reading
 //! - Extraction pipeline orchestration
 
-#[cfg(any(feature = "otel", not(feature = "office")))]
 use crate::KreuzbergError;
 use crate::Result;
 use crate::core::config::ExtractionConfig;
@@ -152,11 +151,7 @@ pub async fn extract_file(
             #[cfg(feature = "office")]
             LEGACY_WORD_MIME_TYPE => {
                 let original_bytes = tokio::fs::read(path).await?;
-                let conversion = convert_doc_to_docx(&original_bytes).await?;
-                let mut result =
-                    extract_bytes_with_extractor(&conversion.converted_bytes, &conversion.target_mime, config).await?;
-                apply_libreoffice_metadata(&mut result, LEGACY_WORD_MIME_TYPE, &conversion);
-                return Ok(result);
+                return extract_legacy_office(&original_bytes, LEGACY_WORD_MIME_TYPE, config).await;
             }
             #[cfg(not(feature = "office"))]
             LEGACY_WORD_MIME_TYPE => {
@@ -167,11 +162,7 @@ pub async fn extract_file(
             #[cfg(feature = "office")]
             LEGACY_POWERPOINT_MIME_TYPE => {
                 let original_bytes = tokio::fs::read(path).await?;
-                let conversion = convert_ppt_to_pptx(&original_bytes).await?;
-                let mut result =
-                    extract_bytes_with_extractor(&conversion.converted_bytes, &conversion.target_mime, config).await?;
-                apply_libreoffice_metadata(&mut result, LEGACY_POWERPOINT_MIME_TYPE, &conversion);
-                return Ok(result);
+                return extract_legacy_office(&original_bytes, LEGACY_POWERPOINT_MIME_TYPE, config).await;
             }
             #[cfg(not(feature = "office"))]
             LEGACY_POWERPOINT_MIME_TYPE => {
@@ -220,8 +211,35 @@ pub(in crate::core::extractor) async fn extract_bytes_with_extractor(
     Ok(result)
 }
 
+/// Extract a legacy Word or PowerPoint file through its LibreOffice conversion.
+///
+/// Without LibreOffice, the file is read by the native legacy Office extractor, which
+/// recovers its text and summary information but not its formatting.
+#[cfg(feature = "office")]
+pub(in crate::core::extractor) async fn extract_legacy_office(
+    content: &[u8],
+    legacy_mime: &'static str,
+    config: &ExtractionConfig,
+) -> Result<ExtractionResult> {
+    let conversion = if legacy_mime == LEGACY_POWERPOINT_MIME_TYPE {
+        convert_ppt_to_pptx(content).await
+    } else {
+        convert_doc_to_docx(content).await
+    };
+    let conversion = match conversion {
+        Ok(conversion) => conversion,
+        Err(KreuzbergError::MissingDependency(_)) => {
+            return extract_bytes_with_extractor(content, legacy_mime, config).await;
+        }
+        Err(err) => return Err(err),
+    };
+    let mut result = extract_bytes_with_extractor(&conversion.converted_bytes, &conversion.target_mime, config).await?;
+    apply_libreoffice_metadata(&mut result, legacy_mime, &conversion);
+    Ok(result)
+}
+
 #[cfg(feature = "office")]
-pub(in crate::core::extractor) fn apply_libreoffice_metadata(
+fn apply_libreoffice_metadata(
     result: &mut ExtractionResult,
     legacy_mime: &str,
     conversion: &LibreOfficeConversionResult,
@@ -9,6 +9,7 @@ use std::collections::{HashMap, HashSet};
 use std::path::Path;
 
 pub const HTML_MIME_TYPE: &str = "text/html";
+pub const WEBARCHIVE_MIME_TYPE: &str = "application/x-webarchive";
 pub const MARKDOWN_MIME_TYPE: &str = "text/markdown";
 pub const PDF_MIME_TYPE: &str = "application/pdf";
 pub const PLAIN_TEXT_MIME_TYPE: &str = "text/plain";
@@ -35,6 +36,21 @@ pub const EXCEL_TEMPLATE_MIME_TYPE: &str = "application/vnd.ms-excel.template.ma
 
 pub const OPENDOC_SPREADSHEET_MIME_TYPE: &str = "application/vnd.oasis.opendocument.spreadsheet";
 
+pub const DICOM_MIME_TYPE: &str = "application/dicom";
+
+pub const WORDPERFECT_MIME_TYPE: &str = "application/vnd.wordperfect";
+pub const WORDPRO_MIME_TYPE: &str = "application/vnd.lotus-wordpro";
+pub const AMIPRO_MIME_TYPE: &str = "application/vnd.lotus-amipro";
+
+pub const FICTIONBOOK_MIME_TYPE: &str = "application/x-fictionbook+xml";
+pub const DJVU_MIME_TYPE: &str = "image/vnd.djvu";
+pub const XPS_MIME_TYPE: &str = "application/vnd.ms-xpsdocument";
+pub const OXPS_MIME_TYPE: &str = "application/oxps";
+
+/// Extensions of legacy word processor documents. They are claimed only when the content
+/// carries the signature of one, as `.sam` and `.wp` are used by unrelated formats.
+const WORD_PROCESSOR_EXTENSIONS: [&str; 7] = ["wpd", "wp", "wp5", "wp6", "wpt", "lwp", "sam"];
+
 /// Extension to MIME type mapping (ported from Python EXT_TO_MIME_TYPE).
 static EXT_TO_MIME: Lazy<HashMap<&'static str, &'static str>> = Lazy::new(|| {
     let mut m = HashMap::new();
@@ -47,6 +63,7 @@ static EXT_TO_MIME: Lazy<HashMap<&'static str, &'static str>> = Lazy::new(|| {
 
     m.insert("html", HTML_MIME_TYPE);
     m.insert("htm", HTML_MIME_TYPE);
+    m.insert("webarchive", WEBARCHIVE_MIME_TYPE);
 
     m.insert("xlsx", EXCEL_MIME_TYPE);
     m.insert("xls", EXCEL_BINARY_MIME_TYPE);
@@ -63,10 +80,16 @@ static EXT_TO_MIME: Lazy<HashMap<&'static str, &'static str>> = Lazy::new(|| {
     );
     m.insert("pptm", "application/vnd.ms-powerpoint.presentation.macroEnabled.12");
     m.insert("ppt", LEGACY_POWERPOINT_MIME_TYPE);
+    m.insert("pps", LEGACY_POWERPOINT_MIME_TYPE);
+    m.insert("pot", LEGACY_POWERPOINT_MIME_TYPE);
 
     m.insert("docx", DOCX_MIME_TYPE);
     m.insert("doc", LEGACY_WORD_MIME_TYPE);
+    m.insert("dot", LEGACY_WORD_MIME_TYPE);
     m.insert("odt", "application/vnd.oasis.opendocument.text");
+    m.insert("fb2", FICTIONBOOK_MIME_TYPE);
+    m.insert("xps", XPS_MIME_TYPE);
+    m.insert("oxps", OXPS_MIME_TYPE);
 
     m.insert("bmp", "image/bmp");
     m.insert("gif", "image/gif");
@@ -84,6 +107,11 @@ static EXT_TO_MIME: Lazy<HashMap<&'static str, &'static str>> = Lazy::new(|| {
     m.insert("pbm", "image/x-portable-bitmap");
     m.insert("pgm", "image/x-portable-graymap");
     m.insert("ppm", "image/x-portable-pixmap");
+    m.insert("djvu", DJVU_MIME_TYPE);
+    m.insert("djv", DJVU_MIME_TYPE);
+
+    m.insert("dcm", DICOM_MIME_TYPE);
+    m.insert("dicom", DICOM_MIME_TYPE);
 
     m.insert("csv", "text/csv");
     m.insert("tsv", "text/tab-separated-values");
@@ -102,6 +130,9 @@ static EXT_TO_MIME: Lazy<HashMap<&'static str, &'static str>> = Lazy::new(|| {
     m.insert("gz", "application/gzip");
     m.insert("tgz", "application/x-tar");
     m.insert("7z", "application/x-7z-compressed");
+    m.insert("rar", "application/vnd.rar");
+    m.insert("zst", "application/zstd");
+    m.insert("tzst", "application/x-tar");
 
     m.insert("rst", "text/x-rst");
     m.insert("org", "text/x-org");
@@ -153,7 +184,7 @@ static SUPPORTED_MIME_TYPES: Lazy<HashSet<&'static str>> = Lazy::new(|| {
     set.insert("application/x-biblatex");
     set.insert("application/x-bibtex");
     set.insert("application/x-endnote+xml");
-    set.insert("application/x-fictionbook+xml");
+    set.insert(FICTIONBOOK_MIME_TYPE);
     set.insert("application/x-ipynb+json");
     set.insert("application/x-jats+xml");
     set.insert("application/x-latex");
@@ -190,6 +221,7 @@ static SUPPORTED_MIME_TYPES: Lazy<HashSet<&'static str>> = Lazy::new(|| {
     set.insert(LEGACY_WORD_MIME_TYPE);
     set.insert(LEGACY_POWERPOINT_MIME_TYPE);
     set.insert(HTML_MIME_TYPE);
+    set.insert(WEBARCHIVE_MIME_TYPE);
     set.insert(EML_MIME_TYPE);
     set.insert(MSG_MIME_TYPE);
     set.insert(JSON_MIME_TYPE);
@@ -203,6 +235,13 @@ static SUPPORTED_MIME_TYPES: Lazy<HashSet<&'static str>> = Lazy::new(|| {
     set.insert(XML_MIME_TYPE);
     set.insert(XML_TEXT_MIME_TYPE);
     set.insert(SVG_MIME_TYPE);
+    set.insert(DICOM_MIME_TYPE);
+    set.insert(WORDPERFECT_MIME_TYPE);
+    set.insert(WORDPRO_MIME_TYPE);
+    set.insert(AMIPRO_MIME_TYPE);
+    set.insert(DJVU_MIME_TYPE);
+    set.insert(XPS_MIME_TYPE);
+    set.insert(OXPS_MIME_TYPE);
 
     set.insert("application/zip");
     set.insert("application/x-zip-compressed");
@@ -211,14 +250,21 @@ static SUPPORTED_MIME_TYPES: Lazy<HashSet<&'static str>> = Lazy::new(|| {
     set.insert("application/x-gtar");
     set.insert("application/x-ustar");
     set.insert("application/x-7z-compressed");
+    set.insert("application/zstd");
+    set.insert("application/vnd.rar");
+    set.insert("application/x-rar-compressed");
+    set.insert("application/x-rar");
 
     set
 });
 
 /// Detect MIME type from a file path.
 ///
-/// Uses file extension to determine MIME type. Falls back to `mime_guess` crate
-/// if extension-based detection fails.
+/// Uses file extension to determine MIME type, treating `.fb2.zip` as a zipped FictionBook
+/// ebook. Falls back to `mime_guess` crate if extension-based detection fails. Files
+/// without an extension are checked for the DICOM signature, as DICOM files are often
+/// stored without one, and files with the extension of a legacy word processor document
+/// for the signature of one.
 ///
 /// # Arguments
 ///
@@ -245,12 +291,32 @@ pub fn detect_mime_type(path: impl AsRef<Path>, check_exists: bool) -> Result<St
 
     let extension = path.extension().and_then(|ext| ext.to_str()).map(|s| s.to_lowercase());
 
+    if extension.as_deref() == Some("zip")
+        && path
+            .file_stem()
+            .and_then(|stem| stem.to_str())
+            .is_some_and(|stem| stem.to_lowercase().ends_with(".fb2"))
+    {
+        return Ok(FICTIONBOOK_MIME_TYPE.to_string());
+    }
+
     if let Some(ext) = &extension
         && let Some(mime_type) = EXT_TO_MIME.get(ext.as_str())
     {
         return Ok(mime_type.to_string());
     }
 
+    if extension.is_none() && is_dicom_file(path) {
+        return Ok(DICOM_MIME_TYPE.to_string());
+    }
+
+    if let Some(ext) = &extension
+        && WORD_PROCESSOR_EXTENSIONS.contains(&ext.as_str())
+        && let Some(mime_type) = word_processor_file(path)
+    {
+        return Ok(mime_type.to_string());
+    }
+
     let guess = mime_guess::from_path(path).first();
     if let Some(mime) = guess {
         return Ok(mime.to_string());
@@ -269,6 +335,52 @@ pub fn detect_mime_type(path: impl AsRef<Path>, check_exists: bool) -> Result<St
     )))
 }
 
+/// Check whether the file at `path` starts with the DICOM Part 10 preamble and prefix.
+fn is_dicom_file(path: &Path) -> bool {
+    use std::io::Read;
+
+    let mut header = [0u8; 132];
+    std::fs::File::open(path)
+        .and_then(|mut file| file.read_exact(&mut header))
+        .is_ok_and(|()| &header[128..] == b"DICM")
+}
+
+/// Return the MIME type of the legacy word processor document at `path`, if it is one.
+fn word_processor_file(path: &Path) -> Option<&'static str> {
+    use std::io::Read;
+
+    let mut header = Vec::with_capacity(16);
+    std::fs::File::open(path)
+        .and_then(|file| file.take(16).read_to_end(&mut header))
+        .ok()?;
+    word_processor_mime_type(&header)
+}
+
+/// Return the MIME type of a WordPerfect, Lotus Word Pro or Ami Pro document from its
+/// signature, or `None` when `content` is not one.
+pub(crate) fn word_processor_mime_type(content: &[u8]) -> Option<&'static str> {
+    if content.len() >= 16 && content.starts_with(b"\xFFWPC") && content[8] == 1 {
+        Some(WORDPERFECT_MIME_TYPE)
+    } else if content.starts_with(b"WordPro") {
+        Some(WORDPRO_MIME_TYPE)
+    } else if content.starts_with(b"[ver]") {
+        Some(AMIPRO_MIME_TYPE)
+    } else {
+        None
+    }
+}
+
+/// Check whether `content` is a single-page or bundled DjVu document.
+pub(crate) fn is_djvu(content: &[u8]) -> bool {
+    content.starts_with(b"AT&TFORM") && matches!(content.get(12..16), Some(b"DJVU" | b"DJVM"))
+}
+
+/// Check whether `content` is a FictionBook document, whose root element is expected
+/// within its first kilobyte.
+fn is_fictionbook(content: &[u8]) -> bool {
+    memchr::memmem::find(&content[..content.len().min(1024)], b"<FictionBook").is_some()
+}
+
 /// Validate that a MIME type is supported.
 ///
 /// # Arguments
@@ -336,6 +448,31 @@ pub fn detect_or_validate(path: Option<&Path>, mime_type: Option<&str>) -> Resul
 ///
 /// Returns `KreuzbergError::UnsupportedFormat` if MIME type cannot be determined.
 pub fn detect_mime_type_from_bytes(content: &[u8]) -> Result<String> {
+    // Safari webarchives are binary property lists naming their main resource.
+    if content.starts_with(b"bplist00") && memchr::memmem::find(content, b"WebMainResource").is_some() {
+        return Ok(WEBARCHIVE_MIME_TYPE.to_string());
+    }
+
+    if let Some(mime_type) = word_processor_mime_type(content) {
+        return Ok(mime_type.to_string());
+    }
+
+    if is_djvu(content) {
+        return Ok(DJVU_MIME_TYPE.to_string());
+    }
+
+    if is_fictionbook(content) {
+        return Ok(FICTIONBOOK_MIME_TYPE.to_string());
+    }
+
+    // XPS packages are ZIP files, told apart from other packages by their relationships.
+    #[cfg(feature = "office")]
+    if content.starts_with(b"PK\x03\x04")
+        && let Some(mime_type) = crate::extraction::xps::xps_mime_type(content)
+    {
+        return Ok(mime_type.to_string());
+    }
+
     if let Some(kind) = infer::get(content) {
         let mime_type = kind.mime_type();
 
@@ -516,6 +653,7 @@ mod tests {
             ("test.md", MARKDOWN_MIME_TYPE),
             ("test.html", HTML_MIME_TYPE),
             ("test.htm", HTML_MIME_TYPE),
+            ("test.webarchive", WEBARCHIVE_MIME_TYPE),
         ];
 
         for (filename, expected_mime) in test_cases {
@@ -540,6 +678,90 @@ mod tests {
         }
     }
 
+    #[test]
+    fn test_detect_mime_type_from_bytes_webarchive() {
+        let archive = b"bplist00\xd1\x01\x02_\x10\x0fWebMainResource";
+        assert_eq!(detect_mime_type_from_bytes(archive).unwrap(), WEBARCHIVE_MIME_TYPE);
+        assert_ne!(
+            detect_mime_type_from_bytes(b"bplist00\xd0").unwrap_or_default(),
+            WEBARCHIVE_MIME_TYPE
+        );
+    }
+
+    #[test]
+    fn test_detect_mime_type_dicom() {
+        let dir = tempdir().unwrap();
+        let mut preamble = vec![0u8; 128];
+        preamble.extend_from_slice(b"DICM");
+
+        let with_extension = dir.path().join("image.dcm");
+        File::create(&with_extension).unwrap();
+        assert_eq!(detect_mime_type(&with_extension, true).unwrap(), DICOM_MIME_TYPE);
+
+        let without_extension = dir.path().join("IM000001");
+        std::fs::write(&without_extension, &preamble).unwrap();
+        assert_eq!(detect_mime_type(&without_extension, true).unwrap(), DICOM_MIME_TYPE);
+        assert_eq!(detect_mime_type_from_bytes(&preamble).unwrap(), DICOM_MIME_TYPE);
+    }
+
+    #[test]
+    fn test_detect_mime_type_word_processor() {
+        let dir = tempdir().unwrap();
+        let word_perfect = b"\xFFWPC\x10\x00\x00\x00\x01\x0A\x02\x01\x00\x00\x00\x00";
+
+        let document = dir.path().join("letter.wpd");
+        std::fs::write(&document, word_perfect).unwrap();
+        assert_eq!(detect_mime_type(&document, true).unwrap(), WORDPERFECT_MIME_TYPE);
+        assert_eq!(
+            detect_mime_type_from_bytes(word_perfect).unwrap(),
+            WORDPERFECT_MIME_TYPE
+        );
+        assert_eq!(
+            detect_mime_type_from_bytes(b"[ver]\r\n\t4\r\n").unwrap(),
+            AMIPRO_MIME_TYPE
+        );
+
+        let alignment = dir.path().join("reads.sam");
+        std::fs::write(&alignment, b"@HD\tVN:1.6\n").unwrap();
+        assert_ne!(detect_mime_type(&alignment, true).unwrap_or_default(), AMIPRO_MIME_TYPE);
+    }
+
+    #[test]
+    fn test_detect_mime_type_fictionbook_and_djvu() {
+        assert_eq!(detect_mime_type("novel.fb2", false).unwrap(), FICTIONBOOK_MIME_TYPE);
+        assert_eq!(detect_mime_type("novel.FB2.zip", false).unwrap(), FICTIONBOOK_MIME_TYPE);
+        assert_eq!(detect_mime_type("scan.djvu", false).unwrap(), DJVU_MIME_TYPE);
+
+        let book = b"<?xml version=\"1.0\"?>\n<FictionBook xmlns=\"http://www.gribuser.ru/xml/fictionbook/2.0\">";
+        assert_eq!(detect_mime_type_from_bytes(book).unwrap(), FICTIONBOOK_MIME_TYPE);
+        assert_eq!(
+            detect_mime_type_from_bytes(b"AT&TFORM\x00\x00\x00\x20DJVUINFO").unwrap(),
+            DJVU_MIME_TYPE
+        );
+        assert_ne!(
+            detect_mime_type_from_bytes(b"AT&TFORM\x00\x00\x00\x20AIFF").unwrap_or_default(),
+            DJVU_MIME_TYPE
+        );
+    }
+
+    #[test]
+    fn test_detect_mime_type_xps() {
+        assert_eq!(detect_mime_type("invoice.xps", false).unwrap(), XPS_MIME_TYPE);
+        assert_eq!(detect_mime_type("invoice.OXPS", false).unwrap(), OXPS_MIME_TYPE);
+    }
+
+    #[cfg(feature = "office")]
+    #[test]
+    fn test_detect_mime_type_xps_from_bytes() {
+        use crate::extraction::xps::test_support;
+
+        let document = test_support::document();
+        assert_eq!(detect_mime_type_from_bytes(&document).unwrap(), XPS_MIME_TYPE);
+
+        let other = test_support::package(&[("_rels/.rels", b"<Relationships/>")]);
+        assert_ne!(detect_mime_type_from_bytes(&other).unwrap_or_default(), XPS_MIME_TYPE);
+    }
+
     #[test]
     fn test_validate_mime_type_exact() {
         assert!(validate_mime_type("application/pdf").is_ok());
@@ -0,0 +1,90 @@
+//! Selection of the parts of an extraction result to return.
+
+use crate::core::config::ResultField;
+use crate::types::{ExtractionResult, Metadata};
+
+/// Drop the parts of the result that `include` leaves out.
+///
+/// Does nothing when `include` is `None`. Dropped parts are emptied rather than removed:
+/// `content` becomes an empty string, `tables` an empty list, and the optional parts
+/// `None`. Leaving out `Metadata` resets the metadata to its default but keeps `error`,
+/// which reports how the extraction went rather than describing the document.
+pub fn apply_include(result: &mut ExtractionResult, include: Option<&[ResultField]>) {
+    let Some(include) = include else {
+        return;
+    };
+    let keep = |field| include.contains(&field);
+
+    if !keep(ResultField::Content) {
+        result.content = String::new();
+        result.djot_content = None;
+        result.elements = None;
+    }
+    if !keep(ResultField::Tables) {
+        result.tables = Vec::new();
+    }
+    if !keep(ResultField::Images) {
+        result.images = None;
+    }
+    if !keep(ResultField::Chunks) {
+        result.chunks = None;
+    }
+    if !keep(ResultField::Pages) {
+        result.pages = None;
+    }
+    if !keep(ResultField::Metadata) {
+        result.metadata = Metadata {
+            error: result.metadata.error.take(),
+            ..Default::default()
+        };
+        result.detected_languages = None;
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::types::Table;
+
+    fn full_result() -> ExtractionResult {
+        ExtractionResult {
+            content: "Hello World".to_string(),
+            mime_type: "text/plain".to_string(),
+            metadata: Metadata {
+                title: Some("Title".to_string()),
+                ..Default::default()
+            },
+            tables: vec![Table {
+                cells: vec![vec!["a".to_string()]],
+                markdown: "| a |".to_string(),
+                page_number: 1,
+            }],
+            detected_languages: Some(vec!["en".to_string()]),
+            chunks: None,
+            images: None,
+            pages: None,
+            djot_content: None,
+            elements: None,
+        }
+    }
+
+    #[test]
+    fn test_apply_include_none_keeps_everything() {
+        let mut result = full_result();
+        apply_include(&mut result, None);
+        assert_eq!(result.content, "Hello World");
+        assert_eq!(result.tables.len(), 1);
+        assert!(result.metadata.title.is_some());
+    }
+
+    #[test]
+    fn test_apply_include_tables_only() {
+        let mut result = full_result();
+        apply_include(&mut result, Some(&[ResultField::Tables]));
+        assert!(result.content.is_empty());
+        assert_eq!(result.tables.len(), 1);
+        assert!(result.metadata.title.is_none());
+        assert!(result.detected_languages.is_none());
+        assert_eq!(result.mime_type, "text/plain");
+    }
+}
@@ -7,6 +7,7 @@ mod cache;
 mod execution;
 mod features;
 mod format;
+mod include;
 mod initialization;
 
 #[cfg(test)]
@@ -14,6 +15,7 @@ mod tests;
 
 pub use cache::clear_processor_cache;
 pub use format::apply_output_format;
+pub use include::apply_include;
 
 use crate::Result;
 use crate::core::config::ExtractionConfig;
@@ -30,6 +32,7 @@ use initialization::{get_processors_from_cache, initialize_features, initialize_
 /// 2. Quality Processing - Text cleaning and quality scoring
 /// 3. Chunking - Text splitting if enabled
 /// 4. Validators - Run validation hooks on the processed result (can fail fast)
+/// 5. Output format conversion, then dropping the parts left out of `config.include`
 ///
 /// # Arguments
 ///
@@ -84,8 +87,9 @@ pub async fn run_pipeline(mut result: ExtractionResult, config: &ExtractionConfi
         ));
     }
 
-    // Apply output format conversion as the final step
+    // Apply output format conversion, then drop the parts the caller did not ask for
     apply_output_format(&mut result, config.output_format);
+    apply_include(&mut result, config.include.as_deref());
 
     Ok(result)
 }
@@ -128,8 +132,9 @@ pub fn run_pipeline_sync(mut result: ExtractionResult, config: &ExtractionConfig
         ));
     }
 
-    // Apply output format conversion as the final step
+    // Apply output format conversion, then drop the parts the caller did not ask for
     apply_output_format(&mut result, config.output_format);
+    apply_include(&mut result, config.include.as_deref());
 
     Ok(result)
 }
@@ -2,20 +2,32 @@
 //!
 //! This module provides functions for extracting file lists and contents from archives.
 //! Supported formats:
-//! - ZIP archives
-//! - TAR archives (including compressed TAR.GZ, TAR.BZ2)
-//! - 7Z archives
+//! - ZIP archives, including encrypted entries (ZipCrypto, AES)
+//! - TAR archives (including zstd-compressed TAR.ZST)
+//! - 7Z archives, including encrypted archives
+//! - RAR archives (listing, and the content of uncompressed entries)
 //!
-//! Each format has its own submodule with specialized extraction logic.
+//! Each format has its own submodule with specialized extraction logic. The
+//! `_with_passwords` variants decrypt encrypted entries and report the entries they cannot
+//! read instead of failing the whole archive.
 
+mod rar;
 mod sevenz;
 mod tar;
 mod zip;
 
+use std::collections::HashMap;
+
+pub use crate::types::ArchiveEntryError;
+
 // Re-export all public functions for backward compatibility
-pub use sevenz::{extract_7z_metadata, extract_7z_text_content};
+pub use rar::{extract_rar_metadata, extract_rar_text_content};
+pub use sevenz::{
+    extract_7z_metadata, extract_7z_metadata_with_passwords, extract_7z_text_content,
+    extract_7z_text_content_with_passwords,
+};
 pub use tar::{extract_tar_metadata, extract_tar_text_content};
-pub use zip::{extract_zip_metadata, extract_zip_text_content};
+pub use zip::{extract_zip_metadata, extract_zip_text_content, extract_zip_text_content_with_passwords};
 
 /// Archive metadata extracted from an archive file.
 #[derive(Debug, Clone)]
@@ -41,11 +53,26 @@ pub struct ArchiveEntry {
     pub is_dir: bool,
 }
 
+/// Text content of an archive, with the text entries that could not be read.
+#[derive(Debug, Clone, Default)]
+pub struct ArchiveTextContent {
+    /// Content of the text entries, keyed by path
+    pub contents: HashMap<String, String>,
+    /// Text entries that could not be read, such as encrypted entries no password decrypts
+    pub entry_errors: Vec<ArchiveEntryError>,
+}
+
 /// Common text file extensions that should be extracted from archives.
 pub(crate) const TEXT_EXTENSIONS: &[&str] = &[
     ".txt", ".md", ".json", ".xml", ".html", ".csv", ".log", ".yaml", ".toml",
 ];
 
+/// Whether the entry at `path` has one of the [`TEXT_EXTENSIONS`].
+pub(crate) fn is_text_path(path: &str) -> bool {
+    let lower = path.to_lowercase();
+    TEXT_EXTENSIONS.iter().any(|ext| lower.ends_with(ext))
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -0,0 +1,460 @@
+//! RAR archive extraction.
+//!
+//! Reads the headers of RAR 4 and RAR 5 archives to list their entries. There is no
+//! decompressor for the RAR algorithms, so text content is only read from entries that are
+//! stored without compression and encryption; the other text entries are reported in
+//! `entry_errors`.
+
+use super::{ArchiveEntry, ArchiveEntryError, ArchiveMetadata, ArchiveTextContent, is_text_path};
+use crate::error::{KreuzbergError, Result};
+
+const RAR4_SIGNATURE: &[u8] = b"Rar!\x1a\x07\x00";
+const RAR5_SIGNATURE: &[u8] = b"Rar!\x1a\x07\x01\x00";
+
+/// An entry as read from its header.
+struct RarEntry {
+    path: String,
+    size: u64,
+    is_dir: bool,
+    encrypted: bool,
+    /// Whether the data is stored without compression.
+    stored: bool,
+    /// Whether the data continues in another volume.
+    split: bool,
+    /// Range of the entry data in the archive bytes.
+    data: std::ops::Range<usize>,
+}
+
+/// Extract metadata from a RAR archive.
+///
+/// # Arguments
+///
+/// * `bytes` - The RAR archive bytes (RAR 4 or RAR 5)
+///
+/// # Returns
+///
+/// Returns `ArchiveMetadata` containing:
+/// - Format: "RAR"
+/// - File list with paths, sizes, and directory flags
+/// - Total file count
+/// - Total uncompressed size
+///
+/// # Errors
+///
+/// Returns an error if the archive is not a RAR archive, is truncated, or has encrypted
+/// headers.
+pub fn extract_rar_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
+    let entries = read_rar_entries(bytes)?;
+
+    let mut total_size = 0u64;
+    let file_list: Vec<ArchiveEntry> = entries
+        .into_iter()
+        .map(|entry| {
+            if !entry.is_dir {
+                total_size += entry.size;
+            }
+            ArchiveEntry {
+                path: entry.path,
+                size: entry.size,
+                is_dir: entry.is_dir,
+            }
+        })
+        .collect();
+
+    Ok(ArchiveMetadata {
+        format: "RAR".to_string(),
+        file_count: file_list.len(),
+        file_list,
+        total_size,
+    })
+}
+
+/// Extract text content from files within a RAR archive.
+///
+/// Only stored (uncompressed), unencrypted files with common text extensions are read.
+/// Text files that are compressed, encrypted or split across volumes are reported in
+/// `entry_errors`.
+///
+/// # Errors
+///
+/// Returns an error if the archive is not a RAR archive, is truncated, or has encrypted
+/// headers.
+pub fn extract_rar_text_content(bytes: &[u8]) -> Result<ArchiveTextContent> {
+    let mut result = ArchiveTextContent::default();
+
+    for entry in read_rar_entries(bytes)? {
+        if entry.is_dir || !is_text_path(&entry.path) {
+            continue;
+        }
+        let error = if entry.encrypted {
+            "decrypting RAR entries is not supported"
+        } else if entry.split {
+            "entry is split across RAR volumes"
+        } else if !entry.stored {
+            "RAR compression is not supported; only stored entries are read"
+        } else {
+            match bytes.get(entry.data.clone()).map(std::str::from_utf8) {
+                Some(Ok(text)) => {
+                    result.contents.insert(entry.path, text.to_string());
+                }
+                Some(Err(_)) => {}
+                None => result.entry_errors.push(ArchiveEntryError {
+                    path: entry.path,
+                    error: "entry data is truncated".to_string(),
+                }),
+            }
+            continue;
+        };
+        result.entry_errors.push(ArchiveEntryError {
+            path: entry.path,
+            error: error.to_string(),
+        });
+    }
+
+    Ok(result)
+}
+
+fn read_rar_entries(bytes: &[u8]) -> Result<Vec<RarEntry>> {
+    if bytes.starts_with(RAR5_SIGNATURE) {
+        read_rar5_entries(bytes)
+    } else if bytes.starts_with(RAR4_SIGNATURE) {
+        read_rar4_entries(bytes)
+    } else {
+        Err(KreuzbergError::parsing("Failed to read RAR archive: missing RAR signature"))
+    }
+}
+
+fn truncated() -> KreuzbergError {
+    KreuzbergError::parsing("Failed to read RAR archive: truncated header")
+}
+
+fn encrypted_headers() -> KreuzbergError {
+    KreuzbergError::parsing("Failed to read RAR archive: the file list is encrypted")
+}
+
+/// Bounds-checked little-endian reader over header bytes.
+struct Reader<'a> {
+    bytes: &'a [u8],
+    pos: usize,
+}
+
+impl<'a> Reader<'a> {
+    fn new(bytes: &'a [u8], pos: usize) -> Self {
+        Self { bytes, pos }
+    }
+
+    fn take(&mut self, len: usize) -> Result<&'a [u8]> {
+        let end = self.pos.checked_add(len).ok_or_else(truncated)?;
+        let slice = self.bytes.get(self.pos..end).ok_or_else(truncated)?;
+        self.pos = end;
+        Ok(slice)
+    }
+
+    fn u8(&mut self) -> Result<u8> {
+        Ok(self.take(1)?[0])
+    }
+
+    fn u16(&mut self) -> Result<u16> {
+        let b = self.take(2)?;
+        Ok(u16::from_le_bytes([b[0], b[1]]))
+    }
+
+    fn u32(&mut self) -> Result<u32> {
+        let b = self.take(4)?;
+        Ok(u32::from_le_bytes([b[0], b[1], b[2], b[3]]))
+    }
+
+    /// A RAR 5 variable-length integer: 7 bits per byte, high bit set on all but the last.
+    fn vint(&mut self) -> Result<u64> {
+        let mut value = 0u64;
+        for shift in (0..64).step_by(7) {
+            let byte = self.u8()?;
+            value |= u64::from(byte & 0x7f) << shift;
+            if byte & 0x80 == 0 {
+                return Ok(value);
+            }
+        }
+        Err(truncated())
+    }
+}
+
+fn to_usize(value: u64) -> Result<usize> {
+    usize::try_from(value).map_err(|_| truncated())
+}
+
+/// Archive paths use `/` whatever the host that created the archive.
+fn normalize_path(name: &[u8]) -> String {
+    String::from_utf8_lossy(name).replace('\\', "/")
+}
+
+fn read_rar4_entries(bytes: &[u8]) -> Result<Vec<RarEntry>> {
+    const MAIN_HEAD: u8 = 0x73;
+    const FILE_HEAD: u8 = 0x74;
+    const END_HEAD: u8 = 0x7b;
+    const LONG_BLOCK: u16 = 0x8000;
+
+    let mut entries = Vec::new();
+    let mut pos = RAR4_SIGNATURE.len();
+
+    while pos < bytes.len() {
+        let mut reader = Reader::new(bytes, pos);
+        let _crc = reader.u16()?;
+        let head_type = reader.u8()?;
+        let flags = reader.u16()?;
+        let head_size = usize::from(reader.u16()?);
+        if head_size < 7 {
+            return Err(truncated());
+        }
+        let header = bytes.get(pos..pos + head_size).ok_or_else(truncated)?;
+
+        let mut add_size = 0u64;
+        if flags & LONG_BLOCK != 0 {
+            add_size = u64::from(Reader::new(header, 7).u32()?);
+        }
+
+        match head_type {
+            MAIN_HEAD if flags & 0x0080 != 0 => return Err(encrypted_headers()),
+            FILE_HEAD => {
+                let mut reader = Reader::new(header, 7);
+                let pack_low = reader.u32()?;
+                let unp_low = reader.u32()?;
+                let _host_os = reader.u8()?;
+                let _file_crc = reader.u32()?;
+                let _ftime = reader.u32()?;
+                let _unp_ver = reader.u8()?;
+                let method = reader.u8()?;
+                let name_size = usize::from(reader.u16()?);
+                let _attr = reader.u32()?;
+                let (mut pack_size, mut unp_size) = (u64::from(pack_low), u64::from(unp_low));
+                if flags & 0x0100 != 0 {
+                    pack_size |= u64::from(reader.u32()?) << 32;
+                    unp_size |= u64::from(reader.u32()?) << 32;
+                }
+                let mut name = reader.take(name_size)?;
+                if flags & 0x0200 != 0 {
+                    // Unicode names follow the ASCII name after a NUL byte; the ASCII part is
+                    // the name UnRAR falls back to.
+                    if let Some(nul) = name.iter().position(|&b| b == 0) {
+                        name = &name[..nul];
+                    }
+                }
+                add_size = pack_size;
+
+                let data_start = pos + head_size;
+                entries.push(RarEntry {
+                    path: normalize_path(name),
+                    size: unp_size,
+                    is_dir: flags & 0x00e0 == 0x00e0,
+                    encrypted: flags & 0x0004 != 0,
+                    stored: method == 0x30,
+                    split: flags & 0x0003 != 0,
+                    data: data_start..data_start.saturating_add(to_usize(pack_size)?),
+                });
+            }
+            END_HEAD => break,
+            _ => {}
+        }
+
+        pos = pos
+            .checked_add(head_size)
+            .and_then(|p| p.checked_add(to_usize(add_size).ok()?))
+            .ok_or_else(truncated)?;
+    }
+
+    Ok(entries)
+}
+
+fn read_rar5_entries(bytes: &[u8]) -> Result<Vec<RarEntry>> {
+    const FILE_HEADER: u64 = 2;
+    const ENCRYPTION_HEADER: u64 = 4;
+    const END_HEADER: u64 = 5;
+    const FILE_ENCRYPTION_RECORD: u64 = 0x01;
+
+    let mut entries = Vec::new();
+    let mut pos = RAR5_SIGNATURE.len();
+
+    while pos < bytes.len() {
+        let mut reader = Reader::new(bytes, pos);
+        let _crc = reader.u32()?;
+        let header_size = to_usize(reader.vint()?)?;
+        let header_start = reader.pos;
+        let header_end = header_start.checked_add(header_size).ok_or_else(truncated)?;
+        let header = bytes.get(..header_end).ok_or_else(truncated)?;
+
+        let mut reader = Reader::new(header, header_start);
+        let header_type = reader.vint()?;
+        let header_flags = reader.vint()?;
+        let extra_size = if header_flags & 0x0001 != 0 {
+            to_usize(reader.vint()?)?
+        } else {
+            0
+        };
+        let data_size = if header_flags & 0x0002 != 0 {
+            to_usize(reader.vint()?)?
+        } else {
+            0
+        };
+
+        match header_type {
+            ENCRYPTION_HEADER => return Err(encrypted_headers()),
+            FILE_HEADER => {
+                let file_flags = reader.vint()?;
+                let unpacked_size = reader.vint()?;
+                let _attributes = reader.vint()?;
+                if file_flags & 0x0002 != 0 {
+                    reader.u32()?;
+                }
+                if file_flags & 0x0004 != 0 {
+                    reader.u32()?;
+                }
+                let compression = reader.vint()?;
+                let _host_os = reader.vint()?;
+                let name_length = to_usize(reader.vint()?)?;
+                let name = reader.take(name_length)?;
+
+                let extra_start = header_end.checked_sub(extra_size).ok_or_else(truncated)?;
+                let mut extra = Reader::new(header, extra_start);
+                let mut encrypted = false;
+                while extra.pos < header_end {
+                    let record_size = to_usize(extra.vint()?)?;
+                    let record_start = extra.pos;
+                    if extra.vint()? == FILE_ENCRYPTION_RECORD {
+                        encrypted = true;
+                    }
+                    extra.pos = record_start.checked_add(record_size).ok_or_else(truncated)?;
+                }
+
+                entries.push(RarEntry {
+                    path: normalize_path(name),
+                    size: unpacked_size,
+                    is_dir: file_flags & 0x0001 != 0,
+                    encrypted,
+                    stored: (compression >> 7) & 0x7 == 0,
+                    split: header_flags & 0x0018 != 0,
+                    data: header_end..header_end.saturating_add(data_size),
+                });
+            }
+            END_HEADER => break,
+            _ => {}
+        }
+
+        pos = header_end.checked_add(data_size).ok_or_else(truncated)?;
+    }
+
+    Ok(entries)
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn vint(mut value: u64, out: &mut Vec<u8>) {
+        loop {
+            let byte = (value & 0x7f) as u8;
+            value >>= 7;
+            if value == 0 {
+                out.push(byte);
+                return;
+            }
+            out.push(byte | 0x80);
+        }
+    }
+
+    /// A RAR 5 block: CRC (unchecked), header size, header, data.
+    fn rar5_block(header: &[u8], data: &[u8], out: &mut Vec<u8>) {
+        out.extend_from_slice(&[0; 4]);
+        vint(header.len() as u64, out);
+        out.extend_from_slice(header);
+        out.extend_from_slice(data);
+    }
+
+    fn rar5_file(name: &str, data: &[u8], compression: u64, encrypted: bool, out: &mut Vec<u8>) {
+        let mut extra = Vec::new();
+        if encrypted {
+            // File encryption record (type 0x01); its fields are not read.
+            let record = [0x01, 0, 0];
+            vint(record.len() as u64, &mut extra);
+            extra.extend_from_slice(&record);
+        }
+        let mut header = Vec::new();
+        vint(2, &mut header);
+        vint(if encrypted { 0x0003 } else { 0x0002 }, &mut header);
+        if encrypted {
+            vint(extra.len() as u64, &mut header);
+        }
+        vint(data.len() as u64, &mut header);
+        vint(0, &mut header);
+        vint(data.len() as u64, &mut header);
+        vint(0, &mut header);
+        vint(compression, &mut header);
+        vint(0, &mut header);
+        vint(name.len() as u64, &mut header);
+        header.extend_from_slice(name.as_bytes());
+        header.extend_from_slice(&extra);
+        rar5_block(&header, data, out);
+    }
+
+    fn rar5_archive() -> Vec<u8> {
+        let mut bytes = RAR5_SIGNATURE.to_vec();
+        rar5_block(&[1, 0, 0], &[], &mut bytes);
+        rar5_file("docs\\readme.txt", b"stored text", 0, false, &mut bytes);
+        rar5_file("packed.txt", b"\x01\x02", 3 << 7, false, &mut bytes);
+        rar5_file("secret.txt", b"\x03\x04", 0, true, &mut bytes);
+        rar5_block(&[5, 0, 0], &[], &mut bytes);
+        bytes
+    }
+
+    #[test]
+    fn test_rar5_metadata() {
+        let metadata = extract_rar_metadata(&rar5_archive()).unwrap();
+        assert_eq!(metadata.format, "RAR");
+        assert_eq!(metadata.file_count, 3);
+        assert_eq!(metadata.file_list[0].path, "docs/readme.txt");
+        assert_eq!(metadata.total_size, 11 + 2 + 2);
+    }
+
+    #[test]
+    fn test_rar5_text_content_reports_unreadable_entries() {
+        let content = extract_rar_text_content(&rar5_archive()).unwrap();
+        assert_eq!(
+            content.contents.get("docs/readme.txt").map(String::as_str),
+            Some("stored text")
+        );
+        let failed: Vec<&str> = content.entry_errors.iter().map(|e| e.path.as_str()).collect();
+        assert_eq!(failed, ["packed.txt", "secret.txt"]);
+    }
+
+    #[test]
+    fn test_rar4_stored_entry() {
+        let name = b"notes.txt";
+        let data = b"plain";
+        let mut bytes = RAR4_SIGNATURE.to_vec();
+        bytes.extend_from_slice(&[0, 0, 0x73, 0, 0, 13, 0, 0, 0, 0, 0, 0, 0]);
+        let head_size = 32 + name.len() as u16;
+        bytes.extend_from_slice(&[0, 0, 0x74]);
+        bytes.extend_from_slice(&0x8000u16.to_le_bytes());
+        bytes.extend_from_slice(&head_size.to_le_bytes());
+        bytes.extend_from_slice(&(data.len() as u32).to_le_bytes());
+        bytes.extend_from_slice(&(data.len() as u32).to_le_bytes());
+        bytes.extend_from_slice(&[0; 9]);
+        bytes.extend_from_slice(&[20, 0x30]);
+        bytes.extend_from_slice(&(name.len() as u16).to_le_bytes());
+        bytes.extend_from_slice(&[0; 4]);
+        bytes.extend_from_slice(name);
+        bytes.extend_from_slice(data);
+        bytes.extend_from_slice(&[0, 0, 0x7b, 0, 0x40, 7, 0]);
+
+        let metadata = extract_rar_metadata(&bytes).unwrap();
+        assert_eq!(metadata.file_count, 1);
+        assert_eq!(metadata.file_list[0].path, "notes.txt");
+        let content = extract_rar_text_content(&bytes).unwrap();
+        assert_eq!(content.contents.get("notes.txt").map(String::as_str), Some("plain"));
+        assert!(content.entry_errors.is_empty());
+    }
+
+    #[test]
+    fn test_rar_rejects_other_formats() {
+        assert!(extract_rar_metadata(b"PK\x03\x04").is_err());
+        assert!(extract_rar_metadata(&RAR5_SIGNATURE[..]).unwrap().file_list.is_empty());
+    }
+}
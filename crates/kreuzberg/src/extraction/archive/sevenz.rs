@@ -2,10 +2,10 @@
 //!
 //! Provides functions for extracting metadata and text content from 7Z archives.
 
-use super::{ArchiveEntry, ArchiveMetadata, TEXT_EXTENSIONS};
+use super::{ArchiveEntry, ArchiveEntryError, ArchiveMetadata, ArchiveTextContent, is_text_path};
 use crate::error::{KreuzbergError, Result};
 use sevenz_rust2::{ArchiveReader, Password};
-use std::collections::HashMap;
+use std::collections::{HashMap, HashSet};
 use std::io::Cursor;
 
 /// Extract metadata from a 7z archive.
@@ -26,9 +26,19 @@ use std::io::Cursor;
 ///
 /// Returns an error if the 7z archive cannot be read or parsed.
 pub fn extract_7z_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
-    let cursor = Cursor::new(bytes);
-    let archive = ArchiveReader::new(cursor, Password::empty())
-        .map_err(|e| KreuzbergError::parsing(format!("Failed to read 7z archive: {}", e)))?;
+    extract_7z_metadata_with_passwords(bytes, &[])
+}
+
+/// Extract metadata from a 7z archive whose file list may be encrypted.
+///
+/// The archive is opened without a password first, then with each of `passwords` in order.
+///
+/// # Errors
+///
+/// Returns an error if the 7z archive cannot be parsed, or if its file list is encrypted
+/// and none of the passwords decrypts it.
+pub fn extract_7z_metadata_with_passwords(bytes: &[u8], passwords: &[String]) -> Result<ArchiveMetadata> {
+    let archive = open_7z(bytes, passwords)?.0;
 
     let mut file_list = Vec::new();
     let mut total_size = 0u64;
@@ -66,33 +76,99 @@ pub fn extract_7z_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
 /// # Returns
 ///
 /// Returns a `HashMap` mapping file paths to their text content.
-/// Binary files and files with non-text extensions are excluded.
+/// Binary files, files with non-text extensions and encrypted files are excluded.
 ///
 /// # Errors
 ///
 /// Returns an error if the 7z archive cannot be read or parsed.
 pub fn extract_7z_text_content(bytes: &[u8]) -> Result<HashMap<String, String>> {
-    let cursor = Cursor::new(bytes);
-    let mut archive = ArchiveReader::new(cursor, Password::empty())
-        .map_err(|e| KreuzbergError::parsing(format!("Failed to read 7z archive: {}", e)))?;
-
-    let mut contents = HashMap::new();
+    Ok(extract_7z_text_content_with_passwords(bytes, &[])?.contents)
+}
 
-    archive
-        .for_each_entries(|entry, reader| {
-            let path = entry.name().to_string();
+/// Extract text content from files within a 7z archive, decrypting encrypted entries.
+///
+/// 7z encrypts whole solid blocks, so each password is tried in turn on the text entries
+/// that are still unread. Entries that none of them decrypts are reported in `entry_errors`
+/// instead of failing the archive.
+///
+/// # Errors
+///
+/// Returns an error if the 7z archive cannot be parsed, or if its file list is encrypted
+/// and none of the passwords decrypts it.
+pub fn extract_7z_text_content_with_passwords(bytes: &[u8], passwords: &[String]) -> Result<ArchiveTextContent> {
+    let (archive, opened_with) = open_7z(bytes, passwords)?;
+    let mut pending: HashSet<String> = archive
+        .archive()
+        .files
+        .iter()
+        .filter(|entry| !entry.is_directory() && is_text_path(entry.name()))
+        .map(|entry| entry.name().to_string())
+        .collect();
+    drop(archive);
 
-            if !entry.is_directory() && TEXT_EXTENSIONS.iter().any(|ext| path.to_lowercase().ends_with(ext)) {
+    let mut result = ArchiveTextContent::default();
+    for password in candidate_passwords(passwords).skip(opened_with) {
+        if pending.is_empty() {
+            break;
+        }
+        let Ok(mut archive) = ArchiveReader::new(Cursor::new(bytes), password) else {
+            continue;
+        };
+        // A wrong password fails the block it encrypts; entries read before that are kept.
+        let _ = archive.for_each_entries(|entry, reader| {
+            let path = entry.name();
+            if pending.contains(path) {
                 let mut content = Vec::new();
-                if let Ok(_) = reader.read_to_end(&mut content)
-                    && let Ok(text) = String::from_utf8(content)
-                {
-                    contents.insert(path, text);
+                if reader.read_to_end(&mut content).is_ok() {
+                    pending.remove(path);
+                    if let Ok(text) = String::from_utf8(content) {
+                        result.contents.insert(path.to_string(), text);
+                    }
                 }
             }
             Ok(true)
+        });
+    }
+
+    let mut unread: Vec<String> = pending.into_iter().collect();
+    unread.sort();
+    let error = if passwords.is_empty() {
+        "entry is encrypted and no password was provided"
+    } else {
+        "none of the provided passwords decrypts the entry"
+    };
+    result.entry_errors = unread
+        .into_iter()
+        .map(|path| ArchiveEntryError {
+            path,
+            error: error.to_string(),
         })
-        .map_err(|e| KreuzbergError::parsing(format!("Failed to read 7z entries: {}", e)))?;
+        .collect();
+
+    Ok(result)
+}
 
-    Ok(contents)
+/// The empty password followed by `passwords`.
+fn candidate_passwords(passwords: &[String]) -> impl Iterator<Item = Password> + '_ {
+    std::iter::once(Password::empty()).chain(passwords.iter().map(|password| Password::from(password.as_str())))
+}
+
+/// Opens a 7z archive with the first candidate password that decrypts its headers, returning
+/// the reader and the index of that candidate.
+fn open_7z<'a>(bytes: &'a [u8], passwords: &[String]) -> Result<(ArchiveReader<Cursor<&'a [u8]>>, usize)> {
+    let mut first_error = None;
+    for (index, password) in candidate_passwords(passwords).enumerate() {
+        match ArchiveReader::new(Cursor::new(bytes), password) {
+            Ok(archive) => return Ok((archive, index)),
+            Err(e) => {
+                first_error.get_or_insert(e);
+            }
+        }
+    }
+    let message = match first_error {
+        Some(e) if !passwords.is_empty() => format!("{} (none of the provided passwords decrypts it)", e),
+        Some(e) => e.to_string(),
+        None => "no password to try".to_string(),
+    };
+    Err(KreuzbergError::parsing(format!("Failed to read 7z archive: {}", message)))
 }
@@ -1,19 +1,47 @@
 //! TAR archive extraction.
 //!
 //! Provides functions for extracting metadata and text content from TAR archives.
-//! Supports plain TAR as well as compressed variants (TAR.GZ, TAR.BZ2).
+//! Supports plain TAR as well as zstd-compressed TAR (TAR.ZST).
 
-use super::{ArchiveEntry, ArchiveMetadata, TEXT_EXTENSIONS};
+use super::{ArchiveEntry, ArchiveMetadata, is_text_path};
 use crate::error::{KreuzbergError, Result};
+use std::borrow::Cow;
 use std::collections::HashMap;
 use std::io::{Cursor, Read};
 use tar::Archive as TarArchive;
 
+/// Magic number of a zstd frame.
+const ZSTD_MAGIC: [u8; 4] = [0x28, 0xB5, 0x2F, 0xFD];
+
+/// Upper bound on the decompressed size of a TAR.ZST archive, against decompression bombs.
+const MAX_DECOMPRESSED_SIZE: u64 = 1024 * 1024 * 1024;
+
+/// Returns the TAR stream of `bytes`, decompressing it first if it is zstd-compressed.
+fn decompress_tar(bytes: &[u8]) -> Result<Cow<'_, [u8]>> {
+    if !bytes.starts_with(&ZSTD_MAGIC) {
+        return Ok(Cow::Borrowed(bytes));
+    }
+    let decoder = zstd::stream::read::Decoder::new(bytes)
+        .map_err(|e| KreuzbergError::parsing(format!("Failed to read zstd stream: {}", e)))?;
+    let mut tar = Vec::new();
+    decoder
+        .take(MAX_DECOMPRESSED_SIZE + 1)
+        .read_to_end(&mut tar)
+        .map_err(|e| KreuzbergError::parsing(format!("Failed to decompress zstd stream: {}", e)))?;
+    if tar.len() as u64 > MAX_DECOMPRESSED_SIZE {
+        return Err(KreuzbergError::parsing(format!(
+            "Decompressed TAR exceeds {} bytes",
+            MAX_DECOMPRESSED_SIZE
+        )));
+    }
+    Ok(Cow::Owned(tar))
+}
+
 /// Extract metadata from a TAR archive.
 ///
 /// # Arguments
 ///
-/// * `bytes` - The TAR archive bytes (can be compressed with gzip or bzip2)
+/// * `bytes` - The TAR archive bytes (can be compressed with zstd)
 ///
 /// # Returns
 ///
@@ -27,6 +55,8 @@ use tar::Archive as TarArchive;
 ///
 /// Returns an error if the TAR archive cannot be read or parsed.
 pub fn extract_tar_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
+    let bytes = decompress_tar(bytes)?;
+    let bytes = bytes.as_ref();
     let cursor = Cursor::new(bytes);
     let mut archive = TarArchive::new(cursor);
 
@@ -73,7 +103,7 @@ pub fn extract_tar_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
 ///
 /// # Arguments
 ///
-/// * `bytes` - The TAR archive bytes (can be compressed with gzip or bzip2)
+/// * `bytes` - The TAR archive bytes (can be compressed with zstd)
 ///
 /// # Returns
 ///
@@ -84,6 +114,8 @@ pub fn extract_tar_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
 ///
 /// Returns an error if the TAR archive cannot be read or parsed.
 pub fn extract_tar_text_content(bytes: &[u8]) -> Result<HashMap<String, String>> {
+    let bytes = decompress_tar(bytes)?;
+    let bytes = bytes.as_ref();
     let cursor = Cursor::new(bytes);
     let mut archive = TarArchive::new(cursor);
 
@@ -104,8 +136,7 @@ pub fn extract_tar_text_content(bytes: &[u8]) -> Result<HashMap<String, String>>
             .to_string_lossy()
             .to_string();
 
-        if !entry.header().entry_type().is_dir() && TEXT_EXTENSIONS.iter().any(|ext| path.to_lowercase().ends_with(ext))
-        {
+        if !entry.header().entry_type().is_dir() && is_text_path(&path) {
             let estimated_size = (entry.size().min(10 * 1024 * 1024)) as usize;
             let mut content = String::with_capacity(estimated_size);
             if entry.read_to_string(&mut content).is_ok() {
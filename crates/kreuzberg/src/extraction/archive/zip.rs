@@ -2,7 +2,7 @@
 //!
 //! Provides functions for extracting metadata and text content from ZIP archives.
 
-use super::{ArchiveEntry, ArchiveMetadata, TEXT_EXTENSIONS};
+use super::{ArchiveEntry, ArchiveEntryError, ArchiveMetadata, ArchiveTextContent, is_text_path};
 use crate::error::{KreuzbergError, Result};
 use std::collections::HashMap;
 use std::io::{Cursor, Read};
@@ -34,8 +34,9 @@ pub fn extract_zip_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
     let mut total_size = 0u64;
 
     for i in 0..archive.len() {
+        // Raw access reads the entry header without decrypting, so encrypted entries are listed too.
         let file = archive
-            .by_index(i)
+            .by_index_raw(i)
             .map_err(|e| KreuzbergError::parsing(format!("Failed to read ZIP entry: {}", e)))?;
 
         let path = file.name().to_string();
@@ -68,34 +69,86 @@ pub fn extract_zip_metadata(bytes: &[u8]) -> Result<ArchiveMetadata> {
 /// # Returns
 ///
 /// Returns a `HashMap` mapping file paths to their text content.
-/// Binary files and files with non-text extensions are excluded.
+/// Binary files, files with non-text extensions and encrypted files are excluded.
 ///
 /// # Errors
 ///
 /// Returns an error if the ZIP archive cannot be read or parsed.
 pub fn extract_zip_text_content(bytes: &[u8]) -> Result<HashMap<String, String>> {
+    Ok(extract_zip_text_content_with_passwords(bytes, &[])?.contents)
+}
+
+/// Extract text content from files within a ZIP archive, decrypting encrypted entries.
+///
+/// Each encrypted text entry is tried with `passwords` in order (ZipCrypto and AES). Entries
+/// that none of them decrypts are reported in `entry_errors` instead of failing the archive.
+///
+/// # Errors
+///
+/// Returns an error if the ZIP archive cannot be read or parsed.
+pub fn extract_zip_text_content_with_passwords(bytes: &[u8], passwords: &[String]) -> Result<ArchiveTextContent> {
     let cursor = Cursor::new(bytes);
     let mut archive =
         ZipArchive::new(cursor).map_err(|e| KreuzbergError::parsing(format!("Failed to read ZIP archive: {}", e)))?;
 
     let estimated_text_files = archive.len().saturating_mul(3).saturating_div(10).max(2);
-    let mut contents = HashMap::with_capacity(estimated_text_files);
+    let mut result = ArchiveTextContent {
+        contents: HashMap::with_capacity(estimated_text_files),
+        entry_errors: Vec::new(),
+    };
 
     for i in 0..archive.len() {
-        let mut file = archive
-            .by_index(i)
-            .map_err(|e| KreuzbergError::parsing(format!("Failed to read ZIP entry: {}", e)))?;
+        let (path, is_dir, encrypted, size) = {
+            let file = archive
+                .by_index_raw(i)
+                .map_err(|e| KreuzbergError::parsing(format!("Failed to read ZIP entry: {}", e)))?;
+            (file.name().to_string(), file.is_dir(), file.encrypted(), file.size())
+        };
 
-        let path = file.name().to_string();
+        if is_dir || !is_text_path(&path) {
+            continue;
+        }
 
-        if !file.is_dir() && TEXT_EXTENSIONS.iter().any(|ext| path.to_lowercase().ends_with(ext)) {
-            let estimated_size = (file.size() as usize).min(10 * 1024 * 1024);
+        if !encrypted {
+            let mut file = archive
+                .by_index(i)
+                .map_err(|e| KreuzbergError::parsing(format!("Failed to read ZIP entry: {}", e)))?;
+            let estimated_size = (size as usize).min(10 * 1024 * 1024);
             let mut content = String::with_capacity(estimated_size);
             if file.read_to_string(&mut content).is_ok() {
-                contents.insert(path, content);
+                result.contents.insert(path, content);
+            }
+            continue;
+        }
+
+        if passwords.is_empty() {
+            result.entry_errors.push(ArchiveEntryError {
+                path,
+                error: "entry is encrypted and no password was provided".to_string(),
+            });
+            continue;
+        }
+
+        let decrypted = passwords.iter().find_map(|password| {
+            let mut file = archive.by_index_decrypt(i, password.as_bytes()).ok()?;
+            let mut data = Vec::with_capacity((size as usize).min(10 * 1024 * 1024));
+            // ZipCrypto accepts about one wrong password in 256; the CRC check on read rejects it.
+            file.read_to_end(&mut data).ok()?;
+            Some(data)
+        });
+
+        match decrypted {
+            Some(data) => {
+                if let Ok(content) = String::from_utf8(data) {
+                    result.contents.insert(path, content);
+                }
             }
+            None => result.entry_errors.push(ArchiveEntryError {
+                path,
+                error: "none of the provided passwords decrypts the entry".to_string(),
+            }),
         }
     }
 
-    Ok(contents)
+    Ok(result)
 }
@@ -0,0 +1,342 @@
+//! Compound File Binary (OLE2) reader.
+//!
+//! Compound files are the container of the pre-2007 Office formats. [`CompoundFile`]
+//! reads their streams by name, which is all the legacy Office readers need. Every
+//! sector chain is bounded by the number of sectors in the file, so looping or truncated
+//! chains are rejected instead of followed.
+
+use crate::{KreuzbergError, Result};
+
+/// Signature opening every compound file.
+pub const CFB_SIGNATURE: [u8; 8] = [0xD0, 0xCF, 0x11, 0xE0, 0xA1, 0xB1, 0x1A, 0xE1];
+
+const END_OF_CHAIN: u32 = 0xFFFF_FFFE;
+const HEADER_DIFAT_ENTRIES: usize = 109;
+const ENTRY_SIZE: usize = 128;
+const ENTRY_STREAM: u8 = 2;
+const ENTRY_ROOT: u8 = 5;
+const MINI_SECTOR_SIZE: usize = 64;
+
+#[derive(Debug, Clone, Default)]
+struct DirectoryEntry {
+    name: String,
+    kind: u8,
+    start: u32,
+    size: u64,
+}
+
+/// A parsed compound file, borrowing its bytes.
+#[derive(Debug)]
+pub struct CompoundFile<'a> {
+    data: &'a [u8],
+    sector_size: usize,
+    sector_count: usize,
+    fat: Vec<u32>,
+    mini_fat: Vec<u32>,
+    mini_stream: Vec<u8>,
+    mini_cutoff: u64,
+    entries: Vec<DirectoryEntry>,
+}
+
+/// Reports whether `data` starts with the compound file signature.
+pub fn is_compound_file(data: &[u8]) -> bool {
+    data.starts_with(&CFB_SIGNATURE)
+}
+
+fn read_u16(data: &[u8], at: usize) -> u16 {
+    u16::from_le_bytes([data[at], data[at + 1]])
+}
+
+fn read_u32(data: &[u8], at: usize) -> u32 {
+    u32::from_le_bytes([data[at], data[at + 1], data[at + 2], data[at + 3]])
+}
+
+fn read_u64(data: &[u8], at: usize) -> u64 {
+    let mut bytes = [0u8; 8];
+    bytes.copy_from_slice(&data[at..at + 8]);
+    u64::from_le_bytes(bytes)
+}
+
+impl<'a> CompoundFile<'a> {
+    /// Parse the header, allocation tables and directory of a compound file.
+    ///
+    /// # Errors
+    ///
+    /// Returns `KreuzbergError::Parsing` if `data` is not a compound file or its
+    /// structures are out of range.
+    pub fn open(data: &'a [u8]) -> Result<Self> {
+        if data.len() < 512 || !is_compound_file(data) {
+            return Err(KreuzbergError::parsing("not a compound file"));
+        }
+        let shift = read_u16(data, 0x1E);
+        if shift != 9 && shift != 12 {
+            return Err(KreuzbergError::parsing(format!("invalid sector shift {}", shift)));
+        }
+        let sector_size = 1usize << shift;
+        // Sectors follow the header, which takes one sector; the last may be short.
+        let sector_count = (data.len() - 1) / sector_size;
+        if sector_count == 0 {
+            return Err(KreuzbergError::parsing("truncated compound file"));
+        }
+        let mut file = Self {
+            data,
+            sector_size,
+            sector_count,
+            fat: Vec::new(),
+            mini_fat: Vec::new(),
+            mini_stream: Vec::new(),
+            mini_cutoff: u64::from(read_u32(data, 0x38)),
+            entries: Vec::new(),
+        };
+
+        // The FAT sectors are listed in the header, then in the DIFAT chain.
+        let mut fat_sectors = Vec::new();
+        for i in 0..HEADER_DIFAT_ENTRIES {
+            let sector = read_u32(data, 0x4C + 4 * i);
+            if sector < END_OF_CHAIN {
+                fat_sectors.push(sector);
+            }
+        }
+        let mut difat = read_u32(data, 0x44);
+        let mut seen = 0;
+        while difat < END_OF_CHAIN && seen < sector_count {
+            let sector = file.sector(difat)?;
+            let per_sector = sector_size / 4 - 1;
+            if sector.len() < 4 * (per_sector + 1) {
+                return Err(KreuzbergError::parsing("truncated DIFAT sector"));
+            }
+            for i in 0..per_sector {
+                let s = read_u32(sector, 4 * i);
+                if s < END_OF_CHAIN {
+                    fat_sectors.push(s);
+                }
+            }
+            difat = read_u32(sector, 4 * per_sector);
+            seen += 1;
+        }
+        if fat_sectors.len() > sector_count {
+            return Err(KreuzbergError::parsing("more FAT sectors than sectors"));
+        }
+        for s in fat_sectors {
+            let sector = file.sector(s)?;
+            file.fat.extend(sector.chunks_exact(4).map(|entry| read_u32(entry, 0)));
+        }
+
+        let directory = file
+            .chain(read_u32(data, 0x30), 0)
+            .map_err(|e| KreuzbergError::parsing(format!("directory: {}", e)))?;
+        for raw in directory.chunks_exact(ENTRY_SIZE) {
+            let name_len = usize::from(read_u16(raw, 0x40));
+            if !(2..=64).contains(&name_len) {
+                file.entries.push(DirectoryEntry::default());
+                continue;
+            }
+            let units: Vec<u16> = (0..name_len / 2 - 1).map(|i| read_u16(raw, 2 * i)).collect();
+            file.entries.push(DirectoryEntry {
+                name: String::from_utf16_lossy(&units),
+                kind: raw[0x42],
+                start: read_u32(raw, 0x74),
+                size: read_u64(raw, 0x78) & 0xFFFF_FFFF,
+            });
+        }
+        let root = match file.entries.first() {
+            Some(entry) if entry.kind == ENTRY_ROOT => entry.clone(),
+            _ => return Err(KreuzbergError::parsing("missing root entry")),
+        };
+
+        file.mini_stream = file
+            .chain(root.start, root.size)
+            .map_err(|e| KreuzbergError::parsing(format!("mini stream: {}", e)))?;
+        let mini_fat = file
+            .chain(read_u32(data, 0x3C), 0)
+            .map_err(|e| KreuzbergError::parsing(format!("mini FAT: {}", e)))?;
+        file.mini_fat = mini_fat.chunks_exact(4).map(|entry| read_u32(entry, 0)).collect();
+        Ok(file)
+    }
+
+    fn sector(&self, n: u32) -> Result<&'a [u8]> {
+        let start = (n as usize + 1).saturating_mul(self.sector_size);
+        if start >= self.data.len() {
+            return Err(KreuzbergError::parsing(format!("sector {} out of range", n)));
+        }
+        // The last sector of a file may be short.
+        let end = (start + self.sector_size).min(self.data.len());
+        Ok(&self.data[start..end])
+    }
+
+    /// Read the sectors chained from `start`. A size of 0 reads the whole chain.
+    fn chain(&self, start: u32, size: u64) -> Result<Vec<u8>> {
+        let mut out = Vec::new();
+        let mut n = start;
+        let mut seen = 0;
+        while n < END_OF_CHAIN {
+            if seen > self.sector_count || n as usize >= self.fat.len() {
+                return Err(KreuzbergError::parsing("invalid sector chain"));
+            }
+            out.extend_from_slice(self.sector(n)?);
+            if size > 0 && out.len() as u64 >= size {
+                out.truncate(size as usize);
+                return Ok(out);
+            }
+            n = self.fat[n as usize];
+            seen += 1;
+        }
+        if size > out.len() as u64 {
+            return Err(KreuzbergError::parsing("stream shorter than its size"));
+        }
+        Ok(out)
+    }
+
+    fn mini_chain(&self, start: u32, size: u64) -> Result<Vec<u8>> {
+        let mut out = Vec::new();
+        let mut n = start;
+        let mut seen = 0;
+        while n < END_OF_CHAIN && (out.len() as u64) < size {
+            let offset = n as usize * MINI_SECTOR_SIZE;
+            if seen > self.mini_fat.len()
+                || n as usize >= self.mini_fat.len()
+                || offset + MINI_SECTOR_SIZE > self.mini_stream.len()
+            {
+                return Err(KreuzbergError::parsing("invalid mini sector chain"));
+            }
+            out.extend_from_slice(&self.mini_stream[offset..offset + MINI_SECTOR_SIZE]);
+            n = self.mini_fat[n as usize];
+            seen += 1;
+        }
+        if (out.len() as u64) < size {
+            return Err(KreuzbergError::parsing("stream shorter than its size"));
+        }
+        out.truncate(size as usize);
+        Ok(out)
+    }
+
+    /// Return the contents of the stream called `name`, wherever it is stored, or `None`
+    /// when there is no such stream or it cannot be read.
+    pub fn stream(&self, name: &str) -> Option<Vec<u8>> {
+        let entry = self
+            .entries
+            .iter()
+            .find(|entry| entry.kind == ENTRY_STREAM && entry.name == name)?;
+        if entry.size > self.data.len() as u64 {
+            return None;
+        }
+        if entry.size < self.mini_cutoff {
+            self.mini_chain(entry.start, entry.size).ok()
+        } else {
+            self.chain(entry.start, entry.size).ok()
+        }
+    }
+}
+
+#[cfg(test)]
+pub(crate) mod test_support {
+    //! Builders of small compound files for tests.
+
+    use super::*;
+
+    /// Build a version 3 compound file with 512-byte sectors. The mini stream cutoff is
+    /// 0, so every stream is stored in regular sectors.
+    pub(crate) fn compound_file(streams: &[(&str, &[u8])]) -> Vec<u8> {
+        const SECTOR_SIZE: usize = 512;
+        let mut sectors = Vec::new();
+        let mut fat: Vec<u32> = Vec::new();
+        let mut allocate = |data: &[u8], sectors: &mut Vec<u8>| -> u32 {
+            if data.is_empty() {
+                return END_OF_CHAIN;
+            }
+            let start = fat.len() as u32;
+            let count = data.len().div_ceil(SECTOR_SIZE);
+            for i in 0..count {
+                fat.push(if i < count - 1 {
+                    start + i as u32 + 1
+                } else {
+                    END_OF_CHAIN
+                });
+            }
+            let mut padded = data.to_vec();
+            padded.resize(count * SECTOR_SIZE, 0);
+            sectors.extend_from_slice(&padded);
+            start
+        };
+
+        let entry = |name: &str, kind: u8, start: u32, size: usize| -> Vec<u8> {
+            let mut out = vec![0u8; ENTRY_SIZE];
+            let units: Vec<u16> = name.encode_utf16().collect();
+            for (i, unit) in units.iter().enumerate() {
+                out[2 * i..2 * i + 2].copy_from_slice(&unit.to_le_bytes());
+            }
+            out[0x40..0x42].copy_from_slice(&((2 * units.len() + 2) as u16).to_le_bytes());
+            out[0x42] = kind;
+            out[0x74..0x78].copy_from_slice(&start.to_le_bytes());
+            out[0x78..0x80].copy_from_slice(&(size as u64).to_le_bytes());
+            out
+        };
+        let mut directory = entry("Root Entry", ENTRY_ROOT, END_OF_CHAIN, 0);
+        for &(name, data) in streams {
+            let start = allocate(data, &mut sectors);
+            directory.extend(entry(name, ENTRY_STREAM, start, data.len()));
+        }
+        let directory_start = allocate(&directory, &mut sectors);
+
+        let fat_sector = fat.len() as u32;
+        fat.push(0xFFFF_FFFD);
+        let mut table = vec![0u8; SECTOR_SIZE];
+        for i in 0..SECTOR_SIZE / 4 {
+            let value = fat.get(i).copied().unwrap_or(0xFFFF_FFFF);
+            table[4 * i..4 * i + 4].copy_from_slice(&value.to_le_bytes());
+        }
+        sectors.extend_from_slice(&table);
+
+        let mut header = vec![0u8; SECTOR_SIZE];
+        header[..8].copy_from_slice(&CFB_SIGNATURE);
+        header[0x18..0x1A].copy_from_slice(&0x3Eu16.to_le_bytes());
+        header[0x1A..0x1C].copy_from_slice(&3u16.to_le_bytes());
+        header[0x1C..0x1E].copy_from_slice(&0xFFFEu16.to_le_bytes());
+        header[0x1E..0x20].copy_from_slice(&9u16.to_le_bytes());
+        header[0x20..0x22].copy_from_slice(&6u16.to_le_bytes());
+        header[0x2C..0x30].copy_from_slice(&1u32.to_le_bytes());
+        header[0x30..0x34].copy_from_slice(&directory_start.to_le_bytes());
+        header[0x3C..0x40].copy_from_slice(&END_OF_CHAIN.to_le_bytes());
+        header[0x44..0x48].copy_from_slice(&END_OF_CHAIN.to_le_bytes());
+        for i in 0..HEADER_DIFAT_ENTRIES {
+            header[0x4C + 4 * i..0x50 + 4 * i].copy_from_slice(&0xFFFF_FFFFu32.to_le_bytes());
+        }
+        header[0x4C..0x50].copy_from_slice(&fat_sector.to_le_bytes());
+        header.extend_from_slice(&sectors);
+        header
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::test_support::compound_file;
+    use super::*;
+
+    #[test]
+    fn test_reads_streams_by_name() {
+        let body: Vec<u8> = (0..1500u32).map(|i| (i % 251) as u8).collect();
+        let data = compound_file(&[("WordDocument", body.as_slice()), ("Other", &b"short"[..])]);
+        let file = CompoundFile::open(&data).expect("compound file should open");
+
+        assert_eq!(file.stream("WordDocument").as_deref(), Some(body.as_slice()));
+        assert_eq!(file.stream("Other").as_deref(), Some(&b"short"[..]));
+        assert!(file.stream("Missing").is_none());
+    }
+
+    #[test]
+    fn test_rejects_broken_chains() {
+        let data = compound_file(&[("WordDocument", &[0u8; 1500][..])]);
+        assert!(CompoundFile::open(&data).is_ok());
+
+        // Point the directory's FAT entry back at itself.
+        let fat_sector = read_u32(&data, 0x4C) as usize;
+        let directory = read_u32(&data, 0x30);
+        let mut looped = data.clone();
+        let at = (fat_sector + 1) * 512 + 4 * directory as usize;
+        looped[at..at + 4].copy_from_slice(&directory.to_le_bytes());
+        assert!(CompoundFile::open(&looped).is_err());
+
+        assert!(CompoundFile::open(&data[..600]).is_err());
+        assert!(CompoundFile::open(b"not a compound file").is_err());
+    }
+}
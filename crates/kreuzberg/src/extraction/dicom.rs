@@ -0,0 +1,981 @@
+//! DICOM Part 10 reader.
+//!
+//! Reads the dataset of a DICOM file in any of the uncompressed transfer syntaxes, including
+//! deflated datasets, and renders its patient, study and series attributes and the content
+//! tree of structured reports as text. The first frame of the pixel data can be returned as
+//! an image for OCR.
+
+use crate::core::config::DicomConfig;
+use crate::types::DicomMetadata;
+use crate::{KreuzbergError, Result};
+use std::collections::HashMap;
+
+// DICOM tags, as group << 16 | element.
+const TRANSFER_SYNTAX_UID: u32 = 0x0002_0010;
+const SPECIFIC_CHARACTER_SET: u32 = 0x0008_0005;
+const SOP_CLASS_UID: u32 = 0x0008_0016;
+const SOP_INSTANCE_UID: u32 = 0x0008_0018;
+const STUDY_DATE: u32 = 0x0008_0020;
+const ACCESSION_NUMBER: u32 = 0x0008_0050;
+const MODALITY: u32 = 0x0008_0060;
+const MANUFACTURER: u32 = 0x0008_0070;
+const INSTITUTION_NAME: u32 = 0x0008_0080;
+const REFERRING_PHYSICIAN_NAME: u32 = 0x0008_0090;
+const CODE_MEANING: u32 = 0x0008_0104;
+const STUDY_DESCRIPTION: u32 = 0x0008_1030;
+const SERIES_DESCRIPTION: u32 = 0x0008_103E;
+const PATIENT_NAME: u32 = 0x0010_0010;
+const PATIENT_ID: u32 = 0x0010_0020;
+const PATIENT_BIRTH_DATE: u32 = 0x0010_0030;
+const PATIENT_SEX: u32 = 0x0010_0040;
+const PATIENT_AGE: u32 = 0x0010_1010;
+const BODY_PART_EXAMINED: u32 = 0x0018_0015;
+const STUDY_INSTANCE_UID: u32 = 0x0020_000D;
+const SERIES_INSTANCE_UID: u32 = 0x0020_000E;
+const SAMPLES_PER_PIXEL: u32 = 0x0028_0002;
+#[cfg(feature = "ocr")]
+const PHOTOMETRIC_INTERPRETATION: u32 = 0x0028_0004;
+const PLANAR_CONFIGURATION: u32 = 0x0028_0006;
+const NUMBER_OF_FRAMES: u32 = 0x0028_0008;
+const ROWS: u32 = 0x0028_0010;
+const COLUMNS: u32 = 0x0028_0011;
+const BITS_ALLOCATED: u32 = 0x0028_0100;
+const PIXEL_REPRESENTATION: u32 = 0x0028_0103;
+const MEASUREMENT_UNITS_CODE_SEQUENCE: u32 = 0x0040_08EA;
+const VALUE_TYPE: u32 = 0x0040_A040;
+const CONCEPT_NAME_CODE_SEQUENCE: u32 = 0x0040_A043;
+const SR_DATE_TIME: u32 = 0x0040_A120;
+const SR_DATE: u32 = 0x0040_A121;
+const SR_TIME: u32 = 0x0040_A122;
+const SR_PERSON_NAME: u32 = 0x0040_A123;
+const SR_UID: u32 = 0x0040_A124;
+const TEXT_VALUE: u32 = 0x0040_A160;
+const CONCEPT_CODE_SEQUENCE: u32 = 0x0040_A168;
+const MEASURED_VALUE_SEQUENCE: u32 = 0x0040_A300;
+const NUMERIC_VALUE: u32 = 0x0040_A30A;
+const CONTENT_SEQUENCE: u32 = 0x0040_A730;
+const PIXEL_DATA: u32 = 0x7FE0_0010;
+const ITEM: u32 = 0xFFFE_E000;
+const ITEM_DELIMITATION: u32 = 0xFFFE_E00D;
+const SEQUENCE_DELIMITATION: u32 = 0xFFFE_E0DD;
+
+// Transfer syntaxes that change how the dataset or pixel data is read.
+const IMPLICIT_VR_LITTLE_ENDIAN: &str = "1.2.840.10008.1.2";
+const DEFLATED_LITTLE_ENDIAN: &str = "1.2.840.10008.1.2.1.99";
+const EXPLICIT_VR_BIG_ENDIAN: &str = "1.2.840.10008.1.2.2";
+#[cfg(feature = "ocr")]
+const JPEG_BASELINE: &str = "1.2.840.10008.1.2.4.50";
+#[cfg(feature = "ocr")]
+const JPEG_EXTENDED: &str = "1.2.840.10008.1.2.4.51";
+#[cfg(feature = "ocr")]
+const JPEG_2000_LOSSLESS: &str = "1.2.840.10008.1.2.4.90";
+#[cfg(feature = "ocr")]
+const JPEG_2000: &str = "1.2.840.10008.1.2.4.91";
+
+const UNDEFINED_LENGTH: u32 = 0xFFFF_FFFF;
+/// Maximum nesting of sequences.
+const MAX_DEPTH: usize = 32;
+/// Maximum size of a deflated dataset once inflated.
+const MAX_INFLATED: usize = 256 << 20;
+
+/// Tags removed by anonymization.
+const IDENTIFYING_TAGS: [u32; 6] = [
+    PATIENT_NAME,
+    PATIENT_ID,
+    PATIENT_BIRTH_DATE,
+    ACCESSION_NUMBER,
+    INSTITUTION_NAME,
+    REFERRING_PHYSICIAN_NAME,
+];
+
+/// Text, metadata and first frame of a DICOM object.
+pub struct DicomContent {
+    /// Summary of the patient, study and series, followed by the text of a structured report
+    pub text: String,
+    pub metadata: DicomMetadata,
+    /// First frame of the pixel data as an image an OCR backend can read, with its MIME type.
+    /// Only set when `DicomConfig::ocr_pixel_data` is.
+    pub frame: Option<(Vec<u8>, &'static str)>,
+    /// Patient identifiers to mask in free text when anonymizing
+    identifiers: Vec<String>,
+}
+
+impl DicomContent {
+    /// Mask the patient identifiers of the object in `text` when anonymizing, for text
+    /// recognized from the pixel data. Details that do not match the dataset's identifiers
+    /// are not detected.
+    pub fn mask_identifiers(&self, text: &str) -> String {
+        mask_identifiers(text, &self.identifiers)
+    }
+}
+
+/// Check whether `data` is a DICOM Part 10 file: a 128-byte preamble followed by "DICM".
+pub fn is_dicom(data: &[u8]) -> bool {
+    data.get(128..132) == Some(&b"DICM"[..])
+}
+
+/// Read a DICOM Part 10 file.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if `data` is not a DICOM file or its dataset is
+/// malformed.
+pub fn read_dicom(data: &[u8], config: &DicomConfig) -> Result<DicomContent> {
+    if !is_dicom(data) {
+        return Err(KreuzbergError::parsing("not a DICOM file: missing DICM prefix"));
+    }
+
+    let inflated;
+    let mut reader = Reader {
+        data,
+        pos: 132,
+        order: ByteOrder::LittleEndian,
+        explicit: true,
+        latin1: false,
+    };
+    let mut transfer_syntax = String::new();
+    while reader.data.get(reader.pos..reader.pos + 2) == Some(&[0x02, 0x00][..]) {
+        let (tag, element) = reader.read_element(0)?;
+        if tag == TRANSFER_SYNTAX_UID {
+            transfer_syntax = String::from_utf8_lossy(element.value)
+                .trim_end_matches([' ', '\0'])
+                .to_string();
+        }
+    }
+    match transfer_syntax.as_str() {
+        IMPLICIT_VR_LITTLE_ENDIAN => reader.explicit = false,
+        EXPLICIT_VR_BIG_ENDIAN => reader.order = ByteOrder::BigEndian,
+        DEFLATED_LITTLE_ENDIAN => {
+            inflated = inflate(&data[reader.pos..])?;
+            reader.data = &inflated;
+            reader.pos = 0;
+        }
+        _ => {}
+    }
+
+    let mut dataset = reader.read_dataset(reader.data.len(), false, 0)?;
+    let identifiers = if config.anonymize {
+        let identifiers = patient_identifiers(&dataset);
+        for tag in IDENTIFYING_TAGS {
+            dataset.elements.remove(&tag);
+        }
+        identifiers
+    } else {
+        Vec::new()
+    };
+    let mut metadata = dicom_metadata(&dataset, &transfer_syntax);
+    metadata.anonymized = config.anonymize;
+
+    let mut text = String::new();
+    write_summary(&mut text, &metadata);
+    if let Some(title) = &metadata.report_title {
+        let mut report = String::new();
+        write_report(&mut report, dataset.items(CONTENT_SEQUENCE), 0, config.anonymize);
+        text.push('\n');
+        text.push_str(title);
+        text.push('\n');
+        text.push_str(&mask_identifiers(&report, &identifiers));
+    }
+
+    #[cfg(feature = "ocr")]
+    let frame = if config.ocr_pixel_data {
+        frame_image(&dataset, &transfer_syntax)
+    } else {
+        None
+    };
+    #[cfg(not(feature = "ocr"))]
+    let frame = None;
+
+    Ok(DicomContent {
+        text,
+        metadata,
+        frame,
+        identifiers,
+    })
+}
+
+fn inflate(data: &[u8]) -> Result<Vec<u8>> {
+    use std::io::Read;
+
+    let mut inflated = Vec::new();
+    flate2::read::DeflateDecoder::new(data)
+        .take(MAX_INFLATED as u64 + 1)
+        .read_to_end(&mut inflated)
+        .map_err(|e| KreuzbergError::parsing_with_source("failed to inflate DICOM dataset", e))?;
+    if inflated.len() > MAX_INFLATED {
+        return Err(KreuzbergError::parsing("inflated DICOM dataset is too large"));
+    }
+    Ok(inflated)
+}
+
+#[derive(Clone, Copy)]
+enum ByteOrder {
+    LittleEndian,
+    BigEndian,
+}
+
+impl ByteOrder {
+    fn u16(self, bytes: &[u8]) -> u16 {
+        let bytes = [bytes[0], bytes[1]];
+        match self {
+            ByteOrder::LittleEndian => u16::from_le_bytes(bytes),
+            ByteOrder::BigEndian => u16::from_be_bytes(bytes),
+        }
+    }
+
+    fn u32(self, bytes: &[u8]) -> u32 {
+        let bytes = [bytes[0], bytes[1], bytes[2], bytes[3]];
+        match self {
+            ByteOrder::LittleEndian => u32::from_le_bytes(bytes),
+            ByteOrder::BigEndian => u32::from_be_bytes(bytes),
+        }
+    }
+}
+
+/// A data element. Sequences hold items; encapsulated pixel data holds fragments, the
+/// first being the basic offset table.
+struct Element<'a> {
+    vr: &'a [u8],
+    value: &'a [u8],
+    items: Vec<Dataset<'a>>,
+    #[cfg_attr(not(feature = "ocr"), allow(dead_code))]
+    fragments: Option<Vec<&'a [u8]>>,
+}
+
+/// A dataset or sequence item, keyed by tag.
+struct Dataset<'a> {
+    elements: HashMap<u32, Element<'a>>,
+    order: ByteOrder,
+    latin1: bool,
+}
+
+struct Reader<'a> {
+    data: &'a [u8],
+    pos: usize,
+    order: ByteOrder,
+    explicit: bool,
+    latin1: bool,
+}
+
+impl<'a> Reader<'a> {
+    fn error(&self, message: impl std::fmt::Display) -> KreuzbergError {
+        KreuzbergError::parsing(format!("malformed DICOM at offset {}: {}", self.pos, message))
+    }
+
+    /// Read a tag, its VR and its value length. Item and delimitation tags have no VR in
+    /// any transfer syntax; the VR of tags read from implicit VR datasets is looked up.
+    fn header(&mut self) -> Result<(u32, &'a [u8], u32)> {
+        let data = self.data;
+        let header = data
+            .get(self.pos..self.pos + 8)
+            .ok_or_else(|| self.error("truncated element header"))?;
+        let tag = (self.order.u16(header) as u32) << 16 | self.order.u16(&header[2..]) as u32;
+        if tag >> 16 == 0xFFFE || !self.explicit {
+            self.pos += 8;
+            let vr: &[u8] = if tag >> 16 == 0xFFFE { b"" } else { implicit_vr(tag) };
+            return Ok((tag, vr, self.order.u32(&header[4..])));
+        }
+        let vr = &header[4..6];
+        match vr {
+            b"OB" | b"OD" | b"OF" | b"OL" | b"OV" | b"OW" | b"SQ" | b"SV" | b"UC" | b"UN" | b"UR" | b"UT" | b"UV" => {
+                let length = data
+                    .get(self.pos + 8..self.pos + 12)
+                    .ok_or_else(|| self.error("truncated element header"))?;
+                let length = self.order.u32(length);
+                self.pos += 12;
+                Ok((tag, vr, length))
+            }
+            _ => {
+                self.pos += 8;
+                Ok((tag, vr, self.order.u16(&header[6..]) as u32))
+            }
+        }
+    }
+
+    /// Read the next `length` bytes.
+    fn read_value(&mut self, length: u32) -> Result<&'a [u8]> {
+        let length = length as usize;
+        if length > self.data.len() - self.pos {
+            return Err(self.error(format!("value length {} exceeds data", length)));
+        }
+        let value = &self.data[self.pos..self.pos + length];
+        self.pos += length;
+        Ok(value)
+    }
+
+    fn read_element(&mut self, depth: usize) -> Result<(u32, Element<'a>)> {
+        let (tag, vr, length) = self.header()?;
+        let mut element = Element {
+            vr,
+            value: &[],
+            items: Vec::new(),
+            fragments: None,
+        };
+        if tag >> 16 == 0xFFFE {
+            // Delimiters are handled by the caller.
+        } else if vr == b"SQ" || (vr == b"UN" && length == UNDEFINED_LENGTH) {
+            element.vr = b"SQ";
+            element.items = self.read_items(length, depth + 1)?;
+        } else if tag == PIXEL_DATA && length == UNDEFINED_LENGTH {
+            element.fragments = Some(self.read_fragments()?);
+        } else if length == UNDEFINED_LENGTH {
+            return Err(self.error(format!("undefined length for VR {}", String::from_utf8_lossy(vr))));
+        } else {
+            element.value = self.read_value(length)?;
+        }
+        if tag == SPECIFIC_CHARACTER_SET {
+            self.latin1 = memchr::memmem::find(element.value, b"ISO_IR 100").is_some();
+        }
+        Ok((tag, element))
+    }
+
+    /// Read elements up to `end`, or up to an item delimitation tag when `delimited` is set.
+    fn read_dataset(&mut self, end: usize, delimited: bool, depth: usize) -> Result<Dataset<'a>> {
+        let mut elements = HashMap::new();
+        while self.pos < end {
+            let (tag, element) = self.read_element(depth)?;
+            if tag == ITEM_DELIMITATION && delimited {
+                break;
+            }
+            if tag >> 16 != 0xFFFE {
+                elements.insert(tag, element);
+            }
+        }
+        Ok(Dataset {
+            elements,
+            order: self.order,
+            latin1: self.latin1,
+        })
+    }
+
+    fn read_items(&mut self, length: u32, depth: usize) -> Result<Vec<Dataset<'a>>> {
+        if depth > MAX_DEPTH {
+            return Err(self.error(format!("sequences nested deeper than {}", MAX_DEPTH)));
+        }
+        let end = if length == UNDEFINED_LENGTH {
+            self.data.len()
+        } else if length as usize > self.data.len() - self.pos {
+            return Err(self.error(format!("sequence length {} exceeds data", length)));
+        } else {
+            self.pos + length as usize
+        };
+
+        let mut items = Vec::new();
+        while self.pos < end {
+            let (tag, _, item_length) = self.header()?;
+            if tag == SEQUENCE_DELIMITATION {
+                break;
+            }
+            if tag != ITEM {
+                return Err(self.error(format!("expected sequence item, found tag {:08X}", tag)));
+            }
+            let (item_end, delimited) = if item_length == UNDEFINED_LENGTH {
+                (self.data.len(), true)
+            } else if item_length as usize > end.saturating_sub(self.pos) {
+                return Err(self.error(format!("item length {} exceeds sequence", item_length)));
+            } else {
+                (self.pos + item_length as usize, false)
+            };
+            items.push(self.read_dataset(item_end, delimited, depth)?);
+        }
+        Ok(items)
+    }
+
+    fn read_fragments(&mut self) -> Result<Vec<&'a [u8]>> {
+        let mut fragments = Vec::new();
+        loop {
+            let (tag, _, length) = self.header()?;
+            if tag == SEQUENCE_DELIMITATION {
+                return Ok(fragments);
+            }
+            if tag != ITEM {
+                return Err(self.error(format!("expected pixel data fragment, found tag {:08X}", tag)));
+            }
+            fragments.push(self.read_value(length)?);
+        }
+    }
+}
+
+/// VR of the tags read from implicit VR datasets, where the VR is not encoded. Other tags
+/// are read as UN.
+fn implicit_vr(tag: u32) -> &'static [u8] {
+    match tag {
+        MEASUREMENT_UNITS_CODE_SEQUENCE
+        | CONCEPT_NAME_CODE_SEQUENCE
+        | CONCEPT_CODE_SEQUENCE
+        | MEASURED_VALUE_SEQUENCE
+        | CONTENT_SEQUENCE => b"SQ",
+        SAMPLES_PER_PIXEL | PLANAR_CONFIGURATION | ROWS | COLUMNS | BITS_ALLOCATED | PIXEL_REPRESENTATION => b"US",
+        PIXEL_DATA => b"OW",
+        _ => b"UN",
+    }
+}
+
+impl Dataset<'_> {
+    /// Text value of `tag` with padding removed. Latin-1 text, the most common non-ASCII
+    /// character set, is converted to UTF-8.
+    fn str(&self, tag: u32) -> String {
+        let Some(element) = self.elements.get(&tag) else {
+            return String::new();
+        };
+        let text: String = if self.latin1 {
+            element.value.iter().map(|&b| b as char).collect()
+        } else {
+            String::from_utf8_lossy(element.value).into_owned()
+        };
+        text.trim_matches([' ', '\0']).to_string()
+    }
+
+    /// Value of a US or IS element.
+    fn int(&self, tag: u32) -> usize {
+        match self.elements.get(&tag) {
+            Some(element) if element.vr == b"US" => {
+                if element.value.len() < 2 {
+                    0
+                } else {
+                    self.order.u16(element.value) as usize
+                }
+            }
+            Some(_) => self.str(tag).parse().unwrap_or(0),
+            None => 0,
+        }
+    }
+
+    fn items(&self, tag: u32) -> &[Dataset<'_>] {
+        self.elements
+            .get(&tag)
+            .map(|element| element.items.as_slice())
+            .unwrap_or_default()
+    }
+
+    /// Meaning of the first code in a code sequence.
+    fn code_meaning(&self, tag: u32) -> String {
+        self.items(tag)
+            .first()
+            .map(|item| item.str(CODE_MEANING))
+            .unwrap_or_default()
+    }
+}
+
+fn dicom_metadata(d: &Dataset<'_>, transfer_syntax: &str) -> DicomMetadata {
+    let text = |value: String| Some(value).filter(|value| !value.is_empty());
+    let number = |value: usize| Some(value).filter(|&value| value > 0);
+    DicomMetadata {
+        patient_name: text(person_name(&d.str(PATIENT_NAME))),
+        patient_id: text(d.str(PATIENT_ID)),
+        patient_birth_date: text(date(&d.str(PATIENT_BIRTH_DATE))),
+        patient_sex: text(d.str(PATIENT_SEX)),
+        patient_age: text(d.str(PATIENT_AGE)),
+        study_instance_uid: text(d.str(STUDY_INSTANCE_UID)),
+        study_date: text(date(&d.str(STUDY_DATE))),
+        study_description: text(d.str(STUDY_DESCRIPTION)),
+        accession_number: text(d.str(ACCESSION_NUMBER)),
+        series_instance_uid: text(d.str(SERIES_INSTANCE_UID)),
+        series_description: text(d.str(SERIES_DESCRIPTION)),
+        modality: text(d.str(MODALITY)),
+        sop_class_uid: text(d.str(SOP_CLASS_UID)),
+        sop_instance_uid: text(d.str(SOP_INSTANCE_UID)),
+        manufacturer: text(d.str(MANUFACTURER)),
+        institution_name: text(d.str(INSTITUTION_NAME)),
+        referring_physician_name: text(person_name(&d.str(REFERRING_PHYSICIAN_NAME))),
+        body_part_examined: text(d.str(BODY_PART_EXAMINED)),
+        transfer_syntax_uid: text(transfer_syntax.to_string()),
+        report_title: d
+            .elements
+            .contains_key(&CONTENT_SEQUENCE)
+            .then(|| text(d.code_meaning(CONCEPT_NAME_CODE_SEQUENCE)).unwrap_or_else(|| "Report".to_string())),
+        rows: number(d.int(ROWS)),
+        columns: number(d.int(COLUMNS)),
+        number_of_frames: number(d.int(NUMBER_OF_FRAMES)),
+        has_pixel_data: d.elements.contains_key(&PIXEL_DATA),
+        anonymized: false,
+    }
+}
+
+fn write_summary(out: &mut String, meta: &DicomMetadata) {
+    let fields = [
+        ("Patient", &meta.patient_name),
+        ("Patient ID", &meta.patient_id),
+        ("Birth date", &meta.patient_birth_date),
+        ("Sex", &meta.patient_sex),
+        ("Age", &meta.patient_age),
+        ("Study", &meta.study_description),
+        ("Study date", &meta.study_date),
+        ("Accession number", &meta.accession_number),
+        ("Series", &meta.series_description),
+        ("Modality", &meta.modality),
+        ("Body part", &meta.body_part_examined),
+        ("Institution", &meta.institution_name),
+        ("Referring physician", &meta.referring_physician_name),
+        ("Manufacturer", &meta.manufacturer),
+    ];
+    for (label, value) in fields {
+        if let Some(value) = value {
+            out.push_str(label);
+            out.push_str(": ");
+            out.push_str(value);
+            out.push('\n');
+        }
+    }
+}
+
+/// Render the content tree of a structured report: containers as indented headings, other
+/// items as "Concept: value" lines. Person names are left out when anonymizing.
+fn write_report(out: &mut String, items: &[Dataset<'_>], depth: usize, anonymize: bool) {
+    let indent = "  ".repeat(depth);
+    for item in items {
+        let name = item.code_meaning(CONCEPT_NAME_CODE_SEQUENCE);
+        let value = match item.str(VALUE_TYPE).as_str() {
+            "CONTAINER" => {
+                if !name.is_empty() {
+                    out.push_str(&indent);
+                    out.push_str(&name);
+                    out.push('\n');
+                }
+                write_report(out, item.items(CONTENT_SEQUENCE), depth + 1, anonymize);
+                continue;
+            }
+            "TEXT" => item.str(TEXT_VALUE),
+            "CODE" => item.code_meaning(CONCEPT_CODE_SEQUENCE),
+            "NUM" => item
+                .items(MEASURED_VALUE_SEQUENCE)
+                .first()
+                .map(|measured| {
+                    let value = measured.str(NUMERIC_VALUE);
+                    let unit = measured.code_meaning(MEASUREMENT_UNITS_CODE_SEQUENCE);
+                    format!("{} {}", value, unit).trim().to_string()
+                })
+                .unwrap_or_default(),
+            "PNAME" if anonymize => continue,
+            "PNAME" => person_name(&item.str(SR_PERSON_NAME)),
+            "DATE" => date(&item.str(SR_DATE)),
+            "TIME" => item.str(SR_TIME),
+            "DATETIME" => item.str(SR_DATE_TIME),
+            "UIDREF" => item.str(SR_UID),
+            _ => String::new(),
+        };
+        if !value.is_empty() {
+            out.push_str(&indent);
+            if !name.is_empty() {
+                out.push_str(&name);
+                out.push_str(": ");
+            }
+            out.push_str(&value);
+            out.push('\n');
+        }
+        write_report(out, item.items(CONTENT_SEQUENCE), depth + 1, anonymize);
+    }
+}
+
+/// Format the alphabetic representation of a PN value, "Family^Given^Middle^Prefix^Suffix",
+/// in reading order.
+fn person_name(value: &str) -> String {
+    let alphabetic = value.split('=').next().unwrap_or_default();
+    let parts: Vec<&str> = alphabetic.split('^').map(str::trim).collect();
+    [3, 1, 2, 0, 4]
+        .iter()
+        .filter_map(|&i| parts.get(i).copied().filter(|part| !part.is_empty()))
+        .collect::<Vec<_>>()
+        .join(" ")
+}
+
+/// Format a DA value, YYYYMMDD, as YYYY-MM-DD.
+fn date(value: &str) -> String {
+    if value.len() != 8 || !value.is_ascii() {
+        return value.to_string();
+    }
+    format!("{}-{}-{}", &value[..4], &value[4..6], &value[6..])
+}
+
+/// Patient identifiers to mask in free text.
+fn patient_identifiers(d: &Dataset<'_>) -> Vec<String> {
+    let mut identifiers: Vec<String> = [d.str(PATIENT_ID), d.str(ACCESSION_NUMBER)]
+        .into_iter()
+        .filter(|value| value.len() >= 3)
+        .collect();
+    let name = d.str(PATIENT_NAME);
+    let alphabetic = name.split('=').next().unwrap_or_default();
+    identifiers.extend(
+        alphabetic
+            .split('^')
+            .map(str::trim)
+            .filter(|part| part.len() >= 3)
+            .map(str::to_string),
+    );
+    identifiers
+}
+
+/// Replace the patient identifiers in report and recognized text.
+fn mask_identifiers(text: &str, identifiers: &[String]) -> String {
+    identifiers.iter().fold(text.to_string(), |text, identifier| {
+        replace_fold(&text, identifier, "[REDACTED]")
+    })
+}
+
+/// Replace the case-insensitive occurrences of `old` in `text`.
+fn replace_fold(text: &str, old: &str, replacement: &str) -> String {
+    let (lower, lower_old) = (fold_case(text), fold_case(old));
+    let mut out = String::with_capacity(text.len());
+    let mut copied = 0;
+    for (start, _) in lower.match_indices(&lower_old) {
+        out.push_str(&text[copied..start]);
+        out.push_str(replacement);
+        copied = start + old.len();
+    }
+    out.push_str(&text[copied..]);
+    out
+}
+
+/// Lowercase the characters of `text` whose lowercase form has the same UTF-8 length, so
+/// that byte offsets into the result are offsets into `text`.
+fn fold_case(text: &str) -> String {
+    text.chars()
+        .map(|c| {
+            let mut lower = c.to_lowercase();
+            match (lower.next(), lower.next()) {
+                (Some(l), None) if l.len_utf8() == c.len_utf8() => l,
+                _ => c,
+            }
+        })
+        .collect()
+}
+
+/// First frame of the pixel data as an image an OCR backend can read: JPEG and JPEG 2000
+/// frames as they are, native 8- and 16-bit grayscale and 8-bit RGB frames encoded as PNG.
+/// Other pixel data, such as RLE or JPEG-LS, is skipped.
+#[cfg(feature = "ocr")]
+fn frame_image(d: &Dataset<'_>, transfer_syntax: &str) -> Option<(Vec<u8>, &'static str)> {
+    use image::{DynamicImage, GrayImage, ImageFormat, RgbImage};
+
+    let pixels = d.elements.get(&PIXEL_DATA)?;
+    if let Some(fragments) = &pixels.fragments {
+        let mime_type = match transfer_syntax {
+            JPEG_BASELINE | JPEG_EXTENDED => "image/jpeg",
+            JPEG_2000_LOSSLESS | JPEG_2000 => "image/jp2",
+            _ => return None,
+        };
+        // The first fragment is the basic offset table. A single frame may span several
+        // fragments; otherwise each frame is one fragment.
+        if fragments.len() < 2 {
+            return None;
+        }
+        if d.int(NUMBER_OF_FRAMES) > 1 {
+            return Some((fragments[1].to_vec(), mime_type));
+        }
+        return Some((fragments[1..].concat(), mime_type));
+    }
+
+    let (rows, columns) = (d.int(ROWS), d.int(COLUMNS));
+    let samples = d.int(SAMPLES_PER_PIXEL).max(1);
+    let bits = d.int(BITS_ALLOCATED);
+    let (width, height) = (u32::try_from(columns).ok()?, u32::try_from(rows).ok()?);
+    let n = rows.checked_mul(columns).filter(|&n| n > 0)?;
+    let value = pixels.value;
+    let image = match (samples, bits) {
+        (1, 8 | 16) => {
+            let mut gray = if bits == 8 && value.len() >= n {
+                value[..n].to_vec()
+            } else if bits == 16 && value.len() / 2 >= n {
+                window_16(&value[..2 * n], d.order, d.int(PIXEL_REPRESENTATION) == 1)
+            } else {
+                return None;
+            };
+            if d.str(PHOTOMETRIC_INTERPRETATION) == "MONOCHROME1" {
+                gray.iter_mut().for_each(|pixel| *pixel = 0xFF - *pixel);
+            }
+            DynamicImage::ImageLuma8(GrayImage::from_raw(width, height, gray)?)
+        }
+        (3, 8) if value.len() / 3 >= n => {
+            let rgb = if d.int(PLANAR_CONFIGURATION) == 1 {
+                (0..n)
+                    .flat_map(|i| [value[i], value[n + i], value[2 * n + i]])
+                    .collect()
+            } else {
+                value[..3 * n].to_vec()
+            };
+            DynamicImage::ImageRgb8(RgbImage::from_raw(width, height, rgb)?)
+        }
+        _ => return None,
+    };
+    let mut encoded = Vec::new();
+    image
+        .write_to(&mut std::io::Cursor::new(&mut encoded), ImageFormat::Png)
+        .ok()?;
+    Some((encoded, "image/png"))
+}
+
+/// Map 16-bit samples linearly from their range onto 8-bit gray.
+#[cfg(feature = "ocr")]
+fn window_16(value: &[u8], order: ByteOrder, signed: bool) -> Vec<u8> {
+    let samples: Vec<i32> = value
+        .chunks_exact(2)
+        .map(|bytes| {
+            let raw = order.u16(bytes);
+            if signed { raw as i16 as i32 } else { raw as i32 }
+        })
+        .collect();
+    let low = samples.iter().copied().min().unwrap_or_default();
+    let high = samples.iter().copied().max().unwrap_or_default();
+    if high <= low {
+        return vec![0; samples.len()];
+    }
+    samples
+        .iter()
+        .map(|&sample| ((sample - low) * 0xFF / (high - low)) as u8)
+        .collect()
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Encode an explicit VR little endian element, padding text values to an even length.
+    fn element(tag: u32, vr: &str, value: &[u8]) -> Vec<u8> {
+        let mut value = value.to_vec();
+        if value.len() % 2 == 1 {
+            value.push(b' ');
+        }
+        let mut out = [(tag >> 16) as u16, tag as u16]
+            .iter()
+            .flat_map(|half| half.to_le_bytes())
+            .collect::<Vec<u8>>();
+        out.extend_from_slice(vr.as_bytes());
+        if matches!(vr, "OB" | "OW" | "SQ" | "UN" | "UT") {
+            out.extend_from_slice(&[0, 0]);
+            out.extend_from_slice(&(value.len() as u32).to_le_bytes());
+        } else {
+            out.extend_from_slice(&(value.len() as u16).to_le_bytes());
+        }
+        out.extend_from_slice(&value);
+        out
+    }
+
+    /// Encode a sequence of undefined length with delimited items.
+    fn sequence(tag: u32, items: &[Vec<u8>]) -> Vec<u8> {
+        let mut out = element(tag, "SQ", &[]);
+        out.truncate(out.len() - 4);
+        out.extend_from_slice(&[0xFF; 4]);
+        for item in items {
+            out.extend_from_slice(&[0xFE, 0xFF, 0x00, 0xE0, 0xFF, 0xFF, 0xFF, 0xFF]);
+            out.extend_from_slice(item);
+            out.extend_from_slice(&[0xFE, 0xFF, 0x0D, 0xE0, 0, 0, 0, 0]);
+        }
+        out.extend_from_slice(&[0xFE, 0xFF, 0xDD, 0xE0, 0, 0, 0, 0]);
+        out
+    }
+
+    fn dicom_file(transfer_syntax: &str, dataset: &[Vec<u8>]) -> Vec<u8> {
+        let mut out = vec![0; 128];
+        out.extend_from_slice(b"DICM");
+        out.extend(element(TRANSFER_SYNTAX_UID, "UI", transfer_syntax.as_bytes()));
+        out.extend(dataset.concat());
+        out
+    }
+
+    fn code(meaning: &str) -> Vec<u8> {
+        element(CODE_MEANING, "LO", meaning.as_bytes())
+    }
+
+    fn report() -> Vec<u8> {
+        dicom_file(
+            "1.2.840.10008.1.2.1",
+            &[
+                element(STUDY_DATE, "DA", b"20240315"),
+                element(MODALITY, "CS", b"SR"),
+                element(PATIENT_NAME, "PN", b"Doe^Jane"),
+                element(PATIENT_ID, "LO", b"MRN12345"),
+                element(PATIENT_SEX, "CS", b"F"),
+                element(VALUE_TYPE, "CS", b"CONTAINER"),
+                sequence(CONCEPT_NAME_CODE_SEQUENCE, &[code("Radiology Report")]),
+                sequence(
+                    CONTENT_SEQUENCE,
+                    &[
+                        [
+                            element(VALUE_TYPE, "CS", b"PNAME"),
+                            sequence(CONCEPT_NAME_CODE_SEQUENCE, &[code("Observer")]),
+                            element(SR_PERSON_NAME, "PN", b"House^Gregory^^Dr"),
+                        ]
+                        .concat(),
+                        [
+                            element(VALUE_TYPE, "CS", b"CONTAINER"),
+                            sequence(CONCEPT_NAME_CODE_SEQUENCE, &[code("Findings")]),
+                            sequence(
+                                CONTENT_SEQUENCE,
+                                &[
+                                    [
+                                        element(VALUE_TYPE, "CS", b"TEXT"),
+                                        sequence(CONCEPT_NAME_CODE_SEQUENCE, &[code("Finding")]),
+                                        element(TEXT_VALUE, "UT", b"No acute abnormality. Patient MRN12345."),
+                                    ]
+                                    .concat(),
+                                    [
+                                        element(VALUE_TYPE, "CS", b"NUM"),
+                                        sequence(CONCEPT_NAME_CODE_SEQUENCE, &[code("Diameter")]),
+                                        sequence(
+                                            MEASURED_VALUE_SEQUENCE,
+                                            &[[
+                                                element(NUMERIC_VALUE, "DS", b"12.5"),
+                                                sequence(MEASUREMENT_UNITS_CODE_SEQUENCE, &[code("mm")]),
+                                            ]
+                                            .concat()],
+                                        ),
+                                    ]
+                                    .concat(),
+                                ],
+                            ),
+                        ]
+                        .concat(),
+                    ],
+                ),
+            ],
+        )
+    }
+
+    #[test]
+    fn test_read_dicom_structured_report() {
+        let content = read_dicom(&report(), &DicomConfig::default()).expect("report should be read");
+
+        let meta = &content.metadata;
+        assert_eq!(meta.patient_name.as_deref(), Some("Jane Doe"));
+        assert_eq!(meta.patient_id.as_deref(), Some("MRN12345"));
+        assert_eq!(meta.study_date.as_deref(), Some("2024-03-15"));
+        assert_eq!(meta.modality.as_deref(), Some("SR"));
+        assert_eq!(meta.report_title.as_deref(), Some("Radiology Report"));
+        assert!(!meta.anonymized);
+        for want in [
+            "Patient: Jane Doe",
+            "Radiology Report\nObserver: Dr Gregory House\nFindings\n  Finding: No acute abnormality.",
+            "  Diameter: 12.5 mm",
+        ] {
+            assert!(content.text.contains(want), "missing {:?} in:\n{}", want, content.text);
+        }
+    }
+
+    #[test]
+    fn test_read_dicom_anonymize() {
+        let config = DicomConfig {
+            anonymize: true,
+            ..Default::default()
+        };
+        let content = read_dicom(&report(), &config).expect("report should be read");
+
+        let meta = &content.metadata;
+        assert!(meta.patient_name.is_none() && meta.patient_id.is_none());
+        assert_eq!(meta.patient_sex.as_deref(), Some("F"));
+        assert!(meta.anonymized);
+        for leaked in ["Jane", "Doe", "Gregory", "MRN12345"] {
+            assert!(!content.text.contains(leaked), "leaks {:?}:\n{}", leaked, content.text);
+        }
+        assert_eq!(
+            content.mask_identifiers("Name: DOE, JANE id mrn12345"),
+            "Name: [REDACTED], [REDACTED] id [REDACTED]"
+        );
+    }
+
+    #[test]
+    fn test_read_dicom_implicit_vr() {
+        let implicit = |tag: u32, value: &[u8]| {
+            let mut out = [(tag >> 16) as u16, tag as u16]
+                .iter()
+                .flat_map(|half| half.to_le_bytes())
+                .collect::<Vec<u8>>();
+            out.extend_from_slice(&(value.len() as u32).to_le_bytes());
+            out.extend_from_slice(value);
+            out
+        };
+        let item = implicit(CODE_MEANING, b"Chest");
+        let sequence_item = [
+            &[0xFE, 0xFF, 0x00, 0xE0][..],
+            &(item.len() as u32).to_le_bytes()[..],
+            &item[..],
+        ]
+        .concat();
+        let data = dicom_file(
+            IMPLICIT_VR_LITTLE_ENDIAN,
+            &[
+                implicit(MODALITY, b"CT"),
+                implicit(CONTENT_SEQUENCE, &[]),
+                implicit(CONCEPT_NAME_CODE_SEQUENCE, &sequence_item),
+                implicit(ROWS, &512u16.to_le_bytes()),
+            ],
+        );
+
+        let content = read_dicom(&data, &DicomConfig::default()).expect("implicit VR file should be read");
+        assert_eq!(
+            content.metadata.transfer_syntax_uid.as_deref(),
+            Some(IMPLICIT_VR_LITTLE_ENDIAN)
+        );
+        assert_eq!(content.metadata.modality.as_deref(), Some("CT"));
+        assert_eq!(content.metadata.rows, Some(512));
+        assert_eq!(content.metadata.report_title.as_deref(), Some("Chest"));
+    }
+
+    #[test]
+    fn test_read_dicom_deflated() {
+        use flate2::{Compression, write::DeflateEncoder};
+        use std::io::Write;
+
+        let mut encoder = DeflateEncoder::new(Vec::new(), Compression::default());
+        encoder.write_all(&element(MODALITY, "CS", b"MR")).unwrap();
+        let mut data = dicom_file(DEFLATED_LITTLE_ENDIAN, &[]);
+        data.extend(encoder.finish().unwrap());
+
+        let content = read_dicom(&data, &DicomConfig::default()).expect("deflated file should be read");
+        assert_eq!(content.metadata.modality.as_deref(), Some("MR"));
+    }
+
+    #[cfg(feature = "ocr")]
+    #[test]
+    fn test_read_dicom_frame() {
+        let data = dicom_file(
+            "1.2.840.10008.1.2.1",
+            &[
+                element(SAMPLES_PER_PIXEL, "US", &1u16.to_le_bytes()),
+                element(PHOTOMETRIC_INTERPRETATION, "CS", b"MONOCHROME1"),
+                element(ROWS, "US", &2u16.to_le_bytes()),
+                element(COLUMNS, "US", &3u16.to_le_bytes()),
+                element(BITS_ALLOCATED, "US", &8u16.to_le_bytes()),
+                element(PIXEL_DATA, "OW", &[0, 50, 100, 150, 200, 255]),
+            ],
+        );
+        let config = DicomConfig {
+            ocr_pixel_data: true,
+            ..Default::default()
+        };
+
+        let content = read_dicom(&data, &config).expect("image should be read");
+        assert!(content.metadata.has_pixel_data);
+        let (frame, mime_type) = content.frame.expect("frame should be decoded");
+        assert_eq!(mime_type, "image/png");
+        let image = image::load_from_memory(&frame).unwrap().to_luma8();
+        assert_eq!(image.dimensions(), (3, 2));
+        assert_eq!(image.get_pixel(0, 0).0, [0xFF], "MONOCHROME1 should be inverted");
+    }
+
+    #[test]
+    fn test_read_dicom_rejects_malformed_input() {
+        let valid = report();
+        let mut oversized = element(MODALITY, "CS", b"CT");
+        oversized.truncate(6);
+        oversized.extend_from_slice(&[0xFF, 0x7F]);
+        for data in [
+            b"hello".to_vec(),
+            valid[..valid.len() - 10].to_vec(),
+            dicom_file("1.2.840.10008.1.2.1", &[oversized]),
+        ] {
+            assert!(read_dicom(&data, &DicomConfig::default()).is_err());
+        }
+    }
+
+    #[test]
+    fn test_person_name() {
+        for (value, want) in [
+            ("Doe^Jane", "Jane Doe"),
+            ("Doe^Jane^Q^Dr^Jr", "Dr Jane Q Doe Jr"),
+            ("Yamada^Tarou=山田^太郎", "Tarou Yamada"),
+            ("", ""),
+        ] {
+            assert_eq!(person_name(value), want, "person_name({:?})", value);
+        }
+    }
+}
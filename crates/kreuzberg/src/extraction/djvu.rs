@@ -0,0 +1,263 @@
+//! DjVu text layer reader.
+//!
+//! DjVu documents are IFF85 files: a single page is a `DJVU` form, and a bundled document
+//! a `DJVM` form holding a directory, its pages, and `DJVI` forms shared between them.
+//! The text of each page is read from its uncompressed text layer, and the title and
+//! author from its annotations. The page images are not decoded, so pages without a
+//! readable text layer come back empty.
+
+use super::word_processor::collapse_lines;
+use crate::core::mime::is_djvu;
+use crate::types::DjvuMetadata;
+use crate::{KreuzbergError, Result};
+
+/// Text and metadata read from a DjVu document.
+#[derive(Debug, Clone, Default)]
+pub struct DjvuContent {
+    /// The text of each page, empty for pages without an uncompressed text layer.
+    pub pages: Vec<String>,
+    pub metadata: DjvuMetadata,
+}
+
+/// A chunk of an IFF85 file. Forms carry their type in `kind` and their children in `data`.
+struct Chunk<'a> {
+    id: &'a [u8],
+    kind: &'a [u8],
+    data: &'a [u8],
+}
+
+/// Split `data` into chunks, which are padded to an even length.
+fn chunks(mut data: &[u8]) -> Vec<Chunk<'_>> {
+    let mut chunks = Vec::new();
+    while data.len() >= 8 {
+        let size = (u32::from_be_bytes([data[4], data[5], data[6], data[7]]) as usize).min(data.len() - 8);
+        let mut chunk = Chunk {
+            id: &data[..4],
+            kind: &[],
+            data: &data[8..8 + size],
+        };
+        if chunk.id == b"FORM" && size >= 4 {
+            (chunk.kind, chunk.data) = chunk.data.split_at(4);
+        }
+        chunks.push(chunk);
+        data = &data[(8 + size + size % 2).min(data.len())..];
+    }
+    chunks
+}
+
+/// Read the pages of a single-page or bundled DjVu document.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if `data` is not a DjVu document, or is an indirect
+/// document whose pages are stored in separate files.
+pub fn read_djvu(data: &[u8]) -> Result<DjvuContent> {
+    if !is_djvu(data) {
+        return Err(KreuzbergError::parsing("not a DjVu document: missing AT&T signature"));
+    }
+    let Some(root) = chunks(&data[4..]).into_iter().next() else {
+        return Err(KreuzbergError::parsing("DjVu document has no root form"));
+    };
+
+    let mut pages = Vec::new();
+    let mut shared = Vec::new();
+    if root.kind == b"DJVU" {
+        pages.push(root);
+    } else {
+        for chunk in chunks(root.data) {
+            match (chunk.id, chunk.kind) {
+                (b"DIRM", _) if chunk.data.first().is_some_and(|flags| flags & 0x80 == 0) => {
+                    return Err(KreuzbergError::parsing(
+                        "indirect DjVu documents, whose pages are separate files, are not supported",
+                    ));
+                }
+                (b"FORM", b"DJVU") => pages.push(chunk),
+                (b"FORM", b"DJVI") => shared.push(chunk),
+                _ => {}
+            }
+        }
+    }
+
+    let mut metadata = DjvuMetadata {
+        page_count: pages.len(),
+        ..Default::default()
+    };
+    let mut texts = vec![String::new(); pages.len()];
+    for (index, page) in pages.iter().enumerate() {
+        for chunk in chunks(page.data) {
+            match chunk.id {
+                b"INFO" if index == 0 && chunk.data.len() >= 8 => {
+                    let info = chunk.data;
+                    metadata.width = Some(u16::from_be_bytes([info[0], info[1]]).into());
+                    metadata.height = Some(u16::from_be_bytes([info[2], info[3]]).into());
+                    metadata.version = Some(format!("{}.{}", info[5], info[4]));
+                    metadata.dpi = Some(u16::from_le_bytes([info[6], info[7]]).into());
+                }
+                b"TXTa" => {
+                    if let Some(text) = text_layer(chunk.data) {
+                        texts[index] = text;
+                        metadata.text_pages += 1;
+                    }
+                }
+                b"TXTz" => metadata.compressed_text_pages += 1,
+                b"ANTa" | b"METa" => document_info(chunk.data, &mut metadata),
+                _ => {}
+            }
+        }
+    }
+    for include in &shared {
+        for chunk in chunks(include.data) {
+            if chunk.id == b"ANTa" || chunk.id == b"METa" {
+                document_info(chunk.data, &mut metadata);
+            }
+        }
+    }
+
+    Ok(DjvuContent { pages: texts, metadata })
+}
+
+/// Decode an uncompressed text layer: a 24-bit length and UTF-8 text with control
+/// characters ending lines, paragraphs, regions and columns. The zone tree that follows
+/// is not needed for plain text.
+fn text_layer(data: &[u8]) -> Option<String> {
+    let size = usize::from(*data.first()?) << 16 | usize::from(*data.get(1)?) << 8 | usize::from(*data.get(2)?);
+    let text = String::from_utf8_lossy(data.get(3..3 + size)?).replace(['\x0B', '\x1D', '\x1F'], "\n\n");
+    Some(collapse_lines(&text))
+}
+
+/// Read the title and author from the `(metadata (key "value") ...)` expression of an
+/// uncompressed annotation chunk.
+fn document_info(data: &[u8], metadata: &mut DjvuMetadata) {
+    let text = String::from_utf8_lossy(data);
+    let Some(start) = text.find("(metadata") else {
+        return;
+    };
+    let mut rest = &text[start + "(metadata".len()..];
+    while let Some(open) = rest.find('(') {
+        let entry = rest[open + 1..].trim_start();
+        let Some((key, value)) = entry.split_once(' ') else {
+            return;
+        };
+        let Some((value, remainder)) = quoted_prefix(value.trim_start()) else {
+            return;
+        };
+        match key {
+            "title" => metadata.title = Some(value),
+            "author" => metadata.author = Some(value),
+            _ => {}
+        }
+        rest = remainder;
+    }
+}
+
+/// Unquote the double-quoted string at the start of `text`, returning it and the text
+/// after its closing quote.
+fn quoted_prefix(text: &str) -> Option<(String, &str)> {
+    let mut value = String::new();
+    let mut chars = text.strip_prefix('"')?.chars();
+    while let Some(c) = chars.next() {
+        match c {
+            '"' => return Some((value, chars.as_str())),
+            '\\' => match chars.next()? {
+                'n' => value.push('\n'),
+                't' => value.push('\t'),
+                escaped => value.push(escaped),
+            },
+            c => value.push(c),
+        }
+    }
+    None
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn chunk(id: &str, data: &[u8]) -> Vec<u8> {
+        let mut out = id.as_bytes().to_vec();
+        out.extend_from_slice(&(data.len() as u32).to_be_bytes());
+        out.extend_from_slice(data);
+        if data.len() % 2 == 1 {
+            out.push(0);
+        }
+        out
+    }
+
+    fn form(kind: &str, children: &[Vec<u8>]) -> Vec<u8> {
+        let mut data = kind.as_bytes().to_vec();
+        for child in children {
+            data.extend_from_slice(child);
+        }
+        chunk("FORM", &data)
+    }
+
+    fn page(text: Option<&str>, compressed: bool) -> Vec<u8> {
+        let info = [0x09, 0xC4, 0x0C, 0xE4, 26, 0, 0x2C, 0x01, 22, 1];
+        let mut children = vec![chunk("INFO", &info), chunk("Sjbz", &[0, 0, 0])];
+        if compressed {
+            children.push(chunk("TXTz", &[1, 2, 3]));
+        } else if let Some(text) = text {
+            let mut layer = (text.len() as u32).to_be_bytes()[1..].to_vec();
+            layer.extend_from_slice(text.as_bytes());
+            layer.push(1);
+            children.push(chunk("TXTa", &layer));
+        }
+        form("DJVU", &children)
+    }
+
+    fn document(root: Vec<u8>) -> Vec<u8> {
+        let mut data = b"AT&T".to_vec();
+        data.extend_from_slice(&root);
+        data
+    }
+
+    #[test]
+    fn test_read_djvu_bundled() {
+        let annotations = chunk("ANTa", br#"(metadata (title "Field \"Notes\"") (author "E. Darwin"))"#);
+        let data = document(form(
+            "DJVM",
+            &[
+                chunk("DIRM", &[0x81, 0, 3]),
+                form("DJVI", &[annotations]),
+                page(Some("Chapter One\nThe voyage\x1fbegan in May."), false),
+                page(None, true),
+                page(Some("Page three"), false),
+            ],
+        ));
+
+        let djvu = read_djvu(&data).expect("bundled document should be read");
+        assert_eq!(
+            djvu.pages,
+            vec![
+                "Chapter One\nThe voyage\n\nbegan in May.".to_string(),
+                String::new(),
+                "Page three".to_string(),
+            ]
+        );
+        assert_eq!(
+            djvu.metadata,
+            DjvuMetadata {
+                page_count: 3,
+                width: Some(2500),
+                height: Some(3300),
+                dpi: Some(300),
+                version: Some("0.26".to_string()),
+                text_pages: 2,
+                compressed_text_pages: 1,
+                title: Some("Field \"Notes\"".to_string()),
+                author: Some("E. Darwin".to_string()),
+            }
+        );
+    }
+
+    #[test]
+    fn test_read_djvu_indirect() {
+        let data = document(form("DJVM", &[chunk("DIRM", &[0x01, 0, 1])]));
+        assert!(read_djvu(&data).is_err());
+    }
+
+    #[test]
+    fn test_read_djvu_rejects_other_files() {
+        assert!(read_djvu(b"FORM\x00\x00\x00\x04AIFF").is_err());
+    }
+}
@@ -0,0 +1,617 @@
+//! Native readers of the pre-2007 binary Word and PowerPoint formats.
+//!
+//! The legacy formats are normally converted through LibreOffice. When it is not
+//! installed, these readers recover the text and summary information directly from the
+//! compound file. Formatting, tables and embedded objects are not recovered.
+
+use super::cfb::CompoundFile;
+use crate::types::LegacyOfficeMetadata;
+use crate::{KreuzbergError, Result};
+use std::collections::HashMap;
+
+/// Text and summary information read from a binary Word or PowerPoint file.
+#[derive(Debug, Clone, Default)]
+pub struct LegacyOfficeContent {
+    pub text: String,
+    pub metadata: LegacyOfficeMetadata,
+}
+
+/// Read the text and summary information of a Word 97-2003 document.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if the file is not a compound file, is encrypted, or
+/// its piece table is malformed.
+pub fn read_legacy_word(data: &[u8]) -> Result<LegacyOfficeContent> {
+    let file = CompoundFile::open(data)?;
+    let metadata = summary_information(&file);
+    let text = word_document_text(&file)
+        .map_err(|e| KreuzbergError::parsing(format!("failed to read legacy Word document: {}", e)))?;
+    Ok(LegacyOfficeContent { text, metadata })
+}
+
+/// Read the slide text and summary information of a PowerPoint 97-2003 presentation.
+/// Slides are separated by blank lines.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if the file is not a compound file or has no
+/// PowerPoint Document stream.
+pub fn read_legacy_powerpoint(data: &[u8]) -> Result<LegacyOfficeContent> {
+    let file = CompoundFile::open(data)?;
+    let mut metadata = summary_information(&file);
+    let document = file.stream("PowerPoint Document").ok_or_else(|| {
+        KreuzbergError::parsing("failed to read legacy PowerPoint document: missing PowerPoint Document stream")
+    })?;
+    let slides = powerpoint_slides(&document);
+    metadata.slide_count = Some(slides.len());
+    Ok(LegacyOfficeContent {
+        text: slides.join("\n\n"),
+        metadata,
+    })
+}
+
+fn read_u16(data: &[u8], at: usize) -> Option<u16> {
+    let bytes = data.get(at..at.checked_add(2)?)?;
+    Some(u16::from_le_bytes([bytes[0], bytes[1]]))
+}
+
+fn read_u32(data: &[u8], at: usize) -> Option<u32> {
+    let bytes = data.get(at..at.checked_add(4)?)?;
+    Some(u32::from_le_bytes([bytes[0], bytes[1], bytes[2], bytes[3]]))
+}
+
+fn read_u64(data: &[u8], at: usize) -> Option<u64> {
+    let bytes = data.get(at..at.checked_add(8)?)?;
+    let mut out = [0u8; 8];
+    out.copy_from_slice(bytes);
+    Some(u64::from_le_bytes(out))
+}
+
+// Summary information property identifiers and types.
+const PROPERTY_CODE_PAGE: u32 = 1;
+const PROPERTY_TITLE: u32 = 2;
+const PROPERTY_SUBJECT: u32 = 3;
+const PROPERTY_AUTHOR: u32 = 4;
+const PROPERTY_KEYWORDS: u32 = 5;
+const PROPERTY_COMMENTS: u32 = 6;
+const PROPERTY_TEMPLATE: u32 = 7;
+const PROPERTY_LAST_AUTHOR: u32 = 8;
+const PROPERTY_REVISION: u32 = 9;
+const PROPERTY_CREATED: u32 = 12;
+const PROPERTY_SAVED: u32 = 13;
+const PROPERTY_PAGE_COUNT: u32 = 14;
+const PROPERTY_WORD_COUNT: u32 = 15;
+const PROPERTY_CHAR_COUNT: u32 = 16;
+const PROPERTY_APPLICATION: u32 = 18;
+const VT_I2: u32 = 0x02;
+const VT_I4: u32 = 0x03;
+const VT_LPSTR: u32 = 0x1E;
+const VT_LPWSTR: u32 = 0x1F;
+const VT_FILETIME: u32 = 0x40;
+
+enum PropertyValue<'a> {
+    Int(i32),
+    Time(u64),
+    Text { kind: u32, raw: &'a [u8] },
+}
+
+fn summary_information(file: &CompoundFile<'_>) -> LegacyOfficeMetadata {
+    file.stream("\u{5}SummaryInformation")
+        .map(|summary| read_summary_information(&summary))
+        .unwrap_or_default()
+}
+
+/// Read the first section of a SummaryInformation property set. Malformed properties are
+/// skipped.
+fn read_summary_information(data: &[u8]) -> LegacyOfficeMetadata {
+    let mut meta = LegacyOfficeMetadata::default();
+    if data.len() < 48 || read_u16(data, 0) != Some(0xFFFE) {
+        return meta;
+    }
+    let Some(section) = read_u32(data, 44).map(|s| s as usize) else {
+        return meta;
+    };
+    let Some(count) = section
+        .checked_add(8)
+        .filter(|&header| header <= data.len())
+        .and_then(|_| read_u32(data, section + 4))
+        .map(|c| c as usize)
+    else {
+        return meta;
+    };
+    if count > (data.len() - section - 8) / 8 {
+        return meta;
+    }
+
+    let mut values = HashMap::new();
+    for i in 0..count {
+        let (Some(id), Some(relative)) = (
+            read_u32(data, section + 8 + 8 * i),
+            read_u32(data, section + 12 + 8 * i),
+        ) else {
+            continue;
+        };
+        let Some(offset) = section.checked_add(relative as usize) else {
+            continue;
+        };
+        if offset.checked_add(8).is_none_or(|end| end > data.len()) {
+            continue;
+        }
+        let kind = read_u32(data, offset).unwrap_or_default();
+        let value = &data[offset + 4..];
+        let parsed = match kind {
+            VT_I2 => read_u16(value, 0).map(|n| PropertyValue::Int(i32::from(n as i16))),
+            VT_I4 => read_u32(value, 0).map(|n| PropertyValue::Int(n as i32)),
+            VT_FILETIME => read_u64(value, 0).map(PropertyValue::Time),
+            VT_LPSTR | VT_LPWSTR => Some(PropertyValue::Text { kind, raw: value }),
+            _ => None,
+        };
+        if let Some(parsed) = parsed {
+            values.insert(id, parsed);
+        }
+    }
+
+    let code_page = match values.get(&PROPERTY_CODE_PAGE) {
+        Some(PropertyValue::Int(page)) => u32::from(*page as u16),
+        _ => 1252,
+    };
+    let text = |id: u32| match values.get(&id) {
+        Some(PropertyValue::Text { kind, raw }) => property_text(*kind, raw, code_page).filter(|s| !s.is_empty()),
+        _ => None,
+    };
+    let number = |id: u32| match values.get(&id) {
+        Some(PropertyValue::Int(n)) if *n != 0 => Some(*n),
+        _ => None,
+    };
+    let timestamp = |id: u32| match values.get(&id) {
+        Some(PropertyValue::Time(ticks)) => filetime_to_rfc3339(*ticks),
+        _ => None,
+    };
+    meta.title = text(PROPERTY_TITLE);
+    meta.subject = text(PROPERTY_SUBJECT);
+    meta.author = text(PROPERTY_AUTHOR);
+    meta.keywords = text(PROPERTY_KEYWORDS);
+    meta.comments = text(PROPERTY_COMMENTS);
+    meta.template = text(PROPERTY_TEMPLATE);
+    meta.last_author = text(PROPERTY_LAST_AUTHOR);
+    meta.revision = text(PROPERTY_REVISION);
+    meta.application = text(PROPERTY_APPLICATION);
+    meta.created_at = timestamp(PROPERTY_CREATED);
+    meta.modified_at = timestamp(PROPERTY_SAVED);
+    meta.page_count = number(PROPERTY_PAGE_COUNT);
+    meta.word_count = number(PROPERTY_WORD_COUNT);
+    meta.character_count = number(PROPERTY_CHAR_COUNT);
+    meta
+}
+
+/// Decode a string property, whose value starts with its length in characters.
+fn property_text(kind: u32, raw: &[u8], code_page: u32) -> Option<String> {
+    let mut n = read_u32(raw, 0)? as usize;
+    let body = &raw[4..];
+    let text = if kind == VT_LPWSTR || code_page == 1200 {
+        if kind == VT_LPWSTR {
+            n = n.checked_mul(2)?;
+        }
+        decode_utf16le(body.get(..n)?)
+    } else if code_page == 65001 {
+        String::from_utf8_lossy(body.get(..n)?).into_owned()
+    } else {
+        decode_cp1252(body.get(..n)?)
+    };
+    Some(text.trim_end_matches(['\0', ' ']).to_string())
+}
+
+/// Convert a Windows FILETIME, 100 ns intervals since 1601, to RFC 3339. Unset times are
+/// stored as zero or as small durations, so times before 1980 are dropped.
+fn filetime_to_rfc3339(ticks: u64) -> Option<String> {
+    const EPOCH_DELTA: u64 = 116_444_736_000_000_000; // 1601-01-01 to 1970-01-01 in ticks
+    let seconds = ticks.checked_sub(EPOCH_DELTA)? / 10_000_000;
+    let (year, month, day) = civil_from_days((seconds / 86_400) as i64);
+    if year < 1980 {
+        return None;
+    }
+    let time = seconds % 86_400;
+    Some(format!(
+        "{:04}-{:02}-{:02}T{:02}:{:02}:{:02}Z",
+        year,
+        month,
+        day,
+        time / 3600,
+        time % 3600 / 60,
+        time % 60
+    ))
+}
+
+/// Convert days since 1970-01-01 to a proleptic Gregorian date.
+fn civil_from_days(days: i64) -> (i64, u32, u32) {
+    let z = days + 719_468;
+    let era = z.div_euclid(146_097);
+    let doe = z.rem_euclid(146_097);
+    let yoe = (doe - doe / 1460 + doe / 36_524 - doe / 146_096) / 365;
+    let doy = doe - (365 * yoe + yoe / 4 - yoe / 100);
+    let mp = (5 * doy + 2) / 153;
+    let day = (doy - (153 * mp + 2) / 5 + 1) as u32;
+    let month = (if mp < 10 { mp + 3 } else { mp - 9 }) as u32;
+    let year = yoe + era * 400 + i64::from(month <= 2);
+    (year, month, day)
+}
+
+/// Decode little-endian UTF-16, replacing unpaired surrogates.
+pub(crate) fn decode_utf16le(data: &[u8]) -> String {
+    let units: Vec<u16> = data
+        .chunks_exact(2)
+        .map(|pair| u16::from_le_bytes([pair[0], pair[1]]))
+        .collect();
+    String::from_utf16_lossy(&units)
+}
+
+/// Characters of the bytes 0x80-0x9F of Windows-1252, where it differs from Latin-1.
+const CP1252_HIGH: [char; 32] = [
+    '€', '\u{81}', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u{8D}', 'Ž', '\u{8F}', '\u{90}', '‘', '’',
+    '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u{9D}', 'ž', 'Ÿ',
+];
+
+/// Decode Windows-1252 text.
+pub(crate) fn decode_cp1252(data: &[u8]) -> String {
+    data.iter()
+        .map(|&b| match b {
+            0x80..=0x9F => CP1252_HIGH[usize::from(b - 0x80)],
+            _ => char::from(b),
+        })
+        .collect()
+}
+
+/// Read the main document text of a Word 97-2003 file through its piece table, turning
+/// Word's control characters into plain text.
+fn word_document_text(file: &CompoundFile<'_>) -> std::result::Result<String, String> {
+    let document = file
+        .stream("WordDocument")
+        .filter(|document| document.len() >= 0x22 && read_u16(document, 0) == Some(0xA5EC))
+        .ok_or("missing WordDocument stream")?;
+    let flags = read_u16(&document, 0x0A).unwrap_or_default();
+    if flags & 0x0100 != 0 {
+        return Err("document is encrypted".to_string());
+    }
+    let table_name = if flags & 0x0200 != 0 { "1Table" } else { "0Table" };
+    let table = file
+        .stream(table_name)
+        .ok_or_else(|| format!("missing {} stream", table_name))?;
+
+    let (ccp_text, fc_clx, lcb_clx) = piece_table_location(&document).ok_or("truncated file information block")?;
+    let clx_end = fc_clx
+        .checked_add(lcb_clx)
+        .filter(|&end| lcb_clx > 0 && end <= table.len())
+        .ok_or("piece table out of range")?;
+
+    // The CLX holds property modifiers (0x01) followed by the piece table (0x02).
+    let mut clx = &table[fc_clx..clx_end];
+    while clx.first() == Some(&0x01) {
+        let size = read_u16(clx, 1)
+            .map(|n| 3 + usize::from(n))
+            .filter(|&size| size <= clx.len())
+            .ok_or("truncated property modifier")?;
+        clx = &clx[size..];
+    }
+    if clx.len() < 5 || clx[0] != 0x02 {
+        return Err("missing piece table".to_string());
+    }
+    let mut plc = &clx[5..];
+    if let Some(size) = read_u32(clx, 1).map(|n| n as usize)
+        && size <= plc.len()
+    {
+        plc = &plc[..size];
+    }
+    let pieces = plc.len().saturating_sub(4) / 12;
+    let mut text = String::new();
+    for i in 0..pieces {
+        let cp_start = read_u32(plc, 4 * i).unwrap_or_default() as usize;
+        let cp_end = read_u32(plc, 4 * i + 4).unwrap_or_default() as usize;
+        if cp_start >= ccp_text {
+            break;
+        }
+        let cp_end = cp_end.min(ccp_text);
+        if cp_end <= cp_start {
+            continue;
+        }
+        let fc = read_u32(plc, 4 * (pieces + 1) + 8 * i + 2).unwrap_or_default();
+        let n = cp_end - cp_start;
+        if fc & 0x4000_0000 != 0 {
+            let offset = (fc & !0x4000_0000) as usize / 2;
+            let bytes = document
+                .get(offset..offset.saturating_add(n))
+                .ok_or("piece out of range")?;
+            text.push_str(&decode_cp1252(bytes));
+            continue;
+        }
+        let offset = fc as usize;
+        let bytes = document
+            .get(offset..offset.saturating_add(n.saturating_mul(2)))
+            .ok_or("piece out of range")?;
+        text.push_str(&decode_utf16le(bytes));
+    }
+    Ok(word_plain_text(&text))
+}
+
+/// Read the text length and the location of the piece table from the FIB of a
+/// WordDocument stream, a fixed base followed by three counted arrays.
+fn piece_table_location(document: &[u8]) -> Option<(usize, usize, usize)> {
+    let u16_at = |at: usize| read_u16(document, at).map(usize::from);
+    let u32_at = |at: usize| read_u32(document, at).map(|n| n as usize);
+    let lw_start = 0x22 + 2 * u16_at(0x20)? + 2;
+    let cslw = u16_at(lw_start - 2)?;
+    let ccp_text = u32_at(lw_start + 12)?;
+    let fc_start = lw_start + 4 * cslw + 2;
+    let cb_rg_fc_lcb = u16_at(fc_start - 2)?;
+    let fc_clx = u32_at(fc_start + 66 * 4)?;
+    let lcb_clx = u32_at(fc_start + 67 * 4)?;
+    (cb_rg_fc_lcb >= 68).then_some((ccp_text, fc_clx, lcb_clx))
+}
+
+/// Drop field instructions and object anchors and map paragraph, cell and break marks to
+/// newlines and tabs.
+fn word_plain_text(text: &str) -> String {
+    let mut out = String::with_capacity(text.len());
+    // For each open field, whether its instruction is still running.
+    let mut fields: Vec<bool> = Vec::new();
+    for c in text.chars() {
+        match c {
+            '\u{13}' => {
+                fields.push(true);
+                continue;
+            }
+            '\u{14}' => {
+                if let Some(instruction) = fields.last_mut() {
+                    *instruction = false;
+                }
+                continue;
+            }
+            '\u{15}' => {
+                fields.pop();
+                continue;
+            }
+            _ => {}
+        }
+        if fields.last() == Some(&true) {
+            continue;
+        }
+        match c {
+            '\r' | '\u{0B}' | '\u{0C}' => out.push('\n'),
+            '\u{07}' => out.push('\t'),
+            '\u{1E}' => out.push('-'),
+            '\u{01}' | '\u{02}' | '\u{05}' | '\u{08}' | '\u{1F}' => {}
+            _ => out.push(c),
+        }
+    }
+    out.split('\n')
+        .map(|line| line.trim_end_matches(['\t', ' ']))
+        .collect::<Vec<_>>()
+        .join("\n")
+        .trim()
+        .to_string()
+}
+
+// PowerPoint record types.
+const PPT_SLIDE_CONTAINER: u16 = 0x03EE;
+const PPT_SLIDE_PERSIST_ATOM: u16 = 0x03F3;
+const PPT_TEXT_CHARS_ATOM: u16 = 0x0FA0;
+const PPT_TEXT_BYTES_ATOM: u16 = 0x0FA8;
+const PPT_SLIDE_LIST_TEXT: u16 = 0x0FF0;
+const MAX_PPT_RECORD_DEPTH: usize = 32;
+
+#[derive(Default)]
+struct SlideTexts {
+    listed: Vec<Vec<String>>,
+    drawn: Vec<Vec<String>>,
+}
+
+/// Return the text of each slide of a PowerPoint Document stream, from the slide list of
+/// the document when it carries the text, or else from the slides' drawings.
+fn powerpoint_slides(document: &[u8]) -> Vec<String> {
+    let mut slides = SlideTexts::default();
+    walk_records(document, 0, false, false, &mut slides);
+    let listed_text = slides.listed.iter().map(Vec::len).sum::<usize>();
+    let chosen = if listed_text == 0 { slides.drawn } else { slides.listed };
+    chosen.into_iter().map(|texts| texts.join("\n")).collect()
+}
+
+fn walk_records(mut data: &[u8], depth: usize, in_list: bool, in_slide: bool, slides: &mut SlideTexts) {
+    while data.len() >= 8 {
+        let ver_instance = read_u16(data, 0).unwrap_or_default();
+        let kind = read_u16(data, 2).unwrap_or_default();
+        let size = (read_u32(data, 4).unwrap_or_default() as usize).min(data.len() - 8);
+        let body = &data[8..8 + size];
+        data = &data[8 + size..];
+
+        let text = match kind {
+            PPT_TEXT_CHARS_ATOM => decode_utf16le(body),
+            PPT_TEXT_BYTES_ATOM => decode_cp1252(body),
+            PPT_SLIDE_PERSIST_ATOM => {
+                if in_list {
+                    slides.listed.push(Vec::new());
+                }
+                String::new()
+            }
+            _ => String::new(),
+        };
+        let text = text.replace(['\r', '\u{0B}'], "\n");
+        let text = text.trim();
+        if !text.is_empty() {
+            let slide = if in_list && !slides.listed.is_empty() {
+                slides.listed.last_mut()
+            } else if in_slide {
+                slides.drawn.last_mut()
+            } else {
+                None
+            };
+            if let Some(slide) = slide {
+                slide.push(text.to_string());
+            }
+        }
+
+        if ver_instance & 0x000F == 0x000F && depth < MAX_PPT_RECORD_DEPTH {
+            // Only the slide list (instance 0) holds slide text; 1 and 2 are masters and
+            // notes.
+            let list = in_list || (kind == PPT_SLIDE_LIST_TEXT && ver_instance >> 4 == 0);
+            let slide = in_slide || kind == PPT_SLIDE_CONTAINER;
+            if kind == PPT_SLIDE_CONTAINER {
+                slides.drawn.push(Vec::new());
+            }
+            walk_records(body, depth + 1, list, slide, slides);
+        }
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::extraction::cfb::test_support::compound_file;
+
+    fn le16(n: u16) -> [u8; 2] {
+        n.to_le_bytes()
+    }
+
+    fn le32(n: u32) -> [u8; 4] {
+        n.to_le_bytes()
+    }
+
+    /// Encode a SummaryInformation property set with the given properties, each an
+    /// identifier, a type, and an encoded value.
+    fn summary_stream(properties: &[Vec<u8>]) -> Vec<u8> {
+        let mut out = vec![0u8; 48];
+        out[..2].copy_from_slice(&le16(0xFFFE));
+        out[24..28].copy_from_slice(&le32(1));
+        out[44..48].copy_from_slice(&le32(48));
+        let mut section = vec![0u8; 8 + 8 * properties.len()];
+        section[4..8].copy_from_slice(&le32(properties.len() as u32));
+        let mut values = Vec::new();
+        for (i, property) in properties.iter().enumerate() {
+            section[8 + 8 * i..12 + 8 * i].copy_from_slice(&property[..4]);
+            let offset = (section.len() + values.len()) as u32;
+            section[12 + 8 * i..16 + 8 * i].copy_from_slice(&le32(offset));
+            values.extend_from_slice(&property[4..]);
+            while values.len() % 4 != 0 {
+                values.push(0);
+            }
+        }
+        let size = (section.len() + values.len()) as u32;
+        section[..4].copy_from_slice(&le32(size));
+        out.extend(section);
+        out.extend(values);
+        out
+    }
+
+    fn property(id: u32, kind: u32, value: &[u8]) -> Vec<u8> {
+        [&le32(id)[..], &le32(kind), value].concat()
+    }
+
+    fn string_property(id: u32, text: &[u8]) -> Vec<u8> {
+        property(id, VT_LPSTR, &[&le32(text.len() as u32 + 1)[..], text, &[0]].concat())
+    }
+
+    /// Build the WordDocument and 1Table streams of a Word 97 file whose text is stored
+    /// as a single Windows-1252 piece.
+    fn word_streams(text: &[u8]) -> (Vec<u8>, Vec<u8>) {
+        const TEXT_OFFSET: usize = 1024;
+        let mut document = vec![0u8; TEXT_OFFSET];
+        document[..2].copy_from_slice(&le16(0xA5EC));
+        document[0x0A..0x0C].copy_from_slice(&le16(0x0200));
+        document[0x20..0x22].copy_from_slice(&le16(14));
+        document[0x3E..0x40].copy_from_slice(&le16(22));
+        document[0x4C..0x50].copy_from_slice(&le32(text.len() as u32));
+        document[0x98..0x9A].copy_from_slice(&le16(93));
+
+        let mut table = vec![0x02];
+        table.extend_from_slice(&le32(16));
+        table.extend_from_slice(&le32(0));
+        table.extend_from_slice(&le32(text.len() as u32));
+        table.extend_from_slice(&[0, 0]);
+        table.extend_from_slice(&le32(0x4000_0000 | (TEXT_OFFSET as u32 * 2)));
+        table.extend_from_slice(&[0, 0]);
+        document[0x9A + 66 * 4..0x9A + 67 * 4].copy_from_slice(&le32(0));
+        document[0x9A + 67 * 4..0x9A + 68 * 4].copy_from_slice(&le32(table.len() as u32));
+        document.extend_from_slice(text);
+        (document, table)
+    }
+
+    fn ppt_record(ver_instance: u16, kind: u16, body: &[u8]) -> Vec<u8> {
+        [&le16(ver_instance)[..], &le16(kind), &le32(body.len() as u32), body].concat()
+    }
+
+    #[test]
+    fn test_read_legacy_word() {
+        // 2004-03-09T14:30:00Z
+        let ticks = 1_078_842_600u64 * 10_000_000 + 116_444_736_000_000_000;
+        let summary = summary_stream(&[
+            property(PROPERTY_CODE_PAGE, VT_I2, &le16(1252)),
+            string_property(PROPERTY_TITLE, b"Quarterly \x93Review\x94"),
+            string_property(PROPERTY_AUTHOR, b"J. Doe"),
+            string_property(PROPERTY_APPLICATION, b"Microsoft Word 9.0"),
+            property(PROPERTY_CREATED, VT_FILETIME, &ticks.to_le_bytes()),
+            property(PROPERTY_WORD_COUNT, VT_I4, &le32(42)),
+        ]);
+        let (document, table) =
+            word_streams(b"Hello \x13 HYPERLINK \"x\" \x14World\x15\rA\x07B\x07\x07\rEnd\x0Cof file\r");
+        let data = compound_file(&[
+            ("WordDocument", document.as_slice()),
+            ("1Table", table.as_slice()),
+            ("\u{5}SummaryInformation", summary.as_slice()),
+        ]);
+
+        let content = read_legacy_word(&data).expect("legacy Word document should be read");
+        assert_eq!(content.text, "Hello World\nA\tB\nEnd\nof file");
+        let meta = content.metadata;
+        assert_eq!(meta.title.as_deref(), Some("Quarterly “Review”"));
+        assert_eq!(meta.author.as_deref(), Some("J. Doe"));
+        assert_eq!(meta.application.as_deref(), Some("Microsoft Word 9.0"));
+        assert_eq!(meta.created_at.as_deref(), Some("2004-03-09T14:30:00Z"));
+        assert_eq!(meta.word_count, Some(42));
+        assert_eq!(meta.slide_count, None);
+    }
+
+    #[test]
+    fn test_read_legacy_word_encrypted() {
+        let (mut document, table) = word_streams(b"secret");
+        document[0x0A..0x0C].copy_from_slice(&le16(0x0300));
+        let data = compound_file(&[("WordDocument", document.as_slice()), ("1Table", table.as_slice())]);
+        assert!(read_legacy_word(&data).is_err());
+    }
+
+    #[test]
+    fn test_read_legacy_powerpoint() {
+        let utf16: Vec<u8> = "Roadmap – 2004".encode_utf16().flat_map(u16::to_le_bytes).collect();
+        let persist = ppt_record(0, PPT_SLIDE_PERSIST_ATOM, &[0u8; 20]);
+        let list = [
+            persist.clone(),
+            ppt_record(0, PPT_TEXT_CHARS_ATOM, &utf16),
+            ppt_record(0, PPT_TEXT_BYTES_ATOM, b"Ship it\rThen rest"),
+            persist,
+            ppt_record(0, PPT_TEXT_BYTES_ATOM, b"Questions?"),
+        ]
+        .concat();
+        let masters = ppt_record(
+            0x001F,
+            PPT_SLIDE_LIST_TEXT,
+            &ppt_record(0, PPT_TEXT_BYTES_ATOM, b"Master title"),
+        );
+        let stream = ppt_record(
+            0x000F,
+            0x03E8,
+            &[masters, ppt_record(0x000F, PPT_SLIDE_LIST_TEXT, &list)].concat(),
+        );
+        let data = compound_file(&[("PowerPoint Document", stream.as_slice())]);
+
+        let content = read_legacy_powerpoint(&data).expect("legacy PowerPoint file should be read");
+        assert_eq!(content.text, "Roadmap – 2004\nShip it\nThen rest\n\nQuestions?");
+        assert_eq!(content.metadata.slide_count, Some(2));
+    }
+
+    #[test]
+    fn test_filetime_to_rfc3339() {
+        assert_eq!(filetime_to_rfc3339(0), None);
+        assert_eq!(
+            filetime_to_rfc3339(116_444_736_000_000_000 + 951_782_400 * 10_000_000).as_deref(),
+            Some("2000-02-29T00:00:00Z")
+        );
+    }
+}
@@ -66,11 +66,9 @@ impl TempDir {
 }
 
 impl Drop for TempDir {
+    // Removed synchronously so that no converted document outlives the extraction ~keep
     fn drop(&mut self) {
-        let path = self.path.clone();
-        tokio::spawn(async move {
-            let _ = fs::remove_dir_all(&path).await;
-        });
+        let _ = std_fs::remove_dir_all(&self.path);
     }
 }
 
@@ -201,7 +199,7 @@ pub async fn convert_office_doc(
 ) -> Result<Vec<u8>> {
     let soffice_path = check_libreoffice_available().await?;
 
-    let profile_dir = std::env::temp_dir().join(format!("kreuzberg_lo_profile_{}", uuid::Uuid::new_v4()));
+    let profile_dir = crate::utils::temp_dir().join(format!("kreuzberg_lo_profile_{}", uuid::Uuid::new_v4()));
     let _profile_guard = TempDir::new(profile_dir.clone()).await?;
     let user_install_arg = format!("-env:UserInstallation={}", path_to_file_uri(&profile_dir));
 
@@ -310,7 +308,7 @@ pub async fn convert_office_doc(
 
 /// Convert .doc to .docx using LibreOffice
 pub async fn convert_doc_to_docx(doc_bytes: &[u8]) -> Result<LibreOfficeConversionResult> {
-    let temp_dir = std::env::temp_dir();
+    let temp_dir = crate::utils::temp_dir();
     let unique_id = uuid::Uuid::new_v4();
     let input_dir_path = temp_dir.join(format!("kreuzberg_doc_{}", unique_id));
     let output_dir_path = temp_dir.join(format!("kreuzberg_doc_{}_out", unique_id));
@@ -334,7 +332,7 @@ pub async fn convert_doc_to_docx(doc_bytes: &[u8]) -> Result<LibreOfficeConversi
 
 /// Convert .ppt to .pptx using LibreOffice
 pub async fn convert_ppt_to_pptx(ppt_bytes: &[u8]) -> Result<LibreOfficeConversionResult> {
-    let temp_dir = std::env::temp_dir();
+    let temp_dir = crate::utils::temp_dir();
     let unique_id = uuid::Uuid::new_v4();
     let input_dir_path = temp_dir.join(format!("kreuzberg_ppt_{}", unique_id));
     let output_dir_path = temp_dir.join(format!("kreuzberg_ppt_{}_out", unique_id));
@@ -356,6 +354,25 @@ pub async fn convert_ppt_to_pptx(ppt_bytes: &[u8]) -> Result<LibreOfficeConversi
     })
 }
 
+/// Convert an Office document, such as a presentation, to PDF using LibreOffice so its
+/// pages can be rendered. `extension` names the input format, as LibreOffice detects it
+/// from the file name.
+pub async fn convert_office_doc_to_pdf(bytes: &[u8], extension: &str) -> Result<Vec<u8>> {
+    let temp_dir = crate::utils::temp_dir();
+    let unique_id = uuid::Uuid::new_v4();
+    let input_dir_path = temp_dir.join(format!("kreuzberg_pdf_{}", unique_id));
+    let output_dir_path = temp_dir.join(format!("kreuzberg_pdf_{}_out", unique_id));
+
+    // RAII guards ensure cleanup on all paths including panic ~keep
+    let _input_guard = TempDir::new(input_dir_path.clone()).await?;
+    let _output_guard = TempDir::new(output_dir_path.clone()).await?;
+
+    let input_path = input_dir_path.join(format!("input.{}", extension));
+    fs::write(&input_path, bytes).await?;
+
+    convert_office_doc(&input_path, &output_dir_path, "pdf", DEFAULT_CONVERSION_TIMEOUT).await
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -567,8 +584,6 @@ mod tests {
             assert!(temp_path.exists());
         }
 
-        tokio::time::sleep(tokio::time::Duration::from_millis(100)).await;
-
-        assert!(!temp_path.exists() || fs::read_dir(&temp_path).await.is_err());
+        assert!(!temp_path.exists());
     }
 }
@@ -1,3 +1,4 @@
+pub mod pages;
 pub mod structured;
 pub mod text;
 pub mod transform;
@@ -5,6 +6,9 @@ pub mod transform;
 #[cfg(feature = "ocr")]
 pub mod image;
 
+#[cfg(feature = "ocr")]
+pub mod tiff;
+
 /// Capacity estimation utilities for string pre-allocation.
 ///
 /// This module provides functions to estimate the capacity needed for string buffers
@@ -15,6 +19,9 @@ pub mod capacity;
 #[cfg(feature = "archives")]
 pub mod archive;
 
+#[cfg(feature = "dicom")]
+pub mod dicom;
+
 #[cfg(feature = "email")]
 pub mod email;
 
@@ -24,9 +31,24 @@ pub mod excel;
 #[cfg(feature = "html")]
 pub mod html;
 
+#[cfg(feature = "html")]
+pub mod plist;
+
+#[cfg(feature = "html")]
+pub mod web_archive;
+
+#[cfg(feature = "office")]
+pub mod cfb;
+
+#[cfg(feature = "office")]
+pub mod djvu;
+
 #[cfg(feature = "office")]
 pub mod docx;
 
+#[cfg(feature = "office")]
+pub mod legacy_office;
+
 #[cfg(feature = "office")]
 pub mod libreoffice;
 
@@ -36,6 +58,12 @@ pub mod office_metadata;
 #[cfg(feature = "office")]
 pub mod pptx;
 
+#[cfg(feature = "office")]
+pub mod word_processor;
+
+#[cfg(feature = "office")]
+pub mod xps;
+
 #[cfg(feature = "excel")]
 pub mod table;
 
@@ -45,6 +73,7 @@ pub mod xml;
 #[cfg(any(feature = "office", feature = "html", feature = "xml"))]
 pub mod markdown;
 
+pub use pages::{PaginatedContent, paginate};
 pub use structured::{JsonExtractionConfig, StructuredDataResult, parse_json, parse_toml, parse_yaml};
 pub use text::parse_text;
 pub use transform::{
@@ -54,12 +83,20 @@ pub use transform::{
 #[cfg(feature = "ocr")]
 pub use image::{ImageMetadata, extract_image_metadata};
 
+#[cfg(feature = "ocr")]
+pub use tiff::{SplitTiff, TiffPage, split_tiff};
+
 #[cfg(feature = "archives")]
 pub use archive::{
-    ArchiveEntry, ArchiveMetadata, extract_7z_metadata, extract_7z_text_content, extract_tar_metadata,
-    extract_tar_text_content, extract_zip_metadata, extract_zip_text_content,
+    ArchiveEntry, ArchiveMetadata, ArchiveTextContent, extract_7z_metadata, extract_7z_metadata_with_passwords,
+    extract_7z_text_content, extract_7z_text_content_with_passwords, extract_rar_metadata, extract_rar_text_content,
+    extract_tar_metadata, extract_tar_text_content, extract_zip_metadata, extract_zip_text_content,
+    extract_zip_text_content_with_passwords,
 };
 
+#[cfg(feature = "dicom")]
+pub use dicom::{DicomContent, read_dicom};
+
 #[cfg(feature = "email")]
 pub use email::{build_email_text_output, extract_email_content, parse_eml_content, parse_msg_content};
 
@@ -69,8 +106,17 @@ pub use excel::{excel_to_markdown, read_excel_bytes, read_excel_file};
 #[cfg(feature = "html")]
 pub use html::{convert_html_to_markdown, process_html};
 
+#[cfg(feature = "html")]
+pub use web_archive::webarchive_to_html;
+
 #[cfg(feature = "office")]
-pub use libreoffice::{check_libreoffice_available, convert_doc_to_docx, convert_ppt_to_pptx};
+pub use djvu::{DjvuContent, read_djvu};
+
+#[cfg(feature = "office")]
+pub use legacy_office::{LegacyOfficeContent, read_legacy_powerpoint, read_legacy_word};
+
+#[cfg(feature = "office")]
+pub use libreoffice::{check_libreoffice_available, convert_doc_to_docx, convert_office_doc_to_pdf, convert_ppt_to_pptx};
 
 #[cfg(feature = "office")]
 pub use office_metadata::{
@@ -82,6 +128,12 @@ pub use office_metadata::{
 #[cfg(feature = "office")]
 pub use pptx::{extract_pptx_from_bytes, extract_pptx_from_path};
 
+#[cfg(feature = "office")]
+pub use word_processor::{WordProcessorContent, read_word_processor};
+
+#[cfg(feature = "office")]
+pub use xps::{XpsContent, XpsImage, XpsPage, read_xps};
+
 #[cfg(feature = "excel")]
 pub use table::table_from_arrow_to_markdown;
 
@@ -93,7 +93,12 @@ pub fn extract_core_properties<R: Read + std::io::Seek>(archive: &mut ZipArchive
         }
     }
 
-    let doc = roxmltree::Document::parse(&xml_content)
+    parse_core_properties(&xml_content)
+}
+
+/// Parse the Dublin Core metadata of a core properties part, wherever the package keeps it.
+pub(crate) fn parse_core_properties(xml_content: &str) -> Result<CoreProperties> {
+    let doc = roxmltree::Document::parse(xml_content)
         .map_err(|e| KreuzbergError::parsing(format!("Failed to parse core.xml: {}", e)))?;
 
     let root = doc.root_element();
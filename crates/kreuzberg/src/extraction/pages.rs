@@ -0,0 +1,88 @@
+//! Assembly of documents read page by page.
+//!
+//! Extractors that read the text of each page themselves lay the pages out the way the
+//! PDF extractor does: separated by a blank line, or each preceded by the configured page
+//! marker, with a boundary per page in the page structure.
+
+use crate::core::config::PageConfig;
+use crate::types::{PageBoundary, PageContent, PageInfo, PageStructure, PageUnitType};
+
+/// The content of a document assembled from its pages.
+#[derive(Debug, Clone)]
+pub struct PaginatedContent {
+    pub content: String,
+    pub structure: PageStructure,
+    /// The pages themselves, kept only when page extraction is enabled.
+    pub pages: Option<Vec<PageContent>>,
+}
+
+/// Join `pages` into the content of a document, with a boundary for each and `infos` as
+/// the per-page metadata of its page structure.
+pub fn paginate(pages: Vec<PageContent>, infos: Vec<PageInfo>, config: Option<&PageConfig>) -> PaginatedContent {
+    let mut content = String::new();
+    let mut boundaries = Vec::with_capacity(pages.len());
+    for (index, page) in pages.iter().enumerate() {
+        if let Some(cfg) = config
+            && cfg.insert_page_markers
+        {
+            content.push_str(&cfg.marker_format.replace("{page_num}", &page.page_number.to_string()));
+        } else if index > 0 {
+            content.push_str("\n\n");
+        }
+        let byte_start = content.len();
+        content.push_str(&page.content);
+        boundaries.push(PageBoundary {
+            byte_start,
+            byte_end: content.len(),
+            page_number: page.page_number,
+        });
+    }
+
+    PaginatedContent {
+        content,
+        structure: PageStructure {
+            total_count: pages.len(),
+            unit_type: PageUnitType::Page,
+            boundaries: Some(boundaries),
+            pages: Some(infos),
+        },
+        pages: config.is_some_and(|cfg| cfg.extract_pages).then_some(pages),
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    fn page(page_number: usize, content: &str) -> PageContent {
+        PageContent {
+            page_number,
+            content: content.to_string(),
+            tables: vec![],
+            images: vec![],
+            hierarchy: None,
+        }
+    }
+
+    #[test]
+    fn test_paginate_with_markers() {
+        let config = PageConfig {
+            insert_page_markers: true,
+            marker_format: "[page {page_num}]".to_string(),
+            ..Default::default()
+        };
+        let result = paginate(vec![page(1, "One"), page(2, "Two")], vec![], Some(&config));
+
+        assert_eq!(result.content, "[page 1]One[page 2]Two");
+        let boundaries = result.structure.boundaries.unwrap();
+        assert_eq!(&result.content[boundaries[1].byte_start..boundaries[1].byte_end], "Two");
+        assert!(result.pages.is_none());
+    }
+
+    #[test]
+    fn test_paginate_without_config() {
+        let result = paginate(vec![page(1, "One"), page(2, "")], vec![], None);
+        assert_eq!(result.content, "One\n\n");
+        assert_eq!(result.structure.total_count, 2);
+    }
+}
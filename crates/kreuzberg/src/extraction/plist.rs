@@ -0,0 +1,343 @@
+//! Binary property list decoding.
+//!
+//! Decodes the subset of the `bplist00` format needed to read archives written by Apple
+//! software: dictionaries, arrays, strings, data, numbers and booleans.
+
+use crate::{KreuzbergError, Result};
+use std::collections::{HashMap, HashSet};
+
+/// Magic bytes opening every binary property list.
+pub const BINARY_PLIST_MAGIC: &[u8] = b"bplist00";
+
+/// Bound on the objects decoded from one property list, since shared references let a
+/// small file expand into a very large tree.
+const MAX_PLIST_OBJECTS: usize = 1 << 20;
+
+/// Bound on the nesting of arrays and dictionaries.
+const MAX_PLIST_DEPTH: usize = 256;
+
+/// An object of a property list. Data objects borrow from the decoded buffer.
+#[derive(Debug, Clone, PartialEq)]
+pub enum PlistValue<'a> {
+    Null,
+    Bool(bool),
+    Integer(i64),
+    Real(f64),
+    Data(&'a [u8]),
+    String(String),
+    Array(Vec<PlistValue<'a>>),
+    Dictionary(HashMap<String, PlistValue<'a>>),
+}
+
+impl<'a> PlistValue<'a> {
+    /// Value of `key` if this is a dictionary holding it.
+    pub fn get(&self, key: &str) -> Option<&PlistValue<'a>> {
+        match self {
+            PlistValue::Dictionary(entries) => entries.get(key),
+            _ => None,
+        }
+    }
+
+    pub fn as_str(&self) -> Option<&str> {
+        match self {
+            PlistValue::String(text) => Some(text),
+            _ => None,
+        }
+    }
+
+    pub fn as_data(&self) -> Option<&'a [u8]> {
+        match self {
+            PlistValue::Data(data) => Some(data),
+            _ => None,
+        }
+    }
+
+    pub fn as_array(&self) -> Option<&[PlistValue<'a>]> {
+        match self {
+            PlistValue::Array(items) => Some(items),
+            _ => None,
+        }
+    }
+}
+
+/// Decode the top-level object of a binary property list.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if `data` is not a binary property list, refers to
+/// objects outside of it, contains reference cycles, or nests or expands beyond the
+/// decoder's bounds.
+pub fn decode_binary_plist(data: &[u8]) -> Result<PlistValue<'_>> {
+    if !data.starts_with(BINARY_PLIST_MAGIC) || data.len() < BINARY_PLIST_MAGIC.len() + 32 {
+        return Err(KreuzbergError::parsing("not a binary property list"));
+    }
+    let trailer = &data[data.len() - 32..];
+    let offset_size = usize::from(trailer[6]);
+    let ref_size = usize::from(trailer[7]);
+    let count = read_big_endian(&trailer[8..16]);
+    let top = read_big_endian(&trailer[16..24]);
+    let table_offset = read_big_endian(&trailer[24..32]);
+    if !(1..=8).contains(&offset_size) || !(1..=8).contains(&ref_size) || count == 0 || top >= count {
+        return Err(KreuzbergError::parsing("invalid property list trailer"));
+    }
+    let len = data.len() as u64;
+    if table_offset > len || count > (len - table_offset) / offset_size as u64 {
+        return Err(KreuzbergError::parsing("property list offset table out of range"));
+    }
+
+    let offsets = data[table_offset as usize..]
+        .chunks_exact(offset_size)
+        .take(count as usize)
+        .map(read_big_endian)
+        .collect();
+    let mut plist = BinaryPlist {
+        data,
+        offsets,
+        ref_size,
+        decoding: HashSet::new(),
+        decoded: 0,
+    };
+    plist.object(top, 0)
+}
+
+fn read_big_endian(bytes: &[u8]) -> u64 {
+    bytes.iter().fold(0, |n, &b| (n << 8) | u64::from(b))
+}
+
+struct BinaryPlist<'a> {
+    data: &'a [u8],
+    offsets: Vec<u64>,
+    ref_size: usize,
+    decoding: HashSet<u64>,
+    decoded: usize,
+}
+
+impl<'a> BinaryPlist<'a> {
+    fn object(&mut self, reference: u64, depth: usize) -> Result<PlistValue<'a>> {
+        let Some(&offset) = self.offsets.get(reference as usize) else {
+            return Err(KreuzbergError::parsing(format!(
+                "object reference {} out of range",
+                reference
+            )));
+        };
+        if depth > MAX_PLIST_DEPTH {
+            return Err(KreuzbergError::parsing("property list is nested too deeply"));
+        }
+        if self.decoding.contains(&reference) {
+            return Err(KreuzbergError::parsing("property list contains a reference cycle"));
+        }
+        self.decoded += 1;
+        if self.decoded > MAX_PLIST_OBJECTS {
+            return Err(KreuzbergError::parsing("property list has too many objects"));
+        }
+
+        self.decoding.insert(reference);
+        let value = self.decode(reference, offset, depth);
+        self.decoding.remove(&reference);
+        value
+    }
+
+    fn decode(&mut self, reference: u64, offset: u64, depth: usize) -> Result<PlistValue<'a>> {
+        let Some(&marker) = self.data.get(offset as usize) else {
+            return Err(KreuzbergError::parsing(format!("object {} out of range", reference)));
+        };
+        let (kind, info) = (marker >> 4, marker & 0x0F);
+        let pos = offset + 1;
+
+        match kind {
+            0x0 => {
+                return Ok(match marker {
+                    0x08 => PlistValue::Bool(false),
+                    0x09 => PlistValue::Bool(true),
+                    _ => PlistValue::Null,
+                });
+            }
+            0x1 => {
+                let bytes = self.bytes(pos, 1u64 << info.min(3))?;
+                return Ok(PlistValue::Integer(read_big_endian(bytes) as i64));
+            }
+            0x2 => {
+                let bytes = self.bytes(pos, 1u64 << info.min(4))?;
+                return match *bytes {
+                    [a, b, c, d] => Ok(PlistValue::Real(f64::from(f32::from_be_bytes([a, b, c, d])))),
+                    [a, b, c, d, e, f, g, h] => Ok(PlistValue::Real(f64::from_be_bytes([a, b, c, d, e, f, g, h]))),
+                    _ => Err(KreuzbergError::parsing("unsupported real size")),
+                };
+            }
+            _ => {}
+        }
+
+        let (length, pos) = self.length(info, pos)?;
+        match kind {
+            0x4 => Ok(PlistValue::Data(self.bytes(pos, length)?)),
+            0x5 => Ok(PlistValue::String(
+                String::from_utf8_lossy(self.bytes(pos, length)?).into_owned(),
+            )),
+            0x6 => {
+                let bytes = self.bytes(pos, length.saturating_mul(2))?;
+                let units: Vec<u16> = bytes
+                    .chunks_exact(2)
+                    .map(|pair| u16::from_be_bytes([pair[0], pair[1]]))
+                    .collect();
+                Ok(PlistValue::String(String::from_utf16_lossy(&units)))
+            }
+            0xA => {
+                let refs = self.refs(pos, length)?;
+                let mut items = Vec::with_capacity(refs.len());
+                for reference in refs {
+                    items.push(self.object(reference, depth + 1)?);
+                }
+                Ok(PlistValue::Array(items))
+            }
+            0xD => {
+                let refs = self.refs(pos, length.saturating_mul(2))?;
+                let (keys, values) = refs.split_at(refs.len() / 2);
+                let mut entries = HashMap::with_capacity(keys.len());
+                for (&key, &value) in keys.iter().zip(values) {
+                    let PlistValue::String(name) = self.object(key, depth + 1)? else {
+                        return Err(KreuzbergError::parsing("property list dictionary key is not a string"));
+                    };
+                    let value = self.object(value, depth + 1)?;
+                    entries.insert(name, value);
+                }
+                Ok(PlistValue::Dictionary(entries))
+            }
+            _ => Err(KreuzbergError::parsing(format!(
+                "unsupported property list object type 0x{:x}",
+                kind
+            ))),
+        }
+    }
+
+    /// Read the element count of a variable-length object, which is stored in the marker
+    /// or, when the marker holds 0xF, in a following integer object. Returns the count and
+    /// the position of the elements.
+    fn length(&self, info: u8, pos: u64) -> Result<(u64, u64)> {
+        if info != 0x0F {
+            return Ok((u64::from(info), pos));
+        }
+        let header = self.bytes(pos, 1)?[0];
+        if header >> 4 != 0x1 {
+            return Err(KreuzbergError::parsing("invalid property list length"));
+        }
+        let size = 1u64 << (header & 0x0F).min(3);
+        let length = read_big_endian(self.bytes(pos + 1, size)?);
+        Ok((length, pos + 1 + size))
+    }
+
+    fn refs(&self, pos: u64, count: u64) -> Result<Vec<u64>> {
+        let bytes = self.bytes(pos, count.saturating_mul(self.ref_size as u64))?;
+        Ok(bytes.chunks_exact(self.ref_size).map(read_big_endian).collect())
+    }
+
+    fn bytes(&self, pos: u64, n: u64) -> Result<&'a [u8]> {
+        let len = self.data.len() as u64;
+        if pos > len || n > len - pos {
+            return Err(KreuzbergError::parsing("property list object out of range"));
+        }
+        Ok(&self.data[pos as usize..(pos + n) as usize])
+    }
+}
+
+#[cfg(test)]
+pub(crate) mod test_support {
+    use super::BINARY_PLIST_MAGIC;
+
+    /// An object to encode with `binary_plist`.
+    pub(crate) enum Object<'a> {
+        String(&'a str),
+        Data(&'a [u8]),
+        Array(Vec<Object<'a>>),
+        Dictionary(Vec<(&'a str, Object<'a>)>),
+    }
+
+    /// Encode `root` as a binary property list with one-byte references, so at most 256
+    /// objects, and eight-byte offsets.
+    pub(crate) fn binary_plist(root: &Object<'_>) -> Vec<u8> {
+        let mut objects = Vec::new();
+        encode(root, &mut objects);
+        let mut out = BINARY_PLIST_MAGIC.to_vec();
+        let mut offsets = Vec::with_capacity(objects.len());
+        for object in &objects {
+            offsets.push(out.len() as u64);
+            out.extend_from_slice(object);
+        }
+        let table = out.len() as u64;
+        for offset in &offsets {
+            out.extend_from_slice(&offset.to_be_bytes());
+        }
+        out.extend_from_slice(&[0, 0, 0, 0, 0, 0, 8, 1]);
+        out.extend_from_slice(&(objects.len() as u64).to_be_bytes());
+        out.extend_from_slice(&0u64.to_be_bytes());
+        out.extend_from_slice(&table.to_be_bytes());
+        out
+    }
+
+    /// Append the encoding of `object` and its children to `objects`, returning its index.
+    fn encode(object: &Object<'_>, objects: &mut Vec<Vec<u8>>) -> u8 {
+        let index = objects.len();
+        objects.push(Vec::new());
+        let encoded = match object {
+            Object::String(text) => [header(0x5, text.len()), text.as_bytes().to_vec()].concat(),
+            Object::Data(data) => [header(0x4, data.len()), data.to_vec()].concat(),
+            Object::Array(items) => {
+                let refs: Vec<u8> = items.iter().map(|item| encode(item, objects)).collect();
+                [header(0xA, refs.len()), refs].concat()
+            }
+            Object::Dictionary(entries) => {
+                let keys: Vec<u8> = entries
+                    .iter()
+                    .map(|(key, _)| encode(&Object::String(*key), objects))
+                    .collect();
+                let values: Vec<u8> = entries.iter().map(|(_, value)| encode(value, objects)).collect();
+                [header(0xD, entries.len()), keys, values].concat()
+            }
+        };
+        objects[index] = encoded;
+        index as u8
+    }
+
+    fn header(kind: u8, length: usize) -> Vec<u8> {
+        if length < 0x0F {
+            return vec![(kind << 4) | length as u8];
+        }
+        let mut out = vec![(kind << 4) | 0x0F, 0x13];
+        out.extend_from_slice(&(length as u64).to_be_bytes());
+        out
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::test_support::{Object, binary_plist};
+    use super::*;
+
+    #[test]
+    fn test_decode_binary_plist() {
+        let long = "a".repeat(40);
+        let data = binary_plist(&Object::Dictionary(vec![
+            ("name", Object::String("Page")),
+            ("long", Object::String(&long)),
+            ("data", Object::Data(b"\x00\x01")),
+            ("list", Object::Array(vec![Object::String("x"), Object::String("y")])),
+        ]));
+
+        let root = decode_binary_plist(&data).expect("property list should decode");
+        assert_eq!(root.get("name").and_then(PlistValue::as_str), Some("Page"));
+        assert_eq!(root.get("long").and_then(PlistValue::as_str), Some(long.as_str()));
+        assert_eq!(root.get("data").and_then(PlistValue::as_data), Some(&b"\x00\x01"[..]));
+        let list = root.get("list").and_then(PlistValue::as_array).expect("list");
+        assert_eq!(list.len(), 2);
+        assert_eq!(list[1].as_str(), Some("y"));
+    }
+
+    #[test]
+    fn test_decode_binary_plist_rejects_malformed_input() {
+        let mut cyclic = binary_plist(&Object::Array(vec![Object::String("x")]));
+        // Point the array's only reference back at the array itself.
+        cyclic[BINARY_PLIST_MAGIC.len() + 1] = 0;
+        for data in [&b"not a plist"[..], BINARY_PLIST_MAGIC, &cyclic] {
+            assert!(decode_binary_plist(data).is_err());
+        }
+    }
+}
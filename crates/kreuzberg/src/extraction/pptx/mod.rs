@@ -187,7 +187,7 @@ pub fn extract_pptx_from_bytes(
     use std::sync::atomic::{AtomicU64, Ordering};
     static COUNTER: AtomicU64 = AtomicU64::new(0);
     let unique_id = COUNTER.fetch_add(1, Ordering::SeqCst);
-    let temp_path = std::env::temp_dir().join(format!("temp_pptx_{}_{}.pptx", std::process::id(), unique_id));
+    let temp_path = crate::utils::temp_dir().join(format!("temp_pptx_{}_{}.pptx", std::process::id(), unique_id));
 
     // IO errors must bubble up - temp file write issues need user reports ~keep
     std::fs::write(&temp_path, data)?;
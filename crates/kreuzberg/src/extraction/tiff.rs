@@ -0,0 +1,540 @@
+//! Multi-page TIFF splitting.
+//!
+//! Multi-page TIFFs, scanned documents and faxes above all, are split into single-page
+//! TIFFs so that each page is recognized on its own and gets its own text, boundary and
+//! `PageContent`. Only classic TIFFs are split; reduced-resolution images such as
+//! thumbnails are not pages.
+
+use crate::types::FaxMetadata;
+use crate::{KreuzbergError, Result};
+use std::collections::{HashMap, HashSet};
+
+const NEW_SUBFILE_TYPE: u16 = 254;
+const SUBFILE_TYPE: u16 = 255;
+const IMAGE_WIDTH: u16 = 256;
+const IMAGE_LENGTH: u16 = 257;
+const COMPRESSION: u16 = 259;
+const STRIP_OFFSETS: u16 = 273;
+const STRIP_BYTE_COUNTS: u16 = 279;
+const X_RESOLUTION: u16 = 282;
+const Y_RESOLUTION: u16 = 283;
+const FREE_OFFSETS: u16 = 288;
+const FREE_BYTE_COUNTS: u16 = 289;
+const RESOLUTION_UNIT: u16 = 296;
+const TILE_OFFSETS: u16 = 324;
+const TILE_BYTE_COUNTS: u16 = 325;
+const SUB_IFDS: u16 = 330;
+const JPEG_OFFSET: u16 = 513;
+const JPEG_LENGTH: u16 = 514;
+const EXIF_IFD: u16 = 34665;
+const GPS_IFD: u16 = 34853;
+const INTEROP_IFD: u16 = 40965;
+
+/// Largest number of directories walked for pages.
+const MAX_PAGES: usize = 10_000;
+
+/// Largest number of entries in a directory.
+const MAX_DIRECTORY_ENTRIES: usize = 4096;
+
+/// A page of a multi-page TIFF, written as a single-page TIFF.
+#[derive(Debug, Clone)]
+pub struct TiffPage {
+    pub data: Vec<u8>,
+    /// Width and height in points when the resolution is known, else in pixels
+    pub dimensions: (f64, f64),
+}
+
+/// The pages of a multi-page TIFF and, for faxes, their encoding.
+#[derive(Debug, Clone)]
+pub struct SplitTiff {
+    pub pages: Vec<TiffPage>,
+    pub fax: Option<FaxMetadata>,
+}
+
+/// Split a multi-page TIFF into single-page TIFFs in the original byte order, or return
+/// `None` when `data` has a single page.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if `data` is not a classic TIFF, its directory chain
+/// loops, or the image data of a page lies outside the file.
+pub fn split_tiff(data: &[u8]) -> Result<Option<SplitTiff>> {
+    let (order, directories) = read_directories(data)?;
+    if directories.len() < 2 {
+        return Ok(None);
+    }
+
+    let mut pages = Vec::with_capacity(directories.len());
+    for (index, directory) in directories.iter().enumerate() {
+        let page = directory
+            .standalone(data, order)
+            .map_err(|message| KreuzbergError::parsing(format!("TIFF page {}: {}", index + 1, message)))?;
+        pages.push(TiffPage {
+            data: page,
+            dimensions: directory.dimensions(),
+        });
+    }
+
+    let first = &directories[0];
+    let dpi = |tag| Some(first.resolution(tag)).filter(|dpi| *dpi > 0.0);
+    let fax = compression_name(first.field(COMPRESSION)).map(|compression| FaxMetadata {
+        compression: compression.to_string(),
+        horizontal_dpi: dpi(X_RESOLUTION),
+        vertical_dpi: dpi(Y_RESOLUTION),
+        page_count: directories.len(),
+    });
+    Ok(Some(SplitTiff { pages, fax }))
+}
+
+/// Name the CCITT fax compressions.
+fn compression_name(compression: u64) -> Option<&'static str> {
+    match compression {
+        2 => Some("ccitt_rle"),
+        3 => Some("group3"),
+        4 => Some("group4"),
+        _ => None,
+    }
+}
+
+/// Return the tag giving the sizes of the image data located by `tag`, if it locates any.
+fn size_tag(tag: u16) -> Option<u16> {
+    match tag {
+        STRIP_OFFSETS => Some(STRIP_BYTE_COUNTS),
+        TILE_OFFSETS => Some(TILE_BYTE_COUNTS),
+        JPEG_OFFSET => Some(JPEG_LENGTH),
+        _ => None,
+    }
+}
+
+/// Whether `tag` points to data outside the page, which a split page does not carry.
+fn is_pointer_tag(tag: u16) -> bool {
+    matches!(
+        tag,
+        FREE_OFFSETS | FREE_BYTE_COUNTS | SUB_IFDS | EXIF_IFD | GPS_IFD | INTEROP_IFD
+    )
+}
+
+/// Size in bytes of a value of a field type, or 0 for unknown types.
+fn type_size(kind: u16) -> usize {
+    match kind {
+        1 | 2 | 6 | 7 => 1,
+        3 | 8 => 2,
+        4 | 9 | 11 => 4,
+        5 | 10 | 12 => 8,
+        _ => 0,
+    }
+}
+
+#[derive(Debug, Clone, Copy, PartialEq)]
+enum ByteOrder {
+    Little,
+    Big,
+}
+
+impl ByteOrder {
+    fn u16(self, data: &[u8]) -> u16 {
+        let bytes = [data[0], data[1]];
+        match self {
+            Self::Little => u16::from_le_bytes(bytes),
+            Self::Big => u16::from_be_bytes(bytes),
+        }
+    }
+
+    fn u32(self, data: &[u8]) -> u32 {
+        let bytes = [data[0], data[1], data[2], data[3]];
+        match self {
+            Self::Little => u32::from_le_bytes(bytes),
+            Self::Big => u32::from_be_bytes(bytes),
+        }
+    }
+
+    fn u16_bytes(self, value: u16) -> [u8; 2] {
+        match self {
+            Self::Little => value.to_le_bytes(),
+            Self::Big => value.to_be_bytes(),
+        }
+    }
+
+    fn u32_bytes(self, value: u32) -> [u8; 4] {
+        match self {
+            Self::Little => value.to_le_bytes(),
+            Self::Big => value.to_be_bytes(),
+        }
+    }
+}
+
+/// A directory entry and its value, wherever the file keeps it.
+struct Entry<'a> {
+    tag: u16,
+    kind: u16,
+    count: u32,
+    value: &'a [u8],
+}
+
+impl Entry<'_> {
+    /// Return the integer values of the entry; rationals come as numerator and
+    /// denominator pairs.
+    fn numbers(&self, order: ByteOrder) -> Vec<u64> {
+        match self.kind {
+            1 | 6 => self.value.iter().map(|&byte| u64::from(byte)).collect(),
+            3 | 8 => self
+                .value
+                .chunks_exact(2)
+                .map(|pair| u64::from(order.u16(pair)))
+                .collect(),
+            4 | 5 | 9 | 10 => self
+                .value
+                .chunks_exact(4)
+                .map(|quad| u64::from(order.u32(quad)))
+                .collect(),
+            _ => Vec::new(),
+        }
+    }
+}
+
+/// An image directory: its entries and their values as numbers.
+struct Directory<'a> {
+    entries: Vec<Entry<'a>>,
+    fields: HashMap<u16, Vec<u64>>,
+}
+
+/// Read the directories of a classic TIFF, skipping reduced-resolution images.
+fn read_directories(data: &[u8]) -> Result<(ByteOrder, Vec<Directory<'_>>)> {
+    if data.len() < 8 {
+        return Err(KreuzbergError::parsing("not a TIFF file"));
+    }
+    let order = match &data[..4] {
+        b"II*\0" => ByteOrder::Little,
+        b"MM\0*" => ByteOrder::Big,
+        _ => return Err(KreuzbergError::parsing("not a classic TIFF file")),
+    };
+
+    let mut directories = Vec::new();
+    let mut seen = HashSet::new();
+    let mut offset = order.u32(&data[4..]);
+    while offset != 0 {
+        if !seen.insert(offset) || seen.len() > MAX_PAGES {
+            return Err(KreuzbergError::parsing("TIFF directory chain loops or is too long"));
+        }
+        let start = offset as usize;
+        if start < 8 || start + 2 > data.len() {
+            return Err(KreuzbergError::parsing(format!(
+                "invalid TIFF directory offset {}",
+                offset
+            )));
+        }
+        let count = usize::from(order.u16(&data[start..]));
+        let end = start + 2 + 12 * count;
+        if count > MAX_DIRECTORY_ENTRIES || end + 4 > data.len() {
+            return Err(KreuzbergError::parsing(format!(
+                "invalid TIFF directory size {}",
+                count
+            )));
+        }
+
+        let mut directory = Directory {
+            entries: Vec::with_capacity(count),
+            fields: HashMap::new(),
+        };
+        for raw in data[start + 2..end].chunks_exact(12) {
+            let (tag, kind, values) = (order.u16(raw), order.u16(&raw[2..]), order.u32(&raw[4..]));
+            let size = type_size(kind);
+            let length = size as u64 * u64::from(values);
+            if size == 0 || length > data.len() as u64 {
+                continue;
+            }
+            let length = length as usize;
+            let value = if length <= 4 {
+                &raw[8..8 + length]
+            } else {
+                let at = order.u32(&raw[8..]) as usize;
+                match data.get(at..at + length) {
+                    Some(value) => value,
+                    None => continue,
+                }
+            };
+            let entry = Entry {
+                tag,
+                kind,
+                count: values,
+                value,
+            };
+            directory.fields.insert(tag, entry.numbers(order));
+            directory.entries.push(entry);
+        }
+
+        let reduced = directory.field(NEW_SUBFILE_TYPE) & 1 != 0 || directory.field(SUBFILE_TYPE) == 2;
+        if !reduced {
+            directories.push(directory);
+        }
+        offset = order.u32(&data[end..]);
+    }
+    Ok((order, directories))
+}
+
+impl Directory<'_> {
+    /// Return the first value of a field, or 0.
+    fn field(&self, tag: u16) -> u64 {
+        self.fields
+            .get(&tag)
+            .and_then(|values| values.first())
+            .copied()
+            .unwrap_or(0)
+    }
+
+    /// Return the resolution of a field in dots per inch, or 0 when it is unknown.
+    fn resolution(&self, tag: u16) -> f64 {
+        let Some([numerator, denominator, ..]) = self.fields.get(&tag).map(Vec::as_slice) else {
+            return 0.0;
+        };
+        if *denominator == 0 {
+            return 0.0;
+        }
+        let dpi = *numerator as f64 / *denominator as f64;
+        match self.field(RESOLUTION_UNIT) {
+            1 => 0.0,
+            3 => dpi * 2.54,
+            _ => dpi,
+        }
+    }
+
+    /// Size the page in points when its resolution is known, else in pixels.
+    fn dimensions(&self) -> (f64, f64) {
+        let (width, height) = (self.field(IMAGE_WIDTH) as f64, self.field(IMAGE_LENGTH) as f64);
+        let (x, y) = (self.resolution(X_RESOLUTION), self.resolution(Y_RESOLUTION));
+        if x > 0.0 && y > 0.0 {
+            (width / x * 72.0, height / y * 72.0)
+        } else {
+            (width, height)
+        }
+    }
+
+    /// Write the page as a single-page TIFF, copying its image data and dropping pointers
+    /// to data outside the page.
+    fn standalone(&self, data: &[u8], order: ByteOrder) -> std::result::Result<Vec<u8>, String> {
+        let mut entries: Vec<&Entry<'_>> = self.entries.iter().filter(|entry| !is_pointer_tag(entry.tag)).collect();
+        entries.sort_by_key(|entry| entry.tag);
+
+        // The directory follows the header; values too long to fit an entry follow the
+        // directory, and the image data follows them.
+        let mut offsets = vec![0; entries.len()];
+        let mut end = 8 + 2 + 12 * entries.len() + 4;
+        for (index, entry) in entries.iter().enumerate() {
+            let length = match size_tag(entry.tag) {
+                Some(_) => 4 * entry.count as usize,
+                None => entry.value.len(),
+            };
+            if length > 4 {
+                offsets[index] = end;
+                end += length + length % 2;
+            }
+        }
+
+        let mut blocks = Vec::new();
+        let mut kinds: Vec<u16> = entries.iter().map(|entry| entry.kind).collect();
+        let mut values: Vec<Vec<u8>> = entries.iter().map(|entry| entry.value.to_vec()).collect();
+        for (index, entry) in entries.iter().enumerate() {
+            let Some(sizes_tag) = size_tag(entry.tag) else {
+                continue;
+            };
+            let starts = entry.numbers(order);
+            let sizes = self.fields.get(&sizes_tag).map(Vec::as_slice).unwrap_or_default();
+            if starts.len() != sizes.len() {
+                return Err(format!(
+                    "tag {} has {} offsets but {} sizes",
+                    entry.tag,
+                    starts.len(),
+                    sizes.len()
+                ));
+            }
+            let mut value = Vec::with_capacity(4 * starts.len());
+            for (&start, &size) in starts.iter().zip(sizes) {
+                let block = usize::try_from(start)
+                    .ok()
+                    .zip(usize::try_from(size).ok())
+                    .and_then(|(start, size)| data.get(start..start.checked_add(size)?))
+                    .ok_or_else(|| "image data out of range".to_string())?;
+                value.extend_from_slice(&order.u32_bytes((end + blocks.len()) as u32));
+                blocks.extend_from_slice(block);
+            }
+            kinds[index] = 4;
+            values[index] = value;
+        }
+
+        let mut out = vec![0; end];
+        out.reserve(blocks.len());
+        out[..4].copy_from_slice(&data[..4]);
+        out[4..8].copy_from_slice(&order.u32_bytes(8));
+        out[8..10].copy_from_slice(&order.u16_bytes(entries.len() as u16));
+        for (index, entry) in entries.iter().enumerate() {
+            let at = 10 + 12 * index;
+            out[at..at + 2].copy_from_slice(&order.u16_bytes(entry.tag));
+            out[at + 2..at + 4].copy_from_slice(&order.u16_bytes(kinds[index]));
+            out[at + 4..at + 8].copy_from_slice(&order.u32_bytes(entry.count));
+            let value = &values[index];
+            if value.len() > 4 {
+                out[at + 8..at + 12].copy_from_slice(&order.u32_bytes(offsets[index] as u32));
+                out[offsets[index]..offsets[index] + value.len()].copy_from_slice(value);
+            } else {
+                out[at + 8..at + 8 + value.len()].copy_from_slice(value);
+            }
+        }
+        out.extend_from_slice(&blocks);
+        Ok(out)
+    }
+}
+
+#[cfg(test)]
+pub(crate) mod test_support {
+    use super::*;
+
+    /// A directory entry of a test file. Values of type 5 are numerator and denominator
+    /// pairs.
+    pub(crate) type TestEntry = (u16, u16, Vec<u32>);
+
+    /// Write a little-endian TIFF with one directory per page, each with its entries and
+    /// one strip of image data.
+    pub(crate) fn tiff(pages: &[Vec<TestEntry>], strips: &[&[u8]]) -> Vec<u8> {
+        let mut out = b"II*\0\0\0\0\0".to_vec();
+        let mut link = 4;
+        for (entries, strip) in pages.iter().zip(strips) {
+            let strip_at = out.len() as u32;
+            out.extend_from_slice(strip);
+            let mut entries = entries.clone();
+            entries.push((STRIP_OFFSETS, 4, vec![strip_at]));
+            entries.push((STRIP_BYTE_COUNTS, 4, vec![strip.len() as u32]));
+            if out.len() % 2 == 1 {
+                out.push(0);
+            }
+
+            let directory_at = out.len();
+            let extra_at = directory_at + 2 + 12 * entries.len() + 4;
+            let mut directory = (entries.len() as u16).to_le_bytes().to_vec();
+            let mut extra = Vec::new();
+            for (tag, kind, values) in &entries {
+                let mut value = Vec::new();
+                for &v in values {
+                    if *kind == 3 {
+                        value.extend_from_slice(&(v as u16).to_le_bytes());
+                    } else {
+                        value.extend_from_slice(&v.to_le_bytes());
+                    }
+                }
+                let count = if *kind == 5 { values.len() / 2 } else { values.len() };
+                directory.extend_from_slice(&tag.to_le_bytes());
+                directory.extend_from_slice(&kind.to_le_bytes());
+                directory.extend_from_slice(&(count as u32).to_le_bytes());
+                if value.len() > 4 {
+                    directory.extend_from_slice(&((extra_at + extra.len()) as u32).to_le_bytes());
+                    extra.extend_from_slice(&value);
+                } else {
+                    value.resize(4, 0);
+                    directory.extend_from_slice(&value);
+                }
+            }
+            out[link..link + 4].copy_from_slice(&(directory_at as u32).to_le_bytes());
+            link = directory_at + directory.len();
+            out.extend_from_slice(&directory);
+            out.extend_from_slice(&[0; 4]);
+            out.extend_from_slice(&extra);
+        }
+        out
+    }
+
+    /// A page of a standard-resolution Group 4 fax, `width` pixels wide.
+    pub(crate) fn fax_page(width: u32) -> Vec<TestEntry> {
+        vec![
+            (IMAGE_WIDTH, 4, vec![width]),
+            (IMAGE_LENGTH, 4, vec![1100]),
+            (COMPRESSION, 3, vec![4]),
+            (X_RESOLUTION, 5, vec![204, 1]),
+            (Y_RESOLUTION, 5, vec![98, 1]),
+            (RESOLUTION_UNIT, 3, vec![2]),
+            (EXIF_IFD, 4, vec![12345]),
+        ]
+    }
+
+    /// An uncompressed 8-bit grayscale page of `width` by `height` pixels at 72 dpi, and
+    /// its strip.
+    pub(crate) fn grayscale_page(width: u32, height: u32) -> (Vec<TestEntry>, Vec<u8>) {
+        const BITS_PER_SAMPLE: u16 = 258;
+        const PHOTOMETRIC_INTERPRETATION: u16 = 262;
+        const SAMPLES_PER_PIXEL: u16 = 277;
+        const ROWS_PER_STRIP: u16 = 278;
+
+        let entries = vec![
+            (IMAGE_WIDTH, 4, vec![width]),
+            (IMAGE_LENGTH, 4, vec![height]),
+            (BITS_PER_SAMPLE, 3, vec![8]),
+            (COMPRESSION, 3, vec![1]),
+            (PHOTOMETRIC_INTERPRETATION, 3, vec![1]),
+            (SAMPLES_PER_PIXEL, 3, vec![1]),
+            (ROWS_PER_STRIP, 4, vec![height]),
+            (X_RESOLUTION, 5, vec![72, 1]),
+            (Y_RESOLUTION, 5, vec![72, 1]),
+            (RESOLUTION_UNIT, 3, vec![2]),
+        ];
+        (entries, vec![0x80; (width * height) as usize])
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::test_support::{fax_page, tiff};
+    use super::*;
+
+    #[test]
+    fn test_split_tiff() {
+        let mut thumbnail = vec![(NEW_SUBFILE_TYPE, 4, vec![1])];
+        thumbnail.extend(fax_page(64));
+        let data = tiff(
+            &[fax_page(1728), thumbnail, fax_page(1728)],
+            &[b"first page strip", b"thumb", b"second"],
+        );
+
+        let split = split_tiff(&data)
+            .expect("fax should be split")
+            .expect("fax has several pages");
+        assert_eq!(split.pages.len(), 2, "the thumbnail is not a page");
+        for (page, strip) in split.pages.iter().zip([&b"first page strip"[..], &b"second"[..]]) {
+            let (order, directories) = read_directories(&page.data).expect("page should be a TIFF");
+            assert_eq!(order, ByteOrder::Little);
+            assert_eq!(directories.len(), 1);
+            let directory = &directories[0];
+            let start = directory.field(STRIP_OFFSETS) as usize;
+            let size = directory.field(STRIP_BYTE_COUNTS) as usize;
+            assert_eq!(&page.data[start..start + size], strip);
+            assert!(!directory.fields.contains_key(&EXIF_IFD));
+            assert_eq!(directory.field(COMPRESSION), 4);
+        }
+
+        // 1728x1100 pixels at the 204x98 dpi of a standard-resolution fax is about 8.5x11 inches.
+        let (width, height) = split.pages[1].dimensions;
+        assert_eq!((width.round(), height.round()), (610.0, 808.0));
+        assert_eq!(
+            split.fax,
+            Some(FaxMetadata {
+                compression: "group4".to_string(),
+                horizontal_dpi: Some(204.0),
+                vertical_dpi: Some(98.0),
+                page_count: 2,
+            })
+        );
+    }
+
+    #[test]
+    fn test_split_tiff_single_page() {
+        let data = tiff(&[fax_page(1728)], &[b"only"]);
+        assert!(split_tiff(&data).expect("single page should be read").is_none());
+    }
+
+    #[test]
+    fn test_split_tiff_rejects_loops() {
+        let mut data = tiff(&[fax_page(1728)], &[b"only"]);
+        let directory = u32::from_le_bytes(data[4..8].try_into().unwrap()) as usize;
+        let count = usize::from(u16::from_le_bytes([data[directory], data[directory + 1]]));
+        let next = directory + 2 + 12 * count;
+        data[next..next + 4].copy_from_slice(&(directory as u32).to_le_bytes());
+        assert!(split_tiff(&data).is_err());
+    }
+}
@@ -0,0 +1,180 @@
+//! Safari webarchive reconstruction.
+//!
+//! A webarchive is a binary property list holding the main resource of a saved page, the
+//! resources it loaded, and the archives of its frames. The page is rebuilt with those
+//! resources inlined as data URIs, so it can be extracted like any other HTML document.
+
+use super::plist::{PlistValue, decode_binary_plist};
+use crate::{KreuzbergError, Result};
+use base64::prelude::*;
+
+/// One file of a saved web page.
+struct WebResource<'a> {
+    url: &'a str,
+    mime_type: &'a str,
+    data: &'a [u8],
+}
+
+/// Rebuild the main page of a webarchive with the resources saved with it, including the
+/// pages of its frames, inlined as data URIs.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if `data` is not a binary property list or has no
+/// main resource.
+pub fn webarchive_to_html(data: &[u8]) -> Result<Vec<u8>> {
+    let archive = decode_binary_plist(data)?;
+    let page = archive
+        .get("WebMainResource")
+        .and_then(web_resource)
+        .ok_or_else(|| KreuzbergError::parsing("webarchive has no main resource"))?;
+    let mut resources = Vec::new();
+    collect_subresources(&archive, &mut resources);
+    Ok(inline_web_resources(&page, &resources))
+}
+
+fn collect_subresources<'p>(archive: &'p PlistValue<'_>, resources: &mut Vec<WebResource<'p>>) {
+    let subresources = archive.get("WebSubresources").and_then(PlistValue::as_array);
+    resources.extend(subresources.unwrap_or_default().iter().filter_map(web_resource));
+    let frames = archive.get("WebSubframeArchives").and_then(PlistValue::as_array);
+    for frame in frames.unwrap_or_default() {
+        if let Some(resource) = frame.get("WebMainResource").and_then(web_resource) {
+            resources.push(resource);
+        }
+        collect_subresources(frame, resources);
+    }
+}
+
+fn web_resource<'p>(value: &'p PlistValue<'_>) -> Option<WebResource<'p>> {
+    Some(WebResource {
+        data: value.get("WebResourceData")?.as_data()?,
+        url: value
+            .get("WebResourceURL")
+            .and_then(PlistValue::as_str)
+            .unwrap_or_default(),
+        mime_type: value
+            .get("WebResourceMIMEType")
+            .and_then(PlistValue::as_str)
+            .unwrap_or_default(),
+    })
+}
+
+/// Replace the references of `page` to `resources` with data URIs. References are matched
+/// as quoted attribute values or CSS `url()` arguments, by absolute URL or by URL relative
+/// to the page.
+fn inline_web_resources(page: &WebResource<'_>, resources: &[WebResource<'_>]) -> Vec<u8> {
+    let mut replacements = Vec::new();
+    for resource in resources.iter().filter(|resource| !resource.data.is_empty()) {
+        let mime_type = if resource.mime_type.is_empty() {
+            "application/octet-stream"
+        } else {
+            resource.mime_type
+        };
+        let uri = format!("data:{};base64,{}", mime_type, BASE64_STANDARD.encode(resource.data));
+        for reference in web_resource_refs(page.url, resource) {
+            replacements.push((reference, uri.clone()));
+        }
+    }
+    if replacements.is_empty() {
+        return page.data.to_vec();
+    }
+
+    let data = page.data;
+    let mut out = Vec::with_capacity(data.len());
+    let (mut copied, mut pos) = (0, 0);
+    while let Some(found) = memchr::memchr3(b'"', b'\'', b'(', &data[pos..]) {
+        let open = pos + found;
+        let close = if data[open] == b'(' { b')' } else { data[open] };
+        let rest = &data[open + 1..];
+        let replacement = replacements
+            .iter()
+            .find(|(reference, _)| rest.starts_with(reference.as_bytes()) && rest.get(reference.len()) == Some(&close));
+        pos = open + 1;
+        if let Some((reference, uri)) = replacement {
+            out.extend_from_slice(&data[copied..pos]);
+            out.extend_from_slice(uri.as_bytes());
+            out.push(close);
+            pos += reference.len() + 1;
+            copied = pos;
+        }
+    }
+    out.extend_from_slice(&data[copied..]);
+    out
+}
+
+/// List the strings a page at `page_url` may use to refer to `resource`.
+fn web_resource_refs(page_url: &str, resource: &WebResource<'_>) -> Vec<String> {
+    let mut refs = Vec::new();
+    if resource.url.is_empty() {
+        return refs;
+    }
+    refs.push(resource.url.to_string());
+    if let Some(slash) = page_url.rfind('/') {
+        let dir = &page_url[..=slash];
+        if let Some(relative) = resource.url.strip_prefix(dir)
+            && !relative.is_empty()
+        {
+            refs.push(relative.to_string());
+        }
+    }
+    refs
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::extraction::plist::test_support::{Object, binary_plist};
+
+    fn resource<'a>(url: &'a str, mime_type: &'a str, data: &'a [u8]) -> Object<'a> {
+        Object::Dictionary(vec![
+            ("WebResourceURL", Object::String(url)),
+            ("WebResourceMIMEType", Object::String(mime_type)),
+            ("WebResourceData", Object::Data(data)),
+        ])
+    }
+
+    #[test]
+    fn test_webarchive_to_html() {
+        let page = br#"<html><body><img src="logo.png"><div style="background:url(https://example.com/img/bg.gif)"></div><iframe src='frame.html'></iframe><a href="other.html">x</a></body></html>"#;
+        let data = binary_plist(&Object::Dictionary(vec![
+            (
+                "WebMainResource",
+                resource("https://example.com/index.html", "text/html", page),
+            ),
+            (
+                "WebSubresources",
+                Object::Array(vec![
+                    resource("https://example.com/logo.png", "image/png", b"PNG"),
+                    resource("https://example.com/img/bg.gif", "", b"GIF"),
+                ]),
+            ),
+            (
+                "WebSubframeArchives",
+                Object::Array(vec![Object::Dictionary(vec![(
+                    "WebMainResource",
+                    resource("https://example.com/frame.html", "text/html", b"<p>Frame</p>"),
+                )])]),
+            ),
+        ]));
+
+        let html = String::from_utf8(webarchive_to_html(&data).expect("webarchive should be read")).unwrap();
+        assert!(html.contains(r#"src="data:image/png;base64,UE5H""#), "{}", html);
+        assert!(
+            html.contains("url(data:application/octet-stream;base64,R0lG)"),
+            "{}",
+            html
+        );
+        assert!(
+            html.contains("src='data:text/html;base64,PHA+RnJhbWU8L3A+'"),
+            "{}",
+            html
+        );
+        assert!(html.contains(r#"href="other.html""#), "{}", html);
+    }
+
+    #[test]
+    fn test_webarchive_without_main_resource() {
+        let data = binary_plist(&Object::Dictionary(vec![("WebSubresources", Object::Array(vec![]))]));
+        assert!(webarchive_to_html(&data).is_err());
+    }
+}
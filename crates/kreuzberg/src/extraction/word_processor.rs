@@ -0,0 +1,381 @@
+//! Text readers of legacy word processor formats.
+//!
+//! WordPerfect 5.x and 6+ documents are read from their document area, skipping function
+//! codes, and Ami Pro documents from their document section. The object store of Lotus
+//! Word Pro is undocumented, so its text is recovered from the printable runs of the file.
+//! Formatting is dropped; paragraphs are separated by newlines.
+
+use super::legacy_office::decode_cp1252;
+use crate::core::mime::{AMIPRO_MIME_TYPE, WORDPERFECT_MIME_TYPE, WORDPRO_MIME_TYPE, word_processor_mime_type};
+use crate::types::WordProcessorMetadata;
+use crate::{KreuzbergError, Result};
+
+/// Text and metadata read from a legacy word processor document.
+#[derive(Debug, Clone, Default)]
+pub struct WordProcessorContent {
+    pub text: String,
+    pub metadata: WordProcessorMetadata,
+}
+
+/// Read the text of a WordPerfect, Lotus Word Pro or Ami Pro document of `mime_type`.
+///
+/// The text of a password-protected WordPerfect document is left empty and
+/// `WordProcessorMetadata::encrypted` is set.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if a WordPerfect document has an unknown version or
+/// its document area is out of range, and `KreuzbergError::UnsupportedFormat` for any
+/// other MIME type.
+pub fn read_word_processor(data: &[u8], mime_type: &str) -> Result<WordProcessorContent> {
+    match mime_type {
+        WORDPERFECT_MIME_TYPE => word_perfect(data),
+        WORDPRO_MIME_TYPE => Ok(word_pro(data)),
+        AMIPRO_MIME_TYPE => Ok(ami_pro(data)),
+        _ => Err(KreuzbergError::UnsupportedFormat(mime_type.to_string())),
+    }
+}
+
+fn word_perfect(data: &[u8]) -> Result<WordProcessorContent> {
+    if word_processor_mime_type(data) != Some(WORDPERFECT_MIME_TYPE) {
+        return Err(KreuzbergError::parsing("not a WordPerfect document"));
+    }
+    let start = u32::from_le_bytes([data[4], data[5], data[6], data[7]]) as usize;
+    let (major, minor) = (data[10], data[11]);
+    let version = match major {
+        0 => format!("5.{}", minor),
+        2 => "6+".to_string(),
+        _ => {
+            return Err(KreuzbergError::parsing(format!(
+                "unknown WordPerfect version {}.{}",
+                major, minor
+            )));
+        }
+    };
+    let mut metadata = WordProcessorMetadata {
+        application: "WordPerfect".to_string(),
+        version: Some(version),
+        encrypted: false,
+    };
+    if u16::from_le_bytes([data[12], data[13]]) != 0 {
+        metadata.encrypted = true;
+        return Ok(WordProcessorContent {
+            text: String::new(),
+            metadata,
+        });
+    }
+    if start < 16 || start > data.len() {
+        return Err(KreuzbergError::parsing("WordPerfect document area out of range"));
+    }
+    let text = if major == 0 {
+        word_perfect5_text(&data[start..])
+    } else {
+        word_perfect6_text(&data[start..])
+    };
+    Ok(WordProcessorContent { text, metadata })
+}
+
+/// Sizes of the WordPerfect 5 function codes 0xC0-0xCF.
+const WP5_FIXED_LENGTHS: [usize; 16] = [4, 9, 11, 3, 3, 5, 6, 7, 4, 5, 6, 4, 4, 5, 6, 4];
+
+fn word_perfect5_text(data: &[u8]) -> String {
+    let mut out = String::new();
+    let mut i = 0;
+    while i < data.len() {
+        let code = data[i];
+        match code {
+            0x20..=0x7E => out.push(char::from(code)),
+            0x0A | 0x0C | 0x8C | 0x99 => out.push('\n'),
+            0x0D | 0x0B | 0xA0 => out.push(' '),
+            0xA9 => out.push('-'),
+            0xC0 => {
+                // Extended character: the character, then its set. Only ASCII is kept.
+                push_extended_ascii(&mut out, data, i);
+                i += WP5_FIXED_LENGTHS[0];
+                continue;
+            }
+            0xC1..=0xCF => {
+                i += WP5_FIXED_LENGTHS[usize::from(code - 0xC0)];
+                continue;
+            }
+            0xD0..=0xFE => {
+                // Variable length: code, subcode, length, then length bytes.
+                i = match data.get(i + 2..i + 4) {
+                    Some(length) => i + 4 + usize::from(u16::from_le_bytes([length[0], length[1]])),
+                    None => data.len(),
+                };
+                continue;
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+    collapse_lines(&out)
+}
+
+/// Sizes of the WordPerfect 6 function codes 0xF0-0xFF.
+const WP6_FIXED_LENGTHS: [usize; 16] = [4, 5, 3, 3, 3, 3, 4, 4, 4, 5, 5, 6, 6, 8, 8, 1];
+
+fn word_perfect6_text(data: &[u8]) -> String {
+    let mut out = String::new();
+    let mut i = 0;
+    while i < data.len() {
+        let code = data[i];
+        match code {
+            0x20..=0x7E => out.push(char::from(code)),
+            0x80 | 0x81 | 0xCF => out.push(' '),
+            0x84 => out.push('-'),
+            0xC7..=0xCE => out.push('\n'),
+            0xD0..=0xEF => {
+                // The end-of-line group: soft breaks, then hard breaks, table cells, and rows.
+                if code == 0xD0 {
+                    out.push(if data.get(i + 1).is_some_and(|&sub| sub <= 0x03) {
+                        ' '
+                    } else {
+                        '\n'
+                    });
+                }
+                // Variable length: code, subgroup, then the size of the whole group.
+                let size = data
+                    .get(i + 2..i + 4)
+                    .map_or(0, |size| usize::from(u16::from_le_bytes([size[0], size[1]])));
+                if size < 4 {
+                    break;
+                }
+                i += size;
+                continue;
+            }
+            0xF0 => {
+                // Extended character: the character, then its set. Only ASCII is kept.
+                push_extended_ascii(&mut out, data, i);
+                i += WP6_FIXED_LENGTHS[0];
+                continue;
+            }
+            0xF1..=0xFF => {
+                i += WP6_FIXED_LENGTHS[usize::from(code - 0xF0)];
+                continue;
+            }
+            _ => {}
+        }
+        i += 1;
+    }
+    collapse_lines(&out)
+}
+
+/// Append the extended character whose function code is at `at` if it is in the ASCII set.
+fn push_extended_ascii(out: &mut String, data: &[u8], at: usize) {
+    if let Some(&[character, 0]) = data.get(at + 1..at + 3)
+        && (0x20..0x7F).contains(&character)
+    {
+        out.push(char::from(character));
+    }
+}
+
+/// Minimum number of characters of a printable run kept from a Word Pro file.
+const MIN_RUN: usize = 4;
+
+fn word_pro(data: &[u8]) -> WordProcessorContent {
+    let decoded = String::from_utf8_lossy(data);
+    let runs: Vec<&str> = decoded
+        .split(|c: char| c != '\t' && (c.is_control() || c == char::REPLACEMENT_CHARACTER))
+        .filter(|run| run.chars().count() >= MIN_RUN)
+        .map(str::trim)
+        .collect();
+    WordProcessorContent {
+        text: collapse_lines(&runs.join("\n")),
+        metadata: WordProcessorMetadata {
+            application: "Lotus Word Pro".to_string(),
+            ..Default::default()
+        },
+    }
+}
+
+/// Read the document section of an Ami Pro file: paragraphs separated by blank lines, each
+/// optionally led by its `@style@`, with formatting in angle brackets and literal brackets
+/// doubled.
+fn ami_pro(data: &[u8]) -> WordProcessorContent {
+    let decoded = decode_cp1252(data).replace("\r\n", "\n");
+    let lines: Vec<&str> = decoded.split('\n').collect();
+    let version = lines
+        .iter()
+        .position(|&line| line == "[ver]")
+        .and_then(|at| lines.get(at + 1))
+        .map(|version| version.trim().to_string());
+    let body = match lines.iter().position(|&line| line == "[edoc]") {
+        Some(at) => &lines[at + 1..],
+        None => &lines[..],
+    };
+
+    let mut paragraphs = Vec::new();
+    let mut current: Vec<String> = Vec::new();
+    for &line in body {
+        if line == "[end]" {
+            break;
+        }
+        if line.trim().is_empty() {
+            if !current.is_empty() {
+                paragraphs.push(current.join(" "));
+                current.clear();
+            }
+            continue;
+        }
+        let mut line = line;
+        if current.is_empty()
+            && let Some(style) = line.strip_prefix('@')
+            && let Some(end) = style.find('@')
+        {
+            line = &style[end + 1..];
+        }
+        let text = ami_pro_inline(line);
+        let text = text.trim();
+        if !text.is_empty() {
+            current.push(text.to_string());
+        }
+    }
+    if !current.is_empty() {
+        paragraphs.push(current.join(" "));
+    }
+
+    WordProcessorContent {
+        text: paragraphs.join("\n"),
+        metadata: WordProcessorMetadata {
+            application: "Ami Pro".to_string(),
+            version,
+            encrypted: false,
+        },
+    }
+}
+
+/// Drop the formatting codes of an Ami Pro line.
+fn ami_pro_inline(line: &str) -> String {
+    let mut out = String::with_capacity(line.len());
+    let mut chars = line.chars().peekable();
+    while let Some(c) = chars.next() {
+        match c {
+            '<' | '>' if chars.peek() == Some(&c) => {
+                out.push(c);
+                chars.next();
+            }
+            '<' => {
+                if !chars.by_ref().any(|c| c == '>') {
+                    break;
+                }
+            }
+            _ => out.push(c),
+        }
+    }
+    out
+}
+
+/// Trim each line, collapse its whitespace, and collapse runs of blank lines.
+pub(crate) fn collapse_lines(text: &str) -> String {
+    let mut lines: Vec<String> = Vec::new();
+    let mut blank = false;
+    for line in text.split('\n') {
+        let line = line.split_whitespace().collect::<Vec<_>>().join(" ");
+        if line.is_empty() {
+            blank = !lines.is_empty();
+            continue;
+        }
+        if blank {
+            lines.push(String::new());
+            blank = false;
+        }
+        lines.push(line);
+    }
+    lines.join("\n")
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    /// Build a WordPerfect file of the given major version whose document area holds `body`.
+    fn word_perfect_file(major: u8, body: &[u8]) -> Vec<u8> {
+        let mut data = vec![0u8; 16];
+        data[..4].copy_from_slice(b"\xFFWPC");
+        data[4..8].copy_from_slice(&16u32.to_le_bytes());
+        data[8..12].copy_from_slice(&[1, 10, major, 1]);
+        data.extend_from_slice(body);
+        data
+    }
+
+    #[test]
+    fn test_word_perfect5() {
+        let body = [
+            &b"Dear"[..],
+            &[0xC3, 0x0C, 0xC3], // bold on
+            b" Sir",
+            &[0xC4, 0x0C, 0xC4], // bold off
+            &[0x0A, 0x0A],
+            b"Pay",
+            &[0x0D],
+            b"now",
+            &[0xC0, b'X', 0, 0xC0],
+            &[0xD0, 0x01, 0x04, 0x00, 0xAA, 0xBB, 0x01, 0xD0], // variable-length group
+            b"!",
+            &[0x0C],
+        ]
+        .concat();
+        let document = read_word_processor(&word_perfect_file(0, &body), WORDPERFECT_MIME_TYPE).unwrap();
+        assert_eq!(document.text, "Dear Sir\n\nPay nowX!");
+        assert_eq!(document.metadata.application, "WordPerfect");
+        assert_eq!(document.metadata.version.as_deref(), Some("5.1"));
+    }
+
+    #[test]
+    fn test_word_perfect6() {
+        let body = [
+            &b"Motion"[..],
+            &[0x80],
+            b"to",
+            &[0x81],
+            b"dismiss",
+            &[0xF2, 0x0C, 0xF2], // attribute on
+            &[0xD4, 0x05, 0x08, 0x00, 0xAA, 0xBB, 0x08, 0xD4],
+            &[0xCC],
+            b"Filed",
+            &[0xD0, 0x04, 0x04, 0x00], // hard end of line group
+            b"today",
+        ]
+        .concat();
+        let document = read_word_processor(&word_perfect_file(2, &body), WORDPERFECT_MIME_TYPE).unwrap();
+        assert_eq!(document.text, "Motion to dismiss\nFiled\ntoday");
+        assert_eq!(document.metadata.version.as_deref(), Some("6+"));
+    }
+
+    #[test]
+    fn test_word_perfect_encrypted() {
+        let mut data = word_perfect_file(2, b"scrambled");
+        data[12..14].copy_from_slice(&0x1234u16.to_le_bytes());
+        let document = read_word_processor(&data, WORDPERFECT_MIME_TYPE).unwrap();
+        assert!(document.text.is_empty());
+        assert!(document.metadata.encrypted);
+    }
+
+    #[test]
+    fn test_word_perfect_malformed() {
+        let mut data = word_perfect_file(0, b"");
+        data[4..8].copy_from_slice(&u32::MAX.to_le_bytes());
+        assert!(read_word_processor(&data, WORDPERFECT_MIME_TYPE).is_err());
+        assert!(read_word_processor(b"plain text", WORDPERFECT_MIME_TYPE).is_err());
+    }
+
+    #[test]
+    fn test_ami_pro() {
+        let data = b"[ver]\r\n\t4\r\n[sty]\r\n\tBody Text\r\n[edoc]\r\n@Title@The <+!>Lease<-!>\r\n\r\n\
+            Rent is due\r\non the 1st <<net>>.\r\n\r\n[end]\r\n";
+        let document = read_word_processor(data, AMIPRO_MIME_TYPE).unwrap();
+        assert_eq!(document.text, "The Lease\nRent is due on the 1st <net>.");
+        assert_eq!(document.metadata.application, "Ami Pro");
+        assert_eq!(document.metadata.version.as_deref(), Some("4"));
+    }
+
+    #[test]
+    fn test_word_pro() {
+        let data = b"WordPro\x00\x01\x02Quarterly report\x00\x07ab\x00Revenue grew";
+        let document = read_word_processor(data, WORDPRO_MIME_TYPE).unwrap();
+        assert_eq!(document.text, "WordPro\nQuarterly report\nRevenue grew");
+        assert_eq!(document.metadata.application, "Lotus Word Pro");
+    }
+}
@@ -0,0 +1,571 @@
+//! XML Paper Specification reader.
+//!
+//! XPS and OpenXPS documents are OPC packages: a fixed document sequence names the fixed
+//! documents, which name their fixed pages. The text of a page comes from its glyph runs,
+//! placed in reading order once the render transforms of their canvases are applied, and
+//! its images from the image brushes it paints with.
+
+use super::office_metadata::core_properties::parse_core_properties;
+use crate::core::mime::{OXPS_MIME_TYPE, XPS_MIME_TYPE};
+use crate::types::XpsMetadata;
+use crate::{KreuzbergError, Result};
+use roxmltree::{Document, Node};
+use std::collections::HashMap;
+use std::io::{Cursor, Read};
+use zip::ZipArchive;
+
+/// Largest size of a single package part once unpacked.
+const MAX_PART_SIZE: u64 = 256 * 1024 * 1024;
+
+/// XPS units, 1/96 inch, per point.
+const UNITS_PER_POINT: f64 = 96.0 / 72.0;
+
+/// Text, images and metadata read from an XPS document.
+#[derive(Debug, Clone, Default)]
+pub struct XpsContent {
+    pub pages: Vec<XpsPage>,
+    pub metadata: XpsMetadata,
+    pub subject: Option<String>,
+    pub language: Option<String>,
+}
+
+/// A fixed page of an XPS document.
+#[derive(Debug, Clone, Default)]
+pub struct XpsPage {
+    pub text: String,
+    /// Width and height in points
+    pub dimensions: Option<(f64, f64)>,
+    /// Number of images the page paints
+    pub image_count: usize,
+    /// The images the page paints, read only when requested
+    pub images: Vec<XpsImage>,
+}
+
+/// An image painted on a page, with its format taken from its part name.
+#[derive(Debug, Clone, Default)]
+pub struct XpsImage {
+    pub data: Vec<u8>,
+    pub format: String,
+}
+
+/// Read the fixed pages of an XPS or OpenXPS document in order, with the images they
+/// paint when `read_images` is set. Images that are missing from the package are skipped.
+///
+/// # Errors
+///
+/// Returns `KreuzbergError::Parsing` if `data` is not an XPS package or a part of its
+/// document structure is missing or malformed.
+pub fn read_xps(data: &[u8], read_images: bool) -> Result<XpsContent> {
+    let mut package = Package::open(data)?;
+    let (page_names, document_count) = package.page_names()?;
+    let properties = package
+        .core_properties
+        .clone()
+        .and_then(|name| package.read(&name).ok())
+        .and_then(|part| xml_text(&part).ok())
+        .and_then(|xml| parse_core_properties(&xml).ok())
+        .unwrap_or_default();
+
+    let mut pages = Vec::with_capacity(page_names.len());
+    for name in &page_names {
+        let xml = xml_text(&package.read(name)?)?;
+        let document = parse_part(&xml)?;
+        let root = document.root_element();
+        let layout = PageLayout::read(root, name);
+
+        let dimension = |name: &str| root.attribute(name).and_then(|value| value.trim().parse::<f64>().ok());
+        let dimensions = match (dimension("Width"), dimension("Height")) {
+            (Some(width), Some(height)) if width > 0.0 && height > 0.0 => {
+                Some((width / UNITS_PER_POINT, height / UNITS_PER_POINT))
+            }
+            _ => None,
+        };
+        let images = if read_images {
+            layout
+                .images
+                .iter()
+                .filter_map(|source| {
+                    Some(XpsImage {
+                        data: package.read(source).ok()?,
+                        format: part_extension(source),
+                    })
+                })
+                .collect()
+        } else {
+            Vec::new()
+        };
+
+        pages.push(XpsPage {
+            text: page_text(layout.runs),
+            dimensions,
+            image_count: layout.images.len(),
+            images,
+        });
+    }
+
+    Ok(XpsContent {
+        metadata: XpsMetadata {
+            title: properties.title,
+            creator: properties.creator,
+            keywords: properties.keywords,
+            description: properties.description,
+            last_modified_by: properties.last_modified_by,
+            revision: properties.revision,
+            created_at: properties.created,
+            modified_at: properties.modified,
+            page_count: pages.len(),
+            document_count,
+        },
+        pages,
+        subject: properties.subject,
+        language: properties.language,
+    })
+}
+
+/// Return the MIME type of an XPS or OpenXPS package, or `None` when `data` is not one.
+pub(crate) fn xps_mime_type(data: &[u8]) -> Option<&'static str> {
+    let package = Package::open(data).ok()?;
+    if package.fixed_representation.contains("openxps") {
+        Some(OXPS_MIME_TYPE)
+    } else {
+        Some(XPS_MIME_TYPE)
+    }
+}
+
+struct Package<'a> {
+    archive: ZipArchive<Cursor<&'a [u8]>>,
+    /// Index of each part by its normalized name.
+    parts: HashMap<String, usize>,
+    /// Relationship type of the document sequence, which tells XPS from OpenXPS.
+    fixed_representation: String,
+    sequence: String,
+    core_properties: Option<String>,
+}
+
+impl<'a> Package<'a> {
+    fn open(data: &'a [u8]) -> Result<Self> {
+        let mut archive = ZipArchive::new(Cursor::new(data))
+            .map_err(|e| KreuzbergError::parsing(format!("Failed to open XPS package: {}", e)))?;
+        let mut parts = HashMap::new();
+        for index in 0..archive.len() {
+            if let Ok(file) = archive.by_index_raw(index) {
+                parts.insert(part_key(file.name()), index);
+            }
+        }
+        let mut package = Self {
+            archive,
+            parts,
+            fixed_representation: String::new(),
+            sequence: String::new(),
+            core_properties: None,
+        };
+
+        for (kind, target) in package.relationships("")? {
+            if kind.ends_with("/fixedrepresentation") {
+                package.fixed_representation = kind;
+                package.sequence = target;
+            } else if kind.ends_with("/core-properties") {
+                package.core_properties = Some(target);
+            }
+        }
+        if package.sequence.is_empty() {
+            return Err(KreuzbergError::parsing("XPS package has no fixed document sequence"));
+        }
+        Ok(package)
+    }
+
+    /// Read the part called `name`, which is case-insensitive.
+    fn read(&mut self, name: &str) -> Result<Vec<u8>> {
+        let index = *self
+            .parts
+            .get(&part_key(name))
+            .ok_or_else(|| KreuzbergError::parsing(format!("XPS package is missing part {}", name)))?;
+        let file = self
+            .archive
+            .by_index(index)
+            .map_err(|e| KreuzbergError::parsing(format!("Failed to read XPS part {}: {}", name, e)))?;
+        let mut data = Vec::new();
+        file.take(MAX_PART_SIZE + 1)
+            .read_to_end(&mut data)
+            .map_err(|e| KreuzbergError::parsing(format!("Failed to unpack XPS part {}: {}", name, e)))?;
+        if data.len() as u64 > MAX_PART_SIZE {
+            return Err(KreuzbergError::parsing(format!(
+                "XPS part {} exceeds {} bytes",
+                name, MAX_PART_SIZE
+            )));
+        }
+        Ok(data)
+    }
+
+    /// Return the type and target part of each relationship of the part called `source`.
+    fn relationships(&mut self, source: &str) -> Result<Vec<(String, String)>> {
+        let part = source.trim_start_matches('/');
+        let rels = match part.rsplit_once('/') {
+            Some((dir, base)) => format!("{}/_rels/{}.rels", dir, base),
+            None => format!("_rels/{}.rels", part),
+        };
+        let xml = xml_text(&self.read(&rels)?)?;
+        Ok(parse_part(&xml)?
+            .descendants()
+            .filter(|node| node.has_tag_name("Relationship"))
+            .map(|node| {
+                let target = node.attribute("Target").unwrap_or_default();
+                (
+                    node.attribute("Type").unwrap_or_default().to_string(),
+                    resolve_part(source, target),
+                )
+            })
+            .collect())
+    }
+
+    /// Return the fixed pages of every document of the sequence, in order, and the number
+    /// of documents.
+    fn page_names(&mut self) -> Result<(Vec<String>, usize)> {
+        let sequence_name = self.sequence.clone();
+        let sequence = xml_text(&self.read(&sequence_name)?)?;
+        let documents: Vec<String> = parse_part(&sequence)?
+            .descendants()
+            .filter(|node| node.has_tag_name("DocumentReference"))
+            .filter_map(|node| node.attribute("Source"))
+            .map(|source| resolve_part(&sequence_name, source))
+            .collect();
+
+        let mut pages = Vec::new();
+        for document in &documents {
+            let xml = xml_text(&self.read(document)?)?;
+            pages.extend(
+                parse_part(&xml)?
+                    .descendants()
+                    .filter(|node| node.has_tag_name("PageContent"))
+                    .filter_map(|node| node.attribute("Source"))
+                    .map(|source| resolve_part(document, source)),
+            );
+        }
+        Ok((pages, documents.len()))
+    }
+}
+
+/// Normalize a part name for lookup: part names are case-insensitive and may be
+/// percent-encoded in the ZIP directory.
+fn part_key(name: &str) -> String {
+    let bytes = name.as_bytes();
+    let mut decoded = Vec::with_capacity(bytes.len());
+    let mut i = 0;
+    while i < bytes.len() {
+        if bytes[i] == b'%'
+            && let Some(byte) = name.get(i + 1..i + 3).and_then(|hex| u8::from_str_radix(hex, 16).ok())
+        {
+            decoded.push(byte);
+            i += 3;
+        } else {
+            decoded.push(bytes[i]);
+            i += 1;
+        }
+    }
+    String::from_utf8_lossy(&decoded).trim_start_matches('/').to_lowercase()
+}
+
+/// Resolve a reference made from the part called `from` to an absolute part name.
+fn resolve_part(from: &str, reference: &str) -> String {
+    let path = if reference.starts_with('/') {
+        reference.to_string()
+    } else {
+        let dir = from.rsplit_once('/').map_or("", |(dir, _)| dir);
+        format!("{}/{}", dir, reference)
+    };
+    let mut segments = Vec::new();
+    for segment in path.split('/') {
+        match segment {
+            "" | "." => {}
+            ".." => {
+                segments.pop();
+            }
+            segment => segments.push(segment),
+        }
+    }
+    format!("/{}", segments.join("/"))
+}
+
+/// Return the lowercase extension of a part name, used as the format of an image.
+fn part_extension(name: &str) -> String {
+    let file = name.rsplit('/').next().unwrap_or(name);
+    file.rsplit_once('.')
+        .map(|(_, extension)| extension.to_lowercase())
+        .unwrap_or_default()
+}
+
+/// Decode a UTF-8 or UTF-16 XML part.
+fn xml_text(data: &[u8]) -> Result<String> {
+    let utf16 = |rest: &[u8], decode: fn([u8; 2]) -> u16| -> String {
+        char::decode_utf16(rest.chunks_exact(2).map(|pair| decode([pair[0], pair[1]])))
+            .map(|c| c.unwrap_or(char::REPLACEMENT_CHARACTER))
+            .collect()
+    };
+    if let Some(rest) = data.strip_prefix(b"\xFF\xFE") {
+        return Ok(utf16(rest, u16::from_le_bytes));
+    }
+    if let Some(rest) = data.strip_prefix(b"\xFE\xFF") {
+        return Ok(utf16(rest, u16::from_be_bytes));
+    }
+    let data = data.strip_prefix(b"\xEF\xBB\xBF").unwrap_or(data);
+    String::from_utf8(data.to_vec()).map_err(|_| KreuzbergError::parsing("XPS part is neither UTF-8 nor UTF-16"))
+}
+
+fn parse_part(xml: &str) -> Result<Document<'_>> {
+    Document::parse(xml).map_err(|e| KreuzbergError::parsing(format!("Failed to parse XPS part: {}", e)))
+}
+
+/// An affine transform: x' = a*x + c*y + e, y' = b*x + d*y + f.
+type Matrix = [f64; 6];
+
+const IDENTITY: Matrix = [1.0, 0.0, 0.0, 1.0, 0.0, 0.0];
+
+/// Return the transform that applies `m` and then `outer`.
+fn compose(m: Matrix, outer: Matrix) -> Matrix {
+    [
+        m[0] * outer[0] + m[1] * outer[2],
+        m[0] * outer[1] + m[1] * outer[3],
+        m[2] * outer[0] + m[3] * outer[2],
+        m[2] * outer[1] + m[3] * outer[3],
+        m[4] * outer[0] + m[5] * outer[2] + outer[4],
+        m[4] * outer[1] + m[5] * outer[3] + outer[5],
+    ]
+}
+
+/// Parse `m11,m12,m21,m22,dx,dy`. Resource references are not resolved.
+fn parse_matrix(value: &str) -> Option<Matrix> {
+    let values: Vec<f64> = value
+        .split([',', ' '])
+        .filter(|field| !field.is_empty())
+        .map(|field| field.parse().ok())
+        .collect::<Option<_>>()?;
+    values.try_into().ok()
+}
+
+/// Return the transform an element sets on itself, by attribute or by a property element
+/// such as `<Canvas.RenderTransform>`.
+fn own_transform(node: Node<'_, '_>) -> Option<Matrix> {
+    if let Some(matrix) = node.attribute("RenderTransform").and_then(parse_matrix) {
+        return Some(matrix);
+    }
+    node.children()
+        .find(|child| child.tag_name().name().ends_with(".RenderTransform"))?
+        .children()
+        .find(|child| child.has_tag_name("MatrixTransform"))?
+        .attribute("Matrix")
+        .and_then(parse_matrix)
+}
+
+/// A glyph run placed on the page, in XPS units.
+struct GlyphRun {
+    x: f64,
+    y: f64,
+    size: f64,
+    text: String,
+}
+
+/// The glyph runs of a fixed page and the images it paints.
+struct PageLayout {
+    runs: Vec<GlyphRun>,
+    images: Vec<String>,
+}
+
+impl PageLayout {
+    fn read(root: Node<'_, '_>, name: &str) -> Self {
+        let mut layout = Self {
+            runs: Vec::new(),
+            images: Vec::new(),
+        };
+        let mut stack = vec![(root, IDENTITY)];
+        while let Some((node, parent)) = stack.pop() {
+            let transform = own_transform(node).map_or(parent, |matrix| compose(matrix, parent));
+            match node.tag_name().name() {
+                "Glyphs" => layout.runs.extend(glyph_run(node, transform)),
+                "ImageBrush" => {
+                    if let Some(source) = node.attribute("ImageSource")
+                        && !source.is_empty()
+                        && !source.starts_with('{')
+                    {
+                        let source = resolve_part(name, source);
+                        if !layout.images.contains(&source) {
+                            layout.images.push(source);
+                        }
+                    }
+                }
+                _ => {}
+            }
+            stack.extend(
+                node.children()
+                    .filter(|child| child.is_element())
+                    .rev()
+                    .map(|child| (child, transform)),
+            );
+        }
+        layout
+    }
+}
+
+fn glyph_run(node: Node<'_, '_>, transform: Matrix) -> Option<GlyphRun> {
+    let text = node.attribute("UnicodeString")?;
+    let text = text.strip_prefix("{}").unwrap_or(text).trim();
+    if text.is_empty() {
+        return None;
+    }
+    let number = |name: &str| {
+        node.attribute(name)
+            .and_then(|value| value.trim().parse::<f64>().ok())
+            .unwrap_or(0.0)
+    };
+    let (x, y) = (number("OriginX"), number("OriginY"));
+    let scale = (transform[0] * transform[3] - transform[1] * transform[2]).abs().sqrt();
+    let size = number("FontRenderingEmSize") * scale;
+    Some(GlyphRun {
+        x: transform[0] * x + transform[2] * y + transform[4],
+        y: transform[1] * x + transform[3] * y + transform[5],
+        size: if size > 0.0 { size } else { 12.0 },
+        text: text.to_string(),
+    })
+}
+
+/// Place glyph runs in reading order: runs whose baselines are within half a font size
+/// form a line, read left to right, and a gap of more than one and a half line heights
+/// starts a new paragraph.
+fn page_text(mut runs: Vec<GlyphRun>) -> String {
+    runs.sort_by(|a, b| a.y.total_cmp(&b.y));
+    let mut lines = Vec::new();
+    let mut line: Vec<GlyphRun> = Vec::new();
+    for run in runs {
+        if let Some(first) = line.first()
+            && (run.y - first.y).abs() >= first.size / 2.0
+        {
+            let paragraph = run.y - first.y > 1.5 * 1.2 * first.size;
+            lines.push(line_text(&mut line));
+            if paragraph {
+                lines.push(String::new());
+            }
+        }
+        line.push(run);
+    }
+    if !line.is_empty() {
+        lines.push(line_text(&mut line));
+    }
+    lines.join("\n")
+}
+
+fn line_text(line: &mut Vec<GlyphRun>) -> String {
+    line.sort_by(|a, b| a.x.total_cmp(&b.x));
+    line.drain(..).map(|run| run.text).collect::<Vec<_>>().join(" ")
+}
+
+#[cfg(test)]
+pub(crate) mod test_support {
+    use std::io::{Cursor, Write};
+
+    /// Zip `parts`, given as part names and contents, into a package.
+    pub(crate) fn package(parts: &[(&str, &[u8])]) -> Vec<u8> {
+        let mut zip = zip::ZipWriter::new(Cursor::new(Vec::new()));
+        let options = zip::write::FileOptions::<()>::default().compression_method(zip::CompressionMethod::Deflated);
+        for (name, content) in parts {
+            zip.start_file(*name, options).unwrap();
+            zip.write_all(content).unwrap();
+        }
+        zip.finish().unwrap().into_inner()
+    }
+
+    /// A two-page XPS document: text in three canvases on the first page, and a scanned
+    /// image on the second.
+    pub(crate) fn document() -> Vec<u8> {
+        package(&[
+            (
+                "_rels/.rels",
+                br#"<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Type="http://schemas.microsoft.com/xps/2005/06/fixedrepresentation" Target="/FixedDocumentSequence.fdseq" Id="R0"/>
+<Relationship Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml" Id="R1"/>
+</Relationships>"#,
+            ),
+            (
+                "docProps/core.xml",
+                br#"<coreProperties xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>Invoice 42</dc:title><dc:subject>Billing</dc:subject><dc:creator>Print Spooler</dc:creator></coreProperties>"#,
+            ),
+            (
+                "FixedDocumentSequence.fdseq",
+                br#"<FixedDocumentSequence><DocumentReference Source="Documents/1/FixedDocument.fdoc"/></FixedDocumentSequence>"#,
+            ),
+            (
+                "Documents/1/FixedDocument.fdoc",
+                br#"<FixedDocument><PageContent Source="Pages/1.fpage"/><PageContent Source="Pages/2.fpage"/></FixedDocument>"#,
+            ),
+            (
+                "Documents/1/Pages/1.fpage",
+                br#"<FixedPage Width="816" Height="1056">
+<Glyphs OriginX="400" OriginY="96" FontRenderingEmSize="16" UnicodeString="Total: 12.00"/>
+<Glyphs OriginX="96" OriginY="96" FontRenderingEmSize="16" UnicodeString="{}Invoice"/>
+<Canvas RenderTransform="1,0,0,1,0,100">
+  <Glyphs OriginX="96" OriginY="100" FontRenderingEmSize="12" UnicodeString="Thank you."/>
+</Canvas>
+<Canvas>
+  <Canvas.RenderTransform><MatrixTransform Matrix="2,0,0,2,0,0"/></Canvas.RenderTransform>
+  <Glyphs OriginX="48" OriginY="110" FontRenderingEmSize="6" UnicodeString="Come again"/>
+</Canvas>
+</FixedPage>"#,
+            ),
+            (
+                "Documents/1/Pages/2.fpage",
+                br#"<FixedPage Width="816" Height="1056">
+<Path Data="M 0,0 L 816,0 816,1056 0,1056 Z"><Path.Fill><ImageBrush ImageSource="../../../Resources/scan.png"/></Path.Fill></Path>
+</FixedPage>"#,
+            ),
+            ("Resources/scan.png", b"\x89PNG\r\n\x1a\nnot really"),
+        ])
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_read_xps() {
+        let data = test_support::document();
+        assert_eq!(xps_mime_type(&data), Some(XPS_MIME_TYPE));
+
+        let xps = read_xps(&data, true).expect("XPS document should be read");
+        assert_eq!(xps.pages.len(), 2);
+        assert_eq!(xps.pages[0].text, "Invoice Total: 12.00\n\nThank you.\nCome again");
+        assert_eq!(xps.pages[0].dimensions, Some((612.0, 792.0)));
+        assert_eq!(xps.pages[1].text, "");
+        assert_eq!(xps.pages[1].image_count, 1);
+        assert_eq!(xps.pages[1].images[0].format, "png");
+
+        assert_eq!(xps.metadata.title.as_deref(), Some("Invoice 42"));
+        assert_eq!(xps.metadata.creator.as_deref(), Some("Print Spooler"));
+        assert_eq!(xps.metadata.page_count, 2);
+        assert_eq!(xps.metadata.document_count, 1);
+        assert_eq!(xps.subject.as_deref(), Some("Billing"));
+    }
+
+    #[test]
+    fn test_read_xps_without_images() {
+        let xps = read_xps(&test_support::document(), false).expect("XPS document should be read");
+        assert_eq!(xps.pages[1].image_count, 1);
+        assert!(xps.pages[1].images.is_empty());
+    }
+
+    #[test]
+    fn test_read_xps_rejects_other_packages() {
+        let data = test_support::package(&[("word/document.xml", b"<document/>")]);
+        assert_eq!(xps_mime_type(&data), None);
+        assert!(read_xps(&data, false).is_err());
+    }
+
+    #[test]
+    fn test_resolve_part() {
+        assert_eq!(
+            resolve_part("/Documents/1/Pages/2.fpage", "../../../Resources/scan.png"),
+            "/Resources/scan.png"
+        );
+        assert_eq!(resolve_part("", "docProps/core.xml"), "/docProps/core.xml");
+        assert_eq!(part_key("/Resources/My%20Scan.PNG"), "resources/my scan.png");
+    }
+}
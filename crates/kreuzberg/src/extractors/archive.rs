@@ -1,10 +1,11 @@
-//! Archive extractors for ZIP, TAR, and 7z formats.
+//! Archive extractors for ZIP, TAR, 7z, and RAR formats.
 
 use crate::Result;
 use crate::core::config::ExtractionConfig;
 use crate::extraction::archive::{
-    ArchiveMetadata as ExtractedMetadata, extract_7z_metadata, extract_7z_text_content, extract_tar_metadata,
-    extract_tar_text_content, extract_zip_metadata, extract_zip_text_content,
+    ArchiveMetadata as ExtractedMetadata, ArchiveTextContent, extract_7z_metadata_with_passwords,
+    extract_7z_text_content_with_passwords, extract_rar_metadata, extract_rar_text_content, extract_tar_metadata,
+    extract_tar_text_content, extract_zip_metadata, extract_zip_text_content_with_passwords,
 };
 use crate::plugins::{DocumentExtractor, Plugin};
 use crate::types::{ArchiveMetadata, ExtractionResult, Metadata};
@@ -13,11 +14,11 @@ use std::collections::HashMap;
 
 /// Build an ExtractionResult from archive metadata and text contents.
 ///
-/// This helper function eliminates duplication across ZIP/TAR/7Z extractors by centralizing
+/// This helper function eliminates duplication across ZIP/TAR/7Z/RAR extractors by centralizing
 /// the logic for transforming extracted metadata into the final result structure.
 fn build_archive_result(
     extraction_metadata: ExtractedMetadata,
-    text_contents: HashMap<String, String>,
+    text_content: ArchiveTextContent,
     format_name: &str,
     mime_type: &str,
 ) -> ExtractionResult {
@@ -33,6 +34,7 @@ fn build_archive_result(
         file_list: file_names,
         total_size: extraction_metadata.total_size as usize,
         compressed_size: None,
+        entry_errors: text_content.entry_errors.clone(),
     };
 
     let mut additional = HashMap::new();
@@ -58,13 +60,20 @@ fn build_archive_result(
         output.push_str(&format!("- {} ({} bytes)\n", entry.path, entry.size));
     }
 
-    if !text_contents.is_empty() {
+    if !text_content.contents.is_empty() {
         output.push_str("\n\nText File Contents:\n\n");
-        for (path, content) in text_contents {
+        for (path, content) in text_content.contents {
             output.push_str(&format!("=== {} ===\n{}\n\n", path, content));
         }
     }
 
+    if !text_content.entry_errors.is_empty() {
+        output.push_str("\n\nUnreadable Files:\n");
+        for entry_error in &text_content.entry_errors {
+            output.push_str(&format!("- {}: {}\n", entry_error.path, entry_error.error));
+        }
+    }
+
     ExtractionResult {
         content: output,
         mime_type: mime_type.to_string(),
@@ -130,7 +139,7 @@ impl Plugin for ZipExtractor {
 #[async_trait]
 impl DocumentExtractor for ZipExtractor {
     #[cfg_attr(feature = "otel", tracing::instrument(
-        skip(self, content, _config),
+        skip(self, content, config),
         fields(
             extractor.name = self.name(),
             content.size_bytes = content.len(),
@@ -140,13 +149,14 @@ impl DocumentExtractor for ZipExtractor {
         &self,
         content: &[u8],
         mime_type: &str,
-        _config: &ExtractionConfig,
+        config: &ExtractionConfig,
     ) -> Result<ExtractionResult> {
+        let passwords = config.passwords.as_deref().unwrap_or_default();
         let extraction_metadata = extract_zip_metadata(content)?;
-        let text_contents = extract_zip_text_content(content)?;
+        let text_content = extract_zip_text_content_with_passwords(content, passwords)?;
         Ok(build_archive_result(
             extraction_metadata,
-            text_contents,
+            text_content,
             "ZIP",
             mime_type,
         ))
@@ -197,7 +207,7 @@ impl Plugin for TarExtractor {
     }
 
     fn description(&self) -> &str {
-        "Extracts file lists and text content from TAR archives"
+        "Extracts file lists and text content from TAR and TAR.ZST archives"
     }
 
     fn author(&self) -> &str {
@@ -221,10 +231,13 @@ impl DocumentExtractor for TarExtractor {
         _config: &ExtractionConfig,
     ) -> Result<ExtractionResult> {
         let extraction_metadata = extract_tar_metadata(content)?;
-        let text_contents = extract_tar_text_content(content)?;
+        let text_content = ArchiveTextContent {
+            contents: extract_tar_text_content(content)?,
+            ..Default::default()
+        };
         Ok(build_archive_result(
             extraction_metadata,
-            text_contents,
+            text_content,
             "TAR",
             mime_type,
         ))
@@ -236,6 +249,7 @@ impl DocumentExtractor for TarExtractor {
             "application/tar",
             "application/x-gtar",
             "application/x-ustar",
+            "application/zstd",
         ]
     }
 
@@ -291,7 +305,7 @@ impl Plugin for SevenZExtractor {
 #[async_trait]
 impl DocumentExtractor for SevenZExtractor {
     #[cfg_attr(feature = "otel", tracing::instrument(
-        skip(self, content, _config),
+        skip(self, content, config),
         fields(
             extractor.name = self.name(),
             content.size_bytes = content.len(),
@@ -301,13 +315,14 @@ impl DocumentExtractor for SevenZExtractor {
         &self,
         content: &[u8],
         mime_type: &str,
-        _config: &ExtractionConfig,
+        config: &ExtractionConfig,
     ) -> Result<ExtractionResult> {
-        let extraction_metadata = extract_7z_metadata(content)?;
-        let text_contents = extract_7z_text_content(content)?;
+        let passwords = config.passwords.as_deref().unwrap_or_default();
+        let extraction_metadata = extract_7z_metadata_with_passwords(content, passwords)?;
+        let text_content = extract_7z_text_content_with_passwords(content, passwords)?;
         Ok(build_archive_result(
             extraction_metadata,
-            text_contents,
+            text_content,
             "7Z",
             mime_type,
         ))
@@ -322,6 +337,84 @@ impl DocumentExtractor for SevenZExtractor {
     }
 }
 
+/// RAR archive extractor.
+///
+/// Lists the entries of RAR archives and extracts the text content of stored entries.
+pub struct RarExtractor;
+
+impl RarExtractor {
+    /// Create a new RAR extractor.
+    pub fn new() -> Self {
+        Self
+    }
+}
+
+impl Default for RarExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for RarExtractor {
+    fn name(&self) -> &str {
+        "rar-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts file lists and stored text content from RAR archives"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for RarExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, _config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        _config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        let extraction_metadata = extract_rar_metadata(content)?;
+        let text_content = extract_rar_text_content(content)?;
+        Ok(build_archive_result(
+            extraction_metadata,
+            text_content,
+            "RAR",
+            mime_type,
+        ))
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &["application/vnd.rar", "application/x-rar-compressed", "application/x-rar"]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+}
+
 #[cfg(test)]
 mod tests {
     use super::*;
@@ -404,6 +497,92 @@ mod tests {
         assert_eq!(archive_meta.file_count, 1);
     }
 
+    fn encrypted_zip() -> Vec<u8> {
+        let mut cursor = Cursor::new(Vec::new());
+        {
+            let mut zip = ZipWriter::new(&mut cursor);
+            let plain = FileOptions::<'_, ()>::default();
+            let encrypted = FileOptions::<'_, ()>::default().with_deprecated_encryption(b"s3cret");
+
+            zip.start_file("open.txt", plain).unwrap();
+            zip.write_all(b"Open content").unwrap();
+            zip.start_file("locked.txt", encrypted).unwrap();
+            zip.write_all(b"Locked content").unwrap();
+
+            zip.finish().unwrap();
+        }
+        cursor.into_inner()
+    }
+
+    #[tokio::test]
+    async fn test_zip_extractor_decrypts_with_passwords() {
+        let extractor = ZipExtractor::new();
+        let config = ExtractionConfig {
+            passwords: Some(vec!["wrong".to_string(), "s3cret".to_string()]),
+            ..Default::default()
+        };
+
+        let result = extractor
+            .extract_bytes(&encrypted_zip(), "application/zip", &config)
+            .await
+            .unwrap();
+
+        assert!(result.content.contains("Open content"));
+        assert!(result.content.contains("Locked content"));
+        let archive_meta = match result.metadata.format.as_ref().unwrap() {
+            crate::types::FormatMetadata::Archive(meta) => meta,
+            _ => panic!("Expected Archive metadata"),
+        };
+        assert_eq!(archive_meta.file_count, 2);
+        assert!(archive_meta.entry_errors.is_empty());
+    }
+
+    #[tokio::test]
+    async fn test_zip_extractor_reports_undecryptable_entries() {
+        let extractor = ZipExtractor::new();
+        let config = ExtractionConfig::default();
+
+        let result = extractor
+            .extract_bytes(&encrypted_zip(), "application/zip", &config)
+            .await
+            .unwrap();
+
+        assert!(result.content.contains("Open content"));
+        assert!(!result.content.contains("Locked content"));
+        let archive_meta = match result.metadata.format.as_ref().unwrap() {
+            crate::types::FormatMetadata::Archive(meta) => meta,
+            _ => panic!("Expected Archive metadata"),
+        };
+        assert_eq!(archive_meta.entry_errors.len(), 1);
+        assert_eq!(archive_meta.entry_errors[0].path, "locked.txt");
+    }
+
+    #[tokio::test]
+    async fn test_tar_extractor_zstd() {
+        let extractor = TarExtractor::new();
+
+        let mut tar_bytes = Vec::new();
+        {
+            let mut tar = TarBuilder::new(&mut tar_bytes);
+            let data = b"Compressed hello";
+            let mut header = tar::Header::new_gnu();
+            header.set_path("test.txt").unwrap();
+            header.set_size(data.len() as u64);
+            header.set_cksum();
+            tar.append(&header, &data[..]).unwrap();
+            tar.finish().unwrap();
+        }
+        let bytes = zstd::stream::encode_all(&tar_bytes[..], 3).unwrap();
+
+        let result = extractor
+            .extract_bytes(&bytes, "application/zstd", &ExtractionConfig::default())
+            .await
+            .unwrap();
+
+        assert!(result.content.contains("TAR Archive"));
+        assert!(result.content.contains("Compressed hello"));
+    }
+
     #[tokio::test]
     async fn test_zip_extractor_invalid() {
         let extractor = ZipExtractor::new();
@@ -446,4 +625,12 @@ mod tests {
         assert!(extractor.supported_mime_types().contains(&"application/tar"));
         assert_eq!(extractor.priority(), 50);
     }
+
+    #[test]
+    fn test_rar_plugin_interface() {
+        let extractor = RarExtractor::new();
+        assert_eq!(extractor.name(), "rar-extractor");
+        assert!(extractor.supported_mime_types().contains(&"application/vnd.rar"));
+        assert_eq!(extractor.priority(), 50);
+    }
 }
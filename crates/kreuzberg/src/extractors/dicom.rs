@@ -0,0 +1,172 @@
+//! DICOM extractor.
+
+use crate::Result;
+use crate::core::config::ExtractionConfig;
+use crate::core::mime::DICOM_MIME_TYPE;
+use crate::extraction::dicom::read_dicom;
+use crate::plugins::{DocumentExtractor, Plugin};
+use crate::types::{ExtractionResult, FormatMetadata, Metadata};
+use async_trait::async_trait;
+
+/// DICOM extractor.
+///
+/// Renders a DICOM object as its patient, study and series attributes and the text of a
+/// structured report, with `DicomMetadata`. With `DicomConfig::ocr_pixel_data` set, the text
+/// recognized in the first frame of its pixel data is appended; pixel data that cannot be
+/// decoded, such as RLE or JPEG-LS, is skipped.
+pub struct DicomExtractor;
+
+impl DicomExtractor {
+    /// Create a new DICOM extractor.
+    pub fn new() -> Self {
+        Self
+    }
+
+    /// Recognize the text of a frame of pixel data with the configured OCR backend.
+    #[cfg(feature = "ocr")]
+    async fn recognize_frame(&self, frame: &[u8], config: &ExtractionConfig) -> Result<String> {
+        use crate::plugins::registry::get_ocr_backend_registry;
+
+        let ocr_config = config.ocr.clone().unwrap_or_default();
+        let backend = {
+            let registry = get_ocr_backend_registry();
+            let registry = registry.read().map_err(|e| crate::KreuzbergError::Plugin {
+                message: format!("Failed to acquire read lock on OCR backend registry: {}", e),
+                plugin_name: "ocr-registry".to_string(),
+            })?;
+            registry.get(&ocr_config.backend)?
+        };
+        let recognized = backend.process_image(frame, &ocr_config).await?;
+        Ok(recognized.content)
+    }
+}
+
+impl Default for DicomExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for DicomExtractor {
+    fn name(&self) -> &str {
+        "dicom-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts patient, study and series attributes and structured reports from DICOM files"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for DicomExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        let dicom_config = config.dicom.clone().unwrap_or_default();
+        let dicom = read_dicom(content, &dicom_config)?;
+
+        #[allow(unused_mut)]
+        let mut text = dicom.text.clone();
+        #[cfg(feature = "ocr")]
+        if let Some((frame, _)) = &dicom.frame {
+            let recognized = dicom.mask_identifiers(self.recognize_frame(frame, config).await?.trim());
+            if !recognized.is_empty() {
+                text.push_str("\nPixel data text\n");
+                text.push_str(&recognized);
+            }
+        }
+
+        Ok(ExtractionResult {
+            content: text.trim().to_string(),
+            mime_type: mime_type.to_string(),
+            metadata: Metadata {
+                format: Some(FormatMetadata::Dicom(dicom.metadata)),
+                ..Default::default()
+            },
+            tables: vec![],
+            detected_languages: None,
+            chunks: None,
+            images: None,
+            pages: None,
+            djot_content: None,
+            elements: None,
+        })
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &[DICOM_MIME_TYPE]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn test_dicom_extractor_reports_metadata() {
+        let mut data = vec![0; 128];
+        data.extend_from_slice(b"DICM");
+        data.extend_from_slice(&[0x08, 0x00, 0x60, 0x00, b'C', b'S', 2, 0, b'C', b'T']);
+
+        let extractor = DicomExtractor::new();
+        let result = extractor
+            .extract_bytes(&data, DICOM_MIME_TYPE, &ExtractionConfig::default())
+            .await
+            .expect("DICOM file should be extracted");
+
+        assert_eq!(result.content, "Modality: CT");
+        match result.metadata.format {
+            Some(FormatMetadata::Dicom(meta)) => {
+                assert_eq!(meta.modality.as_deref(), Some("CT"));
+                assert!(!meta.has_pixel_data);
+            }
+            other => panic!("Expected DICOM metadata, got {:?}", other),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_dicom_extractor_rejects_other_files() {
+        let extractor = DicomExtractor::new();
+        let result = extractor
+            .extract_bytes(b"plain text", DICOM_MIME_TYPE, &ExtractionConfig::default())
+            .await;
+        assert!(result.is_err());
+    }
+
+    #[test]
+    fn test_dicom_plugin_interface() {
+        let extractor = DicomExtractor::new();
+        assert_eq!(extractor.name(), "dicom-extractor");
+        assert_eq!(extractor.supported_mime_types(), &[DICOM_MIME_TYPE]);
+    }
+}
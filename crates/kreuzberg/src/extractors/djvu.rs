@@ -0,0 +1,195 @@
+//! DjVu extractor.
+
+use crate::Result;
+use crate::core::config::ExtractionConfig;
+use crate::core::mime::DJVU_MIME_TYPE;
+use crate::extraction::djvu::read_djvu;
+use crate::extractors::SyncExtractor;
+use crate::plugins::{DocumentExtractor, Plugin};
+use crate::types::{ExtractionResult, FormatMetadata, Metadata, PageContent};
+use async_trait::async_trait;
+
+/// DjVu extractor.
+///
+/// Extracts the uncompressed text layers of single-page and bundled DjVu documents, one
+/// `PageContent` per page when page extraction is enabled, with `DjvuMetadata`. Page
+/// images are not recognized, and BZZ-compressed text layers are counted in
+/// `DjvuMetadata::compressed_text_pages` but not decoded.
+pub struct DjvuExtractor;
+
+impl DjvuExtractor {
+    /// Create a new DjVu extractor.
+    pub fn new() -> Self {
+        Self
+    }
+}
+
+impl Default for DjvuExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for DjvuExtractor {
+    fn name(&self) -> &str {
+        "djvu-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts the text layers of DjVu documents"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+impl SyncExtractor for DjvuExtractor {
+    fn extract_sync(&self, content: &[u8], mime_type: &str, config: &ExtractionConfig) -> Result<ExtractionResult> {
+        let djvu = read_djvu(content)?;
+
+        let text = djvu
+            .pages
+            .iter()
+            .filter(|page| !page.is_empty())
+            .map(String::as_str)
+            .collect::<Vec<_>>()
+            .join("\n\n");
+        let pages = config.pages.as_ref().is_some_and(|pages| pages.extract_pages).then(|| {
+            djvu.pages
+                .into_iter()
+                .enumerate()
+                .map(|(index, content)| PageContent {
+                    page_number: index + 1,
+                    content,
+                    tables: vec![],
+                    images: vec![],
+                    hierarchy: None,
+                })
+                .collect()
+        });
+
+        Ok(ExtractionResult {
+            content: text,
+            mime_type: mime_type.to_string(),
+            metadata: Metadata {
+                format: Some(FormatMetadata::Djvu(djvu.metadata)),
+                ..Default::default()
+            },
+            tables: vec![],
+            detected_languages: None,
+            chunks: None,
+            images: None,
+            pages,
+            djot_content: None,
+            elements: None,
+        })
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for DjvuExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        self.extract_sync(content, mime_type, config)
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &[DJVU_MIME_TYPE]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+
+    fn as_sync_extractor(&self) -> Option<&dyn crate::extractors::SyncExtractor> {
+        Some(self)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::core::config::PageConfig;
+
+    fn single_page(text: &str) -> Vec<u8> {
+        let mut layer = (text.len() as u32).to_be_bytes()[1..].to_vec();
+        layer.extend_from_slice(text.as_bytes());
+        let mut form = b"DJVUTXTa".to_vec();
+        form.extend_from_slice(&(layer.len() as u32).to_be_bytes());
+        form.extend_from_slice(&layer);
+        let mut data = b"AT&TFORM".to_vec();
+        data.extend_from_slice(&(form.len() as u32).to_be_bytes());
+        data.extend_from_slice(&form);
+        data
+    }
+
+    #[tokio::test]
+    async fn test_djvu_extractor_pages() {
+        let config = ExtractionConfig {
+            pages: Some(PageConfig {
+                extract_pages: true,
+                ..Default::default()
+            }),
+            ..Default::default()
+        };
+
+        let extractor = DjvuExtractor::new();
+        let result = extractor
+            .extract_bytes(&single_page("Hello  DjVu"), DJVU_MIME_TYPE, &config)
+            .await
+            .expect("DjVu document should be extracted");
+
+        assert_eq!(result.content, "Hello DjVu");
+        let pages = result.pages.expect("pages should be extracted");
+        assert_eq!(pages.len(), 1);
+        assert_eq!(pages[0].page_number, 1);
+        assert_eq!(pages[0].content, "Hello DjVu");
+        match result.metadata.format {
+            Some(FormatMetadata::Djvu(meta)) => {
+                assert_eq!(meta.page_count, 1);
+                assert_eq!(meta.text_pages, 1);
+            }
+            other => panic!("Expected DjVu metadata, got {:?}", other),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_djvu_extractor_rejects_other_files() {
+        let extractor = DjvuExtractor::new();
+        let result = extractor
+            .extract_bytes(b"plain text", DJVU_MIME_TYPE, &ExtractionConfig::default())
+            .await;
+        assert!(result.is_err());
+    }
+
+    #[test]
+    fn test_djvu_plugin_interface() {
+        let extractor = DjvuExtractor::new();
+        assert_eq!(extractor.name(), "djvu-extractor");
+        assert_eq!(extractor.supported_mime_types(), &[DJVU_MIME_TYPE]);
+    }
+}
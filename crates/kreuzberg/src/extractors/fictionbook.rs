@@ -9,14 +9,33 @@
 //! - Paragraphs and text content with inline formatting
 //! - Inline markup: emphasis, strong, strikethrough, subscript, superscript, code
 //! - Blockquotes and notes
+//! - Title, authors, series and publishing information as `FictionBookMetadata`
+//! - Embedded images, when image extraction is enabled
+//!
+//! Zipped books (`.fb2.zip`) are unpacked first, and books declared in Windows-1251 or
+//! Windows-1252 are transcoded to UTF-8.
 
-use crate::Result;
 use crate::core::config::ExtractionConfig;
+use crate::extraction::legacy_office::decode_cp1252;
 use crate::plugins::{DocumentExtractor, Plugin};
-use crate::types::{ExtractionResult, Metadata};
+use crate::types::{ExtractedImage, ExtractionResult, FictionBookMetadata, FormatMetadata, Metadata};
+use crate::{KreuzbergError, Result};
 use async_trait::async_trait;
+use base64::prelude::*;
 use quick_xml::Reader;
-use quick_xml::events::Event;
+use quick_xml::events::{BytesStart, Event};
+use std::borrow::Cow;
+use std::io::Read;
+
+/// Maximum size of a zipped book once unpacked.
+const MAX_UNPACKED_SIZE: u64 = 256 * 1024 * 1024;
+
+/// Characters of the bytes 0x80-0xBF of Windows-1251; 0xC0-0xFF are А-я.
+const CP1251_HIGH: [char; 64] = [
+    'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ', 'ђ', '‘', '’', '“', '”', '•', '–',
+    '—', '\u{98}', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ', '\u{A0}', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«',
+    '¬', '\u{AD}', '®', 'Ї', '°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+];
 
 /// FictionBook document extractor.
 ///
@@ -34,6 +53,58 @@ impl FictionBookExtractor {
         Self
     }
 
+    /// Unpack a zipped book and transcode a book declared in a single-byte encoding to UTF-8.
+    fn prepare_document(content: &[u8]) -> Result<Cow<'_, [u8]>> {
+        let document = if content.starts_with(b"PK\x03\x04") {
+            Cow::Owned(Self::unpack_book(content)?)
+        } else {
+            Cow::Borrowed(content)
+        };
+        Ok(match Self::declared_decoder(&document) {
+            Some(decode) => Cow::Owned(decode(&document).into_bytes()),
+            None => document,
+        })
+    }
+
+    /// Read the first `.fb2` file of a zipped book.
+    fn unpack_book(content: &[u8]) -> Result<Vec<u8>> {
+        let mut archive = zip::ZipArchive::new(std::io::Cursor::new(content))
+            .map_err(|e| KreuzbergError::parsing(format!("Failed to open FictionBook archive: {}", e)))?;
+        for index in 0..archive.len() {
+            let file = archive
+                .by_index(index)
+                .map_err(|e| KreuzbergError::parsing(format!("Failed to read FictionBook archive: {}", e)))?;
+            if !file.name().to_lowercase().ends_with(".fb2") {
+                continue;
+            }
+            let mut book = Vec::new();
+            file.take(MAX_UNPACKED_SIZE + 1)
+                .read_to_end(&mut book)
+                .map_err(|e| KreuzbergError::parsing(format!("Failed to unpack FictionBook archive: {}", e)))?;
+            if book.len() as u64 > MAX_UNPACKED_SIZE {
+                return Err(KreuzbergError::parsing(format!(
+                    "Unpacked FictionBook exceeds {} bytes",
+                    MAX_UNPACKED_SIZE
+                )));
+            }
+            return Ok(book);
+        }
+        Err(KreuzbergError::parsing("FictionBook archive contains no .fb2 file"))
+    }
+
+    /// Return the decoder of the single-byte encoding named by the XML declaration, if any.
+    fn declared_decoder(document: &[u8]) -> Option<fn(&[u8]) -> String> {
+        let declaration = document.strip_prefix(b"<?xml")?;
+        let end = memchr::memmem::find(declaration, b"?>")?;
+        let declaration = String::from_utf8_lossy(&declaration[..end]).to_ascii_lowercase();
+        let encoding = declaration.split("encoding=").nth(1)?.trim_start_matches(['"', '\'']);
+        match encoding.split(['"', '\'']).next()? {
+            "windows-1251" | "cp1251" => Some(decode_cp1251),
+            "windows-1252" | "cp1252" | "iso-8859-1" | "latin1" => Some(decode_cp1252),
+            _ => None,
+        }
+    }
+
     /// Extract paragraph content with markdown formatting preservation.
     /// Handles inline formatting tags like emphasis (*), strong (**), strikethrough (~~), etc.
     fn extract_paragraph_content(reader: &mut Reader<&[u8]>) -> Result<String> {
@@ -202,7 +273,9 @@ impl FictionBookExtractor {
     fn extract_metadata(data: &[u8]) -> Result<Metadata> {
         let mut reader = Reader::from_reader(data);
         let mut metadata = Metadata::default();
+        let mut book = FictionBookMetadata::default();
         let mut in_title_info = false;
+        let mut in_publish_info = false;
         let mut in_description = false;
 
         loop {
@@ -217,11 +290,15 @@ impl FictionBookExtractor {
                         "title-info" if in_description => {
                             in_title_info = true;
                         }
+                        "publish-info" if in_description => {
+                            in_publish_info = true;
+                        }
                         "genre" if in_title_info => {
                             if let Ok(Event::Text(t)) = reader.read_event() {
                                 let genre = String::from_utf8_lossy(t.as_ref()).to_string();
                                 if !genre.trim().is_empty() && genre.trim() != "unrecognised" {
                                     metadata.subject = Some(genre.trim().to_string());
+                                    book.genres.push(genre.trim().to_string());
                                 }
                             }
                         }
@@ -230,6 +307,7 @@ impl FictionBookExtractor {
                                 let date = String::from_utf8_lossy(t.as_ref()).to_string();
                                 if !date.trim().is_empty() {
                                     metadata.created_at = Some(date.trim().to_string());
+                                    book.date = Some(date.trim().to_string());
                                 }
                             }
                         }
@@ -241,13 +319,44 @@ impl FictionBookExtractor {
                                 }
                             }
                         }
+                        "book-title" if in_title_info => {
+                            book.title = Self::element_text(&mut reader);
+                        }
+                        "author" if in_title_info => {
+                            book.authors.extend(Self::extract_author_name(&mut reader));
+                        }
+                        "annotation" if in_title_info => {
+                            book.annotation = Self::element_text(&mut reader);
+                        }
+                        "keywords" if in_title_info => {
+                            book.keywords = Self::element_text(&mut reader);
+                        }
+                        "sequence" if in_title_info => {
+                            Self::read_sequence(&e, &mut book);
+                        }
+                        "publisher" if in_publish_info => {
+                            book.publisher = Self::element_text(&mut reader);
+                        }
+                        "year" if in_publish_info => {
+                            book.year = Self::element_text(&mut reader);
+                        }
+                        "isbn" if in_publish_info => {
+                            book.isbn = Self::element_text(&mut reader);
+                        }
                         _ => {}
                     }
                 }
+                Ok(Event::Empty(e)) => {
+                    if in_title_info && e.name().as_ref() == b"sequence" {
+                        Self::read_sequence(&e, &mut book);
+                    }
+                }
                 Ok(Event::End(e)) => {
                     let tag = String::from_utf8_lossy(e.name().as_ref()).to_string();
                     if tag == "title-info" {
                         in_title_info = false;
+                    } else if tag == "publish-info" {
+                        in_publish_info = false;
                     } else if tag == "description" {
                         in_description = false;
                     }
@@ -258,9 +367,105 @@ impl FictionBookExtractor {
             }
         }
 
+        metadata.format = Some(FormatMetadata::FictionBook(book));
         Ok(metadata)
     }
 
+    /// Read the text of the element just started, or `None` when it has none.
+    fn element_text(reader: &mut Reader<&[u8]>) -> Option<String> {
+        Self::extract_text_content(reader).ok().filter(|text| !text.is_empty())
+    }
+
+    /// Read the name of an author from its first, middle and last names, falling back to
+    /// its nickname.
+    fn extract_author_name(reader: &mut Reader<&[u8]>) -> Option<String> {
+        let mut names = Vec::new();
+        let mut nickname = None;
+
+        loop {
+            match reader.read_event() {
+                Ok(Event::Start(e)) => {
+                    let text = Self::element_text(reader);
+                    match e.name().as_ref() {
+                        b"first-name" | b"middle-name" | b"last-name" => names.extend(text),
+                        b"nickname" => nickname = text,
+                        _ => {}
+                    }
+                }
+                Ok(Event::End(_)) | Ok(Event::Eof) | Err(_) => break,
+                _ => {}
+            }
+        }
+
+        if names.is_empty() {
+            nickname
+        } else {
+            Some(names.join(" "))
+        }
+    }
+
+    /// Read the series a book belongs to and its position in it.
+    fn read_sequence(element: &BytesStart<'_>, book: &mut FictionBookMetadata) {
+        for attribute in element.attributes().flatten() {
+            let value = String::from_utf8_lossy(attribute.value.as_ref()).trim().to_string();
+            match attribute.key.as_ref() {
+                b"name" if !value.is_empty() => book.sequence = Some(value),
+                b"number" => book.sequence_number = value.parse().ok(),
+                _ => {}
+            }
+        }
+    }
+
+    /// Decode the images embedded in the `<binary>` elements of a FictionBook document.
+    fn extract_images(data: &[u8]) -> Vec<ExtractedImage> {
+        let mut reader = Reader::from_reader(data);
+        let mut images = Vec::new();
+
+        loop {
+            match reader.read_event() {
+                Ok(Event::Start(e)) if e.name().as_ref() == b"binary" => {
+                    let mut id = None;
+                    let mut content_type = String::new();
+                    for attribute in e.attributes().flatten() {
+                        let value = String::from_utf8_lossy(attribute.value.as_ref()).to_string();
+                        match attribute.key.as_ref() {
+                            b"id" => id = Some(value),
+                            b"content-type" => content_type = value,
+                            _ => {}
+                        }
+                    }
+                    let encoded: String = Self::extract_text_content(&mut reader)
+                        .unwrap_or_default()
+                        .split_whitespace()
+                        .collect();
+                    let Some(format) = content_type.strip_prefix("image/") else {
+                        continue;
+                    };
+                    let Ok(data) = BASE64_STANDARD.decode(encoded) else {
+                        continue;
+                    };
+                    images.push(ExtractedImage {
+                        data,
+                        format: format.to_string(),
+                        image_index: images.len(),
+                        page_number: None,
+                        width: None,
+                        height: None,
+                        colorspace: None,
+                        bits_per_component: None,
+                        is_mask: false,
+                        description: id,
+                        ocr_result: None,
+                    });
+                }
+                Ok(Event::Eof) | Err(_) => break,
+                _ => {}
+            }
+        }
+
+        images
+    }
+
     /// Extract content from FictionBook document body sections.
     fn extract_body_content(data: &[u8]) -> Result<String> {
         let mut reader = Reader::from_reader(data);
@@ -394,6 +599,17 @@ impl FictionBookExtractor {
     }
 }
 
+/// Decode Windows-1251 text.
+fn decode_cp1251(data: &[u8]) -> String {
+    data.iter()
+        .map(|&b| match b {
+            0xC0..=0xFF => char::from_u32(u32::from('А') + u32::from(b - 0xC0)).unwrap_or(char::REPLACEMENT_CHARACTER),
+            0x80..=0xBF => CP1251_HIGH[usize::from(b - 0x80)],
+            _ => char::from(b),
+        })
+        .collect()
+}
+
 impl Plugin for FictionBookExtractor {
     fn name(&self) -> &str {
         "fictionbook-extractor"
@@ -426,11 +642,19 @@ impl DocumentExtractor for FictionBookExtractor {
         &self,
         content: &[u8],
         mime_type: &str,
-        _config: &ExtractionConfig,
+        config: &ExtractionConfig,
     ) -> Result<ExtractionResult> {
-        let metadata = Self::extract_metadata(content)?;
+        let document = Self::prepare_document(content)?;
+
+        let metadata = Self::extract_metadata(&document)?;
+
+        let extracted_content = Self::extract_body_content(&document)?;
 
-        let extracted_content = Self::extract_body_content(content)?;
+        let images = config
+            .images
+            .as_ref()
+            .is_some_and(|images| images.extract_images)
+            .then(|| Self::extract_images(&document));
 
         Ok(ExtractionResult {
             content: extracted_content,
@@ -439,7 +663,7 @@ impl DocumentExtractor for FictionBookExtractor {
             tables: vec![],
             detected_languages: None,
             chunks: None,
-            images: None,
+            images,
             djot_content: None,
             pages: None,
             elements: None,
@@ -491,4 +715,89 @@ mod tests {
         assert!(extractor.initialize().is_ok());
         assert!(extractor.shutdown().is_ok());
     }
+
+    #[tokio::test]
+    async fn test_fictionbook_metadata_and_images() {
+        let book = br#"<?xml version="1.0" encoding="utf-8"?>
+<FictionBook xmlns="http://www.gribuser.ru/xml/fictionbook/2.0">
+<description>
+  <title-info>
+    <genre>sf</genre><genre>adventure</genre>
+    <author><first-name>Arkady</first-name><last-name>Strugatsky</last-name></author>
+    <author><nickname>B.S.</nickname></author>
+    <book-title>Roadside Picnic</book-title>
+    <annotation><p>A <emphasis>classic</emphasis> novel.</p><p>Second line.</p></annotation>
+    <lang>en</lang>
+    <sequence name="Noon Universe" number="3"/>
+  </title-info>
+  <publish-info><publisher>Macmillan</publisher><year>1977</year><isbn>0-02-615170-7</isbn></publish-info>
+</description>
+<body><section><p>Red Schuhart, stalker.</p></section></body>
+<binary id="cover.png" content-type="image/png">iVBORw0K
+GgoAAAAN</binary>
+</FictionBook>"#;
+        let config = ExtractionConfig {
+            images: Some(crate::core::config::ImageExtractionConfig {
+                extract_images: true,
+                target_dpi: 300,
+                max_image_dimension: 4096,
+                auto_adjust_dpi: true,
+                min_dpi: 72,
+                max_dpi: 600,
+            }),
+            ..Default::default()
+        };
+
+        let result = FictionBookExtractor::new()
+            .extract_bytes(book, "application/x-fictionbook+xml", &config)
+            .await
+            .expect("FictionBook should be extracted");
+
+        assert_eq!(result.metadata.language.as_deref(), Some("en"));
+        match &result.metadata.format {
+            Some(FormatMetadata::FictionBook(meta)) => {
+                assert_eq!(meta.title.as_deref(), Some("Roadside Picnic"));
+                assert_eq!(meta.authors, vec!["Arkady Strugatsky", "B.S."]);
+                assert_eq!(meta.genres, vec!["sf", "adventure"]);
+                assert_eq!(meta.annotation.as_deref(), Some("A classic novel.\nSecond line."));
+                assert_eq!(meta.sequence.as_deref(), Some("Noon Universe"));
+                assert_eq!(meta.sequence_number, Some(3));
+                assert_eq!(meta.publisher.as_deref(), Some("Macmillan"));
+                assert_eq!(meta.year.as_deref(), Some("1977"));
+                assert_eq!(meta.isbn.as_deref(), Some("0-02-615170-7"));
+            }
+            other => panic!("Expected FictionBook metadata, got {:?}", other),
+        }
+        let images = result.images.expect("images should be extracted");
+        assert_eq!(images.len(), 1);
+        assert_eq!(images[0].format, "png");
+        assert_eq!(images[0].description.as_deref(), Some("cover.png"));
+        assert!(images[0].data.starts_with(b"\x89PNG"));
+    }
+
+    #[tokio::test]
+    async fn test_fictionbook_zipped_windows_1251() {
+        use std::io::{Cursor, Write};
+
+        let book = b"<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n<FictionBook><description><title-info>\
+            <book-title>\xcf\xe8\xea\xed\xe8\xea</book-title></title-info></description>\
+            <body><section><p>\xd2\xe5\xea\xf1\xf2 \x97 \xb9 1</p></section></body></FictionBook>";
+        let mut zip = zip::ZipWriter::new(Cursor::new(Vec::new()));
+        let options = zip::write::FileOptions::<()>::default().compression_method(zip::CompressionMethod::Deflated);
+        zip.start_file("book.fb2", options).unwrap();
+        zip.write_all(book).unwrap();
+        let archive = zip.finish().unwrap().into_inner();
+
+        let result = FictionBookExtractor::new()
+            .extract_bytes(&archive, "application/x-fictionbook+xml", &ExtractionConfig::default())
+            .await
+            .expect("zipped FictionBook should be extracted");
+
+        assert_eq!(result.content, "Текст — № 1");
+        assert!(result.images.is_none());
+        match &result.metadata.format {
+            Some(FormatMetadata::FictionBook(meta)) => assert_eq!(meta.title.as_deref(), Some("Пикник")),
+            other => panic!("Expected FictionBook metadata, got {:?}", other),
+        }
+    }
 }
@@ -1,17 +1,22 @@
 //! Image extractors for various image formats.
 
-use crate::Result;
 use crate::core::config::ExtractionConfig;
 use crate::extraction::image::extract_image_metadata;
+use crate::extraction::pages::paginate;
+use crate::extraction::tiff::{SplitTiff, split_tiff};
 use crate::plugins::{DocumentExtractor, Plugin};
-use crate::types::{ExtractionResult, Metadata};
+use crate::types::{ExtractionResult, Metadata, PageContent, PageInfo};
+use crate::{KreuzbergError, Result};
 use async_trait::async_trait;
+use std::sync::Arc;
 
 /// Image extractor for various image formats.
 ///
 /// Supports: PNG, JPEG, WebP, BMP, TIFF, GIF.
 /// Extracts dimensions, format, and EXIF metadata.
 /// Optionally runs OCR when configured.
+/// Multi-page TIFFs are extracted page by page, with the encoding of faxes reported under
+/// the `fax` key of the additional metadata.
 pub struct ImageExtractor;
 
 impl ImageExtractor {
@@ -64,50 +69,9 @@ impl ImageExtractor {
 
         Ok(result)
     }
-}
-
-impl Default for ImageExtractor {
-    fn default() -> Self {
-        Self::new()
-    }
-}
-
-impl Plugin for ImageExtractor {
-    fn name(&self) -> &str {
-        "image-extractor"
-    }
-
-    fn version(&self) -> String {
-        env!("CARGO_PKG_VERSION").to_string()
-    }
-
-    fn initialize(&self) -> Result<()> {
-        Ok(())
-    }
 
-    fn shutdown(&self) -> Result<()> {
-        Ok(())
-    }
-
-    fn description(&self) -> &str {
-        "Extracts dimensions, format, and EXIF data from images (PNG, JPEG, WebP, BMP, TIFF, GIF)"
-    }
-
-    fn author(&self) -> &str {
-        "Kreuzberg Team"
-    }
-}
-
-#[async_trait]
-impl DocumentExtractor for ImageExtractor {
-    #[cfg_attr(feature = "otel", tracing::instrument(
-        skip(self, content, config),
-        fields(
-            extractor.name = self.name(),
-            content.size_bytes = content.len(),
-        )
-    ))]
-    async fn extract_bytes(
+    /// Extract a single image.
+    async fn extract_image(
         &self,
         content: &[u8],
         mime_type: &str,
@@ -176,6 +140,135 @@ impl DocumentExtractor for ImageExtractor {
         })
     }
 
+    /// Extract a multi-page TIFF page by page, so that each page is recognized on its own
+    /// and gets its own text, boundary and `PageContent`.
+    async fn extract_tiff_pages(
+        &self,
+        split: SplitTiff,
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        let page_config = ExtractionConfig {
+            pages: None,
+            ..config.clone()
+        };
+
+        let mut first = None;
+        let mut pages = Vec::with_capacity(split.pages.len());
+        let mut infos = Vec::with_capacity(split.pages.len());
+        let mut tables = Vec::new();
+        let mut images = Vec::new();
+        for (index, page) in split.pages.into_iter().enumerate() {
+            let page_number = index + 1;
+            let mut extracted = self
+                .extract_image(&page.data, mime_type, &page_config)
+                .await
+                .map_err(|e| KreuzbergError::parsing(format!("Failed to extract TIFF page {}: {}", page_number, e)))?;
+
+            let mut page_content = PageContent {
+                page_number,
+                content: extracted.content.trim().to_string(),
+                tables: vec![],
+                images: vec![],
+                hierarchy: None,
+            };
+            for mut table in std::mem::take(&mut extracted.tables) {
+                table.page_number = page_number;
+                page_content.tables.push(Arc::new(table.clone()));
+                tables.push(table);
+            }
+            for mut image in extracted.images.take().unwrap_or_default() {
+                image.page_number = Some(page_number);
+                image.image_index = images.len();
+                page_content.images.push(Arc::new(image.clone()));
+                images.push(image);
+            }
+            pages.push(page_content);
+            infos.push(PageInfo {
+                number: page_number,
+                title: None,
+                dimensions: Some(page.dimensions),
+                image_count: Some(1),
+                table_count: None,
+                hidden: None,
+            });
+            first.get_or_insert(extracted);
+        }
+        let Some(mut result) = first else {
+            return Err(KreuzbergError::parsing("TIFF has no pages"));
+        };
+
+        let paginated = paginate(pages, infos, config.pages.as_ref());
+        result.content = paginated.content;
+        result.metadata.pages = Some(paginated.structure);
+        result.pages = paginated.pages;
+        result.tables = tables;
+        result.images = (!images.is_empty()).then_some(images);
+        if let Some(fax) = split.fax {
+            result
+                .metadata
+                .additional
+                .insert("fax".to_string(), serde_json::json!(fax));
+        }
+        Ok(result)
+    }
+}
+
+impl Default for ImageExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for ImageExtractor {
+    fn name(&self) -> &str {
+        "image-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts dimensions, format, and EXIF data from images (PNG, JPEG, WebP, BMP, TIFF, GIF)"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for ImageExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        if mime_type == "image/tiff"
+            && let Ok(Some(split)) = split_tiff(content)
+        {
+            return self.extract_tiff_pages(split, mime_type, config).await;
+        }
+        self.extract_image(content, mime_type, config).await
+    }
+
     fn supported_mime_types(&self) -> &[&str] {
         &[
             "image/png",
@@ -207,6 +300,38 @@ mod tests {
         assert!(result.is_err());
     }
 
+    #[tokio::test]
+    async fn test_image_extractor_tiff_pages() {
+        use crate::core::config::PageConfig;
+        use crate::extraction::tiff::test_support::{grayscale_page, tiff};
+
+        let (first, first_strip) = grayscale_page(4, 2);
+        let (second, second_strip) = grayscale_page(6, 3);
+        let data = tiff(&[first, second], &[&first_strip, &second_strip]);
+        let config = ExtractionConfig {
+            pages: Some(PageConfig {
+                extract_pages: true,
+                ..Default::default()
+            }),
+            ..Default::default()
+        };
+
+        let extractor = ImageExtractor::new();
+        let result = extractor
+            .extract_bytes(&data, "image/tiff", &config)
+            .await
+            .expect("multi-page TIFF should be extracted");
+
+        assert_eq!(result.content, "Image: TIFF 4x2\n\nImage: TIFF 6x3");
+        let pages = result.pages.expect("pages should be extracted");
+        assert_eq!(pages.len(), 2);
+        assert_eq!(pages[1].content, "Image: TIFF 6x3");
+        let structure = result.metadata.pages.expect("page structure should be set");
+        assert_eq!(structure.total_count, 2);
+        assert_eq!(structure.pages.unwrap()[1].dimensions, Some((6.0, 3.0)));
+        assert!(!result.metadata.additional.contains_key("fax"));
+    }
+
     #[test]
     fn test_image_plugin_interface() {
         let extractor = ImageExtractor::new();
@@ -0,0 +1,170 @@
+//! Native legacy Word and PowerPoint extractor.
+//!
+//! Binary `.doc` and `.ppt` files are converted through LibreOffice when it is installed.
+//! This extractor is the fallback used without it: it reads their text and summary
+//! information straight from the compound file.
+
+use crate::Result;
+use crate::core::config::ExtractionConfig;
+use crate::core::mime::{LEGACY_POWERPOINT_MIME_TYPE, LEGACY_WORD_MIME_TYPE};
+use crate::extraction::legacy_office::{read_legacy_powerpoint, read_legacy_word};
+use crate::extractors::SyncExtractor;
+use crate::plugins::{DocumentExtractor, Plugin};
+use crate::types::{ExtractionResult, FormatMetadata, Metadata};
+use async_trait::async_trait;
+
+/// Legacy Word and PowerPoint extractor.
+///
+/// Extracts the text of Word 97-2003 documents through their piece table and the slide
+/// text of PowerPoint 97-2003 presentations, with their summary information as
+/// `LegacyOfficeMetadata`. Formatting, tables and embedded objects are not recovered.
+pub struct LegacyOfficeExtractor;
+
+impl LegacyOfficeExtractor {
+    /// Create a new legacy Office extractor.
+    pub fn new() -> Self {
+        Self
+    }
+}
+
+impl Default for LegacyOfficeExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for LegacyOfficeExtractor {
+    fn name(&self) -> &str {
+        "legacy-office-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts text and summary information from binary Word and PowerPoint files without LibreOffice"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+impl SyncExtractor for LegacyOfficeExtractor {
+    fn extract_sync(&self, content: &[u8], mime_type: &str, _config: &ExtractionConfig) -> Result<ExtractionResult> {
+        let document = if mime_type == LEGACY_POWERPOINT_MIME_TYPE {
+            read_legacy_powerpoint(content)?
+        } else {
+            read_legacy_word(content)?
+        };
+
+        Ok(ExtractionResult {
+            content: document.text,
+            mime_type: mime_type.to_string(),
+            metadata: Metadata {
+                format: Some(FormatMetadata::LegacyOffice(document.metadata)),
+                ..Default::default()
+            },
+            tables: vec![],
+            detected_languages: None,
+            chunks: None,
+            images: None,
+            pages: None,
+            djot_content: None,
+            elements: None,
+        })
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for LegacyOfficeExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        self.extract_sync(content, mime_type, config)
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &[LEGACY_WORD_MIME_TYPE, LEGACY_POWERPOINT_MIME_TYPE]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+
+    fn as_sync_extractor(&self) -> Option<&dyn crate::extractors::SyncExtractor> {
+        Some(self)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::extraction::cfb::test_support::compound_file;
+
+    #[tokio::test]
+    async fn test_legacy_office_extractor_reports_metadata() {
+        fn record(ver_instance: u16, kind: u16, body: &[u8]) -> Vec<u8> {
+            [
+                &ver_instance.to_le_bytes()[..],
+                &kind.to_le_bytes(),
+                &(body.len() as u32).to_le_bytes(),
+                body,
+            ]
+            .concat()
+        }
+        let slide = record(0x000F, 0x03EE, &record(0, 0x0FA8, b"Hello"));
+        let data = compound_file(&[("PowerPoint Document", slide.as_slice())]);
+
+        let extractor = LegacyOfficeExtractor::new();
+        let result = extractor
+            .extract_bytes(&data, LEGACY_POWERPOINT_MIME_TYPE, &ExtractionConfig::default())
+            .await
+            .expect("legacy PowerPoint file should be extracted");
+
+        assert_eq!(result.content, "Hello");
+        assert_eq!(result.mime_type, LEGACY_POWERPOINT_MIME_TYPE);
+        match result.metadata.format {
+            Some(FormatMetadata::LegacyOffice(meta)) => assert_eq!(meta.slide_count, Some(1)),
+            other => panic!("Expected legacy Office metadata, got {:?}", other),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_legacy_office_extractor_rejects_other_files() {
+        let extractor = LegacyOfficeExtractor::new();
+        let result = extractor
+            .extract_bytes(b"plain text", LEGACY_WORD_MIME_TYPE, &ExtractionConfig::default())
+            .await;
+        assert!(result.is_err());
+    }
+
+    #[test]
+    fn test_legacy_office_plugin_interface() {
+        let extractor = LegacyOfficeExtractor::new();
+        assert_eq!(extractor.name(), "legacy-office-extractor");
+        assert_eq!(
+            extractor.supported_mime_types(),
+            &[LEGACY_WORD_MIME_TYPE, LEGACY_POWERPOINT_MIME_TYPE]
+        );
+    }
+}
@@ -76,6 +76,9 @@ pub mod image;
 #[cfg(feature = "archives")]
 pub mod archive;
 
+#[cfg(feature = "dicom")]
+pub mod dicom;
+
 #[cfg(feature = "email")]
 pub mod email;
 
@@ -85,9 +88,15 @@ pub mod excel;
 #[cfg(feature = "html")]
 pub mod html;
 
+#[cfg(feature = "html")]
+pub mod web_archive;
+
 #[cfg(feature = "office")]
 pub mod bibtex;
 
+#[cfg(feature = "office")]
+pub mod djvu;
+
 #[cfg(all(feature = "tokio-runtime", feature = "office"))]
 pub mod docx;
 
@@ -97,6 +106,9 @@ pub mod epub;
 #[cfg(feature = "office")]
 pub mod fictionbook;
 
+#[cfg(feature = "office")]
+pub mod legacy_office;
+
 #[cfg(feature = "office")]
 pub mod markdown;
 
@@ -121,6 +133,12 @@ pub mod opml;
 #[cfg(feature = "office")]
 pub mod typst;
 
+#[cfg(feature = "office")]
+pub mod word_processor;
+
+#[cfg(feature = "office")]
+pub mod xps;
+
 #[cfg(feature = "xml")]
 pub mod jats;
 
@@ -146,7 +164,10 @@ pub use text::{MarkdownExtractor, PlainTextExtractor};
 pub use image::ImageExtractor;
 
 #[cfg(feature = "archives")]
-pub use archive::{SevenZExtractor, TarExtractor, ZipExtractor};
+pub use archive::{RarExtractor, SevenZExtractor, TarExtractor, ZipExtractor};
+
+#[cfg(feature = "dicom")]
+pub use dicom::DicomExtractor;
 
 #[cfg(feature = "email")]
 pub use email::EmailExtractor;
@@ -157,9 +178,15 @@ pub use excel::ExcelExtractor;
 #[cfg(feature = "html")]
 pub use html::HtmlExtractor;
 
+#[cfg(feature = "html")]
+pub use web_archive::WebArchiveExtractor;
+
 #[cfg(feature = "office")]
 pub use bibtex::BibtexExtractor;
 
+#[cfg(feature = "office")]
+pub use djvu::DjvuExtractor;
+
 #[cfg(all(feature = "tokio-runtime", feature = "office"))]
 pub use docx::DocxExtractor;
 
@@ -171,6 +198,9 @@ pub use fictionbook::FictionBookExtractor;
 
 pub use djot_format::DjotExtractor;
 
+#[cfg(feature = "office")]
+pub use legacy_office::LegacyOfficeExtractor;
+
 #[cfg(feature = "office")]
 pub use markdown::MarkdownExtractor as EnhancedMarkdownExtractor;
 
@@ -198,6 +228,12 @@ pub use opml::OpmlExtractor;
 #[cfg(feature = "office")]
 pub use typst::TypstExtractor;
 
+#[cfg(feature = "office")]
+pub use word_processor::WordProcessorExtractor;
+
+#[cfg(feature = "office")]
+pub use xps::XpsExtractor;
+
 #[cfg(feature = "pdf")]
 pub use pdf::PdfExtractor;
 
@@ -301,6 +337,10 @@ pub fn register_default_extractors() -> Result<()> {
         registry.register(Arc::new(OrgModeExtractor::new()))?;
         registry.register(Arc::new(OpmlExtractor::new()))?;
         registry.register(Arc::new(TypstExtractor::new()))?;
+        registry.register(Arc::new(LegacyOfficeExtractor::new()))?;
+        registry.register(Arc::new(WordProcessorExtractor::new()))?;
+        registry.register(Arc::new(DjvuExtractor::new()))?;
+        registry.register(Arc::new(XpsExtractor::new()))?;
     }
 
     #[cfg(all(feature = "tokio-runtime", feature = "office"))]
@@ -313,14 +353,21 @@ pub fn register_default_extractors() -> Result<()> {
     #[cfg(feature = "email")]
     registry.register(Arc::new(EmailExtractor::new()))?;
 
+    #[cfg(feature = "dicom")]
+    registry.register(Arc::new(DicomExtractor::new()))?;
+
     #[cfg(feature = "html")]
-    registry.register(Arc::new(HtmlExtractor::new()))?;
+    {
+        registry.register(Arc::new(HtmlExtractor::new()))?;
+        registry.register(Arc::new(WebArchiveExtractor::new()))?;
+    }
 
     #[cfg(feature = "archives")]
     {
         registry.register(Arc::new(ZipExtractor::new()))?;
         registry.register(Arc::new(TarExtractor::new()))?;
         registry.register(Arc::new(SevenZExtractor::new()))?;
+        registry.register(Arc::new(RarExtractor::new()))?;
     }
 
     Ok(())
@@ -380,7 +427,7 @@ mod tests {
 
         #[cfg(feature = "office")]
         {
-            expected_count += 10;
+            expected_count += 14;
             assert!(extractor_names.contains(&"markdown-extractor".to_string()));
             assert!(extractor_names.contains(&"bibtex-extractor".to_string()));
             assert!(extractor_names.contains(&"epub-extractor".to_string()));
@@ -392,6 +439,10 @@ mod tests {
             assert!(extractor_names.contains(&"orgmode-extractor".to_string()));
             assert!(extractor_names.contains(&"opml-extractor".to_string()));
             assert!(extractor_names.contains(&"typst-extractor".to_string()));
+            assert!(extractor_names.contains(&"legacy-office-extractor".to_string()));
+            assert!(extractor_names.contains(&"word-processor-extractor".to_string()));
+            assert!(extractor_names.contains(&"djvu-extractor".to_string()));
+            assert!(extractor_names.contains(&"xps-extractor".to_string()));
         }
 
         #[cfg(all(feature = "tokio-runtime", feature = "office"))]
@@ -408,10 +459,17 @@ mod tests {
             assert!(extractor_names.contains(&"email-extractor".to_string()));
         }
 
-        #[cfg(feature = "html")]
+        #[cfg(feature = "dicom")]
         {
             expected_count += 1;
+            assert!(extractor_names.contains(&"dicom-extractor".to_string()));
+        }
+
+        #[cfg(feature = "html")]
+        {
+            expected_count += 2;
             assert!(extractor_names.contains(&"html-extractor".to_string()));
+            assert!(extractor_names.contains(&"webarchive-extractor".to_string()));
         }
 
         #[cfg(feature = "archives")]
@@ -0,0 +1,129 @@
+//! Safari webarchive extractor.
+
+use crate::Result;
+use crate::core::config::ExtractionConfig;
+use crate::core::mime::WEBARCHIVE_MIME_TYPE;
+use crate::extraction::web_archive::webarchive_to_html;
+use crate::extractors::{HtmlExtractor, SyncExtractor};
+use crate::plugins::{DocumentExtractor, Plugin};
+use crate::types::ExtractionResult;
+use async_trait::async_trait;
+
+/// Safari webarchive extractor.
+///
+/// Rebuilds the saved page with its resources inlined as data URIs and extracts it with
+/// the HTML extractor, so results carry `HtmlMetadata` like any other web page.
+pub struct WebArchiveExtractor;
+
+impl WebArchiveExtractor {
+    /// Create a new webarchive extractor.
+    pub fn new() -> Self {
+        Self
+    }
+}
+
+impl Default for WebArchiveExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for WebArchiveExtractor {
+    fn name(&self) -> &str {
+        "webarchive-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts saved Safari web pages through the HTML extractor"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+impl SyncExtractor for WebArchiveExtractor {
+    fn extract_sync(&self, content: &[u8], mime_type: &str, config: &ExtractionConfig) -> Result<ExtractionResult> {
+        let html = webarchive_to_html(content)?;
+        HtmlExtractor::new().extract_sync(&html, mime_type, config)
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for WebArchiveExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        self.extract_sync(content, mime_type, config)
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &[WEBARCHIVE_MIME_TYPE]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+
+    fn as_sync_extractor(&self) -> Option<&dyn crate::extractors::SyncExtractor> {
+        Some(self)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::extraction::plist::test_support::{Object, binary_plist};
+    use crate::types::FormatMetadata;
+
+    #[tokio::test]
+    async fn test_webarchive_extractor_extracts_main_resource() {
+        let page = b"<html><head><title>Saved</title></head><body><p>Hello from Safari</p></body></html>";
+        let data = binary_plist(&Object::Dictionary(vec![(
+            "WebMainResource",
+            Object::Dictionary(vec![
+                ("WebResourceURL", Object::String("https://example.com/")),
+                ("WebResourceMIMEType", Object::String("text/html")),
+                ("WebResourceData", Object::Data(page)),
+            ]),
+        )]));
+
+        let extractor = WebArchiveExtractor::new();
+        let result = extractor
+            .extract_bytes(&data, WEBARCHIVE_MIME_TYPE, &ExtractionConfig::default())
+            .await
+            .expect("webarchive should be extracted");
+
+        assert!(result.content.contains("Hello from Safari"));
+        assert!(matches!(result.metadata.format, Some(FormatMetadata::Html(_))));
+    }
+
+    #[test]
+    fn test_webarchive_plugin_interface() {
+        let extractor = WebArchiveExtractor::new();
+        assert_eq!(extractor.name(), "webarchive-extractor");
+        assert_eq!(extractor.supported_mime_types(), &[WEBARCHIVE_MIME_TYPE]);
+    }
+}
@@ -0,0 +1,154 @@
+//! Legacy word processor extractor.
+
+use crate::Result;
+use crate::core::config::ExtractionConfig;
+use crate::core::mime::{AMIPRO_MIME_TYPE, WORDPERFECT_MIME_TYPE, WORDPRO_MIME_TYPE};
+use crate::extraction::word_processor::read_word_processor;
+use crate::extractors::SyncExtractor;
+use crate::plugins::{DocumentExtractor, Plugin};
+use crate::types::{ExtractionResult, FormatMetadata, Metadata};
+use async_trait::async_trait;
+
+/// WordPerfect, Lotus Word Pro and Ami Pro extractor.
+///
+/// Extracts the text of WordPerfect 5.x and 6+, Lotus Word Pro and Ami Pro documents, with
+/// the application and format version as `WordProcessorMetadata`. Formatting is dropped,
+/// and password-protected WordPerfect documents are reported without text.
+pub struct WordProcessorExtractor;
+
+impl WordProcessorExtractor {
+    /// Create a new word processor extractor.
+    pub fn new() -> Self {
+        Self
+    }
+}
+
+impl Default for WordProcessorExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for WordProcessorExtractor {
+    fn name(&self) -> &str {
+        "word-processor-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts text from WordPerfect, Lotus Word Pro and Ami Pro documents"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+impl SyncExtractor for WordProcessorExtractor {
+    fn extract_sync(&self, content: &[u8], mime_type: &str, _config: &ExtractionConfig) -> Result<ExtractionResult> {
+        let document = read_word_processor(content, mime_type)?;
+
+        Ok(ExtractionResult {
+            content: document.text,
+            mime_type: mime_type.to_string(),
+            metadata: Metadata {
+                format: Some(FormatMetadata::WordProcessor(document.metadata)),
+                ..Default::default()
+            },
+            tables: vec![],
+            detected_languages: None,
+            chunks: None,
+            images: None,
+            pages: None,
+            djot_content: None,
+            elements: None,
+        })
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for WordProcessorExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        self.extract_sync(content, mime_type, config)
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &[WORDPERFECT_MIME_TYPE, WORDPRO_MIME_TYPE, AMIPRO_MIME_TYPE]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+
+    fn as_sync_extractor(&self) -> Option<&dyn crate::extractors::SyncExtractor> {
+        Some(self)
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[tokio::test]
+    async fn test_word_processor_extractor_reports_metadata() {
+        let data = b"[ver]\r\n\t4\r\n[edoc]\r\nHello from Ami Pro\r\n[end]\r\n";
+
+        let extractor = WordProcessorExtractor::new();
+        let result = extractor
+            .extract_bytes(data, AMIPRO_MIME_TYPE, &ExtractionConfig::default())
+            .await
+            .expect("Ami Pro document should be extracted");
+
+        assert_eq!(result.content, "Hello from Ami Pro");
+        assert_eq!(result.mime_type, AMIPRO_MIME_TYPE);
+        match result.metadata.format {
+            Some(FormatMetadata::WordProcessor(meta)) => {
+                assert_eq!(meta.application, "Ami Pro");
+                assert_eq!(meta.version.as_deref(), Some("4"));
+            }
+            other => panic!("Expected word processor metadata, got {:?}", other),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_word_processor_extractor_rejects_other_files() {
+        let extractor = WordProcessorExtractor::new();
+        let result = extractor
+            .extract_bytes(b"plain text", WORDPERFECT_MIME_TYPE, &ExtractionConfig::default())
+            .await;
+        assert!(result.is_err());
+    }
+
+    #[test]
+    fn test_word_processor_plugin_interface() {
+        let extractor = WordProcessorExtractor::new();
+        assert_eq!(extractor.name(), "word-processor-extractor");
+        assert_eq!(
+            extractor.supported_mime_types(),
+            &[WORDPERFECT_MIME_TYPE, WORDPRO_MIME_TYPE, AMIPRO_MIME_TYPE]
+        );
+    }
+}
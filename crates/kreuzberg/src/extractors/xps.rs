@@ -0,0 +1,266 @@
+//! XPS extractor.
+
+use crate::Result;
+use crate::core::config::ExtractionConfig;
+use crate::core::mime::{OXPS_MIME_TYPE, XPS_MIME_TYPE};
+use crate::extraction::pages::paginate;
+use crate::extraction::xps::read_xps;
+use crate::plugins::{DocumentExtractor, Plugin};
+use crate::types::{ExtractedImage, ExtractionResult, FormatMetadata, Metadata, PageContent, PageInfo};
+use async_trait::async_trait;
+use std::sync::Arc;
+
+/// XPS and OpenXPS extractor.
+///
+/// Extracts the text of each fixed page from its glyph runs, in reading order, with a page
+/// boundary per page, `PageContent` entries when page extraction is enabled, and the core
+/// properties as `XpsMetadata`. With OCR configured, pages without text are recognized
+/// from the largest image they paint.
+pub struct XpsExtractor;
+
+impl XpsExtractor {
+    /// Create a new XPS extractor.
+    pub fn new() -> Self {
+        Self
+    }
+
+    /// Recognize the text of a page image with the configured OCR backend.
+    #[cfg(feature = "ocr")]
+    async fn recognize_image(&self, image: &[u8], config: &ExtractionConfig) -> Result<String> {
+        use crate::plugins::registry::get_ocr_backend_registry;
+
+        let ocr_config = config.ocr.clone().unwrap_or_default();
+        let backend = {
+            let registry = get_ocr_backend_registry();
+            let registry = registry.read().map_err(|e| crate::KreuzbergError::Plugin {
+                message: format!("Failed to acquire read lock on OCR backend registry: {}", e),
+                plugin_name: "ocr-registry".to_string(),
+            })?;
+            registry.get(&ocr_config.backend)?
+        };
+        let recognized = backend.process_image(image, &ocr_config).await?;
+        Ok(recognized.content)
+    }
+}
+
+impl Default for XpsExtractor {
+    fn default() -> Self {
+        Self::new()
+    }
+}
+
+impl Plugin for XpsExtractor {
+    fn name(&self) -> &str {
+        "xps-extractor"
+    }
+
+    fn version(&self) -> String {
+        env!("CARGO_PKG_VERSION").to_string()
+    }
+
+    fn initialize(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn shutdown(&self) -> Result<()> {
+        Ok(())
+    }
+
+    fn description(&self) -> &str {
+        "Extracts text, images and core properties from XPS and OpenXPS documents"
+    }
+
+    fn author(&self) -> &str {
+        "Kreuzberg Team"
+    }
+}
+
+#[async_trait]
+impl DocumentExtractor for XpsExtractor {
+    #[cfg_attr(feature = "otel", tracing::instrument(
+        skip(self, content, config),
+        fields(
+            extractor.name = self.name(),
+            content.size_bytes = content.len(),
+        )
+    ))]
+    async fn extract_bytes(
+        &self,
+        content: &[u8],
+        mime_type: &str,
+        config: &ExtractionConfig,
+    ) -> Result<ExtractionResult> {
+        let extract_images = config.images.as_ref().is_some_and(|images| images.extract_images);
+        let recognize_pages = cfg!(feature = "ocr") && config.ocr.is_some();
+        let xps = read_xps(content, extract_images || recognize_pages)?;
+
+        let mut images = Vec::new();
+        let mut pages = Vec::with_capacity(xps.pages.len());
+        let mut infos = Vec::with_capacity(xps.pages.len());
+        for (index, page) in xps.pages.into_iter().enumerate() {
+            let page_number = index + 1;
+
+            #[allow(unused_mut)]
+            let mut text = page.text;
+            #[cfg(feature = "ocr")]
+            if recognize_pages
+                && text.is_empty()
+                && let Some(largest) = page.images.iter().max_by_key(|image| image.data.len())
+                && let Ok(recognized) = self.recognize_image(&largest.data, config).await
+            {
+                text = recognized.trim().to_string();
+            }
+
+            let mut page_images = Vec::new();
+            if extract_images {
+                for image in page.images {
+                    let image = ExtractedImage {
+                        data: image.data,
+                        format: image.format,
+                        image_index: images.len(),
+                        page_number: Some(page_number),
+                        width: None,
+                        height: None,
+                        colorspace: None,
+                        bits_per_component: None,
+                        is_mask: false,
+                        description: None,
+                        ocr_result: None,
+                    };
+                    page_images.push(Arc::new(image.clone()));
+                    images.push(image);
+                }
+            }
+
+            pages.push(PageContent {
+                page_number,
+                content: text,
+                tables: vec![],
+                images: page_images,
+                hierarchy: None,
+            });
+            infos.push(PageInfo {
+                number: page_number,
+                title: None,
+                dimensions: page.dimensions,
+                image_count: Some(page.image_count),
+                table_count: None,
+                hidden: None,
+            });
+        }
+        let paginated = paginate(pages, infos, config.pages.as_ref());
+
+        Ok(ExtractionResult {
+            content: paginated.content,
+            mime_type: mime_type.to_string(),
+            metadata: Metadata {
+                subject: xps.subject,
+                language: xps.language,
+                pages: Some(paginated.structure),
+                format: Some(FormatMetadata::Xps(xps.metadata)),
+                ..Default::default()
+            },
+            tables: vec![],
+            detected_languages: None,
+            chunks: None,
+            images: extract_images.then_some(images),
+            pages: paginated.pages,
+            djot_content: None,
+            elements: None,
+        })
+    }
+
+    fn supported_mime_types(&self) -> &[&str] {
+        &[XPS_MIME_TYPE, OXPS_MIME_TYPE]
+    }
+
+    fn priority(&self) -> i32 {
+        50
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+    use crate::core::config::{ImageExtractionConfig, PageConfig};
+    use crate::extraction::xps::test_support;
+
+    #[tokio::test]
+    async fn test_xps_extractor_pages_and_images() {
+        let config = ExtractionConfig {
+            images: Some(ImageExtractionConfig {
+                extract_images: true,
+                target_dpi: 300,
+                max_image_dimension: 4096,
+                auto_adjust_dpi: true,
+                min_dpi: 72,
+                max_dpi: 600,
+            }),
+            pages: Some(PageConfig {
+                extract_pages: true,
+                ..Default::default()
+            }),
+            ..Default::default()
+        };
+
+        let extractor = XpsExtractor::new();
+        let result = extractor
+            .extract_bytes(&test_support::document(), XPS_MIME_TYPE, &config)
+            .await
+            .expect("XPS document should be extracted");
+
+        let pages = result.pages.expect("pages should be extracted");
+        assert_eq!(pages[0].content, "Invoice Total: 12.00\n\nThank you.\nCome again");
+        assert_eq!(pages[1].images.len(), 1);
+        assert_eq!(result.content, format!("{}\n\n", pages[0].content));
+
+        let images = result.images.expect("images should be extracted");
+        assert_eq!(images.len(), 1);
+        assert_eq!(images[0].page_number, Some(2));
+        assert_eq!(images[0].format, "png");
+
+        let structure = result.metadata.pages.expect("page structure should be set");
+        assert_eq!(structure.total_count, 2);
+        assert_eq!(structure.pages.unwrap()[0].dimensions, Some((612.0, 792.0)));
+        assert_eq!(result.metadata.subject.as_deref(), Some("Billing"));
+        match result.metadata.format {
+            Some(FormatMetadata::Xps(meta)) => {
+                assert_eq!(meta.title.as_deref(), Some("Invoice 42"));
+                assert_eq!(meta.page_count, 2);
+            }
+            other => panic!("Expected XPS metadata, got {:?}", other),
+        }
+    }
+
+    #[tokio::test]
+    async fn test_xps_extractor_page_markers() {
+        let config = ExtractionConfig {
+            pages: Some(PageConfig {
+                insert_page_markers: true,
+                marker_format: "[page {page_num}]".to_string(),
+                ..Default::default()
+            }),
+            ..Default::default()
+        };
+
+        let extractor = XpsExtractor::new();
+        let result = extractor
+            .extract_bytes(&test_support::document(), XPS_MIME_TYPE, &config)
+            .await
+            .expect("XPS document should be extracted");
+
+        assert_eq!(
+            result.content,
+            "[page 1]Invoice Total: 12.00\n\nThank you.\nCome again[page 2]"
+        );
+        assert!(result.pages.is_none());
+        assert!(result.images.is_none());
+    }
+
+    #[test]
+    fn test_xps_plugin_interface() {
+        let extractor = XpsExtractor::new();
+        assert_eq!(extractor.name(), "xps-extractor");
+        assert_eq!(extractor.supported_mime_types(), &[XPS_MIME_TYPE, OXPS_MIME_TYPE]);
+    }
+}
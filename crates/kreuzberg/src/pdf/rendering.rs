@@ -1,5 +1,7 @@
 use super::bindings::{PdfiumHandle, bind_pdfium};
 use super::error::{PdfError, Result};
+#[cfg(feature = "ocr")]
+use crate::ocr::table::HocrWord;
 use image::DynamicImage;
 use pdfium_render::prelude::*;
 use serde::{Deserialize, Serialize};
@@ -27,6 +29,17 @@ impl Default for PageRenderOptions {
     }
 }
 
+/// The text layer of a page: its size in PDF points and its words, positioned in points
+/// from the top-left corner of the page.
+#[cfg(feature = "ocr")]
+#[derive(Debug, Clone)]
+pub struct PageTextLayer {
+    pub page_index: usize,
+    pub width: f32,
+    pub height: f32,
+    pub words: Vec<HocrWord>,
+}
+
 pub struct PdfRenderer<'a> {
     pdfium: PdfiumHandle<'a>,
 }
@@ -88,10 +101,22 @@ impl PdfRenderer<'_> {
         };
 
         let scale = dpi as f64 / PDF_POINTS_PER_INCH;
+        let target_width = ((width_points * scale as f32) as i32).max(1);
+        let target_height = ((height_points * scale as f32) as i32).max(1);
+        if target_width > options.max_image_dimension || target_height > options.max_image_dimension {
+            return Err(PdfError::RenderingFailed(format!(
+                "Page {} at {} DPI would be {}x{} pixels, above the {} pixel limit",
+                page_index + 1,
+                dpi,
+                target_width,
+                target_height,
+                options.max_image_dimension
+            )));
+        }
 
         let config = PdfRenderConfig::new()
-            .set_target_width(((width_points * scale as f32) as i32).max(1))
-            .set_target_height(((height_points * scale as f32) as i32).max(1))
+            .set_target_width(target_width)
+            .set_target_height(target_height)
             .rotate_if_landscape(PdfPageRenderRotation::None, false);
 
         let bitmap = page
@@ -103,6 +128,82 @@ impl PdfRenderer<'_> {
         Ok(DynamicImage::ImageRgb8(image))
     }
 
+    /// Extract the words of a page with their positions, in PDF points measured from the
+    /// top-left corner of the page.
+    #[cfg(feature = "ocr")]
+    pub fn page_words_with_password(
+        &self,
+        pdf_bytes: &[u8],
+        page_index: usize,
+        password: Option<&str>,
+    ) -> Result<Vec<HocrWord>> {
+        let document = self.pdfium.load_pdf_from_byte_slice(pdf_bytes, password).map_err(|e| {
+            let err_msg = super::error::format_pdfium_error(e);
+            if (err_msg.contains("password") || err_msg.contains("Password")) && password.is_some() {
+                PdfError::InvalidPassword
+            } else if err_msg.contains("password") || err_msg.contains("Password") {
+                PdfError::PasswordRequired
+            } else {
+                PdfError::InvalidPdf(err_msg)
+            }
+        })?;
+
+        let page = document
+            .pages()
+            .get(page_index as i32)
+            .map_err(|_| PdfError::PageNotFound(page_index))?;
+
+        super::table::extract_words_from_page(&page, 0.0)
+    }
+
+    /// Extract the words of the pages from `first_index` through `last_index` (0-indexed,
+    /// inclusive; the last page when `None`) without rendering them. The document is loaded
+    /// once, and each page comes with its size in PDF points.
+    #[cfg(feature = "ocr")]
+    pub fn text_layer_with_password(
+        &self,
+        pdf_bytes: &[u8],
+        first_index: usize,
+        last_index: Option<usize>,
+        password: Option<&str>,
+    ) -> Result<Vec<PageTextLayer>> {
+        let document = self.pdfium.load_pdf_from_byte_slice(pdf_bytes, password).map_err(|e| {
+            let err_msg = super::error::format_pdfium_error(e);
+            if (err_msg.contains("password") || err_msg.contains("Password")) && password.is_some() {
+                PdfError::InvalidPassword
+            } else if err_msg.contains("password") || err_msg.contains("Password") {
+                PdfError::PasswordRequired
+            } else {
+                PdfError::InvalidPdf(err_msg)
+            }
+        })?;
+
+        let page_count = document.pages().len() as usize;
+        let last_index = match last_index {
+            Some(last) if last >= page_count => return Err(PdfError::PageNotFound(last)),
+            Some(last) => last,
+            None => page_count.saturating_sub(1),
+        };
+        if first_index >= page_count {
+            return Err(PdfError::PageNotFound(first_index));
+        }
+
+        let mut layers = Vec::with_capacity(last_index.saturating_sub(first_index) + 1);
+        for page_index in first_index..=last_index {
+            let page = document
+                .pages()
+                .get(page_index as i32)
+                .map_err(|_| PdfError::PageNotFound(page_index))?;
+            layers.push(PageTextLayer {
+                page_index,
+                width: page.width().value,
+                height: page.height().value,
+                words: super::table::extract_words_from_page(&page, 0.0)?,
+            });
+        }
+        Ok(layers)
+    }
+
     pub fn render_all_pages(&self, pdf_bytes: &[u8], options: &PageRenderOptions) -> Result<Vec<DynamicImage>> {
         self.render_all_pages_with_password(pdf_bytes, options, None)
     }
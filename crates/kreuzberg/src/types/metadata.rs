@@ -29,6 +29,13 @@ pub enum FormatMetadata {
     Text(TextMetadata),
     Html(Box<HtmlMetadata>),
     Ocr(OcrMetadata),
+    LegacyOffice(LegacyOfficeMetadata),
+    Dicom(DicomMetadata),
+    WordProcessor(WordProcessorMetadata),
+    #[serde(rename = "fb2")]
+    FictionBook(FictionBookMetadata),
+    Djvu(DjvuMetadata),
+    Xps(XpsMetadata),
 }
 
 /// Extraction result metadata.
@@ -145,12 +152,12 @@ pub struct EmailMetadata {
     pub attachments: Vec<String>,
 }
 
-/// Archive (ZIP/TAR/7Z) metadata.
+/// Archive (ZIP/TAR/7Z/RAR) metadata.
 ///
 /// Extracted from compressed archive files containing file lists and size information.
 #[derive(Debug, Clone, Serialize, Deserialize)]
 pub struct ArchiveMetadata {
-    /// Archive format ("ZIP", "TAR", "7Z", etc.)
+    /// Archive format ("ZIP", "TAR", "7Z", "RAR")
     pub format: String,
     /// Total number of files in the archive
     pub file_count: usize,
@@ -162,6 +169,20 @@ pub struct ArchiveMetadata {
     /// Compressed size in bytes (if available)
     #[serde(skip_serializing_if = "Option::is_none")]
     pub compressed_size: Option<usize>,
+
+    /// Entries whose content could not be read, such as encrypted entries that none of the
+    /// configured passwords decrypts
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub entry_errors: Vec<ArchiveEntryError>,
+}
+
+/// An archive entry whose content could not be read.
+#[derive(Debug, Clone, PartialEq, Eq, Serialize, Deserialize)]
+pub struct ArchiveEntryError {
+    /// Path of the entry within the archive
+    pub path: String,
+    /// Why the entry could not be read
+    pub error: String,
 }
 
 /// Image metadata extracted from image files.
@@ -179,6 +200,21 @@ pub struct ImageMetadata {
     pub exif: HashMap<String, String>,
 }
 
+/// Fax encoding of a multi-page TIFF, reported under the `fax` key of
+/// `Metadata::additional`.
+#[derive(Debug, Clone, PartialEq, Serialize, Deserialize)]
+pub struct FaxMetadata {
+    /// CCITT compression: `ccitt_rle`, `group3` or `group4`
+    pub compression: String,
+    /// Horizontal and vertical resolution, which differ for standard-resolution faxes
+    /// whose pixels are twice as tall as they are wide
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub horizontal_dpi: Option<f64>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub vertical_dpi: Option<f64>,
+    pub page_count: usize,
+}
+
 /// XML metadata extracted during XML parsing.
 ///
 /// Provides statistics about XML document structure.
@@ -558,3 +594,195 @@ pub struct PptxMetadata {
     /// Names of slides (if available)
     pub slide_names: Vec<String>,
 }
+
+/// Summary information of a binary Word or PowerPoint file read without LibreOffice.
+///
+/// Times are RFC 3339 in UTC.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct LegacyOfficeMetadata {
+    /// Application that last saved the file
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub application: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub title: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub subject: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub author: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub keywords: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub comments: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub template: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub last_author: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub revision: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub created_at: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub modified_at: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub page_count: Option<i32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub word_count: Option<i32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub character_count: Option<i32>,
+    /// Number of slides of a PowerPoint file
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub slide_count: Option<usize>,
+}
+
+/// DICOM object metadata: the patient, study and series it belongs to and the image it
+/// carries.
+///
+/// Dates are formatted as `YYYY-MM-DD`. `report_title` is set for structured reports (SR).
+/// When `DicomConfig::anonymize` is set, the patient and staff identifiers are left out and
+/// `anonymized` is true.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct DicomMetadata {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub patient_name: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub patient_id: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub patient_birth_date: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub patient_sex: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub patient_age: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub study_instance_uid: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub study_date: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub study_description: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub accession_number: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub series_instance_uid: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub series_description: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub modality: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub sop_class_uid: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub sop_instance_uid: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub manufacturer: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub institution_name: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub referring_physician_name: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub body_part_examined: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub transfer_syntax_uid: Option<String>,
+    /// Title of a structured report
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub report_title: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub rows: Option<usize>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub columns: Option<usize>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub number_of_frames: Option<usize>,
+    pub has_pixel_data: bool,
+    /// Whether the patient and staff identifiers were removed
+    pub anonymized: bool,
+}
+
+/// Metadata of a WordPerfect, Lotus Word Pro or Ami Pro document.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct WordProcessorMetadata {
+    /// Program that wrote the document, e.g. "WordPerfect"
+    pub application: String,
+    /// File format version, e.g. "5.1"
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub version: Option<String>,
+    /// Whether the document is password-protected, in which case its text is not extracted
+    #[serde(default)]
+    pub encrypted: bool,
+}
+
+/// Title and publishing information of a FictionBook ebook.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct FictionBookMetadata {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub title: Option<String>,
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub authors: Vec<String>,
+    #[serde(default, skip_serializing_if = "Vec::is_empty")]
+    pub genres: Vec<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub keywords: Option<String>,
+    /// Paragraphs of the annotation, one per line
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub annotation: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub date: Option<String>,
+    /// Name of the series the book belongs to
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub sequence: Option<String>,
+    /// Position of the book in its series
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub sequence_number: Option<u32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub publisher: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub year: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub isbn: Option<String>,
+}
+
+/// DjVu document metadata and the state of its text layer.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct DjvuMetadata {
+    pub page_count: usize,
+    /// Pixel width of the first page
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub width: Option<u32>,
+    /// Pixel height of the first page
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub height: Option<u32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub dpi: Option<u32>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub version: Option<String>,
+    /// Number of pages whose text layer was extracted
+    pub text_pages: usize,
+    /// Number of pages whose text layer is BZZ-compressed, which is not decoded; their
+    /// text is missing from the result
+    #[serde(default)]
+    pub compressed_text_pages: usize,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub title: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub author: Option<String>,
+}
+
+/// Core properties and structure of an XPS or OpenXPS document.
+#[derive(Debug, Clone, Default, PartialEq, Serialize, Deserialize)]
+pub struct XpsMetadata {
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub title: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub creator: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub keywords: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub description: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub last_modified_by: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub revision: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub created_at: Option<String>,
+    #[serde(skip_serializing_if = "Option::is_none")]
+    pub modified_at: Option<String>,
+    pub page_count: usize,
+    /// Number of fixed documents in the document sequence
+    pub document_count: usize,
+}
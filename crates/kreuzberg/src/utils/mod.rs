@@ -4,6 +4,7 @@
 //! - Quality processing: clean OCR artifacts, calculate quality scores
 //! - String utilities: safe decoding, mojibake fixing, encoding detection
 //! - Object pooling: reusable pools for batch processing to reduce allocations
+//! - Temporary files: where extractors spool data to disk
 
 #[cfg(feature = "quality")]
 pub mod quality;
@@ -14,6 +15,7 @@ pub mod string_utils;
 pub mod pool;
 pub mod pool_sizing;
 pub mod string_pool;
+pub mod temp;
 
 #[cfg(feature = "quality")]
 pub use quality::{calculate_quality_score, clean_extracted_text, normalize_spaces};
@@ -29,3 +31,5 @@ pub use pool::{
 pub use pool_sizing::{PoolSizeHint, estimate_pool_size};
 
 pub use string_pool::{InternedString, intern_language_code, intern_mime_type};
+
+pub use temp::{set_temp_dir, temp_dir};
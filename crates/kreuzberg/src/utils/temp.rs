@@ -0,0 +1,43 @@
+//! Location of the temporary files written during extraction.
+//!
+//! Some extractors spool data to disk: LibreOffice converts legacy Office documents from
+//! files, and PPTX bytes are reopened from a file. They create their files under
+//! [`temp_dir`], which is the system temporary directory unless an embedding application
+//! redirects it with [`set_temp_dir`], for instance to a private directory on encrypted
+//! storage that it wipes after each extraction.
+
+use std::path::PathBuf;
+use std::sync::RwLock;
+
+static TEMP_DIR: RwLock<Option<PathBuf>> = RwLock::new(None);
+
+/// Directory under which extractors create their temporary files.
+pub fn temp_dir() -> PathBuf {
+    match TEMP_DIR.read() {
+        Ok(dir) => dir.clone().unwrap_or_else(std::env::temp_dir),
+        Err(poisoned) => poisoned.into_inner().clone().unwrap_or_else(std::env::temp_dir),
+    }
+}
+
+/// Redirects the temporary files of later extractions to `dir`, or back to the system
+/// temporary directory when `None`. The setting is process-wide.
+pub fn set_temp_dir(dir: Option<PathBuf>) {
+    match TEMP_DIR.write() {
+        Ok(mut current) => *current = dir,
+        Err(poisoned) => *poisoned.into_inner() = dir,
+    }
+}
+
+#[cfg(test)]
+mod tests {
+    use super::*;
+
+    #[test]
+    fn test_set_temp_dir_redirects_and_resets() {
+        let dir = std::env::temp_dir().join("kreuzberg_temp_dir_test");
+        set_temp_dir(Some(dir.clone()));
+        assert_eq!(temp_dir(), dir);
+        set_temp_dir(None);
+        assert_eq!(temp_dir(), std::env::temp_dir());
+    }
+}
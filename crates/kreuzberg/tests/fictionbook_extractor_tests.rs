@@ -46,6 +46,29 @@ async fn test_fictionbook_extract_metadata_genre() {
     assert!(result.metadata.subject.is_none());
 }
 
+#[tokio::test]
+async fn test_fictionbook_extract_format_metadata() {
+    let extractor = kreuzberg::extractors::FictionBookExtractor::new();
+    let path = test_file_path("meta.fb2");
+
+    let result = extractor
+        .extract_file(&path, "application/x-fictionbook+xml", &ExtractionConfig::default())
+        .await
+        .expect("Failed to extract FB2 file");
+
+    match result.metadata.format {
+        Some(kreuzberg::FormatMetadata::FictionBook(meta)) => {
+            assert_eq!(meta.title.as_deref(), Some("Book title"));
+            assert_eq!(
+                meta.annotation.as_deref(),
+                Some("This is the abstract.\nIt consists of two paragraphs.")
+            );
+            assert!(meta.genres.is_empty(), "unrecognised genres should be skipped");
+        }
+        other => panic!("Expected FictionBook metadata, got {:?}", other),
+    }
+}
+
 #[tokio::test]
 async fn test_fictionbook_extract_content_sections() {
     let extractor = kreuzberg::extractors::FictionBookExtractor::new();
@@ -0,0 +1,190 @@
+package kreuzberg
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Acronym is an acronym defined in Content, as in "Service Level Agreement (SLA)" or
+// "SLA (Service Level Agreement)". ByteStart and ByteEnd delimit the definition, acronym
+// and parentheses included. Uses counts the other occurrences of the acronym in Content.
+type Acronym struct {
+	Acronym    string `json:"acronym"`
+	Definition string `json:"definition"`
+	ByteStart  uint64 `json:"byte_start"`
+	ByteEnd    uint64 `json:"byte_end"`
+	Uses       int    `json:"uses"`
+}
+
+// Acronym length limits, in runes.
+const (
+	acronymMinLength = 2
+	acronymMaxLength = 10
+)
+
+// DetectAcronyms returns the glossary of the acronyms defined in text, in order of their
+// first definition. An acronym defined more than once keeps its first definition. A
+// definition is read from the words before or inside the parentheses whose initials and
+// letters spell the acronym in order, as Schwartz and Hearst describe, so that "Request
+// for Comments (RFC)" and "Internet of Things (IoT)" are found but "a new plan (SLA)" is
+// not.
+func DetectAcronyms(text string) []Acronym {
+	var glossary []Acronym
+	seen := map[string]bool{}
+	for open := strings.IndexByte(text, '('); open >= 0; {
+		closing := strings.IndexAny(text[open+1:], "()\n")
+		if closing < 0 {
+			break
+		}
+		closing += open + 1
+		if text[closing] == ')' {
+			inner := strings.TrimSpace(text[open+1 : closing])
+			if acronym, ok := acronymDefinition(text, open, closing, inner); ok && !seen[acronym.Acronym] {
+				seen[acronym.Acronym] = true
+				glossary = append(glossary, acronym)
+			}
+		}
+		next := strings.IndexByte(text[closing:], '(')
+		if next < 0 {
+			break
+		}
+		open = closing + next
+	}
+	for i := range glossary {
+		glossary[i].Uses = countAcronymUses(text, glossary[i])
+	}
+	return glossary
+}
+
+// acronymDefinition reads a definition around the parentheses at text[open] and
+// text[closing] holding inner: the long form before an acronym in parentheses, or the
+// long form in parentheses after an acronym.
+func acronymDefinition(text string, open, closing int, inner string) (Acronym, bool) {
+	if isAcronym(inner) {
+		before := strings.TrimRightFunc(sentenceBefore(text, open), unicode.IsSpace)
+		from := open - len(sentenceBefore(text, open))
+		// Only the last min(|A|+5, 2|A|) words may define an acronym of |A| runes.
+		limit := min(utf8.RuneCountInString(inner)+5, 2*utf8.RuneCountInString(inner))
+		words := wordStarts(before)
+		if len(words) > limit {
+			from += words[len(words)-limit]
+			before = before[words[len(words)-limit]:]
+		}
+		if start, ok := longForm(inner, before); ok {
+			return Acronym{Acronym: inner, Definition: before[start:], ByteStart: uint64(from + start), ByteEnd: uint64(closing + 1)}, true
+		}
+		return Acronym{}, false
+	}
+
+	// "SLA (Service Level Agreement)": the acronym is the word before the parentheses.
+	before := strings.TrimRight(text[:open], " \t")
+	start := strings.LastIndexFunc(before, func(r rune) bool { return !isAcronymRune(r) }) + 1
+	short := before[start:]
+	if !isAcronym(short) {
+		return Acronym{}, false
+	}
+	if at, ok := longForm(short, inner); ok && at == 0 {
+		return Acronym{Acronym: short, Definition: inner, ByteStart: uint64(start), ByteEnd: uint64(closing + 1)}, true
+	}
+	return Acronym{}, false
+}
+
+func isAcronymRune(r rune) bool {
+	return isWordRune(r) || r == '&' || r == '-' || r == '/' || r == '.'
+}
+
+// isAcronym reports whether text looks like an acronym: a single word of 2 to 10 runes
+// starting with a letter or digit, with at least two capitals and more capitals than
+// lowercase letters or exactly one lowercase letter, as "SLA", "R&D", "IoT" or "mRNA".
+func isAcronym(text string) bool {
+	n := utf8.RuneCountInString(text)
+	if n < acronymMinLength || n > acronymMaxLength {
+		return false
+	}
+	if first, _ := utf8.DecodeRuneInString(text); !isWordRune(first) {
+		return false
+	}
+	upper, lower := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		case !isAcronymRune(r):
+			return false
+		}
+	}
+	return upper >= 2 && (upper > lower || lower == 1)
+}
+
+// sentenceBefore returns the text before end back to the start of its sentence or clause.
+func sentenceBefore(text string, end int) string {
+	start := strings.LastIndexAny(text[:end], ".;:!?()[]\n") + 1
+	return text[start:end]
+}
+
+// wordStarts returns the byte offsets of the words of text.
+func wordStarts(text string) []int {
+	var starts []int
+	inWord := false
+	for i, r := range text {
+		if space := unicode.IsSpace(r); !space && !inWord {
+			starts = append(starts, i)
+			inWord = true
+		} else if space {
+			inWord = false
+		}
+	}
+	return starts
+}
+
+// longForm returns where the shortest suffix of candidate, starting at a word, whose
+// letters spell short in order begins, with the first letter of short starting a word.
+// This is the algorithm of Schwartz and Hearst (2003).
+func longForm(short, candidate string) (int, bool) {
+	s, l := []rune(short), []rune(candidate)
+	si, li := len(s)-1, len(l)-1
+	for si >= 0 {
+		c := unicode.ToLower(s[si])
+		if !isWordRune(c) {
+			si--
+			continue
+		}
+		for li >= 0 && (unicode.ToLower(l[li]) != c || (si == 0 && li > 0 && isWordRune(l[li-1]))) {
+			li--
+		}
+		if li < 0 {
+			return 0, false
+		}
+		li--
+		si--
+	}
+	start := li + 1
+	for start > 0 && !unicode.IsSpace(l[start-1]) {
+		start--
+	}
+	long := string(l[start:])
+	if len(l)-start <= len(s) || len(strings.Fields(long)) < 2 || strings.Contains(long, short) {
+		return 0, false
+	}
+	return len(string(l[:start])), true
+}
+
+// countAcronymUses counts the whole-word occurrences of the acronym outside its
+// definition.
+func countAcronymUses(text string, acronym Acronym) int {
+	uses := 0
+	for from := 0; ; {
+		i := strings.Index(text[from:], acronym.Acronym)
+		if i < 0 {
+			return uses
+		}
+		start, end := from+i, from+i+len(acronym.Acronym)
+		if bounded(text, start, end) && (uint64(start) < acronym.ByteStart || uint64(start) >= acronym.ByteEnd) {
+			uses++
+		}
+		from = end
+	}
+}
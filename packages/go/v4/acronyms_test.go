@@ -0,0 +1,38 @@
+package kreuzberg
+
+import "testing"
+
+func TestDetectAcronyms(t *testing.T) {
+	text := "The Service Level Agreement (SLA) applies. Each SLA names an RFC (Request for Comments)\n" +
+		"and the Internet  of Things (IoT). A new plan (SLA) is not a definition; the SLA is.\n" +
+		"See (Fig. 2) and (ABC)."
+	glossary := DetectAcronyms(text)
+	want := []struct {
+		acronym, definition, span string
+		uses                      int
+	}{
+		{"SLA", "Service Level Agreement", "Service Level Agreement (SLA)", 3},
+		{"RFC", "Request for Comments", "RFC (Request for Comments)", 0},
+		{"IoT", "Internet  of Things", "Internet  of Things (IoT)", 0},
+	}
+	if len(glossary) != len(want) {
+		t.Fatalf("DetectAcronyms = %+v", glossary)
+	}
+	for i, w := range want {
+		got := glossary[i]
+		if got.Acronym != w.acronym || got.Definition != w.definition || got.Uses != w.uses ||
+			text[got.ByteStart:got.ByteEnd] != w.span {
+			t.Fatalf("acronym %d = %+v (%q), want %v", i, got, text[got.ByteStart:got.ByteEnd], w)
+		}
+	}
+}
+
+func TestAcronymStage(t *testing.T) {
+	result := &ExtractionResult{Content: "Optical Character Recognition (OCR) reads scans."}
+	if err := runResultStages(result, NewExtractionConfig(WithAcronyms(true))); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Acronyms) != 1 || result.Acronyms[0].Definition != "Optical Character Recognition" {
+		t.Fatalf("Acronyms = %+v", result.Acronyms)
+	}
+}
@@ -0,0 +1,228 @@
+package kreuzberg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOverloaded is wrapped by the RuntimeError returned when an AdmissionController
+// turns an extraction away. Test for it with errors.Is.
+var ErrOverloaded = errors.New("kreuzberg: overloaded")
+
+// AdmissionConfig bounds the work an AdmissionController lets run at once. Zero limits
+// are unlimited.
+type AdmissionConfig struct {
+	// MaxConcurrentOCRPages caps the pages expected to need OCR across running extractions.
+	MaxConcurrentOCRPages int
+	// MaxInFlightMemory caps the estimated native memory of running extractions, in bytes.
+	MaxInFlightMemory int64
+	// MaxQueued is the number of extractions that may wait for capacity. Extractions
+	// beyond it are rejected immediately; zero rejects whenever capacity is exhausted.
+	MaxQueued int
+	// QueueTimeout bounds how long a queued extraction waits before it is rejected.
+	// Zero waits until the context is done.
+	QueueTimeout time.Duration
+}
+
+// AdmissionController queues or rejects extractions whose estimated cost does not fit in
+// the remaining capacity, so that bursts of batch work cannot push latency-sensitive
+// extractions into overload. Queued extractions are admitted in arrival order, each as
+// soon as the capacity freed before it fits, so a large extraction is not overtaken by
+// smaller ones indefinitely. An extraction larger than the whole capacity is admitted
+// only when nothing else is running. It is safe for concurrent use.
+type AdmissionController struct {
+	config AdmissionConfig
+
+	mu       sync.Mutex
+	ocrPages int
+	memory   int64
+	running  int
+	waiters  []*admissionWaiter
+}
+
+// admissionWaiter is a queued extraction. ready is closed once its cost is reserved.
+type admissionWaiter struct {
+	cost  admissionCost
+	ready chan struct{}
+}
+
+// admissionCost is the estimated load of one extraction.
+type admissionCost struct {
+	ocrPages int
+	memory   int64
+}
+
+// NewAdmissionController creates a controller enforcing config.
+func NewAdmissionController(config AdmissionConfig) (*AdmissionController, error) {
+	if config.MaxConcurrentOCRPages < 0 || config.MaxInFlightMemory < 0 || config.MaxQueued < 0 || config.QueueTimeout < 0 {
+		return nil, newValidationErrorWithContext("admission limits cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	return &AdmissionController{config: config}, nil
+}
+
+// ExtractFile extracts the file at path once its estimated cost fits. The cost is
+// estimated from the size and extension of the file alone, so that waiting extractions
+// do not read their files or take the native library; scanned PDFs count as OCR work only
+// when ExtractionConfig.ForceOCR is set.
+func (a *AdmissionController) ExtractFile(ctx context.Context, path string, config *ExtractionConfig) (*ExtractionResult, error) {
+	cost, err := fileAdmissionCost(path, config)
+	if err != nil {
+		return nil, err
+	}
+	release, err := a.acquire(ctx, cost)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ExtractFileWithContext(ctx, path, config)
+}
+
+// ExtractBytes extracts an in-memory document once its estimated cost fits.
+func (a *AdmissionController) ExtractBytes(ctx context.Context, data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	release, err := a.acquire(ctx, bytesAdmissionCost(data, mimeType, config))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return ExtractBytesWithContext(ctx, data, mimeType, config)
+}
+
+// bytesAdmissionCost estimates the load of extracting an in-memory document.
+func bytesAdmissionCost(data []byte, mimeType string, config *ExtractionConfig) admissionCost {
+	return sizeAdmissionCost(int64(len(data)), mimeType, config)
+}
+
+// fileAdmissionCost estimates the load of extracting the file at path from its size and
+// the MIME type of its extension, without reading it.
+func fileAdmissionCost(path string, config *ExtractionConfig) (admissionCost, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return admissionCost{}, newIOErrorWithContext(fmt.Sprintf("failed to stat file: %s", path), err, ErrorCodeIo, nil)
+	}
+	mimeType, _, _ := strings.Cut(mime.TypeByExtension(strings.ToLower(filepath.Ext(path))), ";")
+	return sizeAdmissionCost(info.Size(), mimeType, config), nil
+}
+
+// sizeAdmissionCost estimates the load of extracting a document of size bytes.
+func sizeAdmissionCost(size int64, mimeType string, config *ExtractionConfig) admissionCost {
+	cost := admissionCost{memory: size * estimateMemoryPerByte}
+	pages := estimatePageCount(mimeType, size)
+	switch {
+	case config != nil && config.ForceOCR != nil && *config.ForceOCR:
+		cost.ocrPages = pages
+	case strings.HasPrefix(mimeType, "image/"):
+		cost.ocrPages = pages
+	}
+	if cost.ocrPages > 0 {
+		cost.memory += estimateOCRPageMemory
+	}
+	return cost
+}
+
+// acquire reserves capacity for cost, waiting in the queue when allowed. The returned
+// function releases the reservation.
+func (a *AdmissionController) acquire(ctx context.Context, cost admissionCost) (func(), error) {
+	a.mu.Lock()
+	if len(a.waiters) == 0 && a.fits(cost) {
+		a.reserve(cost)
+		a.mu.Unlock()
+		return a.releaseFunc(cost), nil
+	}
+	if len(a.waiters) >= a.config.MaxQueued {
+		a.mu.Unlock()
+		return nil, a.overloaded("queue is full")
+	}
+	waiter := &admissionWaiter{cost: cost, ready: make(chan struct{})}
+	a.waiters = append(a.waiters, waiter)
+	a.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if a.config.QueueTimeout > 0 {
+		timer := time.NewTimer(a.config.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case <-waiter.ready:
+		return a.releaseFunc(cost), nil
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timeout:
+		err = a.overloaded("timed out waiting for capacity")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	select {
+	case <-waiter.ready:
+		// The capacity was handed over while giving up; pass it on.
+		a.unreserve(cost)
+	default:
+		a.waiters = slices.DeleteFunc(a.waiters, func(w *admissionWaiter) bool { return w == waiter })
+	}
+	a.grant()
+	return nil, err
+}
+
+func (a *AdmissionController) releaseFunc(cost admissionCost) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			a.mu.Lock()
+			a.unreserve(cost)
+			a.grant()
+			a.mu.Unlock()
+		})
+	}
+}
+
+// grant hands the free capacity to the queued extractions in arrival order, stopping at
+// the first that does not fit. The caller holds a.mu.
+func (a *AdmissionController) grant() {
+	for len(a.waiters) > 0 && a.fits(a.waiters[0].cost) {
+		waiter := a.waiters[0]
+		a.waiters = a.waiters[1:]
+		a.reserve(waiter.cost)
+		close(waiter.ready)
+	}
+}
+
+// reserve and unreserve account for a running extraction. The caller holds a.mu.
+func (a *AdmissionController) reserve(cost admissionCost) {
+	a.ocrPages += cost.ocrPages
+	a.memory += cost.memory
+	a.running++
+}
+
+func (a *AdmissionController) unreserve(cost admissionCost) {
+	a.ocrPages -= cost.ocrPages
+	a.memory -= cost.memory
+	a.running--
+}
+
+// fits reports whether cost can start now. The caller holds a.mu.
+func (a *AdmissionController) fits(cost admissionCost) bool {
+	if a.running == 0 {
+		return true
+	}
+	if limit := a.config.MaxConcurrentOCRPages; limit > 0 && a.ocrPages+cost.ocrPages > limit {
+		return false
+	}
+	if limit := a.config.MaxInFlightMemory; limit > 0 && a.memory+cost.memory > limit {
+		return false
+	}
+	return true
+}
+
+func (a *AdmissionController) overloaded(reason string) error {
+	return newRuntimeErrorWithContext(fmt.Sprintf("admission control rejected the extraction (%s)", reason), ErrOverloaded, ErrorCodeInternal, nil)
+}
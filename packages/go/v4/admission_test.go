@@ -0,0 +1,135 @@
+package kreuzberg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdmissionControllerRejectsWhenFull(t *testing.T) {
+	controller, err := NewAdmissionController(AdmissionConfig{MaxConcurrentOCRPages: 10})
+	if err != nil {
+		t.Fatalf("NewAdmissionController: %v", err)
+	}
+	release, err := controller.acquire(context.Background(), admissionCost{ocrPages: 8})
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	if _, err := controller.acquire(context.Background(), admissionCost{ocrPages: 5}); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected ErrOverloaded, got %v", err)
+	}
+	release()
+	// An oversized extraction is admitted when nothing else runs.
+	release, err = controller.acquire(context.Background(), admissionCost{ocrPages: 50})
+	if err != nil {
+		t.Fatalf("oversized acquire: %v", err)
+	}
+	release()
+}
+
+func TestAdmissionControllerQueues(t *testing.T) {
+	controller, err := NewAdmissionController(AdmissionConfig{MaxInFlightMemory: 100, MaxQueued: 1, QueueTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("NewAdmissionController: %v", err)
+	}
+	release, err := controller.acquire(context.Background(), admissionCost{memory: 80})
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	admitted := make(chan error, 1)
+	go func() {
+		next, err := controller.acquire(context.Background(), admissionCost{memory: 80})
+		if err == nil {
+			next()
+		}
+		admitted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	release()
+	if err := <-admitted; err != nil {
+		t.Fatalf("queued acquire: %v", err)
+	}
+}
+
+func TestAdmissionControllerQueueTimeout(t *testing.T) {
+	controller, err := NewAdmissionController(AdmissionConfig{MaxInFlightMemory: 100, MaxQueued: 1, QueueTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewAdmissionController: %v", err)
+	}
+	release, err := controller.acquire(context.Background(), admissionCost{memory: 80})
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+	defer release()
+	if _, err := controller.acquire(context.Background(), admissionCost{memory: 80}); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected ErrOverloaded after timeout, got %v", err)
+	}
+	if _, err := NewAdmissionController(AdmissionConfig{MaxQueued: -1}); err == nil {
+		t.Fatalf("expected negative limits to be rejected")
+	}
+}
+
+func TestAdmissionControllerAdmitsInArrivalOrder(t *testing.T) {
+	controller, err := NewAdmissionController(AdmissionConfig{MaxInFlightMemory: 100, MaxQueued: 2})
+	if err != nil {
+		t.Fatalf("NewAdmissionController: %v", err)
+	}
+	release, err := controller.acquire(context.Background(), admissionCost{memory: 80})
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	type admission struct {
+		label   string
+		release func()
+	}
+	admitted := make(chan admission, 2)
+	waiting := func(n int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for {
+			controller.mu.Lock()
+			queued := len(controller.waiters)
+			controller.mu.Unlock()
+			if queued == n {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %d queued extractions, got %d", n, queued)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	queue := func(label string, memory int64) {
+		go func() {
+			release, err := controller.acquire(context.Background(), admissionCost{memory: memory})
+			if err != nil {
+				t.Errorf("acquire %s: %v", label, err)
+			}
+			admitted <- admission{label, release}
+		}()
+	}
+	queue("large", 90)
+	waiting(1)
+	// The small extraction fits now but must not overtake the large one.
+	queue("small", 20)
+	waiting(2)
+
+	release()
+	first := <-admitted
+	if first.label != "large" {
+		t.Fatalf("admitted %s first", first.label)
+	}
+	waiting(1)
+	first.release()
+	if second := <-admitted; second.label != "small" {
+		t.Fatalf("admitted %s second", second.label)
+	} else {
+		second.release()
+	}
+	if controller.memory != 0 || controller.running != 0 {
+		t.Fatalf("expected an idle controller, got %d bytes in %d extractions", controller.memory, controller.running)
+	}
+}
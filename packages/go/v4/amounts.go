@@ -0,0 +1,314 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Amount kinds reported in Amount.Kind and accepted by AmountDetectionConfig.Kinds.
+const (
+	// AmountMoney is a sum of money with a currency symbol or code.
+	AmountMoney = "money"
+	// AmountMeasurement is a number with a unit of measurement.
+	AmountMeasurement = "measurement"
+)
+
+// Amount is a sum of money or a measurement found in Content. Value is the number as a
+// plain decimal, as NormalizedValue reports numbers. Money carries the ISO 4217 code of
+// its currency; measurements carry the symbol of their unit ("kg", "m2", "°C") and its
+// quantity: "length", "mass", "volume", "area", "temperature", "energy" or "power".
+// ByteStart and ByteEnd delimit Text, symbols and units included.
+type Amount struct {
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+	Value     string `json:"value"`
+	Currency  string `json:"currency,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	Quantity  string `json:"quantity,omitempty"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// AmountDetectionConfig enables the detection of sums of money and measurements into
+// ExtractionResult.Amounts.
+type AmountDetectionConfig struct {
+	// Kinds limits detection to AmountMoney and/or AmountMeasurement. Default: both.
+	Kinds []string `json:"kinds,omitempty"`
+	// Locale reads numbers and ambiguous symbols such as "$" and "kr" as a BCP 47 tag
+	// such as "en-CA". Default: the detected language of the document, else "en-US".
+	Locale string `json:"locale,omitempty"`
+}
+
+// validateAmountDetectionConfig rejects unknown kinds and malformed locales.
+func validateAmountDetectionConfig(cfg *AmountDetectionConfig) error {
+	for _, kind := range cfg.Kinds {
+		if kind != AmountMoney && kind != AmountMeasurement {
+			return newValidationErrorWithContext(fmt.Sprintf("invalid amount kind: %s", kind), nil, ErrorCodeValidation, nil)
+		}
+	}
+	return validateValueLocale(cfg.Locale)
+}
+
+// currencySymbols maps currency symbols and codes to ISO 4217 codes. Symbols mapped to ""
+// are resolved by locale; see valueLocale.currency.
+var currencySymbols = map[string]string{
+	"$": "USD", "US$": "USD", "€": "EUR", "£": "GBP", "¥": "JPY", "￥": "JPY", "円": "JPY",
+	"₹": "INR", "₽": "RUB", "₩": "KRW", "₺": "TRY", "₪": "ILS", "₫": "VND", "฿": "THB",
+	"₴": "UAH", "₱": "PHP", "₦": "NGN", "zł": "PLN", "Kč": "CZK", "Ft": "HUF", "R$": "BRL",
+	"C$": "CAD", "CA$": "CAD", "A$": "AUD", "AU$": "AUD", "NZ$": "NZD", "HK$": "HKD",
+	"S$": "SGD", "MX$": "MXN", "CN¥": "CNY", "元": "CNY", "RMB": "CNY", "Fr.": "CHF",
+	"kr": "", "kr.": "",
+
+	"USD": "USD", "EUR": "EUR", "GBP": "GBP", "JPY": "JPY", "CNY": "CNY", "CHF": "CHF",
+	"CAD": "CAD", "AUD": "AUD", "NZD": "NZD", "HKD": "HKD", "SGD": "SGD", "SEK": "SEK",
+	"NOK": "NOK", "DKK": "DKK", "ISK": "ISK", "PLN": "PLN", "CZK": "CZK", "HUF": "HUF",
+	"RON": "RON", "BGN": "BGN", "TRY": "TRY", "RUB": "RUB", "UAH": "UAH", "INR": "INR",
+	"IDR": "IDR", "KRW": "KRW", "THB": "THB", "VND": "VND", "PHP": "PHP", "MYR": "MYR",
+	"BRL": "BRL", "MXN": "MXN", "ARS": "ARS", "CLP": "CLP", "COP": "COP", "PEN": "PEN",
+	"ZAR": "ZAR", "NGN": "NGN", "EGP": "EGP", "ILS": "ILS", "AED": "AED", "SAR": "SAR",
+	"QAR": "QAR", "KWD": "KWD",
+}
+
+// regionDollars are the currencies written "$" in countries other than the United States.
+var regionDollars = map[string]string{
+	"ca": "CAD", "au": "AUD", "nz": "NZD", "hk": "HKD", "sg": "SGD", "mx": "MXN",
+	"ar": "ARS", "cl": "CLP", "co": "COP",
+}
+
+// currency returns the ISO 4217 code of symbol as written in the locale, or "" when the
+// locale does not say which currency it is.
+func (l valueLocale) currency(symbol string) string {
+	switch symbol {
+	case "$":
+		if code, ok := regionDollars[l.region]; ok {
+			return code
+		}
+	case "¥", "￥":
+		if l.region == "cn" || l.language == "zh" || l.language == "zho" || l.language == "cmn" {
+			return "CNY"
+		}
+	case "kr", "kr.":
+		switch l.language {
+		case "sv", "swe":
+			return "SEK"
+		case "da", "dan":
+			return "DKK"
+		case "no", "nb", "nn", "nor", "nob", "nno":
+			return "NOK"
+		case "is", "isl":
+			return "ISK"
+		}
+	}
+	return currencySymbols[symbol]
+}
+
+type measurementUnit struct {
+	symbol   string
+	quantity string
+}
+
+// measurementUnits maps unit spellings to their symbol and quantity.
+var measurementUnits = map[string]measurementUnit{
+	"mm": {"mm", "length"}, "cm": {"cm", "length"}, "m": {"m", "length"}, "km": {"km", "length"},
+	"in": {"in", "length"}, "ft": {"ft", "length"}, "feet": {"ft", "length"}, "foot": {"ft", "length"},
+	"yd": {"yd", "length"}, "mi": {"mi", "length"}, "mile": {"mi", "length"}, "miles": {"mi", "length"},
+
+	"mg": {"mg", "mass"}, "g": {"g", "mass"}, "kg": {"kg", "mass"}, "KG": {"kg", "mass"},
+	"t": {"t", "mass"}, "tonnes": {"t", "mass"}, "tons": {"t", "mass"}, "lb": {"lb", "mass"},
+	"lbs": {"lb", "mass"}, "oz": {"oz", "mass"},
+
+	"ml": {"ml", "volume"}, "mL": {"ml", "volume"}, "cl": {"cl", "volume"}, "l": {"l", "volume"},
+	"L": {"l", "volume"}, "liter": {"l", "volume"}, "liters": {"l", "volume"}, "litre": {"l", "volume"},
+	"litres": {"l", "volume"}, "gal": {"gal", "volume"}, "m³": {"m3", "volume"}, "m3": {"m3", "volume"},
+	"cbm": {"m3", "volume"},
+
+	"m²": {"m2", "area"}, "m2": {"m2", "area"}, "sqm": {"m2", "area"}, "qm": {"m2", "area"},
+	"km²": {"km2", "area"}, "km2": {"km2", "area"}, "ha": {"ha", "area"}, "ft²": {"ft2", "area"},
+	"sq ft": {"ft2", "area"},
+
+	"°C": {"°C", "temperature"}, "℃": {"°C", "temperature"}, "°F": {"°F", "temperature"},
+	"℉": {"°F", "temperature"},
+
+	"Wh": {"Wh", "energy"}, "kWh": {"kWh", "energy"}, "MWh": {"MWh", "energy"},
+	"W": {"W", "power"}, "kW": {"kW", "power"}, "MW": {"MW", "power"},
+}
+
+// attachedUnits are read only when written against the number, as in "12in"; apart they
+// are too often words.
+var attachedUnits = map[string]bool{"in": true}
+
+var (
+	currencyTokens = longestFirst(currencySymbols)
+	unitTokens     = longestFirst(measurementUnits)
+)
+
+// longestFirst returns the keys of m, longest first, so that "US$" is matched before "$".
+func longestFirst[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// DetectAmounts finds the sums of money and measurements of result.Content: numbers
+// preceded or followed by a currency symbol or code, and numbers followed by a unit.
+// Numbers are read in cfg.Locale or the detected language of result; a nil cfg detects
+// both kinds.
+func DetectAmounts(result *ExtractionResult, cfg *AmountDetectionConfig) []Amount {
+	if result == nil {
+		return nil
+	}
+	money, measurements, tag := true, true, ""
+	if cfg != nil {
+		tag = cfg.Locale
+		if len(cfg.Kinds) > 0 {
+			money, measurements = false, false
+			for _, kind := range cfg.Kinds {
+				money = money || kind == AmountMoney
+				measurements = measurements || kind == AmountMeasurement
+			}
+		}
+	}
+	return detectAmounts(result.Content, resultValueLocale(result, tag), money, measurements)
+}
+
+func detectAmounts(text string, locale valueLocale, money, measurements bool) []Amount {
+	var amounts []Amount
+	for _, span := range locale.numbers.FindAllStringIndex(text, -1) {
+		start, end := span[0], span[1]
+		if r, _ := utf8.DecodeRuneInString(text[start:]); r == '-' || r == '+' || r == '−' {
+			if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+				start += utf8.RuneLen(r)
+			}
+		}
+		if numberContinues(text, start, end, locale) {
+			continue
+		}
+		amount := Amount{Value: canonicalNumber(text[start:end], locale)}
+		first, last := start, end
+		if money {
+			if code, from, ok := currencyBefore(text, start, locale); ok {
+				amount.Kind, amount.Currency, first = AmountMoney, code, from
+			} else if code, to, ok := currencyAfter(text, end, locale); ok {
+				amount.Kind, amount.Currency, last = AmountMoney, code, to
+			}
+		}
+		if amount.Kind == "" && measurements {
+			if unit, to, ok := unitAfter(text, end); ok {
+				amount.Kind, amount.Unit, amount.Quantity, last = AmountMeasurement, unit.symbol, unit.quantity, to
+			}
+		}
+		if amount.Kind == "" || !separate(text, first, last) {
+			continue
+		}
+		// A sign written before the currency, as in "-$5".
+		if sign, size := utf8.DecodeLastRuneInString(text[:first]); first < start && (sign == '-' || sign == '−') &&
+			!strings.ContainsAny(text[start:end], "-−") && separate(text, first-size, last) {
+			first -= size
+			if amount.Value != "0" {
+				amount.Value = "-" + amount.Value
+			}
+		}
+		amount.Text = text[first:last]
+		amount.ByteStart, amount.ByteEnd = uint64(first), uint64(last)
+		amounts = append(amounts, amount)
+	}
+	return amounts
+}
+
+// isAmountSpace reports whether r may separate a number from its currency or unit.
+func isAmountSpace(r rune) bool {
+	return r == ' ' || r == '\u00a0' || r == '\u202f'
+}
+
+// currencyBefore finds a currency written before the number at start, returning its code
+// and where it begins.
+func currencyBefore(text string, start int, locale valueLocale) (string, int, bool) {
+	before := text[:start]
+	if r, size := utf8.DecodeLastRuneInString(before); isAmountSpace(r) {
+		before = before[:len(before)-size]
+	}
+	for _, token := range currencyTokens {
+		if !strings.HasSuffix(before, token) {
+			continue
+		}
+		if code := locale.currency(token); code != "" {
+			return code, len(before) - len(token), true
+		}
+	}
+	return "", 0, false
+}
+
+// currencyAfter finds a currency written after the number ending at end, returning its
+// code and where it ends.
+func currencyAfter(text string, end int, locale valueLocale) (string, int, bool) {
+	after := text[end:]
+	if r, size := utf8.DecodeRuneInString(after); isAmountSpace(r) {
+		after = after[size:]
+	}
+	for _, token := range currencyTokens {
+		if !strings.HasPrefix(after, token) {
+			continue
+		}
+		to := len(text) - len(after) + len(token)
+		if code := locale.currency(token); code != "" && !runsOn(text, to) {
+			return code, to, true
+		}
+	}
+	return "", 0, false
+}
+
+// unitAfter finds a unit written after the number ending at end, returning it and where
+// it ends.
+func unitAfter(text string, end int) (measurementUnit, int, bool) {
+	after := text[end:]
+	spaced := false
+	if r, size := utf8.DecodeRuneInString(after); isAmountSpace(r) {
+		after, spaced = after[size:], true
+	}
+	for _, token := range unitTokens {
+		if !strings.HasPrefix(after, token) || (spaced && attachedUnits[token]) {
+			continue
+		}
+		if to := len(text) - len(after) + len(token); !runsOn(text, to) {
+			return measurementUnits[token], to, true
+		}
+	}
+	return measurementUnit{}, 0, false
+}
+
+// separate reports whether text[start:end] runs into neither neighbouring word. Only
+// digits and Latin letters join words; other symbols and scripts may touch them, as in
+// "5元的" or "€5abc".
+func separate(text string, start, end int) bool {
+	if first, _ := utf8.DecodeRuneInString(text[start:]); latinWordRune(first) {
+		if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+			return false
+		}
+	}
+	return !runsOn(text, end)
+}
+
+// runsOn reports whether the text ending at end runs into the word that follows.
+func runsOn(text string, end int) bool {
+	if last, _ := utf8.DecodeLastRuneInString(text[:end]); latinWordRune(last) {
+		if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+			return true
+		}
+	}
+	return false
+}
+
+func latinWordRune(r rune) bool {
+	return r >= '0' && r <= '9' || unicode.Is(unicode.Latin, r)
+}
@@ -0,0 +1,48 @@
+package kreuzberg
+
+import "testing"
+
+func TestDetectAmounts(t *testing.T) {
+	result := &ExtractionResult{Content: "Total: 1.234,50 € netto, Versand EUR 12, 25 kg je Palette, 3,5m², Rabatt -$5."}
+	amounts := DetectAmounts(result, &AmountDetectionConfig{Locale: "de-DE"})
+	want := []Amount{
+		{Kind: AmountMoney, Text: "1.234,50 €", Value: "1234.5", Currency: "EUR"},
+		{Kind: AmountMoney, Text: "EUR 12", Value: "12", Currency: "EUR"},
+		{Kind: AmountMeasurement, Text: "25 kg", Value: "25", Unit: "kg", Quantity: "mass"},
+		{Kind: AmountMeasurement, Text: "3,5m²", Value: "3.5", Unit: "m2", Quantity: "area"},
+		{Kind: AmountMoney, Text: "-$5", Value: "-5", Currency: "USD"},
+	}
+	if len(amounts) != len(want) {
+		t.Fatalf("DetectAmounts = %+v", amounts)
+	}
+	for i, w := range want {
+		got := amounts[i]
+		if result.Content[got.ByteStart:got.ByteEnd] != got.Text {
+			t.Fatalf("amount %d offsets do not delimit %q", i, got.Text)
+		}
+		got.ByteStart, got.ByteEnd = 0, 0
+		if got != w {
+			t.Fatalf("amount %d = %+v, want %+v", i, got, w)
+		}
+	}
+
+	result.Content = "Paid C$40 and 12 in 3 boxes; 100 kr in Oslo."
+	amounts = DetectAmounts(result, &AmountDetectionConfig{Locale: "nb-NO", Kinds: []string{AmountMoney}})
+	if len(amounts) != 2 || amounts[0].Currency != "CAD" || amounts[1].Currency != "NOK" {
+		t.Fatalf("DetectAmounts = %+v", amounts)
+	}
+}
+
+func TestAmountDetectionStage(t *testing.T) {
+	config := NewExtractionConfig(WithAmountDetection("", AmountMeasurement))
+	result := &ExtractionResult{Content: "Weight 2,000.5 lbs, price $99", DetectedLanguages: []string{"eng"}}
+	if err := runResultStages(result, config); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Amounts) != 1 || result.Amounts[0].Value != "2000.5" || result.Amounts[0].Unit != "lb" {
+		t.Fatalf("Amounts = %+v", result.Amounts)
+	}
+	if err := validateResultStages(NewExtractionConfig(WithAmountDetection("", "percent"))); err == nil {
+		t.Fatal("expected an unknown kind to be rejected")
+	}
+}
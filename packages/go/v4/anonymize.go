@@ -0,0 +1,266 @@
+package kreuzberg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// AnonymizationMode selects what Anonymize does with identifying metadata.
+type AnonymizationMode string
+
+const (
+	// AnonymizeStrip removes identifying values.
+	AnonymizeStrip AnonymizationMode = "strip"
+	// AnonymizeHash replaces each identifying value with "anon-" and 16 hex digits of its
+	// HMAC-SHA256 keyed with AnonymizationConfig.Salt, so that documents by the same
+	// author can still be grouped without revealing who it is.
+	AnonymizeHash AnonymizationMode = "hash"
+)
+
+// AnonymizationConfig removes the metadata identifying the people, organizations,
+// software and places behind a document before the result is returned, for datasets
+// shared outside the organization: authors and last editors, creating and producing
+// software, email addresses and meeting participants, camera identifiers, GPS positions,
+// DICOM patient and staff identifiers, and the additional fields the core reports for
+// them, including the authors of tracked changes and comments. Positions are always
+// removed. Content is not changed; see DicomConfig.Anonymize for DICOM reports.
+type AnonymizationConfig struct {
+	// Mode is AnonymizeStrip or AnonymizeHash. Default: AnonymizeStrip.
+	Mode AnonymizationMode `json:"mode,omitempty"`
+	// Salt keys the hashes of AnonymizeHash. Keep it secret: without it, hashes of
+	// guessable values such as names can be recovered by hashing candidates.
+	Salt string `json:"salt,omitempty"`
+}
+
+func validateAnonymizationConfig(cfg *AnonymizationConfig) error {
+	switch cfg.Mode {
+	case "", AnonymizeStrip, AnonymizeHash:
+		return nil
+	}
+	return newValidationErrorWithContext(fmt.Sprintf("unknown anonymization mode %q", cfg.Mode), nil, ErrorCodeValidation, nil)
+}
+
+// identifyingAdditionalFields are the additional metadata fields anonymization removes
+// or hashes.
+var identifyingAdditionalFields = map[string]bool{
+	"author": true, "authors": true, "creator": true, "created_by": true, "modified_by": true,
+	"last_modified_by": true, "producer": true, "application": true, "generator": true,
+	"organization": true, "company": true, "manager": true, "template": true,
+	"tracked_change_authors": true, "comment_authors": true, "revision_authors": true,
+}
+
+// identifyingExifTags are the EXIF tags naming the owner or the device of a photo. Tags
+// starting with "GPS" are removed in every mode.
+var identifyingExifTags = map[string]bool{
+	"Artist": true, "Copyright": true, "Make": true, "Model": true, "Software": true,
+	"HostComputer": true, "BodySerialNumber": true, "SerialNumber": true,
+	"CameraOwnerName": true, "OwnerName": true, "LensMake": true, "LensModel": true,
+	"LensSerialNumber": true, "ImageUniqueID": true, "XPAuthor": true,
+}
+
+// Anonymize removes or hashes the identifying metadata of result and of its embedded
+// documents, in place. See AnonymizationConfig. A nil cfg strips.
+func Anonymize(result *ExtractionResult, cfg *AnonymizationConfig) {
+	if result != nil {
+		newAnonymizer(cfg).result(result)
+	}
+}
+
+// applyAnonymization anonymizes the metadata of result but not of its embedded documents,
+// which were extracted with the same configuration and are anonymized already.
+func applyAnonymization(result *ExtractionResult, cfg *AnonymizationConfig) {
+	newAnonymizer(cfg).metadata(&result.Metadata)
+}
+
+type anonymizer struct {
+	hash bool
+	salt []byte
+}
+
+func newAnonymizer(cfg *AnonymizationConfig) anonymizer {
+	if cfg != nil && cfg.Mode == AnonymizeHash {
+		return anonymizer{hash: true, salt: []byte(cfg.Salt)}
+	}
+	return anonymizer{}
+}
+
+func (a anonymizer) result(result *ExtractionResult) {
+	a.metadata(&result.Metadata)
+	for i := range result.EmbeddedDocuments {
+		if nested := result.EmbeddedDocuments[i].Result; nested != nil {
+			a.result(nested)
+		}
+	}
+}
+
+func (a anonymizer) metadata(m *Metadata) {
+	m.materialize()
+	format := &m.Format
+	if pdf := format.Pdf; pdf != nil {
+		pdf.Authors = a.list(pdf.Authors)
+		pdf.CreatedBy = a.pointer(pdf.CreatedBy)
+		pdf.Producer = a.pointer(pdf.Producer)
+	}
+	if email := format.Email; email != nil {
+		email.FromEmail = a.pointer(email.FromEmail)
+		email.FromName = a.pointer(email.FromName)
+		email.ToEmails = a.list(email.ToEmails)
+		email.CcEmails = a.list(email.CcEmails)
+		email.BccEmails = a.list(email.BccEmails)
+		email.MessageID = a.pointer(email.MessageID)
+		for i := range email.Meetings {
+			meeting := &email.Meetings[i]
+			if meeting.Organizer != nil {
+				a.attendee(meeting.Organizer)
+			}
+			for j := range meeting.Attendees {
+				a.attendee(&meeting.Attendees[j])
+			}
+		}
+	}
+	if image := format.Image; image != nil {
+		image.CameraMake = a.text(image.CameraMake)
+		image.CameraModel = a.text(image.CameraModel)
+		image.GPS = nil
+		for tag, value := range image.EXIF {
+			switch {
+			case strings.HasPrefix(tag, "GPS"):
+				delete(image.EXIF, tag)
+			case identifyingExifTags[tag] && a.hash:
+				image.EXIF[tag] = a.text(value)
+			case identifyingExifTags[tag]:
+				delete(image.EXIF, tag)
+			}
+		}
+	}
+	if html := format.HTML; html != nil {
+		html.Author = a.pointer(html.Author)
+		a.stringMap(html.MetaTags, func(key string) bool {
+			return key == "author" || key == "generator" || key == "creator"
+		})
+		a.stringMap(html.TwitterCard, func(key string) bool {
+			return strings.TrimPrefix(key, "twitter:") == "creator"
+		})
+	}
+	if pptx := format.Pptx; pptx != nil {
+		pptx.Author = a.pointer(pptx.Author)
+	}
+	if dicom := format.DICOM; dicom != nil {
+		dicom.PatientName = a.text(dicom.PatientName)
+		dicom.PatientID = a.text(dicom.PatientID)
+		dicom.PatientBirthDate = a.text(dicom.PatientBirthDate)
+		dicom.AccessionNumber = a.text(dicom.AccessionNumber)
+		dicom.InstitutionName = a.text(dicom.InstitutionName)
+		dicom.ReferringPhysicianName = a.text(dicom.ReferringPhysicianName)
+	}
+	if rtf := format.RTF; rtf != nil {
+		rtf.Authors = a.list(rtf.Authors)
+		rtf.CreatedBy = a.text(rtf.CreatedBy)
+		rtf.ModifiedBy = a.text(rtf.ModifiedBy)
+		rtf.Generator = a.text(rtf.Generator)
+	}
+	if office := format.LegacyOffice; office != nil {
+		office.Author = a.text(office.Author)
+		office.LastAuthor = a.text(office.LastAuthor)
+		office.Application = a.text(office.Application)
+		office.Template = a.text(office.Template)
+	}
+	if processor := format.WordProcessor; processor != nil {
+		processor.Application = a.text(processor.Application)
+	}
+	if book := format.FictionBook; book != nil {
+		book.Authors = a.list(book.Authors)
+	}
+	if djvu := format.DjVu; djvu != nil {
+		djvu.Author = a.text(djvu.Author)
+	}
+	if xps := format.XPS; xps != nil {
+		xps.Creator = a.text(xps.Creator)
+		xps.LastModifiedBy = a.text(xps.LastModifiedBy)
+	}
+
+	for key, raw := range m.Additional {
+		if !identifyingAdditionalFields[key] {
+			continue
+		}
+		if replaced, ok := a.rawJSON(raw); ok {
+			m.Additional[key] = replaced
+		} else {
+			delete(m.Additional, key)
+		}
+	}
+}
+
+// text returns the hash of value, or "" when stripping.
+func (a anonymizer) text(value string) string {
+	if value == "" || !a.hash {
+		return ""
+	}
+	mac := hmac.New(sha256.New, a.salt)
+	mac.Write([]byte(value))
+	return "anon-" + hex.EncodeToString(mac.Sum(nil)[:8])
+}
+
+func (a anonymizer) pointer(value *string) *string {
+	if value == nil || !a.hash {
+		return nil
+	}
+	hashed := a.text(*value)
+	return &hashed
+}
+
+// list hashes values, or empties the list when stripping.
+func (a anonymizer) list(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	hashed := make([]string, 0, len(values))
+	if a.hash {
+		for _, value := range values {
+			hashed = append(hashed, a.text(value))
+		}
+	}
+	return hashed
+}
+
+func (a anonymizer) attendee(attendee *CalendarAttendee) {
+	attendee.Name = a.text(attendee.Name)
+	attendee.Email = a.text(attendee.Email)
+}
+
+// stringMap removes or hashes the entries of values whose key is identifying.
+func (a anonymizer) stringMap(values map[string]string, identifying func(key string) bool) {
+	for key, value := range values {
+		if !identifying(strings.ToLower(key)) {
+			continue
+		}
+		if a.hash {
+			values[key] = a.text(value)
+		} else {
+			delete(values, key)
+		}
+	}
+}
+
+// rawJSON hashes a string or a list of strings, reporting false for any other value or
+// when stripping.
+func (a anonymizer) rawJSON(raw json.RawMessage) (json.RawMessage, bool) {
+	if !a.hash {
+		return nil, false
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		hashed, _ := json.Marshal(a.text(value))
+		return hashed, true
+	}
+	var values []string
+	if err := json.Unmarshal(raw, &values); err == nil {
+		hashed, _ := json.Marshal(a.list(values))
+		return hashed, true
+	}
+	return nil, false
+}
@@ -0,0 +1,83 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func anonymizationTestResult() *ExtractionResult {
+	author := "Jane Doe"
+	result := &ExtractionResult{Content: "text"}
+	result.Metadata.Format = FormatMetadata{Type: FormatPDF, Pdf: &PdfMetadata{
+		Authors:   []string{author},
+		CreatedBy: &author,
+		Producer:  StringPtr("Acme PDF 3.1"),
+		Title:     StringPtr("Quarterly report"),
+	}}
+	result.Metadata.Additional = map[string]json.RawMessage{
+		"tracked_change_authors": json.RawMessage(`["Jane Doe","John Roe"]`),
+		"company":                json.RawMessage(`"Acme"`),
+		"category":               json.RawMessage(`"finance"`),
+	}
+	photo := &ExtractionResult{}
+	photo.Metadata.Format = FormatMetadata{Type: FormatImage, Image: &ImageMetadata{
+		CameraMake: "Canon",
+		GPS:        &GPSCoordinates{Latitude: 52.5, Longitude: 13.4},
+		EXIF:       map[string]string{"GPSLatitude": "52.5", "Artist": "Jane Doe", "ExposureTime": "1/60"},
+	}}
+	result.EmbeddedDocuments = []EmbeddedDocument{{Name: "photo.jpg", Result: photo}}
+	return result
+}
+
+func TestAnonymizeStrip(t *testing.T) {
+	result := anonymizationTestResult()
+	Anonymize(result, nil)
+
+	pdf := result.Metadata.Format.Pdf
+	if len(pdf.Authors) != 0 || pdf.CreatedBy != nil || pdf.Producer != nil {
+		t.Fatalf("identifying PDF metadata kept: %+v", pdf)
+	}
+	if pdf.Title == nil || *pdf.Title != "Quarterly report" {
+		t.Fatal("the title should be kept")
+	}
+	if _, ok := result.Metadata.Additional["tracked_change_authors"]; ok {
+		t.Fatal("tracked change authors kept")
+	}
+	if _, ok := result.Metadata.Additional["category"]; !ok {
+		t.Fatal("non-identifying additional field removed")
+	}
+	image := result.EmbeddedDocuments[0].Result.Metadata.Format.Image
+	if image.GPS != nil || image.CameraMake != "" || len(image.EXIF) != 1 || image.EXIF["ExposureTime"] != "1/60" {
+		t.Fatalf("embedded photo not anonymized: %+v", image)
+	}
+}
+
+func TestAnonymizeHashStage(t *testing.T) {
+	config := NewExtractionConfig(WithAnonymization(AnonymizeHash, "secret"))
+	result := anonymizationTestResult()
+	if err := runResultStages(result, config); err != nil {
+		t.Fatal(err)
+	}
+	pdf := result.Metadata.Format.Pdf
+	if len(pdf.Authors) != 1 || !strings.HasPrefix(pdf.Authors[0], "anon-") || *pdf.CreatedBy != pdf.Authors[0] {
+		t.Fatalf("the same author should hash to the same value: %+v", pdf)
+	}
+	var editors []string
+	if err := json.Unmarshal(result.Metadata.Additional["tracked_change_authors"], &editors); err != nil {
+		t.Fatal(err)
+	}
+	if len(editors) != 2 || editors[0] != pdf.Authors[0] || editors[1] == editors[0] {
+		t.Fatalf("unexpected tracked change authors %v", editors)
+	}
+
+	other := anonymizationTestResult()
+	Anonymize(other, &AnonymizationConfig{Mode: AnonymizeHash, Salt: "another secret"})
+	if other.Metadata.Format.Pdf.Authors[0] == pdf.Authors[0] {
+		t.Fatal("hashes should depend on the salt")
+	}
+
+	if err := validateResultStages(&ExtractionConfig{Anonymization: &AnonymizationConfig{Mode: "blur"}}); err == nil {
+		t.Fatal("expected an unknown mode to be rejected")
+	}
+}
@@ -0,0 +1,558 @@
+package kreuzberg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ArrowBatches holds the tables and chunks of a result in the Arrow IPC streaming format,
+// which pyarrow (pyarrow.ipc.open_stream), Polars (read_ipc_stream), DuckDB and the Arrow
+// libraries read without copying the column buffers.
+type ArrowBatches struct {
+	// Tables holds one stream per table of the result, in order, each with one record
+	// batch. Columns are named after the table's first row and typed int64, float64, bool
+	// or utf8 from their cells; empty cells are null. The page number is in the schema
+	// metadata under kreuzberg.page_number.
+	Tables [][]byte
+	// Chunks is a stream with one record batch of the chunks, nil when the result has none.
+	// Its columns are content, byte_start, byte_end, chunk_index, token_count, first_page,
+	// last_page and, when chunks were embedded, embedding: a fixed-size list of float32
+	// when every chunk has an embedding of the same dimension, otherwise a list.
+	Chunks []byte
+}
+
+// ToArrow encodes the tables and chunks of the result as Arrow record batches. The chunks
+// of a paged result are read back.
+func (r *ExtractionResult) ToArrow() (*ArrowBatches, error) {
+	if r == nil {
+		return nil, newValidationErrorWithContext("result is nil", nil, ErrorCodeValidation, nil)
+	}
+	batches := &ArrowBatches{}
+	for i, table := range r.Tables {
+		columns, rows, err := arrowTableColumns(table)
+		if err != nil {
+			return nil, newSerializationErrorWithContext(fmt.Sprintf("failed to encode table %d as Arrow", i), err, ErrorCodeValidation, nil)
+		}
+		metadata := [][2]string{{"kreuzberg.page_number", strconv.Itoa(table.PageNumber)}}
+		batches.Tables = append(batches.Tables, arrowStream(columns, rows, metadata))
+	}
+	chunks, err := pagedChunks(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) > 0 {
+		columns, err := arrowChunkColumns(chunks)
+		if err != nil {
+			return nil, newSerializationErrorWithContext("failed to encode chunks as Arrow", err, ErrorCodeValidation, nil)
+		}
+		batches.Chunks = arrowStream(columns, len(chunks), nil)
+	}
+	return batches, nil
+}
+
+// Arrow type and message header identifiers, from Schema.fbs and Message.fbs.
+const (
+	arrowTypeInt           = 2
+	arrowTypeFloatingPoint = 3
+	arrowTypeUtf8          = 5
+	arrowTypeBool          = 6
+	arrowTypeList          = 12
+	arrowTypeFixedSizeList = 16
+
+	arrowHeaderSchema      = 1
+	arrowHeaderRecordBatch = 3
+
+	arrowMetadataV5 = 4
+
+	arrowPrecisionSingle = 1
+	arrowPrecisionDouble = 2
+)
+
+var errArrowOffsetOverflow = errors.New("column data exceeds 2 GiB")
+
+// arrowColumn is a column of a record batch: its schema field and its buffers.
+type arrowColumn struct {
+	name     string
+	nullable bool
+	typeID   byte
+	typ      fbTable
+	length   int
+	nulls    int
+	validity []byte
+	buffers  [][]byte
+	children []arrowColumn
+}
+
+// arrowValidity returns the validity bitmap of valid and its null count, or no bitmap
+// when every value is valid. A nil valid means every value is.
+func arrowValidity(valid []bool) ([]byte, int) {
+	bitmap := make([]byte, (len(valid)+7)/8)
+	nulls := 0
+	for i, ok := range valid {
+		if ok {
+			bitmap[i/8] |= 1 << (i % 8)
+		} else {
+			nulls++
+		}
+	}
+	if nulls == 0 {
+		return nil, 0
+	}
+	return bitmap, nulls
+}
+
+func newArrowColumn(name string, typeID byte, typ fbTable, length int, valid []bool, buffers ...[]byte) arrowColumn {
+	validity, nulls := arrowValidity(valid)
+	return arrowColumn{
+		name:     name,
+		nullable: valid != nil,
+		typeID:   typeID,
+		typ:      typ,
+		length:   length,
+		nulls:    nulls,
+		validity: validity,
+		buffers:  buffers,
+	}
+}
+
+func arrowIntType(signed bool) fbTable {
+	isSigned := uint64(0)
+	if signed {
+		isSigned = 1
+	}
+	return fbTable{fbScalar(4, 64), fbScalar(1, isSigned)}
+}
+
+// arrowInt64Column encodes 64-bit integers, signed or not, given as their bits.
+func arrowInt64Column(name string, values []uint64, valid []bool, signed bool) arrowColumn {
+	data := make([]byte, 8*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint64(data[8*i:], value)
+	}
+	return newArrowColumn(name, arrowTypeInt, arrowIntType(signed), len(values), valid, data)
+}
+
+func arrowFloat64Column(name string, values []float64, valid []bool) arrowColumn {
+	data := make([]byte, 8*len(values))
+	for i, value := range values {
+		binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(value))
+	}
+	return newArrowColumn(name, arrowTypeFloatingPoint, fbTable{fbScalar(2, arrowPrecisionDouble)}, len(values), valid, data)
+}
+
+func arrowBoolColumn(name string, values []bool, valid []bool) arrowColumn {
+	data := make([]byte, (len(values)+7)/8)
+	for i, value := range values {
+		if value {
+			data[i/8] |= 1 << (i % 8)
+		}
+	}
+	return newArrowColumn(name, arrowTypeBool, fbTable{}, len(values), valid, data)
+}
+
+func arrowUtf8Column(name string, values []string, valid []bool) (arrowColumn, error) {
+	offsets := make([]byte, 4*(len(values)+1))
+	var data []byte
+	for i, value := range values {
+		data = append(data, value...)
+		if len(data) > math.MaxInt32 {
+			return arrowColumn{}, errArrowOffsetOverflow
+		}
+		binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(data)))
+	}
+	return newArrowColumn(name, arrowTypeUtf8, fbTable{}, len(values), valid, offsets, data), nil
+}
+
+// arrowTableColumns types the columns of a table from its cells, naming them after its
+// first row, and returns them with the number of rows.
+func arrowTableColumns(table Table) ([]arrowColumn, int, error) {
+	if len(table.Cells) == 0 {
+		return nil, 0, nil
+	}
+	width := 0
+	for _, row := range table.Cells {
+		width = max(width, len(row))
+	}
+	rows := table.Cells[1:]
+	names := arrowColumnNames(table.Cells[0], width)
+	columns := make([]arrowColumn, 0, width)
+	for j := 0; j < width; j++ {
+		values := make([]string, len(rows))
+		valid := make([]bool, len(rows))
+		for i, row := range rows {
+			if j < len(row) {
+				values[i] = strings.TrimSpace(row[j])
+				valid[i] = values[i] != ""
+			}
+		}
+		column, err := arrowTypedColumn(names[j], values, valid)
+		if err != nil {
+			return nil, 0, err
+		}
+		columns = append(columns, column)
+	}
+	return columns, len(rows), nil
+}
+
+// arrowColumnNames names columns after the header cells, numbering those without a name
+// and suffixing repeated ones.
+func arrowColumnNames(header []string, width int) []string {
+	names := make([]string, width)
+	seen := map[string]int{}
+	for j := range names {
+		name := ""
+		if j < len(header) {
+			name = strings.TrimSpace(header[j])
+		}
+		if name == "" {
+			name = fmt.Sprintf("column_%d", j+1)
+		}
+		seen[name]++
+		if seen[name] > 1 {
+			name = fmt.Sprintf("%s_%d", name, seen[name])
+		}
+		names[j] = name
+	}
+	return names
+}
+
+// arrowTypedColumn encodes the cells of a column as int64, float64 or bool when all its
+// non-empty cells parse as such, and as utf8 otherwise.
+func arrowTypedColumn(name string, values []string, valid []bool) (arrowColumn, error) {
+	ints := make([]uint64, len(values))
+	floats := make([]float64, len(values))
+	bools := make([]bool, len(values))
+	isInt, isFloat, isBool, present := true, true, true, false
+	for i, value := range values {
+		if !valid[i] {
+			continue
+		}
+		present = true
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil && isInt {
+			ints[i] = uint64(n)
+		} else {
+			isInt = false
+		}
+		// ParseFloat accepts words such as "inf" and "nan", which are text in a table.
+		if f, err := strconv.ParseFloat(value, 64); err == nil && isFloat && strings.ContainsAny(value, "0123456789") {
+			floats[i] = f
+		} else {
+			isFloat = false
+		}
+		if b, err := strconv.ParseBool(value); err == nil && isBool && (strings.EqualFold(value, "true") || strings.EqualFold(value, "false")) {
+			bools[i] = b
+		} else {
+			isBool = false
+		}
+	}
+	switch {
+	case !present:
+	case isInt:
+		return arrowInt64Column(name, ints, valid, true), nil
+	case isFloat:
+		return arrowFloat64Column(name, floats, valid), nil
+	case isBool:
+		return arrowBoolColumn(name, bools, valid), nil
+	}
+	return arrowUtf8Column(name, values, valid)
+}
+
+func arrowChunkColumns(chunks Chunks) ([]arrowColumn, error) {
+	n := len(chunks)
+	contents := make([]string, n)
+	byteStarts := make([]uint64, n)
+	byteEnds := make([]uint64, n)
+	indexes := make([]uint64, n)
+	tokenCounts, tokenValid := make([]uint64, n), make([]bool, n)
+	firstPages, firstValid := make([]uint64, n), make([]bool, n)
+	lastPages, lastValid := make([]uint64, n), make([]bool, n)
+	for i, chunk := range chunks {
+		contents[i] = chunk.Content
+		byteStarts[i] = chunk.Metadata.ByteStart
+		byteEnds[i] = chunk.Metadata.ByteEnd
+		indexes[i] = uint64(int64(chunk.Metadata.ChunkIndex))
+		if chunk.Metadata.TokenCount != nil {
+			tokenCounts[i], tokenValid[i] = uint64(int64(*chunk.Metadata.TokenCount)), true
+		}
+		if chunk.Metadata.FirstPage != nil {
+			firstPages[i], firstValid[i] = *chunk.Metadata.FirstPage, true
+		}
+		if chunk.Metadata.LastPage != nil {
+			lastPages[i], lastValid[i] = *chunk.Metadata.LastPage, true
+		}
+	}
+	content, err := arrowUtf8Column("content", contents, nil)
+	if err != nil {
+		return nil, err
+	}
+	columns := []arrowColumn{
+		content,
+		arrowInt64Column("byte_start", byteStarts, nil, false),
+		arrowInt64Column("byte_end", byteEnds, nil, false),
+		arrowInt64Column("chunk_index", indexes, nil, true),
+		arrowInt64Column("token_count", tokenCounts, tokenValid, true),
+		arrowInt64Column("first_page", firstPages, firstValid, false),
+		arrowInt64Column("last_page", lastPages, lastValid, false),
+	}
+	if embedding, ok := arrowEmbeddingColumn(chunks); ok {
+		columns = append(columns, embedding)
+	}
+	return columns, nil
+}
+
+// arrowEmbeddingColumn encodes the chunk embeddings as a fixed-size list of float32 when
+// they all have the same dimension, and as a list with nulls for the chunks without one
+// otherwise. It reports false when no chunk was embedded.
+func arrowEmbeddingColumn(chunks Chunks) (arrowColumn, bool) {
+	dimension, fixed, total := len(chunks[0].Embedding), true, 0
+	for _, chunk := range chunks {
+		fixed = fixed && len(chunk.Embedding) == dimension
+		total += len(chunk.Embedding)
+	}
+	if total == 0 {
+		return arrowColumn{}, false
+	}
+	values := make([]byte, 0, 4*total)
+	offsets := make([]byte, 4*(len(chunks)+1))
+	valid := make([]bool, len(chunks))
+	for i, chunk := range chunks {
+		for _, value := range chunk.Embedding {
+			values = binary.LittleEndian.AppendUint32(values, math.Float32bits(value))
+		}
+		binary.LittleEndian.PutUint32(offsets[4*(i+1):], uint32(len(values)/4))
+		valid[i] = chunk.Embedding != nil
+	}
+	item := newArrowColumn("item", arrowTypeFloatingPoint, fbTable{fbScalar(2, arrowPrecisionSingle)}, total, nil, values)
+	var column arrowColumn
+	if fixed {
+		column = newArrowColumn("embedding", arrowTypeFixedSizeList, fbTable{fbScalar(4, uint64(dimension))}, len(chunks), nil)
+	} else {
+		column = newArrowColumn("embedding", arrowTypeList, fbTable{}, len(chunks), valid, offsets)
+	}
+	column.children = []arrowColumn{item}
+	return column, true
+}
+
+// arrowStream encodes columns of length rows as an IPC stream: the schema, one record
+// batch and the end-of-stream marker.
+func arrowStream(columns []arrowColumn, length int, metadata [][2]string) []byte {
+	stream := arrowMessage(arrowHeaderSchema, func(b *fbBuilder) int {
+		schema := fbTable{
+			fbScalar(2, 0), // little-endian
+			fbRef(func(b *fbBuilder) int {
+				return b.tableVector(len(columns), func(i int) fbTable { return columns[i].field() })
+			}),
+			nil,
+		}
+		if len(metadata) > 0 {
+			schema[2] = fbRef(func(b *fbBuilder) int {
+				return b.tableVector(len(metadata), func(i int) fbTable {
+					return fbTable{
+						fbRef(func(b *fbBuilder) int { return b.string(metadata[i][0]) }),
+						fbRef(func(b *fbBuilder) int { return b.string(metadata[i][1]) }),
+					}
+				})
+			})
+		}
+		return b.table(schema)
+	}, 0)
+
+	var nodes []byte
+	var buffers [][]byte
+	for i := range columns {
+		columns[i].collect(&nodes, &buffers)
+	}
+	var body, layout []byte
+	for _, buffer := range buffers {
+		layout = binary.LittleEndian.AppendUint64(layout, uint64(len(body)))
+		layout = binary.LittleEndian.AppendUint64(layout, uint64(len(buffer)))
+		body = append(body, buffer...)
+		body = append(body, make([]byte, padding(len(body), 8))...)
+	}
+	stream = append(stream, arrowMessage(arrowHeaderRecordBatch, func(b *fbBuilder) int {
+		return b.table(fbTable{
+			fbScalar(8, uint64(length)),
+			fbRef(func(b *fbBuilder) int { return b.structVector(16, nodes) }),
+			fbRef(func(b *fbBuilder) int { return b.structVector(16, layout) }),
+		})
+	}, len(body))...)
+	stream = append(stream, body...)
+	return append(stream, 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0)
+}
+
+// collect appends the field nodes and buffers of the column and its children, in the
+// depth-first order of the schema.
+func (c *arrowColumn) collect(nodes *[]byte, buffers *[][]byte) {
+	*nodes = binary.LittleEndian.AppendUint64(*nodes, uint64(c.length))
+	*nodes = binary.LittleEndian.AppendUint64(*nodes, uint64(c.nulls))
+	*buffers = append(*buffers, c.validity)
+	*buffers = append(*buffers, c.buffers...)
+	for i := range c.children {
+		c.children[i].collect(nodes, buffers)
+	}
+}
+
+// field returns the schema Field table of the column.
+func (c *arrowColumn) field() fbTable {
+	nullable := uint64(0)
+	if c.nullable {
+		nullable = 1
+	}
+	return fbTable{
+		fbRef(func(b *fbBuilder) int { return b.string(c.name) }),
+		fbScalar(1, nullable),
+		fbScalar(1, uint64(c.typeID)),
+		fbRef(func(b *fbBuilder) int { return b.table(c.typ) }),
+		nil, // dictionary
+		fbRef(func(b *fbBuilder) int {
+			return b.tableVector(len(c.children), func(i int) fbTable { return c.children[i].field() })
+		}),
+	}
+}
+
+// arrowMessage encapsulates a Message with the given header and body length: the
+// continuation marker, the metadata length and the metadata padded to 8 bytes. The body
+// follows it in the stream.
+func arrowMessage(headerType byte, header func(*fbBuilder) int, bodyLength int) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	root := b.table(fbTable{
+		fbScalar(2, arrowMetadataV5),
+		fbScalar(1, uint64(headerType)),
+		fbRef(header),
+		fbScalar(8, uint64(bodyLength)),
+	})
+	binary.LittleEndian.PutUint32(b.buf, uint32(root))
+	b.pad(8, 0)
+
+	out := make([]byte, 8, 8+len(b.buf))
+	binary.LittleEndian.PutUint32(out, 0xffffffff)
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(b.buf)))
+	return append(out, b.buf...)
+}
+
+func padding(n, align int) int {
+	return (align - n%align) % align
+}
+
+// fbBuilder writes FlatBuffers front to back: a table is followed by the objects it
+// references, so every offset points forward as the format requires. Positions are
+// relative to the start of the buffer, which must be 8-byte aligned where it is read.
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbField is a field of a FlatBuffers table: a scalar of size bytes, or an offset to the
+// object ref writes.
+type fbField struct {
+	size  int
+	value uint64
+	ref   func(*fbBuilder) int
+}
+
+// fbTable lists the fields of a table by id; a nil field is absent.
+type fbTable []*fbField
+
+func fbScalar(size int, value uint64) *fbField {
+	return &fbField{size: size, value: value}
+}
+
+func fbRef(ref func(*fbBuilder) int) *fbField {
+	return &fbField{size: 4, ref: ref}
+}
+
+// pad aligns the end of the buffer so that extra more bytes end on an align boundary.
+func (b *fbBuilder) pad(align, extra int) {
+	b.buf = append(b.buf, make([]byte, padding(len(b.buf)+extra, align))...)
+}
+
+// table writes a vtable, the table right after it and then the objects it references, and
+// returns the position of the table.
+func (b *fbBuilder) table(fields fbTable) int {
+	order := make([]int, 0, len(fields))
+	for i, field := range fields {
+		if field != nil {
+			order = append(order, i)
+		}
+	}
+	// Largest fields first keeps every field aligned without padding between them.
+	sort.SliceStable(order, func(x, y int) bool { return fields[order[x]].size > fields[order[y]].size })
+	offsets := make([]int, len(fields))
+	size, align := 4, 4
+	for _, i := range order {
+		size += padding(size, fields[i].size)
+		offsets[i] = size
+		size += fields[i].size
+		align = max(align, fields[i].size)
+	}
+
+	vtableSize := 4 + 2*len(fields)
+	b.pad(align, vtableSize)
+	vtable := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(vtableSize))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(size))
+	for _, offset := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(offset))
+	}
+	start := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[start:], uint32(int32(start-vtable)))
+	for _, i := range order {
+		at := b.buf[start+offsets[i]:]
+		switch fields[i].size {
+		case 1:
+			at[0] = byte(fields[i].value)
+		case 2:
+			binary.LittleEndian.PutUint16(at, uint16(fields[i].value))
+		case 4:
+			binary.LittleEndian.PutUint32(at, uint32(fields[i].value))
+		case 8:
+			binary.LittleEndian.PutUint64(at, fields[i].value)
+		}
+	}
+	for i, field := range fields {
+		if field != nil && field.ref != nil {
+			slot := start + offsets[i]
+			// The object is written first: writing it may move the buffer.
+			target := field.ref(b)
+			binary.LittleEndian.PutUint32(b.buf[slot:], uint32(target-slot))
+		}
+	}
+	return start
+}
+
+func (b *fbBuilder) string(s string) int {
+	b.pad(4, 0)
+	start := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return start
+}
+
+// tableVector writes a vector of n tables and then the tables.
+func (b *fbBuilder) tableVector(n int, table func(int) fbTable) int {
+	b.pad(4, 0)
+	start := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(n))
+	b.buf = append(b.buf, make([]byte, 4*n)...)
+	for i := 0; i < n; i++ {
+		slot := start + 4 + 4*i
+		target := b.table(table(i))
+		binary.LittleEndian.PutUint32(b.buf[slot:], uint32(target-slot))
+	}
+	return start
+}
+
+// structVector writes a vector of 8-byte aligned structs of size bytes, encoded in data.
+func (b *fbBuilder) structVector(size int, data []byte) int {
+	b.pad(8, 4)
+	start := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(data)/size))
+	b.buf = append(b.buf, data...)
+	return start
+}
@@ -0,0 +1,201 @@
+package kreuzberg
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// fbRead reads the FlatBuffers tables written by fbBuilder.
+type fbRead struct {
+	buf []byte
+	pos int
+}
+
+func (t fbRead) offset(field int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if 4+2*field >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	return int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*field:]))
+}
+
+func (t fbRead) scalar(field, size int) uint64 {
+	at := t.offset(field)
+	if at == 0 {
+		return 0
+	}
+	value := uint64(0)
+	for i := size - 1; i >= 0; i-- {
+		value = value<<8 | uint64(t.buf[t.pos+at+i])
+	}
+	return value
+}
+
+func (t fbRead) ref(field int) int {
+	slot := t.pos + t.offset(field)
+	return slot + int(binary.LittleEndian.Uint32(t.buf[slot:]))
+}
+
+func (t fbRead) table(field int) fbRead {
+	return fbRead{t.buf, t.ref(field)}
+}
+
+func (t fbRead) vector(field int) (int, int) {
+	start := t.ref(field)
+	return int(binary.LittleEndian.Uint32(t.buf[start:])), start + 4
+}
+
+func (t fbRead) tables(field int) []fbRead {
+	n, start := t.vector(field)
+	out := make([]fbRead, n)
+	for i := range out {
+		slot := start + 4*i
+		out[i] = fbRead{t.buf, slot + int(binary.LittleEndian.Uint32(t.buf[slot:]))}
+	}
+	return out
+}
+
+func (t fbRead) string(field int) string {
+	n, start := t.vector(field)
+	return string(t.buf[start : start+n])
+}
+
+type arrowMessageRead struct {
+	header     fbRead
+	headerType byte
+	body       []byte
+}
+
+func readArrowStream(t *testing.T, stream []byte) []arrowMessageRead {
+	t.Helper()
+	var messages []arrowMessageRead
+	for {
+		if binary.LittleEndian.Uint32(stream) != 0xffffffff {
+			t.Fatal("expected a continuation marker")
+		}
+		size := int(binary.LittleEndian.Uint32(stream[4:]))
+		if size == 0 {
+			if len(stream) != 8 {
+				t.Fatalf("%d bytes after the end of the stream", len(stream)-8)
+			}
+			return messages
+		}
+		if size%8 != 0 {
+			t.Fatalf("metadata of %d bytes is not padded", size)
+		}
+		metadata := stream[8 : 8+size]
+		message := fbRead{metadata, int(binary.LittleEndian.Uint32(metadata))}
+		if version := message.scalar(0, 2); version != arrowMetadataV5 {
+			t.Fatalf("expected metadata version V5, got %d", version)
+		}
+		bodyLength := int(message.scalar(3, 8))
+		messages = append(messages, arrowMessageRead{
+			header:     message.table(2),
+			headerType: byte(message.scalar(1, 1)),
+			body:       stream[8+size : 8+size+bodyLength],
+		})
+		stream = stream[8+size+bodyLength:]
+	}
+}
+
+func TestToArrowTypesTableColumns(t *testing.T) {
+	result := &ExtractionResult{Tables: []Table{{
+		Cells: [][]string{
+			{"Item", "Qty", "Price", "Paid", "Qty"},
+			{"Pen", "3", "1.50", "true", ""},
+			{"Ink", "", "12", "false", "nan"},
+		},
+		PageNumber: 2,
+	}}}
+
+	batches, err := result.ToArrow()
+	if err != nil {
+		t.Fatalf("ToArrow: %v", err)
+	}
+	if len(batches.Tables) != 1 || batches.Chunks != nil {
+		t.Fatalf("expected one table stream and no chunks, got %d and %v", len(batches.Tables), batches.Chunks)
+	}
+	messages := readArrowStream(t, batches.Tables[0])
+	if len(messages) != 2 || messages[0].headerType != arrowHeaderSchema || messages[1].headerType != arrowHeaderRecordBatch {
+		t.Fatalf("expected a schema and a record batch, got %+v", messages)
+	}
+
+	var names []string
+	var types []byte
+	for _, field := range messages[0].header.tables(1) {
+		names = append(names, field.string(0))
+		types = append(types, byte(field.scalar(2, 1)))
+	}
+	if !reflect.DeepEqual(names, []string{"Item", "Qty", "Price", "Paid", "Qty_2"}) {
+		t.Fatalf("unexpected column names %v", names)
+	}
+	expected := []byte{arrowTypeUtf8, arrowTypeInt, arrowTypeFloatingPoint, arrowTypeBool, arrowTypeUtf8}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("expected column types %v, got %v", expected, types)
+	}
+	metadata := messages[0].header.tables(2)
+	if len(metadata) != 1 || metadata[0].string(0) != "kreuzberg.page_number" || metadata[0].string(1) != "2" {
+		t.Fatal("expected the page number in the schema metadata")
+	}
+
+	batch := messages[1].header
+	if rows := batch.scalar(0, 8); rows != 2 {
+		t.Fatalf("expected 2 rows, got %d", rows)
+	}
+	nodes, nodesAt := batch.vector(1)
+	if nodes != 5 {
+		t.Fatalf("expected 5 field nodes, got %d", nodes)
+	}
+	if qtyNulls := binary.LittleEndian.Uint64(batch.buf[nodesAt+16+8:]); qtyNulls != 1 {
+		t.Fatalf("expected one null quantity, got %d", qtyNulls)
+	}
+	// Buffers: Item validity, offsets and data, then Qty validity and values.
+	_, buffersAt := batch.vector(2)
+	qty := buffersAt + 4*16
+	offset := binary.LittleEndian.Uint64(batch.buf[qty:])
+	if offset%8 != 0 {
+		t.Fatalf("buffer at offset %d is not aligned", offset)
+	}
+	if first := int64(binary.LittleEndian.Uint64(messages[1].body[offset:])); first != 3 {
+		t.Fatalf("expected the first quantity to be 3, got %d", first)
+	}
+}
+
+func TestToArrowEncodesChunkEmbeddings(t *testing.T) {
+	page := uint64(1)
+	result := &ExtractionResult{Chunks: Chunks{
+		{Content: "first", Embedding: []float32{0.5, 1}, Metadata: ChunkMetadata{ByteEnd: 5, FirstPage: &page}},
+		{Content: "second", Embedding: []float32{2, 4}, Metadata: ChunkMetadata{ByteStart: 6, ByteEnd: 12, ChunkIndex: 1}},
+	}}
+
+	batches, err := result.ToArrow()
+	if err != nil {
+		t.Fatalf("ToArrow: %v", err)
+	}
+	messages := readArrowStream(t, batches.Chunks)
+	fields := messages[0].header.tables(1)
+	embedding := fields[len(fields)-1]
+	if embedding.string(0) != "embedding" || byte(embedding.scalar(2, 1)) != arrowTypeFixedSizeList {
+		t.Fatalf("expected a fixed-size list embedding column, got %s", embedding.string(0))
+	}
+	if size := embedding.table(3).scalar(0, 4); size != 2 {
+		t.Fatalf("expected a list size of 2, got %d", size)
+	}
+	if children := embedding.tables(5); len(children) != 1 || children[0].table(3).scalar(0, 2) != arrowPrecisionSingle {
+		t.Fatal("expected float32 list items")
+	}
+
+	batch := messages[1].header
+	buffers, buffersAt := batch.vector(2)
+	last := buffersAt + 16*(buffers-1)
+	offset := binary.LittleEndian.Uint64(batch.buf[last:])
+	length := binary.LittleEndian.Uint64(batch.buf[last+8:])
+	if length != 16 {
+		t.Fatalf("expected 4 embedding values, got %d bytes", length)
+	}
+	if value := math.Float32frombits(binary.LittleEndian.Uint32(messages[1].body[offset+12:])); value != 4 {
+		t.Fatalf("expected the last embedding value to be 4, got %v", value)
+	}
+}
@@ -730,3 +730,46 @@ func TestBatchDataIntegrity(t *testing.T) {
 		t.Fatalf("expected %d results, got %d", len(items), len(results))
 	}
 }
+
+// TestBatchExtractBytesBindingFormats tests that batches extract the formats the binding
+// handles itself as single extractions do.
+func TestBatchExtractBytesBindingFormats(t *testing.T) {
+	data := []byte("2024-01-02 10:00:00 INFO started\n2024-01-02 10:00:01 ERROR failed\n")
+	single, err := ExtractBytesSync(data, mimeTypeLog, nil)
+	if err != nil {
+		t.Fatalf("ExtractBytesSync: %v", err)
+	}
+	results, err := BatchExtractBytesSync([]BytesWithMime{{Data: data, MimeType: mimeTypeLog}}, nil)
+	if err != nil {
+		t.Fatalf("BatchExtractBytesSync: %v", err)
+	}
+	if len(results) != 1 || results[0].Content != single.Content || results[0].MimeType != single.MimeType {
+		t.Fatalf("batch result %+v differs from %+v", results[0], single)
+	}
+}
+
+// TestDispatchBatch tests that documents are split between single and batch extraction
+// and put back in order.
+func TestDispatchBatch(t *testing.T) {
+	results, err := dispatchBatch(4, func(i int) bool { return i%2 == 0 }, func(i int) (*ExtractionResult, error) {
+		if i == 2 {
+			return nil, newUnsupportedFormatErrorWithContext("x", "unsupported", nil, ErrorCodeUnsupportedFormat, nil)
+		}
+		return &ExtractionResult{Content: fmt.Sprint("single ", i)}, nil
+	}, func(indices []int) ([]*ExtractionResult, error) {
+		var batch []*ExtractionResult
+		for _, i := range indices {
+			batch = append(batch, &ExtractionResult{Content: fmt.Sprint("batch ", i)})
+		}
+		return batch, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Content != "single 0" || results[1].Content != "batch 1" || results[3].Content != "batch 3" {
+		t.Fatalf("results out of order: %q %q %q", results[0].Content, results[1].Content, results[3].Content)
+	}
+	if meta := results[2].Metadata.Error; meta == nil || errorKindForType(meta.ErrorType) != ErrorKindUnsupportedFormat {
+		t.Fatalf("failed document = %+v", results[2].Metadata.Error)
+	}
+}
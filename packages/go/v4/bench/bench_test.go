@@ -0,0 +1,163 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCorpus(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"notes.txt":      "plain text notes",
+		"sub/report.txt": "a nested report",
+		".hidden/x.txt":  "skipped",
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCorpusRoundTrip(t *testing.T) {
+	dir := writeCorpus(t)
+	corpus, err := ScanCorpus("notes", dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(corpus.Documents) != 2 || corpus.Documents[0].Path != "notes.txt" || corpus.Documents[1].Path != "sub/report.txt" {
+		t.Fatalf("unexpected documents: %+v", corpus.Documents)
+	}
+	if corpus.Documents[0].Size != 16 || len(corpus.Documents[0].SHA256) != 64 {
+		t.Errorf("unexpected document: %+v", corpus.Documents[0])
+	}
+
+	descriptor := filepath.Join(t.TempDir(), "corpus.json")
+	if err := corpus.Save(descriptor); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadCorpus(descriptor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Verify(); err != nil {
+		t.Fatalf("freshly saved corpus should verify: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.Verify(); err == nil || !strings.Contains(err.Error(), "notes.txt") {
+		t.Errorf("expected a mismatch for notes.txt, got %v", err)
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	var samples []time.Duration
+	for i := 100; i >= 1; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	stats := newLatencyStats(samples)
+	want := LatencyStats{
+		Count: 100, Min: time.Millisecond, Mean: 50500 * time.Microsecond,
+		P50: 50 * time.Millisecond, P90: 90 * time.Millisecond, P95: 95 * time.Millisecond, P99: 99 * time.Millisecond,
+		Max: 100 * time.Millisecond,
+	}
+	if stats != want {
+		t.Errorf("newLatencyStats = %+v, want %+v", stats, want)
+	}
+	if samples[0] != 100*time.Millisecond {
+		t.Error("samples should not be reordered")
+	}
+	if single := newLatencyStats([]time.Duration{time.Second}); single.P50 != time.Second || single.P99 != time.Second {
+		t.Errorf("unexpected stats for one sample: %+v", single)
+	}
+	if empty := newLatencyStats(nil); empty != (LatencyStats{}) {
+		t.Errorf("unexpected stats for no samples: %+v", empty)
+	}
+}
+
+func TestReportExport(t *testing.T) {
+	report := &Report{
+		Corpus:     "notes",
+		Iterations: 2,
+		Errors:     1,
+		Latency:    LatencyStats{Count: 3, Min: time.Millisecond, Max: 2500 * time.Microsecond},
+		Documents: []DocumentReport{
+			{Path: "a.txt", MimeType: "text/plain", Size: 10, Latency: LatencyStats{Count: 2, Max: 2500 * time.Microsecond}},
+			{Path: "b.txt", Size: 5, Errors: 1, LastError: "unreadable", Latency: LatencyStats{Count: 1}},
+		},
+	}
+
+	var csvOut bytes.Buffer
+	if err := report.WriteCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(csvOut.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "path,mime_type,size,count,errors") {
+		t.Fatalf("unexpected CSV:\n%s", csvOut.String())
+	}
+	if lines[1] != "a.txt,text/plain,10,2,0,0.000,0.000,0.000,0.000,0.000,0.000,2.500" || !strings.HasPrefix(lines[3], "*,,15,3,1,1.000,") {
+		t.Errorf("unexpected CSV rows:\n%s", csvOut.String())
+	}
+
+	var jsonOut bytes.Buffer
+	if err := report.WriteJSON(&jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Documents[1].LastError != "unreadable" || decoded.Latency.Max != 2500*time.Microsecond {
+		t.Errorf("unexpected JSON round trip: %+v", decoded)
+	}
+	if !strings.Contains(jsonOut.String(), `"p95_ns"`) {
+		t.Errorf("JSON should name durations in nanoseconds:\n%s", jsonOut.String())
+	}
+}
+
+func TestRun(t *testing.T) {
+	corpus, err := ScanCorpus("notes", writeCorpus(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Run(context.Background(), &Corpus{}, Options{}); err == nil {
+		t.Error("expected an error for an empty corpus")
+	}
+
+	report, err := Run(context.Background(), corpus, Options{Iterations: 3, Verify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Iterations != 3 || len(report.Documents) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	// Extractions either succeed or are counted as errors, whether or not the native
+	// library is available.
+	for _, doc := range report.Documents {
+		if doc.Latency.Count+doc.Errors != 3 {
+			t.Errorf("%s: %d measured and %d failed extractions, want 3 in all", doc.Path, doc.Latency.Count, doc.Errors)
+		}
+	}
+	if report.Latency.Count+report.Errors != 6 || report.Memory.Allocations == 0 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Run(ctx, corpus, Options{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
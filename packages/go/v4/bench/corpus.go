@@ -0,0 +1,167 @@
+// Package bench benchmarks Kreuzberg extraction on your own documents.
+//
+// A Corpus describes a set of documents by path, size and SHA-256, so a benchmark can be
+// repeated on exactly the same inputs on another machine or with another version of the
+// library. Run extracts every document of a corpus a number of times and returns a Report
+// with percentile latencies, throughput and memory use, which can be exported as JSON or
+// CSV to compare versions and configurations:
+//
+//	corpus, err := bench.ScanCorpus("invoices", "testdata/invoices")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	report, err := bench.Run(ctx, corpus, bench.Options{Iterations: 5, Warmup: 1})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	report.WriteCSV(os.Stdout)
+package bench
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// Corpus is a reproducible set of documents to benchmark.
+type Corpus struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Documents   []Document `json:"documents"`
+
+	// root is the directory document paths are relative to.
+	root string
+}
+
+// Document is a document of a corpus. Path is relative to the corpus descriptor, Size and
+// SHA256 pin its content. MimeType is the type detected from its path, recorded for
+// reports; documents are extracted as files, with the library's own detection.
+type Document struct {
+	Path     string `json:"path"`
+	MimeType string `json:"mime_type,omitempty"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// ScanCorpus describes the regular files below dir, in lexical order, as a corpus named
+// name. Hidden files and directories are skipped.
+func ScanCorpus(name, dir string) (*Corpus, error) {
+	corpus := &Corpus{Name: name, root: dir}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != dir && strings.HasPrefix(entry.Name(), ".") {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		size, sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		mimeType, _ := kreuzberg.DetectMimeTypeFromPath(path)
+		corpus.Documents = append(corpus.Documents, Document{Path: filepath.ToSlash(rel), MimeType: mimeType, Size: size, SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan corpus %s: %w", dir, err)
+	}
+	return corpus, nil
+}
+
+// LoadCorpus reads the corpus descriptor at path. Document paths are resolved relative to
+// the directory of the descriptor.
+func LoadCorpus(path string) (*Corpus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load corpus: %w", err)
+	}
+	corpus := &Corpus{}
+	if err := json.Unmarshal(data, corpus); err != nil {
+		return nil, fmt.Errorf("load corpus %s: %w", path, err)
+	}
+	corpus.root = filepath.Dir(path)
+	return corpus, nil
+}
+
+// Save writes the corpus descriptor to path, with document paths relative to the directory
+// of path.
+func (c *Corpus) Save(path string) error {
+	saved := *c
+	saved.Documents = make([]Document, len(c.Documents))
+	for i, doc := range c.Documents {
+		rel, err := filepath.Rel(filepath.Dir(path), c.Path(doc))
+		if err != nil {
+			return fmt.Errorf("save corpus: %w", err)
+		}
+		doc.Path = filepath.ToSlash(rel)
+		saved.Documents[i] = doc
+	}
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save corpus: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Path returns the path of doc on disk.
+func (c *Corpus) Path(doc Document) string {
+	return filepath.Join(c.root, filepath.FromSlash(doc.Path))
+}
+
+// Verify checks that every document is present with the recorded size and SHA-256, so that
+// results are comparable with earlier runs on the corpus. It reports every mismatch.
+func (c *Corpus) Verify() error {
+	var errs []error
+	for _, doc := range c.Documents {
+		size, sum, err := hashFile(c.Path(doc))
+		switch {
+		case err != nil:
+			errs = append(errs, err)
+		case size != doc.Size || sum != doc.SHA256:
+			errs = append(errs, fmt.Errorf("%s: content differs from the corpus descriptor", doc.Path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func hashFile(path string) (int64, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// totalBytes returns the total size of the documents of the corpus.
+func (c *Corpus) totalBytes() int64 {
+	var total int64
+	for _, doc := range c.Documents {
+		total += doc.Size
+	}
+	return total
+}
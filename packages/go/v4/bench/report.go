@@ -0,0 +1,154 @@
+package bench
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// Report is the outcome of a benchmark run. Durations are exported to JSON in nanoseconds.
+type Report struct {
+	Corpus         string                      `json:"corpus"`
+	LibraryVersion string                      `json:"library_version"`
+	GoVersion      string                      `json:"go_version"`
+	Platform       string                      `json:"platform"`
+	Config         *kreuzberg.ExtractionConfig `json:"config,omitempty"`
+	Iterations     int                         `json:"iterations"`
+	StartedAt      time.Time                   `json:"started_at"`
+	Duration       time.Duration               `json:"duration_ns"`
+
+	// DocumentsPerSecond counts successful extractions; BytesPerSecond counts the input
+	// size of every measured extraction.
+	DocumentsPerSecond float64 `json:"documents_per_second"`
+	BytesPerSecond     float64 `json:"bytes_per_second"`
+	Errors             int     `json:"errors"`
+
+	Latency   LatencyStats     `json:"latency"`
+	Memory    MemoryStats      `json:"memory"`
+	Documents []DocumentReport `json:"documents"`
+}
+
+// DocumentReport is the outcome of the extractions of one document.
+type DocumentReport struct {
+	Path      string       `json:"path"`
+	MimeType  string       `json:"mime_type,omitempty"`
+	Size      int64        `json:"size"`
+	Errors    int          `json:"errors"`
+	LastError string       `json:"last_error,omitempty"`
+	Latency   LatencyStats `json:"latency"`
+}
+
+// LatencyStats summarizes the latencies of successful extractions. Percentiles use the
+// nearest-rank method.
+type LatencyStats struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min_ns"`
+	Mean  time.Duration `json:"mean_ns"`
+	P50   time.Duration `json:"p50_ns"`
+	P90   time.Duration `json:"p90_ns"`
+	P95   time.Duration `json:"p95_ns"`
+	P99   time.Duration `json:"p99_ns"`
+	Max   time.Duration `json:"max_ns"`
+}
+
+// MemoryStats reports the memory use of a run. The Go figures cover the measured
+// extractions only. PeakRSSBytes is the peak resident set size of the process since it
+// started, which includes the memory of the native core; it is 0 where unavailable.
+type MemoryStats struct {
+	AllocatedBytes uint64 `json:"allocated_bytes"`
+	Allocations    uint64 `json:"allocations"`
+	GCCycles       uint32 `json:"gc_cycles"`
+	PeakHeapBytes  uint64 `json:"peak_heap_bytes"`
+	PeakRSSBytes   uint64 `json:"peak_rss_bytes"`
+}
+
+func newReport(corpus *Corpus, config *kreuzberg.ExtractionConfig, iterations int) *Report {
+	report := &Report{
+		Corpus:         corpus.Name,
+		LibraryVersion: kreuzberg.LibraryVersion(),
+		GoVersion:      runtime.Version(),
+		Platform:       runtime.GOOS + "/" + runtime.GOARCH,
+		Config:         config,
+		Iterations:     iterations,
+		StartedAt:      time.Now().UTC(),
+		Documents:      make([]DocumentReport, len(corpus.Documents)),
+	}
+	for i, doc := range corpus.Documents {
+		report.Documents[i] = DocumentReport{Path: doc.Path, MimeType: doc.MimeType, Size: doc.Size}
+	}
+	return report
+}
+
+func newLatencyStats(samples []time.Duration) LatencyStats {
+	if len(samples) == 0 {
+		return LatencyStats{}
+	}
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	var total time.Duration
+	for _, sample := range sorted {
+		total += sample
+	}
+	return LatencyStats{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(sorted, 50),
+		P90:   percentile(sorted, 90),
+		P95:   percentile(sorted, 95),
+		P99:   percentile(sorted, 99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the nearest-rank p-th percentile of sorted.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// WriteJSON writes the report to w as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// csvHeader lists the columns of WriteCSV.
+var csvHeader = []string{"path", "mime_type", "size", "count", "errors", "min_ms", "mean_ms", "p50_ms", "p90_ms", "p95_ms", "p99_ms", "max_ms"}
+
+// WriteCSV writes the report to w as CSV, one row per document followed by a row for the
+// whole corpus whose path is "*". Latencies are in milliseconds.
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	var size int64
+	for _, doc := range r.Documents {
+		size += doc.Size
+		if err := writer.Write(csvRow(doc.Path, doc.MimeType, doc.Size, doc.Errors, doc.Latency)); err != nil {
+			return err
+		}
+	}
+	if err := writer.Write(csvRow("*", "", size, r.Errors, r.Latency)); err != nil {
+		return err
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func csvRow(path, mimeType string, size int64, errors int, latency LatencyStats) []string {
+	row := []string{path, mimeType, strconv.FormatInt(size, 10), strconv.Itoa(latency.Count), strconv.Itoa(errors)}
+	for _, d := range []time.Duration{latency.Min, latency.Mean, latency.P50, latency.P90, latency.P95, latency.P99, latency.Max} {
+		row = append(row, strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64))
+	}
+	return row
+}
@@ -0,0 +1,119 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// Options configures a benchmark run.
+type Options struct {
+	// Config is the extraction configuration benchmarked; nil uses the library defaults.
+	Config *kreuzberg.ExtractionConfig
+	// Iterations is the number of times every document is extracted and measured.
+	// Default: 1.
+	Iterations int
+	// Warmup is the number of unmeasured extractions of the corpus before the measured
+	// ones, to load models and fill caches. Default: 0.
+	Warmup int
+	// Verify checks the corpus against its descriptor before running. Default: false.
+	Verify bool
+}
+
+// Run extracts every document of corpus opts.Iterations times, one at a time, and reports
+// the latencies and memory use of the measured extractions. Failed extractions are counted
+// per document and excluded from the latencies. Run stops early, returning ctx.Err(), when
+// ctx is canceled.
+func Run(ctx context.Context, corpus *Corpus, opts Options) (*Report, error) {
+	if corpus == nil || len(corpus.Documents) == 0 {
+		return nil, errors.New("bench: corpus has no documents")
+	}
+	if opts.Verify {
+		if err := corpus.Verify(); err != nil {
+			return nil, err
+		}
+	}
+	iterations := max(opts.Iterations, 1)
+
+	for range opts.Warmup {
+		for _, doc := range corpus.Documents {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			_, _ = kreuzberg.ExtractFileWithContext(ctx, corpus.Path(doc), opts.Config)
+		}
+	}
+
+	report := newReport(corpus, opts.Config, iterations)
+	samples := make([][]time.Duration, len(corpus.Documents))
+	var all []time.Duration
+	memory := startMemoryTracking()
+	started := time.Now()
+	for range iterations {
+		for i, doc := range corpus.Documents {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			start := time.Now()
+			_, err := kreuzberg.ExtractFileWithContext(ctx, corpus.Path(doc), opts.Config)
+			elapsed := time.Since(start)
+			memory.sample()
+			if err != nil {
+				report.Documents[i].Errors++
+				report.Documents[i].LastError = err.Error()
+				continue
+			}
+			samples[i] = append(samples[i], elapsed)
+			all = append(all, elapsed)
+		}
+	}
+	report.Duration = time.Since(started)
+	report.Memory = memory.stop()
+
+	for i := range report.Documents {
+		report.Documents[i].Latency = newLatencyStats(samples[i])
+		report.Errors += report.Documents[i].Errors
+	}
+	report.Latency = newLatencyStats(all)
+	if seconds := report.Duration.Seconds(); seconds > 0 {
+		report.DocumentsPerSecond = float64(len(all)) / seconds
+		report.BytesPerSecond = float64(corpus.totalBytes()*int64(iterations)) / seconds
+	}
+	return report, nil
+}
+
+// memoryTracker follows the Go heap across a run.
+type memoryTracker struct {
+	start    runtime.MemStats
+	peakHeap uint64
+}
+
+func startMemoryTracking() *memoryTracker {
+	runtime.GC()
+	tracker := &memoryTracker{}
+	runtime.ReadMemStats(&tracker.start)
+	tracker.peakHeap = tracker.start.HeapInuse
+	return tracker
+}
+
+// sample records the heap in use after an extraction, outside of its measured time.
+func (m *memoryTracker) sample() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	m.peakHeap = max(m.peakHeap, stats.HeapInuse)
+}
+
+func (m *memoryTracker) stop() MemoryStats {
+	var end runtime.MemStats
+	runtime.ReadMemStats(&end)
+	return MemoryStats{
+		AllocatedBytes: end.TotalAlloc - m.start.TotalAlloc,
+		Allocations:    end.Mallocs - m.start.Mallocs,
+		GCCycles:       end.NumGC - m.start.NumGC,
+		PeakHeapBytes:  max(m.peakHeap, end.HeapInuse),
+		PeakRSSBytes:   peakRSS(),
+	}
+}
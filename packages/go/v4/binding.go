@@ -4,6 +4,7 @@ package kreuzberg
 #include "internal/ffi/kreuzberg.h"
 #include <stdlib.h>
 #include <stdint.h>
+#include <string.h>
 
 // Extraction API function declarations
 const char *kreuzberg_last_error(void);
@@ -63,10 +64,12 @@ import "C"
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 	"unsafe"
 )
 
@@ -83,6 +86,9 @@ type BytesWithMime struct {
 
 // ExtractFileSync extracts content and metadata from the file at the provided path.
 func ExtractFileSync(path string, config *ExtractionConfig) (*ExtractionResult, error) {
+	if err := compatibility.Err(); err != nil {
+		return nil, err
+	}
 	// Validate path is not empty
 	if path == "" {
 		return nil, newValidationErrorWithContext("path is required", nil, ErrorCodeValidation, nil)
@@ -94,8 +100,150 @@ func ExtractFileSync(path string, config *ExtractionConfig) (*ExtractionResult,
 			return nil, err
 		}
 	}
+	if err := validateResultStages(config); err != nil {
+		return nil, err
+	}
+
+	profile := startProfile(config, ProfileLabelFile, path, ProfileLabelStage, ProfileStageNative)
+	defer profile.end()
+	start := time.Now()
+	result, err := extractFileWithFallbacks(path, config)
+	if err != nil {
+		return nil, err
+	}
+	read := sync.OnceValues(func() ([]byte, error) { return readDocument(path) })
+	recordExtraction("", fileSize(path), read, result, config, time.Since(start))
+	profile.set(ProfileLabelFormat, result.MimeType)
+	profile.set(ProfileLabelStage, ProfileStageBinding)
+	if err := applyDocumentStages(result, read, config); err != nil {
+		return nil, err
+	}
+	if err := runResultStages(result, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyDocumentStages runs the binding-side stages that read the original document,
+// returned by read, on the result of a single extraction. The stages share one call of
+// read, made by the first stage that needs the document, and the stages reading the text
+// layer of a PDF share one call of pdfTextLayer.
+func applyDocumentStages(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) error {
+	read = sync.OnceValues(read)
+	layer := sync.OnceValues(func() ([]PageImage, error) {
+		data, err := read()
+		if err != nil {
+			return nil, err
+		}
+		return pdfTextLayer(data, textLayerRequest{DPI: textLayerDPI})
+	})
+	applyTextEncodingStage(result, read, config)
+	applyVerticalTextStage(result, layer, config)
+	applyRubyTextStage(result, read, config)
+	if err := applyEmailStages(result, read, config); err != nil {
+		return err
+	}
+	applyGeoStage(result, read, config)
+	applyExifStage(result, read, config)
+	applyPortfolioStage(result, read, config)
+	applyNestedStage(result, read, config)
+	applyHeadingStage(result, read, layer, config)
+	applyLinkStage(result, read, config)
+	applyInlineStyleStage(result, read, layer, config)
+	applySourceStage(result, read, layer, config)
+	applyScanQualityStage(result, read, config)
+	applyFontStage(result, read, config)
+	applyTableDetectionStage(result, read, layer, config)
+	return nil
+}
+
+// applyBatchDocumentStages runs applyDocumentStages on the results of a batch, one
+// document at a time so that a single original is held in memory. read returns the
+// original of document i.
+func applyBatchDocumentStages(results []*ExtractionResult, read func(int) ([]byte, error), config *ExtractionConfig) error {
+	for i, result := range results {
+		if err := applyDocumentStages(result, func() ([]byte, error) { return read(i) }, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	cPath := C.CString(path)
+// extractFileNative performs the native extraction for ExtractFileSync while holding ffiMutex.
+func extractFileNative(path string, config *ExtractionConfig) (*ExtractionResult, error) {
+	if isMHTMLPath(path) {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return extractMHTML(data, config)
+	}
+	if language := codeLanguageForPath(path); language != "" {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return extractCode(data, language), nil
+	}
+	if isLogPath(path) {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return extractLog(data), nil
+	}
+	if isEDIPath(path) {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		if detectEDI(data) != "" {
+			return extractEDI(data)
+		}
+	}
+	if mimeType := modernImageMimeTypeFromPath(path); mimeType != "" {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		return extractModernImage(data, mimeType, config)
+	}
+	if isJPEGPath(path) && config != nil && config.OCR != nil {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		if result, err := extractOrientedJPEG(data, config); result != nil || err != nil {
+			return result, err
+		}
+	}
+	if isPDFPath(path) && bindingRecognizesPages(config) {
+		data, err := readDocument(path)
+		if err != nil {
+			return nil, err
+		}
+		if result, err := extractPDFPages(data, config); result != nil || err != nil {
+			return result, err
+		}
+	}
+	return extractFileCore(path, config)
+}
+
+// bindingHandlesPath reports whether extractFileNative may extract path in the binding
+// rather than hand it straight to the core library. It must cover every case of
+// extractFileNative, so that batches extract such documents as ExtractFileSync does.
+func bindingHandlesPath(path string, config *ExtractionConfig) bool {
+	return isMHTMLPath(path) || codeLanguageForPath(path) != "" || isLogPath(path) ||
+		isEDIPath(path) || modernImageMimeTypeFromPath(path) != "" || isJPEGPath(path) && config != nil && config.OCR != nil ||
+		isPDFPath(path) && bindingRecognizesPages(config)
+}
+
+// extractFileCore hands a file to the core library.
+func extractFileCore(path string, config *ExtractionConfig) (result *ExtractionResult, err error) {
+	if compressAbove(config) >= 0 {
+		return extractFileCompressed(path, config)
+	}
+	cPath := C.CString(NormalizePath(path))
 	defer C.free(unsafe.Pointer(cPath))
 
 	cfgPtr, cfgCleanup, err := newConfigJSON(config)
@@ -109,6 +257,12 @@ func ExtractFileSync(path string, config *ExtractionConfig) (*ExtractionResult,
 	// Serialize FFI calls to prevent concurrent PDFium access
 	ffiMutex.Lock()
 	defer ffiMutex.Unlock()
+	leaveSpool, err := enterNativeSpool(config)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveSpool(&err)
+	defer timeNative(time.Now(), &result)
 
 	var cRes *C.CExtractionResult
 	if cfgPtr != nil {
@@ -122,11 +276,14 @@ func ExtractFileSync(path string, config *ExtractionConfig) (*ExtractionResult,
 	}
 	defer C.kreuzberg_free_result(cRes)
 
-	return convertCResult(cRes)
+	return convertCResult(cRes, lazyMetadata(config))
 }
 
 // ExtractBytesSync extracts content and metadata from a byte array with the given MIME type.
 func ExtractBytesSync(data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	if err := compatibility.Err(); err != nil {
+		return nil, err
+	}
 	if mimeType == "" {
 		return nil, newValidationErrorWithContext("mimeType is required", nil, ErrorCodeValidation, nil)
 	}
@@ -137,7 +294,82 @@ func ExtractBytesSync(data []byte, mimeType string, config *ExtractionConfig) (*
 			return nil, err
 		}
 	}
+	if err := validateResultStages(config); err != nil {
+		return nil, err
+	}
 
+	profile := startProfile(config, ProfileLabelFormat, mimeType, ProfileLabelStage, ProfileStageNative)
+	defer profile.end()
+	start := time.Now()
+	result, err := extractBytesWithFallbacks(data, mimeType, config)
+	if err != nil {
+		return nil, err
+	}
+	recordExtraction(mimeType, int64(len(data)), func() ([]byte, error) { return data, nil }, result, config, time.Since(start))
+	profile.set(ProfileLabelStage, ProfileStageBinding)
+	if err := applyDocumentStages(result, func() ([]byte, error) { return data, nil }, config); err != nil {
+		return nil, err
+	}
+	if err := runResultStages(result, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// extractBytesNative performs the native extraction for ExtractBytesSync while holding ffiMutex.
+func extractBytesNative(data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	if mimeType == MimeTypeMHTML {
+		return extractMHTML(data, config)
+	}
+	if language := codeLanguageForMime(mimeType); language != "" {
+		return extractCode(data, language), nil
+	}
+	switch mimeType {
+	case mimeTypeLog:
+		return extractLog(data), nil
+	case MimeTypeEDIX12, MimeTypeEDIFACT:
+		return extractEDI(data)
+	case MimeTypeFixedWidth:
+		return extractFixedWidth(data), nil
+	case MimeTypeHEIC, MimeTypeHEIF, MimeTypeAVIF, MimeTypeJXL:
+		return extractModernImage(data, mimeType, config)
+	case "image/jpeg":
+		if result, err := extractOrientedJPEG(data, config); result != nil || err != nil {
+			return result, err
+		}
+	case "application/pdf":
+		if result, err := extractPDFPages(data, config); result != nil || err != nil {
+			return result, err
+		}
+	}
+	return extractBytesCore(data, mimeType, config)
+}
+
+// bindingHandlesMime reports whether extractBytesNative may extract a document of
+// mimeType in the binding rather than hand it straight to the core library. It must cover
+// every case of extractBytesNative, so that batches extract such documents as
+// ExtractBytesSync does.
+func bindingHandlesMime(mimeType string, config *ExtractionConfig) bool {
+	if mimeType == MimeTypeMHTML || codeLanguageForMime(mimeType) != "" {
+		return true
+	}
+	switch mimeType {
+	case mimeTypeLog, MimeTypeEDIX12, MimeTypeEDIFACT, MimeTypeFixedWidth, MimeTypeHEIC, MimeTypeHEIF, MimeTypeAVIF,
+		MimeTypeJXL:
+		return true
+	case "image/jpeg":
+		return config != nil && config.OCR != nil
+	case "application/pdf":
+		return bindingRecognizesPages(config)
+	}
+	return false
+}
+
+// extractBytesCore hands a buffer to the core library.
+func extractBytesCore(data []byte, mimeType string, config *ExtractionConfig) (result *ExtractionResult, err error) {
+	if compressAbove(config) >= 0 {
+		return extractBytesCompressed(data, mimeType, config)
+	}
 	buf := C.CBytes(data)
 	defer C.free(buf)
 
@@ -155,6 +387,12 @@ func ExtractBytesSync(data []byte, mimeType string, config *ExtractionConfig) (*
 	// Serialize FFI calls to prevent concurrent PDFium access
 	ffiMutex.Lock()
 	defer ffiMutex.Unlock()
+	leaveSpool, err := enterNativeSpool(config)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveSpool(&err)
+	defer timeNative(time.Now(), &result)
 
 	var cRes *C.CExtractionResult
 	if cfgPtr != nil {
@@ -168,11 +406,14 @@ func ExtractBytesSync(data []byte, mimeType string, config *ExtractionConfig) (*
 	}
 	defer C.kreuzberg_free_result(cRes)
 
-	return convertCResult(cRes)
+	return convertCResult(cRes, lazyMetadata(config))
 }
 
 // BatchExtractFilesSync extracts multiple files sequentially but leverages the optimized batch pipeline.
 func BatchExtractFilesSync(paths []string, config *ExtractionConfig) ([]*ExtractionResult, error) {
+	if err := compatibility.Err(); err != nil {
+		return nil, err
+	}
 	if len(paths) == 0 {
 		return []*ExtractionResult{}, nil
 	}
@@ -183,13 +424,90 @@ func BatchExtractFilesSync(paths []string, config *ExtractionConfig) ([]*Extract
 			return nil, err
 		}
 	}
+	if err := validateResultStages(config); err != nil {
+		return nil, err
+	}
+
+	profile := startProfile(config)
+	defer profile.end()
+	extract := func(paths []string) ([]*ExtractionResult, error) {
+		return batchExtractFiles(paths, config, profile)
+	}
+	if config != nil && config.Quarantine != nil {
+		extractFiles := extract
+		extract = func(paths []string) ([]*ExtractionResult, error) {
+			return extractQuarantined(paths, config.Quarantine, extractFiles)
+		}
+	}
+	if config != nil && config.FilePolicy != nil {
+		extractFiles := extract
+		extract = func(paths []string) ([]*ExtractionResult, error) {
+			return extractWithFilePolicy(paths, config.FilePolicy, extractFiles)
+		}
+	}
+	var results []*ExtractionResult
+	var err error
+	if config != nil && config.Deduplicate != nil && *config.Deduplicate {
+		results, err = deduplicate(paths, fileContentKey, extract)
+	} else {
+		results, err = extract(paths)
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.set(ProfileLabelStage, ProfileStageBinding)
+	if err := runBatchResultStages(results, config); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// batchExtractFiles runs the native batch extraction for BatchExtractFilesSync, then
+// retries and falls back on failed documents as configured.
+func batchExtractFiles(paths []string, config *ExtractionConfig, profile *profileScope) ([]*ExtractionResult, error) {
+	profile.set(ProfileLabelStage, ProfileStageNative)
+	native := nativeConfig(config)
+	policy := newRetryPolicy(retryConfig(config))
+	results, err := retryDo(policy, func() ([]*ExtractionResult, error) {
+		return dispatchBatch(len(paths), func(i int) bool {
+			return bindingHandlesPath(paths[i], native)
+		}, func(i int) (*ExtractionResult, error) {
+			return extractFileNative(paths[i], native)
+		}, func(indices []int) ([]*ExtractionResult, error) {
+			rest := make([]string, len(indices))
+			for j, i := range indices {
+				rest[j] = paths[i]
+			}
+			return batchExtractFilesNative(rest, native)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	retryFailedResults(policy, results, func(i int) (*ExtractionResult, error) {
+		return extractFileNative(paths[i], native)
+	})
+	fallbackFailedResults(results, config, func(i int) string {
+		mimeType, _ := DetectMimeTypeFromPath(paths[i])
+		return mimeType
+	}, func(i int, mimeType string, chain []string) (*ExtractionResult, error) {
+		return fileFallbacks(paths[i], mimeType, chain, native)
+	})
+	profile.set(ProfileLabelStage, ProfileStageBinding)
+	if err := applyBatchDocumentStages(results, func(i int) ([]byte, error) { return readDocument(paths[i]) }, config); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
 
+// batchExtractFilesNative performs the native batch extraction for BatchExtractFilesSync while holding ffiMutex.
+func batchExtractFilesNative(paths []string, config *ExtractionConfig) (results []*ExtractionResult, err error) {
 	cStrings := make([]*C.char, len(paths))
 	for i, path := range paths {
 		if path == "" {
 			return nil, newValidationErrorWithContext(fmt.Sprintf("path at index %d is empty", i), nil, ErrorCodeValidation, nil)
 		}
-		cStrings[i] = C.CString(path)
+		cStrings[i] = C.CString(NormalizePath(path))
 	}
 	defer func() {
 		for _, ptr := range cStrings {
@@ -208,18 +526,30 @@ func BatchExtractFilesSync(paths []string, config *ExtractionConfig) ([]*Extract
 	// Serialize FFI calls to prevent concurrent PDFium access
 	ffiMutex.Lock()
 	defer ffiMutex.Unlock()
+	leaveSpool, err := enterNativeSpool(config)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveSpool(&err)
+	defer timeNativeBatch(time.Now(), &results)
 
+	if packedBatches(config) {
+		return batchExtractFilesPacked(cStrings, cfgPtr, config)
+	}
 	batch := C.kreuzberg_batch_extract_files_sync((**C.char)(unsafe.Pointer(&cStrings[0])), C.uintptr_t(len(paths)), cfgPtr)
 	if batch == nil {
 		return nil, lastError()
 	}
 	defer C.kreuzberg_free_batch_result(batch)
 
-	return convertCBatchResult(batch)
+	return convertCBatchResult(batch, lazyMetadata(config))
 }
 
 // BatchExtractBytesSync processes multiple in-memory documents in one pass.
 func BatchExtractBytesSync(items []BytesWithMime, config *ExtractionConfig) ([]*ExtractionResult, error) {
+	if err := compatibility.Err(); err != nil {
+		return nil, err
+	}
 	if len(items) == 0 {
 		return []*ExtractionResult{}, nil
 	}
@@ -230,7 +560,108 @@ func BatchExtractBytesSync(items []BytesWithMime, config *ExtractionConfig) ([]*
 			return nil, err
 		}
 	}
+	if err := validateResultStages(config); err != nil {
+		return nil, err
+	}
 
+	profile := startProfile(config)
+	defer profile.end()
+	var results []*ExtractionResult
+	var err error
+	if config != nil && config.Deduplicate != nil && *config.Deduplicate {
+		results, err = deduplicate(items, bytesContentKey, func(unique []BytesWithMime) ([]*ExtractionResult, error) {
+			return batchExtractBytes(unique, config, profile)
+		})
+	} else {
+		results, err = batchExtractBytes(items, config, profile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	profile.set(ProfileLabelStage, ProfileStageBinding)
+	if err := runBatchResultStages(results, config); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// batchExtractBytes runs the native batch extraction for BatchExtractBytesSync, then
+// retries and falls back on failed documents as configured.
+func batchExtractBytes(items []BytesWithMime, config *ExtractionConfig, profile *profileScope) ([]*ExtractionResult, error) {
+	profile.set(ProfileLabelStage, ProfileStageNative)
+	native := nativeConfig(config)
+	policy := newRetryPolicy(retryConfig(config))
+	results, err := retryDo(policy, func() ([]*ExtractionResult, error) {
+		return dispatchBatch(len(items), func(i int) bool {
+			return len(items[i].Data) > 0 && bindingHandlesMime(items[i].MimeType, native)
+		}, func(i int) (*ExtractionResult, error) {
+			return extractBytesNative(items[i].Data, items[i].MimeType, native)
+		}, func(indices []int) ([]*ExtractionResult, error) {
+			rest := make([]BytesWithMime, len(indices))
+			for j, i := range indices {
+				rest[j] = items[i]
+			}
+			return batchExtractBytesNative(rest, native)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	retryFailedResults(policy, results, func(i int) (*ExtractionResult, error) {
+		return extractBytesNative(items[i].Data, items[i].MimeType, native)
+	})
+	fallbackFailedResults(results, config, func(i int) string {
+		return items[i].MimeType
+	}, func(i int, mimeType string, chain []string) (*ExtractionResult, error) {
+		return bytesFallbacks(items[i].Data, mimeType, chain, native)
+	})
+	profile.set(ProfileLabelStage, ProfileStageBinding)
+	if err := applyBatchDocumentStages(results, func(i int) ([]byte, error) { return items[i].Data, nil }, config); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// dispatchBatch extracts the n documents of a batch: those the binding handles itself
+// one at a time with extractOne, as the single-document functions would, and the others
+// together with extractRest, which receives their indices. A document failing in
+// extractOne is reported as a failed result, as the native batch reports its failures.
+func dispatchBatch(n int, handled func(int) bool, extractOne func(int) (*ExtractionResult, error), extractRest func([]int) ([]*ExtractionResult, error)) ([]*ExtractionResult, error) {
+	results := make([]*ExtractionResult, n)
+	var rest []int
+	for i := range n {
+		if !handled(i) {
+			rest = append(rest, i)
+			continue
+		}
+		result, err := extractOne(i)
+		if err != nil {
+			kind := ErrorKindUnknown
+			var kreuzbergErr KreuzbergError
+			if errors.As(err, &kreuzbergErr) {
+				kind = kreuzbergErr.Kind()
+			}
+			result = failedResult(string(kind), err.Error())
+		}
+		results[i] = result
+	}
+	if len(rest) == 0 {
+		return results, nil
+	}
+	extracted, err := extractRest(rest)
+	if err != nil {
+		return nil, err
+	}
+	for j, i := range rest {
+		if j < len(extracted) {
+			results[i] = extracted[j]
+		}
+	}
+	return results, nil
+}
+
+// batchExtractBytesNative performs the native batch extraction for BatchExtractBytesSync while holding ffiMutex.
+func batchExtractBytesNative(items []BytesWithMime, config *ExtractionConfig) (results []*ExtractionResult, err error) {
 	cItems := make([]C.CBytesWithMime, len(items))
 	cBuffers := make([]unsafe.Pointer, len(items))
 
@@ -273,14 +704,23 @@ func BatchExtractBytesSync(items []BytesWithMime, config *ExtractionConfig) ([]*
 	// Serialize FFI calls to prevent concurrent PDFium access
 	ffiMutex.Lock()
 	defer ffiMutex.Unlock()
+	leaveSpool, err := enterNativeSpool(config)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveSpool(&err)
+	defer timeNativeBatch(time.Now(), &results)
 
+	if packedBatches(config) {
+		return batchExtractBytesPacked(cItems, cfgPtr, config)
+	}
 	batch := C.kreuzberg_batch_extract_bytes_sync((*C.CBytesWithMime)(unsafe.Pointer(&cItems[0])), C.uintptr_t(len(items)), cfgPtr)
 	if batch == nil {
 		return nil, lastError()
 	}
 	defer C.kreuzberg_free_batch_result(batch)
 
-	return convertCBatchResult(batch)
+	return convertCBatchResult(batch, lazyMetadata(config))
 }
 
 // ExtractFileWithContext extracts content and metadata from a file at the given path,
@@ -362,12 +802,16 @@ func LastPanicContext() *PanicContext {
 	return &ctx
 }
 
-func convertCResult(cRes *C.CExtractionResult) (*ExtractionResult, error) {
-	result := &ExtractionResult{
-		Content:  C.GoString(cRes.content),
-		MimeType: C.GoString(cRes.mime_type),
-		Success:  bool(cRes.success),
+// convertCResult copies a result of the core library. When lazy, the format payload and
+// the additional fields of its metadata are decoded on first access.
+func convertCResult(cRes *C.CExtractionResult, lazy bool) (*ExtractionResult, error) {
+	result := &ExtractionResult{}
+	if lazy {
+		result.Metadata.deferred = &deferredMetadata{}
 	}
+	result.Content = C.GoString(cRes.content)
+	result.MimeType = C.GoString(cRes.mime_type)
+	result.Success = bool(cRes.success)
 
 	if err := decodeJSONCString(cRes.tables_json, &result.Tables); err != nil {
 		return nil, newSerializationErrorWithContext("failed to decode tables", err, ErrorCodeValidation, nil)
@@ -413,10 +857,11 @@ func convertCResult(cRes *C.CExtractionResult) (*ExtractionResult, error) {
 		return nil, newSerializationErrorWithContext("failed to decode elements", err, ErrorCodeValidation, nil)
 	}
 
+	promoteRtfMetadata(result)
 	return result, nil
 }
 
-func convertCBatchResult(cBatch *C.CBatchResult) ([]*ExtractionResult, error) {
+func convertCBatchResult(cBatch *C.CBatchResult, lazy bool) ([]*ExtractionResult, error) {
 	count := int(cBatch.count)
 	results := make([]*ExtractionResult, 0, count)
 	if count == 0 {
@@ -429,7 +874,7 @@ func convertCBatchResult(cBatch *C.CBatchResult) ([]*ExtractionResult, error) {
 			results = append(results, nil)
 			continue
 		}
-		res, err := convertCResult(ptr)
+		res, err := convertCResult(ptr, lazy)
 		if err != nil {
 			return nil, err
 		}
@@ -442,17 +887,24 @@ func decodeJSONCString[T any](ptr *C.char, target *T) error {
 	if ptr == nil {
 		return nil
 	}
-	raw := C.GoString(ptr)
-	if raw == "" {
+	length := C.strlen(ptr)
+	if length == 0 {
 		return nil
 	}
-	return json.Unmarshal([]byte(raw), target)
+	// Decode in place: json.Unmarshal copies what it keeps, so no Go copy of the
+	// document is needed.
+	return json.Unmarshal(unsafe.Slice((*byte)(unsafe.Pointer(ptr)), int(length)), target)
 }
 
 func newConfigJSON(config *ExtractionConfig) (*C.char, func(), error) {
 	if config == nil {
 		return nil, nil, nil
 	}
+	if config.Include != nil {
+		native := *config
+		native.Include = nativeInclude(config)
+		config = &native
+	}
 	data, err := json.Marshal(config)
 	if err != nil {
 		return nil, nil, newSerializationErrorWithContext("failed to encode config", err, ErrorCodeValidation, nil)
@@ -468,12 +920,10 @@ func newConfigJSON(config *ExtractionConfig) (*C.char, func(), error) {
 }
 
 func lastError() error {
-	errPtr := C.kreuzberg_last_error()
-	if errPtr == nil {
-		return newRuntimeErrorWithContext("unknown error", nil, ErrorCodeInternal, nil)
+	var errMsg string
+	if errPtr := C.kreuzberg_last_error(); errPtr != nil {
+		errMsg = C.GoString(errPtr)
 	}
-
-	errMsg := C.GoString(errPtr)
 	code := ErrorCode(C.kreuzberg_last_error_code())
 
 	// Check for panic context regardless of error code
@@ -490,6 +940,14 @@ func lastError() error {
 		}
 	}
 
+	// A panic caught at the FFI boundary reports the panic shield's own code, which does
+	// not follow ErrorCode, so it is classified as an internal error.
+	if panicCtx != nil {
+		return newRuntimeErrorWithContext(messageWithFallback(errMsg, panicCtx.Message), nil, ErrorCodeInternal, panicCtx)
+	}
+	if errMsg == "" {
+		return newRuntimeErrorWithContext("unknown error", nil, ErrorCodeInternal, nil)
+	}
 	return classifyNativeError(errMsg, code, panicCtx)
 }
 
@@ -507,7 +965,7 @@ func LoadExtractionConfigFromFile(path string) (*ExtractionConfig, error) {
 		return nil, newValidationErrorWithContext("config path cannot be empty", nil, ErrorCodeValidation, nil)
 	}
 
-	cPath := C.CString(path)
+	cPath := C.CString(NormalizePath(path))
 	defer C.free(unsafe.Pointer(cPath))
 
 	ffiMutex.Lock()
@@ -567,6 +1025,15 @@ func DetectMimeType(data []byte) (string, error) {
 	if len(data) == 0 {
 		return "", newValidationErrorWithContext("data cannot be empty", nil, ErrorCodeValidation, nil)
 	}
+	if detectMHTML(data) {
+		return MimeTypeMHTML, nil
+	}
+	if mimeType := detectEDI(data); mimeType != "" {
+		return mimeType, nil
+	}
+	if mimeType := detectModernImage(data); mimeType != "" {
+		return mimeType, nil
+	}
 
 	buf := C.CBytes(data)
 	defer C.free(buf)
@@ -588,8 +1055,27 @@ func DetectMimeTypeFromPath(path string) (string, error) {
 	if path == "" {
 		return "", newValidationErrorWithContext("path cannot be empty", nil, ErrorCodeValidation, nil)
 	}
+	if isMHTMLPath(path) {
+		return MimeTypeMHTML, nil
+	}
+	if language := codeLanguageForPath(path); language != "" {
+		return codeMimePrefix + language, nil
+	}
+	if isLogPath(path) {
+		return mimeTypeLog, nil
+	}
+	if isEDIPath(path) {
+		if data, err := readDocument(path); err == nil {
+			if mimeType := detectEDI(data); mimeType != "" {
+				return mimeType, nil
+			}
+		}
+	}
+	if mimeType := modernImageMimeTypeFromPath(path); mimeType != "" {
+		return mimeType, nil
+	}
 
-	cPath := C.CString(path)
+	cPath := C.CString(NormalizePath(path))
 	defer C.free(unsafe.Pointer(cPath))
 
 	ffiMutex.Lock()
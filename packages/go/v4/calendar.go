@@ -0,0 +1,288 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CalendarEvent is a meeting described by an iCalendar VEVENT, such as the invitation
+// carried by an email. Method is the iCalendar METHOD of the enclosing calendar, e.g.
+// REQUEST for an invitation or CANCEL for a cancellation. Start and End are nil when
+// absent; AllDay events carry dates at midnight UTC. Recurrence holds the RRULE value.
+type CalendarEvent struct {
+	UID         string             `json:"uid,omitempty"`
+	Method      string             `json:"method,omitempty"`
+	Summary     string             `json:"summary,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Location    string             `json:"location,omitempty"`
+	Start       *time.Time         `json:"start,omitempty"`
+	End         *time.Time         `json:"end,omitempty"`
+	AllDay      bool               `json:"all_day,omitempty"`
+	Organizer   *CalendarAttendee  `json:"organizer,omitempty"`
+	Attendees   []CalendarAttendee `json:"attendees,omitempty"`
+	Recurrence  string             `json:"recurrence,omitempty"`
+	Status      string             `json:"status,omitempty"`
+	Sequence    int                `json:"sequence,omitempty"`
+}
+
+// CalendarAttendee is the organizer or an attendee of a CalendarEvent. Role and Status
+// are the iCalendar ROLE and PARTSTAT parameters, e.g. REQ-PARTICIPANT and ACCEPTED.
+type CalendarAttendee struct {
+	Name   string `json:"name,omitempty"`
+	Email  string `json:"email,omitempty"`
+	Role   string `json:"role,omitempty"`
+	Status string `json:"status,omitempty"`
+}
+
+// calendarLine is one unfolded content line of an iCalendar object.
+type calendarLine struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ParseCalendar parses the events of an iCalendar (.ics) object. Properties it cannot
+// interpret are skipped, so malformed input yields the events that could be read.
+func ParseCalendar(data []byte) []CalendarEvent {
+	var events []CalendarEvent
+	var event *CalendarEvent
+	method := ""
+	nested := 0
+	for _, line := range calendarLines(data) {
+		switch {
+		case line.name == "BEGIN" && strings.EqualFold(line.value, "VEVENT") && event == nil:
+			event = &CalendarEvent{Method: method}
+			continue
+		case line.name == "END" && strings.EqualFold(line.value, "VEVENT") && event != nil && nested == 0:
+			events = append(events, *event)
+			event = nil
+			continue
+		case line.name == "BEGIN" && event != nil:
+			nested++
+			continue
+		case line.name == "END" && event != nil:
+			nested--
+			continue
+		case line.name == "METHOD" && event == nil:
+			method = strings.ToUpper(line.value)
+			continue
+		}
+		if event == nil || nested > 0 {
+			continue
+		}
+		applyCalendarProperty(event, line)
+	}
+	return events
+}
+
+func applyCalendarProperty(event *CalendarEvent, line calendarLine) {
+	switch line.name {
+	case "UID":
+		event.UID = line.value
+	case "SUMMARY":
+		event.Summary = unescapeCalendarText(line.value)
+	case "DESCRIPTION":
+		event.Description = unescapeCalendarText(line.value)
+	case "LOCATION":
+		event.Location = unescapeCalendarText(line.value)
+	case "DTSTART":
+		if start, allDay, ok := parseCalendarTime(line); ok {
+			event.Start = &start
+			event.AllDay = allDay
+		}
+	case "DTEND":
+		if end, _, ok := parseCalendarTime(line); ok {
+			event.End = &end
+		}
+	case "ORGANIZER":
+		organizer := calendarAttendee(line)
+		event.Organizer = &organizer
+	case "ATTENDEE":
+		event.Attendees = append(event.Attendees, calendarAttendee(line))
+	case "RRULE":
+		event.Recurrence = line.value
+	case "STATUS":
+		event.Status = strings.ToUpper(line.value)
+	case "SEQUENCE":
+		if sequence, err := strconv.Atoi(line.value); err == nil {
+			event.Sequence = sequence
+		}
+	}
+}
+
+// calendarLines unfolds and splits the content lines of an iCalendar object.
+func calendarLines(data []byte) []calendarLine {
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.NewReplacer("\n ", "", "\n\t", "").Replace(text)
+	var lines []calendarLine
+	for _, raw := range strings.Split(text, "\n") {
+		if line, ok := parseCalendarLine(raw); ok {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// parseCalendarLine splits NAME;PARAM=VALUE;...:VALUE, honoring quoted parameter values.
+func parseCalendarLine(raw string) (calendarLine, bool) {
+	inQuotes := false
+	split := -1
+	for i, r := range raw {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+	if split <= 0 {
+		return calendarLine{}, false
+	}
+	fields := splitOutsideQuotes(raw[:split], ';')
+	line := calendarLine{name: strings.ToUpper(fields[0]), params: map[string]string{}, value: raw[split+1:]}
+	for _, param := range fields[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			line.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+		}
+	}
+	return line, true
+}
+
+func splitOutsideQuotes(s string, sep rune) []string {
+	var fields []string
+	inQuotes := false
+	start := 0
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+		case r == sep && !inQuotes:
+			fields = append(fields, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(fields, s[start:])
+}
+
+func unescapeCalendarText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// parseCalendarTime parses a DATE or DATE-TIME value: UTC times end in Z, local times use
+// the TZID parameter when it names a known zone and are otherwise taken as UTC.
+func parseCalendarTime(line calendarLine) (time.Time, bool, bool) {
+	value := strings.TrimSpace(line.value)
+	if line.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		t, err := time.Parse("20060102", value)
+		return t, true, err == nil
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err == nil
+	}
+	location := time.UTC
+	if tzid := line.params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, location)
+	return t, false, err == nil
+}
+
+func calendarAttendee(line calendarLine) CalendarAttendee {
+	email := line.value
+	if len(email) >= len("mailto:") && strings.EqualFold(email[:len("mailto:")], "mailto:") {
+		email = email[len("mailto:"):]
+	}
+	return CalendarAttendee{
+		Name:   line.params["CN"],
+		Email:  email,
+		Role:   line.params["ROLE"],
+		Status: line.params["PARTSTAT"],
+	}
+}
+
+// emlCalendarParts returns the decoded text/calendar parts of an RFC 822 message.
+func emlCalendarParts(data []byte) [][]byte {
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	var parts [][]byte
+	collectCalendarParts(message.Header.Get("Content-Type"), message.Header.Get("Content-Transfer-Encoding"), message.Body, &parts, 0)
+	return parts
+}
+
+// maxMIMEDepth bounds the nesting of multipart bodies walked for calendar parts.
+const maxMIMEDepth = 10
+
+func collectCalendarParts(contentType, encoding string, body io.Reader, parts *[][]byte, depth int) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil || depth > maxMIMEDepth {
+		return
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextRawPart()
+			if err != nil {
+				return
+			}
+			collectCalendarParts(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, parts, depth+1)
+		}
+	}
+	if mediaType != "text/calendar" && mediaType != "application/ics" {
+		return
+	}
+	if decoded, err := io.ReadAll(transferDecoder(body, encoding)); err == nil {
+		*parts = append(*parts, decoded)
+	}
+}
+
+// transferDecoder undoes the Content-Transfer-Encoding of a MIME part body.
+func transferDecoder(body io.Reader, encoding string) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	}
+	return body
+}
+
+// emailMeetings collects the events of the calendar parts of an email. Parts of EML
+// messages are found by walking the MIME tree; for other messages, the attachments
+// returned by the native parser are used.
+func emailMeetings(data []byte, mimeType string, email *parsedEmail) []CalendarEvent {
+	var parts [][]byte
+	if mimeType == mimeTypeEML {
+		parts = emlCalendarParts(data)
+	} else if email != nil {
+		for _, attachment := range email.Attachments {
+			isCalendar := attachment.MimeType != nil && *attachment.MimeType == "text/calendar"
+			if attachment.Name != nil && strings.HasSuffix(strings.ToLower(*attachment.Name), ".ics") {
+				isCalendar = true
+			}
+			if !isCalendar || attachment.Data == nil {
+				continue
+			}
+			if decoded, err := base64.StdEncoding.DecodeString(*attachment.Data); err == nil {
+				parts = append(parts, decoded)
+			}
+		}
+	}
+	var events []CalendarEvent
+	for _, part := range parts {
+		events = append(events, ParseCalendar(part)...)
+	}
+	return events
+}
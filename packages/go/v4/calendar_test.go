@@ -0,0 +1,105 @@
+package kreuzberg
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const testInvite = "BEGIN:VCALENDAR\r\n" +
+	"METHOD:REQUEST\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"UID:budget-review-1\r\n" +
+	"SUMMARY:Budget review\\, Q3\r\n" +
+	"DESCRIPTION:Agenda:\\n1. Forecast\r\n" +
+	"LOCATION:Room 4\r\n" +
+	"DTSTART;TZID=Europe/Berlin:20240603T090000\r\n" +
+	"DTEND:20240603T080000Z\r\n" +
+	"RRULE:FREQ=WEEKLY;COUNT=4\r\n" +
+	"ORGANIZER;CN=\"Alice: Finance\":mailto:alice@example.com\r\n" +
+	"ATTENDEE;CN=Bob;ROLE=REQ-PARTICIPANT;PARTSTAT=NEEDS-ACTION:MAILTO:bob@exa\r\n" +
+	" mple.com\r\n" +
+	"SEQUENCE:2\r\n" +
+	"BEGIN:VALARM\r\n" +
+	"DESCRIPTION:Reminder\r\n" +
+	"END:VALARM\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseCalendar(t *testing.T) {
+	events := ParseCalendar([]byte(testInvite))
+	if len(events) != 1 {
+		t.Fatalf("expected one event, got %d", len(events))
+	}
+	event := events[0]
+	if event.Method != "REQUEST" || event.Summary != "Budget review, Q3" || event.Description != "Agenda:\n1. Forecast" {
+		t.Fatalf("unexpected event text %+v", event)
+	}
+	if event.Start == nil || !event.Start.Equal(time.Date(2024, 6, 3, 7, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected start %v", event.Start)
+	}
+	if event.End == nil || !event.End.Equal(time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected end %v", event.End)
+	}
+	if event.Recurrence != "FREQ=WEEKLY;COUNT=4" || event.Sequence != 2 || event.Location != "Room 4" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if event.Organizer == nil || event.Organizer.Name != "Alice: Finance" || event.Organizer.Email != "alice@example.com" {
+		t.Fatalf("unexpected organizer %+v", event.Organizer)
+	}
+	want := CalendarAttendee{Name: "Bob", Email: "bob@example.com", Role: "REQ-PARTICIPANT", Status: "NEEDS-ACTION"}
+	if len(event.Attendees) != 1 || event.Attendees[0] != want {
+		t.Fatalf("unexpected attendees %+v", event.Attendees)
+	}
+}
+
+func TestParseCalendarAllDay(t *testing.T) {
+	events := ParseCalendar([]byte("BEGIN:VEVENT\nDTSTART;VALUE=DATE:20240704\nSUMMARY:Holiday\nEND:VEVENT\n"))
+	if len(events) != 1 || !events[0].AllDay || !events[0].Start.Equal(time.Date(2024, 7, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected all-day event %+v", events)
+	}
+}
+
+func TestEmlCalendarParts(t *testing.T) {
+	eml := strings.Join([]string{
+		"From: alice@example.com",
+		"Subject: Invitation: Budget review",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="outer"`,
+		"",
+		"--outer",
+		`Content-Type: multipart/alternative; boundary="inner"`,
+		"",
+		"--inner",
+		"Content-Type: text/plain",
+		"",
+		"You are invited.",
+		"--inner",
+		`Content-Type: text/calendar; method=REQUEST; charset=UTF-8`,
+		"Content-Transfer-Encoding: base64",
+		"",
+		"QkVHSU46VkNBTEVOREFSDQpCRUdJTjpWRVZFTlQNClNVTU1BUlk6QnVkZ2V0IHJldmlldw0KRU5E",
+		"OlZFVkVOVA0KRU5EOlZDQUxFTkRBUg0K",
+		"--inner--",
+		"--outer--",
+		"",
+	}, "\r\n")
+	events := emailMeetings([]byte(eml), mimeTypeEML, nil)
+	if len(events) != 1 || events[0].Summary != "Budget review" {
+		t.Fatalf("unexpected meetings %+v", events)
+	}
+	read := func() ([]byte, error) { return []byte(eml), nil }
+	for _, config := range []*ExtractionConfig{nil, NewExtractionConfig(), NewExtractionConfig(WithMeetings(false))} {
+		result := &ExtractionResult{MimeType: mimeTypeEML}
+		if err := applyEmailStages(result, read, config); err != nil || result.Metadata.Format.Email != nil {
+			t.Fatalf("expected meetings to be left out unless enabled, got %+v, %v", result.Metadata.Format, err)
+		}
+	}
+	result := &ExtractionResult{MimeType: mimeTypeEML}
+	if err := applyEmailStages(result, read, NewExtractionConfig(WithMeetings(true))); err != nil {
+		t.Fatal(err)
+	}
+	if email := result.Metadata.Format.Email; email == nil || len(email.Meetings) != 1 {
+		t.Fatalf("expected the meeting in the metadata, got %+v", result.Metadata.Format)
+	}
+}
@@ -0,0 +1,540 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chat export formats read by ExtractChat.
+const (
+	// ChatFormatWhatsApp is the text transcript written by WhatsApp's "Export chat".
+	ChatFormatWhatsApp = "whatsapp"
+	// ChatFormatSlack is a Slack workspace export: per-channel folders of daily JSON files.
+	ChatFormatSlack = "slack"
+	// ChatFormatTeams is a list of Microsoft Graph chatMessage objects, as returned by the
+	// Teams chat and channel message APIs.
+	ChatFormatTeams = "teams"
+)
+
+// Defaults applied to unset ChatConfig fields.
+const (
+	defaultChatWindowGapSeconds = 30 * 60
+	defaultChatMaxWindowChars   = 2000
+)
+
+// ChatResult holds the messages of a chat export. Content renders one message per line,
+// "[2006-01-02 15:04] Sender: text", and Chunks splits it into conversation windows: runs
+// of messages in one channel without a long silence.
+type ChatResult struct {
+	Format   string        `json:"format"`
+	Messages []ChatMessage `json:"messages"`
+	Content  string        `json:"content"`
+	Chunks   []Chunk       `json:"chunks,omitempty"`
+}
+
+// ChatMessage is one message of a chat export. Sender is empty for system messages.
+// WhatsApp exports carry no time zone, so their timestamps are the wall-clock time of the
+// exporting device, expressed in UTC.
+type ChatMessage struct {
+	Channel     string         `json:"channel,omitempty"`
+	Sender      string         `json:"sender,omitempty"`
+	Timestamp   time.Time      `json:"timestamp,omitzero"`
+	Text        string         `json:"text"`
+	Reactions   []ChatReaction `json:"reactions,omitempty"`
+	Attachments []string       `json:"attachments,omitempty"`
+}
+
+// ChatReaction counts the users who reacted to a message with Name, the emoji or reaction
+// name as the platform exports it.
+type ChatReaction struct {
+	Name  string   `json:"name"`
+	Users []string `json:"users,omitempty"`
+	Count int      `json:"count"`
+}
+
+var (
+	// whatsAppLinePattern matches the first line of a message in the iOS form
+	// "[31/12/23, 21:15:02] Alice: hi" and the Android form "31/12/23, 21:15 - Alice: hi".
+	whatsAppLinePattern     = regexp.MustCompile(`^\x{200e}?\[?(\d{1,4})[./-](\d{1,2})[./-](\d{1,4}),?\s(\d{1,2}:\d{2}(?::\d{2})?(?:[\s\x{202f}]?[AaPp]\.?[Mm]\.?)?)\]?(?:\s-)?\s(.*)$`)
+	whatsAppAttachedPattern = regexp.MustCompile(`^\x{200e}?<attached: ([^>]+)>$`)
+	whatsAppFilePattern     = regexp.MustCompile(`^\x{200e}?(.+) \(file attached\)$`)
+	slackMentionPattern     = regexp.MustCompile(`<@([A-Z0-9]+)(?:\|([^>]*))?>`)
+	htmlTagPattern          = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// ExtractChat reads the chat export at path: a WhatsApp transcript, a Slack export folder,
+// a Teams JSON file, or a ZIP archive holding any of them.
+func ExtractChat(path string, config *ExtractionConfig) (*ChatResult, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read chat export", err, ErrorCodeIo, nil)
+	}
+	if info.IsDir() {
+		return extractChatFS(os.DirFS(path), config)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read chat export", err, ErrorCodeIo, nil)
+	}
+	if bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, newParsingErrorWithContext("failed to open chat export archive", err, ErrorCodeParsing, nil)
+		}
+		return extractChatFS(archive, config)
+	}
+	return ExtractChatBytes(data, "", config)
+}
+
+// ExtractChatBytes parses a single-file chat export held in memory. An empty format is
+// detected from the content: a JSON array of Slack messages, other JSON as Graph
+// chatMessage objects, and anything else as a WhatsApp transcript.
+func ExtractChatBytes(data []byte, format string, config *ExtractionConfig) (*ChatResult, error) {
+	if format == "" {
+		format = detectChatFormat(data)
+	}
+	var messages []ChatMessage
+	var err error
+	switch format {
+	case ChatFormatWhatsApp:
+		messages = parseWhatsApp(data)
+	case ChatFormatSlack:
+		messages, err = parseSlack(data, "", nil)
+	case ChatFormatTeams:
+		messages, err = parseTeams(data)
+	default:
+		return nil, newValidationErrorWithContext(fmt.Sprintf("unknown chat format: %s", format), nil, ErrorCodeValidation, nil)
+	}
+	if err != nil {
+		return nil, newParsingErrorWithContext("failed to parse chat export", err, ErrorCodeParsing, nil)
+	}
+	return newChatResult(format, messages, config)
+}
+
+// extractChatFS reads an exported folder or archive: a Slack export when it holds
+// channel folders of JSON files, or else its WhatsApp transcript or Teams JSON file.
+func extractChatFS(fsys fs.FS, config *ExtractionConfig) (*ChatResult, error) {
+	var files []string
+	err := fs.WalkDir(fsys, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, name)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read chat export", err, ErrorCodeIo, nil)
+	}
+	sort.Strings(files)
+
+	var channelFiles, transcripts []string
+	for _, name := range files {
+		switch {
+		case strings.HasSuffix(name, ".json") && strings.Contains(name, "/"):
+			channelFiles = append(channelFiles, name)
+		case strings.HasSuffix(name, ".txt"), strings.HasSuffix(name, ".json"):
+			transcripts = append(transcripts, name)
+		}
+	}
+
+	if len(channelFiles) > 0 {
+		users := slackUsers(fsys)
+		var messages []ChatMessage
+		for _, name := range channelFiles {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return nil, newIOErrorWithContext("failed to read chat export", err, ErrorCodeIo, nil)
+			}
+			parsed, err := parseSlack(data, path.Base(path.Dir(name)), users)
+			if err != nil {
+				return nil, newParsingErrorWithContext(fmt.Sprintf("failed to parse %s", name), err, ErrorCodeParsing, nil)
+			}
+			messages = append(messages, parsed...)
+		}
+		return newChatResult(ChatFormatSlack, messages, config)
+	}
+	for _, name := range transcripts {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, newIOErrorWithContext("failed to read chat export", err, ErrorCodeIo, nil)
+		}
+		if strings.HasSuffix(name, ".txt") || detectChatFormat(data) == ChatFormatTeams {
+			return ExtractChatBytes(data, "", config)
+		}
+	}
+	return nil, newValidationErrorWithContext("no chat transcript found in export", nil, ErrorCodeValidation, nil)
+}
+
+func detectChatFormat(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	if len(trimmed) == 0 || !json.Valid(trimmed) {
+		return ChatFormatWhatsApp
+	}
+	if trimmed[0] == '[' && !bytes.Contains(trimmed, []byte(`"createdDateTime"`)) {
+		return ChatFormatSlack
+	}
+	return ChatFormatTeams
+}
+
+func validateChatConfig(cfg *ChatConfig) error {
+	if cfg.WindowGapSeconds != nil && *cfg.WindowGapSeconds < 0 {
+		return newValidationErrorWithContext("chat window_gap_seconds cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	if cfg.MaxWindowChars != nil && *cfg.MaxWindowChars < 0 {
+		return newValidationErrorWithContext("chat max_window_chars cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// newChatResult orders messages by time, keeping export order for equal timestamps, and
+// renders them into content and conversation windows.
+func newChatResult(format string, messages []ChatMessage, config *ExtractionConfig) (*ChatResult, error) {
+	gap := time.Duration(defaultChatWindowGapSeconds) * time.Second
+	maxChars := defaultChatMaxWindowChars
+	if config != nil && config.Chat != nil {
+		if err := validateChatConfig(config.Chat); err != nil {
+			return nil, err
+		}
+		if config.Chat.WindowGapSeconds != nil {
+			gap = time.Duration(*config.Chat.WindowGapSeconds) * time.Second
+		}
+		if config.Chat.MaxWindowChars != nil {
+			maxChars = *config.Chat.MaxWindowChars
+		}
+	}
+	sort.SliceStable(messages, func(i, j int) bool {
+		if messages[i].Channel != messages[j].Channel {
+			return messages[i].Channel < messages[j].Channel
+		}
+		return messages[i].Timestamp.Before(messages[j].Timestamp)
+	})
+
+	result := &ChatResult{Format: format, Messages: messages}
+	var content strings.Builder
+	windowStart := 0
+	closeWindow := func() {
+		if content.Len() > windowStart {
+			window := strings.TrimSuffix(content.String()[windowStart:], "\n")
+			result.Chunks = append(result.Chunks, Chunk{
+				Content:  window,
+				Metadata: ChunkMetadata{ByteStart: uint64(windowStart), ByteEnd: uint64(windowStart + len(window))},
+			})
+		}
+		windowStart = content.Len()
+	}
+	for i, message := range messages {
+		line := renderChatMessage(message)
+		if i > 0 {
+			previous := messages[i-1]
+			switch {
+			case message.Channel != previous.Channel,
+				gap > 0 && message.Timestamp.Sub(previous.Timestamp) > gap,
+				maxChars > 0 && content.Len()-windowStart+len(line) > maxChars:
+				closeWindow()
+			}
+		}
+		content.WriteString(line)
+	}
+	closeWindow()
+
+	result.Content = strings.TrimSuffix(content.String(), "\n")
+	for i := range result.Chunks {
+		result.Chunks[i].Metadata.ChunkIndex = i
+		result.Chunks[i].Metadata.TotalChunks = len(result.Chunks)
+	}
+	return result, nil
+}
+
+// renderChatMessage renders message as a newline-terminated line of Content.
+func renderChatMessage(message ChatMessage) string {
+	var b strings.Builder
+	if !message.Timestamp.IsZero() {
+		b.WriteString("[" + message.Timestamp.Format("2006-01-02 15:04") + "] ")
+	}
+	if message.Sender != "" {
+		b.WriteString(message.Sender + ": ")
+	}
+	b.WriteString(message.Text)
+	for _, name := range message.Attachments {
+		fmt.Fprintf(&b, " (attachment: %s)", name)
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// whatsAppHeader is the first line of a WhatsApp message, with the date left unparsed
+// until the day/month order of the whole transcript is known.
+type whatsAppHeader struct {
+	date [3]int
+	time string
+	rest string
+}
+
+// parseWhatsApp parses a WhatsApp transcript. Dates are day-first unless a month field
+// greater than 12 shows the export used the US month-first order; four-digit leading
+// fields are read as year-month-day.
+func parseWhatsApp(data []byte) []ChatMessage {
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+	var headers []whatsAppHeader
+	for _, line := range strings.Split(text, "\n") {
+		if match := whatsAppLinePattern.FindStringSubmatch(line); match != nil {
+			header := whatsAppHeader{time: match[4], rest: match[5]}
+			for i := range header.date {
+				header.date[i], _ = strconv.Atoi(match[i+1])
+			}
+			headers = append(headers, header)
+			continue
+		}
+		if len(headers) > 0 {
+			last := &headers[len(headers)-1]
+			last.rest += "\n" + line
+		}
+	}
+
+	dayFirstSeen, monthFirstSeen := false, false
+	for _, header := range headers {
+		dayFirstSeen = dayFirstSeen || (header.date[0] > 12 && header.date[0] < 1000)
+		monthFirstSeen = monthFirstSeen || header.date[1] > 12
+	}
+	monthFirst := monthFirstSeen && !dayFirstSeen
+
+	messages := make([]ChatMessage, 0, len(headers))
+	for _, header := range headers {
+		message := ChatMessage{Timestamp: whatsAppTime(header, monthFirst), Text: strings.TrimRight(header.rest, "\n")}
+		if sender, body, ok := strings.Cut(message.Text, ": "); ok && !strings.Contains(sender, "\n") {
+			message.Sender = strings.TrimPrefix(sender, "\u200e")
+			message.Text = body
+		}
+		if match := whatsAppAttachedPattern.FindStringSubmatch(message.Text); match != nil {
+			message.Attachments = []string{match[1]}
+			message.Text = ""
+		} else if match := whatsAppFilePattern.FindStringSubmatch(message.Text); match != nil {
+			message.Attachments = []string{match[1]}
+			message.Text = ""
+		}
+		messages = append(messages, message)
+	}
+	return messages
+}
+
+func whatsAppTime(header whatsAppHeader, monthFirst bool) time.Time {
+	year, month, day := header.date[2], header.date[1], header.date[0]
+	switch {
+	case header.date[0] >= 1000:
+		year, month, day = header.date[0], header.date[1], header.date[2]
+	case monthFirst:
+		month, day = header.date[0], header.date[1]
+	}
+	if year < 100 {
+		year += 2000
+	}
+	clock := strings.NewReplacer("\u202f", " ", ".", "").Replace(strings.ToUpper(header.time))
+	for _, layout := range []string{"15:04:05", "15:04", "3:04:05 PM", "3:04 PM", "3:04:05PM", "3:04PM"} {
+		if t, err := time.Parse(layout, clock); err == nil {
+			return time.Date(year, time.Month(month), day, t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+		}
+	}
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// slackMessage is a message of a Slack export channel file.
+type slackMessage struct {
+	Type        string `json:"type"`
+	Subtype     string `json:"subtype"`
+	User        string `json:"user"`
+	Username    string `json:"username"`
+	UserProfile struct {
+		RealName    string `json:"real_name"`
+		DisplayName string `json:"display_name"`
+	} `json:"user_profile"`
+	Text      string `json:"text"`
+	Timestamp string `json:"ts"`
+	Reactions []struct {
+		Name  string   `json:"name"`
+		Users []string `json:"users"`
+		Count int      `json:"count"`
+	} `json:"reactions"`
+	Files []struct {
+		Name  string `json:"name"`
+		Title string `json:"title"`
+	} `json:"files"`
+}
+
+// slackUsers maps user IDs to names from the users.json file of a Slack export.
+func slackUsers(fsys fs.FS) map[string]string {
+	data, err := fs.ReadFile(fsys, "users.json")
+	if err != nil {
+		return nil
+	}
+	var users []struct {
+		ID       string `json:"id"`
+		Name     string `json:"name"`
+		RealName string `json:"real_name"`
+	}
+	if json.Unmarshal(data, &users) != nil {
+		return nil
+	}
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.ID] = cmp.Or(user.RealName, user.Name)
+	}
+	return names
+}
+
+// parseSlack parses one channel file of a Slack export. User IDs are resolved through
+// users, then through the profiles embedded in the messages themselves.
+func parseSlack(data []byte, channel string, users map[string]string) ([]ChatMessage, error) {
+	var raw []slackMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(users))
+	for id, name := range users {
+		names[id] = name
+	}
+	for _, message := range raw {
+		if _, ok := names[message.User]; !ok && message.User != "" {
+			if name := cmp.Or(message.UserProfile.RealName, message.UserProfile.DisplayName); name != "" {
+				names[message.User] = name
+			}
+		}
+	}
+	userName := func(id string) string { return cmp.Or(names[id], id) }
+
+	messages := make([]ChatMessage, 0, len(raw))
+	for _, message := range raw {
+		if message.Type != "" && message.Type != "message" {
+			continue
+		}
+		parsed := ChatMessage{
+			Channel:   channel,
+			Sender:    cmp.Or(names[message.User], message.Username, message.User),
+			Timestamp: slackTime(message.Timestamp),
+			Text: slackMentionPattern.ReplaceAllStringFunc(message.Text, func(mention string) string {
+				match := slackMentionPattern.FindStringSubmatch(mention)
+				return "@" + cmp.Or(match[2], userName(match[1]))
+			}),
+		}
+		parsed.Text = html.UnescapeString(parsed.Text)
+		for _, reaction := range message.Reactions {
+			users := make([]string, len(reaction.Users))
+			for i, id := range reaction.Users {
+				users[i] = userName(id)
+			}
+			parsed.Reactions = append(parsed.Reactions, ChatReaction{Name: reaction.Name, Users: users, Count: max(reaction.Count, len(users))})
+		}
+		for _, file := range message.Files {
+			if name := cmp.Or(file.Name, file.Title); name != "" {
+				parsed.Attachments = append(parsed.Attachments, name)
+			}
+		}
+		messages = append(messages, parsed)
+	}
+	return messages, nil
+}
+
+// slackTime parses a Slack "seconds.microseconds" timestamp.
+func slackTime(ts string) time.Time {
+	seconds, fraction, _ := strings.Cut(ts, ".")
+	sec, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	micros, _ := strconv.ParseInt((fraction + "000000")[:6], 10, 64)
+	return time.Unix(sec, micros*int64(time.Microsecond)).UTC()
+}
+
+// teamsMessage is a Microsoft Graph chatMessage.
+type teamsMessage struct {
+	CreatedDateTime time.Time    `json:"createdDateTime"`
+	From            *teamsSender `json:"from"`
+	Body            struct {
+		ContentType string `json:"contentType"`
+		Content     string `json:"content"`
+	} `json:"body"`
+	Reactions []struct {
+		ReactionType string       `json:"reactionType"`
+		User         *teamsSender `json:"user"`
+	} `json:"reactions"`
+	Attachments []struct {
+		Name string `json:"name"`
+	} `json:"attachments"`
+}
+
+type teamsSender struct {
+	User        *struct{ DisplayName string } `json:"user"`
+	Application *struct{ DisplayName string } `json:"application"`
+}
+
+func (s *teamsSender) name() string {
+	switch {
+	case s == nil:
+		return ""
+	case s.User != nil:
+		return s.User.DisplayName
+	case s.Application != nil:
+		return s.Application.DisplayName
+	}
+	return ""
+}
+
+// parseTeams parses Graph chatMessage objects given as a JSON array or as the "value" or
+// "messages" list of an object.
+func parseTeams(data []byte) ([]ChatMessage, error) {
+	var raw []teamsMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		var page struct {
+			Value    []teamsMessage `json:"value"`
+			Messages []teamsMessage `json:"messages"`
+		}
+		if json.Unmarshal(data, &page) != nil {
+			return nil, err
+		}
+		raw = append(page.Value, page.Messages...)
+	}
+	if raw == nil {
+		return nil, errors.New("no Teams messages found")
+	}
+
+	messages := make([]ChatMessage, 0, len(raw))
+	for _, message := range raw {
+		text := message.Body.Content
+		if strings.EqualFold(message.Body.ContentType, "html") {
+			text = strings.NewReplacer("<br>", "\n", "<br/>", "\n", "</p>", "\n").Replace(text)
+			text = strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(text, "")))
+		}
+		parsed := ChatMessage{Sender: message.From.name(), Timestamp: message.CreatedDateTime.UTC(), Text: text}
+		reactions := make(map[string]int)
+		for _, reaction := range message.Reactions {
+			index, ok := reactions[reaction.ReactionType]
+			if !ok {
+				index = len(parsed.Reactions)
+				reactions[reaction.ReactionType] = index
+				parsed.Reactions = append(parsed.Reactions, ChatReaction{Name: reaction.ReactionType})
+			}
+			parsed.Reactions[index].Count++
+			if name := reaction.User.name(); name != "" {
+				parsed.Reactions[index].Users = append(parsed.Reactions[index].Users, name)
+			}
+		}
+		for _, attachment := range message.Attachments {
+			if attachment.Name != "" {
+				parsed.Attachments = append(parsed.Attachments, attachment.Name)
+			}
+		}
+		messages = append(messages, parsed)
+	}
+	return messages, nil
+}
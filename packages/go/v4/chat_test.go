@@ -0,0 +1,197 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWhatsAppIOS(t *testing.T) {
+	transcript := "[31/12/23, 21:15:02] Alice: Happy new year\n" +
+		"see you soon\n" +
+		"[31/12/23, 21:16:40] Bob: ‎<attached: 00000012-PHOTO-2023-12-31.jpg>\n" +
+		"[01/01/24, 09:00:00] Messages and calls are end-to-end encrypted.\n"
+	result, err := ExtractChatBytes([]byte(transcript), "", nil)
+	if err != nil {
+		t.Fatalf("ExtractChatBytes: %v", err)
+	}
+	if result.Format != ChatFormatWhatsApp || len(result.Messages) != 3 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	first := result.Messages[0]
+	if first.Sender != "Alice" || first.Text != "Happy new year\nsee you soon" {
+		t.Fatalf("unexpected first message: %+v", first)
+	}
+	if want := time.Date(2023, 12, 31, 21, 15, 2, 0, time.UTC); !first.Timestamp.Equal(want) {
+		t.Fatalf("timestamp = %v, want %v", first.Timestamp, want)
+	}
+	if got := result.Messages[1].Attachments; len(got) != 1 || got[0] != "00000012-PHOTO-2023-12-31.jpg" {
+		t.Fatalf("unexpected attachments: %v", got)
+	}
+	if system := result.Messages[2]; system.Sender != "" {
+		t.Fatalf("system message has sender %q", system.Sender)
+	}
+}
+
+func TestParseWhatsAppAndroidMonthFirst(t *testing.T) {
+	transcript := "12/31/23, 9:15 PM - Alice: hi\n" +
+		"1/1/24, 10:05 AM - Bob: IMG-20240101-WA0001.jpg (file attached)\n"
+	messages := parseWhatsApp([]byte(transcript))
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if want := time.Date(2023, 12, 31, 21, 15, 0, 0, time.UTC); !messages[0].Timestamp.Equal(want) {
+		t.Fatalf("timestamp = %v, want %v", messages[0].Timestamp, want)
+	}
+	if want := time.Date(2024, 1, 1, 10, 5, 0, 0, time.UTC); !messages[1].Timestamp.Equal(want) {
+		t.Fatalf("timestamp = %v, want %v", messages[1].Timestamp, want)
+	}
+	if got := messages[1].Attachments; len(got) != 1 || got[0] != "IMG-20240101-WA0001.jpg" {
+		t.Fatalf("unexpected attachments: %v", got)
+	}
+}
+
+func TestParseSlackExportFolder(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("users.json", `[{"id":"U1","name":"alice","real_name":"Alice Smith"},{"id":"U2","name":"bob"}]`)
+	writeFile("general/2024-01-01.json", `[
+		{"type":"message","user":"U1","text":"hello <@U2> &amp; all","ts":"1704103200.000100",
+		 "reactions":[{"name":"wave","users":["U2"],"count":1}],
+		 "files":[{"name":"agenda.pdf"}]},
+		{"type":"message","user":"U2","text":"hi","ts":"1704103260.000200"}
+	]`)
+
+	result, err := ExtractChat(dir, nil)
+	if err != nil {
+		t.Fatalf("ExtractChat: %v", err)
+	}
+	if result.Format != ChatFormatSlack || len(result.Messages) != 2 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	first := result.Messages[0]
+	if first.Channel != "general" || first.Sender != "Alice Smith" || first.Text != "hello @bob & all" {
+		t.Fatalf("unexpected message: %+v", first)
+	}
+	if len(first.Reactions) != 1 || first.Reactions[0].Name != "wave" || first.Reactions[0].Users[0] != "bob" {
+		t.Fatalf("unexpected reactions: %+v", first.Reactions)
+	}
+	if len(first.Attachments) != 1 || first.Attachments[0] != "agenda.pdf" {
+		t.Fatalf("unexpected attachments: %v", first.Attachments)
+	}
+	if want := time.Unix(1704103200, 100_000).UTC(); !first.Timestamp.Equal(want) {
+		t.Fatalf("timestamp = %v, want %v", first.Timestamp, want)
+	}
+}
+
+func TestParseTeamsMessages(t *testing.T) {
+	data := `{"value":[
+		{"createdDateTime":"2024-03-01T10:00:00Z","from":{"user":{"displayName":"Carol"}},
+		 "body":{"contentType":"html","content":"<p>Status &amp; plans</p>"},
+		 "reactions":[{"reactionType":"like","user":{"user":{"displayName":"Dan"}}},
+		              {"reactionType":"like","user":{"user":{"displayName":"Eve"}}}],
+		 "attachments":[{"name":"plan.docx"}]}
+	]}`
+	result, err := ExtractChatBytes([]byte(data), "", nil)
+	if err != nil {
+		t.Fatalf("ExtractChatBytes: %v", err)
+	}
+	if result.Format != ChatFormatTeams || len(result.Messages) != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	message := result.Messages[0]
+	if message.Sender != "Carol" || message.Text != "Status & plans" {
+		t.Fatalf("unexpected message: %+v", message)
+	}
+	if len(message.Reactions) != 1 || message.Reactions[0].Count != 2 || len(message.Reactions[0].Users) != 2 {
+		t.Fatalf("unexpected reactions: %+v", message.Reactions)
+	}
+	if len(message.Attachments) != 1 || message.Attachments[0] != "plan.docx" {
+		t.Fatalf("unexpected attachments: %v", message.Attachments)
+	}
+}
+
+func TestChatWindows(t *testing.T) {
+	transcript := "[01/01/24, 09:00:00] Alice: morning\n" +
+		"[01/01/24, 09:05:00] Bob: morning\n" +
+		"[01/01/24, 14:00:00] Alice: lunch?\n"
+	result, err := ExtractChatBytes([]byte(transcript), ChatFormatWhatsApp, nil)
+	if err != nil {
+		t.Fatalf("ExtractChatBytes: %v", err)
+	}
+	if len(result.Chunks) != 2 {
+		t.Fatalf("expected 2 windows, got %d: %+v", len(result.Chunks), result.Chunks)
+	}
+	for i, chunk := range result.Chunks {
+		if got := result.Content[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]; got != chunk.Content {
+			t.Errorf("chunk %d offsets select %q, want %q", i, got, chunk.Content)
+		}
+		if chunk.Metadata.ChunkIndex != i || chunk.Metadata.TotalChunks != 2 {
+			t.Errorf("chunk %d has index %d of %d", i, chunk.Metadata.ChunkIndex, chunk.Metadata.TotalChunks)
+		}
+	}
+	if !strings.HasPrefix(result.Chunks[1].Content, "[2024-01-01 14:00] Alice: lunch?") {
+		t.Fatalf("unexpected second window: %q", result.Chunks[1].Content)
+	}
+
+	cfg := NewExtractionConfig(WithChat(WithWindowGap(10*time.Hour), WithMaxWindowChars(40)))
+	result, err = ExtractChatBytes([]byte(transcript), ChatFormatWhatsApp, cfg)
+	if err != nil {
+		t.Fatalf("ExtractChatBytes: %v", err)
+	}
+	if len(result.Chunks) != 3 {
+		t.Fatalf("expected size-limited windows, got %d", len(result.Chunks))
+	}
+}
+
+func TestExtractChatZip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "WhatsApp Chat.zip")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := zip.NewWriter(file)
+	entry, err := archive.Create("_chat.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := entry.Write([]byte("[01/01/24, 09:00:00] Alice: hi\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := ExtractChat(path, nil)
+	if err != nil {
+		t.Fatalf("ExtractChat: %v", err)
+	}
+	if len(result.Messages) != 1 || result.Messages[0].Sender != "Alice" {
+		t.Fatalf("unexpected messages: %+v", result.Messages)
+	}
+}
+
+func TestChatConfigValidation(t *testing.T) {
+	cfg := NewExtractionConfig(WithChat(WithMaxWindowChars(-1)))
+	if _, err := ExtractChatBytes([]byte("[01/01/24, 09:00:00] Alice: hi"), "", cfg); err == nil {
+		t.Fatal("expected validation error")
+	}
+	if _, err := ExtractChatBytes([]byte("x"), "irc", nil); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+}
@@ -0,0 +1,355 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Supported values for ChunkingConfig.Strategy.
+const (
+	// ChunkingStrategyDefault uses the native character-based chunker.
+	ChunkingStrategyDefault = ""
+	// ChunkingStrategyMarkdownStructure chunks along the document's heading structure and
+	// never splits fenced code blocks or tables. Chunks carry their heading path.
+	ChunkingStrategyMarkdownStructure = "markdown_structure"
+)
+
+// defaultStructureChunkChars mirrors the native default chunk size.
+const defaultStructureChunkChars = 1000
+
+type structureBlockKind int
+
+const (
+	blockParagraph structureBlockKind = iota
+	blockHeading
+	blockCode
+	blockTable
+)
+
+// structureBlock is a contiguous region of Content with a structural role.
+type structureBlock struct {
+	kind  structureBlockKind
+	start int
+	end   int
+	level int
+	text  string
+}
+
+// usesStructureChunking reports whether chunking is performed by the binding instead of the core.
+func usesStructureChunking(config *ExtractionConfig) bool {
+	return config != nil && config.Chunking != nil && config.Chunking.Strategy == ChunkingStrategyMarkdownStructure
+}
+
+// validateChunkingStrategy rejects unknown strategies and option combinations the
+// structure-aware chunker cannot honour.
+func validateChunkingStrategy(cfg *ChunkingConfig) error {
+	switch cfg.Strategy {
+	case ChunkingStrategyDefault:
+	case ChunkingStrategyMarkdownStructure:
+		if cfg.Embedding != nil {
+			return newValidationErrorWithContext(
+				"embeddings are not supported with the markdown_structure chunking strategy",
+				nil, ErrorCodeValidation, nil)
+		}
+	default:
+		return newValidationErrorWithContext(
+			fmt.Sprintf("invalid chunking strategy: %s", cfg.Strategy), nil, ErrorCodeValidation, nil)
+	}
+	return validateTablePolicy(cfg)
+}
+
+// structureChunkLimit returns the maximum number of characters per chunk.
+func structureChunkLimit(cfg *ChunkingConfig) int {
+	switch {
+	case cfg.MaxChars != nil && *cfg.MaxChars > 0:
+		return *cfg.MaxChars
+	case cfg.ChunkSize != nil && *cfg.ChunkSize > 0:
+		return *cfg.ChunkSize
+	default:
+		return defaultStructureChunkChars
+	}
+}
+
+// knownHeadings collects heading texts reported in format metadata or detected by the
+// heading stage so that headings in non-Markdown output can be recognized. The value is
+// the heading level.
+func knownHeadings(result *ExtractionResult) map[string]int {
+	headings := map[string]int{}
+	meta := result.Metadata
+	if text, ok := meta.TextMetadata(); ok {
+		for _, header := range text.Headers {
+			headings[strings.TrimSpace(header)] = 1
+		}
+	}
+	if html, ok := meta.HTMLMetadata(); ok {
+		for _, header := range html.Headers {
+			headings[strings.TrimSpace(header.Text)] = int(header.Level)
+		}
+	}
+	walkHeadings(result.Headings, func(heading *Heading) {
+		headings[heading.Text] = heading.Level
+	})
+	delete(headings, "")
+	return headings
+}
+
+// parseATXHeading recognizes Markdown "# Heading" lines.
+func parseATXHeading(line string) (int, string, bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 {
+		return 0, "", false
+	}
+	level := 0
+	for level < len(trimmed) && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := trimmed[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false
+	}
+	text := strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#"))
+	return level, text, true
+}
+
+// fenceMarker returns the fence opening a code block ("```" or "~~~"), if any.
+func fenceMarker(line string) string {
+	trimmed := strings.TrimLeft(line, " ")
+	for _, marker := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, marker) {
+			return marker
+		}
+	}
+	return ""
+}
+
+// parseStructureBlocks splits content into headings, fenced code blocks, tables, and
+// paragraphs. Blank lines separate blocks and are not part of any block.
+func parseStructureBlocks(content string, headings map[string]int) []structureBlock {
+	var blocks []structureBlock
+	var open *structureBlock
+	fence := ""
+
+	closeOpen := func() {
+		if open != nil {
+			blocks = append(blocks, *open)
+			open = nil
+		}
+	}
+
+	forEachLine(content, func(pos int, line string) {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case fence != "":
+			open.end = pos + len(line)
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) {
+				fence = ""
+				closeOpen()
+			}
+		case fenceMarker(line) != "":
+			closeOpen()
+			fence = fenceMarker(line)
+			open = &structureBlock{kind: blockCode, start: pos, end: pos + len(line)}
+		case trimmed == "":
+			closeOpen()
+		case strings.HasPrefix(trimmed, "|"):
+			if open == nil || open.kind != blockTable {
+				closeOpen()
+				open = &structureBlock{kind: blockTable, start: pos}
+			}
+			open.end = pos + len(line)
+		default:
+			level, text, isHeading := parseATXHeading(line)
+			if !isHeading {
+				level, isHeading = headings[trimmed]
+				text = trimmed
+			}
+			if isHeading {
+				closeOpen()
+				blocks = append(blocks, structureBlock{kind: blockHeading, start: pos, end: pos + len(line), level: level, text: text})
+				break
+			}
+			if open == nil || open.kind != blockParagraph {
+				closeOpen()
+				open = &structureBlock{kind: blockParagraph, start: pos}
+			}
+			open.end = pos + len(line)
+		}
+	})
+	closeOpen()
+	return blocks
+}
+
+// wordRanges splits the byte range [start, end) of content into whitespace-delimited words.
+func wordRanges(content string, start, end int) [][2]int {
+	var words [][2]int
+	wordStart := -1
+	for i := start; i < end; {
+		r, size := utf8.DecodeRuneInString(content[i:])
+		if unicode.IsSpace(r) {
+			if wordStart >= 0 {
+				words = append(words, [2]int{wordStart, i})
+				wordStart = -1
+			}
+		} else if wordStart < 0 {
+			wordStart = i
+		}
+		i += size
+	}
+	if wordStart >= 0 {
+		words = append(words, [2]int{wordStart, end})
+	}
+	return words
+}
+
+// structureChunker packs structure blocks into chunks.
+type structureChunker struct {
+	content     string
+	limit       int
+	chunks      Chunks
+	start       int
+	end         int
+	chars       int
+	active      bool
+	headingOnly bool
+	path        []string
+	stack       []structureBlock
+}
+
+func (c *structureChunker) flush() {
+	if !c.active {
+		return
+	}
+	c.chunks = append(c.chunks, Chunk{
+		Content: c.content[c.start:c.end],
+		Metadata: ChunkMetadata{
+			ByteStart:   uint64(c.start),
+			ByteEnd:     uint64(c.end),
+			HeadingPath: c.path,
+		},
+	})
+	c.active = false
+}
+
+// fits reports whether extending the current chunk to end stays within the limit.
+func (c *structureChunker) fits(end int) bool {
+	if !c.active {
+		return true
+	}
+	return c.chars+utf8.RuneCountInString(c.content[c.end:end]) <= c.limit
+}
+
+func (c *structureChunker) add(start, end int) {
+	if !c.active {
+		c.start = start
+		c.chars = 0
+		c.end = start
+		c.active = true
+		c.headingOnly = false
+		c.path = c.currentPath()
+	}
+	c.chars += utf8.RuneCountInString(c.content[c.end:end])
+	c.end = end
+}
+
+func (c *structureChunker) currentPath() []string {
+	if len(c.stack) == 0 {
+		return nil
+	}
+	path := make([]string, len(c.stack))
+	for i, heading := range c.stack {
+		path[i] = heading.text
+	}
+	return path
+}
+
+func (c *structureChunker) heading(block structureBlock) {
+	c.flush()
+	for len(c.stack) > 0 && c.stack[len(c.stack)-1].level >= block.level {
+		c.stack = c.stack[:len(c.stack)-1]
+	}
+	c.stack = append(c.stack, block)
+	c.add(block.start, block.end)
+	c.headingOnly = true
+}
+
+func (c *structureChunker) atomic(block structureBlock) {
+	if !c.fits(block.end) && !c.headingOnly {
+		c.flush()
+	}
+	c.add(block.start, block.end)
+	c.headingOnly = false
+}
+
+func (c *structureChunker) paragraph(block structureBlock) {
+	blockChars := utf8.RuneCountInString(c.content[block.start:block.end])
+	switch {
+	case c.fits(block.end):
+		c.add(block.start, block.end)
+	case blockChars <= c.limit && !c.headingOnly:
+		c.flush()
+		c.add(block.start, block.end)
+	default:
+		for _, word := range wordRanges(c.content, block.start, block.end) {
+			if !c.fits(word[1]) && !c.headingOnly {
+				c.flush()
+			}
+			c.add(word[0], word[1])
+			c.headingOnly = false
+		}
+	}
+	c.headingOnly = false
+}
+
+// chunkByStructure splits result.Content along its heading structure.
+func chunkByStructure(result *ExtractionResult, cfg *ChunkingConfig) Chunks {
+	if result.Content == "" {
+		return nil
+	}
+	chunker := &structureChunker{content: result.Content, limit: structureChunkLimit(cfg)}
+	for _, block := range parseStructureBlocks(result.Content, knownHeadings(result)) {
+		switch block.kind {
+		case blockHeading:
+			chunker.heading(block)
+		case blockCode, blockTable:
+			chunker.atomic(block)
+		default:
+			chunker.paragraph(block)
+		}
+	}
+	chunker.flush()
+
+	for i := range chunker.chunks {
+		chunker.chunks[i].Metadata.ChunkIndex = i
+		chunker.chunks[i].Metadata.TotalChunks = len(chunker.chunks)
+	}
+	return chunker.chunks
+}
+
+// applyChunkStages runs the binding-side chunking stages: structure-aware chunking when
+// selected, the table policy, and page attribution. Chunks created by the binding get
+// their first and last page from the page spans when page boundaries are available.
+func applyChunkStages(result *ExtractionResult, cfg *ChunkingConfig) {
+	if code, ok := result.Metadata.CodeMetadata(); ok {
+		result.Chunks = chunkCode(result.Content, code, cfg)
+	} else if cfg.Strategy == ChunkingStrategyMarkdownStructure {
+		result.Chunks = chunkByStructure(result, cfg)
+	}
+	applyTablePolicy(result, cfg)
+	assignChunkPageSpans(result)
+	for i := range result.Chunks {
+		meta := &result.Chunks[i].Metadata
+		if meta.FirstPage != nil || len(meta.PageSpans) == 0 {
+			continue
+		}
+		first := meta.PageSpans[0].PageNumber
+		last := meta.PageSpans[len(meta.PageSpans)-1].PageNumber
+		meta.FirstPage = &first
+		meta.LastPage = &last
+	}
+}
@@ -0,0 +1,166 @@
+package kreuzberg
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const structureTestDoc = `# Guide
+
+Intro paragraph.
+
+## Install
+
+Run the installer.
+
+` + "```sh\ngo get example.com/kreuzberg\n\ngo generate ./...\n```" + `
+
+## Tables
+
+| a | b |
+|---|---|
+| 1 | 2 |
+
+# Appendix
+
+Closing words.`
+
+func TestParseStructureBlocks(t *testing.T) {
+	blocks := parseStructureBlocks(structureTestDoc, nil)
+	var kinds []structureBlockKind
+	for _, block := range blocks {
+		kinds = append(kinds, block.kind)
+	}
+	want := []structureBlockKind{
+		blockHeading, blockParagraph,
+		blockHeading, blockParagraph, blockCode,
+		blockHeading, blockTable,
+		blockHeading, blockParagraph,
+	}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("unexpected block kinds: %v", kinds)
+	}
+	code := blocks[4]
+	if !strings.HasPrefix(structureTestDoc[code.start:code.end], "```sh") || !strings.HasSuffix(structureTestDoc[code.start:code.end], "```") {
+		t.Errorf("code block boundaries wrong: %q", structureTestDoc[code.start:code.end])
+	}
+}
+
+func TestChunkByStructureHeadingPaths(t *testing.T) {
+	result := &ExtractionResult{Content: structureTestDoc}
+	chunks := chunkByStructure(result, NewChunkingConfig(WithChunkingStrategy(ChunkingStrategyMarkdownStructure)))
+
+	want := [][]string{
+		{"Guide"},
+		{"Guide", "Install"},
+		{"Guide", "Tables"},
+		{"Appendix"},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %d: %+v", len(want), len(chunks), chunks)
+	}
+	for i, chunk := range chunks {
+		if !reflect.DeepEqual(chunk.Metadata.HeadingPath, want[i]) {
+			t.Errorf("chunk %d heading path = %v, want %v", i, chunk.Metadata.HeadingPath, want[i])
+		}
+		if got := structureTestDoc[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]; got != chunk.Content {
+			t.Errorf("chunk %d offsets do not match content", i)
+		}
+		if chunk.Metadata.ChunkIndex != i || chunk.Metadata.TotalChunks != len(want) {
+			t.Errorf("chunk %d has index %d/%d", i, chunk.Metadata.ChunkIndex, chunk.Metadata.TotalChunks)
+		}
+	}
+}
+
+func TestChunkByStructureKeepsCodeBlocksWhole(t *testing.T) {
+	result := &ExtractionResult{Content: structureTestDoc}
+	chunks := chunkByStructure(result, NewChunkingConfig(
+		WithChunkingStrategy(ChunkingStrategyMarkdownStructure),
+		WithMaxChars(20),
+	))
+	for _, chunk := range chunks {
+		if strings.Count(chunk.Content, "```")%2 != 0 {
+			t.Errorf("code fence split across chunks: %q", chunk.Content)
+		}
+		if strings.Contains(chunk.Content, "| a | b |") && !strings.Contains(chunk.Content, "| 1 | 2 |") {
+			t.Errorf("table split across chunks: %q", chunk.Content)
+		}
+	}
+}
+
+func TestChunkByStructureSplitsLongParagraphs(t *testing.T) {
+	content := "# Title\n\n" + strings.Repeat("word ", 50)
+	result := &ExtractionResult{Content: strings.TrimSpace(content)}
+	chunks := chunkByStructure(result, NewChunkingConfig(
+		WithChunkingStrategy(ChunkingStrategyMarkdownStructure),
+		WithMaxChars(40),
+	))
+	if len(chunks) < 5 {
+		t.Fatalf("expected the paragraph to be split, got %d chunks", len(chunks))
+	}
+	if !strings.HasPrefix(chunks[0].Content, "# Title\n\nword") {
+		t.Errorf("heading should stay with the following text: %q", chunks[0].Content)
+	}
+	for i, chunk := range chunks {
+		if len([]rune(chunk.Content)) > 40 {
+			t.Errorf("chunk %d exceeds limit: %q", i, chunk.Content)
+		}
+		if !reflect.DeepEqual(chunk.Metadata.HeadingPath, []string{"Title"}) {
+			t.Errorf("chunk %d heading path = %v", i, chunk.Metadata.HeadingPath)
+		}
+	}
+}
+
+func TestChunkByStructureUsesMetadataHeadings(t *testing.T) {
+	result := &ExtractionResult{
+		Content: "Overview\n\nSome text.\n\nDetails\n\nMore text.",
+		Metadata: Metadata{Format: FormatMetadata{
+			Type: FormatText,
+			Text: &TextMetadata{Headers: []string{"Overview", "Details"}},
+		}},
+	}
+	chunks := chunkByStructure(result, NewChunkingConfig(WithChunkingStrategy(ChunkingStrategyMarkdownStructure)))
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	if chunks[1].Metadata.HeadingPath[0] != "Details" {
+		t.Errorf("unexpected heading path: %v", chunks[1].Metadata.HeadingPath)
+	}
+}
+
+func TestStructureChunkingStage(t *testing.T) {
+	config := NewExtractionConfig(WithChunking(WithChunkingStrategy(ChunkingStrategyMarkdownStructure)))
+	if native := nativeConfig(config); native.Chunking != nil || config.Chunking == nil {
+		t.Fatal("native config should not request native chunking")
+	}
+
+	result := &ExtractionResult{
+		Content: structureTestDoc,
+		Metadata: Metadata{PageStructure: &PageStructure{
+			TotalCount: 1,
+			Boundaries: []PageBoundary{{ByteStart: 0, ByteEnd: uint64(len(structureTestDoc)), PageNumber: 1}},
+		}},
+	}
+	if err := runResultStages(result, config); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if len(result.Chunks) == 0 || result.Chunks[0].Metadata.FirstPage == nil || *result.Chunks[0].Metadata.FirstPage != 1 {
+		t.Fatalf("expected chunks with page information, got %+v", result.Chunks)
+	}
+}
+
+func TestValidateChunkingStrategy(t *testing.T) {
+	var validationErr *ValidationError
+	if err := validateChunkingStrategy(&ChunkingConfig{Strategy: "semantic"}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	withEmbedding := NewChunkingConfig(
+		WithChunkingStrategy(ChunkingStrategyMarkdownStructure),
+		WithEmbedding(),
+	)
+	if err := validateChunkingStrategy(withEmbedding); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for embeddings, got %v", err)
+	}
+}
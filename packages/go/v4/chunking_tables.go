@@ -0,0 +1,141 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Supported values for ChunkingConfig.TablePolicy.
+const (
+	// TablePolicyInline keeps Markdown tables in the surrounding chunks (default).
+	TablePolicyInline = "inline"
+	// TablePolicySeparate emits every table as a chunk of its own.
+	TablePolicySeparate = "separate"
+	// TablePolicySkip leaves tables out of the chunks entirely.
+	TablePolicySkip = "skip"
+)
+
+// validateTablePolicy rejects unknown table policies. Policies that reshape chunks are
+// applied after the core has chunked, so they cannot be combined with embeddings.
+func validateTablePolicy(cfg *ChunkingConfig) error {
+	switch cfg.TablePolicy {
+	case "", TablePolicyInline:
+		return nil
+	case TablePolicySeparate, TablePolicySkip:
+		if cfg.Embedding != nil {
+			return newValidationErrorWithContext(
+				fmt.Sprintf("embeddings are not supported with the %s table policy", cfg.TablePolicy),
+				nil, ErrorCodeValidation, nil)
+		}
+		return nil
+	default:
+		return newValidationErrorWithContext(
+			fmt.Sprintf("invalid table policy: %s", cfg.TablePolicy), nil, ErrorCodeValidation, nil)
+	}
+}
+
+// tableRanges returns the byte ranges of the Markdown tables in content.
+func tableRanges(content string) [][2]int {
+	var ranges [][2]int
+	for _, block := range parseStructureBlocks(content, nil) {
+		if block.kind == blockTable {
+			ranges = append(ranges, [2]int{block.start, block.end})
+		}
+	}
+	return ranges
+}
+
+// overlapsAny reports whether [start, end) intersects any of ranges.
+func overlapsAny(ranges [][2]int, start, end int) bool {
+	for _, r := range ranges {
+		if r[0] < end && start < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// applyTablePolicy flags table-bearing chunks and, for the separate and skip policies,
+// cuts tables out of the chunks. Chunk pieces left around a table become chunks of their
+// own; page information of those pieces is recomputed by the caller.
+func applyTablePolicy(result *ExtractionResult, cfg *ChunkingConfig) {
+	if len(result.Chunks) == 0 {
+		return
+	}
+	tables := tableRanges(result.Content)
+	if len(tables) == 0 {
+		return
+	}
+
+	if cfg.TablePolicy != TablePolicySeparate && cfg.TablePolicy != TablePolicySkip {
+		for i := range result.Chunks {
+			meta := &result.Chunks[i].Metadata
+			meta.ContainsTable = overlapsAny(tables, int(meta.ByteStart), int(meta.ByteEnd))
+		}
+		return
+	}
+
+	var chunks Chunks
+	emitted := make([]bool, len(tables))
+	for _, chunk := range result.Chunks {
+		start, end := int(chunk.Metadata.ByteStart), int(chunk.Metadata.ByteEnd)
+		if end > len(result.Content) || !overlapsAny(tables, start, end) {
+			chunks = append(chunks, chunk)
+			continue
+		}
+
+		pos := start
+		for i, table := range tables {
+			if table[1] <= start || table[0] >= end {
+				continue
+			}
+			chunks = appendChunkPiece(chunks, result.Content, chunk, pos, table[0])
+			if cfg.TablePolicy == TablePolicySeparate && !emitted[i] {
+				piece := derivedChunk(chunk, result.Content, table[0], table[1])
+				piece.Metadata.ContainsTable = true
+				chunks = append(chunks, piece)
+				emitted[i] = true
+			}
+			pos = max(pos, table[1])
+		}
+		chunks = appendChunkPiece(chunks, result.Content, chunk, pos, end)
+	}
+
+	sort.SliceStable(chunks, func(i, j int) bool {
+		return chunks[i].Metadata.ByteStart < chunks[j].Metadata.ByteStart
+	})
+	for i := range chunks {
+		chunks[i].Metadata.ChunkIndex = i
+		chunks[i].Metadata.TotalChunks = len(chunks)
+	}
+	result.Chunks = chunks
+}
+
+// appendChunkPiece appends the whitespace-trimmed range [start, end) of content as a
+// chunk derived from parent, unless the range is blank.
+func appendChunkPiece(chunks Chunks, content string, parent Chunk, start, end int) Chunks {
+	if start >= end {
+		return chunks
+	}
+	text := content[start:end]
+	trimmed := strings.TrimSpace(text)
+	if trimmed == "" {
+		return chunks
+	}
+	start += strings.Index(text, trimmed)
+	return append(chunks, derivedChunk(parent, content, start, start+len(trimmed)))
+}
+
+// derivedChunk returns a chunk covering [start, end) of content that inherits the heading
+// path of parent. Page information is reset so it can be recomputed for the new range.
+func derivedChunk(parent Chunk, content string, start, end int) Chunk {
+	return Chunk{
+		Content: content[start:end],
+		Metadata: ChunkMetadata{
+			ByteStart:   uint64(start),
+			ByteEnd:     uint64(end),
+			HeadingPath: parent.Metadata.HeadingPath,
+		},
+	}
+}
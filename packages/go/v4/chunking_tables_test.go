@@ -0,0 +1,113 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const tableTestDoc = "Before the table.\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\nAfter the table."
+
+// wholeDocumentChunk mimics a native chunk that covers all of content.
+func wholeDocumentChunk(content string) Chunks {
+	return Chunks{{
+		Content:  content,
+		Metadata: ChunkMetadata{ByteEnd: uint64(len(content)), TotalChunks: 1},
+	}}
+}
+
+func TestTablePolicyInlineFlagsChunks(t *testing.T) {
+	result := &ExtractionResult{Content: tableTestDoc, Chunks: wholeDocumentChunk(tableTestDoc)}
+	applyTablePolicy(result, NewChunkingConfig())
+	if len(result.Chunks) != 1 || !result.Chunks[0].Metadata.ContainsTable {
+		t.Fatalf("expected a single table-bearing chunk, got %+v", result.Chunks)
+	}
+}
+
+func TestTablePolicySeparate(t *testing.T) {
+	result := &ExtractionResult{Content: tableTestDoc, Chunks: wholeDocumentChunk(tableTestDoc)}
+	applyTablePolicy(result, NewChunkingConfig(WithTablePolicy(TablePolicySeparate)))
+
+	want := []string{"Before the table.", "| a | b |\n|---|---|\n| 1 | 2 |", "After the table."}
+	if len(result.Chunks) != len(want) {
+		t.Fatalf("expected %d chunks, got %+v", len(want), result.Chunks)
+	}
+	for i, chunk := range result.Chunks {
+		if chunk.Content != want[i] {
+			t.Errorf("chunk %d = %q, want %q", i, chunk.Content, want[i])
+		}
+		if tableTestDoc[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd] != chunk.Content {
+			t.Errorf("chunk %d offsets do not match content", i)
+		}
+		if chunk.Metadata.ContainsTable != (i == 1) {
+			t.Errorf("chunk %d ContainsTable = %v", i, chunk.Metadata.ContainsTable)
+		}
+		if chunk.Metadata.ChunkIndex != i || chunk.Metadata.TotalChunks != len(want) {
+			t.Errorf("chunk %d has index %d/%d", i, chunk.Metadata.ChunkIndex, chunk.Metadata.TotalChunks)
+		}
+	}
+}
+
+func TestTablePolicySkip(t *testing.T) {
+	result := &ExtractionResult{Content: tableTestDoc, Chunks: wholeDocumentChunk(tableTestDoc)}
+	applyTablePolicy(result, NewChunkingConfig(WithTablePolicy(TablePolicySkip)))
+	if len(result.Chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %+v", result.Chunks)
+	}
+	for _, chunk := range result.Chunks {
+		if strings.Contains(chunk.Content, "|") || chunk.Metadata.ContainsTable {
+			t.Errorf("table content leaked into chunk %q", chunk.Content)
+		}
+	}
+}
+
+func TestTablePolicySeparateEmitsOverlappedTableOnce(t *testing.T) {
+	tableEnd := strings.Index(tableTestDoc, "\n\nAfter")
+	result := &ExtractionResult{
+		Content: tableTestDoc,
+		Chunks: Chunks{
+			{Content: tableTestDoc[:tableEnd], Metadata: ChunkMetadata{ByteEnd: uint64(tableEnd)}},
+			{Content: tableTestDoc[20:], Metadata: ChunkMetadata{ByteStart: 20, ByteEnd: uint64(len(tableTestDoc))}},
+		},
+	}
+	applyTablePolicy(result, NewChunkingConfig(WithTablePolicy(TablePolicySeparate)))
+	tables := 0
+	for _, chunk := range result.Chunks {
+		if chunk.Metadata.ContainsTable {
+			tables++
+		}
+	}
+	if tables != 1 || len(result.Chunks) != 3 {
+		t.Fatalf("expected one table chunk among 3 chunks, got %+v", result.Chunks)
+	}
+}
+
+func TestTablePolicyWithStructureChunking(t *testing.T) {
+	result := &ExtractionResult{Content: "# Data\n\n" + tableTestDoc}
+	applyChunkStages(result, NewChunkingConfig(
+		WithChunkingStrategy(ChunkingStrategyMarkdownStructure),
+		WithTablePolicy(TablePolicySeparate),
+	))
+	if len(result.Chunks) != 3 {
+		t.Fatalf("expected 3 chunks, got %+v", result.Chunks)
+	}
+	for i, chunk := range result.Chunks {
+		if len(chunk.Metadata.HeadingPath) != 1 || chunk.Metadata.HeadingPath[0] != "Data" {
+			t.Errorf("chunk %d heading path = %v", i, chunk.Metadata.HeadingPath)
+		}
+	}
+}
+
+func TestValidateTablePolicy(t *testing.T) {
+	var validationErr *ValidationError
+	if err := validateChunkingStrategy(NewChunkingConfig(WithTablePolicy("merge"))); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	withEmbedding := NewChunkingConfig(WithTablePolicy(TablePolicySkip), WithEmbedding())
+	if err := validateChunkingStrategy(withEmbedding); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for embeddings, got %v", err)
+	}
+	if err := validateChunkingStrategy(NewChunkingConfig(WithTablePolicy(TablePolicyInline), WithEmbedding())); err != nil {
+		t.Fatalf("inline policy should allow embeddings: %v", err)
+	}
+}
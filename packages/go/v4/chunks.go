@@ -0,0 +1,121 @@
+package kreuzberg
+
+import (
+	"sort"
+	"strings"
+)
+
+// Reassemble merges chunks back into contiguous text, removing the overlap between
+// consecutive chunks. Chunks are ordered by ChunkMetadata.ByteStart. When the byte
+// offsets of a chunk agree with its content the overlap is taken from the offsets;
+// otherwise the longest suffix of the text so far that prefixes the chunk is dropped.
+// Gaps between non-adjacent chunks (typically trimmed whitespace) become a single space.
+func (c Chunks) Reassemble() string {
+	if len(c) == 0 {
+		return ""
+	}
+	ordered := make([]Chunk, len(c))
+	copy(ordered, c)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Metadata.ByteStart < ordered[j].Metadata.ByteStart
+	})
+
+	var b strings.Builder
+	b.WriteString(ordered[0].Content)
+	prevEnd := ordered[0].Metadata.ByteEnd
+	prevOffsets := chunkOffsetsConsistent(ordered[0])
+
+	for _, chunk := range ordered[1:] {
+		if prevOffsets && chunkOffsetsConsistent(chunk) {
+			switch {
+			case chunk.Metadata.ByteStart > prevEnd:
+				b.WriteByte(' ')
+				b.WriteString(chunk.Content)
+			case chunk.Metadata.ByteEnd > prevEnd:
+				overlap := prevEnd - chunk.Metadata.ByteStart
+				b.WriteString(chunk.Content[overlap:])
+			}
+		} else {
+			overlap := textOverlap(b.String(), chunk.Content)
+			if overlap == 0 && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(chunk.Content[overlap:])
+		}
+		if chunk.Metadata.ByteEnd > prevEnd {
+			prevEnd = chunk.Metadata.ByteEnd
+		}
+		prevOffsets = chunkOffsetsConsistent(chunk)
+	}
+	return b.String()
+}
+
+// chunkOffsetsConsistent reports whether the byte range recorded for chunk matches the
+// length of its content, i.e. offsets can be trusted for overlap computation.
+func chunkOffsetsConsistent(chunk Chunk) bool {
+	meta := chunk.Metadata
+	return meta.ByteEnd > meta.ByteStart && meta.ByteEnd-meta.ByteStart == uint64(len(chunk.Content))
+}
+
+// textOverlap returns the length of the longest suffix of prev that is also a prefix of next.
+func textOverlap(prev, next string) int {
+	limit := min(len(prev), len(next))
+	for n := limit; n > 0; n-- {
+		if strings.HasSuffix(prev, next[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
+// RechunkResult re-chunks the Content of an existing result with different chunking
+// parameters, without re-extracting the source document. The native chunker runs over
+// Content as plain text, so embeddings configured in chunking are generated as well.
+// The returned result is a shallow copy of result with new Chunks; the pages of a result
+// paged with PagingConfig are read back into it, and it is not paged.
+func RechunkResult(result *ExtractionResult, chunking *ChunkingConfig) (*ExtractionResult, error) {
+	if result == nil {
+		return nil, newValidationErrorWithContext("result cannot be nil", nil, ErrorCodeValidation, nil)
+	}
+	if chunking == nil {
+		return nil, newValidationErrorWithContext("chunking config cannot be nil", nil, ErrorCodeValidation, nil)
+	}
+
+	if err := validateChunkingStrategy(chunking); err != nil {
+		return nil, err
+	}
+
+	out := *result
+	out.Chunks = nil
+	if result.paged != nil {
+		pages, err := pagedPages(result)
+		if err != nil {
+			return nil, err
+		}
+		out.Pages, out.paged = pages, nil
+	}
+	if result.Content == "" {
+		return &out, nil
+	}
+	if chunking.Strategy == ChunkingStrategyMarkdownStructure {
+		applyChunkStages(&out, chunking)
+		return &out, nil
+	}
+
+	config := &ExtractionConfig{
+		UseCache:                BoolPtr(false),
+		EnableQualityProcessing: BoolPtr(false),
+		Chunking:                chunking,
+	}
+	rechunked, err := ExtractBytesSync([]byte(result.Content), "text/plain", config)
+	if err != nil {
+		return nil, err
+	}
+	if rechunked.Content != result.Content {
+		return nil, newParsingErrorWithContext("re-chunking altered the content; chunk offsets would not match", nil, ErrorCodeParsing, nil)
+	}
+
+	out.Chunks = rechunked.Chunks
+	applyChunkStages(&out, chunking)
+	return &out, nil
+}
@@ -0,0 +1,95 @@
+package kreuzberg
+
+import "testing"
+
+func chunkAt(text string, start int) Chunk {
+	return Chunk{
+		Content:  text,
+		Metadata: ChunkMetadata{ByteStart: uint64(start), ByteEnd: uint64(start + len(text))},
+	}
+}
+
+func TestChunksReassembleWithOffsets(t *testing.T) {
+	content := "The quick brown fox jumps over the lazy dog"
+	chunks := Chunks{
+		chunkAt(content[0:19], 0),
+		chunkAt(content[10:30], 10),
+		chunkAt(content[26:], 26),
+	}
+	if got := chunks.Reassemble(); got != content {
+		t.Fatalf("got %q, want %q", got, content)
+	}
+}
+
+func TestChunksReassembleOutOfOrder(t *testing.T) {
+	content := "alpha beta gamma delta"
+	chunks := Chunks{
+		chunkAt(content[11:], 11),
+		chunkAt(content[0:10], 0),
+		chunkAt(content[6:16], 6),
+	}
+	if got := chunks.Reassemble(); got != content {
+		t.Fatalf("got %q, want %q", got, content)
+	}
+}
+
+func TestChunksReassembleFillsTrimmedGaps(t *testing.T) {
+	// Source text: "first part   second part"
+	chunks := Chunks{
+		chunkAt("first part", 0),
+		chunkAt("second part", 13),
+	}
+	if got := chunks.Reassemble(); got != "first part second part" {
+		t.Fatalf("unexpected reassembly: %q", got)
+	}
+}
+
+func TestChunksReassembleWithoutOffsets(t *testing.T) {
+	chunks := Chunks{
+		{Content: "hello wonderful"},
+		{Content: "wonderful world"},
+	}
+	if got := chunks.Reassemble(); got != "hello wonderful world" {
+		t.Fatalf("unexpected reassembly: %q", got)
+	}
+	if got := (Chunks{}).Reassemble(); got != "" {
+		t.Fatalf("empty chunks should reassemble to empty string, got %q", got)
+	}
+}
+
+func TestRechunkResultValidation(t *testing.T) {
+	if _, err := RechunkResult(nil, &ChunkingConfig{}); err == nil {
+		t.Fatal("expected error for nil result")
+	}
+	if _, err := RechunkResult(&ExtractionResult{}, nil); err == nil {
+		t.Fatal("expected error for nil chunking config")
+	}
+	out, err := RechunkResult(&ExtractionResult{Chunks: Chunks{{Content: "stale"}}}, &ChunkingConfig{})
+	if err != nil {
+		t.Fatalf("unexpected error for empty content: %v", err)
+	}
+	if len(out.Chunks) != 0 {
+		t.Fatalf("expected no chunks for empty content, got %d", len(out.Chunks))
+	}
+}
+
+func TestRechunkResult(t *testing.T) {
+	content := "Kreuzberg extracts text. " + "It also splits documents into overlapping chunks for retrieval. "
+	original := &ExtractionResult{Content: content + content + content, MimeType: "application/pdf"}
+
+	out, err := RechunkResult(original, NewChunkingConfig(WithMaxChars(60), WithMaxOverlap(10)))
+	if err != nil {
+		t.Fatalf("RechunkResult failed: %v", err)
+	}
+	if out == original || out.MimeType != original.MimeType {
+		t.Fatal("expected a copy of the original result")
+	}
+	if len(out.Chunks) < 2 {
+		t.Fatalf("expected multiple chunks, got %d", len(out.Chunks))
+	}
+	for i, chunk := range out.Chunks {
+		if got := out.Content[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]; got != chunk.Content {
+			t.Errorf("chunk %d offsets do not match content: %q vs %q", i, got, chunk.Content)
+		}
+	}
+}
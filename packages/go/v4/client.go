@@ -0,0 +1,438 @@
+package kreuzberg
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ClientConfig configures a Client.
+type ClientConfig struct {
+	// Tenant names the tenant the client serves.
+	Tenant string
+	// Config is the extraction configuration of calls made with a nil config.
+	Config *ExtractionConfig
+	// Admission bounds the work of this client alone. Nil admits every call.
+	Admission *AdmissionConfig
+	// CacheEntries is the number of results the client caches, evicting the least recently
+	// used. Zero disables the cache.
+	CacheEntries int
+	// Scheduler shares the native core fairly between the tenants of the clients configured
+	// with it. Single-document calls wait at PriorityInteractive and batches at PriorityBulk
+	// unless their context sets another with ContextWithPriority. Nil runs calls as they
+	// come.
+	Scheduler *Scheduler
+	// Weight is the number of calls of the tenant the scheduler serves in a row before
+	// turning to the next tenant of the same priority. Zero leaves the tenant's weight as it
+	// is.
+	Weight int
+}
+
+// ClientStats counts the calls of a Client. Extractions and Errors count documents;
+// Duration is the time spent extracting them, cache hits excluded.
+type ClientStats struct {
+	Extractions int           `json:"extractions"`
+	Errors      int           `json:"errors"`
+	Rejected    int           `json:"rejected"`
+	CacheHits   int           `json:"cache_hits"`
+	CacheMisses int           `json:"cache_misses"`
+	Bytes       int64         `json:"bytes"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// Client extracts documents on behalf of one tenant, with its own default configuration,
+// result cache, admission limits and statistics, so that one process can serve several
+// tenants without them sharing settings or observing each other's documents. Clients
+// never use the native result cache, which the whole process shares. A Client is safe for
+// concurrent use.
+type Client struct {
+	tenant    string
+	config    *ExtractionConfig
+	admission *AdmissionController
+	scheduler *Scheduler
+	cache     *resultCache
+
+	mu    sync.Mutex
+	stats ClientStats
+}
+
+// NewClient creates a client from cfg.
+func NewClient(cfg ClientConfig) (*Client, error) {
+	if cfg.CacheEntries < 0 {
+		return nil, newValidationErrorWithContext("client cache entries cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	if cfg.Weight < 0 {
+		return nil, newValidationErrorWithContext("client weight cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	if err := validateResultStages(cfg.Config); err != nil {
+		return nil, err
+	}
+	client := &Client{tenant: cfg.Tenant, config: cfg.Config, scheduler: cfg.Scheduler}
+	if cfg.Scheduler != nil && cfg.Weight > 0 {
+		cfg.Scheduler.SetWeight(cfg.Tenant, cfg.Weight)
+	}
+	if cfg.Admission != nil {
+		admission, err := NewAdmissionController(*cfg.Admission)
+		if err != nil {
+			return nil, err
+		}
+		client.admission = admission
+	}
+	if cfg.CacheEntries > 0 {
+		client.cache = newResultCache(cfg.CacheEntries)
+	}
+	return client, nil
+}
+
+// Tenant returns the name of the tenant the client serves.
+func (c *Client) Tenant() string {
+	return c.tenant
+}
+
+// Stats returns a snapshot of the client's statistics.
+func (c *Client) Stats() ClientStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// PurgeCache empties the client's result cache.
+func (c *Client) PurgeCache() {
+	c.cache.purge()
+}
+
+// ExtractFile extracts the file at path. A nil config uses the client's configuration.
+func (c *Client) ExtractFile(ctx context.Context, path string, config *ExtractionConfig) (*ExtractionResult, error) {
+	config = c.tenantConfig(config)
+	key := c.cache.key(fileContentKey(path), config)
+	if result, ok := c.lookup(key); ok {
+		return result, nil
+	}
+	var cost admissionCost
+	if c.admission != nil {
+		var err error
+		if cost, err = fileAdmissionCost(path, config); err != nil {
+			return nil, err
+		}
+	}
+	release, err := c.admit(ctx, cost, priorityFrom(ctx, PriorityInteractive))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	start := time.Now()
+	result, err := ExtractFileWithContext(ctx, path, config)
+	c.record(1, failures(err), fileSize(path), time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, result)
+	return result, nil
+}
+
+// ExtractBytes extracts an in-memory document. A nil config uses the client's
+// configuration.
+func (c *Client) ExtractBytes(ctx context.Context, data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	config = c.tenantConfig(config)
+	key := c.cache.key(bytesContentKey(BytesWithMime{Data: data, MimeType: mimeType}), config)
+	if result, ok := c.lookup(key); ok {
+		return result, nil
+	}
+	release, err := c.admit(ctx, bytesAdmissionCost(data, mimeType, config), priorityFrom(ctx, PriorityInteractive))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	start := time.Now()
+	result, err := ExtractBytesWithContext(ctx, data, mimeType, config)
+	c.record(1, failures(err), int64(len(data)), time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	c.cache.put(key, result)
+	return result, nil
+}
+
+// BatchExtractFiles extracts several files in one batch. A nil config uses the client's
+// configuration.
+func (c *Client) BatchExtractFiles(ctx context.Context, paths []string, config *ExtractionConfig) ([]*ExtractionResult, error) {
+	config = c.tenantConfig(config)
+	keys := make([]string, len(paths))
+	for i, path := range paths {
+		keys[i] = c.cache.key(fileContentKey(path), config)
+	}
+	cost := func(i int) (admissionCost, error) {
+		return fileAdmissionCost(paths[i], config)
+	}
+	return c.batch(ctx, keys, cost, func(misses []int) ([]*ExtractionResult, int64, error) {
+		batch := make([]string, len(misses))
+		var size int64
+		for slot, i := range misses {
+			batch[slot] = paths[i]
+			size += fileSize(paths[i])
+		}
+		results, err := BatchExtractFilesWithContext(ctx, batch, config)
+		return results, size, err
+	})
+}
+
+// BatchExtractBytes extracts several in-memory documents in one batch. A nil config uses
+// the client's configuration.
+func (c *Client) BatchExtractBytes(ctx context.Context, items []BytesWithMime, config *ExtractionConfig) ([]*ExtractionResult, error) {
+	config = c.tenantConfig(config)
+	keys := make([]string, len(items))
+	for i, item := range items {
+		keys[i] = c.cache.key(bytesContentKey(item), config)
+	}
+	cost := func(i int) (admissionCost, error) {
+		return bytesAdmissionCost(items[i].Data, items[i].MimeType, config), nil
+	}
+	return c.batch(ctx, keys, cost, func(misses []int) ([]*ExtractionResult, int64, error) {
+		batch := make([]BytesWithMime, len(misses))
+		var size int64
+		for slot, i := range misses {
+			batch[slot] = items[i]
+			size += int64(len(items[i].Data))
+		}
+		results, err := BatchExtractBytesWithContext(ctx, batch, config)
+		return results, size, err
+	})
+}
+
+// batch serves the documents whose keys are cached from the cache and extracts the others
+// in one batch once admitted.
+func (c *Client) batch(ctx context.Context, keys []string, cost func(int) (admissionCost, error), extract func(misses []int) ([]*ExtractionResult, int64, error)) ([]*ExtractionResult, error) {
+	results := make([]*ExtractionResult, len(keys))
+	var misses []int
+	for i, key := range keys {
+		if result, ok := c.lookup(key); ok {
+			results[i] = result
+			continue
+		}
+		misses = append(misses, i)
+	}
+	if len(misses) == 0 {
+		return results, nil
+	}
+
+	var total admissionCost
+	if c.admission != nil {
+		for _, i := range misses {
+			itemCost, err := cost(i)
+			if err != nil {
+				return nil, err
+			}
+			total.ocrPages += itemCost.ocrPages
+			total.memory += itemCost.memory
+		}
+	}
+	release, err := c.admit(ctx, total, priorityFrom(ctx, PriorityBulk))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	start := time.Now()
+	extracted, size, err := extract(misses)
+	elapsed := time.Since(start)
+	if err != nil {
+		c.record(len(misses), len(misses), size, elapsed)
+		return nil, err
+	}
+	failed := 0
+	for slot, i := range misses {
+		if slot >= len(extracted) || extracted[slot] == nil {
+			continue
+		}
+		results[i] = extracted[slot]
+		if extracted[slot].Metadata.Error != nil {
+			failed++
+			continue
+		}
+		c.cache.put(keys[i], extracted[slot])
+	}
+	c.record(len(misses), failed, size, elapsed)
+	return results, nil
+}
+
+// tenantConfig returns config, or the client's configuration when nil, with the native
+// result cache disabled.
+func (c *Client) tenantConfig(config *ExtractionConfig) *ExtractionConfig {
+	if config == nil {
+		config = c.config
+	}
+	tenant := &ExtractionConfig{}
+	if config != nil {
+		*tenant = *config
+	}
+	tenant.UseCache = BoolPtr(false)
+	return tenant
+}
+
+type priorityKey struct{}
+
+// ContextWithPriority returns a context whose Client calls are scheduled at priority p,
+// overriding the default of their kind: PriorityInteractive for single documents and
+// PriorityBulk for batches.
+func ContextWithPriority(ctx context.Context, p Priority) context.Context {
+	return context.WithValue(ctx, priorityKey{}, p)
+}
+
+// priorityFrom returns the priority set on ctx by ContextWithPriority, or fallback.
+func priorityFrom(ctx context.Context, fallback Priority) Priority {
+	p, ok := ctx.Value(priorityKey{}).(Priority)
+	if !ok {
+		return fallback
+	}
+	return p
+}
+
+// admit waits for the client's admission controller, then for its scheduler at priority.
+func (c *Client) admit(ctx context.Context, cost admissionCost, priority Priority) (func(), error) {
+	release := func() {}
+	if c.admission != nil {
+		admitted, err := c.admission.acquire(ctx, cost)
+		if err != nil {
+			c.mu.Lock()
+			if errors.Is(err, ErrOverloaded) {
+				c.stats.Rejected++
+			}
+			c.mu.Unlock()
+			return nil, err
+		}
+		release = admitted
+	}
+	if c.scheduler != nil {
+		scheduled, err := c.scheduler.Acquire(ctx, c.tenant, priority)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		admitted := release
+		release = func() {
+			scheduled()
+			admitted()
+		}
+	}
+	return release, nil
+}
+
+// lookup returns the cached result for key and counts the hit or miss.
+func (c *Client) lookup(key string) (*ExtractionResult, bool) {
+	if c.cache == nil {
+		return nil, false
+	}
+	result, ok := c.cache.get(key)
+	c.mu.Lock()
+	if ok {
+		c.stats.CacheHits++
+	} else {
+		c.stats.CacheMisses++
+	}
+	c.mu.Unlock()
+	return result, ok
+}
+
+// record counts extracted documents, failed of which failed.
+func (c *Client) record(documents, failed int, size int64, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Extractions += documents
+	c.stats.Errors += failed
+	c.stats.Bytes += size
+	c.stats.Duration += elapsed
+}
+
+// failures returns the number of documents failed by a single extraction returning err.
+func failures(err error) int {
+	if err != nil {
+		return 1
+	}
+	return 0
+}
+
+// resultCache is a least-recently-used cache of results, kept as JSON so that callers
+// cannot modify a cached result. A nil cache caches nothing.
+type resultCache struct {
+	mu      sync.Mutex
+	limit   int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type resultCacheEntry struct {
+	key    string
+	result []byte
+}
+
+func newResultCache(limit int) *resultCache {
+	return &resultCache{limit: limit, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// key combines the content key of a document with its configuration. It is empty, and
+// never cached, when the cache is disabled or the content has no key.
+func (rc *resultCache) key(contentKey string, config *ExtractionConfig) string {
+	if rc == nil || contentKey == "" {
+		return ""
+	}
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return ""
+	}
+	return contentKey + "\x00" + string(encoded)
+}
+
+func (rc *resultCache) get(key string) (*ExtractionResult, bool) {
+	if rc == nil || key == "" {
+		return nil, false
+	}
+	rc.mu.Lock()
+	element, ok := rc.entries[key]
+	if ok {
+		rc.order.MoveToFront(element)
+	}
+	rc.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	result := &ExtractionResult{}
+	if err := json.Unmarshal(element.Value.(*resultCacheEntry).result, result); err != nil {
+		return nil, false
+	}
+	return result, true
+}
+
+func (rc *resultCache) put(key string, result *ExtractionResult) {
+	if rc == nil || key == "" || result == nil {
+		return
+	}
+	encoded, err := json.Marshal(result)
+	if err != nil {
+		return
+	}
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if element, ok := rc.entries[key]; ok {
+		element.Value.(*resultCacheEntry).result = encoded
+		rc.order.MoveToFront(element)
+		return
+	}
+	rc.entries[key] = rc.order.PushFront(&resultCacheEntry{key: key, result: encoded})
+	for rc.order.Len() > rc.limit {
+		oldest := rc.order.Back()
+		rc.order.Remove(oldest)
+		delete(rc.entries, oldest.Value.(*resultCacheEntry).key)
+	}
+}
+
+func (rc *resultCache) purge() {
+	if rc == nil {
+		return
+	}
+	rc.mu.Lock()
+	rc.order.Init()
+	clear(rc.entries)
+	rc.mu.Unlock()
+}
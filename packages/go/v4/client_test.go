@@ -0,0 +1,118 @@
+package kreuzberg
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNewClientRejectsNegativeCache(t *testing.T) {
+	if _, err := NewClient(ClientConfig{Tenant: "acme", CacheEntries: -1}); err == nil {
+		t.Fatal("expected an error for negative cache entries")
+	}
+	client, err := NewClient(ClientConfig{Tenant: "acme", CacheEntries: 4})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if client.Tenant() != "acme" {
+		t.Fatalf("expected tenant acme, got %q", client.Tenant())
+	}
+}
+
+func TestClientTenantConfig(t *testing.T) {
+	defaults := &ExtractionConfig{EnableQualityProcessing: BoolPtr(true), UseCache: BoolPtr(true)}
+	client, err := NewClient(ClientConfig{Tenant: "acme", Config: defaults})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+
+	config := client.tenantConfig(nil)
+	if config.EnableQualityProcessing == nil || !*config.EnableQualityProcessing {
+		t.Fatal("expected the client configuration for a nil config")
+	}
+	if config.UseCache == nil || *config.UseCache {
+		t.Fatal("expected the native cache to be disabled")
+	}
+	if !*defaults.UseCache {
+		t.Fatal("the client configuration was modified")
+	}
+
+	own := &ExtractionConfig{ForceOCR: BoolPtr(true)}
+	config = client.tenantConfig(own)
+	if config.EnableQualityProcessing != nil || config.ForceOCR == nil {
+		t.Fatal("expected the call configuration to replace the client configuration")
+	}
+	if own.UseCache != nil {
+		t.Fatal("the call configuration was modified")
+	}
+}
+
+func TestResultCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResultCache(2)
+	config := &ExtractionConfig{}
+	a, b, c := cache.key("a", config), cache.key("b", config), cache.key("c", config)
+	cache.put(a, &ExtractionResult{Content: "a"})
+	cache.put(b, &ExtractionResult{Content: "b"})
+	if _, ok := cache.get(a); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.put(c, &ExtractionResult{Content: "c"})
+	if _, ok := cache.get(b); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	result, ok := cache.get(a)
+	if !ok || result.Content != "a" {
+		t.Fatalf("expected a to survive, got %v %v", result, ok)
+	}
+
+	// Cached results are copies.
+	result.Content = "modified"
+	if result, _ := cache.get(a); result.Content != "a" {
+		t.Fatalf("cached result was modified: %q", result.Content)
+	}
+
+	cache.purge()
+	if _, ok := cache.get(c); ok {
+		t.Fatal("expected an empty cache after purge")
+	}
+}
+
+func TestResultCacheKeys(t *testing.T) {
+	cache := newResultCache(2)
+	if key := cache.key("", &ExtractionConfig{}); key != "" {
+		t.Fatalf("expected no key without a content key, got %q", key)
+	}
+	if cache.key("a", &ExtractionConfig{}) == cache.key("a", &ExtractionConfig{ForceOCR: BoolPtr(true)}) {
+		t.Fatal("expected configurations to produce different keys")
+	}
+
+	var disabled *resultCache
+	if key := disabled.key("a", nil); key != "" {
+		t.Fatalf("expected no key from a nil cache, got %q", key)
+	}
+	disabled.put("a", &ExtractionResult{})
+	if _, ok := disabled.get("a"); ok {
+		t.Fatal("expected a nil cache to cache nothing")
+	}
+	disabled.purge()
+}
+
+func TestClientSchedulingPriority(t *testing.T) {
+	scheduler := NewScheduler(1)
+	if _, err := NewClient(ClientConfig{Tenant: "acme", Scheduler: scheduler, Weight: -1}); err == nil {
+		t.Fatal("expected a negative weight to be rejected")
+	}
+	if _, err := NewClient(ClientConfig{Tenant: "acme", Scheduler: scheduler, Weight: 3}); err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	if weight := scheduler.weights["acme"]; weight != 3 {
+		t.Fatalf("expected the tenant weight to be 3, got %d", weight)
+	}
+
+	ctx := context.Background()
+	if p := priorityFrom(ctx, PriorityBulk); p != PriorityBulk {
+		t.Fatalf("expected the fallback priority, got %d", p)
+	}
+	if p := priorityFrom(ContextWithPriority(ctx, PriorityInteractive), PriorityBulk); p != PriorityInteractive {
+		t.Fatalf("expected the context priority, got %d", p)
+	}
+}
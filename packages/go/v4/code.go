@@ -0,0 +1,539 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of CodeSymbol.
+const (
+	SymbolFunction  = "function"
+	SymbolMethod    = "method"
+	SymbolClass     = "class"
+	SymbolStruct    = "struct"
+	SymbolInterface = "interface"
+	SymbolEnum      = "enum"
+	SymbolTrait     = "trait"
+	SymbolType      = "type"
+	SymbolModule    = "module"
+	SymbolImpl      = "impl"
+)
+
+// codeMimePrefix prefixes the MIME type of source files, e.g. "text/x-go".
+const codeMimePrefix = "text/x-"
+
+// CodeMetadata describes a source file: its programming language, an outline of the
+// symbols it declares, and its comments. Line numbers are 1-based.
+type CodeMetadata struct {
+	ProgrammingLanguage string        `json:"programming_language"`
+	LineCount           int           `json:"line_count"`
+	Symbols             []CodeSymbol  `json:"symbols"`
+	Comments            []CodeComment `json:"comments,omitempty"`
+}
+
+// CodeSymbol is a declaration found in a source file. Parent names the enclosing symbol,
+// e.g. the class of a method.
+type CodeSymbol struct {
+	Name      string `json:"name"`
+	Kind      string `json:"kind"`
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Parent    string `json:"parent,omitempty"`
+}
+
+// CodeComment is a comment, with consecutive line comments merged into one. Text has the
+// comment markers removed.
+type CodeComment struct {
+	StartLine int    `json:"start_line"`
+	EndLine   int    `json:"end_line"`
+	Text      string `json:"text"`
+}
+
+// codeSyntax describes the lexical and declaration syntax of a language well enough to
+// outline it without a parser.
+type codeSyntax struct {
+	lineComments []string
+	blockComment [2]string
+	quotes       string
+	tripleQuotes bool
+	// indentBlocks is set for languages whose bodies end where indentation returns to
+	// the level of the declaration.
+	indentBlocks bool
+	symbols      []symbolPattern
+}
+
+// symbolPattern matches a declaration line. The pattern's "name" group is the symbol
+// name, and a "kind" group, when present, overrides kind. Declarations with needsBody
+// set are ignored when no body follows, such as C prototypes.
+type symbolPattern struct {
+	kind      string
+	pattern   *regexp.Regexp
+	needsBody bool
+}
+
+var (
+	cStyleComments = []string{"//"}
+	hashComments   = []string{"#"}
+	cBlockComment  = [2]string{"/*", "*/"}
+
+	// braceClassPattern matches the type declarations shared by Java, C#, Kotlin, Swift,
+	// Scala, and PHP.
+	braceClassPattern = symbolPattern{kind: SymbolClass, pattern: regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|static|final|abstract|sealed|open|data|partial|export|default|readonly)\s+)*(?P<kind>class|interface|enum|struct|record|trait|protocol|object)\s+(?P<name>\w+)`)}
+	// cFunctionPattern matches a C-family function definition starting at column 0.
+	cFunctionPattern = symbolPattern{kind: SymbolFunction, needsBody: true, pattern: regexp.MustCompile(`^(?:[\w*&:<>,]+\s+)+\**(?P<name>[\w:~]+)\s*\([^;]*$`)}
+	// memberFunctionPattern matches an indented method definition of Java or C#.
+	memberFunctionPattern = symbolPattern{kind: SymbolMethod, needsBody: true, pattern: regexp.MustCompile(`^\s+(?:(?:public|private|protected|internal|static|final|abstract|synchronized|override|virtual|async|sealed|extern|unsafe)\s+)*[\w<>\[\],.?]+\s+(?P<name>\w+)\s*\([^;]*$`)}
+)
+
+var codeSyntaxes = map[string]*codeSyntax{
+	"go": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: "\"'`", symbols: []symbolPattern{
+		{kind: SymbolMethod, pattern: regexp.MustCompile(`^func\s+\([^)]*\)\s*(?P<name>\w+)`)},
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^func\s+(?P<name>\w+)`)},
+		{kind: SymbolType, pattern: regexp.MustCompile(`^type\s+(?P<name>\w+)(?:\[[^\]]*\])?\s+(?P<kind>struct|interface)?`)},
+	}},
+	"python": {lineComments: hashComments, quotes: `"'`, tripleQuotes: true, indentBlocks: true, symbols: []symbolPattern{
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:async\s+)?def\s+(?P<name>\w+)`)},
+		{kind: SymbolClass, pattern: regexp.MustCompile(`^\s*class\s+(?P<name>\w+)`)},
+	}},
+	"rust": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"`, symbols: []symbolPattern{
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|extern\s+"[^"]*")\s+)*fn\s+(?P<name>\w+)`)},
+		{kind: SymbolStruct, pattern: regexp.MustCompile(`^\s*(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?(?P<kind>struct|enum|trait|mod)\s+(?P<name>\w+)`)},
+		{kind: SymbolImpl, needsBody: true, pattern: regexp.MustCompile(`^\s*(?:unsafe\s+)?impl(?:<[^>]*>)?\s+(?:[\w:<>, ]+\s+for\s+)?(?P<name>\w+)`)},
+	}},
+	"javascript": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: "\"'`", symbols: jsSymbols()},
+	"typescript": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: "\"'`", symbols: append(jsSymbols(),
+		symbolPattern{kind: SymbolInterface, pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:declare\s+)?(?P<kind>interface|enum)\s+(?P<name>\w+)`)},
+		symbolPattern{kind: SymbolType, pattern: regexp.MustCompile(`^\s*(?:export\s+)?type\s+(?P<name>\w+)\s*(?:<[^>]*>)?\s*=`)},
+	)},
+	"java":   {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"'`, symbols: []symbolPattern{braceClassPattern, memberFunctionPattern}},
+	"csharp": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"'`, symbols: []symbolPattern{braceClassPattern, memberFunctionPattern}},
+	"kotlin": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"'`, symbols: []symbolPattern{
+		braceClassPattern,
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:(?:public|private|protected|internal|override|open|suspend|inline|operator)\s+)*fun\s+(?:<[^>]*>\s*)?(?:[\w.]+\.)?(?P<name>\w+)`)},
+	}},
+	"swift": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"`, symbols: []symbolPattern{
+		braceClassPattern,
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:(?:public|private|fileprivate|internal|open|static|override|mutating|@\w+)\s+)*func\s+(?P<name>\w+)`)},
+	}},
+	"scala": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"`, symbols: []symbolPattern{
+		braceClassPattern,
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:(?:private|protected|override|final|implicit)\s+)*def\s+(?P<name>\w+)`)},
+	}},
+	"c": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"'`, symbols: []symbolPattern{
+		{kind: SymbolStruct, needsBody: true, pattern: regexp.MustCompile(`^\s*(?:typedef\s+)?(?P<kind>struct|enum|union)\s+(?P<name>\w+)`)},
+		cFunctionPattern,
+	}},
+	"cpp": {lineComments: cStyleComments, blockComment: cBlockComment, quotes: `"'`, symbols: []symbolPattern{
+		{kind: SymbolClass, needsBody: true, pattern: regexp.MustCompile(`^\s*(?:template\s*<[^>]*>\s*)?(?:typedef\s+)?(?P<kind>class|struct|enum|union|namespace)\s+(?:class\s+)?(?P<name>\w+)`)},
+		cFunctionPattern,
+	}},
+	"php": {lineComments: append([]string{"#"}, cStyleComments...), blockComment: cBlockComment, quotes: `"'`, symbols: []symbolPattern{
+		braceClassPattern,
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:(?:public|private|protected|static|final|abstract)\s+)*function\s+&?(?P<name>\w+)`)},
+	}},
+	"ruby": {lineComments: hashComments, quotes: `"'`, indentBlocks: true, symbols: []symbolPattern{
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*def\s+(?:self\.)?(?P<name>[\w?!=]+)`)},
+		{kind: SymbolClass, pattern: regexp.MustCompile(`^\s*(?P<kind>class|module)\s+(?P<name>[\w:]+)`)},
+	}},
+	"shell": {lineComments: hashComments, quotes: `"'`, symbols: []symbolPattern{
+		{kind: SymbolFunction, needsBody: true, pattern: regexp.MustCompile(`^\s*(?:function\s+)?(?P<name>[\w-]+)\s*\(\)`)},
+		{kind: SymbolFunction, needsBody: true, pattern: regexp.MustCompile(`^\s*function\s+(?P<name>[\w-]+)`)},
+	}},
+}
+
+func jsSymbols() []symbolPattern {
+	return []symbolPattern{
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*(?P<name>\w+)`)},
+		{kind: SymbolClass, pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:default\s+)?(?:abstract\s+)?class\s+(?P<name>\w+)`)},
+		{kind: SymbolFunction, pattern: regexp.MustCompile(`^\s*(?:export\s+)?(?:const|let|var)\s+(?P<name>\w+)\s*(?::[^=]+)?=\s*(?:async\s+)?(?:function\b|(?:\([^)]*\)|\w+)\s*(?::[^=]+)?=>)`)},
+		{kind: SymbolMethod, needsBody: true, pattern: regexp.MustCompile(`^\s+(?:(?:static|async|public|private|protected|readonly|get|set)\s+)*\*?(?P<name>\w+)\s*(?:<[^>]*>)?\([^;]*$`)},
+	}
+}
+
+// codeKeywords are words that look like declarations to the looser patterns, such as
+// "if (x) {" to a method pattern.
+var codeKeywords = map[string]struct{}{
+	"if": {}, "else": {}, "for": {}, "while": {}, "switch": {}, "catch": {}, "return": {},
+	"function": {}, "new": {}, "do": {}, "try": {}, "with": {}, "sizeof": {}, "elif": {},
+}
+
+var codeExtensions = map[string]string{
+	".go": "go", ".py": "python", ".pyw": "python", ".rs": "rust",
+	".js": "javascript", ".mjs": "javascript", ".cjs": "javascript", ".jsx": "javascript",
+	".ts": "typescript", ".mts": "typescript", ".tsx": "typescript",
+	".java": "java", ".cs": "csharp", ".kt": "kotlin", ".kts": "kotlin", ".swift": "swift",
+	".scala": "scala", ".c": "c", ".h": "c", ".cc": "cpp", ".cpp": "cpp", ".cxx": "cpp",
+	".hpp": "cpp", ".hh": "cpp", ".php": "php", ".rb": "ruby", ".sh": "shell", ".bash": "shell",
+	".zsh": "shell",
+}
+
+var shebangLanguages = map[string]string{
+	"python": "python", "python3": "python", "node": "javascript", "ruby": "ruby",
+	"sh": "shell", "bash": "shell", "zsh": "shell", "php": "php",
+}
+
+// codeLanguageForPath returns the programming language of the source file at path from
+// its extension, or "" when it is not a recognized source file.
+func codeLanguageForPath(path string) string {
+	return codeExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// codeLanguageForMime returns the programming language of a "text/x-<language>" MIME
+// type, or "".
+func codeLanguageForMime(mimeType string) string {
+	language, ok := strings.CutPrefix(mimeType, codeMimePrefix)
+	if _, known := codeSyntaxes[language]; !ok || !known {
+		return ""
+	}
+	return language
+}
+
+// DetectCodeLanguage names the programming language of a source file from its path and,
+// for scripts without a recognized extension, its "#!" line. It returns "" for files that
+// are not recognized source code.
+func DetectCodeLanguage(path string, data []byte) string {
+	if language := codeLanguageForPath(path); language != "" {
+		return language
+	}
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	interpreter, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("#!"))
+	if !ok {
+		return ""
+	}
+	fields := strings.Fields(string(interpreter))
+	if len(fields) == 0 {
+		return ""
+	}
+	name := filepath.Base(fields[0])
+	if name == "env" && len(fields) > 1 {
+		name = fields[1]
+	}
+	return shebangLanguages[name]
+}
+
+// extractCode builds the result of a source file: its text as Content and a CodeMetadata
+// outline. Chunking, when configured, follows symbol boundaries.
+func extractCode(data []byte, language string) *ExtractionResult {
+	source := string(data)
+	meta := AnalyzeCode(source, language)
+	return &ExtractionResult{
+		Content:  source,
+		MimeType: codeMimePrefix + language,
+		Metadata: Metadata{Format: FormatMetadata{Type: FormatCode, Code: meta}},
+		Success:  true,
+	}
+}
+
+// AnalyzeCode outlines source written in language, one of the values DetectCodeLanguage
+// returns. Symbols are found by matching declaration lines and their extent by brace
+// matching or, for Python and Ruby, indentation, so the outline is approximate for code
+// that defines symbols through macros or unusual formatting.
+func AnalyzeCode(source, language string) *CodeMetadata {
+	lines := strings.Split(strings.ReplaceAll(source, "\r\n", "\n"), "\n")
+	meta := &CodeMetadata{ProgrammingLanguage: language, LineCount: len(lines), Symbols: []CodeSymbol{}}
+	if strings.HasSuffix(source, "\n") {
+		meta.LineCount--
+	}
+	syntax, ok := codeSyntaxes[language]
+	if !ok {
+		return meta
+	}
+
+	masked, comments := scanCode(lines, syntax)
+	meta.Comments = comments
+	for i, line := range masked {
+		if symbol, ok := matchSymbol(masked, i, line, syntax); ok {
+			meta.Symbols = append(meta.Symbols, symbol)
+		}
+	}
+	nestSymbols(meta.Symbols)
+	return meta
+}
+
+func matchSymbol(masked []string, index int, line string, syntax *codeSyntax) (CodeSymbol, bool) {
+	for _, symbol := range syntax.symbols {
+		match := symbol.pattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		name := match[symbol.pattern.SubexpIndex("name")]
+		if _, keyword := codeKeywords[name]; keyword || name == "" {
+			continue
+		}
+		kind := symbol.kind
+		if i := symbol.pattern.SubexpIndex("kind"); i >= 0 && match[i] != "" {
+			kind = symbolKind(match[i])
+		}
+		end, hasBody := symbolEnd(masked, index, syntax)
+		if symbol.needsBody && !hasBody {
+			continue
+		}
+		return CodeSymbol{Name: name, Kind: kind, StartLine: index + 1, EndLine: end + 1}, true
+	}
+	return CodeSymbol{}, false
+}
+
+// symbolKind maps declaration keywords to symbol kinds.
+func symbolKind(keyword string) string {
+	switch keyword {
+	case "interface", "protocol":
+		return SymbolInterface
+	case "struct", "union", "record":
+		return SymbolStruct
+	case "enum":
+		return SymbolEnum
+	case "trait":
+		return SymbolTrait
+	case "mod", "module", "namespace":
+		return SymbolModule
+	}
+	return SymbolClass
+}
+
+// symbolEnd returns the 0-based last line of the declaration starting at line start and
+// whether it has a body.
+func symbolEnd(masked []string, start int, syntax *codeSyntax) (int, bool) {
+	if syntax.indentBlocks {
+		indent := indentation(masked[start])
+		end := start
+		for i := start + 1; i < len(masked); i++ {
+			trimmed := strings.TrimSpace(masked[i])
+			if trimmed == "" {
+				continue
+			}
+			if indentation(masked[i]) <= indent {
+				if trimmed == "end" {
+					end = i
+				}
+				break
+			}
+			end = i
+		}
+		return end, end > start
+	}
+
+	depth := 0
+	opened := false
+	for i := start; i < len(masked); i++ {
+		for _, r := range masked[i] {
+			switch {
+			case r == '{':
+				depth++
+				opened = true
+			case r == '}' && opened:
+				depth--
+				if depth == 0 {
+					return i, true
+				}
+			case r == ';' && !opened:
+				return i, false
+			}
+		}
+		// Declarations whose body does not open within a few lines have none.
+		if !opened && i-start >= 3 {
+			return start, false
+		}
+	}
+	return len(masked) - 1, opened
+}
+
+func indentation(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " \t"))
+}
+
+// nestSymbols sets the parent of symbols declared within another, sorting them by
+// position, and marks functions declared in types as methods.
+func nestSymbols(symbols []CodeSymbol) {
+	sort.SliceStable(symbols, func(i, j int) bool { return symbols[i].StartLine < symbols[j].StartLine })
+	var stack []int
+	for i := range symbols {
+		for len(stack) > 0 && symbols[stack[len(stack)-1]].EndLine < symbols[i].StartLine {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) > 0 {
+			parent := symbols[stack[len(stack)-1]]
+			symbols[i].Parent = parent.Name
+			if symbols[i].Kind == SymbolFunction && parent.Kind != SymbolFunction && parent.Kind != SymbolModule {
+				symbols[i].Kind = SymbolMethod
+			}
+		}
+		if symbols[i].EndLine > symbols[i].StartLine {
+			stack = append(stack, i)
+		}
+	}
+}
+
+// scanCode blanks out the comments and string literals of lines, so that declaration
+// patterns and brace matching only see code, and collects the comments.
+func scanCode(lines []string, syntax *codeSyntax) ([]string, []CodeComment) {
+	masked := make([]string, len(lines))
+	var comments []CodeComment
+	// merge is the index of the line comment that a line comment on the next line
+	// continues, or -1.
+	merge := -1
+	var (
+		inBlock    bool
+		blockStart int
+		blockText  strings.Builder
+		quote      string
+	)
+	for n, line := range lines {
+		var out strings.Builder
+		i := 0
+		for i < len(line) {
+			rest := line[i:]
+			switch {
+			case inBlock:
+				if end := strings.Index(rest, syntax.blockComment[1]); end >= 0 {
+					blockText.WriteString(rest[:end])
+					out.WriteString(strings.Repeat(" ", end+len(syntax.blockComment[1])))
+					i += end + len(syntax.blockComment[1])
+					inBlock = false
+					comments = append(comments, CodeComment{StartLine: blockStart + 1, EndLine: n + 1, Text: cleanBlockComment(blockText.String())})
+					merge = -1
+					continue
+				}
+				blockText.WriteString(rest)
+				out.WriteString(strings.Repeat(" ", len(rest)))
+				i = len(line)
+			case quote != "":
+				if rest[0] == '\\' && len(quote) == 1 && len(rest) > 1 {
+					out.WriteString("  ")
+					i += 2
+					continue
+				}
+				if strings.HasPrefix(rest, quote) {
+					out.WriteString(quote)
+					i += len(quote)
+					quote = ""
+					continue
+				}
+				out.WriteByte(' ')
+				i++
+			case syntax.blockComment[0] != "" && strings.HasPrefix(rest, syntax.blockComment[0]):
+				inBlock = true
+				blockStart = n
+				blockText.Reset()
+				out.WriteString(strings.Repeat(" ", len(syntax.blockComment[0])))
+				i += len(syntax.blockComment[0])
+			case strings.ContainsRune(syntax.quotes, rune(rest[0])):
+				quote = rest[:1]
+				if syntax.tripleQuotes && strings.HasPrefix(rest, strings.Repeat(quote, 3)) {
+					quote = strings.Repeat(quote, 3)
+				}
+				out.WriteString(quote)
+				i += len(quote)
+			default:
+				if marker := lineCommentMarker(line, i, syntax); marker != "" {
+					text := strings.TrimSpace(rest[len(marker):])
+					if merge >= 0 && comments[merge].EndLine == n {
+						comments[merge].EndLine = n + 1
+						comments[merge].Text += "\n" + text
+					} else {
+						comments = append(comments, CodeComment{StartLine: n + 1, EndLine: n + 1, Text: text})
+						merge = len(comments) - 1
+					}
+					i = len(line)
+					continue
+				}
+				out.WriteByte(rest[0])
+				i++
+			}
+		}
+		if inBlock {
+			blockText.WriteByte('\n')
+		}
+		// Only backquoted and triple-quoted strings span lines.
+		if len(quote) == 1 && quote != "`" {
+			quote = ""
+		}
+		masked[n] = out.String()
+	}
+	return masked, comments
+}
+
+// lineCommentMarker returns the line comment marker starting at line[i], or "". A "#"
+// only starts a comment at the beginning of a word, as in shell.
+func lineCommentMarker(line string, i int, syntax *codeSyntax) string {
+	for _, marker := range syntax.lineComments {
+		if !strings.HasPrefix(line[i:], marker) {
+			continue
+		}
+		if marker == "#" && i > 0 && line[i-1] != ' ' && line[i-1] != '\t' {
+			continue
+		}
+		return marker
+	}
+	return ""
+}
+
+// cleanBlockComment strips the leading asterisks of a block comment's lines.
+func cleanBlockComment(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "*")
+		lines[i] = strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// chunkCode splits the content of a source file at the boundaries of its top-level
+// symbols, each with the comment block directly above it, and merges neighbouring pieces
+// up to the chunk size. A symbol longer than the chunk size stays whole.
+func chunkCode(content string, meta *CodeMetadata, cfg *ChunkingConfig) Chunks {
+	limit := structureChunkLimit(cfg)
+	lineStarts := []int{0}
+	for i, r := range content {
+		if r == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	offset := func(line int) int {
+		if line-1 < len(lineStarts) {
+			return lineStarts[line-1]
+		}
+		return len(content)
+	}
+
+	boundaries := []int{0}
+	for _, symbol := range meta.Symbols {
+		if symbol.Parent != "" {
+			continue
+		}
+		start := symbol.StartLine
+		for _, comment := range meta.Comments {
+			if comment.EndLine == start-1 {
+				start = comment.StartLine
+			}
+		}
+		if at := offset(start); at > boundaries[len(boundaries)-1] {
+			boundaries = append(boundaries, at)
+		}
+	}
+	boundaries = append(boundaries, len(content))
+
+	var chunks Chunks
+	chunkStart := 0
+	for i := 1; i < len(boundaries); i++ {
+		end := boundaries[i]
+		if i < len(boundaries)-1 && boundaries[i+1]-chunkStart <= limit {
+			continue
+		}
+		if text := strings.TrimRight(content[chunkStart:end], "\n"); strings.TrimSpace(text) != "" {
+			chunks = append(chunks, Chunk{
+				Content:  text,
+				Metadata: ChunkMetadata{ByteStart: uint64(chunkStart), ByteEnd: uint64(chunkStart + len(text))},
+			})
+		}
+		chunkStart = end
+	}
+	for i := range chunks {
+		chunks[i].Metadata.ChunkIndex = i
+		chunks[i].Metadata.TotalChunks = len(chunks)
+	}
+	return chunks
+}
@@ -0,0 +1,208 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func symbolsByName(symbols []CodeSymbol) map[string]CodeSymbol {
+	byName := make(map[string]CodeSymbol, len(symbols))
+	for _, symbol := range symbols {
+		byName[symbol.Name] = symbol
+	}
+	return byName
+}
+
+func TestAnalyzeCodeGo(t *testing.T) {
+	source := `package demo
+
+// Greeter says hello.
+// It is safe for concurrent use.
+type Greeter struct {
+	name string
+}
+
+/* Greet returns "func Fake() {" as a greeting. */
+func (g *Greeter) Greet() string {
+	return "func Fake() {" + g.name
+}
+
+func New(name string) *Greeter {
+	return &Greeter{name: name}
+}
+`
+	meta := AnalyzeCode(source, "go")
+	if meta.LineCount != 16 {
+		t.Fatalf("LineCount = %d, want 16", meta.LineCount)
+	}
+	symbols := symbolsByName(meta.Symbols)
+	if len(meta.Symbols) != 3 {
+		t.Fatalf("expected 3 symbols, got %+v", meta.Symbols)
+	}
+	if got := symbols["Greeter"]; got.Kind != SymbolStruct || got.StartLine != 5 || got.EndLine != 7 {
+		t.Errorf("unexpected Greeter: %+v", got)
+	}
+	if got := symbols["Greet"]; got.Kind != SymbolMethod || got.StartLine != 10 || got.EndLine != 12 {
+		t.Errorf("unexpected Greet: %+v", got)
+	}
+	if got := symbols["New"]; got.Kind != SymbolFunction || got.EndLine != 16 {
+		t.Errorf("unexpected New: %+v", got)
+	}
+	if len(meta.Comments) != 2 {
+		t.Fatalf("expected 2 comments, got %+v", meta.Comments)
+	}
+	if got := meta.Comments[0]; got.StartLine != 3 || got.EndLine != 4 || got.Text != "Greeter says hello.\nIt is safe for concurrent use." {
+		t.Errorf("unexpected line comment: %+v", got)
+	}
+	if got := meta.Comments[1]; got.Text != `Greet returns "func Fake() {" as a greeting.` {
+		t.Errorf("unexpected block comment: %+v", got)
+	}
+}
+
+func TestAnalyzeCodePython(t *testing.T) {
+	source := `import os
+
+class Store:
+    """Holds items.
+
+    def not_a_method(self): ...
+    """
+
+    def add(self, item):
+        # keep it
+        self.items.append(item)
+
+    async def flush(self):
+        pass
+
+
+def main():
+    Store().add("#not a comment")
+`
+	meta := AnalyzeCode(source, "python")
+	symbols := symbolsByName(meta.Symbols)
+	if len(meta.Symbols) != 4 {
+		t.Fatalf("expected 4 symbols, got %+v", meta.Symbols)
+	}
+	if got := symbols["Store"]; got.Kind != SymbolClass || got.StartLine != 3 || got.EndLine != 14 {
+		t.Errorf("unexpected Store: %+v", got)
+	}
+	if got := symbols["add"]; got.Kind != SymbolMethod || got.Parent != "Store" || got.EndLine != 11 {
+		t.Errorf("unexpected add: %+v", got)
+	}
+	if got := symbols["flush"]; got.Kind != SymbolMethod || got.Parent != "Store" {
+		t.Errorf("unexpected flush: %+v", got)
+	}
+	if got := symbols["main"]; got.Kind != SymbolFunction || got.Parent != "" || got.StartLine != 17 {
+		t.Errorf("unexpected main: %+v", got)
+	}
+	if len(meta.Comments) != 1 || meta.Comments[0].Text != "keep it" {
+		t.Errorf("unexpected comments: %+v", meta.Comments)
+	}
+}
+
+func TestAnalyzeCodeSkipsPrototypes(t *testing.T) {
+	source := "int add(int a, int b);\n\nint add(int a, int b)\n{\n    if (a) {\n        return a + b;\n    }\n    return b;\n}\n"
+	meta := AnalyzeCode(source, "c")
+	if len(meta.Symbols) != 1 {
+		t.Fatalf("expected only the definition, got %+v", meta.Symbols)
+	}
+	if got := meta.Symbols[0]; got.Name != "add" || got.StartLine != 3 || got.EndLine != 9 {
+		t.Errorf("unexpected symbol: %+v", got)
+	}
+}
+
+func TestAnalyzeCodeTypeScriptClass(t *testing.T) {
+	source := "export interface Shape {\n  area(): number;\n}\n\nexport class Circle implements Shape {\n  constructor(private r: number) {}\n  area(): number {\n    if (this.r) {\n      return 3.14 * this.r * this.r;\n    }\n    return 0;\n  }\n}\n\nexport const scale = (s: Shape, k: number) => s.area() * k;\n"
+	meta := AnalyzeCode(source, "typescript")
+	symbols := symbolsByName(meta.Symbols)
+	if got := symbols["Shape"]; got.Kind != SymbolInterface {
+		t.Errorf("unexpected Shape: %+v", got)
+	}
+	if got := symbols["area"]; got.Kind != SymbolMethod || got.Parent != "Circle" || got.StartLine != 7 {
+		t.Errorf("unexpected area: %+v", got)
+	}
+	if got := symbols["scale"]; got.Kind != SymbolFunction {
+		t.Errorf("unexpected scale: %+v", got)
+	}
+	if _, ok := symbols["if"]; ok {
+		t.Error("keyword reported as symbol")
+	}
+}
+
+func TestDetectCodeLanguage(t *testing.T) {
+	cases := []struct {
+		path string
+		data string
+		want string
+	}{
+		{"main.go", "", "go"},
+		{"lib/App.TSX", "", "typescript"},
+		{"deploy", "#!/usr/bin/env bash\necho hi\n", "shell"},
+		{"tool", "#!/usr/bin/python3\n", "python"},
+		{"notes.txt", "", ""},
+		{"README", "hello", ""},
+	}
+	for _, tc := range cases {
+		if got := DetectCodeLanguage(tc.path, []byte(tc.data)); got != tc.want {
+			t.Errorf("DetectCodeLanguage(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+	if got := codeLanguageForMime("text/x-rust"); got != "rust" {
+		t.Errorf("codeLanguageForMime = %q", got)
+	}
+	if got := codeLanguageForMime("text/x-rst"); got != "" {
+		t.Errorf("codeLanguageForMime(text/x-rst) = %q", got)
+	}
+}
+
+func TestChunkCodeFollowsSymbols(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("package demo\n\n")
+	for _, name := range []string{"Alpha", "Beta", "Gamma"} {
+		b.WriteString("// " + name + " does work.\nfunc " + name + "() {\n\tprintln(\"" + strings.Repeat("x", 40) + "\")\n}\n\n")
+	}
+	result := extractCode([]byte(b.String()), "go")
+	meta, ok := result.Metadata.CodeMetadata()
+	if !ok {
+		t.Fatal("expected code metadata")
+	}
+	limit := 80
+	chunks := chunkCode(result.Content, meta, &ChunkingConfig{MaxChars: &limit})
+	if len(chunks) != 4 {
+		t.Fatalf("expected package clause and one chunk per function, got %d: %+v", len(chunks), chunks)
+	}
+	for i, chunk := range chunks[1:] {
+		if !strings.HasPrefix(chunk.Content, "// ") {
+			t.Errorf("chunk %d does not start at its doc comment: %q", i+1, chunk.Content)
+		}
+		if got := result.Content[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]; got != chunk.Content {
+			t.Errorf("chunk %d offsets select %q", i+1, got)
+		}
+	}
+
+	limit = 10_000
+	if chunks := chunkCode(result.Content, meta, &ChunkingConfig{MaxChars: &limit}); len(chunks) != 1 {
+		t.Fatalf("expected symbols merged into one chunk, got %d", len(chunks))
+	}
+}
+
+func TestCodeMetadataRoundTrip(t *testing.T) {
+	result := extractCode([]byte("def f():\n    pass\n"), "python")
+	if result.MimeType != "text/x-python" {
+		t.Fatalf("MimeType = %q", result.MimeType)
+	}
+	data, err := json.Marshal(result.Metadata)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Metadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	meta, ok := decoded.CodeMetadata()
+	if !ok || meta.ProgrammingLanguage != "python" || len(meta.Symbols) != 1 || decoded.Additional != nil {
+		t.Fatalf("unexpected round trip: %s", data)
+	}
+}
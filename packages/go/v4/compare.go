@@ -0,0 +1,309 @@
+package kreuzberg
+
+import (
+	"strings"
+)
+
+// Change types reported in DocumentChange.Type.
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeChanged = "changed"
+)
+
+// Block kinds reported in DocumentChange.Kind.
+const (
+	BlockParagraph = "paragraph"
+	BlockHeading   = "heading"
+	BlockCode      = "code"
+	BlockTable     = "table"
+)
+
+// changedSimilarity is the minimum word similarity for a removed and an added block of
+// the same kind to be reported as one changed block.
+const changedSimilarity = 0.5
+
+// DocumentDiff is the structural difference between two extraction results.
+type DocumentDiff struct {
+	// Changes lists the differences in document order.
+	Changes []DocumentChange `json:"changes,omitempty"`
+	// Unchanged counts the blocks present in both documents.
+	Unchanged int `json:"unchanged"`
+}
+
+// HasChanges reports whether the documents differ.
+func (d *DocumentDiff) HasChanges() bool {
+	return d != nil && len(d.Changes) > 0
+}
+
+// DocumentChange is an added, removed, or changed block. Before is nil for added blocks
+// and After is nil for removed blocks.
+type DocumentChange struct {
+	Type       string    `json:"type"`
+	Kind       string    `json:"kind"`
+	Before     *BlockRef `json:"before,omitempty"`
+	After      *BlockRef `json:"after,omitempty"`
+	Similarity float64   `json:"similarity,omitempty"`
+}
+
+// BlockRef locates a block in one of the compared results. PageNumber is 0 when the
+// result has no page boundaries. Tables taken from ExtractionResult.Tables, rather than
+// from Content, have TableIndex set and no byte range.
+type BlockRef struct {
+	Text       string `json:"text"`
+	ByteStart  uint64 `json:"byte_start"`
+	ByteEnd    uint64 `json:"byte_end"`
+	PageNumber uint64 `json:"page_number,omitempty"`
+	TableIndex *int   `json:"table_index,omitempty"`
+}
+
+// diffBlock is a comparable unit of a document.
+type diffBlock struct {
+	kind string
+	key  string
+	ref  BlockRef
+}
+
+// CompareDocuments computes a structural diff between two extraction results. Content is
+// split into paragraphs, headings, code blocks, and tables; blocks are compared with
+// whitespace normalized and anchored to their pages. Removed and added blocks of the same
+// kind that are similar enough are reported as changed.
+func CompareDocuments(a, b *ExtractionResult) (*DocumentDiff, error) {
+	if a == nil || b == nil {
+		return nil, newValidationErrorWithContext("results to compare cannot be nil", nil, ErrorCodeValidation, nil)
+	}
+	before := diffBlocks(a)
+	after := diffBlocks(b)
+
+	diff := &DocumentDiff{}
+	var removed, added []diffBlock
+	flush := func() {
+		diff.Changes = append(diff.Changes, pairChanges(removed, added)...)
+		removed, added = nil, nil
+	}
+	for _, op := range diffSequences(before, after) {
+		switch op.kind {
+		case diffEqual:
+			flush()
+			diff.Unchanged++
+		case diffDelete:
+			removed = append(removed, before[op.index])
+		case diffInsert:
+			added = append(added, after[op.index])
+		}
+	}
+	flush()
+	return diff, nil
+}
+
+// diffBlocks splits result into comparable blocks. When Content holds no Markdown tables,
+// the detected tables are compared instead.
+func diffBlocks(result *ExtractionResult) []diffBlock {
+	var blocks []diffBlock
+	hasTables := false
+	for _, block := range parseStructureBlocks(result.Content, knownHeadings(result)) {
+		text := result.Content[block.start:block.end]
+		kind := BlockParagraph
+		switch block.kind {
+		case blockHeading:
+			kind = BlockHeading
+		case blockCode:
+			kind = BlockCode
+		case blockTable:
+			kind = BlockTable
+			hasTables = true
+		}
+		ref := BlockRef{Text: text, ByteStart: uint64(block.start), ByteEnd: uint64(block.end)}
+		if spans := result.PageSpans(ref.ByteStart, ref.ByteStart); len(spans) > 0 {
+			ref.PageNumber = spans[0].PageNumber
+		}
+		blocks = append(blocks, diffBlock{kind: kind, key: kind + "\x00" + strings.Join(strings.Fields(text), " "), ref: ref})
+	}
+	if hasTables {
+		return blocks
+	}
+	for i, table := range result.Tables {
+		index := i
+		ref := BlockRef{Text: table.Markdown, PageNumber: uint64(max(table.PageNumber, 0)), TableIndex: &index}
+		blocks = append(blocks, diffBlock{kind: BlockTable, key: BlockTable + "\x00" + strings.Join(strings.Fields(table.Markdown), " "), ref: ref})
+	}
+	return blocks
+}
+
+// pairChanges turns a run of removed and added blocks into changes. Removed blocks are
+// matched in order with the first sufficiently similar added block of the same kind;
+// added blocks skipped over by a match are reported as added before it.
+func pairChanges(removed, added []diffBlock) []DocumentChange {
+	var changes []DocumentChange
+	next := 0
+	for _, r := range removed {
+		match := -1
+		var similarity float64
+		for j := next; j < len(added); j++ {
+			if added[j].kind != r.kind {
+				continue
+			}
+			if s := wordSimilarity(r.ref.Text, added[j].ref.Text); s >= changedSimilarity {
+				match, similarity = j, s
+				break
+			}
+		}
+		if match < 0 {
+			ref := r.ref
+			changes = append(changes, DocumentChange{Type: ChangeRemoved, Kind: r.kind, Before: &ref})
+			continue
+		}
+		for ; next < match; next++ {
+			ref := added[next].ref
+			changes = append(changes, DocumentChange{Type: ChangeAdded, Kind: added[next].kind, After: &ref})
+		}
+		before, after := r.ref, added[match].ref
+		changes = append(changes, DocumentChange{Type: ChangeChanged, Kind: r.kind, Before: &before, After: &after, Similarity: similarity})
+		next = match + 1
+	}
+	for ; next < len(added); next++ {
+		ref := added[next].ref
+		changes = append(changes, DocumentChange{Type: ChangeAdded, Kind: added[next].kind, After: &ref})
+	}
+	return changes
+}
+
+// wordSimilarity returns the Dice coefficient of the word multisets of a and b.
+func wordSimilarity(a, b string) float64 {
+	wordsA, wordsB := strings.Fields(strings.ToLower(a)), strings.Fields(strings.ToLower(b))
+	if len(wordsA)+len(wordsB) == 0 {
+		return 1
+	}
+	counts := make(map[string]int, len(wordsA))
+	for _, word := range wordsA {
+		counts[word]++
+	}
+	shared := 0
+	for _, word := range wordsB {
+		if counts[word] > 0 {
+			counts[word]--
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(wordsA)+len(wordsB))
+}
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is one step of an edit script. index refers to the old sequence for equal and
+// delete steps and to the new sequence for insert steps.
+type diffOp struct {
+	kind  diffOpKind
+	index int
+}
+
+// diffSequences computes a shortest edit script between the block keys of a and b using
+// the linear-space variant of Myers' algorithm, which splits the problem at the middle
+// snake of each range instead of keeping the trace of every edit distance.
+func diffSequences(a, b []diffBlock) []diffOp {
+	size := 2*(len(a)+len(b)) + 3
+	d := &sequenceDiff{a: a, b: b, forward: make([]int, size), backward: make([]int, size)}
+	d.diff(0, len(a), 0, len(b))
+	return d.ops
+}
+
+// sequenceDiff holds the state of diffSequences: the furthest reaching x of each diagonal,
+// searching from the start and from the end of a range, and the script so far.
+type sequenceDiff struct {
+	a, b              []diffBlock
+	forward, backward []int
+	ops               []diffOp
+}
+
+// diff appends the edit script turning a[aLo:aHi] into b[bLo:bHi].
+func (d *sequenceDiff) diff(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo].key == d.b[bLo].key {
+		d.ops = append(d.ops, diffOp{kind: diffEqual, index: aLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aLo < aHi-suffix && bLo < bHi-suffix && d.a[aHi-suffix-1].key == d.b[bHi-suffix-1].key {
+		suffix++
+	}
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.ops = append(d.ops, diffOp{kind: diffInsert, index: j})
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.ops = append(d.ops, diffOp{kind: diffDelete, index: i})
+		}
+	default:
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.diff(aLo, x, bLo, y)
+		for i := x; i < u; i++ {
+			d.ops = append(d.ops, diffOp{kind: diffEqual, index: i})
+		}
+		d.diff(u, aHi, v, bHi)
+	}
+
+	for i := aHi; i < aHi+suffix; i++ {
+		d.ops = append(d.ops, diffOp{kind: diffEqual, index: i})
+	}
+}
+
+// middleSnake finds the snake, from (x, y) to (u, v), in the middle of a shortest edit
+// script of a[aLo:aHi] and b[bLo:bHi], searching from both ends at once. Both ranges are
+// non-empty.
+func (d *sequenceDiff) middleSnake(aLo, aHi, bLo, bHi int) (x, y, u, v int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta%2 != 0
+	offset := len(d.forward) / 2
+	d.forward[offset+1], d.backward[offset+1] = 0, 0
+	for depth := 0; depth <= (n+m+1)/2; depth++ {
+		for k := -depth; k <= depth; k += 2 {
+			var fx int
+			if k == -depth || (k != depth && d.forward[offset+k-1] < d.forward[offset+k+1]) {
+				fx = d.forward[offset+k+1]
+			} else {
+				fx = d.forward[offset+k-1] + 1
+			}
+			fy := fx - k
+			startX, startY := fx, fy
+			for fx < n && fy < m && d.a[aLo+fx].key == d.b[bLo+fy].key {
+				fx++
+				fy++
+			}
+			d.forward[offset+k] = fx
+			if reverse := delta - k; odd && reverse >= -(depth-1) && reverse <= depth-1 && fx+d.backward[offset+reverse] >= n {
+				return aLo + startX, bLo + startY, aLo + fx, bLo + fy
+			}
+		}
+		for k := -depth; k <= depth; k += 2 {
+			var rx int
+			if k == -depth || (k != depth && d.backward[offset+k-1] < d.backward[offset+k+1]) {
+				rx = d.backward[offset+k+1]
+			} else {
+				rx = d.backward[offset+k-1] + 1
+			}
+			ry := rx - k
+			startX, startY := rx, ry
+			for rx < n && ry < m && d.a[aHi-1-rx].key == d.b[bHi-1-ry].key {
+				rx++
+				ry++
+			}
+			d.backward[offset+k] = rx
+			if forward := delta - k; !odd && forward >= -depth && forward <= depth && rx+d.forward[offset+forward] >= n {
+				return aHi - rx, bHi - ry, aHi - startX, bHi - startY
+			}
+		}
+	}
+	// Unreachable: the searches meet by half the edit distance.
+	return aLo, bLo, aLo, bLo
+}
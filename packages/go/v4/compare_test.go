@@ -0,0 +1,162 @@
+package kreuzberg
+
+import (
+	"errors"
+	"math/rand/v2"
+	"testing"
+)
+
+func pagedResult(pages ...string) *ExtractionResult {
+	result := &ExtractionResult{Metadata: Metadata{PageStructure: &PageStructure{}}}
+	for i, page := range pages {
+		start := uint64(len(result.Content))
+		result.Content += page
+		result.Metadata.PageStructure.Boundaries = append(result.Metadata.PageStructure.Boundaries, PageBoundary{
+			ByteStart:  start,
+			ByteEnd:    uint64(len(result.Content)),
+			PageNumber: uint64(i + 1),
+		})
+	}
+	result.Metadata.PageStructure.TotalCount = uint64(len(pages))
+	return result
+}
+
+func TestCompareDocuments(t *testing.T) {
+	before := pagedResult(
+		"# Terms\n\nThe fee is 100 dollars per month.\n\nThis clause will be removed.\n\n",
+		"| Plan | Price |\n|---|---|\n| Basic | 100 |\n\nClosing words.",
+	)
+	after := pagedResult(
+		"# Terms\n\nThe fee is 120 dollars per month.\n\n",
+		"A brand new paragraph.\n\n| Plan | Price |\n|---|---|\n| Basic | 120 |\n\nClosing   words.",
+	)
+
+	diff, err := CompareDocuments(before, after)
+	if err != nil {
+		t.Fatalf("compare: %v", err)
+	}
+	want := []struct {
+		typ, kind string
+	}{
+		{ChangeChanged, BlockParagraph},
+		{ChangeRemoved, BlockParagraph},
+		{ChangeAdded, BlockParagraph},
+		{ChangeChanged, BlockTable},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff.Changes)
+	}
+	for i, change := range diff.Changes {
+		if change.Type != want[i].typ || change.Kind != want[i].kind {
+			t.Errorf("change %d = %s %s, want %s %s", i, change.Type, change.Kind, want[i].typ, want[i].kind)
+		}
+	}
+	if diff.Unchanged != 2 {
+		t.Errorf("expected 2 unchanged blocks, got %d", diff.Unchanged)
+	}
+
+	table := diff.Changes[3]
+	if table.Before.PageNumber != 2 || table.After.PageNumber != 2 {
+		t.Errorf("unexpected table page anchors: %+v / %+v", table.Before, table.After)
+	}
+	if added := diff.Changes[2].After; added.Text != "A brand new paragraph." || after.Content[added.ByteStart:added.ByteEnd] != added.Text {
+		t.Errorf("unexpected added block: %+v", added)
+	}
+}
+
+func TestCompareDocumentsIdentical(t *testing.T) {
+	result := &ExtractionResult{Content: "One.\n\nTwo."}
+	diff, err := CompareDocuments(result, result)
+	if err != nil || diff.HasChanges() || diff.Unchanged != 2 {
+		t.Fatalf("unexpected diff: %+v (%v)", diff, err)
+	}
+}
+
+func TestCompareDocumentsDetectedTables(t *testing.T) {
+	before := &ExtractionResult{Tables: []Table{{Markdown: "| a |\n|---|\n| 1 |", PageNumber: 3}}}
+	after := &ExtractionResult{}
+	diff, err := CompareDocuments(before, after)
+	if err != nil || len(diff.Changes) != 1 {
+		t.Fatalf("unexpected diff: %+v (%v)", diff, err)
+	}
+	removed := diff.Changes[0]
+	if removed.Type != ChangeRemoved || removed.Before.TableIndex == nil || removed.Before.PageNumber != 3 {
+		t.Errorf("unexpected change: %+v", removed)
+	}
+}
+
+func TestCompareDocumentsNil(t *testing.T) {
+	var validationErr *ValidationError
+	if _, err := CompareDocuments(nil, &ExtractionResult{}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestDiffSequencesShortest(t *testing.T) {
+	blocks := func(keys string) []diffBlock {
+		out := make([]diffBlock, len(keys))
+		for i, key := range keys {
+			out[i] = diffBlock{key: string(key)}
+		}
+		return out
+	}
+	// editDistance counts the inserts and deletes of a shortest script by dynamic programming.
+	editDistance := func(a, b string) int {
+		row := make([]int, len(b)+1)
+		for j := range row {
+			row[j] = j
+		}
+		for i := 1; i <= len(a); i++ {
+			diagonal := row[0]
+			row[0] = i
+			for j := 1; j <= len(b); j++ {
+				next := min(row[j], row[j-1]) + 1
+				if a[i-1] == b[j-1] {
+					next = min(next, diagonal)
+				}
+				diagonal, row[j] = row[j], next
+			}
+		}
+		return row[len(b)]
+	}
+
+	random := rand.New(rand.NewPCG(1, 2))
+	word := func() string {
+		b := make([]byte, random.IntN(12))
+		for i := range b {
+			b[i] = "abc"[random.IntN(3)]
+		}
+		return string(b)
+	}
+	for range 500 {
+		a, b := word(), word()
+		ops := diffSequences(blocks(a), blocks(b))
+		var rebuilt []byte
+		edits, i := 0, 0
+		for _, op := range ops {
+			switch op.kind {
+			case diffEqual:
+				if op.index != i {
+					t.Fatalf("%q -> %q: equal step at %d, expected %d", a, b, op.index, i)
+				}
+				rebuilt = append(rebuilt, a[op.index])
+				i++
+			case diffDelete:
+				if op.index != i {
+					t.Fatalf("%q -> %q: delete step at %d, expected %d", a, b, op.index, i)
+				}
+				edits++
+				i++
+			case diffInsert:
+				rebuilt = append(rebuilt, b[op.index])
+				edits++
+			}
+		}
+		if string(rebuilt) != b || i != len(a) {
+			t.Fatalf("%q -> %q: script builds %q", a, b, rebuilt)
+		}
+		if want := editDistance(a, b); edits != want {
+			t.Fatalf("%q -> %q: %d edits, shortest is %d", a, b, edits, want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+
+// kreuzberg_build_info is weak so that a binary linked against a library that predates it
+// still starts and can report the mismatch, instead of failing to resolve the symbol.
+#if !defined(_WIN32)
+#pragma weak kreuzberg_build_info
+#endif
+
+static char *kreuzberg_go_build_info(int *present) {
+#if !defined(_WIN32)
+	if (kreuzberg_build_info == NULL) {
+		*present = 0;
+		return NULL;
+	}
+#endif
+	*present = 1;
+	return kreuzberg_build_info();
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// BindingVersion is the version of this binding. It expects a native library with the
+// same major and minor version.
+const BindingVersion = "4.2.2"
+
+// ErrIncompatibleLibrary is wrapped by the RuntimeError returned by extractions when the
+// native library failed the compatibility check. Test for it with errors.Is; the
+// CompatibilityReport from CheckCompatibility explains the mismatch.
+var ErrIncompatibleLibrary = errors.New("kreuzberg: incompatible native library")
+
+// CompatibilityReport is the outcome of the handshake between the binding and the native
+// library, made when the package is initialized.
+type CompatibilityReport struct {
+	BindingVersion string `json:"binding_version"`
+	LibraryVersion string `json:"library_version"`
+	// RequiredABIVersion is the C API revision of the header the binding was built against.
+	RequiredABIVersion int `json:"required_abi_version"`
+	// LibraryABIVersion is the C API revision of the native library, 0 when the library
+	// predates the handshake.
+	LibraryABIVersion int `json:"library_abi_version"`
+	// Features lists the Cargo features the native library was built with.
+	Features []string `json:"features"`
+	// Compatible is false when the binding cannot safely use the library; Problems then
+	// says why. Warnings lists mismatches that do not prevent its use.
+	Compatible bool     `json:"compatible"`
+	Problems   []string `json:"problems,omitempty"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// HasFeature reports whether the native library was built with the Cargo feature name.
+func (r *CompatibilityReport) HasFeature(name string) bool {
+	return slices.Contains(r.Features, name)
+}
+
+// Err returns nil when the library is compatible, and otherwise a RuntimeError wrapping
+// ErrIncompatibleLibrary that lists the problems.
+func (r *CompatibilityReport) Err() error {
+	if r.Compatible {
+		return nil
+	}
+	message := fmt.Sprintf("native library %s cannot be used by binding %s: %s", messageWithFallback(r.LibraryVersion, "(unknown version)"), r.BindingVersion, strings.Join(r.Problems, "; "))
+	return newRuntimeErrorWithContext(message, ErrIncompatibleLibrary, ErrorCodeInternal, nil)
+}
+
+// compatibility is the report of the handshake made by init.
+var compatibility *CompatibilityReport
+
+func init() {
+	compatibility = negotiateCompatibility()
+}
+
+// CheckCompatibility returns the report of the handshake between the binding and the
+// native library. Extractions fail with its Err when the library is incompatible; calling
+// CheckCompatibility at startup lets a service fail fast with the full report instead.
+func CheckCompatibility() *CompatibilityReport {
+	report := *compatibility
+	report.Features = slices.Clone(compatibility.Features)
+	report.Problems = slices.Clone(compatibility.Problems)
+	report.Warnings = slices.Clone(compatibility.Warnings)
+	return &report
+}
+
+// libraryBuildInfo is the JSON object returned by kreuzberg_build_info.
+type libraryBuildInfo struct {
+	Version    string   `json:"version"`
+	ABIVersion int      `json:"abi_version"`
+	Features   []string `json:"features"`
+}
+
+func negotiateCompatibility() *CompatibilityReport {
+	report := &CompatibilityReport{BindingVersion: BindingVersion, RequiredABIVersion: int(C.KREUZBERG_ABI_VERSION)}
+	info, present, err := readBuildInfo()
+	switch {
+	case !present:
+		report.LibraryVersion = LibraryVersion()
+		report.Problems = append(report.Problems, "the library predates version negotiation and is older than the binding expects")
+	case err != nil:
+		report.LibraryVersion = LibraryVersion()
+		report.Problems = append(report.Problems, "reading the library build info failed: "+err.Error())
+	default:
+		report.LibraryVersion = info.Version
+		report.LibraryABIVersion = info.ABIVersion
+		report.Features = info.Features
+		report.checkVersions()
+	}
+	report.Compatible = len(report.Problems) == 0
+	return report
+}
+
+func readBuildInfo() (*libraryBuildInfo, bool, error) {
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	var present C.int
+	ptr := C.kreuzberg_go_build_info(&present)
+	if present == 0 {
+		return nil, false, nil
+	}
+	if ptr == nil {
+		return nil, true, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+	info := &libraryBuildInfo{}
+	if err := decodeJSONCString(ptr, info); err != nil {
+		return nil, true, err
+	}
+	return info, true, nil
+}
+
+// checkVersions compares the ABI revisions and the release versions of the binding and
+// the library.
+func (r *CompatibilityReport) checkVersions() {
+	if r.LibraryABIVersion < r.RequiredABIVersion {
+		r.Problems = append(r.Problems, fmt.Sprintf("the library implements C API revision %d, older than revision %d the binding was built against", r.LibraryABIVersion, r.RequiredABIVersion))
+	}
+	binding, bindingOK := parseReleaseVersion(r.BindingVersion)
+	library, libraryOK := parseReleaseVersion(r.LibraryVersion)
+	switch {
+	case !bindingOK || !libraryOK:
+		r.Warnings = append(r.Warnings, fmt.Sprintf("cannot compare versions %q and %q", r.BindingVersion, r.LibraryVersion))
+	case library[0] != binding[0]:
+		r.Problems = append(r.Problems, fmt.Sprintf("major version %d differs from the binding's %d", library[0], binding[0]))
+	case library[1] < binding[1]:
+		r.Problems = append(r.Problems, fmt.Sprintf("version %s is older than the %d.%d the binding expects", r.LibraryVersion, binding[0], binding[1]))
+	case r.LibraryVersion != r.BindingVersion:
+		r.Warnings = append(r.Warnings, fmt.Sprintf("library version %s differs from the binding's %s", r.LibraryVersion, r.BindingVersion))
+	}
+}
+
+// parseReleaseVersion parses the major, minor and patch numbers of a semantic version,
+// ignoring any pre-release or build suffix.
+func parseReleaseVersion(version string) ([3]int, bool) {
+	var parsed [3]int
+	core, _, _ := strings.Cut(version, "+")
+	core, _, _ = strings.Cut(core, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return parsed, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return parsed, false
+		}
+		parsed[i] = n
+	}
+	return parsed, true
+}
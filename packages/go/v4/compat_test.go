@@ -0,0 +1,56 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCheckCompatibility(t *testing.T) {
+	report := CheckCompatibility()
+	if !report.Compatible {
+		t.Fatalf("expected the test library to be compatible: %+v", report)
+	}
+	if report.BindingVersion != BindingVersion || report.LibraryABIVersion < report.RequiredABIVersion {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("Err() = %v for a compatible library", err)
+	}
+}
+
+func TestCompatibilityReportCheckVersions(t *testing.T) {
+	cases := []struct {
+		library    string
+		abi        int
+		compatible bool
+		warnings   int
+	}{
+		{library: "4.2.2", abi: 1, compatible: true},
+		{library: "4.2.5", abi: 1, compatible: true, warnings: 1},
+		{library: "4.3.0-rc.1", abi: 2, compatible: true, warnings: 1},
+		{library: "4.1.9", abi: 1, compatible: false},
+		{library: "5.0.0", abi: 1, compatible: false},
+		{library: "4.2.2", abi: 0, compatible: false},
+		{library: "dev", abi: 1, compatible: true, warnings: 1},
+	}
+	for _, tc := range cases {
+		report := &CompatibilityReport{BindingVersion: "4.2.2", LibraryVersion: tc.library, RequiredABIVersion: 1, LibraryABIVersion: tc.abi}
+		report.checkVersions()
+		report.Compatible = len(report.Problems) == 0
+		if report.Compatible != tc.compatible || len(report.Warnings) != tc.warnings {
+			t.Errorf("%s (ABI %d): compatible=%v warnings=%v problems=%v", tc.library, tc.abi, report.Compatible, report.Warnings, report.Problems)
+		}
+	}
+}
+
+func TestCompatibilityReportErr(t *testing.T) {
+	report := &CompatibilityReport{BindingVersion: "4.2.2", LibraryVersion: "4.1.0", Problems: []string{"too old"}}
+	err := report.Err()
+	if !errors.Is(err, ErrIncompatibleLibrary) {
+		t.Fatalf("expected ErrIncompatibleLibrary, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "4.1.0") || !strings.Contains(err.Error(), "too old") {
+		t.Fatalf("error does not explain the mismatch: %v", err)
+	}
+}
@@ -0,0 +1,176 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// defaultMinCompressedContent is the content size from which results are compressed when
+// ResultCompressionConfig.MinContentBytes is unset.
+const defaultMinCompressedContent = 1 << 20
+
+// zstdMagic starts every zstd frame.
+var zstdMagic = []byte{0x28, 0xB5, 0x2F, 0xFD}
+
+// zstdReaders recycles the decoders of compressed results along with their window and
+// block buffers. The decoders are single-threaded and run no goroutines, so the ones the
+// pool drops need not be closed.
+var zstdReaders = sync.Pool{New: func() any {
+	decoder, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		panic(err)
+	}
+	return decoder
+}}
+
+// acquireZstdReader returns a pooled decoder reading the zstd stream from input. It is
+// returned to the pool with releaseZstdReader.
+func acquireZstdReader(input io.Reader) *zstd.Decoder {
+	decoder := zstdReaders.Get().(*zstd.Decoder)
+	// Reset fails only for closed decoders, which are never pooled.
+	_ = decoder.Reset(input)
+	return decoder
+}
+
+func releaseZstdReader(decoder *zstd.Decoder) {
+	_ = decoder.Reset(nil)
+	zstdReaders.Put(decoder)
+}
+
+func validateResultCompressionConfig(cfg *ResultCompressionConfig) error {
+	if cfg.MinContentBytes != nil && *cfg.MinContentBytes < 0 {
+		return newValidationErrorWithContext(fmt.Sprintf("minimum compressed content size must not be negative, got %d", *cfg.MinContentBytes), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// compressAbove returns the content size from which results are compressed with config,
+// or -1 when results cross the FFI boundary as C strings.
+func compressAbove(config *ExtractionConfig) int {
+	if config == nil || config.ResultCompression == nil {
+		return -1
+	}
+	if threshold := config.ResultCompression.MinContentBytes; threshold != nil {
+		return *threshold
+	}
+	return defaultMinCompressedContent
+}
+
+// extractBytesCompressed hands a buffer to the core library and decodes the result it
+// returns as a single, possibly compressed, JSON document.
+func extractBytesCompressed(data []byte, mimeType string, config *ExtractionConfig) (result *ExtractionResult, err error) {
+	buf := C.CBytes(data)
+	defer C.free(buf)
+
+	cMime := C.CString(mimeType)
+	defer C.free(unsafe.Pointer(cMime))
+
+	cfgPtr, cfgCleanup, err := newConfigJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	if cfgCleanup != nil {
+		defer cfgCleanup()
+	}
+
+	// Serialize FFI calls to prevent concurrent PDFium access
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	leaveSpool, err := enterNativeSpool(config)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveSpool(&err)
+	defer timeNative(time.Now(), &result)
+
+	var length C.uintptr_t
+	ptr := C.kreuzberg_extract_bytes_compressed((*C.uint8_t)(buf), C.uintptr_t(len(data)), cMime, cfgPtr, C.uintptr_t(compressAbove(config)), &length)
+	return decodeCResultBuffer(ptr, length, lazyMetadata(config))
+}
+
+// extractFileCompressed hands a file to the core library and decodes the result it
+// returns as a single, possibly compressed, JSON document.
+func extractFileCompressed(path string, config *ExtractionConfig) (result *ExtractionResult, err error) {
+	cPath := C.CString(NormalizePath(path))
+	defer C.free(unsafe.Pointer(cPath))
+
+	cfgPtr, cfgCleanup, err := newConfigJSON(config)
+	if err != nil {
+		return nil, err
+	}
+	if cfgCleanup != nil {
+		defer cfgCleanup()
+	}
+
+	// Serialize FFI calls to prevent concurrent PDFium access
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	leaveSpool, err := enterNativeSpool(config)
+	if err != nil {
+		return nil, err
+	}
+	defer leaveSpool(&err)
+	defer timeNative(time.Now(), &result)
+
+	var length C.uintptr_t
+	ptr := C.kreuzberg_extract_file_compressed(cPath, cfgPtr, C.uintptr_t(compressAbove(config)), &length)
+	return decodeCResultBuffer(ptr, length, lazyMetadata(config))
+}
+
+// decodeCResultBuffer decodes a result buffer returned by the core library in place and
+// frees it, deferring the format metadata when lazy. Must be called while holding
+// ffiMutex, for lastError.
+func decodeCResultBuffer(ptr *C.uint8_t, length C.uintptr_t, lazy bool) (*ExtractionResult, error) {
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_bytes(ptr, length)
+	return decodeResultBuffer(unsafe.Slice((*byte)(unsafe.Pointer(ptr)), int(length)), lazy)
+}
+
+// decodeResultBuffer decodes a result serialized as JSON, zstd-compressed or not. A
+// compressed result is decompressed as it is decoded, never held whole.
+func decodeResultBuffer(data []byte, lazy bool) (*ExtractionResult, error) {
+	reader, release := resultBufferReader(data)
+	defer release()
+	return decodeResult(json.NewDecoder(reader), lazy)
+}
+
+// resultBufferReader returns a reader of the JSON in a result buffer, decompressing it
+// when compressed, and a function releasing the reader.
+func resultBufferReader(data []byte) (io.Reader, func()) {
+	reader := bytes.NewReader(data)
+	if !bytes.HasPrefix(data, zstdMagic) {
+		return reader, func() {}
+	}
+	decompressor := acquireZstdReader(reader)
+	return decompressor, func() { releaseZstdReader(decompressor) }
+}
+
+// decodeResult decodes the next result of decoder. When lazy, the format payload and the
+// additional fields of its metadata are decoded on first access.
+func decodeResult(decoder *json.Decoder, lazy bool) (*ExtractionResult, error) {
+	result := &ExtractionResult{}
+	if lazy {
+		result.Metadata.deferred = &deferredMetadata{}
+	}
+	if err := decoder.Decode(result); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode result", err, ErrorCodeValidation, nil)
+	}
+	promoteRtfMetadata(result)
+	return result, nil
+}
@@ -0,0 +1,73 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// compressedResultFrame is {"content":"page text page text ...","mime_type":"application/pdf",
+// "metadata":{...},"tables":[],"success":true} compressed with zstd -19.
+var compressedResultFrame = []byte{
+	0x28, 0xb5, 0x2f, 0xfd, 0x60, 0x7e, 0x01, 0xa5, 0x03, 0x00, 0xb2, 0x87, 0x17, 0x17, 0x90, 0x3b,
+	0x07, 0x18, 0xf9, 0x67, 0x32, 0x22, 0x02, 0xc6, 0x7f, 0xf9, 0x2e, 0xb3, 0x92, 0xff, 0x52, 0x17,
+	0x9f, 0x89, 0xcc, 0xb8, 0x1e, 0xd1, 0x36, 0xf0, 0xe9, 0x18, 0x57, 0xa9, 0x54, 0x10, 0xe9, 0xb8,
+	0xcc, 0xa1, 0x77, 0x8a, 0x84, 0xf4, 0x5b, 0xe3, 0x94, 0x71, 0x65, 0xd0, 0x25, 0x4e, 0x3e, 0x74,
+	0x6f, 0x07, 0xa7, 0xaf, 0x76, 0x39, 0x9a, 0x68, 0x28, 0xad, 0x57, 0x44, 0xbf, 0x09, 0xf2, 0x82,
+	0xe6, 0xd6, 0x55, 0x2d, 0xe6, 0x3d, 0x75, 0x7a, 0xdf, 0x73, 0x63, 0xd9, 0x62, 0x4e, 0x06, 0xee,
+	0xec, 0x81, 0x55, 0xf4, 0x39, 0xfd, 0xb6, 0x6f, 0x4e, 0x19, 0x0a, 0x06, 0x00, 0x8d, 0x80, 0x0e,
+	0x43, 0x00, 0xab, 0x03, 0x50, 0x08, 0xf4, 0x0c, 0x2a, 0x70, 0xce, 0xab, 0x96, 0x26,
+}
+
+func TestDecodeResultBuffer(t *testing.T) {
+	compressed := compressedResultFrame
+	result, err := decodeResultBuffer(compressed, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Content != strings.Repeat("page text ", 50) || result.MimeType != "application/pdf" || !result.Success {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if result.Metadata.Subject == nil || *result.Metadata.Subject != "Report" {
+		t.Errorf("unexpected metadata: %+v", result.Metadata)
+	}
+
+	// Success is decoded, not assumed.
+	plain, err := decodeResultBuffer([]byte(`{"content":"short","mime_type":"text/plain","metadata":{},"tables":[]}`), false)
+	if err != nil || plain.Content != "short" || plain.Success {
+		t.Errorf("unexpected plain result: %+v, %v", plain, err)
+	}
+
+	var serializationErr *SerializationError
+	if _, err := decodeResultBuffer(compressed[:40], false); !errors.As(err, &serializationErr) {
+		t.Errorf("expected SerializationError for a truncated frame, got %v", err)
+	}
+}
+
+func TestResultCompressionConfig(t *testing.T) {
+	if got := compressAbove(nil); got != -1 {
+		t.Errorf("compressAbove(nil) = %d", got)
+	}
+	if got := compressAbove(NewExtractionConfig(WithResultCompression())); got != defaultMinCompressedContent {
+		t.Errorf("default compressAbove = %d", got)
+	}
+	if got := compressAbove(NewExtractionConfig(WithResultCompression(WithMinContentBytes(0)))); got != 0 {
+		t.Errorf("compressAbove = %d, want 0", got)
+	}
+	var validationErr *ValidationError
+	if err := validateResultStages(NewExtractionConfig(WithResultCompression(WithMinContentBytes(-1)))); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestPooledZstdReaders(t *testing.T) {
+	for range 3 {
+		result, err := decodeResultBuffer(compressedResultFrame, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Content != strings.Repeat("page text ", 50) {
+			t.Fatalf("unexpected content from a reused decoder: %q", result.Content)
+		}
+	}
+}
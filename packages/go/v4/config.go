@@ -180,6 +180,153 @@ func ConfigMerge(base, override *ExtractionConfig) error {
 	if override.ResultFormat != "" {
 		base.ResultFormat = override.ResultFormat
 	}
+	if override.Normalization != nil {
+		base.Normalization = override.Normalization
+	}
+	if override.TranslateTo != "" {
+		base.TranslateTo = override.TranslateTo
+	}
+	if override.Translation != nil {
+		base.Translation = override.Translation
+	}
+	if override.SectionDetection != nil {
+		base.SectionDetection = override.SectionDetection
+	}
+	if override.TableExtraction != nil {
+		base.TableExtraction = override.TableExtraction
+	}
+	if override.KeyValues != nil {
+		base.KeyValues = override.KeyValues
+	}
+	if override.MarkDetection != nil {
+		base.MarkDetection = override.MarkDetection
+	}
+	if override.OffsetUnit != "" {
+		base.OffsetUnit = override.OffsetUnit
+	}
+	if override.Retry != nil {
+		base.Retry = override.Retry
+	}
+	if len(override.Fallbacks) > 0 {
+		base.Fallbacks = override.Fallbacks
+	}
+	if override.Quarantine != nil {
+		base.Quarantine = override.Quarantine
+	}
+	if override.Deduplicate != nil {
+		base.Deduplicate = override.Deduplicate
+	}
+	if override.FilePolicy != nil {
+		base.FilePolicy = override.FilePolicy
+	}
+	if override.Email != nil {
+		base.Email = override.Email
+	}
+	if override.Chat != nil {
+		base.Chat = override.Chat
+	}
+	if override.Dicom != nil {
+		base.Dicom = override.Dicom
+	}
+	if override.TextStats != nil {
+		base.TextStats = override.TextStats
+	}
+	if override.HeadingDetection != nil {
+		base.HeadingDetection = override.HeadingDetection
+	}
+	if override.LinkExtraction != nil {
+		base.LinkExtraction = override.LinkExtraction
+	}
+	if override.InlineStyles != nil {
+		base.InlineStyles = override.InlineStyles
+	}
+	if override.SourceAttribution != nil {
+		base.SourceAttribution = override.SourceAttribution
+	}
+	if override.Speculative != nil {
+		base.Speculative = override.Speculative
+	}
+	if override.ResultCompression != nil {
+		base.ResultCompression = override.ResultCompression
+	}
+	if override.SharedContent != nil {
+		base.SharedContent = override.SharedContent
+	}
+	if override.PackedBatches != nil {
+		base.PackedBatches = override.PackedBatches
+	}
+	if override.ProfileLabels != nil {
+		base.ProfileLabels = override.ProfileLabels
+	}
+	if override.Retention != nil {
+		base.Retention = override.Retention
+	}
+	if override.Passwords != nil {
+		base.Passwords = override.Passwords
+	}
+	if override.MaxDepth != nil {
+		base.MaxDepth = override.MaxDepth
+	}
+	if override.MaxNestedBytes != nil {
+		base.MaxNestedBytes = override.MaxNestedBytes
+	}
+	if override.Include != nil {
+		base.Include = override.Include
+	}
+	if override.LazyMetadata != nil {
+		base.LazyMetadata = override.LazyMetadata
+	}
+	if override.Paging != nil {
+		base.Paging = override.Paging
+	}
+	if override.Anonymization != nil {
+		base.Anonymization = override.Anonymization
+	}
+	if override.Amounts != nil {
+		base.Amounts = override.Amounts
+	}
+	if override.Acronyms != nil {
+		base.Acronyms = override.Acronyms
+	}
+	if override.Turns != nil {
+		base.Turns = override.Turns
+	}
+	if override.LanguageFilter != nil {
+		base.LanguageFilter = override.LanguageFilter
+	}
+	if override.PageDeduplication != nil {
+		base.PageDeduplication = override.PageDeduplication
+	}
+	if override.ScanQuality != nil {
+		base.ScanQuality = override.ScanQuality
+	}
+	if override.CoordinateUnit != "" {
+		base.CoordinateUnit = override.CoordinateUnit
+	}
+	if override.FontInventory != nil {
+		base.FontInventory = override.FontInventory
+	}
+	if override.TextEncoding != nil {
+		base.TextEncoding = override.TextEncoding
+	}
+	if override.VerticalText != nil {
+		base.VerticalText = override.VerticalText
+	}
+	if override.RubyText != "" {
+		base.RubyText = override.RubyText
+	}
+	if override.Portfolios != nil {
+		base.Portfolios = override.Portfolios
+	}
+	if override.GeoMetadata != nil {
+		base.GeoMetadata = override.GeoMetadata
+	}
+	if override.ExifMetadata != nil {
+		base.ExifMetadata = override.ExifMetadata
+	}
+	if override.Meetings != nil {
+		base.Meetings = override.Meetings
+	}
 
 	return nil
 }
@@ -0,0 +1,152 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+)
+
+// Config file formats understood by LoadConfig, ConfigFromYAML, and ConfigToYAML.
+const (
+	ConfigFormatJSON = "json"
+	ConfigFormatYAML = "yaml"
+	ConfigFormatTOML = "toml"
+)
+
+// LoadConfig reads an ExtractionConfig from a JSON, YAML, or TOML file, chosen by its
+// extension. Unlike LoadExtractionConfigFromFile, it keeps settings applied by this
+// binding (such as normalization or section detection), rejects unknown fields, and
+// validates the result. Settings left out of the file are filled with the library
+// defaults, so the returned config describes exactly what an extraction will use.
+func LoadConfig(path string) (*ExtractionConfig, error) {
+	if path == "" {
+		return nil, newValidationErrorWithContext("config path cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	format, err := configFormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newIOErrorWithContext(fmt.Sprintf("failed to read config file %s", path), err, ErrorCodeIo, nil)
+	}
+	document, err := convertConfigFormat(string(content), format, ConfigFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigDocument(document, true)
+}
+
+// ConfigFromYAML parses an ExtractionConfig from a YAML document. Unknown fields are
+// rejected and the config is validated, but defaults are not filled in.
+func ConfigFromYAML(yamlStr string) (*ExtractionConfig, error) {
+	if strings.TrimSpace(yamlStr) == "" {
+		return nil, newValidationErrorWithContext("YAML string cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	document, err := convertConfigFormat(yamlStr, ConfigFormatYAML, ConfigFormatJSON)
+	if err != nil {
+		return nil, err
+	}
+	return parseConfigDocument(document, false)
+}
+
+// ConfigToYAML serializes an ExtractionConfig to YAML, including binding-side settings.
+// Unset fields are omitted.
+func ConfigToYAML(config *ExtractionConfig) (string, error) {
+	if config == nil {
+		return "", newValidationErrorWithContext("config cannot be nil", nil, ErrorCodeValidation, nil)
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", newSerializationErrorWithContext("failed to encode config", err, ErrorCodeValidation, nil)
+	}
+	return convertConfigFormat(string(data), ConfigFormatJSON, ConfigFormatYAML)
+}
+
+// configFormatFromPath maps a config file extension to its format.
+func configFormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ConfigFormatJSON, nil
+	case ".yaml", ".yml":
+		return ConfigFormatYAML, nil
+	case ".toml":
+		return ConfigFormatTOML, nil
+	}
+	return "", newValidationErrorWithContext(fmt.Sprintf("unsupported config file extension: %s", path), nil, ErrorCodeValidation, nil)
+}
+
+// parseConfigDocument strictly decodes a JSON config document and validates it. With
+// withDefaults, the library defaults are merged under the settings of the document.
+func parseConfigDocument(document string, withDefaults bool) (*ExtractionConfig, error) {
+	cfg := &ExtractionConfig{}
+	if err := decodeConfigStrict(document, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Chunking != nil {
+		if err := validateChunkingConfig(cfg.Chunking); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateResultStages(cfg); err != nil {
+		return nil, err
+	}
+
+	// The native round trip validates the settings it models and reports them with defaults.
+	normalized, err := ConfigToJSON(nativeConfig(cfg))
+	if err != nil {
+		return nil, err
+	}
+	if !withDefaults {
+		return cfg, nil
+	}
+	merged := &ExtractionConfig{}
+	if err := json.Unmarshal([]byte(normalized), merged); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode config defaults", err, ErrorCodeValidation, nil)
+	}
+	if err := json.Unmarshal([]byte(document), merged); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode config", err, ErrorCodeValidation, nil)
+	}
+	return merged, nil
+}
+
+// decodeConfigStrict decodes document into cfg, rejecting fields ExtractionConfig does not define.
+func decodeConfigStrict(document string, cfg *ExtractionConfig) error {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(document)))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(cfg); err != nil {
+		return newValidationErrorWithContext(fmt.Sprintf("invalid config: %v", err), err, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// convertConfigFormat converts a config document between JSON, YAML, and TOML.
+func convertConfigFormat(content, from, to string) (string, error) {
+	if from == to {
+		return content, nil
+	}
+	cContent := C.CString(content)
+	defer C.free(unsafe.Pointer(cContent))
+	cFrom := C.CString(from)
+	defer C.free(unsafe.Pointer(cFrom))
+	cTo := C.CString(to)
+	defer C.free(unsafe.Pointer(cTo))
+
+	ptr := C.kreuzberg_config_convert_format(cContent, cFrom, cTo)
+	if ptr == nil {
+		return "", lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	return C.GoString(ptr), nil
+}
@@ -0,0 +1,44 @@
+package kreuzberg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigRejectsUnknownFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kreuzberg.json")
+	if err := os.WriteFile(path, []byte(`{"use_cache": true, "chunkng": {"max_chars": 100}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	_, err := LoadConfig(path)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for unknown field, got %v", err)
+	}
+}
+
+func TestLoadConfigValidatesBindingSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kreuzberg.json")
+	if err := os.WriteFile(path, []byte(`{"offset_unit": "words"}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	var validationErr *ValidationError
+	if _, err := LoadConfig(path); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for invalid offset unit, got %v", err)
+	}
+}
+
+func TestLoadConfigUnsupportedExtension(t *testing.T) {
+	var validationErr *ValidationError
+	if _, err := LoadConfig("kreuzberg.ini"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for unsupported extension, got %v", err)
+	}
+	if _, err := ConfigFromYAML("  \n"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for empty YAML, got %v", err)
+	}
+	if _, err := ConfigToYAML(nil); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for nil config, got %v", err)
+	}
+}
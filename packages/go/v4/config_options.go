@@ -15,6 +15,8 @@ package kreuzberg
 //		),
 //	)
 
+import "time"
+
 // ============================================================================
 // ExtractionConfig Options
 // ============================================================================
@@ -143,6 +145,393 @@ func WithResultFormat(format string) ExtractionOption {
 	}
 }
 
+// WithNormalization sets the text normalization configuration with functional options.
+func WithNormalization(opts ...NormalizationOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Normalization = NewNormalizationConfig(opts...)
+	}
+}
+
+// WithTranslateTo sets the target language for the translation step.
+func WithTranslateTo(language string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.TranslateTo = language
+	}
+}
+
+// WithTranslation sets the translation configuration with functional options.
+func WithTranslation(opts ...TranslationOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Translation = NewTranslationConfig(opts...)
+	}
+}
+
+// WithTableExtraction sets the table extraction configuration with functional options.
+func WithTableExtraction(opts ...TableExtractionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.TableExtraction = NewTableExtractionConfig(opts...)
+	}
+}
+
+// WithKeyValues enables key-value pair extraction with functional options.
+func WithKeyValues(opts ...KeyValueOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.KeyValues = NewKeyValueConfig(opts...)
+	}
+}
+
+// WithMarkDetection enables signature and stamp detection with functional options.
+func WithMarkDetection(opts ...MarkDetectionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.MarkDetection = NewMarkDetectionConfig(opts...)
+	}
+}
+
+// WithOffsetUnit reports chunk and page boundary offsets in the given unit as well.
+func WithOffsetUnit(unit string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.OffsetUnit = unit
+	}
+}
+
+// WithCoordinateUnit converts page dimensions and the bounding boxes of tables and
+// elements from points to the given unit, such as CoordinateUnitNormalized.
+func WithCoordinateUnit(unit string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.CoordinateUnit = unit
+	}
+}
+
+// WithRetry retries transient failures in batch extraction with functional options.
+func WithRetry(opts ...RetryOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Retry = NewRetryConfig(opts...)
+	}
+}
+
+// WithFallbacks sets the fallback chain for a MIME type, a "type/*" wildcard, or "*" for
+// any format, e.g. WithFallbacks("application/pdf", FallbackNative, FallbackOCR,
+// FallbackStrings).
+func WithFallbacks(mimeType string, steps ...string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		if c.Fallbacks == nil {
+			c.Fallbacks = make(map[string][]string)
+		}
+		c.Fallbacks[mimeType] = steps
+	}
+}
+
+// WithQuarantine skips files that keep failing in batch extraction, recording them in the
+// manifest at manifestPath.
+func WithQuarantine(manifestPath string, opts ...QuarantineOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Quarantine = NewQuarantineConfig(manifestPath, opts...)
+	}
+}
+
+// WithDeduplicate sets whether batch extraction extracts identical documents only once.
+func WithDeduplicate(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Deduplicate = &enabled
+	}
+}
+
+// WithFilePolicy sets which files batch extraction skips with functional options.
+func WithFilePolicy(opts ...FilePolicyOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.FilePolicy = NewFilePolicyConfig(opts...)
+	}
+}
+
+// WithEmail sets how email messages are rendered with functional options.
+func WithEmail(opts ...EmailOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Email = NewEmailConfig(opts...)
+	}
+}
+
+// WithChat sets how chat exports are split into conversation windows with functional options.
+func WithChat(opts ...ChatOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Chat = NewChatConfig(opts...)
+	}
+}
+
+// WithDicom configures DICOM extraction with functional options.
+func WithDicom(opts ...DicomOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Dicom = NewDicomConfig(opts...)
+	}
+}
+
+// WithTextStats enables text statistics and readability scores with functional options.
+func WithTextStats(opts ...TextStatsOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.TextStats = NewTextStatsConfig(opts...)
+	}
+}
+
+// WithHeadingDetection enables the heading tree with functional options.
+func WithHeadingDetection(opts ...HeadingDetectionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.HeadingDetection = NewHeadingDetectionConfig(opts...)
+	}
+}
+
+// WithLinkExtraction enables hyperlink extraction with functional options.
+func WithLinkExtraction(opts ...LinkExtractionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.LinkExtraction = NewLinkExtractionConfig(opts...)
+	}
+}
+
+// WithInlineStyles enables inline style annotations with functional options.
+func WithInlineStyles(opts ...InlineStyleOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.InlineStyles = NewInlineStyleConfig(opts...)
+	}
+}
+
+// WithSourceAttribution enables text source attribution with functional options.
+func WithSourceAttribution(opts ...SourceAttributionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.SourceAttribution = NewSourceAttributionConfig(opts...)
+	}
+}
+
+// WithSpeculative enables speculative extraction of PDFs with functional options.
+func WithSpeculative(opts ...SpeculativeOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Speculative = NewSpeculativeConfig(opts...)
+	}
+}
+
+// WithResultCompression enables compressed results with functional options.
+func WithResultCompression(opts ...ResultCompressionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.ResultCompression = NewResultCompressionConfig(opts...)
+	}
+}
+
+// WithSharedContent sets whether the text of chunks and pages is held as views of the
+// result's Content rather than as copies. A chunk or page kept on its own then keeps the
+// whole of Content alive.
+func WithSharedContent(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.SharedContent = &enabled
+	}
+}
+
+// WithPackedBatches sets whether batch extraction returns all results from the core in one
+// JSON buffer, compressed as configured by WithResultCompression, rather than as separate
+// C results. It cuts the per-document overhead of batches of many small documents.
+func WithPackedBatches(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.PackedBatches = &enabled
+	}
+}
+
+// WithProfileLabels sets whether extractions label their goroutine with the file, format
+// and stage being processed, for CPU profiles. See ProfileLabelFile.
+func WithProfileLabels(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.ProfileLabels = &enabled
+	}
+}
+
+// WithRetention keeps the temporary files of extractions in private directories under dir,
+// wiped when each extraction returns. An empty dir uses os.TempDir(). See RetentionConfig.
+func WithRetention(dir string, requireEncryption bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Retention = &RetentionConfig{Dir: dir, RequireEncryption: &requireEncryption}
+	}
+}
+
+// WithPasswords sets the passwords tried, in order, on encrypted ZIP and 7z entries.
+// Entries none of them decrypts are listed in ArchiveMetadata.EntryErrors. Encrypted PDFs
+// use PdfConfig.Passwords.
+func WithPasswords(passwords ...string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Passwords = passwords
+	}
+}
+
+// WithMaxDepth extracts the entries of archives and the attachments of emails into
+// ExtractionResult.EmbeddedDocuments, opening nested archives and emails down to depth
+// levels.
+func WithMaxDepth(depth int) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.MaxDepth = &depth
+	}
+}
+
+// WithMaxNestedBytes limits the decompressed bytes of all the nested documents of one
+// extraction. Default: DefaultMaxNestedBytes.
+func WithMaxNestedBytes(limit int64) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.MaxNestedBytes = &limit
+	}
+}
+
+// WithInclude returns only the given parts of the result. The native library leaves out
+// the others unless a binding-side stage needs them, saving their serialization and
+// copies. Parts left out are empty in the result.
+func WithInclude(fields ...ResultField) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Include = fields
+	}
+}
+
+// WithLazyMetadata decodes the format payload and the additional fields of result
+// metadata on the first call of an accessor such as Metadata.PdfMetadata or
+// Metadata.AdditionalFields instead of for every result, which saves time on batches
+// whose metadata goes unread. Metadata.Format then holds only its Type and
+// Metadata.Additional is nil until an accessor is called.
+func WithLazyMetadata(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.LazyMetadata = &enabled
+	}
+}
+
+// WithPaging moves the pages and chunks of results holding at least minEntries of them
+// to a file, read back a range at a time with GetPages and GetChunks. See PagingConfig.
+func WithPaging(minEntries int) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Paging = &PagingConfig{MinEntries: &minEntries}
+	}
+}
+
+// WithAnonymization strips or hashes the identifying metadata of results, such as authors,
+// creating software and GPS positions. salt keys the hashes of AnonymizeHash. See
+// AnonymizationConfig.
+func WithAnonymization(mode AnonymizationMode, salt string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Anonymization = &AnonymizationConfig{Mode: mode, Salt: salt}
+	}
+}
+
+// WithAmountDetection detects sums of money and measurements into ExtractionResult.Amounts,
+// reading numbers in locale, or the detected language when it is empty. kinds limits
+// detection to AmountMoney or AmountMeasurement.
+func WithAmountDetection(locale string, kinds ...string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Amounts = &AmountDetectionConfig{Kinds: kinds, Locale: locale}
+	}
+}
+
+// WithAcronyms sets whether the acronyms defined in Content are collected into
+// ExtractionResult.Acronyms.
+func WithAcronyms(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Acronyms = &enabled
+	}
+}
+
+// WithTurnDetection segments transcripts into speaker turns in ExtractionResult.Turns.
+// speakers are labels accepted even when they speak only once.
+func WithTurnDetection(speakers ...string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Turns = &TurnDetectionConfig{Speakers: speakers}
+	}
+}
+
+// WithLanguageFilter keeps only the paragraphs written in languages, ISO 639-1 or ISO 639-3
+// codes, dropping or segregating the others according to mode. See LanguageFilterConfig.
+func WithLanguageFilter(mode string, languages ...string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.LanguageFilter = &LanguageFilterConfig{Languages: languages, Mode: mode}
+	}
+}
+
+// WithPageDeduplication flags blank pages and pages repeating an earlier one, and with
+// collapse removes them. See PageDeduplicationConfig.
+func WithPageDeduplication(collapse bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.PageDeduplication = &PageDeduplicationConfig{Collapse: collapse}
+	}
+}
+
+// WithScanQuality sets whether the DPI, color mode, skew angle and noise of scanned pages
+// are measured into PageInfo.Scan.
+func WithScanQuality(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.ScanQuality = &enabled
+	}
+}
+
+// WithFontInventory sets whether the fonts of PDF and Word documents are listed in
+// Metadata.Fonts, with warnings for fonts likely to garble the extracted text.
+func WithFontInventory(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.FontInventory = &enabled
+	}
+}
+
+// WithTextEncoding transcodes plain text and CSV documents from the given encoding, such
+// as EncodingWindows1251, to UTF-8; an empty encoding detects it. See TextEncodingConfig.
+func WithTextEncoding(encoding string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.TextEncoding = &TextEncodingConfig{Encoding: encoding}
+	}
+}
+
+// WithVerticalText sets whether vertically typeset Japanese and Chinese text in PDF
+// documents is read in column order, with the writing mode and vertical regions of each
+// page in PageInfo.
+func WithVerticalText(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.VerticalText = &enabled
+	}
+}
+
+// WithRubyText sets how the ruby annotations of Word, HTML and EPUB documents, such as
+// Japanese furigana, are written in the extracted text: RubyTextInline,
+// RubyTextParenthesized or RubyTextDropped.
+func WithRubyText(mode string) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.RubyText = mode
+	}
+}
+
+// WithPortfolios sets whether the files embedded in PDF portfolios are extracted into
+// ExtractionResult.EmbeddedDocuments, with the portfolio manifest in Metadata.Portfolio.
+func WithPortfolios(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Portfolios = &enabled
+	}
+}
+
+// WithGeoMetadata sets whether the geospatial registration of GeoPDF maps and GeoTIFF
+// images is read into Metadata.Geo.
+func WithGeoMetadata(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.GeoMetadata = &enabled
+	}
+}
+
+// WithExifMetadata sets whether the camera, capture time, orientation and GPS fields of
+// ImageMetadata are read from the EXIF data of images.
+func WithExifMetadata(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.ExifMetadata = &enabled
+	}
+}
+
+// WithMeetings sets whether the meeting invitations of EML and MSG messages are parsed
+// into EmailMetadata.Meetings.
+func WithMeetings(enabled bool) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.Meetings = &enabled
+	}
+}
+
+// WithSectionDetection enables section detection with functional options.
+func WithSectionDetection(opts ...SectionDetectionOption) ExtractionOption {
+	return func(c *ExtractionConfig) {
+		c.SectionDetection = NewSectionDetectionConfig(opts...)
+	}
+}
+
 // ============================================================================
 // OCRConfig Options
 // ============================================================================
@@ -170,6 +559,14 @@ func WithOCRLanguage(lang string) OCROption {
 	}
 }
 
+// WithOCRPageParallelism sets how many pages of a PDF are recognized concurrently when
+// OCR is forced.
+func WithOCRPageParallelism(pages int) OCROption {
+	return func(c *OCRConfig) {
+		c.PageParallelism = &pages
+	}
+}
+
 // WithTesseract sets the Tesseract configuration with functional options.
 func WithTesseract(opts ...TesseractOption) OCROption {
 	return func(c *OCRConfig) {
@@ -440,6 +837,20 @@ func WithChunkOverlap(overlap int) ChunkingOption {
 	}
 }
 
+// WithChunkingStrategy sets the chunking strategy ("" or "markdown_structure").
+func WithChunkingStrategy(strategy string) ChunkingOption {
+	return func(c *ChunkingConfig) {
+		c.Strategy = strategy
+	}
+}
+
+// WithTablePolicy sets how tables are chunked ("inline", "separate", or "skip").
+func WithTablePolicy(policy string) ChunkingOption {
+	return func(c *ChunkingConfig) {
+		c.TablePolicy = policy
+	}
+}
+
 // WithChunkingPreset sets the chunking preset.
 func WithChunkingPreset(preset string) ChunkingOption {
 	return func(c *ChunkingConfig) {
@@ -1253,3 +1664,581 @@ func WithMarkerFormat(format string) PageOption {
 		c.MarkerFormat = &format
 	}
 }
+
+// ============================================================================
+// NormalizationConfig Options
+// ============================================================================
+
+// NewNormalizationConfig creates a new NormalizationConfig with the given options.
+func NewNormalizationConfig(opts ...NormalizationOption) *NormalizationConfig {
+	cfg := &NormalizationConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithFullWidthToHalfWidth enables folding of full-width forms to half-width.
+func WithFullWidthToHalfWidth(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.FullWidthToHalfWidth = &enabled
+	}
+}
+
+// WithArabicShaping enables normalization of Arabic presentation forms.
+func WithArabicShaping(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.ArabicShaping = &enabled
+	}
+}
+
+// WithGermanEszett sets the German ß handling mode ("keep" or "ss").
+func WithGermanEszett(mode string) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.GermanEszett = mode
+	}
+}
+
+// WithStripBOM sets whether a leading byte-order mark is removed.
+func WithStripBOM(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.StripBOM = &enabled
+	}
+}
+
+// WithNormalizeNewlines sets whether CRLF and CR line endings are converted to LF.
+func WithNormalizeNewlines(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.NormalizeNewlines = &enabled
+	}
+}
+
+// WithStripControlCharacters sets whether control characters other than tab, line feed,
+// carriage return and form feed are removed.
+func WithStripControlCharacters(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.StripControlCharacters = &enabled
+	}
+}
+
+// WithStripZeroWidth sets whether zero-width and invisible characters are removed.
+func WithStripZeroWidth(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.StripZeroWidth = &enabled
+	}
+}
+
+// WithDateNormalization sets whether dates are recorded as ISO 8601 in
+// ExtractionResult.NormalizedValues.
+func WithDateNormalization(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.Dates = &enabled
+	}
+}
+
+// WithNumberNormalization sets whether numbers are recorded as plain decimals in
+// ExtractionResult.NormalizedValues.
+func WithNumberNormalization(enabled bool) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.Numbers = &enabled
+	}
+}
+
+// WithNormalizationLocale sets the BCP 47 locale, such as "de-DE", that numeric dates and
+// numbers are read in.
+func WithNormalizationLocale(locale string) NormalizationOption {
+	return func(c *NormalizationConfig) {
+		c.Locale = locale
+	}
+}
+
+// ============================================================================
+// TranslationConfig Options
+// ============================================================================
+
+// NewTranslationConfig creates a new TranslationConfig with the given options.
+func NewTranslationConfig(opts ...TranslationOption) *TranslationConfig {
+	cfg := &TranslationConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithTranslator selects a registered translator by name.
+func WithTranslator(name string) TranslationOption {
+	return func(c *TranslationConfig) {
+		c.Translator = name
+	}
+}
+
+// WithTranslateChunks sets whether chunks are translated as well.
+func WithTranslateChunks(enabled bool) TranslationOption {
+	return func(c *TranslationConfig) {
+		c.TranslateChunks = &enabled
+	}
+}
+
+// ============================================================================
+// SectionDetectionConfig Options
+// ============================================================================
+
+// NewSectionDetectionConfig creates a new SectionDetectionConfig with the given options.
+func NewSectionDetectionConfig(opts ...SectionDetectionOption) *SectionDetectionConfig {
+	cfg := &SectionDetectionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithSectionLabels restricts section detection to the given labels.
+func WithSectionLabels(labels ...string) SectionDetectionOption {
+	return func(c *SectionDetectionConfig) {
+		c.Labels = labels
+	}
+}
+
+// ============================================================================
+// TableExtractionConfig Options
+// ============================================================================
+
+// NewTableExtractionConfig creates a new TableExtractionConfig with the given options.
+func NewTableExtractionConfig(opts ...TableExtractionOption) *TableExtractionConfig {
+	cfg := &TableExtractionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithTableBackend selects the table detection backend ("heuristic" or "none").
+func WithTableBackend(backend string) TableExtractionOption {
+	return func(c *TableExtractionConfig) {
+		c.Backend = backend
+	}
+}
+
+// WithTableMinConfidence drops tables detected with a lower confidence.
+func WithTableMinConfidence(confidence float64) TableExtractionOption {
+	return func(c *TableExtractionConfig) {
+		c.MinConfidence = &confidence
+	}
+}
+
+// ============================================================================
+// KeyValueConfig Options
+// ============================================================================
+
+// NewKeyValueConfig creates a new KeyValueConfig with the given options.
+func NewKeyValueConfig(opts ...KeyValueOption) *KeyValueConfig {
+	cfg := &KeyValueConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithKeyValueMinConfidence drops key-value pairs with a lower confidence.
+func WithKeyValueMinConfidence(confidence float64) KeyValueOption {
+	return func(c *KeyValueConfig) {
+		c.MinConfidence = &confidence
+	}
+}
+
+// ============================================================================
+// MarkDetectionConfig Options
+// ============================================================================
+
+// NewMarkDetectionConfig creates a new MarkDetectionConfig with the given options.
+func NewMarkDetectionConfig(opts ...MarkDetectionOption) *MarkDetectionConfig {
+	cfg := &MarkDetectionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMarkKinds limits detection to the given mark kinds.
+func WithMarkKinds(kinds ...string) MarkDetectionOption {
+	return func(c *MarkDetectionConfig) {
+		c.Kinds = kinds
+	}
+}
+
+// WithMarkMinConfidence drops marks with a lower confidence.
+func WithMarkMinConfidence(confidence float64) MarkDetectionOption {
+	return func(c *MarkDetectionConfig) {
+		c.MinConfidence = &confidence
+	}
+}
+
+// ============================================================================
+// RetryConfig Options
+// ============================================================================
+
+// NewRetryConfig creates a new RetryConfig with the given options.
+func NewRetryConfig(opts ...RetryOption) *RetryConfig {
+	cfg := &RetryConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMaxAttempts sets the total number of attempts per document.
+func WithMaxAttempts(attempts int) RetryOption {
+	return func(c *RetryConfig) {
+		c.MaxAttempts = &attempts
+	}
+}
+
+// WithBackoff sets the initial and maximum wait between attempts.
+func WithBackoff(initial, maximum time.Duration) RetryOption {
+	return func(c *RetryConfig) {
+		initialMs := int(initial.Milliseconds())
+		maxMs := int(maximum.Milliseconds())
+		c.InitialBackoffMs = &initialMs
+		c.MaxBackoffMs = &maxMs
+	}
+}
+
+// WithRetryOn sets the error kinds that are retried.
+func WithRetryOn(kinds ...ErrorKind) RetryOption {
+	return func(c *RetryConfig) {
+		c.RetryOn = kinds
+	}
+}
+
+// ============================================================================
+// QuarantineConfig Options
+// ============================================================================
+
+// NewQuarantineConfig creates a new QuarantineConfig with the given manifest path and options.
+func NewQuarantineConfig(manifestPath string, opts ...QuarantineOption) *QuarantineConfig {
+	cfg := &QuarantineConfig{ManifestPath: manifestPath}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMaxFailures sets the number of failed extractions after which a file is quarantined.
+func WithMaxFailures(failures int) QuarantineOption {
+	return func(c *QuarantineConfig) {
+		c.MaxFailures = &failures
+	}
+}
+
+// WithDocumentTimeout sets the milliseconds after which a document is abandoned and
+// counted as a failed extraction.
+func WithDocumentTimeout(ms int) QuarantineOption {
+	return func(c *QuarantineConfig) {
+		c.TimeoutMs = &ms
+	}
+}
+
+// ============================================================================
+// FilePolicyConfig Options
+// ============================================================================
+
+// NewFilePolicyConfig creates a new FilePolicyConfig with the given options.
+func NewFilePolicyConfig(opts ...FilePolicyOption) *FilePolicyConfig {
+	cfg := &FilePolicyConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithSymlinkPolicy sets how symbolic links are handled: "follow", "skip", or "error".
+func WithSymlinkPolicy(policy string) FilePolicyOption {
+	return func(c *FilePolicyConfig) {
+		c.Symlinks = policy
+	}
+}
+
+// WithSpecialFilePolicy sets how devices, pipes, and sockets are handled: "skip" or "error".
+func WithSpecialFilePolicy(policy string) FilePolicyOption {
+	return func(c *FilePolicyConfig) {
+		c.SpecialFiles = policy
+	}
+}
+
+// WithMaxFileSize skips files larger than size bytes.
+func WithMaxFileSize(size int64) FilePolicyOption {
+	return func(c *FilePolicyConfig) {
+		c.MaxFileSize = size
+	}
+}
+
+// WithSparseFilePolicy sets how files with holes are handled: "extract", "skip", or "error".
+func WithSparseFilePolicy(policy string) FilePolicyOption {
+	return func(c *FilePolicyConfig) {
+		c.SparseFiles = policy
+	}
+}
+
+// ============================================================================
+// EmailConfig Options
+// ============================================================================
+
+// NewEmailConfig creates a new EmailConfig with the given options.
+func NewEmailConfig(opts ...EmailOption) *EmailConfig {
+	cfg := &EmailConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithPreferHTML sets whether the HTML body is rendered instead of the plain text body.
+func WithPreferHTML(enabled bool) EmailOption {
+	return func(c *EmailConfig) {
+		c.PreferHTML = &enabled
+	}
+}
+
+// WithStripQuotedReplies sets whether quoted earlier messages are removed.
+func WithStripQuotedReplies(enabled bool) EmailOption {
+	return func(c *EmailConfig) {
+		c.StripQuotedReplies = &enabled
+	}
+}
+
+// WithInlineAttachments sets whether attachment content is extracted into the message.
+func WithInlineAttachments(enabled bool) EmailOption {
+	return func(c *EmailConfig) {
+		c.InlineAttachments = &enabled
+	}
+}
+
+// WithHeadersAsFrontMatter sets whether headers are rendered as YAML front matter.
+func WithHeadersAsFrontMatter(enabled bool) EmailOption {
+	return func(c *EmailConfig) {
+		c.HeadersAsFrontMatter = &enabled
+	}
+}
+
+// ============================================================================
+// ChatConfig Options
+// ============================================================================
+
+// NewChatConfig creates a new ChatConfig with the given options.
+func NewChatConfig(opts ...ChatOption) *ChatConfig {
+	cfg := &ChatConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithWindowGap sets the silence after which a new conversation window starts.
+func WithWindowGap(gap time.Duration) ChatOption {
+	return func(c *ChatConfig) {
+		seconds := int(gap / time.Second)
+		c.WindowGapSeconds = &seconds
+	}
+}
+
+// WithMaxWindowChars caps the size of a conversation window; zero disables the cap.
+func WithMaxWindowChars(chars int) ChatOption {
+	return func(c *ChatConfig) {
+		c.MaxWindowChars = &chars
+	}
+}
+
+// ============================================================================
+// DicomConfig Options
+// ============================================================================
+
+// NewDicomConfig creates a new DicomConfig with the given options.
+func NewDicomConfig(opts ...DicomOption) *DicomConfig {
+	cfg := &DicomConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithAnonymize removes patient and staff identifiers from DICOM output.
+func WithAnonymize(enabled bool) DicomOption {
+	return func(c *DicomConfig) {
+		c.Anonymize = &enabled
+	}
+}
+
+// WithPixelDataOCR runs OCR over the pixel data of DICOM images.
+func WithPixelDataOCR(enabled bool) DicomOption {
+	return func(c *DicomConfig) {
+		c.OCRPixelData = &enabled
+	}
+}
+
+// ============================================================================
+// TextStatsConfig Options
+// ============================================================================
+
+// NewTextStatsConfig creates a new TextStatsConfig with the given options.
+func NewTextStatsConfig(opts ...TextStatsOption) *TextStatsConfig {
+	cfg := &TextStatsConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithReadabilityLanguage selects the language of the Flesch reading ease formula.
+func WithReadabilityLanguage(language string) TextStatsOption {
+	return func(c *TextStatsConfig) {
+		c.Language = language
+	}
+}
+
+// ============================================================================
+// HeadingDetectionConfig Options
+// ============================================================================
+
+// NewHeadingDetectionConfig creates a new HeadingDetectionConfig with the given options.
+func NewHeadingDetectionConfig(opts ...HeadingDetectionOption) *HeadingDetectionConfig {
+	cfg := &HeadingDetectionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMaxHeadingLevel leaves out headings deeper than level.
+func WithMaxHeadingLevel(level int) HeadingDetectionOption {
+	return func(c *HeadingDetectionConfig) {
+		c.MaxLevel = &level
+	}
+}
+
+// WithPDFFontSizes enables or disables font size analysis of PDFs.
+func WithPDFFontSizes(enabled bool) HeadingDetectionOption {
+	return func(c *HeadingDetectionConfig) {
+		c.PDFFontSizes = &enabled
+	}
+}
+
+// ============================================================================
+// LinkExtractionConfig Options
+// ============================================================================
+
+// NewLinkExtractionConfig creates a new LinkExtractionConfig with the given options.
+func NewLinkExtractionConfig(opts ...LinkExtractionOption) *LinkExtractionConfig {
+	cfg := &LinkExtractionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithLinkValidation requests external links to record their HTTP status.
+func WithLinkValidation(enabled bool) LinkExtractionOption {
+	return func(c *LinkExtractionConfig) {
+		c.Validate = &enabled
+	}
+}
+
+// WithLinkValidationTimeout bounds the validation of each link.
+func WithLinkValidationTimeout(ms int) LinkExtractionOption {
+	return func(c *LinkExtractionConfig) {
+		c.ValidationTimeoutMs = &ms
+	}
+}
+
+// ============================================================================
+// InlineStyleConfig Options
+// ============================================================================
+
+// NewInlineStyleConfig creates a new InlineStyleConfig with the given options.
+func NewInlineStyleConfig(opts ...InlineStyleOption) *InlineStyleConfig {
+	cfg := &InlineStyleConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithStyles limits the annotations to the given styles.
+func WithStyles(styles ...string) InlineStyleOption {
+	return func(c *InlineStyleConfig) {
+		c.Styles = styles
+	}
+}
+
+// ============================================================================
+// SourceAttributionConfig Options
+// ============================================================================
+
+// NewSourceAttributionConfig creates a new SourceAttributionConfig with the given options.
+func NewSourceAttributionConfig(opts ...SourceAttributionOption) *SourceAttributionConfig {
+	cfg := &SourceAttributionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithNativeThreshold sets the share of text layer words from which a block is native.
+func WithNativeThreshold(threshold float64) SourceAttributionOption {
+	return func(c *SourceAttributionConfig) {
+		c.NativeThreshold = &threshold
+	}
+}
+
+// WithOCRThreshold sets the share of text layer words up to which a block is OCR.
+func WithOCRThreshold(threshold float64) SourceAttributionOption {
+	return func(c *SourceAttributionConfig) {
+		c.OCRThreshold = &threshold
+	}
+}
+
+// WithReconciliation reconciles hybrid PDF pages with their OCR.
+func WithReconciliation(enabled bool) SourceAttributionOption {
+	return func(c *SourceAttributionConfig) {
+		c.Reconcile = &enabled
+	}
+}
+
+// ============================================================================
+// SpeculativeConfig Options
+// ============================================================================
+
+// NewSpeculativeConfig creates a new SpeculativeConfig with the given options.
+func NewSpeculativeConfig(opts ...SpeculativeOption) *SpeculativeConfig {
+	cfg := &SpeculativeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithQualityThreshold sets the text quality a path must reach to be returned.
+func WithQualityThreshold(threshold float64) SpeculativeOption {
+	return func(c *SpeculativeConfig) {
+		c.QualityThreshold = &threshold
+	}
+}
+
+// ============================================================================
+// ResultCompressionConfig Options
+// ============================================================================
+
+// NewResultCompressionConfig creates a new ResultCompressionConfig with the given options.
+func NewResultCompressionConfig(opts ...ResultCompressionOption) *ResultCompressionConfig {
+	cfg := &ResultCompressionConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// WithMinContentBytes sets the content size from which results are compressed.
+func WithMinContentBytes(size int) ResultCompressionOption {
+	return func(c *ResultCompressionConfig) {
+		c.MinContentBytes = &size
+	}
+}
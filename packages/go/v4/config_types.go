@@ -67,6 +67,63 @@ type HTMLConversionOption func(*HTMLConversionOptions)
 // PageOption is a functional option for configuring PageConfig.
 type PageOption func(*PageConfig)
 
+// NormalizationOption is a functional option for configuring NormalizationConfig.
+type NormalizationOption func(*NormalizationConfig)
+
+// TranslationOption is a functional option for configuring TranslationConfig.
+type TranslationOption func(*TranslationConfig)
+
+// SectionDetectionOption is a functional option for configuring SectionDetectionConfig.
+type SectionDetectionOption func(*SectionDetectionConfig)
+
+// TableExtractionOption is a functional option for configuring TableExtractionConfig.
+type TableExtractionOption func(*TableExtractionConfig)
+
+// KeyValueOption is a functional option for configuring KeyValueConfig.
+type KeyValueOption func(*KeyValueConfig)
+
+// MarkDetectionOption is a functional option for configuring MarkDetectionConfig.
+type MarkDetectionOption func(*MarkDetectionConfig)
+
+// ChatOption is a functional option for configuring ChatConfig.
+type ChatOption func(*ChatConfig)
+
+// DicomOption is a functional option for configuring DicomConfig.
+type DicomOption func(*DicomConfig)
+
+// TextStatsOption is a functional option for configuring TextStatsConfig.
+type TextStatsOption func(*TextStatsConfig)
+
+// HeadingDetectionOption is a functional option for configuring HeadingDetectionConfig.
+type HeadingDetectionOption func(*HeadingDetectionConfig)
+
+// LinkExtractionOption is a functional option for configuring LinkExtractionConfig.
+type LinkExtractionOption func(*LinkExtractionConfig)
+
+// InlineStyleOption is a functional option for configuring InlineStyleConfig.
+type InlineStyleOption func(*InlineStyleConfig)
+
+// SourceAttributionOption is a functional option for configuring SourceAttributionConfig.
+type SourceAttributionOption func(*SourceAttributionConfig)
+
+// SpeculativeOption is a functional option for configuring SpeculativeConfig.
+type SpeculativeOption func(*SpeculativeConfig)
+
+// ResultCompressionOption is a functional option for configuring ResultCompressionConfig.
+type ResultCompressionOption func(*ResultCompressionConfig)
+
+// EmailOption is a functional option for configuring EmailConfig.
+type EmailOption func(*EmailConfig)
+
+// FilePolicyOption is a functional option for configuring FilePolicyConfig.
+type FilePolicyOption func(*FilePolicyConfig)
+
+// QuarantineOption is a functional option for configuring QuarantineConfig.
+type QuarantineOption func(*QuarantineConfig)
+
+// RetryOption is a functional option for configuring RetryConfig.
+type RetryOption func(*RetryConfig)
+
 // ExtractionConfig mirrors the Rust ExtractionConfig structure and is serialized to JSON
 // before crossing the FFI boundary. Use pointer fields to omit values and rely on Kreuzberg
 // defaults whenever possible.
@@ -87,6 +144,55 @@ type ExtractionConfig struct {
 	MaxConcurrentExtractions *int                     `json:"max_concurrent_extractions,omitempty"`
 	OutputFormat             string                   `json:"output_format,omitempty"`
 	ResultFormat             string                   `json:"result_format,omitempty"`
+	Normalization            *NormalizationConfig     `json:"normalization,omitempty"`
+	TranslateTo              string                   `json:"translate_to,omitempty"`
+	Translation              *TranslationConfig       `json:"translation,omitempty"`
+	SectionDetection         *SectionDetectionConfig  `json:"section_detection,omitempty"`
+	TableExtraction          *TableExtractionConfig   `json:"table_extraction,omitempty"`
+	KeyValues                *KeyValueConfig          `json:"key_values,omitempty"`
+	MarkDetection            *MarkDetectionConfig     `json:"mark_detection,omitempty"`
+	OffsetUnit               string                   `json:"offset_unit,omitempty"`
+	Retry                    *RetryConfig             `json:"retry,omitempty"`
+	Fallbacks                map[string][]string      `json:"fallbacks,omitempty"`
+	Quarantine               *QuarantineConfig        `json:"quarantine,omitempty"`
+	Deduplicate              *bool                    `json:"deduplicate,omitempty"`
+	FilePolicy               *FilePolicyConfig        `json:"file_policy,omitempty"`
+	Email                    *EmailConfig             `json:"email,omitempty"`
+	Chat                     *ChatConfig              `json:"chat,omitempty"`
+	Dicom                    *DicomConfig             `json:"dicom,omitempty"`
+	TextStats                *TextStatsConfig         `json:"text_stats,omitempty"`
+	HeadingDetection         *HeadingDetectionConfig  `json:"heading_detection,omitempty"`
+	LinkExtraction           *LinkExtractionConfig    `json:"link_extraction,omitempty"`
+	InlineStyles             *InlineStyleConfig       `json:"inline_styles,omitempty"`
+	SourceAttribution        *SourceAttributionConfig `json:"source_attribution,omitempty"`
+	Speculative              *SpeculativeConfig       `json:"speculative,omitempty"`
+	ResultCompression        *ResultCompressionConfig `json:"result_compression,omitempty"`
+	SharedContent            *bool                    `json:"shared_content,omitempty"`
+	PackedBatches            *bool                    `json:"packed_batches,omitempty"`
+	ProfileLabels            *bool                    `json:"profile_labels,omitempty"`
+	Retention                *RetentionConfig         `json:"retention,omitempty"`
+	Passwords                []string                 `json:"passwords,omitempty"`
+	MaxDepth                 *int                     `json:"max_depth,omitempty"`
+	MaxNestedBytes           *int64                   `json:"max_nested_bytes,omitempty"`
+	Include                  []ResultField            `json:"include,omitempty"`
+	LazyMetadata             *bool                    `json:"lazy_metadata,omitempty"`
+	Paging                   *PagingConfig            `json:"paging,omitempty"`
+	Anonymization            *AnonymizationConfig     `json:"anonymization,omitempty"`
+	Amounts                  *AmountDetectionConfig   `json:"amounts,omitempty"`
+	Acronyms                 *bool                    `json:"acronyms,omitempty"`
+	Turns                    *TurnDetectionConfig     `json:"turns,omitempty"`
+	LanguageFilter           *LanguageFilterConfig    `json:"language_filter,omitempty"`
+	PageDeduplication        *PageDeduplicationConfig `json:"page_deduplication,omitempty"`
+	ScanQuality              *bool                    `json:"scan_quality,omitempty"`
+	CoordinateUnit           string                   `json:"coordinate_unit,omitempty"`
+	FontInventory            *bool                    `json:"font_inventory,omitempty"`
+	TextEncoding             *TextEncodingConfig      `json:"text_encoding,omitempty"`
+	VerticalText             *bool                    `json:"vertical_text,omitempty"`
+	RubyText                 string                   `json:"ruby_text,omitempty"`
+	Portfolios               *bool                    `json:"portfolios,omitempty"`
+	GeoMetadata              *bool                    `json:"geo_metadata,omitempty"`
+	ExifMetadata             *bool                    `json:"exif_metadata,omitempty"`
+	Meetings                 *bool                    `json:"meetings,omitempty"`
 }
 
 // OCRConfig selects and configures OCR backends.
@@ -94,6 +200,10 @@ type OCRConfig struct {
 	Backend   string           `json:"backend,omitempty"`
 	Language  *string          `json:"language,omitempty"`
 	Tesseract *TesseractConfig `json:"tesseract_config,omitempty"`
+	// PageParallelism is the number of pages of a PDF recognized concurrently when OCR is
+	// forced. Values above 1 have the binding render the pages and recognize them as a
+	// batch on the core's thread pool. Default: 0, pages recognized one at a time.
+	PageParallelism *int `json:"page_parallelism,omitempty"`
 }
 
 // TesseractConfig exposes fine-grained controls for the Tesseract backend.
@@ -141,6 +251,13 @@ type ChunkingConfig struct {
 	Preset       *string          `json:"preset,omitempty"`
 	Embedding    *EmbeddingConfig `json:"embedding,omitempty"`
 	Enabled      *bool            `json:"enabled,omitempty"`
+
+	// Strategy selects the chunker: "" (native, default) or "markdown_structure".
+	Strategy string `json:"strategy,omitempty"`
+
+	// TablePolicy controls how Markdown tables in Content are chunked: "inline"
+	// (default), "separate", or "skip".
+	TablePolicy string `json:"table_policy,omitempty"`
 }
 
 // ImageExtractionConfig controls inline image extraction from PDFs/Office docs.
@@ -293,6 +410,247 @@ type PageConfig struct {
 	MarkerFormat      *string `json:"marker_format,omitempty"`
 }
 
+// NormalizationConfig enables language-specific text normalization. It is applied by the
+// Go binding to Content, Pages, and Chunks after extraction; chunk and page byte offsets
+// are remapped to the normalized Content.
+type NormalizationConfig struct {
+	// Fold full-width ASCII forms and the ideographic space to half-width (CJK). Default: false.
+	FullWidthToHalfWidth *bool `json:"full_width_to_half_width,omitempty"`
+
+	// Replace Arabic presentation forms with base letters and drop tatweel. Default: false.
+	ArabicShaping *bool `json:"arabic_shaping,omitempty"`
+
+	// German ß handling: "keep" (default) or "ss".
+	GermanEszett string `json:"german_eszett,omitempty"`
+
+	// Remove the byte-order mark (U+FEFF) that starts text read from some files.
+	// Default: false.
+	StripBOM *bool `json:"strip_bom,omitempty"`
+
+	// Convert CRLF and lone CR line endings to LF. Default: false.
+	NormalizeNewlines *bool `json:"normalize_newlines,omitempty"`
+
+	// Remove C0 and C1 control characters other than tab, line feed, carriage return and
+	// form feed, which separates pages of plain text. Default: false.
+	StripControlCharacters *bool `json:"strip_control_characters,omitempty"`
+
+	// Remove zero-width and invisible characters that break words apart in search and
+	// tokenization: zero-width space, word joiner, zero-width no-break space, soft hyphen
+	// and Mongolian vowel separator. Default: false.
+	StripZeroWidth *bool `json:"strip_zero_width,omitempty"`
+
+	// Record the dates of Content and of date metadata fields in
+	// ExtractionResult.NormalizedValues as ISO 8601. Default: false.
+	Dates *bool `json:"dates,omitempty"`
+
+	// Record the numbers of Content in ExtractionResult.NormalizedValues as plain
+	// decimals. Default: false.
+	Numbers *bool `json:"numbers,omitempty"`
+
+	// Locale reads numeric dates ("03/04/2024") and numbers ("1.234,5") as a BCP 47 tag
+	// such as "en-US" or "de-DE". Default: the detected language of the document, else
+	// "en-US".
+	Locale string `json:"locale,omitempty"`
+}
+
+// TranslationConfig tunes the translation step that runs when ExtractionConfig.TranslateTo
+// is set. Translators are registered with RegisterTranslator.
+type TranslationConfig struct {
+	// Name of the registered translator to use. Default: the only registered translator.
+	Translator string `json:"translator,omitempty"`
+
+	// Also translate each chunk into Chunk.TranslatedContent. Default: false.
+	TranslateChunks *bool `json:"translate_chunks,omitempty"`
+}
+
+// SectionDetectionConfig enables the section segmentation step, which labels regions of
+// Content in ExtractionResult.Sections.
+type SectionDetectionConfig struct {
+	// Labels restricts detection to the given section labels. Default: all labels.
+	Labels []string `json:"labels,omitempty"`
+}
+
+// TableExtractionConfig selects the table detection backend and filters detected tables
+// by confidence.
+type TableExtractionConfig struct {
+	// Backend: "heuristic" (default) keeps the tables the core extracts and detects those
+	// of PDF pages from ruling lines and text alignment; "none" drops all tables.
+	Backend string `json:"backend,omitempty"`
+
+	// Drop tables whose confidence is below this value (0.0-1.0). Default: keep all.
+	MinConfidence *float64 `json:"min_confidence,omitempty"`
+}
+
+// KeyValueConfig enables form label/value extraction into ExtractionResult.KeyValues.
+type KeyValueConfig struct {
+	// Drop pairs whose confidence is below this value (0.0-1.0). Default: keep all.
+	MinConfidence *float64 `json:"min_confidence,omitempty"`
+}
+
+// MarkDetectionConfig enables signature and stamp detection into ExtractionResult.Marks.
+// Detection runs on extracted images; they are requested from the core automatically and
+// only kept in the result when Images.ExtractImages is set. Images in formats other than
+// PNG, JPEG and GIF, such as JBIG2 and CCITT fax scans, and images above 50 megapixels are
+// skipped; see DetectMarks.
+type MarkDetectionConfig struct {
+	// Kinds limits detection to "signature" and/or "stamp". Default: both.
+	Kinds []string `json:"kinds,omitempty"`
+	// Drop marks whose confidence is below this value (0.0-1.0). Default: keep all.
+	MinConfidence *float64 `json:"min_confidence,omitempty"`
+}
+
+// RetryConfig retries documents of a batch whose extraction failed with a transient error,
+// such as a locked file or a momentary allocation failure. Each failed document is
+// retried on its own, waiting between attempts with exponential backoff.
+type RetryConfig struct {
+	// Total attempts per document, the first included. Default: 3.
+	MaxAttempts *int `json:"max_attempts,omitempty"`
+	// Wait before the first retry, doubled for each further retry. Default: 100.
+	InitialBackoffMs *int `json:"initial_backoff_ms,omitempty"`
+	// Upper bound for the wait between attempts. Default: 5000.
+	MaxBackoffMs *int `json:"max_backoff_ms,omitempty"`
+	// Error kinds worth retrying, as reported by KreuzbergError.Kind. Default: "io" and "runtime".
+	RetryOn []ErrorKind `json:"retry_on,omitempty"`
+}
+
+// QuarantineConfig makes BatchExtractFilesSync skip files that keep failing. Failures are
+// counted across runs in a JSON manifest; once a file reaches MaxFailures it is
+// quarantined and reported as a failed result without being extracted.
+type QuarantineConfig struct {
+	// Path of the manifest recording failures and quarantined files. Required.
+	ManifestPath string `json:"manifest_path"`
+	// Failed extractions after which a file is quarantined. Default: 3.
+	MaxFailures *int `json:"max_failures,omitempty"`
+	// Abandon a document, counting it as failed, after this many milliseconds. Default: no
+	// limit.
+	TimeoutMs *int `json:"timeout_ms,omitempty"`
+}
+
+// FilePolicyConfig decides which files BatchExtractFilesSync and ExtractDirectory extract.
+// Skipped files get an error result stating the reason; files whose policy is "error" fail
+// the batch.
+type FilePolicyConfig struct {
+	// Symlinks is "follow", "skip", or "error". Default: "follow".
+	Symlinks string `json:"symlinks,omitempty"`
+	// SpecialFiles (devices, pipes, sockets) is "skip" or "error". Default: "skip".
+	SpecialFiles string `json:"special_files,omitempty"`
+	// Skip files larger than this many bytes. Default: no limit.
+	MaxFileSize int64 `json:"max_file_size,omitempty"`
+	// SparseFiles (files with holes that occupy no disk space) is "extract", "skip", or
+	// "error". Holes are found with SEEK_HOLE, so sparse files are only detected on Linux
+	// file systems that report them. Default: "extract".
+	SparseFiles string `json:"sparse_files,omitempty"`
+}
+
+// EmailConfig controls how email messages (.eml, .msg) are rendered into Content.
+type EmailConfig struct {
+	// Render the HTML body, converted to the output format, when a message has both
+	// bodies. Default: the plain text body.
+	PreferHTML *bool `json:"prefer_html,omitempty"`
+	// Drop the earlier messages quoted in replies. Default: keep them.
+	StripQuotedReplies *bool `json:"strip_quoted_replies,omitempty"`
+	// Append the extracted content of each attachment. Default: list their names.
+	InlineAttachments *bool `json:"inline_attachments,omitempty"`
+	// Render headers as a YAML front matter block instead of "Name: value" lines.
+	HeadersAsFrontMatter *bool `json:"headers_as_front_matter,omitempty"`
+}
+
+// ChatConfig controls how ExtractChat groups messages into conversation windows.
+type ChatConfig struct {
+	// Start a new window after a silence longer than this many seconds. Default: 1800.
+	WindowGapSeconds *int `json:"window_gap_seconds,omitempty"`
+	// Start a new window before one grows past this many characters. Zero disables the
+	// limit. Default: 2000.
+	MaxWindowChars *int `json:"max_window_chars,omitempty"`
+}
+
+// DicomConfig controls DICOM extraction.
+type DicomConfig struct {
+	// Remove the patient name, ID and birth date, the accession number, the institution
+	// and the referring physician from metadata and content, and person names from
+	// structured reports; occurrences of the patient identifiers in report and OCR text
+	// are masked. Default: false.
+	Anonymize *bool `json:"anonymize,omitempty"`
+	// Run OCR over the first frame of the pixel data to recover burned-in text, using the
+	// OCR configuration of the extraction. Default: false.
+	OCRPixelData *bool `json:"ocr_pixel_data,omitempty"`
+}
+
+// TextStatsConfig enables text statistics and readability scores in
+// ExtractionResult.TextStats, computed from the extracted content of any format.
+type TextStatsConfig struct {
+	// Language whose Flesch reading ease formula is used: "en", "de", "es", "fr", "it"
+	// or "nl". Default: "en".
+	Language string `json:"language,omitempty"`
+}
+
+// HeadingDetectionConfig enables the heading tree in ExtractionResult.Headings. Headings
+// are found in Markdown output, in the heading metadata of HTML and text documents, in
+// the heading styles of Word documents and, for PDFs, by font size. Chunks without a
+// heading path get theirs from the tree.
+type HeadingDetectionConfig struct {
+	// Keep headings down to this level (1-6). Default: 6.
+	MaxLevel *int `json:"max_level,omitempty"`
+	// Render the text layer of PDFs without Markdown headings to tell headings from body
+	// text by font size. Default: true.
+	PDFFontSizes *bool `json:"pdf_font_sizes,omitempty"`
+}
+
+// LinkExtractionConfig enables hyperlink extraction into ExtractionResult.Links, from the
+// link annotations of PDFs, the hyperlinks and HYPERLINK fields of Word documents, HTML
+// anchors and the links in the content of any format.
+type LinkExtractionConfig struct {
+	// Request every distinct external http(s) link and record its status. Default: false.
+	Validate *bool `json:"validate,omitempty"`
+	// Give up on a link after this many milliseconds. Default: 5000.
+	ValidationTimeoutMs *int `json:"validation_timeout_ms,omitempty"`
+}
+
+// InlineStyleConfig enables inline style annotations in ExtractionResult.StyleSpans: the
+// bold, italic, underlined, struck-through, highlighted and colored text of Word document
+// runs, the emphasis of HTML markup and Markdown, and the markup annotations and colored
+// text of PDFs. StyledMarkdown renders the annotations as Markdown.
+type InlineStyleConfig struct {
+	// Annotate only these styles (StyleBold, StyleItalic, StyleUnderline,
+	// StyleStrikethrough, StyleHighlight, StyleColor). Default: all.
+	Styles []string `json:"styles,omitempty"`
+}
+
+// SourceAttributionConfig enables text source attribution: ExtractionResult.TextSources
+// and PageContent.Source tell text read from the document from text recognized by OCR.
+// The blocks of PDFs are compared with the text layer of their page.
+type SourceAttributionConfig struct {
+	// Count a PDF block as native when at least this share of its words is in the text
+	// layer. Default: 0.9.
+	NativeThreshold *float64 `json:"native_threshold,omitempty"`
+	// Count a PDF block as OCR when at most this share of its words is in the text layer;
+	// blocks between the thresholds are hybrid. Default: 0.1.
+	OCRThreshold *float64 `json:"ocr_threshold,omitempty"`
+	// OCR the hybrid pages of PDFs and keep the better of the text layer and the OCR of
+	// each block, reporting the decision in Metadata.Reconciliation. Default: false.
+	Reconcile *bool `json:"reconcile,omitempty"`
+}
+
+// SpeculativeConfig enables speculative extraction of PDFs: the text layer and the OCR of
+// the pages are extracted side by side and the first text good enough is returned, the
+// other path being canceled. Metadata.Speculation reports the outcome. PDFs with forced
+// OCR are extracted as usual.
+type SpeculativeConfig struct {
+	// Return a path's text once this share of its words looks like words rather than
+	// broken glyphs. Default: 0.8.
+	QualityThreshold *float64 `json:"quality_threshold,omitempty"`
+}
+
+// ResultCompressionConfig has results cross the FFI boundary as a single JSON document,
+// zstd-compressed when large and decompressed as it is decoded, instead of as separate C
+// strings copied into Go. It cuts the memory held while extracting very large text
+// outputs.
+type ResultCompressionConfig struct {
+	// Compress results whose content holds at least this many bytes; smaller results
+	// cross as plain JSON. Default: 1048576 (1 MiB).
+	MinContentBytes *int `json:"min_content_bytes,omitempty"`
+}
+
 // OutputFormat controls the format of extracted content.
 // Options: "plain", "text", "markdown", "md", "djot", "html"
 // Default: "plain" (via Rust)
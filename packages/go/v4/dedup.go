@@ -0,0 +1,70 @@
+package kreuzberg
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// deduplicate extracts each distinct document of items once and maps duplicates to the
+// same result. Items whose key is empty are always extracted.
+func deduplicate[T any](items []T, key func(T) string, extract func([]T) ([]*ExtractionResult, error)) ([]*ExtractionResult, error) {
+	slots := make([]int, len(items))
+	firstByKey := make(map[string]int, len(items))
+	unique := make([]T, 0, len(items))
+	for i, item := range items {
+		k := key(item)
+		if slot, ok := firstByKey[k]; ok && k != "" {
+			slots[i] = slot
+			continue
+		}
+		if k != "" {
+			firstByKey[k] = len(unique)
+		}
+		slots[i] = len(unique)
+		unique = append(unique, item)
+	}
+	if len(unique) == len(items) {
+		return extract(items)
+	}
+
+	extracted, err := extract(unique)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*ExtractionResult, len(items))
+	for i, slot := range slots {
+		if slot < len(extracted) {
+			results[i] = extracted[slot]
+		}
+	}
+	return results, nil
+}
+
+// fileContentKey hashes the file at path. The extension is part of the key because it
+// can decide the format a file is extracted as. Unreadable and special files get an
+// empty key.
+func fileContentKey(path string) string {
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return ""
+	}
+	return strings.ToLower(filepath.Ext(path)) + ":" + hex.EncodeToString(hash.Sum(nil))
+}
+
+// bytesContentKey hashes an in-memory document together with its MIME type.
+func bytesContentKey(item BytesWithMime) string {
+	sum := sha256.Sum256(item.Data)
+	return item.MimeType + ":" + hex.EncodeToString(sum[:])
+}
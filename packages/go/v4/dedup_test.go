@@ -0,0 +1,52 @@
+package kreuzberg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeduplicateFilesByContent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	a := write("a.txt", "same attachment")
+	b := write("b.txt", "same attachment")
+	c := write("c.txt", "different")
+	d := write("d.md", "same attachment")
+	missing := filepath.Join(dir, "missing.txt")
+
+	var extracted []string
+	results, err := deduplicate([]string{a, b, c, d, missing, missing}, fileContentKey, func(paths []string) ([]*ExtractionResult, error) {
+		extracted = paths
+		results := make([]*ExtractionResult, len(paths))
+		for i, path := range paths {
+			results[i] = &ExtractionResult{Content: path}
+		}
+		return results, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(extracted) != 5 {
+		t.Fatalf("expected five distinct extractions, got %v", extracted)
+	}
+	if results[0] != results[1] || results[0] == results[2] || results[0] == results[3] {
+		t.Fatalf("expected only identical files with the same extension to share a result")
+	}
+	if results[4] == results[5] {
+		t.Fatalf("expected unreadable files to be extracted individually")
+	}
+}
+
+func TestBytesContentKeyIncludesMimeType(t *testing.T) {
+	data := []byte("<p>hi</p>")
+	if bytesContentKey(BytesWithMime{Data: data, MimeType: "text/html"}) == bytesContentKey(BytesWithMime{Data: data, MimeType: "text/plain"}) {
+		t.Fatalf("expected the MIME type to be part of the key")
+	}
+}
@@ -0,0 +1,298 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MIME types of EDI interchanges.
+const (
+	MimeTypeEDIX12  = "application/edi-x12"
+	MimeTypeEDIFACT = "application/edifact"
+)
+
+// EDI standards reported in EdiMetadata.Standard.
+const (
+	EDIStandardX12     = "x12"
+	EDIStandardEDIFACT = "edifact"
+)
+
+// x12HeaderLength is the fixed length of an X12 ISA segment, terminator included.
+const x12HeaderLength = 106
+
+// EdiMetadata describes an EDI interchange: its envelope and the transaction sets
+// (X12) or messages (EDIFACT) it carries. Date is the interchange preparation time as
+// "2006-01-02T15:04".
+type EdiMetadata struct {
+	Standard      string           `json:"standard"`
+	Version       string           `json:"version,omitempty"`
+	Sender        string           `json:"sender,omitempty"`
+	Receiver      string           `json:"receiver,omitempty"`
+	ControlNumber string           `json:"control_number,omitempty"`
+	Date          string           `json:"date,omitempty"`
+	SegmentCount  int              `json:"segment_count"`
+	Transactions  []EdiTransaction `json:"transactions"`
+}
+
+// EdiTransaction is an X12 transaction set (ST/SE) or an EDIFACT message (UNH/UNT).
+// Type is the transaction set identifier, such as "850", or the message type, such as
+// "ORDERS". SegmentCount includes the header and trailer segments.
+type EdiTransaction struct {
+	Type          string `json:"type"`
+	ControlNumber string `json:"control_number,omitempty"`
+	SegmentCount  int    `json:"segment_count"`
+}
+
+// ediSegment is a segment split into its tag and data elements.
+type ediSegment struct {
+	tag      string
+	elements []string
+}
+
+// ediDelimiters are the separators of an interchange.
+type ediDelimiters struct {
+	element    byte
+	component  byte
+	segment    byte
+	release    byte
+	hasRelease bool
+}
+
+// detectEDI returns the EDI MIME type of data, or "" when it is not an interchange.
+func detectEDI(data []byte) string {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	switch {
+	case len(trimmed) >= x12HeaderLength && bytes.HasPrefix(trimmed, []byte("ISA")):
+		return MimeTypeEDIX12
+	case bytes.HasPrefix(trimmed, []byte("UNA")), bytes.HasPrefix(trimmed, []byte("UNB+")):
+		return MimeTypeEDIFACT
+	}
+	return ""
+}
+
+// isEDIPath reports whether path has an EDI file extension.
+func isEDIPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".edi", ".x12", ".edifact", ".edf":
+		return true
+	}
+	return false
+}
+
+// extractEDI builds the result of an EDI interchange: one segment per line as Content,
+// a table of segments per transaction, and EdiMetadata.
+func extractEDI(data []byte) (*ExtractionResult, error) {
+	meta, segments, err := parseEDI(data)
+	if err != nil {
+		return nil, err
+	}
+	mimeType := MimeTypeEDIX12
+	if meta.Standard == EDIStandardEDIFACT {
+		mimeType = MimeTypeEDIFACT
+	}
+
+	var content strings.Builder
+	var tables []Table
+	var rows [][]string
+	for _, segment := range segments {
+		content.WriteString(strings.Join(append([]string{segment.tag}, segment.elements...), " | "))
+		content.WriteString("\n")
+		switch segment.tag {
+		case "ST", "UNH":
+			rows = nil
+		}
+		rows = append(rows, append([]string{segment.tag}, segment.elements...))
+		switch segment.tag {
+		case "SE", "UNT":
+			tables = append(tables, ediTable(rows))
+			rows = nil
+		}
+	}
+	return &ExtractionResult{
+		Content:  strings.TrimSuffix(content.String(), "\n"),
+		MimeType: mimeType,
+		Metadata: Metadata{Format: FormatMetadata{Type: FormatEDI, EDI: meta}},
+		Tables:   tables,
+		Success:  true,
+	}, nil
+}
+
+// ediTable builds the table of a transaction: one row per segment, with element
+// positions as the header.
+func ediTable(rows [][]string) Table {
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	header := []string{"Segment"}
+	for i := 1; i < width; i++ {
+		header = append(header, fmt.Sprintf("%02d", i))
+	}
+	cells := append([][]string{header}, rows...)
+	return Table{Cells: cells, Markdown: tableMarkdown(cells)}
+}
+
+// ParseEDI parses the envelope and transactions of an X12 or EDIFACT interchange. The
+// delimiters are read from the ISA segment or the UNA service string advice.
+func ParseEDI(data []byte) (*EdiMetadata, error) {
+	meta, _, err := parseEDI(data)
+	return meta, err
+}
+
+func parseEDI(data []byte) (*EdiMetadata, []ediSegment, error) {
+	trimmed := bytes.TrimLeft(data, " \t\r\n\ufeff")
+	switch detectEDI(trimmed) {
+	case MimeTypeEDIX12:
+		header := trimmed[:x12HeaderLength]
+		delimiters := ediDelimiters{element: header[3], component: header[104], segment: header[105]}
+		segments := splitEDISegments(trimmed, delimiters)
+		return x12Metadata(segments), segments, nil
+	case MimeTypeEDIFACT:
+		delimiters := ediDelimiters{element: '+', component: ':', segment: '\'', release: '?', hasRelease: true}
+		if una, ok := bytes.CutPrefix(trimmed, []byte("UNA")); ok {
+			if len(una) < 6 {
+				return nil, nil, newParsingErrorWithContext("truncated UNA service string advice", nil, ErrorCodeParsing, nil)
+			}
+			delimiters = ediDelimiters{component: una[0], element: una[1], release: una[3], hasRelease: una[3] != ' ', segment: una[5]}
+			trimmed = una[6:]
+		}
+		segments := splitEDISegments(trimmed, delimiters)
+		return edifactMetadata(segments), segments, nil
+	}
+	return nil, nil, newParsingErrorWithContext("not an X12 or EDIFACT interchange", nil, ErrorCodeParsing, nil)
+}
+
+// splitEDISegments splits an interchange into segments and elements. Released
+// (escaped) delimiters are kept as data. Composite elements keep their component
+// separators, normalized to ":".
+func splitEDISegments(data []byte, delimiters ediDelimiters) []ediSegment {
+	var segments []ediSegment
+	var elements []string
+	var element []byte
+	flushSegment := func() {
+		elements = append(elements, string(element))
+		element = nil
+		if tag := strings.TrimSpace(elements[0]); tag != "" {
+			segments = append(segments, ediSegment{tag: tag, elements: elements[1:]})
+		}
+		elements = nil
+	}
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case delimiters.hasRelease && c == delimiters.release && i+1 < len(data):
+			i++
+			element = append(element, data[i])
+		case c == delimiters.segment:
+			flushSegment()
+		case c == delimiters.element:
+			elements = append(elements, string(element))
+			element = nil
+		case c == '\r' || c == '\n':
+			// Line breaks after segment terminators are formatting.
+		case c == delimiters.component && delimiters.component != ':':
+			element = append(element, ':')
+		default:
+			element = append(element, c)
+		}
+	}
+	if len(bytes.TrimSpace(element)) > 0 || len(elements) > 0 {
+		flushSegment()
+	}
+	return segments
+}
+
+func ediElement(segment ediSegment, position int) string {
+	if position < 1 || position > len(segment.elements) {
+		return ""
+	}
+	return strings.TrimSpace(segment.elements[position-1])
+}
+
+func x12Metadata(segments []ediSegment) *EdiMetadata {
+	meta := &EdiMetadata{Standard: EDIStandardX12, SegmentCount: len(segments), Transactions: []EdiTransaction{}}
+	var current *EdiTransaction
+	for _, segment := range segments {
+		if current != nil {
+			current.SegmentCount++
+		}
+		switch segment.tag {
+		case "ISA":
+			meta.Sender = ediElement(segment, 6)
+			meta.Receiver = ediElement(segment, 8)
+			meta.Date = ediDate(ediElement(segment, 9), ediElement(segment, 10))
+			meta.Version = ediElement(segment, 12)
+			meta.ControlNumber = ediElement(segment, 13)
+		case "GS":
+			if version := ediElement(segment, 8); version != "" {
+				meta.Version = version
+			}
+		case "ST":
+			meta.Transactions = append(meta.Transactions, EdiTransaction{Type: ediElement(segment, 1), ControlNumber: ediElement(segment, 2), SegmentCount: 1})
+			current = &meta.Transactions[len(meta.Transactions)-1]
+		case "SE":
+			current = nil
+		}
+	}
+	return meta
+}
+
+func edifactMetadata(segments []ediSegment) *EdiMetadata {
+	meta := &EdiMetadata{Standard: EDIStandardEDIFACT, SegmentCount: len(segments), Transactions: []EdiTransaction{}}
+	var current *EdiTransaction
+	for _, segment := range segments {
+		if current != nil {
+			current.SegmentCount++
+		}
+		switch segment.tag {
+		case "UNB":
+			syntax := strings.Split(ediElement(segment, 1), ":")
+			if len(syntax) > 1 {
+				meta.Version = syntax[0] + ":" + syntax[1]
+			}
+			meta.Sender, _, _ = strings.Cut(ediElement(segment, 2), ":")
+			meta.Receiver, _, _ = strings.Cut(ediElement(segment, 3), ":")
+			date, clock, _ := strings.Cut(ediElement(segment, 4), ":")
+			meta.Date = ediDate(date, clock)
+			meta.ControlNumber = ediElement(segment, 5)
+		case "UNH":
+			messageType := strings.Split(ediElement(segment, 2), ":")
+			transaction := EdiTransaction{Type: messageType[0], ControlNumber: ediElement(segment, 1), SegmentCount: 1}
+			if len(messageType) >= 3 {
+				meta.Version = messageType[1] + ":" + messageType[2]
+			}
+			meta.Transactions = append(meta.Transactions, transaction)
+			current = &meta.Transactions[len(meta.Transactions)-1]
+		case "UNT":
+			current = nil
+		}
+	}
+	return meta
+}
+
+// ediDate formats a YYMMDD or CCYYMMDD date and HHMM time as "2006-01-02T15:04".
+func ediDate(date, clock string) string {
+	if len(date) == 6 {
+		year, err := strconv.Atoi(date[:2])
+		if err != nil {
+			return ""
+		}
+		// X12 and EDIFACT two-digit years pivot at 50.
+		century := "20"
+		if year >= 50 {
+			century = "19"
+		}
+		date = century + date
+	}
+	if len(date) != 8 {
+		return ""
+	}
+	formatted := date[:4] + "-" + date[4:6] + "-" + date[6:8]
+	if len(clock) >= 4 {
+		formatted += "T" + clock[:2] + ":" + clock[2:4]
+	}
+	return formatted
+}
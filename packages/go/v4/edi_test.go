@@ -0,0 +1,86 @@
+package kreuzberg
+
+import (
+	"strings"
+	"testing"
+)
+
+const testX12 = "ISA*00*          *00*          *ZZ*SENDERID       *ZZ*RECEIVERID     *240315*1030*U*00401*000000905*0*P*>~\n" +
+	"GS*PO*SENDERID*RECEIVERID*20240315*1030*1*X*004010~\n" +
+	"ST*850*0001~\n" +
+	"BEG*00*SA*PO-1001**20240315~\n" +
+	"PO1*1*10*EA*2.50**VP*WIDGET>BLUE~\n" +
+	"SE*4*0001~\n" +
+	"GE*1*1~\n" +
+	"IEA*1*000000905~\n"
+
+func TestParseEDIX12(t *testing.T) {
+	if got := detectEDI([]byte(testX12)); got != MimeTypeEDIX12 {
+		t.Fatalf("detectEDI = %q", got)
+	}
+	meta, err := ParseEDI([]byte(testX12))
+	if err != nil {
+		t.Fatalf("ParseEDI: %v", err)
+	}
+	if meta.Standard != EDIStandardX12 || meta.Sender != "SENDERID" || meta.Receiver != "RECEIVERID" ||
+		meta.ControlNumber != "000000905" || meta.Version != "004010" || meta.Date != "2024-03-15T10:30" {
+		t.Fatalf("unexpected envelope: %+v", meta)
+	}
+	if meta.SegmentCount != 8 || len(meta.Transactions) != 1 {
+		t.Fatalf("unexpected counts: %+v", meta)
+	}
+	if tx := meta.Transactions[0]; tx.Type != "850" || tx.ControlNumber != "0001" || tx.SegmentCount != 4 {
+		t.Fatalf("unexpected transaction: %+v", tx)
+	}
+}
+
+func TestExtractEDIBuildsTransactionTables(t *testing.T) {
+	result, err := extractEDI([]byte(testX12))
+	if err != nil {
+		t.Fatalf("extractEDI: %v", err)
+	}
+	if !strings.Contains(result.Content, "PO1 | 1 | 10 | EA | 2.50 |  | VP | WIDGET:BLUE") {
+		t.Fatalf("unexpected content:\n%s", result.Content)
+	}
+	if len(result.Tables) != 1 || len(result.Tables[0].Cells) != 5 || result.Tables[0].Cells[1][0] != "ST" {
+		t.Fatalf("unexpected tables: %+v", result.Tables)
+	}
+	if meta, ok := result.Metadata.EdiMetadata(); !ok || meta.Standard != EDIStandardX12 {
+		t.Fatalf("missing EDI metadata: %+v", result.Metadata.Format)
+	}
+}
+
+func TestParseEDIFACT(t *testing.T) {
+	data := "UNA:+.? '\n" +
+		"UNB+UNOC:3+SENDER:14+RECEIVER:14+240315:1030+REF42'\n" +
+		"UNH+1+ORDERS:D:96A:UN'\n" +
+		"BGM+220+PO?+1001+9'\n" +
+		"UNT+3+1'\n" +
+		"UNZ+1+REF42'"
+	if got := detectEDI([]byte(data)); got != MimeTypeEDIFACT {
+		t.Fatalf("detectEDI = %q", got)
+	}
+	meta, segments, err := parseEDI([]byte(data))
+	if err != nil {
+		t.Fatalf("parseEDI: %v", err)
+	}
+	if meta.Standard != EDIStandardEDIFACT || meta.Sender != "SENDER" || meta.Receiver != "RECEIVER" ||
+		meta.ControlNumber != "REF42" || meta.Version != "D:96A" || meta.Date != "2024-03-15T10:30" {
+		t.Fatalf("unexpected envelope: %+v", meta)
+	}
+	if len(meta.Transactions) != 1 || meta.Transactions[0].Type != "ORDERS" || meta.Transactions[0].SegmentCount != 3 {
+		t.Fatalf("unexpected transactions: %+v", meta.Transactions)
+	}
+	if got := ediElement(segments[2], 2); got != "PO+1001" {
+		t.Fatalf("released separator not kept: %q", got)
+	}
+}
+
+func TestParseEDIRejectsOtherData(t *testing.T) {
+	if _, err := ParseEDI([]byte("hello world")); err == nil {
+		t.Fatal("expected error")
+	}
+	if _, err := ParseEDI([]byte("UNA:+")); err == nil {
+		t.Fatal("expected error for truncated UNA")
+	}
+}
@@ -0,0 +1,329 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unsafe"
+)
+
+// MIME types of the email messages EmailConfig applies to.
+const (
+	mimeTypeEML = "message/rfc822"
+	mimeTypeMSG = "application/vnd.ms-outlook"
+)
+
+// parsedEmail mirrors the JSON returned by kreuzberg_email_parse.
+type parsedEmail struct {
+	Subject     *string            `json:"subject"`
+	FromEmail   *string            `json:"from_email"`
+	ToEmails    []string           `json:"to_emails"`
+	CcEmails    []string           `json:"cc_emails"`
+	BccEmails   []string           `json:"bcc_emails"`
+	Date        *string            `json:"date"`
+	MessageID   *string            `json:"message_id"`
+	PlainText   *string            `json:"plain_text"`
+	HTMLContent *string            `json:"html_content"`
+	Attachments []parsedAttachment `json:"attachments"`
+}
+
+type parsedAttachment struct {
+	Name     *string `json:"name"`
+	MimeType *string `json:"mime_type"`
+	Data     *string `json:"data"`
+}
+
+var (
+	// quoteAttributionPattern matches the line introducing a quoted message, e.g.
+	// "On Mon, 3 Jun 2024 at 10:02, Alice <alice@example.com> wrote:".
+	quoteAttributionPattern = regexp.MustCompile(`^On\s.+\swrote:$`)
+	// originalMessagePattern matches the separator Outlook places above the message
+	// being replied to.
+	originalMessagePattern = regexp.MustCompile(`^-{2,}\s*Original Message\s*-{2,}$`)
+)
+
+func isEmailMimeType(mimeType string) bool {
+	return mimeType == mimeTypeEML || mimeType == mimeTypeMSG
+}
+
+// applyEmailStages runs the binding-side processing of email results: meetings found
+// in calendar parts are added to the email metadata when config.Meetings is set, and
+// Content is re-rendered as configured by config.Email. read returns the original
+// message. Chunks produced by the native chunker are rebuilt over re-rendered content.
+func applyEmailStages(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) error {
+	if result == nil || config == nil || !isEmailMimeType(result.MimeType) || result.Metadata.Error != nil {
+		return nil
+	}
+	meetings := config.Meetings != nil && *config.Meetings
+	render := config.Email != nil
+	if !meetings && !render {
+		return nil
+	}
+	// Without rendering options, meetings are a best-effort addition that never fails the
+	// extraction.
+	data, err := read()
+	if err != nil {
+		if render {
+			return err
+		}
+		return nil
+	}
+	var email *parsedEmail
+	if render || (meetings && result.MimeType == mimeTypeMSG) {
+		if email, err = parseEmail(data, result.MimeType); err != nil && render {
+			return err
+		}
+	}
+
+	if meetings {
+		addEmailMeetings(result, emailMeetings(data, result.MimeType, email))
+	}
+
+	if !render {
+		return nil
+	}
+	result.Content = renderEmail(email, config)
+	if len(result.Chunks) > 0 && config.Chunking != nil && !usesStructureChunking(config) {
+		rechunked, err := RechunkResult(result, config.Chunking)
+		if err != nil {
+			return err
+		}
+		result.Chunks = rechunked.Chunks
+	}
+	return nil
+}
+
+// addEmailMeetings adds meetings, if any, to the email metadata of result.
+func addEmailMeetings(result *ExtractionResult, meetings []CalendarEvent) {
+	if len(meetings) == 0 {
+		return
+	}
+	result.Metadata.materialize()
+	if result.Metadata.Format.Email == nil {
+		result.Metadata.Format.Type = FormatEmail
+		result.Metadata.Format.Email = &EmailMetadata{}
+	}
+	result.Metadata.Format.Email.Meetings = meetings
+}
+
+// renderEmail renders the headers, body, and attachments of email.
+func renderEmail(email *parsedEmail, config *ExtractionConfig) string {
+	cfg := config.Email
+	var parts []string
+	if cfg.HeadersAsFrontMatter != nil && *cfg.HeadersAsFrontMatter {
+		parts = append(parts, emailFrontMatter(email))
+	} else if headers := emailHeaderLines(email); headers != "" {
+		parts = append(parts, headers)
+	}
+
+	body := emailBody(email, config)
+	if cfg.StripQuotedReplies != nil && *cfg.StripQuotedReplies {
+		body = stripQuotedReplies(body)
+	}
+	parts = append(parts, body)
+
+	if cfg.InlineAttachments != nil && *cfg.InlineAttachments {
+		parts = append(parts, inlineAttachments(email.Attachments, config)...)
+	} else if names := attachmentNames(email.Attachments); len(names) > 0 {
+		parts = append(parts, "Attachments: "+strings.Join(names, ", "))
+	}
+	return strings.Join(parts, "\n")
+}
+
+// emailHeaderLines renders the headers as the native email extractor does.
+func emailHeaderLines(email *parsedEmail) string {
+	var lines []string
+	if email.Subject != nil {
+		lines = append(lines, "Subject: "+*email.Subject)
+	}
+	if email.FromEmail != nil {
+		lines = append(lines, "From: "+*email.FromEmail)
+	}
+	if len(email.ToEmails) > 0 {
+		lines = append(lines, "To: "+strings.Join(email.ToEmails, ", "))
+	}
+	if len(email.CcEmails) > 0 {
+		lines = append(lines, "CC: "+strings.Join(email.CcEmails, ", "))
+	}
+	if len(email.BccEmails) > 0 {
+		lines = append(lines, "BCC: "+strings.Join(email.BccEmails, ", "))
+	}
+	if email.Date != nil {
+		lines = append(lines, "Date: "+*email.Date)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// emailFrontMatter renders the headers as a YAML front matter block. Values are written
+// as JSON, which is valid YAML and needs no further escaping.
+func emailFrontMatter(email *parsedEmail) string {
+	lines := []string{"---"}
+	add := func(key string, value any) {
+		encoded, err := json.Marshal(value)
+		if err == nil {
+			lines = append(lines, key+": "+string(encoded))
+		}
+	}
+	if email.Subject != nil {
+		add("subject", *email.Subject)
+	}
+	if email.FromEmail != nil {
+		add("from", *email.FromEmail)
+	}
+	if len(email.ToEmails) > 0 {
+		add("to", email.ToEmails)
+	}
+	if len(email.CcEmails) > 0 {
+		add("cc", email.CcEmails)
+	}
+	if len(email.BccEmails) > 0 {
+		add("bcc", email.BccEmails)
+	}
+	if email.Date != nil {
+		add("date", *email.Date)
+	}
+	if email.MessageID != nil {
+		add("message_id", *email.MessageID)
+	}
+	if names := attachmentNames(email.Attachments); len(names) > 0 {
+		add("attachments", names)
+	}
+	lines = append(lines, "---")
+	return strings.Join(lines, "\n")
+}
+
+// emailBody returns the body selected by config.Email.PreferHTML. HTML bodies are
+// converted with the native HTML extractor in the configured output format.
+func emailBody(email *parsedEmail, config *ExtractionConfig) string {
+	plain := ""
+	if email.PlainText != nil {
+		plain = *email.PlainText
+	}
+	preferHTML := config.Email.PreferHTML != nil && *config.Email.PreferHTML
+	if email.HTMLContent == nil || (!preferHTML && strings.TrimSpace(plain) != "") {
+		return plain
+	}
+	converted, err := ExtractBytesSync([]byte(*email.HTMLContent), "text/html", &ExtractionConfig{
+		UseCache:     BoolPtr(false),
+		OutputFormat: config.OutputFormat,
+	})
+	if err != nil {
+		return plain
+	}
+	return converted.Content
+}
+
+// stripQuotedReplies removes the earlier messages quoted in a reply: lines starting with
+// ">", the attribution line introducing them, and everything below an Outlook
+// "Original Message" separator.
+func stripQuotedReplies(body string) string {
+	lines := strings.Split(body, "\n")
+	kept := make([]string, 0, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if originalMessagePattern.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		if quoteAttributionPattern.MatchString(trimmed) && nextLineQuoted(lines[i+1:]) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimRight(strings.Join(kept, "\n"), " \t\r\n")
+}
+
+// nextLineQuoted reports whether the first non-blank line of lines is quoted.
+func nextLineQuoted(lines []string) bool {
+	for _, line := range lines {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return strings.HasPrefix(trimmed, ">")
+		}
+	}
+	return false
+}
+
+// inlineAttachments extracts each attachment and renders it as a section of the message.
+func inlineAttachments(attachments []parsedAttachment, config *ExtractionConfig) []string {
+	var sections []string
+	for i, attachment := range attachments {
+		name := fmt.Sprintf("attachment %d", i+1)
+		if attachment.Name != nil && *attachment.Name != "" {
+			name = *attachment.Name
+		}
+		content, err := extractAttachment(attachment, config)
+		if err != nil {
+			sections = append(sections, fmt.Sprintf("\nAttachment: %s (not extracted: %v)", name, err))
+			continue
+		}
+		sections = append(sections, fmt.Sprintf("\nAttachment: %s\n%s", name, content))
+	}
+	return sections
+}
+
+func extractAttachment(attachment parsedAttachment, config *ExtractionConfig) (string, error) {
+	if attachment.Data == nil || attachment.MimeType == nil {
+		return "", errors.New("no content")
+	}
+	data, err := base64.StdEncoding.DecodeString(*attachment.Data)
+	if err != nil {
+		return "", err
+	}
+	result, err := ExtractBytesSync(data, *attachment.MimeType, &ExtractionConfig{
+		UseCache:     BoolPtr(false),
+		OutputFormat: config.OutputFormat,
+		Email:        config.Email,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.Content, nil
+}
+
+func attachmentNames(attachments []parsedAttachment) []string {
+	var names []string
+	for _, attachment := range attachments {
+		if attachment.Name != nil && *attachment.Name != "" {
+			names = append(names, *attachment.Name)
+		}
+	}
+	return names
+}
+
+// parseEmail parses an email message without rendering it.
+func parseEmail(data []byte, mimeType string) (*parsedEmail, error) {
+	if len(data) == 0 {
+		return nil, newValidationErrorWithContext("email content is empty", nil, ErrorCodeValidation, nil)
+	}
+	buf := C.CBytes(data)
+	defer C.free(buf)
+	cMime := C.CString(mimeType)
+	defer C.free(unsafe.Pointer(cMime))
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_email_parse((*C.uint8_t)(buf), C.uintptr_t(len(data)), cMime)
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	email := &parsedEmail{}
+	if err := decodeJSONCString(ptr, email); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode email", err, ErrorCodeValidation, nil)
+	}
+	return email, nil
+}
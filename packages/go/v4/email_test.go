@@ -0,0 +1,97 @@
+package kreuzberg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripQuotedReplies(t *testing.T) {
+	body := strings.Join([]string{
+		"Sounds good, see you then.",
+		"",
+		"On Mon, 3 Jun 2024 at 10:02, Alice <alice@example.com> wrote:",
+		"> Can we meet on Tuesday?",
+		">> Earlier thread",
+		"",
+	}, "\n")
+	if got := stripQuotedReplies(body); got != "Sounds good, see you then." {
+		t.Fatalf("unexpected body %q", got)
+	}
+
+	outlook := "Approved.\n\n-----Original Message-----\nFrom: Bob\nPlease approve the invoice."
+	if got := stripQuotedReplies(outlook); got != "Approved." {
+		t.Fatalf("unexpected body %q", got)
+	}
+
+	unquoted := "On Monday the team wrote: the plan.\nNothing quoted here."
+	if got := stripQuotedReplies(unquoted); got != unquoted {
+		t.Fatalf("expected unquoted text to be kept, got %q", got)
+	}
+}
+
+func TestRenderEmailFrontMatter(t *testing.T) {
+	email := &parsedEmail{
+		Subject:     StringPtr(`Budget: "Q3"`),
+		FromEmail:   StringPtr("alice@example.com"),
+		ToEmails:    []string{"bob@example.com", "carol@example.com"},
+		PlainText:   StringPtr("See attached.\n\n> old"),
+		Attachments: []parsedAttachment{{Name: StringPtr("budget.xlsx")}},
+	}
+	config := NewExtractionConfig(WithEmail(WithHeadersAsFrontMatter(true), WithStripQuotedReplies(true)))
+	want := strings.Join([]string{
+		"---",
+		`subject: "Budget: \"Q3\""`,
+		`from: "alice@example.com"`,
+		`to: ["bob@example.com","carol@example.com"]`,
+		`attachments: ["budget.xlsx"]`,
+		"---",
+		"See attached.",
+		"Attachments: budget.xlsx",
+	}, "\n")
+	if got := renderEmail(email, config); got != want {
+		t.Fatalf("unexpected rendering:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRenderEmailHeaderLines(t *testing.T) {
+	email := &parsedEmail{
+		Subject:   StringPtr("Hello"),
+		FromEmail: StringPtr("alice@example.com"),
+		CcEmails:  []string{"dave@example.com"},
+		PlainText: StringPtr("Hi Bob"),
+	}
+	want := "Subject: Hello\nFrom: alice@example.com\nCC: dave@example.com\nHi Bob"
+	if got := renderEmail(email, NewExtractionConfig(WithEmail())); got != want {
+		t.Fatalf("unexpected rendering %q", got)
+	}
+}
+
+func TestExtractEmailWithInlineAttachments(t *testing.T) {
+	eml := strings.Join([]string{
+		"From: alice@example.com",
+		"To: bob@example.com",
+		"Subject: Notes",
+		"MIME-Version: 1.0",
+		`Content-Type: multipart/mixed; boundary="b"`,
+		"",
+		"--b",
+		"Content-Type: text/plain",
+		"",
+		"Notes attached.",
+		"--b",
+		"Content-Type: text/plain",
+		`Content-Disposition: attachment; filename="notes.txt"`,
+		"",
+		"Remember the milk.",
+		"--b--",
+		"",
+	}, "\r\n")
+	config := NewExtractionConfig(WithEmail(WithInlineAttachments(true)))
+	result, err := ExtractBytesSync([]byte(eml), "message/rfc822", config)
+	if err != nil {
+		t.Fatalf("extraction failed: %v", err)
+	}
+	if !strings.Contains(result.Content, "Attachment: notes.txt\nRemember the milk.") {
+		t.Fatalf("expected inlined attachment, got %q", result.Content)
+	}
+}
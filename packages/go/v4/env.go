@@ -0,0 +1,156 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by ConfigFromEnv. The OCR, chunking, cache, and token
+// reduction variables share their names with the overrides the native library applies
+// to config files, so one deployment environment drives every binding.
+const (
+	// EnvConfigFile names a config file loaded with LoadConfig beneath the other variables.
+	EnvConfigFile         = "KREUZBERG_CONFIG"
+	EnvOCRLanguage        = "KREUZBERG_OCR_LANGUAGE"
+	EnvOCRBackend         = "KREUZBERG_OCR_BACKEND"
+	EnvForceOCR           = "KREUZBERG_FORCE_OCR"
+	EnvMaxConcurrency     = "KREUZBERG_MAX_CONCURRENCY"
+	EnvCacheEnabled       = "KREUZBERG_CACHE_ENABLED"
+	EnvChunkingMaxChars   = "KREUZBERG_CHUNKING_MAX_CHARS"
+	EnvChunkingMaxOverlap = "KREUZBERG_CHUNKING_MAX_OVERLAP"
+	EnvTokenReductionMode = "KREUZBERG_TOKEN_REDUCTION_MODE"
+	EnvOutputFormat       = "KREUZBERG_OUTPUT_FORMAT"
+
+	// envOCRLanguageShort is accepted as an alias of EnvOCRLanguage.
+	envOCRLanguageShort = "KREUZBERG_OCR_LANG"
+)
+
+// ConfigFromEnv builds an ExtractionConfig from KREUZBERG_* environment variables. Unset
+// variables leave the corresponding settings unset; malformed values are reported as
+// ValidationError naming the variable. KREUZBERG_CACHE_DIR needs no handling here: the
+// native library reads it directly.
+func ConfigFromEnv() (*ExtractionConfig, error) {
+	return configFromLookup(os.LookupEnv)
+}
+
+// ResolveConfig layers explicit on top of the environment: settings of explicit win, and
+// the environment fills in the rest. Nested configs such as OCR are taken as a whole from
+// whichever layer sets them, following ConfigMerge. A nil explicit returns the
+// environment config.
+func ResolveConfig(explicit *ExtractionConfig) (*ExtractionConfig, error) {
+	cfg, err := ConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if explicit != nil {
+		if err := ConfigMerge(cfg, explicit); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func configFromLookup(lookup func(string) (string, bool)) (*ExtractionConfig, error) {
+	cfg := &ExtractionConfig{}
+	if path, ok := lookup(EnvConfigFile); ok && path != "" {
+		loaded, err := LoadConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		cfg = loaded
+	}
+
+	language, ok := lookup(EnvOCRLanguage)
+	if !ok {
+		language, ok = lookup(envOCRLanguageShort)
+	}
+	if ok && language != "" {
+		if cfg.OCR == nil {
+			cfg.OCR = &OCRConfig{}
+		}
+		cfg.OCR.Language = &language
+	}
+	if backend, ok := lookup(EnvOCRBackend); ok && backend != "" {
+		if cfg.OCR == nil {
+			cfg.OCR = &OCRConfig{}
+		}
+		cfg.OCR.Backend = backend
+	}
+
+	if err := envBool(lookup, EnvForceOCR, &cfg.ForceOCR); err != nil {
+		return nil, err
+	}
+	if err := envBool(lookup, EnvCacheEnabled, &cfg.UseCache); err != nil {
+		return nil, err
+	}
+
+	if value, ok := lookup(EnvMaxConcurrency); ok {
+		n, err := envInt(EnvMaxConcurrency, value, 1)
+		if err != nil {
+			return nil, err
+		}
+		cfg.MaxConcurrentExtractions = &n
+	}
+
+	if value, ok := lookup(EnvChunkingMaxChars); ok {
+		n, err := envInt(EnvChunkingMaxChars, value, 1)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Chunking == nil {
+			cfg.Chunking = &ChunkingConfig{}
+		}
+		cfg.Chunking.MaxChars = &n
+	}
+	if value, ok := lookup(EnvChunkingMaxOverlap); ok {
+		n, err := envInt(EnvChunkingMaxOverlap, value, 0)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Chunking == nil {
+			cfg.Chunking = &ChunkingConfig{}
+		}
+		cfg.Chunking.MaxOverlap = &n
+	}
+	if cfg.Chunking != nil {
+		if err := validateChunkingConfig(cfg.Chunking); err != nil {
+			return nil, err
+		}
+	}
+
+	if mode, ok := lookup(EnvTokenReductionMode); ok && mode != "" {
+		if cfg.TokenReduction == nil {
+			cfg.TokenReduction = &TokenReductionConfig{}
+		}
+		cfg.TokenReduction.Mode = mode
+	}
+	if format, ok := lookup(EnvOutputFormat); ok && format != "" {
+		cfg.OutputFormat = format
+	}
+	return cfg, nil
+}
+
+// envBool parses the boolean variable name into target when it is set.
+func envBool(lookup func(string) (string, bool), name string, target **bool) error {
+	value, ok := lookup(name)
+	if !ok || value == "" {
+		return nil
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		return newValidationErrorWithContext(fmt.Sprintf("invalid value for %s: %q is not a boolean", name, value), err, ErrorCodeValidation, nil)
+	}
+	*target = &parsed
+	return nil
+}
+
+// envInt parses the integer variable name and checks it is at least minimum.
+func envInt(name, value string, minimum int) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n < minimum {
+		return 0, newValidationErrorWithContext(fmt.Sprintf("invalid value for %s: %q must be an integer of at least %d", name, value, minimum), err, ErrorCodeValidation, nil)
+	}
+	return n, nil
+}
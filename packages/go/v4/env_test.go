@@ -0,0 +1,66 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func envLookup(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	cfg, err := configFromLookup(envLookup(map[string]string{
+		"KREUZBERG_OCR_LANG":  "deu",
+		EnvOCRBackend:         "tesseract",
+		EnvMaxConcurrency:     "4",
+		EnvCacheEnabled:       "false",
+		EnvChunkingMaxChars:   "800",
+		EnvChunkingMaxOverlap: "80",
+		EnvTokenReductionMode: "light",
+	}))
+	if err != nil {
+		t.Fatalf("configFromLookup: %v", err)
+	}
+	if cfg.OCR == nil || *cfg.OCR.Language != "deu" || cfg.OCR.Backend != "tesseract" {
+		t.Fatalf("unexpected OCR config %+v", cfg.OCR)
+	}
+	if *cfg.MaxConcurrentExtractions != 4 || *cfg.UseCache {
+		t.Fatalf("unexpected concurrency or cache settings")
+	}
+	if *cfg.Chunking.MaxChars != 800 || *cfg.Chunking.MaxOverlap != 80 {
+		t.Fatalf("unexpected chunking %+v", cfg.Chunking)
+	}
+	if cfg.TokenReduction.Mode != "light" || cfg.ForceOCR != nil {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestConfigFromEnvRejectsMalformedValues(t *testing.T) {
+	for name, value := range map[string]string{
+		EnvMaxConcurrency:   "0",
+		EnvForceOCR:         "sometimes",
+		EnvChunkingMaxChars: "many",
+	} {
+		_, err := configFromLookup(envLookup(map[string]string{name: value}))
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("%s=%s: expected ValidationError, got %v", name, value, err)
+		}
+	}
+}
+
+func TestResolveConfigPrefersExplicitSettings(t *testing.T) {
+	t.Setenv(EnvOutputFormat, "markdown")
+	t.Setenv(EnvMaxConcurrency, "2")
+	cfg, err := ResolveConfig(NewExtractionConfig(WithMaxConcurrentExtractions(8)))
+	if err != nil {
+		t.Fatalf("ResolveConfig: %v", err)
+	}
+	if *cfg.MaxConcurrentExtractions != 8 || cfg.OutputFormat != "markdown" {
+		t.Fatalf("unexpected resolved config %+v", cfg)
+	}
+}
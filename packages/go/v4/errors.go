@@ -11,6 +11,7 @@ const char *kreuzberg_error_code_description(uint32_t code);
 import "C"
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 )
@@ -73,6 +74,8 @@ type PanicContext struct {
 	Function     string `json:"function"`
 	Message      string `json:"message"`
 	TimestampSec int64  `json:"timestamp_secs"`
+	// Backtrace is the stack of the panicking thread or goroutine, when it was captured.
+	Backtrace string `json:"backtrace,omitempty"`
 }
 
 // String returns a formatted string representation of PanicContext.
@@ -227,6 +230,12 @@ func newUnsupportedFormatErrorWithContext(format string, message string, cause e
 	}
 }
 
+// isUnsupportedFormat reports whether err is the core's unsupported-format error.
+func isUnsupportedFormat(err error) bool {
+	var unsupported *UnsupportedFormatError
+	return errors.As(err, &unsupported)
+}
+
 func newIOErrorWithContext(message string, cause error, code ErrorCode, panicCtx *PanicContext) *IOError {
 	return &IOError{baseError: makeBaseError(ErrorKindIO, message, cause, code, panicCtx)}
 }
@@ -1,6 +1,7 @@
 package kreuzberg
 
 import (
+	"os"
 	"strings"
 	"testing"
 )
@@ -68,6 +69,35 @@ func TestErrorWithPanicContext(t *testing.T) {
 	}
 }
 
+func TestNativePanicRecovery(t *testing.T) {
+	if err := injectNativeFault("kreuzberg_get_valid_ocr_backends"); err != nil {
+		if os.Getenv("REQUIRE_FAULT_INJECTION") == "true" {
+			t.Fatalf("fault injection unavailable: %v", err)
+		}
+		t.Skipf("fault injection unavailable: %v", err)
+	}
+	_, err := GetValidOCRBackends()
+	if !IsPanic(err) {
+		t.Fatalf("expected a panic error, got %v", err)
+	}
+	runtimeErr, ok := err.(*RuntimeError)
+	if !ok || runtimeErr.Code() != ErrorCodeInternal {
+		t.Fatalf("expected an internal RuntimeError, got %#v", err)
+	}
+	panicCtx := runtimeErr.PanicCtx()
+	if panicCtx.Function != "kreuzberg_get_valid_ocr_backends" || !strings.Contains(panicCtx.Message, "Injected fault") {
+		t.Fatalf("unexpected panic context: %s", panicCtx)
+	}
+	if panicCtx.Backtrace == "" {
+		t.Fatal("expected the native backtrace in the panic context")
+	}
+
+	backends, err := GetValidOCRBackends()
+	if err != nil || len(backends) == 0 {
+		t.Fatalf("library unusable after a recovered panic: %v %v", backends, err)
+	}
+}
+
 func TestExtractBytesSyncValidationErrors(t *testing.T) {
 	if _, err := ExtractBytesSync([]byte("hello"), "", nil); err == nil {
 		t.Fatalf("expected error for empty mime type")
@@ -0,0 +1,162 @@
+package kreuzberg
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// Defaults used by EstimateExtraction before any extraction of a format has completed.
+const (
+	defaultDurationPerCostUnit = 25 * time.Millisecond
+	// estimateMemoryPerByte approximates the working set of an extraction as a multiple of
+	// the document size.
+	estimateMemoryPerByte = 4
+	// estimateOCRPageMemory is the size of one page bitmap at 300 DPI (US Letter, RGB).
+	estimateOCRPageMemory = 2550 * 3300 * 3
+)
+
+// FormatStats summarizes the extractions of one MIME type completed in this process by
+// ExtractFileSync and ExtractBytesSync. CostUnits weighs the documents as ExtractPlan does,
+// and Duration excludes the time extractions waited for the native library to be free.
+type FormatStats struct {
+	Extractions int           `json:"extractions"`
+	Bytes       int64         `json:"bytes"`
+	Pages       int           `json:"pages"`
+	CostUnits   float64       `json:"cost_units"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// ExtractionEstimate predicts the duration and memory of an extraction. Samples is the
+// number of past extractions of the same MIME type the duration is based on; when it is
+// zero, built-in per-page defaults are used instead.
+type ExtractionEstimate struct {
+	Plan        *ExtractionPlan `json:"plan"`
+	Duration    time.Duration   `json:"duration"`
+	MemoryBytes int64           `json:"memory_bytes"`
+	Samples     int             `json:"samples"`
+}
+
+var (
+	formatStatsMu sync.Mutex
+	formatStats   = make(map[string]*FormatStats)
+)
+
+// EstimateExtraction plans the extraction of path with ExtractPlan and predicts its
+// duration from the time extractions of the same MIME type took per cost unit in this
+// process. Estimates are rough: they assume the document resembles those seen before.
+func EstimateExtraction(path string, config *ExtractionConfig) (*ExtractionEstimate, error) {
+	plan, err := ExtractPlan(path, config)
+	if err != nil {
+		return nil, err
+	}
+	return estimateFromPlan(plan), nil
+}
+
+func estimateFromPlan(plan *ExtractionPlan) *ExtractionEstimate {
+	estimate := &ExtractionEstimate{
+		Plan:        plan,
+		Duration:    time.Duration(plan.CostUnits * float64(defaultDurationPerCostUnit)),
+		MemoryBytes: plan.FileSize * estimateMemoryPerByte,
+	}
+	if plan.OCRRequired {
+		estimate.MemoryBytes += estimateOCRPageMemory
+	}
+
+	formatStatsMu.Lock()
+	stats, ok := formatStats[plan.MimeType]
+	if ok && stats.CostUnits > 0 {
+		estimate.Duration = time.Duration(plan.CostUnits * float64(stats.Duration) / stats.CostUnits)
+		estimate.Samples = stats.Extractions
+	}
+	formatStatsMu.Unlock()
+	return estimate
+}
+
+// ExtractionStatistics returns a snapshot of the per-MIME-type statistics used by
+// EstimateExtraction.
+func ExtractionStatistics() map[string]FormatStats {
+	formatStatsMu.Lock()
+	defer formatStatsMu.Unlock()
+	snapshot := make(map[string]FormatStats, len(formatStats))
+	for mimeType, stats := range formatStats {
+		snapshot[mimeType] = *stats
+	}
+	return snapshot
+}
+
+// ResetExtractionStatistics discards the statistics collected so far.
+func ResetExtractionStatistics() {
+	formatStatsMu.Lock()
+	formatStats = make(map[string]*FormatStats)
+	formatStatsMu.Unlock()
+}
+
+// recordExtraction adds a completed extraction to the statistics of its MIME type. Its
+// cost is weighed by planPages, as ExtractPlan weighs the documents estimated from these
+// statistics, with read returning the document for PDFs. Its duration is the time spent
+// in native calls once ffiMutex was held, or elapsed when it made none, so that waiting
+// for other extractions does not inflate the learned rate.
+func recordExtraction(mimeType string, size int64, read func() ([]byte, error), result *ExtractionResult, config *ExtractionConfig, elapsed time.Duration) {
+	if result == nil {
+		return
+	}
+	if result.MimeType != "" {
+		mimeType = result.MimeType
+	}
+	plan := &ExtractionPlan{MimeType: mimeType, FileSize: size}
+	if err := planPages(plan, read, config); err != nil {
+		return
+	}
+	pages := plan.PageCount
+	if result.Metadata.PageStructure != nil && result.Metadata.PageStructure.TotalCount > 0 {
+		pages = int(result.Metadata.PageStructure.TotalCount)
+	}
+	if result.nativeDuration > 0 {
+		elapsed = result.nativeDuration
+	}
+
+	formatStatsMu.Lock()
+	defer formatStatsMu.Unlock()
+	stats, ok := formatStats[mimeType]
+	if !ok {
+		stats = &FormatStats{}
+		formatStats[mimeType] = stats
+	}
+	stats.Extractions++
+	stats.Bytes += size
+	stats.Pages += pages
+	stats.CostUnits += plan.CostUnits
+	stats.Duration += elapsed
+}
+
+// timeNative adds the time since began to the native duration of *result. Extraction
+// functions defer it once they hold ffiMutex.
+func timeNative(began time.Time, result **ExtractionResult) {
+	if *result != nil {
+		(*result).nativeDuration += time.Since(began)
+	}
+}
+
+// timeNativeBatch shares the time since began evenly between the native durations of
+// *results.
+func timeNativeBatch(began time.Time, results *[]*ExtractionResult) {
+	if len(*results) == 0 {
+		return
+	}
+	share := time.Since(began) / time.Duration(len(*results))
+	for _, result := range *results {
+		if result != nil {
+			result.nativeDuration += share
+		}
+	}
+}
+
+// fileSize returns the size of the file at path, or 0 when it cannot be read.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,33 @@
+package kreuzberg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateUsesRecordedStatistics(t *testing.T) {
+	ResetExtractionStatistics()
+	defer ResetExtractionStatistics()
+
+	plan := &ExtractionPlan{MimeType: "text/plain", FileSize: 1000, PageCount: 4, CostUnits: 4}
+	if estimate := estimateFromPlan(plan); estimate.Samples != 0 || estimate.Duration != 4*defaultDurationPerCostUnit {
+		t.Fatalf("expected default estimate, got %+v", estimate)
+	}
+
+	// The cost follows ExtractPlan, from the size, while Pages counts the pages extracted.
+	// The time spent waiting for the native library is not counted.
+	result := &ExtractionResult{MimeType: "text/plain", Metadata: Metadata{PageStructure: &PageStructure{TotalCount: 2}}, nativeDuration: 100 * time.Millisecond}
+	recordExtraction("", 500, nil, result, nil, time.Second)
+
+	stats := ExtractionStatistics()["text/plain"]
+	if stats.Extractions != 1 || stats.Pages != 2 || stats.CostUnits != 1 || stats.Duration != 100*time.Millisecond {
+		t.Fatalf("unexpected statistics %+v", stats)
+	}
+	estimate := estimateFromPlan(plan)
+	if estimate.Samples != 1 || estimate.Duration != 400*time.Millisecond {
+		t.Fatalf("expected estimate from history, got %+v", estimate)
+	}
+	if estimate.MemoryBytes != 1000*estimateMemoryPerByte {
+		t.Fatalf("unexpected memory estimate %d", estimate.MemoryBytes)
+	}
+}
@@ -0,0 +1,125 @@
+// Package events publishes Kreuzberg extraction results as CloudEvents, for event-driven
+// ingestion pipelines.
+//
+// An Emitter wraps every result in a CloudEvents 1.0 envelope whose subject is the path of
+// the extracted file and whose data is the result JSON, and publishes it to its sinks: an
+// HTTP endpoint, in structured or binary content mode, or a Kafka topic through a producer
+// of your choice:
+//
+//	emitter := &events.Emitter{
+//		Source: "/ingest/worker-1",
+//		Sinks:  []events.Sink{&events.HTTPSink{URL: "https://broker.example.com/events"}},
+//	}
+//	result, err := kreuzberg.ExtractFileSync(path, nil)
+//	if err := emitter.Emit(ctx, path, result, err); err != nil {
+//		log.Print(err)
+//	}
+//
+// A WebhookSink pushes results to the endpoint of a client, signed with HMAC-SHA256 and
+// retried with exponential backoff, so clients can submit documents with Emitter.Submit
+// and receive the results when they are ready instead of waiting for them.
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// SpecVersion is the CloudEvents version of the events.
+const SpecVersion = "1.0"
+
+// Event types.
+const (
+	// TypeExtractionCompleted is the type of events carrying an extraction result.
+	TypeExtractionCompleted = "dev.kreuzberg.extraction.completed"
+	// TypeExtractionFailed is the type of events reporting a failed extraction, whose data
+	// is an ErrorData.
+	TypeExtractionFailed = "dev.kreuzberg.extraction.failed"
+)
+
+// Event is a CloudEvents envelope in the JSON event format.
+type Event struct {
+	SpecVersion     string          `json:"specversion"`
+	ID              string          `json:"id"`
+	Source          string          `json:"source"`
+	Type            string          `json:"type"`
+	Subject         string          `json:"subject,omitempty"`
+	Time            string          `json:"time,omitempty"`
+	DataContentType string          `json:"datacontenttype,omitempty"`
+	Data            json.RawMessage `json:"data,omitempty"`
+}
+
+// ErrorData is the data of a TypeExtractionFailed event.
+type ErrorData struct {
+	Message string `json:"message"`
+	// Kind and Code classify Kreuzberg errors; they are empty for other errors.
+	Kind kreuzberg.ErrorKind  `json:"kind,omitempty"`
+	Code *kreuzberg.ErrorCode `json:"code,omitempty"`
+}
+
+// NewResultEvent wraps the result extracted from path in a TypeExtractionCompleted event
+// from source.
+func NewResultEvent(source, path string, result *kreuzberg.ExtractionResult) (*Event, error) {
+	if result == nil {
+		return nil, errors.New("events: result is nil")
+	}
+	return newEvent(source, TypeExtractionCompleted, path, result)
+}
+
+// NewErrorEvent reports the failed extraction of path in a TypeExtractionFailed event from
+// source.
+func NewErrorEvent(source, path string, err error) (*Event, error) {
+	if err == nil {
+		return nil, errors.New("events: error is nil")
+	}
+	data := ErrorData{Message: err.Error()}
+	var kerr kreuzberg.KreuzbergError
+	if errors.As(err, &kerr) {
+		code := kerr.Code()
+		data.Kind, data.Code = kerr.Kind(), &code
+	}
+	return newEvent(source, TypeExtractionFailed, path, data)
+}
+
+func newEvent(source, eventType, subject string, data any) (*Event, error) {
+	if source == "" {
+		return nil, errors.New("events: source is required")
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("events: encoding event data: %w", err)
+	}
+	id, err := newEventID()
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            eventType,
+		Subject:         subject,
+		Time:            time.Now().UTC().Format(time.RFC3339Nano),
+		DataContentType: "application/json",
+		Data:            encoded,
+	}, nil
+}
+
+// newEventID returns a random UUID (version 4), unique per event as CloudEvents requires
+// together with the source.
+func newEventID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", fmt.Errorf("events: generating event id: %w", err)
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+	text := hex.EncodeToString(id[:])
+	return text[:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:], nil
+}
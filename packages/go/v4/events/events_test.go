@@ -0,0 +1,99 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+type recordingProducer struct {
+	messages []KafkaMessage
+}
+
+func (p *recordingProducer) Produce(_ context.Context, message KafkaMessage) error {
+	p.messages = append(p.messages, message)
+	return nil
+}
+
+func TestEmitterPublishesStructuredAndBinaryEvents(t *testing.T) {
+	var structured Event
+	httpServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != structuredContentType {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&structured); err != nil {
+			t.Errorf("decoding event: %v", err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer httpServer.Close()
+	producer := &recordingProducer{}
+	emitter := &Emitter{
+		Source: "/tests",
+		Sinks: []Sink{
+			&HTTPSink{URL: httpServer.URL},
+			&KafkaSink{Producer: producer, Topic: "results", Binary: true},
+		},
+	}
+
+	result := &kreuzberg.ExtractionResult{Content: "hello", MimeType: "text/plain", Success: true}
+	if err := emitter.Emit(context.Background(), "docs/hello.txt", result, nil); err != nil {
+		t.Fatalf("Emit: %v", err)
+	}
+
+	if structured.SpecVersion != SpecVersion || structured.Type != TypeExtractionCompleted || structured.Subject != "docs/hello.txt" || structured.ID == "" {
+		t.Fatalf("unexpected envelope %+v", structured)
+	}
+	var data kreuzberg.ExtractionResult
+	if err := json.Unmarshal(structured.Data, &data); err != nil || data.Content != "hello" {
+		t.Fatalf("expected the result as data, got %s (%v)", structured.Data, err)
+	}
+
+	if len(producer.messages) != 1 {
+		t.Fatalf("expected one Kafka record, got %d", len(producer.messages))
+	}
+	message := producer.messages[0]
+	if message.Topic != "results" || string(message.Key) != "docs/hello.txt" {
+		t.Fatalf("unexpected record %q keyed %q", message.Topic, message.Key)
+	}
+	headers := map[string]string{}
+	for _, header := range message.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	if headers["ce_type"] != TypeExtractionCompleted || headers["ce_id"] != structured.ID || headers["content-type"] != "application/json" {
+		t.Fatalf("unexpected headers %v", headers)
+	}
+}
+
+func TestHTTPSinkBinaryModeAndFailures(t *testing.T) {
+	var subject string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = r.Header.Get("ce-subject")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	event, err := NewErrorEvent("/tests", "scans/relevé 1.pdf", errors.New("unreadable"))
+	if err != nil {
+		t.Fatalf("NewErrorEvent: %v", err)
+	}
+	sink := &HTTPSink{URL: server.URL, Binary: true}
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Fatal("expected an error for a 503 answer")
+	}
+	if subject != "scans/relev%C3%A9%201.pdf" {
+		t.Fatalf("expected a percent-encoded subject, got %q", subject)
+	}
+	var data ErrorData
+	if err := json.Unmarshal(body, &data); err != nil || data.Message != "unreadable" || data.Code != nil {
+		t.Fatalf("unexpected error data %s (%v)", body, err)
+	}
+}
@@ -0,0 +1,208 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// structuredContentType is the media type of an event sent whole in the JSON event format.
+const structuredContentType = "application/cloudevents+json; charset=UTF-8"
+
+// Sink publishes events.
+type Sink interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+// Emitter publishes the results of extractions to its sinks.
+type Emitter struct {
+	// Source identifies the producer of the events, such as a URI naming the service.
+	Source string
+	// Sinks receive every event, in order.
+	Sinks []Sink
+}
+
+// Emit publishes the outcome of the extraction of path to every sink: a
+// TypeExtractionCompleted event carrying result when err is nil, and a
+// TypeExtractionFailed event otherwise. A failing sink does not keep the event from the
+// others; their errors are joined.
+func (e *Emitter) Emit(ctx context.Context, path string, result *kreuzberg.ExtractionResult, err error) error {
+	var event *Event
+	var eventErr error
+	if err != nil {
+		event, eventErr = NewErrorEvent(e.Source, path, err)
+	} else {
+		event, eventErr = NewResultEvent(e.Source, path, result)
+	}
+	if eventErr != nil {
+		return eventErr
+	}
+	var errs []error
+	for i, sink := range e.Sinks {
+		if err := sink.Publish(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("events: sink %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HTTPSink posts events to an HTTP endpoint following the CloudEvents HTTP protocol
+// binding.
+type HTTPSink struct {
+	// URL receives the events.
+	URL string
+	// Client performs the requests; http.DefaultClient when nil.
+	Client *http.Client
+	// Header is sent with every request, to authorize it for one.
+	Header http.Header
+	// Binary sends the event data as the body and its attributes as ce- headers instead of
+	// the whole event as JSON. Default: false.
+	Binary bool
+}
+
+// Publish posts event, failing unless the endpoint answers with a 2xx status.
+func (s *HTTPSink) Publish(ctx context.Context, event *Event) error {
+	body, contentType, err := encodeEvent(event, s.Binary)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("events: %w", err)
+	}
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.Binary {
+		for name, value := range binaryAttributes(event) {
+			req.Header.Set("ce-"+name, percentEncode(value))
+		}
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("events: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("events: %s answered %s", s.URL, resp.Status)
+	}
+	return nil
+}
+
+// KafkaHeader is a header of a Kafka record.
+type KafkaHeader struct {
+	Key   string
+	Value []byte
+}
+
+// KafkaMessage is a record to write to a Kafka topic.
+type KafkaMessage struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers []KafkaHeader
+}
+
+// KafkaProducer writes records to Kafka. Adapt the producer of your Kafka client, such as
+// franz-go, sarama or confluent-kafka-go, to it.
+type KafkaProducer interface {
+	Produce(ctx context.Context, message KafkaMessage) error
+}
+
+// KafkaSink writes events to a Kafka topic following the CloudEvents Kafka protocol
+// binding. Records are keyed by the event subject, the path of the extracted file, so
+// the events of one file keep their order in a partition.
+type KafkaSink struct {
+	Producer KafkaProducer
+	Topic    string
+	// Binary writes the event data as the record value and its attributes as ce_ headers
+	// instead of the whole event as JSON. Default: false.
+	Binary bool
+}
+
+// Publish writes event to the topic.
+func (s *KafkaSink) Publish(ctx context.Context, event *Event) error {
+	if s.Producer == nil {
+		return errors.New("events: Kafka sink has no producer")
+	}
+	value, contentType, err := encodeEvent(event, s.Binary)
+	if err != nil {
+		return err
+	}
+	message := KafkaMessage{
+		Topic:   s.Topic,
+		Value:   value,
+		Headers: []KafkaHeader{{Key: "content-type", Value: []byte(contentType)}},
+	}
+	if event.Subject != "" {
+		message.Key = []byte(event.Subject)
+	}
+	if s.Binary {
+		for name, value := range binaryAttributes(event) {
+			message.Headers = append(message.Headers, KafkaHeader{Key: "ce_" + name, Value: []byte(value)})
+		}
+	}
+	return s.Producer.Produce(ctx, message)
+}
+
+// encodeEvent returns the payload of event and its content type: the data alone in binary
+// mode, the whole event otherwise.
+func encodeEvent(event *Event, binary bool) ([]byte, string, error) {
+	if event == nil {
+		return nil, "", errors.New("events: event is nil")
+	}
+	if binary {
+		return event.Data, event.DataContentType, nil
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return nil, "", fmt.Errorf("events: encoding event: %w", err)
+	}
+	return body, structuredContentType, nil
+}
+
+// binaryAttributes returns the context attributes sent as headers in binary mode, by
+// attribute name. The data content type is sent as the content type instead.
+func binaryAttributes(event *Event) map[string]string {
+	attributes := map[string]string{
+		"specversion": event.SpecVersion,
+		"id":          event.ID,
+		"source":      event.Source,
+		"type":        event.Type,
+	}
+	if event.Subject != "" {
+		attributes["subject"] = event.Subject
+	}
+	if event.Time != "" {
+		attributes["time"] = event.Time
+	}
+	return attributes
+}
+
+// percentEncode escapes the bytes that the HTTP protocol binding requires escaped in
+// header values: those outside printable ASCII, space, double quote and percent.
+func percentEncode(value string) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c >= 0x7f || c == '"' || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// Webhook headers. The signature is "sha256=" followed by the hex HMAC-SHA256, keyed with
+// the webhook secret, of the timestamp, a dot and the body.
+const (
+	HeaderWebhookID        = "Webhook-Id"
+	HeaderWebhookTimestamp = "Webhook-Timestamp"
+	HeaderWebhookSignature = "Webhook-Signature"
+)
+
+// Defaults applied to unset WebhookSink fields.
+const (
+	defaultWebhookAttempts   = 5
+	defaultWebhookBackoff    = time.Second
+	defaultWebhookMaxBackoff = time.Minute
+)
+
+// ErrInvalidSignature is returned by VerifyWebhook for a request that is unsigned, signed
+// with another secret, altered or too old.
+var ErrInvalidSignature = errors.New("events: invalid webhook signature")
+
+// WebhookSink delivers events to a client's HTTP endpoint as signed JSON, retrying failed
+// deliveries with exponential backoff. Receivers check deliveries with VerifyWebhook and
+// can drop repeated ones by their Webhook-Id, the event id.
+type WebhookSink struct {
+	// URL receives the events.
+	URL string
+	// Secret signs the deliveries. Unsigned when empty.
+	Secret []byte
+	// Client performs the requests; http.DefaultClient when nil.
+	Client *http.Client
+	// MaxAttempts bounds the deliveries tried per event. Default: 5.
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled for each later one up to
+	// MaxBackoff and randomized by up to half to spread the retries of many events. A
+	// Retry-After answer overrides it. Defaults: 1s and 1m.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Publish delivers event, retrying on network errors and on 408, 429 and 5xx answers
+// until MaxAttempts deliveries failed or ctx is done.
+func (s *WebhookSink) Publish(ctx context.Context, event *Event) error {
+	body, contentType, err := encodeEvent(event, false)
+	if err != nil {
+		return err
+	}
+	attempts := s.MaxAttempts
+	if attempts < 1 {
+		attempts = defaultWebhookAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		retryAfter, err := s.deliver(ctx, event.ID, body, contentType)
+		if err == nil {
+			return nil
+		}
+		var permanent permanentError
+		if errors.As(err, &permanent) || attempt == attempts {
+			return fmt.Errorf("events: webhook delivery failed after %d attempts: %w", attempt, err)
+		}
+		wait := retryAfter
+		if wait <= 0 {
+			wait = s.backoff(attempt)
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return fmt.Errorf("events: webhook delivery abandoned: %w", ctx.Err())
+		}
+	}
+}
+
+// permanentError is a delivery failure that retrying cannot fix.
+type permanentError struct {
+	error
+}
+
+// deliver posts one delivery and returns the wait the endpoint asked for with
+// Retry-After, if any.
+func (s *WebhookSink) deliver(ctx context.Context, id string, body []byte, contentType string) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, permanentError{err}
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set(HeaderWebhookID, id)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	if len(s.Secret) > 0 {
+		req.Header.Set(HeaderWebhookSignature, "sha256="+webhookSignature(s.Secret, timestamp, body))
+	}
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, permanentError{err}
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode <= 299:
+		return 0, nil
+	case resp.StatusCode == http.StatusRequestTimeout, resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		var wait time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			wait = time.Duration(seconds) * time.Second
+		}
+		return wait, fmt.Errorf("%s answered %s", s.URL, resp.Status)
+	}
+	return 0, permanentError{fmt.Errorf("%s answered %s", s.URL, resp.Status)}
+}
+
+// backoff returns the wait after the given failed attempt, counting from 1.
+func (s *WebhookSink) backoff(attempt int) time.Duration {
+	wait, limit := s.InitialBackoff, s.MaxBackoff
+	if wait <= 0 {
+		wait = defaultWebhookBackoff
+	}
+	if limit <= 0 {
+		limit = defaultWebhookMaxBackoff
+	}
+	for i := 1; i < attempt && wait < limit; i++ {
+		wait *= 2
+	}
+	wait = min(wait, limit)
+	return wait/2 + rand.N(wait/2+1)
+}
+
+func webhookSignature(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhook checks the signature of a delivery received with header and body against
+// secret, and that it was signed within tolerance of now, which rejects replayed
+// deliveries. It returns ErrInvalidSignature when the delivery does not pass.
+func VerifyWebhook(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	timestamp := header.Get(HeaderWebhookTimestamp)
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return ErrInvalidSignature
+	}
+	signature, ok := strings.CutPrefix(header.Get(HeaderWebhookSignature), "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	expected := webhookSignature(secret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Submit extracts path in the background and emits the outcome to the sinks of the
+// emitter and to extra, such as a WebhookSink for the endpoint of the client that
+// submitted the document. The returned channel receives the outcome of Emit once the
+// event has been delivered or has failed to be. Canceling ctx stops the extraction, and the
+// failure is still delivered.
+func (e *Emitter) Submit(ctx context.Context, path string, config *kreuzberg.ExtractionConfig, extra ...Sink) <-chan error {
+	done := make(chan error, 1)
+	emitter := &Emitter{Source: e.Source, Sinks: append(append([]Sink(nil), e.Sinks...), extra...)}
+	go func() {
+		result, err := kreuzberg.ExtractFileWithContext(ctx, path, config)
+		done <- emitter.Emit(context.WithoutCancel(ctx), path, result, err)
+	}()
+	return done
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSinkRetriesAndSigns(t *testing.T) {
+	secret := []byte("shared secret")
+	var attempts atomic.Int32
+	verified := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		verified <- VerifyWebhook(secret, r.Header, body, time.Minute)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	event, err := NewErrorEvent("/tests", "a.pdf", errors.New("unreadable"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &WebhookSink{URL: server.URL, Secret: secret, InitialBackoff: time.Millisecond}
+	if err := sink.Publish(context.Background(), event); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if attempts.Load() != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts.Load())
+	}
+	if err := <-verified; err != nil {
+		t.Fatalf("signature did not verify: %v", err)
+	}
+
+	header := http.Header{}
+	header.Set(HeaderWebhookTimestamp, "1700000000")
+	header.Set(HeaderWebhookSignature, "sha256="+webhookSignature(secret, "1700000000", []byte("{}")))
+	if err := VerifyWebhook(secret, header, []byte("{}"), time.Minute); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("expected a stale delivery to be rejected, got %v", err)
+	}
+}
+
+func TestWebhookSinkDoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	event, err := NewErrorEvent("/tests", "a.pdf", errors.New("unreadable"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &WebhookSink{URL: server.URL, InitialBackoff: time.Millisecond}
+	if err := sink.Publish(context.Background(), event); err == nil {
+		t.Fatal("expected an error for a 400 answer")
+	}
+	if attempts.Load() != 1 {
+		t.Fatalf("expected a single attempt, got %d", attempts.Load())
+	}
+}
+
+func TestEmitterSubmitPushesResult(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "note.txt")
+	if err := os.WriteFile(path, []byte("pushed when ready"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	received := make(chan Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+			received <- event
+		}
+	}))
+	defer server.Close()
+
+	emitter := &Emitter{Source: "/tests"}
+	if err := <-emitter.Submit(context.Background(), path, nil, &WebhookSink{URL: server.URL}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	event := <-received
+	if event.Subject != path || event.Type == "" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
@@ -0,0 +1,280 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// GPSCoordinates is the position recorded with a photo, in decimal degrees with south
+// and west negative, and the altitude in metres above sea level when recorded.
+type GPSCoordinates struct {
+	Latitude  float64  `json:"latitude"`
+	Longitude float64  `json:"longitude"`
+	Altitude  *float64 `json:"altitude,omitempty"`
+}
+
+// EXIF tags read into ImageMetadata.
+const (
+	exifMake               = 0x010f
+	exifModel              = 0x0110
+	exifOrientation        = 0x0112
+	exifDateTime           = 0x0132
+	exifDateTimeOriginal   = 0x9003
+	exifOffsetTimeOriginal = 0x9011
+	exifOffsetTime         = 0x9010
+	gpsLatitudeRef         = 1
+	gpsLatitude            = 2
+	gpsLongitudeRef        = 3
+	gpsLongitude           = 4
+	gpsAltitudeRef         = 5
+	gpsAltitude            = 6
+)
+
+// exifTIFF finds the TIFF-structured EXIF block of an image: the file itself for TIFF,
+// the APP1 segment of a JPEG, the eXIf chunk of a PNG, the EXIF chunk of a WebP, the
+// Exif item of a HEIF or AVIF file and the Exif box of a JPEG XL container.
+func exifTIFF(data []byte) []byte {
+	if _, ok := tiffByteOrder(data); ok {
+		return data
+	}
+	for _, marker := range []string{"Exif\x00\x00", "eXIf", "EXIF", "Exif"} {
+		at := bytes.Index(data, []byte(marker))
+		if at < 0 {
+			continue
+		}
+		// The TIFF header follows the marker directly, after its NUL padding, or after
+		// a chunk size or header offset.
+		for _, skip := range []int{4, 6, 8} {
+			if at+skip < len(data) {
+				if _, ok := tiffByteOrder(data[at+skip:]); ok {
+					return data[at+skip:]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readExif fills the typed EXIF fields of meta from the EXIF block of the original image.
+func readExif(meta *ImageMetadata, data []byte) {
+	block := exifTIFF(data)
+	order, ok := tiffByteOrder(block)
+	if !ok {
+		return
+	}
+	primary, err := readTIFFDirectory(block)
+	if err != nil {
+		return
+	}
+	meta.CameraMake = exifText(primary[exifMake])
+	meta.CameraModel = exifText(primary[exifModel])
+	if values := primary[exifOrientation].numbers; len(values) == 1 && values[0] >= 1 && values[0] <= 8 {
+		meta.Orientation = int(values[0])
+	}
+
+	var exif map[int]tiffField
+	if pointer := primary[tiffExifIFD].numbers; len(pointer) == 1 {
+		exif, _ = readTIFFDirectoryAt(block, order, int(pointer[0]))
+	}
+	if captured, ok := exifTime(exifText(exif[exifDateTimeOriginal]), exifText(exif[exifOffsetTimeOriginal])); ok {
+		meta.CapturedAt = &captured
+	} else if captured, ok := exifTime(exifText(primary[exifDateTime]), exifText(exif[exifOffsetTime])); ok {
+		meta.CapturedAt = &captured
+	}
+
+	if pointer := primary[tiffGPSIFD].numbers; len(pointer) == 1 {
+		if gps, err := readTIFFDirectoryAt(block, order, int(pointer[0])); err == nil {
+			meta.GPS = gpsCoordinates(gps)
+		}
+	}
+}
+
+// exifText returns an ASCII field without its NUL terminator and padding.
+func exifText(field tiffField) string {
+	text, _, _ := strings.Cut(field.text, "\x00")
+	return strings.TrimSpace(text)
+}
+
+// exifTime parses an EXIF "YYYY:MM:DD HH:MM:SS" date with its "+HH:MM" offset, if any.
+func exifTime(value, offset string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if offset != "" {
+		if parsed, err := time.Parse("2006:01:02 15:04:05-07:00", value+offset); err == nil {
+			return parsed, true
+		}
+	}
+	parsed, err := time.ParseInLocation("2006:01:02 15:04:05", value, time.UTC)
+	return parsed, err == nil
+}
+
+// gpsCoordinates reads the position of a GPS directory, given as degrees, minutes and
+// seconds with hemisphere references.
+func gpsCoordinates(gps map[int]tiffField) *GPSCoordinates {
+	degrees := func(tag, ref int, negative string) (float64, bool) {
+		values := gps[tag].numbers
+		if len(values) != 3 {
+			return 0, false
+		}
+		value := values[0] + values[1]/60 + values[2]/3600
+		if strings.EqualFold(exifText(gps[ref]), negative) {
+			value = -value
+		}
+		return value, true
+	}
+	latitude, ok := degrees(gpsLatitude, gpsLatitudeRef, "S")
+	if !ok {
+		return nil
+	}
+	longitude, ok := degrees(gpsLongitude, gpsLongitudeRef, "W")
+	if !ok {
+		return nil
+	}
+	coordinates := &GPSCoordinates{Latitude: latitude, Longitude: longitude}
+	if values := gps[gpsAltitude].numbers; len(values) == 1 {
+		altitude := values[0]
+		if ref := gps[gpsAltitudeRef].numbers; len(ref) == 1 && ref[0] == 1 {
+			altitude = -altitude
+		}
+		coordinates.Altitude = &altitude
+	}
+	return coordinates
+}
+
+// exifFieldNames are the JSON names of the ImageMetadata fields read by readExif.
+var exifFieldNames = []string{"camera_make", "camera_model", "captured_at", "orientation", "gps"}
+
+// applyExifStage adds the typed EXIF fields to the metadata of image results when
+// ExtractionConfig.ExifMetadata is set. read returns the original document. Metadata
+// decoded lazily gets the fields in its undecoded JSON and stays undecoded. Like the
+// geospatial stage it never fails the extraction.
+func applyExifStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.ExifMetadata == nil || !*config.ExifMetadata {
+		return
+	}
+	if result.Metadata.Format.Type != FormatImage {
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	if result.Metadata.deferred != nil {
+		var exif ImageMetadata
+		readExif(&exif, data)
+		if result.Metadata.deferFields(exifFields(&exif)) {
+			return
+		}
+		result.Metadata.materialize()
+	}
+	if result.Metadata.Format.Image != nil {
+		readExif(result.Metadata.Format.Image, data)
+	}
+}
+
+// exifFields returns the EXIF fields of meta that are set, encoded as in the metadata
+// JSON.
+func exifFields(meta *ImageMetadata) map[string]json.RawMessage {
+	encoded, err := json.Marshal(meta)
+	if err != nil {
+		return nil
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &all); err != nil {
+		return nil
+	}
+	fields := map[string]json.RawMessage{}
+	for _, name := range exifFieldNames {
+		if value, ok := all[name]; ok {
+			fields[name] = value
+		}
+	}
+	return fields
+}
+
+// isJPEGPath reports whether path has a JPEG extension.
+func isJPEGPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jpg", ".jpeg", ".jpe", ".jfif":
+		return true
+	}
+	return false
+}
+
+// extractOrientedJPEG runs OCR on a JPEG turned upright according to its EXIF
+// orientation, since the core recognizes pixels in the order they are stored. It returns
+// nil and no error when OCR is not configured or the image is already upright, leaving
+// the file to the core.
+func extractOrientedJPEG(data []byte, config *ExtractionConfig) (*ExtractionResult, error) {
+	if config == nil || config.OCR == nil {
+		return nil, nil
+	}
+	var meta ImageMetadata
+	readExif(&meta, data)
+	if meta.Orientation < 2 {
+		return nil, nil
+	}
+	img, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil
+	}
+	var upright bytes.Buffer
+	if err := png.Encode(&upright, orientImage(img, meta.Orientation)); err != nil {
+		return nil, nil
+	}
+
+	result, err := extractBytesCore(upright.Bytes(), "image/png", config)
+	if err != nil {
+		return nil, err
+	}
+	result.MimeType = "image/jpeg"
+	if imageMeta, ok := result.Metadata.ImageMetadata(); ok {
+		imageMeta.Format = "JPEG"
+	}
+	return result, nil
+}
+
+// orientImage applies an EXIF orientation: 2 to 4 mirror or turn the image half way,
+// 5 to 8 transpose it with a mirror or turn it a quarter.
+func orientImage(img image.Image, orientation int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	size := image.Rect(0, 0, width, height)
+	if orientation >= 5 {
+		size = image.Rect(0, 0, height, width)
+	}
+	out := image.NewRGBA(size)
+	for y := range height {
+		for x := range width {
+			var dx, dy int
+			switch orientation {
+			case 2:
+				dx, dy = width-1-x, y
+			case 3:
+				dx, dy = width-1-x, height-1-y
+			case 4:
+				dx, dy = x, height-1-y
+			case 5:
+				dx, dy = y, x
+			case 6:
+				dx, dy = height-1-y, x
+			case 7:
+				dx, dy = height-1-y, width-1-x
+			case 8:
+				dx, dy = y, width-1-x
+			}
+			out.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return out
+}
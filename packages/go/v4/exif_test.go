@@ -0,0 +1,196 @@
+package kreuzberg
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+type exifTestEntry struct {
+	tag, kind uint16
+	count     uint32
+	value     []byte
+	directory int
+}
+
+func exifTestASCII(tag uint16, text string) exifTestEntry {
+	return exifTestEntry{tag: tag, kind: 2, count: uint32(len(text) + 1), value: append([]byte(text), 0)}
+}
+
+func exifTestShort(tag, value uint16) exifTestEntry {
+	return exifTestEntry{tag: tag, kind: 3, count: 1, value: binary.LittleEndian.AppendUint16(nil, value)}
+}
+
+// exifTestRationals takes numerator and denominator pairs.
+func exifTestRationals(tag uint16, values ...uint32) exifTestEntry {
+	entry := exifTestEntry{tag: tag, kind: 5, count: uint32(len(values) / 2)}
+	for _, v := range values {
+		entry.value = binary.LittleEndian.AppendUint32(entry.value, v)
+	}
+	return entry
+}
+
+// exifTestPointer points at the directory with the given index.
+func exifTestPointer(tag uint16, directory int) exifTestEntry {
+	return exifTestEntry{tag: tag, kind: 4, count: 1, directory: directory}
+}
+
+// exifTestBlock writes a little-endian TIFF block with the directories laid out one after
+// another, each followed by its out-of-line values.
+func exifTestBlock(directories [][]exifTestEntry) []byte {
+	offsets := make([]int, len(directories))
+	at := 8
+	for i, entries := range directories {
+		offsets[i] = at
+		at += 6 + 12*len(entries)
+		for _, entry := range entries {
+			if len(entry.value) > 4 {
+				at += len(entry.value)
+			}
+		}
+	}
+
+	out := []byte("II*\x00\x08\x00\x00\x00")
+	for i, entries := range directories {
+		extraAt := offsets[i] + 6 + 12*len(entries)
+		var extra []byte
+		out = binary.LittleEndian.AppendUint16(out, uint16(len(entries)))
+		for _, entry := range entries {
+			value := entry.value
+			if entry.directory > 0 {
+				value = binary.LittleEndian.AppendUint32(nil, uint32(offsets[entry.directory]))
+			}
+			out = binary.LittleEndian.AppendUint16(out, entry.tag)
+			out = binary.LittleEndian.AppendUint16(out, entry.kind)
+			out = binary.LittleEndian.AppendUint32(out, entry.count)
+			if len(value) > 4 {
+				out = binary.LittleEndian.AppendUint32(out, uint32(extraAt+len(extra)))
+				extra = append(extra, value...)
+				continue
+			}
+			out = append(out, append(value, make([]byte, 4-len(value))...)...)
+		}
+		out = append(append(out, 0, 0, 0, 0), extra...)
+	}
+	return out
+}
+
+func exifTestPhoto() []byte {
+	block := exifTestBlock([][]exifTestEntry{
+		{
+			exifTestASCII(exifMake, "Apple"),
+			exifTestASCII(exifModel, "iPhone 15 Pro"),
+			exifTestShort(exifOrientation, 6),
+			exifTestASCII(exifDateTime, "2024:05:02 10:00:00"),
+			exifTestPointer(tiffExifIFD, 1),
+			exifTestPointer(tiffGPSIFD, 2),
+		},
+		{
+			exifTestASCII(exifDateTimeOriginal, "2024:05:01 18:30:15"),
+			exifTestASCII(exifOffsetTimeOriginal, "+02:00"),
+		},
+		{
+			exifTestASCII(gpsLatitudeRef, "N"),
+			exifTestRationals(gpsLatitude, 52, 1, 31, 1, 1230, 100),
+			exifTestASCII(gpsLongitudeRef, "W"),
+			exifTestRationals(gpsLongitude, 13, 1, 24, 1, 0, 1),
+			exifTestRationals(gpsAltitude, 345, 10),
+		},
+	})
+	segment := append([]byte("Exif\x00\x00"), block...)
+	jpeg := []byte{0xff, 0xd8, 0xff, 0xe1, byte((len(segment) + 2) >> 8), byte(len(segment) + 2)}
+	return append(append(jpeg, segment...), 0xff, 0xd9)
+}
+
+func TestReadExif(t *testing.T) {
+	var meta ImageMetadata
+	readExif(&meta, exifTestPhoto())
+
+	if meta.CameraMake != "Apple" || meta.CameraModel != "iPhone 15 Pro" || meta.Orientation != 6 {
+		t.Fatalf("unexpected camera fields: %+v", meta)
+	}
+	want := time.Date(2024, 5, 1, 16, 30, 15, 0, time.UTC)
+	if meta.CapturedAt == nil || !meta.CapturedAt.Equal(want) {
+		t.Fatalf("captured at %v, want %v", meta.CapturedAt, want)
+	}
+	if _, offset := meta.CapturedAt.Zone(); offset != 2*3600 {
+		t.Fatalf("capture time lost its offset: %v", meta.CapturedAt)
+	}
+
+	gps := meta.GPS
+	if gps == nil || gps.Latitude < 52.5200 || gps.Latitude > 52.5201 || gps.Longitude != -13.4 {
+		t.Fatalf("unexpected coordinates: %+v", gps)
+	}
+	if gps.Altitude == nil || *gps.Altitude != 34.5 {
+		t.Fatalf("unexpected altitude: %v", gps.Altitude)
+	}
+}
+
+func TestReadExifWithoutExif(t *testing.T) {
+	var meta ImageMetadata
+	readExif(&meta, []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"))
+	if meta.CameraMake != "" || meta.CapturedAt != nil || meta.GPS != nil || meta.Orientation != 0 {
+		t.Fatalf("expected no EXIF fields, got %+v", meta)
+	}
+}
+
+func TestApplyExifStage(t *testing.T) {
+	read := func() ([]byte, error) { return exifTestPhoto(), nil }
+	input := []byte(`{"content":"","mime_type":"image/jpeg","metadata":{"format_type":"image","width":4,"height":3,"format":"JPEG","exif":{"Make":"Apple"},"camera_make":"stale"}}`)
+
+	for _, lazy := range []bool{false, true} {
+		result, err := decodeResultBuffer(input, lazy)
+		if err != nil {
+			t.Fatalf("decodeResultBuffer: %v", err)
+		}
+		applyExifStage(result, read, NewExtractionConfig())
+		if image, _ := result.Metadata.ImageMetadata(); image.CameraModel != "" {
+			t.Fatalf("lazy=%v: expected no EXIF fields unless enabled, got %+v", lazy, image)
+		}
+
+		result, err = decodeResultBuffer(input, lazy)
+		if err != nil {
+			t.Fatalf("decodeResultBuffer: %v", err)
+		}
+		applyExifStage(result, read, NewExtractionConfig(WithExifMetadata(true)))
+		if lazy && result.Metadata.Format.Image != nil {
+			t.Fatal("expected lazy metadata to stay undecoded")
+		}
+		image, ok := result.Metadata.ImageMetadata()
+		if !ok || image.Width != 4 || image.EXIF["Make"] != "Apple" {
+			t.Fatalf("lazy=%v: expected the core's fields to be kept, got %+v", lazy, image)
+		}
+		if image.CameraMake != "Apple" || image.CameraModel != "iPhone 15 Pro" || image.Orientation != 6 || image.CapturedAt == nil || image.GPS == nil {
+			t.Fatalf("lazy=%v: unexpected EXIF fields %+v", lazy, image)
+		}
+	}
+}
+
+func TestOrientImage(t *testing.T) {
+	// A 3x2 image whose top-left pixel is marked.
+	img := image.NewGray(image.Rect(0, 0, 3, 2))
+	img.SetGray(0, 0, color.Gray{Y: 0xff})
+
+	cases := map[int]image.Point{
+		2: {2, 0}, 3: {2, 1}, 4: {0, 1}, 5: {0, 0}, 6: {1, 0}, 7: {1, 2}, 8: {0, 2},
+	}
+	for orientation, want := range cases {
+		out := orientImage(img, orientation)
+		size := out.Bounds().Size()
+		if orientation >= 5 && size != (image.Point{2, 3}) || orientation < 5 && size != (image.Point{3, 2}) {
+			t.Fatalf("orientation %d: size %v", orientation, size)
+		}
+		if r, _, _, _ := out.At(want.X, want.Y).RGBA(); r != 0xffff {
+			t.Fatalf("orientation %d: marked pixel is not at %v", orientation, want)
+		}
+	}
+}
+
+func TestExtractOrientedJPEGNeedsOCR(t *testing.T) {
+	result, err := extractOrientedJPEG(exifTestPhoto(), &ExtractionConfig{})
+	if result != nil || err != nil {
+		t.Fatalf("expected the JPEG to be left to the core without OCR, got %v, %v", result, err)
+	}
+}
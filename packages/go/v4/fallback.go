@@ -0,0 +1,216 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Steps of a fallback chain configured in ExtractionConfig.Fallbacks.
+const (
+	// FallbackNative runs the extractor registered for the format.
+	FallbackNative = "native"
+	// FallbackOCR runs the extraction again with OCR forced on every page.
+	FallbackOCR = "ocr"
+	// FallbackStrings dumps the runs of printable text found in the raw bytes.
+	FallbackStrings = "strings"
+)
+
+// fallbackMinRun is the shortest run of printable characters kept by FallbackStrings.
+const fallbackMinRun = 4
+
+// FallbackMetadata records the fallback chain applied to a document. Chain lists the
+// steps tried, in order; the last one produced the result. Errors holds the reason each
+// earlier step was abandoned.
+type FallbackMetadata struct {
+	Chain  []string `json:"chain"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+func validateFallbacks(fallbacks map[string][]string) error {
+	for mimeType, chain := range fallbacks {
+		if len(chain) == 0 {
+			return newValidationErrorWithContext(fmt.Sprintf("fallback chain for %s is empty", mimeType), nil, ErrorCodeValidation, nil)
+		}
+		for _, step := range chain {
+			switch step {
+			case FallbackNative, FallbackOCR, FallbackStrings:
+			default:
+				return newValidationErrorWithContext(fmt.Sprintf("unknown fallback step %q for %s", step, mimeType), nil, ErrorCodeValidation, nil)
+			}
+		}
+	}
+	return nil
+}
+
+// fallbackChain returns the chain configured for mimeType, looking up the exact type,
+// then its "type/*" wildcard, then "*". It returns nil when none applies.
+func fallbackChain(config *ExtractionConfig, mimeType string) []string {
+	if config == nil || len(config.Fallbacks) == 0 {
+		return nil
+	}
+	keys := []string{mimeType}
+	if major, _, ok := strings.Cut(mimeType, "/"); ok {
+		keys = append(keys, major+"/*")
+	}
+	for _, key := range append(keys, "*") {
+		if chain, ok := config.Fallbacks[key]; ok {
+			return chain
+		}
+	}
+	return nil
+}
+
+// extractWithFallbacks runs the steps of chain until one yields non-blank content. When
+// every step fails, the first result obtained is returned, or the first error if no step
+// produced a result. extract runs a native extraction with the given config and read
+// returns the raw document for FallbackStrings.
+func extractWithFallbacks(chain []string, mimeType string, native *ExtractionConfig, extract func(*ExtractionConfig) (*ExtractionResult, error), read func() ([]byte, error)) (*ExtractionResult, error) {
+	meta := &FallbackMetadata{}
+	var first *ExtractionResult
+	var firstErr error
+	for _, step := range chain {
+		meta.Chain = append(meta.Chain, step)
+		result, err := runFallbackStep(step, mimeType, native, extract, read)
+		if err == nil && result != nil && result.Metadata.Error == nil && strings.TrimSpace(result.Content) != "" {
+			result.Metadata.Fallback = meta
+			return result, nil
+		}
+		switch {
+		case err != nil:
+			meta.Errors = append(meta.Errors, fmt.Sprintf("%s: %v", step, err))
+			if firstErr == nil {
+				firstErr = err
+			}
+		case result != nil && result.Metadata.Error != nil:
+			meta.Errors = append(meta.Errors, fmt.Sprintf("%s: %s", step, result.Metadata.Error.Message))
+		default:
+			meta.Errors = append(meta.Errors, fmt.Sprintf("%s: no content", step))
+		}
+		if first == nil && result != nil {
+			first = result
+		}
+	}
+	if first == nil {
+		return nil, firstErr
+	}
+	first.Metadata.Fallback = meta
+	return first, nil
+}
+
+func runFallbackStep(step, mimeType string, native *ExtractionConfig, extract func(*ExtractionConfig) (*ExtractionResult, error), read func() ([]byte, error)) (*ExtractionResult, error) {
+	switch step {
+	case FallbackOCR:
+		cfg := ExtractionConfig{}
+		if native != nil {
+			cfg = *native
+		}
+		cfg.ForceOCR = BoolPtr(true)
+		return extract(&cfg)
+	case FallbackStrings:
+		data, err := read()
+		if err != nil {
+			return nil, err
+		}
+		return &ExtractionResult{Content: printableStrings(data, fallbackMinRun), MimeType: mimeType, Success: true}, nil
+	}
+	return extract(native)
+}
+
+// printableStrings returns the runs of at least minRun printable characters in data, one
+// per line, in the manner of the strings utility.
+func printableStrings(data []byte, minRun int) string {
+	var out strings.Builder
+	var run []rune
+	flush := func() {
+		if len(run) >= minRun {
+			if out.Len() > 0 {
+				out.WriteByte('\n')
+			}
+			out.WriteString(strings.TrimSpace(string(run)))
+		}
+		run = run[:0]
+	}
+	for len(data) > 0 {
+		r, size := utf8.DecodeRune(data)
+		data = data[size:]
+		if r != utf8.RuneError && (unicode.IsPrint(r) || r == '\t') {
+			run = append(run, r)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return out.String()
+}
+
+// extractFileWithFallbacks extracts the file at path, applying the fallback chain
+// configured for its MIME type.
+func extractFileWithFallbacks(path string, config *ExtractionConfig) (*ExtractionResult, error) {
+	native := nativeConfig(config)
+	if config == nil || len(config.Fallbacks) == 0 {
+		return extractFileNative(path, native)
+	}
+	mimeType, _ := DetectMimeTypeFromPath(path)
+	chain := fallbackChain(config, mimeType)
+	if chain == nil {
+		return extractFileNative(path, native)
+	}
+	return fileFallbacks(path, mimeType, chain, native)
+}
+
+func fileFallbacks(path, mimeType string, chain []string, native *ExtractionConfig) (*ExtractionResult, error) {
+	return extractWithFallbacks(chain, mimeType, native,
+		func(cfg *ExtractionConfig) (*ExtractionResult, error) { return extractFileNative(path, cfg) },
+		func() ([]byte, error) { return readDocument(path) })
+}
+
+// readDocument reads the file at path for binding-side processing of its raw content.
+func readDocument(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read document", err, ErrorCodeIo, nil)
+	}
+	return data, nil
+}
+
+// extractBytesWithFallbacks extracts data, applying the fallback chain configured for
+// mimeType.
+func extractBytesWithFallbacks(data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	native := nativeConfig(config)
+	chain := fallbackChain(config, mimeType)
+	if chain == nil {
+		return extractBytesNative(data, mimeType, native)
+	}
+	return bytesFallbacks(data, mimeType, chain, native)
+}
+
+func bytesFallbacks(data []byte, mimeType string, chain []string, native *ExtractionConfig) (*ExtractionResult, error) {
+	return extractWithFallbacks(chain, mimeType, native,
+		func(cfg *ExtractionConfig) (*ExtractionResult, error) { return extractBytesNative(data, mimeType, cfg) },
+		func() ([]byte, error) { return data, nil })
+}
+
+// fallbackFailedResults replaces the failed documents of a batch with the outcome of
+// their fallback chains. mimeType names the format of document i and extract runs a
+// chain on it.
+func fallbackFailedResults(results []*ExtractionResult, config *ExtractionConfig, mimeType func(int) string, extract func(i int, mimeType string, chain []string) (*ExtractionResult, error)) {
+	if config == nil || len(config.Fallbacks) == 0 {
+		return
+	}
+	for i, result := range results {
+		if result == nil || result.Metadata.Error == nil {
+			continue
+		}
+		mime := mimeType(i)
+		chain := fallbackChain(config, mime)
+		if chain == nil {
+			continue
+		}
+		if fallback, err := extract(i, mime, chain); err == nil {
+			results[i] = fallback
+		}
+	}
+}
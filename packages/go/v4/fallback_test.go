@@ -0,0 +1,94 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFallbackChainLookup(t *testing.T) {
+	cfg := NewExtractionConfig(
+		WithFallbacks("application/pdf", FallbackNative, FallbackOCR),
+		WithFallbacks("image/*", FallbackOCR),
+		WithFallbacks("*", FallbackNative, FallbackStrings),
+	)
+	cases := map[string][]string{
+		"application/pdf": {FallbackNative, FallbackOCR},
+		"image/png":       {FallbackOCR},
+		"text/plain":      {FallbackNative, FallbackStrings},
+	}
+	for mimeType, want := range cases {
+		if got := fallbackChain(cfg, mimeType); !reflect.DeepEqual(got, want) {
+			t.Errorf("fallbackChain(%q) = %v, want %v", mimeType, got, want)
+		}
+	}
+	if chain := fallbackChain(nil, "application/pdf"); chain != nil {
+		t.Fatalf("expected no chain without config, got %v", chain)
+	}
+}
+
+func TestExtractWithFallbacksRecordsChain(t *testing.T) {
+	var forced []bool
+	extract := func(cfg *ExtractionConfig) (*ExtractionResult, error) {
+		forced = append(forced, cfg != nil && cfg.ForceOCR != nil && *cfg.ForceOCR)
+		if len(forced) == 1 {
+			return nil, newParsingErrorWithContext("broken xref", nil, ErrorCodeParsing, nil)
+		}
+		return &ExtractionResult{Content: "  "}, nil
+	}
+	read := func() ([]byte, error) { return []byte("\x00\x01Invoice 42\x00\xffok\x02Total due\n"), nil }
+
+	result, err := extractWithFallbacks([]string{FallbackNative, FallbackOCR, FallbackStrings}, "application/pdf", nil, extract, read)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(forced, []bool{false, true}) {
+		t.Fatalf("expected native then forced OCR, got %v", forced)
+	}
+	if result.Content != "Invoice 42\nTotal due" {
+		t.Fatalf("unexpected strings dump %q", result.Content)
+	}
+	fallback := result.Metadata.Fallback
+	if fallback == nil || !reflect.DeepEqual(fallback.Chain, []string{FallbackNative, FallbackOCR, FallbackStrings}) || len(fallback.Errors) != 2 {
+		t.Fatalf("unexpected fallback metadata %+v", fallback)
+	}
+}
+
+func TestExtractWithFallbacksReturnsFirstError(t *testing.T) {
+	parsing := newParsingErrorWithContext("broken xref", nil, ErrorCodeParsing, nil)
+	_, err := extractWithFallbacks([]string{FallbackNative, FallbackOCR}, "application/pdf", nil,
+		func(*ExtractionConfig) (*ExtractionResult, error) { return nil, parsing },
+		func() ([]byte, error) { return nil, nil })
+	if !errors.Is(err, parsing) {
+		t.Fatalf("expected the first step's error, got %v", err)
+	}
+}
+
+func TestFallbackMetadataRoundTrip(t *testing.T) {
+	meta := Metadata{Fallback: &FallbackMetadata{Chain: []string{FallbackNative, FallbackOCR}, Errors: []string{"native: no content"}}}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Metadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(decoded.Fallback, meta.Fallback) || decoded.Additional != nil {
+		t.Fatalf("unexpected round trip %+v", decoded)
+	}
+}
+
+func TestFallbacksValidation(t *testing.T) {
+	for _, cfg := range []*ExtractionConfig{
+		NewExtractionConfig(WithFallbacks("application/pdf")),
+		NewExtractionConfig(WithFallbacks("*", FallbackNative, "magic")),
+	} {
+		_, err := ExtractBytesSync([]byte("x"), "text/plain", cfg)
+		var validation *ValidationError
+		if !errors.As(err, &validation) {
+			t.Errorf("expected ValidationError for %v, got %v", cfg.Fallbacks, err)
+		}
+	}
+}
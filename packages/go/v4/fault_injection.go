@@ -0,0 +1,23 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+*/
+import "C"
+
+import "unsafe"
+
+// injectNativeFault arms a panic in the next call of the core library's FFI function
+// named function, for tests of panic recovery. It fails unless the library was built with
+// the `fault-injection` feature.
+func injectNativeFault(function string) error {
+	cFunction := C.CString(function)
+	defer C.free(unsafe.Pointer(cFunction))
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	if C.kreuzberg_inject_fault(cFunction) != 0 {
+		return lastError()
+	}
+	return nil
+}
@@ -0,0 +1,245 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Policies for symbolic links, special files, and sparse files in FilePolicyConfig.
+const (
+	FilePolicyFollow  = "follow"
+	FilePolicySkip    = "skip"
+	FilePolicyError   = "error"
+	FilePolicyExtract = "extract"
+)
+
+// skippedErrorType is the ErrorMetadata.ErrorType of results for files excluded by a
+// FilePolicyConfig.
+const skippedErrorType = "Skipped"
+
+func validateFilePolicy(cfg *FilePolicyConfig) error {
+	switch cfg.Symlinks {
+	case "", FilePolicyFollow, FilePolicySkip, FilePolicyError:
+	default:
+		return newValidationErrorWithContext(fmt.Sprintf("invalid symlink policy: %s (expected follow, skip, or error)", cfg.Symlinks), nil, ErrorCodeValidation, nil)
+	}
+	switch cfg.SpecialFiles {
+	case "", FilePolicySkip, FilePolicyError:
+	default:
+		return newValidationErrorWithContext(fmt.Sprintf("invalid special file policy: %s (expected skip or error)", cfg.SpecialFiles), nil, ErrorCodeValidation, nil)
+	}
+	switch cfg.SparseFiles {
+	case "", FilePolicyExtract, FilePolicySkip, FilePolicyError:
+	default:
+		return newValidationErrorWithContext(fmt.Sprintf("invalid sparse file policy: %s (expected extract, skip, or error)", cfg.SparseFiles), nil, ErrorCodeValidation, nil)
+	}
+	if cfg.MaxFileSize < 0 {
+		return newValidationErrorWithContext("max_file_size cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// extractWithFilePolicy runs extract on the paths admitted by cfg. Skipped paths get an
+// error result stating the reason; a path whose policy is "error" fails the whole batch.
+func extractWithFilePolicy(paths []string, cfg *FilePolicyConfig, extract func([]string) ([]*ExtractionResult, error)) ([]*ExtractionResult, error) {
+	results := make([]*ExtractionResult, len(paths))
+	var pending []string
+	var pendingIndex []int
+	for i, path := range paths {
+		reason, err := fileSkipReason(path, cfg)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			results[i] = failedResult(skippedErrorType, fmt.Sprintf("skipped %s: %s", path, reason))
+			continue
+		}
+		pending = append(pending, path)
+		pendingIndex = append(pendingIndex, i)
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+	extracted, err := extract(pending)
+	if err != nil {
+		return nil, err
+	}
+	for j, result := range extracted {
+		results[pendingIndex[j]] = result
+	}
+	return results, nil
+}
+
+// fileSkipReason applies cfg to the file at path and returns why it is skipped, or "" when
+// it is extracted. Files that cannot be inspected are left for the extraction to report.
+func fileSkipReason(path string, cfg *FilePolicyConfig) (string, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", nil
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		switch cfg.Symlinks {
+		case FilePolicySkip:
+			return "symbolic link", nil
+		case FilePolicyError:
+			return "", newValidationErrorWithContext(fmt.Sprintf("symbolic link not allowed: %s", path), nil, ErrorCodeValidation, nil)
+		}
+		if info, err = os.Stat(path); err != nil {
+			return "", nil
+		}
+	}
+	if !info.Mode().IsRegular() && !info.IsDir() {
+		if cfg.SpecialFiles == FilePolicyError {
+			return "", newValidationErrorWithContext(fmt.Sprintf("special file not allowed: %s (%s)", path, info.Mode().Type()), nil, ErrorCodeValidation, nil)
+		}
+		return fmt.Sprintf("special file (%s)", info.Mode().Type()), nil
+	}
+	if cfg.MaxFileSize > 0 && info.Size() > cfg.MaxFileSize {
+		return fmt.Sprintf("size %d exceeds limit of %d bytes", info.Size(), cfg.MaxFileSize), nil
+	}
+	if cfg.SparseFiles != "" && cfg.SparseFiles != FilePolicyExtract && info.Mode().IsRegular() && fileHasHoles(path, info.Size()) {
+		if cfg.SparseFiles == FilePolicyError {
+			return "", newValidationErrorWithContext(fmt.Sprintf("sparse file not allowed: %s", path), nil, ErrorCodeValidation, nil)
+		}
+		return "sparse file", nil
+	}
+	return "", nil
+}
+
+// DirOptions controls which entries of a directory ExtractDirectory extracts.
+type DirOptions struct {
+	// Recursive descends into subdirectories. Default: only the files directly in the
+	// directory.
+	Recursive bool
+	// Policy decides which files are extracted, as FilePolicyConfig does for
+	// BatchExtractFilesSync. Its Symlinks setting also applies to symbolic links to
+	// directories, which are descended into only when followed. Default:
+	// ExtractionConfig.FilePolicy, or else the FilePolicyConfig defaults.
+	Policy *FilePolicyConfig
+}
+
+// DirectoryResult is the result of one entry found by ExtractDirectory.
+type DirectoryResult struct {
+	Path   string            `json:"path"`
+	Result *ExtractionResult `json:"result"`
+}
+
+// ExtractDirectory extracts the files of the directory at root in one batch, in directory
+// order. Entries excluded by the policy of opts, including symbolic links to directories
+// that are skipped or were already visited, get an error result stating the reason; an
+// entry whose policy is "error" fails the whole call.
+func ExtractDirectory(root string, opts DirOptions, config *ExtractionConfig) ([]DirectoryResult, error) {
+	if root == "" {
+		return nil, newValidationErrorWithContext("root is required", nil, ErrorCodeValidation, nil)
+	}
+	policy := opts.Policy
+	if policy == nil && config != nil {
+		policy = config.FilePolicy
+	}
+	if policy == nil {
+		policy = &FilePolicyConfig{}
+	}
+	if err := validateFilePolicy(policy); err != nil {
+		return nil, err
+	}
+	batchConfig := &ExtractionConfig{}
+	if config != nil {
+		*batchConfig = *config
+	}
+	batchConfig.FilePolicy = policy
+	return extractDirectory(root, opts.Recursive, policy, func(paths []string) ([]*ExtractionResult, error) {
+		return BatchExtractFilesSync(paths, batchConfig)
+	})
+}
+
+// extractDirectory walks root and runs extract on the files found.
+func extractDirectory(root string, recursive bool, policy *FilePolicyConfig, extract func([]string) ([]*ExtractionResult, error)) ([]DirectoryResult, error) {
+	walker := &directoryWalker{recursive: recursive, policy: policy, visited: map[string]bool{}}
+	if err := walker.descend(root); err != nil {
+		return nil, err
+	}
+	if len(walker.files) == 0 {
+		return walker.entries, nil
+	}
+	results, err := extract(walker.files)
+	if err != nil {
+		return nil, err
+	}
+	for i, result := range results {
+		walker.entries[walker.fileIndex[i]].Result = result
+	}
+	return walker.entries, nil
+}
+
+// directoryWalker lists the entries of a directory tree for ExtractDirectory. Files are
+// left for the batch to apply the policy to; directories are handled as they are met.
+type directoryWalker struct {
+	recursive bool
+	policy    *FilePolicyConfig
+	// visited holds the resolved paths of the directories entered, so that links cannot
+	// lead into a cycle.
+	visited   map[string]bool
+	entries   []DirectoryResult
+	files     []string
+	fileIndex []int
+}
+
+func (w *directoryWalker) descend(dir string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return newIOErrorWithContext(fmt.Sprintf("failed to resolve directory %s", dir), err, ErrorCodeIo, nil)
+	}
+	if w.visited[resolved] {
+		w.skip(dir, "directory already visited")
+		return nil
+	}
+	w.visited[resolved] = true
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return newIOErrorWithContext(fmt.Sprintf("failed to read directory %s", dir), err, ErrorCodeIo, nil)
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		isDir := entry.IsDir()
+		if entry.Type()&fs.ModeSymlink != 0 {
+			if target, err := os.Stat(path); err == nil && target.IsDir() {
+				switch w.policy.Symlinks {
+				case FilePolicySkip:
+					w.skip(path, "symbolic link")
+					continue
+				case FilePolicyError:
+					return newValidationErrorWithContext(fmt.Sprintf("symbolic link not allowed: %s", path), nil, ErrorCodeValidation, nil)
+				}
+				isDir = true
+			}
+		}
+		if !isDir {
+			w.fileIndex = append(w.fileIndex, len(w.entries))
+			w.files = append(w.files, path)
+			w.entries = append(w.entries, DirectoryResult{Path: path})
+			continue
+		}
+		if w.recursive {
+			if err := w.descend(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (w *directoryWalker) skip(path, reason string) {
+	w.entries = append(w.entries, DirectoryResult{Path: path, Result: failedResult(skippedErrorType, fmt.Sprintf("skipped %s: %s", path, reason))})
+}
+
+// failedResult builds the error result reported for a document of a batch that was not
+// extracted.
+func failedResult(errorType, message string) *ExtractionResult {
+	return &ExtractionResult{
+		Content:  "Error: " + message,
+		MimeType: "text/plain",
+		Metadata: Metadata{Error: &ErrorMetadata{ErrorType: errorType, Message: message}},
+	}
+}
@@ -0,0 +1,166 @@
+package kreuzberg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExtractWithFilePolicy(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.txt")
+	large := filepath.Join(dir, "large.txt")
+	if err := os.WriteFile(small, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, make([]byte, 2048), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(small, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	var extracted []string
+	extract := func(paths []string) ([]*ExtractionResult, error) {
+		extracted = paths
+		results := make([]*ExtractionResult, len(paths))
+		for i := range paths {
+			results[i] = &ExtractionResult{Content: "ok"}
+		}
+		return results, nil
+	}
+
+	cfg := NewFilePolicyConfig(WithSymlinkPolicy(FilePolicySkip), WithMaxFileSize(1024))
+	results, err := extractWithFilePolicy([]string{small, link, large}, cfg, extract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(extracted) != 1 || extracted[0] != small || results[0].Content != "ok" {
+		t.Fatalf("expected only the small file to be extracted, got %v", extracted)
+	}
+	for i, reason := range map[int]string{1: "symbolic link", 2: "exceeds limit"} {
+		meta := results[i].Metadata.Error
+		if meta == nil || meta.ErrorType != skippedErrorType || !strings.Contains(meta.Message, reason) {
+			t.Fatalf("expected result %d to be skipped for %q, got %+v", i, reason, meta)
+		}
+	}
+
+	if _, err := extractWithFilePolicy([]string{link}, NewFilePolicyConfig(WithSymlinkPolicy(FilePolicyError)), extract); err == nil {
+		t.Fatalf("expected the error policy to fail on a symlink")
+	}
+	if _, err := extractWithFilePolicy([]string{link}, NewFilePolicyConfig(), extract); err != nil || extracted[0] != link {
+		t.Fatalf("expected symlinks to be followed by default, got %v (%v)", extracted, err)
+	}
+}
+
+func TestFilePolicySkipsSpecialFiles(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no device files on Windows")
+	}
+	reason, err := fileSkipReason(os.DevNull, NewFilePolicyConfig())
+	if err != nil || !strings.HasPrefix(reason, "special file") {
+		t.Fatalf("expected %s to be skipped as a special file, got %q (%v)", os.DevNull, reason, err)
+	}
+	if _, err := fileSkipReason(os.DevNull, NewFilePolicyConfig(WithSpecialFilePolicy(FilePolicyError))); err == nil {
+		t.Fatalf("expected the error policy to reject %s", os.DevNull)
+	}
+}
+
+func TestFilePolicyValidation(t *testing.T) {
+	for _, cfg := range []*FilePolicyConfig{
+		NewFilePolicyConfig(WithSymlinkPolicy("ignore")),
+		NewFilePolicyConfig(WithSpecialFilePolicy(FilePolicyFollow)),
+		NewFilePolicyConfig(WithMaxFileSize(-1)),
+		NewFilePolicyConfig(WithSparseFilePolicy(FilePolicyFollow)),
+	} {
+		_, err := BatchExtractFilesSync([]string{"a.pdf"}, &ExtractionConfig{FilePolicy: cfg})
+		var validation *ValidationError
+		if !errors.As(err, &validation) {
+			t.Errorf("expected ValidationError for %+v, got %v", cfg, err)
+		}
+	}
+}
+
+func TestFilePolicySparseFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sparse.img")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := file.Truncate(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	if !fileHasHoles(path, 1<<20) {
+		t.Skip("file system does not report holes")
+	}
+
+	if reason, err := fileSkipReason(path, NewFilePolicyConfig()); err != nil || reason != "" {
+		t.Fatalf("expected sparse files to be extracted by default, got %q (%v)", reason, err)
+	}
+	if reason, err := fileSkipReason(path, NewFilePolicyConfig(WithSparseFilePolicy(FilePolicySkip))); err != nil || reason != "sparse file" {
+		t.Fatalf("expected the sparse file to be skipped, got %q (%v)", reason, err)
+	}
+	if _, err := fileSkipReason(path, NewFilePolicyConfig(WithSparseFilePolicy(FilePolicyError))); err == nil {
+		t.Fatalf("expected the error policy to reject the sparse file")
+	}
+}
+
+func TestExtractDirectory(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "sub/b.txt"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(root, filepath.Join(root, "sub", "loop")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	extract := func(paths []string) ([]*ExtractionResult, error) {
+		results := make([]*ExtractionResult, len(paths))
+		for i, path := range paths {
+			results[i] = &ExtractionResult{Content: filepath.Base(path)}
+		}
+		return results, nil
+	}
+	summary := func(entries []DirectoryResult) []string {
+		var lines []string
+		for _, entry := range entries {
+			rel, _ := filepath.Rel(root, entry.Path)
+			if meta := entry.Result.Metadata.Error; meta != nil {
+				rel += " skipped: " + strings.TrimPrefix(meta.Message, "skipped "+entry.Path+": ")
+			}
+			lines = append(lines, rel)
+		}
+		return lines
+	}
+
+	entries, err := extractDirectory(root, false, NewFilePolicyConfig(), extract)
+	if err != nil || !slices.Equal(summary(entries), []string{"a.txt"}) {
+		t.Fatalf("expected only the top-level file, got %v (%v)", summary(entries), err)
+	}
+	entries, err = extractDirectory(root, true, NewFilePolicyConfig(), extract)
+	if want := []string{"a.txt", "sub/b.txt", "sub/loop skipped: directory already visited"}; err != nil || !slices.Equal(summary(entries), want) {
+		t.Fatalf("got %v (%v), want %v", summary(entries), err, want)
+	}
+	if entries[1].Result.Content != "b.txt" {
+		t.Fatalf("results out of order: %+v", entries[1].Result)
+	}
+	entries, err = extractDirectory(root, true, NewFilePolicyConfig(WithSymlinkPolicy(FilePolicySkip)), extract)
+	if want := []string{"a.txt", "sub/b.txt", "sub/loop skipped: symbolic link"}; err != nil || !slices.Equal(summary(entries), want) {
+		t.Fatalf("got %v (%v), want %v", summary(entries), err, want)
+	}
+	if _, err := extractDirectory(root, true, NewFilePolicyConfig(WithSymlinkPolicy(FilePolicyError)), extract); err == nil {
+		t.Fatalf("expected the error policy to fail on the linked directory")
+	}
+}
@@ -0,0 +1,20 @@
+//go:build linux
+
+package kreuzberg
+
+import "os"
+
+// seekHole is SEEK_HOLE, the lseek whence finding the next hole at or after an offset.
+const seekHole = 4
+
+// fileHasHoles reports whether the file at path, of size bytes, has a hole before its
+// end. File systems without hole support report the end of the file as the only hole.
+func fileHasHoles(path string, size int64) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	hole, err := file.Seek(0, seekHole)
+	return err == nil && hole < size
+}
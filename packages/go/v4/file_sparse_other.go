@@ -0,0 +1,8 @@
+//go:build !linux
+
+package kreuzberg
+
+// fileHasHoles reports whether the file at path has holes. Holes are only found on Linux.
+func fileHasHoles(path string, size int64) bool {
+	return false
+}
@@ -0,0 +1,132 @@
+package kreuzberg
+
+import (
+	"slices"
+	"strings"
+)
+
+// MimeTypeFixedWidth selects fixed-width report parsing in ExtractBytesSync. Fixed-width
+// text has no reliable signature, so it is never detected and must be requested.
+const MimeTypeFixedWidth = "text/x-fixed-width"
+
+// FixedWidthColumn is a column of a fixed-width report. Start and End are rune offsets
+// within a line, End exclusive; an End of 0 extends the column to the end of the line.
+type FixedWidthColumn struct {
+	Name  string `json:"name"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// InferFixedWidthColumns infers the columns of a fixed-width report from the positions
+// that are blank on every line. The first line names the columns; separator lines of
+// dashes or equals signs are ignored. Each column extends to the start of the next so
+// values that overflow into the gutter are kept. It returns nil when fewer than two
+// columns are found.
+func InferFixedWidthColumns(text string) []FixedWidthColumn {
+	lines := fixedWidthLines(text)
+	if len(lines) < 2 {
+		return nil
+	}
+	width := 0
+	for _, line := range lines {
+		width = max(width, len(line))
+	}
+	blank := make([]bool, width)
+	for i := range blank {
+		blank[i] = true
+	}
+	for _, line := range lines {
+		for i, r := range line {
+			if r != ' ' {
+				blank[i] = false
+			}
+		}
+	}
+
+	var starts []int
+	for i := range width {
+		if !blank[i] && (i == 0 || blank[i-1]) {
+			starts = append(starts, i)
+		}
+	}
+	if len(starts) < 2 {
+		return nil
+	}
+	columns := make([]FixedWidthColumn, len(starts))
+	for i, start := range starts {
+		columns[i] = FixedWidthColumn{Start: start}
+		if i+1 < len(starts) {
+			columns[i].End = starts[i+1]
+		}
+		columns[i].Name = fixedWidthCell(lines[0], columns[i])
+	}
+	return columns
+}
+
+// ParseFixedWidth splits the lines of a fixed-width report into a table using columns.
+// The column names form the header row; a line repeating the header, such as a page
+// header in a paginated report, is skipped.
+func ParseFixedWidth(text string, columns []FixedWidthColumn) Table {
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = column.Name
+	}
+	cells := [][]string{header}
+	for _, line := range fixedWidthLines(text) {
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = fixedWidthCell(line, column)
+		}
+		if slices.Equal(row, header) {
+			continue
+		}
+		cells = append(cells, row)
+	}
+	return Table{Cells: cells, Markdown: tableMarkdown(cells)}
+}
+
+// extractFixedWidth keeps the report text as Content and adds the inferred table.
+func extractFixedWidth(data []byte) *ExtractionResult {
+	text := strings.ToValidUTF8(string(data), "�")
+	result := &ExtractionResult{Content: text, MimeType: MimeTypeFixedWidth, Success: true}
+	if columns := InferFixedWidthColumns(text); columns != nil {
+		result.Tables = []Table{ParseFixedWidth(text, columns)}
+	}
+	return result
+}
+
+// fixedWidthLines returns the non-blank, non-separator lines of text as runes, with
+// tabs expanded to eight-column stops.
+func fixedWidthLines(text string) [][]rune {
+	var lines [][]rune
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r\f")
+		if line == "" || strings.Trim(line, "-=+ ") == "" {
+			continue
+		}
+		var runes []rune
+		for _, r := range line {
+			if r == '\t' {
+				runes = append(runes, []rune(strings.Repeat(" ", 8-len(runes)%8))...)
+				continue
+			}
+			runes = append(runes, r)
+		}
+		lines = append(lines, runes)
+	}
+	return lines
+}
+
+func fixedWidthCell(line []rune, column FixedWidthColumn) string {
+	if column.Start >= len(line) {
+		return ""
+	}
+	end := len(line)
+	if column.End > 0 {
+		end = min(end, column.End)
+	}
+	if end <= column.Start {
+		return ""
+	}
+	return strings.TrimSpace(string(line[column.Start:end]))
+}
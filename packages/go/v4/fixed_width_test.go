@@ -0,0 +1,49 @@
+package kreuzberg
+
+import (
+	"testing"
+)
+
+const testFixedWidthReport = "ACCOUNT   NAME            BALANCE\n" +
+	"--------  --------------  -------\n" +
+	"10001     Acme Corp        1250.00\n" +
+	"10002     Globex            -75.10\n" +
+	"\n" +
+	"ACCOUNT   NAME            BALANCE\n" +
+	"10003     Initech         13000.5\n"
+
+func TestInferFixedWidthColumns(t *testing.T) {
+	columns := InferFixedWidthColumns(testFixedWidthReport)
+	want := []FixedWidthColumn{
+		{Name: "ACCOUNT", Start: 0, End: 10},
+		{Name: "NAME", Start: 10, End: 26},
+		{Name: "BALANCE", Start: 26, End: 0},
+	}
+	if len(columns) != len(want) {
+		t.Fatalf("expected %d columns, got %+v", len(want), columns)
+	}
+	for i := range want {
+		if columns[i] != want[i] {
+			t.Errorf("column %d = %+v, want %+v", i, columns[i], want[i])
+		}
+	}
+}
+
+func TestParseFixedWidthSkipsRepeatedHeaders(t *testing.T) {
+	table := ParseFixedWidth(testFixedWidthReport, InferFixedWidthColumns(testFixedWidthReport))
+	if len(table.Cells) != 4 {
+		t.Fatalf("expected header and 3 rows, got %+v", table.Cells)
+	}
+	if row := table.Cells[2]; row[1] != "Globex" || row[2] != "-75.10" {
+		t.Fatalf("unexpected row: %q", row)
+	}
+	if table.Markdown == "" {
+		t.Fatal("expected markdown")
+	}
+}
+
+func TestInferFixedWidthColumnsNeedsColumns(t *testing.T) {
+	if columns := InferFixedWidthColumns("just one line of prose"); columns != nil {
+		t.Fatalf("expected no columns, got %+v", columns)
+	}
+}
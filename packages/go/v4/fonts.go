@@ -0,0 +1,365 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Warnings reported in FontInfo.Warnings.
+const (
+	// FontWarningNotEmbedded is a PDF font neither embedded nor one of the standard 14,
+	// which viewers and the extractor replace with a substitute.
+	FontWarningNotEmbedded = "not_embedded"
+	// FontWarningNoUnicode is a PDF font without a ToUnicode map whose glyphs cannot be
+	// mapped to characters, a frequent cause of garbled text.
+	FontWarningNoUnicode = "no_unicode"
+	// FontWarningMissingGlyphs is a Word font set on text in scripts its declared Unicode
+	// ranges do not cover, which Word renders with a fallback font.
+	FontWarningMissingGlyphs = "missing_glyphs"
+)
+
+// FontInfo describes a font of a PDF or Word document. Type is the PDF font type, such
+// as "TrueType" or "Type0", and is empty for Word fonts. Subset fonts embed only the
+// glyphs the document uses. Pages lists the PDF pages using the font.
+type FontInfo struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type,omitempty"`
+	Embedded bool     `json:"embedded"`
+	Subset   bool     `json:"subset,omitempty"`
+	Pages    []uint64 `json:"pages,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// pdfStandardFonts are the standard 14 fonts every PDF viewer provides, by base name and
+// common aliases.
+var pdfStandardFonts = map[string]bool{
+	"Courier": true, "Courier-Bold": true, "Courier-Oblique": true, "Courier-BoldOblique": true,
+	"Helvetica": true, "Helvetica-Bold": true, "Helvetica-Oblique": true, "Helvetica-BoldOblique": true,
+	"Times-Roman": true, "Times-Bold": true, "Times-Italic": true, "Times-BoldItalic": true,
+	"Symbol": true, "ZapfDingbats": true,
+	"Arial": true, "Arial,Bold": true, "Arial,Italic": true, "Arial,BoldItalic": true,
+	"TimesNewRoman": true, "TimesNewRoman,Bold": true, "TimesNewRoman,Italic": true, "TimesNewRoman,BoldItalic": true,
+	"CourierNew": true, "CourierNew,Bold": true, "CourierNew,Italic": true, "CourierNew,BoldItalic": true,
+}
+
+// pdfSymbolicFlag is the Symbolic bit of a font descriptor's Flags.
+const pdfSymbolicFlag = 4
+
+// applyFontStage lists the fonts of PDF and Word documents in Metadata.Fonts when
+// ExtractionConfig.FontInventory is set. read returns the original document. Like the
+// other stages that read the original, it never fails the extraction.
+func applyFontStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.FontInventory == nil || !*config.FontInventory {
+		return
+	}
+	if result.MimeType != "application/pdf" && result.MimeType != docxMimeType {
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	if result.MimeType == docxMimeType {
+		result.Metadata.Fonts = docxFonts(data)
+	} else {
+		result.Metadata.Fonts = pdfFonts(data)
+	}
+}
+
+// splitSubsetPrefix removes the tag of a subset font name, six capitals and a plus sign
+// as in "ABCDEF+Garamond".
+func splitSubsetPrefix(name string) (string, bool) {
+	if len(name) < 8 || name[6] != '+' {
+		return name, false
+	}
+	for _, c := range name[:6] {
+		if c < 'A' || c > 'Z' {
+			return name, false
+		}
+	}
+	return name[7:], true
+}
+
+// pdfFonts lists the fonts of the pages of a PDF, from their resources and those of the
+// form XObjects they draw. Fonts with the same name, type, embedding and subsetting are
+// listed once with all their pages.
+func pdfFonts(data []byte) []FontInfo {
+	if !isPDF(data) {
+		return nil
+	}
+	objects := readPDFObjects(data)
+	catalog := objects.catalog()
+	if catalog == nil {
+		return nil
+	}
+	var fonts []FontInfo
+	index := map[string]int{}
+	for i, page := range objects.pageRefs(catalog) {
+		number := uint64(i + 1)
+		resources := []pdfDict{objects.dict(objects.inherited(page, "Resources"))}
+		xobjects := objects.dict(resources[0]["XObject"])
+		for _, name := range slices.Sorted(maps.Keys(xobjects)) {
+			if form := objects.dict(xobjects[name]); form["Subtype"] == pdfName("Form") {
+				resources = append(resources, objects.dict(form["Resources"]))
+			}
+		}
+		for _, resource := range resources {
+			fontResources := objects.dict(resource["Font"])
+			for _, name := range slices.Sorted(maps.Keys(fontResources)) {
+				font := pdfFontInfo(objects, objects.dict(fontResources[name]))
+				if font.Name == "" {
+					continue
+				}
+				key := font.Name + "\x00" + font.Type + "\x00" + strconv.FormatBool(font.Embedded) + strconv.FormatBool(font.Subset)
+				at, ok := index[key]
+				if !ok {
+					at = len(fonts)
+					index[key] = at
+					fonts = append(fonts, font)
+				}
+				if pages := fonts[at].Pages; len(pages) == 0 || pages[len(pages)-1] != number {
+					fonts[at].Pages = append(pages, number)
+				}
+			}
+		}
+	}
+	return fonts
+}
+
+// pdfFontInfo describes a font dictionary. The descriptor of a composite (Type0) font is
+// that of its descendant font.
+func pdfFontInfo(objects pdfObjects, font pdfDict) FontInfo {
+	baseFont, _ := objects.resolve(font["BaseFont"]).(pdfName)
+	subtype, _ := objects.resolve(font["Subtype"]).(pdfName)
+	info := FontInfo{Type: string(subtype)}
+	info.Name, info.Subset = splitSubsetPrefix(string(baseFont))
+	if subtype == "Type3" && info.Name == "" {
+		info.Name = "Type3"
+	}
+
+	descriptor := objects.dict(font["FontDescriptor"])
+	if descendants, ok := objects.resolve(font["DescendantFonts"]).([]any); ok && len(descendants) > 0 {
+		descriptor = objects.dict(objects.dict(descendants[0])["FontDescriptor"])
+	}
+	for _, key := range []string{"FontFile", "FontFile2", "FontFile3"} {
+		if descriptor[key] != nil {
+			info.Embedded = true
+		}
+	}
+	// Type 3 glyphs are drawn by the document itself.
+	if subtype == "Type3" {
+		info.Embedded = true
+	}
+	if !info.Embedded && !pdfStandardFonts[info.Name] {
+		info.Warnings = append(info.Warnings, FontWarningNotEmbedded)
+	}
+
+	if font["ToUnicode"] == nil {
+		flags, _ := pdfInt(objects.resolve(descriptor["Flags"]))
+		symbolic := flags&pdfSymbolicFlag != 0 && font["Encoding"] == nil
+		if subtype == "Type0" || subtype == "Type3" || symbolic {
+			info.Warnings = append(info.Warnings, FontWarningNoUnicode)
+		}
+	}
+	return info
+}
+
+// unicodeRangeBits maps blocks of characters to their bit of the OpenType Unicode ranges
+// (OS/2 ulUnicodeRange) that Word records in the w:sig element of a font. Only scripts
+// that fonts commonly lack are listed.
+var unicodeRangeBits = []struct {
+	low, high rune
+	bit       int
+}{
+	{0x0370, 0x03FF, 7},  // Greek
+	{0x0400, 0x052F, 9},  // Cyrillic
+	{0x0530, 0x058F, 10}, // Armenian
+	{0x0590, 0x05FF, 11}, // Hebrew
+	{0x0600, 0x06FF, 13}, // Arabic
+	{0x0900, 0x097F, 15}, // Devanagari
+	{0x0980, 0x09FF, 16}, // Bengali
+	{0x0B80, 0x0BFF, 20}, // Tamil
+	{0x0E00, 0x0E7F, 24}, // Thai
+	{0x10A0, 0x10FF, 26}, // Georgian
+	{0x1100, 0x11FF, 28}, // Hangul Jamo
+	{0x3000, 0x303F, 48}, // CJK Symbols and Punctuation
+	{0x3040, 0x309F, 49}, // Hiragana
+	{0x30A0, 0x30FF, 50}, // Katakana
+	{0x3400, 0x4DBF, 59}, // CJK Unified Ideographs Extension A
+	{0x4E00, 0x9FFF, 59}, // CJK Unified Ideographs
+	{0xAC00, 0xD7AF, 56}, // Hangul Syllables
+}
+
+// docxFont is a font of a Word document's font table.
+type docxFont struct {
+	info      FontInfo
+	usb       [4]uint32
+	hasRanges bool
+	missing   bool
+}
+
+// docxFonts lists the fonts of a Word document's font table, with those embedded in the
+// document and whether they are embedded as subsets. Fonts whose declared Unicode ranges
+// miss characters of text set in them are flagged with FontWarningMissingGlyphs.
+func docxFonts(data []byte) []FontInfo {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	subset := bytes.Contains(docxPart(archive, "word/settings.xml"), []byte("saveSubsetFonts"))
+	fonts := docxFontTable(docxPart(archive, "word/fontTable.xml"))
+	index := map[string]*docxFont{}
+	for i := range fonts {
+		fonts[i].info.Subset = subset && fonts[i].info.Embedded
+		index[fonts[i].info.Name] = &fonts[i]
+	}
+	docxRunFonts(docxPart(archive, "word/document.xml"), func(name, text string) {
+		font := index[name]
+		if font == nil || !font.hasRanges || font.missing {
+			return
+		}
+		for _, r := range text {
+			if bit, ok := unicodeRangeBit(r); ok && font.usb[bit/32]&(1<<(bit%32)) == 0 {
+				font.missing = true
+				return
+			}
+		}
+	})
+
+	infos := make([]FontInfo, 0, len(fonts))
+	for _, font := range fonts {
+		if font.missing {
+			font.info.Warnings = append(font.info.Warnings, FontWarningMissingGlyphs)
+		}
+		infos = append(infos, font.info)
+	}
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// unicodeRangeBit returns the Unicode range bit of r, when it lies in a range Word
+// records.
+func unicodeRangeBit(r rune) (int, bool) {
+	for _, block := range unicodeRangeBits {
+		if r >= block.low && r <= block.high {
+			return block.bit, true
+		}
+	}
+	return 0, false
+}
+
+// docxFontTable reads the fonts of a Word font table part: their names, whether a
+// regular, bold or italic face is embedded, and their w:sig Unicode ranges.
+func docxFontTable(data []byte) []docxFont {
+	var fonts []docxFont
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return fonts
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch name := start.Name.Local; {
+		case name == "font":
+			fonts = append(fonts, docxFont{info: FontInfo{Name: xmlAttr(start, "name")}})
+		case len(fonts) == 0:
+		case strings.HasPrefix(name, "embed") && name != "embedTrueTypeFonts" && name != "embedSystemFonts":
+			fonts[len(fonts)-1].info.Embedded = true
+		case name == "sig":
+			font := &fonts[len(fonts)-1]
+			for i := range font.usb {
+				value, err := strconv.ParseUint(xmlAttr(start, "usb"+strconv.Itoa(i)), 16, 32)
+				if err != nil {
+					break
+				}
+				font.usb[i] = uint32(value)
+				font.hasRanges = true
+			}
+		}
+	}
+}
+
+// docxRunFonts calls fn with the font and text of every run of a Word document part that
+// names its font directly. The font is that set for the run's script: East Asian, complex
+// script or else ASCII.
+func docxRunFonts(data []byte, fn func(font, text string)) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var fonts map[string]string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "r":
+			fonts = nil
+		case "rFonts":
+			fonts = map[string]string{}
+			for _, attr := range start.Attr {
+				fonts[attr.Name.Local] = attr.Value
+			}
+		case "t":
+			var text string
+			if fonts == nil || decoder.DecodeElement(&text, &start) != nil {
+				continue
+			}
+			fn(runFont(fonts, text), text)
+		}
+	}
+}
+
+// runFont picks the font of a run's w:rFonts for its text.
+func runFont(fonts map[string]string, text string) string {
+	for _, r := range text {
+		bit, ok := unicodeRangeBit(r)
+		switch {
+		case !ok:
+		case bit >= 48 && bit <= 61 && fonts["eastAsia"] != "":
+			return fonts["eastAsia"]
+		case bit >= 11 && bit <= 24 && fonts["cs"] != "":
+			return fonts["cs"]
+		}
+	}
+	if fonts["ascii"] != "" {
+		return fonts["ascii"]
+	}
+	return fonts["hAnsi"]
+}
+
+// mergeFonts adds the fonts of a part of a document, whose pages follow pageOffset pages
+// of earlier parts, to those of the merged result.
+func mergeFonts(merged, part []FontInfo, pageOffset uint64) []FontInfo {
+	for _, font := range part {
+		pages := make([]uint64, len(font.Pages))
+		for i, page := range font.Pages {
+			pages[i] = page + pageOffset
+		}
+		found := false
+		for i := range merged {
+			if merged[i].Name == font.Name && merged[i].Type == font.Type &&
+				merged[i].Embedded == font.Embedded && merged[i].Subset == font.Subset {
+				merged[i].Pages = append(merged[i].Pages, pages...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			font.Pages = pages
+			merged = append(merged, font)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,76 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestPDFFonts(t *testing.T) {
+	data := geoTestPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 /Resources << /Font << /F1 5 0 R /F2 6 0 R >> >> >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Page /Parent 2 0 R /Resources << /Font << /F1 5 0 R /F3 7 0 R >> >> >>",
+		"<< /Type /Font /Subtype /TrueType /BaseFont /ABCDEF+Garamond /FontDescriptor 8 0 R /ToUnicode 9 0 R >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica >>",
+		"<< /Type /Font /Subtype /Type0 /BaseFont /MSMincho /DescendantFonts [10 0 R] >>",
+		"<< /Type /FontDescriptor /Flags 32 /FontFile2 11 0 R >>",
+		"<< /Length 0 >>\nstream\n\nendstream",
+		"<< /Type /Font /Subtype /CIDFontType2 /FontDescriptor << /Type /FontDescriptor /Flags 4 >> >>",
+	)
+	want := []FontInfo{
+		{Name: "Garamond", Type: "TrueType", Embedded: true, Subset: true, Pages: []uint64{1, 2}},
+		{Name: "Helvetica", Type: "Type1", Pages: []uint64{1}},
+		{Name: "MSMincho", Type: "Type0", Pages: []uint64{2}, Warnings: []string{FontWarningNotEmbedded, FontWarningNoUnicode}},
+	}
+	got := pdfFonts(data)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("pdfFonts = %+v", got)
+	}
+
+	merged := mergeFonts(got, []FontInfo{{Name: "Helvetica", Type: "Type1", Pages: []uint64{1}}}, 2)
+	if len(merged) != 3 || !reflect.DeepEqual(merged[1].Pages, []uint64{1, 3}) {
+		t.Fatalf("mergeFonts = %+v", merged)
+	}
+}
+
+func TestDOCXFonts(t *testing.T) {
+	fontTable := `<w:fonts xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:font w:name="Calibri"><w:sig w:usb0="E4002EFF" w:usb1="C000247B" w:usb2="00000009" w:usb3="00000000" w:csb0="000001FF" w:csb1="00000000"/></w:font>
+<w:font w:name="Brand Sans"><w:embedRegular r:id="rId1" w:fontKey="{00000000-0000-0000-0000-000000000000}"/><w:sig w:usb0="00000003" w:usb1="00000000" w:usb2="00000000" w:usb3="00000000" w:csb0="00000001" w:csb1="00000000"/></w:font>
+</w:fonts>`
+	document := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri"/></w:rPr><w:t>Привет</w:t></w:r></w:p>
+<w:p><w:r><w:rPr><w:rFonts w:ascii="Brand Sans" w:hAnsi="Brand Sans"/></w:rPr><w:t>Привет</w:t></w:r></w:p>
+</w:body></w:document>`
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"word/fontTable.xml": fontTable,
+		"word/document.xml":  document,
+		"word/settings.xml":  `<w:settings xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:embedTrueTypeFonts/><w:saveSubsetFonts/></w:settings>`,
+	} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []FontInfo{
+		{Name: "Brand Sans", Embedded: true, Subset: true, Warnings: []string{FontWarningMissingGlyphs}},
+		{Name: "Calibri"},
+	}
+	result := &ExtractionResult{MimeType: docxMimeType}
+	applyFontStage(result, func() ([]byte, error) { return buf.Bytes(), nil }, NewExtractionConfig(WithFontInventory(true)))
+	if !reflect.DeepEqual(result.Metadata.Fonts, want) {
+		t.Fatalf("Fonts = %+v", result.Metadata.Fonts)
+	}
+}
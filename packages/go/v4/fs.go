@@ -0,0 +1,52 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdbool.h>
+*/
+import "C"
+
+import (
+	"io/fs"
+	"unsafe"
+)
+
+// ExtractFS extracts the file name from fsys, so documents held in embedded assets, zip
+// archives, or test fixtures can be extracted without touching the OS file system. The
+// MIME type is derived from the file extension, or from the content when the extension
+// is missing or unknown.
+func ExtractFS(fsys fs.FS, name string, config *ExtractionConfig) (*ExtractionResult, error) {
+	if fsys == nil {
+		return nil, newValidationErrorWithContext("file system is required", nil, ErrorCodeValidation, nil)
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read "+name, err, ErrorCodeIo, nil)
+	}
+	mimeType, err := mimeTypeFromName(name)
+	if err != nil {
+		if mimeType, err = DetectMimeType(data); err != nil {
+			return nil, err
+		}
+	}
+	return ExtractBytesSync(data, mimeType, config)
+}
+
+// mimeTypeFromName maps the extension of name to a MIME type without requiring the file
+// to exist.
+func mimeTypeFromName(name string) (string, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_detect_mime_type(cName, C.bool(false))
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return "", lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	return C.GoString(ptr), nil
+}
@@ -0,0 +1,36 @@
+package kreuzberg
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestExtractFSMissingFile(t *testing.T) {
+	fsys := fstest.MapFS{"docs/a.txt": {Data: []byte("hello")}}
+	_, err := ExtractFS(fsys, "docs/missing.txt", nil)
+	var ioErr *IOError
+	if !errors.As(err, &ioErr) || !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected IOError wrapping fs.ErrNotExist, got %v", err)
+	}
+}
+
+func TestExtractFSRequiresFileSystem(t *testing.T) {
+	_, err := ExtractFS(nil, "a.txt", nil)
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
+
+func TestExtractFSText(t *testing.T) {
+	fsys := fstest.MapFS{"docs/notes.txt": {Data: []byte("Quarterly review notes")}}
+	result, err := ExtractFS(fsys, "docs/notes.txt", nil)
+	if err != nil {
+		t.Fatalf("ExtractFS failed: %v", err)
+	}
+	if result.MimeType != "text/plain" || result.Content != "Quarterly review notes" {
+		t.Fatalf("unexpected result %q (%s)", result.Content, result.MimeType)
+	}
+}
@@ -0,0 +1,156 @@
+package kreuzberg
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ErrFuzzLimit is wrapped by the ValidationError returned when ExtractBytesFuzz rejects an
+// input or a result for exceeding its FuzzLimits. Test for it with errors.Is; such inputs
+// are expensive, not crashes.
+var ErrFuzzLimit = errors.New("kreuzberg: fuzz limit exceeded")
+
+// FuzzLimits bounds the resources ExtractBytesFuzz spends on one input. Zero values use
+// the defaults.
+type FuzzLimits struct {
+	// MaxInputBytes rejects larger inputs before extraction. Default: 1 MiB.
+	MaxInputBytes int
+	// MaxContentBytes caps the size of the extracted content. Default: 16 MiB.
+	MaxContentBytes int
+	// MaxElements caps the number of chunks, pages, tables, images and elements of the
+	// result, each counted separately. Default: 10000.
+	MaxElements int
+	// Timeout caps the duration of an extraction. Native extraction cannot be interrupted,
+	// so the limit is checked once the extraction returns. Default: 10s.
+	Timeout time.Duration
+}
+
+func (l FuzzLimits) withDefaults() FuzzLimits {
+	if l.MaxInputBytes <= 0 {
+		l.MaxInputBytes = 1 << 20
+	}
+	if l.MaxContentBytes <= 0 {
+		l.MaxContentBytes = 16 << 20
+	}
+	if l.MaxElements <= 0 {
+		l.MaxElements = 10000
+	}
+	if l.Timeout <= 0 {
+		l.Timeout = 10 * time.Second
+	}
+	return l
+}
+
+// ExtractBytesFuzz extracts data like ExtractBytesSync for fuzzing campaigns (go test
+// -fuzz, go-fuzz, OSS-Fuzz). An empty mimeType is detected from data.
+//
+// Every input either succeeds or fails with an error: panics in the binding's own parsers
+// are recovered, and panics in the core library are trapped at the FFI boundary, both
+// surfacing as errors for which IsPanic reports true. A harness should report those, and
+// errors wrapping ErrFuzzLimit if it hunts for resource exhaustion, and ignore the others,
+// which are the expected outcome of malformed input.
+//
+// The extraction runs without the result cache, retries or quarantine, so that inputs do
+// not affect each other or the file system.
+func ExtractBytesFuzz(data []byte, mimeType string, config *ExtractionConfig, limits FuzzLimits) (result *ExtractionResult, err error) {
+	limits = limits.withDefaults()
+	if len(data) > limits.MaxInputBytes {
+		return nil, fuzzLimitError("input of %d bytes exceeds MaxInputBytes (%d)", len(data), limits.MaxInputBytes)
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			result, err = nil, newPanicError(recovered)
+		}
+	}()
+
+	if mimeType == "" {
+		if mimeType, err = DetectMimeType(data); err != nil {
+			return nil, err
+		}
+	}
+	start := time.Now()
+	result, err = ExtractBytesSync(data, mimeType, fuzzConfig(config))
+	if elapsed := time.Since(start); elapsed > limits.Timeout {
+		return nil, fuzzLimitError("extraction took %s, more than Timeout (%s)", elapsed, limits.Timeout)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFuzzResult(result, limits); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// IsPanic reports whether err records a panic, in the core library or in the binding,
+// rather than an extraction failure. Its PanicCtx locates the panic.
+func IsPanic(err error) bool {
+	var kerr KreuzbergError
+	return errors.As(err, &kerr) && kerr.PanicCtx() != nil
+}
+
+// fuzzConfig returns a copy of config without the stages that persist state across
+// extractions.
+func fuzzConfig(config *ExtractionConfig) *ExtractionConfig {
+	fuzz := &ExtractionConfig{}
+	if config != nil {
+		*fuzz = *config
+	}
+	fuzz.UseCache = BoolPtr(false)
+	fuzz.Retry = nil
+	fuzz.Quarantine = nil
+	return fuzz
+}
+
+func checkFuzzResult(result *ExtractionResult, limits FuzzLimits) error {
+	if len(result.Content) > limits.MaxContentBytes {
+		return fuzzLimitError("content of %d bytes exceeds MaxContentBytes (%d)", len(result.Content), limits.MaxContentBytes)
+	}
+	counts := []struct {
+		name  string
+		count int
+	}{
+		{"chunks", len(result.Chunks)},
+		{"pages", len(result.Pages)},
+		{"tables", len(result.Tables)},
+		{"images", len(result.Images)},
+		{"elements", len(result.Elements)},
+	}
+	for _, c := range counts {
+		if c.count > limits.MaxElements {
+			return fuzzLimitError("%d %s exceed MaxElements (%d)", c.count, c.name, limits.MaxElements)
+		}
+	}
+	return nil
+}
+
+func fuzzLimitError(format string, args ...any) error {
+	return newValidationErrorWithContext(fmt.Sprintf(format, args...), ErrFuzzLimit, ErrorCodeValidation, nil)
+}
+
+// newPanicError converts a value recovered from a panic into a RuntimeError whose
+// PanicContext locates the panic and holds the stack of the goroutine. It must be called
+// from the deferred function that recovered the value.
+func newPanicError(recovered any) error {
+	message := fmt.Sprint(recovered)
+	panicCtx := &PanicContext{Message: message, TimestampSec: time.Now().Unix(), Backtrace: string(debug.Stack())}
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") {
+			panicCtx.File, panicCtx.Line, panicCtx.Function = frame.File, frame.Line, frame.Function
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	cause, _ := recovered.(error)
+	return newRuntimeErrorWithContext("panic during extraction: "+message, cause, ErrorCodeInternal, panicCtx)
+}
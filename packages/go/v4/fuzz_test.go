@@ -0,0 +1,76 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExtractBytesFuzzRejectsLargeInput(t *testing.T) {
+	_, err := ExtractBytesFuzz(make([]byte, 2048), "text/plain", nil, FuzzLimits{MaxInputBytes: 1024})
+	if !errors.Is(err, ErrFuzzLimit) {
+		t.Fatalf("expected ErrFuzzLimit, got %v", err)
+	}
+	if IsPanic(err) {
+		t.Fatalf("limit error reported as a panic: %v", err)
+	}
+}
+
+func TestCheckFuzzResultLimits(t *testing.T) {
+	limits := FuzzLimits{MaxContentBytes: 4, MaxElements: 1}.withDefaults()
+	if err := checkFuzzResult(&ExtractionResult{Content: "abcd", Pages: make([]PageContent, 1)}, limits); err != nil {
+		t.Fatalf("result within limits rejected: %v", err)
+	}
+	if err := checkFuzzResult(&ExtractionResult{Content: "abcde"}, limits); !errors.Is(err, ErrFuzzLimit) {
+		t.Fatalf("expected ErrFuzzLimit for content, got %v", err)
+	}
+	if err := checkFuzzResult(&ExtractionResult{Tables: make([]Table, 2)}, limits); !errors.Is(err, ErrFuzzLimit) {
+		t.Fatalf("expected ErrFuzzLimit for tables, got %v", err)
+	}
+}
+
+func TestFuzzConfigDisablesStatefulStages(t *testing.T) {
+	config := &ExtractionConfig{UseCache: BoolPtr(true), Retry: &RetryConfig{}, Quarantine: &QuarantineConfig{}, OutputFormat: "markdown"}
+	fuzz := fuzzConfig(config)
+	if *fuzz.UseCache || fuzz.Retry != nil || fuzz.Quarantine != nil || fuzz.OutputFormat != "markdown" {
+		t.Fatalf("unexpected fuzz config: %+v", fuzz)
+	}
+	if !*config.UseCache || config.Retry == nil {
+		t.Fatal("fuzzConfig modified its argument")
+	}
+}
+
+func TestNewPanicErrorLocatesPanic(t *testing.T) {
+	err := func() (err error) {
+		defer func() { err = newPanicError(recover()) }()
+		var pages []PageContent
+		_ = pages[3]
+		return nil
+	}()
+	if !IsPanic(err) {
+		t.Fatalf("expected a panic error, got %v", err)
+	}
+	var runtimeErr *RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Code() != ErrorCodeInternal {
+		t.Fatalf("expected an internal RuntimeError, got %#v", err)
+	}
+	ctx := runtimeErr.PanicCtx()
+	if !strings.Contains(ctx.Function, "TestNewPanicErrorLocatesPanic") || !strings.HasSuffix(ctx.File, "fuzz_test.go") {
+		t.Fatalf("panic not located in the test: %+v", ctx)
+	}
+	if !strings.Contains(ctx.Message, "index out of range") {
+		t.Fatalf("unexpected panic message %q", ctx.Message)
+	}
+}
+
+func FuzzExtractBytes(f *testing.F) {
+	f.Add([]byte("plain text"), "text/plain")
+	f.Add([]byte("<html><body><p>Hello</p></body></html>"), "text/html")
+	f.Add([]byte("From: a@example.com\r\nSubject: hi\r\n\r\nbody"), "message/rfc822")
+	f.Add([]byte("%PDF-1.7\n"), "application/pdf")
+	f.Fuzz(func(t *testing.T, data []byte, mimeType string) {
+		if _, err := ExtractBytesFuzz(data, mimeType, nil, FuzzLimits{}); IsPanic(err) {
+			t.Fatalf("extraction panicked: %v (%s)", err, err.(KreuzbergError).PanicCtx())
+		}
+	})
+}
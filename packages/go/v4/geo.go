@@ -0,0 +1,373 @@
+package kreuzberg
+
+import (
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Encodings of geospatial registration reported in GeoMetadata.Encoding.
+const (
+	// GeoEncodingGeoTIFF is the GeoTIFF tag and key set.
+	GeoEncodingGeoTIFF = "geotiff"
+	// GeoEncodingGeoPDF is the ISO 32000 geospatial measure dictionary.
+	GeoEncodingGeoPDF = "geopdf"
+	// GeoEncodingLGI is the OGC GeoPDF best practice encoding (LGIDict).
+	GeoEncodingLGI = "lgi"
+)
+
+// GeoMetadata describes the geospatial registration of a GeoTIFF image or a GeoPDF
+// map. CRS is the coordinate reference system as "EPSG:<code>" when one is given.
+// Bounds is in Units: the CRS's units for GeoTIFF, and longitude and latitude in
+// degrees for GeoPDF, whose registration points are always geographic.
+type GeoMetadata struct {
+	Encoding  string     `json:"encoding"`
+	CRS       string     `json:"crs,omitempty"`
+	CRSName   string     `json:"crs_name,omitempty"`
+	ModelType string     `json:"model_type,omitempty"`
+	Units     string     `json:"units,omitempty"`
+	WKT       string     `json:"wkt,omitempty"`
+	Bounds    *GeoBounds `json:"bounds,omitempty"`
+}
+
+// GeoBounds is an axis-aligned bounding box; X is easting or longitude, Y northing or
+// latitude.
+type GeoBounds struct {
+	MinX float64 `json:"min_x"`
+	MinY float64 `json:"min_y"`
+	MaxX float64 `json:"max_x"`
+	MaxY float64 `json:"max_y"`
+}
+
+// extend grows b to include the point (x, y).
+func (b *GeoBounds) extend(x, y float64) {
+	b.MinX, b.MaxX = math.Min(b.MinX, x), math.Max(b.MaxX, x)
+	b.MinY, b.MaxY = math.Min(b.MinY, y), math.Max(b.MaxY, y)
+}
+
+func newGeoBounds(x, y float64) *GeoBounds {
+	return &GeoBounds{MinX: x, MinY: y, MaxX: x, MaxY: y}
+}
+
+// ParseGeoMetadata reads the geospatial registration of a GeoTIFF or GeoPDF file. It
+// returns nil when data is neither or carries no registration.
+func ParseGeoMetadata(data []byte) *GeoMetadata {
+	if isPDF(data) {
+		return geoPDFMetadata(readPDFObjects(data))
+	}
+	if meta, err := geoTIFFMetadata(data); err == nil {
+		return meta
+	}
+	return nil
+}
+
+// applyGeoStage adds the geospatial registration of PDF and TIFF documents to their
+// metadata when ExtractionConfig.GeoMetadata is set. read returns the original document.
+// Like meeting detection in emails, it is a best-effort addition that never fails the
+// extraction.
+func applyGeoStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.GeoMetadata == nil || !*config.GeoMetadata {
+		return
+	}
+	if result.Metadata.Error != nil {
+		return
+	}
+	switch result.MimeType {
+	case "application/pdf", "image/tiff":
+	default:
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	result.Metadata.Geo = ParseGeoMetadata(data)
+}
+
+// GeoTIFF tags and keys.
+const (
+	tiffImageWidth          = 256
+	tiffImageLength         = 257
+	geoTIFFPixelScale       = 33550
+	geoTIFFTiepoint         = 33922
+	geoTIFFTransformation   = 34264
+	geoTIFFKeyDirectory     = 34735
+	geoTIFFDoubleParams     = 34736
+	geoTIFFASCIIParams      = 34737
+	geoKeyModelType         = 1024
+	geoKeyRasterType        = 1025
+	geoKeyCitation          = 1026
+	geoKeyGeographicType    = 2048
+	geoKeyGeogCitation      = 2049
+	geoKeyGeogAngularUnits  = 2054
+	geoKeyProjectedCSType   = 3072
+	geoKeyPCSCitation       = 3073
+	geoKeyProjLinearUnits   = 3076
+	geoKeyUserDefined       = 32767
+	geoRasterPixelIsPoint   = 2
+	maxTIFFDirectoryEntries = 4096
+)
+
+var geoModelTypes = map[int]string{1: "projected", 2: "geographic", 3: "geocentric"}
+
+var geoUnits = map[int]string{
+	9001: "metre", 9002: "foot", 9003: "us_survey_foot", 9101: "radian", 9102: "degree",
+}
+
+// tiffField is a directory entry's values, as numbers or text.
+type tiffField struct {
+	numbers []float64
+	text    string
+}
+
+// readTIFFDirectory reads the fields of the first image file directory.
+func readTIFFDirectory(data []byte) (map[int]tiffField, error) {
+	order, ok := tiffByteOrder(data)
+	if !ok {
+		return nil, fmt.Errorf("not a TIFF file")
+	}
+	return readTIFFDirectoryAt(data, order, int(order.Uint32(data[4:])))
+}
+
+// tiffByteOrder reads the byte order of a TIFF header.
+func tiffByteOrder(data []byte) (binary.ByteOrder, bool) {
+	if len(data) < 8 {
+		return nil, false
+	}
+	switch string(data[:4]) {
+	case "II*\x00":
+		return binary.LittleEndian, true
+	case "MM\x00*":
+		return binary.BigEndian, true
+	}
+	return nil, false
+}
+
+// readTIFFDirectoryAt reads the fields of the image file directory at offset. Rationals
+// are read as their quotient.
+func readTIFFDirectoryAt(data []byte, order binary.ByteOrder, offset int) (map[int]tiffField, error) {
+	if offset < 8 || offset+2 > len(data) {
+		return nil, fmt.Errorf("invalid directory offset %d", offset)
+	}
+	count := int(order.Uint16(data[offset:]))
+	if count > maxTIFFDirectoryEntries || offset+2+12*count > len(data) {
+		return nil, fmt.Errorf("invalid directory size %d", count)
+	}
+	fields := map[int]tiffField{}
+	for i := range count {
+		entry := data[offset+2+12*i:]
+		tag, kind, n := int(order.Uint16(entry)), order.Uint16(entry[2:]), int(order.Uint32(entry[4:]))
+		size := map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 10: 8, 11: 4, 12: 8, 16: 8}[kind]
+		if size == 0 || n < 0 || n > len(data)/size {
+			continue
+		}
+		value := entry[8:12]
+		if size*n > 4 {
+			start := int(order.Uint32(entry[8:]))
+			if start < 0 || start+size*n > len(data) {
+				continue
+			}
+			value = data[start:]
+		}
+		var field tiffField
+		if kind == 2 {
+			field.text = string(value[:n])
+		} else {
+			field.numbers = make([]float64, n)
+			for j := range n {
+				switch kind {
+				case 1, 7:
+					field.numbers[j] = float64(value[j])
+				case 3:
+					field.numbers[j] = float64(order.Uint16(value[2*j:]))
+				case 4:
+					field.numbers[j] = float64(order.Uint32(value[4*j:]))
+				case 5:
+					if denominator := order.Uint32(value[8*j+4:]); denominator != 0 {
+						field.numbers[j] = float64(order.Uint32(value[8*j:])) / float64(denominator)
+					}
+				case 10:
+					if denominator := int32(order.Uint32(value[8*j+4:])); denominator != 0 {
+						field.numbers[j] = float64(int32(order.Uint32(value[8*j:]))) / float64(denominator)
+					}
+				case 11:
+					field.numbers[j] = float64(math.Float32frombits(order.Uint32(value[4*j:])))
+				case 12:
+					field.numbers[j] = math.Float64frombits(order.Uint64(value[8*j:]))
+				case 16:
+					field.numbers[j] = float64(order.Uint64(value[8*j:]))
+				}
+			}
+		}
+		fields[tag] = field
+	}
+	return fields, nil
+}
+
+func geoTIFFMetadata(data []byte) (*GeoMetadata, error) {
+	fields, err := readTIFFDirectory(data)
+	if err != nil {
+		return nil, err
+	}
+	directory := fields[geoTIFFKeyDirectory].numbers
+	if len(directory) < 4 {
+		return nil, fmt.Errorf("no GeoTIFF key directory")
+	}
+	keys := map[int]any{}
+	for i := range int(directory[3]) {
+		if 8+4*i > len(directory) {
+			break
+		}
+		entry := directory[4+4*i:]
+		id, location, count, value := int(entry[0]), int(entry[1]), int(entry[2]), int(entry[3])
+		switch location {
+		case 0:
+			keys[id] = value
+		case geoTIFFDoubleParams:
+			if doubles := fields[geoTIFFDoubleParams].numbers; value+count <= len(doubles) && count > 0 {
+				keys[id] = doubles[value]
+			}
+		case geoTIFFASCIIParams:
+			if text := fields[geoTIFFASCIIParams].text; value+count <= len(text) {
+				keys[id] = strings.TrimRight(text[value:value+count], "|\x00 ")
+			}
+		}
+	}
+
+	meta := &GeoMetadata{Encoding: GeoEncodingGeoTIFF}
+	model, _ := keys[geoKeyModelType].(int)
+	meta.ModelType = geoModelTypes[model]
+	code, _ := keys[geoKeyGeographicType].(int)
+	units, _ := keys[geoKeyGeogAngularUnits].(int)
+	if model == 1 {
+		code, _ = keys[geoKeyProjectedCSType].(int)
+		units, _ = keys[geoKeyProjLinearUnits].(int)
+	}
+	if code > 0 && code != geoKeyUserDefined {
+		meta.CRS = "EPSG:" + strconv.Itoa(code)
+	}
+	meta.Units = geoUnits[units]
+	if meta.Units == "" && model == 2 {
+		meta.Units = "degree"
+	}
+	for _, key := range []int{geoKeyPCSCitation, geoKeyCitation, geoKeyGeogCitation} {
+		if citation, ok := keys[key].(string); ok && citation != "" {
+			meta.CRSName = citation
+			break
+		}
+	}
+
+	width, height := 0.0, 0.0
+	if values := fields[tiffImageWidth].numbers; len(values) > 0 {
+		width = values[0]
+	}
+	if values := fields[tiffImageLength].numbers; len(values) > 0 {
+		height = values[0]
+	}
+	// Point rasters register pixel centres; the image extends half a pixel beyond.
+	shift := 0.0
+	if raster, _ := keys[geoKeyRasterType].(int); raster == geoRasterPixelIsPoint {
+		shift = -0.5
+	}
+	var toModel func(i, j float64) (float64, float64)
+	scale, tiepoint := fields[geoTIFFPixelScale].numbers, fields[geoTIFFTiepoint].numbers
+	if matrix := fields[geoTIFFTransformation].numbers; len(matrix) == 16 {
+		toModel = func(i, j float64) (float64, float64) {
+			return matrix[0]*i + matrix[1]*j + matrix[3], matrix[4]*i + matrix[5]*j + matrix[7]
+		}
+	} else if len(scale) >= 2 && len(tiepoint) >= 6 {
+		toModel = func(i, j float64) (float64, float64) {
+			return tiepoint[3] + (i-tiepoint[0])*scale[0], tiepoint[4] - (j-tiepoint[1])*scale[1]
+		}
+	}
+	if toModel != nil && width > 0 && height > 0 {
+		for n, corner := range [][2]float64{{0, 0}, {width, 0}, {0, height}, {width, height}} {
+			x, y := toModel(corner[0]+shift, corner[1]+shift)
+			if n == 0 {
+				meta.Bounds = newGeoBounds(x, y)
+				continue
+			}
+			meta.Bounds.extend(x, y)
+		}
+	}
+	return meta, nil
+}
+
+var (
+	wktName      = regexp.MustCompile(`^\s*[A-Z_]+\[\s*"([^"]*)"`)
+	wktAuthority = regexp.MustCompile(`AUTHORITY\[\s*"EPSG"\s*,\s*"?(\d+)"?\s*\]`)
+)
+
+// geoPDFMetadata reads the geospatial measure dictionaries of a PDF, or failing those,
+// its LGI dictionaries. The bounds of all measure dictionaries are merged.
+func geoPDFMetadata(objects pdfObjects) *GeoMetadata {
+	var meta *GeoMetadata
+	numbers := slices.Sorted(maps.Keys(objects))
+	for _, num := range numbers {
+		dict := objects.dict(objects[num])
+		if dict["Subtype"] != pdfName("GEO") {
+			continue
+		}
+		points, _ := pdfNumbers(objects.resolve(dict["GPTS"]))
+		if meta == nil {
+			meta = &GeoMetadata{Encoding: GeoEncodingGeoPDF, Units: "degree"}
+			gcs := objects.dict(dict["GCS"])
+			switch gcs["Type"] {
+			case pdfName("PROJCS"):
+				meta.ModelType = "projected"
+			case pdfName("GEOGCS"):
+				meta.ModelType = "geographic"
+			}
+			if code, ok := pdfInt(gcs["EPSG"]); ok {
+				meta.CRS = "EPSG:" + strconv.Itoa(code)
+			}
+			if wkt, ok := objects.resolve(gcs["WKT"]).(string); ok {
+				meta.WKT = wkt
+				if match := wktName.FindStringSubmatch(wkt); match != nil {
+					meta.CRSName = match[1]
+				}
+				if matches := wktAuthority.FindAllStringSubmatch(wkt, -1); meta.CRS == "" && matches != nil {
+					// The authority of the CRS itself closes the WKT.
+					meta.CRS = "EPSG:" + matches[len(matches)-1][1]
+				}
+			}
+		}
+		// GPTS lists latitude, longitude pairs.
+		for i := 0; i+1 < len(points); i += 2 {
+			if meta.Bounds == nil {
+				meta.Bounds = newGeoBounds(points[i+1], points[i])
+				continue
+			}
+			meta.Bounds.extend(points[i+1], points[i])
+		}
+	}
+	if meta != nil {
+		return meta
+	}
+
+	for _, num := range numbers {
+		dict := objects.dict(objects[num])
+		if dict["Type"] != pdfName("LGIDict") {
+			continue
+		}
+		meta = &GeoMetadata{Encoding: GeoEncodingLGI}
+		projection := objects.dict(dict["Projection"])
+		if kind, ok := objects.resolve(projection["ProjectionType"]).(string); ok {
+			name := kind
+			if zone, ok := pdfInt(projection["Zone"]); ok {
+				name += fmt.Sprintf(" zone %d", zone)
+			}
+			if datum, ok := objects.resolve(projection["Datum"]).(string); ok {
+				name += ", datum " + datum
+			}
+			meta.CRSName = name
+		}
+		return meta
+	}
+	return nil
+}
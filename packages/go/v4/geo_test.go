@@ -0,0 +1,165 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// geoTestTIFF builds a little-endian GeoTIFF header registering a 100x50 image in UTM
+// zone 33N with 10 m pixels.
+func geoTestTIFF() []byte {
+	type entry struct {
+		tag, kind uint16
+		values    []byte
+		count     int
+	}
+	shorts := func(values ...uint16) []byte {
+		var out []byte
+		for _, v := range values {
+			out = binary.LittleEndian.AppendUint16(out, v)
+		}
+		return out
+	}
+	doubles := func(values ...float64) []byte {
+		var out []byte
+		for _, v := range values {
+			out = binary.LittleEndian.AppendUint64(out, math.Float64bits(v))
+		}
+		return out
+	}
+	ascii := "WGS 84 / UTM zone 33N|\x00"
+	entries := []entry{
+		{tiffImageWidth, 3, shorts(100), 1},
+		{tiffImageLength, 3, shorts(50), 1},
+		{geoTIFFPixelScale, 12, doubles(10, 10, 0), 3},
+		{geoTIFFTiepoint, 12, doubles(0, 0, 0, 500000, 4600000, 0), 6},
+		{geoTIFFKeyDirectory, 3, shorts(1, 1, 0, 4,
+			geoKeyModelType, 0, 1, 1,
+			geoKeyProjectedCSType, 0, 1, 32633,
+			geoKeyPCSCitation, geoTIFFASCIIParams, uint16(len(ascii)-1), 0,
+			geoKeyProjLinearUnits, 0, 1, 9001), 20},
+		{geoTIFFASCIIParams, 2, []byte(ascii), len(ascii)},
+	}
+	out := []byte("II*\x00")
+	out = binary.LittleEndian.AppendUint32(out, 8)
+	out = binary.LittleEndian.AppendUint16(out, uint16(len(entries)))
+	dataOffset := 8 + 2 + 12*len(entries) + 4
+	var payload []byte
+	for _, e := range entries {
+		out = binary.LittleEndian.AppendUint16(out, e.tag)
+		out = binary.LittleEndian.AppendUint16(out, e.kind)
+		out = binary.LittleEndian.AppendUint32(out, uint32(e.count))
+		if len(e.values) <= 4 {
+			out = append(out, append(e.values, make([]byte, 4-len(e.values))...)...)
+			continue
+		}
+		out = binary.LittleEndian.AppendUint32(out, uint32(dataOffset+len(payload)))
+		payload = append(payload, e.values...)
+	}
+	out = binary.LittleEndian.AppendUint32(out, 0)
+	return append(out, payload...)
+}
+
+func TestParseGeoMetadataGeoTIFF(t *testing.T) {
+	meta := ParseGeoMetadata(geoTestTIFF())
+	if meta == nil {
+		t.Fatal("expected geospatial metadata")
+	}
+	if meta.Encoding != GeoEncodingGeoTIFF || meta.CRS != "EPSG:32633" || meta.ModelType != "projected" ||
+		meta.Units != "metre" || meta.CRSName != "WGS 84 / UTM zone 33N" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+	want := GeoBounds{MinX: 500000, MinY: 4599500, MaxX: 501000, MaxY: 4600000}
+	if meta.Bounds == nil || *meta.Bounds != want {
+		t.Fatalf("bounds = %+v, want %+v", meta.Bounds, want)
+	}
+}
+
+func TestApplyGeoStageIsOptIn(t *testing.T) {
+	read := func() ([]byte, error) { return geoTestTIFF(), nil }
+	result := &ExtractionResult{MimeType: "image/tiff"}
+	applyGeoStage(result, read, NewExtractionConfig())
+	if result.Metadata.Geo != nil {
+		t.Fatal("expected no geospatial metadata unless enabled")
+	}
+	applyGeoStage(result, read, NewExtractionConfig(WithGeoMetadata(true)))
+	if result.Metadata.Geo == nil || result.Metadata.Geo.Encoding != GeoEncodingGeoTIFF {
+		t.Fatalf("unexpected metadata: %+v", result.Metadata.Geo)
+	}
+}
+
+func TestParseGeoMetadataPlainTIFF(t *testing.T) {
+	tiff := []byte("II*\x00\x08\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+	if meta := ParseGeoMetadata(tiff); meta != nil {
+		t.Fatalf("expected nil, got %+v", meta)
+	}
+}
+
+func geoTestPDF(objects ...string) []byte {
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	for i, object := range objects {
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	b.WriteString("trailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestParseGeoMetadataGeoPDF(t *testing.T) {
+	wkt := `PROJCS["WGS 84 / UTM zone 33N",GEOGCS["WGS 84",AUTHORITY["EPSG","4326"]],AUTHORITY["EPSG","32633"]]`
+	var packed bytes.Buffer
+	w := zlib.NewWriter(&packed)
+	fmt.Fprintf(w, "4 0 << /Type /Measure /Subtype /GEO /GCS 3 0 R /GPTS [45.0 15.0 46.0 15.0 46.0 16.5 45.0 16.5] >>")
+	w.Close()
+	data := geoTestPDF(
+		"<< /Type /Catalog >>",
+		fmt.Sprintf("<< /Type /ObjStm /N 1 /First 4 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", packed.Len(), packed.Bytes()),
+		fmt.Sprintf("<< /Type /PROJCS /WKT (%s) >>", wkt),
+	)
+	meta := ParseGeoMetadata(data)
+	if meta == nil {
+		t.Fatal("expected geospatial metadata")
+	}
+	if meta.Encoding != GeoEncodingGeoPDF || meta.CRS != "EPSG:32633" || meta.CRSName != "WGS 84 / UTM zone 33N" ||
+		meta.ModelType != "projected" || meta.Units != "degree" || meta.WKT != wkt {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+	want := GeoBounds{MinX: 15, MinY: 45, MaxX: 16.5, MaxY: 46}
+	if meta.Bounds == nil || *meta.Bounds != want {
+		t.Fatalf("bounds = %+v, want %+v", meta.Bounds, want)
+	}
+}
+
+func TestParseGeoMetadataLGIDict(t *testing.T) {
+	data := geoTestPDF(
+		"<< /Type /Page /LGIDict [2 0 R] >>",
+		"<< /Type /LGIDict /Version (2.1) /Projection << /Type /Projection /ProjectionType (UT) /Zone 17 /Datum (WE) >> >>",
+	)
+	meta := ParseGeoMetadata(data)
+	if meta == nil || meta.Encoding != GeoEncodingLGI || meta.CRSName != "UT zone 17, datum WE" {
+		t.Fatalf("unexpected metadata: %+v", meta)
+	}
+	if meta := ParseGeoMetadata(geoTestPDF("<< /Type /Catalog >>")); meta != nil {
+		t.Fatalf("expected nil for a plain PDF, got %+v", meta)
+	}
+}
+
+func TestMetadataGeoRoundTrip(t *testing.T) {
+	original := Metadata{Geo: &GeoMetadata{Encoding: GeoEncodingGeoTIFF, CRS: "EPSG:4326", Bounds: &GeoBounds{MaxX: 1, MaxY: 2}}}
+	data, err := json.Marshal(original)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Metadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if decoded.Geo == nil || decoded.Geo.CRS != "EPSG:4326" || *decoded.Geo.Bounds != *original.Geo.Bounds || decoded.Additional != nil {
+		t.Fatalf("unexpected round trip: %+v", decoded)
+	}
+}
@@ -4,3 +4,21 @@ go 1.25
 
 // Local development; remove this section for published releases
 // replace github.com/kreuzberg-dev/kreuzberg => ../../
+
+require (
+	github.com/alicebob/miniredis/v2 v2.35.0
+	github.com/klauspost/compress v1.18.0
+	github.com/redis/go-redis/v9 v9.14.1
+	google.golang.org/grpc v1.75.1
+	google.golang.org/protobuf v1.36.6
+)
+
+require (
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/net v0.41.0 // indirect
+	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250707201910-8d1bb00bc6a7 // indirect
+)
@@ -0,0 +1,396 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Heading is a heading of Content with the headings nested below it. ByteStart and
+// ByteEnd delimit the heading's line, and PageNumber is 0 when unknown.
+type Heading struct {
+	Level      int       `json:"level"`
+	Text       string    `json:"text"`
+	ByteStart  uint64    `json:"byte_start"`
+	ByteEnd    uint64    `json:"byte_end"`
+	PageNumber uint64    `json:"page_number,omitempty"`
+	Children   []Heading `json:"children,omitempty"`
+}
+
+const (
+	// docxMimeType is the MIME type of Word documents, whose heading styles are read by
+	// the heading stage.
+	docxMimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	// defaultMaxHeadingLevel is the deepest heading kept when HeadingDetectionConfig.MaxLevel
+	// is unset.
+	defaultMaxHeadingLevel = 6
+	// headingFontRatio is how much larger than the body text a line must be set to count
+	// as a heading.
+	headingFontRatio = 1.15
+	// maxDOCXPartSize bounds the parts of a Word document read for its heading styles.
+	maxDOCXPartSize = 64 << 20
+)
+
+func validateHeadingDetectionConfig(cfg *HeadingDetectionConfig) error {
+	if cfg.MaxLevel != nil && (*cfg.MaxLevel < 1 || *cfg.MaxLevel > 6) {
+		return newValidationErrorWithContext(fmt.Sprintf("max heading level must be between 1 and 6, got %d", *cfg.MaxLevel), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// applyHeadingStage builds the heading tree of a result. Besides Markdown headings and
+// the headings the core reports in metadata, it reads the heading styles of Word
+// documents and, for PDFs without Markdown headings, tells headings from body text by
+// font size. read returns the original document and layer the text layer of its pages.
+// Like the other stages that read the original, it never fails the extraction.
+func applyHeadingStage(result *ExtractionResult, read func() ([]byte, error), layer func() ([]PageImage, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.HeadingDetection == nil || result.Content == "" {
+		return
+	}
+	cfg := config.HeadingDetection
+	known := map[string]int{}
+	switch result.MimeType {
+	case docxMimeType:
+		if data, err := read(); err == nil {
+			for text, level := range docxHeadings(data) {
+				known[text] = level
+			}
+		}
+	case "application/pdf":
+		if (cfg.PDFFontSizes == nil || *cfg.PDFFontSizes) && !hasATXHeadings(result.Content) {
+			if pages, err := layer(); err == nil {
+				for text, level := range fontSizeHeadings(pages) {
+					known[text] = level
+				}
+			}
+		}
+	}
+
+	maxLevel := defaultMaxHeadingLevel
+	if cfg.MaxLevel != nil {
+		maxLevel = *cfg.MaxLevel
+	}
+	result.Headings = detectHeadings(result, known, maxLevel)
+}
+
+// detectHeadings returns the heading tree of result's Content: Markdown headings, lines
+// matching a text of known at its level, and the headings result's metadata reports.
+// Headings deeper than maxLevel are left out.
+func detectHeadings(result *ExtractionResult, known map[string]int, maxLevel int) []Heading {
+	headings := knownHeadings(result)
+	for text, level := range known {
+		headings[text] = level
+	}
+	var flat []Heading
+	for _, block := range parseStructureBlocks(result.Content, headings) {
+		if block.kind != blockHeading || block.level > maxLevel || block.text == "" {
+			continue
+		}
+		heading := Heading{Level: block.level, Text: block.text, ByteStart: uint64(block.start), ByteEnd: uint64(block.end)}
+		if structure := result.Metadata.PageStructure; structure != nil {
+			for _, boundary := range structure.Boundaries {
+				if heading.ByteStart >= boundary.ByteStart && heading.ByteStart < boundary.ByteEnd {
+					heading.PageNumber = boundary.PageNumber
+					break
+				}
+			}
+		}
+		flat = append(flat, heading)
+	}
+	return nestHeadings(flat)
+}
+
+// nestHeadings nests each heading of flat under the closest preceding heading of a
+// lower level.
+func nestHeadings(flat []Heading) []Heading {
+	var roots []Heading
+	for i := 0; i < len(flat); {
+		heading := flat[i]
+		end := i + 1
+		for end < len(flat) && flat[end].Level > heading.Level {
+			end++
+		}
+		heading.Children = nestHeadings(flat[i+1 : end])
+		roots = append(roots, heading)
+		i = end
+	}
+	return roots
+}
+
+// walkHeadings calls fn for every heading of a tree in document order.
+func walkHeadings(headings []Heading, fn func(*Heading)) {
+	for i := range headings {
+		fn(&headings[i])
+		walkHeadings(headings[i].Children, fn)
+	}
+}
+
+// cloneHeadings returns a deep copy of a heading tree.
+func cloneHeadings(headings []Heading) []Heading {
+	if headings == nil {
+		return nil
+	}
+	clone := make([]Heading, len(headings))
+	for i, heading := range headings {
+		heading.Children = cloneHeadings(heading.Children)
+		clone[i] = heading
+	}
+	return clone
+}
+
+// headingPath returns the texts of the headings enclosing offset, outermost first.
+func headingPath(headings []Heading, offset uint64) []string {
+	var path []string
+	for len(headings) > 0 {
+		i := sort.Search(len(headings), func(i int) bool { return headings[i].ByteStart > offset }) - 1
+		if i < 0 {
+			break
+		}
+		path = append(path, headings[i].Text)
+		headings = headings[i].Children
+	}
+	return path
+}
+
+// labelChunkHeadings sets the heading path of chunks that have none from the heading tree.
+func labelChunkHeadings(result *ExtractionResult) {
+	for i := range result.Chunks {
+		meta := &result.Chunks[i].Metadata
+		if len(meta.HeadingPath) == 0 {
+			meta.HeadingPath = headingPath(result.Headings, meta.ByteStart)
+		}
+	}
+}
+
+// hasATXHeadings reports whether content has Markdown headings.
+func hasATXHeadings(content string) bool {
+	found := false
+	forEachLine(content, func(_ int, line string) {
+		if !found {
+			_, _, found = parseATXHeading(line)
+		}
+	})
+	return found
+}
+
+// docxHeadings returns the text and level of the paragraphs of a Word document set in a
+// heading style, with the Title style as level 1, or given an outline level directly.
+func docxHeadings(data []byte) map[string]int {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	styles := docxStyleLevels(docxPart(archive, "word/styles.xml"))
+
+	headings := map[string]int{}
+	decoder := xml.NewDecoder(bytes.NewReader(docxPart(archive, "word/document.xml")))
+	var text strings.Builder
+	level, inParagraph := 0, false
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return headings
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "p":
+				text.Reset()
+				level, inParagraph = 0, true
+			case "pStyle":
+				if level == 0 {
+					level = styles[xmlAttr(token, "val")]
+				}
+			case "outlineLvl":
+				if outline, err := strconv.Atoi(xmlAttr(token, "val")); err == nil && outline < 9 {
+					level = outline + 1
+				}
+			case "tab":
+				text.WriteString(" ")
+			case "t":
+				var value string
+				if decoder.DecodeElement(&value, &token) == nil {
+					text.WriteString(value)
+				}
+			}
+		case xml.EndElement:
+			if token.Name.Local == "p" && inParagraph {
+				inParagraph = false
+				if title := strings.Join(strings.Fields(text.String()), " "); level > 0 && title != "" {
+					if _, ok := headings[title]; !ok {
+						headings[title] = level
+					}
+				}
+			}
+		}
+	}
+}
+
+// docxPart returns the part of a Word document called name, or nil.
+func docxPart(archive *zip.Reader, name string) []byte {
+	for _, file := range archive.File {
+		if file.Name != name {
+			continue
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return nil
+		}
+		defer reader.Close()
+		content, err := io.ReadAll(io.LimitReader(reader, maxDOCXPartSize))
+		if err != nil {
+			return nil
+		}
+		return content
+	}
+	return nil
+}
+
+// xmlAttr returns the value of the attribute of element with the local name name, or "".
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// docxStyleLevels maps the paragraph style IDs of a Word document's styles part to the
+// heading level they set: that of the built-in "heading N" and "Title" styles, of an
+// outline level, or of the style they are based on.
+func docxStyleLevels(data []byte) map[string]int {
+	type style struct {
+		basedOn string
+		level   int
+	}
+	styles := map[string]*style{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var current *style
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "style":
+			current = nil
+			if xmlAttr(start, "type") == "paragraph" {
+				current = &style{}
+				styles[xmlAttr(start, "styleId")] = current
+			}
+		case "name":
+			if current == nil {
+				continue
+			}
+			name := strings.ToLower(xmlAttr(start, "val"))
+			if name == "title" {
+				current.level = 1
+			} else if number, ok := strings.CutPrefix(name, "heading "); ok {
+				if n, err := strconv.Atoi(number); err == nil && n >= 1 && n <= 9 {
+					current.level = n
+				}
+			}
+		case "basedOn":
+			if current != nil {
+				current.basedOn = xmlAttr(start, "val")
+			}
+		case "outlineLvl":
+			if current != nil {
+				if outline, err := strconv.Atoi(xmlAttr(start, "val")); err == nil && outline < 9 {
+					current.level = outline + 1
+				}
+			}
+		}
+	}
+
+	levels := map[string]int{}
+	for id, s := range styles {
+		for depth := 0; s != nil && depth < 10; depth++ {
+			if s.level > 0 {
+				levels[id] = s.level
+				break
+			}
+			s = styles[s.basedOn]
+		}
+	}
+	return levels
+}
+
+// fontSizeHeadings tells the headings of PDF pages from their body text by font size. The
+// words of each page's text layer are joined into lines sized by their tallest word; the
+// size covering the most characters is the body size, and short lines set at least
+// headingFontRatio larger are headings, the largest size being level 1.
+func fontSizeHeadings(pages []PageImage) map[string]int {
+	type line struct {
+		text string
+		size float64
+	}
+	var lines []line
+	characters := map[float64]int{}
+	for _, page := range pages {
+		var words []string
+		size, top := 0.0, 0.0
+		flush := func() {
+			if len(words) > 0 {
+				text := strings.Join(words, " ")
+				lines = append(lines, line{text: text, size: size})
+				characters[size] += utf8.RuneCountInString(text)
+			}
+			words, size = nil, 0
+		}
+		for _, word := range page.Words {
+			if len(words) > 0 && math.Abs(word.Top-top) > word.Height/2 {
+				flush()
+			}
+			if len(words) == 0 {
+				top = word.Top
+			}
+			words = append(words, word.Text)
+			size = max(size, math.Round(word.Height))
+		}
+		flush()
+	}
+
+	body, most := 0.0, 0
+	for size, count := range characters {
+		if count > most || count == most && size < body {
+			body, most = size, count
+		}
+	}
+	if body == 0 {
+		return nil
+	}
+	var sizes []float64
+	for _, l := range lines {
+		if l.size >= body*headingFontRatio && !slices.Contains(sizes, l.size) {
+			sizes = append(sizes, l.size)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(sizes)))
+
+	headings := map[string]int{}
+	for _, l := range lines {
+		level := slices.Index(sizes, l.size) + 1
+		if level == 0 || utf8.RuneCountInString(l.text) > maxSectionHeadingChars || !strings.ContainsFunc(l.text, unicode.IsLetter) {
+			continue
+		}
+		if _, ok := headings[l.text]; !ok {
+			headings[l.text] = min(level, 6)
+		}
+	}
+	return headings
+}
@@ -0,0 +1,160 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const headingTestDoc = `# Guide
+
+Intro text.
+
+## Install
+
+### Linux
+
+Steps.
+
+## Usage
+
+Run it.
+
+# Reference
+
+#### Deep
+`
+
+func TestDetectHeadings(t *testing.T) {
+	pageBreak := uint64(strings.Index(headingTestDoc, "## Usage"))
+	result := &ExtractionResult{Content: headingTestDoc}
+	result.Metadata.PageStructure = &PageStructure{Boundaries: []PageBoundary{
+		{ByteStart: 0, ByteEnd: pageBreak, PageNumber: 1},
+		{ByteStart: pageBreak, ByteEnd: uint64(len(headingTestDoc)), PageNumber: 2},
+	}}
+
+	headings := detectHeadings(result, nil, defaultMaxHeadingLevel)
+	if len(headings) != 2 || headings[0].Text != "Guide" || headings[1].Text != "Reference" {
+		t.Fatalf("unexpected roots: %+v", headings)
+	}
+	guide := headings[0]
+	if len(guide.Children) != 2 || guide.Children[0].Text != "Install" || guide.Children[1].Text != "Usage" {
+		t.Fatalf("unexpected children of Guide: %+v", guide.Children)
+	}
+	if linux := guide.Children[0].Children; len(linux) != 1 || linux[0].Text != "Linux" || linux[0].Level != 3 {
+		t.Fatalf("unexpected children of Install: %+v", linux)
+	}
+	if guide.PageNumber != 1 || guide.Children[1].PageNumber != 2 {
+		t.Errorf("unexpected pages: %d, %d", guide.PageNumber, guide.Children[1].PageNumber)
+	}
+	if start := guide.Children[1].ByteStart; headingTestDoc[start:guide.Children[1].ByteEnd] != "## Usage" {
+		t.Errorf("unexpected span of Usage: %d-%d", start, guide.Children[1].ByteEnd)
+	}
+	if deep := headings[1].Children; len(deep) != 1 || deep[0].Level != 4 {
+		t.Errorf("a skipped level should still nest: %+v", deep)
+	}
+
+	if shallow := detectHeadings(result, nil, 2); len(shallow[0].Children[0].Children) != 0 || len(shallow[1].Children) != 0 {
+		t.Errorf("headings below level 2 should be left out: %+v", shallow)
+	}
+
+	plain := &ExtractionResult{Content: "Overview\nSome text.\nDetails\nMore text."}
+	known := map[string]int{"Overview": 1, "Details": 2}
+	if tree := detectHeadings(plain, known, defaultMaxHeadingLevel); len(tree) != 1 || len(tree[0].Children) != 1 || tree[0].Children[0].Text != "Details" {
+		t.Errorf("known headings should be found in plain text: %+v", tree)
+	}
+}
+
+func TestHeadingStage(t *testing.T) {
+	config := NewExtractionConfig(WithHeadingDetection(WithMaxHeadingLevel(7)))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	config = NewExtractionConfig(WithHeadingDetection())
+	result := &ExtractionResult{Content: headingTestDoc, MimeType: "text/markdown"}
+	intro, usage := uint64(strings.Index(headingTestDoc, "Intro text.")), uint64(strings.Index(headingTestDoc, "Run it."))
+	result.Chunks = Chunks{
+		{Content: "Intro text.", Metadata: ChunkMetadata{ByteStart: intro, ByteEnd: intro + 11}},
+		{Content: "Run it.", Metadata: ChunkMetadata{ByteStart: usage, ByteEnd: usage + 7}},
+	}
+	applyHeadingStage(result, func() ([]byte, error) { return nil, errors.New("not read") }, func() ([]PageImage, error) { return nil, errors.New("not read") }, config)
+	if err := runResultStages(result, config); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if len(result.Headings) != 2 {
+		t.Fatalf("expected 2 root headings, got %+v", result.Headings)
+	}
+	if path := result.Chunks[1].Metadata.HeadingPath; !reflect.DeepEqual(path, []string{"Guide", "Usage"}) {
+		t.Errorf("unexpected heading path: %q", path)
+	}
+	if path := result.Chunks[0].Metadata.HeadingPath; !reflect.DeepEqual(path, []string{"Guide"}) {
+		t.Errorf("unexpected heading path: %q", path)
+	}
+}
+
+func TestDOCXHeadings(t *testing.T) {
+	styles := `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="paragraph" w:styleId="Titel"><w:name w:val="Title"/></w:style>
+<w:style w:type="paragraph" w:styleId="berschrift1"><w:name w:val="heading 1"/><w:basedOn w:val="Standard"/></w:style>
+<w:style w:type="paragraph" w:styleId="Kapitel"><w:name w:val="Kapitel"/><w:basedOn w:val="berschrift1"/></w:style>
+<w:style w:type="paragraph" w:styleId="Standard"><w:name w:val="Normal"/></w:style>
+</w:styles>`
+	document := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:pStyle w:val="Titel"/></w:pPr><w:r><w:t>Annual</w:t></w:r><w:r><w:t xml:space="preserve"> Report</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Standard"/></w:pPr><w:r><w:t>Body text.</w:t></w:r></w:p>
+<w:p><w:pPr><w:pStyle w:val="Kapitel"/></w:pPr><w:r><w:t>Results</w:t></w:r></w:p>
+<w:p><w:pPr><w:outlineLvl w:val="2"/></w:pPr><w:r><w:t>Regional</w:t></w:r></w:p>
+</w:body></w:document>`
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"word/styles.xml": styles, "word/document.xml": document} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]int{"Annual Report": 1, "Results": 1, "Regional": 3}
+	if got := docxHeadings(buf.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("docxHeadings = %v, want %v", got, want)
+	}
+	if got := docxHeadings([]byte("not a zip")); len(got) != 0 {
+		t.Errorf("expected no headings, got %v", got)
+	}
+}
+
+func TestFontSizeHeadings(t *testing.T) {
+	line := func(top, height float64, words ...string) []PageWord {
+		var out []PageWord
+		for i, word := range words {
+			out = append(out, PageWord{Text: word, Left: float64(i * 40), Top: top, Width: 30, Height: height})
+		}
+		return out
+	}
+	var words []PageWord
+	words = append(words, line(40, 24, "Annual", "Report")...)
+	words = append(words, line(80, 11, "This", "is", "the", "body", "text", "of", "the", "report.")...)
+	words = append(words, line(100, 16, "Results")...)
+	words = append(words, line(120, 11, "More", "body", "text", "follows", "here.")...)
+	words = append(words, line(140, 16, "2024")...)
+	words = append(words, line(160, 12, "Slightly", "taller", "text")...)
+
+	want := map[string]int{"Annual Report": 1, "Results": 2}
+	if got := fontSizeHeadings([]PageImage{{PageNumber: 1, Words: words}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("fontSizeHeadings = %v, want %v", got, want)
+	}
+	if got := fontSizeHeadings(nil); got != nil {
+		t.Errorf("expected nil for no pages, got %v", got)
+	}
+}
@@ -0,0 +1,86 @@
+package kreuzberg
+
+import "slices"
+
+// ResultField names a part of an ExtractionResult, for ExtractionConfig.Include.
+type ResultField string
+
+const (
+	// ResultFieldContent is Content and Elements.
+	ResultFieldContent ResultField = "content"
+	// ResultFieldTables is Tables.
+	ResultFieldTables ResultField = "tables"
+	// ResultFieldImages is Images.
+	ResultFieldImages ResultField = "images"
+	// ResultFieldChunks is Chunks.
+	ResultFieldChunks ResultField = "chunks"
+	// ResultFieldPages is Pages.
+	ResultFieldPages ResultField = "pages"
+	// ResultFieldMetadata is Metadata, except Metadata.Error, and DetectedLanguages.
+	ResultFieldMetadata ResultField = "metadata"
+)
+
+var resultFields = []ResultField{
+	ResultFieldContent, ResultFieldTables, ResultFieldImages, ResultFieldChunks, ResultFieldPages, ResultFieldMetadata,
+}
+
+func validateInclude(include []ResultField) error {
+	for _, field := range include {
+		if !slices.Contains(resultFields, field) {
+			return newValidationErrorWithContext("unknown result field "+string(field)+" in include", nil, ErrorCodeValidation, nil)
+		}
+	}
+	return nil
+}
+
+// nativeInclude returns the parts of the result the native library must return for
+// config: those of config.Include, or all of them when a binding-side stage reads the
+// native result, in which case applyInclude drops the others afterwards.
+func nativeInclude(config *ExtractionConfig) []ResultField {
+	if config.Include == nil || bindingReadsResult(config) {
+		return nil
+	}
+	return config.Include
+}
+
+// bindingReadsResult reports whether config enables a binding-side stage that reads the
+// content, chunks, pages or metadata returned by the native library.
+func bindingReadsResult(config *ExtractionConfig) bool {
+	return config.Chunking != nil || config.Normalization != nil || config.TranslateTo != "" ||
+		config.SectionDetection != nil || config.TableExtraction != nil || config.KeyValues != nil ||
+		config.MarkDetection != nil || config.TextStats != nil || config.HeadingDetection != nil ||
+		config.LinkExtraction != nil || config.InlineStyles != nil || config.SourceAttribution != nil ||
+		config.Email != nil || config.Chat != nil || config.OffsetUnit != "" || config.Speculative != nil ||
+		config.PageDeduplication != nil || scanQualityEnabled(config) || config.CoordinateUnit != "" ||
+		config.FontInventory != nil || config.TextEncoding != nil ||
+		config.VerticalText != nil || config.RubyText != "" || len(config.Fallbacks) > 0
+}
+
+// applyInclude empties the parts of result that include leaves out. A nil include keeps
+// everything. Metadata.Error is kept with the metadata left out, as it reports how the
+// extraction went.
+func applyInclude(result *ExtractionResult, include []ResultField) {
+	if include == nil {
+		return
+	}
+	if !slices.Contains(include, ResultFieldContent) {
+		result.Content = ""
+		result.Elements = nil
+	}
+	if !slices.Contains(include, ResultFieldTables) {
+		result.Tables = nil
+	}
+	if !slices.Contains(include, ResultFieldImages) {
+		result.Images = nil
+	}
+	if !slices.Contains(include, ResultFieldChunks) {
+		result.Chunks = nil
+	}
+	if !slices.Contains(include, ResultFieldPages) {
+		result.Pages = nil
+	}
+	if !slices.Contains(include, ResultFieldMetadata) {
+		result.Metadata = Metadata{Error: result.Metadata.Error}
+		result.DetectedLanguages = nil
+	}
+}
@@ -0,0 +1,47 @@
+package kreuzberg
+
+import "testing"
+
+func TestApplyIncludeKeepsSelectedParts(t *testing.T) {
+	result := &ExtractionResult{
+		Content:           "text",
+		MimeType:          "application/pdf",
+		Metadata:          Metadata{Subject: StringPtr("Subject"), Error: &ErrorMetadata{Message: "partial"}},
+		Tables:            []Table{{Markdown: "| a |"}},
+		DetectedLanguages: []string{"en"},
+		Pages:             []PageContent{{PageNumber: 1}},
+	}
+	applyInclude(result, []ResultField{ResultFieldTables})
+	if result.Content != "" || result.Pages != nil || result.Metadata.Subject != nil || result.DetectedLanguages != nil {
+		t.Fatalf("expected only tables to remain, got %+v", result)
+	}
+	if len(result.Tables) != 1 || result.MimeType != "application/pdf" || result.Metadata.Error == nil {
+		t.Fatalf("expected the tables, MIME type and metadata error to remain, got %+v", result)
+	}
+
+	result = &ExtractionResult{Content: "text"}
+	applyInclude(result, nil)
+	if result.Content != "text" {
+		t.Fatal("expected a nil include to keep everything")
+	}
+}
+
+func TestNativeInclude(t *testing.T) {
+	config := NewExtractionConfig(WithInclude(ResultFieldMetadata))
+	if got := nativeInclude(config); len(got) != 1 || got[0] != ResultFieldMetadata {
+		t.Fatalf("expected the include list to reach the native library, got %v", got)
+	}
+	config.Chunking = &ChunkingConfig{}
+	if got := nativeInclude(config); got != nil {
+		t.Fatalf("expected the full native result for binding-side chunking, got %v", got)
+	}
+}
+
+func TestValidateInclude(t *testing.T) {
+	if err := validateResultStages(NewExtractionConfig(WithInclude("summary"))); err == nil {
+		t.Fatal("expected an error for an unknown result field")
+	}
+	if err := validateResultStages(NewExtractionConfig(WithInclude(ResultFieldContent, ResultFieldChunks))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
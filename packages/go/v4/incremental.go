@@ -0,0 +1,354 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// Fingerprint records the state of a document when it was extracted, so that
+// ExtractFileIncremental can tell which pages changed. PageHashes is only set for PDFs.
+type Fingerprint struct {
+	DocumentHash string   `json:"document_hash"`
+	PageHashes   []string `json:"page_hashes,omitempty"`
+}
+
+// ExtractFileIncremental extracts path, reusing previous for the pages that did not change
+// since fingerprint was taken. It returns the new result and fingerprint to pass to the
+// next call. When previous or fingerprint is nil, the document is not a PDF, its page
+// count changed, or more than half of its pages changed, the whole document is extracted.
+//
+// Pages are compared by a hash of everything they draw: their content streams, their
+// attributes, and the resources and annotations those reference, so replacing an image
+// or font used by a page marks the page changed. Document-level metadata is carried over from previous. Chunks and binding-side stages,
+// including those reading the document such as heading detection, are recomputed over
+// the merged content. Results passed as previous must carry page
+// boundaries; results returned by this function always do.
+func ExtractFileIncremental(path string, previous *ExtractionResult, fingerprint *Fingerprint, config *ExtractionConfig) (*ExtractionResult, *Fingerprint, error) {
+	if path == "" {
+		return nil, nil, newValidationErrorWithContext("path is required", nil, ErrorCodeValidation, nil)
+	}
+	if config != nil && config.Chunking != nil {
+		if err := validateChunkingConfig(config.Chunking); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := validateResultStages(config); err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, newIOErrorWithContext("failed to read document", err, ErrorCodeIo, nil)
+	}
+	sum := sha256.Sum256(data)
+	current := &Fingerprint{DocumentHash: hex.EncodeToString(sum[:])}
+	if previous != nil && fingerprint != nil && fingerprint.DocumentHash == current.DocumentHash {
+		return previous, fingerprint, nil
+	}
+
+	mimeType, err := DetectMimeType(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if mimeType == "application/pdf" {
+		// Documents lopdf cannot parse, such as encrypted ones, are always extracted in full.
+		if hashes, err := pdfPageHashes(data); err == nil {
+			current.PageHashes = hashes
+		}
+	}
+
+	changed, ok := changedPages(previous, fingerprint, current)
+	if !ok {
+		result, err := extractTrackingPages(data, mimeType, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return result, current, nil
+	}
+	if len(changed) == 0 {
+		return previous, current, nil
+	}
+
+	subset, err := pdfSelectPages(data, changed)
+	if err != nil {
+		return nil, nil, err
+	}
+	native := withPageTracking(nativeConfig(config))
+	native.Chunking = nil
+	partial, err := extractBytesNative(subset, mimeType, native)
+	if err != nil {
+		return nil, nil, err
+	}
+	merged, err := mergePages(previous, partial, changed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if config == nil {
+		return merged, current, nil
+	}
+	if err := applyDocumentStages(merged, func() ([]byte, error) { return data, nil }, config); err != nil {
+		return nil, nil, err
+	}
+	stages := *config
+	if config.Chunking != nil {
+		if merged, err = RechunkResult(merged, config.Chunking); err != nil {
+			return nil, nil, err
+		}
+		stages.Chunking = nil
+	}
+	if err := runResultStages(merged, &stages); err != nil {
+		return nil, nil, err
+	}
+	return merged, current, nil
+}
+
+// extractTrackingPages extracts data in full with page boundaries enabled.
+func extractTrackingPages(data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	result, err := extractBytesNative(data, mimeType, withPageTracking(nativeConfig(config)))
+	if err != nil {
+		return nil, err
+	}
+	if err := applyDocumentStages(result, func() ([]byte, error) { return data, nil }, config); err != nil {
+		return nil, err
+	}
+	if err := runResultStages(result, config); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// withPageTracking returns a copy of config with page boundary tracking enabled.
+func withPageTracking(config *ExtractionConfig) *ExtractionConfig {
+	tracked := ExtractionConfig{}
+	if config != nil {
+		tracked = *config
+	}
+	if tracked.Pages == nil {
+		tracked.Pages = &PageConfig{}
+	}
+	return &tracked
+}
+
+// changedPages returns the 1-indexed pages whose hashes differ between fingerprints. It
+// returns false when the pages cannot be compared or previous lacks one boundary per page.
+func changedPages(previous *ExtractionResult, before, after *Fingerprint) ([]int, bool) {
+	if previous == nil || before == nil || len(after.PageHashes) == 0 || len(before.PageHashes) != len(after.PageHashes) {
+		return nil, false
+	}
+	if !hasPageBoundaries(previous, len(after.PageHashes)) {
+		return nil, false
+	}
+	var changed []int
+	for i, hash := range after.PageHashes {
+		if before.PageHashes[i] != hash {
+			changed = append(changed, i+1)
+		}
+	}
+	if 2*len(changed) > len(after.PageHashes) {
+		return nil, false
+	}
+	return changed, true
+}
+
+// hasPageBoundaries reports whether result has ordered boundaries for pages 1..count.
+func hasPageBoundaries(result *ExtractionResult, count int) bool {
+	if result.Metadata.PageStructure == nil || len(result.Metadata.PageStructure.Boundaries) != count {
+		return false
+	}
+	var cursor uint64
+	for i, boundary := range result.Metadata.PageStructure.Boundaries {
+		if boundary.PageNumber != uint64(i+1) || boundary.ByteStart < cursor || boundary.ByteEnd < boundary.ByteStart || boundary.ByteEnd > uint64(len(result.Content)) {
+			return false
+		}
+		cursor = boundary.ByteEnd
+	}
+	return true
+}
+
+// mergePages replaces the changed pages of previous with the pages of partial, which was
+// extracted from a document holding only those pages, in order. Text between pages is
+// kept from previous. Chunks and stage outputs are cleared for recomputation, and the
+// pages of a paged previous result are brought back into memory.
+func mergePages(previous, partial *ExtractionResult, changed []int) (*ExtractionResult, error) {
+	if !hasPageBoundaries(partial, len(changed)) {
+		return nil, newParsingErrorWithContext("re-extracted pages do not match the requested pages", nil, ErrorCodeParsing, nil)
+	}
+	// The pages of a paged previous result are read back: the merged result must not share
+	// its paging file, which previous.Close removes.
+	previousPages, _, err := pagedContents(previous)
+	if err != nil {
+		return nil, err
+	}
+	// original maps a page number of partial to the page it replaces.
+	original := func(page uint64) uint64 { return uint64(changed[page-1]) }
+	replaced := make(map[uint64]bool, len(changed))
+	for _, page := range changed {
+		replaced[uint64(page)] = true
+	}
+
+	prevBounds := previous.Metadata.PageStructure.Boundaries
+	partBounds := partial.Metadata.PageStructure.Boundaries
+	bounds := make([]PageBoundary, len(prevBounds))
+	var content strings.Builder
+	var cursor uint64
+	next := 0
+	for i, boundary := range prevBounds {
+		content.WriteString(previous.Content[cursor:boundary.ByteStart])
+		text := previous.Content[boundary.ByteStart:boundary.ByteEnd]
+		if replaced[boundary.PageNumber] {
+			text = partial.Content[partBounds[next].ByteStart:partBounds[next].ByteEnd]
+			next++
+		}
+		start := uint64(content.Len())
+		content.WriteString(text)
+		bounds[i] = PageBoundary{ByteStart: start, ByteEnd: uint64(content.Len()), PageNumber: boundary.PageNumber}
+		cursor = boundary.ByteEnd
+	}
+	content.WriteString(previous.Content[cursor:])
+
+	merged := *previous
+	merged.Content = content.String()
+	structure := *previous.Metadata.PageStructure
+	structure.Boundaries = bounds
+	merged.Metadata.PageStructure = &structure
+	merged.paged = nil
+	merged.Chunks = nil
+	merged.Sections = nil
+	merged.Headings = nil
+	merged.Links = nil
+	merged.StyleSpans = nil
+	merged.TextSources = nil
+	merged.KeyValues = nil
+	merged.Marks = nil
+	merged.TranslatedContent = ""
+	merged.TranslationSegments = nil
+
+	merged.Pages = nil
+	if len(previousPages) > 0 {
+		for _, page := range previousPages {
+			if !replaced[page.PageNumber] {
+				merged.Pages = append(merged.Pages, page)
+			}
+		}
+		for _, page := range partial.Pages {
+			page.PageNumber = original(page.PageNumber)
+			merged.Pages = append(merged.Pages, page)
+		}
+		sort.SliceStable(merged.Pages, func(i, j int) bool { return merged.Pages[i].PageNumber < merged.Pages[j].PageNumber })
+	}
+
+	merged.Tables = nil
+	for _, table := range previous.Tables {
+		if !replaced[uint64(max(table.PageNumber, 0))] {
+			merged.Tables = append(merged.Tables, table)
+		}
+	}
+	for _, table := range partial.Tables {
+		if table.PageNumber > 0 && table.PageNumber <= len(changed) {
+			table.PageNumber = int(original(uint64(table.PageNumber)))
+			merged.Tables = append(merged.Tables, table)
+		}
+	}
+	sort.SliceStable(merged.Tables, func(i, j int) bool { return merged.Tables[i].PageNumber < merged.Tables[j].PageNumber })
+
+	merged.Images = nil
+	for _, image := range previous.Images {
+		if image.PageNumber == nil || !replaced[uint64(max(*image.PageNumber, 0))] {
+			merged.Images = append(merged.Images, image)
+		}
+	}
+	for _, image := range partial.Images {
+		if image.PageNumber != nil && *image.PageNumber > 0 && *image.PageNumber <= len(changed) {
+			page := int(original(uint64(*image.PageNumber)))
+			image.PageNumber = &page
+			merged.Images = append(merged.Images, image)
+		}
+	}
+
+	merged.Elements = nil
+	for _, element := range previous.Elements {
+		if page := element.Metadata.PageNumber; page == nil || !replaced[uint64(max(*page, 0))] {
+			merged.Elements = append(merged.Elements, element)
+		}
+	}
+	for _, element := range partial.Elements {
+		if page := element.Metadata.PageNumber; page != nil && *page > 0 && *page <= int64(len(changed)) {
+			mapped := int64(original(uint64(*page)))
+			element.Metadata.PageNumber = &mapped
+			merged.Elements = append(merged.Elements, element)
+		}
+	}
+	sort.SliceStable(merged.Elements, func(i, j int) bool {
+		return elementPage(merged.Elements[i]) < elementPage(merged.Elements[j])
+	})
+	return &merged, nil
+}
+
+func elementPage(element Element) int64 {
+	if element.Metadata.PageNumber == nil {
+		return 0
+	}
+	return *element.Metadata.PageNumber
+}
+
+// pdfPageHashes returns the hash of every page of a PDF.
+func pdfPageHashes(data []byte) ([]string, error) {
+	buf := C.CBytes(data)
+	defer C.free(buf)
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_pdf_page_hashes((*C.uint8_t)(buf), C.uintptr_t(len(data)))
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	var hashes []string
+	if err := decodeJSONCString(ptr, &hashes); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode page hashes", err, ErrorCodeValidation, nil)
+	}
+	return hashes, nil
+}
+
+// pdfSelectPages returns a PDF holding only the given 1-indexed pages of data.
+func pdfSelectPages(data []byte, pages []int) ([]byte, error) {
+	pagesJSON, err := json.Marshal(pages)
+	if err != nil {
+		return nil, newSerializationErrorWithContext("failed to encode pages", err, ErrorCodeValidation, nil)
+	}
+	buf := C.CBytes(data)
+	defer C.free(buf)
+	cPages := C.CString(string(pagesJSON))
+	defer C.free(unsafe.Pointer(cPages))
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_pdf_select_pages((*C.uint8_t)(buf), C.uintptr_t(len(data)), cPages)
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	subset, err := base64.StdEncoding.DecodeString(C.GoString(ptr))
+	if err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode selected pages", err, ErrorCodeValidation, nil)
+	}
+	return subset, nil
+}
@@ -0,0 +1,83 @@
+package kreuzberg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func incrementalTestResult(content string, bounds ...uint64) *ExtractionResult {
+	result := &ExtractionResult{Content: content, Metadata: Metadata{PageStructure: &PageStructure{UnitType: PageUnitTypePage}}}
+	for i := 0; i+1 < len(bounds); i += 2 {
+		result.Metadata.PageStructure.Boundaries = append(result.Metadata.PageStructure.Boundaries,
+			PageBoundary{ByteStart: bounds[i], ByteEnd: bounds[i+1], PageNumber: uint64(i/2 + 1)})
+	}
+	result.Metadata.PageStructure.TotalCount = uint64(len(result.Metadata.PageStructure.Boundaries))
+	return result
+}
+
+func TestChangedPages(t *testing.T) {
+	previous := incrementalTestResult("aaa\nbbb\nccc", 0, 3, 4, 7, 8, 11)
+	before := &Fingerprint{DocumentHash: "x", PageHashes: []string{"1", "2", "3"}}
+
+	changed, ok := changedPages(previous, before, &Fingerprint{DocumentHash: "y", PageHashes: []string{"1", "9", "3"}})
+	if !ok || !reflect.DeepEqual(changed, []int{2}) {
+		t.Fatalf("got %v %v, want [2] true", changed, ok)
+	}
+	if _, ok := changedPages(previous, before, &Fingerprint{PageHashes: []string{"1", "2"}}); ok {
+		t.Fatalf("expected page count change to force a full extraction")
+	}
+	if _, ok := changedPages(previous, before, &Fingerprint{PageHashes: []string{"7", "8", "3"}}); ok {
+		t.Fatalf("expected a majority of changed pages to force a full extraction")
+	}
+	if _, ok := changedPages(&ExtractionResult{Content: "aaa"}, before, &Fingerprint{PageHashes: []string{"1", "9", "3"}}); ok {
+		t.Fatalf("expected a result without boundaries to force a full extraction")
+	}
+}
+
+func TestMergePagesReplacesChangedPages(t *testing.T) {
+	page := func(n int) *int { return &n }
+	previous := incrementalTestResult("one\n\ntwo\n\nthree", 0, 3, 5, 8, 10, 15)
+	previous.Tables = []Table{{PageNumber: 1, Markdown: "a"}, {PageNumber: 2, Markdown: "old"}}
+	previous.Images = []ExtractedImage{{PageNumber: page(2)}, {PageNumber: page(3)}}
+	previous.Chunks = []Chunk{{Content: "stale"}}
+
+	partial := incrementalTestResult("second page", 0, 11)
+	partial.Tables = []Table{{PageNumber: 1, Markdown: "new"}}
+	partial.Images = []ExtractedImage{{PageNumber: page(1)}}
+
+	merged, err := mergePages(previous, partial, []int{2})
+	if err != nil {
+		t.Fatalf("mergePages: %v", err)
+	}
+	if merged.Content != "one\n\nsecond page\n\nthree" {
+		t.Fatalf("unexpected content %q", merged.Content)
+	}
+	want := []PageBoundary{
+		{ByteStart: 0, ByteEnd: 3, PageNumber: 1},
+		{ByteStart: 5, ByteEnd: 16, PageNumber: 2},
+		{ByteStart: 18, ByteEnd: 23, PageNumber: 3},
+	}
+	if !reflect.DeepEqual(merged.Metadata.PageStructure.Boundaries, want) {
+		t.Fatalf("got boundaries %+v, want %+v", merged.Metadata.PageStructure.Boundaries, want)
+	}
+	if len(merged.Tables) != 2 || merged.Tables[1].Markdown != "new" || merged.Tables[1].PageNumber != 2 {
+		t.Fatalf("unexpected tables %+v", merged.Tables)
+	}
+	if len(merged.Images) != 2 || *merged.Images[1].PageNumber != 2 {
+		t.Fatalf("unexpected images %+v", merged.Images)
+	}
+	if merged.Chunks != nil {
+		t.Fatalf("expected chunks to be cleared")
+	}
+	if previous.Content != "one\n\ntwo\n\nthree" || previous.Metadata.PageStructure.Boundaries[1].ByteEnd != 8 {
+		t.Fatalf("previous result was modified")
+	}
+}
+
+func TestMergePagesRejectsMismatchedPartial(t *testing.T) {
+	previous := incrementalTestResult("one\ntwo", 0, 3, 4, 7)
+	partial := incrementalTestResult("x", 0, 1)
+	if _, err := mergePages(previous, partial, []int{1, 2}); err == nil {
+		t.Fatalf("expected an error for a partial result with too few pages")
+	}
+}
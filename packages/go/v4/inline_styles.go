@@ -0,0 +1,797 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Styles reported in StyleSpan.Style.
+const (
+	StyleBold          = "bold"
+	StyleItalic        = "italic"
+	StyleUnderline     = "underline"
+	StyleStrikethrough = "strikethrough"
+	StyleHighlight     = "highlight"
+	StyleColor         = "color"
+)
+
+// StyleSpan marks a byte range of Content set in an inline style. Color is the
+// "#RRGGBB" color of highlight and color spans.
+type StyleSpan struct {
+	Style     string `json:"style"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+	Color     string `json:"color,omitempty"`
+}
+
+// styledRun is a piece of a paragraph's text with the styles it is set in, each mapped
+// to its color, or "" for styles without one.
+type styledRun struct {
+	text   string
+	styles map[string]string
+}
+
+// styleMarkers are the Markdown delimiters StyledMarkdown writes for each style.
+var styleMarkers = map[string][2]string{
+	StyleBold:          {"**", "**"},
+	StyleItalic:        {"*", "*"},
+	StyleUnderline:     {"<u>", "</u>"},
+	StyleStrikethrough: {"~~", "~~"},
+	StyleHighlight:     {"<mark>", "</mark>"},
+	StyleColor:         {`<span style="color: %s">`, "</span>"},
+}
+
+var (
+	markdownEmphasis = []struct {
+		style   string
+		pattern *regexp.Regexp
+	}{
+		{StyleStrikethrough, regexp.MustCompile(`~~([^~\s](?:[^~\n]*[^~\s])?)~~`)},
+		{StyleBold, regexp.MustCompile(`\*\*([^*\s](?:[^*\n]*[^*\s])?)\*\*`)},
+		{StyleBold, regexp.MustCompile(`\b__([^_\s](?:[^_\n]*[^_\s])?)__\b`)},
+		{StyleItalic, regexp.MustCompile(`(?:^|[^*])\*([^*\s](?:[^*\n]*[^*\s])?)\*(?:[^*]|$)`)},
+		{StyleItalic, regexp.MustCompile(`(?:^|[^\w_])_([^_\s](?:[^_\n]*[^_\s])?)_(?:[^\w_]|$)`)},
+	}
+	htmlTag      = regexp.MustCompile(`<(/?)([a-zA-Z][a-zA-Z0-9]*)[^>]*?(/?)>|<!--[\s\S]*?-->`)
+	htmlStyles   = map[string]string{"b": StyleBold, "strong": StyleBold, "i": StyleItalic, "em": StyleItalic, "u": StyleUnderline, "ins": StyleUnderline, "s": StyleStrikethrough, "strike": StyleStrikethrough, "del": StyleStrikethrough}
+	htmlBlocks   = map[string]bool{"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "blockquote": true, "pre": true, "section": true, "article": true, "header": true, "footer": true, "table": true, "ul": true, "ol": true, "dt": true, "dd": true, "hr": true, "title": true}
+	htmlSpace    = regexp.MustCompile(`\s+`)
+	htmlSkipped  = map[string]bool{"script": true, "style": true, "head": true, "noscript": true, "template": true}
+	allStyleKeys = []string{StyleBold, StyleItalic, StyleUnderline, StyleStrikethrough, StyleHighlight, StyleColor}
+	// docxHighlights maps the named highlight colors of Word documents to their values.
+	docxHighlights = map[string]string{
+		"black": "#000000", "blue": "#0000FF", "cyan": "#00FFFF", "green": "#00FF00", "magenta": "#FF00FF",
+		"red": "#FF0000", "yellow": "#FFFF00", "white": "#FFFFFF", "darkBlue": "#000080", "darkCyan": "#008080",
+		"darkGreen": "#008000", "darkMagenta": "#800080", "darkRed": "#800000", "darkYellow": "#808000",
+		"darkGray": "#808080", "lightGray": "#C0C0C0",
+	}
+)
+
+func validateInlineStyleConfig(cfg *InlineStyleConfig) error {
+	for _, style := range cfg.Styles {
+		if !slices.Contains(allStyleKeys, style) {
+			return newValidationErrorWithContext(fmt.Sprintf("invalid inline style: %s", style), nil, ErrorCodeValidation, nil)
+		}
+	}
+	return nil
+}
+
+// applyInlineStyleStage annotates the inline styles of a result: those of the runs of
+// Word documents, the markup of HTML and the text markup annotations and fill colors of
+// PDFs, read from the original returned by read, and the emphasis of Markdown content.
+// The annotations of PDFs are matched to the text layer returned by layer. It never fails
+// the extraction.
+func applyInlineStyleStage(result *ExtractionResult, read func() ([]byte, error), layer func() ([]PageImage, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.InlineStyles == nil || result.Content == "" {
+		return
+	}
+	var spans []StyleSpan
+	if isMarkdownResult(result, config) {
+		spans = markdownStyleSpans(result.Content)
+	}
+	var paragraphs [][]styledRun
+	switch result.MimeType {
+	case "application/pdf":
+		if data, err := read(); err == nil {
+			spans = append(spans, pdfStyleSpans(result, data, layer, config.InlineStyles.Styles)...)
+		}
+	case docxMimeType:
+		if data, err := read(); err == nil {
+			paragraphs = docxStyledParagraphs(data)
+		}
+	case "text/html", "application/xhtml+xml":
+		if data, err := read(); err == nil {
+			paragraphs = htmlStyledParagraphs(string(data))
+		}
+	}
+	spans = append(spans, locateStyledRuns(result.Content, paragraphs)...)
+	result.StyleSpans = filterStyleSpans(spans, config.InlineStyles.Styles)
+}
+
+// isMarkdownResult reports whether result's Content is Markdown.
+func isMarkdownResult(result *ExtractionResult, config *ExtractionConfig) bool {
+	switch config.OutputFormat {
+	case string(OutputFormatMarkdown), string(OutputFormatMd):
+		return true
+	}
+	return result.MimeType == "text/markdown" || result.MimeType == "text/x-markdown"
+}
+
+// filterStyleSpans drops spans of styles not in styles, when given, and duplicates, and
+// sorts the rest by position.
+func filterStyleSpans(spans []StyleSpan, styles []string) []StyleSpan {
+	seen := map[StyleSpan]bool{}
+	var kept []StyleSpan
+	for _, span := range spans {
+		if seen[span] || span.ByteStart >= span.ByteEnd || len(styles) > 0 && !slices.Contains(styles, span.Style) {
+			continue
+		}
+		seen[span] = true
+		kept = append(kept, span)
+	}
+	sort.SliceStable(kept, func(i, j int) bool {
+		if kept[i].ByteStart != kept[j].ByteStart {
+			return kept[i].ByteStart < kept[j].ByteStart
+		}
+		return kept[i].ByteEnd > kept[j].ByteEnd
+	})
+	return kept
+}
+
+// markdownStyleSpans returns the emphasized text of Markdown content, outside code.
+func markdownStyleSpans(content string) []StyleSpan {
+	var spans []StyleSpan
+	fence := ""
+	forEachLine(content, func(pos int, line string) {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) {
+				fence = ""
+			}
+			return
+		}
+		if fence = fenceMarker(line); fence != "" {
+			return
+		}
+		code := codeSpans(line)
+		for _, emphasis := range markdownEmphasis {
+			for _, match := range emphasis.pattern.FindAllStringSubmatchIndex(line, -1) {
+				inCode := false
+				for _, span := range code {
+					inCode = inCode || match[2] >= span[0] && match[2] < span[1]
+				}
+				if !inCode {
+					spans = append(spans, StyleSpan{Style: emphasis.style, ByteStart: uint64(pos + match[2]), ByteEnd: uint64(pos + match[3])})
+				}
+			}
+		}
+	})
+	return spans
+}
+
+// codeSpans returns the byte ranges of the `code` spans of a line.
+func codeSpans(line string) [][2]int {
+	var spans [][2]int
+	for start := 0; ; {
+		open := strings.IndexByte(line[start:], '`')
+		if open < 0 {
+			return spans
+		}
+		open += start
+		end := strings.IndexByte(line[open+1:], '`')
+		if end < 0 {
+			return spans
+		}
+		end += open + 2
+		spans = append(spans, [2]int{open, end})
+		start = end
+	}
+}
+
+// locateStyledRuns finds the styled text of paragraphs in content, in order. A paragraph
+// found verbatim places its runs exactly; otherwise each styled stretch is searched for on
+// its own after the previous one.
+func locateStyledRuns(content string, paragraphs [][]styledRun) []StyleSpan {
+	var spans []StyleSpan
+	cursor := 0
+	for _, runs := range paragraphs {
+		var text strings.Builder
+		for _, run := range runs {
+			text.WriteString(run.text)
+		}
+		paragraph := text.String()
+		trimmed := strings.TrimSpace(paragraph)
+		if trimmed == "" {
+			continue
+		}
+		lead := strings.Index(paragraph, trimmed)
+		ranges := styledRanges(runs)
+		if at := strings.Index(content[cursor:], trimmed); at >= 0 {
+			start := cursor + at - lead
+			for _, r := range ranges {
+				spans = append(spans, StyleSpan{Style: r.style, ByteStart: uint64(start + r.start), ByteEnd: uint64(start + r.end), Color: r.color})
+			}
+			cursor = start + lead + len(trimmed)
+			continue
+		}
+		for _, r := range ranges {
+			if start, end := findText(content, cursor, paragraph[r.start:r.end]); start >= 0 {
+				spans = append(spans, StyleSpan{Style: r.style, ByteStart: uint64(start), ByteEnd: uint64(end), Color: r.color})
+				cursor = start
+			}
+		}
+	}
+	return spans
+}
+
+// findText returns the byte range of the first occurrence of text in content at or after
+// from, or -1. Whitespace in text matches any run of whitespace, as extraction may break
+// lines differently from the source.
+func findText(content string, from int, text string) (int, int) {
+	if at := strings.Index(content[from:], text); at >= 0 {
+		return from + at, from + at + len(text)
+	}
+	words := strings.Fields(text)
+	if len(words) < 2 {
+		return -1, -1
+	}
+	for i, word := range words {
+		words[i] = regexp.QuoteMeta(word)
+	}
+	match := regexp.MustCompile(strings.Join(words, `\s+`)).FindStringIndex(content[from:])
+	if match == nil {
+		return -1, -1
+	}
+	return from + match[0], from + match[1]
+}
+
+// styledRange is a stretch of a paragraph's text in one style and color.
+type styledRange struct {
+	style, color string
+	start, end   int
+}
+
+// styledRanges merges the runs of a paragraph into maximal stretches per style and color,
+// without surrounding whitespace.
+func styledRanges(runs []styledRun) []styledRange {
+	var ranges []styledRange
+	var paragraph strings.Builder
+	for _, run := range runs {
+		paragraph.WriteString(run.text)
+	}
+	text := paragraph.String()
+	type openRange struct {
+		start int
+		color string
+	}
+	open := map[string]openRange{}
+	offset := 0
+	closeStyle := func(style string, end int) {
+		start, color := open[style].start, open[style].color
+		delete(open, style)
+		for start < end {
+			r, size := utf8.DecodeRuneInString(text[start:])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			start += size
+		}
+		for end > start {
+			r, size := utf8.DecodeLastRuneInString(text[:end])
+			if !unicode.IsSpace(r) {
+				break
+			}
+			end -= size
+		}
+		if end > start {
+			ranges = append(ranges, styledRange{style: style, color: color, start: start, end: end})
+		}
+	}
+	for _, run := range runs {
+		if strings.TrimSpace(run.text) == "" {
+			offset += len(run.text)
+			continue
+		}
+		for _, style := range allStyleKeys {
+			current, isOpen := open[style]
+			color, styled := run.styles[style]
+			if isOpen && (!styled || color != current.color) {
+				closeStyle(style, offset)
+				isOpen = false
+			}
+			if styled && !isOpen {
+				open[style] = openRange{start: offset, color: color}
+			}
+		}
+		offset += len(run.text)
+	}
+	for _, style := range allStyleKeys {
+		if _, isOpen := open[style]; isOpen {
+			closeStyle(style, offset)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	return ranges
+}
+
+// docxStyledParagraphs reads the paragraphs of a Word document as styled runs. Run
+// properties and character styles set bold, italic, underline, strikethrough, font color,
+// and highlight, either a named highlight color or run shading.
+func docxStyledParagraphs(data []byte) [][]styledRun {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	characterStyles := docxCharacterStyles(docxPart(archive, "word/styles.xml"))
+
+	var paragraphs [][]styledRun
+	var runs []styledRun
+	var styles map[string]string
+	inProperties := false
+	decoder := xml.NewDecoder(bytes.NewReader(docxPart(archive, "word/document.xml")))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return paragraphs
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch local := token.Name.Local; local {
+			case "p":
+				runs = nil
+			case "r":
+				styles = map[string]string{}
+			case "rPr":
+				inProperties = styles != nil
+			case "rStyle":
+				if inProperties {
+					for style, color := range characterStyles[xmlAttr(token, "val")] {
+						styles[style] = color
+					}
+				}
+			case "b", "i", "strike", "dstrike", "u", "color", "highlight", "shd":
+				if inProperties {
+					docxRunProperty(styles, token)
+				}
+			case "t":
+				var value string
+				if decoder.DecodeElement(&value, &token) == nil && styles != nil {
+					runs = append(runs, styledRun{text: value, styles: styles})
+				}
+			case "tab":
+				if styles != nil && !inProperties {
+					runs = append(runs, styledRun{text: " ", styles: styles})
+				}
+			case "br", "cr":
+				if styles != nil {
+					runs = append(runs, styledRun{text: "\n", styles: styles})
+				}
+			}
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "rPr":
+				inProperties = false
+			case "r":
+				styles = nil
+			case "p":
+				paragraphs = append(paragraphs, runs)
+				runs = nil
+			}
+		}
+	}
+}
+
+// docxRunProperty applies a run property element such as <w:b/>, <w:u w:val="none"/> or
+// <w:color w:val="C00000"/>. Black and automatic font colors are not annotated.
+func docxRunProperty(styles map[string]string, element xml.StartElement) {
+	value := xmlAttr(element, "val")
+	switch element.Name.Local {
+	case "color":
+		if color := hexColor(value); color != "" && color != "#000000" {
+			styles[StyleColor] = color
+		} else {
+			delete(styles, StyleColor)
+		}
+	case "highlight":
+		if color := docxHighlights[value]; color != "" {
+			styles[StyleHighlight] = color
+		} else {
+			delete(styles, StyleHighlight)
+		}
+	case "shd":
+		if color := hexColor(xmlAttr(element, "fill")); color != "" && color != "#FFFFFF" {
+			styles[StyleHighlight] = color
+		}
+	default:
+		style := map[string]string{"b": StyleBold, "i": StyleItalic, "strike": StyleStrikethrough, "dstrike": StyleStrikethrough, "u": StyleUnderline}[element.Name.Local]
+		switch value {
+		case "0", "false", "off", "none":
+			delete(styles, style)
+		default:
+			styles[style] = ""
+		}
+	}
+}
+
+// hexColor returns a six-digit hex color such as "c00000" as "#C00000", or "".
+func hexColor(value string) string {
+	if len(value) != 6 || strings.Trim(strings.ToUpper(value), "0123456789ABCDEF") != "" {
+		return ""
+	}
+	return "#" + strings.ToUpper(value)
+}
+
+// docxCharacterStyles maps the character style IDs of a Word document's styles part to
+// the inline styles their run properties set.
+func docxCharacterStyles(data []byte) map[string]map[string]string {
+	characterStyles := map[string]map[string]string{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	var current map[string]string
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return characterStyles
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "style":
+			current = nil
+			if xmlAttr(start, "type") == "character" {
+				current = map[string]string{}
+				characterStyles[xmlAttr(start, "styleId")] = current
+			}
+		case "b", "i", "strike", "dstrike", "u", "color", "highlight", "shd":
+			if current != nil {
+				docxRunProperty(current, start)
+			}
+		}
+	}
+}
+
+// htmlStyledParagraphs reads the text of an HTML document as styled runs, a paragraph per
+// block element, with whitespace collapsed as a browser would.
+func htmlStyledParagraphs(document string) [][]styledRun {
+	var paragraphs [][]styledRun
+	var runs []styledRun
+	open := map[string]int{}
+	skipping := ""
+	endParagraph := func() {
+		if len(runs) > 0 {
+			paragraphs = append(paragraphs, runs)
+		}
+		runs = nil
+	}
+	addText := func(text string) {
+		text = htmlSpace.ReplaceAllString(html.UnescapeString(text), " ")
+		if len(runs) == 0 || strings.HasSuffix(runs[len(runs)-1].text, " ") {
+			text = strings.TrimLeft(text, " ")
+		}
+		if text == "" {
+			return
+		}
+		styles := map[string]string{}
+		for style, depth := range open {
+			if depth > 0 {
+				styles[style] = ""
+			}
+		}
+		runs = append(runs, styledRun{text: text, styles: styles})
+	}
+
+	position := 0
+	for _, match := range htmlTag.FindAllStringSubmatchIndex(document, -1) {
+		if skipping == "" {
+			addText(document[position:match[0]])
+		}
+		position = match[1]
+		if match[4] < 0 {
+			continue // a comment
+		}
+		closing := match[3] > match[2]
+		name := strings.ToLower(document[match[4]:match[5]])
+		switch {
+		case skipping != "":
+			if closing && name == skipping {
+				skipping = ""
+			}
+		case htmlSkipped[name] && !closing && match[7] == match[6]:
+			skipping = name
+		case htmlStyles[name] != "":
+			if closing {
+				open[htmlStyles[name]]--
+			} else {
+				open[htmlStyles[name]]++
+			}
+		case htmlBlocks[name]:
+			endParagraph()
+		}
+	}
+	if skipping == "" {
+		addText(document[position:])
+	}
+	endParagraph()
+	return paragraphs
+}
+
+// pdfMarkupStyles maps the subtypes of PDF text markup annotations to the styles they mark.
+var pdfMarkupStyles = map[pdfName]string{"Highlight": StyleHighlight, "Underline": StyleUnderline, "Squiggly": StyleUnderline, "StrikeOut": StyleStrikethrough}
+
+// pdfMarkup is a text markup annotation of a PDF page: the style it marks, the color of a
+// highlight, and the areas it covers as [left, bottom, right, top] from the lower-left
+// corner of the page.
+type pdfMarkup struct {
+	style, color string
+	areas        [][4]float64
+}
+
+// pdfStyleSpans annotates a PDF's highlight, underline and strike-out annotations and its
+// text shown in a fill color other than black. Annotations are matched to the words of
+// each page's text layer; colored text is read from the page content streams, so text
+// set in fonts without a readable encoding is missed. Each page's styled text is searched
+// for in that page's part of Content. layer returns the text layer of data's pages.
+func pdfStyleSpans(result *ExtractionResult, data []byte, layer func() ([]PageImage, error), styles []string) []StyleSpan {
+	if !isPDF(data) {
+		return nil
+	}
+	objects := readPDFObjects(data)
+	catalog := objects.catalog()
+	if catalog == nil {
+		return nil
+	}
+	wanted := func(style string) bool { return len(styles) == 0 || slices.Contains(styles, style) }
+	pages := objects.pageRefs(catalog)
+	sources := make([][][]styledRun, len(pages))
+	markup := make([][]pdfMarkup, len(pages))
+	annotated := false
+	for i, ref := range pages {
+		if wanted(StyleColor) {
+			sources[i] = append(sources[i], pdfColoredRuns(objects.pageContent(ref)))
+		}
+		markup[i] = pdfMarkupAnnotations(objects, ref, wanted)
+		annotated = annotated || len(markup[i]) > 0
+	}
+	if annotated {
+		rendered, err := layer()
+		if err == nil {
+			for _, page := range rendered {
+				if i := page.PageNumber - 1; i >= 0 && i < len(pages) {
+					sources[i] = append(sources[i], markupRuns(markup[i], page)...)
+				}
+			}
+		}
+	}
+
+	var spans []StyleSpan
+	for i, paragraphs := range sources {
+		start, end := pageContentRange(result, i+1)
+		for _, runs := range paragraphs {
+			for _, span := range locateStyledRuns(result.Content[start:end], [][]styledRun{runs}) {
+				span.ByteStart += uint64(start)
+				span.ByteEnd += uint64(start)
+				spans = append(spans, span)
+			}
+		}
+	}
+	return spans
+}
+
+// pageContentRange returns the byte range of a page in result's Content, or all of Content
+// when its page boundaries are unknown.
+func pageContentRange(result *ExtractionResult, page int) (int, int) {
+	if structure := result.Metadata.PageStructure; structure != nil {
+		for _, boundary := range structure.Boundaries {
+			if boundary.PageNumber == uint64(page) && boundary.ByteStart <= boundary.ByteEnd && boundary.ByteEnd <= uint64(len(result.Content)) {
+				return int(boundary.ByteStart), int(boundary.ByteEnd)
+			}
+		}
+	}
+	return 0, len(result.Content)
+}
+
+// pdfMarkupAnnotations returns the text markup annotations of a page in wanted styles.
+// Highlights without a color are yellow.
+func pdfMarkupAnnotations(objects pdfObjects, page pdfRef, wanted func(string) bool) []pdfMarkup {
+	left, bottom := 0.0, 0.0
+	if box, ok := pdfNumbers(objects.inherited(page, "MediaBox")); ok && len(box) == 4 {
+		left, bottom = min(box[0], box[2]), min(box[1], box[3])
+	}
+	annotations, _ := objects.resolve(objects.dict(page)["Annots"]).([]any)
+	var markup []pdfMarkup
+	for _, value := range annotations {
+		annotation := objects.dict(value)
+		subtype, _ := annotation["Subtype"].(pdfName)
+		style := pdfMarkupStyles[subtype]
+		if style == "" || !wanted(style) {
+			continue
+		}
+		m := pdfMarkup{style: style}
+		if style == StyleHighlight {
+			m.color = "#FFFF00"
+			if components, ok := pdfNumbers(objects.resolve(annotation["C"])); ok && pdfColor(components) != "" {
+				m.color = pdfColor(components)
+			}
+		}
+		points, ok := pdfNumbers(objects.resolve(annotation["QuadPoints"]))
+		if !ok || len(points) < 8 {
+			points = nil
+			if rect, ok := pdfNumbers(objects.resolve(annotation["Rect"])); ok && len(rect) == 4 {
+				points = []float64{rect[0], rect[1], rect[2], rect[1], rect[0], rect[3], rect[2], rect[3]}
+			}
+		}
+		for q := 0; q+8 <= len(points); q += 8 {
+			xs := []float64{points[q], points[q+2], points[q+4], points[q+6]}
+			ys := []float64{points[q+1], points[q+3], points[q+5], points[q+7]}
+			m.areas = append(m.areas, [4]float64{slices.Min(xs) - left, slices.Min(ys) - bottom, slices.Max(xs) - left, slices.Max(ys) - bottom})
+		}
+		if len(m.areas) > 0 {
+			markup = append(markup, m)
+		}
+	}
+	return markup
+}
+
+// markupRuns returns the words of a page's text layer centered under each markup
+// annotation as a paragraph of one run. The page is read at textLayerDPI.
+func markupRuns(markup []pdfMarkup, page PageImage) [][]styledRun {
+	var paragraphs [][]styledRun
+	for _, m := range markup {
+		var words []string
+		for _, word := range page.Words {
+			x, y := word.Left+word.Width/2, float64(page.Height)-(word.Top+word.Height/2)
+			if slices.ContainsFunc(m.areas, func(area [4]float64) bool {
+				return x >= area[0] && x <= area[2] && y >= area[1] && y <= area[3]
+			}) {
+				words = append(words, word.Text)
+			}
+		}
+		if len(words) > 0 {
+			paragraphs = append(paragraphs, []styledRun{{text: strings.Join(words, " "), styles: map[string]string{m.style: m.color}}})
+		}
+	}
+	return paragraphs
+}
+
+// pdfColoredRuns reads the text a page's content stream shows as runs styled with the
+// fill color it is shown in, when not black. Text that does not decode to printable
+// characters, as with fonts without a simple encoding, is left out.
+func pdfColoredRuns(content []byte) []styledRun {
+	var runs []styledRun
+	fill := ""
+	var saved []string
+	show := func(text string) {
+		if strings.ContainsFunc(text, func(r rune) bool { return r < ' ' || r >= 0x7F && r < 0xA0 || r == utf8.RuneError }) {
+			return
+		}
+		styles := map[string]string{}
+		if fill != "" && fill != "#000000" {
+			styles[StyleColor] = fill
+		}
+		runs = append(runs, styledRun{text: text, styles: styles})
+	}
+	pdfOperations(content, func(operator string, operands []any) {
+		var last any
+		if len(operands) > 0 {
+			last = operands[len(operands)-1]
+		}
+		text, _ := last.(string)
+		switch operator {
+		case "q":
+			saved = append(saved, fill)
+		case "Q":
+			if n := len(saved); n > 0 {
+				fill, saved = saved[n-1], saved[:n-1]
+			}
+		case "g", "rg", "k", "sc", "scn":
+			components, _ := pdfNumbers(operands)
+			fill = pdfColor(components)
+		case "cs":
+			fill = ""
+		case "BT", "Td", "TD", "T*", "Tm":
+			show(" ")
+		case "Tj":
+			show(text)
+		case "'", "\"":
+			show(" ")
+			show(text)
+		case "TJ":
+			var shown strings.Builder
+			items, _ := last.([]any)
+			for _, item := range items {
+				switch item := item.(type) {
+				case string:
+					shown.WriteString(item)
+				case float64:
+					// A large negative adjustment moves right by a word space or more.
+					if item < -200 {
+						shown.WriteString(" ")
+					}
+				}
+			}
+			show(shown.String())
+		}
+	})
+	return runs
+}
+
+// pdfColor returns the gray, RGB or CMYK color given by its components as "#RRGGBB", or
+// "" for any other number of components.
+func pdfColor(components []float64) string {
+	var r, g, b float64
+	switch len(components) {
+	case 1:
+		r, g, b = components[0], components[0], components[0]
+	case 3:
+		r, g, b = components[0], components[1], components[2]
+	case 4:
+		k := components[3]
+		r, g, b = (1-components[0])*(1-k), (1-components[1])*(1-k), (1-components[2])*(1-k)
+	default:
+		return ""
+	}
+	channel := func(v float64) int { return int(math.Round(math.Max(0, math.Min(1, v)) * 255)) }
+	return fmt.Sprintf("#%02X%02X%02X", channel(r), channel(g), channel(b))
+}
+
+// StyledMarkdown returns content with the styled spans wrapped in Markdown emphasis:
+// **bold**, *italic* and ~~strikethrough~~, or in the HTML Markdown allows for the rest:
+// <u>underline</u>, <mark>highlight</mark> and <span style="color: #C00000">color</span>.
+// Spans already wrapped in their delimiters are left as they are.
+func StyledMarkdown(content string, spans []StyleSpan) string {
+	type insertion struct {
+		at     int
+		text   string
+		order  int
+		length int
+	}
+	var insertions []insertion
+	for _, span := range spans {
+		markers, ok := styleMarkers[span.Style]
+		start, end := int(span.ByteStart), int(span.ByteEnd)
+		if !ok || start >= end || end > len(content) {
+			continue
+		}
+		opening := markers[0]
+		if span.Style == StyleColor {
+			opening = fmt.Sprintf(opening, span.Color)
+		}
+		if strings.HasSuffix(content[:start], opening) && strings.HasPrefix(content[end:], markers[1]) {
+			continue
+		}
+		// At one position, closing markers come first, inner ones before outer ones, and
+		// opening markers follow, outer ones before inner ones.
+		insertions = append(insertions,
+			insertion{at: start, text: opening, order: 1, length: -(end - start)},
+			insertion{at: end, text: markers[1], order: 0, length: end - start})
+	}
+	sort.SliceStable(insertions, func(i, j int) bool {
+		a, b := insertions[i], insertions[j]
+		if a.at != b.at {
+			return a.at < b.at
+		}
+		if a.order != b.order {
+			return a.order < b.order
+		}
+		return a.length < b.length
+	})
+	var out strings.Builder
+	position := 0
+	for _, ins := range insertions {
+		out.WriteString(content[position:ins.at])
+		out.WriteString(ins.text)
+		position = ins.at
+	}
+	out.WriteString(content[position:])
+	return out.String()
+}
@@ -0,0 +1,158 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// spanTexts returns "style:text" for each span of content.
+func spanTexts(content string, spans []StyleSpan) []string {
+	var out []string
+	for _, span := range spans {
+		out = append(out, span.Style+":"+content[span.ByteStart:span.ByteEnd])
+	}
+	return out
+}
+
+func TestMarkdownStyleSpans(t *testing.T) {
+	content := "A **bold** and *italic* word, ~~void~~ and __strong__ _em_.\n" +
+		"Math 2 * 3 * 4 and `**code**` stay.\n" +
+		"```\n**fenced**\n```\n"
+	spans := filterStyleSpans(markdownStyleSpans(content), nil)
+	want := []string{"bold:bold", "italic:italic", "strikethrough:void", "bold:strong", "italic:em"}
+	if got := spanTexts(content, spans); !reflect.DeepEqual(got, want) {
+		t.Errorf("markdownStyleSpans = %q, want %q", got, want)
+	}
+}
+
+func TestDOCXStyledParagraphs(t *testing.T) {
+	styles := `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="character" w:styleId="Strong"><w:rPr><w:b/></w:rPr></w:style>
+</w:styles>`
+	document := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:pPr><w:rPr><w:b/></w:rPr></w:pPr><w:r><w:t xml:space="preserve">The buyer </w:t></w:r><w:r><w:rPr><w:strike/></w:rPr><w:t xml:space="preserve">shall </w:t></w:r><w:r><w:rPr><w:strike/></w:rPr><w:t>not</w:t></w:r><w:r><w:t xml:space="preserve"> pay.</w:t></w:r></w:p>
+<w:p><w:r><w:rPr><w:rStyle w:val="Strong"/><w:i/></w:rPr><w:t>Net</w:t></w:r><w:r><w:rPr><w:b w:val="0"/><w:u w:val="single"/></w:rPr><w:t xml:space="preserve"> 30 days</w:t></w:r></w:p>
+<w:p><w:r><w:rPr><w:highlight w:val="yellow"/></w:rPr><w:t xml:space="preserve">Liability </w:t></w:r><w:r><w:rPr><w:shd w:val="clear" w:fill="FFFF00"/></w:rPr><w:t>is capped</w:t></w:r><w:r><w:rPr><w:color w:val="auto"/></w:rPr><w:t xml:space="preserve"> at </w:t></w:r><w:r><w:rPr><w:color w:val="c00000"/></w:rPr><w:t>EUR 1M</w:t></w:r></w:p>
+</w:body></w:document>`
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"word/styles.xml": styles, "word/document.xml": document} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "Contract\n\nThe buyer shall not pay.\n\nNet 30 days\n\nLiability is capped at EUR 1M\n"
+	spans := filterStyleSpans(locateStyledRuns(content, docxStyledParagraphs(buf.Bytes())), nil)
+	want := []string{"strikethrough:shall not", "bold:Net", "italic:Net", "underline:30 days", "highlight:Liability is capped", "color:EUR 1M"}
+	if got := spanTexts(content, spans); !reflect.DeepEqual(got, want) {
+		t.Errorf("DOCX spans = %q, want %q", got, want)
+	}
+	if spans[4].Color != "#FFFF00" || spans[5].Color != "#C00000" {
+		t.Errorf("unexpected colors: %q, %q", spans[4].Color, spans[5].Color)
+	}
+}
+
+func TestPDFStyleSpans(t *testing.T) {
+	stream := "BT /F1 12 Tf 72 700 Td (The buyer ) Tj 1 0 0 rg (shall) Tj [( ) -250 (not)] TJ 0 g ( pay.) Tj ET\n" +
+		"q 0 0 1 rg BT 72 680 Td (Blue) Tj ET Q BT 72 660 Td (Black again) Tj ET"
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 /MediaBox [0 0 612 792] >>",
+		"<< /Type /Page /Parent 2 0 R /Contents 4 0 R /Annots [5 0 R 6 0 R] >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(stream), stream),
+		"<< /Type /Annot /Subtype /Highlight /Rect [70 690 200 715] /QuadPoints [70 715 200 715 70 690 200 690] /C [0 1 0] >>",
+		"<< /Type /Annot /Subtype /StrikeOut /Rect [70 650 200 670] >>",
+	}
+	pdf := "%PDF-1.7\n"
+	for i, object := range objects {
+		pdf += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	pdf += "trailer\n<< /Root 1 0 R >>\n%%EOF\n"
+
+	content := "The buyer shall not pay.\nBlue\nBlack again\n"
+	result := &ExtractionResult{Content: content, MimeType: "application/pdf"}
+	spans := filterStyleSpans(pdfStyleSpans(result, []byte(pdf), func() ([]PageImage, error) { return nil, errors.New("not read") }, []string{StyleColor}), nil)
+	want := []string{"color:shall not", "color:Blue"}
+	if got := spanTexts(content, spans); !reflect.DeepEqual(got, want) {
+		t.Errorf("PDF color spans = %q, want %q", got, want)
+	}
+	if spans[0].Color != "#FF0000" || spans[1].Color != "#0000FF" {
+		t.Errorf("unexpected colors: %q, %q", spans[0].Color, spans[1].Color)
+	}
+
+	parsed := readPDFObjects([]byte(pdf))
+	markup := pdfMarkupAnnotations(parsed, parsed.pageRefs(parsed.catalog())[0], func(string) bool { return true })
+	page := PageImage{PageNumber: 1, Height: 792, Words: []PageWord{
+		{Text: "The", Left: 72, Top: 80, Width: 20, Height: 12},
+		{Text: "buyer", Left: 96, Top: 80, Width: 30, Height: 12},
+		{Text: "Black", Left: 72, Top: 124, Width: 30, Height: 12},
+		{Text: "again", Left: 106, Top: 124, Width: 30, Height: 12},
+		{Text: "Elsewhere", Left: 300, Top: 80, Width: 50, Height: 12},
+	}}
+	spans = filterStyleSpans(locateStyledRuns(content, markupRuns(markup, page)), nil)
+	want = []string{"highlight:The buyer", "strikethrough:Black again"}
+	if got := spanTexts(content, spans); !reflect.DeepEqual(got, want) {
+		t.Errorf("PDF markup spans = %q, want %q", got, want)
+	}
+	if spans[0].Color != "#00FF00" {
+		t.Errorf("unexpected highlight color: %q", spans[0].Color)
+	}
+}
+
+func TestInlineStyleStage(t *testing.T) {
+	config := NewExtractionConfig(WithInlineStyles(WithStyles("blink")))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	document := `<html><head><title>Terms</title><style>b { color: red }</style></head><body>
+<h1>Terms</h1>
+<p>Payment is due in <del>60</del> <ins>30</ins> days, <b>without   <i>any</i> deduction</b>.</p>
+<!-- <b>hidden</b> -->
+</body></html>`
+	content := "Terms\n\nPayment is due in 60 30 days, without any deduction.\n"
+	result := &ExtractionResult{Content: content, MimeType: "text/html"}
+	config = NewExtractionConfig(WithInlineStyles())
+	applyInlineStyleStage(result, func() ([]byte, error) { return []byte(document), nil }, func() ([]PageImage, error) { return nil, errors.New("not read") }, config)
+	want := []string{"strikethrough:60", "underline:30", "bold:without any deduction", "italic:any"}
+	if got := spanTexts(content, result.StyleSpans); !reflect.DeepEqual(got, want) {
+		t.Errorf("HTML spans = %q, want %q", got, want)
+	}
+
+	markdown := StyledMarkdown(content, result.StyleSpans)
+	if !strings.Contains(markdown, "~~60~~ <u>30</u> days, **without *any* deduction**.") {
+		t.Errorf("unexpected Markdown: %q", markdown)
+	}
+	colored := StyledMarkdown("Pay EUR 1M now.", []StyleSpan{{Style: StyleColor, ByteStart: 4, ByteEnd: 10, Color: "#C00000"}, {Style: StyleHighlight, ByteStart: 0, ByteEnd: 14, Color: "#FFFF00"}})
+	if want := `<mark>Pay <span style="color: #C00000">EUR 1M</span> now</mark>.`; colored != want {
+		t.Errorf("StyledMarkdown = %q, want %q", colored, want)
+	}
+
+	config = NewExtractionConfig(WithInlineStyles(WithStyles(StyleStrikethrough)))
+	applyInlineStyleStage(result, func() ([]byte, error) { return []byte(document), nil }, func() ([]PageImage, error) { return nil, errors.New("not read") }, config)
+	if got := spanTexts(content, result.StyleSpans); !reflect.DeepEqual(got, []string{"strikethrough:60"}) {
+		t.Errorf("filtered spans = %q", got)
+	}
+}
+
+func TestStyledMarkdownKeepsExistingEmphasis(t *testing.T) {
+	content := "Keep **this** as is."
+	spans := markdownStyleSpans(content)
+	if got := StyledMarkdown(content, spans); got != content {
+		t.Errorf("StyledMarkdown = %q, want %q", got, content)
+	}
+}
@@ -649,6 +649,127 @@ int kreuzberg_extract_batch_parallel(const char *const *files,
                                      struct Option_ErrorCallback error_callback,
                                      uintptr_t max_parallel);
 
+/**
+ * Extract a byte array and return the result as JSON, zstd-compressed when its content
+ * holds at least `compress_above` bytes.
+ *
+ * The JSON object has the fields of the core `ExtractionResult` and the `success` flag of
+ * `CExtractionResult`. Compressed buffers start
+ * with the zstd magic number `28 B5 2F FD`.
+ *
+ * # Safety
+ *
+ * - `data` must be a valid pointer to a byte array of length `data_len`
+ * - `mime_type` must be a valid null-terminated C string
+ * - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+ * - `out_len` must be a valid pointer; it receives the length of the returned buffer
+ * - The returned buffer must be freed with `kreuzberg_free_bytes`
+ * - Returns NULL on error (check `kreuzberg_last_error` for details)
+ */
+uint8_t *kreuzberg_extract_bytes_compressed(const uint8_t *data,
+                                            uintptr_t data_len,
+                                            const char *mime_type,
+                                            const char *config_json,
+                                            uintptr_t compress_above,
+                                            uintptr_t *out_len);
+
+/**
+ * Extract a file and return the result as JSON, zstd-compressed when its content holds
+ * at least `compress_above` bytes. See `kreuzberg_extract_bytes_compressed`.
+ *
+ * # Safety
+ *
+ * - `file_path` must be a valid null-terminated C string
+ * - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+ * - `out_len` must be a valid pointer; it receives the length of the returned buffer
+ * - The returned buffer must be freed with `kreuzberg_free_bytes`
+ * - Returns NULL on error (check `kreuzberg_last_error` for details)
+ */
+uint8_t *kreuzberg_extract_file_compressed(const char *file_path,
+                                           const char *config_json,
+                                           uintptr_t compress_above,
+                                           uintptr_t *out_len);
+
+/**
+ * Batch extract files and return their results packed into one JSON array, in the order
+ * of `file_paths`, zstd-compressed when their contents hold at least `compress_above`
+ * bytes together.
+ *
+ * # Safety
+ *
+ * - `file_paths` must be a valid pointer to an array of `count` null-terminated C strings
+ * - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+ * - `out_len` must be a valid pointer; it receives the length of the returned buffer
+ * - The returned buffer must be freed with `kreuzberg_free_bytes`
+ * - Returns NULL on error (check `kreuzberg_last_error` for details)
+ */
+uint8_t *kreuzberg_batch_extract_files_packed(const char *const *file_paths,
+                                              uintptr_t count,
+                                              const char *config_json,
+                                              uintptr_t compress_above,
+                                              uintptr_t *out_len);
+
+/**
+ * Batch extract byte arrays and return their results packed into one JSON array. See
+ * `kreuzberg_batch_extract_files_packed`.
+ *
+ * # Safety
+ *
+ * - `items` must be a valid pointer to an array of `count` CBytesWithMime structures
+ * - `config_json` must be a valid null-terminated C string containing JSON, or NULL for default config
+ * - `out_len` must be a valid pointer; it receives the length of the returned buffer
+ * - The returned buffer must be freed with `kreuzberg_free_bytes`
+ * - Returns NULL on error (check `kreuzberg_last_error` for details)
+ */
+uint8_t *kreuzberg_batch_extract_bytes_packed(const struct CBytesWithMime *items,
+                                              uintptr_t count,
+                                              const char *config_json,
+                                              uintptr_t compress_above,
+                                              uintptr_t *out_len);
+
+/**
+ * Free a buffer returned by `kreuzberg_extract_bytes_compressed`,
+ * `kreuzberg_extract_file_compressed` or the packed batch functions.
+ *
+ * # Safety
+ *
+ * - `data` must have been returned by one of those functions, with `len` the length it reported
+ * - `data` must not be used after this call
+ * - Passing NULL is a no-op
+ */
+void kreuzberg_free_bytes(uint8_t *data, uintptr_t len);
+
+/**
+ * Folded stacks: one `thread;outer;...;inner count` line per sampled stack.
+ */
+#define PROFILE_FORMAT_FOLDED 0
+
+/**
+ * An SVG flame graph.
+ */
+#define PROFILE_FORMAT_FLAMEGRAPH 1
+
+/**
+ * Start sampling the CPU time of the process at `frequency` Hz.
+ *
+ * Returns 0 on success and -1 on error (check `kreuzberg_last_error` for details), including
+ * when a profile is already being captured or the library was built without the
+ * `profiling` feature.
+ */
+int32_t kreuzberg_profiling_start(int32_t frequency);
+
+/**
+ * Stop the profile started with `kreuzberg_profiling_start` and return it encoded in
+ * `format`, `PROFILE_FORMAT_FOLDED` (0) or `PROFILE_FORMAT_FLAMEGRAPH` (1).
+ *
+ * # Safety
+ *
+ * - `out_len` must be a valid pointer; it receives the length of the returned buffer
+ * - The returned buffer must be freed with `kreuzberg_free_bytes`
+ * - Returns NULL on error (check `kreuzberg_last_error` for details)
+ */
+uint8_t *kreuzberg_profiling_stop(int32_t format, uintptr_t *out_len);
+
 /**
  * Parse an ExtractionConfig from a JSON string.
  *
@@ -762,6 +883,23 @@ ExtractionConfig *kreuzberg_config_from_file(const char *path);
  */
 char *kreuzberg_config_discover(void);
 
+/**
+ * Convert a configuration document between JSON, YAML, and TOML.
+ *
+ * The document is converted as plain data: fields are neither validated nor filled
+ * with defaults, so settings handled by the bindings survive the conversion.
+ * `from_format` and `to_format` are "json", "yaml", or "toml".
+ *
+ * # Safety
+ *
+ * - `content`, `from_format`, and `to_format` must be valid null-terminated C strings
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_config_convert_format(const char *content,
+                                      const char *from_format,
+                                      const char *to_format);
+
 /**
  * List available embedding preset names.
  *
@@ -1422,6 +1560,21 @@ struct CBatchResult *kreuzberg_batch_extract_bytes_sync(const struct CBytesWithM
                                                         uintptr_t count,
                                                         const char *config_json);
 
+/**
+ * Redirect the temporary files that extractions spool to disk, such as the documents
+ * converted by LibreOffice, to the directory `dir`. NULL restores the system temporary
+ * directory.
+ *
+ * The setting is process-wide and applies to extractions started afterwards. The
+ * directory must exist; the library creates and removes its files within it.
+ *
+ * # Safety
+ *
+ * - `dir` must be NULL or a valid null-terminated C string
+ * - Returns 0 on success and -1 on error (check `kreuzberg_last_error`)
+ */
+int32_t kreuzberg_set_temp_dir(const char *dir);
+
 /**
  * Parse HeadingStyle from string to discriminant.
  *
@@ -1730,6 +1883,128 @@ char *kreuzberg_detect_mime_type_from_path(const char *file_path);
  */
 char *kreuzberg_get_extensions_for_mime(const char *mime_type);
 
+/**
+ * Arm a fault: the next call of the FFI function named `function` panics inside its panic
+ * guard, so bindings can test that they recover. NULL disarms the pending fault.
+ *
+ * For tests only. Returns 0 on success and -1 on error (check `kreuzberg_last_error`),
+ * including when the library was built without the `fault-injection` feature.
+ *
+ * # Safety
+ *
+ * - `function` must be NULL or a valid null-terminated C string
+ */
+int32_t kreuzberg_inject_fault(const char *function);
+
+/**
+ * Fingerprint every page of a PDF.
+ *
+ * Returns a JSON array with one hex-encoded 64-bit hash per page, in page order. A page
+ * hash covers its content streams, its attributes including inherited ones, and the
+ * objects they reference, such as fonts, images, form XObjects, and annotations.
+ *
+ * # Safety
+ *
+ * - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_pdf_page_hashes(const uint8_t *pdf_bytes, uintptr_t len);
+
+/**
+ * Probe a PDF without extracting it.
+ *
+ * Returns a JSON object with `page_count`, `text_pages` (pages whose content streams
+ * show text), and `encrypted`.
+ *
+ * # Safety
+ *
+ * - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_pdf_probe(const uint8_t *pdf_bytes, uintptr_t len);
+
+/**
+ * Parse an email message (.eml or .msg) without rendering it.
+ *
+ * Returns a JSON object with the headers, the plain text and HTML bodies, and the
+ * attachments with base64-encoded content.
+ *
+ * # Safety
+ *
+ * - `data` must point to a valid buffer of at least `len` bytes
+ * - `mime_type` must be a valid null-terminated C string
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_email_parse(const uint8_t *data, uintptr_t len, const char *mime_type);
+
+/**
+ * Build a PDF holding only the given pages of another PDF.
+ *
+ * `pages_json` is a JSON array of 1-indexed page numbers. Returns the new PDF encoded as
+ * base64.
+ *
+ * # Safety
+ *
+ * - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+ * - `pages_json` must be a valid null-terminated C string
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_pdf_select_pages(const uint8_t *pdf_bytes, uintptr_t len, const char *pages_json);
+
+/**
+ * Render PDF pages to PNG or JPEG images.
+ *
+ * `options_json` is a JSON object with optional fields `first_page` (1-indexed, default 1),
+ * `last_page` (inclusive, default: last page), `dpi` (1 to 600, default 72), `format` ("png"
+ * or "jpeg", default "png"), `password`, `text_layer` (default false) and `mime_type`.
+ * With `mime_type` set to a PowerPoint type (PPTX or PPT), the document is converted to
+ * PDF with LibreOffice first. Pages wider or taller than 10000 pixels at the requested
+ * resolution fail the call. The result is a JSON array of objects with `page_number`,
+ * `width`, `height`, `format`, and base64-encoded `data`. With `text_layer`, each page also
+ * has `words`: objects with `text`, `left`, `top`, `width`, and `height` in pixels of the
+ * image, measured from its top-left corner.
+ *
+ * # Safety
+ *
+ * - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+ * - `options_json` must be a valid null-terminated C string or NULL for defaults
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ *
+ * # Example (C)
+ *
+ * ```c
+ * char* pages = kreuzberg_render_pdf_pages(data, len, "{\"last_page\": 1, \"dpi\": 96}");
+ * if (pages != NULL) {
+ *     printf("Rendered: %s\n", pages);
+ *     kreuzberg_free_string(pages);
+ * }
+ * ```
+ */
+char *kreuzberg_render_pdf_pages(const uint8_t *pdf_bytes, uintptr_t len, const char *options_json);
+
+/**
+ * Read the text layer of PDF pages without rendering them.
+ *
+ * `options_json` is a JSON object with optional fields `first_page` (1-indexed, default 1),
+ * `last_page` (inclusive, default: last page), `dpi` (1 to 600, default 72) and `password`.
+ * The result is a JSON array of objects with `page_number`, `width`, `height`, and `words`,
+ * sized and positioned as `kreuzberg_render_pdf_pages` reports them at the same `dpi` with
+ * `text_layer`, but without the image.
+ *
+ * # Safety
+ *
+ * - `pdf_bytes` must point to a valid buffer of at least `len` bytes
+ * - `options_json` must be a valid null-terminated C string or NULL for defaults
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_pdf_text_layer(const uint8_t *pdf_bytes, uintptr_t len, const char *options_json);
+
 /**
  * Register a custom DocumentExtractor via FFI callback.
  *
@@ -1799,6 +2074,19 @@ bool kreuzberg_unregister_document_extractor(const char *name);
  */
 char *kreuzberg_list_document_extractors(void);
 
+/**
+ * Name the DocumentExtractor that would handle a MIME type.
+ *
+ * Built-in extractors are registered first if no extraction has run yet.
+ *
+ * # Safety
+ *
+ * - `mime_type` must be a valid null-terminated C string
+ * - Returned string must be freed with `kreuzberg_free_string`.
+ * - Returns NULL on error, including when no extractor supports the MIME type (check `kreuzberg_last_error`).
+ */
+char *kreuzberg_document_extractor_for_mime(const char *mime_type);
+
 /**
  * Clear all registered DocumentExtractors.
  *
@@ -2769,6 +3057,13 @@ struct CStringInternStats kreuzberg_string_intern_stats(void);
  */
 void kreuzberg_string_intern_reset(void);
 
+/**
+ * Revision of the C API. It is bumped whenever a function changes signature or ownership
+ * rules, or bindings start relying on a new function, so that a binding can refuse a
+ * library older than the header it was built against.
+ */
+#define KREUZBERG_ABI_VERSION 2
+
 /**
  * Get the last error message from a failed operation.
  *
@@ -2830,6 +3125,7 @@ int32_t kreuzberg_last_error_code(void);
  * - function: Name of the function that panicked
  * - message: Panic message
  * - timestamp_secs: Unix timestamp when panic occurred
+ * - backtrace: Backtrace of the panicking thread, or null if unavailable
  *
  * # Safety
  *
@@ -2868,6 +3164,21 @@ char *kreuzberg_last_panic_context(void);
  */
 const char *kreuzberg_version(void);
 
+/**
+ * Get the version, ABI revision and enabled features of the library as a JSON object,
+ * for bindings to check at initialization that they can use it:
+ *
+ * ```json
+ * {"version": "4.2.2", "abi_version": 2, "features": ["pdf", "embeddings"]}
+ * ```
+ *
+ * # Safety
+ *
+ * - The returned string must be freed with `kreuzberg_free_string`
+ * - Returns NULL on error (check `kreuzberg_last_error`)
+ */
+char *kreuzberg_build_info(void);
+
 /**
  * Validates a binarization method string.
  *
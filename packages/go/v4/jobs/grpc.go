@@ -0,0 +1,238 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4/jobs/jobspb"
+)
+
+// RegisterService registers the jobs of m on s as the gRPC service kreuzberg.jobs.v1.Jobs
+// of jobspb:
+//
+//	server := grpc.NewServer()
+//	manager.RegisterService(server)
+//	err := server.Serve(listener)
+//
+// The service follows Handler: documents are streamed to Options.SpoolDir, up to the same
+// size, submitted configs lose their server settings, jobs are listed a page at a time
+// without results, and results are answered as ExtractionResult JSON.
+func (m *Manager) RegisterService(s grpc.ServiceRegistrar) {
+	jobspb.RegisterJobsServer(s, &jobsServer{m: m})
+}
+
+type jobsServer struct {
+	jobspb.UnimplementedJobsServer
+	m *Manager
+}
+
+func (s *jobsServer) SubmitJob(stream jobspb.Jobs_SubmitJobServer) error {
+	first, err := stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return status.Error(codes.InvalidArgument, "missing document")
+	}
+	if err != nil {
+		return err
+	}
+	document := first.GetDocument()
+	if document == nil {
+		return status.Error(codes.InvalidArgument, "the first message must describe the document")
+	}
+
+	req := Request{MimeType: document.GetMimeType(), WebhookURL: document.GetWebhookUrl()}
+	if config := document.GetConfig(); len(config) > 0 {
+		if req.Config, err = clientConfig(config); err != nil {
+			return status.Error(codes.InvalidArgument, "invalid config: "+err.Error())
+		}
+	}
+	if err := s.m.checkWebhook(req.WebhookURL); err != nil {
+		return grpcError(err)
+	}
+	spooled, err := s.m.spool(&chunkReader{stream: stream}, document.GetFilename())
+	if err != nil {
+		return grpcError(err)
+	}
+	if req.MimeType == "" {
+		if req.MimeType, err = kreuzberg.DetectMimeTypeFromPath(spooled); err != nil {
+			os.Remove(spooled)
+			return status.Error(codes.InvalidArgument, "detecting MIME type: "+err.Error())
+		}
+	}
+
+	job, err := s.m.submit(stream.Context(), req, spooled)
+	if err != nil {
+		return grpcError(err)
+	}
+	answer, err := jobProto(job)
+	if err != nil {
+		return grpcError(err)
+	}
+	return stream.SendAndClose(answer)
+}
+
+// chunkReader reads the content of a document from the chunks of a SubmitJob stream,
+// failing once it exceeds maxUploadSize.
+type chunkReader struct {
+	stream jobspb.Jobs_SubmitJobServer
+	chunk  []byte
+	read   int64
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.chunk) == 0 {
+		msg, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		if msg.GetDocument() != nil {
+			return 0, status.Error(codes.InvalidArgument, "the document is described twice")
+		}
+		r.chunk = msg.GetChunk()
+		if r.read += int64(len(r.chunk)); r.read > maxUploadSize {
+			return 0, status.Errorf(codes.ResourceExhausted, "document larger than %d bytes", maxUploadSize)
+		}
+	}
+	n := copy(p, r.chunk)
+	r.chunk = r.chunk[n:]
+	return n, nil
+}
+
+func (s *jobsServer) GetJob(ctx context.Context, req *jobspb.GetJobRequest) (*jobspb.Job, error) {
+	job, err := s.m.GetJob(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	answer, err := jobProto(job)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return answer, nil
+}
+
+func (s *jobsServer) ListJobs(ctx context.Context, req *jobspb.ListJobsRequest) (*jobspb.ListJobsResponse, error) {
+	offset, limit := int(req.GetOffset()), int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultWindow
+	}
+	if offset < 0 || limit < 0 || limit > maxWindow {
+		return nil, status.Errorf(codes.InvalidArgument, "offset must not be negative and limit must be between 1 and %d", maxWindow)
+	}
+	opts := ListOptions{Offset: offset, Limit: limit}
+	if req.GetState() != jobspb.State_STATE_UNSPECIFIED {
+		state, ok := stateOf(req.GetState())
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown state %v", req.GetState())
+		}
+		opts.State = state
+	}
+	jobs, err := s.m.ListJobs(ctx, opts)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	answer := &jobspb.ListJobsResponse{}
+	for _, job := range jobs {
+		listed, err := jobProto(job)
+		if err != nil {
+			return nil, grpcError(err)
+		}
+		answer.Jobs = append(answer.Jobs, listed)
+	}
+	if len(jobs) == limit {
+		next := int32(offset + limit)
+		answer.NextOffset = &next
+	}
+	return answer, nil
+}
+
+func (s *jobsServer) CancelJob(ctx context.Context, req *jobspb.CancelJobRequest) (*jobspb.Job, error) {
+	job, err := s.m.CancelJob(ctx, req.GetId())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	answer, err := jobProto(job)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return answer, nil
+}
+
+// stateProtos maps the states of jobs to those of the service.
+var stateProtos = map[State]jobspb.State{
+	StateQueued:    jobspb.State_STATE_QUEUED,
+	StateRunning:   jobspb.State_STATE_RUNNING,
+	StateSucceeded: jobspb.State_STATE_SUCCEEDED,
+	StateFailed:    jobspb.State_STATE_FAILED,
+	StateCanceled:  jobspb.State_STATE_CANCELED,
+}
+
+func stateOf(state jobspb.State) (State, bool) {
+	for s, p := range stateProtos {
+		if p == state {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+// jobProto converts a job to its message, without its spooled document.
+func jobProto(job *Job) (*jobspb.Job, error) {
+	answer := &jobspb.Job{
+		Id:         job.ID,
+		State:      stateProtos[job.State],
+		Path:       job.Path,
+		MimeType:   job.MimeType,
+		WebhookUrl: job.WebhookURL,
+		Error:      job.Error,
+		CreatedAt:  timestamppb.New(job.CreatedAt),
+		StartedAt:  timestampProto(job.StartedAt),
+		FinishedAt: timestampProto(job.FinishedAt),
+	}
+	var err error
+	if job.Config != nil {
+		if answer.Config, err = json.Marshal(job.Config); err != nil {
+			return nil, err
+		}
+	}
+	if job.Result != nil {
+		if answer.Result, err = json.Marshal(job.Result); err != nil {
+			return nil, err
+		}
+	}
+	return answer, nil
+}
+
+func timestampProto(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+// grpcError converts an error of the Manager to a gRPC status, the way writeJobError
+// converts it to an HTTP status.
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrFinished):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrWebhookNotAllowed):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, ErrClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	if st, ok := status.FromError(err); ok {
+		return st.Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,270 @@
+package jobs
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// maxUploadSize bounds the multipart body of a submitted job.
+const maxUploadSize = 256 << 20
+
+// defaultWindow is the number of jobs, pages or chunks answered when the request does not
+// say, and maxWindow the number of jobs answered at most.
+const (
+	defaultWindow = 100
+	maxWindow     = 1000
+)
+
+// Handler serves the jobs of m over HTTP, under /jobs:
+//
+//	POST   /jobs              submit a multipart form with the document in "file", and
+//	                          optional "mime_type", "config" (ExtractionConfig JSON) and
+//	                          "webhook_url" fields; answers 202 with the queued job, or
+//	                          400 for a webhook URL the Manager may not deliver to
+//	GET    /jobs              list "limit" jobs, by default 100, from job "offset" in
+//	                          creation order, filtered by the "state" query parameter;
+//	                          answers the jobs without results, and "next_offset" when
+//	                          more may follow
+//	GET    /jobs/{id}         get a job, with its result once it succeeded
+//	GET    /jobs/{id}/pages   get the result pages numbered "from" through "to",
+//	                          by default 1 through 100
+//	GET    /jobs/{id}/chunks  get "limit" result chunks from chunk "offset", by default
+//	                          the first 100
+//	DELETE /jobs/{id}         cancel a job; answers 409 when it is already finished
+//
+// Jobs are answered as JSON without their submitted document, which is spooled to a file
+// of Options.SpoolDir as it is uploaded. Clients of results with many
+// pages or chunks read them a window at a time from the pages and chunks endpoints.
+//
+// A submitted config cannot reach the server's file system or resources: its settings
+// naming server paths or limits (Retention, Paging, Quarantine, FilePolicy and
+// MaxConcurrentExtractions) are ignored.
+func (m *Manager) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /jobs", m.handleSubmit)
+	mux.HandleFunc("GET /jobs", m.handleList)
+	mux.HandleFunc("GET /jobs/{id}", m.handleGet)
+	mux.HandleFunc("GET /jobs/{id}/pages", m.handlePages)
+	mux.HandleFunc("GET /jobs/{id}/chunks", m.handleChunks)
+	mux.HandleFunc("DELETE /jobs/{id}", m.handleCancel)
+	return mux
+}
+
+func (m *Manager) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "missing document in the file field")
+		return
+	}
+	defer file.Close()
+
+	req := Request{MimeType: r.FormValue("mime_type"), WebhookURL: r.FormValue("webhook_url")}
+	if config := r.FormValue("config"); config != "" {
+		if req.Config, err = clientConfig([]byte(config)); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid config: "+err.Error())
+			return
+		}
+	}
+	if err := m.checkWebhook(req.WebhookURL); err != nil {
+		writeJobError(w, err)
+		return
+	}
+	document, err := m.spool(file, header.Filename)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	if req.MimeType == "" {
+		if contentType := header.Header.Get("Content-Type"); contentType != "application/octet-stream" {
+			req.MimeType = contentType
+		}
+	}
+	if req.MimeType == "" {
+		if req.MimeType, err = kreuzberg.DetectMimeTypeFromPath(document); err != nil {
+			os.Remove(document)
+			writeError(w, http.StatusBadRequest, "detecting MIME type: "+err.Error())
+			return
+		}
+	}
+
+	job, err := m.submit(r.Context(), req, document)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	writeJob(w, http.StatusAccepted, job)
+}
+
+// clientConfig decodes the extraction configuration submitted by a client, dropping the
+// settings that belong to the server: the paths it writes temporary files, spools and
+// quarantine manifests to, the files it agrees to read, and its concurrency.
+func clientConfig(data []byte) (*kreuzberg.ExtractionConfig, error) {
+	config := &kreuzberg.ExtractionConfig{}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, err
+	}
+	config.Retention = nil
+	config.Paging = nil
+	config.Quarantine = nil
+	config.FilePolicy = nil
+	config.MaxConcurrentExtractions = nil
+	return config, nil
+}
+
+func (m *Manager) handleList(w http.ResponseWriter, r *http.Request) {
+	offset, ok := queryInt(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(w, r, "limit", defaultWindow)
+	if !ok {
+		return
+	}
+	if limit == 0 || limit > maxWindow {
+		writeError(w, http.StatusBadRequest, "limit must be between 1 and "+strconv.Itoa(maxWindow))
+		return
+	}
+	opts := ListOptions{State: State(r.URL.Query().Get("state")), Offset: offset, Limit: limit}
+	jobs, err := m.ListJobs(r.Context(), opts)
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	for _, job := range jobs {
+		job.Document = ""
+	}
+	answer := map[string]any{"jobs": jobs}
+	if len(jobs) == limit {
+		answer["next_offset"] = offset + limit
+	}
+	writeJSON(w, http.StatusOK, answer)
+}
+
+func (m *Manager) handleGet(w http.ResponseWriter, r *http.Request) {
+	job, err := m.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeJobError(w, err)
+		return
+	}
+	writeJob(w, http.StatusOK, job)
+}
+
+func (m *Manager) handlePages(w http.ResponseWriter, r *http.Request) {
+	result, ok := m.resultOf(w, r)
+	if !ok {
+		return
+	}
+	from, ok := queryInt(w, r, "from", 1)
+	if !ok {
+		return
+	}
+	to, ok := queryInt(w, r, "to", from+defaultWindow-1)
+	if !ok {
+		return
+	}
+	pages, err := kreuzberg.GetPages(result, from, to)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"pages": pages})
+}
+
+func (m *Manager) handleChunks(w http.ResponseWriter, r *http.Request) {
+	result, ok := m.resultOf(w, r)
+	if !ok {
+		return
+	}
+	offset, ok := queryInt(w, r, "offset", 0)
+	if !ok {
+		return
+	}
+	limit, ok := queryInt(w, r, "limit", defaultWindow)
+	if !ok {
+		return
+	}
+	chunks, err := kreuzberg.GetChunks(result, offset, limit)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	total, _ := result.GetChunkCount()
+	writeJSON(w, http.StatusOK, map[string]any{"chunks": chunks, "total": total})
+}
+
+func (m *Manager) handleCancel(w http.ResponseWriter, r *http.Request) {
+	job, err := m.CancelJob(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, ErrFinished):
+		writeJob(w, http.StatusConflict, job)
+	case err != nil:
+		writeJobError(w, err)
+	default:
+		writeJob(w, http.StatusOK, job)
+	}
+}
+
+// resultOf returns the result of the job named in the request path, answering 404 for an
+// unknown job and 409 for one that has not succeeded.
+func (m *Manager) resultOf(w http.ResponseWriter, r *http.Request) (*kreuzberg.ExtractionResult, bool) {
+	job, err := m.GetJob(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeJobError(w, err)
+		return nil, false
+	}
+	if job.State != StateSucceeded || job.Result == nil {
+		writeError(w, http.StatusConflict, "job "+string(job.State)+", no result")
+		return nil, false
+	}
+	return job.Result, true
+}
+
+// queryInt returns the non-negative integer query parameter name, or fallback when it is
+// absent, answering 400 when it is invalid.
+func queryInt(w http.ResponseWriter, r *http.Request, name string, fallback int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		writeError(w, http.StatusBadRequest, "invalid "+name)
+		return 0, false
+	}
+	return n, true
+}
+
+func writeJob(w http.ResponseWriter, status int, job *Job) {
+	answered := *job
+	answered.Document = ""
+	writeJSON(w, status, &answered)
+}
+
+func writeJobError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, ErrWebhookNotAllowed):
+		writeError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, ErrClosed):
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+	default:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
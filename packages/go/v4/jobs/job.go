@@ -0,0 +1,101 @@
+// Package jobs runs Kreuzberg extractions as jobs that outlive the request submitting
+// them.
+//
+// A Manager queues submitted documents, extracts them with a bounded number of workers
+// and records every job in a Store, so clients can disconnect and poll for the result
+// later, and jobs interrupted by a restart are resumed by the next Manager opened on the
+// same store. Stores keep jobs in memory, in a SQL database such as SQLite, or in Redis.
+// Manager.Handler serves the jobs over HTTP:
+//
+//	store, err := jobs.NewSQLStore(ctx, db)
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	manager, err := jobs.NewManager(ctx, store, jobs.Options{Workers: 4})
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	defer manager.Close()
+//	http.Handle("/jobs", manager.Handler())
+//	http.Handle("/jobs/", manager.Handler())
+//
+// Manager.RegisterService serves the same jobs over gRPC, as the service of jobspb.
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// State is the stage of a job.
+type State string
+
+// Job states. Succeeded, failed and canceled jobs are finished.
+const (
+	StateQueued    State = "queued"
+	StateRunning   State = "running"
+	StateSucceeded State = "succeeded"
+	StateFailed    State = "failed"
+	StateCanceled  State = "canceled"
+)
+
+// Finished reports whether a job in state s is done.
+func (s State) Finished() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCanceled
+}
+
+var (
+	// ErrNotFound is returned for an unknown job id.
+	ErrNotFound = errors.New("jobs: job not found")
+	// ErrFinished is returned when canceling a job that is already finished.
+	ErrFinished = errors.New("jobs: job already finished")
+	// ErrClosed is returned when submitting to a closed Manager.
+	ErrClosed = errors.New("jobs: manager closed")
+)
+
+// Request describes the document a job extracts: a file by Path, or Data of MimeType,
+// which the Manager spools to a file until the job finishes.
+type Request struct {
+	Path     string
+	Data     []byte
+	MimeType string
+	// Config is the extraction configuration; the Manager's default when nil.
+	Config *kreuzberg.ExtractionConfig
+	// WebhookURL, when set, receives the finished job as a CloudEvent signed with
+	// Options.WebhookSecret. It must name a host Options.WebhookHosts allows.
+	WebhookURL string
+}
+
+// Job is a submitted extraction and its outcome.
+type Job struct {
+	ID    string `json:"id"`
+	State State  `json:"state"`
+
+	Path string `json:"path,omitempty"`
+	// Document is the spooled copy of a document submitted as data, removed when the job
+	// finishes. It is not answered over HTTP.
+	Document   string                      `json:"document,omitempty"`
+	MimeType   string                      `json:"mime_type,omitempty"`
+	Config     *kreuzberg.ExtractionConfig `json:"config,omitempty"`
+	WebhookURL string                      `json:"webhook_url,omitempty"`
+
+	// Result is set when the job succeeded, Error when it failed.
+	Result *kreuzberg.ExtractionResult `json:"result,omitempty"`
+	Error  string                      `json:"error,omitempty"`
+
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+func newJobID() (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
@@ -0,0 +1,6 @@
+// Package jobspb holds the messages and gRPC stubs of the kreuzberg.jobs.v1.Jobs service,
+// generated from jobs.proto. Servers register it with the RegisterService method of a
+// jobs.Manager; clients call it with NewJobsClient.
+package jobspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative jobs.proto
@@ -0,0 +1,367 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4/events"
+)
+
+// Options configures a Manager.
+type Options struct {
+	// Workers bounds the jobs extracted at once. Default: 1.
+	Workers int
+	// SpoolDir holds the documents submitted as data until their jobs finish. Queued jobs
+	// resume after a restart only if it outlives the process. Default: os.TempDir().
+	SpoolDir string
+	// Config is the extraction configuration of requests without one.
+	Config *kreuzberg.ExtractionConfig
+	// Source is the CloudEvents source of webhook notifications. Default: "kreuzberg/jobs".
+	Source string
+	// WebhookSecret signs webhook notifications; unsigned when empty.
+	WebhookSecret []byte
+	// WebhookHosts lists the host names webhook URLs may name; "*.example.com" allows the
+	// subdomains of example.com. When empty, webhooks may name any host except those that
+	// are, or resolve to, loopback, private, link-local, shared (100.64.0.0/10),
+	// unspecified or multicast addresses, so that a submitted webhook URL cannot reach the
+	// services of the network the Manager runs in. Deliveries bypass HTTP proxies, and
+	// redirects are held to the same rules.
+	WebhookHosts []string
+}
+
+// ListOptions selects the jobs of ListJobs.
+type ListOptions struct {
+	// State keeps the jobs in that state; all when empty.
+	State State
+	// Offset skips the oldest Offset matching jobs.
+	Offset int
+	// Limit keeps the next Limit matching jobs; all when 0.
+	Limit int
+}
+
+// Manager runs the jobs of a Store. It is safe for concurrent use.
+type Manager struct {
+	store    Store
+	opts     Options
+	slots    chan struct{}
+	webhooks webhookPolicy
+	// webhookClient delivers the webhook notifications under the webhooks policy.
+	webhookClient *http.Client
+
+	// ctx is canceled by Close, stopping the queued jobs and webhook deliveries.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// mu serializes the state changes of jobs.
+	mu      sync.Mutex
+	running map[string]context.CancelFunc
+	closed  bool
+}
+
+// NewManager returns a Manager running the jobs of store. Jobs the store holds as queued
+// or running, left by a Manager that was closed or crashed, are queued again.
+func NewManager(ctx context.Context, store Store, opts Options) (*Manager, error) {
+	if store == nil {
+		return nil, errors.New("jobs: store is nil")
+	}
+	if opts.Source == "" {
+		opts.Source = "kreuzberg/jobs"
+	}
+	m := &Manager{
+		store:   store,
+		opts:    opts,
+		slots:   make(chan struct{}, max(opts.Workers, 1)),
+		running: make(map[string]context.CancelFunc),
+	}
+	m.webhooks = newWebhookPolicy(opts.WebhookHosts)
+	m.webhookClient = m.webhooks.client()
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+
+	var pending []*Job
+	for _, state := range []State{StateQueued, StateRunning} {
+		jobs, err := store.List(ctx, ListOptions{State: state})
+		if err != nil {
+			return nil, err
+		}
+		pending = append(pending, jobs...)
+	}
+	slices.SortStableFunc(pending, func(a, b *Job) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	for _, job := range pending {
+		if job.State == StateRunning {
+			job.State, job.StartedAt = StateQueued, nil
+			if err := store.Put(ctx, job); err != nil {
+				return nil, err
+			}
+		}
+		m.start(job.ID)
+	}
+	return m, nil
+}
+
+// Close stops accepting jobs and waits for the running extractions, which cannot be
+// interrupted, to finish. Queued jobs stay queued in the store for the next Manager, and
+// webhook notifications still being delivered are abandoned.
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+	m.cancel()
+	m.wg.Wait()
+	return nil
+}
+
+// SubmitJob queues the extraction of a document and returns the queued job. A webhook URL
+// the Manager may not deliver to (Options.WebhookHosts) fails with ErrWebhookNotAllowed.
+func (m *Manager) SubmitJob(ctx context.Context, req Request) (*Job, error) {
+	if (req.Path == "") == (req.Data == nil) {
+		return nil, errors.New("jobs: a request needs either a path or data")
+	}
+	if req.Data != nil && req.MimeType == "" {
+		return nil, errors.New("jobs: data needs a MIME type")
+	}
+	if err := m.checkWebhook(req.WebhookURL); err != nil {
+		return nil, err
+	}
+	var document string
+	if req.Data != nil {
+		var err error
+		if document, err = m.spool(bytes.NewReader(req.Data), ""); err != nil {
+			return nil, err
+		}
+	}
+	return m.submit(ctx, req, document)
+}
+
+// checkWebhook checks a webhook URL of a submitted job, if any.
+func (m *Manager) checkWebhook(url string) error {
+	if url == "" {
+		return nil
+	}
+	return m.webhooks.check(url)
+}
+
+// spool copies a submitted document to a new file of Options.SpoolDir, named with the
+// extension of name if any, and returns its path.
+func (m *Manager) spool(r io.Reader, name string) (string, error) {
+	file, err := os.CreateTemp(m.opts.SpoolDir, "kreuzberg-job-*"+filepath.Ext(filepath.Base(name)))
+	if err != nil {
+		return "", fmt.Errorf("jobs: spooling document: %w", err)
+	}
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("jobs: spooling document: %w", err)
+	}
+	return file.Name(), nil
+}
+
+// submit queues the job of req, whose document is the spooled file document when it was
+// submitted as data. The spooled file is removed if the job cannot be queued.
+func (m *Manager) submit(ctx context.Context, req Request, document string) (job *Job, err error) {
+	if document != "" {
+		defer func() {
+			if err != nil {
+				os.Remove(document)
+			}
+		}()
+	}
+	id, err := newJobID()
+	if err != nil {
+		return nil, fmt.Errorf("jobs: generating job id: %w", err)
+	}
+	job = &Job{
+		ID:         id,
+		State:      StateQueued,
+		Path:       req.Path,
+		Document:   document,
+		MimeType:   req.MimeType,
+		Config:     req.Config,
+		WebhookURL: req.WebhookURL,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if job.Config == nil {
+		job.Config = m.opts.Config
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	if err := m.store.Put(ctx, job); err != nil {
+		return nil, err
+	}
+	m.start(job.ID)
+	return job, nil
+}
+
+// GetJob returns the job with the id, or ErrNotFound.
+func (m *Manager) GetJob(ctx context.Context, id string) (*Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+// ListJobs returns the jobs matching opts, oldest first, without their results; GetJob
+// returns the result of a job.
+func (m *Manager) ListJobs(ctx context.Context, opts ListOptions) ([]*Job, error) {
+	return m.store.List(ctx, opts)
+}
+
+// CancelJob cancels a queued or running job. A queued job is canceled at once; a running
+// one when its extraction returns, discarding the result, and the job returned is still
+// running. Canceling a finished job returns it with ErrFinished.
+func (m *Manager) CancelJob(ctx context.Context, id string) (*Job, error) {
+	m.mu.Lock()
+	job, err := m.store.Get(ctx, id)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	switch {
+	case job.State.Finished():
+		m.mu.Unlock()
+		return job, ErrFinished
+	case job.State == StateRunning:
+		if cancel, ok := m.running[id]; ok {
+			cancel()
+		}
+		m.mu.Unlock()
+		return job, nil
+	}
+	finish(job, StateCanceled)
+	err = m.store.Put(ctx, job)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	m.notify(job)
+	return job, nil
+}
+
+// start runs a queued job in the background once a worker is free. The caller holds m.mu
+// or is NewManager.
+func (m *Manager) start(id string) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		select {
+		case m.slots <- struct{}{}:
+		case <-m.ctx.Done():
+			return
+		}
+		defer func() { <-m.slots }()
+		m.run(id)
+	}()
+}
+
+func (m *Manager) run(id string) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	m.mu.Lock()
+	job, err := m.store.Get(ctx, id)
+	if err != nil || job.State != StateQueued || m.ctx.Err() != nil {
+		m.mu.Unlock()
+		return
+	}
+	started := time.Now().UTC()
+	job.State, job.StartedAt = StateRunning, &started
+	if err := m.store.Put(ctx, job); err != nil {
+		m.mu.Unlock()
+		return
+	}
+	m.running[id] = cancel
+	m.mu.Unlock()
+
+	var result *kreuzberg.ExtractionResult
+	if job.Path != "" {
+		result, err = kreuzberg.ExtractFileWithContext(ctx, job.Path, job.Config)
+	} else {
+		var data []byte
+		if data, err = os.ReadFile(job.Document); err == nil {
+			result, err = kreuzberg.ExtractBytesWithContext(ctx, data, job.MimeType, job.Config)
+		}
+	}
+
+	m.mu.Lock()
+	delete(m.running, id)
+	switch {
+	case ctx.Err() != nil && m.ctx.Err() == nil:
+		finish(job, StateCanceled)
+	case err == nil:
+		finish(job, StateSucceeded)
+		job.Result = result
+	case m.ctx.Err() != nil:
+		// Interrupted by Close: leave the job for the next Manager.
+		job.State, job.StartedAt = StateQueued, nil
+	default:
+		finish(job, StateFailed)
+		job.Error = err.Error()
+	}
+	err = m.store.Put(context.Background(), job)
+	m.mu.Unlock()
+	if err == nil && job.State.Finished() {
+		m.notify(job)
+	}
+}
+
+// finish moves job to a finished state, removing its spooled document.
+func finish(job *Job, state State) {
+	if job.Document != "" {
+		os.Remove(job.Document)
+	}
+	finished := time.Now().UTC()
+	job.State, job.FinishedAt, job.Document = state, &finished, ""
+}
+
+// notify delivers a finished job to its webhook as a CloudEvent whose id is the job id.
+// Delivery failures are not reported: the job stays available with GetJob.
+func (m *Manager) notify(job *Job) {
+	if job.WebhookURL == "" {
+		return
+	}
+	subject := job.Path
+	if subject == "" {
+		subject = job.ID
+	}
+	var event *events.Event
+	var err error
+	switch job.State {
+	case StateSucceeded:
+		event, err = events.NewResultEvent(m.opts.Source, subject, job.Result)
+	default:
+		message := job.Error
+		if message == "" {
+			message = "job " + string(job.State)
+		}
+		event, err = events.NewErrorEvent(m.opts.Source, subject, errors.New(message))
+	}
+	if err != nil {
+		return
+	}
+	event.ID = job.ID
+	sink := &events.WebhookSink{URL: job.WebhookURL, Secret: m.opts.WebhookSecret, Client: m.webhookClient}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		_ = sink.Publish(m.ctx, event)
+	}()
+}
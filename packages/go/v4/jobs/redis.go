@@ -0,0 +1,139 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisOptions configures a RedisStore.
+type RedisOptions struct {
+	// Prefix starts every key of the store. Default: "kreuzberg:".
+	Prefix string
+}
+
+// RedisStore keeps jobs in Redis: each job as JSON under Prefix+"job:"+id and its result
+// under Prefix+"result:"+id, with their ids in the sorted set Prefix+"jobs", and in
+// Prefix+"jobs:"+state for their state, scored by creation time. Reads of several jobs are
+// pipelined key by key, so the store also works on a Redis Cluster.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore returns a store on client, a single-node, Sentinel or Cluster client from
+// github.com/redis/go-redis/v9 whose connection, authentication and database settings it
+// uses as configured. Closing client is left to the caller.
+func NewRedisStore(client redis.UniversalClient, opts RedisOptions) *RedisStore {
+	if opts.Prefix == "" {
+		opts.Prefix = "kreuzberg:"
+	}
+	return &RedisStore{client: client, prefix: opts.Prefix}
+}
+
+// redisStates are the states a job is indexed by.
+var redisStates = []State{StateQueued, StateRunning, StateSucceeded, StateFailed, StateCanceled}
+
+func (s *RedisStore) jobKey(id string) string {
+	return s.prefix + "job:" + id
+}
+
+func (s *RedisStore) resultKey(id string) string {
+	return s.prefix + "result:" + id
+}
+
+// indexKey is the sorted set of the jobs in state, or of all jobs for "".
+func (s *RedisStore) indexKey(state State) string {
+	if state == "" {
+		return s.prefix + "jobs"
+	}
+	return s.prefix + "jobs:" + string(state)
+}
+
+// Put implements Store.
+func (s *RedisStore) Put(ctx context.Context, job *Job) error {
+	data, result, err := encodeJob(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, s.jobKey(job.ID), data, 0)
+		if result != nil {
+			pipe.Set(ctx, s.resultKey(job.ID), result, 0)
+		} else {
+			pipe.Del(ctx, s.resultKey(job.ID))
+		}
+		member := redis.Z{Score: float64(job.CreatedAt.UnixNano()), Member: job.ID}
+		pipe.ZAdd(ctx, s.indexKey(""), member)
+		for _, state := range redisStates {
+			if state != job.State {
+				pipe.ZRem(ctx, s.indexKey(state), job.ID)
+			}
+		}
+		pipe.ZAdd(ctx, s.indexKey(job.State), member)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("jobs: storing job: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *RedisStore) Get(ctx context.Context, id string) (*Job, error) {
+	var jobCmd, resultCmd *redis.StringCmd
+	_, err := s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		jobCmd = pipe.Get(ctx, s.jobKey(id))
+		resultCmd = pipe.Get(ctx, s.resultKey(id))
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("jobs: reading job: %w", err)
+	}
+	data, err := jobCmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jobs: reading job: %w", err)
+	}
+	result, err := resultCmd.Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("jobs: reading job result: %w", err)
+	}
+	return decodeJob(data, result)
+}
+
+// List implements Store.
+func (s *RedisStore) List(ctx context.Context, opts ListOptions) ([]*Job, error) {
+	stop := int64(-1)
+	if opts.Limit > 0 {
+		stop = int64(max(opts.Offset, 0) + opts.Limit - 1)
+	}
+	ids, err := s.client.ZRange(ctx, s.indexKey(opts.State), int64(max(opts.Offset, 0)), stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("jobs: listing jobs: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	cmds := make([]*redis.StringCmd, len(ids))
+	_, err = s.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, id := range ids {
+			cmds[i] = pipe.Get(ctx, s.jobKey(id))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("jobs: listing jobs: %w", err)
+	}
+	var encoded [][]byte
+	for _, cmd := range cmds {
+		if data, err := cmd.Bytes(); err == nil {
+			encoded = append(encoded, data)
+		}
+	}
+	return decodeJobs(encoded)
+}
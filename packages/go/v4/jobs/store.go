@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kreuzberg-dev/kreuzberg/packages/go/v4"
+)
+
+// Store persists jobs. A Manager serializes its writes; a store shared by several
+// Managers at once is not supported.
+//
+// Stores keep the result of a job apart from the job itself, so that List reads neither
+// results nor, since a Manager spools them to files, submitted documents.
+type Store interface {
+	// Put creates or replaces a job, its result included.
+	Put(ctx context.Context, job *Job) error
+	// Get returns the job with the id, its result included, or ErrNotFound.
+	Get(ctx context.Context, id string) (*Job, error)
+	// List returns the jobs matching opts, oldest first, without their results.
+	List(ctx context.Context, opts ListOptions) ([]*Job, error)
+}
+
+// MemoryStore keeps jobs in memory; they are lost when the process exits.
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*memoryJob
+}
+
+// memoryJob is a job held by a MemoryStore, encoded so that callers cannot share it.
+type memoryJob struct {
+	id        string
+	createdAt time.Time
+	state     State
+	job       []byte
+	result    []byte
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*memoryJob)}
+}
+
+// Put implements Store. The job is copied.
+func (s *MemoryStore) Put(_ context.Context, job *Job) error {
+	data, result, err := encodeJob(job)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stored, ok := s.jobs[job.ID]
+	if !ok {
+		stored = &memoryJob{id: job.ID, createdAt: job.CreatedAt}
+		s.jobs[job.ID] = stored
+	}
+	stored.state, stored.job, stored.result = job.State, data, result
+	return nil
+}
+
+// Get implements Store.
+func (s *MemoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	stored, ok := s.jobs[id]
+	var data, result []byte
+	if ok {
+		data, result = stored.job, stored.result
+	}
+	s.mu.Unlock()
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return decodeJob(data, result)
+}
+
+// List implements Store.
+func (s *MemoryStore) List(_ context.Context, opts ListOptions) ([]*Job, error) {
+	s.mu.Lock()
+	var matching []*memoryJob
+	for _, stored := range s.jobs {
+		if opts.State == "" || stored.state == opts.State {
+			matching = append(matching, stored)
+		}
+	}
+	slices.SortFunc(matching, func(a, b *memoryJob) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.id, b.id)
+	})
+	start, end := opts.window(len(matching))
+	encoded := make([][]byte, 0, end-start)
+	for _, stored := range matching[start:end] {
+		encoded = append(encoded, stored.job)
+	}
+	s.mu.Unlock()
+	return decodeJobs(encoded)
+}
+
+// SQLStore keeps jobs in the kreuzberg_jobs table of a database/sql database, created
+// when missing. The statements are written for SQLite, with a driver such as
+// modernc.org/sqlite or github.com/mattn/go-sqlite3.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore returns a store using db, creating its table if needed.
+func NewSQLStore(ctx context.Context, db *sql.DB) (*SQLStore, error) {
+	schema := []string{
+		`CREATE TABLE IF NOT EXISTS kreuzberg_jobs (
+	id TEXT PRIMARY KEY,
+	created_at INTEGER NOT NULL,
+	state TEXT NOT NULL,
+	job BLOB NOT NULL,
+	result BLOB
+)`,
+		`CREATE INDEX IF NOT EXISTS kreuzberg_jobs_created ON kreuzberg_jobs (created_at, id)`,
+	}
+	for _, statement := range schema {
+		if _, err := db.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("jobs: creating table: %w", err)
+		}
+	}
+	return &SQLStore{db: db}, nil
+}
+
+// Put implements Store.
+func (s *SQLStore) Put(ctx context.Context, job *Job) error {
+	data, result, err := encodeJob(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO kreuzberg_jobs (id, created_at, state, job, result) VALUES (?, ?, ?, ?, ?) ON CONFLICT(id) DO UPDATE SET state = excluded.state, job = excluded.job, result = excluded.result`,
+		job.ID, job.CreatedAt.UnixNano(), string(job.State), data, result)
+	if err != nil {
+		return fmt.Errorf("jobs: storing job: %w", err)
+	}
+	return nil
+}
+
+// Get implements Store.
+func (s *SQLStore) Get(ctx context.Context, id string) (*Job, error) {
+	var data, result []byte
+	err := s.db.QueryRowContext(ctx, `SELECT job, result FROM kreuzberg_jobs WHERE id = ?`, id).Scan(&data, &result)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jobs: reading job: %w", err)
+	}
+	return decodeJob(data, result)
+}
+
+// List implements Store.
+func (s *SQLStore) List(ctx context.Context, opts ListOptions) ([]*Job, error) {
+	limit := -1
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT job FROM kreuzberg_jobs WHERE ? IN ('', state) ORDER BY created_at, id LIMIT ? OFFSET ?`,
+		string(opts.State), limit, max(opts.Offset, 0))
+	if err != nil {
+		return nil, fmt.Errorf("jobs: listing jobs: %w", err)
+	}
+	defer rows.Close()
+	var encoded [][]byte
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("jobs: listing jobs: %w", err)
+		}
+		encoded = append(encoded, data)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("jobs: listing jobs: %w", err)
+	}
+	return decodeJobs(encoded)
+}
+
+// window returns the bounds of the page opts selects among n matching jobs.
+func (opts ListOptions) window(n int) (start, end int) {
+	start = min(max(opts.Offset, 0), n)
+	end = n
+	if opts.Limit > 0 {
+		end = min(start+opts.Limit, n)
+	}
+	return start, end
+}
+
+// encodeJob encodes a job without its result, and the result apart; result is nil for a
+// job without one.
+func encodeJob(job *Job) (data, result []byte, err error) {
+	stripped := *job
+	stripped.Result = nil
+	if data, err = json.Marshal(&stripped); err != nil {
+		return nil, nil, fmt.Errorf("jobs: encoding job: %w", err)
+	}
+	if job.Result != nil {
+		if result, err = json.Marshal(job.Result); err != nil {
+			return nil, nil, fmt.Errorf("jobs: encoding job result: %w", err)
+		}
+	}
+	return data, result, nil
+}
+
+// decodeJob decodes a job and, when not nil, its result.
+func decodeJob(data, result []byte) (*Job, error) {
+	job := &Job{}
+	if err := json.Unmarshal(data, job); err != nil {
+		return nil, fmt.Errorf("jobs: decoding job: %w", err)
+	}
+	if result != nil {
+		job.Result = &kreuzberg.ExtractionResult{}
+		if err := json.Unmarshal(result, job.Result); err != nil {
+			return nil, fmt.Errorf("jobs: decoding job result: %w", err)
+		}
+	}
+	return job, nil
+}
+
+// decodeJobs decodes jobs listed without their results.
+func decodeJobs(encoded [][]byte) ([]*Job, error) {
+	jobs := make([]*Job, 0, len(encoded))
+	for _, data := range encoded {
+		job, err := decodeJob(data, nil)
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
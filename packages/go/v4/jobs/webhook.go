@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrWebhookNotAllowed is returned when submitting a job whose webhook URL is malformed or
+// names a destination the Manager does not deliver to.
+var ErrWebhookNotAllowed = errors.New("jobs: webhook URL not allowed")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which netip does not count
+// as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether ip may receive webhook deliveries without an allow-list: it
+// must not be a loopback, private, link-local, shared, unspecified or multicast address.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !sharedAddressSpace.Contains(ip)
+}
+
+// webhookPolicy decides where webhook notifications may be delivered.
+type webhookPolicy struct {
+	// hosts is Options.WebhookHosts, lowercased; any public address when empty.
+	hosts []string
+}
+
+func newWebhookPolicy(hosts []string) webhookPolicy {
+	policy := webhookPolicy{}
+	for _, host := range hosts {
+		policy.hosts = append(policy.hosts, strings.ToLower(strings.TrimSuffix(host, ".")))
+	}
+	return policy
+}
+
+// check validates a webhook URL when a job is submitted. Without an allow-list, host
+// names are checked again at connection time, against the addresses they resolve to.
+func (p webhookPolicy) check(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrWebhookNotAllowed, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme must be http or https", ErrWebhookNotAllowed)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: credentials in the URL", ErrWebhookNotAllowed)
+	}
+	return p.checkHost(u.Hostname())
+}
+
+func (p webhookPolicy) checkHost(host string) error {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrWebhookNotAllowed)
+	}
+	if len(p.hosts) > 0 {
+		allowed := slices.ContainsFunc(p.hosts, func(pattern string) bool {
+			suffix, wildcard := strings.CutPrefix(pattern, "*.")
+			return host == pattern || (wildcard && strings.HasSuffix(host, "."+suffix))
+		})
+		if !allowed {
+			return fmt.Errorf("%w: host %s is not in the allow-list", ErrWebhookNotAllowed, host)
+		}
+		return nil
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !publicAddr(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrWebhookNotAllowed, host)
+	}
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s is not a public address", ErrWebhookNotAllowed, host)
+	}
+	return nil
+}
+
+// client returns the HTTP client delivering webhooks. Redirects are held to the same policy
+// and, without an allow-list, connections to non-public addresses are refused after name
+// resolution, so a host name cannot be pointed at an internal service.
+func (p webhookPolicy) client() *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if len(p.hosts) == 0 {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s is not a public address", ErrWebhookNotAllowed, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Minute,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("stopped after 5 redirects")
+			}
+			return p.check(req.URL.String())
+		},
+	}
+}
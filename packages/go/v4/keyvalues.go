@@ -0,0 +1,275 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Key-value sources reported in KeyValue.Source.
+const (
+	// KeyValueSourceText marks pairs read from the text of Content.
+	KeyValueSourceText = "text"
+	// KeyValueSourceLayout marks pairs associated by the position of elements on the page.
+	KeyValueSourceLayout = "layout"
+)
+
+// Confidence levels assigned to key-value pairs by association rule.
+const (
+	keyValueConfidenceInline   = 0.9
+	keyValueConfidenceSameRow  = 0.85
+	keyValueConfidenceBelow    = 0.75
+	keyValueConfidenceNextLine = 0.7
+	keyValueConfidenceAligned  = 0.6
+)
+
+// Limits for labels and values, and layout tolerances measured in label heights.
+const (
+	maxKeyValueKeyChars   = 40
+	maxKeyValueKeyWords   = 5
+	maxKeyValueValueChars = 120
+	keyValueRowTolerance  = 0.5
+	keyValueMaxGapRight   = 12
+	keyValueMaxGapBelow   = 2.5
+)
+
+// validateKeyValueConfig checks the confidence threshold of cfg.
+func validateKeyValueConfig(cfg *KeyValueConfig) error {
+	if cfg.MinConfidence != nil && (*cfg.MinConfidence < 0 || *cfg.MinConfidence > 1) {
+		return newValidationErrorWithContext(
+			fmt.Sprintf("key_values min_confidence must be between 0.0 and 1.0, got %v", *cfg.MinConfidence),
+			nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// ExtractKeyValues finds label/value pairs in a result. Elements with coordinates are
+// associated geometrically: a label ending in ":" takes the nearest element to its right
+// on the same row or, failing that, directly below it. Content is scanned for
+// "Label: value" lines, labels alone on a line followed by their value, and labels
+// separated from their value by a wide gap. Pairs below cfg.MinConfidence are dropped;
+// a nil cfg keeps all pairs.
+func ExtractKeyValues(result *ExtractionResult, cfg *KeyValueConfig) []KeyValue {
+	if result == nil {
+		return nil
+	}
+	pairs := layoutKeyValues(result.Elements)
+	seen := make(map[string]bool, len(pairs))
+	for _, pair := range pairs {
+		seen[keyValueIdentity(pair)] = true
+	}
+	for _, pair := range textKeyValues(result) {
+		if !seen[keyValueIdentity(pair)] {
+			pairs = append(pairs, pair)
+		}
+	}
+	if cfg == nil || cfg.MinConfidence == nil {
+		return pairs
+	}
+	kept := pairs[:0]
+	for _, pair := range pairs {
+		if pair.Confidence >= *cfg.MinConfidence {
+			kept = append(kept, pair)
+		}
+	}
+	return kept
+}
+
+func keyValueIdentity(pair KeyValue) string {
+	return strings.ToLower(pair.Key) + "\x00" + pair.Value
+}
+
+// keyValueSeparator splits a line into columns separated by tabs or wide runs of spaces.
+var keyValueSeparator = regexp.MustCompile(`\t+| {3,}`)
+
+// keyValueLabel reports whether text looks like a field label: short, starting with a
+// letter, and made of a few words.
+func keyValueLabel(text string) bool {
+	if text == "" || len([]rune(text)) > maxKeyValueKeyChars {
+		return false
+	}
+	first := []rune(text)[0]
+	if !unicode.IsLetter(first) {
+		return false
+	}
+	return !strings.Contains(text, "|") && len(strings.Fields(text)) <= maxKeyValueKeyWords
+}
+
+// splitInlinePair splits "Label: value" at the first colon that is not part of a URL
+// or a time of day.
+func splitInlinePair(text string) (key, value string, keyLen int, ok bool) {
+	for i := 0; i < len(text); i++ {
+		if text[i] != ':' {
+			continue
+		}
+		if strings.HasPrefix(text[i:], "://") {
+			return "", "", 0, false
+		}
+		if i > 0 && i+1 < len(text) && unicode.IsDigit(rune(text[i-1])) && unicode.IsDigit(rune(text[i+1])) {
+			continue
+		}
+		key = strings.TrimSpace(text[:i])
+		value = strings.TrimSpace(text[i+1:])
+		return key, value, i, keyValueLabel(key)
+	}
+	return "", "", 0, false
+}
+
+// textKeyValues scans Content line by line. Markdown tables, headings, and code blocks
+// are skipped.
+func textKeyValues(result *ExtractionResult) []KeyValue {
+	content := result.Content
+	var pairs []KeyValue
+	add := func(key string, keyStart int, value string, valueStart int, confidence float64) {
+		if value == "" || len([]rune(value)) > maxKeyValueValueChars {
+			return
+		}
+		pair := KeyValue{
+			Key:        key,
+			Value:      value,
+			Confidence: confidence,
+			Source:     KeyValueSourceText,
+			KeyStart:   uint64(keyStart),
+			KeyEnd:     uint64(keyStart + len(key)),
+			ValueStart: uint64(valueStart),
+			ValueEnd:   uint64(valueStart + len(value)),
+		}
+		if spans := result.PageSpans(pair.KeyStart, pair.KeyStart); len(spans) > 0 {
+			pair.PageNumber = spans[0].PageNumber
+		}
+		pairs = append(pairs, pair)
+	}
+
+	pendingKey, pendingStart := "", 0
+	fence := ""
+	forEachLine(content, func(pos int, line string) {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) {
+				fence = ""
+			}
+			return
+		case fenceMarker(line) != "":
+			fence = fenceMarker(line)
+			pendingKey = ""
+			return
+		case trimmed == "":
+			return
+		case strings.HasPrefix(trimmed, "|"):
+			pendingKey = ""
+			return
+		}
+		if _, _, heading := parseATXHeading(line); heading {
+			pendingKey = ""
+			return
+		}
+
+		if pendingKey != "" {
+			key := pendingKey
+			pendingKey = ""
+			if _, _, _, isPair := splitInlinePair(trimmed); !isPair && !strings.HasSuffix(trimmed, ":") {
+				add(key, pendingStart, trimmed, pos+strings.Index(line, trimmed), keyValueConfidenceNextLine)
+				return
+			}
+		}
+
+		columns := keyValueColumns(line)
+		for i, column := range columns {
+			text := line[column[0]:column[1]]
+			key, value, keyLen, isPair := splitInlinePair(text)
+			switch {
+			case isPair && value != "":
+				valueStart := pos + column[0] + keyLen + 1 + strings.Index(text[keyLen+1:], value)
+				add(key, pos+column[0]+strings.Index(text, key), value, valueStart, keyValueConfidenceInline)
+			case isPair && len(columns) == 1:
+				pendingKey, pendingStart = key, pos+column[0]+strings.Index(text, key)
+			case !isPair && len(columns) == 2 && i == 0 && keyValueLabel(text) && !strings.Contains(text, ":"):
+				next := line[columns[1][0]:columns[1][1]]
+				add(text, pos+column[0], next, pos+columns[1][0], keyValueConfidenceAligned)
+				return
+			}
+		}
+	})
+	return pairs
+}
+
+// keyValueColumns returns the byte ranges of the non-blank columns of line.
+func keyValueColumns(line string) [][2]int {
+	var columns [][2]int
+	start := 0
+	for _, sep := range append(keyValueSeparator.FindAllStringIndex(line, -1), []int{len(line), len(line)}) {
+		text := line[start:sep[0]]
+		if trimmed := strings.TrimSpace(text); trimmed != "" {
+			offset := start + strings.Index(text, trimmed)
+			columns = append(columns, [2]int{offset, offset + len(trimmed)})
+		}
+		start = sep[1]
+	}
+	return columns
+}
+
+// layoutKeyValues associates label elements with value elements by position. Only
+// elements with coordinates take part.
+func layoutKeyValues(elements []Element) []KeyValue {
+	var pairs []KeyValue
+	used := make(map[int]bool)
+	for i, label := range elements {
+		box := label.Metadata.Coordinates
+		text := strings.TrimSpace(label.Text)
+		if box == nil || !strings.HasSuffix(text, ":") || !keyValueLabel(strings.TrimSuffix(text, ":")) {
+			continue
+		}
+		height := math.Abs(box.Y1 - box.Y0)
+		if height == 0 {
+			continue
+		}
+
+		best, bestDistance, confidence := -1, math.Inf(1), 0.0
+		for j, candidate := range elements {
+			other := candidate.Metadata.Coordinates
+			if j == i || used[j] || other == nil || !samePage(label, candidate) || strings.TrimSpace(candidate.Text) == "" {
+				continue
+			}
+			centerOffset := math.Abs((box.Y0+box.Y1)/2 - (other.Y0+other.Y1)/2)
+			gapRight := other.X0 - box.X1
+			gapBelow := math.Min(box.Y0, box.Y1) - math.Max(other.Y0, other.Y1)
+			overlapsColumn := other.X0 < box.X1 && other.X1 > box.X0
+
+			switch {
+			case centerOffset <= height*keyValueRowTolerance && gapRight >= 0 && gapRight <= height*keyValueMaxGapRight:
+				if gapRight < bestDistance || confidence < keyValueConfidenceSameRow {
+					best, bestDistance, confidence = j, gapRight, keyValueConfidenceSameRow
+				}
+			case confidence < keyValueConfidenceSameRow && overlapsColumn && gapBelow >= 0 && gapBelow <= height*keyValueMaxGapBelow:
+				if gapBelow < bestDistance {
+					best, bestDistance, confidence = j, gapBelow, keyValueConfidenceBelow
+				}
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		used[best] = true
+		value := elements[best]
+		pair := KeyValue{
+			Key:         strings.TrimSpace(strings.TrimSuffix(text, ":")),
+			Value:       strings.TrimSpace(value.Text),
+			Confidence:  confidence,
+			Source:      KeyValueSourceLayout,
+			BoundingBox: value.Metadata.Coordinates,
+		}
+		if page := label.Metadata.PageNumber; page != nil && *page > 0 {
+			pair.PageNumber = uint64(*page)
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs
+}
+
+func samePage(a, b Element) bool {
+	pa, pb := a.Metadata.PageNumber, b.Metadata.PageNumber
+	return (pa == nil && pb == nil) || (pa != nil && pb != nil && *pa == *pb)
+}
@@ -0,0 +1,79 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExtractKeyValuesFromText(t *testing.T) {
+	content := "Applicant Form\n\nName: Jane Doe\nDate: 2024-05-01    Time: 10:30\nAddress:\n1 Main Street\nPolicy Number      PN-7781\nSee https://example.com for details.\n\n| Key: in table | x |\n"
+	pairs := ExtractKeyValues(&ExtractionResult{Content: content}, nil)
+
+	want := []struct {
+		key, value string
+		confidence float64
+	}{
+		{"Name", "Jane Doe", keyValueConfidenceInline},
+		{"Date", "2024-05-01", keyValueConfidenceInline},
+		{"Time", "10:30", keyValueConfidenceInline},
+		{"Address", "1 Main Street", keyValueConfidenceNextLine},
+		{"Policy Number", "PN-7781", keyValueConfidenceAligned},
+	}
+	if len(pairs) != len(want) {
+		t.Fatalf("expected %d pairs, got %+v", len(want), pairs)
+	}
+	for i, pair := range pairs {
+		if pair.Key != want[i].key || pair.Value != want[i].value || pair.Confidence != want[i].confidence {
+			t.Errorf("pair %d = %+v, want %+v", i, pair, want[i])
+		}
+		if content[pair.KeyStart:pair.KeyEnd] != pair.Key || content[pair.ValueStart:pair.ValueEnd] != pair.Value {
+			t.Errorf("pair %d offsets do not match", i)
+		}
+	}
+
+	filtered := ExtractKeyValues(&ExtractionResult{Content: content}, NewKeyValueConfig(WithKeyValueMinConfidence(0.8)))
+	if len(filtered) != 3 {
+		t.Errorf("expected 3 confident pairs, got %d", len(filtered))
+	}
+}
+
+func TestExtractKeyValuesFromLayout(t *testing.T) {
+	page := int64(1)
+	element := func(text string, x0, y0, x1, y1 float64) Element {
+		return Element{Text: text, Metadata: ElementMetadata{PageNumber: &page, Coordinates: &BoundingBox{X0: x0, Y0: y0, X1: x1, Y1: y1}}}
+	}
+	elements := []Element{
+		element("Invoice No:", 10, 700, 60, 710),
+		element("INV-9", 70, 700, 100, 710),
+		element("Ship To:", 10, 650, 50, 660),
+		element("ACME Warehouse", 10, 635, 90, 645),
+		element("Unrelated footer", 300, 20, 400, 30),
+	}
+	pairs := layoutKeyValues(elements)
+	if len(pairs) != 2 {
+		t.Fatalf("expected 2 pairs, got %+v", pairs)
+	}
+	if pairs[0].Key != "Invoice No" || pairs[0].Value != "INV-9" || pairs[0].Confidence != keyValueConfidenceSameRow {
+		t.Errorf("unexpected same-row pair: %+v", pairs[0])
+	}
+	if pairs[1].Key != "Ship To" || pairs[1].Value != "ACME Warehouse" || pairs[1].Confidence != keyValueConfidenceBelow {
+		t.Errorf("unexpected below pair: %+v", pairs[1])
+	}
+	if pairs[0].PageNumber != 1 || pairs[0].BoundingBox == nil {
+		t.Errorf("layout pair should carry page and box: %+v", pairs[0])
+	}
+}
+
+func TestKeyValueStage(t *testing.T) {
+	var validationErr *ValidationError
+	if err := validateResultStages(NewExtractionConfig(WithKeyValues(WithKeyValueMinConfidence(2)))); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	result := &ExtractionResult{Content: "Total: 12.00"}
+	if err := runResultStages(result, NewExtractionConfig(WithKeyValues())); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if len(result.KeyValues) != 1 || result.KeyValues[0].Value != "12.00" {
+		t.Fatalf("unexpected key values: %+v", result.KeyValues)
+	}
+}
@@ -0,0 +1,301 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Language filter modes accepted by LanguageFilterConfig.Mode.
+const (
+	// LanguageFilterDrop removes the paragraphs in other languages.
+	LanguageFilterDrop = "drop"
+	// LanguageFilterSegregate moves the paragraphs in other languages to
+	// ExtractionResult.SegregatedContent.
+	LanguageFilterSegregate = "segregate"
+)
+
+// LanguageFilterConfig keeps only the paragraphs of Content, Pages and Chunks written in
+// Languages, as for bilingual documents of which one half is indexed. Paragraphs are
+// separated by blank lines, and their language is identified by script and, for Latin
+// script, by the common words of English, German, French, Spanish, Italian, Dutch and
+// Portuguese. Paragraphs too short to identify, such as headings and figures, are kept.
+type LanguageFilterConfig struct {
+	// Languages are ISO 639-1 or ISO 639-3 codes, such as "en" or "eng".
+	Languages []string `json:"languages"`
+	// Mode is LanguageFilterDrop or LanguageFilterSegregate. Default: LanguageFilterDrop.
+	Mode string `json:"mode,omitempty"`
+}
+
+// LanguageSegment holds the paragraphs of one page in one language that the language
+// filter removed from Content. PageNumber is 0 when pages are unknown.
+type LanguageSegment struct {
+	Language   string `json:"language"`
+	PageNumber uint64 `json:"page_number,omitempty"`
+	Content    string `json:"content"`
+}
+
+var languageCodePattern = regexp.MustCompile(`^[A-Za-z]{2,3}$`)
+
+// validateLanguageFilterConfig requires languages and rejects unknown modes.
+func validateLanguageFilterConfig(cfg *LanguageFilterConfig) error {
+	if len(cfg.Languages) == 0 {
+		return newValidationErrorWithContext("language filter requires at least one language", nil, ErrorCodeValidation, nil)
+	}
+	for _, code := range cfg.Languages {
+		if !languageCodePattern.MatchString(code) {
+			return newValidationErrorWithContext(fmt.Sprintf("invalid language code %q, expected ISO 639-1 or ISO 639-3", code), nil, ErrorCodeValidation, nil)
+		}
+	}
+	if cfg.Mode != "" && cfg.Mode != LanguageFilterDrop && cfg.Mode != LanguageFilterSegregate {
+		return newValidationErrorWithContext(fmt.Sprintf("invalid language filter mode: %s", cfg.Mode), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// iso639Alpha3 maps the ISO 639-1 codes of the languages identifyLanguage reports, and
+// the ISO 639-3 code of Chinese as a macrolanguage, to the codes it reports, which are
+// those of ExtractionResult.DetectedLanguages.
+var iso639Alpha3 = map[string]string{
+	"en": "eng", "de": "deu", "fr": "fra", "es": "spa", "it": "ita", "nl": "nld", "pt": "por",
+	"ru": "rus", "uk": "ukr", "el": "ell", "ar": "ara", "he": "heb", "th": "tha", "hi": "hin",
+	"ko": "kor", "ja": "jpn", "zh": "cmn", "zho": "cmn",
+}
+
+func languageCode(code string) string {
+	code = strings.ToLower(code)
+	if alpha3, ok := iso639Alpha3[code]; ok {
+		return alpha3
+	}
+	return code
+}
+
+// commonWords are frequent words telling apart the Latin-script languages identified.
+var commonWords = map[string][]string{
+	"eng": strings.Fields("the and of to is in that for with this are be on it as by was not from have"),
+	"deu": strings.Fields("der die und das ist nicht mit den von sich des auf für ein eine dem auch werden wird zu"),
+	"fra": strings.Fields("le la les et des est une du que pour dans qui sur pas par au avec ce sont aux"),
+	"spa": strings.Fields("el la los las y de que en es por con una para del se no su al como más"),
+	"ita": strings.Fields("il di che la per non una sono del della con gli si è le nel anche alla dei questo"),
+	"nld": strings.Fields("de het een en van is dat niet op te voor met zijn er aan ook wordt bij naar dit"),
+	"por": strings.Fields("o a os as de que não em um uma para com do da é dos das se por mais"),
+}
+
+// wordLanguages maps each common word to the languages it is common in.
+var wordLanguages = func() map[string][]string {
+	index := map[string][]string{}
+	for language, words := range commonWords {
+		for _, word := range words {
+			index[word] = append(index[word], language)
+		}
+	}
+	return index
+}()
+
+// Identification thresholds: a paragraph needs identifyMinLetters letters, and a
+// Latin-script one identifyMinHits common words of its language.
+const (
+	identifyMinLetters = 12
+	identifyMinHits    = 2
+)
+
+// identifyLanguage returns the ISO 639-3 code of the language text is written in, or ""
+// when the text is too short or unclear.
+func identifyLanguage(text string) string {
+	scripts := map[string]int{}
+	letters, kana := 0, 0
+	cyrillicUkrainian := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			scripts["latin"]++
+		case unicode.Is(unicode.Cyrillic, r):
+			scripts["rus"]++
+			cyrillicUkrainian = cyrillicUkrainian || strings.ContainsRune("іїєґІЇЄҐ", r)
+		case unicode.Is(unicode.Greek, r):
+			scripts["ell"]++
+		case unicode.Is(unicode.Arabic, r):
+			scripts["ara"]++
+		case unicode.Is(unicode.Hebrew, r):
+			scripts["heb"]++
+		case unicode.Is(unicode.Thai, r):
+			scripts["tha"]++
+		case unicode.Is(unicode.Devanagari, r):
+			scripts["hin"]++
+		case unicode.Is(unicode.Hangul, r):
+			scripts["kor"]++
+		case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+			scripts["cjk"]++
+			kana++
+		case unicode.Is(unicode.Han, r):
+			scripts["cjk"]++
+		}
+	}
+	if letters < identifyMinLetters {
+		return ""
+	}
+	script, count := "", 0
+	for name, n := range scripts {
+		if n > count || (n == count && name < script) {
+			script, count = name, n
+		}
+	}
+	if 2*count < letters {
+		return ""
+	}
+	switch script {
+	case "latin":
+		return identifyLatinLanguage(text)
+	case "cjk":
+		if 10*kana >= count {
+			return "jpn"
+		}
+		return "cmn"
+	case "rus":
+		if cyrillicUkrainian {
+			return "ukr"
+		}
+	}
+	return script
+}
+
+// identifyLatinLanguage identifies a Latin-script text by its common words, requiring a
+// clear lead over the runner-up.
+func identifyLatinLanguage(text string) string {
+	hits := map[string]int{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !isWordRune(r) }) {
+		for _, language := range wordLanguages[word] {
+			hits[language]++
+		}
+	}
+	best, first, second := "", 0, 0
+	for language, n := range hits {
+		switch {
+		case n > first || (n == first && language < best):
+			best, first, second = language, n, max(first, second)
+		case n > second:
+			second = n
+		}
+	}
+	if first < identifyMinHits || first == second {
+		return ""
+	}
+	return best
+}
+
+// languageFilter decides which paragraphs to keep.
+type languageFilter struct {
+	languages map[string]bool
+}
+
+func newLanguageFilter(cfg *LanguageFilterConfig) languageFilter {
+	f := languageFilter{languages: map[string]bool{}}
+	for _, code := range cfg.Languages {
+		f.languages[languageCode(code)] = true
+	}
+	return f
+}
+
+// removed returns the paragraphs of text in other languages, with their language, and the
+// byte ranges to remove them with their separating blank lines.
+func (f languageFilter) removed(text string) (paragraphs [][2]int, languages []string, spans [][2]int) {
+	all := splitTranslationSegments(text)
+	for i, paragraph := range all {
+		language := identifyLanguage(text[paragraph[0]:paragraph[1]])
+		if language == "" || f.languages[language] {
+			continue
+		}
+		paragraphs = append(paragraphs, paragraph)
+		languages = append(languages, language)
+		// Remove the paragraph up to the next one, or from the previous one when it is last.
+		span := [2]int{paragraph[0], len(text)}
+		if i+1 < len(all) {
+			span[1] = all[i+1][0]
+		} else if i > 0 {
+			span[0] = all[i-1][1]
+		}
+		if n := len(spans); n > 0 && spans[n-1][1] >= span[0] {
+			spans[n-1][1] = max(spans[n-1][1], span[1])
+		} else {
+			spans = append(spans, span)
+		}
+	}
+	return paragraphs, languages, spans
+}
+
+// filter returns text without the paragraphs in other languages.
+func (f languageFilter) filter(text string) string {
+	_, _, spans := f.removed(text)
+	filtered, _ := removeSpans(text, spans)
+	return filtered
+}
+
+// applyLanguageFilter removes the paragraphs in other languages from Content, Pages and
+// Chunks, remapping byte offsets to the filtered Content. Chunks left empty are dropped.
+// In LanguageFilterSegregate mode the removed paragraphs are collected per page and
+// language into SegregatedContent, from Pages when the result has them.
+func applyLanguageFilter(result *ExtractionResult, cfg *LanguageFilterConfig) {
+	f := newLanguageFilter(cfg)
+	segregate := cfg.Mode == LanguageFilterSegregate
+	var segments []LanguageSegment
+	collect := func(text string, paragraphs [][2]int, languages []string, page func(start, end int) uint64) {
+		for i, paragraph := range paragraphs {
+			segments = appendLanguageSegment(segments, languages[i], page(paragraph[0], paragraph[1]), text[paragraph[0]:paragraph[1]])
+		}
+	}
+
+	paragraphs, languages, spans := f.removed(result.Content)
+	if segregate && len(result.Pages) == 0 {
+		collect(result.Content, paragraphs, languages, func(start, end int) uint64 {
+			if spans := result.PageSpans(uint64(start), uint64(end)); len(spans) > 0 {
+				return spans[0].PageNumber
+			}
+			return 0
+		})
+	}
+	content, m := removeSpans(result.Content, spans)
+	result.Content = content
+	remapResultOffsets(result, m)
+
+	for i := range result.Pages {
+		page := &result.Pages[i]
+		paragraphs, languages, spans := f.removed(page.Content)
+		if segregate {
+			collect(page.Content, paragraphs, languages, func(int, int) uint64 { return page.PageNumber })
+		}
+		page.Content, _ = removeSpans(page.Content, spans)
+	}
+
+	chunks := result.Chunks[:0]
+	for _, chunk := range result.Chunks {
+		if chunk.Content = f.filter(chunk.Content); strings.TrimSpace(chunk.Content) != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	if len(chunks) != len(result.Chunks) {
+		for i := range chunks {
+			chunks[i].Metadata.ChunkIndex = i
+			chunks[i].Metadata.TotalChunks = len(chunks)
+		}
+	}
+	if result.Chunks != nil {
+		result.Chunks = chunks
+	}
+	result.SegregatedContent = segments
+}
+
+// appendLanguageSegment adds paragraph to the segment of its page and language.
+func appendLanguageSegment(segments []LanguageSegment, language string, page uint64, paragraph string) []LanguageSegment {
+	for i := range segments {
+		if segments[i].Language == language && segments[i].PageNumber == page {
+			segments[i].Content += "\n\n" + paragraph
+			return segments
+		}
+	}
+	return append(segments, LanguageSegment{Language: language, PageNumber: page, Content: paragraph})
+}
@@ -0,0 +1,81 @@
+package kreuzberg
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	englishParagraph = "The contract is valid for two years and it can be renewed by the parties."
+	germanParagraph  = "Der Vertrag ist für zwei Jahre gültig und kann von den Parteien verlängert werden."
+)
+
+func TestIdentifyLanguage(t *testing.T) {
+	cases := []struct{ text, want string }{
+		{englishParagraph, "eng"},
+		{germanParagraph, "deu"},
+		{"Le contrat est valable pour deux ans et il peut être renouvelé par les parties.", "fra"},
+		{"Договор действует два года и может быть продлён сторонами.", "rus"},
+		{"本契約の有効期間は二年間とし、当事者の合意により更新することができる。", "jpn"},
+		{"本合同有效期为两年，经双方同意可以续签。", "cmn"},
+		{"Section 4.2", ""},
+	}
+	for _, c := range cases {
+		if got := identifyLanguage(c.text); got != c.want {
+			t.Errorf("identifyLanguage(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestLanguageFilterDrop(t *testing.T) {
+	content := "Section 1\n\n" + englishParagraph + "\n\n" + germanParagraph + "\n\n" + englishParagraph
+	result := &ExtractionResult{Content: content, Chunks: Chunks{
+		{Content: englishParagraph, Metadata: ChunkMetadata{ByteStart: 11, ByteEnd: uint64(11 + len(englishParagraph)), TotalChunks: 3}},
+		{Content: germanParagraph, Metadata: ChunkMetadata{ChunkIndex: 1, TotalChunks: 3}},
+		{Content: englishParagraph, Metadata: ChunkMetadata{ChunkIndex: 2, ByteStart: uint64(len(content) - len(englishParagraph)), ByteEnd: uint64(len(content)), TotalChunks: 3}},
+	}}
+	if err := runResultStages(result, NewExtractionConfig(WithLanguageFilter("", "en"))); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Section 1\n\n" + englishParagraph + "\n\n" + englishParagraph; result.Content != want {
+		t.Fatalf("Content = %q", result.Content)
+	}
+	if len(result.Chunks) != 2 || result.Chunks[1].Metadata.ChunkIndex != 1 || result.Chunks[1].Metadata.TotalChunks != 2 {
+		t.Fatalf("Chunks = %+v", result.Chunks)
+	}
+	last := result.Chunks[1].Metadata
+	if result.Content[last.ByteStart:last.ByteEnd] != englishParagraph {
+		t.Fatalf("chunk offsets not remapped: %d..%d", last.ByteStart, last.ByteEnd)
+	}
+	if result.SegregatedContent != nil {
+		t.Fatal("dropped paragraphs should not be kept")
+	}
+}
+
+func TestLanguageFilterSegregate(t *testing.T) {
+	result := &ExtractionResult{
+		Content: englishParagraph + "\n\n" + germanParagraph,
+		Pages: []PageContent{
+			{PageNumber: 1, Content: englishParagraph + "\n\n" + germanParagraph},
+			{PageNumber: 2, Content: germanParagraph + "\n\n" + germanParagraph},
+		},
+	}
+	if err := runResultStages(result, NewExtractionConfig(WithLanguageFilter(LanguageFilterSegregate, "eng"))); err != nil {
+		t.Fatal(err)
+	}
+	if result.Content != englishParagraph || result.Pages[0].Content != englishParagraph || result.Pages[1].Content != "" {
+		t.Fatalf("Content = %q, pages = %+v", result.Content, result.Pages)
+	}
+	segments := result.SegregatedContent
+	if len(segments) != 2 || segments[0].Language != "deu" || segments[1].PageNumber != 2 ||
+		strings.Count(segments[1].Content, "Vertrag") != 2 {
+		t.Fatalf("SegregatedContent = %+v", segments)
+	}
+
+	if err := validateResultStages(NewExtractionConfig(WithLanguageFilter("hide", "en"))); err == nil {
+		t.Fatal("expected an unknown mode to be rejected")
+	}
+	if err := validateResultStages(NewExtractionConfig(WithLanguageFilter(""))); err == nil {
+		t.Fatal("expected a filter without languages to be rejected")
+	}
+}
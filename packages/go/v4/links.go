@@ -0,0 +1,451 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Link types reported in Link.Type.
+const (
+	LinkTypeInternal = "internal"
+	LinkTypeExternal = "external"
+	LinkTypeMailto   = "mailto"
+)
+
+// Link is a hyperlink of a document. Target is resolved against the document's base URL
+// when it has one. Internal links point into the document itself, as "#name" for a named
+// destination or bookmark and "#page=N" for a page. Text is the anchor text, which PDF
+// link annotations only carry as an optional description, and PageNumber is 0 when
+// unknown. When validation is enabled, external web links carry the HTTP status of their
+// target, or the reason no response was received in Error.
+type Link struct {
+	Target     string `json:"target"`
+	Text       string `json:"text,omitempty"`
+	Type       string `json:"type"`
+	PageNumber uint64 `json:"page_number,omitempty"`
+	Status     int    `json:"status,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+const (
+	// defaultLinkValidationTimeout bounds the validation of a link when
+	// LinkExtractionConfig.ValidationTimeoutMs is unset.
+	defaultLinkValidationTimeout = 5 * time.Second
+	// linkValidationConcurrency bounds the links validated at once.
+	linkValidationConcurrency = 8
+)
+
+var (
+	markdownLink   = regexp.MustCompile(`(!?)\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	bareLink       = regexp.MustCompile(`(?i)\b(?:https?://|mailto:)[^\s<>"'()\[\]{}|\\^` + "`" + `]+`)
+	fieldArguments = regexp.MustCompile(`"[^"]*"|\S+`)
+)
+
+func validateLinkExtractionConfig(cfg *LinkExtractionConfig) error {
+	if cfg.ValidationTimeoutMs != nil && *cfg.ValidationTimeoutMs <= 0 {
+		return newValidationErrorWithContext(fmt.Sprintf("link validation timeout must be positive, got %d", *cfg.ValidationTimeoutMs), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// applyLinkStage collects the links of a result: the link annotations of PDFs and the
+// hyperlinks of Word documents, read from the original returned by read, the anchors the
+// core reports for HTML, and the links of Content not found there. It never fails the
+// extraction.
+func applyLinkStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.LinkExtraction == nil {
+		return
+	}
+	var links []Link
+	switch result.MimeType {
+	case "application/pdf":
+		if data, err := read(); err == nil {
+			links = pdfLinks(data)
+		}
+	case docxMimeType:
+		if data, err := read(); err == nil {
+			links = docxLinks(data)
+		}
+	}
+	base := ""
+	if html, ok := result.Metadata.HTMLMetadata(); ok {
+		if html.BaseHref != nil && *html.BaseHref != "" {
+			base = *html.BaseHref
+		} else if html.CanonicalURL != nil {
+			base = *html.CanonicalURL
+		}
+		for _, anchor := range html.Links {
+			links = append(links, newLink(anchor.Href, anchor.Text, base))
+		}
+	}
+
+	known := map[string]bool{}
+	for _, link := range links {
+		known[link.Target] = true
+	}
+	for _, link := range contentLinks(result, base) {
+		if !known[link.Target] {
+			links = append(links, link)
+		}
+	}
+
+	cfg := config.LinkExtraction
+	if cfg.Validate != nil && *cfg.Validate {
+		timeout := defaultLinkValidationTimeout
+		if cfg.ValidationTimeoutMs != nil {
+			timeout = time.Duration(*cfg.ValidationTimeoutMs) * time.Millisecond
+		}
+		validateLinks(links, &http.Client{Timeout: timeout})
+	}
+	result.Links = links
+}
+
+// newLink resolves target against base and classifies it.
+func newLink(target, text, base string) Link {
+	target = strings.TrimSpace(target)
+	if base != "" && !strings.HasPrefix(target, "#") {
+		if baseURL, err := url.Parse(base); err == nil {
+			if ref, err := url.Parse(target); err == nil {
+				target = baseURL.ResolveReference(ref).String()
+			}
+		}
+	}
+	return Link{Target: target, Text: strings.Join(strings.Fields(text), " "), Type: linkType(target)}
+}
+
+// linkType classifies a link target as internal, mailto or external.
+func linkType(target string) string {
+	switch {
+	case strings.HasPrefix(target, "#"):
+		return LinkTypeInternal
+	case len(target) > 7 && strings.EqualFold(target[:7], "mailto:"):
+		return LinkTypeMailto
+	}
+	return LinkTypeExternal
+}
+
+// contentLinks finds Markdown links and bare URLs in Content, with the page each is on.
+func contentLinks(result *ExtractionResult, base string) []Link {
+	var links []Link
+	var covered [][]int
+	page := func(offset int) uint64 {
+		if structure := result.Metadata.PageStructure; structure != nil {
+			for _, boundary := range structure.Boundaries {
+				if uint64(offset) >= boundary.ByteStart && uint64(offset) < boundary.ByteEnd {
+					return boundary.PageNumber
+				}
+			}
+		}
+		return 0
+	}
+	for _, match := range markdownLink.FindAllStringSubmatchIndex(result.Content, -1) {
+		covered = append(covered, match[:2])
+		if match[3] > match[2] {
+			continue // an image
+		}
+		link := newLink(result.Content[match[6]:match[7]], result.Content[match[4]:match[5]], base)
+		link.PageNumber = page(match[0])
+		links = append(links, link)
+	}
+	for _, match := range bareLink.FindAllStringIndex(result.Content, -1) {
+		inside := false
+		for _, span := range covered {
+			inside = inside || match[0] >= span[0] && match[0] < span[1]
+		}
+		target := strings.TrimRight(result.Content[match[0]:match[1]], ".,;:!?*_")
+		if inside || strings.HasSuffix(target, "://") {
+			continue
+		}
+		link := newLink(target, "", "")
+		link.PageNumber = page(match[0])
+		links = append(links, link)
+	}
+	return links
+}
+
+// pdfLinks reads the link annotations of a PDF's pages. URI targets are resolved against
+// the document's base URI, and destinations within the document become "#name" or
+// "#page=N".
+func pdfLinks(data []byte) []Link {
+	if !isPDF(data) || !bytes.Contains(data, []byte("/Link")) {
+		return nil
+	}
+	objects := readPDFObjects(data)
+	catalog := objects.catalog()
+	if catalog == nil {
+		return nil
+	}
+	pages := objects.pageRefs(catalog)
+	pageNumbers := make(map[pdfRef]int, len(pages))
+	for i, ref := range pages {
+		pageNumbers[ref] = i + 1
+	}
+	base, _ := objects.resolve(objects.dict(catalog["URI"])["Base"]).(string)
+
+	var links []Link
+	for i, ref := range pages {
+		annotations, _ := objects.resolve(objects.dict(ref)["Annots"]).([]any)
+		for _, value := range annotations {
+			annotation := objects.dict(value)
+			if annotation["Subtype"] != pdfName("Link") {
+				continue
+			}
+			text, _ := objects.resolve(annotation["Contents"]).(string)
+			action := objects.dict(annotation["A"])
+			var link Link
+			switch objects.resolve(action["S"]) {
+			case pdfName("URI"):
+				target, _ := objects.resolve(action["URI"]).(string)
+				link = newLink(target, text, base)
+			case pdfName("GoTo"):
+				link = newLink(pdfDestination(objects, action["D"], pageNumbers), text, "")
+			case pdfName("GoToR"), pdfName("Launch"):
+				link = newLink(pdfFileSpec(objects, action["F"]), text, "")
+				link.Type = LinkTypeExternal
+			default:
+				if annotation["Dest"] == nil {
+					continue
+				}
+				link = newLink(pdfDestination(objects, annotation["Dest"], pageNumbers), text, "")
+			}
+			if link.Target == "" {
+				continue
+			}
+			link.PageNumber = uint64(i + 1)
+			links = append(links, link)
+		}
+	}
+	return links
+}
+
+// pdfDestination returns the target of a destination: "#name" for a named destination
+// and "#page=N" for an explicit one.
+func pdfDestination(objects pdfObjects, dest any, pageNumbers map[pdfRef]int) string {
+	switch dest := objects.resolve(dest).(type) {
+	case string:
+		return "#" + dest
+	case pdfName:
+		return "#" + string(dest)
+	case []any:
+		if len(dest) == 0 {
+			return ""
+		}
+		if ref, ok := dest[0].(pdfRef); ok && pageNumbers[ref] > 0 {
+			return fmt.Sprintf("#page=%d", pageNumbers[ref])
+		}
+		if index, ok := pdfInt(dest[0]); ok {
+			return fmt.Sprintf("#page=%d", index+1)
+		}
+	case pdfDict:
+		return pdfDestination(objects, dest["D"], pageNumbers)
+	}
+	return ""
+}
+
+// pdfFileSpec returns the file name of a file specification.
+func pdfFileSpec(objects pdfObjects, spec any) string {
+	if name, ok := objects.resolve(spec).(string); ok {
+		return name
+	}
+	dict := objects.dict(spec)
+	for _, key := range []string{"UF", "F", "Unix", "DOS"} {
+		if name, ok := objects.resolve(dict[key]).(string); ok && name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// docxLinks reads the hyperlinks of a Word document: hyperlink elements, targeting a
+// relationship or a bookmark, and HYPERLINK fields, with the text they display.
+func docxLinks(data []byte) []Link {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	targets := map[string]string{}
+	if xml.Unmarshal(docxPart(archive, "word/_rels/document.xml.rels"), &rels) == nil {
+		for _, rel := range rels.Relationships {
+			targets[rel.ID] = rel.Target
+		}
+	}
+
+	// openLink is a link whose text is being read; that of a field starts at the field's
+	// separator.
+	type openLink struct {
+		target string
+		text   strings.Builder
+	}
+	var links []Link
+	var hyperlink, simpleField, field *openLink
+	var instruction strings.Builder
+	inInstruction := false
+	emit := func(link *openLink) {
+		if link != nil && link.target != "" {
+			links = append(links, newLink(link.target, link.text.String(), ""))
+		}
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(docxPart(archive, "word/document.xml")))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return links
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "hyperlink":
+				target := targets[xmlAttr(token, "id")]
+				if anchor := xmlAttr(token, "anchor"); anchor != "" {
+					target += "#" + anchor
+				}
+				hyperlink = &openLink{target: target}
+			case "fldSimple":
+				simpleField = &openLink{target: hyperlinkFieldTarget(xmlAttr(token, "instr"))}
+			case "fldChar":
+				switch xmlAttr(token, "fldCharType") {
+				case "begin":
+					instruction.Reset()
+					inInstruction, field = true, nil
+				case "separate":
+					if inInstruction {
+						field = &openLink{target: hyperlinkFieldTarget(instruction.String())}
+					}
+					inInstruction = false
+				case "end":
+					emit(field)
+					inInstruction, field = false, nil
+				}
+			case "instrText":
+				var value string
+				if decoder.DecodeElement(&value, &token) == nil && inInstruction {
+					instruction.WriteString(value)
+				}
+			case "t", "tab":
+				var value string
+				if token.Name.Local == "tab" {
+					value = " "
+				} else if decoder.DecodeElement(&value, &token) != nil {
+					continue
+				}
+				for _, link := range []*openLink{hyperlink, simpleField, field} {
+					if link != nil {
+						link.text.WriteString(value)
+					}
+				}
+			}
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "hyperlink":
+				emit(hyperlink)
+				hyperlink = nil
+			case "fldSimple":
+				emit(simpleField)
+				simpleField = nil
+			}
+		}
+	}
+}
+
+// hyperlinkFieldTarget returns the target of a HYPERLINK field instruction such as
+// `HYPERLINK "https://example.com" \l "part" \o "tooltip"`, or "" for other fields.
+func hyperlinkFieldTarget(instruction string) string {
+	arguments := fieldArguments.FindAllString(instruction, -1)
+	if len(arguments) == 0 || !strings.EqualFold(arguments[0], "HYPERLINK") {
+		return ""
+	}
+	target, anchor := "", ""
+	for i := 1; i < len(arguments); i++ {
+		switch argument := arguments[i]; argument {
+		case `\l`, `\o`, `\t`:
+			if i+1 < len(arguments) {
+				if argument == `\l` {
+					anchor = strings.Trim(arguments[i+1], `"`)
+				}
+				i++
+			}
+		default:
+			if !strings.HasPrefix(argument, `\`) && target == "" {
+				target = strings.Trim(argument, `"`)
+			}
+		}
+	}
+	if anchor != "" {
+		target += "#" + anchor
+	}
+	return target
+}
+
+// validateLinks requests each distinct external web target of links once, with HEAD or,
+// where servers refuse it, GET, and records the outcome on every link to it.
+func validateLinks(links []Link, client *http.Client) {
+	type outcome struct {
+		status int
+		err    string
+	}
+	outcomes := map[string]*outcome{}
+	for _, link := range links {
+		lower := strings.ToLower(link.Target)
+		if link.Type == LinkTypeExternal && (strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")) {
+			outcomes[link.Target] = &outcome{}
+		}
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, linkValidationConcurrency)
+	for target, result := range outcomes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			status, err := checkLink(client, target)
+			result.status = status
+			if err != nil {
+				result.err = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := range links {
+		if result, ok := outcomes[links[i].Target]; ok {
+			links[i].Status, links[i].Error = result.status, result.err
+		}
+	}
+}
+
+// checkLink returns the HTTP status of target.
+func checkLink(client *http.Client, target string) (int, error) {
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, target, nil)
+		if err != nil {
+			return 0, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		resp.Body.Close()
+		if method == http.MethodHead && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+			continue
+		}
+		return resp.StatusCode, nil
+	}
+	return 0, nil
+}
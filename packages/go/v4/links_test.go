@@ -0,0 +1,165 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNewLink(t *testing.T) {
+	cases := []struct {
+		target, base string
+		want         Link
+	}{
+		{"https://example.com/a", "", Link{Target: "https://example.com/a", Type: LinkTypeExternal}},
+		{"../docs/b.html", "https://example.com/guide/", Link{Target: "https://example.com/docs/b.html", Type: LinkTypeExternal}},
+		{"#install", "https://example.com/guide/", Link{Target: "#install", Type: LinkTypeInternal}},
+		{"MAILTO:team@example.com", "", Link{Target: "MAILTO:team@example.com", Type: LinkTypeMailto}},
+	}
+	for _, tc := range cases {
+		if got := newLink(tc.target, "", tc.base); got != tc.want {
+			t.Errorf("newLink(%q, %q) = %+v, want %+v", tc.target, tc.base, got, tc.want)
+		}
+	}
+}
+
+func TestContentLinks(t *testing.T) {
+	content := "See [the docs](https://example.com/docs \"Docs\") and ![logo](logo.png).\n" +
+		"Write to mailto:team@example.com or visit https://example.com/blog.\n" +
+		"Jump to [install](#install)."
+	result := &ExtractionResult{Content: content}
+	pageBreak := uint64(strings.Index(content, "Write"))
+	result.Metadata.PageStructure = &PageStructure{Boundaries: []PageBoundary{
+		{ByteStart: 0, ByteEnd: pageBreak, PageNumber: 1},
+		{ByteStart: pageBreak, ByteEnd: uint64(len(content)), PageNumber: 2},
+	}}
+
+	want := []Link{
+		{Target: "https://example.com/docs", Text: "the docs", Type: LinkTypeExternal, PageNumber: 1},
+		{Target: "#install", Text: "install", Type: LinkTypeInternal, PageNumber: 2},
+		{Target: "mailto:team@example.com", Type: LinkTypeMailto, PageNumber: 2},
+		{Target: "https://example.com/blog", Type: LinkTypeExternal, PageNumber: 2},
+	}
+	if got := contentLinks(result, ""); !reflect.DeepEqual(got, want) {
+		t.Errorf("contentLinks =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestPDFLinks(t *testing.T) {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R /URI << /Base (https://example.com/) >> >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 2 0 R /Annots [5 0 R 6 0 R] >>",
+		"<< /Type /Page /Parent 2 0 R /Annots [7 0 R 8 0 R] >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 10 10] /A << /S /URI /URI (pricing.html) >> /Contents (Pricing) >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 10 10] /Dest [4 0 R /Fit] >>",
+		"<< /Type /Annot /Subtype /Link /Rect [0 0 10 10] /A << /S /GoTo /D (appendix) >> >>",
+		"<< /Type /Annot /Subtype /Text /Rect [0 0 10 10] /Contents (A note) >>",
+	}
+	pdf := "%PDF-1.7\n"
+	for i, object := range objects {
+		pdf += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	pdf += "trailer\n<< /Root 1 0 R >>\n%%EOF\n"
+
+	want := []Link{
+		{Target: "https://example.com/pricing.html", Text: "Pricing", Type: LinkTypeExternal, PageNumber: 1},
+		{Target: "#page=2", Type: LinkTypeInternal, PageNumber: 1},
+		{Target: "#appendix", Type: LinkTypeInternal, PageNumber: 2},
+	}
+	if got := pdfLinks([]byte(pdf)); !reflect.DeepEqual(got, want) {
+		t.Errorf("pdfLinks =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestDOCXLinks(t *testing.T) {
+	rels := `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId7" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/" TargetMode="External"/>
+</Relationships>`
+	document := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><w:body>
+<w:p><w:hyperlink r:id="rId7"><w:r><w:t>Example</w:t></w:r><w:r><w:t xml:space="preserve"> site</w:t></w:r></w:hyperlink></w:p>
+<w:p><w:hyperlink w:anchor="_Toc1"><w:r><w:t>Chapter 1</w:t></w:r></w:hyperlink></w:p>
+<w:p><w:r><w:fldChar w:fldCharType="begin"/></w:r><w:r><w:instrText xml:space="preserve"> HYPERLINK "mailto:team@example.com" \o "Write" </w:instrText></w:r><w:r><w:fldChar w:fldCharType="separate"/></w:r><w:r><w:t>Contact us</w:t></w:r><w:r><w:fldChar w:fldCharType="end"/></w:r></w:p>
+<w:p><w:fldSimple w:instr=" PAGE "><w:r><w:t>3</w:t></w:r></w:fldSimple></w:p>
+</w:body></w:document>`
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"word/_rels/document.xml.rels": rels, "word/document.xml": document} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Link{
+		{Target: "https://example.com/", Text: "Example site", Type: LinkTypeExternal},
+		{Target: "#_Toc1", Text: "Chapter 1", Type: LinkTypeInternal},
+		{Target: "mailto:team@example.com", Text: "Contact us", Type: LinkTypeMailto},
+	}
+	if got := docxLinks(buf.Bytes()); !reflect.DeepEqual(got, want) {
+		t.Errorf("docxLinks =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestHyperlinkFieldTarget(t *testing.T) {
+	cases := map[string]string{
+		` HYPERLINK "https://example.com" `:           "https://example.com",
+		`HYPERLINK \l "part2" \o "tooltip"`:           "#part2",
+		`HYPERLINK "https://example.com/a" \l "b" \n`: "https://example.com/a#b",
+		`HYPERLINK https://example.com/bare`:          "https://example.com/bare",
+		` PAGEREF _Toc1 \h `:                          "",
+	}
+	for instruction, want := range cases {
+		if got := hyperlinkFieldTarget(instruction); got != want {
+			t.Errorf("hyperlinkFieldTarget(%q) = %q, want %q", instruction, got, want)
+		}
+	}
+}
+
+func TestLinkStage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/gone":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/no-head" && r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	defer server.Close()
+
+	config := NewExtractionConfig(WithLinkExtraction(WithLinkValidationTimeout(0)))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	result := &ExtractionResult{Content: "Home [ok](/ok), see " + server.URL + "/no-head.", MimeType: "text/html"}
+	result.Metadata.Format = FormatMetadata{Type: FormatHTML, HTML: &HtmlMetadata{
+		BaseHref: StringPtr(server.URL + "/"),
+		Links:    []LinkMetadata{{Href: "/ok", Text: "ok"}, {Href: "gone", Text: "Gone"}, {Href: "#top", Text: "Top"}},
+	}}
+	config = NewExtractionConfig(WithLinkExtraction(WithLinkValidation(true)))
+	applyLinkStage(result, func() ([]byte, error) { return nil, errors.New("not read") }, config)
+
+	want := []Link{
+		{Target: server.URL + "/ok", Text: "ok", Type: LinkTypeExternal, Status: http.StatusOK},
+		{Target: server.URL + "/gone", Text: "Gone", Type: LinkTypeExternal, Status: http.StatusNotFound},
+		{Target: "#top", Text: "Top", Type: LinkTypeInternal},
+		{Target: server.URL + "/no-head", Type: LinkTypeExternal, Status: http.StatusOK},
+	}
+	if !reflect.DeepEqual(result.Links, want) {
+		t.Errorf("links =\n%+v\nwant\n%+v", result.Links, want)
+	}
+}
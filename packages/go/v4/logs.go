@@ -0,0 +1,382 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mimeTypeLog is the MIME type of log files extracted by the binding.
+const mimeTypeLog = "text/x-log"
+
+// Log layouts reported in LogMetadata.Layout.
+const (
+	LogLayoutJSON        = "json"
+	LogLayoutLogfmt      = "logfmt"
+	LogLayoutAccess      = "access"
+	LogLayoutSyslog      = "syslog"
+	LogLayoutTimestamped = "timestamped"
+	LogLayoutUnknown     = "unknown"
+)
+
+// Normalized severity levels of LogEntry.Level.
+const (
+	LogLevelTrace = "TRACE"
+	LogLevelDebug = "DEBUG"
+	LogLevelInfo  = "INFO"
+	LogLevelWarn  = "WARN"
+	LogLevelError = "ERROR"
+	LogLevelFatal = "FATAL"
+)
+
+// logSampleLines is the number of lines inspected to detect the layout of a log.
+const logSampleLines = 50
+
+// LogMetadata describes a log file: the layout its lines follow, its entries, and the
+// number of entries at each severity level.
+type LogMetadata struct {
+	Layout     string         `json:"layout"`
+	EntryCount int            `json:"entry_count"`
+	Levels     map[string]int `json:"levels,omitempty"`
+	FirstTime  *time.Time     `json:"first_time,omitempty"`
+	LastTime   *time.Time     `json:"last_time,omitempty"`
+	Entries    []LogEntry     `json:"entries"`
+}
+
+// LogEntry is one entry of a log. Lines that do not start an entry, such as stack
+// traces, are appended to the message of the entry before them. Timestamps are in UTC;
+// those without a zone are assumed to be UTC, and syslog timestamps, which have no year,
+// are placed in the current year. Source is the host, program, or logger that wrote the
+// entry, and Fields holds the remaining keys of JSON and logfmt entries.
+type LogEntry struct {
+	Line      int               `json:"line"`
+	Timestamp time.Time         `json:"timestamp,omitzero"`
+	Level     string            `json:"level,omitempty"`
+	Source    string            `json:"source,omitempty"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+var (
+	accessLogPattern      = regexp.MustCompile(`^(\S+) \S+ (\S+) \[([^\]]+)\] "([^"]*)" (\d{3}) (\S+)(.*)$`)
+	syslogPattern         = regexp.MustCompile(`^(?:<(\d{1,3})>)?([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^:\[\s]+)(?:\[\d+\])?: ?(.*)$`)
+	syslog5424Pattern     = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) \S+ \S+ (?:-|\[.*?\]) ?(.*)$`)
+	timestampedLogPattern = regexp.MustCompile(`^\[?(\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?: ?(?:Z|[+-]\d{2}:?\d{2}|UTC))?)\]?\s*(.*)$`)
+	logLevelPattern       = regexp.MustCompile(`(?i)^[\[(<]?(trace|debug|dbg|info|information|notice|warn|warning|error|err|critical|crit|fatal|panic|emerg|alert|severe)[\])>]?:?(?:\s+|$)`)
+	logfmtPairPattern     = regexp.MustCompile(`(\w[\w.-]*)=("(?:[^"\\]|\\.)*"|\S*)`)
+)
+
+var logTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999 Z07:00",
+	"2006-01-02 15:04:05.999999999 Z0700",
+	"2006-01-02 15:04:05.999999999 MST",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+}
+
+func isLogPath(path string) bool {
+	name := strings.ToLower(filepath.Base(path))
+	if strings.HasSuffix(name, ".log") {
+		return true
+	}
+	// Rotated logs, e.g. "app.log.1".
+	base, suffix, ok := cutLast(name, ".")
+	if !ok || !strings.HasSuffix(base, ".log") {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+func cutLast(s, sep string) (string, string, bool) {
+	i := strings.LastIndex(s, sep)
+	if i < 0 {
+		return s, "", false
+	}
+	return s[:i], s[i+len(sep):], true
+}
+
+// extractLog builds the result of a log file: its text as Content and its entries in
+// LogMetadata.
+func extractLog(data []byte) *ExtractionResult {
+	content := string(data)
+	return &ExtractionResult{
+		Content:  content,
+		MimeType: mimeTypeLog,
+		Metadata: Metadata{Format: FormatMetadata{Type: FormatLog, Log: ParseLog(content)}},
+		Success:  true,
+	}
+}
+
+// ParseLog splits a log into entries. The layout is the one matching most of the first
+// lines: JSON lines, logfmt, Apache/nginx access logs, syslog (RFC 3164 and 5424), or
+// lines starting with an ISO 8601 timestamp.
+func ParseLog(text string) *LogMetadata {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	layout := detectLogLayout(lines)
+	meta := &LogMetadata{Layout: layout, Entries: []LogEntry{}}
+
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		entry, ok := parseLogLine(line, layout)
+		if !ok {
+			if n := len(meta.Entries); n > 0 {
+				meta.Entries[n-1].Message += "\n" + line
+				continue
+			}
+			entry = LogEntry{Message: line}
+		}
+		entry.Line = i + 1
+		meta.Entries = append(meta.Entries, entry)
+	}
+
+	for i := range meta.Entries {
+		entry := &meta.Entries[i]
+		if entry.Level != "" {
+			if meta.Levels == nil {
+				meta.Levels = make(map[string]int)
+			}
+			meta.Levels[entry.Level]++
+		}
+		if entry.Timestamp.IsZero() {
+			continue
+		}
+		if meta.FirstTime == nil || entry.Timestamp.Before(*meta.FirstTime) {
+			meta.FirstTime = &entry.Timestamp
+		}
+		if meta.LastTime == nil || entry.Timestamp.After(*meta.LastTime) {
+			meta.LastTime = &entry.Timestamp
+		}
+	}
+	meta.EntryCount = len(meta.Entries)
+	return meta
+}
+
+func detectLogLayout(lines []string) string {
+	counts := make(map[string]int)
+	sampled := 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if sampled++; sampled > logSampleLines {
+			break
+		}
+		for _, layout := range []string{LogLayoutJSON, LogLayoutAccess, LogLayoutSyslog, LogLayoutTimestamped, LogLayoutLogfmt} {
+			if _, ok := parseLogLine(line, layout); ok {
+				counts[layout]++
+				break
+			}
+		}
+	}
+	best, bestCount := LogLayoutUnknown, 0
+	for _, layout := range []string{LogLayoutJSON, LogLayoutAccess, LogLayoutSyslog, LogLayoutTimestamped, LogLayoutLogfmt} {
+		if counts[layout] > bestCount {
+			best, bestCount = layout, counts[layout]
+		}
+	}
+	return best
+}
+
+// parseLogLine parses line as the first line of an entry in layout.
+func parseLogLine(line, layout string) (LogEntry, bool) {
+	switch layout {
+	case LogLayoutJSON:
+		return parseJSONLogLine(line)
+	case LogLayoutLogfmt:
+		return parseLogfmtLine(line)
+	case LogLayoutAccess:
+		match := accessLogPattern.FindStringSubmatch(line)
+		if match == nil {
+			return LogEntry{}, false
+		}
+		timestamp, _ := time.Parse("02/Jan/2006:15:04:05 -0700", match[3])
+		status, _ := strconv.Atoi(match[5])
+		level := LogLevelInfo
+		switch {
+		case status >= 500:
+			level = LogLevelError
+		case status >= 400:
+			level = LogLevelWarn
+		}
+		fields := map[string]string{"request": match[4], "status": match[5], "bytes": match[6]}
+		if match[2] != "-" {
+			fields["user"] = match[2]
+		}
+		return LogEntry{Timestamp: timestamp.UTC(), Level: level, Source: match[1], Message: match[4], Fields: fields}, true
+	case LogLayoutSyslog:
+		if match := syslog5424Pattern.FindStringSubmatch(line); match != nil {
+			timestamp, _ := time.Parse(time.RFC3339Nano, match[2])
+			return LogEntry{Timestamp: timestamp.UTC(), Level: syslogLevel(match[1]), Source: match[3] + " " + match[4], Message: match[5]}, true
+		}
+		match := syslogPattern.FindStringSubmatch(line)
+		if match == nil {
+			return LogEntry{}, false
+		}
+		timestamp, _ := time.Parse("Jan _2 15:04:05", match[2])
+		if !timestamp.IsZero() {
+			timestamp = timestamp.AddDate(time.Now().UTC().Year(), 0, 0)
+		}
+		entry := LogEntry{Timestamp: timestamp, Level: syslogLevel(match[1]), Source: match[3] + " " + match[4], Message: match[5]}
+		if entry.Level == "" {
+			entry.Level, entry.Message = leadingLogLevel(entry.Message)
+		}
+		return entry, true
+	case LogLayoutTimestamped:
+		match := timestampedLogPattern.FindStringSubmatch(line)
+		if match == nil {
+			return LogEntry{}, false
+		}
+		timestamp, ok := parseLogTimestamp(match[1])
+		if !ok {
+			return LogEntry{}, false
+		}
+		level, message := leadingLogLevel(match[2])
+		return LogEntry{Timestamp: timestamp, Level: level, Message: message}, true
+	}
+	return LogEntry{}, false
+}
+
+func parseJSONLogLine(line string) (LogEntry, bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "{") {
+		return LogEntry{}, false
+	}
+	var raw map[string]any
+	if json.Unmarshal([]byte(trimmed), &raw) != nil {
+		return LogEntry{}, false
+	}
+	fields := make(map[string]string, len(raw))
+	for key, value := range raw {
+		if text, ok := value.(string); ok {
+			fields[key] = text
+			continue
+		}
+		encoded, _ := json.Marshal(value)
+		fields[key] = string(encoded)
+	}
+	return logEntryFromFields(fields), true
+}
+
+func parseLogfmtLine(line string) (LogEntry, bool) {
+	pairs := logfmtPairPattern.FindAllStringSubmatch(line, -1)
+	if len(pairs) < 2 {
+		return LogEntry{}, false
+	}
+	fields := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		value := pair[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		fields[pair[1]] = value
+	}
+	entry := logEntryFromFields(fields)
+	if entry.Timestamp.IsZero() && entry.Level == "" && entry.Message == "" {
+		return LogEntry{}, false
+	}
+	return entry, true
+}
+
+// logEntryFromFields maps the conventional keys of structured log entries to LogEntry
+// fields, leaving the others in Fields.
+func logEntryFromFields(fields map[string]string) LogEntry {
+	var entry LogEntry
+	take := func(keys ...string) string {
+		for _, key := range keys {
+			if value, ok := fields[key]; ok {
+				delete(fields, key)
+				return value
+			}
+		}
+		return ""
+	}
+	if value := take("time", "timestamp", "ts", "@timestamp", "t", "date"); value != "" {
+		entry.Timestamp, _ = parseLogTimestamp(value)
+		if entry.Timestamp.IsZero() {
+			if seconds, err := strconv.ParseFloat(value, 64); err == nil {
+				entry.Timestamp = time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+			}
+		}
+	}
+	entry.Level = normalizeLogLevel(take("level", "lvl", "severity", "log.level", "loglevel"))
+	entry.Source = take("logger", "logger_name", "source", "caller", "component", "service")
+	entry.Message = take("msg", "message", "event")
+	if len(fields) > 0 {
+		entry.Fields = fields
+	}
+	return entry
+}
+
+func parseLogTimestamp(value string) (time.Time, bool) {
+	value = strings.Replace(strings.TrimSpace(value), ",", ".", 1)
+	for _, layout := range logTimestampLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// leadingLogLevel splits a severity keyword, such as "[ERROR]" or "warn:", off the
+// start of message.
+func leadingLogLevel(message string) (string, string) {
+	match := logLevelPattern.FindStringSubmatchIndex(message)
+	if match == nil {
+		return "", message
+	}
+	return normalizeLogLevel(message[match[2]:match[3]]), message[match[1]:]
+}
+
+// normalizeLogLevel maps the severity names used by logging libraries to the LogLevel
+// constants. Unknown names are returned upper-cased.
+func normalizeLogLevel(level string) string {
+	switch upper := strings.ToUpper(strings.TrimSpace(level)); upper {
+	case "":
+		return ""
+	case "TRACE", "FINEST", "FINER":
+		return LogLevelTrace
+	case "DEBUG", "DBG", "FINE":
+		return LogLevelDebug
+	case "INFO", "INFORMATION", "NOTICE", "CONFIG":
+		return LogLevelInfo
+	case "WARN", "WARNING":
+		return LogLevelWarn
+	case "ERROR", "ERR", "SEVERE":
+		return LogLevelError
+	case "FATAL", "CRITICAL", "CRIT", "PANIC", "EMERG", "ALERT":
+		return LogLevelFatal
+	default:
+		return upper
+	}
+}
+
+// syslogLevel maps the severity of a syslog priority value to a level.
+func syslogLevel(priority string) string {
+	value, err := strconv.Atoi(priority)
+	if err != nil {
+		return ""
+	}
+	switch severity := value % 8; {
+	case severity <= 2:
+		return LogLevelFatal
+	case severity == 3:
+		return LogLevelError
+	case severity == 4:
+		return LogLevelWarn
+	case severity <= 6:
+		return LogLevelInfo
+	default:
+		return LogLevelDebug
+	}
+}
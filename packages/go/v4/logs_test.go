@@ -0,0 +1,140 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseLogTimestamped(t *testing.T) {
+	text := "2024-03-01 10:00:00,123 INFO  [main] Server started\n" +
+		"2024-03-01 10:00:05,000 ERROR [worker-1] Job failed\n" +
+		"java.lang.IllegalStateException: boom\n" +
+		"\tat com.example.Job.run(Job.java:42)\n" +
+		"2024-03-01T10:01:00Z [warn] disk almost full\n"
+	meta := ParseLog(text)
+	if meta.Layout != LogLayoutTimestamped || meta.EntryCount != 3 {
+		t.Fatalf("unexpected layout %q with %d entries", meta.Layout, meta.EntryCount)
+	}
+	first := meta.Entries[0]
+	if want := time.Date(2024, 3, 1, 10, 0, 0, 123_000_000, time.UTC); !first.Timestamp.Equal(want) {
+		t.Fatalf("timestamp = %v, want %v", first.Timestamp, want)
+	}
+	if first.Level != LogLevelInfo || first.Message != "[main] Server started" {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	failed := meta.Entries[1]
+	if failed.Level != LogLevelError || failed.Line != 2 {
+		t.Fatalf("unexpected second entry: %+v", failed)
+	}
+	if want := "[worker-1] Job failed\njava.lang.IllegalStateException: boom\n\tat com.example.Job.run(Job.java:42)"; failed.Message != want {
+		t.Fatalf("stack trace not attached: %q", failed.Message)
+	}
+	if meta.Entries[2].Level != LogLevelWarn || meta.Entries[2].Line != 5 {
+		t.Fatalf("unexpected third entry: %+v", meta.Entries[2])
+	}
+	if meta.Levels[LogLevelError] != 1 || meta.Levels[LogLevelInfo] != 1 || meta.Levels[LogLevelWarn] != 1 {
+		t.Fatalf("unexpected level counts: %v", meta.Levels)
+	}
+	if meta.FirstTime == nil || meta.LastTime == nil || !meta.LastTime.Equal(time.Date(2024, 3, 1, 10, 1, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected time range: %v - %v", meta.FirstTime, meta.LastTime)
+	}
+}
+
+func TestParseLogJSONLines(t *testing.T) {
+	text := `{"time":"2024-03-01T10:00:00+02:00","level":"warning","msg":"slow query","logger":"db","duration_ms":1520}` + "\n" +
+		`{"ts":1709287200.5,"severity":"CRITICAL","message":"out of memory"}` + "\n"
+	meta := ParseLog(text)
+	if meta.Layout != LogLayoutJSON || meta.EntryCount != 2 {
+		t.Fatalf("unexpected layout %q with %d entries", meta.Layout, meta.EntryCount)
+	}
+	first := meta.Entries[0]
+	if !first.Timestamp.Equal(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)) || first.Level != LogLevelWarn {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	if first.Source != "db" || first.Message != "slow query" || first.Fields["duration_ms"] != "1520" {
+		t.Fatalf("unexpected first entry fields: %+v", first)
+	}
+	second := meta.Entries[1]
+	if second.Level != LogLevelFatal || !second.Timestamp.Equal(time.Unix(1709287200, 500_000_000).UTC()) {
+		t.Fatalf("unexpected second entry: %+v", second)
+	}
+}
+
+func TestParseLogLogfmt(t *testing.T) {
+	meta := ParseLog(`ts=2024-03-01T10:00:00Z level=debug msg="cache miss" key=user:42` + "\n")
+	if meta.Layout != LogLayoutLogfmt || meta.EntryCount != 1 {
+		t.Fatalf("unexpected layout %q with %d entries", meta.Layout, meta.EntryCount)
+	}
+	entry := meta.Entries[0]
+	if entry.Level != LogLevelDebug || entry.Message != "cache miss" || entry.Fields["key"] != "user:42" {
+		t.Fatalf("unexpected entry: %+v", entry)
+	}
+}
+
+func TestParseLogAccess(t *testing.T) {
+	text := `203.0.113.7 - alice [01/Mar/2024:10:00:00 +0100] "GET /index.html HTTP/1.1" 200 512 "-" "curl/8.0"` + "\n" +
+		`203.0.113.8 - - [01/Mar/2024:10:00:01 +0100] "POST /api HTTP/1.1" 503 0 "-" "curl/8.0"` + "\n"
+	meta := ParseLog(text)
+	if meta.Layout != LogLayoutAccess || meta.EntryCount != 2 {
+		t.Fatalf("unexpected layout %q with %d entries", meta.Layout, meta.EntryCount)
+	}
+	first := meta.Entries[0]
+	if !first.Timestamp.Equal(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)) || first.Source != "203.0.113.7" {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	if first.Fields["user"] != "alice" || first.Fields["status"] != "200" || first.Level != LogLevelInfo {
+		t.Fatalf("unexpected first entry fields: %+v", first)
+	}
+	if meta.Entries[1].Level != LogLevelError {
+		t.Fatalf("5xx response not reported as error: %+v", meta.Entries[1])
+	}
+}
+
+func TestParseLogSyslog(t *testing.T) {
+	text := "Mar  1 10:00:00 web01 sshd[1234]: Accepted publickey for deploy\n" +
+		"<11>1 2024-03-01T10:00:00.000Z web01 nginx 99 - - upstream timed out\n"
+	meta := ParseLog(text)
+	if meta.Layout != LogLayoutSyslog || meta.EntryCount != 2 {
+		t.Fatalf("unexpected layout %q with %d entries", meta.Layout, meta.EntryCount)
+	}
+	first := meta.Entries[0]
+	if first.Source != "web01 sshd" || first.Message != "Accepted publickey for deploy" || first.Timestamp.Month() != time.March {
+		t.Fatalf("unexpected first entry: %+v", first)
+	}
+	second := meta.Entries[1]
+	if second.Level != LogLevelError || second.Source != "web01 nginx" || second.Message != "upstream timed out" {
+		t.Fatalf("unexpected second entry: %+v", second)
+	}
+}
+
+func TestIsLogPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"app.log":        true,
+		"/var/log/X.LOG": true,
+		"app.log.1":      true,
+		"app.log.gz":     false,
+		"catalog":        false,
+		"app.txt":        false,
+	} {
+		if got := isLogPath(path); got != want {
+			t.Errorf("isLogPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestLogMetadataRoundTrip(t *testing.T) {
+	result := extractLog([]byte("2024-03-01 10:00:00 ERROR boom\n"))
+	data, err := json.Marshal(result.Metadata)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var decoded Metadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	meta, ok := decoded.LogMetadata()
+	if !ok || meta.EntryCount != 1 || meta.Entries[0].Level != LogLevelError || decoded.Additional != nil {
+		t.Fatalf("unexpected round trip: %s", data)
+	}
+}
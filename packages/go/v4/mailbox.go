@@ -0,0 +1,240 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"os"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// MailboxResult holds the messages of a mailbox and the conversations they form.
+// Messages are in mailbox order; Threads refer to them by index.
+type MailboxResult struct {
+	Messages []*ExtractionResult `json:"messages"`
+	Threads  []Thread            `json:"threads"`
+}
+
+// Thread is a conversation: a message and the replies to it, linked through the
+// References and In-Reply-To headers. Subject is that of the earliest message.
+type Thread struct {
+	Subject  string          `json:"subject,omitempty"`
+	Messages []ThreadMessage `json:"messages"`
+}
+
+// ThreadMessage places a message in its thread. Index refers to the list of messages the
+// thread was built from; Parent is the index of the message it replies to, or -1 when
+// that message is not in the list. Depth counts the replies above it.
+type ThreadMessage struct {
+	Index     int       `json:"index"`
+	MessageID string    `json:"message_id,omitempty"`
+	Date      time.Time `json:"date,omitzero"`
+	Parent    int       `json:"parent"`
+	Depth     int       `json:"depth"`
+}
+
+// threadHeaders are the headers of a message used for threading.
+type threadHeaders struct {
+	id         string
+	inReplyTo  string
+	references []string
+	subject    string
+	date       time.Time
+}
+
+var messageIDPattern = regexp.MustCompile(`<([^<>\s]+)>`)
+
+// ExtractMailbox extracts every message of the mbox file at path and groups them into
+// threads.
+func ExtractMailbox(path string, config *ExtractionConfig) (*MailboxResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read mailbox", err, ErrorCodeIo, nil)
+	}
+	return ExtractMailboxBytes(data, config)
+}
+
+// ExtractMailboxBytes extracts every message of an mbox mailbox held in memory and groups
+// them into threads. Messages are extracted as one batch with config.
+func ExtractMailboxBytes(data []byte, config *ExtractionConfig) (*MailboxResult, error) {
+	messages := splitMbox(data)
+	items := make([]BytesWithMime, len(messages))
+	for i, message := range messages {
+		items[i] = BytesWithMime{Data: message, MimeType: mimeTypeEML}
+	}
+	results, err := BatchExtractBytesSync(items, config)
+	if err != nil {
+		return nil, err
+	}
+	return &MailboxResult{Messages: results, Threads: BuildThreads(messages)}, nil
+}
+
+// splitMbox splits an mbox mailbox into its messages, dropping the "From " separator
+// lines and undoing the ">From " quoting of body lines.
+func splitMbox(data []byte) [][]byte {
+	var messages [][]byte
+	var current []byte
+	started := false
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("From ")) {
+			if started && len(bytes.TrimSpace(current)) > 0 {
+				messages = append(messages, current)
+			}
+			current = nil
+			started = true
+			continue
+		}
+		if !started {
+			continue
+		}
+		if unquoted, ok := bytes.CutPrefix(line, []byte(">")); ok && bytes.HasPrefix(bytes.TrimLeft(unquoted, ">"), []byte("From ")) {
+			line = unquoted
+		}
+		current = append(current, line...)
+	}
+	if len(bytes.TrimSpace(current)) > 0 {
+		messages = append(messages, current)
+	}
+	return messages
+}
+
+// BuildThreads groups raw RFC 822 messages into conversations by their Message-ID,
+// In-Reply-To, and References headers. Replies whose original is missing still join the
+// thread of the other replies to it. Threads are ordered by their earliest message and
+// list messages by date.
+func BuildThreads(messages [][]byte) []Thread {
+	headers := make([]threadHeaders, len(messages))
+	for i, message := range messages {
+		headers[i] = parseThreadHeaders(message)
+	}
+	return buildThreads(headers)
+}
+
+func parseThreadHeaders(message []byte) threadHeaders {
+	parsed, err := mail.ReadMessage(bytes.NewReader(message))
+	if err != nil {
+		return threadHeaders{}
+	}
+	header := parsed.Header
+	h := threadHeaders{
+		id:         firstMessageID(header.Get("Message-Id")),
+		inReplyTo:  firstMessageID(header.Get("In-Reply-To")),
+		references: messageIDs(header.Get("References")),
+		subject:    header.Get("Subject"),
+	}
+	if date, err := header.Date(); err == nil {
+		h.date = date
+	}
+	return h
+}
+
+func messageIDs(value string) []string {
+	var ids []string
+	for _, match := range messageIDPattern.FindAllStringSubmatch(value, -1) {
+		ids = append(ids, match[1])
+	}
+	return ids
+}
+
+func firstMessageID(value string) string {
+	if ids := messageIDs(value); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+func buildThreads(headers []threadHeaders) []Thread {
+	byID := make(map[string]int, len(headers))
+	for i, h := range headers {
+		if _, seen := byID[h.id]; h.id != "" && !seen {
+			byID[h.id] = i
+		}
+	}
+
+	parents := make([]int, len(headers))
+	for i := range parents {
+		parents[i] = -1
+	}
+	for i, h := range headers {
+		candidates := append([]string{h.inReplyTo}, reversed(h.references)...)
+		for _, id := range candidates {
+			if j, ok := byID[id]; ok && j != i && !isAncestor(parents, i, j) {
+				parents[i] = j
+				break
+			}
+		}
+	}
+
+	groups := make(map[string]*Thread)
+	var order []string
+	for i := range headers {
+		root, depth := i, 0
+		for parents[root] >= 0 {
+			root = parents[root]
+			depth++
+		}
+		key := threadKey(headers[root], root)
+		thread, ok := groups[key]
+		if !ok {
+			thread = &Thread{}
+			groups[key] = thread
+			order = append(order, key)
+		}
+		thread.Messages = append(thread.Messages, ThreadMessage{
+			Index:     i,
+			MessageID: headers[i].id,
+			Date:      headers[i].date,
+			Parent:    parents[i],
+			Depth:     depth,
+		})
+	}
+
+	threads := make([]Thread, 0, len(order))
+	for _, key := range order {
+		thread := groups[key]
+		sort.SliceStable(thread.Messages, func(a, b int) bool {
+			return thread.Messages[a].Date.Before(thread.Messages[b].Date)
+		})
+		thread.Subject = headers[thread.Messages[0].Index].subject
+		threads = append(threads, *thread)
+	}
+	sort.SliceStable(threads, func(a, b int) bool {
+		return threads[a].Messages[0].Date.Before(threads[b].Messages[0].Date)
+	})
+	return threads
+}
+
+// threadKey identifies the thread of a root message: the first message it references,
+// which roots replies whose original is missing under a shared key, or its own ID.
+func threadKey(root threadHeaders, index int) string {
+	switch {
+	case len(root.references) > 0:
+		return root.references[0]
+	case root.inReplyTo != "":
+		return root.inReplyTo
+	case root.id != "":
+		return root.id
+	}
+	return fmt.Sprintf("#%d", index)
+}
+
+// isAncestor reports whether i is an ancestor of j, so that making j the parent of i
+// would form a cycle.
+func isAncestor(parents []int, i, j int) bool {
+	for k := parents[j]; k >= 0; k = parents[k] {
+		if k == i {
+			return true
+		}
+	}
+	return false
+}
+
+func reversed(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[len(values)-1-i] = value
+	}
+	return out
+}
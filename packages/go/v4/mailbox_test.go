@@ -0,0 +1,91 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testMessage(id, date, subject string, headers ...string) []byte {
+	lines := []string{
+		"From: alice@example.com",
+		"Message-ID: <" + id + ">",
+		"Date: " + date,
+		"Subject: " + subject,
+	}
+	lines = append(lines, headers...)
+	lines = append(lines, "", "Body of "+id, "")
+	return []byte(strings.Join(lines, "\r\n"))
+}
+
+func TestBuildThreads(t *testing.T) {
+	messages := [][]byte{
+		testMessage("reply2@x", "Tue, 4 Jun 2024 09:00:00 +0000", "Re: Budget", "In-Reply-To: <reply1@x>", "References: <root@x> <reply1@x>"),
+		testMessage("root@x", "Mon, 3 Jun 2024 09:00:00 +0000", "Budget"),
+		testMessage("other@x", "Mon, 3 Jun 2024 08:00:00 +0000", "Lunch"),
+		testMessage("reply1@x", "Mon, 3 Jun 2024 10:00:00 +0000", "Re: Budget", "In-Reply-To: <root@x>", "References: <root@x>"),
+		testMessage("orphan1@x", "Wed, 5 Jun 2024 09:00:00 +0000", "Re: Offsite", "References: <missing@x>"),
+		testMessage("orphan2@x", "Wed, 5 Jun 2024 10:00:00 +0000", "Re: Offsite", "References: <missing@x>"),
+	}
+	threads := BuildThreads(messages)
+	if len(threads) != 3 {
+		t.Fatalf("expected 3 threads, got %d: %+v", len(threads), threads)
+	}
+
+	if threads[0].Subject != "Lunch" || len(threads[0].Messages) != 1 {
+		t.Fatalf("unexpected first thread %+v", threads[0])
+	}
+
+	budget := threads[1]
+	if budget.Subject != "Budget" {
+		t.Fatalf("expected the budget thread second, got %q", budget.Subject)
+	}
+	var got []string
+	for _, message := range budget.Messages {
+		got = append(got, fmt.Sprintf("%d/%d/%d", message.Index, message.Parent, message.Depth))
+	}
+	if want := "1/-1/0 3/1/1 0/3/2"; strings.Join(got, " ") != want {
+		t.Fatalf("unexpected budget thread %s, want %s", strings.Join(got, " "), want)
+	}
+
+	offsite := threads[2]
+	if len(offsite.Messages) != 2 || offsite.Messages[0].Parent != -1 || offsite.Messages[1].Parent != -1 {
+		t.Fatalf("expected orphaned replies to share a thread, got %+v", offsite)
+	}
+}
+
+func TestBuildThreadsBreaksReferenceCycles(t *testing.T) {
+	messages := [][]byte{
+		testMessage("a@x", "Mon, 3 Jun 2024 09:00:00 +0000", "A", "In-Reply-To: <b@x>"),
+		testMessage("b@x", "Mon, 3 Jun 2024 10:00:00 +0000", "B", "In-Reply-To: <a@x>"),
+	}
+	threads := BuildThreads(messages)
+	if len(threads) != 1 || len(threads[0].Messages) != 2 {
+		t.Fatalf("expected one thread, got %+v", threads)
+	}
+}
+
+func TestSplitMbox(t *testing.T) {
+	mbox := strings.Join([]string{
+		"From alice@example.com Mon Jun  3 09:00:00 2024",
+		"Subject: First",
+		"",
+		">From the archive",
+		"",
+		"From bob@example.com Mon Jun  3 10:00:00 2024",
+		"Subject: Second",
+		"",
+		"Hello",
+		"",
+	}, "\n")
+	messages := splitMbox([]byte(mbox))
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if want := "Subject: First\n\nFrom the archive\n\n"; string(messages[0]) != want {
+		t.Fatalf("unexpected first message %q", messages[0])
+	}
+	if !strings.HasPrefix(string(messages[1]), "Subject: Second\n") {
+		t.Fatalf("unexpected second message %q", messages[1])
+	}
+}
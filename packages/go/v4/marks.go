@@ -0,0 +1,306 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register decoders for extracted images
+	_ "image/jpeg"
+	"image/png"
+	"sort"
+)
+
+// Mark kinds reported in Mark.Kind and accepted by MarkDetectionConfig.Kinds.
+const (
+	// MarkSignature is a handwritten signature.
+	MarkSignature = "signature"
+	// MarkStamp is an official stamp or seal.
+	MarkStamp = "stamp"
+)
+
+// Detection tuning. Images are scanned on a grid of at most markGridSize cells per side;
+// ink within markJoinCells cells joins into one region.
+const (
+	markGridSize       = 300
+	markJoinCells      = 2
+	markMinCells       = 6
+	markInkShare       = 0.08
+	markStampMinAspect = 0.6
+	markStampMaxAspect = 1.7
+	markSignatureWide  = 1.8
+	markMaxDensity     = 0.7
+	markSparseDensity  = 0.35
+	markTallFactor     = 1.5
+	// markMaxPixels bounds the images decoded, so that a crafted or huge image cannot
+	// exhaust memory: 50 megapixels take 200 MB decoded.
+	markMaxPixels = 50_000_000
+)
+
+type inkClass uint8
+
+const (
+	inkNone inkClass = iota
+	inkDark
+	inkColored
+)
+
+// markRegion is a connected ink region in grid cells, inclusive.
+type markRegion struct {
+	class                  inkClass
+	minX, minY, maxX, maxY int
+	cells                  int
+}
+
+func (r markRegion) width() int  { return r.maxX - r.minX + 1 }
+func (r markRegion) height() int { return r.maxY - r.minY + 1 }
+func (r markRegion) density() float64 {
+	return float64(r.cells) / float64(r.width()*r.height())
+}
+
+// validateMarkDetectionConfig rejects unknown kinds and out-of-range thresholds.
+func validateMarkDetectionConfig(cfg *MarkDetectionConfig) error {
+	for _, kind := range cfg.Kinds {
+		if kind != MarkSignature && kind != MarkStamp {
+			return newValidationErrorWithContext(fmt.Sprintf("invalid mark kind: %s", kind), nil, ErrorCodeValidation, nil)
+		}
+	}
+	if cfg.MinConfidence != nil && (*cfg.MinConfidence < 0 || *cfg.MinConfidence > 1) {
+		return newValidationErrorWithContext(
+			fmt.Sprintf("mark min_confidence must be between 0.0 and 1.0, got %v", *cfg.MinConfidence),
+			nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// imagesRequested reports whether the caller asked for images in the result.
+func imagesRequested(config *ExtractionConfig) bool {
+	return config.Images != nil && config.Images.ExtractImages != nil && *config.Images.ExtractImages
+}
+
+// DetectMarks finds handwritten signatures and stamps in the images of a result, such as
+// scanned pages or embedded pictures. Stamps are compact regions of saturated ink;
+// signatures are wide, sparse regions of colored ink, or of dark ink that stands taller
+// than the surrounding printed lines. Bounding boxes are in pixels of the source image
+// with the origin at its bottom-left corner. Only PNG, JPEG and GIF images of up to 50
+// megapixels are decoded; larger images and other formats, such as the JBIG2 and CCITT fax
+// images of many scanned PDFs, are skipped, as are images that cannot be decoded.
+func DetectMarks(result *ExtractionResult, cfg *MarkDetectionConfig) []Mark {
+	if result == nil {
+		return nil
+	}
+	wanted := map[string]bool{MarkSignature: true, MarkStamp: true}
+	if cfg != nil && len(cfg.Kinds) > 0 {
+		wanted = map[string]bool{}
+		for _, kind := range cfg.Kinds {
+			wanted[kind] = true
+		}
+	}
+	var marks []Mark
+	for _, extracted := range result.Images {
+		if extracted.IsMask || len(extracted.Data) == 0 {
+			continue
+		}
+		header, _, err := image.DecodeConfig(bytes.NewReader(extracted.Data))
+		if err != nil || int64(header.Width)*int64(header.Height) > markMaxPixels {
+			continue
+		}
+		img, _, err := image.Decode(bytes.NewReader(extracted.Data))
+		if err != nil {
+			continue
+		}
+		for _, mark := range detectImageMarks(img) {
+			if !wanted[mark.Kind] || (cfg != nil && cfg.MinConfidence != nil && mark.Confidence < *cfg.MinConfidence) {
+				continue
+			}
+			mark.ImageIndex = extracted.ImageIndex
+			if extracted.PageNumber != nil && *extracted.PageNumber > 0 {
+				mark.PageNumber = uint64(*extracted.PageNumber)
+			}
+			marks = append(marks, mark)
+		}
+	}
+	return marks
+}
+
+// detectImageMarks classifies the ink regions of img.
+func detectImageMarks(img image.Image) []Mark {
+	bounds := img.Bounds()
+	cell := max(1, (max(bounds.Dx(), bounds.Dy())+markGridSize-1)/markGridSize)
+	grid, cols, rows := inkGrid(img, cell)
+	regions := inkRegions(grid, cols, rows)
+
+	var darkHeights []int
+	for _, region := range regions {
+		if region.class == inkDark {
+			darkHeights = append(darkHeights, region.height())
+		}
+	}
+	sort.Ints(darkHeights)
+	lineHeight := 0
+	if len(darkHeights) >= 3 {
+		lineHeight = darkHeights[len(darkHeights)/2]
+	}
+
+	var marks []Mark
+	for _, region := range regions {
+		kind, confidence := classifyRegion(region, lineHeight)
+		if kind == "" {
+			continue
+		}
+		rect := image.Rect(
+			bounds.Min.X+region.minX*cell, bounds.Min.Y+region.minY*cell,
+			bounds.Min.X+(region.maxX+1)*cell, bounds.Min.Y+(region.maxY+1)*cell,
+		).Intersect(bounds)
+		marks = append(marks, Mark{
+			Kind:       kind,
+			Confidence: confidence,
+			BoundingBox: BoundingBox{
+				X0: float64(rect.Min.X - bounds.Min.X),
+				Y0: float64(bounds.Max.Y - rect.Max.Y),
+				X1: float64(rect.Max.X - bounds.Min.X),
+				Y1: float64(bounds.Max.Y - rect.Min.Y),
+			},
+			Crop: cropPNG(img, rect),
+		})
+	}
+	return marks
+}
+
+// classifyRegion returns the mark kind and confidence of region, or "" when it looks like
+// printed content. lineHeight is the typical height of dark regions, 0 when unknown.
+func classifyRegion(region markRegion, lineHeight int) (string, float64) {
+	w, h := region.width(), region.height()
+	density := region.density()
+	if w < markMinCells || h < markMinCells || density > markMaxDensity {
+		return "", 0
+	}
+	aspect := float64(w) / float64(h)
+	sparse := density <= markSparseDensity
+
+	if region.class == inkColored && aspect >= markStampMinAspect && aspect <= markStampMaxAspect {
+		squareness := float64(min(w, h)) / float64(max(w, h))
+		confidence := 0.5 + 0.3*squareness
+		if sparse {
+			confidence += 0.15
+		}
+		return MarkStamp, confidence
+	}
+	if aspect < markSignatureWide || !sparse {
+		return "", 0
+	}
+	if region.class == inkColored {
+		return MarkSignature, 0.75
+	}
+	if float64(h) >= markTallFactor*float64(lineHeight) {
+		if lineHeight == 0 {
+			return MarkSignature, 0.45
+		}
+		return MarkSignature, 0.6
+	}
+	return "", 0
+}
+
+// inkGrid samples img into cells of cell×cell pixels and marks the cells holding enough
+// dark or saturated pixels.
+func inkGrid(img image.Image, cell int) ([]inkClass, int, int) {
+	bounds := img.Bounds()
+	cols := (bounds.Dx() + cell - 1) / cell
+	rows := (bounds.Dy() + cell - 1) / cell
+	grid := make([]inkClass, cols*rows)
+	step := max(1, cell/4)
+	for gy := 0; gy < rows; gy++ {
+		for gx := 0; gx < cols; gx++ {
+			dark, colored, samples := 0, 0, 0
+			for y := bounds.Min.Y + gy*cell; y < min(bounds.Min.Y+(gy+1)*cell, bounds.Max.Y); y += step {
+				for x := bounds.Min.X + gx*cell; x < min(bounds.Min.X+(gx+1)*cell, bounds.Max.X); x += step {
+					samples++
+					switch pixelInk(img, x, y) {
+					case inkDark:
+						dark++
+					case inkColored:
+						colored++
+					}
+				}
+			}
+			if samples == 0 || float64(dark+colored)/float64(samples) < markInkShare {
+				continue
+			}
+			if colored >= dark {
+				grid[gy*cols+gx] = inkColored
+			} else {
+				grid[gy*cols+gx] = inkDark
+			}
+		}
+	}
+	return grid, cols, rows
+}
+
+// pixelInk classifies a pixel as dark ink, saturated ink, or background.
+func pixelInk(img image.Image, x, y int) inkClass {
+	r, g, b, a := img.At(x, y).RGBA()
+	if a < 0x8000 {
+		return inkNone
+	}
+	hi := max(r, g, b)
+	lo := min(r, g, b)
+	value := float64(hi) / 0xffff
+	saturation := 0.0
+	if hi > 0 {
+		saturation = float64(hi-lo) / float64(hi)
+	}
+	luminance := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+	switch {
+	case saturation >= 0.35 && value >= 0.2:
+		return inkColored
+	case luminance < 0.4:
+		return inkDark
+	}
+	return inkNone
+}
+
+// inkRegions groups ink cells of the same class that lie within markJoinCells of each
+// other.
+func inkRegions(grid []inkClass, cols, rows int) []markRegion {
+	seen := make([]bool, len(grid))
+	var regions []markRegion
+	var queue []int
+	for start, class := range grid {
+		if class == inkNone || seen[start] {
+			continue
+		}
+		region := markRegion{class: class, minX: cols, minY: rows, maxX: -1, maxY: -1}
+		seen[start] = true
+		queue = append(queue[:0], start)
+		for len(queue) > 0 {
+			index := queue[len(queue)-1]
+			queue = queue[:len(queue)-1]
+			x, y := index%cols, index/cols
+			region.cells++
+			region.minX, region.maxX = min(region.minX, x), max(region.maxX, x)
+			region.minY, region.maxY = min(region.minY, y), max(region.maxY, y)
+			for ny := max(0, y-markJoinCells); ny <= min(rows-1, y+markJoinCells); ny++ {
+				for nx := max(0, x-markJoinCells); nx <= min(cols-1, x+markJoinCells); nx++ {
+					neighbor := ny*cols + nx
+					if !seen[neighbor] && grid[neighbor] == class {
+						seen[neighbor] = true
+						queue = append(queue, neighbor)
+					}
+				}
+			}
+		}
+		regions = append(regions, region)
+	}
+	return regions
+}
+
+// cropPNG encodes the rect area of img as PNG, or returns nil if encoding fails.
+func cropPNG(img image.Image, rect image.Rectangle) []byte {
+	crop := image.NewRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(crop, crop.Bounds(), img, rect.Min, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, crop); err != nil {
+		return nil
+	}
+	return buf.Bytes()
+}
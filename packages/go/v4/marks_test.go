@@ -0,0 +1,100 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// markTestImage draws a printed line, a red ring stamp, and a blue handwritten stroke.
+func markTestImage(t *testing.T) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 600, 400))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for y := 40; y < 52; y++ {
+		for x := 50; x < 300; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	red := color.RGBA{R: 200, G: 20, B: 20, A: 255}
+	for a := 0.0; a < 2*math.Pi; a += 0.002 {
+		for r := 46.0; r <= 50; r++ {
+			img.Set(450+int(r*math.Cos(a)), 120+int(r*math.Sin(a)), red)
+		}
+	}
+	blue := color.RGBA{R: 20, G: 40, B: 180, A: 255}
+	for x := 50; x < 300; x++ {
+		y := 320 + int(15*math.Sin(float64(x)/12))
+		img.Set(x, y, blue)
+		img.Set(x, y+1, blue)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectMarks(t *testing.T) {
+	page := 2
+	result := &ExtractionResult{Images: []ExtractedImage{
+		{Data: markTestImage(t), Format: "png", ImageIndex: 3, PageNumber: &page},
+		{Data: []byte("not an image"), Format: "png", ImageIndex: 4},
+	}}
+	marks := DetectMarks(result, nil)
+	if len(marks) != 2 {
+		t.Fatalf("expected stamp and signature, got %+v", marks)
+	}
+	stamp, signature := marks[0], marks[1]
+	if stamp.Kind != MarkStamp || signature.Kind != MarkSignature {
+		t.Fatalf("unexpected kinds: %s, %s", stamp.Kind, signature.Kind)
+	}
+	if stamp.PageNumber != 2 || stamp.ImageIndex != 3 {
+		t.Errorf("stamp should carry its image's page and index: %+v", stamp)
+	}
+	if box := stamp.BoundingBox; box.X0 > 400 || box.X1 < 500 || box.Y0 > 230 || box.Y1 < 330 {
+		t.Errorf("stamp box %+v does not cover the ring", box)
+	}
+	if box := signature.BoundingBox; box.X0 > 50 || box.X1 < 299 || box.Y1 > 100 {
+		t.Errorf("signature box %+v does not cover the stroke", box)
+	}
+	crop, err := png.Decode(bytes.NewReader(signature.Crop))
+	if err != nil {
+		t.Fatalf("decode crop: %v", err)
+	}
+	if crop.Bounds().Dx() != int(signature.BoundingBox.X1-signature.BoundingBox.X0) {
+		t.Errorf("crop width %d does not match box %+v", crop.Bounds().Dx(), signature.BoundingBox)
+	}
+
+	stamps := DetectMarks(result, NewMarkDetectionConfig(WithMarkKinds(MarkStamp)))
+	if len(stamps) != 1 || stamps[0].Kind != MarkStamp {
+		t.Errorf("expected only the stamp, got %+v", stamps)
+	}
+}
+
+func TestMarkDetectionStage(t *testing.T) {
+	var validationErr *ValidationError
+	if err := validateResultStages(NewExtractionConfig(WithMarkDetection(WithMarkKinds("logo")))); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	config := NewExtractionConfig(WithMarkDetection())
+	native := nativeConfig(config)
+	if !imagesRequested(native) || config.Images != nil {
+		t.Fatalf("native config should request images without changing the caller's config")
+	}
+
+	result := &ExtractionResult{Images: []ExtractedImage{{Data: markTestImage(t), Format: "png"}}}
+	if err := runResultStages(result, config); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if len(result.Marks) != 2 || result.Images != nil {
+		t.Fatalf("expected marks without images, got %d marks and %d images", len(result.Marks), len(result.Images))
+	}
+}
@@ -0,0 +1,348 @@
+package kreuzberg
+
+// defaultMergeSeparator is placed between the contents of merged results.
+const defaultMergeSeparator = "\n\n"
+
+// MergeOptions controls MergeResults.
+type MergeOptions struct {
+	// Separator is inserted between the contents of consecutive results. Defaults to a
+	// blank line; use StringPtr("") to concatenate contents directly.
+	Separator *string
+
+	// TextStats configures the text statistics recomputed over the merged content when the
+	// results carry TextStats. Nil uses the defaults.
+	TextStats *TextStatsConfig
+}
+
+// MergeResults combines results extracted from consecutive parts of one document, such
+// as the chunks of a PDF split before extraction, into a single result.
+//
+// Contents are joined with the separator and every byte offset is shifted accordingly.
+// Pages are renumbered so that each part follows the previous one: a part occupies
+// PageStructure.TotalCount pages, or the highest page number it mentions, and at least
+// one. Tables, images, elements, and chunks are appended in order; image, element, and
+// chunk indices are renumbered. Normalized values, amounts, turns, acronyms, and segregated
+// content are shifted likewise; an acronym defined in several results keeps its first
+// definition and its uses are recounted over the merged content, and text statistics are
+// recomputed. Collapsed pages are renumbered and embedded documents appended. Document
+// metadata, and the normalized values read from it, are taken from the first result.
+// Translations are joined only when every result was translated into the same language.
+// The pages and chunks of results paged with PagingConfig are read back; a result whose
+// paging file was released contributes none and makes the merged result unsuccessful.
+// Nil results are skipped.
+func MergeResults(results []*ExtractionResult, opts MergeOptions) *ExtractionResult {
+	separator := defaultMergeSeparator
+	if opts.Separator != nil {
+		separator = *opts.Separator
+	}
+
+	var parts []*ExtractionResult
+	for _, result := range results {
+		if result != nil {
+			parts = append(parts, result)
+		}
+	}
+	if len(parts) == 0 {
+		return &ExtractionResult{Success: true}
+	}
+
+	merged := &ExtractionResult{
+		MimeType: parts[0].MimeType,
+		Metadata: parts[0].Metadata,
+		Success:  true,
+	}
+	merged.Metadata.PageStructure = nil
+	merged.Metadata.Reconciliation = nil
+	merged.Metadata.Fonts = nil
+	translated := mergeTranslations(parts)
+	if translated {
+		merged.TranslatedLanguage = parts[0].TranslatedLanguage
+	}
+
+	var content, translation []byte
+	var pageOffset uint64
+	var offsetUnit string
+	languages := make(map[string]bool)
+	acronyms := make(map[string]bool)
+	hasTextStats := false
+	for i, part := range parts {
+		if i > 0 {
+			content = append(content, separator...)
+			if translated {
+				translation = append(translation, separator...)
+			}
+		}
+		shift := uint64(len(content))
+		content = append(content, part.Content...)
+		merged.Success = merged.Success && part.Success
+		pages, chunks, err := pagedContents(part)
+		if err != nil {
+			merged.Success = false
+		}
+
+		for _, language := range part.DetectedLanguages {
+			if !languages[language] {
+				languages[language] = true
+				merged.DetectedLanguages = append(merged.DetectedLanguages, language)
+			}
+		}
+
+		if structure := part.Metadata.PageStructure; structure != nil {
+			if merged.Metadata.PageStructure == nil {
+				merged.Metadata.PageStructure = &PageStructure{UnitType: structure.UnitType}
+			}
+			target := merged.Metadata.PageStructure
+			for _, boundary := range structure.Boundaries {
+				if boundary.Offsets != nil {
+					offsetUnit = boundary.Offsets.Unit
+				}
+				target.Boundaries = append(target.Boundaries, PageBoundary{
+					ByteStart:  boundary.ByteStart + shift,
+					ByteEnd:    boundary.ByteEnd + shift,
+					PageNumber: boundary.PageNumber + pageOffset,
+				})
+			}
+			for _, page := range structure.Pages {
+				page.Number += pageOffset
+				target.Pages = append(target.Pages, page)
+			}
+		}
+
+		for _, page := range pages {
+			page.PageNumber += pageOffset
+			merged.Pages = append(merged.Pages, page)
+		}
+		for _, report := range part.Metadata.Reconciliation {
+			report.PageNumber += pageOffset
+			merged.Metadata.Reconciliation = append(merged.Metadata.Reconciliation, report)
+		}
+		merged.Metadata.Fonts = mergeFonts(merged.Metadata.Fonts, part.Metadata.Fonts, pageOffset)
+		for _, table := range part.Tables {
+			if table.PageNumber > 0 {
+				table.PageNumber += int(pageOffset)
+			}
+			merged.Tables = append(merged.Tables, table)
+		}
+		for _, image := range part.Images {
+			if image.PageNumber != nil {
+				page := *image.PageNumber + int(pageOffset)
+				image.PageNumber = &page
+			}
+			image.ImageIndex = len(merged.Images)
+			merged.Images = append(merged.Images, image)
+		}
+		for _, element := range part.Elements {
+			if element.Metadata.PageNumber != nil {
+				page := *element.Metadata.PageNumber + int64(pageOffset)
+				element.Metadata.PageNumber = &page
+			}
+			if element.Metadata.ElementIndex != nil {
+				index := int64(len(merged.Elements))
+				element.Metadata.ElementIndex = &index
+			}
+			merged.Elements = append(merged.Elements, element)
+		}
+		for _, chunk := range chunks {
+			meta := &chunk.Metadata
+			if meta.Offsets != nil {
+				offsetUnit = meta.Offsets.Unit
+				meta.Offsets = nil
+			}
+			meta.ByteStart += shift
+			meta.ByteEnd += shift
+			meta.ChunkIndex = len(merged.Chunks)
+			meta.FirstPage = shiftPage(meta.FirstPage, pageOffset)
+			meta.LastPage = shiftPage(meta.LastPage, pageOffset)
+			if meta.PageSpans != nil {
+				spans := make([]PageSpan, len(meta.PageSpans))
+				for j, span := range meta.PageSpans {
+					span.PageNumber += pageOffset
+					spans[j] = span
+				}
+				meta.PageSpans = spans
+			}
+			merged.Chunks = append(merged.Chunks, chunk)
+		}
+		for _, section := range part.Sections {
+			section.ByteStart += shift
+			section.ByteEnd += shift
+			merged.Sections = append(merged.Sections, section)
+		}
+		headings := cloneHeadings(part.Headings)
+		walkHeadings(headings, func(heading *Heading) {
+			heading.ByteStart += shift
+			heading.ByteEnd += shift
+			if heading.PageNumber > 0 {
+				heading.PageNumber += pageOffset
+			}
+		})
+		merged.Headings = append(merged.Headings, headings...)
+		for _, span := range part.StyleSpans {
+			span.ByteStart += shift
+			span.ByteEnd += shift
+			merged.StyleSpans = append(merged.StyleSpans, span)
+		}
+		for _, source := range part.TextSources {
+			source.ByteStart += shift
+			source.ByteEnd += shift
+			if source.PageNumber > 0 {
+				source.PageNumber += pageOffset
+			}
+			merged.TextSources = append(merged.TextSources, source)
+		}
+		for _, link := range part.Links {
+			if link.PageNumber > 0 {
+				link.PageNumber += pageOffset
+			}
+			merged.Links = append(merged.Links, link)
+		}
+		for _, pair := range part.KeyValues {
+			if pair.PageNumber > 0 {
+				pair.PageNumber += pageOffset
+			}
+			if pair.KeyEnd > 0 || pair.ValueEnd > 0 {
+				pair.KeyStart += shift
+				pair.KeyEnd += shift
+				pair.ValueStart += shift
+				pair.ValueEnd += shift
+			}
+			merged.KeyValues = append(merged.KeyValues, pair)
+		}
+		for _, value := range part.NormalizedValues {
+			if value.Field != "" {
+				// Values read from metadata follow the metadata of the first result.
+				if i == 0 {
+					merged.NormalizedValues = append(merged.NormalizedValues, value)
+				}
+				continue
+			}
+			value.ByteStart += shift
+			value.ByteEnd += shift
+			merged.NormalizedValues = append(merged.NormalizedValues, value)
+		}
+		for _, amount := range part.Amounts {
+			amount.ByteStart += shift
+			amount.ByteEnd += shift
+			merged.Amounts = append(merged.Amounts, amount)
+		}
+		for _, acronym := range part.Acronyms {
+			if !acronyms[acronym.Acronym] {
+				acronyms[acronym.Acronym] = true
+				acronym.ByteStart += shift
+				acronym.ByteEnd += shift
+				merged.Acronyms = append(merged.Acronyms, acronym)
+			}
+		}
+		for _, turn := range part.Turns {
+			turn.ByteStart += shift
+			turn.ByteEnd += shift
+			merged.Turns = append(merged.Turns, turn)
+		}
+		for _, segment := range part.SegregatedContent {
+			if segment.PageNumber > 0 {
+				segment.PageNumber += pageOffset
+			}
+			merged.SegregatedContent = append(merged.SegregatedContent, segment)
+		}
+		for _, page := range part.CollapsedPages {
+			merged.CollapsedPages = append(merged.CollapsedPages, page+pageOffset)
+		}
+		merged.EmbeddedDocuments = append(merged.EmbeddedDocuments, part.EmbeddedDocuments...)
+		hasTextStats = hasTextStats || part.TextStats != nil
+		for _, mark := range part.Marks {
+			if mark.PageNumber > 0 {
+				mark.PageNumber += pageOffset
+			}
+			mark.ImageIndex += len(merged.Images) - len(part.Images)
+			merged.Marks = append(merged.Marks, mark)
+		}
+
+		if translated {
+			targetShift := uint64(len(translation))
+			translation = append(translation, part.TranslatedContent...)
+			for _, segment := range part.TranslationSegments {
+				segment.SourceStart += shift
+				segment.SourceEnd += shift
+				segment.TargetStart += targetShift
+				segment.TargetEnd += targetShift
+				merged.TranslationSegments = append(merged.TranslationSegments, segment)
+			}
+		}
+
+		pageOffset += partPageCount(part)
+	}
+
+	merged.Content = string(content)
+	merged.TranslatedContent = string(translation)
+	for i := range merged.Chunks {
+		merged.Chunks[i].Metadata.TotalChunks = len(merged.Chunks)
+	}
+	for i := range merged.Acronyms {
+		merged.Acronyms[i].Uses = countAcronymUses(merged.Content, merged.Acronyms[i])
+	}
+	if hasTextStats {
+		merged.TextStats = ComputeTextStats(merged.Content, opts.TextStats)
+	}
+	if structure := merged.Metadata.PageStructure; structure != nil {
+		structure.TotalCount = pageOffset
+	}
+	applyOffsetUnit(merged, offsetUnit)
+	return merged
+}
+
+// mergeTranslations reports whether every part was translated into the same language.
+func mergeTranslations(parts []*ExtractionResult) bool {
+	for _, part := range parts {
+		if part.TranslatedContent == "" || part.TranslatedLanguage != parts[0].TranslatedLanguage {
+			return false
+		}
+	}
+	return true
+}
+
+func shiftPage(page *uint64, offset uint64) *uint64 {
+	if page == nil {
+		return nil
+	}
+	shifted := *page + offset
+	return &shifted
+}
+
+// partPageCount returns the number of pages a part occupies in a merged result.
+func partPageCount(part *ExtractionResult) uint64 {
+	if part.Metadata.PageStructure != nil && part.Metadata.PageStructure.TotalCount > 0 {
+		return part.Metadata.PageStructure.TotalCount
+	}
+	var highest uint64
+	note := func(page uint64) { highest = max(highest, page) }
+	if part.Metadata.PageStructure != nil {
+		for _, boundary := range part.Metadata.PageStructure.Boundaries {
+			note(boundary.PageNumber)
+		}
+	}
+	for _, page := range part.Pages {
+		note(page.PageNumber)
+	}
+	for _, page := range part.CollapsedPages {
+		note(page)
+	}
+	if part.paged != nil {
+		for _, entry := range part.paged.pages {
+			note(entry.pageNumber)
+		}
+	}
+	for _, table := range part.Tables {
+		note(uint64(max(table.PageNumber, 0)))
+	}
+	for _, image := range part.Images {
+		if image.PageNumber != nil {
+			note(uint64(max(*image.PageNumber, 0)))
+		}
+	}
+	for _, element := range part.Elements {
+		if element.Metadata.PageNumber != nil {
+			note(uint64(max(*element.Metadata.PageNumber, 0)))
+		}
+	}
+	return max(highest, 1)
+}
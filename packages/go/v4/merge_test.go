@@ -0,0 +1,101 @@
+package kreuzberg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeResultsRenumbersPagesAndChunks(t *testing.T) {
+	first := incrementalTestResult("aaa\nbbb", 0, 3, 4, 7)
+	first.Tables = []Table{{PageNumber: 2}}
+	first.Chunks = Chunks{
+		{Content: "aaa", Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: 3, ChunkIndex: 0, TotalChunks: 2}},
+		{Content: "bbb", Metadata: ChunkMetadata{ByteStart: 4, ByteEnd: 7, ChunkIndex: 1, TotalChunks: 2}},
+	}
+	second := incrementalTestResult("ccc", 0, 3)
+	second.Tables = []Table{{PageNumber: 1}}
+	page := uint64(1)
+	second.Chunks = Chunks{
+		{Content: "ccc", Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: 3, TotalChunks: 1, FirstPage: &page, LastPage: &page}},
+	}
+
+	merged := MergeResults([]*ExtractionResult{first, nil, second}, MergeOptions{})
+	if merged.Content != "aaa\nbbb\n\nccc" {
+		t.Fatalf("unexpected content %q", merged.Content)
+	}
+	wantBounds := []PageBoundary{
+		{ByteStart: 0, ByteEnd: 3, PageNumber: 1},
+		{ByteStart: 4, ByteEnd: 7, PageNumber: 2},
+		{ByteStart: 9, ByteEnd: 12, PageNumber: 3},
+	}
+	if !reflect.DeepEqual(merged.Metadata.PageStructure.Boundaries, wantBounds) {
+		t.Fatalf("got boundaries %+v, want %+v", merged.Metadata.PageStructure.Boundaries, wantBounds)
+	}
+	if merged.Metadata.PageStructure.TotalCount != 3 {
+		t.Fatalf("expected 3 pages, got %d", merged.Metadata.PageStructure.TotalCount)
+	}
+	if merged.Tables[0].PageNumber != 2 || merged.Tables[1].PageNumber != 3 {
+		t.Fatalf("unexpected table pages %+v", merged.Tables)
+	}
+
+	last := merged.Chunks[2]
+	if last.Metadata.ChunkIndex != 2 || last.Metadata.TotalChunks != 3 || last.Metadata.ByteStart != 9 || *last.Metadata.FirstPage != 3 {
+		t.Fatalf("unexpected chunk metadata %+v", last.Metadata)
+	}
+	if merged.Content[last.Metadata.ByteStart:last.Metadata.ByteEnd] != last.Content {
+		t.Fatalf("chunk offsets do not match content")
+	}
+	if *second.Chunks[0].Metadata.FirstPage != 1 || second.Chunks[0].Metadata.ByteStart != 0 {
+		t.Fatalf("input result was modified")
+	}
+}
+
+func TestMergeResultsSeparator(t *testing.T) {
+	parts := []*ExtractionResult{{Content: "a"}, {Content: "b"}}
+	if got := MergeResults(parts, MergeOptions{Separator: StringPtr("")}).Content; got != "ab" {
+		t.Fatalf("expected direct concatenation, got %q", got)
+	}
+	if merged := MergeResults(nil, MergeOptions{}); merged.Content != "" || !merged.Success {
+		t.Fatalf("unexpected empty merge %+v", merged)
+	}
+}
+
+func TestMergeResultsShiftsAnnotations(t *testing.T) {
+	first := &ExtractionResult{
+		Content:          "Service Level Agreement (SLA) on 5 kg",
+		Acronyms:         []Acronym{{Acronym: "SLA", Definition: "Service Level Agreement", ByteStart: 0, ByteEnd: 29}},
+		Amounts:          []Amount{{Kind: "measurement", Text: "5 kg", ByteStart: 33, ByteEnd: 37}},
+		NormalizedValues: []NormalizedValue{{Kind: ValueKindDate, Text: "2024", Field: "created_at", ByteEnd: 4}},
+		CollapsedPages:   []uint64{1},
+		TextStats:        &TextStats{},
+	}
+	second := &ExtractionResult{
+		Content:           "Ann: the SLA holds",
+		Acronyms:          []Acronym{{Acronym: "SLA", Definition: "Some Late Arrival", Uses: 1}},
+		Turns:             []Turn{{Speaker: "Ann", Text: "the SLA holds", ByteStart: 0, ByteEnd: 18}},
+		NormalizedValues:  []NormalizedValue{{Kind: ValueKindDate, Text: "2025", Field: "created_at", ByteEnd: 4}},
+		SegregatedContent: []LanguageSegment{{Language: "de", PageNumber: 1, Content: "Hallo"}},
+		EmbeddedDocuments: []EmbeddedDocument{{Name: "a.txt"}},
+	}
+
+	merged := MergeResults([]*ExtractionResult{first, second}, MergeOptions{})
+	if len(merged.Acronyms) != 1 || merged.Acronyms[0].Definition != "Service Level Agreement" || merged.Acronyms[0].Uses != 1 {
+		t.Fatalf("unexpected acronyms %+v", merged.Acronyms)
+	}
+	turn := merged.Turns[0]
+	if merged.Content[turn.ByteStart:turn.ByteEnd] != "Ann: the SLA holds" {
+		t.Fatalf("turn offsets do not match content: %+v", turn)
+	}
+	if amount := merged.Amounts[0]; merged.Content[amount.ByteStart:amount.ByteEnd] != "5 kg" {
+		t.Fatalf("amount offsets do not match content: %+v", amount)
+	}
+	if len(merged.NormalizedValues) != 1 || merged.NormalizedValues[0].Text != "2024" {
+		t.Fatalf("metadata values should follow the first result: %+v", merged.NormalizedValues)
+	}
+	if !reflect.DeepEqual(merged.CollapsedPages, []uint64{1}) || merged.SegregatedContent[0].PageNumber != 2 {
+		t.Fatalf("unexpected pages %v %+v", merged.CollapsedPages, merged.SegregatedContent)
+	}
+	if len(merged.EmbeddedDocuments) != 1 || merged.TextStats == nil || merged.TextStats.Words == 0 {
+		t.Fatalf("embedded documents or text stats dropped: %+v", merged)
+	}
+}
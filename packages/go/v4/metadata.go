@@ -1,6 +1,11 @@
 package kreuzberg
 
-import "encoding/json"
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"sync"
+)
 
 var metadataCoreKeys = map[string]struct{}{
 	"language":            {},
@@ -10,6 +15,13 @@ var metadataCoreKeys = map[string]struct{}{
 	"image_preprocessing": {},
 	"json_schema":         {},
 	"error":               {},
+	"fallback":            {},
+	"geo":                 {},
+	"fax":                 {},
+	"portfolio":           {},
+	"reconciliation":      {},
+	"speculation":         {},
+	"fonts":               {},
 }
 
 var formatFieldSets = map[FormatType][]string{
@@ -19,18 +31,36 @@ var formatFieldSets = map[FormatType][]string{
 		"width", "height", "summary",
 	},
 	FormatExcel:   {"sheet_count", "sheet_names"},
-	FormatEmail:   {"from_email", "from_name", "to_emails", "cc_emails", "bcc_emails", "message_id", "attachments"},
+	FormatEmail:   {"from_email", "from_name", "to_emails", "cc_emails", "bcc_emails", "message_id", "attachments", "meetings"},
 	FormatPPTX:    {"title", "author", "description", "summary", "fonts"},
-	FormatArchive: {"format", "file_count", "file_list", "total_size", "compressed_size"},
-	FormatImage:   {"width", "height", "format", "exif"},
+	FormatArchive: {"format", "file_count", "file_list", "total_size", "compressed_size", "entry_errors"},
+	FormatImage:   {"width", "height", "format", "exif", "camera_make", "camera_model", "captured_at", "orientation", "gps"},
 	FormatXML:     {"element_count", "unique_elements"},
-	FormatText:    {"line_count", "word_count", "character_count", "headers", "links", "code_blocks"},
+	FormatText:    {"line_count", "word_count", "character_count", "headers", "links", "code_blocks", "encoding"},
 	FormatHTML: {
 		"title", "description", "keywords", "author", "canonical_url", "base_href",
 		"language", "text_direction", "open_graph", "twitter_card", "meta_tags",
 		"headers", "links", "images", "structured_data",
 	},
-	FormatOCR: {"language", "psm", "output_format", "table_count", "table_rows", "table_cols"},
+	FormatOCR:  {"language", "psm", "output_format", "table_count", "table_rows", "table_cols"},
+	FormatCode: {"programming_language", "line_count", "symbols", "comments"},
+	FormatLog:  {"layout", "entry_count", "levels", "first_time", "last_time", "entries"},
+	FormatEDI:  {"standard", "version", "sender", "receiver", "control_number", "date", "segment_count", "transactions"},
+	FormatDICOM: {"patient_name", "patient_id", "patient_birth_date", "patient_sex", "patient_age", "study_instance_uid",
+		"study_date", "study_description", "accession_number", "series_instance_uid", "series_description", "modality",
+		"sop_class_uid", "sop_instance_uid", "manufacturer", "institution_name", "referring_physician_name",
+		"body_part_examined", "transfer_syntax_uid", "report_title", "rows", "columns", "number_of_frames",
+		"has_pixel_data", "anonymized"},
+	FormatRTF: {"title", "authors", "created_by", "modified_by", "generator", "created_at", "modified_at",
+		"revision", "page_count", "word_count", "character_count", "line_count", "paragraph_count"},
+	FormatLegacyOffice: {"application", "title", "subject", "author", "keywords", "comments", "template", "last_author",
+		"revision", "created_at", "modified_at", "page_count", "word_count", "character_count", "slide_count"},
+	FormatWordProcessor: {"application", "version", "encrypted"},
+	FormatFictionBook: {"title", "authors", "genres", "keywords", "annotation", "date", "sequence", "sequence_number",
+		"publisher", "year", "isbn"},
+	FormatDjVu: {"page_count", "width", "height", "dpi", "version", "text_pages", "compressed_text_pages", "title", "author"},
+	FormatXPS: {"title", "creator", "keywords", "description", "last_modified_by", "revision", "created_at", "modified_at",
+		"page_count", "document_count"},
 }
 
 // UnmarshalJSON ensures Metadata captures flattened format unions and additional custom fields.
@@ -71,6 +101,48 @@ func (m *Metadata) UnmarshalJSON(data []byte) error {
 			m.Error = &errMeta
 		}
 	}
+	if value, ok := raw["fallback"]; ok {
+		var fallback FallbackMetadata
+		if err := json.Unmarshal(value, &fallback); err == nil {
+			m.Fallback = &fallback
+		}
+	}
+	if value, ok := raw["geo"]; ok {
+		var geo GeoMetadata
+		if err := json.Unmarshal(value, &geo); err == nil {
+			m.Geo = &geo
+		}
+	}
+	if value, ok := raw["fax"]; ok {
+		var fax FaxMetadata
+		if err := json.Unmarshal(value, &fax); err == nil {
+			m.Fax = &fax
+		}
+	}
+	if value, ok := raw["portfolio"]; ok {
+		var portfolio PortfolioMetadata
+		if err := json.Unmarshal(value, &portfolio); err == nil {
+			m.Portfolio = &portfolio
+		}
+	}
+	if value, ok := raw["reconciliation"]; ok {
+		var reconciliation []PageReconciliation
+		if err := json.Unmarshal(value, &reconciliation); err == nil {
+			m.Reconciliation = reconciliation
+		}
+	}
+	if value, ok := raw["speculation"]; ok {
+		var speculation SpeculationMetadata
+		if err := json.Unmarshal(value, &speculation); err == nil {
+			m.Speculation = &speculation
+		}
+	}
+	if value, ok := raw["fonts"]; ok {
+		var fonts []FontInfo
+		if err := json.Unmarshal(value, &fonts); err == nil {
+			m.Fonts = fonts
+		}
+	}
 	if value, ok := raw["format_type"]; ok {
 		var format string
 		if err := json.Unmarshal(value, &format); err == nil {
@@ -78,6 +150,18 @@ func (m *Metadata) UnmarshalJSON(data []byte) error {
 		}
 	}
 
+	if m.deferred != nil {
+		m.Format = FormatMetadata{Type: m.Format.Type}
+		m.Additional = nil
+		m.deferred = &deferredMetadata{data: bytes.Clone(data)}
+		return nil
+	}
+	return m.decodeDeferred(data, raw)
+}
+
+// decodeDeferred decodes the format payload and the additional fields of the metadata
+// JSON data, whose keys are raw, once Format.Type is known.
+func (m *Metadata) decodeDeferred(data []byte, raw map[string]json.RawMessage) error {
 	if err := m.decodeFormat(data); err != nil {
 		return err
 	}
@@ -104,9 +188,74 @@ func (m *Metadata) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// deferredMetadata holds the metadata JSON of a result decoded with
+// ExtractionConfig.LazyMetadata until its format payload and additional fields are first
+// read. It is shared by the copies of the Metadata.
+type deferredMetadata struct {
+	once sync.Once
+	// mu guards data, which stages may amend until it is decoded.
+	mu         sync.Mutex
+	data       []byte
+	format     FormatMetadata
+	additional map[string]json.RawMessage
+}
+
+// materialize decodes the format payload and the additional fields of a Metadata decoded
+// lazily. A payload that fails to decode is left empty. Metadata decoded eagerly is
+// unchanged.
+func (m *Metadata) materialize() {
+	d := m.deferred
+	if d == nil {
+		return
+	}
+	d.once.Do(func() {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		decoded := Metadata{Format: FormatMetadata{Type: m.Format.Type}}
+		raw := map[string]json.RawMessage{}
+		if d.data != nil && json.Unmarshal(d.data, &raw) == nil && decoded.decodeDeferred(d.data, raw) != nil {
+			decoded.Format = FormatMetadata{Type: m.Format.Type}
+		}
+		d.format, d.additional, d.data = decoded.Format, decoded.Additional, nil
+	})
+	m.Format, m.Additional, m.deferred = d.format, d.additional, nil
+}
+
+// deferFields sets fields, keyed by their JSON names, in the metadata JSON of a Metadata
+// decoded lazily, without decoding it, so that they are decoded with the rest. It
+// reports whether the metadata was still undecoded; the caller sets the fields on the
+// decoded metadata otherwise.
+func (m *Metadata) deferFields(fields map[string]json.RawMessage) bool {
+	d := m.deferred
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.data == nil {
+		return false
+	}
+	raw := map[string]json.RawMessage{}
+	if err := json.Unmarshal(d.data, &raw); err != nil {
+		return false
+	}
+	maps.Copy(raw, fields)
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return false
+	}
+	d.data = data
+	return true
+}
+
+func lazyMetadata(config *ExtractionConfig) bool {
+	return config != nil && config.LazyMetadata != nil && *config.LazyMetadata
+}
+
 // MarshalJSON reserializes Metadata back into the flattened JSON structure that
 // the Rust core produces so round-tripping preserves the original payload.
 func (m Metadata) MarshalJSON() ([]byte, error) {
+	m.materialize()
 	out := make(map[string]any)
 
 	if m.Language != nil {
@@ -127,6 +276,27 @@ func (m Metadata) MarshalJSON() ([]byte, error) {
 	if m.Error != nil {
 		out["error"] = m.Error
 	}
+	if m.Fallback != nil {
+		out["fallback"] = m.Fallback
+	}
+	if m.Geo != nil {
+		out["geo"] = m.Geo
+	}
+	if m.Fax != nil {
+		out["fax"] = m.Fax
+	}
+	if m.Portfolio != nil {
+		out["portfolio"] = m.Portfolio
+	}
+	if len(m.Reconciliation) > 0 {
+		out["reconciliation"] = m.Reconciliation
+	}
+	if m.Speculation != nil {
+		out["speculation"] = m.Speculation
+	}
+	if len(m.Fonts) > 0 {
+		out["fonts"] = m.Fonts
+	}
 
 	formatFields, err := m.encodeFormat()
 	if err != nil {
@@ -143,6 +313,63 @@ func (m Metadata) MarshalJSON() ([]byte, error) {
 	return json.Marshal(out)
 }
 
+// Flatten returns the metadata as a flat map with dotted keys, such as pdf.page_count or
+// email.from_email, for indexing systems like Elasticsearch and OpenSearch. Format fields
+// are prefixed with the format type, the fields of nested objects are joined with dots,
+// and arrays are kept whole. Additional fields keep their names. Numbers are float64.
+func (m Metadata) Flatten() map[string]any {
+	m.materialize()
+	out := make(map[string]any)
+
+	common := m
+	common.Format = FormatMetadata{}
+	common.Additional = nil
+	if data, err := json.Marshal(common); err == nil {
+		var fields map[string]any
+		if json.Unmarshal(data, &fields) == nil {
+			flattenInto(out, "", fields)
+		}
+	}
+
+	if formatFields, err := m.encodeFormat(); err == nil {
+		for key, raw := range formatFields {
+			if key == "format_type" {
+				out[key] = string(m.Format.Type)
+				continue
+			}
+			var value any
+			if json.Unmarshal(raw, &value) == nil {
+				flattenInto(out, string(m.Format.Type)+"."+key, value)
+			}
+		}
+	}
+
+	for key, raw := range m.Additional {
+		var value any
+		if json.Unmarshal(raw, &value) == nil {
+			flattenInto(out, key, value)
+		}
+	}
+	return out
+}
+
+// flattenInto stores value under key in out, descending into objects. Null values are
+// left out.
+func flattenInto(out map[string]any, key string, value any) {
+	switch v := value.(type) {
+	case nil:
+	case map[string]any:
+		for name, field := range v {
+			if key != "" {
+				name = key + "." + name
+			}
+			flattenInto(out, name, field)
+		}
+	default:
+		out[key] = v
+	}
+}
+
 func (m *Metadata) decodeFormat(data []byte) error {
 	switch m.Format.Type {
 	case FormatPDF:
@@ -205,6 +432,66 @@ func (m *Metadata) decodeFormat(data []byte) error {
 			return err
 		}
 		m.Format.OCR = &meta
+	case FormatCode:
+		var meta CodeMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.Code = &meta
+	case FormatLog:
+		var meta LogMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.Log = &meta
+	case FormatEDI:
+		var meta EdiMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.EDI = &meta
+	case FormatDICOM:
+		var meta DicomMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.DICOM = &meta
+	case FormatRTF:
+		var meta RtfMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.RTF = &meta
+	case FormatLegacyOffice:
+		var meta LegacyOfficeMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.LegacyOffice = &meta
+	case FormatWordProcessor:
+		var meta WordProcessorMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.WordProcessor = &meta
+	case FormatFictionBook:
+		var meta FictionBookMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.FictionBook = &meta
+	case FormatDjVu:
+		var meta DjvuMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.DjVu = &meta
+	case FormatXPS:
+		var meta XpsMetadata
+		if err := json.Unmarshal(data, &meta); err != nil {
+			return err
+		}
+		m.Format.XPS = &meta
 	default:
 		m.Format.Type = FormatUnknown
 	}
@@ -245,6 +532,26 @@ func (m Metadata) encodeFormat() (map[string]json.RawMessage, error) {
 		payload = m.Format.HTML
 	case FormatOCR:
 		payload = m.Format.OCR
+	case FormatCode:
+		payload = m.Format.Code
+	case FormatLog:
+		payload = m.Format.Log
+	case FormatEDI:
+		payload = m.Format.EDI
+	case FormatDICOM:
+		payload = m.Format.DICOM
+	case FormatRTF:
+		payload = m.Format.RTF
+	case FormatLegacyOffice:
+		payload = m.Format.LegacyOffice
+	case FormatWordProcessor:
+		payload = m.Format.WordProcessor
+	case FormatFictionBook:
+		payload = m.Format.FictionBook
+	case FormatDjVu:
+		payload = m.Format.DjVu
+	case FormatXPS:
+		payload = m.Format.XPS
 	}
 
 	if payload == nil {
@@ -272,3 +579,38 @@ func encodeStructToRaw(value any) (map[string]json.RawMessage, error) {
 	}
 	return result, nil
 }
+
+// promoteRtfMetadata moves the information group the core reports for RTF documents
+// from Metadata.Additional into RtfMetadata.
+func promoteRtfMetadata(result *ExtractionResult) {
+	if result == nil || result.Metadata.Format.Type != FormatUnknown {
+		return
+	}
+	switch result.MimeType {
+	case "application/rtf", "text/rtf":
+	default:
+		return
+	}
+	result.Metadata.materialize()
+	fields := map[string]json.RawMessage{}
+	for _, key := range formatFieldSets[FormatRTF] {
+		if value, ok := result.Metadata.Additional[key]; ok {
+			fields[key] = value
+		}
+	}
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return
+	}
+	var meta RtfMetadata
+	if err := json.Unmarshal(raw, &meta); err != nil {
+		return
+	}
+	for key := range fields {
+		delete(result.Metadata.Additional, key)
+	}
+	if len(result.Metadata.Additional) == 0 {
+		result.Metadata.Additional = nil
+	}
+	result.Metadata.Format = FormatMetadata{Type: FormatRTF, RTF: &meta}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -1328,3 +1329,135 @@ func BenchmarkHTMLExtractionLargeDocument(b *testing.B) {
 		}
 	}
 }
+
+func TestArchiveMetadataEntryErrors(t *testing.T) {
+	input := []byte(`{
+		"format_type": "archive",
+		"format": "ZIP",
+		"file_count": 2,
+		"file_list": ["open.txt", "locked.txt"],
+		"total_size": 26,
+		"entry_errors": [{"path": "locked.txt", "error": "none of the provided passwords decrypts the entry"}]
+	}`)
+
+	var meta Metadata
+	if err := json.Unmarshal(input, &meta); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	archive, ok := meta.ArchiveMetadata()
+	if !ok {
+		t.Fatal("expected archive metadata")
+	}
+	if len(archive.EntryErrors) != 1 || archive.EntryErrors[0].Path != "locked.txt" {
+		t.Fatalf("expected an entry error for locked.txt, got %+v", archive.EntryErrors)
+	}
+	if len(meta.Additional) != 0 {
+		t.Fatalf("entry_errors leaked into additional metadata: %v", meta.Additional)
+	}
+
+	config := NewExtractionConfig(WithPasswords("first", "second"))
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !strings.Contains(string(encoded), `"passwords":["first","second"]`) {
+		t.Fatalf("expected passwords in the config JSON, got %s", encoded)
+	}
+}
+
+func TestLazyMetadataDecodesOnAccess(t *testing.T) {
+	input := []byte(`{"content":"text","mime_type":"application/pdf","metadata":{"subject":"Agenda","format_type":"pdf","title":"Doc","page_count":2,"custom_meta":{"score":42}}}`)
+
+	result, err := decodeResultBuffer(input, true)
+	if err != nil {
+		t.Fatalf("decodeResultBuffer: %v", err)
+	}
+	meta := result.Metadata
+	if meta.FormatType() != FormatPDF || meta.Subject == nil || *meta.Subject != "Agenda" {
+		t.Fatalf("expected the common fields to be decoded, got %+v", meta)
+	}
+	if meta.Format.Pdf != nil || meta.Additional != nil {
+		t.Fatalf("expected the format payload to be deferred, got %+v", meta.Format)
+	}
+
+	pdf, ok := meta.PdfMetadata()
+	if !ok || pdf.Title == nil || *pdf.Title != "Doc" {
+		t.Fatalf("expected the PDF title from the accessor, got %+v", pdf)
+	}
+	if _, ok := meta.AdditionalFields()["custom_meta"]; !ok {
+		t.Fatalf("expected custom_meta in the additional fields, got %v", meta.AdditionalFields())
+	}
+
+	encoded, err := json.Marshal(result.Metadata)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, field := range []string{`"title":"Doc"`, `"custom_meta":{"score":42}`} {
+		if !strings.Contains(string(encoded), field) {
+			t.Fatalf("expected %s in %s", field, encoded)
+		}
+	}
+}
+
+func TestMetadataFlatten(t *testing.T) {
+	input := []byte(`{
+		"language": "en",
+		"format_type": "email",
+		"from_email": "ana@example.com",
+		"to_emails": ["bo@example.com"],
+		"error": {"error_type": "ParsingError", "message": "partial"},
+		"custom_meta": {"score": 42}
+	}`)
+
+	var meta Metadata
+	if err := json.Unmarshal(input, &meta); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	flat := meta.Flatten()
+	expected := map[string]any{
+		"language":          "en",
+		"format_type":       "email",
+		"email.from_email":  "ana@example.com",
+		"email.to_emails":   []any{"bo@example.com"},
+		"error.error_type":  "ParsingError",
+		"error.message":     "partial",
+		"custom_meta.score": float64(42),
+	}
+	for key, value := range expected {
+		if !reflect.DeepEqual(flat[key], value) {
+			t.Errorf("flat[%q] = %#v, want %#v", key, flat[key], value)
+		}
+	}
+	for key := range flat {
+		if strings.HasPrefix(key, "email.") || key == "language" || key == "format_type" {
+			continue
+		}
+		if _, ok := expected[key]; !ok {
+			t.Errorf("unexpected key %q = %#v", key, flat[key])
+		}
+	}
+}
+
+func TestPromoteRtfMetadata(t *testing.T) {
+	raw := `{"title":"Minutes","subject":"Board","authors":["A. Smith"],"generator":"Riched20",` +
+		`"word_count":120,"created_at":"2003-05-01T09:00:00Z","custom":"kept"}`
+	result := &ExtractionResult{MimeType: "application/rtf"}
+	if err := json.Unmarshal([]byte(raw), &result.Metadata); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	promoteRtfMetadata(result)
+
+	meta, ok := result.Metadata.RtfMetadata()
+	if !ok {
+		t.Fatalf("missing RTF metadata: %+v", result.Metadata)
+	}
+	if meta.Title != "Minutes" || !slices.Equal(meta.Authors, []string{"A. Smith"}) || meta.WordCount != 120 {
+		t.Fatalf("unexpected RTF metadata: %+v", meta)
+	}
+	if result.Metadata.Subject == nil || *result.Metadata.Subject != "Board" {
+		t.Fatalf("subject = %v, want Board", result.Metadata.Subject)
+	}
+	if len(result.Metadata.Additional) != 1 || !strings.Contains(string(result.Metadata.Additional["custom"]), "kept") {
+		t.Fatalf("unexpected additional fields: %v", result.Metadata.Additional)
+	}
+}
@@ -0,0 +1,163 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"path/filepath"
+	"strings"
+)
+
+// MimeTypeMHTML is the MIME type of pages saved as MHTML. They are extracted by
+// reconstructing the page with its resources inlined as data URIs and passing it to the
+// HTML extractor, so results carry HtmlMetadata like any other web page.
+const MimeTypeMHTML = "multipart/related"
+
+// webResource is one file of a saved web page.
+type webResource struct {
+	url       string
+	contentID string
+	mimeType  string
+	data      []byte
+}
+
+// isMHTMLPath reports whether path has an MHTML extension.
+func isMHTMLPath(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".mht", ".mhtml":
+		return true
+	}
+	return false
+}
+
+// detectMHTML recognizes MHTML by content. It is told apart from email with inline
+// images by the headers browsers write when saving a page.
+func detectMHTML(data []byte) bool {
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != MimeTypeMHTML {
+		return false
+	}
+	return message.Header.Get("Snapshot-Content-Location") != "" || strings.Contains(message.Header.Get("From"), "Saved by")
+}
+
+// extractMHTML extracts an MHTML document by reconstructing its page and running the
+// HTML extractor on the result.
+func extractMHTML(data []byte, config *ExtractionConfig) (*ExtractionResult, error) {
+	page, resources, err := mhtmlResources(data)
+	if err != nil {
+		return nil, newParsingErrorWithContext("failed to read saved web page", err, ErrorCodeParsing, nil)
+	}
+	result, err := extractBytesNative(inlineWebResources(page, resources), "text/html", config)
+	if err != nil {
+		return nil, err
+	}
+	result.MimeType = MimeTypeMHTML
+	return result, nil
+}
+
+// mhtmlResources splits an MHTML document into its page, named by the start parameter or
+// else the first HTML part, and the resources saved with it.
+func mhtmlResources(data []byte) (webResource, []webResource, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		return webResource{}, nil, err
+	}
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || !strings.HasPrefix(mediaType, "multipart/") || params["boundary"] == "" {
+		return webResource{}, nil, errors.New("MHTML document is not a multipart message")
+	}
+
+	var resources []webResource
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return webResource{}, nil, err
+		}
+		body, err := io.ReadAll(transferDecoder(part, part.Header.Get("Content-Transfer-Encoding")))
+		if err != nil {
+			return webResource{}, nil, err
+		}
+		partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		resources = append(resources, webResource{
+			url:       part.Header.Get("Content-Location"),
+			contentID: strings.Trim(part.Header.Get("Content-ID"), "<>"),
+			mimeType:  partType,
+			data:      body,
+		})
+	}
+
+	main := -1
+	if start := strings.Trim(params["start"], "<>"); start != "" {
+		for i, resource := range resources {
+			if resource.contentID == start {
+				main = i
+				break
+			}
+		}
+	}
+	for i := 0; main < 0 && i < len(resources); i++ {
+		if resources[i].mimeType == "text/html" || resources[i].mimeType == "application/xhtml+xml" {
+			main = i
+		}
+	}
+	if main < 0 {
+		return webResource{}, nil, errors.New("MHTML document has no HTML part")
+	}
+	page := resources[main]
+	return page, append(resources[:main:main], resources[main+1:]...), nil
+}
+
+// inlineWebResources replaces the references of page to resources with data URIs.
+// References are matched as quoted attribute values or CSS url() arguments, by absolute
+// URL, by URL relative to the page, or by cid: Content-ID.
+func inlineWebResources(page webResource, resources []webResource) []byte {
+	var pairs []string
+	for _, resource := range resources {
+		if len(resource.data) == 0 {
+			continue
+		}
+		mimeType := resource.mimeType
+		if mimeType == "" {
+			mimeType = "application/octet-stream"
+		}
+		uri := "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(resource.data)
+		for _, ref := range webResourceRefs(page.url, resource) {
+			pairs = append(pairs,
+				`"`+ref+`"`, `"`+uri+`"`,
+				`'`+ref+`'`, `'`+uri+`'`,
+				`(`+ref+`)`, `(`+uri+`)`,
+			)
+		}
+	}
+	if len(pairs) == 0 {
+		return page.data
+	}
+	return []byte(strings.NewReplacer(pairs...).Replace(string(page.data)))
+}
+
+// webResourceRefs lists the strings a page may use to refer to resource.
+func webResourceRefs(pageURL string, resource webResource) []string {
+	var refs []string
+	if resource.contentID != "" {
+		refs = append(refs, "cid:"+resource.contentID)
+	}
+	if resource.url != "" {
+		refs = append(refs, resource.url)
+		if dir := pageURL[:strings.LastIndex(pageURL, "/")+1]; dir != "" && strings.HasPrefix(resource.url, dir) && len(resource.url) > len(dir) {
+			refs = append(refs, resource.url[len(dir):])
+		}
+	}
+	return refs
+}
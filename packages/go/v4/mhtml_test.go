@@ -0,0 +1,80 @@
+package kreuzberg
+
+import (
+	"strings"
+	"testing"
+)
+
+const testMHTML = "From: <Saved by Blink>\r\n" +
+	"Snapshot-Content-Location: https://example.com/\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/related; type=\"text/html\"; boundary=\"----boundary\"\r\n" +
+	"\r\n" +
+	"------boundary\r\n" +
+	"Content-Type: text/css\r\n" +
+	"Content-Location: https://example.com/site.css\r\n" +
+	"\r\n" +
+	"body{}\r\n" +
+	"------boundary\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"Content-Location: https://example.com/\r\n" +
+	"\r\n" +
+	"<html><head><title>Saved</title><link rel=3D\"stylesheet\" href=3D\"https://example.com/site.css\">" +
+	"</head><body><img src=3D\"cid:logo@example\"></body></html>\r\n" +
+	"------boundary\r\n" +
+	"Content-Type: image/gif\r\n" +
+	"Content-ID: <logo@example>\r\n" +
+	"Content-Transfer-Encoding: base64\r\n" +
+	"\r\n" +
+	"R0lG\r\n" +
+	"------boundary--\r\n"
+
+func TestMHTMLResources(t *testing.T) {
+	if !detectMHTML([]byte(testMHTML)) {
+		t.Fatal("MHTML document not detected")
+	}
+
+	page, resources, err := mhtmlResources([]byte(testMHTML))
+	if err != nil {
+		t.Fatalf("mhtmlResources: %v", err)
+	}
+	if page.mimeType != "text/html" || len(resources) != 2 {
+		t.Fatalf("unexpected page %q with %d resources", page.mimeType, len(resources))
+	}
+	html := string(inlineWebResources(page, resources))
+	for _, want := range []string{
+		`href="data:text/css;base64,Ym9keXt9"`,
+		`src="data:image/gif;base64,R0lG"`,
+		"<title>Saved</title>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("reconstructed page missing %q: %s", want, html)
+		}
+	}
+}
+
+func TestDetectMHTMLIgnoresEmail(t *testing.T) {
+	email := "From: alice@example.com\r\n" +
+		"Content-Type: multipart/related; boundary=\"b\"\r\n" +
+		"\r\n" +
+		"--b--\r\n"
+	if detectMHTML([]byte(email)) {
+		t.Fatal("email detected as MHTML")
+	}
+}
+
+func TestIsMHTMLPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"page.mht":        true,
+		"page.MHTML":      true,
+		"page.webarchive": false,
+		"page.html":       false,
+		"archive.mht.bak": false,
+		"dir.mht/ok":      false,
+	} {
+		if got := isMHTMLPath(path); got != want {
+			t.Errorf("isMHTMLPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
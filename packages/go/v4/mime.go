@@ -0,0 +1,41 @@
+package kreuzberg
+
+// MIME types of the pre-2007 binary Word and PowerPoint formats. The core reads them by
+// converting through LibreOffice, or reads their text and summary information itself
+// when LibreOffice is unavailable.
+const (
+	MimeTypeLegacyWord       = "application/msword"
+	MimeTypeLegacyPowerPoint = "application/vnd.ms-powerpoint"
+)
+
+// MimeTypeWebArchive is the MIME type of Safari webarchives. The core extracts them by
+// reconstructing the saved page and passing it to the HTML extractor.
+const MimeTypeWebArchive = "application/x-webarchive"
+
+// MimeTypeDICOM is the MIME type of DICOM Part 10 files. The core also recognizes them
+// by content when they are stored without an extension.
+const MimeTypeDICOM = "application/dicom"
+
+// MIME types of the legacy word processor formats. The core extracts their text, and
+// claims the .wpd, .lwp and .sam extensions only for files that carry their signature.
+const (
+	MimeTypeWordPerfect = "application/vnd.wordperfect"
+	MimeTypeWordPro     = "application/vnd.lotus-wordpro"
+	MimeTypeAmiPro      = "application/vnd.lotus-amipro"
+)
+
+// MimeTypeFictionBook is the MIME type of FictionBook 2 ebooks. The core also extracts
+// zipped books (.fb2.zip) and books in Windows-1251.
+const MimeTypeFictionBook = "application/x-fictionbook+xml"
+
+// MimeTypeDjVu is the MIME type of DjVu documents. The core extracts the uncompressed
+// text layers of single-page and bundled documents.
+const MimeTypeDjVu = "image/vnd.djvu"
+
+// MIME types of XML Paper Specification documents and their standardized successor. The
+// core extracts their text page by page, with the images they paint and their core
+// properties.
+const (
+	MimeTypeXPS  = "application/vnd.ms-xpsdocument"
+	MimeTypeOXPS = "application/oxps"
+)
@@ -0,0 +1,235 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MIME types of the image formats the core cannot decode, which the binding decodes
+// before handing the picture to the core's image extractor and OCR.
+const (
+	MimeTypeHEIC = "image/heic"
+	MimeTypeHEIF = "image/heif"
+	MimeTypeAVIF = "image/avif"
+	MimeTypeJXL  = "image/jxl"
+)
+
+// ImageDecoder decodes a HEIC, AVIF or JPEG XL picture. Register one with
+// RegisterImageDecoder to use a codec library such as libheif, libavif or libjxl.
+type ImageDecoder interface {
+	Decode(data []byte) (image.Image, error)
+}
+
+// ImageDecoderFunc adapts an ordinary function to the ImageDecoder interface.
+type ImageDecoderFunc func(data []byte) (image.Image, error)
+
+// Decode calls f(data).
+func (f ImageDecoderFunc) Decode(data []byte) (image.Image, error) {
+	return f(data)
+}
+
+var (
+	imageDecodersMu sync.RWMutex
+	imageDecoders   = map[string]ImageDecoder{}
+)
+
+// imageDecoderCommands lists, per MIME type, the command-line converters tried in order
+// when no decoder is registered. Each is run as "tool input output.png".
+var imageDecoderCommands = map[string][]string{
+	MimeTypeHEIC: {"heif-dec", "heif-convert", "magick"},
+	MimeTypeHEIF: {"heif-dec", "heif-convert", "magick"},
+	MimeTypeAVIF: {"avifdec", "heif-dec", "magick"},
+	MimeTypeJXL:  {"djxl", "magick"},
+}
+
+var modernImageExtensions = map[string]string{
+	".heic":  MimeTypeHEIC,
+	".heics": MimeTypeHEIC,
+	".heif":  MimeTypeHEIF,
+	".hif":   MimeTypeHEIF,
+	".avif":  MimeTypeAVIF,
+	".jxl":   MimeTypeJXL,
+}
+
+var modernImageFormats = map[string]string{
+	MimeTypeHEIC: "HEIC",
+	MimeTypeHEIF: "HEIF",
+	MimeTypeAVIF: "AVIF",
+	MimeTypeJXL:  "JXL",
+}
+
+// RegisterImageDecoder registers the decoder used for one of MimeTypeHEIC, MimeTypeHEIF,
+// MimeTypeAVIF or MimeTypeJXL, replacing the command-line converters.
+func RegisterImageDecoder(mimeType string, decoder ImageDecoder) error {
+	if _, ok := modernImageFormats[mimeType]; !ok {
+		return newValidationErrorWithContext(fmt.Sprintf("no image decoder can be registered for '%s'", mimeType), nil, ErrorCodeValidation, nil)
+	}
+	if decoder == nil {
+		return newValidationErrorWithContext("image decoder cannot be nil", nil, ErrorCodeValidation, nil)
+	}
+
+	imageDecodersMu.Lock()
+	defer imageDecodersMu.Unlock()
+	imageDecoders[mimeType] = decoder
+	return nil
+}
+
+// UnregisterImageDecoder removes the decoder registered for a MIME type.
+func UnregisterImageDecoder(mimeType string) error {
+	imageDecodersMu.Lock()
+	defer imageDecodersMu.Unlock()
+	if _, ok := imageDecoders[mimeType]; !ok {
+		return newPluginErrorWithContext(mimeType, fmt.Sprintf("no image decoder is registered for '%s'", mimeType), nil, ErrorCodePlugin, nil)
+	}
+	delete(imageDecoders, mimeType)
+	return nil
+}
+
+// ListImageDecoders returns the MIME types with a registered decoder in sorted order.
+func ListImageDecoders() ([]string, error) {
+	imageDecodersMu.RLock()
+	defer imageDecodersMu.RUnlock()
+
+	mimeTypes := make([]string, 0, len(imageDecoders))
+	for mimeType := range imageDecoders {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	return mimeTypes, nil
+}
+
+// ClearImageDecoders removes all registered image decoders.
+func ClearImageDecoders() error {
+	imageDecodersMu.Lock()
+	defer imageDecodersMu.Unlock()
+	imageDecoders = map[string]ImageDecoder{}
+	return nil
+}
+
+// modernImageMimeTypeFromPath maps HEIC, HEIF, AVIF and JPEG XL extensions to their MIME
+// type.
+func modernImageMimeTypeFromPath(path string) string {
+	return modernImageExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// detectModernImage recognizes HEIF-family files by the brands of their ftyp box and
+// JPEG XL by its codestream or container signature.
+func detectModernImage(data []byte) string {
+	if bytes.HasPrefix(data, []byte{0xff, 0x0a}) || bytes.HasPrefix(data, []byte("\x00\x00\x00\x0cJXL \r\n\x87\n")) {
+		return MimeTypeJXL
+	}
+	if len(data) < 16 || string(data[4:8]) != "ftyp" {
+		return ""
+	}
+	size := int(binary.BigEndian.Uint32(data))
+	if size < 16 || size > len(data) {
+		size = len(data)
+	}
+	brands := map[string]bool{string(data[8:12]): true}
+	for i := 16; i+4 <= size; i += 4 {
+		brands[string(data[i:i+4])] = true
+	}
+	switch {
+	case brands["avif"] || brands["avis"]:
+		return MimeTypeAVIF
+	case brands["heic"] || brands["heix"] || brands["heim"] || brands["heis"] || brands["hevc"] || brands["hevx"]:
+		return MimeTypeHEIC
+	case brands["mif1"] || brands["msf1"]:
+		return MimeTypeHEIF
+	}
+	return ""
+}
+
+// extractModernImage decodes a HEIC, AVIF or JPEG XL picture, with the registered decoder
+// or else the first command-line converter found, and extracts the resulting PNG with the
+// core so the usual image metadata and OCR apply.
+func extractModernImage(data []byte, mimeType string, config *ExtractionConfig) (*ExtractionResult, error) {
+	imageDecodersMu.RLock()
+	decoder := imageDecoders[mimeType]
+	imageDecodersMu.RUnlock()
+
+	var encoded []byte
+	if decoder != nil {
+		img, err := decoder.Decode(data)
+		if err != nil {
+			return nil, newParsingErrorWithContext("failed to decode "+modernImageFormats[mimeType]+" image", err, ErrorCodeParsing, nil)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, newParsingErrorWithContext("failed to re-encode decoded image", err, ErrorCodeParsing, nil)
+		}
+		encoded = buf.Bytes()
+	} else {
+		converted, err := convertModernImage(data, mimeType, config)
+		if err != nil {
+			return nil, err
+		}
+		encoded = converted
+	}
+
+	result, err := extractBytesCore(encoded, "image/png", config)
+	if err != nil {
+		return nil, err
+	}
+	result.MimeType = mimeType
+	if meta, ok := result.Metadata.ImageMetadata(); ok {
+		meta.Format = modernImageFormats[mimeType]
+	}
+	return result, nil
+}
+
+// convertModernImage runs the first converter from imageDecoderCommands found on PATH, in a
+// temporary directory subject to the RetentionConfig of config.
+func convertModernImage(data []byte, mimeType string, config *ExtractionConfig) (converted []byte, err error) {
+	tool := ""
+	for _, candidate := range imageDecoderCommands[mimeType] {
+		if _, err := exec.LookPath(candidate); err == nil {
+			tool = candidate
+			break
+		}
+	}
+	format := modernImageFormats[mimeType]
+	if tool == "" {
+		return nil, newUnsupportedFormatErrorWithContext(mimeType,
+			fmt.Sprintf("no %s decoder is available: register one with RegisterImageDecoder or install %s", format, strings.Join(imageDecoderCommands[mimeType], ", ")),
+			nil, ErrorCodeUnsupportedFormat, nil)
+	}
+
+	var retention *RetentionConfig
+	if config != nil {
+		retention = config.Retention
+	}
+	dir, err := createSpoolDir(retention, "kreuzberg-image-")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if retention == nil {
+			os.RemoveAll(dir)
+		} else if wipeErr := wipeTempDir(dir); wipeErr != nil && err == nil {
+			err = wipeErr
+		}
+	}()
+	input := filepath.Join(dir, "input."+strings.ToLower(format))
+	output := filepath.Join(dir, "output.png")
+	if err := os.WriteFile(input, data, 0o600); err != nil {
+		return nil, newIOErrorWithContext("failed to write temporary image", err, ErrorCodeIo, nil)
+	}
+	if out, err := exec.Command(tool, input, output).CombinedOutput(); err != nil {
+		return nil, newParsingErrorWithContext(fmt.Sprintf("%s failed to decode %s image: %s", tool, format, strings.TrimSpace(string(out))), err, ErrorCodeParsing, nil)
+	}
+	converted, err = os.ReadFile(output)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read converted image", err, ErrorCodeIo, nil)
+	}
+	return converted, nil
+}
@@ -0,0 +1,82 @@
+package kreuzberg
+
+import (
+	"errors"
+	"image"
+	"testing"
+)
+
+func TestDetectModernImage(t *testing.T) {
+	ftyp := func(major string, compatible ...string) []byte {
+		box := []byte("\x00\x00\x00\x00ftyp" + major + "\x00\x00\x00\x00")
+		for _, brand := range compatible {
+			box = append(box, brand...)
+		}
+		box[3] = byte(len(box))
+		return append(box, "\x00\x00\x00\x08meta"...)
+	}
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"iphone heic", ftyp("heic", "mif1", "miaf", "MiHB", "heic"), MimeTypeHEIC},
+		{"generic heif", ftyp("mif1", "mif1"), MimeTypeHEIF},
+		{"avif", ftyp("avif", "mif1", "miaf"), MimeTypeAVIF},
+		{"avif by compatible brand", ftyp("mif1", "avif"), MimeTypeAVIF},
+		{"jxl codestream", []byte{0xff, 0x0a, 0xfa, 0x1f}, MimeTypeJXL},
+		{"jxl container", []byte("\x00\x00\x00\x0cJXL \r\n\x87\n\x00\x00\x00\x14ftypjxl "), MimeTypeJXL},
+		{"mp4", ftyp("isom", "iso2", "mp41"), ""},
+		{"png", []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR"), ""},
+	}
+	for _, tc := range cases {
+		if got := detectModernImage(tc.data); got != tc.want {
+			t.Errorf("%s: detectModernImage = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+	if got := modernImageMimeTypeFromPath("IMG_0042.HEIC"); got != MimeTypeHEIC {
+		t.Errorf("modernImageMimeTypeFromPath = %q", got)
+	}
+}
+
+func TestImageDecoderRegistry(t *testing.T) {
+	t.Cleanup(func() { _ = ClearImageDecoders() })
+
+	decode := ImageDecoderFunc(func([]byte) (image.Image, error) { return nil, errors.New("corrupt bitstream") })
+	if err := RegisterImageDecoder("image/png", decode); err == nil {
+		t.Fatal("expected registering a decoder for a core format to fail")
+	}
+	if err := RegisterImageDecoder(MimeTypeHEIC, nil); err == nil {
+		t.Fatal("expected a nil decoder to be rejected")
+	}
+	if err := RegisterImageDecoder(MimeTypeHEIC, decode); err != nil {
+		t.Fatalf("RegisterImageDecoder: %v", err)
+	}
+	if mimeTypes, _ := ListImageDecoders(); len(mimeTypes) != 1 || mimeTypes[0] != MimeTypeHEIC {
+		t.Fatalf("ListImageDecoders = %v", mimeTypes)
+	}
+
+	_, err := extractModernImage([]byte("heic"), MimeTypeHEIC, nil)
+	var parsing *ParsingError
+	if !errors.As(err, &parsing) {
+		t.Fatalf("expected the decoder error to surface as a parsing error, got %v", err)
+	}
+
+	if err := UnregisterImageDecoder(MimeTypeHEIC); err != nil {
+		t.Fatalf("UnregisterImageDecoder: %v", err)
+	}
+	if err := UnregisterImageDecoder(MimeTypeHEIC); err == nil {
+		t.Fatal("expected unregistering twice to fail")
+	}
+}
+
+func TestExtractModernImageWithoutDecoder(t *testing.T) {
+	commands := imageDecoderCommands
+	imageDecoderCommands = map[string][]string{MimeTypeAVIF: {"kreuzberg-missing-avif-decoder"}}
+	t.Cleanup(func() { imageDecoderCommands = commands })
+
+	_, err := extractModernImage([]byte("avif"), MimeTypeAVIF, nil)
+	if !isUnsupportedFormat(err) {
+		t.Fatalf("expected an unsupported format error, got %v", err)
+	}
+}
@@ -0,0 +1,224 @@
+package kreuzberg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultMaxNestedBytes is the default of ExtractionConfig.MaxNestedBytes.
+const DefaultMaxNestedBytes int64 = 256 << 20
+
+// nestedEntry is a file read from an archive or attached to an email.
+type nestedEntry struct {
+	name string
+	data []byte
+	err  error
+}
+
+// nestedWalk is the state shared by the documents of one nested extraction.
+type nestedWalk struct {
+	config *ExtractionConfig
+	// remaining is what is left of the MaxNestedBytes budget.
+	remaining int64
+	// ancestors holds the hashes of the containers being expanded, for cycle detection.
+	ancestors map[[sha256.Size]byte]bool
+}
+
+var errNestedBudget = errors.New("nested size budget exhausted")
+
+func isNestedContainer(mimeType string) bool {
+	switch mimeType {
+	case "application/zip", "application/x-zip-compressed",
+		"application/x-tar", "application/tar", "application/x-gtar", "application/x-ustar":
+		return true
+	}
+	return isEmailMimeType(mimeType)
+}
+
+func validateNestedConfig(config *ExtractionConfig) error {
+	if config.MaxDepth != nil && *config.MaxDepth < 0 {
+		return newValidationErrorWithContext("max depth must not be negative", nil, ErrorCodeValidation, nil)
+	}
+	if config.MaxNestedBytes != nil && *config.MaxNestedBytes <= 0 {
+		return newValidationErrorWithContext("max nested bytes must be positive", nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// applyNestedStage extracts the entries of ZIP and TAR archives and the attachments of
+// emails into EmbeddedDocuments when config.MaxDepth is set, opening the containers found
+// among them down to MaxDepth levels. The decompressed entries of the whole tree share the
+// MaxNestedBytes budget, and a container holding a copy of one of its ancestors is not
+// opened again. 7z and RAR archives are not opened. read returns the original document.
+// An entry that fails to extract carries its error instead of failing the document.
+func applyNestedStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.MaxDepth == nil || *config.MaxDepth == 0 ||
+		!isNestedContainer(result.MimeType) || result.Metadata.Error != nil {
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	child := *config
+	child.MaxDepth = nil
+	walk := &nestedWalk{
+		config:    &child,
+		remaining: DefaultMaxNestedBytes,
+		ancestors: map[[sha256.Size]byte]bool{},
+	}
+	if config.MaxNestedBytes != nil {
+		walk.remaining = *config.MaxNestedBytes
+	}
+	walk.expand(result, data, *config.MaxDepth)
+}
+
+// expand extracts the entries of the container result was extracted from, data, and
+// expands the containers among them while depth allows.
+func (w *nestedWalk) expand(result *ExtractionResult, data []byte, depth int) {
+	hash := sha256.Sum256(data)
+	w.ancestors[hash] = true
+	defer delete(w.ancestors, hash)
+
+	for _, entry := range w.entries(data, result.MimeType) {
+		document := EmbeddedDocument{Name: entry.name}
+		switch {
+		case entry.err != nil:
+			document.Error = entry.err.Error()
+		case len(entry.data) == 0:
+			document.Error = "entry is empty"
+		case w.ancestors[sha256.Sum256(entry.data)]:
+			document.Error = "entry is a copy of an enclosing container"
+		default:
+			document.MimeType, document.Result, document.Error = w.extract(entry)
+			if document.Result != nil && depth > 1 && isNestedContainer(document.MimeType) {
+				w.expand(document.Result, entry.data, depth-1)
+			}
+		}
+		result.EmbeddedDocuments = append(result.EmbeddedDocuments, document)
+	}
+}
+
+func (w *nestedWalk) extract(entry nestedEntry) (string, *ExtractionResult, string) {
+	mimeType, err := mimeTypeFromName(entry.name)
+	if err != nil {
+		if mimeType, err = DetectMimeType(entry.data); err != nil {
+			return "", nil, "could not determine the entry's format"
+		}
+	}
+	result, err := ExtractBytesSync(entry.data, mimeType, w.config)
+	if err != nil {
+		return mimeType, nil, err.Error()
+	}
+	return mimeType, result, ""
+}
+
+// entries reads the files of a container.
+func (w *nestedWalk) entries(data []byte, mimeType string) []nestedEntry {
+	switch mimeType {
+	case "application/zip", "application/x-zip-compressed":
+		return w.zipEntries(data)
+	case mimeTypeEML, mimeTypeMSG:
+		return w.attachmentEntries(data, mimeType)
+	}
+	return w.tarEntries(data)
+}
+
+// take reads r whole, charging what it reads to the budget.
+func (w *nestedWalk) take(r io.Reader) ([]byte, error) {
+	if w.remaining <= 0 {
+		return nil, errNestedBudget
+	}
+	data, err := io.ReadAll(io.LimitReader(r, w.remaining+1))
+	w.remaining -= int64(len(data))
+	if err != nil {
+		return nil, err
+	}
+	if w.remaining < 0 {
+		return nil, errNestedBudget
+	}
+	return data, nil
+}
+
+func (w *nestedWalk) zipEntries(data []byte) []nestedEntry {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	var entries []nestedEntry
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		entry := nestedEntry{name: file.Name}
+		switch {
+		case file.Flags&0x1 != 0:
+			entry.err = errors.New("entry is encrypted")
+		case w.remaining <= 0 || file.UncompressedSize64 > uint64(w.remaining):
+			entry.err = errNestedBudget
+		default:
+			var rc io.ReadCloser
+			if rc, entry.err = file.Open(); entry.err == nil {
+				entry.data, entry.err = w.take(rc)
+				rc.Close()
+			}
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// tarEntries reads a TAR archive, which may be gzip-compressed.
+func (w *nestedWalk) tarEntries(data []byte) []nestedEntry {
+	var stream io.Reader = bytes.NewReader(data)
+	if gz, err := gzip.NewReader(bytes.NewReader(data)); err == nil {
+		stream = gz
+	}
+	reader := tar.NewReader(stream)
+	var entries []nestedEntry
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			return entries
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		entry := nestedEntry{name: header.Name}
+		if header.Size > w.remaining {
+			entry.err = errNestedBudget
+		} else {
+			entry.data, entry.err = w.take(reader)
+		}
+		entries = append(entries, entry)
+	}
+}
+
+func (w *nestedWalk) attachmentEntries(data []byte, mimeType string) []nestedEntry {
+	email, err := parseEmail(data, mimeType)
+	if err != nil {
+		return nil
+	}
+	var entries []nestedEntry
+	for i, attachment := range email.Attachments {
+		entry := nestedEntry{name: fmt.Sprintf("attachment %d", i+1)}
+		if attachment.Name != nil && *attachment.Name != "" {
+			entry.name = *attachment.Name
+		}
+		if attachment.Data == nil {
+			entry.err = errors.New("attachment has no content")
+		} else {
+			entry.data, entry.err = w.take(base64.NewDecoder(base64.StdEncoding, strings.NewReader(*attachment.Data)))
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,103 @@
+package kreuzberg
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"testing"
+)
+
+func buildZip(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, data := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func newTestWalk(budget int64) *nestedWalk {
+	return &nestedWalk{config: &ExtractionConfig{}, remaining: budget, ancestors: map[[sha256.Size]byte]bool{}}
+}
+
+func TestNestedZipEntriesChargeBudget(t *testing.T) {
+	data := buildZip(t, map[string][]byte{"a.txt": bytes.Repeat([]byte("a"), 600)})
+	walk := newTestWalk(1000)
+	entries := walk.zipEntries(data)
+	if len(entries) != 1 || entries[0].err != nil || len(entries[0].data) != 600 {
+		t.Fatalf("expected one 600-byte entry, got %+v", entries)
+	}
+	if walk.remaining != 400 {
+		t.Fatalf("expected 400 bytes left, got %d", walk.remaining)
+	}
+	if entries := walk.zipEntries(data); entries[0].err != errNestedBudget {
+		t.Fatalf("expected the budget to be exhausted, got %v", entries[0].err)
+	}
+}
+
+func TestNestedTarEntriesReadGzip(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	writer := tar.NewWriter(gz)
+	content := []byte("inside a tarball")
+	if err := writer.WriteHeader(&tar.Header{Name: "docs/inner.txt", Mode: 0o600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := writer.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := newTestWalk(DefaultMaxNestedBytes).tarEntries(buf.Bytes())
+	if len(entries) != 1 || entries[0].name != "docs/inner.txt" || string(entries[0].data) != string(content) {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+}
+
+func TestNestedCycleIsNotExpanded(t *testing.T) {
+	inner := buildZip(t, map[string][]byte{"leaf.txt": []byte("leaf")})
+	outer := buildZip(t, map[string][]byte{"inner.zip": inner})
+	walk := newTestWalk(DefaultMaxNestedBytes)
+	walk.ancestors[sha256.Sum256(inner)] = true
+
+	result := &ExtractionResult{MimeType: "application/zip"}
+	walk.expand(result, outer, 3)
+	if len(result.EmbeddedDocuments) != 1 {
+		t.Fatalf("expected one embedded document, got %+v", result.EmbeddedDocuments)
+	}
+	if document := result.EmbeddedDocuments[0]; document.Name != "inner.zip" || document.Result != nil || document.Error == "" {
+		t.Fatalf("expected the copy to be refused, got %+v", document)
+	}
+	if len(walk.ancestors) != 1 {
+		t.Fatalf("expected the container to leave the ancestors, got %d", len(walk.ancestors))
+	}
+}
+
+func TestNestedConfigValidation(t *testing.T) {
+	if err := validateResultStages(NewExtractionConfig(WithMaxDepth(-1))); err == nil {
+		t.Fatal("expected an error for a negative max depth")
+	}
+	if err := validateResultStages(NewExtractionConfig(WithMaxNestedBytes(0))); err == nil {
+		t.Fatal("expected an error for a zero nested budget")
+	}
+	if err := validateResultStages(NewExtractionConfig(WithMaxDepth(2), WithMaxNestedBytes(1<<20))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Supported values for NormalizationConfig.GermanEszett.
+const (
+	// GermanEszettKeep leaves ß and ẞ untouched.
+	GermanEszettKeep = "keep"
+	// GermanEszettExpand replaces ß with "ss" and ẞ with "SS".
+	GermanEszettExpand = "ss"
+)
+
+// arabicFormsB lists the base letters of the Arabic Presentation Forms-B block in code
+// point order starting at U+FE80, together with the number of contextual forms each has.
+var arabicFormsB = []struct {
+	base  string
+	forms int
+}{
+	{"ء", 1}, {"آ", 2}, {"أ", 2}, {"ؤ", 2}, {"إ", 2},
+	{"ئ", 4}, {"ا", 2}, {"ب", 4}, {"ة", 2}, {"ت", 4},
+	{"ث", 4}, {"ج", 4}, {"ح", 4}, {"خ", 4}, {"د", 2},
+	{"ذ", 2}, {"ر", 2}, {"ز", 2}, {"س", 4}, {"ش", 4},
+	{"ص", 4}, {"ض", 4}, {"ط", 4}, {"ظ", 4}, {"ع", 4},
+	{"غ", 4}, {"ف", 4}, {"ق", 4}, {"ك", 4}, {"ل", 4},
+	{"م", 4}, {"ن", 4}, {"ه", 4}, {"و", 2}, {"ى", 2},
+	{"ي", 4},
+	{"لآ", 2}, {"لأ", 2}, {"لإ", 2}, {"لا", 2},
+}
+
+// arabicHarakatForms maps the isolated and medial presentation forms of the harakat
+// (U+FE70–U+FE7F) back to their combining marks.
+var arabicHarakatForms = map[rune]string{
+	0xFE70: "ً", 0xFE71: "ً", 0xFE72: "ٌ", 0xFE74: "ٍ",
+	0xFE76: "َ", 0xFE77: "َ", 0xFE78: "ُ", 0xFE79: "ُ",
+	0xFE7A: "ِ", 0xFE7B: "ِ", 0xFE7C: "ّ", 0xFE7D: "ّ",
+	0xFE7E: "ْ", 0xFE7F: "ْ",
+}
+
+var arabicPresentationForms = buildArabicPresentationForms()
+
+func buildArabicPresentationForms() map[rune]string {
+	forms := make(map[rune]string, 160)
+	for r, base := range arabicHarakatForms {
+		forms[r] = base
+	}
+	next := rune(0xFE80)
+	for _, letter := range arabicFormsB {
+		for i := 0; i < letter.forms; i++ {
+			forms[next] = letter.base
+			next++
+		}
+	}
+	return forms
+}
+
+// validateNormalizationConfig rejects unknown normalization settings.
+func validateNormalizationConfig(cfg *NormalizationConfig) error {
+	switch cfg.GermanEszett {
+	case "", GermanEszettKeep, GermanEszettExpand:
+		return validateValueLocale(cfg.Locale)
+	default:
+		return newValidationErrorWithContext(
+			fmt.Sprintf("invalid german_eszett mode: %s (must be %q or %q)", cfg.GermanEszett, GermanEszettKeep, GermanEszettExpand),
+			nil, ErrorCodeValidation, nil)
+	}
+}
+
+// NormalizeText applies the text normalization rules in cfg to text.
+// It is the same transformation applied to extraction results when
+// ExtractionConfig.Normalization is set.
+func NormalizeText(text string, cfg *NormalizationConfig) string {
+	fn := normalizationRuneFunc(cfg)
+	if fn == nil {
+		return text
+	}
+	out, _ := rewriteRunesAt(text, fn)
+	return out
+}
+
+// applyNormalization normalizes Content, Pages, and Chunks of result in place and
+// remaps chunk and page byte offsets to the normalized Content, then records the dates
+// and numbers of the normalized Content when enabled.
+func applyNormalization(result *ExtractionResult, cfg *NormalizationConfig) {
+	if fn := normalizationRuneFunc(cfg); fn != nil {
+		rewriteResultTextAt(result, fn)
+	}
+	applyValueNormalization(result, cfg)
+}
+
+// zeroWidthCharacters are the invisible characters removed by
+// NormalizationConfig.StripZeroWidth. The joiners U+200C and U+200D are kept, as they
+// change how Persian, Indic scripts and emoji are written.
+const zeroWidthCharacters = "\u00AD\u180E\u200B\u2060\uFEFF"
+
+// normalizationRuneFunc builds the per-rune rewrite function for cfg, or nil when no
+// normalization is enabled. It receives the text and the offset of the rune, as line
+// endings and byte-order marks depend on their position.
+func normalizationRuneFunc(cfg *NormalizationConfig) func(s string, i int, r rune) (string, bool) {
+	if cfg == nil {
+		return nil
+	}
+	fullWidth := cfg.FullWidthToHalfWidth != nil && *cfg.FullWidthToHalfWidth
+	arabic := cfg.ArabicShaping != nil && *cfg.ArabicShaping
+	eszett := cfg.GermanEszett == GermanEszettExpand
+	bom := cfg.StripBOM != nil && *cfg.StripBOM
+	newlines := cfg.NormalizeNewlines != nil && *cfg.NormalizeNewlines
+	controls := cfg.StripControlCharacters != nil && *cfg.StripControlCharacters
+	zeroWidth := cfg.StripZeroWidth != nil && *cfg.StripZeroWidth
+	if !fullWidth && !arabic && !eszett && !bom && !newlines && !controls && !zeroWidth {
+		return nil
+	}
+
+	return func(s string, i int, r rune) (string, bool) {
+		if bom && r == 0xFEFF && i == 0 {
+			return "", true
+		}
+		if newlines && r == '\r' {
+			if strings.HasPrefix(s[i+1:], "\n") {
+				return "", true
+			}
+			return "\n", true
+		}
+		if controls && unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' && r != '\f' {
+			return "", true
+		}
+		if zeroWidth && strings.ContainsRune(zeroWidthCharacters, r) {
+			return "", true
+		}
+		if fullWidth {
+			switch {
+			case r >= 0xFF01 && r <= 0xFF5E:
+				return string(r - 0xFF01 + '!'), true
+			case r == 0x3000:
+				return " ", true
+			}
+		}
+		if arabic {
+			if r == 0x0640 {
+				// Tatweel only stretches glyphs and carries no meaning.
+				return "", true
+			}
+			if base, ok := arabicPresentationForms[r]; ok {
+				return base, true
+			}
+		}
+		if eszett {
+			switch r {
+			case 'ß':
+				return "ss", true
+			case 'ẞ':
+				return "SS", true
+			}
+		}
+		return "", false
+	}
+}
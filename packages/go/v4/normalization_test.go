@@ -0,0 +1,135 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNormalizeTextFullWidth(t *testing.T) {
+	cfg := NewNormalizationConfig(WithFullWidthToHalfWidth(true))
+	got := NormalizeText("ＡＢＣ　１２３！東京", cfg)
+	if got != "ABC 123!東京" {
+		t.Fatalf("unexpected normalization: %q", got)
+	}
+}
+
+func TestNormalizeTextArabicShaping(t *testing.T) {
+	cfg := NewNormalizationConfig(WithArabicShaping(true))
+	// "سلام" written with presentation forms plus a tatweel.
+	got := NormalizeText("ﺳﻠـﻼﻡ", cfg)
+	if got != "سللام" {
+		t.Fatalf("unexpected normalization: %q", got)
+	}
+}
+
+func TestNormalizeTextGermanEszett(t *testing.T) {
+	keep := NormalizeText("Straße", NewNormalizationConfig(WithGermanEszett(GermanEszettKeep)))
+	if keep != "Straße" {
+		t.Fatalf("keep mode changed text: %q", keep)
+	}
+	expand := NormalizeText("Straße STRAẞE", NewNormalizationConfig(WithGermanEszett(GermanEszettExpand)))
+	if expand != "Strasse STRASSE" {
+		t.Fatalf("unexpected expansion: %q", expand)
+	}
+}
+
+func TestNormalizeTextPolicy(t *testing.T) {
+	text := "\ufeffone\r\ntwo\rthree\x00\u200bfour\u200d\f\ufeff"
+	bom := NormalizeText(text, NewNormalizationConfig(WithStripBOM(true)))
+	if bom != text[3:] {
+		t.Fatalf("unexpected BOM removal: %q", bom)
+	}
+	all := NormalizeText(text, NewNormalizationConfig(WithStripBOM(true), WithNormalizeNewlines(true),
+		WithStripControlCharacters(true), WithStripZeroWidth(true)))
+	if all != "one\ntwo\nthreefour\u200d\f" {
+		t.Fatalf("unexpected normalization: %q", all)
+	}
+}
+
+func TestApplyNormalizationPolicyOffsets(t *testing.T) {
+	content := "\ufeffline one\r\nline two\r\n"
+	second := strings.Index(content, "line two")
+	result := &ExtractionResult{
+		Content: content,
+		Chunks: []Chunk{
+			{Content: content[:second], Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: uint64(second)}},
+			{Content: content[second:], Metadata: ChunkMetadata{ByteStart: uint64(second), ByteEnd: uint64(len(content))}},
+		},
+		Pages: []PageContent{{PageNumber: 1, Content: content}},
+	}
+	applyNormalization(result, NewNormalizationConfig(WithStripBOM(true), WithNormalizeNewlines(true)))
+	if result.Content != "line one\nline two\n" || result.Pages[0].Content != result.Content {
+		t.Fatalf("unexpected content %q, page %q", result.Content, result.Pages[0].Content)
+	}
+	for i, chunk := range result.Chunks {
+		if span := result.Content[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]; span != chunk.Content {
+			t.Errorf("chunk %d offsets point to %q, want %q", i, span, chunk.Content)
+		}
+	}
+}
+
+func TestNormalizeTextNoOptions(t *testing.T) {
+	text := "ＡＢＣ Straße"
+	if got := NormalizeText(text, &NormalizationConfig{}); got != text {
+		t.Fatalf("empty config should not change text, got %q", got)
+	}
+	if got := NormalizeText(text, nil); got != text {
+		t.Fatalf("nil config should not change text, got %q", got)
+	}
+}
+
+func TestApplyNormalizationRemapsOffsets(t *testing.T) {
+	first := "ＡＢ cd"
+	second := "Fuß"
+	content := first + "\n" + second
+	secondStart := uint64(len(first) + 1)
+
+	result := &ExtractionResult{
+		Content: content,
+		Chunks: []Chunk{
+			{Content: first, Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: uint64(len(first))}},
+			{Content: second, Metadata: ChunkMetadata{ByteStart: secondStart, ByteEnd: uint64(len(content))}},
+		},
+		Pages: []PageContent{{PageNumber: 1, Content: content}},
+		Metadata: Metadata{PageStructure: &PageStructure{
+			TotalCount: 2,
+			UnitType:   PageUnitTypePage,
+			Boundaries: []PageBoundary{
+				{ByteStart: 0, ByteEnd: secondStart, PageNumber: 1},
+				{ByteStart: secondStart, ByteEnd: uint64(len(content)), PageNumber: 2},
+			},
+		}},
+	}
+
+	cfg := NewNormalizationConfig(WithFullWidthToHalfWidth(true), WithGermanEszett(GermanEszettExpand))
+	applyNormalization(result, cfg)
+
+	if result.Content != "AB cd\nFuss" {
+		t.Fatalf("unexpected content: %q", result.Content)
+	}
+	if result.Pages[0].Content != result.Content {
+		t.Fatalf("page content not normalized: %q", result.Pages[0].Content)
+	}
+	for i, chunk := range result.Chunks {
+		span := result.Content[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]
+		if span != chunk.Content {
+			t.Errorf("chunk %d offsets point to %q, want %q", i, span, chunk.Content)
+		}
+	}
+	boundaries := result.Metadata.PageStructure.Boundaries
+	if boundaries[1].ByteStart != 6 || boundaries[1].ByteEnd != uint64(len(result.Content)) {
+		t.Errorf("unexpected page boundary: %+v", boundaries[1])
+	}
+}
+
+func TestValidateNormalizationConfig(t *testing.T) {
+	if err := validateNormalizationConfig(NewNormalizationConfig(WithGermanEszett(GermanEszettExpand))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := validateNormalizationConfig(NewNormalizationConfig(WithGermanEszett("sz")))
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
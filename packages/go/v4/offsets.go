@@ -0,0 +1,140 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"sort"
+	"unicode/utf8"
+)
+
+// Offset units accepted by ExtractionConfig.OffsetUnit and the offset conversion helpers.
+const (
+	// OffsetUnitBytes counts UTF-8 bytes, the unit of all ByteStart/ByteEnd fields.
+	OffsetUnitBytes = "bytes"
+	// OffsetUnitRunes counts Unicode code points (Python str indices, Go runes).
+	OffsetUnitRunes = "runes"
+	// OffsetUnitUTF16 counts UTF-16 code units (JavaScript, C#, Java string indices).
+	OffsetUnitUTF16 = "utf16"
+)
+
+// OffsetRange is a [Start, End) range expressed in Unit.
+type OffsetRange struct {
+	Unit  string `json:"unit"`
+	Start uint64 `json:"start"`
+	End   uint64 `json:"end"`
+}
+
+func validateOffsetUnit(unit string) error {
+	switch unit {
+	case "", OffsetUnitBytes, OffsetUnitRunes, OffsetUnitUTF16:
+		return nil
+	}
+	return newValidationErrorWithContext(fmt.Sprintf("invalid offset unit: %s", unit), nil, ErrorCodeValidation, nil)
+}
+
+// unitWidth returns how many code points or UTF-16 code units the rune r occupies.
+func unitWidth(r rune, unit string) uint64 {
+	if unit == OffsetUnitUTF16 && r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+// ByteOffsetToUnit converts a byte offset in text to unit. The offset must lie on a rune
+// boundary within text.
+func ByteOffsetToUnit(text string, offset uint64, unit string) (uint64, error) {
+	if err := validateOffsetUnit(unit); err != nil {
+		return 0, err
+	}
+	if offset > uint64(len(text)) || (offset < uint64(len(text)) && !utf8.RuneStart(text[offset])) {
+		return 0, newValidationErrorWithContext(fmt.Sprintf("byte offset %d is not a rune boundary of the text", offset), nil, ErrorCodeValidation, nil)
+	}
+	if unit == "" || unit == OffsetUnitBytes {
+		return offset, nil
+	}
+	var count uint64
+	for _, r := range text[:offset] {
+		count += unitWidth(r, unit)
+	}
+	return count, nil
+}
+
+// UnitOffsetToByte converts an offset in unit back to a byte offset in text. Offsets that
+// fall inside a rune, such as between the two halves of a UTF-16 surrogate pair, are
+// rejected.
+func UnitOffsetToByte(text string, offset uint64, unit string) (uint64, error) {
+	if err := validateOffsetUnit(unit); err != nil {
+		return 0, err
+	}
+	if unit == "" || unit == OffsetUnitBytes {
+		return ByteOffsetToUnit(text, offset, OffsetUnitBytes)
+	}
+	var count uint64
+	for i, r := range text {
+		if count == offset {
+			return uint64(i), nil
+		}
+		count += unitWidth(r, unit)
+		if count > offset {
+			break
+		}
+	}
+	if count == offset {
+		return uint64(len(text)), nil
+	}
+	return 0, newValidationErrorWithContext(fmt.Sprintf("%s offset %d is not a boundary of the text", unit, offset), nil, ErrorCodeValidation, nil)
+}
+
+// convertByteOffsets converts many byte offsets of text to unit in one pass. Offsets that
+// fall inside a rune are rounded down to its start.
+func convertByteOffsets(text string, offsets []uint64, unit string) map[uint64]uint64 {
+	sorted := append([]uint64(nil), offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	converted := make(map[uint64]uint64, len(sorted))
+	var count uint64
+	next := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		for next < len(sorted) && sorted[next] < uint64(i+size) {
+			converted[sorted[next]] = count
+			next++
+		}
+		count += unitWidth(r, unit)
+		i += size
+	}
+	for ; next < len(sorted); next++ {
+		converted[sorted[next]] = count
+	}
+	return converted
+}
+
+// applyOffsetUnit fills OffsetRange fields of chunks and page boundaries with their
+// positions in unit. Byte offsets are left untouched.
+func applyOffsetUnit(result *ExtractionResult, unit string) {
+	if unit == "" || unit == OffsetUnitBytes {
+		return
+	}
+	var offsets []uint64
+	for _, chunk := range result.Chunks {
+		offsets = append(offsets, chunk.Metadata.ByteStart, chunk.Metadata.ByteEnd)
+	}
+	var boundaries []PageBoundary
+	if result.Metadata.PageStructure != nil {
+		boundaries = result.Metadata.PageStructure.Boundaries
+	}
+	for _, boundary := range boundaries {
+		offsets = append(offsets, boundary.ByteStart, boundary.ByteEnd)
+	}
+	if len(offsets) == 0 {
+		return
+	}
+
+	converted := convertByteOffsets(result.Content, offsets, unit)
+	for i := range result.Chunks {
+		meta := &result.Chunks[i].Metadata
+		meta.Offsets = &OffsetRange{Unit: unit, Start: converted[meta.ByteStart], End: converted[meta.ByteEnd]}
+	}
+	for i := range boundaries {
+		boundaries[i].Offsets = &OffsetRange{Unit: unit, Start: converted[boundaries[i].ByteStart], End: converted[boundaries[i].ByteEnd]}
+	}
+}
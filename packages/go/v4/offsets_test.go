@@ -0,0 +1,78 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOffsetConversion(t *testing.T) {
+	text := "a€😀b" // 1 + 3 + 4 + 1 bytes; 4 runes; 5 UTF-16 code units
+	cases := []struct {
+		bytes, runes, utf16 uint64
+	}{
+		{0, 0, 0},
+		{1, 1, 1},
+		{4, 2, 2},
+		{8, 3, 4},
+		{9, 4, 5},
+	}
+	for _, tc := range cases {
+		if got, err := ByteOffsetToUnit(text, tc.bytes, OffsetUnitRunes); err != nil || got != tc.runes {
+			t.Errorf("byte %d to runes = %d, %v; want %d", tc.bytes, got, err, tc.runes)
+		}
+		if got, err := ByteOffsetToUnit(text, tc.bytes, OffsetUnitUTF16); err != nil || got != tc.utf16 {
+			t.Errorf("byte %d to utf16 = %d, %v; want %d", tc.bytes, got, err, tc.utf16)
+		}
+		if got, err := UnitOffsetToByte(text, tc.runes, OffsetUnitRunes); err != nil || got != tc.bytes {
+			t.Errorf("rune %d to bytes = %d, %v; want %d", tc.runes, got, err, tc.bytes)
+		}
+		if got, err := UnitOffsetToByte(text, tc.utf16, OffsetUnitUTF16); err != nil || got != tc.bytes {
+			t.Errorf("utf16 %d to bytes = %d, %v; want %d", tc.utf16, got, err, tc.bytes)
+		}
+	}
+
+	var validationErr *ValidationError
+	if _, err := ByteOffsetToUnit(text, 2, OffsetUnitRunes); !errors.As(err, &validationErr) {
+		t.Errorf("offset inside a rune should fail, got %v", err)
+	}
+	if _, err := UnitOffsetToByte(text, 3, OffsetUnitUTF16); !errors.As(err, &validationErr) {
+		t.Errorf("offset inside a surrogate pair should fail, got %v", err)
+	}
+	if _, err := UnitOffsetToByte(text, 6, OffsetUnitRunes); !errors.As(err, &validationErr) {
+		t.Errorf("offset past the end should fail, got %v", err)
+	}
+	if _, err := ByteOffsetToUnit(text, 0, "codepoints"); !errors.As(err, &validationErr) {
+		t.Errorf("unknown unit should fail, got %v", err)
+	}
+}
+
+func TestOffsetUnitStage(t *testing.T) {
+	content := "héllo wörld"
+	result := &ExtractionResult{
+		Content: content,
+		Chunks: []Chunk{
+			{Content: "héllo", Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: 6}},
+			{Content: "wörld", Metadata: ChunkMetadata{ByteStart: 7, ByteEnd: uint64(len(content))}},
+		},
+		Metadata: Metadata{PageStructure: &PageStructure{Boundaries: []PageBoundary{
+			{ByteStart: 0, ByteEnd: uint64(len(content)), PageNumber: 1},
+		}}},
+	}
+	if err := runResultStages(result, NewExtractionConfig(WithOffsetUnit(OffsetUnitRunes))); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if got := result.Chunks[1].Metadata.Offsets; got == nil || *got != (OffsetRange{Unit: OffsetUnitRunes, Start: 6, End: 11}) {
+		t.Fatalf("unexpected chunk offsets: %+v", got)
+	}
+	if got := result.Metadata.PageStructure.Boundaries[0].Offsets; got == nil || got.End != 11 {
+		t.Fatalf("unexpected page offsets: %+v", got)
+	}
+	if result.Chunks[1].Metadata.ByteStart != 7 {
+		t.Errorf("byte offsets must be left untouched")
+	}
+
+	var validationErr *ValidationError
+	if err := validateResultStages(NewExtractionConfig(WithOffsetUnit("chars"))); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+)
+
+// packedBatches reports whether batches are extracted with config into a single packed
+// buffer rather than into separate C results.
+func packedBatches(config *ExtractionConfig) bool {
+	return config != nil && config.PackedBatches != nil && *config.PackedBatches
+}
+
+// packedCompressAbove returns the total content size from which a packed batch is
+// compressed: that of ResultCompressionConfig when set, or else never.
+func packedCompressAbove(config *ExtractionConfig) C.uintptr_t {
+	if threshold := compressAbove(config); threshold >= 0 {
+		return C.uintptr_t(threshold)
+	}
+	return ^C.uintptr_t(0)
+}
+
+// batchExtractFilesPacked runs the native batch extraction of cPaths into a packed
+// buffer. Must be called while holding ffiMutex.
+func batchExtractFilesPacked(cPaths []*C.char, cfgPtr *C.char, config *ExtractionConfig) ([]*ExtractionResult, error) {
+	var length C.uintptr_t
+	ptr := C.kreuzberg_batch_extract_files_packed((**C.char)(unsafe.Pointer(&cPaths[0])), C.uintptr_t(len(cPaths)), cfgPtr, packedCompressAbove(config), &length)
+	return decodeCBatchBuffer(ptr, length, lazyMetadata(config))
+}
+
+// batchExtractBytesPacked runs the native batch extraction of cItems into a packed
+// buffer. Must be called while holding ffiMutex.
+func batchExtractBytesPacked(cItems []C.CBytesWithMime, cfgPtr *C.char, config *ExtractionConfig) ([]*ExtractionResult, error) {
+	var length C.uintptr_t
+	ptr := C.kreuzberg_batch_extract_bytes_packed((*C.CBytesWithMime)(unsafe.Pointer(&cItems[0])), C.uintptr_t(len(cItems)), cfgPtr, packedCompressAbove(config), &length)
+	return decodeCBatchBuffer(ptr, length, lazyMetadata(config))
+}
+
+// decodeCBatchBuffer decodes a packed batch returned by the core library in place and
+// frees it. Must be called while holding ffiMutex, for lastError.
+func decodeCBatchBuffer(ptr *C.uint8_t, length C.uintptr_t, lazy bool) ([]*ExtractionResult, error) {
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_bytes(ptr, length)
+	return decodeBatchBuffer(unsafe.Slice((*byte)(unsafe.Pointer(ptr)), int(length)), lazy)
+}
+
+// decodeBatchBuffer decodes a packed batch, a JSON array of results that may be
+// zstd-compressed, one result at a time.
+func decodeBatchBuffer(data []byte, lazy bool) ([]*ExtractionResult, error) {
+	reader, release := resultBufferReader(data)
+	defer release()
+
+	decoder := json.NewDecoder(reader)
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		if err == nil {
+			err = fmt.Errorf("expected an array of results, got %v", token)
+		}
+		return nil, newSerializationErrorWithContext("failed to decode batch results", err, ErrorCodeValidation, nil)
+	}
+	results := make([]*ExtractionResult, 0)
+	for decoder.More() {
+		result, err := decodeResult(decoder, lazy)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode batch results", err, ErrorCodeValidation, nil)
+	}
+	return results, nil
+}
@@ -0,0 +1,36 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeBatchBuffer(t *testing.T) {
+	results, err := decodeBatchBuffer([]byte(`[{"content":"first","mime_type":"text/plain","metadata":{},"success":true},{"content":"Error: unreadable","mime_type":"text/plain","metadata":{"error":{"error_type":"ParsingError","message":"unreadable"}},"success":true}]`), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Content != "first" || !results[0].Success {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if results[1].Metadata.Error == nil || results[1].Metadata.Error.Message != "unreadable" {
+		t.Errorf("unexpected error metadata: %+v", results[1].Metadata)
+	}
+
+	var serializationErr *SerializationError
+	if _, err := decodeBatchBuffer([]byte(`{"content":"not a batch"}`), false); !errors.As(err, &serializationErr) {
+		t.Errorf("expected SerializationError for an object, got %v", err)
+	}
+	if _, err := decodeBatchBuffer([]byte(`[{"content":"first"},{"content":`), false); !errors.As(err, &serializationErr) {
+		t.Errorf("expected SerializationError for a truncated batch, got %v", err)
+	}
+}
+
+func TestPackedBatches(t *testing.T) {
+	if packedBatches(nil) || packedBatches(NewExtractionConfig(WithPackedBatches(false))) || !packedBatches(NewExtractionConfig(WithPackedBatches(true))) {
+		t.Error("packed batches should follow WithPackedBatches")
+	}
+	if got := packedCompressAbove(NewExtractionConfig(WithPackedBatches(true), WithResultCompression(WithMinContentBytes(4096)))); got != 4096 {
+		t.Errorf("packedCompressAbove = %d, want 4096", got)
+	}
+}
@@ -0,0 +1,216 @@
+package kreuzberg
+
+import (
+	"strings"
+	"unicode"
+)
+
+// PageDeduplicationConfig flags the blank pages of a document and the pages repeating an
+// earlier one, such as cover sheets, fax headers and separator pages, in
+// PageContent.Blank and PageContent.DuplicateOf. Pages are compared by their text with
+// case and whitespace ignored.
+type PageDeduplicationConfig struct {
+	// Collapse removes blank and duplicate pages from Pages, Content and Chunks and lists
+	// them in ExtractionResult.CollapsedPages. Default: false, pages are only flagged.
+	Collapse bool `json:"collapse,omitempty"`
+	// IgnoreDigits compares pages with their digits ignored, so that fax headers and
+	// cover sheets differing only by a page number, date or time match. Default: false.
+	IgnoreDigits bool `json:"ignore_digits,omitempty"`
+}
+
+// blankPageMaxRunes is the number of letters and digits below which a page without tables
+// is blank, leaving room for a page number or specks recognized on an empty scan.
+const blankPageMaxRunes = 4
+
+// pageText is the text of one page, from Pages or else from a page boundary of Content.
+type pageText struct {
+	number uint64
+	text   string
+	tables int
+}
+
+// resultPageTexts returns the pages of result in order, from Pages when it has them and
+// otherwise from the page boundaries of Content.
+func resultPageTexts(result *ExtractionResult) []pageText {
+	var pages []pageText
+	if len(result.Pages) > 0 {
+		for _, page := range result.Pages {
+			pages = append(pages, pageText{number: page.PageNumber, text: page.Content, tables: len(page.Tables)})
+		}
+		return pages
+	}
+	if result.Metadata.PageStructure == nil {
+		return nil
+	}
+	for _, boundary := range result.Metadata.PageStructure.Boundaries {
+		if boundary.ByteStart > boundary.ByteEnd || boundary.ByteEnd > uint64(len(result.Content)) {
+			return nil
+		}
+		pages = append(pages, pageText{number: boundary.PageNumber, text: result.Content[boundary.ByteStart:boundary.ByteEnd]})
+	}
+	return pages
+}
+
+// isBlankPage reports whether page holds no tables and fewer than blankPageMaxRunes
+// letters and digits.
+func isBlankPage(page pageText) bool {
+	if page.tables > 0 {
+		return false
+	}
+	count := 0
+	for _, r := range page.text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if count++; count >= blankPageMaxRunes {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// pageKey returns the text pages are compared by: lower-cased, with runs of whitespace
+// collapsed and, when ignoreDigits is set, runs of digits too.
+func pageKey(text string, ignoreDigits bool) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	space, digit := false, false
+	for _, r := range strings.TrimSpace(text) {
+		switch {
+		case unicode.IsSpace(r):
+			if !space {
+				b.WriteByte(' ')
+			}
+			space, digit = true, false
+			continue
+		case ignoreDigits && unicode.IsDigit(r):
+			if !digit {
+				b.WriteByte('#')
+			}
+			space, digit = false, true
+			continue
+		}
+		space, digit = false, false
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// classifyPages returns the blank pages of pages and, for each page repeating an earlier
+// one, the number of the first page with its text.
+func classifyPages(pages []pageText, ignoreDigits bool) (blank map[uint64]bool, duplicateOf map[uint64]uint64) {
+	blank = map[uint64]bool{}
+	duplicateOf = map[uint64]uint64{}
+	first := map[string]uint64{}
+	for _, page := range pages {
+		if isBlankPage(page) {
+			blank[page.number] = true
+			continue
+		}
+		key := pageKey(page.text, ignoreDigits)
+		if number, ok := first[key]; ok {
+			duplicateOf[page.number] = number
+			continue
+		}
+		first[key] = page.number
+	}
+	return blank, duplicateOf
+}
+
+// applyPageDeduplication flags the blank and duplicate pages of result and, with
+// PageDeduplicationConfig.Collapse, removes them. Their text is removed from Content by
+// page boundary, with the separator that follows it or, for trailing pages, precedes
+// it, and chunks lying wholly within removed text are dropped.
+func applyPageDeduplication(result *ExtractionResult, cfg *PageDeduplicationConfig) {
+	texts := resultPageTexts(result)
+	blank, duplicateOf := classifyPages(texts, cfg.IgnoreDigits)
+	if len(blank) == 0 && len(duplicateOf) == 0 {
+		return
+	}
+	for i := range result.Pages {
+		page := &result.Pages[i]
+		page.Blank = blank[page.PageNumber]
+		page.DuplicateOf = duplicateOf[page.PageNumber]
+	}
+	if !cfg.Collapse {
+		return
+	}
+	collapsed := func(number uint64) bool {
+		_, duplicate := duplicateOf[number]
+		return blank[number] || duplicate
+	}
+	for _, page := range texts {
+		if collapsed(page.number) {
+			result.CollapsedPages = append(result.CollapsedPages, page.number)
+		}
+	}
+
+	pages := result.Pages[:0]
+	for _, page := range result.Pages {
+		if !collapsed(page.PageNumber) {
+			pages = append(pages, page)
+		}
+	}
+	if result.Pages != nil {
+		result.Pages = pages
+	}
+
+	if structure := result.Metadata.PageStructure; structure != nil {
+		boundaries := structure.Boundaries
+		var spans [][2]int
+		kept := boundaries[:0:0]
+		for i, boundary := range boundaries {
+			if !collapsed(boundary.PageNumber) {
+				kept = append(kept, boundary)
+				continue
+			}
+			// Remove the page up to the next one.
+			span := [2]int{int(boundary.ByteStart), int(boundary.ByteEnd)}
+			if i+1 < len(boundaries) {
+				span[1] = int(boundaries[i+1].ByteStart)
+			}
+			if n := len(spans); n > 0 && spans[n-1][1] >= span[0] {
+				spans[n-1][1] = max(spans[n-1][1], span[1])
+			} else {
+				spans = append(spans, span)
+			}
+		}
+		// Trailing pages are removed from the end of the last page kept instead.
+		if n := len(spans); n > 0 && len(kept) > 0 && collapsed(boundaries[len(boundaries)-1].PageNumber) {
+			spans[n-1][0] = min(spans[n-1][0], int(kept[len(kept)-1].ByteEnd))
+		}
+		if len(spans) > 0 && spans[len(spans)-1][1] <= len(result.Content) {
+			dropCollapsedChunks(result, spans)
+			content, m := removeSpans(result.Content, spans)
+			result.Content = content
+			remapResultOffsets(result, m)
+			structure.Boundaries = kept
+		}
+	}
+}
+
+// dropCollapsedChunks removes the chunks of result lying wholly within spans and renumbers
+// the others.
+func dropCollapsedChunks(result *ExtractionResult, spans [][2]int) {
+	within := func(meta ChunkMetadata) bool {
+		for _, span := range spans {
+			if meta.ByteEnd > meta.ByteStart && int(meta.ByteStart) >= span[0] && int(meta.ByteEnd) <= span[1] {
+				return true
+			}
+		}
+		return false
+	}
+	chunks := result.Chunks[:0]
+	for _, chunk := range result.Chunks {
+		if !within(chunk.Metadata) {
+			chunks = append(chunks, chunk)
+		}
+	}
+	if len(chunks) == len(result.Chunks) {
+		return
+	}
+	for i := range chunks {
+		chunks[i].Metadata.ChunkIndex = i
+		chunks[i].Metadata.TotalChunks = len(chunks)
+	}
+	result.Chunks = chunks
+}
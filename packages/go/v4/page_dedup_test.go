@@ -0,0 +1,69 @@
+package kreuzberg
+
+import "testing"
+
+// joinedPagesResult builds a result whose Content joins pages with blank lines, with one
+// page boundary and PageContent per page.
+func joinedPagesResult(pages ...string) *ExtractionResult {
+	result := &ExtractionResult{Metadata: Metadata{PageStructure: &PageStructure{TotalCount: uint64(len(pages))}}}
+	for i, text := range pages {
+		if i > 0 {
+			result.Content += "\n\n"
+		}
+		start := uint64(len(result.Content))
+		result.Content += text
+		number := uint64(i + 1)
+		result.Metadata.PageStructure.Boundaries = append(result.Metadata.PageStructure.Boundaries,
+			PageBoundary{ByteStart: start, ByteEnd: uint64(len(result.Content)), PageNumber: number})
+		result.Pages = append(result.Pages, PageContent{PageNumber: number, Content: text})
+	}
+	return result
+}
+
+func TestPageDeduplicationFlags(t *testing.T) {
+	cover := "FAX COVER SHEET\nTo: Legal\nFrom: Records"
+	result := joinedPagesResult(cover, "Invoice 1042 due on receipt.", " \n. ", "fax  cover sheet\nto: legal\nfrom: records")
+	if err := runResultStages(result, NewExtractionConfig(WithPageDeduplication(false))); err != nil {
+		t.Fatal(err)
+	}
+	pages := result.Pages
+	if pages[0].Blank || pages[0].DuplicateOf != 0 || !pages[2].Blank || pages[3].DuplicateOf != 1 {
+		t.Fatalf("Pages = %+v", pages)
+	}
+	if len(result.Pages) != 4 || result.CollapsedPages != nil {
+		t.Fatal("flagged pages should be kept")
+	}
+
+	stamped := joinedPagesResult("Sent 10:41 page 1 of 2", "Sent 10:42 page 2 of 2")
+	applyPageDeduplication(stamped, &PageDeduplicationConfig{IgnoreDigits: true})
+	if stamped.Pages[1].DuplicateOf != 1 {
+		t.Fatalf("Pages = %+v", stamped.Pages)
+	}
+}
+
+func TestPageDeduplicationCollapse(t *testing.T) {
+	result := joinedPagesResult("Cover sheet for the contract", "First clause.", "", "Cover sheet for the contract")
+	last := uint64(len(result.Content) - len("Cover sheet for the contract"))
+	result.Chunks = Chunks{
+		{Content: "Cover sheet for the contract\n\nFirst clause.", Metadata: ChunkMetadata{ByteEnd: 43, TotalChunks: 2}},
+		{Content: "Cover sheet for the contract", Metadata: ChunkMetadata{ChunkIndex: 1, ByteStart: last, ByteEnd: uint64(len(result.Content)), TotalChunks: 2}},
+	}
+	applyPageDeduplication(result, &PageDeduplicationConfig{Collapse: true})
+
+	if want := "Cover sheet for the contract\n\nFirst clause."; result.Content != want {
+		t.Fatalf("Content = %q", result.Content)
+	}
+	if len(result.Pages) != 2 || result.Pages[1].PageNumber != 2 {
+		t.Fatalf("Pages = %+v", result.Pages)
+	}
+	if len(result.CollapsedPages) != 2 || result.CollapsedPages[0] != 3 || result.CollapsedPages[1] != 4 {
+		t.Fatalf("CollapsedPages = %v", result.CollapsedPages)
+	}
+	boundaries := result.Metadata.PageStructure.Boundaries
+	if len(boundaries) != 2 || boundaries[1].ByteEnd != uint64(len(result.Content)) {
+		t.Fatalf("Boundaries = %+v", boundaries)
+	}
+	if len(result.Chunks) != 1 || result.Chunks[0].Metadata.TotalChunks != 1 {
+		t.Fatalf("Chunks = %+v", result.Chunks)
+	}
+}
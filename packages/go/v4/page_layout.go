@@ -0,0 +1,200 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"math"
+)
+
+// Coordinate units accepted by ExtractionConfig.CoordinateUnit.
+const (
+	// CoordinateUnitPoints measures in points of 1/72 inch, the unit of PDF and of
+	// PageInfo.Dimensions.
+	CoordinateUnitPoints = "pt"
+	// CoordinateUnitInches measures in inches.
+	CoordinateUnitInches = "in"
+	// CoordinateUnitMillimeters measures in millimeters.
+	CoordinateUnitMillimeters = "mm"
+	// CoordinateUnitNormalized measures as a fraction of the width and height of the page,
+	// from 0 to 1, so that bounding boxes compare across page sizes.
+	CoordinateUnitNormalized = "normalized"
+)
+
+// Page orientations reported in PageInfo.Orientation.
+const (
+	// PageOrientationPortrait is a page at least as tall as it is wide.
+	PageOrientationPortrait = "portrait"
+	// PageOrientationLandscape is a page wider than it is tall.
+	PageOrientationLandscape = "landscape"
+)
+
+// PageSizeCustom is the PageInfo.Size of pages of no standard paper size.
+const PageSizeCustom = "custom"
+
+// paperSize is a standard paper size in portrait, in points.
+type paperSize struct {
+	name          string
+	width, height float64
+}
+
+// paperSizes are the paper sizes pages are matched to, within paperSizeTolerance points.
+var paperSizes = []paperSize{
+	{"A3", 842, 1191},
+	{"A4", 595, 842},
+	{"A5", 420, 595},
+	{"B4", 709, 1001},
+	{"B5", 499, 709},
+	{"Letter", 612, 792},
+	{"Legal", 612, 1008},
+	{"Tabloid", 792, 1224},
+	{"Executive", 522, 756},
+}
+
+const paperSizeTolerance = 3
+
+// PageSizeGroup lists the pages of a document sharing a paper size and orientation.
+type PageSizeGroup struct {
+	Size        string   `json:"size"`
+	Orientation string   `json:"orientation"`
+	Pages       []uint64 `json:"pages"`
+}
+
+func validateCoordinateUnit(unit string) error {
+	switch unit {
+	case "", CoordinateUnitPoints, CoordinateUnitInches, CoordinateUnitMillimeters, CoordinateUnitNormalized:
+		return nil
+	}
+	return newValidationErrorWithContext(fmt.Sprintf("invalid coordinate unit: %s", unit), nil, ErrorCodeValidation, nil)
+}
+
+// pageSizeName returns the name of the standard paper size of a page of width×height
+// points in either orientation, or PageSizeCustom.
+func pageSizeName(width, height float64) string {
+	short, long := min(width, height), max(width, height)
+	for _, size := range paperSizes {
+		if math.Abs(short-size.width) <= paperSizeTolerance && math.Abs(long-size.height) <= paperSizeTolerance {
+			return size.name
+		}
+	}
+	return PageSizeCustom
+}
+
+// describePageLayout sets the paper size and orientation of every page of result with
+// known dimensions, and groups them in PageStructure.PageSizes when they differ, which
+// layout consumers check before comparing positions across pages.
+func describePageLayout(result *ExtractionResult) {
+	structure := result.Metadata.PageStructure
+	if structure == nil {
+		return
+	}
+	var groups []PageSizeGroup
+	for i := range structure.Pages {
+		info := &structure.Pages[i]
+		if info.Dimensions == nil || info.Dimensions[0] <= 0 || info.Dimensions[1] <= 0 {
+			continue
+		}
+		info.Size = pageSizeName(info.Dimensions[0], info.Dimensions[1])
+		info.Orientation = PageOrientationPortrait
+		if info.Dimensions[0] > info.Dimensions[1] {
+			info.Orientation = PageOrientationLandscape
+		}
+		found := false
+		for j := range groups {
+			if groups[j].Size == info.Size && groups[j].Orientation == info.Orientation {
+				groups[j].Pages = append(groups[j].Pages, info.Number)
+				found = true
+				break
+			}
+		}
+		if !found {
+			groups = append(groups, PageSizeGroup{Size: info.Size, Orientation: info.Orientation, Pages: []uint64{info.Number}})
+		}
+	}
+	structure.PageSizes = nil
+	if len(groups) > 1 {
+		structure.PageSizes = groups
+	}
+}
+
+// applyCoordinateUnit converts the page dimensions and the bounding boxes of tables,
+// elements and vertical regions of result from points to unit, recording unit in
+// PageStructure.CoordinateUnit. Normalized coordinates need the dimensions of the page,
+// which are kept in points; boxes on pages of unknown size are left in points.
+func applyCoordinateUnit(result *ExtractionResult, unit string) {
+	if unit == "" || unit == CoordinateUnitPoints {
+		return
+	}
+	sizes := map[uint64][2]float64{}
+	if structure := result.Metadata.PageStructure; structure != nil {
+		for _, info := range structure.Pages {
+			if info.Dimensions != nil && info.Dimensions[0] > 0 && info.Dimensions[1] > 0 {
+				sizes[info.Number] = *info.Dimensions
+			}
+		}
+	}
+	// Tables and elements can share their boxes with key-value pairs and page tables.
+	converted := map[*BoundingBox]bool{}
+	convert := func(box *BoundingBox, page uint64) {
+		if box == nil || converted[box] {
+			return
+		}
+		scaleX, scaleY, ok := coordinateScale(unit, sizes[page])
+		if !ok {
+			return
+		}
+		converted[box] = true
+		box.X0, box.X1 = box.X0*scaleX, box.X1*scaleX
+		box.Y0, box.Y1 = box.Y0*scaleY, box.Y1*scaleY
+	}
+	for i := range result.Tables {
+		convert(result.Tables[i].BoundingBox, uint64(max(result.Tables[i].PageNumber, 0)))
+	}
+	for _, page := range result.Pages {
+		for _, table := range page.Tables {
+			convert(table.BoundingBox, page.PageNumber)
+		}
+	}
+	for _, element := range result.Elements {
+		page := uint64(0)
+		if element.Metadata.PageNumber != nil && *element.Metadata.PageNumber > 0 {
+			page = uint64(*element.Metadata.PageNumber)
+		}
+		convert(element.Metadata.Coordinates, page)
+	}
+	for _, pair := range result.KeyValues {
+		convert(pair.BoundingBox, pair.PageNumber)
+	}
+
+	structure := result.Metadata.PageStructure
+	if structure == nil {
+		return
+	}
+	for i := range structure.Pages {
+		info := &structure.Pages[i]
+		for j := range info.VerticalRegions {
+			convert(&info.VerticalRegions[j], info.Number)
+		}
+		if info.Dimensions == nil || unit == CoordinateUnitNormalized {
+			continue
+		}
+		scaleX, scaleY, _ := coordinateScale(unit, *info.Dimensions)
+		info.Dimensions = &[2]float64{info.Dimensions[0] * scaleX, info.Dimensions[1] * scaleY}
+	}
+	structure.CoordinateUnit = unit
+}
+
+// coordinateScale returns the factors converting points to unit on a page of the given
+// size, which only CoordinateUnitNormalized needs.
+func coordinateScale(unit string, size [2]float64) (float64, float64, bool) {
+	switch unit {
+	case CoordinateUnitInches:
+		return 1.0 / 72, 1.0 / 72, true
+	case CoordinateUnitMillimeters:
+		return 25.4 / 72, 25.4 / 72, true
+	case CoordinateUnitNormalized:
+		if size[0] <= 0 || size[1] <= 0 {
+			return 0, 0, false
+		}
+		return 1 / size[0], 1 / size[1], true
+	}
+	return 1, 1, true
+}
@@ -0,0 +1,68 @@
+package kreuzberg
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDescribePageLayout(t *testing.T) {
+	result := &ExtractionResult{Metadata: Metadata{PageStructure: &PageStructure{TotalCount: 4, Pages: []PageInfo{
+		{Number: 1, Dimensions: &[2]float64{612, 792}},
+		{Number: 2, Dimensions: &[2]float64{595.28, 841.89}},
+		{Number: 3, Dimensions: &[2]float64{792, 612}},
+		{Number: 4, Dimensions: &[2]float64{612, 792}},
+	}}}}
+	describePageLayout(result)
+	pages := result.Metadata.PageStructure.Pages
+	if pages[0].Size != "Letter" || pages[1].Size != "A4" || pages[2].Size != "Letter" || pages[2].Orientation != PageOrientationLandscape {
+		t.Fatalf("Pages = %+v", pages)
+	}
+	groups := result.Metadata.PageStructure.PageSizes
+	if len(groups) != 3 || len(groups[0].Pages) != 2 || groups[0].Pages[1] != 4 {
+		t.Fatalf("PageSizes = %+v", groups)
+	}
+
+	uniform := &ExtractionResult{Metadata: Metadata{PageStructure: &PageStructure{Pages: []PageInfo{
+		{Number: 1, Dimensions: &[2]float64{500, 500}},
+		{Number: 2, Dimensions: &[2]float64{500, 500}},
+	}}}}
+	describePageLayout(uniform)
+	if uniform.Metadata.PageStructure.PageSizes != nil || uniform.Metadata.PageStructure.Pages[0].Size != PageSizeCustom {
+		t.Fatalf("uniform structure = %+v", uniform.Metadata.PageStructure)
+	}
+}
+
+func TestCoordinateUnit(t *testing.T) {
+	box := &BoundingBox{X0: 61.2, Y0: 79.2, X1: 306, Y1: 396}
+	page := int64(1)
+	newResult := func() *ExtractionResult {
+		copied := *box
+		return &ExtractionResult{
+			Tables:   []Table{{PageNumber: 1, BoundingBox: &copied}},
+			Elements: []Element{{Metadata: ElementMetadata{PageNumber: &page, Coordinates: &copied}}},
+			Metadata: Metadata{PageStructure: &PageStructure{Pages: []PageInfo{{Number: 1, Dimensions: &[2]float64{612, 792}}}}},
+		}
+	}
+
+	result := newResult()
+	if err := runResultStages(result, NewExtractionConfig(WithCoordinateUnit(CoordinateUnitNormalized))); err != nil {
+		t.Fatal(err)
+	}
+	got := *result.Tables[0].BoundingBox
+	if math.Abs(got.X0-0.1) > 1e-9 || math.Abs(got.Y1-0.5) > 1e-9 {
+		t.Fatalf("normalized box = %+v", got)
+	}
+	if result.Metadata.PageStructure.CoordinateUnit != CoordinateUnitNormalized || result.Metadata.PageStructure.Pages[0].Dimensions[0] != 612 {
+		t.Fatalf("PageStructure = %+v", result.Metadata.PageStructure)
+	}
+
+	result = newResult()
+	applyCoordinateUnit(result, CoordinateUnitInches)
+	if dims := result.Metadata.PageStructure.Pages[0].Dimensions; dims[0] != 8.5 || dims[1] != 11 || result.Tables[0].BoundingBox.X1 != 4.25 {
+		t.Fatalf("inches: dimensions %v, box %+v", dims, result.Tables[0].BoundingBox)
+	}
+
+	if err := validateResultStages(NewExtractionConfig(WithCoordinateUnit("px"))); err == nil {
+		t.Fatal("expected an unknown coordinate unit to be rejected")
+	}
+}
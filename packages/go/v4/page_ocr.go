@@ -0,0 +1,189 @@
+package kreuzberg
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The core recognizes the pages of a PDF with forced OCR one after another, so a long scan
+// takes as long as all its pages together. With OCRConfig.PageParallelism the binding
+// renders the pages itself and hands them to the core as one batch, which recognizes them
+// concurrently on its thread pool; the recognized pages are put back in document order.
+
+// pageOCRDPI renders pages for parallel OCR at the core's OCR resolution.
+const pageOCRDPI = 300
+
+func validateOCRConfig(cfg *OCRConfig) error {
+	if cfg.PageParallelism != nil && *cfg.PageParallelism < 0 {
+		return newValidationErrorWithContext(fmt.Sprintf("OCR page parallelism must not be negative, got %d", *cfg.PageParallelism), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// pageParallelism returns how many pages of a PDF are recognized at a time with forced
+// OCR, or 0 when recognition is left to the core.
+func pageParallelism(config *ExtractionConfig) int {
+	if config == nil || config.ForceOCR == nil || !*config.ForceOCR || config.OCR == nil || config.OCR.PageParallelism == nil {
+		return 0
+	}
+	if workers := *config.OCR.PageParallelism; workers > 1 {
+		return workers
+	}
+	return 0
+}
+
+// bindingRecognizesPages reports whether the binding recognizes the pages of PDFs itself
+// with config, in parallel or speculatively.
+func bindingRecognizesPages(config *ExtractionConfig) bool {
+	return pageParallelism(config) > 0 || speculating(config)
+}
+
+// extractPDFPages extracts a PDF whose pages the binding recognizes itself, or returns nil
+// without an error to leave it to the core.
+func extractPDFPages(data []byte, config *ExtractionConfig) (*ExtractionResult, error) {
+	if speculating(config) {
+		return extractSpeculative(data, config)
+	}
+	return extractPDFPagesParallel(data, config)
+}
+
+func isPDFPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pdf")
+}
+
+// pdfPageCount returns the number of pages in the page tree of a PDF, or 0 when it cannot
+// be read, as for encrypted documents.
+func pdfPageCount(data []byte) int {
+	if !isPDF(data) {
+		return 0
+	}
+	objects := readPDFObjects(data)
+	return len(objects.pageRefs(objects.catalog()))
+}
+
+// recognizedPages holds the pages of a PDF recognized by recognizePDFPages, in order.
+type recognizedPages struct {
+	contents []PageContent
+	infos    []PageInfo
+	tables   []Table
+	// native is the time the native recognition of the pages took.
+	native time.Duration
+}
+
+// extractPDFPagesParallel extracts a PDF with forced OCR, recognizing
+// OCRConfig.PageParallelism pages at a time. Document metadata and images come from an
+// extraction of the PDF without OCR. It returns nil without an error when the PDF is not
+// eligible or its pages cannot be counted or rendered, leaving it to the core.
+func extractPDFPagesParallel(data []byte, config *ExtractionConfig) (*ExtractionResult, error) {
+	workers := pageParallelism(config)
+	count := pdfPageCount(data)
+	if workers == 0 || count < 2 {
+		return nil, nil
+	}
+
+	result, err := extractBytesCore(data, "application/pdf", textOnlyConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	pages, err := recognizePDFPages(context.Background(), data, count, workers, config)
+	if pages == nil || err != nil {
+		return nil, err
+	}
+	return pages.apply(result, config)
+}
+
+// textOnlyConfig returns config for an extraction of a PDF's text layer without OCR and
+// without the page and chunking settings applied once its pages are assembled.
+func textOnlyConfig(config *ExtractionConfig) *ExtractionConfig {
+	text := *config
+	text.ForceOCR, text.OCR = nil, nil
+	text.Pages, text.Chunking = nil, nil
+	return &text
+}
+
+// recognizePDFPages renders the count pages of a PDF and recognizes them with the OCR
+// settings of config, workers pages at a time, stopping between windows of pages when ctx
+// is done. It returns nil without an error when the pages cannot be rendered.
+func recognizePDFPages(ctx context.Context, data []byte, count, workers int, config *ExtractionConfig) (*recognizedPages, error) {
+	pageConfig := *config
+	pageConfig.ForceOCR, pageConfig.Pages, pageConfig.Chunking = nil, nil, nil
+	pageConfig.MaxConcurrentExtractions = &workers
+	if pageConfig.OCR == nil {
+		pageConfig.OCR = &OCRConfig{}
+	}
+
+	recognized := &recognizedPages{
+		contents: make([]PageContent, 0, count),
+		infos:    make([]PageInfo, 0, count),
+	}
+	for first := 1; first <= count; first += workers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		last := min(first+workers-1, count)
+		images, err := renderPDFPages(data, renderRequest{FirstPage: first, LastPage: last, DPI: pageOCRDPI, Format: RenderFormatPNG})
+		if err != nil || len(images) != last-first+1 {
+			return nil, nil
+		}
+		items := make([]BytesWithMime, len(images))
+		for i, image := range images {
+			items[i] = BytesWithMime{Data: image.Data, MimeType: "image/png"}
+		}
+		pages, err := batchExtractBytesNative(items, &pageConfig)
+		if err != nil {
+			return nil, err
+		}
+		for i, page := range pages {
+			number := first + i
+			if page.Metadata.Error != nil {
+				return nil, newOCRErrorWithContext(fmt.Sprintf("failed to recognize PDF page %d: %s", number, page.Metadata.Error.Message), nil, ErrorCodeOcr, nil)
+			}
+			recognized.native += page.nativeDuration
+			content, tables := ocrPageContent(number, page)
+			recognized.contents = append(recognized.contents, content)
+			recognized.tables = append(recognized.tables, tables...)
+			recognized.infos = append(recognized.infos, renderedPageInfo(number, images[i], pageOCRDPI))
+		}
+	}
+	return recognized, nil
+}
+
+// text returns the recognized text of all pages.
+func (pages *recognizedPages) text() string {
+	texts := make([]string, len(pages.contents))
+	for i, page := range pages.contents {
+		texts[i] = page.Content
+	}
+	return strings.Join(texts, "\n\n")
+}
+
+// apply replaces the text, pages and tables of result with the recognized pages.
+func (pages *recognizedPages) apply(result *ExtractionResult, config *ExtractionConfig) (*ExtractionResult, error) {
+	result.Tables, result.Pages, result.Chunks = pages.tables, nil, nil
+	result.nativeDuration += pages.native
+	paginate(result, pages.contents, pages.infos, config)
+	if config != nil && config.Chunking != nil {
+		return RechunkResult(result, config.Chunking)
+	}
+	return result, nil
+}
+
+// ocrPageContent returns the PageContent of page number of a PDF from the recognition of
+// its image, and the tables found on it.
+func ocrPageContent(number int, page *ExtractionResult) (PageContent, []Table) {
+	content := PageContent{PageNumber: uint64(number), Content: strings.TrimSpace(page.Content)}
+	for _, table := range page.Tables {
+		table.PageNumber = number
+		content.Tables = append(content.Tables, table)
+	}
+	return content, content.Tables
+}
+
+// renderedPageInfo sizes a page in points from its image rendered at dpi.
+func renderedPageInfo(number int, image PageImage, dpi int) PageInfo {
+	scale := 72 / float64(dpi)
+	return PageInfo{Number: uint64(number), Dimensions: &[2]float64{float64(image.Width) * scale, float64(image.Height) * scale}}
+}
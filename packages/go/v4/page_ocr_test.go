@@ -0,0 +1,81 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPageParallelism(t *testing.T) {
+	forced := NewExtractionConfig(WithForceOCR(true), WithOCR(WithOCRPageParallelism(4)))
+	if got := pageParallelism(forced); got != 4 {
+		t.Errorf("pageParallelism = %d, want 4", got)
+	}
+	for name, config := range map[string]*ExtractionConfig{
+		"nil":        nil,
+		"not forced": NewExtractionConfig(WithOCR(WithOCRPageParallelism(4))),
+		"unset":      NewExtractionConfig(WithForceOCR(true), WithOCR()),
+		"sequential": NewExtractionConfig(WithForceOCR(true), WithOCR(WithOCRPageParallelism(1))),
+	} {
+		if got := pageParallelism(config); got != 0 {
+			t.Errorf("%s: pageParallelism = %d, want 0", name, got)
+		}
+	}
+
+	var validationErr *ValidationError
+	if err := validateResultStages(NewExtractionConfig(WithOCR(WithOCRPageParallelism(-1)))); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+	if result, err := extractPDFPagesParallel([]byte("not a pdf"), forced); result != nil || err != nil {
+		t.Errorf("expected the core to handle unreadable PDFs, got %v, %v", result, err)
+	}
+}
+
+func TestPDFPageCount(t *testing.T) {
+	data := geoTestPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 3 >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+		"<< /Type /Pages /Kids [5 0 R 6 0 R] /Count 2 >>",
+		"<< /Type /Page /Parent 4 0 R >>",
+		"<< /Type /Page /Parent 4 0 R >>",
+	)
+	if got := pdfPageCount(data); got != 3 {
+		t.Errorf("pdfPageCount = %d, want 3", got)
+	}
+	if got := pdfPageCount([]byte("plain text")); got != 0 {
+		t.Errorf("pdfPageCount of text = %d, want 0", got)
+	}
+}
+
+func TestOCRPageAssembly(t *testing.T) {
+	config := NewExtractionConfig(WithPages(WithExtractPages(true)))
+	var contents []PageContent
+	var tables []Table
+	var infos []PageInfo
+	for i, text := range []string{"  First page\n", "Second page with a table"} {
+		page := &ExtractionResult{Content: text}
+		if i == 1 {
+			page.Tables = []Table{{Markdown: "| a |"}}
+		}
+		content, pageTables := ocrPageContent(i+1, page)
+		contents = append(contents, content)
+		tables = append(tables, pageTables...)
+		infos = append(infos, renderedPageInfo(i+1, PageImage{Width: 2550, Height: 3300}, pageOCRDPI))
+	}
+	result := &ExtractionResult{Tables: tables}
+	paginate(result, contents, infos, config)
+
+	if want := "First page\n\nSecond page with a table"; result.Content != want {
+		t.Errorf("Content = %q, want %q", result.Content, want)
+	}
+	if len(result.Tables) != 1 || result.Tables[0].PageNumber != 2 || len(result.Pages[1].Tables) != 1 {
+		t.Errorf("unexpected tables: %+v", result.Tables)
+	}
+	bounds := result.Metadata.PageStructure.Boundaries
+	if got := result.Content[bounds[1].ByteStart:bounds[1].ByteEnd]; got != "Second page with a table" {
+		t.Errorf("page 2 = %q", got)
+	}
+	if dims := result.Metadata.PageStructure.Pages[0].Dimensions; dims == nil || *dims != [2]float64{612, 792} {
+		t.Errorf("unexpected dimensions: %v", dims)
+	}
+}
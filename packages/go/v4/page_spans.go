@@ -0,0 +1,108 @@
+package kreuzberg
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// PageSpans resolves the Content byte range [byteStart, byteEnd) into per-page spans
+// using PageStructure.Boundaries. Each span carries the page number and the byte range
+// relative to the start of that page. An empty range resolves to the page containing
+// byteStart. It returns nil when no page boundaries are available.
+func (r *ExtractionResult) PageSpans(byteStart, byteEnd uint64) []PageSpan {
+	if r == nil || r.Metadata.PageStructure == nil {
+		return nil
+	}
+	return pageSpansForRange(r.Metadata.PageStructure.Boundaries, byteStart, byteEnd)
+}
+
+// PageAt returns the number of the page whose boundary contains the Content byte offset.
+// It returns false when no page boundaries are available or the offset lies outside them.
+func (r *ExtractionResult) PageAt(offset uint64) (uint64, bool) {
+	spans := r.PageSpans(offset, offset)
+	if len(spans) == 0 {
+		return 0, false
+	}
+	return spans[0].PageNumber, true
+}
+
+// LineColAt returns the 1-indexed line and column of a Content byte offset. Lines are
+// separated by "\n" and columns count runes. It returns false when the offset is past
+// the end of Content.
+func (r *ExtractionResult) LineColAt(offset uint64) (line, col int, ok bool) {
+	if r == nil || offset > uint64(len(r.Content)) {
+		return 0, 0, false
+	}
+	before := r.Content[:offset]
+	lineStart := strings.LastIndexByte(before, '\n') + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[lineStart:]) + 1, true
+}
+
+// Pages returns the numbers of the pages the chunk covers, in order. It uses
+// Metadata.PageSpans when present and falls back to the FirstPage-LastPage range.
+func (c Chunk) Pages() []uint64 {
+	if len(c.Metadata.PageSpans) > 0 {
+		pages := make([]uint64, 0, len(c.Metadata.PageSpans))
+		for _, span := range c.Metadata.PageSpans {
+			if len(pages) == 0 || pages[len(pages)-1] != span.PageNumber {
+				pages = append(pages, span.PageNumber)
+			}
+		}
+		return pages
+	}
+	if c.Metadata.FirstPage == nil {
+		return nil
+	}
+	last := *c.Metadata.FirstPage
+	if c.Metadata.LastPage != nil && *c.Metadata.LastPage > last {
+		last = *c.Metadata.LastPage
+	}
+	var pages []uint64
+	for page := *c.Metadata.FirstPage; page <= last; page++ {
+		pages = append(pages, page)
+	}
+	return pages
+}
+
+func pageSpansForRange(boundaries []PageBoundary, start, end uint64) []PageSpan {
+	if len(boundaries) == 0 || end < start {
+		return nil
+	}
+	idx := sort.Search(len(boundaries), func(i int) bool {
+		return boundaries[i].ByteEnd > start
+	})
+
+	if start == end {
+		if idx == len(boundaries) || boundaries[idx].ByteStart > start {
+			return nil
+		}
+		offset := start - boundaries[idx].ByteStart
+		return []PageSpan{{PageNumber: boundaries[idx].PageNumber, ByteStart: offset, ByteEnd: offset}}
+	}
+
+	var spans []PageSpan
+	for ; idx < len(boundaries) && boundaries[idx].ByteStart < end; idx++ {
+		boundary := boundaries[idx]
+		spanStart := max(start, boundary.ByteStart)
+		spanEnd := min(end, boundary.ByteEnd)
+		spans = append(spans, PageSpan{
+			PageNumber: boundary.PageNumber,
+			ByteStart:  spanStart - boundary.ByteStart,
+			ByteEnd:    spanEnd - boundary.ByteStart,
+		})
+	}
+	return spans
+}
+
+// assignChunkPageSpans fills ChunkMetadata.PageSpans for every chunk of result.
+func assignChunkPageSpans(result *ExtractionResult) {
+	if len(result.Chunks) == 0 || result.Metadata.PageStructure == nil || len(result.Metadata.PageStructure.Boundaries) == 0 {
+		return
+	}
+	boundaries := result.Metadata.PageStructure.Boundaries
+	for i := range result.Chunks {
+		meta := &result.Chunks[i].Metadata
+		meta.PageSpans = pageSpansForRange(boundaries, meta.ByteStart, meta.ByteEnd)
+	}
+}
@@ -0,0 +1,132 @@
+package kreuzberg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func pageSpanTestResult() *ExtractionResult {
+	return &ExtractionResult{
+		Content: "page one text|page two text|page three",
+		Metadata: Metadata{PageStructure: &PageStructure{
+			TotalCount: 3,
+			UnitType:   PageUnitTypePage,
+			Boundaries: []PageBoundary{
+				{ByteStart: 0, ByteEnd: 14, PageNumber: 1},
+				{ByteStart: 14, ByteEnd: 28, PageNumber: 2},
+				{ByteStart: 28, ByteEnd: 38, PageNumber: 3},
+			},
+		}},
+	}
+}
+
+func TestPageSpansWithinSinglePage(t *testing.T) {
+	result := pageSpanTestResult()
+	got := result.PageSpans(19, 22)
+	want := []PageSpan{{PageNumber: 2, ByteStart: 5, ByteEnd: 8}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPageSpansAcrossPages(t *testing.T) {
+	result := pageSpanTestResult()
+	got := result.PageSpans(10, 33)
+	want := []PageSpan{
+		{PageNumber: 1, ByteStart: 10, ByteEnd: 14},
+		{PageNumber: 2, ByteStart: 0, ByteEnd: 14},
+		{PageNumber: 3, ByteStart: 0, ByteEnd: 5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPageSpansEmptyRange(t *testing.T) {
+	result := pageSpanTestResult()
+	got := result.PageSpans(14, 14)
+	want := []PageSpan{{PageNumber: 2, ByteStart: 0, ByteEnd: 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestPageSpansWithoutPageStructure(t *testing.T) {
+	result := &ExtractionResult{Content: "no pages"}
+	if spans := result.PageSpans(0, 3); spans != nil {
+		t.Fatalf("expected nil spans, got %+v", spans)
+	}
+}
+
+func TestAssignChunkPageSpans(t *testing.T) {
+	result := pageSpanTestResult()
+	result.Chunks = []Chunk{
+		{Content: "page one text|page", Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: 18}},
+		{Content: "page three", Metadata: ChunkMetadata{ByteStart: 28, ByteEnd: 38}},
+	}
+	if err := runResultStages(result, &ExtractionConfig{}); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+
+	first := result.Chunks[0].Metadata.PageSpans
+	if len(first) != 2 || first[0].PageNumber != 1 || first[1].PageNumber != 2 || first[1].ByteEnd != 4 {
+		t.Errorf("unexpected spans for first chunk: %+v", first)
+	}
+	second := result.Chunks[1].Metadata.PageSpans
+	if len(second) != 1 || second[0] != (PageSpan{PageNumber: 3, ByteStart: 0, ByteEnd: 10}) {
+		t.Errorf("unexpected spans for second chunk: %+v", second)
+	}
+}
+
+func TestPageAt(t *testing.T) {
+	result := pageSpanTestResult()
+	for offset, want := range map[uint64]uint64{0: 1, 13: 1, 14: 2, 37: 3} {
+		if page, ok := result.PageAt(offset); !ok || page != want {
+			t.Errorf("PageAt(%d) = %d, %v; want %d", offset, page, ok, want)
+		}
+	}
+	if _, ok := result.PageAt(38); ok {
+		t.Errorf("offset past the last boundary should not resolve")
+	}
+	if _, ok := (&ExtractionResult{Content: "no pages"}).PageAt(0); ok {
+		t.Errorf("result without page structure should not resolve")
+	}
+}
+
+func TestLineColAt(t *testing.T) {
+	result := &ExtractionResult{Content: "first\nsécond line\n"}
+	cases := []struct {
+		offset    uint64
+		line, col int
+	}{
+		{0, 1, 1},
+		{5, 1, 6},
+		{6, 2, 1},
+		{9, 2, 3}, // after "sé", where é is two bytes
+		{uint64(len(result.Content)), 3, 1},
+	}
+	for _, tc := range cases {
+		line, col, ok := result.LineColAt(tc.offset)
+		if !ok || line != tc.line || col != tc.col {
+			t.Errorf("LineColAt(%d) = %d:%d, %v; want %d:%d", tc.offset, line, col, ok, tc.line, tc.col)
+		}
+	}
+	if _, _, ok := result.LineColAt(100); ok {
+		t.Errorf("offset past the end should not resolve")
+	}
+}
+
+func TestChunkPages(t *testing.T) {
+	spans := Chunk{Metadata: ChunkMetadata{PageSpans: []PageSpan{{PageNumber: 2}, {PageNumber: 3}}}}
+	if got := spans.Pages(); !reflect.DeepEqual(got, []uint64{2, 3}) {
+		t.Errorf("pages from spans = %v", got)
+	}
+	first, last := uint64(4), uint64(6)
+	ranged := Chunk{Metadata: ChunkMetadata{FirstPage: &first, LastPage: &last}}
+	if got := ranged.Pages(); !reflect.DeepEqual(got, []uint64{4, 5, 6}) {
+		t.Errorf("pages from range = %v", got)
+	}
+	if got := (Chunk{}).Pages(); got != nil {
+		t.Errorf("chunk without pages = %v", got)
+	}
+}
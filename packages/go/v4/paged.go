@@ -0,0 +1,47 @@
+package kreuzberg
+
+import (
+	"strconv"
+	"strings"
+)
+
+const defaultPageMarkerFormat = "\n\n<!-- PAGE {page_num} -->\n\n"
+
+// paginate sets the Content of a result the binding assembles page by page, the way the
+// core does for PDFs: pages are separated by a blank line, or each is preceded by the
+// configured page marker, and each gets a PageStructure boundary and PageInfo. Pages
+// entries are kept only when page extraction is enabled.
+func paginate(result *ExtractionResult, pages []PageContent, infos []PageInfo, config *ExtractionConfig) {
+	var pageConfig PageConfig
+	if config != nil && config.Pages != nil {
+		pageConfig = *config.Pages
+	}
+	markers := pageConfig.InsertPageMarkers != nil && *pageConfig.InsertPageMarkers
+	marker := defaultPageMarkerFormat
+	if pageConfig.MarkerFormat != nil {
+		marker = *pageConfig.MarkerFormat
+	}
+
+	var content strings.Builder
+	structure := &PageStructure{TotalCount: uint64(len(pages)), UnitType: PageUnitTypePage, Pages: infos}
+	for i, page := range pages {
+		switch {
+		case markers:
+			content.WriteString(strings.ReplaceAll(marker, "{page_num}", strconv.FormatUint(page.PageNumber, 10)))
+		case i > 0:
+			content.WriteString("\n\n")
+		}
+		start := uint64(content.Len())
+		content.WriteString(page.Content)
+		structure.Boundaries = append(structure.Boundaries, PageBoundary{
+			ByteStart:  start,
+			ByteEnd:    uint64(content.Len()),
+			PageNumber: page.PageNumber,
+		})
+	}
+	result.Content = content.String()
+	result.Metadata.PageStructure = structure
+	if pageConfig.ExtractPages != nil && *pageConfig.ExtractPages {
+		result.Pages = pages
+	}
+}
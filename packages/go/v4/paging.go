@@ -0,0 +1,321 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// defaultMinPagedEntries is the number of pages and chunks from which results are paged
+// when PagingConfig.MinEntries is unset.
+const defaultMinPagedEntries = 1000
+
+// PagingConfig moves the pages and chunks of large results out of memory once the
+// binding-side stages have run. They are written to a file in a private directory under
+// the RetentionConfig directory, or os.TempDir(), and read back a range at a time with
+// GetPages and GetChunks, or one at a time with ExtractionResult.AllPages and AllChunks,
+// so a document of many thousand pages can be consumed without holding it all. Pages and
+// Chunks of a paged result are nil; the functions and methods of the package that read
+// them, such as ToArrow, RechunkResult, ChunkRange or marshaling the result to JSON, read
+// them back.
+//
+// The file is wiped and removed by ExtractionResult.Close, or when the result is garbage
+// collected.
+type PagingConfig struct {
+	// MinEntries pages results holding at least this many pages and chunks together;
+	// smaller results keep them in memory. Default: 1000.
+	MinEntries *int `json:"min_entries,omitempty"`
+}
+
+func validatePagingConfig(cfg *PagingConfig) error {
+	if cfg.MinEntries != nil && *cfg.MinEntries < 0 {
+		return newValidationErrorWithContext(fmt.Sprintf("minimum paged entries must not be negative, got %d", *cfg.MinEntries), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// pagedEntry locates one JSON-encoded page or chunk in the paging file.
+type pagedEntry struct {
+	offset, length int64
+	// pageNumber is the PageNumber of a page.
+	pageNumber uint64
+	// view is the range of Content the text was a view of before it was paged, when
+	// viewed is set.
+	view   [2]int
+	viewed bool
+}
+
+// pagedStorage holds the pages and chunks of a paged result.
+type pagedStorage struct {
+	pages  []pagedEntry
+	chunks []pagedEntry
+
+	mu      sync.Mutex
+	file    *pagedFile
+	cleanup runtime.Cleanup
+}
+
+// pagedFile is the paging file and its private directory, removed by the cleanup
+// registered on the pagedStorage unless it is closed first.
+type pagedFile struct {
+	file *os.File
+	dir  string
+}
+
+func (f *pagedFile) remove() error {
+	f.file.Close()
+	return wipeTempDir(f.dir)
+}
+
+// discard removes the paging file of a pagedStorage that was not closed.
+func (f *pagedFile) discard() {
+	_ = f.remove()
+}
+
+// pageOut moves the pages and chunks of result to a paging file when there are at least
+// as many as cfg requires.
+func pageOut(result *ExtractionResult, cfg *PagingConfig, retention *RetentionConfig) error {
+	threshold := defaultMinPagedEntries
+	if cfg.MinEntries != nil {
+		threshold = *cfg.MinEntries
+	}
+	if len(result.Pages)+len(result.Chunks) < max(threshold, 1) || result.paged != nil {
+		return nil
+	}
+	dir, err := createSpoolDir(retention, pagingPrefix)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(filepath.Join(dir, "entries.json"))
+	if err != nil {
+		_ = wipeTempDir(dir)
+		return newIOErrorWithContext("failed to create paging file", err, ErrorCodeIo, nil)
+	}
+	spool := &pagedFile{file: file, dir: dir}
+
+	storage := &pagedStorage{file: spool}
+	var offset int64
+	write := func(value any) (pagedEntry, error) {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return pagedEntry{}, err
+		}
+		if _, err := file.Write(data); err != nil {
+			return pagedEntry{}, err
+		}
+		entry := pagedEntry{offset: offset, length: int64(len(data))}
+		offset += entry.length
+		return entry, nil
+	}
+	for i := range result.Pages {
+		entry, err := write(&result.Pages[i])
+		if err != nil {
+			spool.discard()
+			return newIOErrorWithContext("failed to write paging file", err, ErrorCodeIo, nil)
+		}
+		entry.pageNumber = result.Pages[i].PageNumber
+		entry.view[0], entry.view[1], entry.viewed = viewRange(result.Content, result.Pages[i].Content)
+		storage.pages = append(storage.pages, entry)
+	}
+	for i := range result.Chunks {
+		entry, err := write(&result.Chunks[i])
+		if err != nil {
+			spool.discard()
+			return newIOErrorWithContext("failed to write paging file", err, ErrorCodeIo, nil)
+		}
+		entry.view[0], entry.view[1], entry.viewed = viewRange(result.Content, result.Chunks[i].Content)
+		storage.chunks = append(storage.chunks, entry)
+	}
+
+	storage.cleanup = runtime.AddCleanup(storage, (*pagedFile).discard, spool)
+	result.Pages, result.Chunks = nil, nil
+	result.paged = storage
+	return nil
+}
+
+// close removes the paging file. Reading afterwards fails.
+func (s *pagedStorage) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	s.cleanup.Stop()
+	err := s.file.remove()
+	s.file = nil
+	return err
+}
+
+// Close wipes and removes the paging file of a result paged with PagingConfig, after which
+// reading its pages and chunks fails. It does nothing for results held in memory and may
+// be called more than once.
+func (r *ExtractionResult) Close() error {
+	if r == nil || r.paged == nil {
+		return nil
+	}
+	return r.paged.close()
+}
+
+// readPaged decodes the entries of s, in order.
+func readPaged[T any](s *pagedStorage, entries []pagedEntry) ([]T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil, newValidationErrorWithContext("paged result has been released", nil, ErrorCodeValidation, nil)
+	}
+	values := make([]T, len(entries))
+	for i, entry := range entries {
+		data := make([]byte, entry.length)
+		if _, err := s.file.file.ReadAt(data, entry.offset); err != nil {
+			return nil, newIOErrorWithContext("failed to read paging file", err, ErrorCodeIo, nil)
+		}
+		if err := json.Unmarshal(data, &values[i]); err != nil {
+			return nil, newSerializationErrorWithContext("failed to decode paged entry", err, ErrorCodeValidation, nil)
+		}
+	}
+	return values, nil
+}
+
+// pagedContents returns the pages and chunks of result, read back from the paging file
+// when it is paged.
+func pagedContents(result *ExtractionResult) ([]PageContent, []Chunk, error) {
+	pages, err := pagedPages(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	chunks, err := pagedChunks(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pages, chunks, nil
+}
+
+// pagedPages returns the pages of result, read back from the paging file when it is paged.
+func pagedPages(result *ExtractionResult) ([]PageContent, error) {
+	if result.paged == nil {
+		return result.Pages, nil
+	}
+	return readPaged[PageContent](result.paged, result.paged.pages)
+}
+
+// pagedChunks returns the chunks of result, read back from the paging file when it is
+// paged.
+func pagedChunks(result *ExtractionResult) ([]Chunk, error) {
+	if result.paged == nil {
+		return result.Chunks, nil
+	}
+	return readPaged[Chunk](result.paged, result.paged.chunks)
+}
+
+// allPaged iterates over values, or over entries read back from s one at a time when s is
+// not nil.
+func allPaged[T any](s *pagedStorage, entries []pagedEntry, values []T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		if s == nil {
+			for _, value := range values {
+				if !yield(value, nil) {
+					return
+				}
+			}
+			return
+		}
+		for i := range entries {
+			read, err := readPaged[T](s, entries[i:i+1])
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+			if !yield(read[0], nil) {
+				return
+			}
+		}
+	}
+}
+
+// AllPages returns an iterator over the pages of r, in order. The pages of a result paged
+// with PagingConfig are read back one at a time; an error reading one ends the iteration.
+func (r *ExtractionResult) AllPages() iter.Seq2[PageContent, error] {
+	if r.paged == nil {
+		return allPaged(nil, nil, r.Pages)
+	}
+	return allPaged[PageContent](r.paged, r.paged.pages, nil)
+}
+
+// AllChunks returns an iterator over the chunks of r, in order. The chunks of a result
+// paged with PagingConfig are read back one at a time; an error reading one ends the
+// iteration.
+func (r *ExtractionResult) AllChunks() iter.Seq2[Chunk, error] {
+	if r.paged == nil {
+		return allPaged(nil, nil, r.Chunks)
+	}
+	return allPaged[Chunk](r.paged, r.paged.chunks, nil)
+}
+
+// GetPages returns the pages of result numbered from through to, inclusive, in order.
+// Pages are kept in results when PageConfig.ExtractPages is set. For a result paged with
+// PagingConfig only those pages are read back.
+func GetPages(result *ExtractionResult, from, to int) ([]PageContent, error) {
+	if result == nil {
+		return nil, newValidationErrorWithContext("result is nil", nil, ErrorCodeValidation, nil)
+	}
+	if from < 0 || to < from {
+		return nil, newValidationErrorWithContext(fmt.Sprintf("invalid page range %d-%d", from, to), nil, ErrorCodeValidation, nil)
+	}
+	inRange := func(number uint64) bool { return number >= uint64(from) && number <= uint64(to) }
+	if result.paged == nil {
+		var pages []PageContent
+		for _, page := range result.Pages {
+			if inRange(page.PageNumber) {
+				pages = append(pages, page)
+			}
+		}
+		return pages, nil
+	}
+	var entries []pagedEntry
+	for _, entry := range result.paged.pages {
+		if inRange(entry.pageNumber) {
+			entries = append(entries, entry)
+		}
+	}
+	return readPaged[PageContent](result.paged, entries)
+}
+
+// GetChunks returns at most limit chunks of result starting with chunk offset. For a
+// result paged with PagingConfig only those chunks are read back.
+func GetChunks(result *ExtractionResult, offset, limit int) ([]Chunk, error) {
+	if result == nil {
+		return nil, newValidationErrorWithContext("result is nil", nil, ErrorCodeValidation, nil)
+	}
+	if offset < 0 || limit < 0 {
+		return nil, newValidationErrorWithContext(fmt.Sprintf("invalid chunk window offset %d, limit %d", offset, limit), nil, ErrorCodeValidation, nil)
+	}
+	window := func(n int) (int, int) {
+		start := min(offset, n)
+		return start, start + min(limit, n-start)
+	}
+	if result.paged == nil {
+		start, end := window(len(result.Chunks))
+		return append([]Chunk(nil), result.Chunks[start:end]...), nil
+	}
+	start, end := window(len(result.paged.chunks))
+	return readPaged[Chunk](result.paged, result.paged.chunks[start:end])
+}
+
+// MarshalJSON encodes the result, reading the pages and chunks of a paged result back.
+func (r *ExtractionResult) MarshalJSON() ([]byte, error) {
+	type plain ExtractionResult
+	if r.paged == nil {
+		return json.Marshal((*plain)(r))
+	}
+	whole := *r
+	var err error
+	if whole.Pages, whole.Chunks, err = pagedContents(r); err != nil {
+		return nil, err
+	}
+	return json.Marshal((*plain)(&whole))
+}
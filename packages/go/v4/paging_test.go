@@ -0,0 +1,186 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func pagedTestResult(pages, chunks int) *ExtractionResult {
+	result := &ExtractionResult{Content: "document"}
+	for i := 1; i <= pages; i++ {
+		result.Pages = append(result.Pages, PageContent{PageNumber: uint64(i), Content: fmt.Sprintf("page %d", i)})
+	}
+	for i := range chunks {
+		result.Chunks = append(result.Chunks, Chunk{Content: fmt.Sprintf("chunk %d", i), Metadata: ChunkMetadata{ChunkIndex: i}})
+	}
+	return result
+}
+
+func TestPagingStage(t *testing.T) {
+	dir := t.TempDir()
+	config := NewExtractionConfig(WithPaging(10), WithRetention(dir, false))
+
+	small := pagedTestResult(4, 5)
+	if err := runResultStages(small, config); err != nil {
+		t.Fatal(err)
+	}
+	if small.paged != nil || len(small.Pages) != 4 {
+		t.Fatal("a result below MinEntries should stay in memory")
+	}
+
+	result := pagedTestResult(20, 30)
+	if err := runResultStages(result, config); err != nil {
+		t.Fatal(err)
+	}
+	if result.Pages != nil || result.Chunks != nil || result.paged == nil {
+		t.Fatal("expected pages and chunks to be paged out")
+	}
+	if residue, _ := FindTempResidue(dir); len(residue) != 1 {
+		t.Fatalf("expected one paging directory, got %v", residue)
+	}
+
+	pages, err := GetPages(result, 5, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 || pages[0].PageNumber != 5 || pages[2].Content != "page 7" {
+		t.Fatalf("GetPages(5, 7) = %+v", pages)
+	}
+	chunks, err := GetChunks(result, 28, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 2 || chunks[0].Content != "chunk 28" || chunks[1].Metadata.ChunkIndex != 29 {
+		t.Fatalf("GetChunks(28, 10) = %+v", chunks)
+	}
+	if count, _ := result.GetChunkCount(); count != 30 {
+		t.Fatalf("GetChunkCount = %d", count)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var whole ExtractionResult
+	if err := json.Unmarshal(data, &whole); err != nil {
+		t.Fatal(err)
+	}
+	if len(whole.Pages) != 20 || len(whole.Chunks) != 30 {
+		t.Fatalf("JSON holds %d pages and %d chunks", len(whole.Pages), len(whole.Chunks))
+	}
+
+	if err := result.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if residue, _ := FindTempResidue(dir); len(residue) != 0 {
+		t.Fatalf("paging file left after Close: %v", residue)
+	}
+	if _, err := GetPages(result, 1, 1); err == nil {
+		t.Fatal("expected an error reading pages after Close")
+	}
+	if err := result.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+}
+
+func TestPagedResultReaders(t *testing.T) {
+	config := NewExtractionConfig(WithPaging(1), WithSharedContent(true), WithRetention(t.TempDir(), false))
+	result := pagedTestResult(3, 4)
+	result.Content = "page 1 page 2 page 3"
+	for i := range result.Chunks {
+		result.Chunks[i].Content = fmt.Sprintf("page %d", i%3+1)
+	}
+	if err := runResultStages(result, config); err != nil || result.paged == nil {
+		t.Fatalf("expected the result to be paged, err %v", err)
+	}
+
+	var numbers []uint64
+	for page, err := range result.AllPages() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		numbers = append(numbers, page.PageNumber)
+	}
+	if !slices.Equal(numbers, []uint64{1, 2, 3}) {
+		t.Fatalf("AllPages = %v", numbers)
+	}
+	read := 0
+	for _, err := range result.AllChunks() {
+		if err != nil {
+			t.Fatal(err)
+		}
+		if read++; read == 2 {
+			break
+		}
+	}
+	if start, end, ok := result.PageRange(1); !ok || result.Content[start:end] != "page 2" {
+		t.Fatalf("PageRange(1) = %d, %d, %v", start, end, ok)
+	}
+	if start, end, ok := result.ChunkRange(3); !ok || result.Content[start:end] != "page 1" {
+		t.Fatalf("ChunkRange(3) = %d, %d, %v", start, end, ok)
+	}
+	if batches, err := result.ToArrow(); err != nil || batches.Chunks == nil {
+		t.Fatalf("ToArrow dropped the paged chunks: %v", err)
+	}
+	if !strings.Contains(result.String(), "Chunks: 4") {
+		t.Fatalf("String() = %s", result.String())
+	}
+
+	result.Close()
+	for _, err := range result.AllPages() {
+		if err == nil {
+			t.Fatal("expected an error reading a closed paging file")
+		}
+	}
+}
+
+func TestGetPagesInMemory(t *testing.T) {
+	result := pagedTestResult(3, 2)
+	pages, err := GetPages(result, 2, 9)
+	if err != nil || len(pages) != 2 || pages[0].PageNumber != 2 {
+		t.Fatalf("GetPages(2, 9) = %+v, %v", pages, err)
+	}
+	if chunks, err := GetChunks(result, 5, 1); err != nil || len(chunks) != 0 {
+		t.Fatalf("GetChunks past the end = %+v, %v", chunks, err)
+	}
+	if _, err := GetPages(result, 3, 2); err == nil {
+		t.Fatal("expected an error for a reversed page range")
+	}
+}
+
+func TestPagedResultsMerge(t *testing.T) {
+	config := NewExtractionConfig(WithPaging(1), WithRetention(t.TempDir(), false))
+	previous := incrementalTestResult("one\ntwo", 0, 3, 4, 7)
+	previous.Pages = []PageContent{{PageNumber: 1, Content: "one"}, {PageNumber: 2, Content: "two"}}
+	if err := runResultStages(previous, config); err != nil || previous.paged == nil {
+		t.Fatalf("expected previous to be paged, err %v", err)
+	}
+
+	partial := incrementalTestResult("TWO", 0, 3)
+	partial.Pages = []PageContent{{PageNumber: 1, Content: "TWO"}}
+	merged, err := mergePages(previous, partial, []int{2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous.Close()
+	if merged.paged != nil || len(merged.Pages) != 2 || merged.Pages[0].Content != "one" || merged.Pages[1].Content != "TWO" {
+		t.Fatalf("merged pages = %+v", merged.Pages)
+	}
+
+	first, second := pagedTestResult(3, 2), pagedTestResult(1, 1)
+	first.Success, second.Success = true, true
+	if err := runResultStages(first, config); err != nil {
+		t.Fatal(err)
+	}
+	joined := MergeResults([]*ExtractionResult{first, second}, MergeOptions{})
+	if len(joined.Pages) != 4 || joined.Pages[3].PageNumber != 4 || len(joined.Chunks) != 3 || !joined.Success {
+		t.Fatalf("MergeResults = %d pages, %d chunks", len(joined.Pages), len(joined.Chunks))
+	}
+	first.Close()
+	if MergeResults([]*ExtractionResult{first}, MergeOptions{}).Success {
+		t.Fatal("expected a closed paged result to make the merge unsuccessful")
+	}
+}
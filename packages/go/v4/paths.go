@@ -0,0 +1,51 @@
+package kreuzberg
+
+import (
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// windowsMaxPath is the length from which Windows needs the extended-length prefix. It
+// is MAX_PATH less the room the API reserves for an 8.3 file name in directory paths.
+const windowsMaxPath = 248
+
+// NormalizePath prepares path for the native library. On Windows it makes the path
+// absolute and, when it is too long for MAX_PATH, rewrites it with the extended-length
+// prefix: C:\dir\... becomes \\?\C:\dir\... and the UNC share \\server\share\...
+// becomes \\?\UNC\server\share\.... Paths that already carry a \\?\ or \\.\ prefix, and
+// all paths on other platforms, are returned unchanged.
+func NormalizePath(path string) string {
+	if runtime.GOOS != "windows" || path == "" || isVerbatimPath(path) {
+		return path
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if len(path) < windowsMaxPath {
+		return path
+	}
+	return windowsLongPath(path)
+}
+
+// windowsLongPath adds the extended-length prefix to a clean absolute Windows path.
+// Extended-length paths are passed to the file system verbatim, so separators must be
+// backslashes and the path must not contain . or .. elements.
+func windowsLongPath(path string) string {
+	path = strings.ReplaceAll(path, "/", `\`)
+	switch {
+	case isVerbatimPath(path):
+		return path
+	case strings.HasPrefix(path, `\\`):
+		return `\\?\UNC\` + path[2:]
+	case len(path) >= 3 && path[1] == ':' && path[2] == '\\':
+		return `\\?\` + path
+	}
+	return path
+}
+
+// isVerbatimPath reports whether path starts with a \\?\ or \\.\ prefix.
+func isVerbatimPath(path string) bool {
+	return strings.HasPrefix(path, `\\?\`) || strings.HasPrefix(path, `\\.\`) ||
+		strings.HasPrefix(path, `//?/`) || strings.HasPrefix(path, `//./`)
+}
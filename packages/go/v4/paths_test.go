@@ -0,0 +1,41 @@
+package kreuzberg
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestWindowsLongPath(t *testing.T) {
+	cases := map[string]string{
+		`C:\data\report.pdf`:                 `\\?\C:\data\report.pdf`,
+		`C:/data/report.pdf`:                 `\\?\C:\data\report.pdf`,
+		`\\fileserver\legal\2024\report.pdf`: `\\?\UNC\fileserver\legal\2024\report.pdf`,
+		`\\?\C:\data\report.pdf`:             `\\?\C:\data\report.pdf`,
+		`\\?\UNC\fileserver\legal\a.pdf`:     `\\?\UNC\fileserver\legal\a.pdf`,
+		`\\.\PhysicalDrive0`:                 `\\.\PhysicalDrive0`,
+		`relative\report.pdf`:                `relative\report.pdf`,
+	}
+	for path, want := range cases {
+		if got := windowsLongPath(path); got != want {
+			t.Errorf("windowsLongPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestNormalizePath(t *testing.T) {
+	long := `C:\` + strings.Repeat(`very long directory name\`, 12) + "report.pdf"
+	got := NormalizePath(long)
+	if runtime.GOOS != "windows" {
+		if got != long {
+			t.Fatalf("expected paths to be unchanged off Windows, got %q", got)
+		}
+		return
+	}
+	if !strings.HasPrefix(got, `\\?\C:\`) {
+		t.Fatalf("expected an extended-length path, got %q", got)
+	}
+	if short := `C:\data\report.pdf`; NormalizePath(short) != short {
+		t.Fatalf("expected short paths to be unchanged, got %q", NormalizePath(short))
+	}
+}
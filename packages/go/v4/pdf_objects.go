@@ -0,0 +1,613 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+)
+
+// The binding reads a few PDF structures the core does not report (geospatial
+// registration, for one). pdfObjects is a minimal, lenient reader for them: it finds
+// "N G obj" definitions by scanning rather than through the cross-reference table, so
+// it also copes with damaged files, and it unpacks Flate-compressed object streams.
+
+// pdfName is a PDF name, without its leading slash.
+type pdfName string
+
+// pdfRef is an indirect reference, "N G R".
+type pdfRef struct {
+	num, gen int
+}
+
+// pdfDict is a PDF dictionary keyed by name.
+type pdfDict map[string]any
+
+// pdfStream is a stream object: its dictionary and its raw, still encoded data.
+type pdfStream struct {
+	dict pdfDict
+	raw  []byte
+}
+
+const (
+	// maxPDFDepth bounds the nesting of arrays and dictionaries.
+	maxPDFDepth = 64
+	// maxPDFStreamSize bounds the size of a decoded stream.
+	maxPDFStreamSize = 64 << 20
+	// maxPDFObjectStreamsSize bounds the decoded size of all the object streams of a file
+	// together, so that a file of many small compressed streams cannot inflate without
+	// limit.
+	maxPDFObjectStreamsSize = 16 << 20
+)
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// pdfObjects maps object numbers to their values. Later definitions, such as those of
+// incremental updates, replace earlier ones.
+type pdfObjects map[int]any
+
+// isPDF reports whether data starts with a PDF header.
+func isPDF(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n\x00"), []byte("%PDF-"))
+}
+
+// readPDFObjects reads the objects of a PDF file, including those packed in object
+// streams up to maxPDFObjectStreamsSize. Objects that fail to parse are skipped.
+func readPDFObjects(data []byte) pdfObjects {
+	objects := pdfObjects{}
+	var objectStreams []*pdfStream
+	for _, match := range pdfObjectHeader.FindAllSubmatchIndex(data, -1) {
+		num, err := strconv.Atoi(string(data[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+		p := &pdfParser{data: data, pos: match[1]}
+		value, err := p.value()
+		if err != nil {
+			continue
+		}
+		if dict, ok := value.(pdfDict); ok {
+			if stream, ok := p.stream(dict); ok {
+				value = stream
+				if dict["Type"] == pdfName("ObjStm") {
+					objectStreams = append(objectStreams, stream)
+				}
+			}
+		}
+		objects[num] = value
+	}
+	budget := maxPDFObjectStreamsSize
+	for _, stream := range objectStreams {
+		data, err := stream.decodeLimit(budget)
+		if err != nil {
+			if errors.Is(err, errPDFStreamTooLarge) {
+				break
+			}
+			continue
+		}
+		budget -= len(data)
+		objects.unpack(stream, data)
+	}
+	return objects
+}
+
+// unpack adds the objects of an object stream, whose decoded data is data. Objects
+// defined directly in the file take precedence, as an object stream cannot hold an
+// updated object.
+func (objects pdfObjects) unpack(stream *pdfStream, data []byte) {
+	count, _ := pdfInt(stream.dict["N"])
+	first, _ := pdfInt(stream.dict["First"])
+	if first <= 0 || first > len(data) {
+		return
+	}
+	header := &pdfParser{data: data[:first]}
+	for range count {
+		num, err1 := header.value()
+		offset, err2 := header.value()
+		n, ok1 := pdfInt(num)
+		off, ok2 := pdfInt(offset)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 || first+off >= len(data) {
+			return
+		}
+		if _, exists := objects[n]; exists {
+			continue
+		}
+		p := &pdfParser{data: data, pos: first + off}
+		if value, err := p.value(); err == nil {
+			objects[n] = value
+		}
+	}
+}
+
+// resolve follows indirect references.
+func (objects pdfObjects) resolve(value any) any {
+	for range maxPDFDepth {
+		ref, ok := value.(pdfRef)
+		if !ok {
+			break
+		}
+		value = objects[ref.num]
+	}
+	return value
+}
+
+// dict resolves value and returns it as a dictionary; a stream yields its dictionary.
+func (objects pdfObjects) dict(value any) pdfDict {
+	switch v := objects.resolve(value).(type) {
+	case pdfDict:
+		return v
+	case *pdfStream:
+		return v.dict
+	}
+	return nil
+}
+
+// catalog returns the document catalog, that with the highest object number when
+// several objects claim to be one.
+func (objects pdfObjects) catalog() pdfDict {
+	var catalog pdfDict
+	for _, num := range slices.Sorted(maps.Keys(objects)) {
+		if dict := objects.dict(objects[num]); dict["Type"] == pdfName("Catalog") {
+			catalog = dict
+		}
+	}
+	return catalog
+}
+
+// pageRefs returns the pages of the page tree of catalog in order.
+func (objects pdfObjects) pageRefs(catalog pdfDict) []pdfRef {
+	var pages []pdfRef
+	seen := map[pdfRef]bool{}
+	var walk func(node any, depth int)
+	walk = func(node any, depth int) {
+		ref, ok := node.(pdfRef)
+		if !ok || seen[ref] || depth > maxPDFDepth {
+			return
+		}
+		seen[ref] = true
+		dict := objects.dict(ref)
+		if kids, ok := objects.resolve(dict["Kids"]).([]any); ok {
+			for _, kid := range kids {
+				walk(kid, depth+1)
+			}
+		} else if dict != nil {
+			pages = append(pages, ref)
+		}
+	}
+	walk(catalog["Pages"], 0)
+	return pages
+}
+
+// inherited returns the value of key for a page, looked up through its ancestors in the
+// page tree when the page does not set it, like /MediaBox.
+func (objects pdfObjects) inherited(page pdfRef, key string) any {
+	dict := objects.dict(page)
+	for depth := 0; dict != nil && depth < maxPDFDepth; depth++ {
+		if value, ok := dict[key]; ok {
+			return objects.resolve(value)
+		}
+		dict = objects.dict(dict["Parent"])
+	}
+	return nil
+}
+
+// pageContent returns the decoded content streams of a page, joined.
+func (objects pdfObjects) pageContent(page pdfRef) []byte {
+	contents := objects.resolve(objects.dict(page)["Contents"])
+	streams, ok := contents.([]any)
+	if !ok {
+		streams = []any{contents}
+	}
+	var content []byte
+	for _, value := range streams {
+		if stream, ok := objects.resolve(value).(*pdfStream); ok {
+			if data, err := stream.decode(); err == nil {
+				content = append(append(content, data...), '\n')
+			}
+		}
+	}
+	return content
+}
+
+// pdfOperations calls fn for every operator of a content stream with its operands. Inline
+// image data is skipped, and parsing stops at the first malformed operand.
+func pdfOperations(content []byte, fn func(operator string, operands []any)) {
+	p := &pdfParser{data: content}
+	var operands []any
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.data) {
+			return
+		}
+		if c := p.data[p.pos]; c == '<' || c == '[' || c == '(' || c == '/' || c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9' {
+			value, err := p.value()
+			if err != nil {
+				return
+			}
+			operands = append(operands, value)
+			continue
+		}
+		start := p.pos
+		for p.pos < len(p.data) && !pdfIsDelimiter(p.data[p.pos]) {
+			p.pos++
+		}
+		if p.pos == start {
+			p.pos++
+		} else if operator := string(p.data[start:p.pos]); operator == "ID" {
+			end := bytes.Index(p.data[p.pos:], []byte("EI"))
+			if end < 0 {
+				return
+			}
+			p.pos += end + len("EI")
+		} else {
+			fn(operator, operands)
+		}
+		operands = operands[:0]
+	}
+}
+
+// errPDFStreamTooLarge is returned when a decoded stream exceeds its limit.
+var errPDFStreamTooLarge = errors.New("stream too large")
+
+// decode returns the data of a stream with no filter or a single FlateDecode filter.
+func (s *pdfStream) decode() ([]byte, error) {
+	return s.decodeLimit(maxPDFStreamSize)
+}
+
+// decodeLimit is decode for streams decoding to at most limit bytes.
+func (s *pdfStream) decodeLimit(limit int) ([]byte, error) {
+	filter := s.dict["Filter"]
+	if filters, ok := filter.([]any); ok && len(filters) == 1 {
+		filter = filters[0]
+	}
+	switch filter {
+	case nil:
+		if len(s.raw) > limit {
+			return nil, fmt.Errorf("%w: more than %d bytes", errPDFStreamTooLarge, limit)
+		}
+		return s.raw, nil
+	case pdfName("FlateDecode"):
+		reader, err := zlib.NewReader(bytes.NewReader(s.raw))
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(io.LimitReader(reader, int64(limit)+1))
+		if len(data) > limit {
+			return nil, fmt.Errorf("%w: more than %d bytes", errPDFStreamTooLarge, limit)
+		}
+		if err != nil && len(data) == 0 {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unsupported stream filter %v", filter)
+}
+
+type pdfParser struct {
+	data  []byte
+	pos   int
+	depth int
+}
+
+func pdfIsSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f' || c == 0
+}
+
+func pdfIsDelimiter(c byte) bool {
+	return pdfIsSpace(c) || bytes.IndexByte([]byte("()<>[]{}/%"), c) >= 0
+}
+
+func (p *pdfParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case pdfIsSpace(c):
+			p.pos++
+		case c == '%':
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// stream reads the data following a stream dictionary, if any. The data ends at the
+// "endstream" keyword rather than at /Length, which may be an indirect reference.
+func (p *pdfParser) stream(dict pdfDict) (*pdfStream, bool) {
+	p.skipSpace()
+	if !bytes.HasPrefix(p.data[p.pos:], []byte("stream")) {
+		return nil, false
+	}
+	start := p.pos + len("stream")
+	if bytes.HasPrefix(p.data[start:], []byte("\r\n")) {
+		start += 2
+	} else if start < len(p.data) && p.data[start] == '\n' {
+		start++
+	}
+	end := bytes.Index(p.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, false
+	}
+	raw := p.data[start : start+end]
+	if length, ok := pdfInt(dict["Length"]); ok && length >= 0 && length <= len(raw) {
+		raw = raw[:length]
+	}
+	return &pdfStream{dict: dict, raw: raw}, true
+}
+
+func (p *pdfParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("pdf offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// value parses the next object: a dictionary, array, string, name, number, reference,
+// boolean, or null (nil).
+func (p *pdfParser) value() (any, error) {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("unexpected end of data")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		return p.dict()
+	case c == '<':
+		return p.hexString()
+	case c == '[':
+		return p.array()
+	case c == '(':
+		return p.literalString()
+	case c == '/':
+		return p.name(), nil
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.number()
+	}
+	start := p.pos
+	for p.pos < len(p.data) && !pdfIsDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	switch keyword := string(p.data[start:p.pos]); keyword {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	default:
+		p.pos = start
+		return nil, p.errorf("unexpected token %q", keyword)
+	}
+}
+
+func (p *pdfParser) nest() error {
+	p.depth++
+	if p.depth > maxPDFDepth {
+		return p.errorf("objects nested deeper than %d", maxPDFDepth)
+	}
+	return nil
+}
+
+func (p *pdfParser) dict() (any, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	p.pos += 2
+	dict := pdfDict{}
+	for {
+		p.skipSpace()
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return dict, nil
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != '/' {
+			return nil, p.errorf("expected dictionary key")
+		}
+		key := p.name()
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		dict[string(key)] = value
+	}
+}
+
+func (p *pdfParser) array() (any, error) {
+	if err := p.nest(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	p.pos++
+	var array []any
+	for {
+		p.skipSpace()
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+}
+
+func (p *pdfParser) name() pdfName {
+	p.pos++
+	var name []byte
+	for p.pos < len(p.data) && !pdfIsDelimiter(p.data[p.pos]) {
+		c := p.data[p.pos]
+		if c == '#' && p.pos+2 < len(p.data) {
+			if b, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8); err == nil {
+				name = append(name, byte(b))
+				p.pos += 3
+				continue
+			}
+		}
+		name = append(name, c)
+		p.pos++
+	}
+	return pdfName(name)
+}
+
+// number parses a number, or a reference when two integers are followed by "R".
+func (p *pdfParser) number() (any, error) {
+	start := p.pos
+	token := func() string {
+		begin := p.pos
+		for p.pos < len(p.data) && !pdfIsDelimiter(p.data[p.pos]) {
+			p.pos++
+		}
+		return string(p.data[begin:p.pos])
+	}
+	first := token()
+	n, err := strconv.ParseFloat(first, 64)
+	if err != nil {
+		p.pos = start
+		return nil, p.errorf("invalid number %q", first)
+	}
+	num, err := strconv.Atoi(first)
+	if err != nil {
+		return n, nil
+	}
+	// Look ahead for "gen R".
+	after := p.pos
+	p.skipSpace()
+	if gen, err := strconv.Atoi(token()); err == nil {
+		p.skipSpace()
+		if token() == "R" {
+			return pdfRef{num: num, gen: gen}, nil
+		}
+	}
+	p.pos = after
+	return n, nil
+}
+
+func (p *pdfParser) literalString() (any, error) {
+	p.pos++
+	var out []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfText(out), nil
+			}
+		case '\\':
+			if p.pos >= len(p.data) {
+				continue
+			}
+			escaped := p.data[p.pos]
+			p.pos++
+			switch escaped {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+			case '\n':
+			default:
+				if escaped >= '0' && escaped <= '7' {
+					value := int(escaped - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						value = value*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					out = append(out, byte(value))
+				} else {
+					out = append(out, escaped)
+				}
+			}
+			continue
+		}
+		out = append(out, c)
+	}
+	return nil, p.errorf("unterminated string")
+}
+
+func (p *pdfParser) hexString() (any, error) {
+	end := bytes.IndexByte(p.data[p.pos:], '>')
+	if end < 0 {
+		return nil, p.errorf("unterminated hex string")
+	}
+	var digits []byte
+	for _, c := range p.data[p.pos+1 : p.pos+end] {
+		if !pdfIsSpace(c) {
+			digits = append(digits, c)
+		}
+	}
+	p.pos += end + 1
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		b, err := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		if err != nil {
+			return nil, p.errorf("invalid hex string")
+		}
+		out[i] = byte(b)
+	}
+	return pdfText(out), nil
+}
+
+// pdfText decodes a PDF text string: UTF-16BE with a byte order mark, or PDFDocEncoding,
+// read as Latin-1.
+func pdfText(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, (len(raw)-2)/2)
+		for i := range units {
+			units[i] = uint16(raw[2+2*i])<<8 | uint16(raw[3+2*i])
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// pdfInt returns value as an int when it is a whole number.
+func pdfInt(value any) (int, bool) {
+	n, ok := value.(float64)
+	if !ok || n != float64(int(n)) {
+		return 0, false
+	}
+	return int(n), true
+}
+
+// pdfNumbers returns the elements of a numeric array.
+func pdfNumbers(value any) ([]float64, bool) {
+	array, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	numbers := make([]float64, len(array))
+	for i, item := range array {
+		if numbers[i], ok = item.(float64); !ok {
+			return nil, false
+		}
+	}
+	return numbers, true
+}
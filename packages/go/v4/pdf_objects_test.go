@@ -0,0 +1,74 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPDFParserValues(t *testing.T) {
+	p := &pdfParser{data: []byte(`<< /Name /A#20B /Text (a\(b\)\101\
+c) /Hex <FEFF00E9> /Ref 12 0 R /Numbers [1 -2.5 +3] /Nested << /Flag true /Empty null >> >>`)}
+	value, err := p.value()
+	if err != nil {
+		t.Fatalf("value: %v", err)
+	}
+	dict := value.(pdfDict)
+	if dict["Name"] != pdfName("A B") || dict["Text"] != "a(b)Ac" || dict["Hex"] != "é" || dict["Ref"] != (pdfRef{num: 12}) {
+		t.Fatalf("unexpected values: %#v", dict)
+	}
+	if numbers, ok := pdfNumbers(dict["Numbers"]); !ok || len(numbers) != 3 || numbers[1] != -2.5 {
+		t.Fatalf("unexpected numbers: %#v", dict["Numbers"])
+	}
+	nested := dict["Nested"].(pdfDict)
+	if nested["Flag"] != true || nested["Empty"] != nil {
+		t.Fatalf("unexpected nested dictionary: %#v", nested)
+	}
+}
+
+func TestPDFParserRejectsMalformedInput(t *testing.T) {
+	for name, input := range map[string]string{
+		"unterminated dict":   "<< /A 1",
+		"unterminated string": "(abc",
+		"bad key":             "<< 1 2 >>",
+		"too deep":            strings.Repeat("[", maxPDFDepth+1) + strings.Repeat("]", maxPDFDepth+1),
+	} {
+		p := &pdfParser{data: []byte(input)}
+		if _, err := p.value(); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestReadPDFObjectsPrefersLaterDefinitions(t *testing.T) {
+	data := geoTestPDF("<< /Version 1 >>") // object 1
+	data = append(data, "1 0 obj\n<< /Version 2 >>\nendobj\n"...)
+	objects := readPDFObjects(data)
+	if objects.dict(pdfRef{num: 1})["Version"] != 2.0 {
+		t.Fatalf("unexpected object: %#v", objects[1])
+	}
+}
+
+func TestReadPDFObjectsBoundsObjectStreams(t *testing.T) {
+	// Three object streams decoding to 7 MB each: the third exceeds the total.
+	data := []byte("%PDF-1.7\n")
+	for i := range 3 {
+		header := fmt.Sprintf("%d 0 ", 100+i)
+		content := header + "<< /Packed true >>" + strings.Repeat(" ", 7<<20)
+		var packed bytes.Buffer
+		writer := zlib.NewWriter(&packed)
+		writer.Write([]byte(content))
+		writer.Close()
+		data = fmt.Appendf(data, "%d 0 obj\n<< /Type /ObjStm /N 1 /First %d /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+			10+i, len(header), packed.Len(), packed.Bytes())
+	}
+	objects := readPDFObjects(data)
+	if objects.dict(pdfRef{num: 100})["Packed"] != true || objects.dict(pdfRef{num: 101})["Packed"] != true {
+		t.Fatal("expected the objects of the first streams to be unpacked")
+	}
+	if _, ok := objects[102]; ok {
+		t.Fatal("expected the stream beyond the total size to be skipped")
+	}
+}
@@ -0,0 +1,184 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"os"
+	"strings"
+	"unsafe"
+)
+
+// Cost classes reported by ExtractPlan, from cheapest to most expensive.
+const (
+	CostClassSmall  = "small"
+	CostClassMedium = "medium"
+	CostClassLarge  = "large"
+	CostClassXLarge = "xlarge"
+)
+
+// Relative cost of a page with a text layer and of a page that needs OCR.
+const (
+	planPageCost    = 1.0
+	planOCRPageCost = 20.0
+)
+
+// ExtractionPlan describes how a document would be extracted, without extracting it.
+// PageCount is exact for PDFs and estimated from the file size otherwise, as reported by
+// PageCountEstimated. CostUnits weighs pages by whether they need OCR; CostClass buckets
+// it for routing.
+type ExtractionPlan struct {
+	MimeType           string  `json:"mime_type"`
+	Extractor          string  `json:"extractor"`
+	FileSize           int64   `json:"file_size"`
+	PageCount          int     `json:"page_count"`
+	PageCountEstimated bool    `json:"page_count_estimated"`
+	OCRRequired        bool    `json:"ocr_required"`
+	OCRPages           int     `json:"ocr_pages"`
+	Encrypted          bool    `json:"encrypted,omitempty"`
+	CostUnits          float64 `json:"cost_units"`
+	CostClass          string  `json:"cost_class"`
+}
+
+// pdfProbe mirrors the JSON returned by kreuzberg_pdf_probe.
+type pdfProbe struct {
+	PageCount int  `json:"page_count"`
+	TextPages int  `json:"text_pages"`
+	Encrypted bool `json:"encrypted"`
+}
+
+// ExtractPlan inspects the document at path and reports how config would extract it:
+// its MIME type, the extractor that would handle it, its page count, and whether OCR
+// would run. PDFs are parsed to count pages and find pages without a text layer; other
+// documents are only sized. A nil config uses the defaults.
+func ExtractPlan(path string, config *ExtractionConfig) (*ExtractionPlan, error) {
+	if path == "" {
+		return nil, newValidationErrorWithContext("path is required", nil, ErrorCodeValidation, nil)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to stat document", err, ErrorCodeIo, nil)
+	}
+	mimeType, err := DetectMimeTypeFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	extractor, err := extractorForMime(mimeType)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ExtractionPlan{
+		MimeType:  mimeType,
+		Extractor: extractor,
+		FileSize:  info.Size(),
+	}
+	if err := planPages(plan, func() ([]byte, error) { return os.ReadFile(path) }, config); err != nil {
+		return nil, newIOErrorWithContext("failed to read document", err, ErrorCodeIo, nil)
+	}
+	return plan, nil
+}
+
+// planPages fills in the page count, OCR pages and cost of plan from its MIME type and
+// file size. PDFs, whose bytes read returns, are probed; other documents are sized. The
+// statistics EstimateExtraction learns from weigh extractions with it too, so that their
+// cost units match those of plans.
+func planPages(plan *ExtractionPlan, read func() ([]byte, error), config *ExtractionConfig) error {
+	plan.PageCount = estimatePageCount(plan.MimeType, plan.FileSize)
+	plan.PageCountEstimated = true
+	forceOCR := config != nil && config.ForceOCR != nil && *config.ForceOCR
+	switch {
+	case plan.MimeType == "application/pdf":
+		data, err := read()
+		if err != nil {
+			return err
+		}
+		// Documents the probe cannot parse keep the size-based estimate.
+		if probe, err := probePDF(data); err == nil {
+			plan.PageCount = probe.PageCount
+			plan.PageCountEstimated = false
+			plan.Encrypted = probe.Encrypted
+			plan.OCRPages = probe.PageCount - probe.TextPages
+		}
+		if forceOCR {
+			plan.OCRPages = plan.PageCount
+		}
+	case strings.HasPrefix(plan.MimeType, "image/"):
+		plan.OCRPages = plan.PageCount
+	}
+	plan.OCRRequired = plan.OCRPages > 0
+	plan.CostUnits, plan.CostClass = planCost(plan.PageCount, plan.OCRPages)
+	return nil
+}
+
+// estimatePageCount guesses the page count of a non-PDF document from its size.
+func estimatePageCount(mimeType string, size int64) int {
+	bytesPerPage := int64(50_000)
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return 1
+	case strings.HasPrefix(mimeType, "text/"), strings.HasSuffix(mimeType, "json"), strings.HasSuffix(mimeType, "xml"):
+		bytesPerPage = 3_000
+	case strings.Contains(mimeType, "spreadsheet"), strings.Contains(mimeType, "excel"):
+		bytesPerPage = 20_000
+	case strings.Contains(mimeType, "wordprocessing"), strings.Contains(mimeType, "msword"), strings.Contains(mimeType, "opendocument.text"):
+		bytesPerPage = 30_000
+	}
+	return int(max(1, (size+bytesPerPage-1)/bytesPerPage))
+}
+
+// planCost weighs pages by whether they need OCR and buckets the total.
+func planCost(pages, ocrPages int) (float64, string) {
+	units := float64(pages-ocrPages)*planPageCost + float64(ocrPages)*planOCRPageCost
+	switch {
+	case units < 10:
+		return units, CostClassSmall
+	case units < 100:
+		return units, CostClassMedium
+	case units < 1000:
+		return units, CostClassLarge
+	}
+	return units, CostClassXLarge
+}
+
+// probePDF counts the pages of a PDF and those with a text layer.
+func probePDF(data []byte) (*pdfProbe, error) {
+	buf := C.CBytes(data)
+	defer C.free(buf)
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_pdf_probe((*C.uint8_t)(buf), C.uintptr_t(len(data)))
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	probe := &pdfProbe{}
+	if err := decodeJSONCString(ptr, probe); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode PDF probe", err, ErrorCodeValidation, nil)
+	}
+	return probe, nil
+}
+
+// extractorForMime names the document extractor that handles mimeType.
+func extractorForMime(mimeType string) (string, error) {
+	cMime := C.CString(mimeType)
+	defer C.free(unsafe.Pointer(cMime))
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_document_extractor_for_mime(cMime)
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return "", lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	return C.GoString(ptr), nil
+}
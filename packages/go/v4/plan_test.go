@@ -0,0 +1,33 @@
+package kreuzberg
+
+import "testing"
+
+func TestEstimatePageCount(t *testing.T) {
+	cases := []struct {
+		mimeType string
+		size     int64
+		want     int
+	}{
+		{"image/png", 5_000_000, 1},
+		{"text/plain", 6_001, 3},
+		{"application/octet-stream", 0, 1},
+		{"application/vnd.openxmlformats-officedocument.wordprocessingml.document", 90_000, 3},
+	}
+	for _, tc := range cases {
+		if got := estimatePageCount(tc.mimeType, tc.size); got != tc.want {
+			t.Errorf("estimatePageCount(%q, %d) = %d, want %d", tc.mimeType, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestPlanCostWeighsOCRPages(t *testing.T) {
+	if units, class := planCost(5, 0); units != 5 || class != CostClassSmall {
+		t.Fatalf("got %v %s for text pages", units, class)
+	}
+	if units, class := planCost(5, 5); units != 100 || class != CostClassLarge {
+		t.Fatalf("got %v %s for scanned pages", units, class)
+	}
+	if _, class := planCost(2000, 0); class != CostClassXLarge {
+		t.Fatalf("got %s for a large document", class)
+	}
+}
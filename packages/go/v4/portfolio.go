@@ -0,0 +1,281 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"maps"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PortfolioMetadata is the manifest of a PDF portfolio, a PDF whose pages are only a
+// cover sheet for the files it collects. View is how viewers first present the files
+// ("details", "tile", "hidden" or "custom"), InitialDocument the file they open first,
+// and Fields the columns of the file list. Files are in the order of the embedded file
+// name tree and match ExtractionResult.EmbeddedDocuments by index.
+type PortfolioMetadata struct {
+	View            string           `json:"view,omitempty"`
+	InitialDocument string           `json:"initial_document,omitempty"`
+	Fields          []PortfolioField `json:"fields,omitempty"`
+	Files           []PortfolioFile  `json:"files"`
+}
+
+// PortfolioField is a column of a portfolio's file list. Type is "text", "date" or
+// "number" for fields kept in each file's Properties, and "filename", "description",
+// "modified", "created", "size" or "compressed_size" for columns showing a file
+// attribute.
+type PortfolioField struct {
+	Key   string `json:"key"`
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Order int    `json:"order"`
+}
+
+// PortfolioFile is a file embedded in a portfolio. Dates are in RFC 3339 form, and
+// Properties holds the file's values of the portfolio fields, keyed by field key.
+type PortfolioFile struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	MimeType    string            `json:"mime_type,omitempty"`
+	Size        int64             `json:"size,omitempty"`
+	CreatedAt   string            `json:"created_at,omitempty"`
+	ModifiedAt  string            `json:"modified_at,omitempty"`
+	Properties  map[string]string `json:"properties,omitempty"`
+}
+
+// EmbeddedDocument is a file of a PDF portfolio, an archive entry or an email attachment
+// extracted on its own. Name is the path of an archive entry. Error is set instead of
+// Result when the file could not be extracted.
+type EmbeddedDocument struct {
+	Name     string            `json:"name"`
+	MimeType string            `json:"mime_type,omitempty"`
+	Result   *ExtractionResult `json:"result,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+var portfolioViews = map[pdfName]string{"D": "details", "T": "tile", "H": "hidden", "C": "custom"}
+
+var portfolioFieldTypes = map[pdfName]string{
+	"S": "text", "D": "date", "N": "number", "F": "filename", "Desc": "description",
+	"ModDate": "modified", "CreationDate": "created", "Size": "size", "CompressedSize": "compressed_size",
+}
+
+var pdfDatePattern = regexp.MustCompile(`^D:(\d{4})(\d{2})?(\d{2})?(\d{2})?(\d{2})?(\d{2})?(?:([Zz])|([+-])(\d{2})'?(\d{2})?'?)?`)
+
+// portfolioFile is an embedded file and its contents.
+type portfolioFile struct {
+	PortfolioFile
+	data []byte
+}
+
+// readPortfolio reads the collection dictionary and embedded files of a PDF. It returns
+// nil when the PDF is not a portfolio.
+func readPortfolio(data []byte) (*PortfolioMetadata, []portfolioFile) {
+	if !bytes.Contains(data, []byte("/Collection")) {
+		return nil, nil
+	}
+	objects := readPDFObjects(data)
+	var catalog pdfDict
+	for _, num := range slices.Sorted(maps.Keys(objects)) {
+		if dict := objects.dict(objects[num]); dict["Type"] == pdfName("Catalog") && dict["Collection"] != nil {
+			catalog = dict
+		}
+	}
+	if catalog == nil {
+		return nil, nil
+	}
+
+	collection := objects.dict(catalog["Collection"])
+	meta := &PortfolioMetadata{View: portfolioViews[pdfName("D")]}
+	if view, ok := objects.resolve(collection["View"]).(pdfName); ok && portfolioViews[view] != "" {
+		meta.View = portfolioViews[view]
+	}
+	if initial, ok := objects.resolve(collection["D"]).(string); ok {
+		meta.InitialDocument = initial
+	}
+	for key, value := range objects.dict(collection["Schema"]) {
+		field := objects.dict(value)
+		if key == "Type" || field == nil {
+			continue
+		}
+		name, _ := objects.resolve(field["N"]).(string)
+		order, _ := pdfInt(objects.resolve(field["O"]))
+		subtype, _ := objects.resolve(field["Subtype"]).(pdfName)
+		meta.Fields = append(meta.Fields, PortfolioField{Key: key, Name: name, Type: portfolioFieldTypes[subtype], Order: order})
+	}
+	sort.Slice(meta.Fields, func(i, j int) bool {
+		a, b := meta.Fields[i], meta.Fields[j]
+		return a.Order < b.Order || a.Order == b.Order && a.Key < b.Key
+	})
+
+	names := objects.dict(catalog["Names"])
+	var files []portfolioFile
+	objects.walkNameTree(names["EmbeddedFiles"], 0, map[pdfRef]bool{}, func(key string, value any) {
+		spec := objects.dict(value)
+		if spec == nil {
+			return
+		}
+		file := portfolioFile{PortfolioFile: PortfolioFile{Name: key}}
+		for _, entry := range []string{"F", "UF"} {
+			if name, ok := objects.resolve(spec[entry]).(string); ok && name != "" {
+				file.Name = name
+			}
+		}
+		file.Description, _ = objects.resolve(spec["Desc"]).(string)
+		if stream, ok := objects.resolve(objects.dict(spec["EF"])["F"]).(*pdfStream); ok {
+			file.data, _ = stream.decode()
+			subtype, _ := objects.resolve(stream.dict["Subtype"]).(pdfName)
+			file.MimeType = string(subtype)
+			params := objects.dict(stream.dict["Params"])
+			if size, ok := pdfInt(objects.resolve(params["Size"])); ok {
+				file.Size = int64(size)
+			} else {
+				file.Size = int64(len(file.data))
+			}
+			file.CreatedAt = pdfDate(objects.resolve(params["CreationDate"]))
+			file.ModifiedAt = pdfDate(objects.resolve(params["ModDate"]))
+		}
+		for key, value := range objects.dict(spec["CI"]) {
+			if key == "Type" {
+				continue
+			}
+			if text := portfolioValue(objects, value); text != "" {
+				if file.Properties == nil {
+					file.Properties = map[string]string{}
+				}
+				file.Properties[key] = text
+			}
+		}
+		files = append(files, file)
+	})
+	for _, file := range files {
+		meta.Files = append(meta.Files, file.PortfolioFile)
+	}
+	return meta, files
+}
+
+// walkNameTree calls visit for each key and value of a name tree, in order.
+func (objects pdfObjects) walkNameTree(node any, depth int, seen map[pdfRef]bool, visit func(string, any)) {
+	if ref, ok := node.(pdfRef); ok {
+		if seen[ref] {
+			return
+		}
+		seen[ref] = true
+	}
+	dict := objects.dict(node)
+	if dict == nil || depth > maxPDFDepth {
+		return
+	}
+	if names, ok := objects.resolve(dict["Names"]).([]any); ok {
+		for i := 0; i+1 < len(names); i += 2 {
+			if key, ok := objects.resolve(names[i]).(string); ok {
+				visit(key, names[i+1])
+			}
+		}
+	}
+	if kids, ok := objects.resolve(dict["Kids"]).([]any); ok {
+		for _, kid := range kids {
+			objects.walkNameTree(kid, depth+1, seen, visit)
+		}
+	}
+}
+
+// portfolioValue returns a collection item value as text. A value may be given as a
+// collection subitem dictionary with the data in D and a display prefix in P.
+func portfolioValue(objects pdfObjects, value any) string {
+	value = objects.resolve(value)
+	if dict, ok := value.(pdfDict); ok {
+		prefix, _ := objects.resolve(dict["P"]).(string)
+		return prefix + portfolioValue(objects, dict["D"])
+	}
+	switch v := value.(type) {
+	case string:
+		if date := pdfDate(v); date != "" {
+			return date
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case pdfName:
+		return string(v)
+	}
+	return ""
+}
+
+// pdfDate converts a PDF date, "D:YYYYMMDDHHmmSSOHH'mm'" with all but the year optional,
+// to RFC 3339. It returns "" for anything else.
+func pdfDate(value any) string {
+	text, ok := value.(string)
+	if !ok {
+		return ""
+	}
+	match := pdfDatePattern.FindStringSubmatch(strings.TrimSpace(text))
+	if match == nil {
+		return ""
+	}
+	part := func(i, fallback int) int {
+		if match[i] == "" {
+			return fallback
+		}
+		n, _ := strconv.Atoi(match[i])
+		return n
+	}
+	location := time.UTC
+	if match[8] != "" {
+		offset := (part(9, 0)*60 + part(10, 0)) * 60
+		if match[8] == "-" {
+			offset = -offset
+		}
+		location = time.FixedZone("", offset)
+	}
+	date := time.Date(part(1, 0), time.Month(part(2, 1)), part(3, 1), part(4, 0), part(5, 0), part(6, 0), 0, location)
+	return date.Format(time.RFC3339)
+}
+
+// applyPortfolioStage extracts the embedded files of a PDF portfolio, for which the core
+// returns only the cover sheet, into EmbeddedDocuments and adds the portfolio manifest
+// to the metadata when ExtractionConfig.Portfolios is set. read returns the original
+// document. A file that fails to extract carries its error instead of failing the
+// portfolio.
+func applyPortfolioStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.Portfolios == nil || !*config.Portfolios {
+		return
+	}
+	if result.MimeType != "application/pdf" || result.Metadata.Error != nil {
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	meta, files := readPortfolio(data)
+	if meta == nil {
+		return
+	}
+	result.Metadata.Portfolio = meta
+	result.EmbeddedDocuments = make([]EmbeddedDocument, len(files))
+	for i, file := range files {
+		document := EmbeddedDocument{Name: file.Name, MimeType: file.MimeType}
+		if document.MimeType == "" || document.MimeType == "application/octet-stream" {
+			if len(file.data) > 0 {
+				if detected, err := DetectMimeType(file.data); err == nil {
+					document.MimeType = detected
+				}
+			}
+		}
+		switch {
+		case len(file.data) == 0:
+			document.Error = "embedded file has no readable contents"
+		case document.MimeType == "":
+			document.Error = "could not determine the embedded file's format"
+		default:
+			if document.Result, err = ExtractBytesSync(file.data, document.MimeType, config); err != nil {
+				document.Error = err.Error()
+			}
+		}
+		result.EmbeddedDocuments[i] = document
+	}
+}
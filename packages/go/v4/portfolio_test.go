@@ -0,0 +1,136 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"testing"
+)
+
+// portfolioTestPDF writes a portfolio with a Flate-compressed server log, a file with no
+// embedded stream under an intermediate name tree node, and a cover page.
+func portfolioTestPDF() []byte {
+	var log bytes.Buffer
+	writer := zlib.NewWriter(&log)
+	writer.Write([]byte("2024-05-01 10:00:00 ERROR disk full\n"))
+	writer.Close()
+
+	objects := []string{
+		`<< /Type /Catalog /Pages 2 0 R /Names << /EmbeddedFiles 3 0 R >>
+/Collection << /Type /Collection /View /T /D (server.log)
+/Schema << /Type /CollectionSchema /dept << /Subtype /S /N (Department) /O 2 >>
+/filename << /Subtype /F /N (Name) /O 1 >> >> >> >>`,
+		`<< /Type /Pages /Kids [] /Count 0 >>`,
+		`<< /Kids [4 0 R 5 0 R] >>`,
+		`<< /Names [(server.log) 6 0 R] >>`,
+		`<< /Names [(missing.txt) << /Type /Filespec /F (missing.txt) >>] >>`,
+		`<< /Type /Filespec /F (server.log) /UF <FEFF0073006500720076006500720031002E006C006F0067> /Desc (Nightly log)
+/EF << /F 7 0 R >> /CI << /Type /CollectionItem /dept << /D (Ops) /P (Team ) >> >> >>`,
+		fmt.Sprintf("<< /Type /EmbeddedFile /Subtype /text#2Fx-log /Filter /FlateDecode /Length %d\n"+
+			"/Params << /Size 36 /ModDate (D:20240501120000+02'00') >> >>\nstream\n%s\nendstream", log.Len(), log.Bytes()),
+	}
+	out := "%PDF-1.7\n"
+	for i, object := range objects {
+		out += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	return []byte(out + "trailer << /Root 1 0 R >>\n%%EOF\n")
+}
+
+func TestReadPortfolio(t *testing.T) {
+	meta, files := readPortfolio(portfolioTestPDF())
+	if meta == nil {
+		t.Fatal("expected a portfolio")
+	}
+	if meta.View != "tile" || meta.InitialDocument != "server.log" {
+		t.Fatalf("unexpected collection: %+v", meta)
+	}
+	if len(meta.Fields) != 2 || meta.Fields[0].Key != "filename" || meta.Fields[0].Type != "filename" ||
+		meta.Fields[1].Name != "Department" || meta.Fields[1].Type != "text" {
+		t.Fatalf("unexpected fields: %+v", meta.Fields)
+	}
+
+	if len(files) != 2 || len(meta.Files) != 2 {
+		t.Fatalf("got %d files, want 2", len(files))
+	}
+	log := meta.Files[0]
+	if log.Name != "server1.log" || log.Description != "Nightly log" || log.MimeType != mimeTypeLog || log.Size != 36 {
+		t.Fatalf("unexpected file entry: %+v", log)
+	}
+	if log.ModifiedAt != "2024-05-01T12:00:00+02:00" || log.Properties["dept"] != "Team Ops" {
+		t.Fatalf("unexpected file dates or properties: %+v", log)
+	}
+	if !bytes.HasPrefix(files[0].data, []byte("2024-05-01 10:00:00 ERROR")) {
+		t.Fatalf("embedded file not decoded: %q", files[0].data)
+	}
+	if meta.Files[1].Name != "missing.txt" || files[1].data != nil {
+		t.Fatalf("unexpected second file: %+v", meta.Files[1])
+	}
+}
+
+func TestReadPortfolioIgnoresOrdinaryPDFs(t *testing.T) {
+	pdf := []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n%%EOF\n")
+	if meta, files := readPortfolio(pdf); meta != nil || files != nil {
+		t.Fatal("expected no portfolio")
+	}
+}
+
+func TestApplyPortfolioStage(t *testing.T) {
+	data := portfolioTestPDF()
+	result := &ExtractionResult{Content: "cover sheet", MimeType: "application/pdf", Success: true}
+	applyPortfolioStage(result, func() ([]byte, error) { return data, nil }, nil)
+	if result.Metadata.Portfolio != nil || result.EmbeddedDocuments != nil {
+		t.Fatal("expected portfolios to be left alone unless enabled")
+	}
+
+	applyPortfolioStage(result, func() ([]byte, error) { return data, nil }, NewExtractionConfig(WithPortfolios(true)))
+
+	if result.Metadata.Portfolio == nil || len(result.EmbeddedDocuments) != 2 {
+		t.Fatalf("expected two embedded documents, got %+v", result.EmbeddedDocuments)
+	}
+	log := result.EmbeddedDocuments[0]
+	if log.Error != "" || log.Result == nil || log.Result.Metadata.Format.Type != FormatLog {
+		t.Fatalf("log not extracted: %+v", log)
+	}
+	if missing := result.EmbeddedDocuments[1]; missing.Result != nil || missing.Error == "" {
+		t.Fatalf("expected an error for the file without contents: %+v", missing)
+	}
+}
+
+func TestDocumentStagesShareOneRead(t *testing.T) {
+	data := portfolioTestPDF()
+	reads := 0
+	read := func() ([]byte, error) {
+		reads++
+		return data, nil
+	}
+	results := []*ExtractionResult{
+		{MimeType: "application/pdf", Success: true},
+		{MimeType: "application/pdf", Success: true},
+	}
+	config := NewExtractionConfig(WithPortfolios(true), WithFontInventory(true))
+	if err := applyBatchDocumentStages(results, func(int) ([]byte, error) { return read() }, config); err != nil {
+		t.Fatal(err)
+	}
+	if reads != len(results) {
+		t.Fatalf("expected one read per document, got %d", reads)
+	}
+	for _, result := range results {
+		if result.Metadata.Portfolio == nil {
+			t.Fatalf("expected the portfolio stage to run: %+v", result)
+		}
+	}
+}
+
+func TestPDFDate(t *testing.T) {
+	cases := map[string]string{
+		"D:20240102":              "2024-01-02T00:00:00Z",
+		"D:20240102030405Z":       "2024-01-02T03:04:05Z",
+		"D:20240102030405-05'30'": "2024-01-02T03:04:05-05:30",
+		"January 2nd":             "",
+	}
+	for input, want := range cases {
+		if got := pdfDate(input); got != want {
+			t.Errorf("pdfDate(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Names of the built-in presets in the preset registry.
+const (
+	PresetFast         = "fast"
+	PresetHighFidelity = "high_fidelity"
+	PresetRAG          = "rag"
+	PresetOCRHeavy     = "ocr_heavy"
+)
+
+// PresetSet groups the built-in extraction presets. Each method returns a new config;
+// options passed to it are applied on top of the preset.
+type PresetSet struct{}
+
+// Presets exposes the built-in extraction presets, e.g. Presets.RAG().
+var Presets PresetSet
+
+// Fast favors throughput: no quality processing, image extraction, or OCR unless the
+// document has no text layer.
+func (PresetSet) Fast(opts ...ExtractionOption) *ExtractionConfig {
+	return presetConfig(fastPreset, opts)
+}
+
+// HighFidelity favors faithful output: Markdown with tables, images, per-page content,
+// and quality processing.
+func (PresetSet) HighFidelity(opts ...ExtractionOption) *ExtractionConfig {
+	return presetConfig(highFidelityPreset, opts)
+}
+
+// RAG prepares documents for retrieval: Markdown split along headings into chunks that
+// keep tables whole, with page boundaries for citations.
+func (PresetSet) RAG(opts ...ExtractionOption) *ExtractionConfig {
+	return presetConfig(ragPreset, opts)
+}
+
+// OCRHeavy targets scans and photos: OCR on every page at 300 DPI with deskewing,
+// denoising, and automatic rotation.
+func (PresetSet) OCRHeavy(opts ...ExtractionOption) *ExtractionConfig {
+	return presetConfig(ocrHeavyPreset, opts)
+}
+
+var (
+	fastPreset = []ExtractionOption{
+		WithEnableQualityProcessing(false),
+		WithImages(WithExtractImages(false)),
+		WithForceOCR(false),
+	}
+	highFidelityPreset = []ExtractionOption{
+		WithEnableQualityProcessing(true),
+		WithOutputFormat(string(OutputFormatMarkdown)),
+		WithImages(WithExtractImages(true)),
+		WithPages(WithExtractPages(true)),
+		WithTableExtraction(),
+	}
+	ragPreset = []ExtractionOption{
+		WithEnableQualityProcessing(true),
+		WithOutputFormat(string(OutputFormatMarkdown)),
+		WithPages(),
+		WithChunking(
+			WithChunkingStrategy(ChunkingStrategyMarkdownStructure),
+			WithMaxChars(1000),
+			WithMaxOverlap(100),
+			WithTablePolicy(TablePolicySeparate),
+		),
+	}
+	ocrHeavyPreset = []ExtractionOption{
+		WithForceOCR(true),
+		WithOCR(WithTesseract(WithTesseractPreprocessing(
+			WithTargetDPI(300),
+			WithAutoRotate(true),
+			WithDeskew(true),
+			WithDenoise(true),
+		))),
+		WithImages(WithImageTargetDPI(300)),
+	}
+)
+
+var (
+	presetsMu sync.RWMutex
+	presets   = map[string][]ExtractionOption{
+		PresetFast:         fastPreset,
+		PresetHighFidelity: highFidelityPreset,
+		PresetRAG:          ragPreset,
+		PresetOCRHeavy:     ocrHeavyPreset,
+	}
+)
+
+// presetConfig builds a config from the preset options followed by opts. Options capture
+// the values they set by pointer, so the preset is deep-copied before opts are applied to
+// keep configs returned for the same preset independent.
+func presetConfig(preset, opts []ExtractionOption) *ExtractionConfig {
+	base := NewExtractionConfig(preset...)
+	data, err := json.Marshal(base)
+	if err == nil {
+		cfg := &ExtractionConfig{}
+		if json.Unmarshal(data, cfg) == nil {
+			base = cfg
+		}
+	}
+	return profileConfig(base, opts...)
+}
+
+// RegisterPreset adds a named preset built from opts, so services can share extraction
+// settings by name. Built-in presets cannot be replaced, and a user preset must be
+// unregistered before its name is reused.
+func RegisterPreset(name string, opts ...ExtractionOption) error {
+	if name == "" {
+		return newValidationErrorWithContext("preset name cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	if _, exists := presets[name]; exists {
+		return newValidationErrorWithContext(fmt.Sprintf("preset already registered: %s", name), nil, ErrorCodeValidation, nil)
+	}
+	presets[name] = append([]ExtractionOption(nil), opts...)
+	return nil
+}
+
+// UnregisterPreset removes a preset added with RegisterPreset.
+func UnregisterPreset(name string) error {
+	if isBuiltinPreset(name) {
+		return newValidationErrorWithContext(fmt.Sprintf("cannot unregister built-in preset: %s", name), nil, ErrorCodeValidation, nil)
+	}
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+	if _, exists := presets[name]; !exists {
+		return newValidationErrorWithContext(fmt.Sprintf("preset not found: %s", name), nil, ErrorCodeValidation, nil)
+	}
+	delete(presets, name)
+	return nil
+}
+
+// PresetConfig returns a new config for the named preset with opts applied on top.
+func PresetConfig(name string, opts ...ExtractionOption) (*ExtractionConfig, error) {
+	presetsMu.RLock()
+	preset, exists := presets[name]
+	presetsMu.RUnlock()
+	if !exists {
+		return nil, newValidationErrorWithContext(fmt.Sprintf("preset not found: %s", name), nil, ErrorCodeValidation, nil)
+	}
+	return presetConfig(preset, opts), nil
+}
+
+// ListPresets returns the names of all registered presets, built-in ones included, sorted.
+func ListPresets() []string {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func isBuiltinPreset(name string) bool {
+	switch name {
+	case PresetFast, PresetHighFidelity, PresetRAG, PresetOCRHeavy:
+		return true
+	}
+	return false
+}
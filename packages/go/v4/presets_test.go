@@ -0,0 +1,59 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPresetsApplyOverrides(t *testing.T) {
+	cfg := Presets.RAG(WithMaxConcurrentExtractions(2))
+	if cfg.Chunking == nil || cfg.Chunking.Strategy != ChunkingStrategyMarkdownStructure {
+		t.Fatalf("expected markdown_structure chunking, got %+v", cfg.Chunking)
+	}
+	if cfg.MaxConcurrentExtractions == nil || *cfg.MaxConcurrentExtractions != 2 {
+		t.Fatalf("expected override to apply")
+	}
+	if Presets.OCRHeavy().ForceOCR == nil || !*Presets.OCRHeavy().ForceOCR {
+		t.Fatalf("expected OCR heavy preset to force OCR")
+	}
+
+	first, second := Presets.HighFidelity(), Presets.HighFidelity()
+	*first.EnableQualityProcessing = false
+	if !*second.EnableQualityProcessing {
+		t.Fatalf("presets must return independent configs")
+	}
+}
+
+func TestPresetRegistry(t *testing.T) {
+	if err := RegisterPreset("invoices", WithOutputFormat(string(OutputFormatMarkdown))); err != nil {
+		t.Fatalf("RegisterPreset: %v", err)
+	}
+	defer func() { _ = UnregisterPreset("invoices") }()
+
+	var validationErr *ValidationError
+	if err := RegisterPreset("invoices"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected duplicate registration to fail, got %v", err)
+	}
+	if err := RegisterPreset(PresetFast); !errors.As(err, &validationErr) {
+		t.Fatalf("expected built-in preset to be protected, got %v", err)
+	}
+	if err := UnregisterPreset(PresetRAG); !errors.As(err, &validationErr) {
+		t.Fatalf("expected built-in preset to be protected, got %v", err)
+	}
+
+	cfg, err := PresetConfig("invoices", WithUseCache(false))
+	if err != nil {
+		t.Fatalf("PresetConfig: %v", err)
+	}
+	if cfg.OutputFormat != string(OutputFormatMarkdown) || cfg.UseCache == nil || *cfg.UseCache {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+	if _, err := PresetConfig("missing"); !errors.As(err, &validationErr) {
+		t.Fatalf("expected unknown preset to fail, got %v", err)
+	}
+
+	names := ListPresets()
+	if len(names) != 5 || names[2] != "invoices" {
+		t.Fatalf("unexpected presets %v", names)
+	}
+}
@@ -0,0 +1,231 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	_ "image/gif" // Register GIF for imageThumbnail.
+	"image/png"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Preview is a quick look at a document for file-browser preview panes: its title, the
+// text of its first page, and a small image of that page or picture when one can be made
+// cheaply. PageCount is 0 when unknown.
+type Preview struct {
+	MimeType  string     `json:"mime_type"`
+	Title     string     `json:"title,omitempty"`
+	Text      string     `json:"text"`
+	PageCount int        `json:"page_count,omitempty"`
+	Thumbnail *PageImage `json:"thumbnail,omitempty"`
+}
+
+var pdfInfoRef = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+
+const (
+	// previewThumbnailDPI renders a letter-size page at about 300x400 pixels.
+	previewThumbnailDPI = 36
+	// previewThumbnailSize bounds the longer side of an image thumbnail, in pixels.
+	previewThumbnailSize = 256
+	// previewMaxBytes bounds the text of documents without pages.
+	previewMaxBytes = 4096
+)
+
+// ExtractPreview returns the title, first-page text and thumbnail of the document at path.
+// PDFs are read from their first page alone, rendered together with its text layer;
+// other documents are extracted with config stripped of OCR, tables, images, chunking and
+// the other enrichment steps, and their text is cut at the first page or after 4 KiB.
+func ExtractPreview(path string, config *ExtractionConfig) (*Preview, error) {
+	if path == "" {
+		return nil, newValidationErrorWithContext("path cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	mimeType, err := DetectMimeTypeFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	if mimeType == "application/pdf" {
+		if preview, err := pdfPreview(path); err == nil {
+			return preview, nil
+		}
+	}
+
+	result, err := ExtractFileSync(path, previewConfig(config))
+	if err != nil {
+		return nil, err
+	}
+	preview := &Preview{MimeType: result.MimeType, Title: documentTitle(result.Metadata), Text: firstPageText(result)}
+	if structure := result.Metadata.PageStructure; structure != nil {
+		preview.PageCount = int(structure.TotalCount)
+	}
+	if strings.HasPrefix(result.MimeType, "image/") {
+		if data, err := os.ReadFile(path); err == nil {
+			preview.Thumbnail = imageThumbnail(data)
+		}
+	}
+	return preview, nil
+}
+
+// pdfPreview renders the first page of a PDF with its text layer and reads the title and
+// page count from the document's structure, leaving the other pages untouched.
+func pdfPreview(path string) (*Preview, error) {
+	page, err := RenderPage(path, 1, &RenderOptions{DPI: previewThumbnailDPI})
+	if err != nil {
+		return nil, err
+	}
+	preview := &Preview{MimeType: "application/pdf", Text: pageWordsText(page.Words)}
+	page.Words = nil
+	preview.Thumbnail = page
+
+	if data, err := os.ReadFile(path); err == nil {
+		objects := readPDFObjects(data)
+		if infos := pdfInfoRef.FindAllSubmatch(data, -1); infos != nil {
+			// The last trailer is that of the latest update.
+			if num, err := strconv.Atoi(string(infos[len(infos)-1][1])); err == nil {
+				if title, ok := objects.resolve(objects.dict(objects[num])["Title"]).(string); ok {
+					preview.Title = strings.TrimSpace(title)
+				}
+			}
+		}
+		for _, value := range objects {
+			if dict := objects.dict(value); dict["Type"] == pdfName("Catalog") {
+				if count, ok := pdfInt(objects.resolve(objects.dict(dict["Pages"])["Count"])); ok {
+					preview.PageCount = count
+				}
+			}
+		}
+	}
+	return preview, nil
+}
+
+// pageWordsText joins the words of a text layer into lines: a word starts a new line
+// when its top is more than half its height away from that of the line.
+func pageWordsText(words []PageWord) string {
+	var text strings.Builder
+	lineTop := 0.0
+	for i, word := range words {
+		switch {
+		case i == 0:
+			lineTop = word.Top
+		case math.Abs(word.Top-lineTop) > word.Height/2:
+			text.WriteString("\n")
+			lineTop = word.Top
+		default:
+			text.WriteString(" ")
+		}
+		text.WriteString(word.Text)
+	}
+	return text.String()
+}
+
+// previewConfig strips config of the steps a preview does without and asks for pages,
+// so the first one can be cut out.
+func previewConfig(config *ExtractionConfig) *ExtractionConfig {
+	var preview ExtractionConfig
+	if config != nil {
+		preview = *config
+	}
+	preview.OCR = nil
+	preview.ForceOCR = nil
+	preview.Chunking = nil
+	preview.Images = nil
+	preview.TableExtraction = nil
+	preview.Keywords = nil
+	preview.LanguageDetection = nil
+	preview.TokenReduction = nil
+	preview.TranslateTo = ""
+	preview.Translation = nil
+	preview.SectionDetection = nil
+	preview.KeyValues = nil
+	preview.MarkDetection = nil
+	preview.TextStats = nil
+	preview.HeadingDetection = nil
+	preview.LinkExtraction = nil
+	preview.InlineStyles = nil
+	preview.SourceAttribution = nil
+	preview.Speculative = nil
+	preview.Pages = &PageConfig{ExtractPages: BoolPtr(true)}
+	return &preview
+}
+
+// firstPageText returns the first page of a result, or the start of its content when it
+// has no pages.
+func firstPageText(result *ExtractionResult) string {
+	if len(result.Pages) > 0 {
+		return strings.TrimSpace(result.Pages[0].Content)
+	}
+	if structure := result.Metadata.PageStructure; structure != nil && len(structure.Boundaries) > 0 {
+		boundary := structure.Boundaries[0]
+		if boundary.ByteEnd <= uint64(len(result.Content)) && boundary.ByteStart <= boundary.ByteEnd {
+			return strings.TrimSpace(result.Content[boundary.ByteStart:boundary.ByteEnd])
+		}
+	}
+	content := result.Content
+	if len(content) > previewMaxBytes {
+		cut := previewMaxBytes
+		for cut > 0 && !utf8.RuneStart(content[cut]) {
+			cut--
+		}
+		content = content[:cut]
+	}
+	return strings.TrimSpace(content)
+}
+
+// documentTitle returns the title recorded in a document's metadata, if any.
+func documentTitle(m Metadata) string {
+	var title *string
+	if meta, ok := m.PdfMetadata(); ok {
+		title = meta.Title
+	} else if meta, ok := m.PptxMetadata(); ok {
+		title = meta.Title
+	} else if meta, ok := m.HTMLMetadata(); ok {
+		title = meta.Title
+	} else if meta, ok := m.RtfMetadata(); ok {
+		title = &meta.Title
+	} else if meta, ok := m.LegacyOfficeMetadata(); ok {
+		title = &meta.Title
+	} else if meta, ok := m.FictionBookMetadata(); ok {
+		title = &meta.Title
+	} else if meta, ok := m.DjvuMetadata(); ok {
+		title = &meta.Title
+	} else if meta, ok := m.XpsMetadata(); ok {
+		title = &meta.Title
+	} else if _, ok := m.EmailMetadata(); ok {
+		title = m.Subject
+	}
+	if title != nil && *title != "" {
+		return strings.TrimSpace(*title)
+	}
+	var additional string
+	if raw, ok := m.AdditionalFields()["title"]; ok && json.Unmarshal(raw, &additional) == nil {
+		return strings.TrimSpace(additional)
+	}
+	return ""
+}
+
+// imageThumbnail scales a PNG, JPEG or GIF picture down to fit previewThumbnailSize
+// and encodes it as PNG. It returns nil for pictures Go cannot decode.
+func imageThumbnail(data []byte) *PageImage {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	bounds := img.Bounds()
+	scale := math.Min(1, float64(previewThumbnailSize)/float64(max(bounds.Dx(), bounds.Dy())))
+	width, height := max(1, int(float64(bounds.Dx())*scale)), max(1, int(float64(bounds.Dy())*scale))
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			thumbnail.Set(x, y, img.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale)))
+		}
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, thumbnail); err != nil {
+		return nil
+	}
+	return &PageImage{PageNumber: 1, Width: width, Height: height, Format: RenderFormatPNG, Data: encoded.Bytes()}
+}
@@ -0,0 +1,94 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestPageWordsText(t *testing.T) {
+	words := []PageWord{
+		{Text: "Quarterly", Top: 10, Height: 12},
+		{Text: "report", Top: 11, Height: 12},
+		{Text: "Revenue", Top: 30, Height: 10},
+		{Text: "grew", Top: 30, Height: 10},
+	}
+	if got, want := pageWordsText(words), "Quarterly report\nRevenue grew"; got != want {
+		t.Fatalf("pageWordsText = %q, want %q", got, want)
+	}
+}
+
+func TestFirstPageText(t *testing.T) {
+	paged := &ExtractionResult{
+		Content: "one\n\ntwo",
+		Metadata: Metadata{PageStructure: &PageStructure{Boundaries: []PageBoundary{
+			{ByteStart: 0, ByteEnd: 3, PageNumber: 1}, {ByteStart: 5, ByteEnd: 8, PageNumber: 2},
+		}}},
+	}
+	if got := firstPageText(paged); got != "one" {
+		t.Fatalf("firstPageText = %q, want the first boundary", got)
+	}
+
+	long := &ExtractionResult{Content: strings.Repeat("é", previewMaxBytes)}
+	got := firstPageText(long)
+	if len(got) > previewMaxBytes || !strings.HasPrefix(long.Content, got) || !strings.HasSuffix(got, "é") {
+		t.Fatalf("content without pages was not cut on a character boundary: %d bytes", len(got))
+	}
+}
+
+func TestDocumentTitle(t *testing.T) {
+	title := "Annual Report"
+	pdf := Metadata{Format: FormatMetadata{Type: FormatPDF, Pdf: &PdfMetadata{Title: &title}}}
+	if got := documentTitle(pdf); got != title {
+		t.Fatalf("pdf title = %q", got)
+	}
+	subject := "Re: invoice"
+	email := Metadata{Subject: &subject, Format: FormatMetadata{Type: FormatEmail, Email: &EmailMetadata{}}}
+	if got := documentTitle(email); got != subject {
+		t.Fatalf("email title = %q", got)
+	}
+	raw, _ := json.Marshal(" Spec ")
+	docx := Metadata{Additional: map[string]json.RawMessage{"title": raw}}
+	if got := documentTitle(docx); got != "Spec" {
+		t.Fatalf("additional title = %q", got)
+	}
+}
+
+func TestImageThumbnail(t *testing.T) {
+	var encoded bytes.Buffer
+	png.Encode(&encoded, image.NewGray(image.Rect(0, 0, 1024, 512)))
+
+	thumbnail := imageThumbnail(encoded.Bytes())
+	if thumbnail == nil || thumbnail.Width != previewThumbnailSize || thumbnail.Height != previewThumbnailSize/2 {
+		t.Fatalf("unexpected thumbnail: %+v", thumbnail)
+	}
+	if config, err := png.DecodeConfig(bytes.NewReader(thumbnail.Data)); err != nil || config.Width != thumbnail.Width {
+		t.Fatalf("thumbnail is not the PNG it describes: %v", err)
+	}
+	if imageThumbnail([]byte("not an image")) != nil {
+		t.Fatal("expected no thumbnail for undecodable data")
+	}
+}
+
+func TestPreviewConfig(t *testing.T) {
+	enabled := true
+	config := &ExtractionConfig{
+		UseCache: &enabled,
+		OCR:      &OCRConfig{Backend: "tesseract"},
+		Chunking: &ChunkingConfig{},
+		Images:   &ImageExtractionConfig{ExtractImages: &enabled},
+	}
+	preview := previewConfig(config)
+	if preview.OCR != nil || preview.Chunking != nil || preview.Images != nil {
+		t.Fatalf("expensive steps kept: %+v", preview)
+	}
+	if preview.UseCache != &enabled || preview.Pages == nil || !*preview.Pages.ExtractPages {
+		t.Fatal("expected the cache setting kept and pages requested")
+	}
+	if config.OCR == nil {
+		t.Fatal("previewConfig modified its argument")
+	}
+}
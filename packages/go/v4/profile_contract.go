@@ -0,0 +1,445 @@
+package kreuzberg
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Clause labels reported in Clause.Label.
+const (
+	ClauseDefinitions           = "definitions"
+	ClauseTerm                  = "term"
+	ClausePayment               = "payment"
+	ClauseConfidentiality       = "confidentiality"
+	ClauseIntellectualProperty  = "intellectual_property"
+	ClauseWarranties            = "warranties"
+	ClauseIndemnification       = "indemnification"
+	ClauseLimitationOfLiability = "limitation_of_liability"
+	ClauseTermination           = "termination"
+	ClauseForceMajeure          = "force_majeure"
+	ClauseGoverningLaw          = "governing_law"
+	ClauseDisputeResolution     = "dispute_resolution"
+	ClauseAssignment            = "assignment"
+	ClauseNotices               = "notices"
+	ClauseEntireAgreement       = "entire_agreement"
+	ClauseOther                 = "other"
+)
+
+// Contract date kinds reported in ContractDate.Kind.
+const (
+	ContractDateEffective   = "effective"
+	ContractDateTermination = "termination"
+	ContractDateSignature   = "signature"
+)
+
+// ContractAnalysis is the structured result of the legal contract profile.
+type ContractAnalysis struct {
+	// Result is the underlying extraction result the analysis was derived from.
+	Result *ExtractionResult `json:"-"`
+
+	Title           string           `json:"title,omitempty"`
+	Parties         []ContractParty  `json:"parties,omitempty"`
+	Dates           []ContractDate   `json:"dates,omitempty"`
+	Sections        []Section        `json:"sections,omitempty"`
+	Clauses         []Clause         `json:"clauses,omitempty"`
+	SignatureBlocks []SignatureBlock `json:"signature_blocks,omitempty"`
+}
+
+// ContractParty is a party named in the contract preamble. Role is the defined term the
+// contract uses for the party, such as "Licensee", when present.
+type ContractParty struct {
+	Name      string `json:"name"`
+	Role      string `json:"role,omitempty"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// ContractDate is a date mentioned in the contract. Value is the ISO 8601 form when the
+// date could be parsed unambiguously.
+type ContractDate struct {
+	Text      string `json:"text"`
+	Value     string `json:"value,omitempty"`
+	Kind      string `json:"kind,omitempty"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// Clause is a headed provision of the contract with a topical label.
+type Clause struct {
+	Label     string `json:"label"`
+	Heading   string `json:"heading"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// SignatureBlock is a signature section with the signatories found in it.
+type SignatureBlock struct {
+	Signatories []Signatory `json:"signatories,omitempty"`
+	ByteStart   uint64      `json:"byte_start"`
+	ByteEnd     uint64      `json:"byte_end"`
+}
+
+// Signatory is a person signing the contract, as read from "Name:", "Title:", and
+// "Date:" lines of a signature block.
+type Signatory struct {
+	Name  string `json:"name,omitempty"`
+	Title string `json:"title,omitempty"`
+	Date  string `json:"date,omitempty"`
+}
+
+// LegalContractProfile extracts contracts and analyzes their parties, dates, clauses,
+// and signature blocks. Use it through Profiles.LegalContract.
+type LegalContractProfile struct{}
+
+// Config returns the extraction configuration used by the profile. Markdown output keeps
+// the heading structure clause detection relies on.
+func (LegalContractProfile) Config(opts ...ExtractionOption) *ExtractionConfig {
+	return profileConfig(NewExtractionConfig(
+		WithOutputFormat(string(OutputFormatMarkdown)),
+		WithSectionDetection(),
+	), opts...)
+}
+
+// AnalyzeFile extracts the contract at path and analyzes it. A nil config uses Config().
+func (p LegalContractProfile) AnalyzeFile(path string, config *ExtractionConfig) (*ContractAnalysis, error) {
+	if config == nil {
+		config = p.Config()
+	}
+	result, err := ExtractFileSync(path, config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Analyze(result), nil
+}
+
+// AnalyzeBytes extracts an in-memory contract and analyzes it. A nil config uses Config().
+func (p LegalContractProfile) AnalyzeBytes(data []byte, mimeType string, config *ExtractionConfig) (*ContractAnalysis, error) {
+	if config == nil {
+		config = p.Config()
+	}
+	result, err := ExtractBytesSync(data, mimeType, config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Analyze(result), nil
+}
+
+// Analyze derives a ContractAnalysis from an existing extraction result.
+func (LegalContractProfile) Analyze(result *ExtractionResult) *ContractAnalysis {
+	analysis := &ContractAnalysis{Result: result}
+	if result == nil {
+		return analysis
+	}
+	content := result.Content
+
+	analysis.Sections = result.Sections
+	if analysis.Sections == nil {
+		analysis.Sections = DetectSections(content, nil)
+	}
+	if pdf, ok := result.Metadata.PdfMetadata(); ok && pdf.Title != nil && *pdf.Title != "" {
+		analysis.Title = *pdf.Title
+	} else {
+		analysis.Title = contractTitle(content)
+	}
+	analysis.Parties = contractParties(content)
+	analysis.Clauses = contractClauses(content)
+	for _, section := range analysis.Sections {
+		if section.Label == SectionSignatureBlock {
+			analysis.SignatureBlocks = append(analysis.SignatureBlocks, signatureBlock(content, section))
+		}
+	}
+	analysis.Dates = contractDates(content, analysis.SignatureBlocks)
+	return analysis
+}
+
+// contractTitle returns the first heading or, failing that, the first non-blank line.
+func contractTitle(content string) string {
+	for _, line := range strings.Split(content, "\n") {
+		if _, text, ok := parseATXHeading(line); ok && text != "" {
+			return text
+		}
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+// clauseKeywords maps keywords found in clause headings to clause labels, checked in order.
+var clauseKeywords = []struct {
+	keyword string
+	label   string
+}{
+	{"definition", ClauseDefinitions},
+	{"interpretation", ClauseDefinitions},
+	{"confidential", ClauseConfidentiality},
+	{"non-disclosure", ClauseConfidentiality},
+	{"intellectual property", ClauseIntellectualProperty},
+	{"license", ClauseIntellectualProperty},
+	{"warrant", ClauseWarranties},
+	{"representation", ClauseWarranties},
+	{"indemn", ClauseIndemnification},
+	{"limitation of liability", ClauseLimitationOfLiability},
+	{"liability", ClauseLimitationOfLiability},
+	{"terminat", ClauseTermination},
+	{"force majeure", ClauseForceMajeure},
+	{"governing law", ClauseGoverningLaw},
+	{"applicable law", ClauseGoverningLaw},
+	{"jurisdiction", ClauseGoverningLaw},
+	{"dispute", ClauseDisputeResolution},
+	{"arbitration", ClauseDisputeResolution},
+	{"assignment", ClauseAssignment},
+	{"notice", ClauseNotices},
+	{"entire agreement", ClauseEntireAgreement},
+	{"payment", ClausePayment},
+	{"fees", ClausePayment},
+	{"compensation", ClausePayment},
+	{"term", ClauseTerm},
+	{"duration", ClauseTerm},
+}
+
+// clauseLabel returns the label of a clause heading.
+func clauseLabel(heading string) string {
+	title := normalizeSectionTitle(heading)
+	for _, entry := range clauseKeywords {
+		if strings.Contains(title, entry.keyword) {
+			return entry.label
+		}
+	}
+	return ClauseOther
+}
+
+// numberedClauseHeading matches plain-text clause headings such as "5. Termination" or
+// "Section 12 - Governing Law".
+var numberedClauseHeading = regexp.MustCompile(`^(?:(?i:article|section|clause)\s+)?\d+(?:\.\d+)*[.):]?\s*[-–—]?\s*\p{Lu}[^.]{0,80}$`)
+
+// contractClauses returns the headed clauses of content. Clauses start at Markdown
+// headings below the document title or at numbered heading lines, and run to the next one.
+func contractClauses(content string) []Clause {
+	type heading struct {
+		start int
+		text  string
+	}
+	var headings []heading
+	forEachLine(content, func(pos int, line string) {
+		line = strings.TrimSpace(line)
+		if level, text, ok := parseATXHeading(line); ok {
+			if level > 1 {
+				headings = append(headings, heading{start: pos, text: text})
+			}
+		} else if numberedClauseHeading.MatchString(line) {
+			headings = append(headings, heading{start: pos, text: line})
+		}
+	})
+
+	clauses := make([]Clause, 0, len(headings))
+	for i, h := range headings {
+		end := len(content)
+		if i+1 < len(headings) {
+			end = headings[i+1].start
+		}
+		end = h.start + len(strings.TrimRightFunc(content[h.start:end], unicode.IsSpace))
+		if sectionLabel(h.text) == SectionSignatureBlock {
+			continue
+		}
+		clauses = append(clauses, Clause{
+			Label:     clauseLabel(h.text),
+			Heading:   h.text,
+			ByteStart: uint64(h.start),
+			ByteEnd:   uint64(end),
+		})
+	}
+	return clauses
+}
+
+// partiesPattern matches the "between A and B" recital of a contract preamble.
+var partiesPattern = regexp.MustCompile(`(?is)\bbetween\s+(.+?)\s+and\s+(.+?)(?:\.\s|\.$|;|\n\n|$)`)
+
+// partyRolePattern matches a defined-term parenthetical such as (the "Licensee").
+var partyRolePattern = regexp.MustCompile(`\(\s*(?:the\s+)?["“']([^"”']+)["”']\s*\)`)
+
+// contractParties extracts the parties from the first "between ... and ..." recital.
+func contractParties(content string) []ContractParty {
+	match := partiesPattern.FindStringSubmatchIndex(content)
+	if match == nil {
+		return nil
+	}
+	var parties []ContractParty
+	for group := 1; group <= 2; group++ {
+		start, end := match[2*group], match[2*group+1]
+		if party, ok := contractParty(content, start, end); ok {
+			parties = append(parties, party)
+		}
+	}
+	return parties
+}
+
+// contractParty parses one side of the parties recital spanning [start, end) of content.
+// The name ends at the first comma or parenthesis; a quoted defined term becomes the role.
+func contractParty(content string, start, end int) (ContractParty, bool) {
+	text := content[start:end]
+	var role string
+	if m := partyRolePattern.FindStringSubmatch(text); m != nil {
+		role = strings.TrimSpace(m[1])
+	}
+	nameEnd := len(text)
+	if idx := strings.IndexAny(text, ",("); idx >= 0 {
+		nameEnd = idx
+	}
+	name := strings.TrimSpace(text[:nameEnd])
+	if name == "" {
+		return ContractParty{}, false
+	}
+	nameStart := start + strings.Index(text, name)
+	return ContractParty{
+		Name:      name,
+		Role:      role,
+		ByteStart: uint64(nameStart),
+		ByteEnd:   uint64(nameStart + len(name)),
+	}, true
+}
+
+// datePatterns match the date notations recognized in documents, paired with the layout
+// used to parse them. Numeric day/month dates are reported without a Value because their
+// field order is ambiguous.
+var datePatterns = []struct {
+	pattern *regexp.Regexp
+	layout  string
+}{
+	{regexp.MustCompile(`\b(?:January|February|March|April|May|June|July|August|September|October|November|December)\s+\d{1,2}(?:st|nd|rd|th)?,?\s+\d{4}\b`), "January 2 2006"},
+	{regexp.MustCompile(`\b\d{1,2}(?:st|nd|rd|th)?\s+(?:of\s+)?(?:January|February|March|April|May|June|July|August|September|October|November|December),?\s+\d{4}\b`), "2 January 2006"},
+	{regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`), "2006-01-02"},
+	{regexp.MustCompile(`\b\d{1,2}[/.]\d{1,2}[/.]\d{4}\b`), ""},
+}
+
+// ordinalSuffix matches day ordinals and filler removed before parsing a date.
+var ordinalSuffix = regexp.MustCompile(`(\d)(?:st|nd|rd|th)\b|,|\bof\s+`)
+
+// parseDocumentDate parses text with layout after removing ordinals and commas.
+func parseDocumentDate(text, layout string) (time.Time, bool) {
+	if layout == "" {
+		return time.Time{}, false
+	}
+	cleaned := strings.Join(strings.Fields(ordinalSuffix.ReplaceAllString(text, "$1")), " ")
+	parsed, err := time.Parse(layout, cleaned)
+	return parsed, err == nil
+}
+
+// firstDate finds the earliest date in text. value is the ISO 8601 form when the date
+// could be parsed unambiguously.
+func firstDate(text string) (start, end int, value string, ok bool) {
+	start = -1
+	layout := ""
+	for _, dp := range datePatterns {
+		if loc := dp.pattern.FindStringIndex(text); loc != nil && (start < 0 || loc[0] < start) {
+			start, end, layout = loc[0], loc[1], dp.layout
+		}
+	}
+	if start < 0 {
+		return 0, 0, "", false
+	}
+	if parsed, parsedOK := parseDocumentDate(text[start:end], layout); parsedOK {
+		value = parsed.Format("2006-01-02")
+	}
+	return start, end, value, true
+}
+
+// contractDates extracts dates from content, classifying them by the words preceding them
+// and by whether they appear in a signature block.
+func contractDates(content string, blocks []SignatureBlock) []ContractDate {
+	var dates []ContractDate
+	taken := make([]bool, len(content)+1)
+	for _, dp := range datePatterns {
+		for _, loc := range dp.pattern.FindAllStringIndex(content, -1) {
+			if taken[loc[0]] || taken[loc[1]-1] {
+				continue
+			}
+			for i := loc[0]; i < loc[1]; i++ {
+				taken[i] = true
+			}
+			date := ContractDate{
+				Text:      content[loc[0]:loc[1]],
+				Kind:      contractDateKind(content, loc[0], blocks),
+				ByteStart: uint64(loc[0]),
+				ByteEnd:   uint64(loc[1]),
+			}
+			if parsed, ok := parseDocumentDate(date.Text, dp.layout); ok {
+				date.Value = parsed.Format("2006-01-02")
+			}
+			dates = append(dates, date)
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].ByteStart < dates[j].ByteStart })
+	return dates
+}
+
+// contractDateContext is the number of bytes before a date inspected for its kind.
+const contractDateContext = 60
+
+func contractDateKind(content string, start int, blocks []SignatureBlock) string {
+	for _, block := range blocks {
+		if uint64(start) >= block.ByteStart && uint64(start) < block.ByteEnd {
+			return ContractDateSignature
+		}
+	}
+	context := strings.ToLower(content[max(0, start-contractDateContext):start])
+	switch {
+	case strings.Contains(context, "terminat"), strings.Contains(context, "expir"), strings.Contains(context, "until"):
+		return ContractDateTermination
+	case strings.Contains(context, "effective"), strings.Contains(context, "commenc"), strings.Contains(context, "dated"),
+		strings.Contains(context, "entered into"), strings.Contains(context, "made on"):
+		return ContractDateEffective
+	}
+	return ""
+}
+
+// signatureField matches "Name: ...", "By: ...", "Title: ..." and "Date: ..." lines.
+var signatureField = regexp.MustCompile(`(?im)^\s*(by|name|title|date)\s*:\s*(.*?)\s*$`)
+
+// signatureBlock collects the signatories of a signature section. A field line that
+// repeats a field of the current signatory starts a new signatory. "By:" supplies the
+// name unless a "Name:" line follows.
+func signatureBlock(content string, section Section) SignatureBlock {
+	block := SignatureBlock{ByteStart: section.ByteStart, ByteEnd: section.ByteEnd}
+	text := content[section.ByteStart:section.ByteEnd]
+
+	var current Signatory
+	seen := map[string]bool{}
+	flush := func() {
+		if current != (Signatory{}) {
+			block.Signatories = append(block.Signatories, current)
+		}
+		current = Signatory{}
+		seen = map[string]bool{}
+	}
+	for _, m := range signatureField.FindAllStringSubmatch(text, -1) {
+		field := strings.ToLower(m[1])
+		value := strings.Trim(m[2], "_ \t")
+		if seen[field] {
+			flush()
+		}
+		seen[field] = true
+		if value == "" {
+			continue
+		}
+		switch field {
+		case "by":
+			if current.Name == "" {
+				current.Name = value
+			}
+		case "name":
+			current.Name = value
+		case "title":
+			current.Title = value
+		case "date":
+			current.Date = value
+		}
+	}
+	flush()
+	return block
+}
@@ -0,0 +1,119 @@
+package kreuzberg
+
+import (
+	"testing"
+)
+
+const contractTestDoc = `# Software License Agreement
+
+This Agreement is entered into as of January 5th, 2024 between Acme Corp, a Delaware corporation ("Licensor") and Globex LLC (the "Licensee").
+
+## 1. Definitions
+
+Terms used herein.
+
+## 2. License Grant
+
+Licensor grants a license.
+
+## 3. Termination
+
+This Agreement terminates on 31 December 2026 unless renewed.
+
+## 4. Governing Law
+
+The laws of Delaware apply.
+
+## Signatures
+
+By: ________
+Name: Jane Doe
+Title: CEO
+Date: 2024-01-05
+
+Name: John Roe
+Title: Managing Member
+`
+
+func TestLegalContractAnalyze(t *testing.T) {
+	analysis := Profiles.LegalContract.Analyze(&ExtractionResult{Content: contractTestDoc})
+
+	if analysis.Title != "Software License Agreement" {
+		t.Errorf("unexpected title: %q", analysis.Title)
+	}
+
+	if len(analysis.Parties) != 2 {
+		t.Fatalf("expected 2 parties, got %+v", analysis.Parties)
+	}
+	wantParties := []ContractParty{{Name: "Acme Corp", Role: "Licensor"}, {Name: "Globex LLC", Role: "Licensee"}}
+	for i, party := range analysis.Parties {
+		if party.Name != wantParties[i].Name || party.Role != wantParties[i].Role {
+			t.Errorf("party %d = %+v, want %+v", i, party, wantParties[i])
+		}
+		if contractTestDoc[party.ByteStart:party.ByteEnd] != party.Name {
+			t.Errorf("party %d offsets do not match name", i)
+		}
+	}
+
+	wantClauses := []string{ClauseDefinitions, ClauseIntellectualProperty, ClauseTermination, ClauseGoverningLaw}
+	if len(analysis.Clauses) != len(wantClauses) {
+		t.Fatalf("expected %d clauses, got %+v", len(wantClauses), analysis.Clauses)
+	}
+	for i, clause := range analysis.Clauses {
+		if clause.Label != wantClauses[i] {
+			t.Errorf("clause %q labeled %q, want %q", clause.Heading, clause.Label, wantClauses[i])
+		}
+	}
+
+	wantDates := []ContractDate{
+		{Value: "2024-01-05", Kind: ContractDateEffective},
+		{Value: "2026-12-31", Kind: ContractDateTermination},
+		{Value: "2024-01-05", Kind: ContractDateSignature},
+	}
+	if len(analysis.Dates) != len(wantDates) {
+		t.Fatalf("expected %d dates, got %+v", len(wantDates), analysis.Dates)
+	}
+	for i, date := range analysis.Dates {
+		if date.Value != wantDates[i].Value || date.Kind != wantDates[i].Kind {
+			t.Errorf("date %q = %+v, want %+v", date.Text, date, wantDates[i])
+		}
+	}
+
+	if len(analysis.SignatureBlocks) != 1 {
+		t.Fatalf("expected 1 signature block, got %d", len(analysis.SignatureBlocks))
+	}
+	signatories := analysis.SignatureBlocks[0].Signatories
+	want := []Signatory{{Name: "Jane Doe", Title: "CEO", Date: "2024-01-05"}, {Name: "John Roe", Title: "Managing Member"}}
+	if len(signatories) != len(want) {
+		t.Fatalf("expected %d signatories, got %+v", len(want), signatories)
+	}
+	for i := range want {
+		if signatories[i] != want[i] {
+			t.Errorf("signatory %d = %+v, want %+v", i, signatories[i], want[i])
+		}
+	}
+}
+
+func TestLegalContractConfig(t *testing.T) {
+	config := Profiles.LegalContract.Config(WithUseCache(false))
+	if config.OutputFormat != string(OutputFormatMarkdown) || config.SectionDetection == nil {
+		t.Fatalf("unexpected profile config: %+v", config)
+	}
+	if config.UseCache == nil || *config.UseCache {
+		t.Error("caller options should be applied")
+	}
+}
+
+func TestNumberedClauseHeading(t *testing.T) {
+	for line, want := range map[string]bool{
+		"5. Termination":             true,
+		"Section 12 - Governing Law": true,
+		"12.3 Fees":                  true,
+		"2024 was a good year.":      false,
+		"3 apples were delivered":    false,
+	} {
+		if got := numberedClauseHeading.MatchString(line); got != want {
+			t.Errorf("numberedClauseHeading(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
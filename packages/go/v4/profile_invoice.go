@@ -0,0 +1,549 @@
+package kreuzberg
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Confidence levels assigned to invoice fields.
+const (
+	invoiceConfidenceLabeled   = 0.9
+	invoiceConfidenceVerified  = 0.95
+	invoiceConfidenceInferred  = 0.6
+	invoiceConfidenceHeuristic = 0.4
+)
+
+// InvoiceAnalysis is the structured result of the invoice profile. Fields that could not
+// be found are nil.
+type InvoiceAnalysis struct {
+	// Result is the underlying extraction result the analysis was derived from.
+	Result *ExtractionResult `json:"-"`
+
+	Vendor        *InvoiceField     `json:"vendor,omitempty"`
+	InvoiceNumber *InvoiceField     `json:"invoice_number,omitempty"`
+	InvoiceDate   *InvoiceField     `json:"invoice_date,omitempty"`
+	DueDate       *InvoiceField     `json:"due_date,omitempty"`
+	Currency      *InvoiceField     `json:"currency,omitempty"`
+	LineItems     []InvoiceLineItem `json:"line_items,omitempty"`
+	Subtotal      *InvoiceField     `json:"subtotal,omitempty"`
+	Tax           *InvoiceField     `json:"tax,omitempty"`
+	Total         *InvoiceField     `json:"total,omitempty"`
+}
+
+// InvoiceField is a single extracted invoice value. Text is the value as written in
+// Content; Value is its normalized form (ISO 8601 dates, decimal amounts, ISO 4217
+// currency codes). Confidence ranges from 0 to 1.
+type InvoiceField struct {
+	Text       string  `json:"text"`
+	Value      string  `json:"value,omitempty"`
+	Confidence float64 `json:"confidence"`
+	ByteStart  uint64  `json:"byte_start"`
+	ByteEnd    uint64  `json:"byte_end"`
+}
+
+// Float returns the normalized Value of an amount field as a number.
+func (f *InvoiceField) Float() (float64, bool) {
+	if f == nil || f.Value == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(f.Value, 64)
+	return value, err == nil
+}
+
+// InvoiceLineItem is a row of the invoice's line item table.
+type InvoiceLineItem struct {
+	Description string   `json:"description"`
+	Quantity    *float64 `json:"quantity,omitempty"`
+	UnitPrice   *float64 `json:"unit_price,omitempty"`
+	Amount      *float64 `json:"amount,omitempty"`
+	Confidence  float64  `json:"confidence"`
+}
+
+// InvoiceProfile extracts invoices and receipts into structured fields. Use it through
+// Profiles.Invoice.
+type InvoiceProfile struct{}
+
+// Config returns the extraction configuration used by the profile. Markdown output keeps
+// line item tables in Content for documents without table detection.
+func (InvoiceProfile) Config(opts ...ExtractionOption) *ExtractionConfig {
+	return profileConfig(NewExtractionConfig(
+		WithOutputFormat(string(OutputFormatMarkdown)),
+	), opts...)
+}
+
+// AnalyzeFile extracts the invoice at path and analyzes it. A nil config uses Config().
+func (p InvoiceProfile) AnalyzeFile(path string, config *ExtractionConfig) (*InvoiceAnalysis, error) {
+	if config == nil {
+		config = p.Config()
+	}
+	result, err := ExtractFileSync(path, config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Analyze(result), nil
+}
+
+// AnalyzeBytes extracts an in-memory invoice and analyzes it. A nil config uses Config().
+func (p InvoiceProfile) AnalyzeBytes(data []byte, mimeType string, config *ExtractionConfig) (*InvoiceAnalysis, error) {
+	if config == nil {
+		config = p.Config()
+	}
+	result, err := ExtractBytesSync(data, mimeType, config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Analyze(result), nil
+}
+
+// Analyze derives an InvoiceAnalysis from an existing extraction result.
+func (InvoiceProfile) Analyze(result *ExtractionResult) *InvoiceAnalysis {
+	analysis := &InvoiceAnalysis{Result: result}
+	if result == nil {
+		return analysis
+	}
+	content := result.Content
+
+	analysis.InvoiceNumber = labeledInvoiceField(content, invoiceNumberPattern)
+	analysis.InvoiceDate = labeledInvoiceDate(content, invoiceDatePattern)
+	analysis.DueDate = labeledInvoiceDate(content, dueDatePattern)
+	analysis.Vendor = invoiceVendor(content)
+	analysis.Subtotal, analysis.Tax, analysis.Total = invoiceTotals(content)
+	analysis.Currency = invoiceCurrency(content)
+	analysis.LineItems = invoiceLineItems(result)
+	verifyInvoiceTotals(analysis)
+	return analysis
+}
+
+var (
+	invoiceNumberPattern = regexp.MustCompile(`(?i)\b(?:invoice|inv|receipt|bill)\s*(?:no\.?|number|num|#)\s*[:#]?\s*([A-Z0-9][A-Z0-9\-/]*\d[A-Z0-9\-/]*)`)
+	invoiceDatePattern   = regexp.MustCompile(`(?i)\b(?:invoice\s+date|date\s+of\s+issue|issue\s+date|issued|date)\s*:?\s*`)
+	dueDatePattern       = regexp.MustCompile(`(?i)\b(?:due\s+date|payment\s+due|due\s+by|due)\s*:?\s*`)
+	vendorPattern        = regexp.MustCompile(`(?im)^\s*(?:from|vendor|seller|supplier|sold\s+by|billed\s+by)\s*:\s*(.+?)\s*$`)
+	invoiceTitlePattern  = regexp.MustCompile(`(?i)\b(?:invoice|receipt|bill|statement|tax\s+invoice)\b`)
+)
+
+// labeledInvoiceField returns the first capture group of pattern as a labeled field.
+func labeledInvoiceField(content string, pattern *regexp.Regexp) *InvoiceField {
+	loc := pattern.FindStringSubmatchIndex(content)
+	if loc == nil {
+		return nil
+	}
+	text := content[loc[2]:loc[3]]
+	return &InvoiceField{
+		Text:       text,
+		Value:      text,
+		Confidence: invoiceConfidenceLabeled,
+		ByteStart:  uint64(loc[2]),
+		ByteEnd:    uint64(loc[3]),
+	}
+}
+
+// labeledInvoiceDate returns the first date that directly follows a label matched by
+// pattern. Dates whose field order is ambiguous get a lower confidence. A bare "Date"
+// label preceded by "Due" belongs to the due date and is skipped for the invoice date.
+func labeledInvoiceDate(content string, pattern *regexp.Regexp) *InvoiceField {
+	for _, loc := range pattern.FindAllStringIndex(content, -1) {
+		if pattern == invoiceDatePattern && strings.HasSuffix(strings.ToLower(strings.TrimSpace(content[:loc[0]])), "due") {
+			continue
+		}
+		lineEnd := strings.IndexByte(content[loc[1]:], '\n')
+		if lineEnd == -1 {
+			lineEnd = len(content) - loc[1]
+		}
+		rest := content[loc[1] : loc[1]+lineEnd]
+		start, end, value, ok := firstDate(rest)
+		if !ok || strings.TrimSpace(rest[:start]) != "" {
+			continue
+		}
+		confidence := invoiceConfidenceLabeled
+		if value == "" {
+			confidence = invoiceConfidenceInferred
+		}
+		return &InvoiceField{
+			Text:       rest[start:end],
+			Value:      value,
+			Confidence: confidence,
+			ByteStart:  uint64(loc[1] + start),
+			ByteEnd:    uint64(loc[1] + end),
+		}
+	}
+	return nil
+}
+
+// invoiceVendor returns the labeled vendor or, failing that, the first line of the
+// document that is not an invoice title, date, or amount.
+func invoiceVendor(content string) *InvoiceField {
+	if field := labeledInvoiceField(content, vendorPattern); field != nil {
+		return field
+	}
+	var vendor *InvoiceField
+	forEachLine(content, func(pos int, line string) {
+		text := strings.TrimSpace(line)
+		if _, heading, ok := parseATXHeading(line); ok {
+			text = heading
+		}
+		if vendor == nil && text != "" && !invoiceTitlePattern.MatchString(text) && !strings.ContainsAny(text, "|:") && !amountPattern.MatchString(text) {
+			start := pos + strings.Index(line, text)
+			vendor = &InvoiceField{
+				Text:       text,
+				Value:      text,
+				Confidence: invoiceConfidenceHeuristic,
+				ByteStart:  uint64(start),
+				ByteEnd:    uint64(start + len(text)),
+			}
+		}
+	})
+	return vendor
+}
+
+// amountPattern matches monetary amounts with an optional currency symbol or code before
+// or after the number. Percentages are rejected by the caller.
+var amountPattern = regexp.MustCompile(`(?:([$€£¥₹])\s?|\b(USD|EUR|GBP|JPY|CHF|CAD|AUD|INR|CNY)\s?)?(-?\d{1,3}(?:[,.' ]\d{3})+(?:[.,]\d{1,2})?|-?\d+(?:[.,]\d{1,2})?)(?:\s?(USD|EUR|GBP|JPY|CHF|CAD|AUD|INR|CNY)\b|\s?([€£]))?`)
+
+// parsedAmount is an amount found in text.
+type parsedAmount struct {
+	start, end int
+	value      float64
+	currency   string
+}
+
+// findAmounts returns the monetary amounts in text, skipping percentages.
+func findAmounts(text string) []parsedAmount {
+	var amounts []parsedAmount
+	for _, m := range amountPattern.FindAllStringSubmatchIndex(text, -1) {
+		if m[1] < len(text) && text[m[1]] == '%' {
+			continue
+		}
+		value, ok := parseAmountNumber(text[m[6]:m[7]])
+		if !ok {
+			continue
+		}
+		amount := parsedAmount{start: m[0], end: m[1], value: value}
+		for group := 1; group <= 5; group++ {
+			if group == 3 || m[2*group] < 0 {
+				continue
+			}
+			code := text[m[2*group]:m[2*group+1]]
+			if symbol, ok := currencySymbols[code]; ok {
+				code = symbol
+			}
+			amount.currency = code
+		}
+		amounts = append(amounts, amount)
+	}
+	return amounts
+}
+
+// parseAmountNumber parses a number written with either "," or "." as the decimal
+// separator. When both appear the last one is the decimal separator; a lone separator
+// followed by one or two digits is treated as decimal, otherwise as a thousands separator.
+func parseAmountNumber(text string) (float64, bool) {
+	text = strings.NewReplacer("'", "", " ", "").Replace(text)
+	lastComma := strings.LastIndexByte(text, ',')
+	lastDot := strings.LastIndexByte(text, '.')
+	decimal := byte(0)
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		decimal = text[max(lastComma, lastDot)]
+	case lastComma >= 0 && strings.Count(text, ",") == 1 && len(text)-lastComma-1 <= 2:
+		decimal = ','
+	case lastDot >= 0 && strings.Count(text, ".") == 1 && len(text)-lastDot-1 <= 2:
+		decimal = '.'
+	}
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == decimal:
+			b.WriteByte('.')
+		case c == ',' || c == '.':
+		default:
+			b.WriteByte(c)
+		}
+	}
+	value, err := strconv.ParseFloat(b.String(), 64)
+	return value, err == nil
+}
+
+// formatAmount renders an amount as a plain decimal string.
+func formatAmount(value float64) string {
+	return strconv.FormatFloat(value, 'f', 2, 64)
+}
+
+// invoiceTotals scans lines for subtotal, tax, and total labels and takes the last amount
+// on each matching line. "Amount due" style totals take precedence over plain totals;
+// otherwise later lines win over earlier ones.
+func invoiceTotals(content string) (subtotal, tax, total *InvoiceField) {
+	explicitTotal := false
+	forEachLine(content, func(lineStart int, line string) {
+		amounts := findAmounts(line)
+		if len(amounts) == 0 {
+			return
+		}
+		last := amounts[len(amounts)-1]
+		field := &InvoiceField{
+			Text:       line[last.start:last.end],
+			Value:      formatAmount(last.value),
+			Confidence: invoiceConfidenceLabeled,
+			ByteStart:  uint64(lineStart + last.start),
+			ByteEnd:    uint64(lineStart + last.end),
+		}
+
+		lower := strings.ToLower(line)
+		switch {
+		case strings.Contains(lower, "subtotal"), strings.Contains(lower, "sub-total"), strings.Contains(lower, "sub total"):
+			subtotal = field
+		case containsWord(lower, "tax"), containsWord(lower, "vat"), containsWord(lower, "gst"):
+			if !containsWord(lower, "total") || strings.Contains(lower, "total tax") || strings.Contains(lower, "tax total") {
+				tax = field
+			} else if !explicitTotal {
+				total = field
+			}
+		case strings.Contains(lower, "grand total"), strings.Contains(lower, "amount due"),
+			strings.Contains(lower, "balance due"), strings.Contains(lower, "total due"):
+			total = field
+			explicitTotal = true
+		case containsWord(lower, "total") && !explicitTotal:
+			total = field
+		}
+	})
+	return subtotal, tax, total
+}
+
+// containsWord reports whether word appears in text delimited by non-letters.
+func containsWord(text, word string) bool {
+	for idx := 0; ; {
+		found := strings.Index(text[idx:], word)
+		if found < 0 {
+			return false
+		}
+		start := idx + found
+		end := start + len(word)
+		before := start == 0 || !isASCIILetter(text[start-1])
+		after := end == len(text) || !isASCIILetter(text[end])
+		if before && after {
+			return true
+		}
+		idx = end
+	}
+}
+
+func isASCIILetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// invoiceCurrency returns the most frequent currency among the amounts in content.
+func invoiceCurrency(content string) *InvoiceField {
+	counts := map[string]int{}
+	first := map[string]parsedAmount{}
+	total := 0
+	for _, amount := range findAmounts(content) {
+		if amount.currency == "" {
+			continue
+		}
+		if _, ok := first[amount.currency]; !ok {
+			first[amount.currency] = amount
+		}
+		counts[amount.currency]++
+		total++
+	}
+	best := ""
+	for code, count := range counts {
+		if best == "" || count > counts[best] || (count == counts[best] && first[code].start < first[best].start) {
+			best = code
+		}
+	}
+	if best == "" {
+		return nil
+	}
+	amount := first[best]
+	return &InvoiceField{
+		Text:       content[amount.start:amount.end],
+		Value:      best,
+		Confidence: float64(counts[best]) / float64(total),
+		ByteStart:  uint64(amount.start),
+		ByteEnd:    uint64(amount.end),
+	}
+}
+
+// Column header keywords used to locate line item columns, checked in order.
+var (
+	descriptionHeaders = []string{"description", "item", "product", "service", "details", "article"}
+	quantityHeaders    = []string{"qty", "quantity", "units", "hours", "hrs"}
+	unitPriceHeaders   = []string{"unit price", "unit cost", "rate", "price"}
+	amountHeaders      = []string{"line total", "amount", "total", "subtotal"}
+)
+
+// headerColumn returns the index of the first header cell containing one of keywords.
+func headerColumn(header []string, keywords []string, exclude ...int) int {
+	for _, keyword := range keywords {
+		for i, cell := range header {
+			excluded := false
+			for _, e := range exclude {
+				excluded = excluded || e == i
+			}
+			if !excluded && strings.Contains(strings.ToLower(cell), keyword) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// invoiceTables returns the tables of result as cell grids, parsing the Markdown tables
+// of Content when no tables were detected.
+func invoiceTables(result *ExtractionResult) [][][]string {
+	var tables [][][]string
+	for _, table := range result.Tables {
+		tables = append(tables, table.Cells)
+	}
+	if len(tables) > 0 {
+		return tables
+	}
+	for _, r := range tableRanges(result.Content) {
+		tables = append(tables, markdownTableCells(result.Content[r[0]:r[1]]))
+	}
+	return tables
+}
+
+// markdownTableCells splits a Markdown pipe table into rows of trimmed cells, dropping
+// the delimiter row.
+func markdownTableCells(table string) [][]string {
+	var rows [][]string
+	for _, line := range strings.Split(table, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSuffix(strings.TrimPrefix(line, "|"), "|")
+		cells := strings.Split(line, "|")
+		delimiter := true
+		for i := range cells {
+			cells[i] = strings.TrimSpace(cells[i])
+			if strings.Trim(cells[i], ":-") != "" {
+				delimiter = false
+			}
+		}
+		if !delimiter {
+			rows = append(rows, cells)
+		}
+	}
+	return rows
+}
+
+// invoiceLineItems reads line items from the first table with a description column and
+// an amount or price column.
+func invoiceLineItems(result *ExtractionResult) []InvoiceLineItem {
+	for _, rows := range invoiceTables(result) {
+		if len(rows) < 2 {
+			continue
+		}
+		header := rows[0]
+		desc := headerColumn(header, descriptionHeaders)
+		if desc < 0 {
+			continue
+		}
+		qty := headerColumn(header, quantityHeaders, desc)
+		unit := headerColumn(header, unitPriceHeaders, desc, qty)
+		amount := headerColumn(header, amountHeaders, desc, qty, unit)
+		if amount < 0 && unit < 0 {
+			continue
+		}
+
+		var items []InvoiceLineItem
+		for _, row := range rows[1:] {
+			item, ok := invoiceLineItem(row, desc, qty, unit, amount)
+			if ok {
+				items = append(items, item)
+			}
+		}
+		if len(items) > 0 {
+			return items
+		}
+	}
+	return nil
+}
+
+// invoiceLineItem converts a table row into a line item. Rows without a description and
+// totals rows are skipped. Confidence is highest when quantity × unit price = amount.
+func invoiceLineItem(row []string, desc, qty, unit, amount int) (InvoiceLineItem, bool) {
+	cell := func(i int) string {
+		if i < 0 || i >= len(row) {
+			return ""
+		}
+		return row[i]
+	}
+	number := func(i int) *float64 {
+		amounts := findAmounts(cell(i))
+		if len(amounts) == 0 {
+			return nil
+		}
+		return &amounts[len(amounts)-1].value
+	}
+
+	description := cell(desc)
+	lower := strings.ToLower(description)
+	if description == "" || containsWord(lower, "total") || containsWord(lower, "subtotal") || containsWord(lower, "tax") {
+		return InvoiceLineItem{}, false
+	}
+	item := InvoiceLineItem{
+		Description: description,
+		Quantity:    number(qty),
+		UnitPrice:   number(unit),
+		Amount:      number(amount),
+		Confidence:  invoiceConfidenceHeuristic,
+	}
+	if item.Amount == nil && item.Quantity != nil && item.UnitPrice != nil {
+		computed := *item.Quantity * *item.UnitPrice
+		item.Amount = &computed
+	}
+	switch {
+	case item.Amount != nil && item.Quantity != nil && item.UnitPrice != nil && amountsEqual(*item.Quantity**item.UnitPrice, *item.Amount):
+		item.Confidence = invoiceConfidenceVerified
+	case item.Amount != nil:
+		item.Confidence = invoiceConfidenceInferred
+	}
+	return item, true
+}
+
+// amountsEqual compares amounts to the cent.
+func amountsEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// verifyInvoiceTotals raises the confidence of totals that add up and lowers it for
+// totals that contradict each other.
+func verifyInvoiceTotals(analysis *InvoiceAnalysis) {
+	subtotal, hasSubtotal := analysis.Subtotal.Float()
+	tax, hasTax := analysis.Tax.Float()
+	total, hasTotal := analysis.Total.Float()
+
+	if hasSubtotal && hasTotal {
+		if !hasTax {
+			tax = 0
+		}
+		if amountsEqual(subtotal+tax, total) {
+			analysis.Subtotal.Confidence = invoiceConfidenceVerified
+			analysis.Total.Confidence = invoiceConfidenceVerified
+			if hasTax {
+				analysis.Tax.Confidence = invoiceConfidenceVerified
+			}
+		} else {
+			analysis.Total.Confidence = invoiceConfidenceInferred
+		}
+	}
+
+	if len(analysis.LineItems) == 0 {
+		return
+	}
+	sum := 0.0
+	for _, item := range analysis.LineItems {
+		if item.Amount == nil {
+			return
+		}
+		sum += *item.Amount
+	}
+	if hasSubtotal && amountsEqual(sum, subtotal) || !hasSubtotal && hasTotal && amountsEqual(sum, total) {
+		for i := range analysis.LineItems {
+			analysis.LineItems[i].Confidence = max(analysis.LineItems[i].Confidence, invoiceConfidenceLabeled)
+		}
+	}
+}
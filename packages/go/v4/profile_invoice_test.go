@@ -0,0 +1,95 @@
+package kreuzberg
+
+import (
+	"testing"
+)
+
+const invoiceTestDoc = `# INVOICE
+
+Northwind Traders
+12 Harbour Road
+
+Invoice No: INV-2024-0042
+Invoice Date: March 3, 2024
+Due Date: 2024-04-02
+
+| Description | Qty | Unit Price | Amount |
+|---|---|---|---|
+| Widget | 2 | $10.00 | $20.00 |
+| Gadget | 1 | $5.50 | $5.50 |
+
+Subtotal: $25.50
+Tax (10%): $2.55
+Total Due: $28.05
+`
+
+func TestInvoiceAnalyze(t *testing.T) {
+	analysis := Profiles.Invoice.Analyze(&ExtractionResult{Content: invoiceTestDoc})
+
+	fields := map[string]*InvoiceField{
+		"Northwind Traders": analysis.Vendor,
+		"INV-2024-0042":     analysis.InvoiceNumber,
+		"2024-03-03":        analysis.InvoiceDate,
+		"2024-04-02":        analysis.DueDate,
+		"USD":               analysis.Currency,
+		"25.50":             analysis.Subtotal,
+		"2.55":              analysis.Tax,
+		"28.05":             analysis.Total,
+	}
+	for want, field := range fields {
+		if field == nil {
+			t.Errorf("field with value %q not found", want)
+			continue
+		}
+		if field.Value != want {
+			t.Errorf("field value = %q, want %q", field.Value, want)
+		}
+		if field.Confidence <= 0 || field.Confidence > 1 {
+			t.Errorf("field %q has confidence %v", want, field.Confidence)
+		}
+		if invoiceTestDoc[field.ByteStart:field.ByteEnd] != field.Text {
+			t.Errorf("field %q offsets do not match text %q", want, field.Text)
+		}
+	}
+	if analysis.Total.Confidence != invoiceConfidenceVerified {
+		t.Errorf("consistent totals should be verified, got %v", analysis.Total.Confidence)
+	}
+
+	if len(analysis.LineItems) != 2 {
+		t.Fatalf("expected 2 line items, got %+v", analysis.LineItems)
+	}
+	widget := analysis.LineItems[0]
+	if widget.Description != "Widget" || *widget.Quantity != 2 || *widget.UnitPrice != 10 || *widget.Amount != 20 {
+		t.Errorf("unexpected line item: %+v", widget)
+	}
+	if widget.Confidence != invoiceConfidenceVerified {
+		t.Errorf("line item confidence = %v", widget.Confidence)
+	}
+}
+
+func TestInvoiceLineItemsFromTables(t *testing.T) {
+	result := &ExtractionResult{Tables: []Table{{Cells: [][]string{
+		{"Item", "Hours", "Rate", "Line Total"},
+		{"Consulting", "3", "100,00 €", "300,00 €"},
+		{"Total", "", "", "300,00 €"},
+	}}}}
+	items := invoiceLineItems(result)
+	if len(items) != 1 || items[0].Description != "Consulting" || *items[0].Amount != 300 {
+		t.Fatalf("unexpected line items: %+v", items)
+	}
+}
+
+func TestParseAmountNumber(t *testing.T) {
+	for text, want := range map[string]float64{
+		"1,234.56": 1234.56,
+		"1.234,56": 1234.56,
+		"1,234":    1234,
+		"12,5":     12.5,
+		"1 000":    1000,
+		"-3.99":    -3.99,
+	} {
+		if got, ok := parseAmountNumber(text); !ok || got != want {
+			t.Errorf("parseAmountNumber(%q) = %v, %v; want %v", text, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package kreuzberg
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Caption kinds reported in Caption.Kind.
+const (
+	CaptionFigure = "figure"
+	CaptionTable  = "table"
+)
+
+// PaperAnalysis is the structured result of the scientific paper profile.
+type PaperAnalysis struct {
+	// Result is the underlying extraction result the analysis was derived from.
+	Result *ExtractionResult `json:"-"`
+
+	Title        string           `json:"title,omitempty"`
+	Authors      []string         `json:"authors,omitempty"`
+	Affiliations []string         `json:"affiliations,omitempty"`
+	Abstract     string           `json:"abstract,omitempty"`
+	Sections     []PaperSection   `json:"sections,omitempty"`
+	Captions     []Caption        `json:"captions,omitempty"`
+	References   []PaperReference `json:"references,omitempty"`
+}
+
+// PaperSection is a headed section of a paper, such as "2 Related Work".
+type PaperSection struct {
+	Heading   string `json:"heading"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// Caption is a figure or table caption. Label is the caption's own label, for example
+// "Figure 3" or "Table 2".
+type Caption struct {
+	Kind      string `json:"kind"`
+	Label     string `json:"label"`
+	Text      string `json:"text"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// PaperReference is an entry of the bibliography. Raw holds the entry as written; the
+// remaining fields are parsed from it on a best-effort basis.
+type PaperReference struct {
+	Raw       string   `json:"raw"`
+	Authors   []string `json:"authors,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Year      string   `json:"year,omitempty"`
+	DOI       string   `json:"doi,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	ByteStart uint64   `json:"byte_start"`
+	ByteEnd   uint64   `json:"byte_end"`
+}
+
+// ScientificPaperProfile extracts academic papers into front matter, sections, captions,
+// and references. Use it through Profiles.ScientificPaper.
+type ScientificPaperProfile struct{}
+
+// Config returns the extraction configuration used by the profile. Markdown output keeps
+// the heading structure sections are read from.
+func (ScientificPaperProfile) Config(opts ...ExtractionOption) *ExtractionConfig {
+	return profileConfig(NewExtractionConfig(
+		WithOutputFormat(string(OutputFormatMarkdown)),
+	), opts...)
+}
+
+// AnalyzeFile extracts the paper at path and analyzes it. A nil config uses Config().
+func (p ScientificPaperProfile) AnalyzeFile(path string, config *ExtractionConfig) (*PaperAnalysis, error) {
+	if config == nil {
+		config = p.Config()
+	}
+	result, err := ExtractFileSync(path, config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Analyze(result), nil
+}
+
+// AnalyzeBytes extracts an in-memory paper and analyzes it. A nil config uses Config().
+func (p ScientificPaperProfile) AnalyzeBytes(data []byte, mimeType string, config *ExtractionConfig) (*PaperAnalysis, error) {
+	if config == nil {
+		config = p.Config()
+	}
+	result, err := ExtractBytesSync(data, mimeType, config)
+	if err != nil {
+		return nil, err
+	}
+	return p.Analyze(result), nil
+}
+
+// Analyze derives a PaperAnalysis from an existing extraction result.
+func (ScientificPaperProfile) Analyze(result *ExtractionResult) *PaperAnalysis {
+	analysis := &PaperAnalysis{Result: result}
+	if result == nil {
+		return analysis
+	}
+	content := result.Content
+
+	analysis.Sections = paperSections(content)
+	analysis.Abstract = paperAbstract(content)
+	analysis.Captions = paperCaptions(content)
+	analysis.References = paperReferences(content, analysis.Sections)
+
+	titleEnd := 0
+	analysis.Title, titleEnd = paperTitle(content)
+	if pdf, ok := result.Metadata.PdfMetadata(); ok {
+		if pdf.Title != nil && strings.TrimSpace(*pdf.Title) != "" {
+			analysis.Title = strings.TrimSpace(*pdf.Title)
+		}
+		analysis.Authors = pdf.Authors
+	}
+	authors, affiliations := paperFrontMatter(content, titleEnd, analysis.Sections)
+	if len(analysis.Authors) == 0 {
+		analysis.Authors = authors
+	}
+	analysis.Affiliations = affiliations
+	return analysis
+}
+
+// paperTitle returns the first heading or non-blank line and the offset just past it.
+func paperTitle(content string) (string, int) {
+	title, end := "", 0
+	forEachLine(content, func(pos int, line string) {
+		if title != "" {
+			return
+		}
+		text := strings.TrimSpace(line)
+		if _, heading, ok := parseATXHeading(line); ok {
+			text = heading
+		}
+		if text != "" {
+			title, end = text, pos+len(line)
+		}
+	})
+	return title, end
+}
+
+// paperSectionNames are unnumbered section headings common in papers.
+var paperSectionNames = map[string]bool{
+	"abstract": true, "introduction": true, "related work": true, "background": true,
+	"methods": true, "method": true, "methodology": true, "materials and methods": true,
+	"results": true, "discussion": true, "conclusion": true, "conclusions": true,
+	"acknowledgments": true, "acknowledgements": true, "references": true,
+	"bibliography": true, "works cited": true, "literature cited": true, "appendix": true,
+}
+
+// numberedPaperHeading matches numbered headings such as "3 Results" or "2.1 Data".
+var numberedPaperHeading = regexp.MustCompile(`^(?:\d+(?:\.\d+)*\.?|[IVX]+\.)\s+\p{Lu}[^.]{0,80}$`)
+
+// paperSections returns the headed sections of content below the title. A section runs
+// to the next heading.
+func paperSections(content string) []PaperSection {
+	var sections []PaperSection
+	first := true
+	forEachLine(content, func(pos int, line string) {
+		trimmed := strings.TrimSpace(line)
+		heading := ""
+		if level, text, ok := parseATXHeading(line); ok {
+			if first && level == 1 {
+				first = false
+				return
+			}
+			heading = text
+		} else if numberedPaperHeading.MatchString(trimmed) || paperSectionNames[normalizeSectionTitle(trimmed)] {
+			heading = trimmed
+		}
+		if trimmed != "" {
+			first = false
+		}
+		if heading == "" {
+			return
+		}
+		if n := len(sections); n > 0 {
+			sections[n-1].ByteEnd = uint64(pos)
+		}
+		sections = append(sections, PaperSection{Heading: heading, ByteStart: uint64(pos)})
+	})
+	for i := range sections {
+		end := len(content)
+		if i+1 < len(sections) {
+			end = int(sections[i].ByteEnd)
+		}
+		start := int(sections[i].ByteStart)
+		sections[i].ByteEnd = uint64(start + len(strings.TrimRightFunc(content[start:end], unicode.IsSpace)))
+	}
+	return sections
+}
+
+// inlineAbstract matches an abstract introduced on the same line, e.g. "Abstract— We ...".
+var inlineAbstract = regexp.MustCompile(`(?im)^\s*(?:\*\*)?abstract(?:\*\*)?\s*[-—–.:]\s*(\S.*)$`)
+
+// paperAbstract returns the abstract text, either the body of an "Abstract" section or
+// a paragraph introduced by "Abstract".
+func paperAbstract(content string) string {
+	for _, section := range DetectSections(content, NewSectionDetectionConfig(WithSectionLabels(SectionAbstract))) {
+		text := content[section.ByteStart:section.ByteEnd]
+		if idx := strings.IndexByte(text, '\n'); idx >= 0 {
+			if body := strings.TrimSpace(text[idx+1:]); body != "" {
+				return body
+			}
+		}
+	}
+	if m := inlineAbstract.FindStringSubmatchIndex(content); m != nil {
+		end := strings.Index(content[m[2]:], "\n\n")
+		if end == -1 {
+			end = len(content) - m[2]
+		}
+		return strings.TrimSpace(content[m[2] : m[2]+end])
+	}
+	return ""
+}
+
+// affiliationKeywords identify affiliation lines in the front matter.
+var affiliationKeywords = []string{
+	"university", "institute", "department", "dept.", "laboratory", "lab", "college",
+	"school", "faculty", "research", "center", "centre", "hospital", "inc.", "ltd", "gmbh",
+}
+
+// footnoteMarkers are the characters used to tie authors to affiliations.
+const footnoteMarkers = "*†‡§¶∗0123456789⁰¹²³⁴⁵⁶⁷⁸⁹,^"
+
+// paperFrontMatter reads authors and affiliations from the lines between the title and
+// the first section. Affiliation lines contain institution keywords or e-mail addresses;
+// the first other line lists the authors.
+func paperFrontMatter(content string, titleEnd int, sections []PaperSection) ([]string, []string) {
+	end := len(content)
+	if len(sections) > 0 {
+		end = int(sections[0].ByteStart)
+	}
+	if abstract := inlineAbstract.FindStringIndex(content[titleEnd:]); abstract != nil {
+		end = min(end, titleEnd+abstract[0])
+	}
+	if titleEnd >= end {
+		return nil, nil
+	}
+
+	var authors, affiliations []string
+	forEachLine(content[titleEnd:end], func(_ int, line string) {
+		text := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), footnoteMarkers))
+		if text == "" {
+			return
+		}
+		lower := strings.ToLower(text)
+		for _, keyword := range affiliationKeywords {
+			if containsWord(lower, strings.TrimSuffix(keyword, ".")) {
+				affiliations = append(affiliations, text)
+				return
+			}
+		}
+		if strings.Contains(text, "@") || authors != nil {
+			return
+		}
+		for _, name := range splitAuthorList(text) {
+			if name = strings.Trim(name, footnoteMarkers+" "); name != "" {
+				authors = append(authors, name)
+			}
+		}
+	})
+	return authors, affiliations
+}
+
+// splitAuthorList splits "A. Smith, B. Jones and C. Lee" or "Smith, J., & Jones, B."
+// into names. A part consisting only of initials is joined to the preceding surname.
+func splitAuthorList(text string) []string {
+	text = strings.NewReplacer(" & ", ", ", ", and ", ", ", " and ", ", ", ";", ",").Replace(text)
+	var names []string
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if n := len(names); n > 0 && isInitials(part) && !strings.Contains(names[n-1], " ") {
+			names[n-1] += ", " + part
+			continue
+		}
+		names = append(names, part)
+	}
+	return names
+}
+
+// isInitials reports whether text consists of initials such as "J." or "J.-P. K.".
+func isInitials(text string) bool {
+	for _, word := range strings.Fields(text) {
+		for _, initial := range strings.Split(strings.TrimSuffix(word, "."), "-") {
+			initial = strings.TrimSuffix(initial, ".")
+			runes := []rune(initial)
+			if len(runes) != 1 || !unicode.IsUpper(runes[0]) {
+				return false
+			}
+		}
+	}
+	return text != ""
+}
+
+// captionPattern matches figure and table captions at the start of a line.
+var captionPattern = regexp.MustCompile(`(?im)^[ \t]*(?:\*\*)?((fig(?:ure)?|table)\.?\s+(?:\d+[a-z]?|[IVX]+))(?:\*\*)?\s*[:.|—–-]\s*(?:\*\*)?\s*(\S.*)$`)
+
+// paperCaptions returns figure and table captions. A caption extends to the end of its
+// paragraph.
+func paperCaptions(content string) []Caption {
+	var captions []Caption
+	for _, m := range captionPattern.FindAllStringSubmatchIndex(content, -1) {
+		end := strings.Index(content[m[6]:], "\n\n")
+		if end == -1 {
+			end = len(content) - m[6]
+		}
+		end += m[6]
+		kind := CaptionFigure
+		if strings.EqualFold(content[m[4]:m[5]], "table") {
+			kind = CaptionTable
+		}
+		captions = append(captions, Caption{
+			Kind:      kind,
+			Label:     content[m[2]:m[3]],
+			Text:      strings.Join(strings.Fields(content[m[6]:end]), " "),
+			ByteStart: uint64(m[2]),
+			ByteEnd:   uint64(m[6] + len(strings.TrimRightFunc(content[m[6]:end], unicode.IsSpace))),
+		})
+	}
+	return captions
+}
+
+// referenceHeadings name the bibliography section.
+var referenceHeadings = map[string]bool{
+	"references": true, "bibliography": true, "works cited": true, "literature cited": true,
+}
+
+var (
+	referenceMarker = regexp.MustCompile(`^\s*(?:\[\d+\]|\d+\.\s|[-*]\s)`)
+	doiPattern      = regexp.MustCompile(`\b10\.\d{4,9}/[^\s"<>]+`)
+	urlPattern      = regexp.MustCompile(`https?://[^\s"<>]+`)
+	yearPattern     = regexp.MustCompile(`\((\d{4})[a-z]?\)|\b((?:19|20)\d{2})[a-z]?\b`)
+)
+
+// paperReferences parses the entries of the references section. Entries start with a
+// marker such as "[1]" or "1." or, when no markers are used, are separated by blank lines.
+func paperReferences(content string, sections []PaperSection) []PaperReference {
+	var section *PaperSection
+	for i := range sections {
+		if referenceHeadings[normalizeSectionTitle(sections[i].Heading)] {
+			section = &sections[i]
+		}
+	}
+	if section == nil {
+		return nil
+	}
+	start, end := int(section.ByteStart), int(section.ByteEnd)
+	if idx := strings.IndexByte(content[start:end], '\n'); idx >= 0 {
+		start += idx + 1
+	} else {
+		return nil
+	}
+
+	body := content[start:end]
+	marked := referenceMarker.MatchString(strings.TrimLeft(body, "\n"))
+	var spans [][2]int
+	forEachLine(body, func(pos int, line string) {
+		blank := strings.TrimSpace(line) == ""
+		switch {
+		case blank && !marked:
+			spans = append(spans, [2]int{-1, -1})
+		case blank:
+		case marked && referenceMarker.MatchString(line), len(spans) == 0, spans[len(spans)-1][0] < 0:
+			spans = append(spans, [2]int{pos, pos + len(line)})
+		default:
+			spans[len(spans)-1][1] = pos + len(line)
+		}
+	})
+
+	var references []PaperReference
+	for _, span := range spans {
+		if span[0] < 0 {
+			continue
+		}
+		raw := strings.TrimSpace(body[span[0]:span[1]])
+		if raw == "" {
+			continue
+		}
+		rawStart := start + span[0] + strings.Index(body[span[0]:span[1]], raw)
+		reference := parseReference(raw)
+		reference.ByteStart = uint64(rawStart)
+		reference.ByteEnd = uint64(rawStart + len(raw))
+		references = append(references, reference)
+	}
+	return references
+}
+
+// parseReference splits a bibliography entry into authors, year, title, DOI, and URL.
+// Author-year entries ("Smith, J. (2020). Title.") take the authors before the year;
+// otherwise the authors are the first sentence and the title the second.
+func parseReference(raw string) PaperReference {
+	reference := PaperReference{Raw: raw}
+	text := strings.Join(strings.Fields(referenceMarker.ReplaceAllString(raw, "")), " ")
+
+	if m := doiPattern.FindString(text); m != "" {
+		reference.DOI = strings.TrimRight(m, ".,;")
+	}
+	if m := urlPattern.FindString(text); m != "" {
+		reference.URL = strings.TrimRight(m, ".,;")
+	}
+
+	authorsPart, rest := "", text
+	if m := yearPattern.FindStringSubmatchIndex(text); m != nil {
+		if m[2] >= 0 {
+			reference.Year = text[m[2]:m[3]]
+			authorsPart = text[:m[0]]
+			rest = strings.TrimLeft(text[m[1]:], ". ")
+		} else {
+			reference.Year = text[m[4]:m[5]]
+		}
+	}
+	if authorsPart == "" {
+		authorsPart, rest = splitSentence(text)
+	}
+	reference.Authors = splitAuthorList(strings.TrimRight(strings.TrimSpace(authorsPart), ".,"))
+	title, _ := splitSentence(rest)
+	reference.Title = strings.Trim(strings.TrimSpace(title), `."“”`)
+	return reference
+}
+
+// splitSentence splits text after the first period that ends a sentence, ignoring the
+// periods of initials and "et al.".
+func splitSentence(text string) (string, string) {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '.' || (i+1 < len(text) && text[i+1] != ' ') {
+			continue
+		}
+		wordStart := strings.LastIndexAny(text[:i], " .-") + 1
+		word := text[wordStart:i]
+		if len([]rune(word)) <= 1 || word == "al" {
+			continue
+		}
+		return text[:i+1], strings.TrimSpace(text[i+1:])
+	}
+	return text, ""
+}
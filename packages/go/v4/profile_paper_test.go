@@ -0,0 +1,92 @@
+package kreuzberg
+
+import (
+	"reflect"
+	"testing"
+)
+
+const paperTestDoc = `# Learning to Extract Documents
+
+Ada Lovelace¹, Alan Turing² and Grace Hopper¹
+¹ Department of Computer Science, University of Somewhere
+² Institute for Advanced Study
+
+## Abstract
+
+We present a method for extracting documents.
+
+## 1 Introduction
+
+Documents are everywhere (see Figure 1).
+
+Figure 1: Overview of the pipeline.
+The pipeline has three stages.
+
+Table 2. Results on the benchmark.
+
+## References
+
+[1] Smith, J., & Jones, B. (2020). Parsing PDFs at scale. Journal of Documents, 3(2), 1–10. https://doi.org/10.1234/jd.2020.1
+[2] A. Brown, C. Davis, and E. Evans. Tables in the wild. In Proceedings of DocConf, 2019.
+`
+
+func TestScientificPaperAnalyze(t *testing.T) {
+	analysis := Profiles.ScientificPaper.Analyze(&ExtractionResult{Content: paperTestDoc})
+
+	if analysis.Title != "Learning to Extract Documents" {
+		t.Errorf("unexpected title: %q", analysis.Title)
+	}
+	if want := []string{"Ada Lovelace", "Alan Turing", "Grace Hopper"}; !reflect.DeepEqual(analysis.Authors, want) {
+		t.Errorf("authors = %q, want %q", analysis.Authors, want)
+	}
+	if len(analysis.Affiliations) != 2 {
+		t.Errorf("unexpected affiliations: %q", analysis.Affiliations)
+	}
+	if analysis.Abstract != "We present a method for extracting documents." {
+		t.Errorf("unexpected abstract: %q", analysis.Abstract)
+	}
+
+	var headings []string
+	for _, section := range analysis.Sections {
+		headings = append(headings, section.Heading)
+	}
+	if want := []string{"Abstract", "1 Introduction", "References"}; !reflect.DeepEqual(headings, want) {
+		t.Errorf("sections = %q, want %q", headings, want)
+	}
+
+	if len(analysis.Captions) != 2 {
+		t.Fatalf("expected 2 captions, got %+v", analysis.Captions)
+	}
+	figure := analysis.Captions[0]
+	if figure.Kind != CaptionFigure || figure.Label != "Figure 1" || figure.Text != "Overview of the pipeline. The pipeline has three stages." {
+		t.Errorf("unexpected figure caption: %+v", figure)
+	}
+	if table := analysis.Captions[1]; table.Kind != CaptionTable || table.Label != "Table 2" {
+		t.Errorf("unexpected table caption: %+v", table)
+	}
+
+	if len(analysis.References) != 2 {
+		t.Fatalf("expected 2 references, got %+v", analysis.References)
+	}
+	apa := analysis.References[0]
+	if !reflect.DeepEqual(apa.Authors, []string{"Smith, J.", "Jones, B"}) || apa.Year != "2020" ||
+		apa.Title != "Parsing PDFs at scale" || apa.DOI != "10.1234/jd.2020.1" {
+		t.Errorf("unexpected author-year reference: %+v", apa)
+	}
+	numbered := analysis.References[1]
+	if !reflect.DeepEqual(numbered.Authors, []string{"A. Brown", "C. Davis", "E. Evans"}) || numbered.Year != "2019" ||
+		numbered.Title != "Tables in the wild" {
+		t.Errorf("unexpected reference: %+v", numbered)
+	}
+	if paperTestDoc[numbered.ByteStart:numbered.ByteEnd] != numbered.Raw {
+		t.Error("reference offsets do not match raw text")
+	}
+}
+
+func TestPaperReferencesWithoutMarkers(t *testing.T) {
+	content := "References\n\nDoe, J. (2018). First paper.\n\nRoe, R. (2021). Second\npaper title.\n"
+	references := paperReferences(content, paperSections(content))
+	if len(references) != 2 || references[1].Title != "Second paper title" {
+		t.Fatalf("unexpected references: %+v", references)
+	}
+}
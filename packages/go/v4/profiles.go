@@ -0,0 +1,23 @@
+package kreuzberg
+
+// ProfileSet groups the built-in extraction profiles. Profiles bundle a tuned
+// ExtractionConfig with domain-specific analysis of the extraction result.
+type ProfileSet struct {
+	// LegalContract analyzes contracts and agreements.
+	LegalContract LegalContractProfile
+	// Invoice extracts invoices and receipts into structured fields.
+	Invoice InvoiceProfile
+	// ScientificPaper extracts front matter, captions, and references from papers.
+	ScientificPaper ScientificPaperProfile
+}
+
+// Profiles exposes the built-in extraction profiles, e.g. Profiles.LegalContract.
+var Profiles ProfileSet
+
+// profileConfig applies opts on top of base. The caller's options take precedence.
+func profileConfig(base *ExtractionConfig, opts ...ExtractionOption) *ExtractionConfig {
+	for _, opt := range opts {
+		opt(base)
+	}
+	return base
+}
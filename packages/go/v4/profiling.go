@@ -0,0 +1,131 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"context"
+	"io"
+	"runtime/pprof"
+	"unsafe"
+)
+
+// Profile labels set on the goroutine of an extraction when ExtractionConfig.ProfileLabels
+// is set, so CPU profiles can be filtered with `go tool pprof -tagfocus`.
+const (
+	// ProfileLabelFile is the path of the file extracted, absent for in-memory documents
+	// and batches.
+	ProfileLabelFile = "kreuzberg_file"
+	// ProfileLabelFormat is the MIME type of the document, once known.
+	ProfileLabelFormat = "kreuzberg_format"
+	// ProfileLabelStage is ProfileStageNative or ProfileStageBinding.
+	ProfileLabelStage = "kreuzberg_stage"
+)
+
+// Values of ProfileLabelStage.
+const (
+	// ProfileStageNative covers the calls into the core library and the decoding of their
+	// results. In Go CPU profiles the core's own frames appear as a single cgo call; see
+	// StartNativeProfile to resolve them.
+	ProfileStageNative = "native"
+	// ProfileStageBinding covers the stages the binding runs in Go on results.
+	ProfileStageBinding = "binding"
+)
+
+// NativeProfileFormat selects how StopNativeProfile encodes a native CPU profile.
+type NativeProfileFormat int
+
+const (
+	// NativeProfileFolded writes folded stacks, one "thread;outer;...;inner count" line per
+	// sampled stack, as read by flame graph and speedscope tools.
+	NativeProfileFolded NativeProfileFormat = C.PROFILE_FORMAT_FOLDED
+	// NativeProfileFlamegraph writes an SVG flame graph.
+	NativeProfileFlamegraph NativeProfileFormat = C.PROFILE_FORMAT_FLAMEGRAPH
+)
+
+// profileScope tracks the profile labels of an extraction. A nil scope, returned when
+// labels are disabled, ignores every call.
+type profileScope struct {
+	labels []string
+}
+
+// startProfile labels the calling goroutine with labels, key-value pairs, when config
+// enables profile labels.
+func startProfile(config *ExtractionConfig, labels ...string) *profileScope {
+	if config == nil || config.ProfileLabels == nil || !*config.ProfileLabels {
+		return nil
+	}
+	scope := &profileScope{labels: labels}
+	scope.apply()
+	return scope
+}
+
+// set sets label key to value on the goroutine. Empty values are ignored.
+func (s *profileScope) set(key, value string) {
+	if s == nil || value == "" {
+		return
+	}
+	for i := 0; i < len(s.labels); i += 2 {
+		if s.labels[i] == key {
+			s.labels[i+1] = value
+			s.apply()
+			return
+		}
+	}
+	s.labels = append(s.labels, key, value)
+	s.apply()
+}
+
+func (s *profileScope) apply() {
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), pprof.Labels(s.labels...)))
+}
+
+// end removes the profile labels from the goroutine, along with any its caller had set.
+func (s *profileScope) end() {
+	if s != nil {
+		pprof.SetGoroutineLabels(context.Background())
+	}
+}
+
+// StartNativeProfile starts sampling the CPU time of the process from the core library at
+// frequency Hz, resolving the core's Rust frames that Go CPU profiles show as a single
+// cgo call. It requires a core library built with the `profiling` feature on a Unix
+// system.
+//
+// The native profiler takes over SIGPROF until StopNativeProfile, which hands it back:
+// do not run it alongside pprof.StartCPUProfile.
+func StartNativeProfile(frequency int) error {
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	if C.kreuzberg_profiling_start(C.int32_t(frequency)) != 0 {
+		return lastError()
+	}
+	return nil
+}
+
+// StopNativeProfile stops the profile started with StartNativeProfile and writes it to w
+// in format.
+func StopNativeProfile(w io.Writer, format NativeProfileFormat) error {
+	profile, err := stopNativeProfile(format)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(profile)
+	return err
+}
+
+func stopNativeProfile(format NativeProfileFormat) ([]byte, error) {
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	var length C.uintptr_t
+	ptr := C.kreuzberg_profiling_stop(C.int32_t(format), &length)
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_bytes(ptr, length)
+	return C.GoBytes(unsafe.Pointer(ptr), C.int(length)), nil
+}
@@ -0,0 +1,46 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+// goroutineLabels returns the goroutine profile of the process, which lists the labels of
+// each goroutine.
+func goroutineLabels(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestProfileScope(t *testing.T) {
+	if scope := startProfile(NewExtractionConfig()); scope != nil {
+		t.Fatal("labels should be disabled by default")
+	}
+	var disabled *profileScope
+	disabled.set(ProfileLabelStage, ProfileStageBinding)
+	disabled.end()
+
+	scope := startProfile(NewExtractionConfig(WithProfileLabels(true)), ProfileLabelFile, "report.pdf", ProfileLabelStage, ProfileStageNative)
+	scope.set(ProfileLabelFormat, "application/pdf")
+	scope.set(ProfileLabelStage, ProfileStageBinding)
+	profile := goroutineLabels(t)
+	for _, label := range []string{`"kreuzberg_file":"report.pdf"`, `"kreuzberg_format":"application/pdf"`, `"kreuzberg_stage":"binding"`} {
+		if !strings.Contains(profile, label) {
+			t.Errorf("goroutine profile is missing %s", label)
+		}
+	}
+	if strings.Contains(profile, `"kreuzberg_stage":"native"`) {
+		t.Error("stage label was not replaced")
+	}
+
+	scope.end()
+	if strings.Contains(goroutineLabels(t), "kreuzberg_file") {
+		t.Error("labels should be removed when the extraction ends")
+	}
+}
@@ -0,0 +1,307 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultQuarantineMaxFailures is the failure count that quarantines a file when
+// QuarantineConfig.MaxFailures is unset.
+const defaultQuarantineMaxFailures = 3
+
+// quarantinedErrorType is the ErrorMetadata.ErrorType of results for skipped files.
+const quarantinedErrorType = "Quarantined"
+
+// timeoutErrorType is the ErrorMetadata.ErrorType of results for documents abandoned after
+// QuarantineConfig.TimeoutMs.
+const timeoutErrorType = "Timeout"
+
+// QuarantineEntry tracks the failed extractions of one file. The failure count restarts
+// when the file's size or modification time changes or when it is extracted successfully.
+type QuarantineEntry struct {
+	Path          string     `json:"path"`
+	Size          int64      `json:"size"`
+	ModTime       time.Time  `json:"mod_time"`
+	Failures      int        `json:"failures"`
+	LastError     string     `json:"last_error,omitempty"`
+	QuarantinedAt *time.Time `json:"quarantined_at,omitempty"`
+	// Attempting is when an extraction of the file started that has not finished yet.
+	Attempting *time.Time `json:"attempting,omitempty"`
+}
+
+// Quarantined reports whether the file is skipped by batch extraction.
+func (e *QuarantineEntry) Quarantined() bool {
+	return e.QuarantinedAt != nil
+}
+
+// QuarantineManifest is the persisted failure history written to
+// QuarantineConfig.ManifestPath, keyed by absolute file path.
+type QuarantineManifest struct {
+	Entries map[string]*QuarantineEntry `json:"entries"`
+}
+
+// quarantineMu serializes manifest updates within the process.
+var quarantineMu sync.Mutex
+
+// quarantineActive holds the manifest keys of the documents this process is extracting,
+// whose attempts are not left over from a crash. It is guarded by quarantineMu.
+var quarantineActive = map[string]bool{}
+
+// LoadQuarantineManifest reads the manifest at path. A missing file yields an empty
+// manifest.
+func LoadQuarantineManifest(path string) (*QuarantineManifest, error) {
+	manifest := &QuarantineManifest{Entries: make(map[string]*QuarantineEntry)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read quarantine manifest", err, ErrorCodeIo, nil)
+	}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode quarantine manifest", err, ErrorCodeValidation, nil)
+	}
+	if manifest.Entries == nil {
+		manifest.Entries = make(map[string]*QuarantineEntry)
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest to path, replacing it atomically.
+func (m *QuarantineManifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return newSerializationErrorWithContext("failed to encode quarantine manifest", err, ErrorCodeValidation, nil)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return newIOErrorWithContext("failed to write quarantine manifest", err, ErrorCodeIo, nil)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return newIOErrorWithContext("failed to write quarantine manifest", err, ErrorCodeIo, nil)
+	}
+	if err := tmp.Close(); err != nil {
+		return newIOErrorWithContext("failed to write quarantine manifest", err, ErrorCodeIo, nil)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return newIOErrorWithContext("failed to write quarantine manifest", err, ErrorCodeIo, nil)
+	}
+	return nil
+}
+
+// Quarantined returns the quarantined entries sorted by path.
+func (m *QuarantineManifest) Quarantined() []QuarantineEntry {
+	var entries []QuarantineEntry
+	for _, entry := range m.Entries {
+		if entry.Quarantined() {
+			entries = append(entries, *entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// Release forgets the failure history of the file at path, so the next batch extracts it
+// again. It reports whether the file had an entry.
+func (m *QuarantineManifest) Release(path string) bool {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+	_, ok := m.Entries[key]
+	delete(m.Entries, key)
+	return ok
+}
+
+func validateQuarantineConfig(cfg *QuarantineConfig) error {
+	if cfg.ManifestPath == "" {
+		return newValidationErrorWithContext("quarantine manifest_path is required", nil, ErrorCodeValidation, nil)
+	}
+	if cfg.MaxFailures != nil && *cfg.MaxFailures < 1 {
+		return newValidationErrorWithContext("quarantine max_failures must be at least 1", nil, ErrorCodeValidation, nil)
+	}
+	if cfg.TimeoutMs != nil && *cfg.TimeoutMs <= 0 {
+		return newValidationErrorWithContext(fmt.Sprintf("quarantine timeout must be positive, got %d", *cfg.TimeoutMs), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// extractQuarantined runs extract on the paths that are not quarantined, one document at
+// a time, and records the outcome of each in the manifest. Quarantined paths get an error
+// result instead.
+//
+// Before each document an attempt is written to the manifest and cleared once the
+// document finishes, so a document that crashes or hangs the process is counted as
+// failed by the next run that finds its attempt left over. A document running longer
+// than the timeout is abandoned and counted as failed; since the native library stays
+// busy with it, the remaining documents are reported as skipped without counting a
+// failure. The manifest is locked only while it is updated, not during extraction, and
+// is meant to be used by one process at a time.
+func extractQuarantined(paths []string, cfg *QuarantineConfig, extract func([]string) ([]*ExtractionResult, error)) ([]*ExtractionResult, error) {
+	maxFailures := defaultQuarantineMaxFailures
+	if cfg.MaxFailures != nil {
+		maxFailures = *cfg.MaxFailures
+	}
+	var timeout time.Duration
+	if cfg.TimeoutMs != nil {
+		timeout = time.Duration(*cfg.TimeoutMs) * time.Millisecond
+	}
+
+	err := updateQuarantineManifest(cfg.ManifestPath, func(manifest *QuarantineManifest) {
+		for key, entry := range manifest.Entries {
+			if entry.Attempting != nil && !quarantineActive[key] {
+				entry.Attempting = nil
+				entry.fail("extraction did not finish: the process crashed or was stopped", maxFailures)
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*ExtractionResult, len(paths))
+	for i, path := range paths {
+		var entry *QuarantineEntry
+		err := updateQuarantineManifest(cfg.ManifestPath, func(manifest *QuarantineManifest) {
+			entry = manifest.entry(path)
+			if entry.Quarantined() {
+				return
+			}
+			now := time.Now().UTC()
+			entry.Attempting = &now
+			manifest.Entries[entry.Path] = entry
+			quarantineActive[entry.Path] = true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if entry.Quarantined() {
+			results[i] = quarantinedResult(entry)
+			continue
+		}
+
+		result, timedOut, extractErr := extractWithTimeout(path, timeout, extract)
+		message := ""
+		switch {
+		case timedOut:
+			message = fmt.Sprintf("extraction timed out after %s", timeout)
+			result = failedResult(timeoutErrorType, message)
+		case extractErr != nil:
+			message = extractErr.Error()
+		case result == nil:
+			message = "no result"
+		case result.Metadata.Error != nil:
+			message = result.Metadata.Error.Message
+		}
+		err = updateQuarantineManifest(cfg.ManifestPath, func(manifest *QuarantineManifest) {
+			delete(quarantineActive, entry.Path)
+			current := manifest.entry(path)
+			current.Attempting = nil
+			if message == "" {
+				delete(manifest.Entries, current.Path)
+				return
+			}
+			current.fail(message, maxFailures)
+			manifest.Entries[current.Path] = current
+		})
+		if extractErr != nil {
+			return nil, extractErr
+		}
+		if err != nil {
+			return nil, err
+		}
+		results[i] = result
+		if timedOut {
+			for j := i + 1; j < len(paths); j++ {
+				results[j] = failedResult(skippedErrorType, fmt.Sprintf("skipped %s: the extraction of %s timed out and still holds the native library", paths[j], path))
+			}
+			break
+		}
+	}
+	return results, nil
+}
+
+// extractWithTimeout extracts path on its own, giving up after timeout unless it is zero.
+// An abandoned extraction keeps running in the background.
+func extractWithTimeout(path string, timeout time.Duration, extract func([]string) ([]*ExtractionResult, error)) (*ExtractionResult, bool, error) {
+	type outcome struct {
+		result *ExtractionResult
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		results, err := extract([]string{path})
+		var result *ExtractionResult
+		if len(results) > 0 {
+			result = results[0]
+		}
+		done <- outcome{result, err}
+	}()
+	if timeout <= 0 {
+		o := <-done
+		return o.result, false, o.err
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case o := <-done:
+		return o.result, false, o.err
+	case <-timer.C:
+		return nil, true, nil
+	}
+}
+
+// updateQuarantineManifest applies update to the manifest at path and saves it, holding
+// quarantineMu throughout.
+func updateQuarantineManifest(path string, update func(*QuarantineManifest)) error {
+	quarantineMu.Lock()
+	defer quarantineMu.Unlock()
+	manifest, err := LoadQuarantineManifest(path)
+	if err != nil {
+		return err
+	}
+	update(manifest)
+	return manifest.Save(path)
+}
+
+// fail counts a failed extraction, quarantining the file at maxFailures.
+func (e *QuarantineEntry) fail(message string, maxFailures int) {
+	e.Failures++
+	e.LastError = message
+	if e.Failures >= maxFailures && e.QuarantinedAt == nil {
+		now := time.Now().UTC()
+		e.QuarantinedAt = &now
+	}
+}
+
+// entry returns the manifest entry for path, starting a fresh one when the file is new or
+// has changed since its failures were recorded.
+func (m *QuarantineManifest) entry(path string) *QuarantineEntry {
+	key, err := filepath.Abs(path)
+	if err != nil {
+		key = path
+	}
+	fresh := &QuarantineEntry{Path: key}
+	info, statErr := os.Stat(path)
+	if statErr == nil {
+		fresh.Size = info.Size()
+		fresh.ModTime = info.ModTime().UTC()
+	}
+	entry, ok := m.Entries[key]
+	if !ok || (statErr == nil && (entry.Size != fresh.Size || !entry.ModTime.Equal(fresh.ModTime))) {
+		return fresh
+	}
+	return entry
+}
+
+func quarantinedResult(entry *QuarantineEntry) *ExtractionResult {
+	return failedResult(quarantinedErrorType, fmt.Sprintf("quarantined after %d failed extractions: %s", entry.Failures, entry.LastError))
+}
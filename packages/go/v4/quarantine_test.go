@@ -0,0 +1,197 @@
+package kreuzberg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestExtractQuarantinedSkipsRepeatedFailures(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.txt")
+	poison := filepath.Join(dir, "poison.txt")
+	for _, path := range []string{good, poison} {
+		if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := NewQuarantineConfig(filepath.Join(dir, "quarantine.json"), WithMaxFailures(2))
+
+	var extracted [][]string
+	extract := func(paths []string) ([]*ExtractionResult, error) {
+		extracted = append(extracted, paths)
+		results := make([]*ExtractionResult, len(paths))
+		for i, path := range paths {
+			results[i] = &ExtractionResult{Content: "ok"}
+			if path == poison {
+				results[i] = &ExtractionResult{Metadata: Metadata{Error: &ErrorMetadata{ErrorType: "Other", Message: "crashed"}}}
+			}
+		}
+		return results, nil
+	}
+
+	for run := 0; run < 3; run++ {
+		results, err := extractQuarantined([]string{good, poison}, cfg, extract)
+		if err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+		if results[0].Content != "ok" || results[1].Metadata.Error == nil {
+			t.Fatalf("run %d: unexpected results %+v", run, results)
+		}
+	}
+	// Documents are extracted one at a time: two per run, then only the good one.
+	if len(extracted) != 5 || len(extracted[4]) != 1 || extracted[4][0] != good {
+		t.Fatalf("expected the poison file to be skipped on the third run, got %v", extracted)
+	}
+
+	manifest, err := LoadQuarantineManifest(cfg.ManifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quarantined := manifest.Quarantined()
+	if len(quarantined) != 1 || quarantined[0].Path != poison || quarantined[0].Failures != 2 || quarantined[0].LastError != "crashed" {
+		t.Fatalf("unexpected quarantine %+v", quarantined)
+	}
+	if _, ok := manifest.Entries[good]; ok {
+		t.Fatalf("expected successful file not to be tracked")
+	}
+
+	// Changing the file lifts the quarantine.
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(poison, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := extractQuarantined([]string{poison}, cfg, extract); err != nil {
+		t.Fatal(err)
+	}
+	if last := extracted[len(extracted)-1]; len(last) != 1 || last[0] != poison {
+		t.Fatalf("expected the changed file to be extracted again, got %v", extracted)
+	}
+}
+
+func TestQuarantineManifestRelease(t *testing.T) {
+	now := time.Now()
+	manifest := &QuarantineManifest{Entries: map[string]*QuarantineEntry{
+		"/data/a.pdf": {Path: "/data/a.pdf", Failures: 3, QuarantinedAt: &now},
+	}}
+	if !manifest.Release("/data/a.pdf") || len(manifest.Quarantined()) != 0 {
+		t.Fatalf("expected the entry to be released")
+	}
+	if manifest.Release("/data/a.pdf") {
+		t.Fatalf("expected a second release to report no entry")
+	}
+}
+
+func TestQuarantineValidation(t *testing.T) {
+	for _, cfg := range []*QuarantineConfig{
+		NewQuarantineConfig(""),
+		NewQuarantineConfig("quarantine.json", WithMaxFailures(0)),
+	} {
+		_, err := BatchExtractFilesSync([]string{"a.pdf"}, &ExtractionConfig{Quarantine: cfg})
+		var validation *ValidationError
+		if !errors.As(err, &validation) {
+			t.Errorf("expected ValidationError for %+v, got %v", cfg, err)
+		}
+	}
+}
+
+func TestExtractQuarantinedRecoversLeftoverAttempts(t *testing.T) {
+	dir := t.TempDir()
+	crasher := filepath.Join(dir, "crasher.pdf")
+	if err := os.WriteFile(crasher, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := NewQuarantineConfig(filepath.Join(dir, "quarantine.json"), WithMaxFailures(1))
+
+	// A previous run wrote its attempt and died before recording the outcome.
+	manifest, err := LoadQuarantineManifest(cfg.ManifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := manifest.entry(crasher)
+	started := time.Now()
+	entry.Attempting = &started
+	manifest.Entries[entry.Path] = entry
+	if err := manifest.Save(cfg.ManifestPath); err != nil {
+		t.Fatal(err)
+	}
+
+	called := false
+	results, err := extractQuarantined([]string{crasher}, cfg, func(paths []string) ([]*ExtractionResult, error) {
+		called = true
+		return []*ExtractionResult{{Content: "ok"}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if called || results[0].Metadata.Error == nil || results[0].Metadata.Error.ErrorType != quarantinedErrorType {
+		t.Fatalf("expected the crashing file to be quarantined, got %+v (extracted: %v)", results[0], called)
+	}
+	if manifest, _ = LoadQuarantineManifest(cfg.ManifestPath); manifest.Entries[entry.Path].Attempting != nil {
+		t.Fatal("expected the leftover attempt to be cleared")
+	}
+}
+
+func TestExtractQuarantinedTimeout(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for _, name := range []string{"slow.pdf", "next.pdf"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	cfg := NewQuarantineConfig(filepath.Join(dir, "quarantine.json"), WithDocumentTimeout(20))
+
+	release := make(chan struct{})
+	defer close(release)
+	var attempted atomic.Int32
+	results, err := extractQuarantined(paths, cfg, func(batch []string) ([]*ExtractionResult, error) {
+		attempted.Add(int32(len(batch)))
+		<-release
+		return []*ExtractionResult{{Content: "late"}}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Metadata.Error == nil || results[0].Metadata.Error.ErrorType != timeoutErrorType {
+		t.Fatalf("expected a timeout, got %+v", results[0])
+	}
+	if results[1].Metadata.Error == nil || results[1].Metadata.Error.ErrorType != skippedErrorType || attempted.Load() != 1 {
+		t.Fatalf("expected the next file to be skipped, got %+v", results[1])
+	}
+	manifest, err := LoadQuarantineManifest(cfg.ManifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := manifest.entry(paths[0]); entry.Failures != 1 || entry.Attempting != nil {
+		t.Fatalf("slow file entry = %+v", entry)
+	}
+	if _, ok := manifest.Entries[manifest.entry(paths[1]).Path]; ok {
+		t.Fatal("expected the skipped file not to count a failure")
+	}
+}
+
+func TestExtractQuarantinedRecordsErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.pdf")
+	if err := os.WriteFile(path, []byte("content"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := NewQuarantineConfig(filepath.Join(dir, "quarantine.json"))
+	failure := errors.New("native failure")
+	if _, err := extractQuarantined([]string{path}, cfg, func([]string) ([]*ExtractionResult, error) { return nil, failure }); !errors.Is(err, failure) {
+		t.Fatalf("expected the extraction error, got %v", err)
+	}
+	manifest, err := LoadQuarantineManifest(cfg.ManifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry := manifest.entry(path); entry.Failures != 1 || entry.LastError != "native failure" {
+		t.Fatalf("entry = %+v", entry)
+	}
+}
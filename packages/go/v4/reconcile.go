@@ -0,0 +1,286 @@
+package kreuzberg
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ReconciliationMerged is the PageReconciliation.Decision of a page whose text was taken
+// from both its text layer and its OCR.
+const ReconciliationMerged = "merged"
+
+const (
+	// reconcileDPI renders hybrid pages for OCR.
+	reconcileDPI = 300
+	// reconcileMinSimilarity is the word similarity from which an OCR block is taken for
+	// the counterpart of a native block.
+	reconcileMinSimilarity = 0.3
+	// reconcileMargin is how much better the OCR of a block must score to replace its
+	// native text.
+	reconcileMargin = 0.05
+	// reconcileMaxWordRunes bounds the length of a plausible word.
+	reconcileMaxWordRunes = 30
+)
+
+// PageReconciliation reports how a hybrid PDF page was reconciled with its OCR. Decision is
+// TextSourceNative or TextSourceOCR when every block was taken from one source, and
+// ReconciliationMerged otherwise. NativeQuality and OCRQuality score the page's text from
+// each source between 0 and 1, as the share of words that look like words rather than
+// broken glyphs. NativeBlocks and OCRBlocks count the blocks taken from each.
+type PageReconciliation struct {
+	PageNumber    uint64  `json:"page_number"`
+	Decision      string  `json:"decision"`
+	NativeQuality float64 `json:"native_quality"`
+	OCRQuality    float64 `json:"ocr_quality"`
+	NativeBlocks  int     `json:"native_blocks"`
+	OCRBlocks     int     `json:"ocr_blocks"`
+}
+
+// reconciledBlock is a block of a reconciled page and the source it was taken from.
+type reconciledBlock struct {
+	text, source string
+}
+
+// reconcileHybridPages replaces the text of the hybrid pages of a PDF result with the
+// better of their text layer and OCR, block by block. Each page is rendered from data and
+// recognized with the OCR settings of config. Pages that fail to render or recognize
+// keep their text.
+func reconcileHybridPages(result *ExtractionResult, data []byte, config *ExtractionConfig) {
+	ocrConfig := *nativeConfig(config)
+	ocrConfig.Chunking = nil
+	for _, page := range hybridPages(result) {
+		images, err := renderPDFPages(data, renderRequest{FirstPage: int(page), LastPage: int(page), DPI: reconcileDPI, Format: RenderFormatPNG})
+		if err != nil || len(images) == 0 {
+			continue
+		}
+		recognized, err := extractBytesNative(images[0].Data, "image/png", &ocrConfig)
+		if err != nil || recognized == nil {
+			continue
+		}
+		applyReconciliation(result, page, recognized.Content)
+	}
+}
+
+// hybridPages returns the pages with hybrid blocks or blocks of both sources.
+func hybridPages(result *ExtractionResult) []uint64 {
+	sources := map[uint64]string{}
+	var pages []uint64
+	for _, block := range result.TextSources {
+		if block.PageNumber == 0 {
+			continue
+		}
+		previous, seen := sources[block.PageNumber]
+		if !seen {
+			pages = append(pages, block.PageNumber)
+		}
+		if seen && previous != block.Source {
+			sources[block.PageNumber] = TextSourceHybrid
+		} else if !seen || previous != TextSourceHybrid {
+			sources[block.PageNumber] = block.Source
+		}
+	}
+	var hybrid []uint64
+	for _, page := range pages {
+		if sources[page] == TextSourceHybrid {
+			hybrid = append(hybrid, page)
+		}
+	}
+	return hybrid
+}
+
+// applyReconciliation reconciles the text of page with ocr and splices the result into
+// Content in place of the page's text, between the whitespace around it, keeping offsets
+// consistent. Chunks overlapping the page are stretched over its new text.
+func applyReconciliation(result *ExtractionResult, page uint64, ocr string) {
+	pageStart, pageEnd := pageContentRange(result, int(page))
+	text := result.Content[pageStart:pageEnd]
+	start := pageStart + len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	end := pageStart + len(strings.TrimRightFunc(text, unicode.IsSpace))
+	if start > end {
+		start, end = pageStart+len(strings.TrimRight(text, " \t")), pageEnd
+	}
+	blocks, report := reconcileText(result.Content[start:end], ocr)
+	report.PageNumber = page
+
+	var merged strings.Builder
+	var sources []TextSource
+	for i, block := range blocks {
+		if i > 0 {
+			merged.WriteString("\n\n")
+		}
+		blockStart := uint64(start + merged.Len())
+		merged.WriteString(block.text)
+		sources = append(sources, TextSource{PageNumber: page, ByteStart: blockStart, ByteEnd: uint64(start + merged.Len()), Source: block.source})
+	}
+	replacement := merged.String()
+
+	for i := range result.Chunks {
+		meta := &result.Chunks[i].Metadata
+		if meta.ByteEnd > uint64(start) && meta.ByteEnd < uint64(end) {
+			meta.ByteEnd = uint64(end)
+		}
+	}
+	kept := result.TextSources[:0]
+	for _, source := range result.TextSources {
+		if source.PageNumber != page {
+			kept = append(kept, source)
+		}
+	}
+	result.TextSources = kept
+
+	m := spliceOffsetMap(len(result.Content), start, end, len(replacement))
+	result.Content = result.Content[:start] + replacement + result.Content[end:]
+	remapResultOffsets(result, m)
+	for i := range result.Chunks {
+		chunk := &result.Chunks[i]
+		if chunk.Metadata.ByteStart <= uint64(start+len(replacement)) && chunk.Metadata.ByteEnd >= uint64(start) && chunk.Metadata.ByteEnd <= uint64(len(result.Content)) {
+			chunk.Content = result.Content[chunk.Metadata.ByteStart:chunk.Metadata.ByteEnd]
+		}
+	}
+
+	at := len(result.TextSources)
+	for i, source := range result.TextSources {
+		if source.ByteStart >= uint64(start) {
+			at = i
+			break
+		}
+	}
+	result.TextSources = append(result.TextSources[:at], append(sources, result.TextSources[at:]...)...)
+	pageStart, pageEnd = pageContentRange(result, int(page))
+	for i := range result.Pages {
+		if result.Pages[i].PageNumber == page {
+			result.Pages[i].Content = result.Content[pageStart:pageEnd]
+			result.Pages[i].Source = report.Decision
+			if report.Decision == ReconciliationMerged {
+				result.Pages[i].Source = TextSourceHybrid
+			}
+		}
+	}
+	result.Metadata.Reconciliation = append(result.Metadata.Reconciliation, report)
+}
+
+// reconcileText pairs each block of native with the OCR block sharing most of its words,
+// or, for a block of broken glyphs, with the next OCR block, and keeps whichever of the two
+// scores better by textQuality, preferring native text by reconcileMargin. OCR blocks without a native counterpart, such as stamps or handwriting
+// missing from the text layer, are kept in their place when they are plausible text.
+func reconcileText(native, ocr string) ([]reconciledBlock, PageReconciliation) {
+	nativeBlocks, ocrBlocks := blockTexts(native), blockTexts(ocr)
+	nativeWords := map[string]bool{}
+	for _, word := range normalizedWords(native) {
+		nativeWords[word] = true
+	}
+	report := PageReconciliation{NativeQuality: textQuality(native), OCRQuality: textQuality(ocr)}
+
+	used := make([]bool, len(ocrBlocks))
+	next := 0
+	var blocks []reconciledBlock
+	// flushOCR keeps the OCR blocks before limit no native block took, when their text is
+	// plausible and mostly missing from the text layer.
+	flushOCR := func(limit int) {
+		for ; next < limit; next++ {
+			if !used[next] && textQuality(ocrBlocks[next]) >= 0.5 && wordCoverage(ocrBlocks[next], nativeWords) < 0.5 {
+				blocks = append(blocks, reconciledBlock{text: ocrBlocks[next], source: TextSourceOCR})
+				used[next] = true
+			}
+		}
+	}
+	for _, block := range nativeBlocks {
+		best, bestSimilarity := -1, reconcileMinSimilarity
+		for j, candidate := range ocrBlocks {
+			if similarity := wordSimilarity(block, candidate); !used[j] && similarity >= bestSimilarity {
+				best, bestSimilarity = j, similarity
+			}
+		}
+		if best < 0 && textQuality(block) < 0.5 {
+			// Broken glyphs share no words with their OCR; take the next block in place.
+			for j := next; j < len(ocrBlocks) && best < 0; j++ {
+				if !used[j] {
+					best = j
+				}
+			}
+		}
+		if best < 0 {
+			blocks = append(blocks, reconciledBlock{text: block, source: TextSourceNative})
+			continue
+		}
+		used[best] = true
+		flushOCR(best)
+		if textQuality(ocrBlocks[best]) > textQuality(block)+reconcileMargin {
+			blocks = append(blocks, reconciledBlock{text: ocrBlocks[best], source: TextSourceOCR})
+		} else {
+			blocks = append(blocks, reconciledBlock{text: block, source: TextSourceNative})
+		}
+		next = max(next, best+1)
+	}
+	flushOCR(len(ocrBlocks))
+
+	for _, block := range blocks {
+		if block.source == TextSourceOCR {
+			report.OCRBlocks++
+		} else {
+			report.NativeBlocks++
+		}
+	}
+	switch {
+	case report.OCRBlocks == 0:
+		report.Decision = TextSourceNative
+	case report.NativeBlocks == 0:
+		report.Decision = TextSourceOCR
+	default:
+		report.Decision = ReconciliationMerged
+	}
+	return blocks, report
+}
+
+// blockTexts returns the texts of the blocks of text.
+func blockTexts(text string) []string {
+	var texts []string
+	for _, block := range parseStructureBlocks(text, nil) {
+		texts = append(texts, text[block.start:block.end])
+	}
+	return texts
+}
+
+// textQuality returns the share of the whitespace-separated words of text that look like
+// words: no replacement, private-use or control characters, no "(cid:N)" placeholders,
+// mostly letters and digits, not overlong, and with a vowel when longer Latin words.
+func textQuality(text string) float64 {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return 0
+	}
+	plausible := 0
+	for _, word := range words {
+		if plausibleWord(word) {
+			plausible++
+		}
+	}
+	return float64(plausible) / float64(len(words))
+}
+
+func plausibleWord(word string) bool {
+	if strings.Contains(word, "(cid:") || utf8.RuneCountInString(word) > reconcileMaxWordRunes {
+		return false
+	}
+	alphanumeric, letters, total := 0, 0, 0
+	ascii, vowel := true, false
+	for _, r := range word {
+		total++
+		switch {
+		case r == utf8.RuneError || unicode.Is(unicode.Co, r) || unicode.IsControl(r):
+			return false
+		case unicode.IsLetter(r):
+			letters++
+			alphanumeric++
+			ascii = ascii && r < utf8.RuneSelf
+			vowel = vowel || strings.ContainsRune("aeiouyAEIOUY", r)
+		case unicode.IsDigit(r):
+			alphanumeric++
+		}
+	}
+	if alphanumeric*2 < total {
+		return false
+	}
+	return !(ascii && letters > 4 && letters == total && !vowel)
+}
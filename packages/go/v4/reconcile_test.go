@@ -0,0 +1,95 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestReconcileText(t *testing.T) {
+	native := "Invoice number 2024-17\n\n  (cid:12)(cid:7)\n\nPayment within 30 days."
+	ocr := "Invoice number 2024-17\n\nTotal due: 1,250.00 EUR\n\nPayment within 30 days.\n\nPAID 12 March"
+	blocks, report := reconcileText(native, ocr)
+	want := []reconciledBlock{
+		{text: "Invoice number 2024-17", source: TextSourceNative},
+		{text: "Total due: 1,250.00 EUR", source: TextSourceOCR},
+		{text: "Payment within 30 days.", source: TextSourceNative},
+		{text: "PAID 12 March", source: TextSourceOCR},
+	}
+	if !reflect.DeepEqual(blocks, want) {
+		t.Errorf("reconcileText =\n%+v\nwant\n%+v", blocks, want)
+	}
+	if report.Decision != ReconciliationMerged || report.NativeBlocks != 2 || report.OCRBlocks != 2 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.NativeQuality >= report.OCRQuality {
+		t.Errorf("native text should score lower: %+v", report)
+	}
+
+	if blocks, report := reconcileText("Clean text here.", "Clean text hcre."); report.Decision != TextSourceNative || len(blocks) != 1 {
+		t.Errorf("equally plausible text should stay native: %+v %+v", blocks, report)
+	}
+}
+
+func TestApplyReconciliation(t *testing.T) {
+	page1 := "Cover page"
+	page2 := "\n\n "
+	page3 := "\n\nLast page"
+	content := page1 + page2 + page3
+	b1, b2 := uint64(len(page1)), uint64(len(page1+page2))
+	result := &ExtractionResult{Content: content, Pages: []PageContent{{PageNumber: 2, Content: page2}}}
+	result.Metadata.PageStructure = &PageStructure{Boundaries: []PageBoundary{
+		{ByteStart: 0, ByteEnd: b1, PageNumber: 1},
+		{ByteStart: b1, ByteEnd: b2, PageNumber: 2},
+		{ByteStart: b2, ByteEnd: uint64(len(content)), PageNumber: 3},
+	}}
+	result.Chunks = Chunks{
+		{Content: page1, Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: b1}},
+		{Content: page2 + page3, Metadata: ChunkMetadata{ByteStart: b1 + 2, ByteEnd: uint64(len(content))}},
+	}
+	result.TextSources = []TextSource{
+		{PageNumber: 1, ByteStart: 0, ByteEnd: b1, Source: TextSourceNative},
+		{PageNumber: 2, ByteStart: b1 + 2, ByteEnd: b2, Source: TextSourceHybrid},
+		{PageNumber: 3, ByteStart: b2 + 2, ByteEnd: uint64(len(content)), Source: TextSourceNative},
+	}
+	if pages := hybridPages(result); !reflect.DeepEqual(pages, []uint64{2}) {
+		t.Fatalf("hybridPages = %v", pages)
+	}
+
+	applyReconciliation(result, 2, "Signed and sealed")
+	if want := "Cover page\n\nSigned and sealed\n\nLast page"; result.Content != want {
+		t.Fatalf("Content = %q, want %q", result.Content, want)
+	}
+	bounds := result.Metadata.PageStructure.Boundaries
+	if got := result.Content[bounds[1].ByteStart:bounds[1].ByteEnd]; got != "\n\nSigned and sealed" {
+		t.Errorf("page 2 = %q", got)
+	}
+	if got := result.Content[bounds[2].ByteStart:bounds[2].ByteEnd]; got != page3 {
+		t.Errorf("page 3 = %q", got)
+	}
+	if got := result.Chunks[1].Content; got != "Signed and sealed\n\nLast page" {
+		t.Errorf("chunk = %q", got)
+	}
+	var sources []string
+	for _, source := range result.TextSources {
+		sources = append(sources, source.Source+":"+result.Content[source.ByteStart:source.ByteEnd])
+	}
+	if want := []string{"native:Cover page", "ocr:Signed and sealed", "native:Last page"}; !reflect.DeepEqual(sources, want) {
+		t.Errorf("text sources = %q, want %q", sources, want)
+	}
+	if result.Pages[0].Source != TextSourceOCR || result.Pages[0].Content != "\n\nSigned and sealed" {
+		t.Errorf("unexpected page: %+v", result.Pages[0])
+	}
+
+	data, err := json.Marshal(result.Metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Metadata
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Reconciliation) != 1 || decoded.Reconciliation[0].PageNumber != 2 || decoded.Reconciliation[0].Decision != TextSourceOCR {
+		t.Errorf("unexpected reconciliation metadata: %+v", decoded.Reconciliation)
+	}
+}
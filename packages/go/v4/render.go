@@ -0,0 +1,236 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+#include <stdint.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"unsafe"
+)
+
+// Image encodings accepted by the rendering functions.
+const (
+	RenderFormatPNG  = "png"
+	RenderFormatJPEG = "jpeg"
+)
+
+// PageRange selects pages by 1-indexed, inclusive page number. A zero First starts at the
+// first page and a zero Last runs through the final page.
+type PageRange struct {
+	First int `json:"first,omitempty"`
+	Last  int `json:"last,omitempty"`
+}
+
+const (
+	// defaultRenderDPI is the resolution RenderPage uses when RenderOptions.DPI is unset.
+	defaultRenderDPI = 150
+	// maxRenderDPI is the highest resolution the core renderer accepts. It also rejects
+	// pages that would be wider or taller than 10000 pixels.
+	maxRenderDPI = 600
+	// textLayerDPI positions the text layer the document stages share at one pixel per
+	// point.
+	textLayerDPI = 72
+)
+
+// PageImage is a rendered page encoded as Format. Words holds the page's text layer when
+// it was requested.
+type PageImage struct {
+	PageNumber int        `json:"page_number"`
+	Width      int        `json:"width"`
+	Height     int        `json:"height"`
+	Format     string     `json:"format"`
+	Data       []byte     `json:"data"`
+	Words      []PageWord `json:"words,omitempty"`
+}
+
+// PageWord is a word of a page's text layer, positioned in pixels of the rendered image
+// from its top-left corner.
+type PageWord struct {
+	Text   string  `json:"text"`
+	Left   float64 `json:"left"`
+	Top    float64 `json:"top"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// RenderOptions controls RenderPage. A zero DPI renders at 150 dots per inch and an empty
+// Format produces PNG.
+type RenderOptions struct {
+	DPI      int
+	Format   string
+	Password string
+}
+
+// pptxMimeType is the MIME type of PowerPoint presentations.
+const pptxMimeType = "application/vnd.openxmlformats-officedocument.presentationml.presentation"
+
+// renderRequest mirrors the options accepted by kreuzberg_render_pdf_pages.
+type renderRequest struct {
+	FirstPage int    `json:"first_page,omitempty"`
+	LastPage  int    `json:"last_page,omitempty"`
+	DPI       int    `json:"dpi"`
+	Format    string `json:"format"`
+	Password  string `json:"password,omitempty"`
+	TextLayer bool   `json:"text_layer,omitempty"`
+	MimeType  string `json:"mime_type,omitempty"`
+}
+
+// textLayerRequest mirrors the options accepted by kreuzberg_pdf_text_layer.
+type textLayerRequest struct {
+	FirstPage int    `json:"first_page,omitempty"`
+	LastPage  int    `json:"last_page,omitempty"`
+	DPI       int    `json:"dpi"`
+	Password  string `json:"password,omitempty"`
+}
+
+// RenderPageThumbnails rasterizes the pages of a PDF or PowerPoint presentation with the
+// core renderer, at dpi dots per inch (at most 600), as PNG or JPEG. Presentations are
+// converted to PDF with LibreOffice first, which must be installed. Other document types
+// return an UnsupportedFormatError, and pages wider or taller than 10000 pixels at dpi an
+// error.
+func RenderPageThumbnails(path string, pages PageRange, dpi int, format string) ([]PageImage, error) {
+	if path == "" {
+		return nil, newValidationErrorWithContext("path cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	if err := validateRenderOptions(pages, dpi, format); err != nil {
+		return nil, err
+	}
+	data, mimeType, err := readRenderable(path)
+	if err != nil {
+		return nil, err
+	}
+	return renderPDFPages(data, renderRequest{FirstPage: pages.First, LastPage: pages.Last, DPI: dpi, Format: format, MimeType: mimeType})
+}
+
+// RenderPage rasterizes one page (1-indexed) of a PDF or presentation, as
+// RenderPageThumbnails does, together with its text layer, so callers can overlay
+// selectable words on the image.
+func RenderPage(path string, page int, opts *RenderOptions) (*PageImage, error) {
+	if path == "" {
+		return nil, newValidationErrorWithContext("path cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	request := renderRequest{FirstPage: page, LastPage: page, DPI: defaultRenderDPI, Format: RenderFormatPNG, TextLayer: true}
+	if opts != nil {
+		if opts.DPI != 0 {
+			request.DPI = opts.DPI
+		}
+		if opts.Format != "" {
+			request.Format = opts.Format
+		}
+		request.Password = opts.Password
+	}
+	if page < 1 {
+		return nil, newValidationErrorWithContext(fmt.Sprintf("page must be at least 1, got %d", page), nil, ErrorCodeValidation, nil)
+	}
+	if err := validateRenderOptions(PageRange{First: page, Last: page}, request.DPI, request.Format); err != nil {
+		return nil, err
+	}
+	data, mimeType, err := readRenderable(path)
+	if err != nil {
+		return nil, err
+	}
+	request.MimeType = mimeType
+	images, err := renderPDFPages(data, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) == 0 {
+		return nil, newValidationErrorWithContext(fmt.Sprintf("page %d not found", page), nil, ErrorCodeValidation, nil)
+	}
+	return &images[0], nil
+}
+
+// validateRenderOptions rejects invalid page ranges, resolutions, and formats.
+func validateRenderOptions(pages PageRange, dpi int, format string) error {
+	if pages.First < 0 || pages.Last < 0 || (pages.Last != 0 && pages.Last < max(pages.First, 1)) {
+		return newValidationErrorWithContext(fmt.Sprintf("invalid page range: %d-%d", pages.First, pages.Last), nil, ErrorCodeValidation, nil)
+	}
+	if dpi <= 0 || dpi > maxRenderDPI {
+		return newValidationErrorWithContext(fmt.Sprintf("dpi must be between 1 and %d, got %d", maxRenderDPI, dpi), nil, ErrorCodeValidation, nil)
+	}
+	if format != RenderFormatPNG && format != RenderFormatJPEG {
+		return newValidationErrorWithContext(fmt.Sprintf("invalid render format: %s", format), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// readRenderable reads path and returns its MIME type, rejecting documents the core
+// renderer cannot rasterize.
+func readRenderable(path string) ([]byte, string, error) {
+	mimeType, err := DetectMimeTypeFromPath(path)
+	if err != nil {
+		return nil, "", err
+	}
+	switch mimeType {
+	case "application/pdf", pptxMimeType, MimeTypeLegacyPowerPoint:
+	default:
+		return nil, "", newUnsupportedFormatErrorWithContext(mimeType, "page rendering is only supported for PDF documents and PowerPoint presentations", nil, ErrorCodeUnsupportedFormat, nil)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", newIOErrorWithContext("failed to read document", err, ErrorCodeIo, nil)
+	}
+	return data, mimeType, nil
+}
+
+// renderPDFPages calls the native renderer while holding ffiMutex.
+func renderPDFPages(data []byte, request renderRequest) ([]PageImage, error) {
+	options, err := json.Marshal(request)
+	if err != nil {
+		return nil, newSerializationErrorWithContext("failed to encode render options", err, ErrorCodeValidation, nil)
+	}
+	buf := C.CBytes(data)
+	defer C.free(buf)
+	cOptions := C.CString(string(options))
+	defer C.free(unsafe.Pointer(cOptions))
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_render_pdf_pages((*C.uint8_t)(buf), C.uintptr_t(len(data)), cOptions)
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	var images []PageImage
+	if err := decodeJSONCString(ptr, &images); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode rendered pages", err, ErrorCodeValidation, nil)
+	}
+	return images, nil
+}
+
+// pdfTextLayer reads the text layer of a PDF's pages without rendering them, while holding
+// ffiMutex. The pages are sized and their words positioned as renderPDFPages reports them
+// at the same resolution; their Format and Data are empty.
+func pdfTextLayer(data []byte, request textLayerRequest) ([]PageImage, error) {
+	options, err := json.Marshal(request)
+	if err != nil {
+		return nil, newSerializationErrorWithContext("failed to encode text layer options", err, ErrorCodeValidation, nil)
+	}
+	buf := C.CBytes(data)
+	defer C.free(buf)
+	cOptions := C.CString(string(options))
+	defer C.free(unsafe.Pointer(cOptions))
+
+	ffiMutex.Lock()
+	ptr := C.kreuzberg_pdf_text_layer((*C.uint8_t)(buf), C.uintptr_t(len(data)), cOptions)
+	ffiMutex.Unlock()
+
+	if ptr == nil {
+		return nil, lastError()
+	}
+	defer C.kreuzberg_free_string(ptr)
+
+	var pages []PageImage
+	if err := decodeJSONCString(ptr, &pages); err != nil {
+		return nil, newSerializationErrorWithContext("failed to decode text layer", err, ErrorCodeValidation, nil)
+	}
+	return pages, nil
+}
@@ -0,0 +1,60 @@
+package kreuzberg
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderPageThumbnailsValidation(t *testing.T) {
+	cases := []struct {
+		name   string
+		pages  PageRange
+		dpi    int
+		format string
+	}{
+		{"negative first page", PageRange{First: -1}, 72, RenderFormatPNG},
+		{"last before first", PageRange{First: 3, Last: 2}, 72, RenderFormatPNG},
+		{"zero dpi", PageRange{}, 0, RenderFormatPNG},
+		{"excessive dpi", PageRange{}, maxRenderDPI + 1, RenderFormatPNG},
+		{"unknown format", PageRange{}, 72, "gif"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := RenderPageThumbnails("document.pdf", tc.pages, tc.dpi, tc.format)
+			var validationErr *ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected ValidationError, got %v", err)
+			}
+		})
+	}
+	if err := validateRenderOptions(PageRange{First: 2, Last: 2}, 96, RenderFormatJPEG); err != nil {
+		t.Fatalf("single page range should be valid: %v", err)
+	}
+}
+
+func TestRenderPageThumbnailsRejectsNonPDF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("plain text"), 0o600); err != nil {
+		t.Fatalf("write fixture: %v", err)
+	}
+	_, err := RenderPageThumbnails(path, PageRange{}, 72, RenderFormatPNG)
+	var unsupported *UnsupportedFormatError
+	if !errors.As(err, &unsupported) {
+		t.Fatalf("expected UnsupportedFormatError, got %v", err)
+	}
+}
+
+func TestRenderPageValidation(t *testing.T) {
+	var validationErr *ValidationError
+	if _, err := RenderPage("document.pdf", 0, nil); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for page 0, got %v", err)
+	}
+	if _, err := RenderPage("document.pdf", 1, &RenderOptions{DPI: -1}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for negative dpi, got %v", err)
+	}
+	if _, err := RenderPage("document.pdf", 1, &RenderOptions{Format: "bmp"}); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError for unknown format, got %v", err)
+	}
+}
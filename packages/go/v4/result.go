@@ -26,6 +26,9 @@ func (r *ExtractionResult) GetPageCount() (int, error) {
 // Returns -1 if there is an error.
 // This method provides efficient access to chunk count without JSON parsing.
 func (r *ExtractionResult) GetChunkCount() (int, error) {
+	if r.paged != nil {
+		return len(r.paged.chunks), nil
+	}
 	if r.Chunks != nil {
 		return len(r.Chunks), nil
 	}
@@ -133,6 +136,7 @@ func (r *ExtractionResult) String() string {
 		return "<nil ExtractionResult>"
 	}
 
+	chunks, _ := r.GetChunkCount()
 	return fmt.Sprintf("ExtractionResult{MimeType: %s, ContentLen: %d, Tables: %d, Chunks: %d, Success: %v}",
-		r.MimeType, len(r.Content), len(r.Tables), len(r.Chunks), r.Success)
+		r.MimeType, len(r.Content), len(r.Tables), chunks, r.Success)
 }
@@ -0,0 +1,205 @@
+package kreuzberg
+
+/*
+#include "internal/ffi/kreuzberg.h"
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"unsafe"
+)
+
+// spoolPrefix names the private directory of one extraction under RetentionConfig.Dir.
+const spoolPrefix = "kreuzberg-spool-"
+
+// pagingPrefix names the private directory of the paging file of a result.
+const pagingPrefix = "kreuzberg-pages-"
+
+// tempResiduePatterns match the temporary files and directories that extractions create,
+// in a spool directory or, without a RetentionConfig, in the system temporary directory.
+var tempResiduePatterns = []string{
+	spoolPrefix + "*",
+	pagingPrefix + "*",
+	"kreuzberg-image-*",
+	"kreuzberg_doc_*",
+	"kreuzberg_ppt_*",
+	"kreuzberg_lo_profile_*",
+	"temp_pptx_*",
+}
+
+// ErrTempResidue is wrapped by the IOError returned when temporary files of an extraction
+// could not be removed. Test for it with errors.Is.
+var ErrTempResidue = errors.New("kreuzberg: temporary files left behind")
+
+// ErrUnencryptedTempDir is wrapped by the ValidationError returned when
+// RetentionConfig.RequireEncryption is set and the directory is not on encrypted or
+// memory-backed storage.
+var ErrUnencryptedTempDir = errors.New("kreuzberg: temporary directory is not encrypted")
+
+// RetentionConfig controls the temporary files that the native library writes while
+// extracting, such as the documents LibreOffice converts. Each extraction gets a private
+// directory under Dir; when it returns, every file in it is overwritten with zeros and the
+// directory is removed, and the extraction fails with ErrTempResidue if anything is left.
+//
+// Overwriting cannot reach copies kept by SSD wear leveling or copy-on-write file systems;
+// RequireEncryption covers those by keeping the data encrypted or off disk instead.
+type RetentionConfig struct {
+	// Dir holds the temporary files. It must exist. Default: os.TempDir().
+	Dir string `json:"dir,omitempty"`
+	// RequireEncryption refuses to extract unless Dir is an fscrypt-encrypted directory,
+	// on a dm-crypt volume, or on a memory-backed file system (tmpfs, ramfs). It can only
+	// be verified on Linux; elsewhere extraction fails. Default: false.
+	RequireEncryption *bool `json:"require_encryption,omitempty"`
+}
+
+// TempDir returns the directory holding the temporary files.
+func (c *RetentionConfig) TempDir() string {
+	if c == nil || c.Dir == "" {
+		return os.TempDir()
+	}
+	return c.Dir
+}
+
+func validateRetentionConfig(cfg *RetentionConfig) error {
+	info, err := os.Stat(cfg.TempDir())
+	if err != nil {
+		return newValidationErrorWithContext("retention dir is not accessible", err, ErrorCodeValidation, nil)
+	}
+	if !info.IsDir() {
+		return newValidationErrorWithContext(fmt.Sprintf("retention dir %s is not a directory", cfg.TempDir()), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// FindTempResidue lists the temporary files and directories that extractions left in dir,
+// such as those of a process killed mid-extraction. An empty dir is os.TempDir(). An
+// empty list shows that no extraction data remains.
+func FindTempResidue(dir string) ([]string, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, newIOErrorWithContext("failed to read temporary directory", err, ErrorCodeIo, nil)
+	}
+	var residue []string
+	for _, entry := range entries {
+		for _, pattern := range tempResiduePatterns {
+			if matched, _ := filepath.Match(pattern, entry.Name()); matched {
+				residue = append(residue, filepath.Join(dir, entry.Name()))
+				break
+			}
+		}
+	}
+	return residue, nil
+}
+
+// PurgeTempResidue overwrites and removes the residue FindTempResidue reports in dir. It
+// must not run while extractions use dir.
+func PurgeTempResidue(dir string) error {
+	residue, err := FindTempResidue(dir)
+	if err != nil {
+		return err
+	}
+	for _, path := range residue {
+		if err := wipeTempDir(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enterNativeSpool points the temporary files of the native library at a private
+// directory for one native call, when config has a RetentionConfig. The returned function
+// restores the default and wipes the directory, setting *err if files remain and the call
+// did not fail otherwise. The caller holds ffiMutex.
+func enterNativeSpool(config *ExtractionConfig) (func(err *error), error) {
+	if config == nil || config.Retention == nil {
+		return func(*error) {}, nil
+	}
+	dir, err := createSpoolDir(config.Retention, spoolPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cDir := C.CString(dir)
+	defer C.free(unsafe.Pointer(cDir))
+	if C.kreuzberg_set_temp_dir(cDir) != 0 {
+		err := lastError()
+		_ = wipeTempDir(dir)
+		return nil, err
+	}
+	return func(err *error) {
+		C.kreuzberg_set_temp_dir(nil)
+		if wipeErr := wipeTempDir(dir); wipeErr != nil && *err == nil {
+			*err = wipeErr
+		}
+	}, nil
+}
+
+// createSpoolDir creates a private directory under the retention directory, after
+// checking its storage if encryption is required. A nil cfg uses os.TempDir().
+func createSpoolDir(cfg *RetentionConfig, prefix string) (string, error) {
+	parent := cfg.TempDir()
+	if cfg != nil && cfg.RequireEncryption != nil && *cfg.RequireEncryption {
+		encrypted, reason := tempStorageEncrypted(parent)
+		if !encrypted {
+			return "", newValidationErrorWithContext(fmt.Sprintf("retention dir %s is not on encrypted storage: %s", parent, reason), ErrUnencryptedTempDir, ErrorCodeValidation, nil)
+		}
+	}
+	dir, err := os.MkdirTemp(parent, prefix)
+	if err != nil {
+		return "", newIOErrorWithContext("failed to create temporary directory", err, ErrorCodeIo, nil)
+	}
+	return dir, nil
+}
+
+// wipeTempDir overwrites the regular files under path with zeros, removes path and checks
+// that nothing is left.
+func wipeTempDir(path string) error {
+	_ = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			_ = overwriteFile(file)
+		}
+		return nil
+	})
+	removeErr := os.RemoveAll(path)
+	if _, err := os.Lstat(path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	message := "temporary files left in " + path
+	if removeErr != nil {
+		message += ": " + removeErr.Error()
+	}
+	return newIOErrorWithContext(message, ErrTempResidue, ErrorCodeIo, nil)
+}
+
+// overwriteFile replaces the content of a file with zeros and flushes it to disk.
+func overwriteFile(path string) error {
+	file, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(file, zeroReader{}, info.Size()); err != nil {
+		return err
+	}
+	return file.Sync()
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,77 @@
+//go:build linux
+
+package kreuzberg
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	tmpfsMagic = 0x01021994
+	ramfsMagic = 0x858458f6
+	// fsIocGetFlags is FS_IOC_GETFLAGS, _IOR('f', 1, long), with the generic ioctl
+	// encoding; architectures encoding it differently fail the check.
+	fsIocGetFlags = 2<<30 | unsafe.Sizeof(uintptr(0))<<16 | 'f'<<8 | 1
+	fsEncryptFlag = 0x800
+)
+
+// tempStorageEncrypted reports whether dir keeps no plaintext at rest, and if not, why.
+func tempStorageEncrypted(dir string) (bool, string) {
+	var statfs syscall.Statfs_t
+	if err := syscall.Statfs(dir, &statfs); err != nil {
+		return false, err.Error()
+	}
+	if fsType := uint32(statfs.Type); fsType == tmpfsMagic || fsType == ramfsMagic {
+		return true, ""
+	}
+	if fscryptEncrypted(dir) {
+		return true, ""
+	}
+	var stat syscall.Stat_t
+	if err := syscall.Stat(dir, &stat); err != nil {
+		return false, err.Error()
+	}
+	dev := uint64(stat.Dev)
+	major := (dev>>8)&0xfff | (dev>>32)&^0xfff
+	minor := dev&0xff | (dev>>12)&^0xff
+	device, err := filepath.EvalSymlinks(filepath.Join("/sys/dev/block", strconv.FormatUint(major, 10)+":"+strconv.FormatUint(minor, 10)))
+	if err == nil && dmCryptDevice(device, 0) {
+		return true, ""
+	}
+	return false, "it is neither fscrypt-encrypted, on a dm-crypt volume, nor memory-backed"
+}
+
+// fscryptEncrypted reports whether dir has an fscrypt encryption policy.
+func fscryptEncrypted(dir string) bool {
+	file, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	var flags uint64
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, file.Fd(), fsIocGetFlags, uintptr(unsafe.Pointer(&flags)))
+	return errno == 0 && flags&fsEncryptFlag != 0
+}
+
+// dmCryptDevice reports whether the block device at the sysfs path device is a dm-crypt
+// mapping or is stacked on one, as LVM volumes on LUKS are.
+func dmCryptDevice(device string, depth int) bool {
+	if depth > 8 {
+		return false
+	}
+	if uuid, err := os.ReadFile(filepath.Join(device, "dm", "uuid")); err == nil && strings.HasPrefix(string(uuid), "CRYPT-") {
+		return true
+	}
+	slaves, _ := filepath.Glob(filepath.Join(device, "slaves", "*"))
+	for _, slave := range slaves {
+		if resolved, err := filepath.EvalSymlinks(slave); err == nil && dmCryptDevice(resolved, depth+1) {
+			return true
+		}
+	}
+	return false
+}
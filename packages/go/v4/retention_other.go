@@ -0,0 +1,8 @@
+//go:build !linux
+
+package kreuzberg
+
+// tempStorageEncrypted reports whether dir keeps no plaintext at rest, and if not, why.
+func tempStorageEncrypted(dir string) (bool, string) {
+	return false, "encrypted storage can only be verified on Linux"
+}
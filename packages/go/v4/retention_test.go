@@ -0,0 +1,123 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRetentionLeavesNoResidue(t *testing.T) {
+	dir := t.TempDir()
+	config := NewExtractionConfig(WithRetention(dir, false))
+	result, err := ExtractBytesSync([]byte("retained nowhere"), "text/plain", config)
+	if err != nil {
+		t.Fatalf("ExtractBytesSync: %v", err)
+	}
+	if result.Content == "" {
+		t.Fatal("expected content")
+	}
+	residue, err := FindTempResidue(dir)
+	if err != nil {
+		t.Fatalf("FindTempResidue: %v", err)
+	}
+	if len(residue) != 0 {
+		t.Fatalf("expected no residue, got %v", residue)
+	}
+}
+
+func TestNativeSpoolIsWiped(t *testing.T) {
+	dir := t.TempDir()
+	ffiMutex.Lock()
+	defer ffiMutex.Unlock()
+	leave, err := enterNativeSpool(&ExtractionConfig{Retention: &RetentionConfig{Dir: dir}})
+	if err != nil {
+		t.Fatalf("enterNativeSpool: %v", err)
+	}
+	spools, _ := filepath.Glob(filepath.Join(dir, spoolPrefix+"*"))
+	if len(spools) != 1 {
+		t.Fatalf("expected one spool directory, got %v", spools)
+	}
+	if err := os.MkdirAll(filepath.Join(spools[0], "kreuzberg_doc_1"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(spools[0], "kreuzberg_doc_1", "input.doc"), []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var callErr error
+	leave(&callErr)
+	if callErr != nil {
+		t.Fatalf("leaving the spool: %v", callErr)
+	}
+	if _, err := os.Lstat(spools[0]); !os.IsNotExist(err) {
+		t.Fatalf("expected the spool directory to be removed, got %v", err)
+	}
+}
+
+func TestOverwriteFileZeroesContent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spooled")
+	if err := os.WriteFile(path, []byte("confidential"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := overwriteFile(path); err != nil {
+		t.Fatalf("overwriteFile: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, make([]byte, len("confidential"))) {
+		t.Fatalf("expected zeros, got %q", data)
+	}
+}
+
+func TestFindAndPurgeTempResidue(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"kreuzberg-spool-123", "kreuzberg_lo_profile_abc", "temp_pptx_1_2.pptx", "unrelated.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	residue, err := FindTempResidue(dir)
+	if err != nil {
+		t.Fatalf("FindTempResidue: %v", err)
+	}
+	if len(residue) != 3 {
+		t.Fatalf("expected 3 residue entries, got %v", residue)
+	}
+	if err := PurgeTempResidue(dir); err != nil {
+		t.Fatalf("PurgeTempResidue: %v", err)
+	}
+	if residue, _ := FindTempResidue(dir); len(residue) != 0 {
+		t.Fatalf("expected no residue after purge, got %v", residue)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "unrelated.txt")); err != nil {
+		t.Fatalf("unrelated file was removed: %v", err)
+	}
+}
+
+func TestRetentionRequiresEncryption(t *testing.T) {
+	dir := t.TempDir()
+	if encrypted, _ := tempStorageEncrypted(dir); encrypted {
+		t.Skip("the test directory is on encrypted or memory-backed storage")
+	}
+	_, err := createSpoolDir(&RetentionConfig{Dir: dir, RequireEncryption: BoolPtr(true)}, spoolPrefix)
+	if !errors.Is(err, ErrUnencryptedTempDir) {
+		t.Fatalf("expected ErrUnencryptedTempDir, got %v", err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected no spool directory, got %d entries", len(entries))
+	}
+}
+
+func TestRetentionConfigValidation(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	if err := validateResultStages(&ExtractionConfig{Retention: &RetentionConfig{Dir: missing}}); err == nil {
+		t.Fatal("expected an error for a missing retention dir")
+	}
+	if err := validateResultStages(&ExtractionConfig{Retention: &RetentionConfig{}}); err != nil {
+		t.Fatalf("expected the default retention dir to be valid: %v", err)
+	}
+}
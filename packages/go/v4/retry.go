@@ -0,0 +1,168 @@
+package kreuzberg
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Defaults applied to unset RetryConfig fields.
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBackoffMs = 100
+	defaultRetryMaxWaitMs = 5000
+)
+
+// defaultRetryOn lists the error kinds retried when RetryConfig.RetryOn is empty.
+var defaultRetryOn = []ErrorKind{ErrorKindIO, ErrorKindRuntime}
+
+// retryPolicy is a RetryConfig with defaults applied.
+type retryPolicy struct {
+	attempts int
+	backoff  time.Duration
+	maxWait  time.Duration
+	retryOn  []ErrorKind
+}
+
+func validateRetryConfig(cfg *RetryConfig) error {
+	if cfg.MaxAttempts != nil && *cfg.MaxAttempts < 1 {
+		return newValidationErrorWithContext("retry max_attempts must be at least 1", nil, ErrorCodeValidation, nil)
+	}
+	if (cfg.InitialBackoffMs != nil && *cfg.InitialBackoffMs < 0) || (cfg.MaxBackoffMs != nil && *cfg.MaxBackoffMs < 0) {
+		return newValidationErrorWithContext("retry backoff cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	for _, kind := range cfg.RetryOn {
+		if errorKindForType(string(kind)) != kind {
+			return newValidationErrorWithContext(fmt.Sprintf("unknown retry error kind: %s", kind), nil, ErrorCodeValidation, nil)
+		}
+	}
+	return nil
+}
+
+// newRetryPolicy applies the defaults to cfg. A nil cfg yields a single attempt.
+func newRetryPolicy(cfg *RetryConfig) retryPolicy {
+	if cfg == nil {
+		return retryPolicy{attempts: 1}
+	}
+	policy := retryPolicy{
+		attempts: defaultRetryAttempts,
+		backoff:  defaultRetryBackoffMs * time.Millisecond,
+		maxWait:  defaultRetryMaxWaitMs * time.Millisecond,
+		retryOn:  defaultRetryOn,
+	}
+	if cfg.MaxAttempts != nil {
+		policy.attempts = *cfg.MaxAttempts
+	}
+	if cfg.InitialBackoffMs != nil {
+		policy.backoff = time.Duration(*cfg.InitialBackoffMs) * time.Millisecond
+	}
+	if cfg.MaxBackoffMs != nil {
+		policy.maxWait = time.Duration(*cfg.MaxBackoffMs) * time.Millisecond
+	}
+	if len(cfg.RetryOn) > 0 {
+		policy.retryOn = cfg.RetryOn
+	}
+	return policy
+}
+
+// retryConfig returns the retry settings of config, which may be nil.
+func retryConfig(config *ExtractionConfig) *RetryConfig {
+	if config == nil {
+		return nil
+	}
+	return config.Retry
+}
+
+// wait returns the pause before the given retry, counting from 1.
+func (p retryPolicy) wait(retry int) time.Duration {
+	wait := p.backoff
+	for i := 1; i < retry && wait < p.maxWait; i++ {
+		wait *= 2
+	}
+	return min(wait, p.maxWait)
+}
+
+func (p retryPolicy) retryable(kind ErrorKind) bool {
+	return slices.Contains(p.retryOn, kind)
+}
+
+// retryableError reports whether err is a KreuzbergError of a retried kind.
+func (p retryPolicy) retryableError(err error) bool {
+	var kerr KreuzbergError
+	return errors.As(err, &kerr) && p.retryable(kerr.Kind())
+}
+
+// retryDo runs fn until it succeeds, fails with an error that is not retried, or the attempts
+// are used up, and returns its last outcome.
+func retryDo[T any](p retryPolicy, fn func() (T, error)) (T, error) {
+	value, err := fn()
+	for retry := 1; err != nil && retry < p.attempts && p.retryableError(err); retry++ {
+		time.Sleep(p.wait(retry))
+		value, err = fn()
+	}
+	return value, err
+}
+
+// retryFailedResults re-extracts, one at a time, the documents of a batch whose result
+// carries a retryable error. A document that still fails keeps its original error result.
+func retryFailedResults(p retryPolicy, results []*ExtractionResult, extract func(int) (*ExtractionResult, error)) {
+	if p.attempts < 2 {
+		return
+	}
+	for i, result := range results {
+		if result == nil || result.Metadata.Error == nil {
+			continue
+		}
+		if !p.retryable(errorKindForType(result.Metadata.Error.ErrorType)) {
+			continue
+		}
+		var retried *ExtractionResult
+		var err error
+		for retry := 1; retry < p.attempts; retry++ {
+			time.Sleep(p.wait(retry))
+			retried, err = extract(i)
+			if err == nil || !p.retryableError(err) {
+				break
+			}
+		}
+		if err == nil && retried != nil {
+			results[i] = retried
+		}
+	}
+}
+
+// errorKindForType maps the error type recorded in the metadata of a failed batch item,
+// the debug name of the native error variant, to an ErrorKind.
+func errorKindForType(errorType string) ErrorKind {
+	variant := errorType
+	if i := strings.IndexAny(variant, "({ "); i >= 0 {
+		variant = variant[:i]
+	}
+	switch strings.ToLower(strings.ReplaceAll(variant, "_", "")) {
+	case "io":
+		return ErrorKindIO
+	case "validation":
+		return ErrorKindValidation
+	case "parsing":
+		return ErrorKindParsing
+	case "ocr":
+		return ErrorKindOCR
+	case "cache":
+		return ErrorKindCache
+	case "imageprocessing":
+		return ErrorKindImageProcessing
+	case "serialization":
+		return ErrorKindSerialization
+	case "missingdependency":
+		return ErrorKindMissingDependency
+	case "plugin":
+		return ErrorKindPlugin
+	case "unsupportedformat":
+		return ErrorKindUnsupportedFormat
+	case "runtime", "lockpoisoned", "other":
+		return ErrorKindRuntime
+	}
+	return ErrorKindUnknown
+}
@@ -0,0 +1,94 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorKindForBatchErrorType(t *testing.T) {
+	cases := map[string]ErrorKind{
+		`Io(Os { code: 13, kind: PermissionDenied, message: "Permission denied" })`: ErrorKindIO,
+		`Parsing { message: "bad xref", source: None }`:                             ErrorKindParsing,
+		`LockPoisoned("registry")`:                                                  ErrorKindRuntime,
+		`UnsupportedFormat("application/x-foo")`:                                    ErrorKindUnsupportedFormat,
+		"ValidationError":                                                           ErrorKindUnknown,
+	}
+	for errorType, want := range cases {
+		if got := errorKindForType(errorType); got != want {
+			t.Errorf("errorKindForType(%q) = %q, want %q", errorType, got, want)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := newRetryPolicy(NewRetryConfig(WithMaxAttempts(5), WithBackoff(100*time.Millisecond, 300*time.Millisecond)))
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 300 * time.Millisecond}
+	for i, expected := range want {
+		if got := policy.wait(i + 1); got != expected {
+			t.Errorf("wait(%d) = %v, want %v", i+1, got, expected)
+		}
+	}
+	if policy := newRetryPolicy(nil); policy.attempts != 1 {
+		t.Fatalf("expected a single attempt without retry config, got %d", policy.attempts)
+	}
+}
+
+func TestRetryFailedResults(t *testing.T) {
+	policy := newRetryPolicy(NewRetryConfig(WithBackoff(0, 0)))
+	failed := func(errorType string) *ExtractionResult {
+		return &ExtractionResult{Metadata: Metadata{Error: &ErrorMetadata{ErrorType: errorType, Message: "failed"}}}
+	}
+	results := []*ExtractionResult{
+		{Content: "ok"},
+		failed(`Io(Custom { kind: WouldBlock, error: "locked" })`),
+		failed(`Parsing { message: "corrupt", source: None }`),
+		failed(`Io(Custom { kind: NotFound, error: "gone" })`),
+	}
+
+	calls := map[int]int{}
+	retryFailedResults(policy, results, func(i int) (*ExtractionResult, error) {
+		calls[i]++
+		if i == 1 && calls[i] == 2 {
+			return &ExtractionResult{Content: "recovered"}, nil
+		}
+		return nil, newIOErrorWithContext("still failing", nil, ErrorCodeIo, nil)
+	})
+
+	if results[1].Content != "recovered" || calls[1] != 2 {
+		t.Fatalf("expected transient failure to recover on the second retry, got %q after %d calls", results[1].Content, calls[1])
+	}
+	if calls[0] != 0 || calls[2] != 0 {
+		t.Fatalf("expected successes and parsing errors not to be retried, got %v", calls)
+	}
+	if calls[3] != 2 || results[3].Metadata.Error == nil {
+		t.Fatalf("expected persistent failure to keep its error after %d retries, got %d calls", 2, calls[3])
+	}
+}
+
+func TestRetryDoStopsOnNonRetryableError(t *testing.T) {
+	policy := newRetryPolicy(NewRetryConfig(WithBackoff(0, 0), WithRetryOn(ErrorKindIO)))
+	calls := 0
+	_, err := retryDo(policy, func() (int, error) {
+		calls++
+		return 0, newParsingErrorWithContext("corrupt", nil, ErrorCodeParsing, nil)
+	})
+	var parsing *ParsingError
+	if !errors.As(err, &parsing) || calls != 1 {
+		t.Fatalf("expected one attempt for a parsing error, got %d (%v)", calls, err)
+	}
+}
+
+func TestRetryConfigValidation(t *testing.T) {
+	for _, cfg := range []*RetryConfig{
+		NewRetryConfig(WithMaxAttempts(0)),
+		NewRetryConfig(WithBackoff(-time.Second, time.Second)),
+		NewRetryConfig(WithRetryOn("flaky")),
+	} {
+		_, err := BatchExtractBytesSync([]BytesWithMime{{Data: []byte("x"), MimeType: "text/plain"}}, &ExtractionConfig{Retry: cfg})
+		var validation *ValidationError
+		if !errors.As(err, &validation) {
+			t.Errorf("expected ValidationError for %+v, got %v", cfg, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Ruby text outputs accepted by ExtractionConfig.RubyText.
+const (
+	// RubyTextInline writes ruby annotations right after their base text, as in 漢字かんじ.
+	RubyTextInline = "inline"
+	// RubyTextParenthesized writes ruby annotations in full-width parentheses after their
+	// base text, as in 漢字（かんじ）.
+	RubyTextParenthesized = "parenthesized"
+	// RubyTextDropped drops ruby annotations, keeping only their base text.
+	RubyTextDropped = "dropped"
+)
+
+const epubMimeType = "application/epub+zip"
+
+// rubyPair is a ruby annotation, such as the furigana かんじ over the base text 漢字.
+type rubyPair struct {
+	base, text string
+}
+
+func validateRubyText(mode string) error {
+	switch mode {
+	case "", RubyTextInline, RubyTextParenthesized, RubyTextDropped:
+		return nil
+	}
+	return newValidationErrorWithContext(fmt.Sprintf("invalid ruby text output: %s", mode), nil, ErrorCodeValidation, nil)
+}
+
+// applyRubyTextStage rewrites the ruby annotations of Word, HTML and EPUB documents in
+// Content, Pages, Chunks and Headings as ExtractionConfig.RubyText asks, remapping byte
+// offsets. The annotations are read from the original returned by read, and found in the
+// text as extraction writes them: base and annotation next to each other in either order,
+// or the annotation in parentheses. It never fails the extraction.
+func applyRubyTextStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.RubyText == "" || result.Content == "" {
+		return
+	}
+	var parse func([]byte) []rubyPair
+	switch result.MimeType {
+	case docxMimeType:
+		parse = docxRubyPairs
+	case "text/html", "application/xhtml+xml":
+		parse = func(data []byte) []rubyPair { return htmlRubyPairs(string(data)) }
+	case epubMimeType:
+		parse = epubRubyPairs
+	default:
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	forms := rubyForms(parse(data), config.RubyText)
+	if len(forms) == 0 {
+		return
+	}
+
+	skip := 0
+	rewriteResultTextAt(result, func(s string, i int, _ rune) (string, bool) {
+		if i == 0 {
+			skip = 0
+		}
+		if i < skip {
+			return "", true
+		}
+		for _, form := range forms {
+			if strings.HasPrefix(s[i:], form[0]) {
+				skip = i + len(form[0])
+				return form[1], true
+			}
+		}
+		return "", false
+	})
+}
+
+// rubyForms returns the ways each annotation of pairs may be written in extracted text,
+// longest first, each with its rewrite in mode.
+func rubyForms(pairs []rubyPair, mode string) [][2]string {
+	seen := map[string]bool{}
+	var forms [][2]string
+	for _, pair := range pairs {
+		if pair.base == "" || pair.text == "" {
+			continue
+		}
+		var replacement string
+		switch mode {
+		case RubyTextInline:
+			replacement = pair.base + pair.text
+		case RubyTextParenthesized:
+			replacement = pair.base + "（" + pair.text + "）"
+		default:
+			replacement = pair.base
+		}
+		for _, form := range []string{
+			pair.base + "（" + pair.text + "）",
+			pair.base + "(" + pair.text + ")",
+			pair.base + pair.text,
+			pair.text + pair.base,
+		} {
+			if !seen[form] {
+				seen[form] = true
+				forms = append(forms, [2]string{form, replacement})
+			}
+		}
+	}
+	sort.SliceStable(forms, func(i, j int) bool { return len(forms[i][0]) > len(forms[j][0]) })
+	return forms
+}
+
+// docxRubyPairs reads the phonetic guides of a Word document, <w:ruby> elements with
+// the annotation runs in <w:rt> and the base runs in <w:rubyBase>.
+func docxRubyPairs(data []byte) []rubyPair {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	var pairs []rubyPair
+	var pair *rubyPair
+	var target *string
+	decoder := xml.NewDecoder(bytes.NewReader(docxPart(archive, "word/document.xml")))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return pairs
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "ruby":
+				pair = &rubyPair{}
+			case "rt":
+				if pair != nil {
+					target = &pair.text
+				}
+			case "rubyBase":
+				if pair != nil {
+					target = &pair.base
+				}
+			case "t":
+				var value string
+				if decoder.DecodeElement(&value, &token) == nil && target != nil {
+					*target += value
+				}
+			}
+		case xml.EndElement:
+			switch token.Name.Local {
+			case "rt", "rubyBase":
+				target = nil
+			case "ruby":
+				if pair != nil {
+					pairs = append(pairs, *pair)
+				}
+				pair = nil
+			}
+		}
+	}
+}
+
+// htmlRubyPairs reads the <ruby> elements of an HTML document. An element may annotate
+// several bases, each followed by its <rt>; <rp> fallback parentheses are skipped.
+func htmlRubyPairs(document string) []rubyPair {
+	var pairs []rubyPair
+	var base, text strings.Builder
+	inRuby, inText, inFallback := false, false, false
+	position := 0
+	for _, match := range htmlTag.FindAllStringSubmatchIndex(document, -1) {
+		content := html.UnescapeString(document[position:match[0]])
+		position = match[1]
+		switch {
+		case !inRuby || inFallback:
+		case inText:
+			text.WriteString(content)
+		default:
+			base.WriteString(content)
+		}
+		if match[4] < 0 {
+			continue // a comment
+		}
+		closing := match[3] > match[2]
+		switch strings.ToLower(document[match[4]:match[5]]) {
+		case "ruby":
+			inRuby, inText, inFallback = !closing, false, false
+			base.Reset()
+			text.Reset()
+		case "rp":
+			inFallback = !closing
+		case "rt":
+			inText = !closing
+			if closing {
+				pairs = append(pairs, rubyPair{base: strings.TrimSpace(base.String()), text: strings.TrimSpace(text.String())})
+				base.Reset()
+				text.Reset()
+			}
+		}
+	}
+	return pairs
+}
+
+// epubRubyPairs reads the <ruby> elements of the XHTML content documents of an EPUB.
+func epubRubyPairs(data []byte) []rubyPair {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil
+	}
+	var pairs []rubyPair
+	for _, file := range archive.File {
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".xhtml", ".html", ".htm":
+			pairs = append(pairs, htmlRubyPairs(string(docxPart(archive, file.Name)))...)
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,64 @@
+package kreuzberg
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestHTMLRubyPairs(t *testing.T) {
+	document := `<p><ruby>漢<rp>(</rp><rt>かん</rt><rp>)</rp>字<rp>(</rp><rt>じ</rt><rp>)</rp></ruby>を<ruby><rb>読</rb><rt>よ</rt></ruby>む</p>`
+	want := []rubyPair{{"漢", "かん"}, {"字", "じ"}, {"読", "よ"}}
+	if got := htmlRubyPairs(document); !reflect.DeepEqual(got, want) {
+		t.Fatalf("htmlRubyPairs = %+v", got)
+	}
+}
+
+func TestRubyTextStage(t *testing.T) {
+	document := `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body><w:p>
+<w:r><w:ruby><w:rubyPr/><w:rt><w:r><w:t>かんじ</w:t></w:r></w:rt><w:rubyBase><w:r><w:t>漢字</w:t></w:r></w:rubyBase></w:ruby></w:r>
+<w:r><w:t>を読む</w:t></w:r></w:p></w:body></w:document>`
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	w, err := archive.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(document)); err != nil {
+		t.Fatal(err)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := docxRubyPairs(buf.Bytes()); !reflect.DeepEqual(got, []rubyPair{{"漢字", "かんじ"}}) {
+		t.Fatalf("docxRubyPairs = %+v", got)
+	}
+
+	tests := []struct {
+		mode, content string
+	}{
+		{RubyTextInline, "漢字かんじを読む。"},
+		{RubyTextParenthesized, "漢字（かんじ）を読む。"},
+		{RubyTextDropped, "漢字を読む。"},
+	}
+	for _, tt := range tests {
+		result := &ExtractionResult{
+			MimeType: docxMimeType,
+			Content:  "かんじ漢字を読む。\n\n次",
+			Chunks:   []Chunk{{Content: "次", Metadata: ChunkMetadata{ByteStart: 29, ByteEnd: 32}}},
+		}
+		applyRubyTextStage(result, func() ([]byte, error) { return buf.Bytes(), nil }, NewExtractionConfig(WithRubyText(tt.mode)))
+		if want := tt.content + "\n\n次"; result.Content != want {
+			t.Errorf("%s: Content = %q, want %q", tt.mode, result.Content, want)
+			continue
+		}
+		if meta := result.Chunks[0].Metadata; result.Content[meta.ByteStart:meta.ByteEnd] != "次" {
+			t.Errorf("%s: chunk offsets %d-%d", tt.mode, meta.ByteStart, meta.ByteEnd)
+		}
+	}
+
+	if err := validateResultStages(NewExtractionConfig(WithRubyText("above"))); err == nil {
+		t.Fatal("expected an unknown ruby text output to be rejected")
+	}
+}
@@ -0,0 +1,366 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"math"
+	"strings"
+)
+
+// Color modes reported in ScanQuality.ColorMode.
+const (
+	// ScanColor is a page scanned in color.
+	ScanColor = "color"
+	// ScanGrayscale is a page scanned in shades of gray.
+	ScanGrayscale = "grayscale"
+	// ScanBitonal is a page scanned in black and white only, as by fax machines.
+	ScanBitonal = "bitonal"
+)
+
+// ScanQuality describes the scanned image of a page, for scanner QA and for choosing the
+// pages to scan again. Fields are nil or empty when they cannot be measured, as for
+// pixels in encodings the binding cannot decode.
+type ScanQuality struct {
+	// DPI is the horizontal resolution of the scan in dots per inch.
+	DPI *float64 `json:"dpi,omitempty"`
+	// ColorMode is ScanColor, ScanGrayscale or ScanBitonal.
+	ColorMode string `json:"color_mode,omitempty"`
+	// SkewAngle is the angle of the text lines in degrees, positive when they rise to the
+	// right. Angles beyond 5 degrees either way are not measured.
+	SkewAngle *float64 `json:"skew_angle,omitempty"`
+	// Noise is the share of dark specks among the dark areas of the page, from 0 for a
+	// clean scan to 1 for one of speckle only.
+	Noise *float64 `json:"noise,omitempty"`
+}
+
+// Scan analysis tuning. Images are reduced to a grid of at most scanGridSize cells per
+// side; a cell is dark when one of its sampled pixels is, so specks survive the reduction.
+const (
+	scanGridSize     = 600
+	scanDarkLevel    = 0.5
+	scanChroma       = 0.15
+	scanColorShare   = 0.01
+	scanMidtoneShare = 0.02
+	scanMaxSkew      = 5.0
+	scanSkewStep     = 0.1
+	scanMinDarkCells = 100
+	// scanPageAspect is the relative difference in aspect ratio between a page and its
+	// largest image up to which the image is taken for a scan of the whole page.
+	scanPageAspect = 0.15
+)
+
+// TIFF fields describing the color of a page.
+const (
+	tiffBitsPerSample = 258
+	tiffPhotometric   = 262
+)
+
+// scanQualityEnabled reports whether config asks for the scan quality of pages.
+func scanQualityEnabled(config *ExtractionConfig) bool {
+	return config.ScanQuality != nil && *config.ScanQuality
+}
+
+// applyScanQualityStage measures the scanned pages of PDFs and images into the Scan field
+// of their PageInfo. The pages of a PDF are measured on their largest image, which must
+// cover the page; images are measured on the original returned by read. Like the other
+// metadata stages it never fails the extraction.
+func applyScanQualityStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || !scanQualityEnabled(config) {
+		return
+	}
+	switch {
+	case result.MimeType == "application/pdf":
+		for number, extracted := range largestPageImages(result) {
+			if scan := pdfPageScan(result, number, extracted); scan != nil {
+				addPageInfo(result, number).Scan = scan
+			}
+		}
+	case strings.HasPrefix(result.MimeType, "image/"):
+		data, err := read()
+		if err != nil {
+			return
+		}
+		for i, scan := range imageScans(data) {
+			addPageInfo(result, uint64(i+1)).Scan = scan
+		}
+	}
+}
+
+// largestPageImages returns the image with the most pixels of each page.
+func largestPageImages(result *ExtractionResult) map[uint64]ExtractedImage {
+	largest := map[uint64]ExtractedImage{}
+	pixels := func(extracted ExtractedImage) uint64 {
+		if extracted.Width == nil || extracted.Height == nil {
+			return 0
+		}
+		return uint64(*extracted.Width) * uint64(*extracted.Height)
+	}
+	for _, extracted := range result.Images {
+		if extracted.IsMask || extracted.PageNumber == nil || *extracted.PageNumber <= 0 {
+			continue
+		}
+		number := uint64(*extracted.PageNumber)
+		if current, ok := largest[number]; !ok || pixels(extracted) > pixels(current) {
+			largest[number] = extracted
+		}
+	}
+	return largest
+}
+
+// pdfPageScan measures the scan of a PDF page from its largest image. It returns nil when
+// the image does not cover the page, judged by aspect ratio, as for photos in born-digital
+// pages.
+func pdfPageScan(result *ExtractionResult, number uint64, extracted ExtractedImage) *ScanQuality {
+	scan := &ScanQuality{ColorMode: colorSpaceMode(extracted.Colorspace, extracted.BitsPerComponent)}
+	if info := pageInfo(result, number); info != nil && info.Dimensions != nil && extracted.Width != nil && extracted.Height != nil {
+		pageWidth, pageHeight := info.Dimensions[0], info.Dimensions[1]
+		width, height := float64(*extracted.Width), float64(*extracted.Height)
+		if pageWidth <= 0 || pageHeight <= 0 || width <= 0 || height <= 0 {
+			return nil
+		}
+		if math.Abs(width/height-pageWidth/pageHeight) > scanPageAspect*pageWidth/pageHeight {
+			return nil
+		}
+		dpi := math.Round(width / (pageWidth / 72))
+		scan.DPI = &dpi
+	}
+	if img, _, err := image.Decode(bytes.NewReader(extracted.Data)); err == nil {
+		measureScan(scan, img)
+	}
+	return scan
+}
+
+// colorSpaceMode infers the color mode of an image from its PDF color space and bit depth.
+func colorSpaceMode(colorspace *string, bits *uint32) string {
+	switch {
+	case bits != nil && *bits == 1:
+		return ScanBitonal
+	case colorspace == nil:
+		return ""
+	case strings.Contains(*colorspace, "Gray"):
+		return ScanGrayscale
+	case strings.Contains(*colorspace, "RGB"), strings.Contains(*colorspace, "CMYK"), strings.Contains(*colorspace, "Lab"):
+		return ScanColor
+	}
+	return ""
+}
+
+// imageScans measures the pages of an image: each page of a TIFF, from its fields, or the
+// single image of other formats.
+func imageScans(data []byte) []*ScanQuality {
+	if _, pages, err := tiffPages(data); err == nil && len(pages) > 0 {
+		scans := make([]*ScanQuality, len(pages))
+		for i, page := range pages {
+			scans[i] = &ScanQuality{ColorMode: tiffColorMode(page)}
+			if dpi := page.resolution(tiffXResolution); dpi > 0 {
+				dpi = math.Round(dpi)
+				scans[i].DPI = &dpi
+			}
+		}
+		if img, _, err := image.Decode(bytes.NewReader(data)); err == nil {
+			measureScan(scans[0], img)
+		}
+		return scans
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	scan := &ScanQuality{}
+	if dpi := imageDPI(data); dpi > 0 {
+		dpi = math.Round(dpi)
+		scan.DPI = &dpi
+	}
+	measureScan(scan, img)
+	return []*ScanQuality{scan}
+}
+
+// tiffColorMode reads the color mode of a TIFF page from its photometric interpretation
+// and bit depth.
+func tiffColorMode(page tiffPage) string {
+	switch photometric := page.field(tiffPhotometric); {
+	case photometric <= 1 && page.field(tiffBitsPerSample) <= 1:
+		return ScanBitonal
+	case photometric <= 1:
+		return ScanGrayscale
+	case photometric == 2 || photometric == 3 || photometric == 5 || photometric == 6:
+		return ScanColor
+	}
+	return ""
+}
+
+// imageDPI returns the horizontal resolution recorded in an image: its EXIF resolution,
+// the density of a JFIF header or the pHYs chunk of a PNG. It returns 0 when none is.
+func imageDPI(data []byte) float64 {
+	if block := exifTIFF(data); block != nil {
+		if _, pages, err := tiffPages(block); err == nil && len(pages) > 0 {
+			if dpi := pages[0].resolution(tiffXResolution); dpi > 0 {
+				return dpi
+			}
+		}
+	}
+	// JFIF APP0: "JFIF\0", version (2), units (1), X density (2), Y density (2).
+	if at := bytes.Index(data, []byte("JFIF\x00")); at >= 0 && at+10 <= len(data) {
+		density := float64(binary.BigEndian.Uint16(data[at+8:]))
+		switch data[at+7] {
+		case 1:
+			return density
+		case 2:
+			return density * 2.54
+		}
+	}
+	// PNG pHYs: pixels per unit X (4), Y (4), unit (1), where unit 1 is the metre.
+	if at := bytes.Index(data, []byte("pHYs")); at >= 0 && at+13 <= len(data) && data[at+12] == 1 {
+		return float64(binary.BigEndian.Uint32(data[at+4:])) * 0.0254
+	}
+	return 0
+}
+
+// measureScan fills in the color mode when unknown, the skew angle and the noise of scan
+// from the pixels of img.
+func measureScan(scan *ScanQuality, img image.Image) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return
+	}
+	cell := max(1, (max(bounds.Dx(), bounds.Dy())+scanGridSize-1)/scanGridSize)
+	cols := (bounds.Dx() + cell - 1) / cell
+	rows := (bounds.Dy() + cell - 1) / cell
+	dark := make([]bool, cols*rows)
+	step := max(1, cell/3)
+	samples, colored, midtones := 0, 0, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			r, g, b, _ := img.At(x, y).RGBA()
+			luminance := (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)) / 0xffff
+			samples++
+			if float64(max(r, g, b)-min(r, g, b))/0xffff >= scanChroma {
+				colored++
+			}
+			if luminance > 0.2 && luminance < 0.8 {
+				midtones++
+			}
+			if luminance < scanDarkLevel {
+				dark[(y-bounds.Min.Y)/cell*cols+(x-bounds.Min.X)/cell] = true
+			}
+		}
+	}
+	if scan.ColorMode == "" {
+		switch {
+		case float64(colored) > scanColorShare*float64(samples):
+			scan.ColorMode = ScanColor
+		case float64(midtones) < scanMidtoneShare*float64(samples):
+			scan.ColorMode = ScanBitonal
+		default:
+			scan.ColorMode = ScanGrayscale
+		}
+	}
+	if skew, ok := skewAngle(dark, cols, rows); ok {
+		scan.SkewAngle = &skew
+	}
+	if noise, ok := speckleShare(dark, cols, rows); ok {
+		scan.Noise = &noise
+	}
+}
+
+// skewAngle finds the angle whose projection of the dark cells onto the vertical axis is
+// the most peaked, which is the angle at which text lines run. It returns false for pages
+// with too little ink to tell.
+func skewAngle(dark []bool, cols, rows int) (float64, bool) {
+	type point struct{ x, y float64 }
+	var points []point
+	for i, isDark := range dark {
+		if isDark {
+			points = append(points, point{float64(i % cols), float64(i / cols)})
+		}
+	}
+	if len(points) < scanMinDarkCells {
+		return 0, false
+	}
+	// A line rising to the right at angle a keeps y + x*tan(a) constant.
+	reach := int(math.Ceil(float64(cols) * math.Tan(scanMaxSkew*math.Pi/180)))
+	bins := make([]int, rows+2*reach+1)
+	best, bestScore := 0.0, -1
+	steps := int(math.Round(scanMaxSkew / scanSkewStep))
+	for s := -steps; s <= steps; s++ {
+		angle := float64(s) * scanSkewStep
+		slope := math.Tan(angle * math.Pi / 180)
+		clear(bins)
+		for _, p := range points {
+			bins[int(math.Round(p.y+p.x*slope))+reach]++
+		}
+		score := 0
+		for _, n := range bins {
+			score += n * n
+		}
+		if score > bestScore || (score == bestScore && math.Abs(angle) < math.Abs(best)) {
+			best, bestScore = angle, score
+		}
+	}
+	return math.Round(best*10) / 10, true
+}
+
+// speckleShare returns the share of dark cells without a dark neighbour, rounded to three
+// decimals. It returns false when no cell is dark.
+func speckleShare(dark []bool, cols, rows int) (float64, bool) {
+	total, isolated := 0, 0
+	for i, isDark := range dark {
+		if !isDark {
+			continue
+		}
+		total++
+		x, y := i%cols, i/cols
+		alone := true
+		for dy := -1; dy <= 1 && alone; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				nx, ny := x+dx, y+dy
+				if (dx != 0 || dy != 0) && nx >= 0 && ny >= 0 && nx < cols && ny < rows && dark[ny*cols+nx] {
+					alone = false
+					break
+				}
+			}
+		}
+		if alone {
+			isolated++
+		}
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return math.Round(float64(isolated)/float64(total)*1000) / 1000, true
+}
+
+// pageInfo returns the PageInfo of page number, or nil.
+func pageInfo(result *ExtractionResult, number uint64) *PageInfo {
+	if result.Metadata.PageStructure == nil {
+		return nil
+	}
+	for i := range result.Metadata.PageStructure.Pages {
+		if result.Metadata.PageStructure.Pages[i].Number == number {
+			return &result.Metadata.PageStructure.Pages[i]
+		}
+	}
+	return nil
+}
+
+// addPageInfo returns the PageInfo of page number, adding it, and the PageStructure of
+// single images, when there is none.
+func addPageInfo(result *ExtractionResult, number uint64) *PageInfo {
+	if info := pageInfo(result, number); info != nil {
+		return info
+	}
+	if result.Metadata.PageStructure == nil {
+		result.Metadata.PageStructure = &PageStructure{UnitType: PageUnitTypePage}
+	}
+	structure := result.Metadata.PageStructure
+	structure.TotalCount = max(structure.TotalCount, number)
+	at := len(structure.Pages)
+	for at > 0 && structure.Pages[at-1].Number > number {
+		at--
+	}
+	structure.Pages = append(structure.Pages, PageInfo{})
+	copy(structure.Pages[at+1:], structure.Pages[at:])
+	structure.Pages[at] = PageInfo{Number: number}
+	return &structure.Pages[at]
+}
@@ -0,0 +1,97 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// scannedPage draws text-like dashed lines rising to the right at angle degrees on a white
+// page of width×height pixels, with specks every specks pixels of the left margin.
+func scannedPage(width, height int, angle float64, specks int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	slope := math.Tan(angle * math.Pi / 180)
+	for line := 100; line < height-100; line += 40 {
+		for x := 50; x < width-50; x++ {
+			if x%30 > 24 {
+				continue
+			}
+			y := line - int(math.Round(float64(x)*slope))
+			for dy := 0; dy < 8; dy++ {
+				img.SetGray(x, y+dy, color.Gray{})
+			}
+		}
+	}
+	for y := 0; specks > 0 && y < height; y += specks {
+		for x := 2; x < 48; x += specks {
+			img.SetGray(x, y, color.Gray{})
+		}
+	}
+	return img
+}
+
+func TestMeasureScan(t *testing.T) {
+	scan := &ScanQuality{}
+	measureScan(scan, scannedPage(1200, 1600, 2, 0))
+	if scan.ColorMode != ScanBitonal || scan.SkewAngle == nil || math.Abs(*scan.SkewAngle-2) > 0.2 {
+		t.Fatalf("scan = %s, skew %v", scan.ColorMode, scan.SkewAngle)
+	}
+	if scan.Noise == nil || *scan.Noise > 0.01 {
+		t.Fatalf("clean page noise = %v", scan.Noise)
+	}
+
+	noisy := &ScanQuality{}
+	measureScan(noisy, scannedPage(1200, 1600, -1.5, 8))
+	if noisy.SkewAngle == nil || math.Abs(*noisy.SkewAngle+1.5) > 0.2 || *noisy.Noise <= *scan.Noise {
+		t.Fatalf("noisy scan skew %v, noise %v", *noisy.SkewAngle, *noisy.Noise)
+	}
+
+	gray := scannedPage(400, 400, 0, 0)
+	for i := range gray.Pix {
+		gray.Pix[i] = uint8(i % 256)
+	}
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, gray); err != nil {
+		t.Fatal(err)
+	}
+	scans := imageScans(encoded.Bytes())
+	if len(scans) != 1 || scans[0].ColorMode != ScanGrayscale || scans[0].DPI != nil {
+		t.Fatalf("imageScans = %+v", scans)
+	}
+}
+
+func TestScanQualityStagePDF(t *testing.T) {
+	width, height, page := uint32(2550), uint32(3300), 1
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, scannedPage(int(width), int(height), 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	photo, photoHeight, gray := uint32(3000), uint32(600), "DeviceGray"
+	result := &ExtractionResult{
+		MimeType: "application/pdf",
+		Metadata: Metadata{PageStructure: &PageStructure{TotalCount: 2, Pages: []PageInfo{
+			{Number: 1, Dimensions: &[2]float64{612, 792}},
+			{Number: 2, Dimensions: &[2]float64{612, 792}},
+		}}},
+		Images: []ExtractedImage{
+			{Data: encoded.Bytes(), PageNumber: &page, Width: &width, Height: &height, Colorspace: &gray},
+			{PageNumber: new(int), Width: &photo, Height: &photoHeight},
+		},
+	}
+	*result.Images[1].PageNumber = 2
+	applyScanQualityStage(result, nil, NewExtractionConfig(WithScanQuality(true)))
+
+	scan := result.Metadata.PageStructure.Pages[0].Scan
+	if scan == nil || scan.DPI == nil || *scan.DPI != 300 || scan.ColorMode != ScanGrayscale || scan.SkewAngle == nil || *scan.SkewAngle != 0 {
+		t.Fatalf("page 1 scan = %+v", scan)
+	}
+	if result.Metadata.PageStructure.Pages[1].Scan != nil {
+		t.Fatal("a photo not covering the page is not a scan")
+	}
+}
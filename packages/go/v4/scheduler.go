@@ -0,0 +1,196 @@
+package kreuzberg
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// Priority orders the calls waiting for a Scheduler: a free slot goes to a waiting call of
+// the highest priority first, so that interactive calls do not queue behind bulk work.
+type Priority int
+
+const (
+	// PriorityBulk is the priority of batch work, such as backfills.
+	PriorityBulk Priority = iota
+	// PriorityInteractive is the priority of single-document requests.
+	PriorityInteractive
+
+	priorityLevels = int(PriorityInteractive) + 1
+)
+
+// Scheduler shares the native core between the tenants of a process. It runs a bounded
+// number of extractions at once. When calls are waiting, each free slot goes to the
+// highest priority with waiting calls, and within a priority to the tenants in turn, each
+// served up to its weight (SetWeight) before the next, so a tenant issuing many concurrent
+// calls cannot starve the others. Priorities are strict: bulk calls wait as long as
+// interactive calls do. It is safe for concurrent use.
+//
+// Wrap each extraction in Acquire and the function it returns:
+//
+//	release, err := scheduler.Acquire(ctx, tenant, kreuzberg.PriorityInteractive)
+//	if err != nil {
+//		return nil, err
+//	}
+//	defer release()
+//	return kreuzberg.ExtractFileWithContext(ctx, path, config)
+type Scheduler struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	queued  int
+	weights map[string]int
+	levels  [priorityLevels]schedulerQueue
+}
+
+// schedulerQueue holds the waiting calls of one priority.
+type schedulerQueue struct {
+	// ring lists the tenants with waiting calls in turn order; next is the index of the
+	// tenant served next, and served the number of calls granted to it in this turn.
+	ring    []string
+	next    int
+	served  int
+	waiting map[string][]chan struct{}
+}
+
+// NewScheduler creates a scheduler running up to concurrency extractions at once. Native
+// extraction is serialized by the library, so concurrency mostly bounds the Go-side stages
+// that run around it; values below 1 are 1.
+func NewScheduler(concurrency int) *Scheduler {
+	s := &Scheduler{slots: max(concurrency, 1), weights: make(map[string]int)}
+	for i := range s.levels {
+		s.levels[i].waiting = make(map[string][]chan struct{})
+	}
+	return s
+}
+
+// SetWeight sets the number of calls of tenant served in a row before turning to the next
+// tenant of the same priority. Tenants default to 1; values below 1 are 1.
+func (s *Scheduler) SetWeight(tenant string, weight int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if weight <= 1 {
+		delete(s.weights, tenant)
+		return
+	}
+	s.weights[tenant] = weight
+}
+
+// Acquire waits for a slot for a call of tenant at priority and returns the function that
+// frees it, which must be called once the call returns. It returns the context's error,
+// without a slot, if ctx is done first.
+func (s *Scheduler) Acquire(ctx context.Context, tenant string, priority Priority) (func(), error) {
+	priority = min(max(priority, PriorityBulk), PriorityInteractive)
+	s.mu.Lock()
+	if s.running < s.slots && s.queued == 0 {
+		s.running++
+		s.mu.Unlock()
+		return s.releaseFunc(), nil
+	}
+	ready := make(chan struct{})
+	queue := &s.levels[priority]
+	if len(queue.waiting[tenant]) == 0 {
+		queue.ring = append(queue.ring, tenant)
+	}
+	queue.waiting[tenant] = append(queue.waiting[tenant], ready)
+	s.queued++
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return s.releaseFunc(), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		select {
+		case <-ready:
+			// The slot was granted while the context was canceled; pass it on.
+			s.running--
+			s.grant()
+		default:
+			if queue.remove(tenant, ready) {
+				s.queued--
+			}
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (s *Scheduler) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.running--
+			s.grant()
+			s.mu.Unlock()
+		})
+	}
+}
+
+// grant hands free slots to waiting calls, highest priority first. The caller holds s.mu.
+func (s *Scheduler) grant() {
+	for s.running < s.slots && s.queued > 0 {
+		for i := len(s.levels) - 1; i >= 0; i-- {
+			if ready := s.pop(&s.levels[i]); ready != nil {
+				s.queued--
+				s.running++
+				close(ready)
+				break
+			}
+		}
+	}
+}
+
+// pop takes the next waiting call of q in ring order, moving to the next tenant once the
+// current one has been served its weight in this turn. It returns nil when no call waits.
+// The caller holds s.mu.
+func (s *Scheduler) pop(q *schedulerQueue) chan struct{} {
+	if len(q.ring) == 0 {
+		return nil
+	}
+	if q.next >= len(q.ring) {
+		q.next = 0
+	}
+	tenant := q.ring[q.next]
+	queue := q.waiting[tenant]
+	ready := queue[0]
+	q.served++
+	switch {
+	case len(queue) == 1:
+		delete(q.waiting, tenant)
+		q.ring = slices.Delete(q.ring, q.next, q.next+1)
+		q.served = 0
+	case q.served >= max(s.weights[tenant], 1):
+		q.waiting[tenant] = queue[1:]
+		q.next++
+		q.served = 0
+	default:
+		q.waiting[tenant] = queue[1:]
+	}
+	return ready
+}
+
+// remove withdraws a waiting call of tenant, reporting whether it was waiting.
+func (q *schedulerQueue) remove(tenant string, ready chan struct{}) bool {
+	queue := q.waiting[tenant]
+	i := slices.Index(queue, ready)
+	if i < 0 {
+		return false
+	}
+	if len(queue) > 1 {
+		q.waiting[tenant] = slices.Delete(queue, i, i+1)
+		return true
+	}
+	delete(q.waiting, tenant)
+	if j := slices.Index(q.ring, tenant); j >= 0 {
+		q.ring = slices.Delete(q.ring, j, j+1)
+		switch {
+		case j < q.next:
+			q.next--
+		case j == q.next:
+			q.served = 0
+		}
+	}
+	return true
+}
@@ -0,0 +1,162 @@
+package kreuzberg
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitQueued waits until the scheduler has n waiting calls.
+func waitQueued(t *testing.T, s *Scheduler, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		queued := s.queued
+		s.mu.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("expected %d queued calls", n)
+}
+
+// schedulerRecorder queues calls on a scheduler one at a time and records the order in
+// which they are granted.
+type schedulerRecorder struct {
+	t         *testing.T
+	scheduler *Scheduler
+	queued    int
+
+	mu    sync.Mutex
+	order []string
+	wg    sync.WaitGroup
+}
+
+// call queues a call labeled label and waits until it is waiting.
+func (r *schedulerRecorder) call(tenant, label string, priority Priority) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		release, err := r.scheduler.Acquire(context.Background(), tenant, priority)
+		if err != nil {
+			r.t.Errorf("acquire %s: %v", label, err)
+			return
+		}
+		r.mu.Lock()
+		r.order = append(r.order, label)
+		r.mu.Unlock()
+		release()
+	}()
+	r.queued++
+	waitQueued(r.t, r.scheduler, r.queued)
+}
+
+// run frees hold and returns the order in which the queued calls ran.
+func (r *schedulerRecorder) run(hold func()) []string {
+	hold()
+	r.wg.Wait()
+	return r.order
+}
+
+// holdScheduler takes the only slot of a new scheduler.
+func holdScheduler(t *testing.T) (*Scheduler, func()) {
+	t.Helper()
+	scheduler := NewScheduler(1)
+	hold, err := scheduler.Acquire(context.Background(), "holder", PriorityBulk)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	return scheduler, hold
+}
+
+func TestSchedulerRoundRobin(t *testing.T) {
+	scheduler, hold := holdScheduler(t)
+	r := &schedulerRecorder{t: t, scheduler: scheduler}
+	for _, label := range []string{"a1", "a2", "a3"} {
+		r.call("a", label, PriorityBulk)
+	}
+	r.call("b", "b1", PriorityBulk)
+
+	if order, want := r.run(hold), []string{"a1", "b1", "a2", "a3"}; !slices.Equal(order, want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+}
+
+func TestSchedulerPriorityAndWeight(t *testing.T) {
+	scheduler, hold := holdScheduler(t)
+	scheduler.SetWeight("a", 2)
+	r := &schedulerRecorder{t: t, scheduler: scheduler}
+	for _, label := range []string{"a1", "a2", "a3"} {
+		r.call("a", label, PriorityBulk)
+	}
+	r.call("b", "b1", PriorityBulk)
+	r.call("c", "c1", PriorityInteractive)
+
+	if order, want := r.run(hold), []string{"c1", "a1", "a2", "b1", "a3"}; !slices.Equal(order, want) {
+		t.Fatalf("expected order %v, got %v", want, order)
+	}
+}
+
+func TestSchedulerInteractiveNotStarvedByBulk(t *testing.T) {
+	scheduler, hold := holdScheduler(t)
+	r := &schedulerRecorder{t: t, scheduler: scheduler}
+	for i := range 20 {
+		r.call("backfill", "bulk", PriorityBulk)
+		if i == 10 {
+			r.call("web", "interactive", PriorityInteractive)
+		}
+	}
+
+	order := r.run(hold)
+	if len(order) != 21 || order[0] != "interactive" {
+		t.Fatalf("expected the interactive call to run first, got %v", order)
+	}
+}
+
+func TestSchedulerTenantNotStarvedByHeavyTenant(t *testing.T) {
+	scheduler, hold := holdScheduler(t)
+	scheduler.SetWeight("heavy", 3)
+	r := &schedulerRecorder{t: t, scheduler: scheduler}
+	for range 30 {
+		r.call("heavy", "heavy", PriorityBulk)
+	}
+	r.call("light", "light", PriorityBulk)
+
+	order := r.run(hold)
+	if i := slices.Index(order, "light"); i < 0 || i > 3 {
+		t.Fatalf("expected the light tenant to run within the heavy tenant's weight, got position %d of %v", i, order)
+	}
+}
+
+func TestSchedulerCancelWithdrawsCall(t *testing.T) {
+	scheduler, hold := holdScheduler(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	canceled := make(chan error, 1)
+	go func() {
+		_, err := scheduler.Acquire(ctx, "b", PriorityBulk)
+		canceled <- err
+	}()
+	waitQueued(t, scheduler, 1)
+	cancel()
+	if err := <-canceled; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	waitQueued(t, scheduler, 0)
+
+	hold()
+	release, err := scheduler.Acquire(context.Background(), "b", PriorityBulk)
+	if err != nil {
+		t.Fatalf("acquire after cancel: %v", err)
+	}
+	release()
+	release()
+	if waiting := len(scheduler.levels[PriorityBulk].ring); scheduler.running != 0 || waiting != 0 {
+		t.Fatalf("expected an idle scheduler, got %d running and %d waiting tenants", scheduler.running, waiting)
+	}
+}
@@ -0,0 +1,126 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"regexp"
+	"unicode/utf8"
+)
+
+// SearchOptions controls ExtractionResult.Search.
+type SearchOptions struct {
+	// Regex treats the query as a regular expression (RE2 syntax) instead of literal text.
+	Regex bool
+	// IgnoreCase matches regardless of letter case.
+	IgnoreCase bool
+	// WholeWord only matches text that is neither preceded nor followed by a letter or
+	// digit, in any script.
+	WholeWord bool
+	// Limit caps the number of hits. Zero returns all hits.
+	Limit int
+	// Context is the number of bytes of surrounding text to include in SearchHit.Snippet,
+	// extended to rune boundaries. Zero leaves Snippet empty.
+	Context int
+}
+
+// SearchHit is a match in Content. PageNumber is the page holding ByteStart, or 0 when
+// the result has no page boundaries; PageSpans lists every page the match covers.
+type SearchHit struct {
+	Text       string     `json:"text"`
+	ByteStart  uint64     `json:"byte_start"`
+	ByteEnd    uint64     `json:"byte_end"`
+	PageNumber uint64     `json:"page_number,omitempty"`
+	PageSpans  []PageSpan `json:"page_spans,omitempty"`
+	Snippet    string     `json:"snippet,omitempty"`
+}
+
+// Search finds query in Content and maps every hit to its byte range and pages. Empty
+// matches of a regular expression are skipped. A nil opts performs a case-sensitive
+// literal search.
+func (r *ExtractionResult) Search(query string, opts *SearchOptions) ([]SearchHit, error) {
+	if query == "" {
+		return nil, newValidationErrorWithContext("search query cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	if opts == nil {
+		opts = &SearchOptions{}
+	}
+	if opts.Limit < 0 || opts.Context < 0 {
+		return nil, newValidationErrorWithContext("search limit and context cannot be negative", nil, ErrorCodeValidation, nil)
+	}
+	pattern, err := searchPattern(query, opts)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, nil
+	}
+
+	limit := -1
+	if opts.Limit > 0 {
+		limit = opts.Limit
+	}
+	var hits []SearchHit
+	for _, match := range pattern.FindAllStringIndex(r.Content, -1) {
+		if match[0] == match[1] {
+			continue
+		}
+		if opts.WholeWord && !wordBounded(r.Content, match[0], match[1]) {
+			continue
+		}
+		if len(hits) == limit {
+			break
+		}
+		hit := SearchHit{
+			Text:      r.Content[match[0]:match[1]],
+			ByteStart: uint64(match[0]),
+			ByteEnd:   uint64(match[1]),
+			PageSpans: r.PageSpans(uint64(match[0]), uint64(match[1])),
+		}
+		if len(hit.PageSpans) > 0 {
+			hit.PageNumber = hit.PageSpans[0].PageNumber
+		}
+		if opts.Context > 0 {
+			hit.Snippet = snippet(r.Content, match[0], match[1], opts.Context)
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
+
+// searchPattern compiles the query according to opts.
+func searchPattern(query string, opts *SearchOptions) (*regexp.Regexp, error) {
+	expr := query
+	if !opts.Regex {
+		expr = regexp.QuoteMeta(query)
+	}
+	if opts.IgnoreCase {
+		expr = `(?i)` + expr
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, newValidationErrorWithContext(fmt.Sprintf("invalid search pattern: %s", query), err, ErrorCodeValidation, nil)
+	}
+	return pattern, nil
+}
+
+// wordBounded reports whether content[start:end] is neither preceded nor followed by a
+// word rune. RE2's \b only knows ASCII word characters, so it would match inside
+// words such as "straße" or "日本語".
+func wordBounded(content string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(content[:start])
+	after, _ := utf8.DecodeRuneInString(content[end:])
+	return !isWordRune(before) && !isWordRune(after)
+}
+
+// snippet returns content[start:end] with up to context bytes on either side, widened
+// so it does not split a rune.
+func snippet(content string, start, end, context int) string {
+	from := max(0, start-context)
+	for from > 0 && !utf8.RuneStart(content[from]) {
+		from--
+	}
+	to := min(len(content), end+context)
+	for to < len(content) && !utf8.RuneStart(content[to]) {
+		to++
+	}
+	return content[from:to]
+}
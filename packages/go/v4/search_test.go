@@ -0,0 +1,83 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func searchTestResult() *ExtractionResult {
+	content := "Invoice total due.\nThe TOTAL is 42 EUR.\nSubtotal: 40 EUR"
+	return &ExtractionResult{
+		Content: content,
+		Metadata: Metadata{PageStructure: &PageStructure{Boundaries: []PageBoundary{
+			{ByteStart: 0, ByteEnd: 19, PageNumber: 1},
+			{ByteStart: 19, ByteEnd: uint64(len(content)), PageNumber: 2},
+		}}},
+	}
+}
+
+func TestSearchLiteral(t *testing.T) {
+	result := searchTestResult()
+
+	hits, err := result.Search("total", nil)
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].PageNumber != 1 || hits[1].PageNumber != 2 || hits[1].Text != "total" {
+		t.Fatalf("unexpected case-sensitive hits: %+v", hits)
+	}
+	if got := result.Content[hits[0].ByteStart:hits[0].ByteEnd]; got != "total" {
+		t.Errorf("hit offsets point at %q", got)
+	}
+
+	hits, err = result.Search("total", &SearchOptions{IgnoreCase: true, WholeWord: true, Context: 4})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[1].Text != "TOTAL" || hits[1].Snippet != "The TOTAL is " {
+		t.Fatalf("unexpected whole-word hits: %+v", hits)
+	}
+
+	words := &ExtractionResult{Content: "Straße strasse, Maße; 東京都 東京"}
+	hits, _ = words.Search("Maß", &SearchOptions{WholeWord: true})
+	if len(hits) != 0 {
+		t.Fatalf("whole-word search matched inside a word: %+v", hits)
+	}
+	hits, _ = words.Search("東京", &SearchOptions{WholeWord: true})
+	if len(hits) != 1 || hits[0].ByteStart != uint64(len("Straße strasse, Maße; 東京都 ")) {
+		t.Fatalf("unexpected CJK whole-word hits: %+v", hits)
+	}
+
+	hits, _ = result.Search("EUR.", &SearchOptions{Limit: 1})
+	if len(hits) != 1 || hits[0].Text != "EUR." {
+		t.Fatalf("literal query should not be a pattern: %+v", hits)
+	}
+}
+
+func TestSearchRegex(t *testing.T) {
+	result := searchTestResult()
+	hits, err := result.Search(`\d+ EUR`, &SearchOptions{Regex: true})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(hits) != 2 || hits[0].Text != "42 EUR" || hits[1].PageSpans[0].PageNumber != 2 {
+		t.Fatalf("unexpected regex hits: %+v", hits)
+	}
+
+	hits, _ = result.Search(`due\.\nThe`, &SearchOptions{Regex: true})
+	if len(hits) != 1 || len(hits[0].PageSpans) != 2 {
+		t.Fatalf("hit across a page break should span both pages: %+v", hits)
+	}
+
+	if hits, _ := result.Search(`x*`, &SearchOptions{Regex: true}); len(hits) != 0 {
+		t.Errorf("empty matches should be skipped, got %d", len(hits))
+	}
+
+	var validationErr *ValidationError
+	if _, err := result.Search(`(`, &SearchOptions{Regex: true}); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for invalid pattern, got %v", err)
+	}
+	if _, err := result.Search("", nil); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError for empty query, got %v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Section labels reported in Section.Label.
+const (
+	SectionAbstract       = "abstract"
+	SectionIntroduction   = "introduction"
+	SectionTerms          = "terms"
+	SectionSignatureBlock = "signature_block"
+	SectionAppendix       = "appendix"
+)
+
+// maxSectionHeadingChars bounds the length of a plain-text line treated as a heading.
+const maxSectionHeadingChars = 80
+
+// sectionTitles maps normalized heading texts to section labels.
+var sectionTitles = map[string]string{
+	"abstract":                       SectionAbstract,
+	"summary":                        SectionAbstract,
+	"executive summary":              SectionAbstract,
+	"introduction":                   SectionIntroduction,
+	"background":                     SectionIntroduction,
+	"overview":                       SectionIntroduction,
+	"terms":                          SectionTerms,
+	"terms and conditions":           SectionTerms,
+	"terms of use":                   SectionTerms,
+	"terms of service":               SectionTerms,
+	"general terms":                  SectionTerms,
+	"definitions":                    SectionTerms,
+	"definitions and interpretation": SectionTerms,
+	"signature":                      SectionSignatureBlock,
+	"signatures":                     SectionSignatureBlock,
+	"signature page":                 SectionSignatureBlock,
+	"execution":                      SectionSignatureBlock,
+	"appendices":                     SectionAppendix,
+}
+
+// appendixTitle matches appendix headings such as "Appendix", "Annex III", or
+// "Exhibit 2: Pricing"; the optional identifier must end the title or be followed by a separator.
+var appendixTitle = regexp.MustCompile(`^(?:appendix|annex|exhibit|schedule|attachment)(?:\s+(?:[a-z]|\d+(?:\.\d+)*|[ivxlc]+))?(?:\s*[.:)\-–—].*)?$`)
+
+// signatureLeadIns start a signature block even inside a longer line.
+var signatureLeadIns = []string{"in witness whereof", "in witness thereof", "signed, sealed and delivered", "executed as a deed"}
+
+// sectionNumbering matches leading numbering such as "1.", "2.3", "IV.", or "Article 5:".
+var sectionNumbering = regexp.MustCompile(`^(?:(?:article|section|part|chapter)\s+)?(?:\d+(?:\.\d+)*[.):]?|[ivxlc]+[.):])\s+`)
+
+func validateSectionDetectionConfig(cfg *SectionDetectionConfig) error {
+	for _, label := range cfg.Labels {
+		switch label {
+		case SectionAbstract, SectionIntroduction, SectionTerms, SectionSignatureBlock, SectionAppendix:
+		default:
+			return newValidationErrorWithContext(fmt.Sprintf("invalid section label: %s", label), nil, ErrorCodeValidation, nil)
+		}
+	}
+	return nil
+}
+
+// normalizeSectionTitle lowercases a heading and strips numbering and trailing punctuation.
+func normalizeSectionTitle(text string) string {
+	title := strings.ToLower(strings.TrimSpace(text))
+	title = strings.Trim(title, "*_ ")
+	title = sectionNumbering.ReplaceAllString(title, "")
+	return strings.TrimSpace(strings.TrimRight(title, ".:"))
+}
+
+// sectionLabel returns the label of a heading text, if it names a known section.
+func sectionLabel(text string) string {
+	title := normalizeSectionTitle(text)
+	if label, ok := sectionTitles[title]; ok {
+		return label
+	}
+	if appendixTitle.MatchString(title) {
+		return SectionAppendix
+	}
+	for _, leadIn := range signatureLeadIns {
+		if strings.HasPrefix(title, leadIn) {
+			return SectionSignatureBlock
+		}
+	}
+	return ""
+}
+
+// sectionHeading is a line that may start or end a section.
+type sectionHeading struct {
+	start int
+	level int // Markdown heading level; 0 for plain-text lines.
+	label string
+	text  string
+}
+
+// scanSectionHeadings returns Markdown headings and plain-text lines that name a section.
+func scanSectionHeadings(content string) []sectionHeading {
+	var headings []sectionHeading
+	fence := ""
+	forEachLine(content, func(pos int, line string) {
+		switch {
+		case fence != "":
+			if strings.HasPrefix(strings.TrimLeft(line, " "), fence) {
+				fence = ""
+			}
+		case fenceMarker(line) != "":
+			fence = fenceMarker(line)
+		default:
+			if level, text, ok := parseATXHeading(line); ok {
+				headings = append(headings, sectionHeading{start: pos, level: level, label: sectionLabel(text), text: text})
+				break
+			}
+			trimmed := strings.TrimSpace(line)
+			if label := sectionLabel(trimmed); label != "" {
+				if len([]rune(trimmed)) <= maxSectionHeadingChars {
+					headings = append(headings, sectionHeading{start: pos, label: label, text: trimmed})
+				} else if label == SectionSignatureBlock {
+					headings = append(headings, sectionHeading{start: pos, label: label})
+				}
+			}
+		}
+	})
+	return headings
+}
+
+// sectionEndsAt reports whether next terminates the section started by h. Sections run
+// until the next labeled heading or the next Markdown heading at the same or a higher level.
+func sectionEndsAt(h, next sectionHeading) bool {
+	if next.label != "" {
+		return true
+	}
+	if next.level == 0 {
+		return false
+	}
+	return h.level == 0 || next.level <= h.level
+}
+
+// DetectSections labels regions of text such as the abstract, introduction, terms,
+// signature block, and appendices. Sections are recognized by their headings, either
+// Markdown headings or standalone lines, optionally numbered ("2. Definitions").
+// A nil cfg detects all labels.
+func DetectSections(text string, cfg *SectionDetectionConfig) []Section {
+	var allowed map[string]bool
+	if cfg != nil && len(cfg.Labels) > 0 {
+		allowed = make(map[string]bool, len(cfg.Labels))
+		for _, label := range cfg.Labels {
+			allowed[label] = true
+		}
+	}
+
+	headings := scanSectionHeadings(text)
+	var sections []Section
+	for i, h := range headings {
+		if h.label == "" || (allowed != nil && !allowed[h.label]) {
+			continue
+		}
+		end := len(text)
+		for _, next := range headings[i+1:] {
+			if sectionEndsAt(h, next) {
+				end = next.start
+				break
+			}
+		}
+		end = h.start + len(strings.TrimRightFunc(text[h.start:end], unicode.IsSpace))
+		sections = append(sections, Section{
+			Label:     h.label,
+			Heading:   h.text,
+			ByteStart: uint64(h.start),
+			ByteEnd:   uint64(end),
+		})
+	}
+	return sections
+}
@@ -0,0 +1,107 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const sectionTestDoc = `# Service Agreement
+
+## Abstract
+
+A short summary.
+
+## 1. Introduction
+
+Why this agreement exists.
+
+### 1.1 Scope
+
+Scope details.
+
+## 2. Terms and Conditions
+
+The parties agree.
+
+IN WITNESS WHEREOF, the parties have executed this agreement on the date below.
+
+Signed: ________
+
+Appendix A: Pricing
+
+Price list.
+`
+
+func TestDetectSections(t *testing.T) {
+	sections := DetectSections(sectionTestDoc, nil)
+
+	want := []struct {
+		label string
+		start string
+		end   string
+	}{
+		{SectionAbstract, "## Abstract", "A short summary."},
+		{SectionIntroduction, "## 1. Introduction", "Scope details."},
+		{SectionTerms, "## 2. Terms and Conditions", "The parties agree."},
+		{SectionSignatureBlock, "IN WITNESS WHEREOF", "Signed: ________"},
+		{SectionAppendix, "Appendix A: Pricing", "Price list."},
+	}
+	if len(sections) != len(want) {
+		t.Fatalf("expected %d sections, got %+v", len(want), sections)
+	}
+	for i, section := range sections {
+		text := sectionTestDoc[section.ByteStart:section.ByteEnd]
+		if section.Label != want[i].label {
+			t.Errorf("section %d label = %q, want %q", i, section.Label, want[i].label)
+		}
+		if !strings.HasPrefix(text, want[i].start) || !strings.HasSuffix(text, want[i].end) {
+			t.Errorf("section %d (%s) covers %q", i, section.Label, text)
+		}
+	}
+	if sections[1].Heading != "1. Introduction" {
+		t.Errorf("unexpected heading: %q", sections[1].Heading)
+	}
+}
+
+func TestDetectSectionsLabelFilter(t *testing.T) {
+	sections := DetectSections(sectionTestDoc, NewSectionDetectionConfig(WithSectionLabels(SectionAppendix)))
+	if len(sections) != 1 || sections[0].Label != SectionAppendix {
+		t.Fatalf("expected only the appendix, got %+v", sections)
+	}
+}
+
+func TestSectionLabelRejectsProse(t *testing.T) {
+	for _, line := range []string{"Schedule a meeting with the team", "civil terms", "Summary of changes below was approved"} {
+		if label := sectionLabel(line); label != "" {
+			t.Errorf("sectionLabel(%q) = %q, want none", line, label)
+		}
+	}
+	for line, want := range map[string]string{
+		"EXHIBIT 2":              SectionAppendix,
+		"Annex III":              SectionAppendix,
+		"Article 4: Definitions": SectionTerms,
+		"**Signatures**":         SectionSignatureBlock,
+		"IV. Background":         SectionIntroduction,
+	} {
+		if label := sectionLabel(line); label != want {
+			t.Errorf("sectionLabel(%q) = %q, want %q", line, label, want)
+		}
+	}
+}
+
+func TestSectionDetectionStage(t *testing.T) {
+	config := NewExtractionConfig(WithSectionDetection(WithSectionLabels("preamble")))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	result := &ExtractionResult{Content: sectionTestDoc}
+	if err := runResultStages(result, NewExtractionConfig(WithSectionDetection())); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if len(result.Sections) != 5 {
+		t.Fatalf("expected 5 sections, got %d", len(result.Sections))
+	}
+}
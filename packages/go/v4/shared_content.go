@@ -0,0 +1,115 @@
+package kreuzberg
+
+import (
+	"strings"
+	"unsafe"
+)
+
+// Results decoded from the core hold the text of each chunk and page as a string of its
+// own, next to Content: with overlapping chunks the same text is held about three times.
+// With ExtractionConfig.SharedContent the text of chunks and pages is instead re-sliced
+// from Content wherever it occurs there, so it shares Content's backing buffer and the
+// copies are released.
+
+// shareContent makes the text of the chunks and pages of result views of its Content.
+// Text is looked up at its recorded byte offsets first and then searched for from where
+// the previous chunk or page was found; text that does not occur in Content is kept as is.
+func shareContent(result *ExtractionResult) {
+	content := result.Content
+	from := 0
+	for i := range result.Chunks {
+		chunk := &result.Chunks[i]
+		chunk.Content, from = contentView(content, chunk.Content, int(min(chunk.Metadata.ByteStart, uint64(len(content)))), from)
+	}
+
+	boundaries := map[uint64]PageBoundary{}
+	if result.Metadata.PageStructure != nil {
+		for _, boundary := range result.Metadata.PageStructure.Boundaries {
+			boundaries[boundary.PageNumber] = boundary
+		}
+	}
+	from = 0
+	for i := range result.Pages {
+		page := &result.Pages[i]
+		hint := from
+		if boundary, ok := boundaries[page.PageNumber]; ok {
+			hint = int(min(boundary.ByteStart, uint64(len(content))))
+		}
+		page.Content, from = contentView(content, page.Content, hint, from)
+	}
+}
+
+// contentView returns text as a slice of content, found at hint or else searched for from
+// offset from, along with where it was found. It returns text and from unchanged when text
+// is empty or absent.
+func contentView(content, text string, hint, from int) (string, int) {
+	if text == "" {
+		return text, from
+	}
+	if strings.HasPrefix(content[hint:], text) {
+		return content[hint : hint+len(text)], hint
+	}
+	if i := strings.Index(content[from:], text); i >= 0 {
+		return content[from+i : from+i+len(text)], from + i
+	}
+	if i := strings.Index(content[:from], text); i >= 0 {
+		return content[i : i+len(text)], i
+	}
+	return text, from
+}
+
+// ChunkRange returns the byte range of Content that chunk i is a view of. It returns false
+// when i is out of range or the chunk's text is held apart from Content, as it is unless
+// ExtractionConfig.SharedContent is set. For a result paged with PagingConfig it returns
+// the range the chunk was a view of before it was paged.
+func (r *ExtractionResult) ChunkRange(i int) (start, end int, ok bool) {
+	if r == nil {
+		return 0, 0, false
+	}
+	if r.paged != nil {
+		return pagedView(r.paged.chunks, i)
+	}
+	if i < 0 || i >= len(r.Chunks) {
+		return 0, 0, false
+	}
+	return viewRange(r.Content, r.Chunks[i].Content)
+}
+
+// PageRange returns the byte range of Content that Pages[i] is a view of. It returns false
+// when i is out of range or the page's text is held apart from Content, as it is unless
+// ExtractionConfig.SharedContent is set. For a result paged with PagingConfig it returns
+// the range page i was a view of before it was paged.
+func (r *ExtractionResult) PageRange(i int) (start, end int, ok bool) {
+	if r == nil {
+		return 0, 0, false
+	}
+	if r.paged != nil {
+		return pagedView(r.paged.pages, i)
+	}
+	if i < 0 || i >= len(r.Pages) {
+		return 0, 0, false
+	}
+	return viewRange(r.Content, r.Pages[i].Content)
+}
+
+// pagedView returns the range of Content entry i was a view of before it was paged.
+func pagedView(entries []pagedEntry, i int) (start, end int, ok bool) {
+	if i < 0 || i >= len(entries) || !entries[i].viewed {
+		return 0, 0, false
+	}
+	return entries[i].view[0], entries[i].view[1], true
+}
+
+// viewRange returns the range of content that text is a slice of, comparing their backing
+// buffers.
+func viewRange(content, text string) (start, end int, ok bool) {
+	if text == "" || len(text) > len(content) {
+		return 0, 0, false
+	}
+	base := uintptr(unsafe.Pointer(unsafe.StringData(content)))
+	offset := uintptr(unsafe.Pointer(unsafe.StringData(text))) - base
+	if offset > uintptr(len(content)-len(text)) {
+		return 0, 0, false
+	}
+	return int(offset), int(offset) + len(text), true
+}
@@ -0,0 +1,60 @@
+package kreuzberg
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestShareContent(t *testing.T) {
+	content := "First page text.\n\nSecond page text."
+	result := &ExtractionResult{
+		Content: content,
+		Chunks: []Chunk{
+			{Content: strings.Clone("First page text."), Metadata: ChunkMetadata{ByteStart: 0, ByteEnd: 16}},
+			// Offsets that do not match the text fall back to searching for it.
+			{Content: strings.Clone("text.\n\nSecond"), Metadata: ChunkMetadata{ByteStart: 3, ByteEnd: 16}},
+			{Content: "not in content", Metadata: ChunkMetadata{ByteStart: 18, ByteEnd: 32}},
+		},
+		Pages: []PageContent{
+			{PageNumber: 1, Content: strings.Clone("First page text.")},
+			{PageNumber: 2, Content: strings.Clone("Second page text.")},
+		},
+	}
+	result.Metadata.PageStructure = &PageStructure{Boundaries: []PageBoundary{
+		{ByteStart: 0, ByteEnd: 18, PageNumber: 1},
+		{ByteStart: 18, ByteEnd: 35, PageNumber: 2},
+	}}
+	if _, _, ok := result.ChunkRange(0); ok {
+		t.Fatal("decoded chunks should not be views of Content")
+	}
+
+	shareContent(result)
+	for i, want := range [][2]int{{0, 16}, {11, 24}} {
+		start, end, ok := result.ChunkRange(i)
+		if !ok || start != want[0] || end != want[1] {
+			t.Errorf("ChunkRange(%d) = %d, %d, %v, want %v", i, start, end, ok, want)
+		}
+	}
+	if _, _, ok := result.ChunkRange(2); ok || result.Chunks[2].Content != "not in content" {
+		t.Errorf("chunk missing from Content should be kept: %q", result.Chunks[2].Content)
+	}
+	if start, end, ok := result.PageRange(1); !ok || content[start:end] != "Second page text." {
+		t.Errorf("PageRange(1) = %d, %d, %v", start, end, ok)
+	}
+	if _, _, ok := result.PageRange(5); ok {
+		t.Error("PageRange out of range should report false")
+	}
+}
+
+func TestSharedContentStage(t *testing.T) {
+	result := &ExtractionResult{
+		Content: "alpha beta",
+		Chunks:  []Chunk{{Content: strings.Clone("beta"), Metadata: ChunkMetadata{ByteStart: 6, ByteEnd: 10}}},
+	}
+	if err := runResultStages(result, NewExtractionConfig(WithSharedContent(true))); err != nil {
+		t.Fatal(err)
+	}
+	if start, end, ok := result.ChunkRange(0); !ok || start != 6 || end != 10 {
+		t.Errorf("ChunkRange(0) = %d, %d, %v", start, end, ok)
+	}
+}
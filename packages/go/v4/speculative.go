@@ -0,0 +1,161 @@
+package kreuzberg
+
+import (
+	"context"
+	"fmt"
+)
+
+// A speculative extraction of a PDF runs its text layer extraction and the OCR of its
+// pages side by side. FFI calls are serialized, so the two paths interleave rather than
+// overlap: the OCR path renders and recognizes its pages in windows, and between windows
+// the text layer extraction may run or the path may be canceled. Born-digital PDFs are thus
+// returned once their text layer is read, after at most one window of OCR, and scans once
+// their OCR completes. The text layer extraction is a single native call and cannot be
+// canceled: when the OCR path wins first it is abandoned, its result discarded, and it
+// keeps the native core busy until it finishes.
+
+// defaultQualityThreshold is the text quality a path must reach to win when
+// SpeculativeConfig.QualityThreshold is unset.
+const defaultQualityThreshold = 0.8
+
+// SpeculationMetadata reports a speculative extraction. Winner is TextSourceNative or
+// TextSourceOCR, the path whose text was returned. NativeQuality and OCRQuality score the
+// text of the paths that finished, as in PageReconciliation. Canceled reports that the
+// text layer won and the OCR path was canceled before it finished. Abandoned reports that
+// the OCR path won before the text layer extraction finished; that extraction cannot be
+// interrupted, so it still runs to completion in the native core, which stays busy with
+// it, and its result is discarded.
+type SpeculationMetadata struct {
+	Winner        string   `json:"winner"`
+	NativeQuality *float64 `json:"native_quality,omitempty"`
+	OCRQuality    *float64 `json:"ocr_quality,omitempty"`
+	Canceled      bool     `json:"canceled,omitempty"`
+	Abandoned     bool     `json:"abandoned,omitempty"`
+}
+
+// speculativeOutcome is what a path of a speculative extraction produced: the text layer
+// extraction a result, the OCR path its recognized pages.
+type speculativeOutcome struct {
+	result *ExtractionResult
+	pages  *recognizedPages
+	err    error
+}
+
+func validateSpeculativeConfig(cfg *SpeculativeConfig) error {
+	if threshold := qualityThreshold(cfg); threshold < 0 || threshold > 1 {
+		return newValidationErrorWithContext(fmt.Sprintf("speculative quality threshold must be between 0 and 1, got %g", threshold), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+func qualityThreshold(cfg *SpeculativeConfig) float64 {
+	if cfg.QualityThreshold != nil {
+		return *cfg.QualityThreshold
+	}
+	return defaultQualityThreshold
+}
+
+// speculating reports whether PDFs are extracted speculatively with config.
+func speculating(config *ExtractionConfig) bool {
+	return config != nil && config.Speculative != nil && (config.ForceOCR == nil || !*config.ForceOCR)
+}
+
+// extractSpeculative extracts a PDF speculatively. The OCR path recognizes
+// OCRConfig.PageParallelism pages at a time, one by default. It returns nil without an
+// error when the PDF's pages cannot be counted, leaving it to the core.
+func extractSpeculative(data []byte, config *ExtractionConfig) (*ExtractionResult, error) {
+	count := pdfPageCount(data)
+	if !speculating(config) || count == 0 {
+		return nil, nil
+	}
+	workers := 1
+	if config.OCR != nil && config.OCR.PageParallelism != nil {
+		workers = max(*config.OCR.PageParallelism, 1)
+	}
+	textConfig := *config
+	textConfig.ForceOCR, textConfig.OCR = nil, nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	native, ocr := make(chan speculativeOutcome, 1), make(chan speculativeOutcome, 1)
+	go func() {
+		if err := ctx.Err(); err != nil {
+			native <- speculativeOutcome{err: err}
+			return
+		}
+		result, err := extractBytesCore(data, "application/pdf", &textConfig)
+		native <- speculativeOutcome{result: result, err: err}
+	}()
+	go func() {
+		pages, err := recognizePDFPages(ctx, data, count, workers, config)
+		ocr <- speculativeOutcome{pages: pages, err: err}
+	}()
+	return settleSpeculation(native, ocr, cancel, qualityThreshold(config.Speculative), config)
+}
+
+// settleSpeculation waits for the outcomes of the two paths and returns the text of the
+// first whose quality reaches threshold, canceling the OCR path or abandoning the text
+// layer extraction, or else the better of the two. Recognized pages replace the text of the text layer result, whose document metadata
+// is kept when it finished.
+func settleSpeculation(native, ocr <-chan speculativeOutcome, cancel func(), threshold float64, config *ExtractionConfig) (*ExtractionResult, error) {
+	meta := &SpeculationMetadata{}
+	var result *ExtractionResult
+	var pages *recognizedPages
+	var firstErr error
+	pending := 2
+	for ; pending > 0 && meta.Winner == ""; pending-- {
+		var outcome speculativeOutcome
+		select {
+		case outcome = <-native:
+			native = nil
+			if outcome.err == nil && outcome.result != nil {
+				result = outcome.result
+				quality := textQuality(result.Content)
+				meta.NativeQuality = &quality
+				if quality >= threshold {
+					meta.Winner = TextSourceNative
+				}
+			}
+		case outcome = <-ocr:
+			ocr = nil
+			if outcome.err == nil && outcome.pages != nil {
+				pages = outcome.pages
+				quality := textQuality(pages.text())
+				meta.OCRQuality = &quality
+				if quality >= threshold {
+					meta.Winner = TextSourceOCR
+				}
+			}
+		}
+		if outcome.err != nil && firstErr == nil {
+			firstErr = outcome.err
+		}
+	}
+	if pending > 0 {
+		meta.Canceled = native == nil
+		meta.Abandoned = ocr == nil
+	}
+	cancel()
+
+	if meta.Winner == "" {
+		switch {
+		case pages != nil && (result == nil || *meta.OCRQuality > *meta.NativeQuality):
+			meta.Winner = TextSourceOCR
+		case result != nil:
+			meta.Winner = TextSourceNative
+		default:
+			return nil, firstErr
+		}
+	}
+	if meta.Winner == TextSourceOCR {
+		if result == nil {
+			result = &ExtractionResult{MimeType: "application/pdf", Success: true}
+		}
+		var err error
+		if result, err = pages.apply(result, config); err != nil {
+			return nil, err
+		}
+	}
+	result.Metadata.Speculation = meta
+	return result, nil
+}
@@ -0,0 +1,111 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+// speculationPaths returns the channels of a speculative extraction whose text layer path
+// finishes first, with native, and whose OCR path then finishes with ocr. Nil outcomes are
+// never delivered.
+func speculationPaths(native, ocr *speculativeOutcome) (chan speculativeOutcome, chan speculativeOutcome) {
+	nativeCh, ocrCh := make(chan speculativeOutcome), make(chan speculativeOutcome, 1)
+	go func() {
+		if native != nil {
+			nativeCh <- *native
+		}
+		if ocr != nil {
+			ocrCh <- *ocr
+		}
+	}()
+	return nativeCh, ocrCh
+}
+
+func TestSettleSpeculation(t *testing.T) {
+	subject := "Annual report"
+	textLayer := &ExtractionResult{Content: "Revenue grew in every region.", MimeType: "application/pdf", Success: true}
+	textLayer.Metadata.Subject = &subject
+	broken := &ExtractionResult{Content: "(cid:3)(cid:9) ��� (cid:12)", MimeType: "application/pdf", Success: true}
+	broken.Metadata.Subject = &subject
+	recognized := &recognizedPages{contents: []PageContent{
+		{PageNumber: 1, Content: "Scanned invoice"},
+		{PageNumber: 2, Content: "Total due: 40 EUR"},
+	}}
+
+	canceled := false
+	cancel := func() { canceled = true }
+	native, ocr := speculationPaths(&speculativeOutcome{result: textLayer}, nil)
+	result, err := settleSpeculation(native, ocr, cancel, defaultQualityThreshold, nil)
+	if err != nil || result != textLayer {
+		t.Fatalf("expected the text layer result, got %+v, %v", result, err)
+	}
+	if meta := result.Metadata.Speculation; meta.Winner != TextSourceNative || !meta.Canceled || meta.Abandoned || meta.OCRQuality != nil || !canceled {
+		t.Errorf("unexpected speculation: %+v (canceled %v)", meta, canceled)
+	}
+
+	native, ocr = speculationPaths(&speculativeOutcome{result: broken}, &speculativeOutcome{pages: recognized})
+	result, err = settleSpeculation(native, ocr, func() {}, defaultQualityThreshold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Scanned invoice\n\nTotal due: 40 EUR"; result.Content != want {
+		t.Errorf("Content = %q, want %q", result.Content, want)
+	}
+	meta := result.Metadata.Speculation
+	if meta.Winner != TextSourceOCR || meta.NativeQuality == nil || *meta.NativeQuality != 0 || result.Metadata.Subject != &subject {
+		t.Errorf("unexpected result metadata: %+v", result.Metadata)
+	}
+	if len(result.Metadata.PageStructure.Boundaries) != 2 {
+		t.Errorf("unexpected page structure: %+v", result.Metadata.PageStructure)
+	}
+	if meta.Canceled || meta.Abandoned {
+		t.Errorf("both paths finished: %+v", meta)
+	}
+
+	// The OCR path wins while the text layer extraction still runs: it is abandoned, as
+	// the native call cannot be canceled.
+	native, ocr = speculationPaths(nil, &speculativeOutcome{pages: recognized})
+	result, err = settleSpeculation(native, ocr, func() {}, defaultQualityThreshold, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta := result.Metadata.Speculation; meta.Winner != TextSourceOCR || !meta.Abandoned || meta.Canceled || meta.NativeQuality != nil {
+		t.Errorf("unexpected speculation: %+v", meta)
+	}
+
+	// Neither path is good enough: the better text is returned once both finished.
+	native, ocr = speculationPaths(&speculativeOutcome{result: textLayer}, &speculativeOutcome{err: errors.New("no tesseract")})
+	result, err = settleSpeculation(native, ocr, func() {}, 1.1, nil)
+	if err != nil || result.Metadata.Speculation.Winner != TextSourceNative || result.Metadata.Speculation.Canceled {
+		t.Errorf("expected the text layer after both paths, got %+v, %v", result, err)
+	}
+
+	failure := errors.New("broken xref")
+	native, ocr = speculationPaths(&speculativeOutcome{err: failure}, &speculativeOutcome{})
+	if result, err := settleSpeculation(native, ocr, func() {}, defaultQualityThreshold, nil); result != nil || !errors.Is(err, failure) {
+		t.Errorf("expected the text layer error, got %+v, %v", result, err)
+	}
+}
+
+func TestSpeculativeConfig(t *testing.T) {
+	var validationErr *ValidationError
+	if err := validateResultStages(NewExtractionConfig(WithSpeculative(WithQualityThreshold(1.5)))); !errors.As(err, &validationErr) {
+		t.Errorf("expected ValidationError, got %v", err)
+	}
+	if speculating(NewExtractionConfig(WithSpeculative(), WithForceOCR(true))) || !speculating(NewExtractionConfig(WithSpeculative())) {
+		t.Error("forced OCR should not be speculative")
+	}
+	if !bindingRecognizesPages(NewExtractionConfig(WithSpeculative())) {
+		t.Error("speculative PDFs should be recognized by the binding")
+	}
+
+	var decoded Metadata
+	data, err := json.Marshal(Metadata{Speculation: &SpeculationMetadata{Winner: TextSourceOCR, Canceled: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil || decoded.Speculation == nil || decoded.Speculation.Winner != TextSourceOCR || !decoded.Speculation.Canceled {
+		t.Errorf("unexpected speculation metadata: %+v, %v", decoded.Speculation, err)
+	}
+}
@@ -0,0 +1,271 @@
+package kreuzberg
+
+// This file wires the binding-side processing stages that run on results after
+// they cross the FFI boundary. Stages are pure Go and execute outside ffiMutex,
+// so they never block other extractions waiting on the native core.
+
+// nativeConfig returns the configuration sent to the native core. Settings that are fully
+// handled by binding-side stages are removed so the core does not duplicate the work.
+func nativeConfig(config *ExtractionConfig) *ExtractionConfig {
+	skipTables := config != nil && config.TableExtraction != nil && config.TableExtraction.Backend == TableBackendNone
+	needImages := config != nil && (config.MarkDetection != nil || scanQualityEnabled(config)) && !imagesRequested(config)
+	needPages := config != nil && config.PageDeduplication != nil && config.Pages == nil
+	if !usesStructureChunking(config) && !skipTables && !needImages && !needPages {
+		return config
+	}
+	native := *config
+	if needPages {
+		native.Pages = &PageConfig{}
+	}
+	if usesStructureChunking(config) {
+		native.Chunking = nil
+	}
+	if skipTables {
+		disableOCRTableDetection(&native)
+	}
+	if needImages {
+		images := ImageExtractionConfig{}
+		if config.Images != nil {
+			images = *config.Images
+		}
+		images.ExtractImages = BoolPtr(true)
+		native.Images = &images
+	}
+	return &native
+}
+
+// validateResultStages checks the configuration of binding-side stages before any
+// native work is done, so invalid settings fail fast.
+func validateResultStages(config *ExtractionConfig) error {
+	if config == nil {
+		return nil
+	}
+	if config.Chunking != nil {
+		if err := validateChunkingStrategy(config.Chunking); err != nil {
+			return err
+		}
+	}
+	if config.Normalization != nil {
+		if err := validateNormalizationConfig(config.Normalization); err != nil {
+			return err
+		}
+	}
+	if config.TranslateTo != "" {
+		if err := validateTranslationConfig(config); err != nil {
+			return err
+		}
+	}
+	if config.SectionDetection != nil {
+		if err := validateSectionDetectionConfig(config.SectionDetection); err != nil {
+			return err
+		}
+	}
+	if config.TableExtraction != nil {
+		if err := validateTableExtractionConfig(config.TableExtraction); err != nil {
+			return err
+		}
+	}
+	if config.KeyValues != nil {
+		if err := validateKeyValueConfig(config.KeyValues); err != nil {
+			return err
+		}
+	}
+	if config.MarkDetection != nil {
+		if err := validateMarkDetectionConfig(config.MarkDetection); err != nil {
+			return err
+		}
+	}
+	if config.LinkExtraction != nil {
+		if err := validateLinkExtractionConfig(config.LinkExtraction); err != nil {
+			return err
+		}
+	}
+	if config.InlineStyles != nil {
+		if err := validateInlineStyleConfig(config.InlineStyles); err != nil {
+			return err
+		}
+	}
+	if config.OCR != nil {
+		if err := validateOCRConfig(config.OCR); err != nil {
+			return err
+		}
+	}
+	if config.ResultCompression != nil {
+		if err := validateResultCompressionConfig(config.ResultCompression); err != nil {
+			return err
+		}
+	}
+	if config.Speculative != nil {
+		if err := validateSpeculativeConfig(config.Speculative); err != nil {
+			return err
+		}
+	}
+	if config.SourceAttribution != nil {
+		if err := validateSourceAttributionConfig(config.SourceAttribution); err != nil {
+			return err
+		}
+	}
+	if config.HeadingDetection != nil {
+		if err := validateHeadingDetectionConfig(config.HeadingDetection); err != nil {
+			return err
+		}
+	}
+	if config.TextStats != nil {
+		if err := validateTextStatsConfig(config.TextStats); err != nil {
+			return err
+		}
+	}
+	if err := validateOffsetUnit(config.OffsetUnit); err != nil {
+		return err
+	}
+	if err := validateCoordinateUnit(config.CoordinateUnit); err != nil {
+		return err
+	}
+	if err := validateTextEncoding(config.TextEncoding); err != nil {
+		return err
+	}
+	if err := validateRubyText(config.RubyText); err != nil {
+		return err
+	}
+	if config.Retry != nil {
+		if err := validateRetryConfig(config.Retry); err != nil {
+			return err
+		}
+	}
+	if err := validateFallbacks(config.Fallbacks); err != nil {
+		return err
+	}
+	if config.Quarantine != nil {
+		if err := validateQuarantineConfig(config.Quarantine); err != nil {
+			return err
+		}
+	}
+	if config.FilePolicy != nil {
+		if err := validateFilePolicy(config.FilePolicy); err != nil {
+			return err
+		}
+	}
+	if config.Chat != nil {
+		if err := validateChatConfig(config.Chat); err != nil {
+			return err
+		}
+	}
+	if config.Retention != nil {
+		if err := validateRetentionConfig(config.Retention); err != nil {
+			return err
+		}
+	}
+	if config.Paging != nil {
+		if err := validatePagingConfig(config.Paging); err != nil {
+			return err
+		}
+	}
+	if config.Anonymization != nil {
+		if err := validateAnonymizationConfig(config.Anonymization); err != nil {
+			return err
+		}
+	}
+	if config.LanguageFilter != nil {
+		if err := validateLanguageFilterConfig(config.LanguageFilter); err != nil {
+			return err
+		}
+	}
+	if config.Amounts != nil {
+		if err := validateAmountDetectionConfig(config.Amounts); err != nil {
+			return err
+		}
+	}
+	if err := validateNestedConfig(config); err != nil {
+		return err
+	}
+	if err := validateInclude(config.Include); err != nil {
+		return err
+	}
+	return nil
+}
+
+// runResultStages applies the binding-side stages configured in config to result.
+func runResultStages(result *ExtractionResult, config *ExtractionConfig) error {
+	if result == nil || config == nil {
+		return nil
+	}
+	if config.PageDeduplication != nil {
+		applyPageDeduplication(result, config.PageDeduplication)
+	}
+	if config.LanguageFilter != nil {
+		applyLanguageFilter(result, config.LanguageFilter)
+	}
+	if config.Normalization != nil {
+		applyNormalization(result, config.Normalization)
+	}
+	if config.TableExtraction != nil {
+		applyTableExtraction(result, config.TableExtraction)
+	}
+	if config.SectionDetection != nil {
+		result.Sections = DetectSections(result.Content, config.SectionDetection)
+	}
+	if config.KeyValues != nil {
+		result.KeyValues = ExtractKeyValues(result, config.KeyValues)
+	}
+	if config.Amounts != nil {
+		result.Amounts = DetectAmounts(result, config.Amounts)
+	}
+	if config.Acronyms != nil && *config.Acronyms {
+		result.Acronyms = DetectAcronyms(result.Content)
+	}
+	if config.Turns != nil {
+		result.Turns = DetectTurns(result.Content, config.Turns)
+	}
+	if config.MarkDetection != nil {
+		result.Marks = DetectMarks(result, config.MarkDetection)
+	}
+	if (config.MarkDetection != nil || scanQualityEnabled(config)) && !imagesRequested(config) {
+		result.Images = nil
+	}
+	if config.TextStats != nil {
+		result.TextStats = ComputeTextStats(result.Content, config.TextStats)
+	}
+	if config.Chunking != nil {
+		applyChunkStages(result, config.Chunking)
+	} else {
+		assignChunkPageSpans(result)
+	}
+	if len(result.Headings) > 0 {
+		labelChunkHeadings(result)
+	}
+	if config.TranslateTo != "" {
+		if err := applyTranslation(result, config); err != nil {
+			return err
+		}
+	}
+	describePageLayout(result)
+	applyCoordinateUnit(result, config.CoordinateUnit)
+	applyOffsetUnit(result, config.OffsetUnit)
+	applyInclude(result, config.Include)
+	if config.SharedContent != nil && *config.SharedContent {
+		shareContent(result)
+	}
+	if config.Anonymization != nil {
+		applyAnonymization(result, config.Anonymization)
+	}
+	if config.Paging != nil {
+		return pageOut(result, config.Paging, config.Retention)
+	}
+	return nil
+}
+
+// runBatchResultStages applies runResultStages to every distinct non-nil result of a
+// batch. Deduplicated documents share a result, which must be processed only once.
+func runBatchResultStages(results []*ExtractionResult, config *ExtractionConfig) error {
+	seen := make(map[*ExtractionResult]struct{}, len(results))
+	for _, result := range results {
+		if _, ok := seen[result]; ok {
+			continue
+		}
+		seen[result] = struct{}{}
+		if err := runResultStages(result, config); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"cmp"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Table detection tuning, in pixels of pages rendered at textLayerDPI, that is in points.
+const (
+	// tableRuleMinLength is the length from which a horizontal run of dark pixels is a rule.
+	tableRuleMinLength = 36
+	// tableRuleMaxThickness is the thickness above which dark runs are a filled shape.
+	tableRuleMaxThickness = 3
+	// tableRuleTolerance is how far the ends of the rules of one table may be apart.
+	tableRuleTolerance = 4
+	// tableRuleMaxGap is the largest distance between consecutive rules of one table.
+	tableRuleMaxGap = 120
+	// tableVerticalRuleShare is the share of a table's height a column of dark pixels must
+	// cover to be a vertical rule.
+	tableVerticalRuleShare = 0.9
+	// tableColumnGap is the gap between words, in word heights, that separates cells.
+	tableColumnGap = 1.5
+	// tableMinAlignedRows is the number of aligned lines from which they form a table.
+	tableMinAlignedRows = 3
+	// tableMaxCellShare is the widest average cell, as a share of the table, of a table of
+	// two aligned columns; wider ones are taken for two columns of running text.
+	tableMaxCellShare = 0.4
+)
+
+// Confidence factors applied to tableShapeConfidence, by the evidence for a table.
+const (
+	tableGridFactor    = 1.0
+	tableRulesFactor   = 0.85
+	tableAlignedFactor = 0.7
+)
+
+// tableRule is a horizontal ruling line of a rendered page.
+type tableRule struct {
+	left, top, right, bottom float64
+}
+
+// tableSegment is a run of words of one line separated from the others by a wide gap.
+type tableSegment struct {
+	left, right float64
+	text        string
+}
+
+type tableSpan struct {
+	left, right float64
+}
+
+// applyTableDetectionStage reconstructs the tables of PDF pages on which the core found
+// none when the heuristic backend is selected: from the ruling lines of the rendered page,
+// with the columns given by vertical rules or by the alignment of the text layer, and from
+// lines of text aligned in columns. Detected tables carry a Confidence and their
+// BoundingBox, in points from the bottom-left corner of the page. Scanned pages without a
+// text layer are left to OCR table detection. layer returns the text layer of the
+// original's pages, and only the pages with text and without a table are rendered, from
+// the original returned by read, to find their rules. It never fails the extraction.
+func applyTableDetectionStage(result *ExtractionResult, read func() ([]byte, error), layer func() ([]PageImage, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.TableExtraction == nil || result.MimeType != "application/pdf" {
+		return
+	}
+	if backend := config.TableExtraction.Backend; backend != "" && backend != TableBackendHeuristic {
+		return
+	}
+	if config.Include != nil && !slices.Contains(config.Include, ResultFieldTables) {
+		return
+	}
+	pages, err := layer()
+	if err != nil {
+		return
+	}
+	covered := map[int]bool{}
+	for _, table := range result.Tables {
+		covered[table.PageNumber] = true
+	}
+	for _, page := range pages {
+		if covered[page.PageNumber] || len(page.Words) == 0 {
+			continue
+		}
+		if data, err := read(); err == nil {
+			request := renderRequest{FirstPage: page.PageNumber, LastPage: page.PageNumber, DPI: textLayerDPI, Format: RenderFormatPNG}
+			if images, err := renderPDFPages(data, request); err == nil && len(images) == 1 {
+				page.Format, page.Data = images[0].Format, images[0].Data
+			}
+		}
+		result.Tables = append(result.Tables, detectPageTables(page)...)
+	}
+}
+
+// detectPageTables finds the tables of a page rendered at textLayerDPI: ruled tables
+// first, then aligned lines among the words outside them.
+func detectPageTables(page PageImage) []Table {
+	var img image.Image
+	if decoded, err := png.Decode(bytes.NewReader(page.Data)); err == nil {
+		img = decoded
+	}
+	words := page.Words
+	var tables []Table
+	for _, rules := range ruleGroups(horizontalRules(img)) {
+		first, last := rules[0], rules[len(rules)-1]
+		var inside, outside []PageWord
+		for _, word := range words {
+			x, y := word.Left+word.Width/2, word.Top+word.Height/2
+			if x >= first.left && x <= first.right && y >= first.top && y <= last.bottom {
+				inside = append(inside, word)
+			} else {
+				outside = append(outside, word)
+			}
+		}
+		cells, factor := ruledCells(img, rules, inside)
+		if len(cells) < 2 || len(cells[0]) < 2 {
+			continue
+		}
+		words = outside
+		tables = append(tables, detectedTable(page, cells, factor, first.left, first.top, first.right, last.bottom))
+	}
+	return append(tables, alignedTables(page, words)...)
+}
+
+// detectedTable builds the table of cells found on page in the given region, in pixels
+// from the top-left corner.
+func detectedTable(page PageImage, cells [][]string, factor, left, top, right, bottom float64) Table {
+	confidence := factor * tableShapeConfidence(cells)
+	height := float64(page.Height)
+	return Table{
+		Cells:       cells,
+		Markdown:    tableMarkdown(cells),
+		PageNumber:  page.PageNumber,
+		Confidence:  &confidence,
+		BoundingBox: &BoundingBox{X0: left, Y0: height - bottom, X1: right, Y1: height - top},
+	}
+}
+
+func isDark(c color.Color) bool {
+	return color.GrayModel.Convert(c).(color.Gray).Y < 128
+}
+
+// horizontalRules returns the horizontal runs of dark pixels of img at least
+// tableRuleMinLength long and at most tableRuleMaxThickness thick, top to bottom.
+func horizontalRules(img image.Image) []tableRule {
+	if img == nil {
+		return nil
+	}
+	bounds := img.Bounds()
+	var rules, open []tableRule
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		var next []tableRule
+		start := -1
+		for x := bounds.Min.X; x <= bounds.Max.X; x++ {
+			if x < bounds.Max.X && isDark(img.At(x, y)) {
+				if start < 0 {
+					start = x
+				}
+				continue
+			}
+			if start >= 0 && x-start >= tableRuleMinLength {
+				run := tableRule{
+					left: float64(start - bounds.Min.X), right: float64(x - bounds.Min.X),
+					top: float64(y - bounds.Min.Y), bottom: float64(y - bounds.Min.Y + 1),
+				}
+				// A rule thicker than a pixel continues a run of the row above.
+				for i, rule := range open {
+					if math.Abs(rule.left-run.left) <= tableRuleTolerance && math.Abs(rule.right-run.right) <= tableRuleTolerance {
+						run.top = rule.top
+						open = slices.Delete(open, i, i+1)
+						break
+					}
+				}
+				next = append(next, run)
+			}
+			start = -1
+		}
+		rules = appendRules(rules, open)
+		open = next
+	}
+	return appendRules(rules, open)
+}
+
+// appendRules appends the runs that ended, dropping filled shapes.
+func appendRules(rules, ended []tableRule) []tableRule {
+	for _, rule := range ended {
+		if rule.bottom-rule.top <= tableRuleMaxThickness {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ruleGroups gathers rules of the same extent, each at most tableRuleMaxGap below the
+// previous one, into the rules of one table. Single rules are dropped.
+func ruleGroups(rules []tableRule) [][]tableRule {
+	slices.SortStableFunc(rules, func(a, b tableRule) int { return cmp.Compare(a.top, b.top) })
+	var groups [][]tableRule
+	for _, rule := range rules {
+		joined := false
+		for i, group := range groups {
+			last := group[len(group)-1]
+			if math.Abs(last.left-rule.left) <= tableRuleTolerance && math.Abs(last.right-rule.right) <= tableRuleTolerance &&
+				rule.top-last.bottom <= tableRuleMaxGap {
+				groups[i] = append(group, rule)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			groups = append(groups, []tableRule{rule})
+		}
+	}
+	return slices.DeleteFunc(groups, func(group []tableRule) bool { return len(group) < 2 })
+}
+
+// ruledCells lays out the words of a table between rules: a row between each pair of
+// consecutive rules holding words, and columns between the vertical rules of img, or
+// from the alignment of the words when it has none inside the table. It returns the cells
+// and the confidence factor of the evidence.
+func ruledCells(img image.Image, rules []tableRule, words []PageWord) ([][]string, float64) {
+	first, last := rules[0], rules[len(rules)-1]
+	rows := make([][]PageWord, len(rules)-1)
+	for _, word := range words {
+		y := word.Top + word.Height/2
+		for i := range rows {
+			if y >= rules[i].bottom && y <= rules[i+1].top {
+				rows[i] = append(rows[i], word)
+				break
+			}
+		}
+	}
+	rows = slices.DeleteFunc(rows, func(row []PageWord) bool { return len(row) == 0 })
+	if len(rows) == 0 {
+		return nil, 0
+	}
+
+	separators := []float64{first.left}
+	for _, x := range verticalRules(img, first.left, first.bottom, first.right, last.top) {
+		if x-first.left > tableRuleTolerance && first.right-x > tableRuleTolerance {
+			separators = append(separators, x)
+		}
+	}
+	separators = append(separators, first.right)
+	if len(separators) >= 3 {
+		cells := make([][]string, len(rows))
+		for i, row := range rows {
+			cells[i] = make([]string, len(separators)-1)
+			for _, line := range wordLines(row) {
+				for _, word := range line {
+					x := word.Left + word.Width/2
+					column := 0
+					for column < len(separators)-2 && x > separators[column+1] {
+						column++
+					}
+					cells[i][column] = joinCell(cells[i][column], word.Text)
+				}
+			}
+		}
+		return cells, tableGridFactor
+	}
+
+	var segments [][]tableSegment
+	for _, row := range rows {
+		var rowSegments []tableSegment
+		for _, line := range wordLines(row) {
+			rowSegments = append(rowSegments, lineSegments(line)...)
+		}
+		segments = append(segments, rowSegments)
+	}
+	cells, _ := alignedCells(segments)
+	return cells, tableRulesFactor
+}
+
+// verticalRules returns the x positions, left to right, of the columns of dark pixels of
+// img crossing most of the given region. Adjacent columns count once.
+func verticalRules(img image.Image, left, top, right, bottom float64) []float64 {
+	if img == nil || bottom <= top {
+		return nil
+	}
+	bounds := img.Bounds()
+	var xs []float64
+	for x := int(left); x < int(math.Ceil(right)); x++ {
+		dark := 0
+		for y := int(top); y < int(bottom); y++ {
+			if isDark(img.At(bounds.Min.X+x, bounds.Min.Y+y)) {
+				dark++
+			}
+		}
+		if float64(dark) < (bottom-top)*tableVerticalRuleShare {
+			continue
+		}
+		if n := len(xs); n > 0 && float64(x)-xs[n-1] <= tableRuleMaxThickness {
+			continue
+		}
+		xs = append(xs, float64(x))
+	}
+	return xs
+}
+
+// alignedTables finds runs of at least tableMinAlignedRows consecutive lines of words
+// split into the same columns.
+func alignedTables(page PageImage, words []PageWord) []Table {
+	lines := wordLines(slices.Clone(words))
+	var tables []Table
+	for start := 0; start < len(lines); {
+		end := start
+		for end < len(lines) && len(lineSegments(lines[end])) >= 2 && (end == start || lineGap(lines[end-1], lines[end]) <= 2*lineHeight(lines[end])) {
+			end++
+		}
+		if end-start >= tableMinAlignedRows {
+			if table, ok := alignedTable(page, lines[start:end]); ok {
+				tables = append(tables, table)
+			}
+		}
+		start = max(end, start+1)
+	}
+	return tables
+}
+
+// alignedTable lays out lines as a table, reporting false when their segments do not
+// fall into consistent columns.
+func alignedTable(page PageImage, lines [][]PageWord) (Table, bool) {
+	segments := make([][]tableSegment, len(lines))
+	left, top, right, bottom := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	width, count := 0.0, 0
+	for i, line := range lines {
+		segments[i] = lineSegments(line)
+		for _, segment := range segments[i] {
+			width += segment.right - segment.left
+			count++
+		}
+		for _, word := range line {
+			left, top = min(left, word.Left), min(top, word.Top)
+			right, bottom = max(right, word.Left+word.Width), max(bottom, word.Top+word.Height)
+		}
+	}
+	cells, ok := alignedCells(segments)
+	if !ok || len(cells[0]) < 2 {
+		return Table{}, false
+	}
+	if len(cells[0]) == 2 && width/float64(count) > (right-left)*tableMaxCellShare {
+		return Table{}, false
+	}
+	return detectedTable(page, cells, tableAlignedFactor, left, top, right, bottom), true
+}
+
+// alignedCells assigns the segments of each row to the columns their horizontal extents
+// form together. It reports false when two segments of a line fall into one column.
+func alignedCells(rows [][]tableSegment) ([][]string, bool) {
+	var all []tableSegment
+	for _, row := range rows {
+		all = append(all, row...)
+	}
+	slices.SortStableFunc(all, func(a, b tableSegment) int { return cmp.Compare(a.left, b.left) })
+	var columns []tableSpan
+	for _, segment := range all {
+		if n := len(columns); n > 0 && segment.left <= columns[n-1].right {
+			columns[n-1].right = max(columns[n-1].right, segment.right)
+			continue
+		}
+		columns = append(columns, tableSpan{segment.left, segment.right})
+	}
+
+	ok := true
+	cells := make([][]string, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]string, len(columns))
+		used := make([]bool, len(columns))
+		previous := math.Inf(-1)
+		for _, segment := range row {
+			column := slices.IndexFunc(columns, func(c tableSpan) bool { return segment.left >= c.left && segment.right <= c.right })
+			// Segments of a new line start again from the left.
+			if segment.left < previous {
+				clear(used)
+			}
+			previous = segment.left
+			if used[column] {
+				ok = false
+			}
+			used[column] = true
+			cells[i][column] = joinCell(cells[i][column], segment.text)
+		}
+	}
+	return cells, ok
+}
+
+// lineSegments splits a line of words, sorted left to right, where the gap between two
+// words exceeds tableColumnGap times their height.
+func lineSegments(line []PageWord) []tableSegment {
+	var segments []tableSegment
+	for i, word := range line {
+		if i > 0 {
+			previous := line[i-1]
+			current := &segments[len(segments)-1]
+			if word.Left-(previous.Left+previous.Width) <= tableColumnGap*max(previous.Height, word.Height) {
+				current.right = max(current.right, word.Left+word.Width)
+				current.text = joinCell(current.text, word.Text)
+				continue
+			}
+		}
+		segments = append(segments, tableSegment{left: word.Left, right: word.Left + word.Width, text: word.Text})
+	}
+	return segments
+}
+
+func lineHeight(line []PageWord) float64 {
+	height := 0.0
+	for _, word := range line {
+		height = max(height, word.Height)
+	}
+	return height
+}
+
+// lineGap is the vertical distance between the tops of two lines.
+func lineGap(above, below []PageWord) float64 {
+	return below[0].Top - above[0].Top
+}
+
+func joinCell(cell, text string) string {
+	text = strings.TrimSpace(text)
+	if cell == "" {
+		return text
+	}
+	return cell + " " + text
+}
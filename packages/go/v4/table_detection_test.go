@@ -0,0 +1,87 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"reflect"
+	"testing"
+)
+
+// tablePage renders a white page of 400 by 300 points with the given dark rectangles.
+func tablePage(t *testing.T, words []PageWord, rects ...image.Rectangle) PageImage {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, 400, 300))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for _, rect := range rects {
+		draw.Draw(img, rect, image.NewUniform(color.Black), image.Point{}, draw.Src)
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return PageImage{PageNumber: 1, Width: 400, Height: 300, Format: RenderFormatPNG, Data: buf.Bytes(), Words: words}
+}
+
+func TestDetectRuledTable(t *testing.T) {
+	word := func(text string, left, top float64) PageWord {
+		return PageWord{Text: text, Left: left, Top: top, Width: 30, Height: 10}
+	}
+	words := []PageWord{
+		word("Name", 60, 55), word("Total", 160, 55),
+		word("Tea", 60, 85), word("4.50", 160, 85),
+		word("Notes", 60, 200),
+	}
+	grid := []image.Rectangle{
+		image.Rect(50, 50, 250, 51), image.Rect(50, 75, 250, 76), image.Rect(50, 105, 250, 106),
+		image.Rect(50, 50, 51, 106), image.Rect(150, 50, 151, 106), image.Rect(249, 50, 250, 106),
+	}
+	tables := detectPageTables(tablePage(t, words, grid...))
+	if len(tables) != 1 {
+		t.Fatalf("expected one table, got %+v", tables)
+	}
+	table := tables[0]
+	if want := [][]string{{"Name", "Total"}, {"Tea", "4.50"}}; !reflect.DeepEqual(table.Cells, want) {
+		t.Fatalf("cells = %q", table.Cells)
+	}
+	if want := (BoundingBox{X0: 50, Y0: 194, X1: 250, Y1: 250}); *table.BoundingBox != want {
+		t.Fatalf("bounding box = %+v", *table.BoundingBox)
+	}
+	if *table.Confidence != 1 {
+		t.Fatalf("grid confidence = %v", *table.Confidence)
+	}
+
+	// Without vertical rules the columns come from the alignment of the words.
+	tables = detectPageTables(tablePage(t, words, grid[:3]...))
+	if len(tables) != 1 || !reflect.DeepEqual(tables[0].Cells, [][]string{{"Name", "Total"}, {"Tea", "4.50"}}) || *tables[0].Confidence != tableRulesFactor {
+		t.Fatalf("rules only: %+v", tables)
+	}
+}
+
+func TestDetectAlignedTable(t *testing.T) {
+	var words []PageWord
+	for i, row := range [][]string{{"Item", "Qty", "Price"}, {"Tea", "2", "4.50"}, {"Milk", "1", "1.20"}} {
+		for j, text := range row {
+			words = append(words, PageWord{Text: text, Left: 40 + float64(j)*100, Top: 40 + float64(i)*14, Width: 24, Height: 10})
+		}
+	}
+	tables := detectPageTables(tablePage(t, words))
+	if len(tables) != 1 || len(tables[0].Cells) != 3 || tables[0].Cells[2][2] != "1.20" {
+		t.Fatalf("expected the aligned table, got %+v", tables)
+	}
+
+	// Two columns of running text are not a table.
+	var text []PageWord
+	for i := range 4 {
+		for j := range 2 {
+			for k := range 5 {
+				text = append(text, PageWord{Text: "word", Left: 20 + float64(j)*190 + float64(k)*34, Top: 40 + float64(i)*14, Width: 30, Height: 10})
+			}
+		}
+	}
+	if tables := detectPageTables(tablePage(t, text)); len(tables) != 0 {
+		t.Fatalf("running text taken for a table: %+v", tables)
+	}
+}
@@ -0,0 +1,127 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Supported values for TableExtractionConfig.Backend.
+const (
+	// TableBackendHeuristic keeps the tables the core extracts and reconstructs those of
+	// PDF pages it finds none on from ruling lines and text alignment.
+	TableBackendHeuristic = "heuristic"
+	// TableBackendNone disables OCR table reconstruction and drops the tables the core
+	// reads from the document structure, such as those of HTML or Word documents.
+	TableBackendNone = "none"
+)
+
+// validateTableExtractionConfig rejects unknown backends and out-of-range confidence
+// thresholds.
+func validateTableExtractionConfig(cfg *TableExtractionConfig) error {
+	switch cfg.Backend {
+	case "", TableBackendHeuristic, TableBackendNone:
+	default:
+		return newValidationErrorWithContext(fmt.Sprintf("invalid table backend: %s", cfg.Backend), nil, ErrorCodeValidation, nil)
+	}
+	if cfg.MinConfidence != nil && (*cfg.MinConfidence < 0 || *cfg.MinConfidence > 1) {
+		return newValidationErrorWithContext(
+			fmt.Sprintf("table min_confidence must be between 0.0 and 1.0, got %v", *cfg.MinConfidence),
+			nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// disableOCRTableDetection turns off Tesseract table reconstruction in config without
+// modifying the caller's OCR settings.
+func disableOCRTableDetection(config *ExtractionConfig) {
+	if config.OCR == nil || config.OCR.Tesseract == nil {
+		return
+	}
+	ocr := *config.OCR
+	tesseract := *ocr.Tesseract
+	tesseract.EnableTableDetection = BoolPtr(false)
+	ocr.Tesseract = &tesseract
+	config.OCR = &ocr
+}
+
+// applyTableExtraction drops tables when the backend is "none", fills in confidence scores
+// the backend did not report, and removes tables below the configured confidence.
+func applyTableExtraction(result *ExtractionResult, cfg *TableExtractionConfig) {
+	if cfg.Backend == TableBackendNone {
+		result.Tables = nil
+		return
+	}
+	tables := result.Tables[:0]
+	for _, table := range result.Tables {
+		if table.Confidence == nil {
+			confidence := tableShapeConfidence(table.Cells)
+			table.Confidence = &confidence
+		}
+		if cfg.MinConfidence == nil || *table.Confidence >= *cfg.MinConfidence {
+			tables = append(tables, table)
+		}
+	}
+	result.Tables = tables
+}
+
+// tableShapeConfidence estimates how likely cells form a real table: the share of rows
+// with the dominant column count and the share of non-empty cells, halved for tables
+// with a single row or column.
+func tableShapeConfidence(cells [][]string) float64 {
+	if len(cells) == 0 {
+		return 0
+	}
+	widths := map[int]int{}
+	dominant := 0
+	total, filled := 0, 0
+	for _, row := range cells {
+		widths[len(row)]++
+		if widths[len(row)] > widths[dominant] || (widths[len(row)] == widths[dominant] && len(row) > dominant) {
+			dominant = len(row)
+		}
+		for _, cell := range row {
+			total++
+			if strings.TrimSpace(cell) != "" {
+				filled++
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	confidence := 0.5*float64(widths[dominant])/float64(len(cells)) + 0.5*float64(filled)/float64(total)
+	if len(cells) < 2 || dominant < 2 {
+		confidence /= 2
+	}
+	return confidence
+}
+
+// tableMarkdown renders cells as a Markdown pipe table with the first row as header.
+// Rows are padded to the widest row, and pipes in cells are escaped.
+func tableMarkdown(cells [][]string) string {
+	width := 0
+	for _, row := range cells {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return ""
+	}
+	var b strings.Builder
+	writeRow := func(row []string) {
+		b.WriteString("|")
+		for i := range width {
+			cell := ""
+			if i < len(row) {
+				cell = strings.ReplaceAll(strings.ReplaceAll(row[i], "|", `\|`), "\n", " ")
+			}
+			b.WriteString(" " + cell + " |")
+		}
+		b.WriteString("\n")
+	}
+	writeRow(cells[0])
+	b.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, row := range cells[1:] {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,64 @@
+package kreuzberg
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTableShapeConfidence(t *testing.T) {
+	regular := [][]string{{"a", "b"}, {"1", "2"}, {"3", "4"}}
+	ragged := [][]string{{"a", "b", "c"}, {"1"}, {"", "2"}}
+	if got := tableShapeConfidence(regular); got != 1 {
+		t.Errorf("regular table confidence = %v, want 1", got)
+	}
+	if got := tableShapeConfidence(ragged); got >= 0.7 {
+		t.Errorf("ragged table confidence = %v, want < 0.7", got)
+	}
+	if got := tableShapeConfidence([][]string{{"only"}}); got != 0.5 {
+		t.Errorf("single cell confidence = %v, want 0.5", got)
+	}
+}
+
+func TestApplyTableExtraction(t *testing.T) {
+	reported := 0.2
+	result := &ExtractionResult{Tables: []Table{
+		{Cells: [][]string{{"a", "b"}, {"1", "2"}}},
+		{Cells: [][]string{{"a", "b"}, {"1", "2"}}, Confidence: &reported},
+	}}
+	applyTableExtraction(result, NewTableExtractionConfig(WithTableMinConfidence(0.5)))
+	if len(result.Tables) != 1 || *result.Tables[0].Confidence != 1 {
+		t.Fatalf("unexpected tables: %+v", result.Tables)
+	}
+
+	applyTableExtraction(result, NewTableExtractionConfig(WithTableBackend(TableBackendNone)))
+	if result.Tables != nil {
+		t.Fatalf("tables should be dropped, got %+v", result.Tables)
+	}
+}
+
+func TestTableBackendNoneDisablesOCRTableDetection(t *testing.T) {
+	config := NewExtractionConfig(
+		WithOCR(WithTesseract(WithTesseractEnableTableDetection(true))),
+		WithTableExtraction(WithTableBackend(TableBackendNone)),
+	)
+	native := nativeConfig(config)
+	if *native.OCR.Tesseract.EnableTableDetection {
+		t.Fatal("native config should disable table detection")
+	}
+	if !*config.OCR.Tesseract.EnableTableDetection {
+		t.Fatal("caller config must not be modified")
+	}
+}
+
+func TestValidateTableExtractionConfig(t *testing.T) {
+	var validationErr *ValidationError
+	if err := validateTableExtractionConfig(NewTableExtractionConfig(WithTableBackend("camelot"))); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if err := validateTableExtractionConfig(NewTableExtractionConfig(WithTableMinConfidence(1.5))); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	if err := validateTableExtractionConfig(NewTableExtractionConfig(WithTableBackend("ml"))); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+}
@@ -0,0 +1,402 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings of plain text and CSV documents, reported in TextMetadata.Encoding and
+// accepted by TextEncodingConfig.Encoding. The names are those of the WHATWG Encoding
+// Standard.
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1250 = "windows-1250"
+	EncodingWindows1251 = "windows-1251"
+	EncodingWindows1252 = "windows-1252"
+	EncodingWindows1253 = "windows-1253"
+	EncodingKOI8R       = "koi8-r"
+	EncodingIBM866      = "ibm866"
+	EncodingShiftJIS    = "shift_jis"
+	EncodingEUCJP       = "euc-jp"
+)
+
+// TextEncodingConfig controls the decoding of plain text, CSV and TSV documents that are
+// not UTF-8, which the core reads as UTF-8 and turns into mojibake. Such documents are
+// transcoded to UTF-8 before extraction and the encoding is recorded in
+// TextMetadata.Encoding.
+type TextEncodingConfig struct {
+	// Encoding is the encoding of the documents, such as "windows-1251" or "shift_jis".
+	// Empty detects it from byte-order marks, byte patterns and letter statistics, which
+	// can confuse encodings of related languages on short texts.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// textEncodingAliases maps other common names of the supported encodings to theirs.
+var textEncodingAliases = map[string]string{
+	"utf8": EncodingUTF8, "utf-16": EncodingUTF16LE, "utf16le": EncodingUTF16LE, "utf16be": EncodingUTF16BE,
+	"cp1250": EncodingWindows1250, "cp1251": EncodingWindows1251, "cp1252": EncodingWindows1252,
+	"cp1253": EncodingWindows1253, "latin1": EncodingWindows1252, "iso-8859-1": EncodingWindows1252,
+	"koi8r": EncodingKOI8R, "cp866": EncodingIBM866, "ibm-866": EncodingIBM866,
+	"sjis": EncodingShiftJIS, "shift-jis": EncodingShiftJIS, "cp932": EncodingShiftJIS,
+	"windows-31j": EncodingShiftJIS, "eucjp": EncodingEUCJP,
+}
+
+// detectedEncodings are the encodings detection chooses between, in order of preference
+// when they score the same.
+var detectedEncodings = []string{
+	EncodingWindows1252, EncodingWindows1250, EncodingWindows1251, EncodingKOI8R,
+	EncodingIBM866, EncodingWindows1253, EncodingShiftJIS, EncodingEUCJP,
+}
+
+// textEncodingMimeTypes are the formats the core reads as UTF-8 text.
+var textEncodingMimeTypes = map[string]bool{
+	"text/plain": true, "text/csv": true, "text/tab-separated-values": true,
+}
+
+// commonSymbols are the non-ASCII punctuation and symbols frequent in running text,
+// which do not count against an encoding.
+const commonSymbols = "\u00a0–—‘’‚“”„«»¿¡…•·°№€£§©®™×　、。「」・ー"
+
+// canonicalEncoding returns the name of a supported encoding, or "".
+func canonicalEncoding(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := textEncodingAliases[name]; ok {
+		return alias
+	}
+	switch name {
+	case EncodingUTF8, EncodingUTF16LE, EncodingUTF16BE, EncodingWindows1251, EncodingWindows1252,
+		EncodingShiftJIS, EncodingEUCJP:
+		return name
+	}
+	if _, ok := singleByteHigh[name]; ok {
+		return name
+	}
+	return ""
+}
+
+func validateTextEncoding(config *TextEncodingConfig) error {
+	if config == nil || config.Encoding == "" || canonicalEncoding(config.Encoding) != "" {
+		return nil
+	}
+	return newValidationErrorWithContext(fmt.Sprintf("unsupported text encoding: %s", config.Encoding), nil, ErrorCodeValidation, nil)
+}
+
+// applyTextEncodingStage records the encoding of plain text, CSV and TSV results in
+// TextMetadata.Encoding when ExtractionConfig.TextEncoding is set, and extracts documents
+// that are not UTF-8 again from their UTF-8 transcoding, so that content, pages, chunks
+// and counts all come from the decoded text. read returns the original document. Like
+// the other stages that read the original, it never fails the extraction: when the
+// second extraction fails, the result is kept as it was.
+func applyTextEncodingStage(result *ExtractionResult, read func() ([]byte, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.TextEncoding == nil {
+		return
+	}
+	mimeType, _, _ := strings.Cut(result.MimeType, ";")
+	if !textEncodingMimeTypes[strings.TrimSpace(mimeType)] {
+		return
+	}
+	data, err := read()
+	if err != nil {
+		return
+	}
+	encoding := canonicalEncoding(config.TextEncoding.Encoding)
+	if encoding == "" {
+		encoding = detectTextEncoding(data)
+	}
+	if encoding != EncodingUTF8 {
+		decoded, err := extractBytesCore([]byte(decodeText(data, encoding)), result.MimeType, nativeConfig(config))
+		if err != nil || decoded == nil {
+			return
+		}
+		*result = *decoded
+	}
+	result.Metadata.materialize()
+	if result.Metadata.Format.Text == nil {
+		result.Metadata.Format.Type = FormatText
+		result.Metadata.Format.Text = &TextMetadata{}
+	}
+	result.Metadata.Format.Text.Encoding = encoding
+}
+
+// detectTextEncoding guesses the encoding of text: from its byte-order mark, from the
+// zero bytes of UTF-16, as UTF-8 when it is valid, and otherwise as the legacy encoding
+// whose decoding reads most like text.
+func detectTextEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+	if encoding := utf16ByZeros(data); encoding != "" {
+		return encoding
+	}
+	if utf8.Valid(data) {
+		return EncodingUTF8
+	}
+	best, bestScore := EncodingWindows1252, 0
+	for i, encoding := range detectedEncodings {
+		score := encodingScore(decodeText(data, encoding))
+		if i == 0 || score > bestScore {
+			best, bestScore = encoding, score
+		}
+	}
+	return best
+}
+
+// utf16ByZeros recognizes UTF-16 without a byte-order mark by the zero high bytes of its
+// ASCII characters.
+func utf16ByZeros(data []byte) string {
+	if len(data) < 4 {
+		return ""
+	}
+	var even, odd int
+	for i, b := range data {
+		if b == 0 {
+			if i%2 == 0 {
+				even++
+			} else {
+				odd++
+			}
+		}
+	}
+	half := len(data) / 2
+	switch {
+	case odd > half*3/10 && even < half/20:
+		return EncodingUTF16LE
+	case even > half*3/10 && odd < half/20:
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// encodingScore rates how much text reads like natural language. Letters score, more so
+// lowercase and CJK ones; undefined and control characters, unusual symbols, capitals
+// inside lowercase words, final sigmas inside words, accented Latin letters in words
+// without plain ones and other scripts in words with plain Latin letters, all typical of
+// decoding with the wrong encoding, count against it.
+func encodingScore(text string) int {
+	score := 0
+	var word []rune
+	flush := func() {
+		ascii := false
+		for _, r := range word {
+			ascii = ascii || r < utf8.RuneSelf
+		}
+		for i, r := range word {
+			switch {
+			case i > 0 && unicode.IsUpper(r) && unicode.IsLower(word[i-1]):
+				score -= 3
+			case r < utf8.RuneSelf || r >= 0xFF61 && r <= 0xFF9F:
+			case r == 'ς' && i < len(word)-1:
+				score--
+			case unicode.Is(unicode.Latin, r) != ascii && len(word) > 1:
+				score--
+			case unicode.IsLower(r) || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+				score += 2
+			default:
+				score++
+			}
+		}
+		word = word[:0]
+	}
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			word = append(word, r)
+			continue
+		}
+		flush()
+		switch {
+		case r == utf8.RuneError || unicode.IsControl(r) && !unicode.IsSpace(r):
+			score -= 10
+		case r >= utf8.RuneSelf && !strings.ContainsRune(commonSymbols, r):
+			score--
+		}
+	}
+	flush()
+	return score
+}
+
+// decodeText transcodes data from encoding to UTF-8, dropping a byte-order mark and
+// replacing undecodable bytes with U+FFFD.
+func decodeText(data []byte, encoding string) string {
+	switch encoding {
+	case EncodingUTF16LE, EncodingUTF16BE:
+		var order binary.ByteOrder = binary.LittleEndian
+		if encoding == EncodingUTF16BE {
+			order = binary.BigEndian
+		}
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = order.Uint16(data[2*i:])
+		}
+		if len(units) > 0 && units[0] == 0xFEFF {
+			units = units[1:]
+		}
+		return string(utf16.Decode(units))
+	case EncodingWindows1251:
+		return decodeCP1251(data)
+	case EncodingWindows1252:
+		return decodeCP1252(data)
+	case EncodingShiftJIS:
+		return decodeShiftJIS(data)
+	case EncodingEUCJP:
+		return decodeEUCJP(data)
+	}
+	if table, ok := singleByteHigh[encoding]; ok {
+		high := []rune(table)
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+			if b >= 0x80 {
+				runes[i] = high[b-0x80]
+			}
+		}
+		return string(runes)
+	}
+	return strings.ToValidUTF8(strings.TrimPrefix(string(data), "\ufeff"), "\ufffd")
+}
+
+var (
+	shiftJISOnce  sync.Once
+	shiftJISRunes [256][]rune
+)
+
+// shiftJISChar returns the character of a Shift_JIS double-byte code.
+func shiftJISChar(lead, trail byte) (rune, bool) {
+	shiftJISOnce.Do(func() {
+		for i, row := range shiftJISTable {
+			if row != "" {
+				shiftJISRunes[i] = []rune(row)
+			}
+		}
+	})
+	row := shiftJISRunes[lead]
+	if row == nil || trail < 0x40 || trail == 0x7F || trail > 0xFC {
+		return 0, false
+	}
+	index := int(trail) - 0x40
+	if trail > 0x7F {
+		index--
+	}
+	if r := row[index]; r != utf8.RuneError {
+		return r, true
+	}
+	return 0, false
+}
+
+// decodeShiftJIS decodes Shift_JIS as written by Windows (code page 932).
+func decodeShiftJIS(data []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x80:
+			out.WriteByte(b)
+		case b >= 0xA1 && b <= 0xDF:
+			out.WriteRune(0xFF61 + rune(b-0xA1))
+		case i+1 < len(data):
+			if r, ok := shiftJISChar(b, data[i+1]); ok {
+				out.WriteRune(r)
+				i++
+				continue
+			}
+			out.WriteRune(utf8.RuneError)
+		default:
+			out.WriteRune(utf8.RuneError)
+		}
+	}
+	return out.String()
+}
+
+// decodeEUCJP decodes EUC-JP, whose JIS X 0208 characters are those of Shift_JIS under
+// another arrangement of rows and cells. JIS X 0212 characters decode as U+FFFD.
+func decodeEUCJP(data []byte) string {
+	var out strings.Builder
+	for i := 0; i < len(data); i++ {
+		b := data[i]
+		switch {
+		case b < 0x80:
+			out.WriteByte(b)
+		case b == 0x8E && i+1 < len(data) && data[i+1] >= 0xA1 && data[i+1] <= 0xDF:
+			out.WriteRune(0xFF61 + rune(data[i+1]-0xA1))
+			i++
+		case b == 0x8F && i+2 < len(data) && data[i+1] >= 0xA1 && data[i+2] >= 0xA1:
+			out.WriteRune(utf8.RuneError)
+			i += 2
+		case b >= 0xA1 && b <= 0xFE && i+1 < len(data) && data[i+1] >= 0xA1 && data[i+1] <= 0xFE:
+			row, cell := int(b-0xA0), int(data[i+1]-0xA0)
+			lead := (row+1)/2 + 0x80
+			if row > 62 {
+				lead += 0x40
+			}
+			trail := cell + 0x9E
+			if row%2 == 1 {
+				trail = cell + 0x3F
+				if cell > 63 {
+					trail++
+				}
+			}
+			if r, ok := shiftJISChar(byte(lead), byte(trail)); ok {
+				out.WriteRune(r)
+			} else {
+				out.WriteRune(utf8.RuneError)
+			}
+			i++
+		default:
+			out.WriteRune(utf8.RuneError)
+		}
+	}
+	return out.String()
+}
+
+// cp1251High maps the bytes 0x80-0xBF of Windows-1251; 0xC0-0xFF are А-я.
+var cp1251High = [64]rune{
+	'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡', '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+	'ђ', '‘', '’', '“', '”', '•', '–', '—', 0x98, '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+	0xA0, 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§', 'Ё', '©', 'Є', '«', '¬', 0xAD, '®', 'Ї',
+	'°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·', 'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+}
+
+func decodeCP1251(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		switch {
+		case b >= 0xC0:
+			runes[i] = 'А' + rune(b-0xC0)
+		case b >= 0x80:
+			runes[i] = cp1251High[b-0x80]
+		default:
+			runes[i] = rune(b)
+		}
+	}
+	return string(runes)
+}
+
+// cp1252High maps the bytes 0x80-0x9F of Windows-1252, where it differs from Latin-1.
+var cp1252High = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+func decodeCP1252(data []byte) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		if b >= 0x80 && b < 0xA0 {
+			runes[i] = cp1252High[b-0x80]
+			continue
+		}
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
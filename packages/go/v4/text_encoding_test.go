@@ -0,0 +1,51 @@
+package kreuzberg
+
+import "testing"
+
+func TestDetectTextEncoding(t *testing.T) {
+	tests := []struct {
+		data     string
+		encoding string
+		text     string
+	}{
+		{"\xd1\xfa\xe5\xf8\xfc \xe6\xe5 \xe5\xf9\xb8 \xfd\xf2\xe8\xf5 \xec\xff\xe3\xea\xe8\xf5 \xe1\xf3\xeb\xee\xea", EncodingWindows1251, "Съешь же ещё этих мягких булок"},
+		{"\xf3\xdf\xc5\xdb\xd8 \xd6\xc5 \xc5\xdd\xa3 \xdc\xd4\xc9\xc8 \xcd\xd1\xc7\xcb\xc9\xc8 \xc2\xd5\xcc\xcf\xcb", EncodingKOI8R, "Съешь же ещё этих мягких булок"},
+		{"Le c\x9cur a ses raisons. \xc9l\xe8ve, gar\xe7on, o\xf9 \xeates-vous?", EncodingWindows1252, "Le cœur a ses raisons. Élève, garçon, où êtes-vous?"},
+		{"Za\xbf\xf3\xb3\xe6 g\xea\x9cl\xb9 ja\x9f\xf1.", EncodingWindows1250, "Zażółć gęślą jaźń."},
+		{"\x8c\xe1\x94y\x82\xcd\x94L\x82\xc5\x82\xa0\x82\xe9\x81B\x96\xbc\x91O\x82\xcd\x82\xdc\x82\xbe\x96\xb3\x82\xa2\x81B", EncodingShiftJIS, "吾輩は猫である。名前はまだ無い。"},
+		{"\xb8\xe3\xc7\xda\xa4\xcf\xc7\xad\xa4\xc7\xa4\xa2\xa4\xeb\xa1\xa3\xcc\xbe\xc1\xb0\xa4\xcf\xa4\xde\xa4\xc0\xcc\xb5\xa4\xa4\xa1\xa3", EncodingEUCJP, "吾輩は猫である。名前はまだ無い。"},
+		{"\xff\xfeH\x00e\x00l\x00l\x00o\x00,\x00 \x00w\x00o\x00r\x00l\x00d\x00.\x00", EncodingUTF16LE, "Hello, world."},
+		{"Grüße", EncodingUTF8, "Grüße"},
+	}
+	for _, tt := range tests {
+		encoding := detectTextEncoding([]byte(tt.data))
+		if encoding != tt.encoding {
+			t.Errorf("detectTextEncoding(%q) = %s, want %s", tt.text, encoding, tt.encoding)
+			continue
+		}
+		if got := decodeText([]byte(tt.data), encoding); got != tt.text {
+			t.Errorf("decodeText(%s) = %q, want %q", encoding, got, tt.text)
+		}
+	}
+}
+
+func TestTextEncodingConfig(t *testing.T) {
+	if got := canonicalEncoding("CP866"); got != EncodingIBM866 {
+		t.Errorf("canonicalEncoding(CP866) = %q", got)
+	}
+	if got := decodeText([]byte("\x8f\xe0\xa8\xa2\xa5\xe2"), canonicalEncoding("cp866")); got != "Привет" {
+		t.Errorf("decodeText(ibm866) = %q", got)
+	}
+	if err := validateResultStages(NewExtractionConfig(WithTextEncoding("windows-1251"))); err != nil {
+		t.Fatal(err)
+	}
+	if err := validateResultStages(NewExtractionConfig(WithTextEncoding("ebcdic"))); err == nil {
+		t.Fatal("expected an unsupported encoding to be rejected")
+	}
+
+	result := &ExtractionResult{MimeType: "text/csv", Metadata: Metadata{Format: FormatMetadata{Type: FormatText, Text: &TextMetadata{LineCount: 1}}}}
+	applyTextEncodingStage(result, func() ([]byte, error) { return []byte("a,b\n"), nil }, NewExtractionConfig(WithTextEncoding("")))
+	if text, ok := result.Metadata.TextMetadata(); !ok || text.Encoding != EncodingUTF8 || text.LineCount != 1 {
+		t.Fatalf("TextMetadata = %+v", text)
+	}
+}
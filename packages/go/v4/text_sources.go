@@ -0,0 +1,232 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Sources of text reported in TextSource.Source and PageContent.Source.
+const (
+	// TextSourceNative is text read from the document itself, such as a PDF text layer.
+	TextSourceNative = "native"
+	// TextSourceOCR is text recognized in page images.
+	TextSourceOCR = "ocr"
+	// TextSourceHybrid is text partly found in a PDF text layer and partly recognized.
+	TextSourceHybrid = "hybrid"
+)
+
+const (
+	// defaultNativeThreshold is the share of a block's words found in the text layer above
+	// which the block counts as native when SourceAttributionConfig.NativeThreshold is unset.
+	defaultNativeThreshold = 0.9
+	// defaultOCRThreshold is the share below which a block counts as OCR when
+	// SourceAttributionConfig.OCRThreshold is unset.
+	defaultOCRThreshold = 0.1
+)
+
+// TextSource attributes a block of Content to its source. NativeCoverage is the share of
+// the block's words found in the page's PDF text layer; it is nil when the source follows
+// from the format or from forced OCR rather than from a comparison.
+type TextSource struct {
+	PageNumber     uint64   `json:"page_number,omitempty"`
+	ByteStart      uint64   `json:"byte_start"`
+	ByteEnd        uint64   `json:"byte_end"`
+	Source         string   `json:"source"`
+	NativeCoverage *float64 `json:"native_coverage,omitempty"`
+}
+
+func validateSourceAttributionConfig(cfg *SourceAttributionConfig) error {
+	native, ocr := sourceThresholds(cfg)
+	if ocr < 0 || native > 1 || ocr >= native {
+		return newValidationErrorWithContext(fmt.Sprintf("source thresholds must satisfy 0 <= ocr < native <= 1, got ocr %g and native %g", ocr, native), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// sourceThresholds returns the native and OCR thresholds of cfg, defaults applied.
+func sourceThresholds(cfg *SourceAttributionConfig) (float64, float64) {
+	native, ocr := defaultNativeThreshold, defaultOCRThreshold
+	if cfg.NativeThreshold != nil {
+		native = *cfg.NativeThreshold
+	}
+	if cfg.OCRThreshold != nil {
+		ocr = *cfg.OCRThreshold
+	}
+	return native, ocr
+}
+
+// applySourceStage attributes the blocks and pages of a result to the text layer or OCR.
+// Images, forced OCR and OCR fallbacks make every block OCR, and formats other than PDF
+// every block native. The blocks of a PDF are compared with the text layer of their page,
+// returned by layer, and hybrid pages are reconciled with their OCR, rendered from the
+// original returned by read, when SourceAttributionConfig.Reconcile is set. It never fails
+// the extraction.
+func applySourceStage(result *ExtractionResult, read func() ([]byte, error), layer func() ([]PageImage, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.SourceAttribution == nil || result.Content == "" {
+		return
+	}
+	cfg := config.SourceAttribution
+	native, ocr := sourceThresholds(cfg)
+	switch {
+	case ocrResult(result, config):
+		attributeTextSources(result, nil, TextSourceOCR, native, ocr)
+	case result.MimeType == "application/pdf":
+		pages, err := layer()
+		if err != nil {
+			return
+		}
+		attributeTextSources(result, textLayerWords(pages), "", native, ocr)
+		if cfg.Reconcile != nil && *cfg.Reconcile {
+			if data, err := read(); err == nil {
+				reconcileHybridPages(result, data, config)
+			}
+		}
+	default:
+		attributeTextSources(result, nil, TextSourceNative, native, ocr)
+	}
+}
+
+// attributeTextSources sets the text sources of result's blocks and pages: fixed when
+// given, or else from the share of their words found in the text layers of their pages.
+func attributeTextSources(result *ExtractionResult, layers map[uint64]map[string]bool, fixed string, native, ocr float64) {
+	if fixed == "" {
+		result.TextSources = comparedTextSources(result, layers, native, ocr)
+	} else {
+		result.TextSources = textBlocks(result)
+		for i := range result.TextSources {
+			result.TextSources[i].Source = fixed
+		}
+	}
+
+	// A page takes the source its blocks agree on, and is hybrid when they differ.
+	for i := range result.Pages {
+		page := &result.Pages[i]
+		page.Source = ""
+		if strings.TrimSpace(page.Content) != "" && fixed != "" {
+			page.Source = fixed
+			continue
+		}
+		for _, source := range result.TextSources {
+			if source.PageNumber != page.PageNumber {
+				continue
+			}
+			if page.Source == "" || page.Source == source.Source {
+				page.Source = source.Source
+			} else {
+				page.Source = TextSourceHybrid
+			}
+		}
+		if page.Source == "" && strings.TrimSpace(page.Content) != "" {
+			page.Source = classifyCoverage(wordCoverage(page.Content, layers[page.PageNumber]), native, ocr)
+		}
+	}
+}
+
+// ocrResult reports whether all of result's text was recognized: an image, a document
+// extracted with forced OCR, or one whose fallback chain ended in FallbackOCR.
+func ocrResult(result *ExtractionResult, config *ExtractionConfig) bool {
+	if strings.HasPrefix(result.MimeType, "image/") || config.ForceOCR != nil && *config.ForceOCR {
+		return true
+	}
+	if _, ok := result.Metadata.OcrMetadata(); ok {
+		return true
+	}
+	fallback := result.Metadata.Fallback
+	return fallback != nil && len(fallback.Chain) > 0 && fallback.Chain[len(fallback.Chain)-1] == FallbackOCR
+}
+
+// textBlocks returns the blocks of each page of result's Content, or of all of Content
+// with page number 0 when its page boundaries are unknown.
+func textBlocks(result *ExtractionResult) []TextSource {
+	ranges := []PageBoundary{{ByteEnd: uint64(len(result.Content))}}
+	if structure := result.Metadata.PageStructure; structure != nil && len(structure.Boundaries) > 0 {
+		ranges = structure.Boundaries
+	}
+	var blocks []TextSource
+	for _, page := range ranges {
+		if page.ByteStart > page.ByteEnd || page.ByteEnd > uint64(len(result.Content)) {
+			continue
+		}
+		text := result.Content[page.ByteStart:page.ByteEnd]
+		for _, block := range parseStructureBlocks(text, nil) {
+			if strings.ContainsFunc(text[block.start:block.end], isWordRune) {
+				blocks = append(blocks, TextSource{PageNumber: page.PageNumber, ByteStart: page.ByteStart + uint64(block.start), ByteEnd: page.ByteStart + uint64(block.end)})
+			}
+		}
+	}
+	return blocks
+}
+
+// comparedTextSources attributes each block of result by the share of its words found in
+// the text layer of its page: native from native up, OCR up to ocr, and hybrid between.
+func comparedTextSources(result *ExtractionResult, layers map[uint64]map[string]bool, native, ocr float64) []TextSource {
+	all := map[string]bool{}
+	for _, words := range layers {
+		for word := range words {
+			all[word] = true
+		}
+	}
+	blocks := textBlocks(result)
+	for i := range blocks {
+		layer := all
+		if blocks[i].PageNumber > 0 {
+			layer = layers[blocks[i].PageNumber]
+		}
+		coverage := wordCoverage(result.Content[blocks[i].ByteStart:blocks[i].ByteEnd], layer)
+		blocks[i].Source = classifyCoverage(coverage, native, ocr)
+		blocks[i].NativeCoverage = &coverage
+	}
+	return blocks
+}
+
+// classifyCoverage returns the source of text with the given share of words found in the
+// text layer.
+func classifyCoverage(coverage, native, ocr float64) string {
+	switch {
+	case coverage >= native:
+		return TextSourceNative
+	case coverage <= ocr:
+		return TextSourceOCR
+	}
+	return TextSourceHybrid
+}
+
+// textLayerWords returns the normalized words of the text layer of each rendered page.
+func textLayerWords(pages []PageImage) map[uint64]map[string]bool {
+	layers := make(map[uint64]map[string]bool, len(pages))
+	for _, page := range pages {
+		words := map[string]bool{}
+		for _, word := range page.Words {
+			for _, w := range normalizedWords(word.Text) {
+				words[w] = true
+			}
+		}
+		layers[uint64(page.PageNumber)] = words
+	}
+	return layers
+}
+
+// wordCoverage returns the share of the words of text found in layer.
+func wordCoverage(text string, layer map[string]bool) float64 {
+	words := normalizedWords(text)
+	if len(words) == 0 {
+		return 0
+	}
+	found := 0
+	for _, word := range words {
+		if layer[word] {
+			found++
+		}
+	}
+	return float64(found) / float64(len(words))
+}
+
+// normalizedWords splits text into lowercase words of letters and digits.
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !isWordRune(r) })
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
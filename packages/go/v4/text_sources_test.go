@@ -0,0 +1,99 @@
+package kreuzberg
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAttributeTextSources(t *testing.T) {
+	page1 := "Invoice 2024-17\n\nTotal due: 1,250.00 EUR"
+	page2 := "\n\nSigned by the buyer\n\nRecognized stamp text here"
+	content := page1 + page2
+	result := &ExtractionResult{Content: content, Pages: []PageContent{
+		{PageNumber: 1, Content: page1},
+		{PageNumber: 2, Content: page2},
+		{PageNumber: 3},
+	}}
+	result.Metadata.PageStructure = &PageStructure{Boundaries: []PageBoundary{
+		{ByteStart: 0, ByteEnd: uint64(len(page1)), PageNumber: 1},
+		{ByteStart: uint64(len(page1)), ByteEnd: uint64(len(content)), PageNumber: 2},
+	}}
+	layers := textLayerWords([]PageImage{
+		{PageNumber: 1, Words: []PageWord{{Text: "Invoice"}, {Text: "2024-17"}, {Text: "Total"}, {Text: "due:"}, {Text: "1,250.00"}, {Text: "EUR"}}},
+		{PageNumber: 2, Words: []PageWord{{Text: "Signed"}, {Text: "by"}, {Text: "the"}, {Text: "buyer"}, {Text: "stamp"}}},
+	})
+
+	attributeTextSources(result, layers, "", defaultNativeThreshold, defaultOCRThreshold)
+	var got []string
+	for _, source := range result.TextSources {
+		got = append(got, source.Source+":"+content[source.ByteStart:source.ByteEnd])
+	}
+	want := []string{
+		"native:Invoice 2024-17",
+		"native:Total due: 1,250.00 EUR",
+		"native:Signed by the buyer",
+		"hybrid:Recognized stamp text here",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("text sources =\n%q\nwant\n%q", got, want)
+	}
+	if coverage := result.TextSources[3].NativeCoverage; coverage == nil || *coverage != 0.25 {
+		t.Errorf("unexpected coverage: %v", coverage)
+	}
+	if sources := []string{result.Pages[0].Source, result.Pages[1].Source, result.Pages[2].Source}; !reflect.DeepEqual(sources, []string{TextSourceNative, TextSourceHybrid, ""}) {
+		t.Errorf("unexpected page sources: %q", sources)
+	}
+
+	attributeTextSources(result, nil, TextSourceOCR, defaultNativeThreshold, defaultOCRThreshold)
+	for _, source := range result.TextSources {
+		if source.Source != TextSourceOCR || source.NativeCoverage != nil {
+			t.Errorf("expected a fixed OCR source, got %+v", source)
+		}
+	}
+	if result.Pages[1].Source != TextSourceOCR {
+		t.Errorf("unexpected page source: %q", result.Pages[1].Source)
+	}
+}
+
+func TestSourceStage(t *testing.T) {
+	config := NewExtractionConfig(WithSourceAttribution(WithNativeThreshold(0.2), WithOCRThreshold(0.3)))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	read := func() ([]byte, error) { return nil, errors.New("not read") }
+	layer := func() ([]PageImage, error) { return nil, errors.New("not read") }
+	config = NewExtractionConfig(WithSourceAttribution())
+	scan := &ExtractionResult{Content: "Scanned receipt", MimeType: "image/png"}
+	applySourceStage(scan, read, layer, config)
+	doc := &ExtractionResult{Content: "Plain text.\n\nMore text.", MimeType: "text/plain"}
+	applySourceStage(doc, read, layer, config)
+	if len(scan.TextSources) != 1 || scan.TextSources[0].Source != TextSourceOCR {
+		t.Errorf("unexpected image sources: %+v", scan.TextSources)
+	}
+	if len(doc.TextSources) != 2 || doc.TextSources[1].Source != TextSourceNative {
+		t.Errorf("unexpected text sources: %+v", doc.TextSources)
+	}
+
+	fallback := &ExtractionResult{Content: "Recovered", MimeType: "application/pdf"}
+	fallback.Metadata.Fallback = &FallbackMetadata{Chain: []string{FallbackNative, FallbackOCR}}
+	applySourceStage(fallback, read, layer, config)
+	if len(fallback.TextSources) != 1 || fallback.TextSources[0].Source != TextSourceOCR {
+		t.Errorf("unexpected fallback sources: %+v", fallback.TextSources)
+	}
+
+	normalized := &ExtractionResult{Content: "Ａ　text", MimeType: "text/plain"}
+	applySourceStage(normalized, read, layer, config)
+	rewriteResultText(normalized, func(r rune) (string, bool) {
+		if r == '　' {
+			return " ", true
+		}
+		return "", false
+	})
+	if end := normalized.TextSources[0].ByteEnd; !strings.HasSuffix(normalized.Content[:end], "text") {
+		t.Errorf("source offsets not remapped: %d in %q", end, normalized.Content)
+	}
+}
@@ -0,0 +1,210 @@
+package kreuzberg
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// offsetAnchor pins an offset in the original text to an offset in the rewritten text.
+// When verbatim is true the bytes following the anchor (up to the next anchor) were
+// copied unchanged, so offsets inside the span map linearly.
+type offsetAnchor struct {
+	from     int
+	to       int
+	verbatim bool
+}
+
+// offsetMap translates byte offsets from a source string into a rewritten string.
+type offsetMap struct {
+	anchors []offsetAnchor
+	srcLen  int
+	dstLen  int
+}
+
+// Map returns the byte offset in the rewritten text that corresponds to offset in
+// the source text. Offsets inside a replaced rune map to the start of its replacement.
+func (m *offsetMap) Map(offset int) int {
+	if m == nil || len(m.anchors) == 0 {
+		return offset
+	}
+	if offset >= m.srcLen {
+		return m.dstLen
+	}
+	if offset <= 0 {
+		return 0
+	}
+	idx := sort.Search(len(m.anchors), func(i int) bool {
+		return m.anchors[i].from > offset
+	}) - 1
+	anchor := m.anchors[idx]
+	if anchor.verbatim {
+		return anchor.to + (offset - anchor.from)
+	}
+	return anchor.to
+}
+
+// identity reports whether the map leaves every offset unchanged.
+func (m *offsetMap) identity() bool {
+	return m == nil || len(m.anchors) == 0 || (len(m.anchors) == 1 && m.anchors[0].verbatim)
+}
+
+// rewriteRunes rewrites s one rune at a time. fn returns the replacement for a rune and
+// true, or false to keep the rune unchanged. The returned offsetMap translates byte
+// offsets in s into offsets in the rewritten string.
+func rewriteRunes(s string, fn func(r rune) (string, bool)) (string, *offsetMap) {
+	return rewriteRunesAt(s, func(_ string, _ int, r rune) (string, bool) { return fn(r) })
+}
+
+// rewriteRunesAt is rewriteRunes for rewrites that depend on the position of a rune or
+// its neighbours: fn also receives s and the byte offset of the rune.
+func rewriteRunesAt(s string, fn func(s string, i int, r rune) (string, bool)) (string, *offsetMap) {
+	var b strings.Builder
+	m := &offsetMap{srcLen: len(s)}
+	copyStart := 0
+	changed := false
+
+	flush := func(end int) {
+		if end > copyStart {
+			m.anchors = append(m.anchors, offsetAnchor{from: copyStart, to: b.Len(), verbatim: true})
+			b.WriteString(s[copyStart:end])
+		}
+	}
+
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		replacement, ok := fn(s, i, r)
+		if !ok {
+			i += size
+			continue
+		}
+		if !changed {
+			b.Grow(len(s))
+			changed = true
+		}
+		flush(i)
+		m.anchors = append(m.anchors, offsetAnchor{from: i, to: b.Len()})
+		b.WriteString(replacement)
+		i += size
+		copyStart = i
+	}
+
+	if !changed {
+		m.dstLen = len(s)
+		return s, m
+	}
+	flush(len(s))
+	m.dstLen = b.Len()
+	return b.String(), m
+}
+
+// spliceOffsetMap maps offsets across the replacement of the byte range [start, end) of a
+// string of srcLen bytes with replacementLen bytes. Offsets inside the range map to its
+// start.
+func spliceOffsetMap(srcLen, start, end, replacementLen int) *offsetMap {
+	m := &offsetMap{srcLen: srcLen, dstLen: srcLen - (end - start) + replacementLen}
+	if start > 0 {
+		m.anchors = append(m.anchors, offsetAnchor{from: 0, to: 0, verbatim: true})
+	}
+	m.anchors = append(m.anchors, offsetAnchor{from: start, to: start})
+	if end < srcLen {
+		m.anchors = append(m.anchors, offsetAnchor{from: end, to: start + replacementLen, verbatim: true})
+	}
+	return m
+}
+
+// removeSpans removes the sorted, non-overlapping byte ranges spans from s. The returned
+// offsetMap maps offsets inside a removed range to where the range was.
+func removeSpans(s string, spans [][2]int) (string, *offsetMap) {
+	var b strings.Builder
+	m := &offsetMap{srcLen: len(s)}
+	copyStart := 0
+	for _, span := range spans {
+		if span[0] > copyStart {
+			m.anchors = append(m.anchors, offsetAnchor{from: copyStart, to: b.Len(), verbatim: true})
+			b.WriteString(s[copyStart:span[0]])
+		}
+		m.anchors = append(m.anchors, offsetAnchor{from: span[0], to: b.Len()})
+		copyStart = span[1]
+	}
+	if copyStart < len(s) {
+		m.anchors = append(m.anchors, offsetAnchor{from: copyStart, to: b.Len(), verbatim: true})
+		b.WriteString(s[copyStart:])
+	}
+	m.dstLen = b.Len()
+	return b.String(), m
+}
+
+// remapResultOffsets rewrites chunk and page boundary byte offsets after Content has been
+// rewritten according to m.
+func remapResultOffsets(result *ExtractionResult, m *offsetMap) {
+	if m.identity() {
+		return
+	}
+	for i := range result.Chunks {
+		meta := &result.Chunks[i].Metadata
+		meta.ByteStart = uint64(m.Map(int(meta.ByteStart)))
+		meta.ByteEnd = uint64(m.Map(int(meta.ByteEnd)))
+	}
+	if ps := result.Metadata.PageStructure; ps != nil {
+		for i := range ps.Boundaries {
+			ps.Boundaries[i].ByteStart = uint64(m.Map(int(ps.Boundaries[i].ByteStart)))
+			ps.Boundaries[i].ByteEnd = uint64(m.Map(int(ps.Boundaries[i].ByteEnd)))
+		}
+	}
+	walkHeadings(result.Headings, func(heading *Heading) {
+		heading.ByteStart = uint64(m.Map(int(heading.ByteStart)))
+		heading.ByteEnd = uint64(m.Map(int(heading.ByteEnd)))
+	})
+	for i := range result.StyleSpans {
+		span := &result.StyleSpans[i]
+		span.ByteStart = uint64(m.Map(int(span.ByteStart)))
+		span.ByteEnd = uint64(m.Map(int(span.ByteEnd)))
+	}
+	for i := range result.TextSources {
+		source := &result.TextSources[i]
+		source.ByteStart = uint64(m.Map(int(source.ByteStart)))
+		source.ByteEnd = uint64(m.Map(int(source.ByteEnd)))
+	}
+}
+
+// rewriteResultText applies fn to Content, Pages, Chunks, and Headings of result and keeps
+// the byte offsets of chunks, page boundaries, and headings consistent with the rewritten
+// Content.
+func rewriteResultText(result *ExtractionResult, fn func(r rune) (string, bool)) {
+	rewriteResultTextAt(result, func(_ string, _ int, r rune) (string, bool) { return fn(r) })
+}
+
+// rewriteResultTextAt is rewriteResultText with the rewrite function of rewriteRunesAt.
+func rewriteResultTextAt(result *ExtractionResult, fn func(s string, i int, r rune) (string, bool)) {
+	content, m := rewriteRunesAt(result.Content, fn)
+	result.Content = content
+	remapResultOffsets(result, m)
+
+	for i := range result.Pages {
+		result.Pages[i].Content, _ = rewriteRunesAt(result.Pages[i].Content, fn)
+	}
+	for i := range result.Chunks {
+		result.Chunks[i].Content, _ = rewriteRunesAt(result.Chunks[i].Content, fn)
+	}
+	walkHeadings(result.Headings, func(heading *Heading) {
+		heading.Text, _ = rewriteRunesAt(heading.Text, fn)
+	})
+}
+
+// forEachLine calls fn for every line of text with the byte offset of the line start.
+// The line excludes its terminating "\n" and a trailing "\r".
+func forEachLine(text string, fn func(start int, line string)) {
+	for pos := 0; pos < len(text); {
+		end := strings.IndexByte(text[pos:], '\n')
+		next := len(text)
+		if end == -1 {
+			end = len(text)
+		} else {
+			end += pos
+			next = end + 1
+		}
+		fn(pos, strings.TrimSuffix(text[pos:end], "\r"))
+		pos = next
+	}
+}
@@ -0,0 +1,210 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// TextStats describes the extracted text of a document for content-quality checks.
+// Words are runs of letters and digits, with inner apostrophes and hyphens; sentences
+// end at terminal punctuation followed by a space and at paragraph breaks. Averages are
+// words per sentence and letters per word.
+//
+// FleschReadingEase uses the formula of the configured language, where higher is
+// easier; FleschKincaidGrade is the English school grade. LIX adds the average sentence
+// length to the percentage of words longer than six letters, with 40 about the level of
+// a newspaper. TypeTokenRatio is the share of distinct words and MTLD the measure of
+// textual lexical diversity, which unlike the ratio does not fall as the text grows.
+// Scores are 0 for text without words and assume space-separated words.
+type TextStats struct {
+	Sentences             int     `json:"sentences"`
+	Words                 int     `json:"words"`
+	Characters            int     `json:"characters"`
+	Syllables             int     `json:"syllables"`
+	LongWords             int     `json:"long_words"`
+	UniqueWords           int     `json:"unique_words"`
+	AverageSentenceLength float64 `json:"average_sentence_length"`
+	AverageWordLength     float64 `json:"average_word_length"`
+	FleschReadingEase     float64 `json:"flesch_reading_ease"`
+	FleschKincaidGrade    float64 `json:"flesch_kincaid_grade"`
+	LIX                   float64 `json:"lix"`
+	TypeTokenRatio        float64 `json:"type_token_ratio"`
+	MTLD                  float64 `json:"mtld"`
+}
+
+// fleschFormula holds the constants of a language's reading ease formula: base minus
+// sentence times words per sentence minus syllable times syllables per word.
+type fleschFormula struct {
+	base, sentence, syllable float64
+}
+
+// fleschFormulas are Flesch's English formula and its adaptations by Amstad (German),
+// Fernández Huerta (Spanish), Kandel and Moles (French), Vacca (Italian) and Douma
+// (Dutch).
+var fleschFormulas = map[string]fleschFormula{
+	"en": {206.835, 1.015, 84.6},
+	"de": {180, 1, 58.5},
+	"es": {206.84, 1.02, 60},
+	"fr": {207, 1.015, 73.6},
+	"it": {217, 1.3, 60},
+	"nl": {206.835, 0.93, 77},
+}
+
+var (
+	paragraphBreak = regexp.MustCompile(`\n[ \t\r]*\n`)
+	sentenceBreak  = regexp.MustCompile(`[.!?…]+["'”’»)\]]*\s+|[。！？]+`)
+)
+
+const (
+	// lixLongWord is the length from which LIX counts a word as long.
+	lixLongWord = 7
+	// mtldThreshold is the type-token ratio at which MTLD closes a factor.
+	mtldThreshold = 0.72
+)
+
+func validateTextStatsConfig(cfg *TextStatsConfig) error {
+	if cfg.Language == "" {
+		return nil
+	}
+	if _, ok := fleschFormulas[cfg.Language]; !ok {
+		return newValidationErrorWithContext(fmt.Sprintf("invalid text stats language: %s", cfg.Language), nil, ErrorCodeValidation, nil)
+	}
+	return nil
+}
+
+// ComputeTextStats counts the sentences, words and syllables of text and derives its
+// readability scores and vocabulary richness. A nil config uses the defaults.
+func ComputeTextStats(text string, config *TextStatsConfig) *TextStats {
+	language := "en"
+	if config != nil && config.Language != "" {
+		language = config.Language
+	}
+	formula, ok := fleschFormulas[language]
+	if !ok {
+		formula = fleschFormulas["en"]
+	}
+
+	stats := &TextStats{}
+	var tokens []string
+	distinct := map[string]bool{}
+	for _, paragraph := range paragraphBreak.Split(text, -1) {
+		for _, sentence := range sentenceBreak.Split(paragraph, -1) {
+			words := textWords(sentence)
+			if len(words) == 0 {
+				continue
+			}
+			stats.Sentences++
+			for _, word := range words {
+				letters := 0
+				for _, r := range word {
+					if unicode.IsLetter(r) || unicode.IsDigit(r) {
+						letters++
+					}
+				}
+				stats.Characters += letters
+				if letters >= lixLongWord {
+					stats.LongWords++
+				}
+				stats.Syllables += countSyllables(word, language)
+				token := strings.ToLower(word)
+				tokens = append(tokens, token)
+				distinct[token] = true
+			}
+		}
+	}
+	stats.Words = len(tokens)
+	stats.UniqueWords = len(distinct)
+	if stats.Words == 0 {
+		return stats
+	}
+
+	words, sentences := float64(stats.Words), float64(stats.Sentences)
+	stats.AverageSentenceLength = words / sentences
+	stats.AverageWordLength = float64(stats.Characters) / words
+	syllablesPerWord := float64(stats.Syllables) / words
+	stats.FleschReadingEase = formula.base - formula.sentence*stats.AverageSentenceLength - formula.syllable*syllablesPerWord
+	stats.FleschKincaidGrade = 0.39*stats.AverageSentenceLength + 11.8*syllablesPerWord - 15.59
+	stats.LIX = stats.AverageSentenceLength + 100*float64(stats.LongWords)/words
+	stats.TypeTokenRatio = float64(stats.UniqueWords) / words
+	stats.MTLD = mtld(tokens)
+	return stats
+}
+
+// textWords splits text into words, dropping punctuation around them.
+func textWords(text string) []string {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && r != '\'' && r != '’' && r != '-'
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		if word := strings.Trim(field, "'’-"); word != "" {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// countSyllables estimates the syllables of a word as its groups of vowels, not counting
+// a silent final e in English. Every word has at least one.
+func countSyllables(word, language string) int {
+	word = strings.ToLower(word)
+	groups := 0
+	inVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouyàáâãäåæèéêëìíîïòóôõöøùúûüýÿœ", r)
+		if vowel && !inVowel {
+			groups++
+		}
+		inVowel = vowel
+	}
+	if language == "en" && groups > 1 && strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") &&
+		!strings.HasSuffix(word, "ee") {
+		groups--
+	}
+	return max(groups, 1)
+}
+
+// mtld averages the forward and backward passes of the measure of textual lexical
+// diversity: the number of words divided by the number of stretches whose type-token
+// ratio stays above mtldThreshold. It is 0 when no stretch falls to the threshold.
+func mtld(tokens []string) float64 {
+	forward := mtldPass(tokens)
+	reversed := make([]string, len(tokens))
+	for i, token := range tokens {
+		reversed[len(tokens)-1-i] = token
+	}
+	backward := mtldPass(reversed)
+	if forward == 0 || backward == 0 {
+		return 0
+	}
+	return (forward + backward) / 2
+}
+
+// mtldPass runs one pass of mtld over tokens in order, counting the final stretch as
+// the part of a factor its ratio has covered.
+func mtldPass(tokens []string) float64 {
+	factors := 0.0
+	types := map[string]bool{}
+	count := 0
+	ratio := 1.0
+	for _, token := range tokens {
+		types[token] = true
+		count++
+		ratio = float64(len(types)) / float64(count)
+		if ratio <= mtldThreshold {
+			factors++
+			types = map[string]bool{}
+			count = 0
+			ratio = 1
+		}
+	}
+	if count > 0 {
+		factors += (1 - ratio) / (1 - mtldThreshold)
+	}
+	if factors == 0 {
+		return 0
+	}
+	return float64(len(tokens)) / factors
+}
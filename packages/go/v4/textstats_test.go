@@ -0,0 +1,93 @@
+package kreuzberg
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestComputeTextStats(t *testing.T) {
+	stats := ComputeTextStats("The cat sat on the mat. The dog ran!", nil)
+	if stats.Sentences != 2 || stats.Words != 9 || stats.Syllables != 9 || stats.UniqueWords != 7 {
+		t.Fatalf("unexpected counts: %+v", stats)
+	}
+	if stats.Characters != 26 || stats.LongWords != 0 {
+		t.Fatalf("unexpected characters: %+v", stats)
+	}
+	checks := map[string][2]float64{
+		"average sentence length": {stats.AverageSentenceLength, 4.5},
+		"average word length":     {stats.AverageWordLength, 26.0 / 9},
+		"flesch reading ease":     {stats.FleschReadingEase, 206.835 - 1.015*4.5 - 84.6},
+		"flesch-kincaid grade":    {stats.FleschKincaidGrade, 0.39*4.5 + 11.8 - 15.59},
+		"lix":                     {stats.LIX, 4.5},
+		"type-token ratio":        {stats.TypeTokenRatio, 7.0 / 9},
+	}
+	for name, check := range checks {
+		if math.Abs(check[0]-check[1]) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, check[0], check[1])
+		}
+	}
+
+	german := ComputeTextStats("The cat sat on the mat. The dog ran!", &TextStatsConfig{Language: "de"})
+	if math.Abs(german.FleschReadingEase-(180-4.5-58.5)) > 1e-9 {
+		t.Errorf("german reading ease = %v", german.FleschReadingEase)
+	}
+
+	if empty := ComputeTextStats(" \n\n ... ", nil); empty.Words != 0 || empty.Sentences != 0 || empty.FleschReadingEase != 0 {
+		t.Errorf("expected empty stats, got %+v", empty)
+	}
+}
+
+func TestComputeTextStatsSentences(t *testing.T) {
+	cases := map[string]int{
+		"Quarterly Report\n\nRevenue grew by 3.5 percent.": 2,
+		"Is it done? Yes. \"Quite.\" Then go":              4,
+		"Line one\nstill the same sentence.":               1,
+		"第一句。第二句！":                                         2,
+	}
+	for text, want := range cases {
+		if got := ComputeTextStats(text, nil).Sentences; got != want {
+			t.Errorf("sentences of %q = %d, want %d", text, got, want)
+		}
+	}
+	if words := textWords("It's a well-known 'fact' - isn't it?"); len(words) != 6 || words[0] != "It's" || words[2] != "well-known" || words[3] != "fact" {
+		t.Errorf("unexpected words: %q", words)
+	}
+}
+
+func TestCountSyllables(t *testing.T) {
+	cases := map[string]int{"cat": 1, "make": 1, "table": 2, "free": 1, "reading": 2, "readability": 5, "Übergröße": 3, "42": 1}
+	for word, want := range cases {
+		if got := countSyllables(word, "en"); got != want {
+			t.Errorf("countSyllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+	if got := countSyllables("Straße", "de"); got != 2 {
+		t.Errorf("countSyllables(Straße, de) = %d, want 2", got)
+	}
+}
+
+func TestMTLD(t *testing.T) {
+	if got := mtld([]string{"a", "a", "a", "a"}); got != 2 {
+		t.Errorf("mtld of a repeated word = %v, want 2", got)
+	}
+	if got := mtld([]string{"a", "b", "c"}); got != 0 {
+		t.Errorf("mtld without a factor = %v, want 0", got)
+	}
+}
+
+func TestTextStatsStage(t *testing.T) {
+	config := NewExtractionConfig(WithTextStats(WithReadabilityLanguage("xx")))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	result := &ExtractionResult{Content: "One short sentence."}
+	if err := runResultStages(result, NewExtractionConfig(WithTextStats())); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+	if result.TextStats == nil || result.TextStats.Sentences != 1 || result.TextStats.Words != 3 {
+		t.Fatalf("unexpected text stats: %+v", result.TextStats)
+	}
+}
@@ -0,0 +1,141 @@
+package kreuzberg
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The binding reads the directories of classic TIFFs, and of the TIFF blocks that carry
+// EXIF data, for the resolution and color mode of scanned pages. Multi-page TIFFs are
+// split and recognized page by page in the core.
+
+const (
+	tiffNewSubfileType = 254
+	tiffSubfileType    = 255
+	tiffXResolution    = 282
+	tiffResolutionUnit = 296
+	tiffExifIFD        = 34665
+	tiffGPSIFD         = 34853
+
+	// maxTIFFPages bounds the directory chain walked for pages.
+	maxTIFFPages = 10000
+)
+
+type tiffEntry struct {
+	tag   uint16
+	kind  uint16
+	count uint32
+	value []byte
+}
+
+// tiffPage is a page of a TIFF: the fields of its directory as numbers.
+type tiffPage struct {
+	fields map[uint16][]uint64
+}
+
+var tiffTypeSizes = map[uint16]int{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8}
+
+// tiffPages reads the directories of a classic TIFF, skipping reduced-resolution
+// images such as thumbnails.
+func tiffPages(data []byte) (binary.ByteOrder, []tiffPage, error) {
+	if len(data) < 8 {
+		return nil, nil, fmt.Errorf("not a TIFF file")
+	}
+	var order binary.ByteOrder
+	switch string(data[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return nil, nil, fmt.Errorf("not a classic TIFF file")
+	}
+	var pages []tiffPage
+	seen := map[uint32]bool{}
+	for offset := order.Uint32(data[4:]); offset != 0; {
+		if seen[offset] || len(seen) >= maxTIFFPages {
+			return nil, nil, fmt.Errorf("directory chain loops or is too long")
+		}
+		seen[offset] = true
+		start := int(offset)
+		if start < 8 || start+2 > len(data) {
+			return nil, nil, fmt.Errorf("invalid directory offset %d", offset)
+		}
+		count := int(order.Uint16(data[start:]))
+		end := start + 2 + 12*count
+		if count > maxTIFFDirectoryEntries || end+4 > len(data) {
+			return nil, nil, fmt.Errorf("invalid directory size %d", count)
+		}
+		page := tiffPage{fields: map[uint16][]uint64{}}
+		for i := range count {
+			raw := data[start+2+12*i:]
+			entry := tiffEntry{tag: order.Uint16(raw), kind: order.Uint16(raw[2:]), count: order.Uint32(raw[4:])}
+			size := tiffTypeSizes[entry.kind]
+			if size == 0 || uint64(entry.count)*uint64(size) > uint64(len(data)) {
+				continue
+			}
+			length := size * int(entry.count)
+			entry.value = raw[8 : 8+min(length, 4)]
+			if length > 4 {
+				at := int(order.Uint32(raw[8:]))
+				if at < 0 || at+length > len(data) {
+					continue
+				}
+				entry.value = data[at : at+length]
+			}
+			page.fields[entry.tag] = entry.numbers(order)
+		}
+		reduced := len(page.fields[tiffNewSubfileType]) > 0 && page.fields[tiffNewSubfileType][0]&1 != 0
+		reduced = reduced || (len(page.fields[tiffSubfileType]) > 0 && page.fields[tiffSubfileType][0] == 2)
+		if !reduced {
+			pages = append(pages, page)
+		}
+		offset = order.Uint32(data[end:])
+	}
+	return order, pages, nil
+}
+
+// numbers returns the integer values of the entry; rationals are returned as numerator
+// and denominator pairs.
+func (e tiffEntry) numbers(order binary.ByteOrder) []uint64 {
+	var out []uint64
+	switch e.kind {
+	case 1, 6:
+		for _, b := range e.value {
+			out = append(out, uint64(b))
+		}
+	case 3, 8:
+		for i := 0; i+2 <= len(e.value); i += 2 {
+			out = append(out, uint64(order.Uint16(e.value[i:])))
+		}
+	case 4, 5, 9, 10:
+		for i := 0; i+4 <= len(e.value); i += 4 {
+			out = append(out, uint64(order.Uint32(e.value[i:])))
+		}
+	}
+	return out
+}
+
+// field returns the first value of a field, or 0.
+func (p tiffPage) field(tag uint16) uint64 {
+	if values := p.fields[tag]; len(values) > 0 {
+		return values[0]
+	}
+	return 0
+}
+
+// resolution returns the resolution of a field in dots per inch, or 0.
+func (p tiffPage) resolution(tag uint16) float64 {
+	values := p.fields[tag]
+	if len(values) < 2 || values[1] == 0 {
+		return 0
+	}
+	dpi := float64(values[0]) / float64(values[1])
+	switch p.field(tiffResolutionUnit) {
+	case 1:
+		return 0
+	case 3:
+		dpi *= 2.54
+	}
+	return dpi
+}
@@ -0,0 +1,220 @@
+package kreuzberg
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Translator translates text segments into a target language. Implementations must
+// return exactly one translation per input segment, in order.
+type Translator interface {
+	Translate(segments []string, targetLanguage string) ([]string, error)
+}
+
+// TranslatorFunc adapts an ordinary function to the Translator interface.
+type TranslatorFunc func(segments []string, targetLanguage string) ([]string, error)
+
+// Translate calls f(segments, targetLanguage).
+func (f TranslatorFunc) Translate(segments []string, targetLanguage string) ([]string, error) {
+	return f(segments, targetLanguage)
+}
+
+var (
+	translatorsMu sync.RWMutex
+	translators   = map[string]Translator{}
+)
+
+// RegisterTranslator registers a translator used when ExtractionConfig.TranslateTo is set.
+func RegisterTranslator(name string, translator Translator) error {
+	if name == "" {
+		return newValidationErrorWithContext("translator name cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+	if translator == nil {
+		return newValidationErrorWithContext("translator cannot be nil", nil, ErrorCodeValidation, nil)
+	}
+
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	translators[name] = translator
+	return nil
+}
+
+// UnregisterTranslator removes a previously registered translator.
+func UnregisterTranslator(name string) error {
+	if name == "" {
+		return newValidationErrorWithContext("translator name cannot be empty", nil, ErrorCodeValidation, nil)
+	}
+
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	if _, ok := translators[name]; !ok {
+		return newPluginErrorWithContext(name, fmt.Sprintf("translator '%s' is not registered", name), nil, ErrorCodePlugin, nil)
+	}
+	delete(translators, name)
+	return nil
+}
+
+// ListTranslators returns the names of all registered translators in sorted order.
+func ListTranslators() ([]string, error) {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+
+	names := make([]string, 0, len(translators))
+	for name := range translators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// ClearTranslators removes all registered translators.
+func ClearTranslators() error {
+	translatorsMu.Lock()
+	defer translatorsMu.Unlock()
+	translators = map[string]Translator{}
+	return nil
+}
+
+// resolveTranslator returns the translator selected by cfg. When no name is configured
+// the only registered translator is used.
+func resolveTranslator(cfg *TranslationConfig) (string, Translator, error) {
+	translatorsMu.RLock()
+	defer translatorsMu.RUnlock()
+
+	if cfg != nil && cfg.Translator != "" {
+		translator, ok := translators[cfg.Translator]
+		if !ok {
+			return "", nil, newPluginErrorWithContext(cfg.Translator, fmt.Sprintf("translator '%s' is not registered", cfg.Translator), nil, ErrorCodePlugin, nil)
+		}
+		return cfg.Translator, translator, nil
+	}
+
+	switch len(translators) {
+	case 0:
+		return "", nil, newValidationErrorWithContext("translate_to is set but no translator is registered", nil, ErrorCodeValidation, nil)
+	case 1:
+		for name, translator := range translators {
+			return name, translator, nil
+		}
+	}
+	return "", nil, newValidationErrorWithContext("multiple translators registered; set translation.translator to choose one", nil, ErrorCodeValidation, nil)
+}
+
+// validateTranslationConfig checks that a translator can be resolved for config.
+func validateTranslationConfig(config *ExtractionConfig) error {
+	_, _, err := resolveTranslator(config.Translation)
+	return err
+}
+
+// splitTranslationSegments splits text into paragraph segments separated by blank lines.
+// It returns the whitespace-trimmed [start, end) byte range of every non-blank segment;
+// everything between segments is preserved verbatim in the translation.
+func splitTranslationSegments(text string) [][2]int {
+	var spans [][2]int
+	start := 0
+	for start < len(text) {
+		end := strings.Index(text[start:], "\n\n")
+		if end == -1 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		segment := text[start:end]
+		if trimmed := strings.TrimSpace(segment); trimmed != "" {
+			segStart := start + strings.Index(segment, trimmed)
+			spans = append(spans, [2]int{segStart, segStart + len(trimmed)})
+		}
+		start = end
+		for start < len(text) && text[start] == '\n' {
+			start++
+		}
+	}
+	return spans
+}
+
+// applyTranslation fills TranslatedContent, TranslationSegments, and (optionally) the
+// translated chunk contents of result.
+func applyTranslation(result *ExtractionResult, config *ExtractionConfig) error {
+	name, translator, err := resolveTranslator(config.Translation)
+	if err != nil {
+		return err
+	}
+	target := config.TranslateTo
+
+	spans := splitTranslationSegments(result.Content)
+	segments := make([]string, len(spans))
+	for i, span := range spans {
+		segments[i] = result.Content[span[0]:span[1]]
+	}
+	translated, err := runTranslator(name, translator, segments, target)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	result.TranslationSegments = make([]TranslationSegment, 0, len(spans))
+	prev := 0
+	for i, span := range spans {
+		b.WriteString(result.Content[prev:span[0]])
+		targetStart := b.Len()
+		b.WriteString(translated[i])
+		result.TranslationSegments = append(result.TranslationSegments, TranslationSegment{
+			SourceStart: uint64(span[0]),
+			SourceEnd:   uint64(span[1]),
+			TargetStart: uint64(targetStart),
+			TargetEnd:   uint64(b.Len()),
+		})
+		prev = span[1]
+	}
+	b.WriteString(result.Content[prev:])
+	result.TranslatedContent = b.String()
+	result.TranslatedLanguage = target
+
+	if config.Translation == nil || config.Translation.TranslateChunks == nil || !*config.Translation.TranslateChunks || len(result.Chunks) == 0 {
+		return nil
+	}
+	chunkTexts := make([]string, len(result.Chunks))
+	for i := range result.Chunks {
+		chunkTexts[i] = result.Chunks[i].Content
+	}
+	translatedChunks, err := runTranslator(name, translator, chunkTexts, target)
+	if err != nil {
+		return err
+	}
+	for i := range result.Chunks {
+		result.Chunks[i].TranslatedContent = translatedChunks[i]
+	}
+	return nil
+}
+
+func runTranslator(name string, translator Translator, segments []string, target string) ([]string, error) {
+	if len(segments) == 0 {
+		return nil, nil
+	}
+	translated, err := translator.Translate(segments, target)
+	if err != nil {
+		return nil, newPluginErrorWithContext(name, fmt.Sprintf("translator '%s' failed", name), err, ErrorCodePlugin, nil)
+	}
+	if len(translated) != len(segments) {
+		return nil, newPluginErrorWithContext(name,
+			fmt.Sprintf("translator '%s' returned %d segments, expected %d", name, len(translated), len(segments)),
+			nil, ErrorCodePlugin, nil)
+	}
+	return translated, nil
+}
+
+// SourceOffset maps a byte offset in TranslatedContent back to the byte range of the
+// original Content it was translated from. It returns false when the offset falls
+// outside any translated segment or no translation is present.
+func (r *ExtractionResult) SourceOffset(translatedOffset uint64) (start, end uint64, ok bool) {
+	segments := r.TranslationSegments
+	idx := sort.Search(len(segments), func(i int) bool {
+		return segments[i].TargetEnd > translatedOffset
+	})
+	if idx == len(segments) || segments[idx].TargetStart > translatedOffset {
+		return 0, 0, false
+	}
+	return segments[idx].SourceStart, segments[idx].SourceEnd, true
+}
@@ -0,0 +1,125 @@
+package kreuzberg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func upperTranslator() Translator {
+	return TranslatorFunc(func(segments []string, target string) ([]string, error) {
+		out := make([]string, len(segments))
+		for i, segment := range segments {
+			out[i] = "[" + target + "] " + strings.ToUpper(segment)
+		}
+		return out, nil
+	})
+}
+
+func TestTranslatorRegistry(t *testing.T) {
+	t.Cleanup(func() { _ = ClearTranslators() })
+
+	if err := RegisterTranslator("", upperTranslator()); err == nil {
+		t.Fatal("expected error for empty name")
+	}
+	if err := RegisterTranslator("upper", nil); err == nil {
+		t.Fatal("expected error for nil translator")
+	}
+	if err := RegisterTranslator("upper", upperTranslator()); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	names, err := ListTranslators()
+	if err != nil || len(names) != 1 || names[0] != "upper" {
+		t.Fatalf("unexpected translators: %v (%v)", names, err)
+	}
+	if err := UnregisterTranslator("missing"); err == nil {
+		t.Fatal("expected error when unregistering unknown translator")
+	}
+	if err := UnregisterTranslator("upper"); err != nil {
+		t.Fatalf("unregister: %v", err)
+	}
+}
+
+func TestApplyTranslationPreservesOffsets(t *testing.T) {
+	t.Cleanup(func() { _ = ClearTranslators() })
+	if err := RegisterTranslator("upper", upperTranslator()); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	content := "hello world\n\n\nsecond paragraph\n"
+	result := &ExtractionResult{
+		Content: content,
+		Chunks:  []Chunk{{Content: "hello world"}},
+	}
+	config := NewExtractionConfig(WithTranslateTo("de"), WithTranslation(WithTranslateChunks(true)))
+	if err := validateResultStages(config); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if err := runResultStages(result, config); err != nil {
+		t.Fatalf("run stages: %v", err)
+	}
+
+	want := "[de] HELLO WORLD\n\n\n[de] SECOND PARAGRAPH\n"
+	if result.TranslatedContent != want {
+		t.Fatalf("unexpected translation: %q", result.TranslatedContent)
+	}
+	if result.TranslatedLanguage != "de" {
+		t.Errorf("unexpected language: %q", result.TranslatedLanguage)
+	}
+	if len(result.TranslationSegments) != 2 {
+		t.Fatalf("expected 2 segments, got %d", len(result.TranslationSegments))
+	}
+	for _, segment := range result.TranslationSegments {
+		source := content[segment.SourceStart:segment.SourceEnd]
+		target := result.TranslatedContent[segment.TargetStart:segment.TargetEnd]
+		if !strings.HasSuffix(target, strings.ToUpper(source)) {
+			t.Errorf("segment %+v maps %q to %q", segment, source, target)
+		}
+	}
+
+	offset := uint64(strings.Index(result.TranslatedContent, "SECOND"))
+	start, end, ok := result.SourceOffset(offset)
+	if !ok || content[start:end] != "second paragraph" {
+		t.Errorf("SourceOffset(%d) = %d, %d, %v", offset, start, end, ok)
+	}
+	if _, _, ok := result.SourceOffset(uint64(len(result.TranslatedContent))); ok {
+		t.Error("offset past the end should not resolve")
+	}
+
+	if result.Chunks[0].TranslatedContent != "[de] HELLO WORLD" {
+		t.Errorf("unexpected chunk translation: %q", result.Chunks[0].TranslatedContent)
+	}
+}
+
+func TestTranslationRequiresTranslator(t *testing.T) {
+	t.Cleanup(func() { _ = ClearTranslators() })
+
+	config := NewExtractionConfig(WithTranslateTo("fr"))
+	var validationErr *ValidationError
+	if err := validateResultStages(config); !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	config.Translation = NewTranslationConfig(WithTranslator("missing"))
+	var pluginErr *PluginError
+	if err := validateResultStages(config); !errors.As(err, &pluginErr) {
+		t.Fatalf("expected PluginError, got %v", err)
+	}
+}
+
+func TestTranslationSegmentCountMismatch(t *testing.T) {
+	t.Cleanup(func() { _ = ClearTranslators() })
+	broken := TranslatorFunc(func(segments []string, _ string) ([]string, error) {
+		return segments[:0], nil
+	})
+	if err := RegisterTranslator("broken", broken); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	result := &ExtractionResult{Content: "text"}
+	err := runResultStages(result, NewExtractionConfig(WithTranslateTo("es")))
+	var pluginErr *PluginError
+	if !errors.As(err, &pluginErr) || pluginErr.PluginName != "broken" {
+		t.Fatalf("expected PluginError from broken translator, got %v", err)
+	}
+}
@@ -0,0 +1,165 @@
+package kreuzberg
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Turn is what one speaker says in a transcript, from its speaker label to the next.
+// Timestamp is the time written with the label, such as "00:14:03", if any. Text joins
+// the lines of the turn with spaces, without the label and without transcript line
+// numbers; ByteStart and ByteEnd delimit the turn in Content, label included.
+type Turn struct {
+	Speaker   string `json:"speaker"`
+	Text      string `json:"text"`
+	Timestamp string `json:"timestamp,omitempty"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// TurnDetectionConfig enables speaker turn segmentation into ExtractionResult.Turns, for
+// transcripts such as depositions, interviews and meeting minutes.
+type TurnDetectionConfig struct {
+	// Speakers are labels accepted as speakers even when they speak only once, such as
+	// the names of the participants. Matched case-insensitively.
+	Speakers []string `json:"speakers,omitempty"`
+}
+
+var (
+	// speakerLinePattern matches a line opening a turn: "ALICE: text", "Dr. Bob Lee:",
+	// "[00:14:03] Alice: text", "Alice (00:14:03): text" and "**Alice:** text", after an
+	// optional transcript line number.
+	speakerLinePattern = regexp.MustCompile(`^(\s*\d{1,2}\s+)?(?:\[?(\d{1,2}:\d{2}(?::\d{2})?)\]?\s+)?(?:\*\*)?([^\s:*\[#>|(][^:*\n(]{0,39}?)\s*(?:\((\d{1,2}:\d{2}(?::\d{2})?)\))?(?::\*\*|\*\*:|:)(?:\s+|$)`)
+	// questionAnswerPattern matches the "Q." and "A." lines of a deposition.
+	questionAnswerPattern = regexp.MustCompile(`^(\s*\d{1,2}\s+)?([QA])[.:](?:\s+|$)`)
+	lineNumberPattern     = regexp.MustCompile(`^\s*\d{1,2}\s+`)
+)
+
+// notSpeakers are labels of form fields and notes, which look like speaker labels.
+var notSpeakers = map[string]bool{
+	"note": true, "notes": true, "date": true, "time": true, "subject": true, "re": true,
+	"to": true, "from": true, "cc": true, "location": true, "place": true, "attendees": true,
+	"present": true, "absent": true, "agenda": true, "page": true, "exhibit": true,
+	"source": true, "total": true, "example": true, "phone": true, "email": true, "fax": true,
+	"action": true, "decision": true, "question": true, "answer": true, "summary": true,
+}
+
+// speakerLabel reports whether label can name a speaker: at most four words, each
+// capitalized, such as "THE WITNESS", "Dr. Bob Lee" or "Speaker 2".
+func speakerLabel(label string) bool {
+	words := strings.Fields(label)
+	if len(words) == 0 || len(words) > 4 || notSpeakers[strings.ToLower(label)] {
+		return false
+	}
+	for i, word := range words {
+		first := []rune(word)[0]
+		if !unicode.IsUpper(first) && !(i > 0 && unicode.IsDigit(first)) {
+			return false
+		}
+	}
+	return true
+}
+
+// speakerLine is a line opening a turn.
+type speakerLine struct {
+	start, textStart int
+	speaker          string
+	timestamp        string
+	numbered         bool
+}
+
+// DetectTurns segments a transcript into speaker turns. Lines opening with a speaker
+// label start a turn that runs to the next one; the text before the first turn and
+// Markdown headings are left out. Labels must be spoken at least twice, or be a "Q." or
+// "A." of a deposition, or be one of cfg.Speakers; a text with fewer than two speakers
+// has no turns.
+func DetectTurns(text string, cfg *TurnDetectionConfig) []Turn {
+	known := map[string]bool{}
+	if cfg != nil {
+		for _, speaker := range cfg.Speakers {
+			known[strings.ToLower(strings.TrimSpace(speaker))] = true
+		}
+	}
+
+	var lines []speakerLine
+	var headings []int
+	counts := map[string]int{}
+	for start := 0; start < len(text); {
+		end := strings.IndexByte(text[start:], '\n')
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += start
+		}
+		line := text[start:end]
+		if strings.HasPrefix(strings.TrimLeft(line, " \t"), "#") {
+			headings = append(headings, start)
+		} else if m := questionAnswerPattern.FindStringSubmatchIndex(line); m != nil {
+			lines = append(lines, speakerLine{start: start, textStart: start + m[1], speaker: line[m[4]:m[5]], numbered: m[2] >= 0})
+		} else if m := speakerLinePattern.FindStringSubmatchIndex(line); m != nil {
+			speaker := strings.TrimSpace(line[m[6]:m[7]])
+			if speakerLabel(speaker) || known[strings.ToLower(speaker)] {
+				sl := speakerLine{start: start, textStart: start + m[1], speaker: speaker, numbered: m[2] >= 0}
+				if m[4] >= 0 {
+					sl.timestamp = line[m[4]:m[5]]
+				} else if m[8] >= 0 {
+					sl.timestamp = line[m[8]:m[9]]
+				}
+				lines = append(lines, sl)
+				counts[speaker]++
+			}
+		}
+		start = end + 1
+	}
+
+	accepted := lines[:0]
+	speakers := map[string]bool{}
+	for _, line := range lines {
+		if line.speaker == "Q" || line.speaker == "A" || counts[line.speaker] >= 2 || known[strings.ToLower(line.speaker)] {
+			accepted = append(accepted, line)
+			speakers[line.speaker] = true
+		}
+	}
+	if len(speakers) < 2 {
+		return nil
+	}
+
+	turns := make([]Turn, 0, len(accepted))
+	for i, line := range accepted {
+		end := len(text)
+		if i+1 < len(accepted) {
+			end = accepted[i+1].start
+		}
+		for _, heading := range headings {
+			if heading > line.start && heading < end {
+				end = heading
+				break
+			}
+		}
+		end = line.start + len(strings.TrimRightFunc(text[line.start:end], unicode.IsSpace))
+		turns = append(turns, Turn{
+			Speaker:   line.speaker,
+			Text:      turnText(text[min(line.textStart, end):end], line.numbered),
+			Timestamp: line.timestamp,
+			ByteStart: uint64(line.start),
+			ByteEnd:   uint64(end),
+		})
+	}
+	return turns
+}
+
+// turnText joins the lines of a turn with spaces, removing transcript line numbers when
+// the transcript is numbered.
+func turnText(text string, numbered bool) string {
+	var parts []string
+	for _, line := range strings.Split(text, "\n") {
+		if numbered {
+			line = lineNumberPattern.ReplaceAllString(line, "")
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			parts = append(parts, line)
+		}
+	}
+	return strings.Join(parts, " ")
+}
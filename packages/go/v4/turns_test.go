@@ -0,0 +1,40 @@
+package kreuzberg
+
+import "testing"
+
+func TestDetectTurns(t *testing.T) {
+	text := "Deposition of J. Doe\nDate: 3 March\n\n" +
+		"1   Q.  Where were you\n2       on the night?\n3   A.  At home.\n" +
+		"4   MR. SMITH: Objection.\n5   Q.  Alone?\n6   MR. SMITH: Objection, form.\n"
+	turns := DetectTurns(text, nil)
+	want := []struct{ speaker, text string }{
+		{"Q", "Where were you on the night?"},
+		{"A", "At home."},
+		{"MR. SMITH", "Objection."},
+		{"Q", "Alone?"},
+		{"MR. SMITH", "Objection, form."},
+	}
+	if len(turns) != len(want) {
+		t.Fatalf("DetectTurns = %+v", turns)
+	}
+	for i, w := range want {
+		if turns[i].Speaker != w.speaker || turns[i].Text != w.text {
+			t.Fatalf("turn %d = %+v, want %v", i, turns[i], w)
+		}
+	}
+	if got := text[turns[0].ByteStart:turns[0].ByteEnd]; got != "1   Q.  Where were you\n2       on the night?" {
+		t.Fatalf("first turn spans %q", got)
+	}
+
+	minutes := "# Weekly sync\n[00:01:05] Alice: Let's start.\nBob (00:01:09): Ready.\n" +
+		"**Carol:** Me too,\nwith one question.\n# Actions\nAlice to send notes."
+	turns = DetectTurns(minutes, &TurnDetectionConfig{Speakers: []string{"alice", "Bob", "Carol"}})
+	if len(turns) != 3 || turns[0].Timestamp != "00:01:05" || turns[1].Timestamp != "00:01:09" ||
+		turns[2].Speaker != "Carol" || turns[2].Text != "Me too, with one question." {
+		t.Fatalf("DetectTurns = %+v", turns)
+	}
+
+	if turns := DetectTurns("Note: a plain document.\nTotal: 4 items.", nil); turns != nil {
+		t.Fatalf("expected no turns, got %+v", turns)
+	}
+}
@@ -1,6 +1,9 @@
 package kreuzberg
 
-import "encoding/json"
+import (
+	"encoding/json"
+	"time"
+)
 
 // ExtractionResult mirrors the Rust ExtractionResult struct returned by the core API.
 type ExtractionResult struct {
@@ -9,11 +12,124 @@ type ExtractionResult struct {
 	Metadata          Metadata         `json:"metadata"`
 	Tables            []Table          `json:"tables"`
 	DetectedLanguages []string         `json:"detected_languages,omitempty"`
-	Chunks            []Chunk          `json:"chunks,omitempty"`
+	Chunks            Chunks           `json:"chunks,omitempty"`
 	Images            []ExtractedImage `json:"images,omitempty"`
 	Pages             []PageContent    `json:"pages,omitempty"`
 	Elements          []Element        `json:"elements,omitempty"`
 	Success           bool             `json:"success"`
+
+	// TranslatedContent holds Content translated into TranslatedLanguage when
+	// ExtractionConfig.TranslateTo is set.
+	TranslatedContent   string               `json:"translated_content,omitempty"`
+	TranslatedLanguage  string               `json:"translated_language,omitempty"`
+	TranslationSegments []TranslationSegment `json:"translation_segments,omitempty"`
+
+	// Sections labels regions of Content when ExtractionConfig.SectionDetection is set.
+	Sections []Section `json:"sections,omitempty"`
+
+	// KeyValues holds form label/value pairs when ExtractionConfig.KeyValues is set.
+	KeyValues []KeyValue `json:"key_values,omitempty"`
+
+	// Marks holds detected signatures and stamps when ExtractionConfig.MarkDetection is set.
+	Marks []Mark `json:"marks,omitempty"`
+
+	// Headings is the heading tree of Content when ExtractionConfig.HeadingDetection is set.
+	Headings []Heading `json:"headings,omitempty"`
+
+	// Links holds the document's hyperlinks when ExtractionConfig.LinkExtraction is set.
+	Links []Link `json:"links,omitempty"`
+
+	// StyleSpans marks the bold, italic, underlined, struck-through, highlighted and
+	// colored text of Content when ExtractionConfig.InlineStyles is set.
+	StyleSpans []StyleSpan `json:"style_spans,omitempty"`
+
+	// TextSources attributes the blocks of Content to native text or OCR when
+	// ExtractionConfig.SourceAttribution is set.
+	TextSources []TextSource `json:"text_sources,omitempty"`
+
+	// TextStats holds text statistics and readability scores when
+	// ExtractionConfig.TextStats is set.
+	TextStats *TextStats `json:"text_stats,omitempty"`
+
+	// EmbeddedDocuments holds the files of a PDF portfolio, each extracted on its own,
+	// and with ExtractionConfig.MaxDepth the entries of an archive or the attachments of
+	// an email. Nested archives and emails hold their own entries, forming a tree.
+	EmbeddedDocuments []EmbeddedDocument `json:"embedded_documents,omitempty"`
+
+	// NormalizedValues holds the dates and numbers of Content and metadata in canonical
+	// form when NormalizationConfig.Dates or Numbers is set.
+	NormalizedValues []NormalizedValue `json:"normalized_values,omitempty"`
+
+	// Amounts holds the sums of money and measurements of Content when
+	// ExtractionConfig.Amounts is set.
+	Amounts []Amount `json:"amounts,omitempty"`
+
+	// Acronyms is the glossary of the acronyms defined in Content when
+	// ExtractionConfig.Acronyms is set.
+	Acronyms []Acronym `json:"acronyms,omitempty"`
+
+	// Turns segments a transcript by speaker when ExtractionConfig.Turns is set.
+	Turns []Turn `json:"turns,omitempty"`
+
+	// SegregatedContent holds the paragraphs in other languages removed from Content when
+	// ExtractionConfig.LanguageFilter segregates them.
+	SegregatedContent []LanguageSegment `json:"segregated_content,omitempty"`
+
+	// CollapsedPages lists the numbers of the blank and duplicate pages removed when
+	// PageDeduplicationConfig.Collapse is set.
+	CollapsedPages []uint64 `json:"collapsed_pages,omitempty"`
+
+	// paged holds Pages and Chunks when the result is paged; see PagingConfig.
+	paged *pagedStorage
+	// nativeDuration is the time the native calls producing the result ran once ffiMutex
+	// was held; see recordExtraction.
+	nativeDuration time.Duration
+}
+
+// Mark is a handwritten signature or stamp found in one of the result's images.
+// BoundingBox is in pixels of that image with the origin at its bottom-left corner, and
+// Crop holds the marked area encoded as PNG. PageNumber is 0 when unknown.
+type Mark struct {
+	Kind        string      `json:"kind"`
+	Confidence  float64     `json:"confidence"`
+	PageNumber  uint64      `json:"page_number,omitempty"`
+	ImageIndex  int         `json:"image_index"`
+	BoundingBox BoundingBox `json:"bounding_box"`
+	Crop        []byte      `json:"crop,omitempty"`
+}
+
+// KeyValue is a form field label and its value. Pairs read from Content carry the byte
+// ranges of key and value; pairs associated by layout carry the value's bounding box.
+// PageNumber is 0 when unknown.
+type KeyValue struct {
+	Key         string       `json:"key"`
+	Value       string       `json:"value"`
+	Confidence  float64      `json:"confidence"`
+	Source      string       `json:"source"`
+	PageNumber  uint64       `json:"page_number,omitempty"`
+	KeyStart    uint64       `json:"key_start,omitempty"`
+	KeyEnd      uint64       `json:"key_end,omitempty"`
+	ValueStart  uint64       `json:"value_start,omitempty"`
+	ValueEnd    uint64       `json:"value_end,omitempty"`
+	BoundingBox *BoundingBox `json:"bounding_box,omitempty"`
+}
+
+// Section is a labeled region of Content, such as the abstract or a signature block.
+// ByteStart and ByteEnd delimit the section including its heading.
+type Section struct {
+	Label     string `json:"label"`
+	Heading   string `json:"heading,omitempty"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// TranslationSegment maps a translated byte range of TranslatedContent back to the
+// byte range of Content it was produced from.
+type TranslationSegment struct {
+	SourceStart uint64 `json:"source_start"`
+	SourceEnd   uint64 `json:"source_end"`
+	TargetStart uint64 `json:"target_start"`
+	TargetEnd   uint64 `json:"target_end"`
 }
 
 // Table represents a detected table in the source document.
@@ -21,13 +137,24 @@ type Table struct {
 	Cells      [][]string `json:"cells"`
 	Markdown   string     `json:"markdown"`
 	PageNumber int        `json:"page_number"`
+
+	// Confidence is the detection confidence in [0, 1]. When TableExtractionConfig is set
+	// and the backend does not report a score, it is estimated from the table's shape.
+	Confidence *float64 `json:"confidence,omitempty"`
+	// BoundingBox locates the table on its page, in points from the bottom-left corner,
+	// for tables the heuristic backend detects in PDF pages.
+	BoundingBox *BoundingBox `json:"bounding_box,omitempty"`
 }
 
+// Chunks is an ordered list of chunks produced from a single document.
+type Chunks []Chunk
+
 // Chunk contains chunked content plus optional embeddings and metadata.
 type Chunk struct {
-	Content   string        `json:"content"`
-	Embedding []float32     `json:"embedding,omitempty"`
-	Metadata  ChunkMetadata `json:"metadata"`
+	Content           string        `json:"content"`
+	Embedding         []float32     `json:"embedding,omitempty"`
+	Metadata          ChunkMetadata `json:"metadata"`
+	TranslatedContent string        `json:"translated_content,omitempty"`
 }
 
 // ChunkMetadata provides positional information for a chunk.
@@ -39,6 +166,28 @@ type ChunkMetadata struct {
 	TotalChunks int     `json:"total_chunks"`
 	FirstPage   *uint64 `json:"first_page,omitempty"`
 	LastPage    *uint64 `json:"last_page,omitempty"`
+
+	// PageSpans locates the chunk on each page it covers. It is derived from
+	// PageStructure.Boundaries when page tracking is enabled.
+	PageSpans []PageSpan `json:"page_spans,omitempty"`
+
+	// HeadingPath lists the headings enclosing the chunk, outermost first. It is set by
+	// the markdown_structure chunking strategy.
+	HeadingPath []string `json:"heading_path,omitempty"`
+
+	// Offsets repeats ByteStart/ByteEnd in ExtractionConfig.OffsetUnit when that is set.
+	Offsets *OffsetRange `json:"offsets,omitempty"`
+
+	// ContainsTable reports whether the chunk includes a Markdown table.
+	ContainsTable bool `json:"contains_table,omitempty"`
+}
+
+// PageSpan is a byte range on a single page. ByteStart and ByteEnd are relative to the
+// start of the page's text.
+type PageSpan struct {
+	PageNumber uint64 `json:"page_number"`
+	ByteStart  uint64 `json:"byte_start"`
+	ByteEnd    uint64 `json:"byte_end"`
 }
 
 // ExtractedImage represents an extracted image, optionally with nested OCR results.
@@ -56,7 +205,9 @@ type ExtractedImage struct {
 	OCRResult        *ExtractionResult `json:"ocr_result,omitempty"`
 }
 
-// Metadata aggregates document metadata and format-specific payloads.
+// Metadata aggregates document metadata and format-specific payloads. With
+// ExtractionConfig.LazyMetadata, Format holds only its Type and Additional is nil until
+// an accessor such as PdfMetadata or AdditionalFields is called.
 type Metadata struct {
 	Language           *string                     `json:"language,omitempty"`
 	Date               *string                     `json:"date,omitempty"`
@@ -66,39 +217,68 @@ type Metadata struct {
 	JSONSchema         json.RawMessage             `json:"json_schema,omitempty"`
 	Error              *ErrorMetadata              `json:"error,omitempty"`
 	PageStructure      *PageStructure              `json:"page_structure,omitempty"`
+	Fallback           *FallbackMetadata           `json:"fallback,omitempty"`
+	Geo                *GeoMetadata                `json:"geo,omitempty"`
+	Fax                *FaxMetadata                `json:"fax,omitempty"`
+	Portfolio          *PortfolioMetadata          `json:"portfolio,omitempty"`
+	Reconciliation     []PageReconciliation        `json:"reconciliation,omitempty"`
+	Speculation        *SpeculationMetadata        `json:"speculation,omitempty"`
+	Fonts              []FontInfo                  `json:"fonts,omitempty"`
 	Additional         map[string]json.RawMessage  `json:"-"`
+
+	deferred *deferredMetadata
 }
 
 // FormatMetadata represents the discriminated union of metadata formats.
 type FormatMetadata struct {
-	Type    FormatType
-	Pdf     *PdfMetadata
-	Excel   *ExcelMetadata
-	Email   *EmailMetadata
-	Pptx    *PptxMetadata
-	Archive *ArchiveMetadata
-	Image   *ImageMetadata
-	XML     *XMLMetadata
-	Text    *TextMetadata
-	HTML    *HtmlMetadata
-	OCR     *OcrMetadata
+	Type          FormatType
+	Pdf           *PdfMetadata
+	Excel         *ExcelMetadata
+	Email         *EmailMetadata
+	Pptx          *PptxMetadata
+	Archive       *ArchiveMetadata
+	Image         *ImageMetadata
+	XML           *XMLMetadata
+	Text          *TextMetadata
+	HTML          *HtmlMetadata
+	OCR           *OcrMetadata
+	Code          *CodeMetadata
+	Log           *LogMetadata
+	EDI           *EdiMetadata
+	DICOM         *DicomMetadata
+	RTF           *RtfMetadata
+	LegacyOffice  *LegacyOfficeMetadata
+	WordProcessor *WordProcessorMetadata
+	FictionBook   *FictionBookMetadata
+	DjVu          *DjvuMetadata
+	XPS           *XpsMetadata
 }
 
 // FormatType enumerates supported metadata discriminators.
 type FormatType string
 
 const (
-	FormatUnknown FormatType = ""
-	FormatPDF     FormatType = "pdf"
-	FormatExcel   FormatType = "excel"
-	FormatEmail   FormatType = "email"
-	FormatPPTX    FormatType = "pptx"
-	FormatArchive FormatType = "archive"
-	FormatImage   FormatType = "image"
-	FormatXML     FormatType = "xml"
-	FormatText    FormatType = "text"
-	FormatHTML    FormatType = "html"
-	FormatOCR     FormatType = "ocr"
+	FormatUnknown       FormatType = ""
+	FormatPDF           FormatType = "pdf"
+	FormatExcel         FormatType = "excel"
+	FormatEmail         FormatType = "email"
+	FormatPPTX          FormatType = "pptx"
+	FormatArchive       FormatType = "archive"
+	FormatImage         FormatType = "image"
+	FormatXML           FormatType = "xml"
+	FormatText          FormatType = "text"
+	FormatHTML          FormatType = "html"
+	FormatOCR           FormatType = "ocr"
+	FormatCode          FormatType = "code"
+	FormatLog           FormatType = "log"
+	FormatEDI           FormatType = "edi"
+	FormatDICOM         FormatType = "dicom"
+	FormatRTF           FormatType = "rtf"
+	FormatLegacyOffice  FormatType = "legacy_office"
+	FormatWordProcessor FormatType = "word_processor"
+	FormatFictionBook   FormatType = "fb2"
+	FormatDjVu          FormatType = "djvu"
+	FormatXPS           FormatType = "xps"
 )
 
 // FormatType returns the discriminated format string.
@@ -106,56 +286,134 @@ func (m Metadata) FormatType() FormatType {
 	return m.Format.Type
 }
 
+// AdditionalFields returns the metadata fields that are neither common nor part of the
+// format payload.
+func (m Metadata) AdditionalFields() map[string]json.RawMessage {
+	m.materialize()
+	return m.Additional
+}
+
 // PdfMetadata returns the PDF metadata if present.
 func (m Metadata) PdfMetadata() (*PdfMetadata, bool) {
+	m.materialize()
 	return m.Format.Pdf, m.Format.Type == FormatPDF && m.Format.Pdf != nil
 }
 
 // ExcelMetadata returns the Excel metadata if present.
 func (m Metadata) ExcelMetadata() (*ExcelMetadata, bool) {
+	m.materialize()
 	return m.Format.Excel, m.Format.Type == FormatExcel && m.Format.Excel != nil
 }
 
 // EmailMetadata returns the Email metadata if present.
 func (m Metadata) EmailMetadata() (*EmailMetadata, bool) {
+	m.materialize()
 	return m.Format.Email, m.Format.Type == FormatEmail && m.Format.Email != nil
 }
 
 // PptxMetadata returns the PPTX metadata if present.
 func (m Metadata) PptxMetadata() (*PptxMetadata, bool) {
+	m.materialize()
 	return m.Format.Pptx, m.Format.Type == FormatPPTX && m.Format.Pptx != nil
 }
 
 // ArchiveMetadata returns the archive metadata if present.
 func (m Metadata) ArchiveMetadata() (*ArchiveMetadata, bool) {
+	m.materialize()
 	return m.Format.Archive, m.Format.Type == FormatArchive && m.Format.Archive != nil
 }
 
 // ImageMetadata returns the image metadata if present.
 func (m Metadata) ImageMetadata() (*ImageMetadata, bool) {
+	m.materialize()
 	return m.Format.Image, m.Format.Type == FormatImage && m.Format.Image != nil
 }
 
 // XMLMetadata returns the XML metadata if present.
 func (m Metadata) XMLMetadata() (*XMLMetadata, bool) {
+	m.materialize()
 	return m.Format.XML, m.Format.Type == FormatXML && m.Format.XML != nil
 }
 
 // TextMetadata returns the text metadata if present.
 func (m Metadata) TextMetadata() (*TextMetadata, bool) {
+	m.materialize()
 	return m.Format.Text, m.Format.Type == FormatText && m.Format.Text != nil
 }
 
 // HTMLMetadata returns the HTML metadata if present.
 func (m Metadata) HTMLMetadata() (*HtmlMetadata, bool) {
+	m.materialize()
 	return m.Format.HTML, m.Format.Type == FormatHTML && m.Format.HTML != nil
 }
 
 // OcrMetadata returns the OCR metadata if present.
 func (m Metadata) OcrMetadata() (*OcrMetadata, bool) {
+	m.materialize()
 	return m.Format.OCR, m.Format.Type == FormatOCR && m.Format.OCR != nil
 }
 
+// CodeMetadata returns the source code metadata if present.
+func (m Metadata) CodeMetadata() (*CodeMetadata, bool) {
+	m.materialize()
+	return m.Format.Code, m.Format.Type == FormatCode && m.Format.Code != nil
+}
+
+// LogMetadata returns the log metadata if present.
+func (m Metadata) LogMetadata() (*LogMetadata, bool) {
+	m.materialize()
+	return m.Format.Log, m.Format.Type == FormatLog && m.Format.Log != nil
+}
+
+// EdiMetadata returns the EDI interchange metadata if present.
+func (m Metadata) EdiMetadata() (*EdiMetadata, bool) {
+	m.materialize()
+	return m.Format.EDI, m.Format.Type == FormatEDI && m.Format.EDI != nil
+}
+
+// DicomMetadata returns the DICOM metadata if present.
+func (m Metadata) DicomMetadata() (*DicomMetadata, bool) {
+	m.materialize()
+	return m.Format.DICOM, m.Format.Type == FormatDICOM && m.Format.DICOM != nil
+}
+
+// RtfMetadata returns the RTF information group if present.
+func (m Metadata) RtfMetadata() (*RtfMetadata, bool) {
+	m.materialize()
+	return m.Format.RTF, m.Format.Type == FormatRTF && m.Format.RTF != nil
+}
+
+// LegacyOfficeMetadata returns the summary information of a binary Word or PowerPoint
+// file if present.
+func (m Metadata) LegacyOfficeMetadata() (*LegacyOfficeMetadata, bool) {
+	m.materialize()
+	return m.Format.LegacyOffice, m.Format.Type == FormatLegacyOffice && m.Format.LegacyOffice != nil
+}
+
+// WordProcessorMetadata returns the legacy word processor metadata if present.
+func (m Metadata) WordProcessorMetadata() (*WordProcessorMetadata, bool) {
+	m.materialize()
+	return m.Format.WordProcessor, m.Format.Type == FormatWordProcessor && m.Format.WordProcessor != nil
+}
+
+// FictionBookMetadata returns the FictionBook title information if present.
+func (m Metadata) FictionBookMetadata() (*FictionBookMetadata, bool) {
+	m.materialize()
+	return m.Format.FictionBook, m.Format.Type == FormatFictionBook && m.Format.FictionBook != nil
+}
+
+// DjvuMetadata returns the DjVu document metadata if present.
+func (m Metadata) DjvuMetadata() (*DjvuMetadata, bool) {
+	m.materialize()
+	return m.Format.DjVu, m.Format.Type == FormatDjVu && m.Format.DjVu != nil
+}
+
+// XpsMetadata returns the XPS core properties if present.
+func (m Metadata) XpsMetadata() (*XpsMetadata, bool) {
+	m.materialize()
+	return m.Format.XPS, m.Format.Type == FormatXPS && m.Format.XPS != nil
+}
+
 // PdfMetadata contains metadata extracted from PDF documents.
 type PdfMetadata struct {
 	Title       *string  `json:"title,omitempty"`
@@ -182,13 +440,14 @@ type ExcelMetadata struct {
 
 // EmailMetadata captures envelope data for EML/MSG messages.
 type EmailMetadata struct {
-	FromEmail   *string  `json:"from_email,omitempty"`
-	FromName    *string  `json:"from_name,omitempty"`
-	ToEmails    []string `json:"to_emails"`
-	CcEmails    []string `json:"cc_emails"`
-	BccEmails   []string `json:"bcc_emails"`
-	MessageID   *string  `json:"message_id,omitempty"`
-	Attachments []string `json:"attachments"`
+	FromEmail   *string         `json:"from_email,omitempty"`
+	FromName    *string         `json:"from_name,omitempty"`
+	ToEmails    []string        `json:"to_emails"`
+	CcEmails    []string        `json:"cc_emails"`
+	BccEmails   []string        `json:"bcc_emails"`
+	MessageID   *string         `json:"message_id,omitempty"`
+	Attachments []string        `json:"attachments"`
+	Meetings    []CalendarEvent `json:"meetings,omitempty"`
 }
 
 // ArchiveMetadata summarizes archive contents.
@@ -198,14 +457,35 @@ type ArchiveMetadata struct {
 	FileList       []string `json:"file_list"`
 	TotalSize      int      `json:"total_size"`
 	CompressedSize *int     `json:"compressed_size,omitempty"`
+	// EntryErrors lists the entries whose content could not be read, such as encrypted
+	// entries that none of ExtractionConfig.Passwords decrypts.
+	EntryErrors []ArchiveEntryError `json:"entry_errors,omitempty"`
 }
 
-// ImageMetadata describes standalone image documents.
+// ArchiveEntryError describes an archive entry whose content could not be read.
+type ArchiveEntryError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// ImageMetadata describes standalone image documents. EXIF holds the core's display
+// values of common tags; the typed fields are read by the binding from the EXIF data of
+// the original file.
 type ImageMetadata struct {
 	Width  uint32            `json:"width"`
 	Height uint32            `json:"height"`
 	Format string            `json:"format"`
 	EXIF   map[string]string `json:"exif"`
+	// CameraMake and CameraModel are the EXIF Make and Model.
+	CameraMake  string `json:"camera_make,omitempty"`
+	CameraModel string `json:"camera_model,omitempty"`
+	// CapturedAt is DateTimeOriginal, or DateTime when absent, in the recorded offset or
+	// UTC when the camera recorded none.
+	CapturedAt *time.Time `json:"captured_at,omitempty"`
+	// Orientation is the EXIF orientation, 1 (upright) through 8. JPEG images are turned
+	// upright before OCR.
+	Orientation int             `json:"orientation,omitempty"`
+	GPS         *GPSCoordinates `json:"gps,omitempty"`
 }
 
 // XMLMetadata provides statistics for XML documents.
@@ -222,6 +502,9 @@ type TextMetadata struct {
 	Headers        []string    `json:"headers,omitempty"`
 	Links          [][2]string `json:"links,omitempty"`
 	CodeBlocks     [][2]string `json:"code_blocks,omitempty"`
+	// Encoding is the encoding the document was read in when
+	// ExtractionConfig.TextEncoding is set, such as EncodingUTF8 or EncodingShiftJIS.
+	Encoding string `json:"encoding,omitempty"`
 }
 
 //revive:disable-next-line var-naming
@@ -288,6 +571,145 @@ type PptxMetadata struct {
 	Fonts       []string `json:"fonts"`
 }
 
+// LegacyOfficeMetadata is the summary information of a binary Word or PowerPoint file.
+// Times are RFC 3339.
+type LegacyOfficeMetadata struct {
+	Application    string `json:"application,omitempty"`
+	Title          string `json:"title,omitempty"`
+	Subject        string `json:"subject,omitempty"`
+	Author         string `json:"author,omitempty"`
+	Keywords       string `json:"keywords,omitempty"`
+	Comments       string `json:"comments,omitempty"`
+	Template       string `json:"template,omitempty"`
+	LastAuthor     string `json:"last_author,omitempty"`
+	Revision       string `json:"revision,omitempty"`
+	CreatedAt      string `json:"created_at,omitempty"`
+	ModifiedAt     string `json:"modified_at,omitempty"`
+	PageCount      int    `json:"page_count,omitempty"`
+	WordCount      int    `json:"word_count,omitempty"`
+	CharacterCount int    `json:"character_count,omitempty"`
+	SlideCount     int    `json:"slide_count,omitempty"`
+}
+
+// DicomMetadata describes a DICOM object: the patient, study and series it belongs to
+// and the image it carries. Dates are formatted as "2006-01-02". ReportTitle is set for
+// structured reports (SR). When DicomConfig.Anonymize is set, the patient and staff
+// identifiers are left empty and Anonymized is true.
+type DicomMetadata struct {
+	PatientName            string `json:"patient_name,omitempty"`
+	PatientID              string `json:"patient_id,omitempty"`
+	PatientBirthDate       string `json:"patient_birth_date,omitempty"`
+	PatientSex             string `json:"patient_sex,omitempty"`
+	PatientAge             string `json:"patient_age,omitempty"`
+	StudyInstanceUID       string `json:"study_instance_uid,omitempty"`
+	StudyDate              string `json:"study_date,omitempty"`
+	StudyDescription       string `json:"study_description,omitempty"`
+	AccessionNumber        string `json:"accession_number,omitempty"`
+	SeriesInstanceUID      string `json:"series_instance_uid,omitempty"`
+	SeriesDescription      string `json:"series_description,omitempty"`
+	Modality               string `json:"modality,omitempty"`
+	SOPClassUID            string `json:"sop_class_uid,omitempty"`
+	SOPInstanceUID         string `json:"sop_instance_uid,omitempty"`
+	Manufacturer           string `json:"manufacturer,omitempty"`
+	InstitutionName        string `json:"institution_name,omitempty"`
+	ReferringPhysicianName string `json:"referring_physician_name,omitempty"`
+	BodyPartExamined       string `json:"body_part_examined,omitempty"`
+	TransferSyntaxUID      string `json:"transfer_syntax_uid,omitempty"`
+	ReportTitle            string `json:"report_title,omitempty"`
+	Rows                   int    `json:"rows,omitempty"`
+	Columns                int    `json:"columns,omitempty"`
+	NumberOfFrames         int    `json:"number_of_frames,omitempty"`
+	HasPixelData           bool   `json:"has_pixel_data"`
+	Anonymized             bool   `json:"anonymized"`
+}
+
+// WordProcessorMetadata describes a document from a legacy word processor.
+type WordProcessorMetadata struct {
+	// Application is the program that wrote the document, e.g. "WordPerfect".
+	Application string `json:"application"`
+	// Version is the file format version, e.g. "5.1".
+	Version string `json:"version,omitempty"`
+	// Encrypted reports a password-protected document, whose text is not extracted.
+	Encrypted bool `json:"encrypted,omitempty"`
+}
+
+// FictionBookMetadata is the title and publishing information of a FictionBook ebook.
+type FictionBookMetadata struct {
+	Title      string   `json:"title,omitempty"`
+	Authors    []string `json:"authors,omitempty"`
+	Genres     []string `json:"genres,omitempty"`
+	Keywords   string   `json:"keywords,omitempty"`
+	Annotation string   `json:"annotation,omitempty"`
+	Date       string   `json:"date,omitempty"`
+	Sequence   string   `json:"sequence,omitempty"`
+	// SequenceNumber is the position of the book in Sequence, 0 when unnumbered.
+	SequenceNumber int    `json:"sequence_number,omitempty"`
+	Publisher      string `json:"publisher,omitempty"`
+	Year           string `json:"year,omitempty"`
+	ISBN           string `json:"isbn,omitempty"`
+}
+
+// DjvuMetadata describes a DjVu document and its text layer.
+type DjvuMetadata struct {
+	PageCount int `json:"page_count"`
+	// Width and Height are the pixel size of the first page.
+	Width   int    `json:"width,omitempty"`
+	Height  int    `json:"height,omitempty"`
+	DPI     int    `json:"dpi,omitempty"`
+	Version string `json:"version,omitempty"`
+	// TextPages counts the pages whose text layer was extracted.
+	TextPages int `json:"text_pages"`
+	// CompressedTextPages counts the pages whose text layer is BZZ-compressed, which the
+	// core does not decode; their text is missing from the result.
+	CompressedTextPages int    `json:"compressed_text_pages,omitempty"`
+	Title               string `json:"title,omitempty"`
+	Author              string `json:"author,omitempty"`
+}
+
+// FaxMetadata describes the fax encoding of a multi-page TIFF, which the core reports
+// with its pages.
+type FaxMetadata struct {
+	// Compression is "ccitt_rle", "group3", or "group4".
+	Compression string `json:"compression"`
+	// HorizontalDPI and VerticalDPI differ for standard-resolution faxes, whose pixels
+	// are twice as tall as they are wide.
+	HorizontalDPI float64 `json:"horizontal_dpi,omitempty"`
+	VerticalDPI   float64 `json:"vertical_dpi,omitempty"`
+	PageCount     int     `json:"page_count"`
+}
+
+// XpsMetadata is the core properties of an XPS document.
+type XpsMetadata struct {
+	Title          string `json:"title,omitempty"`
+	Creator        string `json:"creator,omitempty"`
+	Keywords       string `json:"keywords,omitempty"`
+	Description    string `json:"description,omitempty"`
+	LastModifiedBy string `json:"last_modified_by,omitempty"`
+	Revision       string `json:"revision,omitempty"`
+	CreatedAt      string `json:"created_at,omitempty"`
+	ModifiedAt     string `json:"modified_at,omitempty"`
+	PageCount      int    `json:"page_count"`
+	DocumentCount  int    `json:"document_count"`
+}
+
+// RtfMetadata is the information group of an RTF document, as reported by the core.
+// Times are RFC 3339; the subject stays in Metadata.Subject.
+type RtfMetadata struct {
+	Title          string   `json:"title,omitempty"`
+	Authors        []string `json:"authors,omitempty"`
+	CreatedBy      string   `json:"created_by,omitempty"`
+	ModifiedBy     string   `json:"modified_by,omitempty"`
+	Generator      string   `json:"generator,omitempty"`
+	CreatedAt      string   `json:"created_at,omitempty"`
+	ModifiedAt     string   `json:"modified_at,omitempty"`
+	Revision       string   `json:"revision,omitempty"`
+	PageCount      int      `json:"page_count,omitempty"`
+	WordCount      int      `json:"word_count,omitempty"`
+	CharacterCount int      `json:"character_count,omitempty"`
+	LineCount      int      `json:"line_count,omitempty"`
+	ParagraphCount int      `json:"paragraph_count,omitempty"`
+}
+
 // OcrMetadata records OCR settings/results associated with an extraction.
 type OcrMetadata struct {
 	Language     string `json:"language"`
@@ -334,6 +756,9 @@ type PageBoundary struct {
 	ByteStart  uint64 `json:"byte_start"`
 	ByteEnd    uint64 `json:"byte_end"`
 	PageNumber uint64 `json:"page_number"`
+
+	// Offsets repeats ByteStart/ByteEnd in ExtractionConfig.OffsetUnit when that is set.
+	Offsets *OffsetRange `json:"offsets,omitempty"`
 }
 
 // PageInfo provides metadata about an individual page/slide/sheet.
@@ -344,6 +769,21 @@ type PageInfo struct {
 	ImageCount  *uint64     `json:"image_count,omitempty"`
 	Visible     *bool       `json:"visible,omitempty"`
 	ContentType *string     `json:"content_type,omitempty"`
+
+	// Scan describes the scanned image of the page when ExtractionConfig.ScanQuality is set.
+	Scan *ScanQuality `json:"scan,omitempty"`
+
+	// Size is the standard paper size of the page, such as "A4" or "Letter", or
+	// PageSizeCustom, and Orientation is PageOrientationPortrait or
+	// PageOrientationLandscape. Both are set when Dimensions are known.
+	Size        string `json:"size,omitempty"`
+	Orientation string `json:"orientation,omitempty"`
+
+	// WritingMode is WritingModeHorizontal, WritingModeVertical or WritingModeMixed, and
+	// VerticalRegions are the bounding boxes of the vertically typeset text of the page.
+	// Both are set for PDF pages with CJK text when ExtractionConfig.VerticalText is set.
+	WritingMode     string        `json:"writing_mode,omitempty"`
+	VerticalRegions []BoundingBox `json:"vertical_regions,omitempty"`
 }
 
 // PageStructure describes the page/slide/sheet structure of a document.
@@ -352,6 +792,15 @@ type PageStructure struct {
 	UnitType   PageUnitType   `json:"unit_type"`
 	Boundaries []PageBoundary `json:"boundaries,omitempty"`
 	Pages      []PageInfo     `json:"pages,omitempty"`
+
+	// PageSizes groups the pages by paper size and orientation when the document mixes
+	// them, as a Letter report with A4 and landscape appendices.
+	PageSizes []PageSizeGroup `json:"page_sizes,omitempty"`
+
+	// CoordinateUnit is the unit of the bounding boxes of tables and elements, and of
+	// Dimensions except for CoordinateUnitNormalized, when ExtractionConfig.CoordinateUnit
+	// converted them. Empty means points.
+	CoordinateUnit string `json:"coordinate_unit,omitempty"`
 }
 
 // PageContent represents extracted content for a single page.
@@ -360,6 +809,15 @@ type PageContent struct {
 	Content    string           `json:"content"`
 	Tables     []Table          `json:"tables,omitempty"`
 	Images     []ExtractedImage `json:"images,omitempty"`
+
+	// Source is TextSourceNative, TextSourceOCR or TextSourceHybrid when
+	// ExtractionConfig.SourceAttribution is set.
+	Source string `json:"source,omitempty"`
+
+	// Blank and DuplicateOf flag blank pages and pages repeating the page numbered
+	// DuplicateOf when ExtractionConfig.PageDeduplication is set.
+	Blank       bool   `json:"blank,omitempty"`
+	DuplicateOf uint64 `json:"duplicate_of,omitempty"`
 }
 
 // ElementType defines semantic classification for extracted elements.
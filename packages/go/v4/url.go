@@ -0,0 +1,290 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// URLOptions configures ExtractURL.
+type URLOptions struct {
+	// Client performs the requests; http.DefaultClient when nil.
+	Client *http.Client
+	// Header is sent with every request, to authorize it for one.
+	Header http.Header
+	// FirstPage, when set, receives the first page of a linearized PDF with the
+	// document's metadata as soon as that page has downloaded, before the rest of the
+	// file is requested. It is not called when the server ignores range requests or the
+	// first page cannot be extracted on its own.
+	FirstPage func(*ExtractionResult)
+}
+
+// linearizationProbeSize is how much of a PDF is requested first: the linearization
+// dictionary must lie within its first 1024 bytes.
+const linearizationProbeSize = 1024
+
+// linearization holds the entries of a linearization dictionary: the file length, the
+// end of the first page's section, the first page's object number and the page count.
+type linearization struct {
+	length, firstPageEnd, firstPage, pageCount int
+}
+
+var (
+	pdfTrailerInfo  = regexp.MustCompile(`trailer\s*<<[\s\S]*?/Info\s+(\d+)\s+\d+\s+R`)
+	pdfPagesKids    = regexp.MustCompile(`/Kids\s*\[[^\]]*\]`)
+	pdfPagesCount   = regexp.MustCompile(`/Count\s+\d+`)
+	pdfHeaderLine   = regexp.MustCompile(`^%PDF-\d\.\d`)
+	pdfObjectEndTag = []byte("endobj")
+)
+
+// ExtractURL downloads the document at rawURL and extracts it. The MIME type is taken
+// from the Content-Type header, or else derived from the URL's extension or the content.
+//
+// When opts.FirstPage is set and the document is a linearized PDF served with range
+// support, the first page's section is requested alone and extracted as a one-page
+// PDF for FirstPage, so previews need not wait for the whole file.
+func ExtractURL(ctx context.Context, rawURL string, config *ExtractionConfig, opts *URLOptions) (*ExtractionResult, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, newValidationErrorWithContext("url must be an absolute http or https URL", err, ErrorCodeValidation, nil)
+	}
+	if opts == nil {
+		opts = &URLOptions{}
+	}
+	fetch := &urlFetcher{ctx: ctx, url: parsed.String(), client: opts.Client, header: opts.Header}
+	if fetch.client == nil {
+		fetch.client = http.DefaultClient
+	}
+
+	var data []byte
+	contentType := ""
+	if opts.FirstPage != nil {
+		data, contentType, err = fetch.progressive(config, opts.FirstPage)
+	} else {
+		data, contentType, _, err = fetch.getRange(-1, -1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType, _, _ := mime.ParseMediaType(contentType)
+	if mimeType == "" || mimeType == "application/octet-stream" || mimeType == "binary/octet-stream" {
+		if mimeType, err = mimeTypeFromName(path.Base(parsed.Path)); err != nil {
+			if mimeType, err = DetectMimeType(data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ExtractBytesWithContext(ctx, data, mimeType, config)
+}
+
+// urlFetcher makes the requests of one ExtractURL call.
+type urlFetcher struct {
+	ctx    context.Context
+	client *http.Client
+	url    string
+	header http.Header
+}
+
+// getRange requests the bytes from start to end inclusive, or to the end of the document
+// when end is negative, or the whole document when start is negative. ranged reports
+// whether the server answered with the range rather than the whole document.
+func (f *urlFetcher) getRange(start, end int) (data []byte, contentType string, ranged bool, err error) {
+	req, err := http.NewRequestWithContext(f.ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, "", false, newValidationErrorWithContext("invalid request", err, ErrorCodeValidation, nil)
+	}
+	for key, values := range f.header {
+		req.Header[key] = slices.Clone(values)
+	}
+	switch {
+	case start >= 0 && end >= 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+	case start >= 0:
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", start))
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, "", false, newIOErrorWithContext("failed to download "+f.url, err, ErrorCodeIo, nil)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent {
+		return nil, "", false, newIOErrorWithContext(fmt.Sprintf("failed to download %s: %s", f.url, resp.Status), nil, ErrorCodeIo, nil)
+	}
+	data, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", false, newIOErrorWithContext("failed to download "+f.url, err, ErrorCodeIo, nil)
+	}
+	return data, resp.Header.Get("Content-Type"), resp.StatusCode == http.StatusPartialContent, nil
+}
+
+// progressive downloads a document in sections when it is a linearized PDF and the
+// server honours range requests, extracting the first page for firstPage once its
+// section has arrived. Anything else is downloaded whole.
+func (f *urlFetcher) progressive(config *ExtractionConfig, firstPage func(*ExtractionResult)) ([]byte, string, error) {
+	head, contentType, ranged, err := f.getRange(0, linearizationProbeSize-1)
+	if err != nil || !ranged {
+		return head, contentType, err
+	}
+	lin, ok := readLinearization(head)
+	if !ok || lin.firstPageEnd > lin.length {
+		return f.rest(head, contentType)
+	}
+
+	data := head
+	if lin.firstPageEnd > len(data) {
+		section, _, ranged, err := f.getRange(len(data), lin.firstPageEnd-1)
+		if err != nil {
+			return nil, "", err
+		}
+		if !ranged {
+			return section, contentType, nil
+		}
+		data = append(data, section...)
+	}
+	if pdf, ok := firstPagePDF(data[:min(len(data), lin.firstPageEnd)], lin); ok {
+		if result, err := ExtractBytesWithContext(f.ctx, pdf, "application/pdf", config); err == nil {
+			if meta, ok := result.Metadata.PdfMetadata(); ok && lin.pageCount > 0 {
+				meta.PageCount = &lin.pageCount
+			}
+			firstPage(result)
+		}
+	}
+	if len(data) >= lin.length {
+		return data[:lin.length], contentType, nil
+	}
+	return f.rest(data, contentType)
+}
+
+// rest completes a download that has the first len(data) bytes.
+func (f *urlFetcher) rest(data []byte, contentType string) ([]byte, string, error) {
+	remainder, _, ranged, err := f.getRange(len(data), -1)
+	if err != nil {
+		return nil, "", err
+	}
+	if !ranged {
+		return remainder, contentType, nil
+	}
+	return append(data, remainder...), contentType, nil
+}
+
+// readLinearization reads the linearization dictionary, the first object of a
+// linearized PDF.
+func readLinearization(head []byte) (linearization, bool) {
+	if !isPDF(head) {
+		return linearization{}, false
+	}
+	match := pdfObjectHeader.FindIndex(head)
+	if match == nil {
+		return linearization{}, false
+	}
+	end := bytes.Index(head[match[0]:], pdfObjectEndTag)
+	if end < 0 {
+		return linearization{}, false
+	}
+	objects := readPDFObjects(head[:match[0]+end+len(pdfObjectEndTag)])
+	for _, value := range objects {
+		dict, ok := value.(pdfDict)
+		if !ok || dict["Linearized"] == nil {
+			continue
+		}
+		var lin linearization
+		var okL, okE, okO bool
+		lin.length, okL = pdfInt(dict["L"])
+		lin.firstPageEnd, okE = pdfInt(dict["E"])
+		lin.firstPage, okO = pdfInt(dict["O"])
+		lin.pageCount, _ = pdfInt(dict["N"])
+		return lin, okL && okE && okO && lin.firstPageEnd > 0
+	}
+	return linearization{}, false
+}
+
+// firstPagePDF rewrites the first page's section of a linearized PDF as a complete
+// one-page PDF: the objects of the section are copied, the page tree root is cut down to
+// the first page, or stood in for when it lies outside the section, and a new
+// cross-reference table is written. It fails when the section packs objects in object
+// streams, which a classic table cannot address.
+func firstPagePDF(section []byte, lin linearization) ([]byte, bool) {
+	if bytes.Contains(section, []byte("/ObjStm")) {
+		return nil, false
+	}
+	spans := map[int][]byte{}
+	for _, match := range pdfObjectHeader.FindAllSubmatchIndex(section, -1) {
+		end := bytes.Index(section[match[1]:], pdfObjectEndTag)
+		if end < 0 {
+			continue
+		}
+		num, err := strconv.Atoi(string(section[match[2]:match[3]]))
+		if err != nil {
+			continue
+		}
+		spans[num] = section[match[0] : match[1]+end+len(pdfObjectEndTag)]
+	}
+
+	objects := readPDFObjects(section)
+	root, pagesRoot := -1, -1
+	for num, value := range objects {
+		dict := objects.dict(value)
+		switch {
+		case dict["Linearized"] != nil, dict["Type"] == pdfName("XRef"):
+			delete(spans, num)
+		case dict["Type"] == pdfName("Catalog"):
+			root = num
+			if ref, ok := dict["Pages"].(pdfRef); ok {
+				pagesRoot = ref.num
+			}
+		}
+	}
+	if root < 0 || pagesRoot < 0 || spans[lin.firstPage] == nil {
+		return nil, false
+	}
+	// The page tree usually follows the remaining pages; a stand-in root is written then.
+	if pages := spans[pagesRoot]; pages != nil {
+		pages = pdfPagesKids.ReplaceAll(pages, []byte(fmt.Sprintf("/Kids [%d 0 R]", lin.firstPage)))
+		spans[pagesRoot] = pdfPagesCount.ReplaceAll(pages, []byte("/Count 1"))
+	} else {
+		spans[pagesRoot] = []byte(fmt.Sprintf("%d 0 obj\n<< /Type /Pages /Kids [%d 0 R] /Count 1 >>\nendobj", pagesRoot, lin.firstPage))
+	}
+
+	var out bytes.Buffer
+	header := pdfHeaderLine.Find(section)
+	if header == nil {
+		header = []byte("%PDF-1.4")
+	}
+	out.Write(header)
+	out.WriteString("\n%\xe2\xe3\xcf\xd3\n")
+	numbers := slices.Sorted(maps.Keys(spans))
+	offsets := map[int]int{}
+	for _, num := range numbers {
+		offsets[num] = out.Len()
+		out.Write(spans[num])
+		out.WriteString("\n")
+	}
+	xref := out.Len()
+	size := numbers[len(numbers)-1] + 1
+	fmt.Fprintf(&out, "xref\n0 %d\n", size)
+	for num := range size {
+		if offset, ok := offsets[num]; ok {
+			fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+		} else {
+			out.WriteString("0000000000 65535 f \n")
+		}
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R", size, root)
+	if match := pdfTrailerInfo.FindSubmatch(section); match != nil {
+		if info, err := strconv.Atoi(string(match[1])); err == nil && spans[info] != nil {
+			fmt.Fprintf(&out, " /Info %d 0 R", info)
+		}
+	}
+	fmt.Fprintf(&out, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return out.Bytes(), true
+}
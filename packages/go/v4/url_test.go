@@ -0,0 +1,156 @@
+package kreuzberg
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// linearizedTestPDF lays out a two-page linearized PDF the way Annex F does: the
+// linearization dictionary, the catalog, the first page, then the second page and the
+// page tree. The linearization dictionary is padded so its entries keep their width, and
+// the first page's section is larger than the probe.
+func linearizedTestPDF() (data []byte, firstPageEnd int) {
+	objects := []struct {
+		num  int
+		body string
+	}{
+		{10, "<< /Linearized 1 /L %010d /E %010d /O 12 /N 2 /T 0 /H [0 0] >>"},
+		{11, "<< /Type /Catalog /Pages 1 0 R >>"},
+		{12, "<< /Type /Page /Parent 1 0 R /MediaBox [0 0 612 792] /Contents 13 0 R >>"},
+		{13, "<< /Length 44 >>\nstream\nBT /F1 12 Tf 72 720 Td (First page) Tj ET\nendstream"},
+		{14, "(" + strings.Repeat("filler ", 200) + ")"},
+		{2, "<< /Type /Page /Parent 1 0 R /MediaBox [0 0 612 792] >>"},
+		{1, "<< /Type /Pages /Kids [12 0 R 2 0 R] /Count 2 >>"},
+	}
+	build := func(length, end int) ([]byte, int) {
+		out := "%PDF-1.7\n"
+		for i, object := range objects {
+			if i == 5 {
+				end = len(out)
+			}
+			body := object.body
+			if i == 0 {
+				body = fmt.Sprintf(body, length, end)
+			}
+			out += fmt.Sprintf("%d 0 obj\n%s\nendobj\n", object.num, body)
+		}
+		out += "trailer << /Root 11 0 R >>\n%%EOF\n"
+		return []byte(out), end
+	}
+	data, end := build(0, 0)
+	return build(len(data), end)
+}
+
+func TestReadLinearization(t *testing.T) {
+	data, end := linearizedTestPDF()
+	lin, ok := readLinearization(data[:linearizationProbeSize])
+	if !ok || lin.length != len(data) || lin.firstPageEnd != end || lin.firstPage != 12 || lin.pageCount != 2 {
+		t.Fatalf("unexpected linearization: %+v, %v", lin, ok)
+	}
+	if _, ok := readLinearization([]byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")); ok {
+		t.Fatal("expected an ordinary PDF not to be read as linearized")
+	}
+}
+
+func TestFirstPagePDF(t *testing.T) {
+	data, end := linearizedTestPDF()
+	lin, _ := readLinearization(data)
+	pdf, ok := firstPagePDF(data[:end], lin)
+	if !ok {
+		t.Fatal("firstPagePDF failed")
+	}
+
+	objects := readPDFObjects(pdf)
+	if _, ok := objects[10]; ok {
+		t.Fatal("linearization dictionary was copied")
+	}
+	pages := objects.dict(objects[1])
+	if count, _ := pdfInt(pages["Count"]); count != 1 {
+		t.Fatalf("stand-in page tree = %v", pages)
+	}
+	if kids, _ := pages["Kids"].([]any); len(kids) != 1 || kids[0] != (pdfRef{num: 12}) {
+		t.Fatalf("stand-in page tree kids = %v", pages["Kids"])
+	}
+
+	// Every in-use cross-reference entry must point at its object.
+	xref := bytes.Index(pdf, []byte("xref\n"))
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindSubmatch(pdf)
+	if xref < 0 || startxref == nil || string(startxref[1]) != strconv.Itoa(xref) {
+		t.Fatalf("startxref does not point at the table:\n%s", pdf)
+	}
+	for num, line := range strings.Split(string(pdf[xref:]), "\n")[2:15] {
+		if !strings.HasSuffix(line, " n ") {
+			continue
+		}
+		offset, _ := strconv.Atoi(line[:10])
+		if !bytes.HasPrefix(pdf[offset:], []byte(fmt.Sprintf("%d 0 obj", num))) {
+			t.Fatalf("entry %d points at %q", num, pdf[offset:offset+10])
+		}
+	}
+	if !bytes.Contains(pdf, []byte("/Root 11 0 R")) {
+		t.Fatal("trailer does not name the catalog")
+	}
+}
+
+func TestURLFetcherProgressive(t *testing.T) {
+	data, end := linearizedTestPDF()
+	var mu sync.Mutex
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mu.Unlock()
+		if r.Header.Get("Authorization") != "Bearer token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		http.ServeContent(w, r, "doc.pdf", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+
+	fetch := &urlFetcher{ctx: context.Background(), client: server.Client(), url: server.URL,
+		header: http.Header{"Authorization": {"Bearer token"}}}
+	got, contentType, err := fetch.progressive(nil, func(*ExtractionResult) {})
+	if err != nil {
+		t.Fatalf("progressive: %v", err)
+	}
+	if !bytes.Equal(got, data) || contentType != "application/pdf" {
+		t.Fatalf("download differs from the document (%d of %d bytes, %q)", len(got), len(data), contentType)
+	}
+	want := []string{"bytes=0-1023", fmt.Sprintf("bytes=1024-%d", end-1), fmt.Sprintf("bytes=%d-", end)}
+	if strings.Join(ranges, ",") != strings.Join(want, ",") {
+		t.Fatalf("ranges = %v, want %v", ranges, want)
+	}
+}
+
+func TestURLFetcherWithoutRangeSupport(t *testing.T) {
+	data := []byte("plain text served whole")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	fetch := &urlFetcher{ctx: context.Background(), client: server.Client(), url: server.URL}
+	got, _, err := fetch.progressive(nil, func(*ExtractionResult) { t.Fatal("unexpected first page") })
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("progressive = %q, %v", got, err)
+	}
+}
+
+func TestExtractURLRejectsOtherSchemes(t *testing.T) {
+	for _, rawURL := range []string{"file:///etc/passwd", "example.com/doc.pdf", "ftp://example.com/doc.pdf"} {
+		if _, err := ExtractURL(context.Background(), rawURL, nil, nil); err == nil {
+			t.Errorf("expected %q to be rejected", rawURL)
+		}
+	}
+}
@@ -0,0 +1,469 @@
+package kreuzberg
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kinds of NormalizedValue.
+const (
+	ValueKindDate   = "date"
+	ValueKindNumber = "number"
+)
+
+// NormalizedValue is a date or number found in Content or in a metadata field, in a
+// canonical form for comparing documents written in different locales. Dates are ISO 8601
+// ("2024-03-15"), or RFC 3339 when the field records a time; numbers are decimals with a
+// "." separator, no grouping and no trailing fractional zeros ("-1234.5"). Field names the
+// metadata field as Metadata.Flatten does, and is empty for Content; ByteStart and ByteEnd
+// delimit Text in Content or in the field.
+type NormalizedValue struct {
+	Kind      string `json:"kind"`
+	Text      string `json:"text"`
+	Value     string `json:"value"`
+	Field     string `json:"field,omitempty"`
+	ByteStart uint64 `json:"byte_start"`
+	ByteEnd   uint64 `json:"byte_end"`
+}
+
+// defaultValueLocale reads values when neither the configuration nor the document names
+// a language.
+const defaultValueLocale = "en-US"
+
+var valueLocalePattern = regexp.MustCompile(`^[A-Za-z]{2,3}(?:[-_][A-Za-z0-9]{2,8})*$`)
+
+// monthNames maps the month names and abbreviations of English, German, French, Spanish,
+// Italian, Dutch and Portuguese, lowercased, to month numbers. Textual dates are read in
+// any of these languages whatever the locale.
+var monthNames = map[string]int{
+	"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6, "july": 7,
+	"august": 8, "september": 9, "october": 10, "november": 11, "december": 12,
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "jun": 6, "jul": 7, "aug": 8, "sep": 9,
+	"sept": 9, "oct": 10, "nov": 11, "dec": 12,
+
+	"januar": 1, "jänner": 1, "februar": 2, "märz": 3, "mai": 5, "juni": 6, "juli": 7,
+	"oktober": 10, "dezember": 12, "mär": 3, "mrz": 3, "okt": 10, "dez": 12,
+
+	"janvier": 1, "février": 2, "mars": 3, "avril": 4, "juin": 6, "juillet": 7, "août": 8,
+	"septembre": 9, "octobre": 10, "novembre": 11, "décembre": 12, "janv": 1, "févr": 2,
+	"fév": 2, "avr": 4, "juil": 7, "déc": 12,
+
+	"enero": 1, "febrero": 2, "marzo": 3, "abril": 4, "mayo": 5, "junio": 6, "julio": 7,
+	"agosto": 8, "septiembre": 9, "setiembre": 9, "octubre": 10, "noviembre": 11,
+	"diciembre": 12, "ene": 1, "abr": 4, "ago": 8, "dic": 12,
+
+	"gennaio": 1, "febbraio": 2, "aprile": 4, "maggio": 5, "giugno": 6, "luglio": 7,
+	"settembre": 9, "ottobre": 10, "dicembre": 12, "gen": 1, "mag": 5, "giu": 6, "lug": 7,
+	"set": 9, "ott": 10,
+
+	"januari": 1, "februari": 2, "maart": 3, "mei": 5, "augustus": 8, "mrt": 3,
+
+	"janeiro": 1, "fevereiro": 2, "março": 3, "maio": 5, "junho": 6, "julho": 7,
+	"setembro": 9, "outubro": 10, "novembro": 11, "dezembro": 12, "fev": 2, "out": 10,
+}
+
+var (
+	monthPattern = func() string {
+		// Longest first, so that "marzo" is not read as "mar".
+		names := longestFirst(monthNames)
+		for i, name := range names {
+			names[i] = regexp.QuoteMeta(name)
+		}
+		return "(" + strings.Join(names, "|") + ")"
+	}()
+
+	// "15 March 2024", "15. März 2024", "1er mars 2024", "15 de marzo de 2024".
+	textDayFirstDate = regexp.MustCompile(`(?i)(\d{1,2})(?:st|nd|rd|th|er|º|\.)?\s+(?:de\s+)?` + monthPattern + `\.?,?\s+(?:de\s+)?(\d{4})`)
+	// "March 15, 2024", "Mar. 15th 2024".
+	textMonthFirstDate = regexp.MustCompile(`(?i)` + monthPattern + `\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})`)
+	// "2024-03-15", "2024/3/15", "2024.03.15".
+	yearFirstDate = regexp.MustCompile(`(\d{4})([-/.])(\d{1,2})([-/.])(\d{1,2})`)
+	// "15.03.2024", "03/15/2024", "15-03-24".
+	numericDate = regexp.MustCompile(`(\d{1,2})([-/.])(\d{1,2})([-/.])(\d{4}|\d{2})`)
+	// "2024年3月15日", "2024년 3월 15일".
+	cjkDate = regexp.MustCompile(`(\d{4})\s*[年년]\s*(\d{1,2})\s*[月월]\s*(\d{1,2})\s*[日일]`)
+)
+
+// valueLocale holds how a locale writes numeric dates and numbers.
+type valueLocale struct {
+	language   string
+	region     string
+	monthFirst bool
+	decimal    rune
+	groups     string
+	numbers    *regexp.Regexp
+}
+
+// pointDecimalLanguages write decimals with a point and group digits with commas. Codes
+// are ISO 639-1 and, as ExtractionResult.DetectedLanguages holds, ISO 639-3.
+var pointDecimalLanguages = map[string]bool{
+	"en": true, "ja": true, "zh": true, "ko": true, "he": true, "th": true, "hi": true,
+	"ms": true, "ga": true, "mt": true,
+	"eng": true, "jpn": true, "zho": true, "cmn": true, "kor": true, "heb": true, "tha": true,
+	"hin": true, "msa": true, "gle": true, "mlt": true,
+}
+
+// newValueLocale returns the conventions of a BCP 47 tag such as "en-US" or "de-CH".
+// Month-first numeric dates are read for US English and English without a region;
+// languages are assumed to write decimals with a comma unless known otherwise.
+func newValueLocale(tag string) valueLocale {
+	parts := strings.FieldsFunc(strings.ToLower(tag), func(r rune) bool { return r == '-' || r == '_' })
+	language, region := "en", ""
+	if len(parts) > 0 {
+		language = parts[0]
+	}
+	for _, part := range parts[1:] {
+		if len(part) == 2 {
+			region = part
+		}
+	}
+	// Digits are grouped with no-break and narrow no-break spaces in most locales too;
+	// ordinary spaces separate numbers too often to be read as grouping.
+	locale := valueLocale{language: language, region: region, decimal: ',', groups: ".\u00a0\u202f"}
+	switch {
+	case region == "ch" || region == "li":
+		locale.decimal, locale.groups = '.', "'’\u00a0\u202f"
+	case pointDecimalLanguages[language]:
+		locale.decimal, locale.groups = '.', ",\u00a0\u202f"
+	}
+	if (language == "en" || language == "eng") && (region == "" || region == "us") {
+		locale.monthFirst = true
+	}
+	groups := regexp.QuoteMeta(locale.groups)
+	locale.numbers = regexp.MustCompile(`[-+−]?(?:\d{1,3}(?:[` + groups + `]\d{3})+|\d+)(?:` + regexp.QuoteMeta(string(locale.decimal)) + `\d+)?`)
+	return locale
+}
+
+// NormalizeValues returns the dates and numbers found in text, in canonical form, reading
+// numeric dates and numbers in cfg.Locale, or US English when it is unset. Dates are
+// found when cfg.Dates is set and numbers when cfg.Numbers is. It is the scan run on
+// Content when ExtractionConfig.Normalization enables them.
+func NormalizeValues(text string, cfg *NormalizationConfig) []NormalizedValue {
+	if cfg == nil {
+		return nil
+	}
+	tag := cfg.Locale
+	if tag == "" {
+		tag = defaultValueLocale
+	}
+	locale := newValueLocale(tag)
+	return scanValues(text, locale, enabled(cfg.Dates), enabled(cfg.Numbers))
+}
+
+func enabled(flag *bool) bool {
+	return flag != nil && *flag
+}
+
+// applyValueNormalization records the dates and numbers of Content and the dates of date
+// metadata fields in result.NormalizedValues.
+func applyValueNormalization(result *ExtractionResult, cfg *NormalizationConfig) {
+	dates, numbers := enabled(cfg.Dates), enabled(cfg.Numbers)
+	if !dates && !numbers {
+		return
+	}
+	locale := resultValueLocale(result, cfg.Locale)
+	values := scanValues(result.Content, locale, dates, numbers)
+	if dates {
+		values = append(values, metadataDates(&result.Metadata, locale)...)
+	}
+	result.NormalizedValues = values
+}
+
+// resultValueLocale returns the conventions of tag or, when it is empty, of the detected
+// language of result, falling back to defaultValueLocale.
+func resultValueLocale(result *ExtractionResult, tag string) valueLocale {
+	if tag == "" && len(result.DetectedLanguages) > 0 {
+		tag = result.DetectedLanguages[0]
+	}
+	if tag == "" && result.Metadata.Language != nil {
+		tag = *result.Metadata.Language
+	}
+	if !valueLocalePattern.MatchString(tag) {
+		tag = defaultValueLocale
+	}
+	return newValueLocale(tag)
+}
+
+// metadataDates normalizes the date fields of metadata.
+func metadataDates(m *Metadata, locale valueLocale) []NormalizedValue {
+	type field struct {
+		name  string
+		value *string
+	}
+	fields := []field{{"date", m.Date}}
+	if pdf, ok := m.PdfMetadata(); ok {
+		fields = append(fields, field{"pdf.created_at", pdf.CreatedAt}, field{"pdf.modified_at", pdf.ModifiedAt})
+	}
+	for _, key := range []string{"created_at", "modified_at"} {
+		var text string
+		if raw, ok := m.AdditionalFields()[key]; ok && json.Unmarshal(raw, &text) == nil {
+			fields = append(fields, field{key, &text})
+		}
+	}
+
+	var values []NormalizedValue
+	for _, field := range fields {
+		if field.value == nil || *field.value == "" {
+			continue
+		}
+		text := *field.value
+		if value, ok := parseTimestamp(strings.TrimSpace(text)); ok {
+			values = append(values, NormalizedValue{Kind: ValueKindDate, Text: text, Value: value, Field: field.name, ByteEnd: uint64(len(text))})
+			continue
+		}
+		if found := scanValues(text, locale, true, false); len(found) > 0 {
+			found[0].Field = field.name
+			values = append(values, found[0])
+		}
+	}
+	return values
+}
+
+// parseTimestamp reads a complete timestamp: RFC 3339, "2006-01-02 15:04:05" or a PDF
+// date such as "D:20240315120000+01'00'".
+func parseTimestamp(text string) (string, bool) {
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t.Format(time.RFC3339), true
+	}
+	if t, err := time.Parse(time.DateTime, text); err == nil {
+		return t.Format("2006-01-02T15:04:05"), true
+	}
+	digits, ok := strings.CutPrefix(text, "D:")
+	if !ok || len(digits) < 4 {
+		return "", false
+	}
+	// Fill the parts a PDF date may omit, then read the zone, "Z" or ±HH'mm'.
+	const full = "00000101000000"
+	end := 0
+	for end < len(digits) && end < len(full) && digits[end] >= '0' && digits[end] <= '9' {
+		end++
+	}
+	stamp := digits[:end] + full[end:]
+	zone := strings.TrimSuffix(strings.ReplaceAll(digits[end:], "'", ":"), ":")
+	layout := "20060102150405"
+	switch {
+	case zone == "":
+		t, err := time.Parse(layout, stamp)
+		if err != nil {
+			return "", false
+		}
+		return t.Format("2006-01-02T15:04:05"), true
+	case zone == "Z" || zone == "Z00:00":
+		zone = "Z"
+	case len(zone) == 3:
+		zone += ":00"
+	}
+	t, err := time.Parse(layout+"Z07:00", stamp+zone)
+	if err != nil {
+		return "", false
+	}
+	return t.Format(time.RFC3339), true
+}
+
+// scanValues finds the dates and numbers of text. Numbers within dates are not reported.
+func scanValues(text string, locale valueLocale, dates, numbers bool) []NormalizedValue {
+	var values []NormalizedValue
+	if dates {
+		values = scanDates(text, locale)
+	}
+	if numbers {
+		dateCount, next := len(values), 0
+		for _, span := range locale.numbers.FindAllStringIndex(text, -1) {
+			start, end := span[0], span[1]
+			if r, _ := utf8.DecodeRuneInString(text[start:]); r == '-' || r == '+' || r == '−' {
+				if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+					start += utf8.RuneLen(r)
+				}
+			}
+			for next < dateCount && int(values[next].ByteEnd) <= start {
+				next++
+			}
+			if next < dateCount && int(values[next].ByteStart) < end {
+				continue
+			}
+			if !numberBounded(text, start, end, locale) {
+				continue
+			}
+			values = append(values, NormalizedValue{
+				Kind:      ValueKindNumber,
+				Text:      text[start:end],
+				Value:     canonicalNumber(text[start:end], locale),
+				ByteStart: uint64(start),
+				ByteEnd:   uint64(end),
+			})
+		}
+		sort.SliceStable(values, func(i, j int) bool { return values[i].ByteStart < values[j].ByteStart })
+	}
+	return values
+}
+
+// scanDates finds the dates of text, keeping the longest of overlapping matches.
+func scanDates(text string, locale valueLocale) []NormalizedValue {
+	type candidate struct {
+		start, end int
+		value      string
+	}
+	var candidates []candidate
+	add := func(match []int, year, month, day int) {
+		start, end := match[0], match[1]
+		if value, ok := isoDate(year, month, day); ok && bounded(text, start, end) {
+			candidates = append(candidates, candidate{start, end, value})
+		}
+	}
+	group := func(match []int, i int) string { return text[match[2*i]:match[2*i+1]] }
+	number := func(match []int, i int) int {
+		n, _ := strconv.Atoi(group(match, i))
+		return n
+	}
+
+	for _, m := range textDayFirstDate.FindAllStringSubmatchIndex(text, -1) {
+		add(m, number(m, 3), monthNames[strings.ToLower(group(m, 2))], number(m, 1))
+	}
+	for _, m := range textMonthFirstDate.FindAllStringSubmatchIndex(text, -1) {
+		add(m, number(m, 3), monthNames[strings.ToLower(group(m, 1))], number(m, 2))
+	}
+	for _, m := range yearFirstDate.FindAllStringSubmatchIndex(text, -1) {
+		if group(m, 2) == group(m, 4) {
+			add(m, number(m, 1), number(m, 3), number(m, 5))
+		}
+	}
+	for _, m := range numericDate.FindAllStringSubmatchIndex(text, -1) {
+		if group(m, 2) != group(m, 4) {
+			continue
+		}
+		first, second, year := number(m, 1), number(m, 3), number(m, 5)
+		if len(group(m, 5)) == 2 {
+			// Two-digit years are only read in fully padded dates such as "05.03.24".
+			if len(group(m, 1)) != 2 || len(group(m, 3)) != 2 {
+				continue
+			}
+			year += 1900
+			if year < 1970 {
+				year += 100
+			}
+		}
+		day, month := first, second
+		if first <= 12 && (second > 12 || locale.monthFirst) {
+			day, month = second, first
+		}
+		add(m, year, month, day)
+	}
+	for _, m := range cjkDate.FindAllStringSubmatchIndex(text, -1) {
+		add(m, number(m, 1), number(m, 2), number(m, 3))
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].start != candidates[j].start {
+			return candidates[i].start < candidates[j].start
+		}
+		return candidates[i].end > candidates[j].end
+	})
+	var values []NormalizedValue
+	end := 0
+	for _, c := range candidates {
+		if c.start < end {
+			continue
+		}
+		values = append(values, NormalizedValue{
+			Kind:      ValueKindDate,
+			Text:      text[c.start:c.end],
+			Value:     c.value,
+			ByteStart: uint64(c.start),
+			ByteEnd:   uint64(c.end),
+		})
+		end = c.end
+	}
+	return values
+}
+
+// isoDate formats a calendar date, reporting false when it does not exist.
+func isoDate(year, month, day int) (string, bool) {
+	if month < 1 || month > 12 || day < 1 {
+		return "", false
+	}
+	t := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if t.Day() != day {
+		return "", false
+	}
+	return t.Format(time.DateOnly), true
+}
+
+// bounded reports whether text[start:end] is not part of a longer word or number.
+func bounded(text string, start, end int) bool {
+	if before, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(before) {
+		return false
+	}
+	if after, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(after) {
+		return false
+	}
+	return true
+}
+
+// numberBounded reports whether text[start:end] is a whole number: not part of a word, and
+// not next to a separator that continues it in a way the locale does not read, as in
+// "1.5" for a locale writing decimals with a comma.
+func numberBounded(text string, start, end int, locale valueLocale) bool {
+	return bounded(text, start, end) && !numberContinues(text, start, end, locale)
+}
+
+// numberContinues reports whether text[start:end] is followed or preceded by a separator
+// and a digit.
+func numberContinues(text string, start, end int, locale valueLocale) bool {
+	separator := func(r rune) bool {
+		return r == locale.decimal || strings.ContainsRune(locale.groups, r) || r == '.' || r == ','
+	}
+	if before, size := utf8.DecodeLastRuneInString(text[:start]); start > 0 && separator(before) {
+		if digit, _ := utf8.DecodeLastRuneInString(text[:start-size]); unicode.IsDigit(digit) {
+			return true
+		}
+	}
+	if after, size := utf8.DecodeRuneInString(text[end:]); end < len(text) && separator(after) {
+		if digit, _ := utf8.DecodeRuneInString(text[end+size:]); unicode.IsDigit(digit) {
+			return true
+		}
+	}
+	return false
+}
+
+// canonicalNumber rewrites a number written in locale as a plain decimal.
+func canonicalNumber(text string, locale valueLocale) string {
+	var sign, integer, fraction strings.Builder
+	inFraction := false
+	for _, r := range text {
+		switch {
+		case r == '-' || r == '−':
+			sign.WriteByte('-')
+		case r == locale.decimal:
+			inFraction = true
+		case r >= '0' && r <= '9' && inFraction:
+			fraction.WriteRune(r)
+		case r >= '0' && r <= '9':
+			integer.WriteRune(r)
+		}
+	}
+	whole := strings.TrimLeft(integer.String(), "0")
+	if whole == "" {
+		whole = "0"
+	}
+	if decimals := strings.TrimRight(fraction.String(), "0"); decimals != "" {
+		whole += "." + decimals
+	}
+	if whole == "0" {
+		return whole
+	}
+	return sign.String() + whole
+}
+
+func validateValueLocale(tag string) error {
+	if tag == "" || valueLocalePattern.MatchString(tag) {
+		return nil
+	}
+	return newValidationErrorWithContext(fmt.Sprintf("invalid locale %q, expected a tag such as \"de-DE\"", tag), nil, ErrorCodeValidation, nil)
+}
@@ -0,0 +1,52 @@
+package kreuzberg
+
+import "testing"
+
+func TestNormalizeValues(t *testing.T) {
+	on := true
+	cfg := &NormalizationConfig{Dates: &on, Numbers: &on, Locale: "de-DE"}
+	values := NormalizeValues("Am 15. März 2024 kostete es 1.234,56 € statt 1.300, Rechnung vom 03.04.2024.", cfg)
+	want := []struct{ kind, text, value string }{
+		{ValueKindDate, "15. März 2024", "2024-03-15"},
+		{ValueKindNumber, "1.234,56", "1234.56"},
+		{ValueKindNumber, "1.300", "1300"},
+		{ValueKindDate, "03.04.2024", "2024-04-03"},
+	}
+	if len(values) != len(want) {
+		t.Fatalf("NormalizeValues = %+v", values)
+	}
+	for i, w := range want {
+		if values[i].Kind != w.kind || values[i].Text != w.text || values[i].Value != w.value {
+			t.Fatalf("value %d = %+v, want %v", i, values[i], w)
+		}
+	}
+
+	cfg.Locale = "en-US"
+	values = NormalizeValues("Due 03/04/2024: -1,250.50", cfg)
+	if len(values) != 2 || values[0].Value != "2024-03-04" || values[1].Value != "-1250.5" {
+		t.Fatalf("en-US values = %+v", values)
+	}
+	if text := "Due 03/04/2024"; values[0].Text != text[values[0].ByteStart:values[0].ByteEnd] {
+		t.Fatalf("offsets %d..%d do not delimit %q", values[0].ByteStart, values[0].ByteEnd, values[0].Text)
+	}
+
+	if err := validateNormalizationConfig(&NormalizationConfig{Locale: "de DE"}); err == nil {
+		t.Fatal("expected an invalid locale to be rejected")
+	}
+}
+
+func TestValueNormalizationStage(t *testing.T) {
+	config := NewExtractionConfig(WithNormalization(WithDateNormalization(true), WithNumberNormalization(true)))
+	result := &ExtractionResult{Content: "Total 2,5 kg", DetectedLanguages: []string{"deu"}}
+	result.Metadata.Format = FormatMetadata{Type: FormatPDF, Pdf: &PdfMetadata{CreatedAt: StringPtr("D:20240315120000+01'00'")}}
+	if err := runResultStages(result, config); err != nil {
+		t.Fatal(err)
+	}
+	values := result.NormalizedValues
+	if len(values) != 2 || values[0].Value != "2.5" {
+		t.Fatalf("NormalizedValues = %+v", values)
+	}
+	if values[1].Field != "pdf.created_at" || values[1].Value != "2024-03-15T12:00:00+01:00" {
+		t.Fatalf("PDF date = %+v", values[1])
+	}
+}
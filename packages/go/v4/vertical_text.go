@@ -0,0 +1,317 @@
+package kreuzberg
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Writing modes reported in PageInfo.WritingMode, named after those of CSS.
+const (
+	// WritingModeHorizontal is text in lines read top to bottom, as in most documents.
+	WritingModeHorizontal = "horizontal-tb"
+	// WritingModeVertical is text in columns read top to bottom, the columns right to
+	// left, as in Japanese and traditional Chinese typesetting.
+	WritingModeVertical = "vertical-rl"
+	// WritingModeMixed is a page with both, such as vertical body text under horizontal
+	// headings or captions.
+	WritingModeMixed = "mixed"
+)
+
+const (
+	// verticalAspect is how many times taller than wide a column of text must be.
+	verticalAspect = 1.5
+	// verticalPageShare is the share of a page's CJK characters set in columns above which
+	// the page is vertical rather than mixed.
+	verticalPageShare = 0.9
+)
+
+// textColumn is a vertical line of a page's text layer, in pixels from the top-left
+// corner of the page.
+type textColumn struct {
+	words                    []PageWord
+	left, top, right, bottom float64
+}
+
+func (c *textColumn) add(word PageWord) {
+	if len(c.words) == 0 {
+		c.left, c.top, c.right, c.bottom = word.Left, word.Top, word.Left+word.Width, word.Top+word.Height
+	} else {
+		c.left, c.top = min(c.left, word.Left), min(c.top, word.Top)
+		c.right, c.bottom = max(c.right, word.Left+word.Width), max(c.bottom, word.Top+word.Height)
+	}
+	c.words = append(c.words, word)
+}
+
+func (c *textColumn) text() string {
+	sort.SliceStable(c.words, func(i, j int) bool { return c.words[i].Top < c.words[j].Top })
+	var b strings.Builder
+	for _, word := range c.words {
+		b.WriteString(word.Text)
+	}
+	return b.String()
+}
+
+// textBlock is a vertical region or a horizontal line of a page, with its text in
+// reading order.
+type textBlock struct {
+	top, right float64
+	text       string
+}
+
+// isCJK reports whether r is a Chinese, Japanese or Korean character or punctuation mark.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		r >= 0x3000 && r <= 0x303F || r >= 0xFF00 && r <= 0xFFEF
+}
+
+func countCJK(text string) int {
+	n := 0
+	for _, r := range text {
+		if isCJK(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// applyVerticalTextStage finds the vertically typeset text of PDF pages when
+// ExtractionConfig.VerticalText is set. Pages with columns of CJK text get their
+// PageInfo.WritingMode and VerticalRegions, and their text in Content and Pages is
+// rebuilt from the text layer in reading order: columns top to bottom and right to left,
+// regions and horizontal lines top to bottom. Chunks are rebuilt from the new Content.
+// layer returns the text layer of the original's pages. It never fails the extraction.
+func applyVerticalTextStage(result *ExtractionResult, layer func() ([]PageImage, error), config *ExtractionConfig) {
+	if result == nil || config == nil || config.VerticalText == nil || !*config.VerticalText || result.MimeType != "application/pdf" {
+		return
+	}
+	pages, err := layer()
+	if err != nil {
+		return
+	}
+
+	texts := map[uint64]string{}
+	for _, page := range pages {
+		number := uint64(page.PageNumber)
+		mode, regions, text := verticalLayout(page)
+		if mode == "" {
+			continue
+		}
+		info := addPageInfo(result, number)
+		info.WritingMode = mode
+		info.VerticalRegions = regions
+		if mode != WritingModeHorizontal {
+			texts[number] = text
+		}
+	}
+	if len(texts) == 0 {
+		return
+	}
+	replacePageTexts(result, texts)
+	if len(result.Chunks) > 0 && config.Chunking != nil && !usesStructureChunking(config) {
+		if rechunked, err := RechunkResult(result, config.Chunking); err == nil {
+			result.Chunks = rechunked.Chunks
+		}
+	}
+}
+
+// verticalLayout returns the writing mode of a page read at textLayerDPI, its
+// vertical regions in points from the bottom-left corner, and its text in reading order.
+// The mode is empty for pages without CJK text.
+func verticalLayout(page PageImage) (string, []BoundingBox, string) {
+	columns, rest := verticalColumns(page.Words)
+	total, vertical := 0, 0
+	for _, word := range page.Words {
+		total += countCJK(word.Text)
+	}
+	if total == 0 {
+		return "", nil, ""
+	}
+	if len(columns) == 0 {
+		return WritingModeHorizontal, nil, ""
+	}
+	for i := range columns {
+		vertical += countCJK(columns[i].text())
+	}
+	mode := WritingModeMixed
+	if float64(vertical) >= float64(total)*verticalPageShare {
+		mode = WritingModeVertical
+	}
+
+	var blocks []textBlock
+	var regions []BoundingBox
+	for _, region := range verticalRegions(columns) {
+		var lines []string
+		left, top, right, bottom := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+		for i := range region {
+			lines = append(lines, region[i].text())
+			left, top = min(left, region[i].left), min(top, region[i].top)
+			right, bottom = max(right, region[i].right), max(bottom, region[i].bottom)
+		}
+		blocks = append(blocks, textBlock{top: top, right: right, text: strings.Join(lines, "\n")})
+		height := float64(page.Height)
+		regions = append(regions, BoundingBox{X0: left, Y0: height - bottom, X1: right, Y1: height - top})
+	}
+	blocks = append(blocks, horizontalLines(rest)...)
+	sort.SliceStable(blocks, func(i, j int) bool {
+		if blocks[i].top != blocks[j].top {
+			return blocks[i].top < blocks[j].top
+		}
+		return blocks[i].right > blocks[j].right
+	})
+	texts := make([]string, len(blocks))
+	for i, block := range blocks {
+		texts[i] = block.text
+	}
+	return mode, regions, strings.Join(texts, "\n")
+}
+
+// verticalColumns gathers the CJK words of a text layer that are stacked one under the
+// other, single characters or words taller than wide, into columns. Words in no column
+// at least verticalAspect times taller than wide are returned as the rest.
+func verticalColumns(words []PageWord) ([]textColumn, []PageWord) {
+	var candidates, rest []PageWord
+	for _, word := range words {
+		n := utf8.RuneCountInString(strings.TrimSpace(word.Text))
+		if countCJK(word.Text) > 0 && (n == 1 || word.Height >= word.Width*verticalAspect) {
+			candidates = append(candidates, word)
+		} else {
+			rest = append(rest, word)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Top < candidates[j].Top })
+
+	var columns []textColumn
+	for _, word := range candidates {
+		center, pitch := word.Left+word.Width/2, max(word.Width, 1)
+		found := false
+		for i := range columns {
+			column := &columns[i]
+			width := column.right - column.left
+			if math.Abs(center-(column.left+column.right)/2) <= max(width, pitch)/2 && word.Top-column.bottom <= max(width, pitch) {
+				column.add(word)
+				found = true
+				break
+			}
+		}
+		if !found {
+			var column textColumn
+			column.add(word)
+			columns = append(columns, column)
+		}
+	}
+
+	var kept []textColumn
+	for _, column := range columns {
+		if utf8.RuneCountInString(column.text()) >= 2 && column.bottom-column.top >= (column.right-column.left)*verticalAspect {
+			kept = append(kept, column)
+		} else {
+			rest = append(rest, column.words...)
+		}
+	}
+	return kept, rest
+}
+
+// verticalRegions groups columns side by side, overlapping vertically and at most two
+// column widths apart, into regions, each with its columns right to left.
+func verticalRegions(columns []textColumn) [][]textColumn {
+	sort.SliceStable(columns, func(i, j int) bool { return columns[i].right > columns[j].right })
+	var regions [][]textColumn
+	for _, column := range columns {
+		width := column.right - column.left
+		joined := false
+		for i, region := range regions {
+			last := region[len(region)-1]
+			overlap := min(last.bottom, column.bottom) - max(last.top, column.top)
+			shorter := min(last.bottom-last.top, column.bottom-column.top)
+			if overlap >= shorter/2 && last.left-column.right <= 2*width && last.left >= column.left {
+				regions[i] = append(region, column)
+				joined = true
+				break
+			}
+		}
+		if !joined {
+			regions = append(regions, []textColumn{column})
+		}
+	}
+	return regions
+}
+
+// horizontalLines joins words into lines of words sharing their vertical position, each
+// read left to right.
+func horizontalLines(words []PageWord) []textBlock {
+	lines := wordLines(words)
+	blocks := make([]textBlock, 0, len(lines))
+	for _, line := range lines {
+		texts := make([]string, len(line))
+		right := 0.0
+		for i, word := range line {
+			texts[i] = word.Text
+			right = max(right, word.Left+word.Width)
+		}
+		blocks = append(blocks, textBlock{top: line[0].Top, right: right, text: strings.Join(texts, " ")})
+	}
+	return blocks
+}
+
+// wordLines groups words sharing their vertical position into lines, top to bottom, each
+// sorted left to right. It sorts words by their top.
+func wordLines(words []PageWord) [][]PageWord {
+	sort.SliceStable(words, func(i, j int) bool { return words[i].Top < words[j].Top })
+	var lines [][]PageWord
+	for _, word := range words {
+		if n := len(lines); n > 0 {
+			first := lines[n-1][0]
+			if math.Abs(word.Top-first.Top) <= max(first.Height, word.Height)/2 {
+				lines[n-1] = append(lines[n-1], word)
+				continue
+			}
+		}
+		lines = append(lines, []PageWord{word})
+	}
+	for _, line := range lines {
+		slices.SortStableFunc(line, func(a, b PageWord) int { return cmp.Compare(a.Left, b.Left) })
+	}
+	return lines
+}
+
+// replacePageTexts replaces the text of the pages numbered in texts in Pages and, within
+// their boundaries, in Content, keeping the whitespace separating pages and remapping
+// offsets. A document of one page without boundaries has its whole Content replaced.
+func replacePageTexts(result *ExtractionResult, texts map[uint64]string) {
+	for i := range result.Pages {
+		if text, ok := texts[result.Pages[i].PageNumber]; ok {
+			result.Pages[i].Content = text + result.Pages[i].Content[len(strings.TrimRightFunc(result.Pages[i].Content, unicode.IsSpace)):]
+		}
+	}
+
+	type span struct{ start, end int }
+	var spans []span
+	var replacements []string
+	if structure := result.Metadata.PageStructure; structure != nil && len(structure.Boundaries) > 0 {
+		for _, boundary := range structure.Boundaries {
+			text, ok := texts[boundary.PageNumber]
+			start, end := int(boundary.ByteStart), int(boundary.ByteEnd)
+			if !ok || start > end || end > len(result.Content) {
+				continue
+			}
+			end = start + len(strings.TrimRightFunc(result.Content[start:end], unicode.IsSpace))
+			spans = append(spans, span{start, end})
+			replacements = append(replacements, text)
+		}
+	} else if text, ok := texts[1]; ok && len(texts) == 1 {
+		spans = append(spans, span{0, len(strings.TrimRightFunc(result.Content, unicode.IsSpace))})
+		replacements = append(replacements, text)
+	}
+	// Later pages first, so that the offsets of earlier ones stay valid.
+	for i := len(spans) - 1; i >= 0; i-- {
+		s := spans[i]
+		m := spliceOffsetMap(len(result.Content), s.start, s.end, len(replacements[i]))
+		result.Content = result.Content[:s.start] + replacements[i] + result.Content[s.end:]
+		remapResultOffsets(result, m)
+	}
+}
@@ -0,0 +1,62 @@
+package kreuzberg
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVerticalLayout(t *testing.T) {
+	column := func(left float64, text string) []PageWord {
+		var words []PageWord
+		for i, r := range []rune(text) {
+			words = append(words, PageWord{Text: string(r), Left: left, Top: 100 + float64(i)*20, Width: 20, Height: 20})
+		}
+		return words
+	}
+	words := []PageWord{{Text: "第一章", Left: 100, Top: 50, Width: 60, Height: 20}}
+	words = append(words, column(370, "猫です")...)
+	words = append(words, column(400, "吾輩は")...)
+
+	mode, regions, text := verticalLayout(PageImage{PageNumber: 1, Width: 600, Height: 800, Words: words})
+	if mode != WritingModeMixed || text != "第一章\n吾輩は\n猫です" {
+		t.Fatalf("verticalLayout = %q, %q", mode, text)
+	}
+	if want := []BoundingBox{{X0: 370, Y0: 640, X1: 420, Y1: 700}}; !reflect.DeepEqual(regions, want) {
+		t.Fatalf("regions = %+v", regions)
+	}
+
+	if mode, _, _ := verticalLayout(PageImage{Words: column(400, "吾輩は")}); mode != WritingModeVertical {
+		t.Fatalf("columns only: mode = %q", mode)
+	}
+	if mode, _, _ := verticalLayout(PageImage{Words: []PageWord{{Text: "吾輩は猫である", Width: 140, Height: 20}}}); mode != WritingModeHorizontal {
+		t.Fatalf("horizontal line: mode = %q", mode)
+	}
+	if mode, _, _ := verticalLayout(PageImage{Words: []PageWord{{Text: "Hello", Width: 50, Height: 12}}}); mode != "" {
+		t.Fatalf("no CJK: mode = %q", mode)
+	}
+}
+
+func TestReplacePageTexts(t *testing.T) {
+	result := &ExtractionResult{
+		Content: "one\n\n猫吾\n輩で\n\nthree",
+		Pages:   []PageContent{{PageNumber: 1, Content: "one"}, {PageNumber: 2, Content: "猫吾\n輩で\n"}, {PageNumber: 3, Content: "three"}},
+		Metadata: Metadata{PageStructure: &PageStructure{Boundaries: []PageBoundary{
+			{ByteStart: 0, ByteEnd: 5, PageNumber: 1},
+			{ByteStart: 5, ByteEnd: 20, PageNumber: 2},
+			{ByteStart: 20, ByteEnd: 25, PageNumber: 3},
+		}}},
+	}
+	replacePageTexts(result, map[uint64]string{2: "吾輩\n猫です"})
+	if result.Content != "one\n\n吾輩\n猫です\n\nthree" || result.Pages[1].Content != "吾輩\n猫です\n" {
+		t.Fatalf("Content = %q, page 2 = %q", result.Content, result.Pages[1].Content)
+	}
+	if got := result.Metadata.PageStructure.Boundaries[2]; got.ByteStart != 23 || got.ByteEnd != 28 {
+		t.Fatalf("page 3 boundary = %+v", got)
+	}
+
+	result.Metadata.PageStructure.Pages = []PageInfo{{Number: 2, VerticalRegions: []BoundingBox{{X0: 72, X1: 144}}}}
+	applyCoordinateUnit(result, CoordinateUnitInches)
+	if got := result.Metadata.PageStructure.Pages[0].VerticalRegions[0]; got.X0 != 1 || got.X1 != 2 {
+		t.Fatalf("inches: region %+v", got)
+	}
+}
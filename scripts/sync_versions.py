@@ -553,6 +553,11 @@ def main():
             r'\d+\.\d+\.\d+(?:-[a-zA-Z0-9.]+)?',
             version,
         ),
+        (
+            repo_root / "packages/go/v4/compat.go",
+            r'(const BindingVersion = ")[^"]+(")',
+            rf"\g<1>{version}\g<2>",
+        ),
         (
             repo_root / "e2e/java/pom.xml",
             r'(<artifactId>kreuzberg</artifactId>\s*<version>)([^<]+)(</version>)',